@@ -13,9 +13,10 @@ import (
 )
 
 var (
-	unimplementableFsMethods = []string{"ListR", "ListP", "MkdirMetadata", "DirSetModTime"}
+	unimplementableFsMethods = []string{"ListR", "ListP", "MkdirMetadata", "DirSetModTime", "SetTierBatch", "ChangedPaths", "DeleteObjects", "ListDeleted", "Undelete", "SoftDelete", "PurgeDeleted", "ResumeChunkWriter", "ResumeWriterAt"}
 	// In these tests we receive objects from the underlying remote which don't implement these methods
-	unimplementableObjectMethods = []string{"GetTier", "ID", "Metadata", "MimeType", "SetTier", "UnWrap", "SetMetadata"}
+	unimplementableObjectMethods    = []string{"GetTier", "ID", "Metadata", "MimeType", "SetTier", "UnWrap", "SetMetadata", "Tags", "SetTags"}
+	unimplementableDirectoryMethods = []string{"ChangeToken"}
 )
 
 // TestIntegration runs integration tests against the remote
@@ -24,9 +25,10 @@ func TestIntegration(t *testing.T) {
 		t.Skip("Skipping as -remote not set")
 	}
 	fstests.Run(t, &fstests.Opt{
-		RemoteName:                   *fstest.RemoteName,
-		UnimplementableFsMethods:     unimplementableFsMethods,
-		UnimplementableObjectMethods: unimplementableObjectMethods,
+		RemoteName:                      *fstest.RemoteName,
+		UnimplementableFsMethods:        unimplementableFsMethods,
+		UnimplementableObjectMethods:    unimplementableObjectMethods,
+		UnimplementableDirectoryMethods: unimplementableDirectoryMethods,
 	})
 }
 
@@ -42,9 +44,10 @@ func TestLocal(t *testing.T) {
 			{Name: name, Key: "type", Value: "archive"},
 			{Name: name, Key: "remote", Value: remote},
 		},
-		QuickTestOK:                  true,
-		UnimplementableFsMethods:     unimplementableFsMethods,
-		UnimplementableObjectMethods: unimplementableObjectMethods,
+		QuickTestOK:                     true,
+		UnimplementableFsMethods:        unimplementableFsMethods,
+		UnimplementableObjectMethods:    unimplementableObjectMethods,
+		UnimplementableDirectoryMethods: unimplementableDirectoryMethods,
 	})
 }
 
@@ -60,8 +63,9 @@ func TestMemory(t *testing.T) {
 			{Name: name, Key: "type", Value: "archive"},
 			{Name: name, Key: "remote", Value: remote},
 		},
-		QuickTestOK:                  true,
-		UnimplementableFsMethods:     unimplementableFsMethods,
-		UnimplementableObjectMethods: unimplementableObjectMethods,
+		QuickTestOK:                     true,
+		UnimplementableFsMethods:        unimplementableFsMethods,
+		UnimplementableObjectMethods:    unimplementableObjectMethods,
+		UnimplementableDirectoryMethods: unimplementableDirectoryMethods,
 	})
 }
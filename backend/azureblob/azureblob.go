@@ -41,6 +41,7 @@ import (
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/configstruct"
 	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/credential"
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/fshttp"
 	"github.com/rclone/rclone/fs/hash"
@@ -124,6 +125,26 @@ var systemMetadataInfo = map[string]fs.MetadataHelp{
 		Type:    "RFC 3339",
 		Example: "2006-01-02T15:04:05.999999999Z07:00",
 	},
+	"owner": {
+		Help:    "Owner of the file - hierarchical namespace only",
+		Type:    "string",
+		Example: "$superuser",
+	},
+	"group": {
+		Help:    "Owning group of the file - hierarchical namespace only",
+		Type:    "string",
+		Example: "$superuser",
+	},
+	"permissions": {
+		Help:    "POSIX permissions of the file - hierarchical namespace only",
+		Type:    "string",
+		Example: "rw-r-----",
+	},
+	"acl": {
+		Help:    "POSIX access control list of the file - hierarchical namespace only",
+		Type:    "string",
+		Example: "user::rw-,group::r--,other::---",
+	},
 }
 
 // Register with Fs
@@ -509,6 +530,44 @@ creates an empty object ending with "/", to persist the folder.
 This object also has the metadata "` + dirMetaKey + ` = ` + dirMetaValue + `" to conform to
 the Microsoft standard.
  `,
+		}, {
+			Name:     "hierarchical_namespace",
+			Default:  false,
+			Advanced: true,
+			Help: `Use Data Lake Storage Gen2 directory semantics.
+
+Set this if the storage account has a hierarchical namespace enabled
+(Azure Data Lake Storage Gen2).
+
+Rclone will then use the DFS endpoint for operations on directories
+rather than emulating directories with blob name prefixes. This means
+
+- directories are real and may be empty
+- directories are created and removed with a single call
+- directories and files are renamed atomically on the server
+- purging a directory is done with a single recursive delete
+
+The owner, group, permissions and ACL of files can be read and written
+as metadata.
+
+This can't be used with "directory_markers".
+`,
+		}, {
+			Name:     "dfs_endpoint",
+			Advanced: true,
+			Help: `Endpoint for the DFS service.
+
+This is only used with "hierarchical_namespace".
+
+Leave blank normally. Rclone works it out from the blob endpoint by
+replacing ".blob." with ".dfs.", so
+
+    https://ACCOUNT.blob.core.windows.net/
+
+becomes
+
+    https://ACCOUNT.dfs.core.windows.net/
+`,
 		}, {
 			Name: "no_check_container",
 			Help: `If set, don't attempt to check the container exists or create it.
@@ -580,6 +639,8 @@ type Options struct {
 	Enc                        encoder.MultiEncoder `config:"encoding"`
 	PublicAccess               string               `config:"public_access"`
 	DirectoryMarkers           bool                 `config:"directory_markers"`
+	HierarchicalNamespace      bool                 `config:"hierarchical_namespace"`
+	DFSEndpoint                string               `config:"dfs_endpoint"`
 	NoCheckContainer           bool                 `config:"no_check_container"`
 	NoHeadObject               bool                 `config:"no_head_object"`
 	DeleteSnapshots            string               `config:"delete_snapshots"`
@@ -605,11 +666,16 @@ type Fs struct {
 	pacer         *fs.Pacer                    // To pace and retry the API calls
 	uploadToken   *pacer.TokenDispenser        // control concurrency
 	publicAccess  container.PublicAccessType   // Container Public Access Level
+	dfs           *dfsClient                   // client for the DFS endpoint if using hierarchical namespace
 
 	// user delegation cache
 	userDelegationMu     sync.Mutex
 	userDelegation       *service.UserDelegationCredential
 	userDelegationExpiry time.Time
+
+	// result of checking soft delete is enabled for SoftDelete
+	softDeleteOnce sync.Once
+	softDeleteErr  error
 }
 
 // Object describes an azure object
@@ -834,6 +900,10 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 			string(blob.AccessTierHot), string(blob.AccessTierCool), string(blob.AccessTierCold), string(blob.AccessTierArchive))
 	}
 
+	if opt.HierarchicalNamespace && opt.DirectoryMarkers {
+		return nil, errors.New("directory_markers can't be used with hierarchical_namespace")
+	}
+
 	if !validatePublicAccess((opt.PublicAccess)) {
 		return nil, fmt.Errorf("supported public access level are %s and %s",
 			string(container.PublicAccessTypeBlob), string(container.PublicAccessTypeContainer))
@@ -868,6 +938,15 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		f.features.CanHaveEmptyDirectories = true
 		fs.Debugf(f, "Using directory markers")
 	}
+	if opt.HierarchicalNamespace {
+		f.features.CanHaveEmptyDirectories = true
+		// Blob batches aren't supported with a hierarchical namespace
+		f.features.DeleteObjects = nil
+	} else {
+		// Moves need the DFS endpoint
+		f.features.Move = nil
+		f.features.DirMove = nil
+	}
 
 	// Client options specifying our own transport
 	policyClientOptions := policy.ClientOptions{
@@ -938,6 +1017,16 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 			_ = f.cntSVC(containerName)
 			f.isLimited = true
 		}
+		// SAS URLs can't be refreshed so warn before they expire
+		if expiry := parts.SAS.ExpiryTime(); !expiry.IsZero() {
+			credential.Register(ctx, credential.Source{
+				Remote: name,
+				Kind:   credential.KindSAS,
+				Expiry: func(ctx context.Context) (time.Time, error) {
+					return expiry, nil
+				},
+			})
+		}
 	case opt.ClientID != "" && opt.Tenant != "" && opt.ClientSecret != "":
 		// Service principal with client secret
 		options := azidentity.ClientSecretCredentialOptions{
@@ -1120,6 +1209,14 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		return nil, fmt.Errorf("internal error: auth failed to make credentials or client")
 	}
 
+	if opt.HierarchicalNamespace {
+		f.dfs, err = f.newDFSClient(policyClientOptions)
+		if err != nil {
+			return nil, err
+		}
+		fs.Debugf(f, "Using hierarchical namespace with DFS endpoint %s", f.dfs.endpoint)
+	}
+
 	if f.rootContainer != "" && f.rootDirectory != "" {
 		// Check to see if the (container,directory) is actually an existing file
 		oldRoot := f.root
@@ -1434,6 +1531,18 @@ func (o *Object) applyMappedMetadata(ctx context.Context, src fs.ObjectInfo, ui
 		return modTime, nil
 	}
 
+	// The access control metadata is set with the DFS endpoint
+	// after the upload
+	if o.fs.dfs != nil {
+		ui.accessControl = make(map[string]string)
+		for key := range dfsACLHeaders {
+			if value, ok := meta[key]; ok {
+				ui.accessControl[key] = value
+				delete(meta, key)
+			}
+		}
+	}
+
 	// Map metadata using common helper
 	headers, userMeta, tags, mappedModTime, err := mapMetadataToAzure(meta, func(format string, args ...any) { fs.Debugf(o, format, args...) })
 	if err != nil {
@@ -1545,6 +1654,12 @@ func (f *Fs) list(ctx context.Context, containerName, directory, prefix string,
 		MaxResults: &maxResults,
 	})
 	foundItems := 0
+	// With a hierarchical namespace a directory is listed both as a
+	// blob and as a prefix so only send it once
+	var seenDirs map[string]struct{}
+	if f.dfs != nil && !recurse {
+		seenDirs = make(map[string]struct{})
+	}
 	for pager.More() {
 		var response container.ListBlobsHierarchyResponse
 		err := f.pacer.Call(func() (bool, error) {
@@ -1592,6 +1707,12 @@ func (f *Fs) list(ctx context.Context, containerName, directory, prefix string,
 			if addContainer {
 				remote = path.Join(containerName, remote)
 			}
+			if isDirectory && seenDirs != nil {
+				if _, found := seenDirs[remote]; found {
+					continue
+				}
+				seenDirs[remote] = struct{}{}
+			}
 			// Send object
 			err = fn(remote, file, isDirectory)
 			if err != nil {
@@ -1619,6 +1740,12 @@ func (f *Fs) list(ctx context.Context, containerName, directory, prefix string,
 			if addContainer {
 				remote = path.Join(containerName, remote)
 			}
+			if seenDirs != nil {
+				if _, found := seenDirs[remote]; found {
+					continue
+				}
+				seenDirs[remote] = struct{}{}
+			}
 			// Send object
 			err = fn(remote, nil, true)
 			if err != nil {
@@ -1626,7 +1753,16 @@ func (f *Fs) list(ctx context.Context, containerName, directory, prefix string,
 			}
 		}
 	}
-	if f.opt.DirectoryMarkers && foundItems == 0 && directory != "" {
+	if f.dfs != nil && foundItems == 0 && directory != "" {
+		// Determine whether the directory exists or not by asking the DFS endpoint
+		resourceType, err := f.dfsPathType(ctx, containerName, strings.TrimSuffix(directory, "/"))
+		if err != nil {
+			return err
+		}
+		if resourceType != dfsResourceDir {
+			return fs.ErrorDirNotFound
+		}
+	} else if f.opt.DirectoryMarkers && foundItems == 0 && directory != "" {
 		// Determine whether the directory exists or not by whether it has a marker
 		_, err := f.readMetaData(ctx, containerName, directory)
 		if err != nil {
@@ -1930,11 +2066,14 @@ func (f *Fs) createDirectoryMarker(ctx context.Context, container, dir string) e
 
 // Mkdir creates the container if it doesn't exist
 func (f *Fs) Mkdir(ctx context.Context, dir string) error {
-	container, _ := f.split(dir)
+	container, directory := f.split(dir)
 	e := f.makeContainer(ctx, container)
 	if e != nil {
 		return e
 	}
+	if f.dfs != nil {
+		return f.dfsMkdir(ctx, container, directory)
+	}
 	return f.createDirectoryMarker(ctx, container, dir)
 }
 
@@ -1945,6 +2084,12 @@ func (f *Fs) mkdirParent(ctx context.Context, remote string) error {
 	if dir == "/" || dir == "." {
 		dir = ""
 	}
+	if f.dfs != nil {
+		// Writing a blob creates its parent directories so
+		// only the container is needed
+		container, _ := f.split(dir)
+		return f.makeContainer(ctx, container)
+	}
 	return f.Mkdir(ctx, dir)
 }
 
@@ -2045,6 +2190,9 @@ func (f *Fs) deleteContainer(ctx context.Context, containerName string) error {
 // Returns an error if it isn't empty
 func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 	container, directory := f.split(dir)
+	if f.dfs != nil && container != "" && directory != "" {
+		return f.dfsDelete(ctx, container, directory, false)
+	}
 	// Remove directory marker file
 	if f.opt.DirectoryMarkers && container != "" && directory != "" {
 		o := &Object{
@@ -2084,6 +2232,9 @@ func (f *Fs) Purge(ctx context.Context, dir string) error {
 		return errors.New("can't purge from root")
 	}
 	if directory != "" {
+		if f.dfs != nil {
+			return f.dfsDelete(ctx, container, directory, true)
+		}
 		// Delegate to caller if not root of a container
 		return fs.ErrorCantPurge
 	}
@@ -2550,6 +2701,16 @@ func (o *Object) Metadata(ctx context.Context) (fs.Metadata, error) {
 	}
 	metadataMu.Unlock()
 
+	// Read the access control if using a hierarchical namespace
+	if o.fs.dfs != nil {
+		container, containerPath := o.split()
+		accessControl, err := o.fs.dfsGetAccessControl(ctx, container, containerPath)
+		if err != nil {
+			return nil, err
+		}
+		m.Merge(accessControl)
+	}
+
 	return m, nil
 }
 
@@ -2733,6 +2894,10 @@ func (f *Fs) readMetaData(ctx context.Context, container, containerPath string)
 		}
 		return nil, err
 	}
+	// With a hierarchical namespace directories are blobs too
+	if f.dfs != nil && resp.ContentLength != nil && isDirectoryMarker(*resp.ContentLength, resp.Metadata, containerPath) {
+		return nil, fs.ErrorNotAFile
+	}
 	return &resp, nil
 }
 
@@ -3306,7 +3471,7 @@ func (w *azChunkWriter) Close(ctx context.Context) (err error) {
 		return fmt.Errorf("failed to complete multipart upload: %w", err)
 	}
 	fs.Debugf(w.o, "multipart upload finished")
-	return err
+	return w.o.setAccessControl(ctx, w.ui)
 }
 
 var warnStreamUpload sync.Once
@@ -3366,9 +3531,10 @@ func (o *Object) uploadSinglepart(ctx context.Context, in io.Reader, size int64,
 
 // Info needed for an upload
 type uploadInfo struct {
-	blb         *blockblob.Client
-	httpHeaders blob.HTTPHeaders
-	isDirMarker bool
+	blb           *blockblob.Client
+	httpHeaders   blob.HTTPHeaders
+	isDirMarker   bool
+	accessControl map[string]string // owner, group, permissions and acl to set after upload
 }
 
 // Prepare the object for upload
@@ -3483,6 +3649,9 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 			return fmt.Errorf("failed to prepare upload: %w", err)
 		}
 		err = o.uploadSinglepart(ctx, in, size, ui)
+		if err == nil {
+			err = o.setAccessControl(ctx, ui)
+		}
 	}
 	if err != nil {
 		return err
@@ -3506,6 +3675,16 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	return o.SetTier(o.fs.opt.AccessTier)
 }
 
+// setAccessControl sets the owner, group, permissions and ACL from
+// the metadata after an upload if using a hierarchical namespace
+func (o *Object) setAccessControl(ctx context.Context, ui uploadInfo) error {
+	if o.fs.dfs == nil || len(ui.accessControl) == 0 {
+		return nil
+	}
+	container, containerPath := o.split()
+	return o.fs.dfsSetAccessControl(ctx, container, containerPath, ui.accessControl)
+}
+
 // Remove an object
 func (o *Object) Remove(ctx context.Context) error {
 	blb := o.getBlobSVC()
@@ -3520,6 +3699,42 @@ func (o *Object) Remove(ctx context.Context) error {
 	})
 }
 
+// checkSoftDelete returns an error unless soft delete for blobs is
+// enabled on the account so deleted blobs are kept
+func (f *Fs) checkSoftDelete(ctx context.Context) error {
+	f.softDeleteOnce.Do(func() {
+		if f.svc == nil {
+			f.softDeleteErr = errors.New("can't check soft delete is enabled without access to the account")
+			return
+		}
+		var props service.GetPropertiesResponse
+		err := f.pacer.Call(func() (bool, error) {
+			var err error
+			props, err = f.svc.GetProperties(ctx, nil)
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			f.softDeleteErr = fmt.Errorf("failed to check soft delete is enabled: %w", err)
+		} else if policy := props.DeleteRetentionPolicy; policy == nil || policy.Enabled == nil || !*policy.Enabled {
+			f.softDeleteErr = errors.New("soft delete for blobs isn't enabled on the account")
+		}
+	})
+	return f.softDeleteErr
+}
+
+// SoftDelete deletes o when soft delete for blobs is enabled on the
+// account so it is kept for the retention period set there.
+func (f *Fs) SoftDelete(ctx context.Context, o fs.Object) error {
+	obj, ok := o.(*Object)
+	if !ok {
+		return fmt.Errorf("can't soft delete %v: not an azureblob object", o)
+	}
+	if err := f.checkSoftDelete(ctx); err != nil {
+		return fserrors.FatalError(fmt.Errorf("can't soft delete from %v: %w", f, err))
+	}
+	return obj.Remove(ctx)
+}
+
 // MimeType of an Object if known, "" otherwise
 func (o *Object) MimeType(ctx context.Context) string {
 	return o.mimeType
@@ -3564,6 +3779,226 @@ func (o *Object) SetTier(tier string) error {
 	return nil
 }
 
+// Tags returns the blob index tags
+//
+// It should return nil if there are no tags
+func (o *Object) Tags(ctx context.Context) (fs.Tags, error) {
+	blb := o.getBlobSVC()
+	var resp blob.GetTagsResponse
+	err := o.fs.pacer.Call(func() (bool, error) {
+		var err error
+		resp, err = blb.GetTags(ctx, nil)
+		return o.fs.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob tags: %w", err)
+	}
+	if len(resp.BlobTagSet) == 0 {
+		return nil, nil
+	}
+	tags := make(fs.Tags, len(resp.BlobTagSet))
+	for _, tag := range resp.BlobTagSet {
+		if tag == nil || tag.Key == nil {
+			continue
+		}
+		value := ""
+		if tag.Value != nil {
+			value = *tag.Value
+		}
+		tags[*tag.Key] = value
+	}
+	return tags, nil
+}
+
+// SetTags replaces the blob index tags
+func (o *Object) SetTags(ctx context.Context, tags fs.Tags) error {
+	blb := o.getBlobSVC()
+	err := o.fs.pacer.Call(func() (bool, error) {
+		_, err := blb.SetTags(ctx, tags, nil)
+		return o.fs.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set blob tags: %w", err)
+	}
+	return nil
+}
+
+// maxBatchSize is the maximum number of sub-requests in a blob batch
+const maxBatchSize = 256
+
+// SetTierBatch changes the tier of all the objects passed in using the
+// blob batch API which changes the tiers of up to 256 blobs in one
+// request.
+func (f *Fs) SetTierBatch(ctx context.Context, tier string, objs []fs.Object) (errs []error, err error) {
+	if !validateAccessTier(tier) {
+		return nil, fmt.Errorf("tier %s not supported by Azure Blob Storage", tier)
+	}
+	return runBatches(objs, func(containerName string, batch []*Object, results map[*Object]error) {
+		f.setTierBatch(ctx, containerName, blob.AccessTier(tier), batch, results)
+	}), nil
+}
+
+// runBatches splits objs into batches of up to maxBatchSize blobs
+// which are all in the same container and calls fn for each batch.
+// fn must store the result for each object in results.
+//
+// It returns an error for each object.
+func runBatches(objs []fs.Object, fn func(containerName string, batch []*Object, results map[*Object]error)) (errs []error) {
+	errs = make([]error, len(objs))
+	// Batches may only contain blobs from one container
+	var containerNames []string
+	byContainer := map[string][]*Object{}
+	for i, obj := range objs {
+		o, ok := obj.(*Object)
+		if !ok {
+			errs[i] = errors.New("not an azureblob object")
+			continue
+		}
+		containerName, _ := o.split()
+		if _, found := byContainer[containerName]; !found {
+			containerNames = append(containerNames, containerName)
+		}
+		byContainer[containerName] = append(byContainer[containerName], o)
+	}
+	results := make(map[*Object]error, len(objs))
+	for _, containerName := range containerNames {
+		batch := byContainer[containerName]
+		for len(batch) > 0 {
+			n := min(len(batch), maxBatchSize)
+			fn(containerName, batch[:n], results)
+			batch = batch[n:]
+		}
+	}
+	for i, obj := range objs {
+		if o, ok := obj.(*Object); ok {
+			errs[i] = results[o]
+		}
+	}
+	return errs
+}
+
+// setTierBatch changes the tier of objs which must all be in
+// containerName with one blob batch request, storing the result for
+// each object in results.
+func (f *Fs) setTierBatch(ctx context.Context, containerName string, tier blob.AccessTier, objs []*Object, results map[*Object]error) {
+	setErr := func(err error) {
+		for _, o := range objs {
+			results[o] = err
+		}
+	}
+	priority := blob.RehydratePriorityStandard
+	opt := container.BatchSetTierOptions{
+		SetTierOptions: blob.SetTierOptions{
+			RehydratePriority: &priority,
+		},
+	}
+	var resp container.SubmitBatchResponse
+	err := f.pacer.Call(func() (bool, error) {
+		// The batch must be rebuilt for each try
+		bb, err := f.cntSVC(containerName).NewBatchBuilder()
+		if err != nil {
+			return false, err
+		}
+		for _, o := range objs {
+			_, containerPath := o.split()
+			err = bb.SetTier(containerPath, tier, &opt)
+			if err != nil {
+				return false, err
+			}
+		}
+		resp, err = f.cntSVC(containerName).SubmitBatch(ctx, bb, nil)
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		setErr(fmt.Errorf("failed to submit set tier batch: %w", err))
+		return
+	}
+	setErr(errors.New("no response in set tier batch"))
+	for _, item := range resp.Responses {
+		if item.ContentID == nil || *item.ContentID < 0 || *item.ContentID >= len(objs) {
+			continue
+		}
+		o := objs[*item.ContentID]
+		if item.Error != nil {
+			results[o] = fmt.Errorf("failed to set Blob Tier: %w", item.Error)
+			continue
+		}
+		results[o] = nil
+		o.accessTier = tier
+		fs.Debugf(o, "Successfully changed object tier to %s", tier)
+	}
+}
+
+// DeleteObjects deletes all the objects passed in using the blob
+// batch API which deletes up to 256 blobs in one request.
+func (f *Fs) DeleteObjects(ctx context.Context, objs []fs.Object) (errs []error, err error) {
+	return runBatches(objs, func(containerName string, batch []*Object, results map[*Object]error) {
+		f.deleteBatch(ctx, containerName, batch, results)
+	}), nil
+}
+
+// deleteBatch deletes objs which must all be in containerName with a
+// blob batch request, storing the result for each object in results.
+//
+// Blobs which fail with a retryable error are retried on their own in
+// the next request.
+func (f *Fs) deleteBatch(ctx context.Context, containerName string, objs []*Object, results map[*Object]error) {
+	opt := container.BatchDeleteOptions{}
+	if f.opt.DeleteSnapshots != "" {
+		action := blob.DeleteSnapshotsOptionType(f.opt.DeleteSnapshots)
+		opt.DeleteSnapshots = &action
+	}
+	pending := objs
+	_ = f.pacer.Call(func() (bool, error) {
+		setErr := func(err error) {
+			for _, o := range pending {
+				results[o] = err
+			}
+		}
+		// The batch must be rebuilt for each try
+		bb, err := f.cntSVC(containerName).NewBatchBuilder()
+		if err != nil {
+			setErr(err)
+			return false, err
+		}
+		for _, o := range pending {
+			_, containerPath := o.split()
+			err = bb.Delete(containerPath, &opt)
+			if err != nil {
+				setErr(err)
+				return false, err
+			}
+		}
+		resp, err := f.cntSVC(containerName).SubmitBatch(ctx, bb, nil)
+		if err != nil {
+			setErr(fmt.Errorf("failed to submit delete batch: %w", err))
+			return f.shouldRetry(ctx, err)
+		}
+		setErr(errors.New("no response in delete batch"))
+		var retry []*Object
+		for _, item := range resp.Responses {
+			if item.ContentID == nil || *item.ContentID < 0 || *item.ContentID >= len(pending) {
+				continue
+			}
+			o := pending[*item.ContentID]
+			if item.Error != nil {
+				results[o] = fmt.Errorf("failed to delete blob: %w", item.Error)
+				if again, _ := f.shouldRetry(ctx, item.Error); again {
+					retry = append(retry, o)
+				}
+				continue
+			}
+			results[o] = nil
+		}
+		pending = retry
+		if len(retry) > 0 {
+			fs.Debugf(f, "Retrying %d blobs which failed to delete", len(retry))
+			return true, fmt.Errorf("failed to delete %d blobs", len(retry))
+		}
+		return false, nil
+	})
+}
+
 // GetTier returns object tier in azure as string
 func (o *Object) GetTier() string {
 	return string(o.accessTier)
@@ -3586,8 +4021,13 @@ var (
 	_ fs.ListRer         = &Fs{}
 	_ fs.ListPer         = &Fs{}
 	_ fs.OpenChunkWriter = &Fs{}
+	_ fs.SetTierBatcher  = &Fs{}
+	_ fs.BatchDeleter    = &Fs{}
+	_ fs.SoftDeleter     = &Fs{}
 	_ fs.Object          = &Object{}
 	_ fs.MimeTyper       = &Object{}
 	_ fs.GetTierer       = &Object{}
 	_ fs.SetTierer       = &Object{}
+	_ fs.Tagger          = &Object{}
+	_ fs.SetTagger       = &Object{}
 )
@@ -0,0 +1,532 @@
+//go:build !plan9 && !solaris && !js
+
+package azureblob
+
+// This file contains the code which talks to the Data Lake Storage
+// Gen2 (DFS) endpoint. This is used on storage accounts with a
+// hierarchical namespace for the operations on directories which
+// can't be done with the blob API.
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/rclone/rclone/fs"
+)
+
+const (
+	dfsAPIVersion    = "2023-11-03"                         // x-ms-version to send to the DFS endpoint
+	dfsStorageScope  = "https://storage.azure.com/.default" // scope for tokens
+	dfsResourceDir   = "directory"                          // x-ms-resource-type of a directory
+	dfsPermissionKey = "permissions"                        // metadata key for the POSIX permissions
+	dfsACLKey        = "acl"                                // metadata key for the POSIX ACL
+)
+
+// dfsACLHeaders maps the ACL metadata keys to the DFS headers which
+// read and write them
+var dfsACLHeaders = map[string]string{
+	"owner":          "x-ms-owner",
+	"group":          "x-ms-group",
+	dfsPermissionKey: "x-ms-permissions",
+	dfsACLKey:        "x-ms-acl",
+}
+
+// dfsClient makes requests to the DFS endpoint of the storage account
+type dfsClient struct {
+	endpoint string           // URL of the DFS endpoint with no trailing /
+	sasQuery string           // SAS to add to each request if set
+	pl       runtime.Pipeline // pipeline to send the requests with
+}
+
+// blobToDFSHost converts the host of a blob endpoint into the host
+// of the corresponding DFS endpoint.
+//
+// Hosts which don't look like blob endpoints are returned unchanged.
+func blobToDFSHost(host string) string {
+	return strings.Replace(host, ".blob.", ".dfs.", 1)
+}
+
+// newDFSClient makes a client for the DFS endpoint using the same
+// credentials as the blob service client.
+func (f *Fs) newDFSClient(clientOptions policy.ClientOptions) (*dfsClient, error) {
+	endpoint := f.opt.DFSEndpoint
+	if endpoint == "" {
+		endpoint = f.svc.URL()
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DFS endpoint: %w", err)
+	}
+	if f.opt.DFSEndpoint == "" {
+		u.Host = blobToDFSHost(u.Host)
+	}
+	c := &dfsClient{
+		sasQuery: u.RawQuery,
+	}
+	u.RawQuery = ""
+	c.endpoint = strings.TrimRight(u.String(), "/")
+
+	var perRetry []policy.Policy
+	switch {
+	case f.cred != nil:
+		perRetry = append(perRetry, runtime.NewBearerTokenPolicy(f.cred, []string{dfsStorageScope}, nil))
+	case f.sharedKeyCred != nil:
+		key, err := base64.StdEncoding.DecodeString(f.opt.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode account key: %w", err)
+		}
+		perRetry = append(perRetry, &sharedKeyPolicy{
+			account: f.sharedKeyCred.AccountName(),
+			key:     key,
+		})
+	}
+	c.pl = runtime.NewPipeline("azureblob", fs.Version, runtime.PipelineOptions{
+		PerRetry: perRetry,
+	}, &clientOptions)
+	return c, nil
+}
+
+// escapePath returns the URL path of containerPath in containerName
+// relative to the endpoint, starting with a /
+func (c *dfsClient) escapePath(containerName, containerPath string) string {
+	var sb strings.Builder
+	sb.WriteString("/")
+	sb.WriteString(url.PathEscape(containerName))
+	if containerPath != "" {
+		for segment := range strings.SplitSeq(containerPath, "/") {
+			sb.WriteString("/")
+			sb.WriteString(url.PathEscape(segment))
+		}
+	}
+	return sb.String()
+}
+
+// addSAS adds the SAS, if any, to the query
+func (c *dfsClient) addSAS(query string) string {
+	if c.sasQuery == "" {
+		return query
+	}
+	if query == "" {
+		return c.sasQuery
+	}
+	return query + "&" + c.sasQuery
+}
+
+// url returns the URL for containerPath in containerName with the
+// params given
+func (c *dfsClient) url(containerName, containerPath string, params url.Values) string {
+	u := c.endpoint + c.escapePath(containerName, containerPath)
+	if query := c.addSAS(params.Encode()); query != "" {
+		u += "?" + query
+	}
+	return u
+}
+
+// renameSource returns the value for the x-ms-rename-source header
+// to rename containerPath in containerName
+func (c *dfsClient) renameSource(containerName, containerPath string) string {
+	source := c.escapePath(containerName, containerPath)
+	if c.sasQuery != "" {
+		source += "?" + c.sasQuery
+	}
+	return source
+}
+
+// sharedKeyPolicy signs requests to the DFS endpoint with the
+// storage account key.
+//
+// The azblob SDK doesn't export its signing policy so this does the
+// same thing.
+type sharedKeyPolicy struct {
+	account string // name of the storage account
+	key     []byte // decoded account key
+}
+
+// Do signs the request and passes it on to the next policy
+func (p *sharedKeyPolicy) Do(req *policy.Request) (*http.Response, error) {
+	raw := req.Raw()
+	raw.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	raw.Header.Set("Authorization", p.authorization(raw))
+	return req.Next()
+}
+
+// authorization returns the Authorization header for req
+func (p *sharedKeyPolicy) authorization(req *http.Request) string {
+	mac := hmac.New(sha256.New, p.key)
+	_, _ = mac.Write([]byte(p.stringToSign(req)))
+	return "SharedKey " + p.account + ":" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// stringToSign works out the string to sign for req
+//
+// See: https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (p *sharedKeyPolicy) stringToSign(req *http.Request) string {
+	h := req.Header
+	contentLength := h.Get("Content-Length")
+	if contentLength == "0" {
+		contentLength = ""
+	}
+
+	// Canonicalized headers are the x-ms- headers sorted by name.
+	// The service sorts ignoring "-" so do the same.
+	var keys []string
+	msHeaders := map[string]string{}
+	for k, v := range h {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if strings.HasPrefix(k, "x-ms-") {
+			keys = append(keys, k)
+			msHeaders[k] = strings.Join(v, ",")
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := strings.ReplaceAll(keys[i], "-", ""), strings.ReplaceAll(keys[j], "-", "")
+		if a != b {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+	var canonicalHeaders []string
+	for _, k := range keys {
+		canonicalHeaders = append(canonicalHeaders, k+":"+msHeaders[k])
+	}
+
+	// Canonicalized resource is the escaped path followed by the
+	// query parameters sorted by name.
+	var resource strings.Builder
+	resource.WriteString("/")
+	resource.WriteString(p.account)
+	if req.URL.Path == "" {
+		resource.WriteString("/")
+	} else {
+		resource.WriteString(req.URL.EscapedPath())
+	}
+	params := req.URL.Query()
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := params[name]
+		sort.Strings(values)
+		resource.WriteString("\n" + strings.ToLower(name) + ":" + strings.Join(values, ","))
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		h.Get("Content-Encoding"),
+		h.Get("Content-Language"),
+		contentLength,
+		h.Get("Content-MD5"),
+		h.Get("Content-Type"),
+		"", // Date is empty as x-ms-date is used
+		h.Get("If-Modified-Since"),
+		h.Get("If-Match"),
+		h.Get("If-None-Match"),
+		h.Get("If-Unmodified-Since"),
+		h.Get("Range"),
+		strings.Join(canonicalHeaders, "\n"),
+		resource.String(),
+	}, "\n")
+}
+
+// dfsErrorCode returns the error code from err if it is an error
+// returned from the DFS endpoint or "" otherwise
+func dfsErrorCode(err error) string {
+	var storageErr *azcore.ResponseError
+	if errors.As(err, &storageErr) {
+		return storageErr.ErrorCode
+	}
+	return ""
+}
+
+// dfsCall makes a request to the DFS endpoint for containerPath in
+// containerName.
+//
+// The body of the response is discarded so only the headers can be
+// used. If the request fails an *azcore.ResponseError is returned.
+func (f *Fs) dfsCall(ctx context.Context, method, containerName, containerPath string, params url.Values, headers map[string]string) (resp *http.Response, err error) {
+	err = f.pacer.Call(func() (bool, error) {
+		req, err := runtime.NewRequest(ctx, method, f.dfs.url(containerName, containerPath, params))
+		if err != nil {
+			return false, err
+		}
+		raw := req.Raw()
+		raw.Header.Set("x-ms-version", dfsAPIVersion)
+		for k, v := range headers {
+			raw.Header.Set(k, v)
+		}
+		resp, err = f.dfs.pl.Do(req)
+		if err == nil {
+			if runtime.HasStatusCode(resp, http.StatusOK, http.StatusCreated, http.StatusAccepted) {
+				runtime.Drain(resp)
+			} else {
+				err = runtime.NewResponseError(resp)
+			}
+		}
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// dfsMkdir creates the directory and any missing parents
+func (f *Fs) dfsMkdir(ctx context.Context, containerName, containerPath string) error {
+	if containerPath == "" {
+		return nil
+	}
+	_, err := f.dfsCall(ctx, http.MethodPut, containerName, containerPath, url.Values{
+		"resource": {"directory"},
+	}, map[string]string{
+		"If-None-Match": "*",
+	})
+	if dfsErrorCode(err) == "PathAlreadyExists" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return nil
+}
+
+// dfsMkdirParent creates the parent directory of containerPath
+func (f *Fs) dfsMkdirParent(ctx context.Context, containerName, containerPath string) error {
+	dir := path.Dir(containerPath)
+	if dir == "." || dir == "/" {
+		return nil
+	}
+	return f.dfsMkdir(ctx, containerName, dir)
+}
+
+// dfsPathType returns the resource type of containerPath, either
+// "file" or "directory", or "" if it doesn't exist
+func (f *Fs) dfsPathType(ctx context.Context, containerName, containerPath string) (string, error) {
+	resp, err := f.dfsCall(ctx, http.MethodHead, containerName, containerPath, nil, nil)
+	if err != nil {
+		var storageErr *azcore.ResponseError
+		if errors.As(err, &storageErr) && storageErr.StatusCode == http.StatusNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return resp.Header.Get("x-ms-resource-type"), nil
+}
+
+// dfsDelete deletes the directory containerPath.
+//
+// If recursive is set it deletes everything in it too, otherwise
+// the directory must be empty.
+func (f *Fs) dfsDelete(ctx context.Context, containerName, containerPath string, recursive bool) error {
+	params := url.Values{
+		"recursive": {strconv.FormatBool(recursive)},
+	}
+	for {
+		resp, err := f.dfsCall(ctx, http.MethodDelete, containerName, containerPath, params, nil)
+		switch dfsErrorCode(err) {
+		case "PathNotFound":
+			return fs.ErrorDirNotFound
+		case "DirectoryNotEmpty":
+			return fs.ErrorDirectoryNotEmpty
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete directory: %w", err)
+		}
+		// Deletes on accounts without a hierarchical
+		// namespace may need more than one call
+		continuation := resp.Header.Get("x-ms-continuation")
+		if continuation == "" {
+			return nil
+		}
+		params.Set("continuation", continuation)
+	}
+}
+
+// dfsRename renames srcPath in srcContainer to dstPath in dstContainer.
+//
+// The parent of dstPath must exist. If overwrite isn't set then the
+// rename fails with fs.ErrorDirExists if dstPath exists.
+func (f *Fs) dfsRename(ctx context.Context, srcContainer, srcPath, dstContainer, dstPath string, overwrite bool) error {
+	headers := map[string]string{
+		"x-ms-rename-source": f.dfs.renameSource(srcContainer, srcPath),
+	}
+	if !overwrite {
+		headers["If-None-Match"] = "*"
+	}
+	_, err := f.dfsCall(ctx, http.MethodPut, dstContainer, dstPath, url.Values{
+		"mode": {"legacy"},
+	}, headers)
+	switch dfsErrorCode(err) {
+	case "PathAlreadyExists":
+		return fs.ErrorDirExists
+	case "SourcePathNotFound":
+		return fs.ErrorObjectNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rename: %w", err)
+	}
+	return nil
+}
+
+// dfsGetAccessControl reads the owner, group, permissions and ACL of
+// containerPath as metadata
+func (f *Fs) dfsGetAccessControl(ctx context.Context, containerName, containerPath string) (fs.Metadata, error) {
+	resp, err := f.dfsCall(ctx, http.MethodHead, containerName, containerPath, url.Values{
+		"action": {"getAccessControl"},
+		"upn":    {"false"},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read access control: %w", err)
+	}
+	m := fs.Metadata{}
+	for key, header := range dfsACLHeaders {
+		if value := resp.Header.Get(header); value != "" {
+			m[key] = value
+		}
+	}
+	return m, nil
+}
+
+// accessControlHeaders returns the headers needed to set the ACL
+// metadata in meta or nil if there isn't any.
+//
+// The permissions and the ACL can't be set at the same time so the
+// ACL, which includes the permissions, is preferred.
+func accessControlHeaders(meta map[string]string) map[string]string {
+	var headers map[string]string
+	for key, header := range dfsACLHeaders {
+		value, ok := meta[key]
+		if !ok || value == "" {
+			continue
+		}
+		if key == dfsPermissionKey {
+			if _, hasACL := meta[dfsACLKey]; hasACL {
+				continue
+			}
+			// A trailing + shows there is an extended ACL
+			// but can't be set
+			value = strings.TrimSuffix(value, "+")
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(dfsACLHeaders))
+		}
+		headers[header] = value
+	}
+	return headers
+}
+
+// dfsSetAccessControl sets the owner, group, permissions and ACL of
+// containerPath from the metadata in meta
+func (f *Fs) dfsSetAccessControl(ctx context.Context, containerName, containerPath string, meta map[string]string) error {
+	headers := accessControlHeaders(meta)
+	if headers == nil {
+		return nil
+	}
+	_, err := f.dfsCall(ctx, http.MethodPatch, containerName, containerPath, url.Values{
+		"action": {"setAccessControl"},
+	}, headers)
+	if err != nil {
+		return fmt.Errorf("failed to set access control: %w", err)
+	}
+	return nil
+}
+
+// sameDFSAccount returns true if other uses the DFS endpoint of the
+// same storage account as f so paths can be renamed between them
+func (f *Fs) sameDFSAccount(other *Fs) bool {
+	return f.dfs != nil && other.dfs != nil && f.dfs.endpoint == other.dfs.endpoint
+}
+
+// Move src to this remote using server-side move operations.
+//
+// This is only possible on storage accounts with a hierarchical
+// namespace where the file is renamed atomically.
+//
+// This is stored with the remote path given.
+//
+// It returns the destination Object and a possible error.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantMove
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok || !f.sameDFSAccount(srcObj.fs) {
+		fs.Debugf(src, "Can't move - not same storage account with hierarchical namespace")
+		return nil, fs.ErrorCantMove
+	}
+	dstContainer, dstPath := f.split(remote)
+	err := f.makeContainer(ctx, dstContainer)
+	if err != nil {
+		return nil, err
+	}
+	err = f.dfsMkdirParent(ctx, dstContainer, dstPath)
+	if err != nil {
+		return nil, err
+	}
+	srcContainer, srcPath := srcObj.split()
+	err = f.dfsRename(ctx, srcContainer, srcPath, dstContainer, dstPath, true)
+	if err != nil {
+		return nil, err
+	}
+	return f.NewObject(ctx, remote)
+}
+
+// DirMove moves src, srcRemote to this remote at dstRemote
+// using server-side move operations.
+//
+// This is only possible on storage accounts with a hierarchical
+// namespace where the directory is renamed atomically.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantDirMove
+//
+// If destination exists then return fs.ErrorDirExists
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	srcFs, ok := src.(*Fs)
+	if !ok || !f.sameDFSAccount(srcFs) {
+		fs.Debugf(srcFs, "Can't move directory - not same storage account with hierarchical namespace")
+		return fs.ErrorCantDirMove
+	}
+	srcContainer, srcPath := srcFs.split(srcRemote)
+	dstContainer, dstPath := f.split(dstRemote)
+	if srcPath == "" || dstPath == "" {
+		fs.Debugf(srcFs, "Can't move directory - can't rename containers")
+		return fs.ErrorCantDirMove
+	}
+	err := f.makeContainer(ctx, dstContainer)
+	if err != nil {
+		return err
+	}
+	err = f.dfsMkdirParent(ctx, dstContainer, dstPath)
+	if err != nil {
+		return err
+	}
+	err = f.dfsRename(ctx, srcContainer, srcPath, dstContainer, dstPath, false)
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		return fs.ErrorDirNotFound
+	}
+	return err
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Mover    = &Fs{}
+	_ fs.DirMover = &Fs{}
+)
@@ -0,0 +1,106 @@
+//go:build !plan9 && !solaris && !js
+
+package azureblob
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobToDFSHost(t *testing.T) {
+	assert.Equal(t, "account.dfs.core.windows.net", blobToDFSHost("account.blob.core.windows.net"))
+	assert.Equal(t, "account.dfs.core.chinacloudapi.cn", blobToDFSHost("account.blob.core.chinacloudapi.cn"))
+	assert.Equal(t, "127.0.0.1:10000", blobToDFSHost("127.0.0.1:10000"))
+}
+
+func TestDFSClientURL(t *testing.T) {
+	c := &dfsClient{endpoint: "https://account.dfs.core.windows.net"}
+	assert.Equal(t, "https://account.dfs.core.windows.net/container", c.url("container", "", nil))
+	assert.Equal(t, "https://account.dfs.core.windows.net/container/dir/file%20name%3F%23", c.url("container", "dir/file name?#", nil))
+	assert.Equal(t, "https://account.dfs.core.windows.net/container/dir?recursive=true", c.url("container", "dir", url.Values{"recursive": {"true"}}))
+	assert.Equal(t, "/container/dir/a%20b", c.renameSource("container", "dir/a b"))
+
+	c.sasQuery = "sv=2023&sig=xyz"
+	assert.Equal(t, "https://account.dfs.core.windows.net/container/dir?sv=2023&sig=xyz", c.url("container", "dir", nil))
+	assert.Equal(t, "https://account.dfs.core.windows.net/container/dir?recursive=false&sv=2023&sig=xyz", c.url("container", "dir", url.Values{"recursive": {"false"}}))
+	assert.Equal(t, "/container/dir?sv=2023&sig=xyz", c.renameSource("container", "dir"))
+}
+
+func TestAccessControlHeaders(t *testing.T) {
+	assert.Nil(t, accessControlHeaders(nil))
+	assert.Nil(t, accessControlHeaders(map[string]string{"mtime": "2006-01-02T15:04:05Z"}))
+	assert.Equal(t, map[string]string{
+		"x-ms-owner":       "alice",
+		"x-ms-group":       "staff",
+		"x-ms-permissions": "rwxr-x---",
+	}, accessControlHeaders(map[string]string{
+		"owner":       "alice",
+		"group":       "staff",
+		"permissions": "rwxr-x---+",
+		"mtime":       "2006-01-02T15:04:05Z",
+	}))
+	// The ACL is preferred to the permissions
+	assert.Equal(t, map[string]string{
+		"x-ms-acl": "user::rwx,group::r-x,other::---",
+	}, accessControlHeaders(map[string]string{
+		"permissions": "rwxr-x---",
+		"acl":         "user::rwx,group::r-x,other::---",
+	}))
+}
+
+// captureTransport records the requests sent through it
+type captureTransport struct {
+	reqs []*http.Request
+}
+
+func (c *captureTransport) Do(req *http.Request) (*http.Response, error) {
+	c.reqs = append(c.reqs, req)
+	return &http.Response{
+		StatusCode: http.StatusNotFound,
+		Header:     http.Header{"X-Ms-Error-Code": {"BlobNotFound"}},
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+// Check the shared key signing matches the azblob SDK
+func TestSharedKeyPolicy(t *testing.T) {
+	ctx := context.Background()
+	key := base64.StdEncoding.EncodeToString([]byte("not a very secret key"))
+	cred, err := service.NewSharedKeyCredential("account", key)
+	require.NoError(t, err)
+	transport := &captureTransport{}
+	svc, err := service.NewClientWithSharedKeyCredential("https://account.blob.core.windows.net/", cred, &service.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Transport: transport,
+			Retry:     policy.RetryOptions{MaxRetries: -1},
+		},
+	})
+	require.NoError(t, err)
+
+	cnt := svc.NewContainerClient("container")
+	_, _ = cnt.NewBlobClient("dir/file name+more").GetProperties(ctx, nil)
+	prefix := "dir/"
+	_, _ = cnt.NewListBlobsHierarchyPager("/", &container.ListBlobsHierarchyOptions{Prefix: &prefix}).NextPage(ctx)
+	require.Len(t, transport.reqs, 2)
+
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	require.NoError(t, err)
+	p := &sharedKeyPolicy{account: "account", key: decodedKey}
+	for _, req := range transport.reqs {
+		want := req.Header.Get("Authorization")
+		require.NotEmpty(t, want)
+		assert.Equal(t, want, p.authorization(req), req.URL.String())
+	}
+}
@@ -68,11 +68,14 @@ const (
 	storageDefaultBaseURL = "file.core.windows.net"
 )
 
+var errNotWithSnapshot = errors.New("can't modify or delete files in --azurefiles-snapshot mode")
+
 func init() {
 	fs.Register(&fs.RegInfo{
 		Name:        "azurefiles",
 		Description: "Microsoft Azure Files",
 		NewFs:       NewFs,
+		CommandHelp: commandHelp,
 		Options: []fs.Option{{
 			Name: "account",
 			Help: `Azure Storage Account Name.
@@ -308,6 +311,16 @@ You will need this much free space in the share as the file will be this size te
 `, "|", "`"),
 			Default:  10 * fs.Gibi,
 			Advanced: true,
+		}, {
+			Name: "snapshot",
+			Help: strings.ReplaceAll(`Share snapshot to read from.
+
+Set this to the timestamp of a share snapshot, as shown by the
+|snapshot list| backend command, to read the share as it was when the
+snapshot was taken, for example |2024-01-02T03:04:05.0000000Z|.
+
+The remote is read only while this is set.`, "|", "`"),
+			Advanced: true,
 		}, {
 			Name:     config.ConfigEncoding,
 			Help:     config.ConfigEncodingHelp,
@@ -354,17 +367,19 @@ type Options struct {
 	ChunkSize                  fs.SizeSuffix        `config:"chunk_size"`
 	MaxStreamSize              fs.SizeSuffix        `config:"max_stream_size"`
 	UploadConcurrency          int                  `config:"upload_concurrency"`
+	Snapshot                   string               `config:"snapshot"`
 	Enc                        encoder.MultiEncoder `config:"encoding"`
 }
 
 // Fs represents a root directory inside a share. The root directory can be ""
 type Fs struct {
-	name        string            // name of this remote
-	root        string            // the path we are working on if any
-	opt         Options           // parsed config options
-	features    *fs.Features      // optional features
-	shareClient *share.Client     // a client for the share itself
-	svc         *directory.Client // the root service
+	name          string            // name of this remote
+	root          string            // the path we are working on if any
+	opt           Options           // parsed config options
+	features      *fs.Features      // optional features
+	serviceClient *service.Client   // a client for the storage account
+	shareClient   *share.Client     // a client for the share itself
+	svc           *directory.Client // the root service
 }
 
 // Object describes a Azure File Share File
@@ -637,13 +652,20 @@ func newFsFromOptions(ctx context.Context, name, root string, opt *Options) (fs.
 	}
 
 	shareClient := client.NewShareClient(opt.ShareName)
+	if opt.Snapshot != "" {
+		shareClient, err = shareClient.WithSnapshot(opt.Snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to make client for snapshot %q: %w", opt.Snapshot, err)
+		}
+	}
 	svc := shareClient.NewRootDirectoryClient()
 	f := &Fs{
-		shareClient: shareClient,
-		svc:         svc,
-		name:        name,
-		root:        root,
-		opt:         *opt,
+		serviceClient: client,
+		shareClient:   shareClient,
+		svc:           svc,
+		name:          name,
+		root:          root,
+		opt:           *opt,
 	}
 	f.features = (&fs.Features{
 		CanHaveEmptyDirectories: true,
@@ -755,6 +777,9 @@ func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
 // This recursiely creating parent directories all the way to the root
 // of the share.
 func (f *Fs) absMkdir(ctx context.Context, absPath string) error {
+	if f.opt.Snapshot != "" {
+		return errNotWithSnapshot
+	}
 	if absPath == "" {
 		return nil
 	}
@@ -805,6 +830,9 @@ func (f *Fs) mkParentDir(ctx context.Context, remote string) error {
 //
 // Returns an error if it isn't empty
 func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	if f.opt.Snapshot != "" {
+		return errNotWithSnapshot
+	}
 	dirClient := f.dirClient(dir)
 	_, err := dirClient.Delete(ctx, nil)
 	if err != nil {
@@ -1033,6 +1061,9 @@ func (o *Object) ModTime(ctx context.Context) time.Time {
 
 // SetModTime sets the modification time
 func (o *Object) SetModTime(ctx context.Context, t time.Time) error {
+	if o.fs.opt.Snapshot != "" {
+		return errNotWithSnapshot
+	}
 	opt := file.SetHTTPHeadersOptions{
 		SMBProperties: &file.SMBProperties{
 			LastWriteTime: &t,
@@ -1052,6 +1083,9 @@ func (o *Object) SetModTime(ctx context.Context, t time.Time) error {
 
 // Remove an object
 func (o *Object) Remove(ctx context.Context) error {
+	if o.fs.opt.Snapshot != "" {
+		return errNotWithSnapshot
+	}
 	if _, err := o.fileClient().Delete(ctx, nil); err != nil {
 		return fmt.Errorf("unable to delete remote %q: %w", o.remote, err)
 	}
@@ -1112,6 +1146,9 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		hasher         = md5.New()
 	)
 
+	if o.fs.opt.Snapshot != "" {
+		return errNotWithSnapshot
+	}
 	if size > int64(maxFileSize) {
 		return fmt.Errorf("update: max supported file size is %vB. provided size is %vB", maxFileSize, fs.SizeSuffix(size))
 	} else if size < 0 {
@@ -1240,6 +1277,9 @@ func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object,
 		fs.Debugf(src, "Can't move - not same remote type")
 		return nil, fs.ErrorCantMove
 	}
+	if f.opt.Snapshot != "" || srcObj.fs.opt.Snapshot != "" {
+		return nil, errNotWithSnapshot
+	}
 	err := f.mkParentDir(ctx, remote)
 	if err != nil {
 		return nil, fmt.Errorf("Move: mkParentDir failed: %w", err)
@@ -1276,6 +1316,9 @@ func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string
 		fs.Debugf(srcFs, "Can't move directory - not same remote type")
 		return fs.ErrorCantDirMove
 	}
+	if dstFs.opt.Snapshot != "" || srcFs.opt.Snapshot != "" {
+		return errNotWithSnapshot
+	}
 
 	_, err := dstFs.dirClient(dstRemote).GetProperties(ctx, nil)
 	if err == nil {
@@ -1321,6 +1364,9 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 		fs.Debugf(src, "Can't copy - not same remote type")
 		return nil, fs.ErrorCantCopy
 	}
+	if f.opt.Snapshot != "" {
+		return nil, errNotWithSnapshot
+	}
 	err := f.mkParentDir(ctx, remote)
 	if err != nil {
 		return nil, fmt.Errorf("Copy: mkParentDir failed: %w", err)
@@ -1432,6 +1478,9 @@ func (w *writerAt) Close() error {
 //
 // It truncates any existing object
 func (f *Fs) OpenWriterAt(ctx context.Context, remote string, size int64) (fs.WriterAtCloser, error) {
+	if f.opt.Snapshot != "" {
+		return nil, errNotWithSnapshot
+	}
 	err := f.mkParentDir(ctx, remote)
 	if err != nil {
 		return nil, fmt.Errorf("OpenWriterAt: failed to create parent directory: %w", err)
@@ -1465,6 +1514,156 @@ func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
 	return usage, nil
 }
 
+// snapshotInfo describes a share snapshot for the snapshot list command
+type snapshotInfo struct {
+	Snapshot     string            `json:"snapshot"`
+	LastModified time.Time         `json:"lastModified"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// listSnapshots returns the snapshots of the share, oldest first
+func (f *Fs) listSnapshots(ctx context.Context) (snapshots []snapshotInfo, err error) {
+	pager := f.serviceClient.NewListSharesPager(&service.ListSharesOptions{
+		Include: service.ListSharesInclude{
+			Metadata:  true,
+			Snapshots: true,
+		},
+		Prefix: &f.opt.ShareName,
+	})
+	for pager.More() {
+		resp, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list snapshots: %w", err)
+		}
+		for _, item := range resp.Shares {
+			if item.Name == nil || *item.Name != f.opt.ShareName || item.Snapshot == nil {
+				continue
+			}
+			snapshot := snapshotInfo{
+				Snapshot: *item.Snapshot,
+			}
+			if item.Properties != nil && item.Properties.LastModified != nil {
+				snapshot.LastModified = *item.Properties.LastModified
+			}
+			if len(item.Metadata) > 0 {
+				snapshot.Metadata = make(map[string]string, len(item.Metadata))
+				for k, v := range item.Metadata {
+					if v != nil {
+						snapshot.Metadata[k] = *v
+					}
+				}
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots, nil
+}
+
+// createSnapshot takes a snapshot of the share returning its timestamp
+func (f *Fs) createSnapshot(ctx context.Context, metadata map[string]string) (string, error) {
+	opt := share.CreateSnapshotOptions{}
+	if len(metadata) > 0 {
+		opt.Metadata = make(map[string]*string, len(metadata))
+		for k, v := range metadata {
+			opt.Metadata[k] = ptr(v)
+		}
+	}
+	// Snapshots are always of the base share
+	shareClient := f.serviceClient.NewShareClient(f.opt.ShareName)
+	resp, err := shareClient.CreateSnapshot(ctx, &opt)
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	if resp.Snapshot == nil {
+		return "", errors.New("failed to create snapshot: no snapshot returned")
+	}
+	return *resp.Snapshot, nil
+}
+
+// deleteSnapshot deletes the share snapshot with the timestamp given
+func (f *Fs) deleteSnapshot(ctx context.Context, snapshot string) error {
+	shareClient := f.serviceClient.NewShareClient(f.opt.ShareName)
+	_, err := shareClient.Delete(ctx, &share.DeleteOptions{
+		ShareSnapshot: &snapshot,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot %q: %w", snapshot, err)
+	}
+	return nil
+}
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "snapshot",
+	Short: "List, create or delete share snapshots.",
+	Long: `This command manages the snapshots of the share for point in time
+restores. The first argument is the action to take.
+
+List the snapshots of the share, oldest first:
+
+` + "```console" + `
+rclone backend snapshot azurefiles: list
+` + "```" + `
+
+Create a snapshot, returning its timestamp. Metadata can be set on
+the snapshot with -o key=value:
+
+` + "```console" + `
+rclone backend snapshot azurefiles: create -o reason=before-migration
+` + "```" + `
+
+Delete one or more snapshots:
+
+` + "```console" + `
+rclone backend snapshot azurefiles: delete 2024-01-02T03:04:05.0000000Z
+` + "```" + `
+
+To read the files in a snapshot use the --azurefiles-snapshot flag
+with its timestamp, for example to restore a directory:
+
+` + "```console" + `
+rclone copy --azurefiles-snapshot 2024-01-02T03:04:05.0000000Z azurefiles:dir /tmp/dir
+` + "```",
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out any, err error) {
+	switch name {
+	case "snapshot":
+		if len(arg) == 0 {
+			return nil, errors.New("need an action: list, create or delete")
+		}
+		switch action := arg[0]; action {
+		case "list":
+			return f.listSnapshots(ctx)
+		case "create":
+			return f.createSnapshot(ctx, opt)
+		case "delete":
+			if len(arg) < 2 {
+				return nil, errors.New("need the timestamps of the snapshots to delete")
+			}
+			for _, snapshot := range arg[1:] {
+				err = f.deleteSnapshot(ctx, snapshot)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("unknown snapshot action %q: must be list, create or delete", action)
+		}
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
 // Check the interfaces are satisfied
 var (
 	_ fs.Fs             = &Fs{}
@@ -1475,6 +1674,7 @@ var (
 	_ fs.Copier         = &Fs{}
 	_ fs.OpenWriterAter = &Fs{}
 	_ fs.ListPer        = &Fs{}
+	_ fs.Commander      = &Fs{}
 	_ fs.Object         = &Object{}
 	_ fs.MimeTyper      = &Object{}
 )
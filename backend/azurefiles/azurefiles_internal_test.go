@@ -4,16 +4,21 @@ package azurefiles
 
 import (
 	"context"
+	"io"
 	"math/rand"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fstest/fstests"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func (f *Fs) InternalTest(t *testing.T) {
 	t.Run("Authentication", f.InternalTestAuth)
+	t.Run("Snapshot", f.InternalTestSnapshot)
 }
 
 var _ fstests.InternalTester = (*Fs)(nil)
@@ -57,6 +62,56 @@ func (f *Fs) InternalTestAuth(t *testing.T) {
 	}
 }
 
+func (f *Fs) InternalTestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	remote := "snapshot-" + randomString(10) + ".txt"
+	put := func(contents string) {
+		_, err := operations.Rcat(ctx, f, remote, io.NopCloser(strings.NewReader(contents)), time.Now(), nil)
+		require.NoError(t, err)
+	}
+	put("before")
+	defer func() {
+		o, err := f.NewObject(ctx, remote)
+		if err == nil {
+			assert.NoError(t, o.Remove(ctx))
+		}
+	}()
+
+	out, err := f.Command(ctx, "snapshot", []string{"create"}, map[string]string{"test": "rclone"})
+	require.NoError(t, err)
+	snapshot := out.(string)
+	defer func() {
+		_, err := f.Command(ctx, "snapshot", []string{"delete", snapshot}, nil)
+		assert.NoError(t, err)
+	}()
+	put("after")
+
+	out, err = f.Command(ctx, "snapshot", []string{"list"}, nil)
+	require.NoError(t, err)
+	found := false
+	for _, info := range out.([]snapshotInfo) {
+		if info.Snapshot == snapshot {
+			found = true
+			assert.Equal(t, "rclone", info.Metadata["test"])
+		}
+	}
+	assert.True(t, found, "snapshot %q not listed", snapshot)
+
+	opt := f.opt
+	opt.Snapshot = snapshot
+	snapFs, err := newFsFromOptions(ctx, f.name, f.root, &opt)
+	require.NoError(t, err)
+	o, err := snapFs.NewObject(ctx, remote)
+	require.NoError(t, err)
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	data, err := io.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "before", string(data))
+	assert.ErrorIs(t, o.Remove(ctx), errNotWithSnapshot)
+}
+
 const chars = "abcdefghijklmnopqrstuvwzyxABCDEFGHIJKLMNOPQRSTUVWZYX"
 
 func randomString(charCount int) string {
@@ -591,11 +591,12 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	}
 	f.setRoot(root)
 	f.features = (&fs.Features{
-		ReadMimeType:          true,
-		WriteMimeType:         true,
-		BucketBased:           true,
-		BucketBasedRootOK:     true,
-		ChunkWriterDoesntSeek: true,
+		ReadMimeType:            true,
+		WriteMimeType:           true,
+		BucketBased:             true,
+		BucketBasedRootOK:       true,
+		ChunkWriterDoesntSeek:   true,
+		ServerSideAcrossConfigs: true,
 	}).Fill(ctx, f)
 	// Set the test flag if required
 	if opt.TestMode != "" {
@@ -1500,6 +1501,161 @@ func (f *Fs) cleanUp(ctx context.Context, deleteHidden bool, deleteUnfinished bo
 	return f.purge(ctx, "", true, deleteHidden, deleteUnfinished, maxAge)
 }
 
+// ListDeleted calls fn for each hidden file in dir which has a
+// previous version to restore.
+func (f *Fs) ListDeleted(ctx context.Context, dir string, fn func(fs.DeletedObject) error) error {
+	bucket, directory := f.split(dir)
+	if bucket == "" {
+		return fs.ErrorListBucketRequired
+	}
+	var (
+		last    = ""
+		pending *fs.DeletedObject // hide marker waiting for the version it hides
+	)
+	return f.list(ctx, bucket, directory, f.rootDirectory, f.rootBucket == "", true, 0, true, false, func(remote string, object *api.File, isDirectory bool) error {
+		if isDirectory {
+			return nil
+		}
+		isLatest := remote != last
+		last = remote
+		if isLatest {
+			pending = nil
+			if object.Action == "hide" {
+				pending = &fs.DeletedObject{
+					Remote:    remote,
+					DeletedAt: time.Time(object.UploadTimestamp),
+					ID:        object.ID,
+				}
+			}
+			return nil
+		}
+		if pending == nil || object.Action != "upload" {
+			return nil
+		}
+		o, err := f.newObjectWithInfo(ctx, remote, object)
+		if err != nil {
+			return err
+		}
+		deleted := *pending
+		pending = nil
+		deleted.Size = o.Size()
+		deleted.ModTime = o.ModTime(ctx)
+		return fn(deleted)
+	})
+}
+
+// Undelete restores a hidden file by deleting its hide marker.
+func (f *Fs) Undelete(ctx context.Context, deleted fs.DeletedObject) error {
+	if f.opt.Versions {
+		return errNotWithVersions
+	}
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
+	if _, err := f.NewObject(ctx, deleted.Remote); err == nil {
+		return fs.ErrorCantUndelete
+	}
+	_, bucketPath := f.split(deleted.Remote)
+	return f.deleteByID(ctx, deleted.ID, bucketPath)
+}
+
+// SoftDelete hides o so it can be restored with Undelete, whatever
+// hard_delete is set to.
+func (f *Fs) SoftDelete(ctx context.Context, o fs.Object) error {
+	if f.opt.Versions {
+		return errNotWithVersions
+	}
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
+	bucket, bucketPath := f.split(o.Remote())
+	return f.hide(ctx, bucket, bucketPath)
+}
+
+// PurgeDeleted deletes the hide marker and all the versions of a
+// hidden file.
+func (f *Fs) PurgeDeleted(ctx context.Context, deleted fs.DeletedObject) error {
+	if f.opt.Versions {
+		return errNotWithVersions
+	}
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
+	if _, err := f.NewObject(ctx, deleted.Remote); err == nil {
+		return fmt.Errorf("not purging %q as it has been restored", deleted.Remote)
+	}
+	bucket, bucketPath := f.split(deleted.Remote)
+	prefix := f.rootDirectory
+	if prefix != "" {
+		prefix += "/"
+	}
+	var ids []string
+	err := f.list(ctx, bucket, bucketPath, prefix, f.rootBucket == "", true, 0, true, true, func(remote string, object *api.File, isDirectory bool) error {
+		if remote != deleted.Remote {
+			return errEndList
+		}
+		if object.Action != "start" {
+			ids = append(ids, object.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err = f.deleteByID(ctx, id, bucketPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sseCustomer returns the SSE-C configuration for server-side copies
+// or nil if SSE-C isn't in use.
+func (f *Fs) sseCustomer() *api.ServerSideEncryption {
+	if f.opt.SSECustomerKey == "" || f.opt.SSECustomerKeyMD5 == "" {
+		return nil
+	}
+	return &api.ServerSideEncryption{
+		Mode:           "SSE-C",
+		Algorithm:      f.opt.SSECustomerAlgorithm,
+		CustomerKey:    f.opt.SSECustomerKeyBase64,
+		CustomerKeyMd5: f.opt.SSECustomerKeyMD5,
+	}
+}
+
+// canCopyFrom returns true if srcObj can be server-side copied to f.
+//
+// Objects on a different remote can be copied if they are in the same
+// account and the destination key is allowed to read their bucket.
+func (f *Fs) canCopyFrom(ctx context.Context, srcObj *Object) bool {
+	if srcObj.fs == f {
+		return true
+	}
+	if srcObj.fs.info.AccountID != f.info.AccountID {
+		fs.Debugf(srcObj, "Can't copy - source is in a different account")
+		return false
+	}
+	allowed := f.info.APIs.Storage.Allowed.Buckets
+	if len(allowed) == 0 {
+		return true
+	}
+	srcBucket, _ := srcObj.split()
+	srcBucketID, err := srcObj.fs.getBucketID(ctx, srcBucket)
+	if err != nil {
+		fs.Debugf(srcObj, "Can't copy - failed to find source bucket: %v", err)
+		return false
+	}
+	for _, bucket := range allowed {
+		if bucket.ID == srcBucketID {
+			return true
+		}
+	}
+	fs.Debugf(srcObj, "Can't copy - destination key isn't allowed to read the source bucket")
+	return false
+}
+
 // copy does a server-side copy from dstObj <- srcObj
 //
 // If newInfo is nil then the metadata will be copied otherwise it
@@ -1543,16 +1699,9 @@ func (f *Fs) copy(ctx context.Context, dstObj *Object, srcObj *Object, newInfo *
 		Name:         f.opt.Enc.FromStandardPath(dstPath),
 		DestBucketID: destBucketID,
 	}
-	if f.opt.SSECustomerKey != "" && f.opt.SSECustomerKeyMD5 != "" {
-		serverSideEncryptionConfig := api.ServerSideEncryption{
-			Mode:           "SSE-C",
-			Algorithm:      f.opt.SSECustomerAlgorithm,
-			CustomerKey:    f.opt.SSECustomerKeyBase64,
-			CustomerKeyMd5: f.opt.SSECustomerKeyMD5,
-		}
-		request.SourceServerSideEncryption = &serverSideEncryptionConfig
-		request.DestinationServerSideEncryption = &serverSideEncryptionConfig
-	}
+	// The source may be on a different remote with its own SSE-C key
+	request.SourceServerSideEncryption = srcObj.fs.sseCustomer()
+	request.DestinationServerSideEncryption = f.sseCustomer()
 	if newInfo == nil {
 		request.MetadataDirective = "COPY"
 	} else {
@@ -1586,6 +1735,9 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 		fs.Debugf(src, "Can't copy - not same remote type")
 		return nil, fs.ErrorCantCopy
 	}
+	if !f.canCopyFrom(ctx, srcObj) {
+		return nil, fs.ErrorCantCopy
+	}
 	// Temporary Object under construction
 	dstObj := &Object{
 		fs:     f,
@@ -2609,6 +2761,9 @@ var (
 	_ fs.PublicLinker    = &Fs{}
 	_ fs.OpenChunkWriter = &Fs{}
 	_ fs.Commander       = &Fs{}
+	_ fs.Undeleter       = &Fs{}
+	_ fs.SoftDeleter     = &Fs{}
+	_ fs.DeletedPurger   = &Fs{}
 	_ fs.Object          = &Object{}
 	_ fs.MimeTyper       = &Object{}
 	_ fs.IDer            = &Object{}
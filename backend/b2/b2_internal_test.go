@@ -186,6 +186,31 @@ func TestParseTimeString(t *testing.T) {
 
 }
 
+func TestCopySSECustomer(t *testing.T) {
+	ctx := context.Background()
+	src := &Fs{}
+	src.info.AccountID = "account"
+	dst := &Fs{}
+	dst.info.AccountID = "account"
+	dst.opt.SSECustomerAlgorithm = "AES256"
+	dst.opt.SSECustomerKey = "key"
+	dst.opt.SSECustomerKeyBase64 = "a2V5"
+	dst.opt.SSECustomerKeyMD5 = "PG4p/bCg4bbBkMEIIk3ojw=="
+
+	assert.Nil(t, src.sseCustomer())
+	assert.Equal(t, &api.ServerSideEncryption{
+		Mode:           "SSE-C",
+		Algorithm:      "AES256",
+		CustomerKey:    "a2V5",
+		CustomerKeyMd5: "PG4p/bCg4bbBkMEIIk3ojw==",
+	}, dst.sseCustomer())
+
+	srcObj := &Object{fs: src, remote: "file"}
+	assert.True(t, dst.canCopyFrom(ctx, srcObj))
+	src.info.AccountID = "other"
+	assert.False(t, dst.canCopyFrom(ctx, srcObj))
+}
+
 // Return a map of the headers in the options with keys stripped of the "x-bz-info-" prefix
 func OpenOptionToMetaData(options []fs.OpenOption) map[string]string {
 	var headers = make(map[string]string)
@@ -348,17 +348,8 @@ func (up *largeUpload) copyChunk(ctx context.Context, part int, partSize int64)
 			PartNumber:  int64(part + 1),
 			Range:       fmt.Sprintf("bytes=%d-%d", offset, offset+partSize-1),
 		}
-
-		if up.o.fs.opt.SSECustomerKey != "" && up.o.fs.opt.SSECustomerKeyMD5 != "" {
-			serverSideEncryptionConfig := api.ServerSideEncryption{
-				Mode:           "SSE-C",
-				Algorithm:      up.o.fs.opt.SSECustomerAlgorithm,
-				CustomerKey:    up.o.fs.opt.SSECustomerKeyBase64,
-				CustomerKeyMd5: up.o.fs.opt.SSECustomerKeyMD5,
-			}
-			request.SourceServerSideEncryption = &serverSideEncryptionConfig
-			request.DestinationServerSideEncryption = &serverSideEncryptionConfig
-		}
+		request.SourceServerSideEncryption = up.src.fs.sseCustomer()
+		request.DestinationServerSideEncryption = up.o.fs.sseCustomer()
 		var response api.UploadPartResponse
 		resp, err := up.f.srv.CallJSON(ctx, &opts, &request, &response)
 		retry, err := up.f.shouldRetry(ctx, resp, err)
@@ -201,11 +201,25 @@ type CopyFile struct {
 // CreateSharedLink is the request for Public Link
 type CreateSharedLink struct {
 	SharedLink struct {
-		URL    string `json:"url,omitempty"`
-		Access string `json:"access,omitempty"`
+		URL         string                 `json:"url,omitempty"`
+		Access      string                 `json:"access,omitempty"`
+		Password    string                 `json:"password,omitempty"`
+		UnsharedAt  *Time                  `json:"unshared_at,omitempty"`
+		Permissions *SharedLinkPermissions `json:"permissions,omitempty"`
 	} `json:"shared_link"`
 }
 
+// SharedLinkPermissions controls what a shared link allows
+type SharedLinkPermissions struct {
+	CanDownload bool `json:"can_download"`
+	CanEdit     bool `json:"can_edit,omitempty"`
+}
+
+// RemoveSharedLink is the request to remove a Public Link
+type RemoveSharedLink struct {
+	SharedLink *struct{} `json:"shared_link"` // null removes the link
+}
+
 // UploadSessionRequest is uses in Create Upload Session
 type UploadSessionRequest struct {
 	FolderID string `json:"folder_id,omitempty"` // don't pass for update
@@ -490,6 +490,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	f.features = (&fs.Features{
 		CaseInsensitive:         true,
 		CanHaveEmptyDirectories: true,
+		PublicLinkOptions:       true,
 	}).Fill(ctx, f)
 	f.srv.SetErrorHandler(errorHandler)
 
@@ -1197,7 +1198,7 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 			return "", err
 		}
 
-		if o.(*Object).publicLink != "" {
+		if o.(*Object).publicLink != "" && !unlink && expire >= fs.DurationOff && !fs.GetLinkOptions(ctx).IsSet() {
 			return o.(*Object).publicLink, nil
 		}
 
@@ -1208,11 +1209,32 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 		}
 	}
 
-	shareLink := api.CreateSharedLink{}
+	var request any
+	if unlink {
+		request = api.RemoveSharedLink{}
+	} else {
+		shareLink := api.CreateSharedLink{}
+		if expire < fs.DurationOff {
+			unsharedAt := api.Time(time.Now().Add(time.Duration(expire)))
+			shareLink.SharedLink.UnsharedAt = &unsharedAt
+		}
+		lo := fs.GetLinkOptions(ctx)
+		if lo.IsSet() {
+			shareLink.SharedLink.Access = "open"
+			shareLink.SharedLink.Password = lo.Password
+		}
+		if lo.Permission != "" {
+			shareLink.SharedLink.Permissions = &api.SharedLinkPermissions{
+				CanDownload: true,
+				CanEdit:     lo.Permission == fs.LinkPermissionEdit,
+			}
+		}
+		request = &shareLink
+	}
 	var info api.Item
 	var resp *http.Response
 	err = f.pacer.Call(func() (bool, error) {
-		resp, err = f.srv.CallJSON(ctx, &opts, &shareLink, &info)
+		resp, err = f.srv.CallJSON(ctx, &opts, request, &info)
 		return shouldRetry(ctx, resp, err)
 	})
 	return info.SharedLink.URL, err
@@ -17,9 +17,9 @@ func TestIntegration(t *testing.T) {
 	fstests.Run(t, &fstests.Opt{
 		RemoteName:                      "TestCache:",
 		NilObject:                       (*cache.Object)(nil),
-		UnimplementableFsMethods:        []string{"PublicLink", "OpenWriterAt", "OpenChunkWriter", "DirSetModTime", "MkdirMetadata", "ListP"},
-		UnimplementableObjectMethods:    []string{"MimeType", "ID", "GetTier", "SetTier", "Metadata", "SetMetadata"},
-		UnimplementableDirectoryMethods: []string{"Metadata", "SetMetadata", "SetModTime"},
+		UnimplementableFsMethods:        []string{"PublicLink", "OpenWriterAt", "OpenChunkWriter", "DirSetModTime", "MkdirMetadata", "ListP", "SetTierBatch", "ChangedPaths", "DeleteObjects", "ListDeleted", "Undelete", "SoftDelete", "PurgeDeleted", "ResumeChunkWriter", "ResumeWriterAt"},
+		UnimplementableObjectMethods:    []string{"MimeType", "ID", "GetTier", "SetTier", "Metadata", "SetMetadata", "Tags", "SetTags"},
+		UnimplementableDirectoryMethods: []string{"Metadata", "SetMetadata", "SetModTime", "ChangeToken"},
 		SkipInvalidUTF8:                 true, // invalid UTF-8 confuses the cache
 	})
 }
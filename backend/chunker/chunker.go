@@ -1927,12 +1927,21 @@ func (f *Fs) CleanUp(ctx context.Context) error {
 }
 
 // About gets quota information from the Fs
+//
+// The number of objects is left out as the underlying remote counts
+// each chunk and metadata object rather than each file.
 func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
 	do := f.base.Features().About
 	if do == nil {
 		return nil, errors.New("not supported by underlying remote")
 	}
-	return do(ctx)
+	usage, err := do(ctx)
+	if err != nil || usage == nil || usage.Objects == nil {
+		return usage, err
+	}
+	out := *usage
+	out.Objects = nil
+	return &out, nil
 }
 
 // UnWrap returns the Fs that this Fs is wrapping
@@ -37,7 +37,10 @@ func TestIntegration(t *testing.T) {
 			"SetTier",
 			"Metadata",
 			"SetMetadata",
+			"Tags",
+			"SetTags",
 		},
+		UnimplementableDirectoryMethods: []string{"ChangeToken"},
 		UnimplementableFsMethods: []string{
 			"PublicLink",
 			"OpenWriterAt",
@@ -47,6 +50,15 @@ func TestIntegration(t *testing.T) {
 			"UserInfo",
 			"Disconnect",
 			"ListP",
+			"SetTierBatch",
+			"ChangedPaths",
+			"DeleteObjects",
+			"ListDeleted",
+			"Undelete",
+			"SoftDelete",
+			"PurgeDeleted",
+			"ResumeChunkWriter",
+			"ResumeWriterAt",
 		},
 	}
 	if *fstest.RemoteName == "" {
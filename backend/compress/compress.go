@@ -270,8 +270,10 @@ func NewFs(ctx context.Context, name, rpath string, m configmap.Mapper) (fs.Fs,
 		DirModTimeUpdatesOnWrite: true,
 		PartialUploads:           true,
 	}).Fill(ctx, f).Mask(ctx, wrappedFs).WrapsFs(f, wrappedFs)
-	// We support reading MIME types no matter the wrapped fs
+	// We support reading and writing MIME types no matter the
+	// wrapped fs as they are stored in the metadata file
 	f.features.ReadMimeType = true
+	f.features.WriteMimeType = true
 	// We can only support putstream if we have serverside copy or move
 	if !operations.CanServerSideMove(wrappedFs) {
 		f.features.Disable("PutStream")
@@ -533,6 +535,29 @@ func checkCompressAndType(in io.Reader, compressionMode int, modeHandler compres
 	return wrap(in), compressible, mime.String(), nil
 }
 
+// originalMimeType returns the mime type to store for src. A
+// content-type in the metadata is preferred, then the mime type
+// reported by the source, then the one detected from the contents,
+// falling back to a guess from the name if the contents weren't
+// recognised.
+func (f *Fs) originalMimeType(ctx context.Context, src fs.ObjectInfo, options []fs.OpenOption, detected string) string {
+	meta, err := fs.GetMetadataOptions(ctx, f, src, options)
+	if err != nil {
+		fs.Debugf(src, "Failed to read metadata for mime type: %v", err)
+	} else if mimeType := meta["content-type"]; mimeType != "" {
+		return mimeType
+	}
+	if do, ok := src.(fs.MimeTyper); ok {
+		if mimeType := do.MimeType(ctx); mimeType != "" && mimeType != "application/octet-stream" {
+			return mimeType
+		}
+	}
+	if detected != "" && detected != "application/octet-stream" {
+		return detected
+	}
+	return fs.MimeTypeFromName(src.Remote())
+}
+
 // verifyObjectHash verifies the Objects hash
 func (f *Fs) verifyObjectHash(ctx context.Context, o fs.Object, hasher *hash.MultiHasher, ht hash.Type) error {
 	srcHash := hasher.Sums()[ht]
@@ -685,6 +710,7 @@ func (f *Fs) putMetadata(ctx context.Context, meta *ObjectMetadata, src fs.Objec
 // data is compressible this parameter will be ignored.
 func (f *Fs) putWithCustomFunctions(ctx context.Context, in io.Reader, src fs.ObjectInfo, options []fs.OpenOption,
 	putData putFn, putMeta putFn, compressible bool, mimeType string) (*Object, error) {
+	mimeType = f.originalMimeType(ctx, src, options, mimeType)
 	// Put file then metadata
 	var dataObject fs.Object
 	var meta *ObjectMetadata
@@ -1257,10 +1283,14 @@ func (o *Object) Size() int64 {
 }
 
 // MimeType returns the MIME type of the file
+//
+// This is the mime type stored in the metadata when the file was
+// uploaded or "" if it couldn't be read so it is guessed from the name.
 func (o *Object) MimeType(ctx context.Context) string {
 	err := o.loadMetadataIfNotLoaded(ctx)
 	if err != nil {
-		return "error/error"
+		fs.Debugf(o, "Failed to read mime type: %v", err)
+		return ""
 	}
 	return o.meta.MimeType
 }
@@ -0,0 +1,42 @@
+package compress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOriginalMimeType(t *testing.T) {
+	ctx := context.Background()
+	f := &Fs{}
+	src := func(remote, mimeType string) *object.StaticObjectInfo {
+		return object.NewStaticObjectInfo(remote, time.Now(), 1, true, nil, nil).WithMimeType(mimeType)
+	}
+	for _, test := range []struct {
+		remote   string
+		srcType  string
+		detected string
+		want     string
+	}{
+		{"page.html", "text/html", "text/plain; charset=utf-8", "text/html"},
+		{"page.html", "", "text/html; charset=utf-8", "text/html; charset=utf-8"},
+		{"page.html", "application/octet-stream", "text/html; charset=utf-8", "text/html; charset=utf-8"},
+		{"data.txt", "", "application/octet-stream", "text/plain; charset=utf-8"},
+		{"data.txt", "", "", "text/plain; charset=utf-8"},
+		{"data", "", "application/octet-stream", "application/octet-stream"},
+	} {
+		got := f.originalMimeType(ctx, src(test.remote, test.srcType), nil, test.detected)
+		assert.Equal(t, test.want, got, "%+v", test)
+	}
+
+	// content-type in the metadata is used with --metadata
+	withMeta := src("page.html", "text/html").WithMetadata(fs.Metadata{"content-type": "application/xhtml+xml"})
+	assert.Equal(t, "text/html", f.originalMimeType(ctx, withMeta, nil, ""))
+	ctx, ci := fs.AddConfig(ctx)
+	ci.Metadata = true
+	assert.Equal(t, "application/xhtml+xml", f.originalMimeType(ctx, withMeta, nil, ""))
+}
@@ -26,9 +26,19 @@ var defaultOpt = fstests.Opt{
 		"PutStream",
 		"UserInfo",
 		"Disconnect",
+		"SetTierBatch",
+		"ChangedPaths",
+		"DeleteObjects",
+		"ListDeleted",
+		"Undelete",
+		"SoftDelete",
+		"PurgeDeleted",
+		"ResumeChunkWriter",
+		"ResumeWriterAt",
 	},
-	TiersToTest:                  []string{"STANDARD", "STANDARD_IA"},
-	UnimplementableObjectMethods: []string{},
+	TiersToTest:                     []string{"STANDARD", "STANDARD_IA"},
+	UnimplementableObjectMethods:    []string{"Tags", "SetTags"},
+	UnimplementableDirectoryMethods: []string{"ChangeToken"},
 }
 
 // TestIntegration runs integration tests against the remote
@@ -1145,6 +1145,21 @@ func (c *Cipher) DecryptedSize(size int64) (int64, error) {
 	return decryptedSize, nil
 }
 
+// DecryptedCapacity returns the size of the largest file which is at
+// most size bytes once encrypted
+func (c *Cipher) DecryptedCapacity(size int64) int64 {
+	size -= int64(fileHeaderSize)
+	if size <= 0 {
+		return 0
+	}
+	blocks, residue := size/blockSize, size%blockSize
+	capacity := blocks * blockDataSize
+	if residue > blockHeaderSize {
+		capacity += residue - blockHeaderSize
+	}
+	return capacity
+}
+
 // check interfaces
 var (
 	_ io.ReadCloser  = (*decrypter)(nil)
@@ -726,6 +726,31 @@ func TestDecryptedSize(t *testing.T) {
 	}
 }
 
+func TestDecryptedCapacity(t *testing.T) {
+	c, _ := newCipher(NameEncryptionStandard, "", "", true, nil)
+	for _, test := range []struct {
+		in       int64
+		expected int64
+	}{
+		{0, 0},
+		{32, 0},
+		{32 + 16, 0},
+		{32 + 16 + 1, 1},
+		{32 + 16 + 65536, 65536},
+		{32 + 16 + 65536 + 16, 65536},
+		{32 + 16 + 65536 + 16 + 10, 65536 + 10},
+		{1 << 40, 1099243257808},
+	} {
+		got := c.DecryptedCapacity(test.in)
+		assert.Equal(t, test.expected, got, fmt.Sprintf("Testing %d", test.in))
+		// The capacity must be the largest size which fits
+		if got > 0 {
+			assert.LessOrEqual(t, c.EncryptedSize(got), test.in)
+		}
+		assert.Greater(t, c.EncryptedSize(got+1), test.in)
+	}
+}
+
 func TestNoncePointer(t *testing.T) {
 	var x nonce
 	assert.Equal(t, (*[24]byte)(&x), x.pointer())
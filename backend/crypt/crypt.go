@@ -6,8 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rclone/rclone/fs"
@@ -172,6 +175,25 @@ Setting suffix to "none" will result in an empty suffix. This may be useful
 when the path length is critical.`,
 			Default:  ".bin",
 			Advanced: true,
+		}, {
+			Name: "name_index",
+			Help: `If set, keep an encrypted index of the file names in each directory.
+
+This stores an extra encrypted object called ".rclone-crypt-index" in
+each directory of the wrapped remote, mapping hashes of the file
+names, ignoring case and unicode normalization, to their encrypted
+names.
+
+It is used to find files whose names differ only in case or unicode
+normalization from the name asked for without listing the directory
+and decrypting every name in it, which is slow for huge directories.
+
+The index is updated whenever a file is written, copied, moved or
+deleted through this remote, which costs an extra read and write of
+the index each time. Changes made by other tools, or by rclone
+without this option, aren't indexed.`,
+			Default:  false,
+			Advanced: true,
 		}},
 	})
 }
@@ -314,6 +336,7 @@ type Options struct {
 	FilenameEncoding        string `config:"filename_encoding"`
 	Suffix                  string `config:"suffix"`
 	StrictNames             bool   `config:"strict_names"`
+	NameIndex               bool   `config:"name_index"`
 }
 
 // Fs represents a wrapped fs.Fs
@@ -325,6 +348,7 @@ type Fs struct {
 	opt      Options
 	features *fs.Features // optional features
 	cipher   *Cipher
+	indexMu  sync.Mutex // protects reading and writing the name index
 }
 
 // Name of the remote (as passed into NewFs)
@@ -350,6 +374,9 @@ func (f *Fs) String() string {
 // Encrypt an object file name to entries.
 func (f *Fs) add(entries *fs.DirEntries, obj fs.Object) error {
 	remote := obj.Remote()
+	if path.Base(remote) == indexName {
+		return nil
+	}
 	decryptedRemote, err := f.cipher.DecryptFileName(remote)
 	if err != nil {
 		if f.opt.StrictNames {
@@ -485,6 +512,9 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 // NewObject finds the Object at remote.
 func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
 	o, err := f.Fs.NewObject(ctx, f.cipher.EncryptFileName(remote))
+	if errors.Is(err, fs.ErrorObjectNotFound) && f.opt.NameIndex {
+		o, err = f.lookupIndex(ctx, remote)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -568,12 +598,20 @@ func (f *Fs) put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options [
 // will return the object and the error, otherwise will return
 // nil and the error
 func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
-	return f.put(ctx, in, src, options, f.Fs.Put)
+	o, err := f.put(ctx, in, src, options, f.Fs.Put)
+	if err == nil {
+		f.updateIndex(ctx, src.Remote(), true)
+	}
+	return o, err
 }
 
 // PutStream uploads to the remote path with the modTime given of indeterminate size
 func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
-	return f.put(ctx, in, src, options, f.Fs.Features().PutStream)
+	o, err := f.put(ctx, in, src, options, f.Fs.Features().PutStream)
+	if err == nil {
+		f.updateIndex(ctx, src.Remote(), true)
+	}
+	return o, err
 }
 
 // Hashes returns the supported hash sets.
@@ -623,6 +661,12 @@ func (f *Fs) DirSetModTime(ctx context.Context, dir string, modTime time.Time) e
 //
 // Return an error if it doesn't exist or isn't empty
 func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	if f.opt.NameIndex {
+		err := f.removeEmptyIndex(ctx, dir)
+		if err != nil {
+			return err
+		}
+	}
 	return f.Fs.Rmdir(ctx, f.cipher.EncryptDirName(dir))
 }
 
@@ -662,6 +706,7 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	if err != nil {
 		return nil, err
 	}
+	f.updateIndex(ctx, remote, true)
 	return f.newObject(oResult), nil
 }
 
@@ -683,10 +728,13 @@ func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	if !ok {
 		return nil, fs.ErrorCantMove
 	}
+	srcRemote := o.Remote()
 	oResult, err := do(ctx, o.Object, f.cipher.EncryptFileName(remote))
 	if err != nil {
 		return nil, err
 	}
+	o.f.updateIndex(ctx, srcRemote, false)
+	f.updateIndex(ctx, remote, true)
 	return f.newObject(oResult), nil
 }
 
@@ -728,6 +776,7 @@ func (f *Fs) PutUnchecked(ctx context.Context, in io.Reader, src fs.ObjectInfo,
 	if err != nil {
 		return nil, err
 	}
+	f.updateIndex(ctx, src.Remote(), true)
 	return f.newObject(o), nil
 }
 
@@ -744,12 +793,23 @@ func (f *Fs) CleanUp(ctx context.Context) error {
 }
 
 // About gets quota information from the Fs
+//
+// The free space is reduced by the encryption overhead so it is the
+// size of file which can be uploaded. The other values are the space
+// used in the underlying remote.
 func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
 	do := f.Fs.Features().About
 	if do == nil {
 		return nil, errors.New("not supported by underlying remote")
 	}
-	return do(ctx)
+	usage, err := do(ctx)
+	if err != nil || usage == nil || usage.Free == nil || f.opt.NoDataEncryption {
+		return usage, err
+	}
+	out := *usage
+	free := f.cipher.DecryptedCapacity(*usage.Free)
+	out.Free = &free
+	return &out, nil
 }
 
 // UnWrap returns the Fs that this Fs is wrapping
@@ -948,6 +1008,151 @@ rclone backend decode crypt: encryptedfile1 [encryptedfile2...]
 rclone rc backend/command command=decode fs=crypt: encryptedfile1 [encryptedfile2...]
 ` + "```",
 	},
+	{
+		Name:  "encode-stream",
+		Short: "Encrypt data from stdin to stdout.",
+		Long: `This reads data from stdin, encrypts it as it would be stored on the
+remote and writes it to stdout, so it can be used in pipelines with
+other tools.
+
+Usage example:
+
+` + "```console" + `
+tar cf - docs | rclone backend encode-stream crypt: > docs.tar.bin
+` + "```" + `
+
+This is only useful from the command line.`,
+	},
+	{
+		Name:  "decode-stream",
+		Short: "Decrypt data from stdin to stdout.",
+		Long: `This reads a file as stored on the remote from stdin, decrypts it and
+writes it to stdout. It returns an error if the data can't be
+decrypted.
+
+This is useful for recovering files fetched from the remote with
+other tools. The crypt remote doesn't need to wrap the remote the
+files came from for this, so it can be used with the connection string
+from ` + "`export-keys`" + ` without a config file.
+
+Usage examples:
+
+` + "```console" + `
+rclone backend decode-stream crypt: < encrypted-file > file
+aws s3 cp s3://bucket/encrypted-file - | rclone backend decode-stream ":crypt,remote=':memory:',password=XXX:" > file
+` + "```" + `
+
+This is only useful from the command line.`,
+	},
+	{
+		Name:  "export-keys",
+		Short: "Show the keys needed to decrypt the remote.",
+		Long: `This shows the passwords, obscured as they are in the config file,
+and the other options needed to decrypt the files in the remote, along
+with a connection string which can be used in place of the remote
+without a config file.
+
+As anyone with these can decrypt the remote it must be run with the
+` + "`-o confirm`" + ` option. Store the output somewhere safe, for example
+with your disaster recovery plan.
+
+Usage example:
+
+` + "```console" + `
+rclone backend export-keys crypt: -o confirm
+` + "```" + `
+
+The connection string wraps an empty ` + "`:memory:`" + ` remote, which is all
+the ` + "`encode-stream`" + ` and ` + "`decode-stream`" + ` commands need. Change
+` + "`remote=`" + ` to where the encrypted files are to read them, for example
+
+` + "```console" + `
+rclone ls ":crypt,remote='/mnt/backup/encrypted',password=XXX:"
+` + "```",
+	},
+}
+
+// cipherOptions are the options which must match for the cipher to
+// decrypt the remote, in the order they are shown in a connection
+// string
+var cipherOptions = []string{
+	"password",
+	"password2",
+	"filename_encryption",
+	"directory_name_encryption",
+	"filename_encoding",
+	"suffix",
+	"no_data_encryption",
+}
+
+// exportKeys returns the cipher options as they would be in the
+// config file along with an on the fly connection string using them.
+//
+// Empty options are left out.
+func (f *Fs) exportKeys() map[string]string {
+	keys := map[string]string{
+		"password":                  f.opt.Password,
+		"password2":                 f.opt.Password2,
+		"filename_encryption":       f.opt.FilenameEncryption,
+		"directory_name_encryption": strconv.FormatBool(f.opt.DirectoryNameEncryption),
+		"filename_encoding":         f.opt.FilenameEncoding,
+		"suffix":                    f.opt.Suffix,
+		"no_data_encryption":        strconv.FormatBool(f.opt.NoDataEncryption),
+	}
+	for key, value := range keys {
+		if value == "" {
+			delete(keys, key)
+		}
+	}
+	var connection strings.Builder
+	connection.WriteString(":crypt")
+	add := func(key, value string) {
+		if strings.ContainsAny(value, `,:'"`) {
+			value = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+		}
+		fmt.Fprintf(&connection, ",%s=%s", key, value)
+	}
+	// Wrap an empty remote which is enough for the stream commands
+	add("remote", ":memory:")
+	for _, key := range cipherOptions {
+		if value, ok := keys[key]; ok {
+			add(key, value)
+		}
+	}
+	connection.WriteString(":")
+	keys["connection_string"] = connection.String()
+	return keys
+}
+
+// encodeStream encrypts in to out as it would be stored on the remote
+func (f *Fs) encodeStream(in io.Reader, out io.Writer) error {
+	if !f.opt.NoDataEncryption {
+		var err error
+		in, err = f.cipher.EncryptData(in)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt: %w", err)
+		}
+	}
+	_, err := io.Copy(out, in)
+	return err
+}
+
+// decodeStream decrypts in, as stored on the remote, to out
+func (f *Fs) decodeStream(in io.Reader, out io.Writer) (err error) {
+	if f.opt.NoDataEncryption {
+		_, err = io.Copy(out, in)
+		return err
+	}
+	decrypted, err := f.cipher.DecryptData(io.NopCloser(in))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt: %w", err)
+	}
+	defer fs.CheckClose(decrypted, &err)
+	_, err = io.Copy(out, decrypted)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return nil
 }
 
 // Command the backend to run a named command
@@ -978,6 +1183,15 @@ func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[str
 			out = append(out, encryptedFileName)
 		}
 		return out, nil
+	case "encode-stream":
+		return nil, f.encodeStream(os.Stdin, os.Stdout)
+	case "decode-stream":
+		return nil, f.decodeStream(os.Stdin, os.Stdout)
+	case "export-keys":
+		if _, ok := opt["confirm"]; !ok {
+			return nil, errors.New("this shows the keys to decrypt the remote - add -o confirm to show them")
+		}
+		return f.exportKeys(), nil
 	default:
 		return nil, fs.ErrorCommandNotFound
 	}
@@ -1093,6 +1307,19 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		return o.Object, o.Object.Update(ctx, in, src, options...)
 	}
 	_, err := o.f.put(ctx, in, src, options, update)
+	if err == nil {
+		o.f.updateIndex(ctx, o.Remote(), true)
+	}
+	return err
+}
+
+// Remove the object
+func (o *Object) Remove(ctx context.Context) error {
+	remote := o.Remote()
+	err := o.Object.Remove(ctx)
+	if err == nil {
+		o.f.updateIndex(ctx, remote, false)
+	}
 	return err
 }
 
@@ -6,6 +6,7 @@ import (
 	"crypto/md5"
 	"fmt"
 	"io"
+	"path"
 	"testing"
 	"time"
 
@@ -119,9 +120,93 @@ func testComputeHash(t *testing.T, f *Fs) {
 	assert.Equal(t, remoteObjHash, computedHash)
 }
 
+// test the encode-stream and decode-stream commands and that the
+// keys from export-keys can decrypt the data
+func testStreams(t *testing.T, f *Fs) {
+	ctx := context.Background()
+	contents := random.String(100)
+	var encrypted bytes.Buffer
+	require.NoError(t, f.encodeStream(bytes.NewBufferString(contents), &encrypted))
+	if !f.opt.NoDataEncryption {
+		assert.NotEqual(t, contents, encrypted.String())
+	}
+
+	var decrypted bytes.Buffer
+	require.NoError(t, f.decodeStream(bytes.NewReader(encrypted.Bytes()), &decrypted))
+	assert.Equal(t, contents, decrypted.String())
+
+	if !f.opt.NoDataEncryption {
+		err := f.decodeStream(bytes.NewBufferString("potato"), io.Discard)
+		assert.ErrorContains(t, err, "failed to decrypt")
+	}
+
+	_, err := f.Command(ctx, "export-keys", nil, nil)
+	assert.ErrorContains(t, err, "-o confirm")
+	out, err := f.Command(ctx, "export-keys", nil, map[string]string{"confirm": ""})
+	require.NoError(t, err)
+	keys := out.(map[string]string)
+	assert.Equal(t, f.opt.Password, keys["password"])
+
+	exported, err := fs.NewFs(ctx, keys["connection_string"])
+	require.NoError(t, err)
+	decrypted.Reset()
+	require.NoError(t, exported.(*Fs).decodeStream(bytes.NewReader(encrypted.Bytes()), &decrypted))
+	assert.Equal(t, contents, decrypted.String())
+	assert.Equal(t, f.EncryptFileName("potato/file.txt"), exported.(*Fs).EncryptFileName("potato/file.txt"))
+}
+
+// test files can be found through the name index
+func testNameIndex(t *testing.T, f *Fs) {
+	if !f.opt.NameIndex {
+		t.Skip("name_index not set")
+	}
+	ctx := context.Background()
+	dir := "name-index"
+	indexRemote := path.Join(f.cipher.EncryptDirName(dir), indexName)
+
+	contents := "contents"
+	src := object.NewStaticObjectInfo(dir+"/Caf\u00e9.txt", time.Now(), int64(len(contents)), true, nil, nil)
+	obj, err := f.Put(ctx, bytes.NewBufferString(contents), src)
+	require.NoError(t, err)
+	_, err = f.Fs.NewObject(ctx, indexRemote)
+	require.NoError(t, err)
+
+	// Differs in case and normalization
+	found, err := f.NewObject(ctx, dir+"/cafe\u0301.TXT")
+	require.NoError(t, err)
+	assert.Equal(t, obj.Remote(), found.Remote())
+	_, err = f.NewObject(ctx, dir+"/potato.txt")
+	assert.ErrorIs(t, err, fs.ErrorObjectNotFound)
+
+	// The index isn't listed
+	entries, err := f.List(ctx, dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, obj.Remote(), entries[0].Remote())
+
+	// Moving updates the index
+	moved, err := f.Move(ctx, obj, dir+"/Moved.txt")
+	if err == fs.ErrorCantMove {
+		t.Skip("can't test move")
+	}
+	require.NoError(t, err)
+	_, err = f.NewObject(ctx, dir+"/cafe\u0301.TXT")
+	assert.ErrorIs(t, err, fs.ErrorObjectNotFound)
+	_, err = f.NewObject(ctx, dir+"/MOVED.TXT")
+	require.NoError(t, err)
+
+	// Removing the last file removes the index
+	require.NoError(t, moved.Remove(ctx))
+	_, err = f.Fs.NewObject(ctx, indexRemote)
+	assert.ErrorIs(t, err, fs.ErrorObjectNotFound)
+	require.NoError(t, f.Rmdir(ctx, dir))
+}
+
 // InternalTest is called by fstests.Run to extra tests
 func (f *Fs) InternalTest(t *testing.T) {
 	t.Run("ObjectInfo", func(t *testing.T) { testObjectInfo(t, f, false) })
 	t.Run("ObjectInfoWrap", func(t *testing.T) { testObjectInfo(t, f, true) })
 	t.Run("ComputeHash", func(t *testing.T) { testComputeHash(t, f) })
+	t.Run("Streams", func(t *testing.T) { testStreams(t, f) })
+	t.Run("NameIndex", func(t *testing.T) { testNameIndex(t, f) })
 }
@@ -10,7 +10,8 @@ import (
 	"github.com/rclone/rclone/backend/crypt"
 	_ "github.com/rclone/rclone/backend/drive" // for integration tests
 	_ "github.com/rclone/rclone/backend/local"
-	_ "github.com/rclone/rclone/backend/swift" // for integration tests
+	_ "github.com/rclone/rclone/backend/memory" // for export-keys
+	_ "github.com/rclone/rclone/backend/swift"  // for integration tests
 	"github.com/rclone/rclone/fs/config/obscure"
 	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/fstest/fstests"
@@ -22,10 +23,11 @@ func TestIntegration(t *testing.T) {
 		t.Skip("Skipping as -remote not set")
 	}
 	fstests.Run(t, &fstests.Opt{
-		RemoteName:                   *fstest.RemoteName,
-		NilObject:                    (*crypt.Object)(nil),
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenChunkWriter"},
-		UnimplementableObjectMethods: []string{"MimeType"},
+		RemoteName:                      *fstest.RemoteName,
+		NilObject:                       (*crypt.Object)(nil),
+		UnimplementableFsMethods:        []string{"OpenWriterAt", "OpenChunkWriter", "SetTierBatch", "ChangedPaths", "DeleteObjects", "ListDeleted", "Undelete", "SoftDelete", "PurgeDeleted", "ResumeChunkWriter", "ResumeWriterAt"},
+		UnimplementableObjectMethods:    []string{"MimeType", "Tags", "SetTags"},
+		UnimplementableDirectoryMethods: []string{"ChangeToken"},
 	})
 }
 
@@ -45,9 +47,10 @@ func TestStandardBase32(t *testing.T) {
 			{Name: name, Key: "password", Value: obscure.MustObscure("potato")},
 			{Name: name, Key: "filename_encryption", Value: "standard"},
 		},
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenChunkWriter"},
-		UnimplementableObjectMethods: []string{"MimeType"},
-		QuickTestOK:                  true,
+		UnimplementableFsMethods:        []string{"OpenWriterAt", "OpenChunkWriter", "SetTierBatch", "ChangedPaths", "DeleteObjects", "ListDeleted", "Undelete", "SoftDelete", "PurgeDeleted", "ResumeChunkWriter", "ResumeWriterAt"},
+		UnimplementableObjectMethods:    []string{"MimeType", "Tags", "SetTags"},
+		UnimplementableDirectoryMethods: []string{"ChangeToken"},
+		QuickTestOK:                     true,
 	})
 }
 
@@ -67,9 +70,10 @@ func TestStandardBase64(t *testing.T) {
 			{Name: name, Key: "filename_encryption", Value: "standard"},
 			{Name: name, Key: "filename_encoding", Value: "base64"},
 		},
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenChunkWriter"},
-		UnimplementableObjectMethods: []string{"MimeType"},
-		QuickTestOK:                  true,
+		UnimplementableFsMethods:        []string{"OpenWriterAt", "OpenChunkWriter", "SetTierBatch", "ChangedPaths", "DeleteObjects", "ListDeleted", "Undelete", "SoftDelete", "PurgeDeleted", "ResumeChunkWriter", "ResumeWriterAt"},
+		UnimplementableObjectMethods:    []string{"MimeType", "Tags", "SetTags"},
+		UnimplementableDirectoryMethods: []string{"ChangeToken"},
+		QuickTestOK:                     true,
 	})
 }
 
@@ -89,9 +93,10 @@ func TestStandardBase32768(t *testing.T) {
 			{Name: name, Key: "filename_encryption", Value: "standard"},
 			{Name: name, Key: "filename_encoding", Value: "base32768"},
 		},
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenChunkWriter"},
-		UnimplementableObjectMethods: []string{"MimeType"},
-		QuickTestOK:                  true,
+		UnimplementableFsMethods:        []string{"OpenWriterAt", "OpenChunkWriter", "SetTierBatch", "ChangedPaths", "DeleteObjects", "ListDeleted", "Undelete", "SoftDelete", "PurgeDeleted", "ResumeChunkWriter", "ResumeWriterAt"},
+		UnimplementableObjectMethods:    []string{"MimeType", "Tags", "SetTags"},
+		UnimplementableDirectoryMethods: []string{"ChangeToken"},
+		QuickTestOK:                     true,
 	})
 }
 
@@ -111,9 +116,34 @@ func TestOff(t *testing.T) {
 			{Name: name, Key: "password", Value: obscure.MustObscure("potato2")},
 			{Name: name, Key: "filename_encryption", Value: "off"},
 		},
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenChunkWriter"},
-		UnimplementableObjectMethods: []string{"MimeType"},
-		QuickTestOK:                  true,
+		UnimplementableFsMethods:        []string{"OpenWriterAt", "OpenChunkWriter", "SetTierBatch", "ChangedPaths", "DeleteObjects", "ListDeleted", "Undelete", "SoftDelete", "PurgeDeleted", "ResumeChunkWriter", "ResumeWriterAt"},
+		UnimplementableObjectMethods:    []string{"MimeType", "Tags", "SetTags"},
+		UnimplementableDirectoryMethods: []string{"ChangeToken"},
+		QuickTestOK:                     true,
+	})
+}
+
+// TestNameIndex runs integration tests against the remote with the
+// name index enabled
+func TestNameIndex(t *testing.T) {
+	if *fstest.RemoteName != "" {
+		t.Skip("Skipping as -remote set")
+	}
+	tempdir := filepath.Join(os.TempDir(), "rclone-crypt-test-name-index")
+	name := "TestCryptNameIndex"
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: name + ":",
+		NilObject:  (*crypt.Object)(nil),
+		ExtraConfig: []fstests.ExtraConfigItem{
+			{Name: name, Key: "type", Value: "crypt"},
+			{Name: name, Key: "remote", Value: tempdir},
+			{Name: name, Key: "password", Value: obscure.MustObscure("potato")},
+			{Name: name, Key: "name_index", Value: "true"},
+		},
+		UnimplementableFsMethods:        []string{"OpenWriterAt", "OpenChunkWriter", "SetTierBatch", "ChangedPaths", "DeleteObjects", "ListDeleted", "Undelete", "SoftDelete", "PurgeDeleted", "ResumeChunkWriter", "ResumeWriterAt"},
+		UnimplementableObjectMethods:    []string{"MimeType", "Tags", "SetTags"},
+		UnimplementableDirectoryMethods: []string{"ChangeToken"},
+		QuickTestOK:                     true,
 	})
 }
 
@@ -136,10 +166,11 @@ func TestObfuscate(t *testing.T) {
 			{Name: name, Key: "password", Value: obscure.MustObscure("potato2")},
 			{Name: name, Key: "filename_encryption", Value: "obfuscate"},
 		},
-		SkipBadWindowsCharacters:     true,
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenChunkWriter"},
-		UnimplementableObjectMethods: []string{"MimeType"},
-		QuickTestOK:                  true,
+		SkipBadWindowsCharacters:        true,
+		UnimplementableFsMethods:        []string{"OpenWriterAt", "OpenChunkWriter", "SetTierBatch", "ChangedPaths", "DeleteObjects", "ListDeleted", "Undelete", "SoftDelete", "PurgeDeleted", "ResumeChunkWriter", "ResumeWriterAt"},
+		UnimplementableObjectMethods:    []string{"MimeType", "Tags", "SetTags"},
+		UnimplementableDirectoryMethods: []string{"ChangeToken"},
+		QuickTestOK:                     true,
 	})
 }
 
@@ -163,9 +194,10 @@ func TestNoDataObfuscate(t *testing.T) {
 			{Name: name, Key: "filename_encryption", Value: "obfuscate"},
 			{Name: name, Key: "no_data_encryption", Value: "true"},
 		},
-		SkipBadWindowsCharacters:     true,
-		UnimplementableFsMethods:     []string{"OpenWriterAt", "OpenChunkWriter"},
-		UnimplementableObjectMethods: []string{"MimeType"},
-		QuickTestOK:                  true,
+		SkipBadWindowsCharacters:        true,
+		UnimplementableFsMethods:        []string{"OpenWriterAt", "OpenChunkWriter", "SetTierBatch", "ChangedPaths", "DeleteObjects", "ListDeleted", "Undelete", "SoftDelete", "PurgeDeleted", "ResumeChunkWriter", "ResumeWriterAt"},
+		UnimplementableObjectMethods:    []string{"MimeType", "Tags", "SetTags"},
+		UnimplementableDirectoryMethods: []string{"ChangeToken"},
+		QuickTestOK:                     true,
 	})
 }
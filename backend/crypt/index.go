@@ -0,0 +1,187 @@
+package crypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/object"
+	"golang.org/x/text/unicode/norm"
+)
+
+// indexName is the name of the object in each directory of the
+// wrapped remote holding the name index if name_index is set.
+//
+// This can't clash with an encrypted or obfuscated name as it starts
+// with a "." and doesn't end with the suffix.
+const indexName = ".rclone-crypt-index"
+
+// nameIndex maps the hash of a folded plaintext leaf name to the
+// encrypted leaf names with that folded name.
+type nameIndex map[string][]string
+
+// indexKey returns the key in the name index for the plaintext leaf.
+//
+// Leaf names are folded in the same way as --ignore-case-sync and
+// unicode normalization so lookups find files stored with a name
+// which differs only in case or normalization.
+func indexKey(leaf string) string {
+	folded := strings.ToLower(norm.NFC.String(leaf))
+	sum := sha256.Sum256([]byte(folded))
+	return hex.EncodeToString(sum[:16])
+}
+
+// splitRemote returns the encrypted directory and encrypted leaf of
+// the plaintext remote along with the key in the name index for it.
+func (f *Fs) splitRemote(remote string) (encDir, encLeaf, key string) {
+	encDir, encLeaf = path.Split(f.cipher.EncryptFileName(remote))
+	return strings.TrimSuffix(encDir, "/"), encLeaf, indexKey(path.Base(remote))
+}
+
+// readIndex reads the name index in encDir of the wrapped remote
+// returning an empty index if there isn't one yet.
+func (f *Fs) readIndex(ctx context.Context, encDir string) (index nameIndex, err error) {
+	index = nameIndex{}
+	o, err := f.Fs.NewObject(ctx, path.Join(encDir, indexName))
+	if errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorDirNotFound) {
+		return index, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to find name index: %w", err)
+	}
+	in, err := o.Open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open name index: %w", err)
+	}
+	rc, err := f.cipher.DecryptData(in)
+	if err != nil {
+		_ = in.Close()
+		return nil, fmt.Errorf("failed to decrypt name index: %w", err)
+	}
+	defer fs.CheckClose(rc, &err)
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read name index: %w", err)
+	}
+	err = json.Unmarshal(data, &index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse name index: %w", err)
+	}
+	return index, nil
+}
+
+// writeIndex writes the name index to encDir of the wrapped remote,
+// removing it if it is empty.
+func (f *Fs) writeIndex(ctx context.Context, encDir string, index nameIndex) error {
+	remote := path.Join(encDir, indexName)
+	if len(index) == 0 {
+		o, err := f.Fs.NewObject(ctx, remote)
+		if err != nil {
+			return nil
+		}
+		return o.Remove(ctx)
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal name index: %w", err)
+	}
+	in, err := f.cipher.EncryptData(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt name index: %w", err)
+	}
+	src := object.NewStaticObjectInfo(remote, time.Now(), f.cipher.EncryptedSize(int64(len(data))), true, nil, f.Fs)
+	_, err = f.Fs.Put(ctx, in, src)
+	if err != nil {
+		return fmt.Errorf("failed to write name index: %w", err)
+	}
+	return nil
+}
+
+// updateIndex adds remote to or removes it from the name index of
+// its directory.
+//
+// Errors are logged rather than returned as the index is only used
+// to speed up lookups.
+func (f *Fs) updateIndex(ctx context.Context, remote string, add bool) {
+	if !f.opt.NameIndex {
+		return
+	}
+	encDir, encLeaf, key := f.splitRemote(remote)
+	f.indexMu.Lock()
+	defer f.indexMu.Unlock()
+	index, err := f.readIndex(ctx, encDir)
+	if err != nil {
+		fs.Errorf(remote, "Failed to update name index: %v", err)
+		return
+	}
+	names := index[key]
+	found := slices.Contains(names, encLeaf)
+	switch {
+	case add && !found:
+		index[key] = append(names, encLeaf)
+	case !add && found:
+		names = slices.DeleteFunc(names, func(name string) bool { return name == encLeaf })
+		if len(names) == 0 {
+			delete(index, key)
+		} else {
+			index[key] = names
+		}
+	default:
+		return
+	}
+	err = f.writeIndex(ctx, encDir, index)
+	if err != nil {
+		fs.Errorf(remote, "Failed to update name index: %v", err)
+	}
+}
+
+// lookupIndex finds an object whose name differs from remote only in
+// case or unicode normalization using the name index, so that the
+// directory doesn't need to be listed and every name decrypted.
+func (f *Fs) lookupIndex(ctx context.Context, remote string) (fs.Object, error) {
+	encDir, _, key := f.splitRemote(remote)
+	f.indexMu.Lock()
+	index, err := f.readIndex(ctx, encDir)
+	f.indexMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	for _, encLeaf := range index[key] {
+		o, err := f.Fs.NewObject(ctx, path.Join(encDir, encLeaf))
+		if err == nil {
+			return o, nil
+		}
+		fs.Debugf(remote, "Name index entry %q not found: %v", encLeaf, err)
+	}
+	return nil, fs.ErrorObjectNotFound
+}
+
+// removeEmptyIndex removes the name index from dir if none of the
+// objects in it exist any more so dir can be removed.
+func (f *Fs) removeEmptyIndex(ctx context.Context, dir string) error {
+	encDir := f.cipher.EncryptDirName(dir)
+	f.indexMu.Lock()
+	defer f.indexMu.Unlock()
+	index, err := f.readIndex(ctx, encDir)
+	if err != nil || len(index) == 0 {
+		return err
+	}
+	for _, names := range index {
+		for _, encLeaf := range names {
+			if _, err := f.Fs.NewObject(ctx, path.Join(encDir, encLeaf)); err == nil {
+				// Directory not empty - let Rmdir report it
+				return nil
+			}
+		}
+	}
+	return f.writeIndex(ctx, encDir, nameIndex{})
+}
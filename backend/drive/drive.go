@@ -198,6 +198,17 @@ func driveScopes(scopesString string) (scopes []string) {
 	return scopes
 }
 
+// Returns true if Google allows the device code flow for all the
+// scopes, which it only does for "drive.file" and "drive.appfolder"
+func driveScopesAllowDeviceCode(scopes []string) bool {
+	for _, scope := range scopes {
+		if scope != scopePrefix+"drive.file" && scope != scopePrefix+"drive.appfolder" {
+			return false
+		}
+	}
+	return len(scopes) > 0
+}
+
 // Returns true if one of the scopes was "drive.appfolder"
 func driveScopesContainsAppFolder(scopes []string) bool {
 	return slices.Contains(scopes, scopePrefix+"drive.appfolder")
@@ -233,6 +244,10 @@ func init() {
 			case "":
 				// Fill in the scopes
 				driveConfig.Scopes = driveScopes(opt.Scope)
+				driveConfig.DeviceAuthURL = ""
+				if driveScopesAllowDeviceCode(driveConfig.Scopes) {
+					driveConfig.DeviceAuthURL = google.Endpoint.DeviceAuthURL
+				}
 
 				// Set the root_folder_id if using drive.appfolder
 				if driveScopesContainsAppFolder(driveConfig.Scopes) {
@@ -437,6 +452,25 @@ commands (copy, sync, etc.), and with all other commands too.`,
 			Default:  "",
 			Help:     "Comma separated list of preferred formats for uploading Google docs.",
 			Advanced: true,
+		}, {
+			Name:    "import_mapping",
+			Default: "",
+			Help: `Comma separated list of extension:type rules for uploading Google docs.
+
+Each rule converts files with the extension to the Google document
+type given when uploading, as if the extension was in
+--drive-import-formats. The type is one of "document", "spreadsheet",
+"presentation" or "drawing" or a full "application/vnd.google-apps."
+MIME type.
+
+For example
+
+    docx:document,xlsx:spreadsheet,pptx:presentation,csv:spreadsheet
+
+Google docs converted on upload keep the modification time of the
+file they were made from, so used with --drive-export-formats files
+can be synced both ways between office files and Google docs.`,
+			Advanced: true,
 		}, {
 			Name:     "allow_import_name_change",
 			Default:  false,
@@ -529,6 +563,27 @@ does not have the right permission, Google will just ignore the flag.`,
 			Default:  false,
 			Help:     "Keep new head revision of each file forever.",
 			Advanced: true,
+		}, {
+			Name: "version_at",
+			Help: `Show files as they were at the specified time.
+
+The parameter should be a date, "2006-01-02", datetime "2006-01-02
+15:04:05" or a duration for that long ago, eg "100d" or "1h".
+
+Each file is shown with the revision which was current at that time
+and files created after it aren't shown. Google docs are shown as they
+are now and files deleted since aren't shown.
+
+Note that drive only keeps old revisions of files for 30 days unless
+they are set to be kept forever, see --drive-keep-revision-forever.
+
+Note that when using this no file write operations are permitted,
+so you can't upload files or delete them.
+
+See [the time option docs](/docs/#time-options) for valid formats.
+`,
+			Default:  fs.Time{},
+			Advanced: true,
 		}, {
 			Name:    "size_as_quota",
 			Default: false,
@@ -788,6 +843,7 @@ type Options struct {
 	Extensions                string               `config:"formats"`
 	ExportExtensions          string               `config:"export_formats"`
 	ImportExtensions          string               `config:"import_formats"`
+	ImportMapping             string               `config:"import_mapping"`
 	AllowImportNameChange     bool                 `config:"allow_import_name_change"`
 	UseCreatedDate            bool                 `config:"use_created_date"`
 	UseSharedDate             bool                 `config:"use_shared_date"`
@@ -797,6 +853,7 @@ type Options struct {
 	ChunkSize                 fs.SizeSuffix        `config:"chunk_size"`
 	AcknowledgeAbuse          bool                 `config:"acknowledge_abuse"`
 	KeepRevisionForever       bool                 `config:"keep_revision_forever"`
+	VersionAt                 fs.Time              `config:"version_at"`
 	SizeAsQuota               bool                 `config:"size_as_quota"`
 	V2DownloadMinSize         fs.SizeSuffix        `config:"v2_download_min_size"`
 	PacerMinSleep             fs.Duration          `config:"pacer_min_sleep"`
@@ -832,6 +889,7 @@ type Fs struct {
 	pacer            *fs.Pacer          // To pace the API calls
 	exportExtensions []string           // preferred extensions to download docs
 	importMimeTypes  []string           // MIME types to convert to docs
+	importMapping    map[string]string  // MIME types to convert to docs with the doc type to use
 	isTeamDrive      bool               // true if this is a team drive
 	m                configmap.Mapper
 	grouping         int32                        // number of IDs to search at once in ListR - read with atomic
@@ -930,9 +988,13 @@ func (f *Fs) shouldRetry(ctx context.Context, err error) (bool, error) {
 			} else if f.opt.StopOnDownloadLimit && reason == "downloadQuotaExceeded" {
 				fs.Errorf(f, "Received download limit error: %v", err)
 				return false, fserrors.FatalError(err)
-			} else if f.opt.StopOnUploadLimit && (reason == "quotaExceeded" || reason == "storageQuotaExceeded") {
-				fs.Errorf(f, "Received upload limit error: %v", err)
-				return false, fserrors.FatalError(err)
+			} else if reason == "quotaExceeded" || reason == "storageQuotaExceeded" {
+				err = fserrors.QuotaError(err)
+				if f.opt.StopOnUploadLimit {
+					fs.Errorf(f, "Received upload limit error: %v", err)
+					return false, fserrors.FatalError(err)
+				}
+				return false, err
 			} else if f.opt.StopOnUploadLimit && reason == "teamDriveFileLimitExceeded" {
 				fs.Errorf(f, "Received Shared Drive file limit error: %v", err)
 				return false, fserrors.FatalError(err)
@@ -1070,10 +1132,14 @@ func (f *Fs) list(ctx context.Context, dirIDs []string, title string, directorie
 			term := fmt.Sprintf("(modifiedTime %s '%s' or mimeType = '%s')", op, timeStr, driveFolderType)
 			query = append(query, term)
 		}
-		queryByTime(">=", fi.ModTimeFrom)
-		queryByTime("<=", fi.ModTimeTo)
+		from, to := fi.ModTimeWindow(ctx)
+		queryByTime(">=", from)
+		queryByTime("<=", to)
 	}
 
+	// Constrain query with any terms for this listing
+	query = append(query, listTerms(ctx)...)
+
 	list := f.svc.Files.List()
 	queryString := strings.Join(query, " and ")
 	if queryString != "" {
@@ -1241,6 +1307,41 @@ func parseExtensions(extensionsIn ...string) (extensions, mimeTypes []string, er
 	return
 }
 
+// parseImportMapping parses a list of comma separated
+// extension:type rules into a map of MIME type to the Google document
+// MIME type to import it as
+func parseImportMapping(mappingIn string) (mapping map[string]string, err error) {
+	for rule := range strings.SplitSeq(mappingIn, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		extension, docType, ok := strings.Cut(rule, ":")
+		if !ok {
+			return nil, fmt.Errorf("import mapping %q should be extension:type", rule)
+		}
+		_, mimeTypes, err := parseExtensions(extension)
+		if err != nil {
+			return nil, err
+		}
+		if len(mimeTypes) != 1 {
+			return nil, fmt.Errorf("import mapping %q should have exactly one extension", rule)
+		}
+		docType = strings.ToLower(strings.TrimSpace(docType))
+		if !strings.Contains(docType, "/") {
+			docType = "application/vnd.google-apps." + docType
+		}
+		if !isInternalMimeType(docType) || docType == driveFolderType {
+			return nil, fmt.Errorf("import mapping %q should convert to a Google document type", rule)
+		}
+		if mapping == nil {
+			mapping = make(map[string]string)
+		}
+		mapping[fixMimeType(mimeTypes[0])] = docType
+	}
+	return mapping, nil
+}
+
 // getClient makes an http client according to the options
 func getClient(ctx context.Context, opt *Options) *http.Client {
 	t := fshttp.NewTransportCustom(ctx, func(t *http.Transport) {
@@ -1382,6 +1483,7 @@ func newFs(ctx context.Context, name, path string, m configmap.Mapper) (*Fs, err
 		CanHaveEmptyDirectories:  true,
 		ServerSideAcrossConfigs:  opt.ServerSideAcrossConfigs,
 		FilterAware:              true,
+		ListQuery:                true,
 		ReadMetadata:             true,
 		WriteMetadata:            true,
 		UserMetadata:             true,
@@ -1390,6 +1492,8 @@ func newFs(ctx context.Context, name, path string, m configmap.Mapper) (*Fs, err
 		WriteDirSetModTime:       true,
 		UserDirMetadata:          true,
 		DirModTimeUpdatesOnWrite: false, // FIXME need to check!
+		PublicLinkOptions:        true,
+		NativeTrash:              opt.UseTrash,
 	}).Fill(ctx, f)
 
 	// Create a new authorized Drive client.
@@ -1463,6 +1567,15 @@ func NewFs(ctx context.Context, name, path string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, err
 	}
+	f.importMapping, err = parseImportMapping(f.opt.ImportMapping)
+	if err != nil {
+		return nil, err
+	}
+	for mimeType := range f.importMapping {
+		if !slices.Contains(f.importMimeTypes, mimeType) {
+			f.importMimeTypes = append(f.importMimeTypes, mimeType)
+		}
+	}
 
 	// Find the current root
 	err = f.dirCache.FindRoot(ctx, false)
@@ -1536,6 +1649,9 @@ func (f *Fs) getFileFields(ctx context.Context) (fields googleapi.Field) {
 	if f.opt.SizeAsQuota {
 		fields += ",quotaBytesUsed"
 	}
+	if f.opt.VersionAt.IsSet() {
+		fields += ",headRevisionId"
+	}
 	if fs.GetConfig(ctx).Metadata {
 		fields += "," + metadataFields
 	}
@@ -1566,6 +1682,12 @@ func (f *Fs) newRegularObject(ctx context.Context, remote string, info *drive.Fi
 	if info.ResourceKey != "" {
 		o.resourceKey = &info.ResourceKey
 	}
+	if f.opt.VersionAt.IsSet() && info.MimeType != shortcutMimeTypeDangling {
+		err = f.pinToVersionAt(ctx, o, info)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return o, nil
 }
 
@@ -1653,6 +1775,8 @@ func (f *Fs) newObjectWithExportInfo(
 	switch {
 	case info.MimeType == driveFolderType:
 		return nil, fs.ErrorIsDir
+	case f.createdAfterVersionAt(info):
+		return nil, fs.ErrorObjectNotFound
 	case info.MimeType == shortcutMimeType:
 		// We can only get here if f.opt.SkipShortcuts is set
 		// and not from a listing. This is unlikely.
@@ -1941,6 +2065,13 @@ func (f *Fs) findExportFormat(ctx context.Context, item *drive.File) (extension,
 func (f *Fs) findImportFormat(ctx context.Context, mimeType string) string {
 	mimeType = fixMimeType(mimeType)
 	ifs := f.importFormats(ctx)
+	if docType, ok := f.importMapping[mimeType]; ok {
+		if slices.Contains(ifs[mimeType], docType) {
+			return docType
+		}
+		fs.Logf(f, "can't import %q as %q - drive supports %q", mimeType, docType, ifs[mimeType])
+		return ""
+	}
 	for _, mt := range f.importMimeTypes {
 		if mt == mimeType {
 			importMimeTypes := ifs[mimeType]
@@ -1991,7 +2122,8 @@ func (f *Fs) ListP(ctx context.Context, dir string, callback fs.ListRCallback) e
 	directoryID = actualID(directoryID)
 
 	var iErr error
-	_, err = f.list(ctx, []string{directoryID}, "", false, false, f.opt.TrashedOnly, false, func(item *drive.File) bool {
+	listCtx := f.withListTerms(ctx, dir, false)
+	_, err = f.list(listCtx, []string{directoryID}, "", false, false, f.opt.TrashedOnly, false, func(item *drive.File) bool {
 		entry, err := f.itemToDirEntry(ctx, path.Join(dir, item.Name), item)
 		if err != nil {
 			iErr = err
@@ -2081,7 +2213,7 @@ func (f *Fs) listRRunner(ctx context.Context, wg *sync.WaitGroup, in chan listRE
 		listRSlices{dirs, paths}.Sort()
 		var iErr error
 		foundItems := false
-		_, err := f.list(ctx, dirs, "", false, false, f.opt.TrashedOnly, false, func(item *drive.File) bool {
+		_, err := f.list(f.withListTerms(ctx, "", true), dirs, "", false, false, f.opt.TrashedOnly, false, func(item *drive.File) bool {
 			// shared with me items have no parents when at the root
 			if f.opt.SharedWithMe && len(item.Parents) == 0 && len(paths) == 1 && paths[0] == "" {
 				item.Parents = dirs
@@ -2405,6 +2537,8 @@ func (f *Fs) resolveShortcut(ctx context.Context, item *drive.File) (newItem *dr
 // (nil, nil) is returned.
 func (f *Fs) itemToDirEntry(ctx context.Context, remote string, item *drive.File) (entry fs.DirEntry, err error) {
 	switch {
+	case f.createdAfterVersionAt(item):
+		// ignore item which didn't exist at --drive-version-at
 	case item.MimeType == driveFolderType:
 		// cache the directory ID for later lookups
 		f.dirCache.Put(remote, item.Id)
@@ -2478,6 +2612,9 @@ func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, opt
 // This will create a duplicate if we upload a new file without
 // checking to see if there is one already - use Put() for that.
 func (f *Fs) PutUnchecked(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	if f.opt.VersionAt.IsSet() {
+		return nil, errNotWithVersionAt
+	}
 	remote := src.Remote()
 	size := src.Size()
 	modTime := src.ModTime(ctx)
@@ -2543,9 +2680,47 @@ func (f *Fs) PutUnchecked(ctx context.Context, in io.Reader, src fs.ObjectInfo,
 	if err != nil {
 		return nil, err
 	}
+	if isInternalMimeType(importMimeType) {
+		info, err = f.fixImportModTime(ctx, info, createInfo.ModifiedTime)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return f.newObjectWithInfo(ctx, remote, info)
 }
 
+// fixImportModTime makes sure a file converted to a Google document
+// on upload has the modification time it was uploaded with.
+//
+// Drive may set the modification time of the converted document to
+// the time of the conversion which would make sync upload it again.
+func (f *Fs) fixImportModTime(ctx context.Context, info *drive.File, modifiedTime string) (*drive.File, error) {
+	want, err := time.Parse(timeFormatIn, modifiedTime)
+	if err != nil {
+		return info, nil
+	}
+	got, err := time.Parse(timeFormatIn, info.ModifiedTime)
+	if err == nil && got.Equal(want.Truncate(time.Millisecond)) {
+		return info, nil
+	}
+	fs.Debugf(f, "Setting modification time of imported document %q to %v", info.Name, want)
+	updateInfo := &drive.File{
+		ModifiedTime: modifiedTime,
+	}
+	var newInfo *drive.File
+	err = f.pacer.Call(func() (bool, error) {
+		newInfo, err = f.svc.Files.Update(actualID(info.Id), updateInfo).
+			Fields(partialFields).
+			SupportsAllDrives(true).
+			Context(ctx).Do()
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set modification time of imported document: %w", err)
+	}
+	return newInfo, nil
+}
+
 // MergeDirs merges the contents of all the directories passed
 // in into the first one and rmdirs the other directories.
 func (f *Fs) MergeDirs(ctx context.Context, dirs []fs.Directory) error {
@@ -2604,6 +2779,9 @@ func (f *Fs) MergeDirs(ctx context.Context, dirs []fs.Directory) error {
 
 // Mkdir creates the container if it doesn't exist
 func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	_, err := f.dirCache.FindDir(ctx, dir, true)
 	return err
 }
@@ -2616,6 +2794,9 @@ func (f *Fs) Mkdir(ctx context.Context, dir string) error {
 //
 // It returns the directory that was created.
 func (f *Fs) MkdirMetadata(ctx context.Context, dir string, metadata fs.Metadata) (fs.Directory, error) {
+	if f.opt.VersionAt.IsSet() {
+		return nil, errNotWithVersionAt
+	}
 	var info *drive.File
 	dirID, err := f.dirCache.FindDir(ctx, dir, false)
 	if err == fs.ErrorDirNotFound {
@@ -2649,6 +2830,9 @@ func (f *Fs) MkdirMetadata(ctx context.Context, dir string, metadata fs.Metadata
 
 // DirSetModTime sets the directory modtime for dir
 func (f *Fs) DirSetModTime(ctx context.Context, dir string, modTime time.Time) error {
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	dirID, err := f.dirCache.FindDir(ctx, dir, false)
 	if err != nil {
 		return err
@@ -2686,6 +2870,9 @@ func (f *Fs) delete(ctx context.Context, id string, useTrash bool) error {
 // purgeCheck removes the dir directory, if check is set then it
 // refuses to do so if it has anything in
 func (f *Fs) purgeCheck(ctx context.Context, dir string, check bool) error {
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	root := path.Join(f.root, dir)
 	dc := f.dirCache
 	directoryID, err := dc.FindDir(ctx, dir, false)
@@ -2755,6 +2942,9 @@ func (f *Fs) Precision() time.Duration {
 //
 // If it isn't possible then return fs.ErrorCantCopy
 func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	if f.opt.VersionAt.IsSet() {
+		return nil, errNotWithVersionAt
+	}
 	var srcObj *baseObject
 	ext := ""
 	isDoc := false
@@ -2921,6 +3111,9 @@ func (f *Fs) cleanupTeamDrive(ctx context.Context, dir string, directoryID strin
 
 // CleanUp empties the trash
 func (f *Fs) CleanUp(ctx context.Context) error {
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	if f.isTeamDrive {
 		directoryID, err := f.dirCache.FindDir(ctx, "", false)
 		if err != nil {
@@ -3000,6 +3193,9 @@ func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
 //
 // If it isn't possible then return fs.ErrorCantMove
 func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	if f.opt.VersionAt.IsSet() {
+		return nil, errNotWithVersionAt
+	}
 	var srcObj *baseObject
 	ext := ""
 	switch src := src.(type) {
@@ -3080,11 +3276,22 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 		id = shortcutID(o.(fs.IDer).ID())
 	}
 
+	if unlink {
+		return "", f.unlinkPublic(ctx, id)
+	}
+
+	lo := fs.GetLinkOptions(ctx)
+	if lo.Password != "" {
+		return "", errors.New("drive doesn't support passwords on public links")
+	}
 	permission := &drive.Permission{
 		AllowFileDiscovery: false,
 		Role:               "reader",
 		Type:               "anyone",
 	}
+	if lo.Permission == fs.LinkPermissionEdit {
+		permission.Role = "writer"
+	}
 
 	err = f.pacer.Call(func() (bool, error) {
 		// TODO: On TeamDrives this might fail if lacking permissions to change ACLs.
@@ -3101,6 +3308,37 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 	return fmt.Sprintf("https://drive.google.com/open?id=%s", id), nil
 }
 
+// unlinkPublic removes the "anyone with the link" permissions from id
+func (f *Fs) unlinkPublic(ctx context.Context, id string) error {
+	var perms *drive.PermissionList
+	err := f.pacer.Call(func() (bool, error) {
+		var err error
+		perms, err = f.svc.Permissions.List(id).
+			Fields("permissions(id,type)").
+			SupportsAllDrives(true).
+			Context(ctx).Do()
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list permissions: %w", err)
+	}
+	for _, perm := range perms.Permissions {
+		if perm.Type != "anyone" {
+			continue
+		}
+		err = f.pacer.Call(func() (bool, error) {
+			err := f.svc.Permissions.Delete(id, perm.Id).
+				SupportsAllDrives(true).
+				Context(ctx).Do()
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove public permission: %w", err)
+		}
+	}
+	return nil
+}
+
 // DirMove moves src, srcRemote to this remote at dstRemote
 // using server-side move operations.
 //
@@ -3110,6 +3348,9 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 //
 // If destination exists then return fs.ErrorDirExists
 func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	srcFs, ok := src.(*Fs)
 	if !ok {
 		fs.Debugf(srcFs, "Can't move directory - not same remote type")
@@ -3533,6 +3774,88 @@ func (f *Fs) unTrashDir(ctx context.Context, dir string, recurse bool) (r unTras
 	return f.unTrash(ctx, dir, directoryID, true)
 }
 
+// ListDeleted calls fn for each file in the trash which was in dir
+// or one of its subdirectories.
+//
+// Google docs aren't listed as they can't be restored to a file of
+// known size.
+func (f *Fs) ListDeleted(ctx context.Context, dir string, fn func(fs.DeletedObject) error) error {
+	directoryID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		return err
+	}
+	return f.listDeleted(ctx, dir, directoryID, fn)
+}
+
+// listDeleted lists the trashed files in dir, directoryID and the
+// directories in it, whether they are trashed or not
+func (f *Fs) listDeleted(ctx context.Context, dir string, directoryID string, fn func(fs.DeletedObject) error) (err error) {
+	var fnErr error
+	_, err = f.list(ctx, []string{actualID(directoryID)}, "", false, false, false, true, func(item *drive.File) bool {
+		remote := path.Join(dir, item.Name)
+		if item.MimeType == driveFolderType {
+			if !isShortcutID(item.Id) {
+				fnErr = f.listDeleted(ctx, remote, item.Id, fn)
+			}
+			return fnErr != nil
+		}
+		if !item.Trashed || strings.HasPrefix(item.MimeType, "application/vnd.google-apps.") {
+			return false
+		}
+		modTime, err := time.Parse(timeFormatIn, item.ModifiedTime)
+		if err != nil {
+			fs.Debugf(remote, "Failed to read modification time: %v", err)
+		}
+		fnErr = fn(fs.DeletedObject{
+			Remote:  remote,
+			Size:    item.Size,
+			ModTime: modTime,
+			ID:      item.Id,
+		})
+		return fnErr != nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list directory: %w", err)
+	}
+	return fnErr
+}
+
+// Undelete restores a file from the trash into the directory it was
+// deleted from, re-creating the directory if it was deleted too.
+func (f *Fs) Undelete(ctx context.Context, deleted fs.DeletedObject) error {
+	if _, err := f.NewObject(ctx, deleted.Remote); err == nil {
+		return fs.ErrorCantUndelete
+	}
+	_, directoryID, err := f.dirCache.FindPath(ctx, deleted.Remote, true)
+	if err != nil {
+		return err
+	}
+	id := actualID(deleted.ID)
+	info, err := f.getFile(ctx, id, "parents")
+	if err != nil {
+		return fmt.Errorf("failed to read trashed file: %w", err)
+	}
+	update := drive.File{
+		ForceSendFields: []string{"Trashed"}, // necessary to set false value
+		Trashed:         false,
+	}
+	call := f.svc.Files.Update(id, &update).
+		SupportsAllDrives(true).
+		Fields("trashed").
+		Context(ctx)
+	if !slices.Contains(info.Parents, actualID(directoryID)) {
+		call.AddParents(actualID(directoryID)).RemoveParents(strings.Join(info.Parents, ","))
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		_, err := call.Do()
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore: %w", err)
+	}
+	return nil
+}
+
 // copy or move file with id to dest
 func (f *Fs) copyOrMoveID(ctx context.Context, operation string, id, dest string) (err error) {
 	info, err := f.getFile(ctx, id, f.getFileFields(ctx))
@@ -3936,6 +4259,29 @@ Third delete all orphaned files to the trash:
 ` + "```console" + `
 rclone backend rescue drive: -o delete
 ` + "```",
+}, {
+	Name:  "thumbnail",
+	Short: "Show thumbnail and preview links for files.",
+	Long: `This command shows links to the thumbnails and previews Google Drive
+makes for the files passed in, so applications can show them without
+downloading the files.
+
+Usage example:
+
+` + "```console" + `
+rclone backend thumbnail drive: path/to/photo.jpg path/to/video.mp4
+rclone backend thumbnail drive: path/to/photo.jpg -o size=512
+` + "```" + `
+
+The thumbnail links are scaled to be no bigger than size pixels in
+either dimension if the size option is given. Files without a
+thumbnail have an empty thumbnailLink.
+
+The thumbnail links are only valid for a few hours and may need the
+credentials of the remote to fetch if the file isn't shared publicly.`,
+	Opts: map[string]string{
+		"size": "Largest dimension of the thumbnail in pixels",
+	},
 }}
 
 // Command the backend to run a named command
@@ -4079,6 +4425,26 @@ func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[str
 			return nil, errors.New("syntax error: need 0 or 1 args or -o delete")
 		}
 		return nil, f.rescue(ctx, dirID, delete)
+	case "thumbnail":
+		if len(arg) == 0 {
+			return nil, errors.New("need at least 1 argument")
+		}
+		size := 0
+		if sizeString, ok := opt["size"]; ok {
+			size, err = strconv.Atoi(sizeString)
+			if err != nil || size <= 0 {
+				return nil, fmt.Errorf("bad size %q", sizeString)
+			}
+		}
+		infos := make([]thumbnailInfo, 0, len(arg))
+		for _, remote := range arg {
+			info, err := f.thumbnailInfo(ctx, remote, size)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read thumbnail of %q: %w", remote, err)
+			}
+			infos = append(infos, info)
+		}
+		return infos, nil
 	default:
 		return nil, fs.ErrorCommandNotFound
 	}
@@ -4189,6 +4555,9 @@ func (o *baseObject) ModTime(ctx context.Context) time.Time {
 
 // SetModTime sets the modification time of the drive fs object
 func (o *baseObject) SetModTime(ctx context.Context, modTime time.Time) error {
+	if o.fs.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	// New metadata
 	updateInfo := &drive.File{
 		ModifiedTime: modTime.Format(timeFormatOut),
@@ -4439,6 +4808,9 @@ func (o *baseObject) update(ctx context.Context, updateInfo *drive.File, uploadM
 //
 // The new object may have been created if an error is returned
 func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	if o.fs.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	// If o is a shortcut
 	if isShortcutID(o.id) {
 		// Delete it first
@@ -4493,6 +4865,9 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 }
 
 func (o *documentObject) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	if o.fs.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	srcMimeType := fs.MimeType(ctx, src)
 	importMimeType := ""
 	updateInfo := &drive.File{
@@ -4516,6 +4891,10 @@ func (o *documentObject) Update(ctx context.Context, in io.Reader, src fs.Object
 	if err != nil {
 		return err
 	}
+	info, err = o.fs.fixImportModTime(ctx, info, updateInfo.ModifiedTime)
+	if err != nil {
+		return err
+	}
 
 	remote := src.Remote()
 	remote = remote[:len(remote)-o.extLen]
@@ -4540,6 +4919,9 @@ func (o *linkObject) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo
 
 // Remove an object
 func (o *baseObject) Remove(ctx context.Context) error {
+	if o.fs.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
 	if len(o.parents) > 1 {
 		return errors.New("can't delete safely - has multiple parents")
 	}
@@ -4556,6 +4938,63 @@ func (o *baseObject) ID() string {
 	return o.id
 }
 
+// thumbnailLinkSize changes the size of the image a thumbnailLink
+// returns. These end in =s220 for the default 220 pixel image.
+func thumbnailLinkSize(link string, size int) string {
+	i := strings.LastIndex(link, "=s")
+	if i < 0 {
+		return link
+	}
+	if _, err := strconv.Atoi(link[i+2:]); err != nil {
+		return link
+	}
+	return link[:i] + "=s" + strconv.Itoa(size)
+}
+
+// thumbnailInfo is returned by the thumbnail backend command
+type thumbnailInfo struct {
+	Remote        string `json:"remote"`
+	ThumbnailLink string `json:"thumbnailLink"`
+	PreviewLink   string `json:"previewLink"`
+}
+
+// thumbnailInfo reads the thumbnail and preview links of the file at
+// remote
+func (f *Fs) thumbnailInfo(ctx context.Context, remote string, size int) (info thumbnailInfo, err error) {
+	info.Remote = remote
+	o, err := f.NewObject(ctx, remote)
+	if err != nil {
+		return info, err
+	}
+	file, err := f.getFile(ctx, actualID(o.(fs.IDer).ID()), "thumbnailLink,webViewLink")
+	if err != nil {
+		return info, err
+	}
+	info.ThumbnailLink = file.ThumbnailLink
+	if size > 0 {
+		info.ThumbnailLink = thumbnailLinkSize(info.ThumbnailLink, size)
+	}
+	info.PreviewLink = file.WebViewLink
+	return info, nil
+}
+
+// Thumbnail returns the thumbnail Google Drive made for the object
+// scaled to be no bigger than size pixels.
+func (o *baseObject) Thumbnail(ctx context.Context, size int) (in io.ReadCloser, mimeType string, err error) {
+	info, err := o.fs.getFile(ctx, actualID(o.id), "thumbnailLink")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read thumbnail link: %w", err)
+	}
+	if info.ThumbnailLink == "" {
+		return nil, "", fs.ErrorNotImplemented
+	}
+	_, res, err := o.httpResponse(ctx, thumbnailLinkSize(info.ThumbnailLink, size), "GET", nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read thumbnail: %w", err)
+	}
+	return res.Body, res.Header.Get("Content-Type"), nil
+}
+
 // ParentID returns the ID of the Object parent if known, or "" if not
 func (o *baseObject) ParentID() string {
 	if len(o.parents) > 0 {
@@ -4676,15 +5115,18 @@ var (
 	_ fs.DirSetModTimer  = (*Fs)(nil)
 	_ fs.MkdirMetadataer = (*Fs)(nil)
 	_ fs.Abouter         = (*Fs)(nil)
+	_ fs.Undeleter       = (*Fs)(nil)
 	_ fs.Object          = (*Object)(nil)
 	_ fs.MimeTyper       = (*Object)(nil)
 	_ fs.IDer            = (*Object)(nil)
 	_ fs.ParentIDer      = (*Object)(nil)
+	_ fs.Thumbnailer     = (*Object)(nil)
 	_ fs.Metadataer      = (*Object)(nil)
 	_ fs.Object          = (*documentObject)(nil)
 	_ fs.MimeTyper       = (*documentObject)(nil)
 	_ fs.IDer            = (*documentObject)(nil)
 	_ fs.ParentIDer      = (*documentObject)(nil)
+	_ fs.Thumbnailer     = (*documentObject)(nil)
 	_ fs.Object          = (*linkObject)(nil)
 	_ fs.MimeTyper       = (*linkObject)(nil)
 	_ fs.IDer            = (*linkObject)(nil)
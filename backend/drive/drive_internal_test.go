@@ -33,26 +33,29 @@ import (
 
 func TestDriveScopes(t *testing.T) {
 	for _, test := range []struct {
-		in       string
-		want     []string
-		wantFlag bool
+		in         string
+		want       []string
+		wantFlag   bool
+		wantDevice bool
 	}{
 		{"", []string{
 			"https://www.googleapis.com/auth/drive",
-		}, false},
+		}, false, false},
 		{" drive.file , drive.readonly", []string{
 			"https://www.googleapis.com/auth/drive.file",
 			"https://www.googleapis.com/auth/drive.readonly",
-		}, false},
+		}, false, false},
 		{" drive.file , drive.appfolder", []string{
 			"https://www.googleapis.com/auth/drive.file",
 			"https://www.googleapis.com/auth/drive.appfolder",
-		}, true},
+		}, true, true},
 	} {
 		got := driveScopes(test.in)
 		assert.Equal(t, test.want, got, test.in)
 		gotFlag := driveScopesContainsAppFolder(got)
 		assert.Equal(t, test.wantFlag, gotFlag, test.in)
+		gotDevice := driveScopesAllowDeviceCode(got)
+		assert.Equal(t, test.wantDevice, gotDevice, test.in)
 	}
 }
 
@@ -114,6 +117,48 @@ func TestInternalParseExtensions(t *testing.T) {
 	assert.Equal(t, []string{".docx", ".svg", ".xlsx"}, extensions)
 }
 
+func TestInternalParseImportMapping(t *testing.T) {
+	for _, test := range []struct {
+		in      string
+		want    map[string]string
+		wantErr string
+	}{
+		{"", nil, ""},
+		{"docx:document, XLSX:Spreadsheet,csv:application/vnd.google-apps.spreadsheet", map[string]string{
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document": "application/vnd.google-apps.document",
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":       "application/vnd.google-apps.spreadsheet",
+			"text/csv; charset=utf-8": "application/vnd.google-apps.spreadsheet",
+		}, ""},
+		{"docx", nil, `import mapping "docx" should be extension:type`},
+		{"potato:document", nil, `couldn't find MIME type for extension ".potato"`},
+		{"docx:text/plain", nil, `import mapping "docx:text/plain" should convert to a Google document type`},
+		{"docx:folder", nil, `import mapping "docx:folder" should convert to a Google document type`},
+	} {
+		got, gotErr := parseImportMapping(test.in)
+		if test.wantErr == "" {
+			assert.NoError(t, gotErr, test.in)
+		} else {
+			assert.EqualError(t, gotErr, test.wantErr, test.in)
+		}
+		assert.Equal(t, test.want, got, test.in)
+	}
+}
+
+func TestInternalFindImportFormat(t *testing.T) {
+	ctx := context.Background()
+	f := new(Fs)
+	_, f.importMimeTypes, _ = parseExtensions("docx")
+	assert.Equal(t, "application/vnd.google-apps.document", f.findImportFormat(ctx, "application/vnd.openxmlformats-officedocument.wordprocessingml.document"))
+	assert.Equal(t, "", f.findImportFormat(ctx, "text/csv"))
+
+	var err error
+	f.importMapping, err = parseImportMapping("csv:spreadsheet,txt:presentation")
+	require.NoError(t, err)
+	assert.Equal(t, "application/vnd.google-apps.spreadsheet", f.findImportFormat(ctx, "text/csv"))
+	// Not a conversion drive supports
+	assert.Equal(t, "", f.findImportFormat(ctx, "text/plain"))
+}
+
 func TestInternalFindExportFormat(t *testing.T) {
 	ctx := context.Background()
 	item := &drive.File{
@@ -191,6 +236,20 @@ func TestExtensionsForImportFormats(t *testing.T) {
 	}
 }
 
+func TestThumbnailLinkSize(t *testing.T) {
+	for _, test := range []struct {
+		link string
+		want string
+	}{
+		{"https://lh3.googleusercontent.com/abc=s220", "https://lh3.googleusercontent.com/abc=s512"},
+		{"https://lh3.googleusercontent.com/abc", "https://lh3.googleusercontent.com/abc"},
+		{"https://lh3.googleusercontent.com/a=sb", "https://lh3.googleusercontent.com/a=sb"},
+		{"", ""},
+	} {
+		assert.Equal(t, test.want, thumbnailLinkSize(test.link, 512), test.link)
+	}
+}
+
 func (f *Fs) InternalTestShouldRetry(t *testing.T) {
 	ctx := context.Background()
 	gatewayTimeout := googleapi.Error{
@@ -239,7 +298,7 @@ func (f *Fs) InternalTestShouldRetry(t *testing.T) {
 		Reason: "quotaExceeded",
 	}
 	generic403.Errors[0] = qEItem
-	expectedQuotaError := fserrors.FatalError(&generic403)
+	expectedQuotaError := fserrors.FatalError(fserrors.QuotaError(&generic403))
 	quotaExceededRetry, quotaExceededError := f.shouldRetry(ctx, &generic403)
 	assert.False(t, quotaExceededRetry)
 	assert.Equal(t, quotaExceededError, expectedQuotaError)
@@ -248,10 +307,11 @@ func (f *Fs) InternalTestShouldRetry(t *testing.T) {
 		Reason: "storageQuotaExceeded",
 	}
 	generic403.Errors[0] = sqEItem
-	expectedStorageQuotaError := fserrors.FatalError(&generic403)
+	expectedStorageQuotaError := fserrors.FatalError(fserrors.QuotaError(&generic403))
 	storageQuotaExceededRetry, storageQuotaExceededError := f.shouldRetry(ctx, &generic403)
 	assert.False(t, storageQuotaExceededRetry)
 	assert.Equal(t, storageQuotaExceededError, expectedStorageQuotaError)
+	assert.True(t, fserrors.IsQuotaError(storageQuotaExceededError))
 }
 
 func (f *Fs) InternalTestDocumentImport(t *testing.T) {
@@ -666,3 +726,38 @@ func (f *Fs) InternalTest(t *testing.T) {
 }
 
 var _ fstests.InternalTester = (*Fs)(nil)
+
+func TestInternalCreatedAfterVersionAt(t *testing.T) {
+	f := &Fs{}
+	item := &drive.File{Name: "file", CreatedTime: "2024-06-01T12:00:00.000Z"}
+	assert.False(t, f.createdAfterVersionAt(item))
+
+	f.opt.VersionAt = fs.Time(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, f.createdAfterVersionAt(item))
+	f.opt.VersionAt = fs.Time(time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC))
+	assert.False(t, f.createdAfterVersionAt(item))
+	item.CreatedTime = ""
+	assert.False(t, f.createdAfterVersionAt(item))
+}
+
+func TestInternalListTerms(t *testing.T) {
+	assert.Equal(t, "2024", driveNamePrefix("2024-06"))
+	assert.Equal(t, "report", driveNamePrefix("report.docx"))
+	assert.Equal(t, "héllo", driveNamePrefix("héllo"))
+	assert.Equal(t, "", driveNamePrefix(""))
+
+	f := &Fs{}
+	ctx := context.Background()
+	assert.Nil(t, listTerms(f.withListTerms(ctx, "logs", false)))
+
+	ctx, fi := filter.AddConfig(ctx)
+	require.NoError(t, fi.AddRule("+ /logs/2024-*"))
+	require.NoError(t, fi.AddRule("- **"))
+	ctx = filter.SetUseFilter(ctx, true)
+	assert.Equal(t, []string{"name contains '2024'"}, listTerms(f.withListTerms(ctx, "logs", false)))
+	assert.Nil(t, listTerms(f.withListTerms(ctx, "logs", true)))
+
+	ctx = filter.SetListQuery(ctx, "starred = true")
+	assert.Equal(t, []string{"name contains '2024'", "(starred = true)"}, listTerms(f.withListTerms(ctx, "logs", false)))
+	assert.Equal(t, []string{"(starred = true)"}, listTerms(f.withListTerms(ctx, "", true)))
+}
@@ -0,0 +1,58 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/rclone/rclone/fs/filter"
+)
+
+// Context key for the extra query terms for a listing
+type listTermsContextKeyType struct{}
+
+var listTermsContextKey = listTermsContextKeyType{}
+
+// withListTerms returns a context with the extra query terms to
+// constrain the listing of dir with.
+//
+// These come from the filters when listing a single directory and
+// from the --query.
+func (f *Fs) withListTerms(ctx context.Context, dir string, recurse bool) context.Context {
+	var terms []string
+	if fi, use := filter.GetConfig(ctx), filter.GetUseFilter(ctx); fi != nil && use && !recurse {
+		if prefix := driveNamePrefix(fi.ListPrefix(dir, false)); prefix != "" {
+			terms = append(terms, fmt.Sprintf("name contains '%s'", prefix))
+		}
+	}
+	if query := filter.GetListQuery(ctx); query != "" {
+		terms = append(terms, "("+query+")")
+	}
+	if len(terms) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, listTermsContextKey, terms)
+}
+
+// listTerms returns the extra query terms set by withListTerms
+func listTerms(ctx context.Context) []string {
+	terms, _ := ctx.Value(listTermsContextKey).([]string)
+	return terms
+}
+
+// driveNamePrefix returns the start of prefix which the name contains
+// operator can be relied on to match.
+//
+// Drive matches the prefixes of words in names so this stops at the
+// first character which isn't a letter or a digit. This also stops
+// before any extension added to the names of exported documents.
+func driveNamePrefix(prefix string) string {
+	i := strings.IndexFunc(prefix, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if i >= 0 {
+		prefix = prefix[:i]
+	}
+	return prefix
+}
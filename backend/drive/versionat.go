@@ -0,0 +1,94 @@
+package drive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	drive "google.golang.org/api/drive/v3"
+)
+
+var errNotWithVersionAt = errors.New("can't modify or delete files in --drive-version-at mode")
+
+// createdAfterVersionAt returns true if item didn't exist at the time
+// set with --drive-version-at
+func (f *Fs) createdAfterVersionAt(item *drive.File) bool {
+	if !f.opt.VersionAt.IsSet() || item.CreatedTime == "" {
+		return false
+	}
+	created, err := time.Parse(timeFormatIn, item.CreatedTime)
+	if err != nil {
+		fs.Debugf(item.Name, "Failed to parse created time %q: %v", item.CreatedTime, err)
+		return false
+	}
+	return created.After(time.Time(f.opt.VersionAt))
+}
+
+// findRevisionAt returns the revision of the file with ID which was
+// current at the time set with --drive-version-at.
+//
+// It returns fs.ErrorObjectNotFound if the file had no content at
+// that time.
+func (f *Fs) findRevisionAt(ctx context.Context, ID string) (found *drive.Revision, err error) {
+	versionAt := time.Time(f.opt.VersionAt)
+	var foundTime time.Time
+	pageToken := ""
+	for {
+		var revs *drive.RevisionList
+		err = f.pacer.Call(func() (bool, error) {
+			revs, err = f.svc.Revisions.List(actualID(ID)).
+				Fields("nextPageToken,revisions(id,modifiedTime,size,md5Checksum)").
+				PageToken(pageToken).
+				Context(ctx).Do()
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list revisions: %w", err)
+		}
+		for _, rev := range revs.Revisions {
+			modTime, err := time.Parse(timeFormatIn, rev.ModifiedTime)
+			if err != nil {
+				fs.Debugf(ID, "Failed to parse revision %q time %q: %v", rev.Id, rev.ModifiedTime, err)
+				continue
+			}
+			if !modTime.After(versionAt) && (found == nil || modTime.After(foundTime)) {
+				found, foundTime = rev, modTime
+			}
+		}
+		if revs.NextPageToken == "" {
+			break
+		}
+		pageToken = revs.NextPageToken
+	}
+	if found == nil {
+		return nil, fs.ErrorObjectNotFound
+	}
+	return found, nil
+}
+
+// pinToVersionAt makes o refer to the revision of its file which was
+// current at the time set with --drive-version-at.
+func (f *Fs) pinToVersionAt(ctx context.Context, o *Object, info *drive.File) error {
+	if f.createdAfterVersionAt(info) {
+		return fs.ErrorObjectNotFound
+	}
+	rev, err := f.findRevisionAt(ctx, info.Id)
+	if err != nil {
+		return err
+	}
+	if rev.Id == info.HeadRevisionId {
+		// The current version so nothing to change
+		return nil
+	}
+	o.url = fmt.Sprintf("%sfiles/%s/revisions/%s?alt=media", f.svc.BasePath, actualID(info.Id), rev.Id)
+	o.md5sum = strings.ToLower(rev.Md5Checksum)
+	o.sha1sum = ""
+	o.sha256sum = ""
+	o.v2Download = false
+	o.bytes = rev.Size
+	o.modifiedDate = rev.ModifiedTime
+	return nil
+}
@@ -468,10 +468,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if ok && oldToken != "" && oldToken[0] != '{' {
 		fs.Infof(name, "Converting token to new format")
 		newToken := fmt.Sprintf(`{"access_token":%q,"token_type":"bearer","expiry":"0001-01-01T00:00:00Z"}`, oldToken)
-		err := config.SetValueAndSave(name, config.ConfigToken, newToken)
-		if err != nil {
-			return nil, fmt.Errorf("NewFS convert token: %w", err)
-		}
+		m.Set(config.ConfigToken, newToken)
 	}
 
 	oAuthClient, _, err := oauthutil.NewClient(ctx, name, m, getOauthConfig(m))
@@ -545,6 +542,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		CaseInsensitive:         true,
 		ReadMimeType:            false,
 		CanHaveEmptyDirectories: true,
+		PublicLinkOptions:       true,
 	})
 
 	// do not fill features yet
@@ -1376,6 +1374,18 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 			},
 		},
 	}
+	if unlink {
+		return "", f.unlink(ctx, absPath)
+	}
+	lo := fs.GetLinkOptions(ctx)
+	if lo.Password != "" {
+		createArg.Settings.RequirePassword = true
+		createArg.Settings.LinkPassword = lo.Password
+		createArg.Settings.RequestedVisibility.Tag = sharing.RequestedVisibilityPassword
+	}
+	if lo.Permission == fs.LinkPermissionEdit {
+		createArg.Settings.Access.Tag = sharing.RequestedLinkAccessLevelEditor
+	}
 	if expire < fs.DurationOff {
 		expiryTime := time.Now().Add(time.Duration(expire)).UTC().Round(time.Second)
 		createArg.Settings.Expires = &expiryTime
@@ -1431,6 +1441,42 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 	return
 }
 
+// unlink revokes all the shared links pointing directly at absPath
+func (f *Fs) unlink(ctx context.Context, absPath string) (err error) {
+	listArg := sharing.ListSharedLinksArg{
+		Path:       absPath,
+		DirectOnly: true,
+	}
+	var listRes *sharing.ListSharedLinksResult
+	err = f.pacer.Call(func() (bool, error) {
+		listRes, err = f.sharing.ListSharedLinks(&listArg)
+		return shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list shared links: %w", err)
+	}
+	for _, linkRes := range listRes.Links {
+		var url string
+		switch res := linkRes.(type) {
+		case *sharing.FileLinkMetadata:
+			url = res.Url
+		case *sharing.FolderLinkMetadata:
+			url = res.Url
+		default:
+			continue
+		}
+		fs.Debugf(absPath, "revoking shared link %q", url)
+		err = f.pacer.Call(func() (bool, error) {
+			err = f.sharing.RevokeSharedLink(&sharing.RevokeSharedLinkArg{Url: url})
+			return shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to revoke shared link: %w", err)
+		}
+	}
+	return nil
+}
+
 // DirMove moves src, srcRemote to this remote at dstRemote
 // using server-side move operations.
 //
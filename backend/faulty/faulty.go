@@ -0,0 +1,590 @@
+// Package faulty implements an overlay backend which injects faults
+// into the operations on another remote for testing error handling.
+package faulty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "faulty",
+		Description: "Inject faults into another remote for testing",
+		NewFs:       NewFs,
+		CommandHelp: commandHelp,
+		MetadataInfo: &fs.MetadataInfo{
+			Help: `Any metadata supported by the underlying remote is read and written.`,
+		},
+		Options: []fs.Option{{
+			Name: "remote",
+			Help: `Remote to inject faults into.
+
+Normally should contain a ':' and a path, e.g. "myremote:path/to/dir",
+"myremote:bucket" or "myremote:".
+
+If this is left empty, then the faulty backend will use the root as
+the remote.
+
+This means that you can use :faulty:remote:path and it will be
+equivalent to setting remote="remote:path".`,
+		}, {
+			Name:    "error_rate",
+			Default: 0.0,
+			Help: `Fraction of operations which fail with an error.
+
+For example 0.1 makes one operation in ten fail.`,
+		}, {
+			Name:    "error_type",
+			Default: "retry",
+			Help:    "How rclone should treat the injected errors.",
+			Examples: []fs.OptionExample{{
+				Value: "retry",
+				Help:  "Retry the operation and the whole sync as for a network error.",
+			}, {
+				Value: "noretry",
+				Help:  "Don't retry the operation, as for a permission error.",
+			}, {
+				Value: "fatal",
+				Help:  "Stop the sync, as for a full disk.",
+			}, {
+				Value: "quota",
+				Help:  "Fail as if the remote is out of quota.",
+			}},
+		}, {
+			Name:    "throttle_rate",
+			Default: 0.0,
+			Help: `Fraction of operations which are throttled.
+
+A throttled operation fails with an error asking for it to be tried
+again after throttle_retry_after, as remotes do when too many
+requests are made.`,
+		}, {
+			Name:     "throttle_retry_after",
+			Default:  fs.Duration(time.Second),
+			Help:     "How long throttled operations ask to wait before trying again.",
+			Advanced: true,
+		}, {
+			Name:    "truncate_rate",
+			Default: 0.0,
+			Help: `Fraction of file reads which are cut short.
+
+A truncated read returns an error after some of the data.`,
+		}, {
+			Name:    "latency",
+			Default: fs.Duration(0),
+			Help:    "Delay to add to every operation.",
+		}, {
+			Name:     "latency_jitter",
+			Default:  fs.Duration(0),
+			Help:     "Up to this much extra delay is added to each operation.",
+			Advanced: true,
+		}, {
+			Name:    "operations",
+			Default: fs.CommaSepList{},
+			Help: `Comma separated list of operations to inject faults into.
+
+Leave empty for all of them. The operations are list, newobject, put,
+update, open, read, remove, mkdir, rmdir, setmodtime, copy, move,
+dirmove, purge and about. Truncation only applies to read and latency
+to all the operations listed.`,
+			Advanced: true,
+		}, {
+			Name:    "seed",
+			Default: int64(0),
+			Help: `Seed for choosing which operations fail.
+
+The faults depend only on the seed, the operation, the path and how
+many times the operation has been done on that path. So running the
+same operations with the same seed gives the same faults. Change the
+seed to get different ones.`,
+			Advanced: true,
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Remote             string          `config:"remote"`
+	ErrorRate          float64         `config:"error_rate"`
+	ErrorType          string          `config:"error_type"`
+	ThrottleRate       float64         `config:"throttle_rate"`
+	ThrottleRetryAfter fs.Duration     `config:"throttle_retry_after"`
+	TruncateRate       float64         `config:"truncate_rate"`
+	Latency            fs.Duration     `config:"latency"`
+	LatencyJitter      fs.Duration     `config:"latency_jitter"`
+	Operations         fs.CommaSepList `config:"operations"`
+	Seed               int64           `config:"seed"`
+}
+
+// Fs represents a remote with faults injected
+type Fs struct {
+	fs.Fs
+	name     string
+	root     string
+	opt      Options
+	features *fs.Features
+	wrapper  fs.Fs
+	inj      *injector
+}
+
+// NewFs constructs an Fs from the path.
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	inj, err := newInjector(opt)
+	if err != nil {
+		return nil, err
+	}
+	remote := opt.Remote
+	if remote == "" {
+		remote = root
+		root = ""
+	}
+	if strings.HasPrefix(remote, name+":") {
+		return nil, errors.New("can't point faulty remote at itself - check the value of the remote setting")
+	}
+	baseFs, err := cache.Get(ctx, fspath.JoinRootPath(remote, root))
+	if err != fs.ErrorIsFile && err != nil {
+		return nil, fmt.Errorf("failed to make remote %q to wrap: %w", remote, err)
+	}
+	f := &Fs{
+		Fs:   baseFs,
+		name: name,
+		root: root,
+		opt:  *opt,
+		inj:  inj,
+	}
+	cache.PinUntilFinalized(f.Fs, f)
+	f.features = (&fs.Features{
+		CaseInsensitive:         true,
+		DuplicateFiles:          true,
+		ReadMimeType:            true,
+		WriteMimeType:           true,
+		CanHaveEmptyDirectories: true,
+		BucketBased:             true,
+		SetTier:                 true,
+		GetTier:                 true,
+		ReadMetadata:            true,
+		WriteMetadata:           true,
+		UserMetadata:            true,
+		PartialUploads:          true,
+	}).Fill(ctx, f).Mask(ctx, baseFs).WrapsFs(f, baseFs)
+	if err == fs.ErrorIsFile {
+		f.root = path.Dir(f.root)
+		if f.root == "." || f.root == "/" {
+			f.root = ""
+		}
+	}
+	return f, err
+}
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string { return f.name }
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string { return f.root }
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features { return f.features }
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set { return f.Fs.Hashes() }
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	return fmt.Sprintf("faulty root '%s'", f.root)
+}
+
+// UnWrap returns the Fs that this Fs is wrapping
+func (f *Fs) UnWrap() fs.Fs { return f.Fs }
+
+// WrapFs returns the Fs that is wrapping this Fs
+func (f *Fs) WrapFs() fs.Fs { return f.wrapper }
+
+// SetWrapper sets the Fs that is wrapping this Fs
+func (f *Fs) SetWrapper(wrapper fs.Fs) { f.wrapper = wrapper }
+
+// wrapEntries wraps the objects in entries in place
+func (f *Fs) wrapEntries(entries fs.DirEntries) fs.DirEntries {
+	for i, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			entries[i] = f.wrapObject(o)
+		}
+	}
+	return entries
+}
+
+// List the objects and directories in dir into entries.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	if err := f.inj.fault(ctx, opList, dir); err != nil {
+		return nil, err
+	}
+	entries, err = f.Fs.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapEntries(entries), nil
+}
+
+// ListR lists the objects and directories recursively into callback.
+func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) error {
+	if err := f.inj.fault(ctx, opList, dir); err != nil {
+		return err
+	}
+	return f.Fs.Features().ListR(ctx, dir, func(entries fs.DirEntries) error {
+		return callback(f.wrapEntries(entries))
+	})
+}
+
+// NewObject finds the Object at remote.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	if err := f.inj.fault(ctx, opNewObject, remote); err != nil {
+		return nil, err
+	}
+	o, err := f.Fs.NewObject(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o), nil
+}
+
+// Put in to the remote path with the modTime given of the given size
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	if err := f.inj.fault(ctx, opPut, src.Remote()); err != nil {
+		return nil, err
+	}
+	o, err := f.Fs.Put(ctx, in, src, options...)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o), nil
+}
+
+// PutStream uploads to the remote path with the modTime given of indeterminate size
+func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	do := f.Fs.Features().PutStream
+	if do == nil {
+		return nil, errors.New("PutStream not supported")
+	}
+	if err := f.inj.fault(ctx, opPut, src.Remote()); err != nil {
+		return nil, err
+	}
+	o, err := do(ctx, in, src, options...)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o), nil
+}
+
+// Mkdir makes the directory (container, bucket)
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	if err := f.inj.fault(ctx, opMkdir, dir); err != nil {
+		return err
+	}
+	return f.Fs.Mkdir(ctx, dir)
+}
+
+// Rmdir removes the directory (container, bucket) if empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	if err := f.inj.fault(ctx, opRmdir, dir); err != nil {
+		return err
+	}
+	return f.Fs.Rmdir(ctx, dir)
+}
+
+// Purge all files in the directory specified
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	do := f.Fs.Features().Purge
+	if do == nil {
+		return fs.ErrorCantPurge
+	}
+	if err := f.inj.fault(ctx, opPurge, dir); err != nil {
+		return err
+	}
+	return do(ctx, dir)
+}
+
+// Copy src to this remote using server-side copy operations.
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	do := f.Fs.Features().Copy
+	if do == nil {
+		return nil, fs.ErrorCantCopy
+	}
+	o, ok := src.(*Object)
+	if !ok {
+		return nil, fs.ErrorCantCopy
+	}
+	if err := f.inj.fault(ctx, opCopy, remote); err != nil {
+		return nil, err
+	}
+	dst, err := do(ctx, o.Object, remote)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(dst), nil
+}
+
+// Move src to this remote using server-side move operations.
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	do := f.Fs.Features().Move
+	if do == nil {
+		return nil, fs.ErrorCantMove
+	}
+	o, ok := src.(*Object)
+	if !ok {
+		return nil, fs.ErrorCantMove
+	}
+	if err := f.inj.fault(ctx, opMove, remote); err != nil {
+		return nil, err
+	}
+	dst, err := do(ctx, o.Object, remote)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(dst), nil
+}
+
+// DirMove moves src, srcRemote to this remote at dstRemote using server-side move operations.
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	do := f.Fs.Features().DirMove
+	if do == nil {
+		return fs.ErrorCantDirMove
+	}
+	srcFs, ok := src.(*Fs)
+	if !ok {
+		return fs.ErrorCantDirMove
+	}
+	if err := f.inj.fault(ctx, opDirMove, dstRemote); err != nil {
+		return err
+	}
+	return do(ctx, srcFs.Fs, srcRemote, dstRemote)
+}
+
+// About gets quota information from the Fs
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	do := f.Fs.Features().About
+	if do == nil {
+		return nil, errors.New("not supported by underlying remote")
+	}
+	if err := f.inj.fault(ctx, opAbout, ""); err != nil {
+		return nil, err
+	}
+	return do(ctx)
+}
+
+// DirCacheFlush resets the directory cache - used in testing
+// as an optional interface
+func (f *Fs) DirCacheFlush() {
+	if do := f.Fs.Features().DirCacheFlush; do != nil {
+		do()
+	}
+}
+
+// Shutdown the backend, closing any background tasks and any cached connections.
+func (f *Fs) Shutdown(ctx context.Context) error {
+	if do := f.Fs.Features().Shutdown; do != nil {
+		return do(ctx)
+	}
+	return nil
+}
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "stats",
+	Short: "Show the faults injected so far.",
+	Long: `Show the number of faults injected by kind and operation.
+
+Usage example:
+
+` + "```console" + `
+rclone backend stats faulty:
+` + "```",
+}, {
+	Name:  "reset",
+	Short: "Start the sequence of faults again.",
+	Long: `Forget the operations done so far so the same operations inject the
+same faults again and clear the stats.
+
+Usage example:
+
+` + "```console" + `
+rclone rc backend/command command=reset fs=faulty:
+` + "```",
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out any, err error) {
+	switch name {
+	case "stats":
+		return newStatsResult(f.inj.stats()), nil
+	case "reset":
+		f.inj.reset()
+		return nil, nil
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// Object represents an object on the wrapped remote
+type Object struct {
+	fs.Object
+	f *Fs
+}
+
+// wrapObject wraps o as an Object
+func (f *Fs) wrapObject(o fs.Object) *Object {
+	return &Object{Object: o, f: f}
+}
+
+// Fs returns read only access to the Fs that this object is part of
+func (o *Object) Fs() fs.Info { return o.f }
+
+// UnWrap returns the wrapped Object
+func (o *Object) UnWrap() fs.Object { return o.Object }
+
+// String returns a description of the Object
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.Object.String()
+}
+
+// Open an object for read
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	if err := o.f.inj.fault(ctx, opOpen, o.Remote()); err != nil {
+		return nil, err
+	}
+	in, err := o.Object.Open(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return o.f.inj.truncate(in, o.Remote(), o.Size()), nil
+}
+
+// Update in to the object with the modTime given of the given size
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	if err := o.f.inj.fault(ctx, opUpdate, o.Remote()); err != nil {
+		return err
+	}
+	return o.Object.Update(ctx, in, src, options...)
+}
+
+// Remove an object
+func (o *Object) Remove(ctx context.Context) error {
+	if err := o.f.inj.fault(ctx, opRemove, o.Remote()); err != nil {
+		return err
+	}
+	return o.Object.Remove(ctx)
+}
+
+// SetModTime sets the modification time of the object
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	if err := o.f.inj.fault(ctx, opSetModTime, o.Remote()); err != nil {
+		return err
+	}
+	return o.Object.SetModTime(ctx, modTime)
+}
+
+// ID returns the ID of the Object if possible
+func (o *Object) ID() string {
+	if do, ok := o.Object.(fs.IDer); ok {
+		return do.ID()
+	}
+	return ""
+}
+
+// GetTier returns the Tier of the Object if possible
+func (o *Object) GetTier() string {
+	if do, ok := o.Object.(fs.GetTierer); ok {
+		return do.GetTier()
+	}
+	return ""
+}
+
+// SetTier set the Tier of the Object if possible
+func (o *Object) SetTier(tier string) error {
+	if do, ok := o.Object.(fs.SetTierer); ok {
+		return do.SetTier(tier)
+	}
+	return errors.New("SetTier not supported")
+}
+
+// MimeType of an Object if known, "" otherwise
+func (o *Object) MimeType(ctx context.Context) string {
+	if do, ok := o.Object.(fs.MimeTyper); ok {
+		return do.MimeType(ctx)
+	}
+	return ""
+}
+
+// Metadata returns metadata for an object
+//
+// It should return nil if there is no Metadata
+func (o *Object) Metadata(ctx context.Context) (fs.Metadata, error) {
+	do, ok := o.Object.(fs.Metadataer)
+	if !ok {
+		return nil, nil
+	}
+	return do.Metadata(ctx)
+}
+
+// SetMetadata sets metadata for an Object
+//
+// It should return fs.ErrorNotImplemented if it can't set metadata
+func (o *Object) SetMetadata(ctx context.Context, metadata fs.Metadata) error {
+	do, ok := o.Object.(fs.SetMetadataer)
+	if !ok {
+		return fs.ErrorNotImplemented
+	}
+	return do.SetMetadata(ctx, metadata)
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs              = (*Fs)(nil)
+	_ fs.Purger          = (*Fs)(nil)
+	_ fs.Copier          = (*Fs)(nil)
+	_ fs.Mover           = (*Fs)(nil)
+	_ fs.DirMover        = (*Fs)(nil)
+	_ fs.PutStreamer     = (*Fs)(nil)
+	_ fs.ListRer         = (*Fs)(nil)
+	_ fs.Abouter         = (*Fs)(nil)
+	_ fs.UnWrapper       = (*Fs)(nil)
+	_ fs.Wrapper         = (*Fs)(nil)
+	_ fs.Commander       = (*Fs)(nil)
+	_ fs.DirCacheFlusher = (*Fs)(nil)
+	_ fs.Shutdowner      = (*Fs)(nil)
+	_ fs.Object          = (*Object)(nil)
+	_ fs.ObjectUnWrapper = (*Object)(nil)
+	_ fs.IDer            = (*Object)(nil)
+	_ fs.GetTierer       = (*Object)(nil)
+	_ fs.SetTierer       = (*Object)(nil)
+	_ fs.MimeTyper       = (*Object)(nil)
+	_ fs.Metadataer      = (*Object)(nil)
+	_ fs.SetMetadataer   = (*Object)(nil)
+)
@@ -0,0 +1,150 @@
+package faulty
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestFs makes a faulty Fs on a new memory remote
+func newTestFs(t *testing.T, m configmap.Simple) *Fs {
+	ctx := context.Background()
+	m["remote"] = ":memory:" + t.Name()
+	f, err := NewFs(ctx, "faulty", "", m)
+	require.NoError(t, err)
+	return f.(*Fs)
+}
+
+// putTest uploads a file to the wrapped remote without faults
+func putTest(t *testing.T, f *Fs, remote, contents string) fs.Object {
+	ctx := context.Background()
+	src := object.NewStaticObjectInfo(remote, time.Now(), int64(len(contents)), true, nil, nil)
+	o, err := f.Fs.Put(ctx, bytes.NewBufferString(contents), src)
+	require.NoError(t, err)
+	return f.wrapObject(o)
+}
+
+func TestNewInjector(t *testing.T) {
+	for _, test := range []struct {
+		opt     Options
+		wantErr string
+	}{
+		{opt: Options{ErrorType: "retry"}},
+		{opt: Options{ErrorType: "quota", ErrorRate: 0.5}},
+		{opt: Options{ErrorType: "fatal", ErrorRate: 0.5, ThrottleRate: 0.5, Operations: fs.CommaSepList{"open", "read"}}},
+		{opt: Options{ErrorType: "retry", ErrorRate: 1.5}, wantErr: "between 0 and 1"},
+		{opt: Options{ErrorType: "retry", ErrorRate: 0.6, ThrottleRate: 0.6}, wantErr: "add up to 1"},
+		{opt: Options{ErrorType: "potato"}, wantErr: "unknown error_type"},
+		{opt: Options{ErrorType: "retry", Operations: fs.CommaSepList{"potato"}}, wantErr: "unknown operation"},
+	} {
+		_, err := newInjector(&test.opt)
+		if test.wantErr == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorContains(t, err, test.wantErr)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFs(t, configmap.Simple{"error_rate": "0.5", "seed": "42"})
+	putTest(t, f, "file.txt", "hello")
+
+	// Record which calls fail
+	var failed []bool
+	for range 20 {
+		_, err := f.NewObject(ctx, "file.txt")
+		if err != nil {
+			assert.ErrorIs(t, err, ErrInjected)
+			assert.True(t, fserrors.IsRetryError(err))
+		}
+		failed = append(failed, err != nil)
+	}
+	assert.Contains(t, failed, true)
+	assert.Contains(t, failed, false)
+	stats := newStatsResult(f.inj.stats())
+	assert.Greater(t, stats.Total, 0)
+	assert.Equal(t, stats.Total, stats.Faults[faultError][opNewObject])
+
+	// After a reset the same calls fail
+	_, err := f.Command(ctx, "reset", nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, f.inj.stats())
+	for i := range 20 {
+		_, err := f.NewObject(ctx, "file.txt")
+		assert.Equal(t, failed[i], err != nil, "call %d", i)
+	}
+
+	// Other operations aren't affected with operations set
+	f = newTestFs(t, configmap.Simple{"error_rate": "1", "operations": "remove", "error_type": "noretry"})
+	o := putTest(t, f, "file.txt", "hello")
+	_, err = f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	err = o.Remove(ctx)
+	assert.ErrorIs(t, err, ErrInjected)
+	assert.True(t, fserrors.IsNoRetryError(err))
+	assert.False(t, fserrors.IsQuotaError(err))
+
+	// Quota errors aren't retried either
+	f = newTestFs(t, configmap.Simple{"error_rate": "1", "operations": "remove", "error_type": "quota"})
+	o = putTest(t, f, "file.txt", "hello")
+	err = o.Remove(ctx)
+	assert.ErrorIs(t, err, ErrInjected)
+	assert.True(t, fserrors.IsQuotaError(err))
+	assert.True(t, fserrors.IsNoRetryError(err))
+}
+
+func TestThrottle(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFs(t, configmap.Simple{"throttle_rate": "1", "throttle_retry_after": "3s"})
+	_, err := f.List(ctx, "")
+	assert.ErrorIs(t, err, ErrThrottled)
+	retryAfter, ok := pacer.IsRetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, retryAfter)
+
+	out, err := f.Command(ctx, "stats", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, out.(statsResult).Faults[faultThrottle][opList])
+}
+
+func TestTruncate(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFs(t, configmap.Simple{"truncate_rate": "1"})
+	contents := "the quick brown fox jumps over the lazy dog"
+	o := putTest(t, f, "file.txt", contents)
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	data, err := io.ReadAll(in)
+	require.NoError(t, in.Close())
+	assert.ErrorIs(t, err, ErrTruncated)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+	assert.Less(t, len(data), len(contents))
+	assert.Equal(t, contents[:len(data)], string(data))
+}
+
+func TestLatency(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFs(t, configmap.Simple{"latency": "50ms", "operations": "mkdir"})
+	start := time.Now()
+	require.NoError(t, f.Mkdir(ctx, "dir"))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// Cancelling the context stops the wait
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, f.Mkdir(ctx, "dir"), context.Canceled)
+}
@@ -0,0 +1,271 @@
+package faulty
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/lib/pacer"
+)
+
+// Operations faults can be injected into
+const (
+	opList       = "list"
+	opNewObject  = "newobject"
+	opPut        = "put"
+	opUpdate     = "update"
+	opOpen       = "open"
+	opRead       = "read"
+	opRemove     = "remove"
+	opMkdir      = "mkdir"
+	opRmdir      = "rmdir"
+	opSetModTime = "setmodtime"
+	opCopy       = "copy"
+	opMove       = "move"
+	opDirMove    = "dirmove"
+	opPurge      = "purge"
+	opAbout      = "about"
+)
+
+var allOps = []string{
+	opList, opNewObject, opPut, opUpdate, opOpen, opRead, opRemove, opMkdir,
+	opRmdir, opSetModTime, opCopy, opMove, opDirMove, opPurge, opAbout,
+}
+
+// Kinds of fault
+const (
+	faultError    = "error"
+	faultThrottle = "throttle"
+	faultTruncate = "truncate"
+)
+
+// Errors returned by injected faults
+var (
+	ErrInjected  = errors.New("faulty: injected error")
+	ErrThrottled = errors.New("faulty: injected throttling")
+	ErrTruncated = errors.New("faulty: injected truncation")
+)
+
+// injector decides which operations fail.
+//
+// Each decision is made from a hash of the seed, the operation, the
+// path and how many times that operation has been done on that path
+// so the same sequence of calls gets the same faults whatever order
+// concurrent calls arrive in.
+type injector struct {
+	opt Options
+	ops map[string]bool // operations to inject faults into
+
+	mu     sync.Mutex
+	calls  map[string]uint64         // calls by operation and path
+	counts map[string]map[string]int // injected faults by kind and operation
+}
+
+// newInjector makes an injector from the options
+func newInjector(opt *Options) (*injector, error) {
+	for _, rate := range []float64{opt.ErrorRate, opt.ThrottleRate, opt.TruncateRate} {
+		if rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("fault rates must be between 0 and 1 but got %g", rate)
+		}
+	}
+	if opt.ErrorRate+opt.ThrottleRate > 1 {
+		return nil, errors.New("error_rate and throttle_rate must add up to 1 or less")
+	}
+	switch opt.ErrorType {
+	case "", "retry", "noretry", "fatal", "quota":
+	default:
+		return nil, fmt.Errorf("unknown error_type %q - use retry, noretry, fatal or quota", opt.ErrorType)
+	}
+	inj := &injector{
+		opt: *opt,
+		ops: make(map[string]bool, len(allOps)),
+	}
+	ops := opt.Operations
+	if len(ops) == 0 {
+		ops = allOps
+	}
+	for _, op := range ops {
+		valid := false
+		for _, known := range allOps {
+			valid = valid || op == known
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown operation %q in operations - use one of %v", op, allOps)
+		}
+		inj.ops[op] = true
+	}
+	inj.reset()
+	return inj, nil
+}
+
+// reset the call counters so the same faults are injected again
+func (inj *injector) reset() {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.calls = map[string]uint64{}
+	inj.counts = map[string]map[string]int{}
+}
+
+// roll returns a number in [0, 1) for the next call of op on remote
+// for the kind of fault
+func (inj *injector) roll(kind, op, remote string) float64 {
+	key := kind + "\x00" + op + "\x00" + remote
+	inj.mu.Lock()
+	n := inj.calls[key]
+	inj.calls[key] = n + 1
+	inj.mu.Unlock()
+
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(inj.opt.Seed))
+	_, _ = h.Write(buf[:])
+	binary.LittleEndian.PutUint64(buf[:], n)
+	_, _ = h.Write(buf[:])
+	_, _ = h.Write([]byte(key))
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
+
+// count records an injected fault
+func (inj *injector) count(kind, op string) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	if inj.counts[kind] == nil {
+		inj.counts[kind] = map[string]int{}
+	}
+	inj.counts[kind][op]++
+}
+
+// stats returns the number of injected faults by kind and operation
+func (inj *injector) stats() map[string]map[string]int {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	out := make(map[string]map[string]int, len(inj.counts))
+	for kind, ops := range inj.counts {
+		out[kind] = make(map[string]int, len(ops))
+		for op, n := range ops {
+			out[kind][op] = n
+		}
+	}
+	return out
+}
+
+// fault delays op on remote by the latency and returns an injected
+// error if op should fail
+func (inj *injector) fault(ctx context.Context, op, remote string) error {
+	if !inj.ops[op] {
+		return nil
+	}
+	if err := inj.delay(ctx, op, remote); err != nil {
+		return err
+	}
+	if inj.opt.ErrorRate == 0 && inj.opt.ThrottleRate == 0 {
+		return nil
+	}
+	u := inj.roll(faultError, op, remote)
+	switch {
+	case u < inj.opt.ErrorRate:
+		inj.count(faultError, op)
+		fs.Debugf(remote, "faulty: injecting error into %s", op)
+		err := fmt.Errorf("%w in %s %q", ErrInjected, op, remote)
+		switch inj.opt.ErrorType {
+		case "noretry":
+			return fserrors.NoRetryError(err)
+		case "fatal":
+			return fserrors.FatalError(err)
+		case "quota":
+			return fserrors.NoRetryError(fserrors.QuotaError(err))
+		}
+		return fserrors.RetryError(err)
+	case u < inj.opt.ErrorRate+inj.opt.ThrottleRate:
+		inj.count(faultThrottle, op)
+		fs.Debugf(remote, "faulty: injecting throttling into %s", op)
+		err := fmt.Errorf("%w in %s %q", ErrThrottled, op, remote)
+		return pacer.RetryAfterError(err, time.Duration(inj.opt.ThrottleRetryAfter))
+	}
+	return nil
+}
+
+// delay waits for the latency plus some of the jitter
+func (inj *injector) delay(ctx context.Context, op, remote string) error {
+	d := time.Duration(inj.opt.Latency)
+	if inj.opt.LatencyJitter > 0 {
+		d += time.Duration(inj.roll("latency", op, remote) * float64(inj.opt.LatencyJitter))
+	}
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// truncate returns in cut short with ErrTruncated if reading remote
+// should be truncated, otherwise in unchanged.
+//
+// The cut is made at a point in the first size bytes.
+func (inj *injector) truncate(in io.ReadCloser, remote string, size int64) io.ReadCloser {
+	if !inj.ops[opRead] || inj.opt.TruncateRate == 0 || size <= 0 {
+		return in
+	}
+	if inj.roll(faultTruncate, opRead, remote) >= inj.opt.TruncateRate {
+		return in
+	}
+	inj.count(faultTruncate, opRead)
+	cut := int64(inj.roll("cut", opRead, remote) * float64(size))
+	fs.Debugf(remote, "faulty: truncating read after %d bytes", cut)
+	return &truncatedReader{in: in, remaining: cut, remote: remote}
+}
+
+// truncatedReader returns an error after reading remaining bytes
+type truncatedReader struct {
+	in        io.ReadCloser
+	remaining int64
+	remote    string
+}
+
+// Read up to the cut then return an error
+func (r *truncatedReader) Read(p []byte) (n int, err error) {
+	if r.remaining <= 0 {
+		return 0, fserrors.RetryError(fmt.Errorf("%w reading %q: %w", ErrTruncated, r.remote, io.ErrUnexpectedEOF))
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err = r.in.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
+
+// Close the underlying reader
+func (r *truncatedReader) Close() error {
+	return r.in.Close()
+}
+
+// statsResult is the output of the stats command
+type statsResult struct {
+	Faults map[string]map[string]int `json:"faults"`
+	Total  int                       `json:"total"`
+}
+
+// newStatsResult makes the output of the stats command
+func newStatsResult(counts map[string]map[string]int) statsResult {
+	res := statsResult{Faults: counts}
+	for _, ops := range counts {
+		for _, n := range ops {
+			res.Total += n
+		}
+	}
+	return res
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/rclone/rclone/lib/env"
 	"github.com/rclone/rclone/lib/pacer"
 	"github.com/rclone/rclone/lib/proxy"
+	"github.com/rclone/rclone/lib/ratelimit"
 	"github.com/rclone/rclone/lib/readers"
 )
 
@@ -49,7 +50,7 @@ func init() {
 		Name:        "ftp",
 		Description: "FTP",
 		NewFs:       NewFs,
-		Options: []fs.Option{{
+		Options: append([]fs.Option{{
 			Name:      "host",
 			Help:      "FTP host to connect to.\n\nE.g. \"ftp.example.com\".",
 			Required:  true,
@@ -240,7 +241,7 @@ a write only folder.
 				Value: "Ctl,LeftPeriod,Slash",
 				Help:  "VsFTPd can't handle file names starting with dot",
 			}},
-		}},
+		}}, ratelimit.SharedOptions...),
 	})
 }
 
@@ -270,6 +271,7 @@ type Options struct {
 	SocksProxy              string               `config:"socks_proxy"`
 	HTTPProxy               string               `config:"http_proxy"`
 	NoCheckUpload           bool                 `config:"no_check_upload"`
+	ratelimit.Options
 }
 
 // Fs represents a remote FTP server
@@ -288,11 +290,12 @@ type Fs struct {
 	pool     []*ftp.ServerConn
 	drain    *time.Timer // used to drain the pool when we stop using the connections
 	tokens   *pacer.TokenDispenser
-	proxyURL *url.URL  // address of HTTP proxy read from environment
-	pacer    *fs.Pacer // pacer for FTP connections
-	fGetTime bool      // true if the ftp library accepts GetTime
-	fSetTime bool      // true if the ftp library accepts SetTime
-	fLstTime bool      // true if the List call returns precise time
+	proxyURL *url.URL           // address of HTTP proxy read from environment
+	pacer    *fs.Pacer          // pacer for FTP connections
+	limiter  *ratelimit.Limiter // per remote tpslimit and bwlimit
+	fGetTime bool               // true if the ftp library accepts GetTime
+	fSetTime bool               // true if the ftp library accepts SetTime
+	fLstTime bool               // true if the List call returns precise time
 }
 
 // Object describes an FTP file
@@ -558,6 +561,7 @@ func (f *Fs) getFtpConnection(ctx context.Context) (c *ftp.ServerConn, err error
 		f.tokens.Get()
 	}
 	accounting.LimitTPS(ctx)
+	f.limiter.Wait(ctx)
 	f.poolMu.Lock()
 	if len(f.pool) > 0 {
 		c = f.pool[0]
@@ -680,6 +684,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (ff fs.Fs
 		tokens:   pacer.NewTokenDispenser(opt.Concurrency),
 		pacer:    fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
 		tlsConf:  fshttp.NewTransport(ctx).TLSClientConfig,
+		limiter:  ratelimit.New(name, &opt.Options),
 	}
 	f.features = (&fs.Features{
 		CanHaveEmptyDirectories: true,
@@ -1336,7 +1341,8 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (rc io.Read
 		return nil, fmt.Errorf("open: %w", err)
 	}
 
-	rc = &ftpReadCloser{rc: readers.NewLimitedReadCloser(fd, limit), c: c, f: o.fs}
+	in := o.fs.limiter.DownloadReadCloser(ctx, readers.NewLimitedReadCloser(fd, limit))
+	rc = &ftpReadCloser{rc: in, c: c, f: o.fs}
 	return rc, nil
 }
 
@@ -1366,7 +1372,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	if err != nil {
 		return fmt.Errorf("Update: %w", err)
 	}
-	err = c.Stor(o.fs.opt.Enc.FromStandardPath(path), in)
+	err = c.Stor(o.fs.opt.Enc.FromStandardPath(path), o.fs.limiter.UploadReader(ctx, in))
 	// Ignore error 250 here - send by some servers
 	if errX := textprotoError(err); errX != nil {
 		switch errX.Code {
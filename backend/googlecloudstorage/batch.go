@@ -0,0 +1,189 @@
+package googlecloudstorage
+
+// Batch deletes using the JSON API batch endpoint
+//
+// See https://cloud.google.com/storage/docs/batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+	"google.golang.org/api/googleapi"
+)
+
+// maxBatchSize is the maximum number of calls in a batch request
+const maxBatchSize = 100
+
+// batchURL returns the URL of the batch endpoint for the service at
+// basePath or "" if it isn't known.
+func batchURL(basePath string) string {
+	base, ok := strings.CutSuffix(basePath, "/storage/v1/")
+	if !ok {
+		return ""
+	}
+	return base + "/batch/storage/v1"
+}
+
+// DeleteObjects deletes all the objects passed in using the batch
+// API which deletes up to 100 objects in one request.
+func (f *Fs) DeleteObjects(ctx context.Context, objs []fs.Object) (errs []error, err error) {
+	errs = make([]error, len(objs))
+	batch := make([]*Object, 0, maxBatchSize)
+	index := make([]int, 0, maxBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		for i, err := range f.deleteBatch(ctx, batch) {
+			errs[index[i]] = err
+		}
+		batch, index = batch[:0], index[:0]
+	}
+	for i, obj := range objs {
+		o, ok := obj.(*Object)
+		if !ok {
+			errs[i] = errors.New("not a google cloud storage object")
+			continue
+		}
+		batch = append(batch, o)
+		index = append(index, i)
+		if len(batch) >= maxBatchSize {
+			flush()
+		}
+	}
+	flush()
+	return errs, nil
+}
+
+// deleteBatch deletes objs with batch requests returning an error
+// for each object.
+//
+// Objects which fail with a retryable error are retried on their own
+// in the next request.
+func (f *Fs) deleteBatch(ctx context.Context, objs []*Object) (errs []error) {
+	errs = make([]error, len(objs))
+	pending := make([]int, len(objs))
+	for i := range objs {
+		pending[i] = i
+	}
+	_ = f.pacer.Call(func() (bool, error) {
+		batch := make([]*Object, len(pending))
+		for i, j := range pending {
+			batch[i] = objs[j]
+		}
+		results, err := f.submitDeleteBatch(ctx, batch)
+		if err != nil {
+			for _, j := range pending {
+				errs[j] = fmt.Errorf("failed to submit delete batch: %w", err)
+			}
+			return shouldRetry(ctx, err)
+		}
+		var retry []int
+		for i, j := range pending {
+			errs[j] = results[i]
+			if again, _ := shouldRetry(ctx, results[i]); again {
+				retry = append(retry, j)
+			}
+		}
+		pending = retry
+		if len(retry) > 0 {
+			fs.Debugf(f, "Retrying %d objects which failed to delete", len(retry))
+			return true, fmt.Errorf("failed to delete %d objects", len(retry))
+		}
+		return false, nil
+	})
+	return errs
+}
+
+// submitDeleteBatch sends one batch request to delete objs and
+// returns the result for each object.
+func (f *Fs) submitDeleteBatch(ctx context.Context, objs []*Object) (results []error, err error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for i, o := range objs {
+		bucket, bucketPath := o.split()
+		path := "/storage/v1/b/" + url.PathEscape(bucket) + "/o/" + url.PathEscape(bucketPath)
+		if f.opt.UserProject != "" {
+			path += "?userProject=" + url.QueryEscape(f.opt.UserProject)
+		}
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"application/http"},
+			"Content-Id":   {"<" + strconv.Itoa(i) + ">"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = fmt.Fprintf(part, "DELETE %s HTTP/1.1\r\n\r\n", path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = mw.Close()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", batchURL(f.svc.BasePath), &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.CheckClose(resp.Body, &err)
+	err = googleapi.CheckResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	return parseDeleteBatch(resp, len(objs))
+}
+
+// parseDeleteBatch reads the result of each of the n calls from the
+// batch response.
+func parseDeleteBatch(resp *http.Response, n int) (results []error, err error) {
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, fmt.Errorf("unexpected batch response Content-Type %q", resp.Header.Get("Content-Type"))
+	}
+	results = make([]error, n)
+	for i := range results {
+		results[i] = errors.New("no response in delete batch")
+	}
+	mr := multipart.NewReader(resp.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read batch response: %w", err)
+		}
+		// The Content-ID of the response is <response-N>
+		contentID := strings.Trim(part.Header.Get("Content-Id"), "<>")
+		i, err := strconv.Atoi(contentID[strings.LastIndex(contentID, "-")+1:])
+		if err != nil || i < 0 || i >= n {
+			fs.Debugf(nil, "Ignoring batch response with unknown Content-ID %q", contentID)
+			continue
+		}
+		partResp, err := http.ReadResponse(bufio.NewReader(part), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read batch response: %w", err)
+		}
+		results[i] = googleapi.CheckResponse(partResp)
+		_ = partResp.Body.Close()
+	}
+	return results, nil
+}
@@ -0,0 +1,97 @@
+package googlecloudstorage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/api/storage/v1"
+)
+
+func TestBatchURL(t *testing.T) {
+	assert.Equal(t, "https://storage.googleapis.com/batch/storage/v1", batchURL("https://storage.googleapis.com/storage/v1/"))
+	assert.Equal(t, "", batchURL("https://example.com/"))
+}
+
+func TestDeleteObjects(t *testing.T) {
+	ctx := context.Background()
+	var (
+		deleted []string
+		tries   = map[string]int{}
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/batch/storage/v1", r.URL.Path)
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=batch_response")
+		mw := multipart.NewWriter(w)
+		require.NoError(t, mw.SetBoundary("batch_response"))
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			req, err := http.ReadRequest(bufio.NewReader(part))
+			require.NoError(t, err)
+			assert.Equal(t, "DELETE", req.Method)
+			path := req.URL.EscapedPath()
+			tries[path]++
+			var status string
+			switch {
+			case strings.HasSuffix(path, "/busy.txt") && tries[path] == 1:
+				status = "HTTP/1.1 503 Service Unavailable\r\nContent-Type: application/json\r\n\r\n{\"error\":{\"code\":503,\"message\":\"busy\"}}"
+			case strings.HasSuffix(path, "/missing.txt"):
+				status = "HTTP/1.1 404 Not Found\r\nContent-Type: application/json\r\n\r\n{\"error\":{\"code\":404,\"message\":\"No such object\"}}"
+			default:
+				deleted = append(deleted, path)
+				status = "HTTP/1.1 204 No Content\r\n\r\n"
+			}
+			out, err := mw.CreatePart(map[string][]string{
+				"Content-Type": {"application/http"},
+				"Content-Id":   {"<response-" + strings.Trim(part.Header.Get("Content-Id"), "<>") + ">"},
+			})
+			require.NoError(t, err)
+			_, err = fmt.Fprint(out, status)
+			require.NoError(t, err)
+		}
+		require.NoError(t, mw.Close())
+	}))
+	defer ts.Close()
+
+	svc, err := storage.NewService(ctx, option.WithHTTPClient(ts.Client()), option.WithEndpoint(ts.URL+"/storage/v1/"))
+	require.NoError(t, err)
+	f := &Fs{
+		svc:    svc,
+		client: ts.Client(),
+		root:   "bucket",
+		pacer:  fs.NewPacer(ctx, pacer.NewS3(pacer.MinSleep(minSleep))),
+	}
+	var objs []fs.Object
+	for _, remote := range []string{"a/file.txt", "busy.txt", "missing.txt"} {
+		objs = append(objs, &Object{fs: f, remote: remote})
+	}
+
+	errs, err := f.DeleteObjects(ctx, objs)
+	require.NoError(t, err)
+	require.Len(t, errs, 3)
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.ErrorContains(t, errs[2], "No such object")
+	assert.Equal(t, []string{"/storage/v1/b/bucket/o/a%2Ffile.txt", "/storage/v1/b/bucket/o/busy.txt"}, deleted)
+	assert.Equal(t, 2, tries["/storage/v1/b/bucket/o/busy.txt"])
+	assert.Equal(t, 1, tries["/storage/v1/b/bucket/o/missing.txt"])
+}
@@ -418,7 +418,8 @@ type Object struct {
 	bytes    int64     // Bytes in the object
 	modTime  time.Time // Modified time of the object
 	mimeType string
-	gzipped  bool // set if object has Content-Encoding: gzip
+	gzipped  bool   // set if object has Content-Encoding: gzip
+	tier     string // storage class of the object
 }
 
 // ------------------------------------------------------------
@@ -576,6 +577,8 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		WriteMimeType:     true,
 		BucketBased:       true,
 		BucketBasedRootOK: true,
+		SetTier:           true,
+		GetTier:           true,
 	}).Fill(ctx, f)
 	if opt.DirectoryMarkers {
 		f.features.CanHaveEmptyDirectories = true
@@ -591,6 +594,10 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, fmt.Errorf("couldn't create Google Cloud Storage client: %w", err)
 	}
+	if batchURL(f.svc.BasePath) == "" {
+		// Custom endpoints may not have a batch endpoint
+		f.features.DeleteObjects = nil
+	}
 
 	if f.rootBucket != "" && f.rootDirectory != "" {
 		// Check to see if the object exists
@@ -1214,6 +1221,7 @@ func (o *Object) setMetaData(info *storage.Object) {
 	o.bytes = int64(info.Size)
 	o.mimeType = info.ContentType
 	o.gzipped = info.ContentEncoding == "gzip"
+	o.tier = info.StorageClass
 
 	// Read md5sum
 	md5sumData, err := base64.StdEncoding.DecodeString(info.Md5Hash)
@@ -1503,13 +1511,60 @@ func (o *Object) MimeType(ctx context.Context) string {
 	return o.mimeType
 }
 
+// SetTier changes the storage class of the object by rewriting it
+// in place.
+func (o *Object) SetTier(tier string) error {
+	ctx := context.TODO()
+	tier = strings.ToUpper(tier)
+	// read the complete existing object first so the metadata is kept
+	object, err := o.readObjectInfo(ctx)
+	if err != nil {
+		return err
+	}
+	object.StorageClass = tier
+	bucket, bucketPath := o.split()
+	rewriteRequest := o.fs.svc.Objects.Rewrite(bucket, bucketPath, bucket, bucketPath, object)
+	if !o.fs.opt.BucketPolicyOnly {
+		rewriteRequest.DestinationPredefinedAcl(o.fs.opt.ObjectACL)
+	}
+	var rewriteResponse *storage.RewriteResponse
+	for {
+		err = o.fs.pacer.Call(func() (bool, error) {
+			rewriteRequest = rewriteRequest.Context(ctx)
+			if o.fs.opt.UserProject != "" {
+				rewriteRequest.UserProject(o.fs.opt.UserProject)
+			}
+			rewriteResponse, err = rewriteRequest.Do()
+			return shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set storage class: %w", err)
+		}
+		if rewriteResponse.Done {
+			break
+		}
+		rewriteRequest.RewriteToken(rewriteResponse.RewriteToken)
+		fs.Debugf(o, "Continuing rewrite %d bytes done", rewriteResponse.TotalBytesRewritten)
+	}
+	o.setMetaData(rewriteResponse.Resource)
+	return nil
+}
+
+// GetTier returns the storage class of the object
+func (o *Object) GetTier() string {
+	return o.tier
+}
+
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs          = &Fs{}
-	_ fs.Copier      = &Fs{}
-	_ fs.PutStreamer = &Fs{}
-	_ fs.ListRer     = &Fs{}
-	_ fs.ListPer     = &Fs{}
-	_ fs.Object      = &Object{}
-	_ fs.MimeTyper   = &Object{}
+	_ fs.Fs           = &Fs{}
+	_ fs.Copier       = &Fs{}
+	_ fs.PutStreamer  = &Fs{}
+	_ fs.ListRer      = &Fs{}
+	_ fs.ListPer      = &Fs{}
+	_ fs.BatchDeleter = &Fs{}
+	_ fs.Object       = &Object{}
+	_ fs.MimeTyper    = &Object{}
+	_ fs.GetTierer    = &Object{}
+	_ fs.SetTierer    = &Object{}
 )
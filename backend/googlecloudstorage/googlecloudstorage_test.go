@@ -13,8 +13,9 @@ import (
 // TestIntegration runs integration tests against the remote
 func TestIntegration(t *testing.T) {
 	fstests.Run(t, &fstests.Opt{
-		RemoteName: "TestGoogleCloudStorage:",
-		NilObject:  (*googlecloudstorage.Object)(nil),
+		RemoteName:  "TestGoogleCloudStorage:",
+		NilObject:   (*googlecloudstorage.Object)(nil),
+		TiersToTest: []string{"STANDARD", "NEARLINE"},
 	})
 }
 
@@ -24,8 +25,9 @@ func TestIntegration2(t *testing.T) {
 	}
 	name := "TestGoogleCloudStorage"
 	fstests.Run(t, &fstests.Opt{
-		RemoteName: name + ":",
-		NilObject:  (*googlecloudstorage.Object)(nil),
+		RemoteName:  name + ":",
+		NilObject:   (*googlecloudstorage.Object)(nil),
+		TiersToTest: []string{"STANDARD", "NEARLINE"},
 		ExtraConfig: []fstests.ExtraConfigItem{
 			{Name: name, Key: "directory_markers", Value: "true"},
 		},
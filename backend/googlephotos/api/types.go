@@ -184,6 +184,11 @@ type BatchCreateResponse struct {
 	} `json:"newMediaItemResults"`
 }
 
+// BatchAddItems is for adding items to an album
+type BatchAddItems struct {
+	MediaItemIDs []string `json:"mediaItemIds"`
+}
+
 // BatchRemoveItems is for removing items from an album
 type BatchRemoveItems struct {
 	MediaItemIDs []string `json:"mediaItemIds"`
@@ -30,6 +30,7 @@ import (
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/lib/batcher"
 	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/kv"
 	"github.com/rclone/rclone/lib/oauthutil"
 	"github.com/rclone/rclone/lib/pacer"
 	"github.com/rclone/rclone/lib/rest"
@@ -43,6 +44,7 @@ var (
 	errAlbumDelete = errors.New("google photos API does not implement deleting albums")
 	errRemove      = errors.New("google photos API only implements removing files from albums")
 	errOwnAlbums   = errors.New("google photos API only allows uploading to albums rclone created")
+	errRemoveOwn   = errors.New("google photos API only allows removing files from albums rclone created")
 	errReadOnly    = errors.New("can't upload files in read only mode")
 )
 
@@ -206,6 +208,28 @@ Then supply the parameter |--gphotos-proxy "http://localhost:8282"| to make
 rclone use the proxy.
 `, "|", "`"),
 			Advanced: true,
+		}, {
+			Name:    "record_hashes",
+			Default: false,
+			Help: `Record the hashes of uploaded media in a local database.
+
+The Google Photos API doesn't return hashes of media items so rclone
+can only compare them by name. If this is set then rclone records the
+MD5 and SHA-1 hashes of the files it uploads in a database in the
+cache directory. This lets rclone
+
+- detect changed files when syncing with --checksum, replacing the old
+  version in the album with the new one
+- avoid uploading a file which is already in the library, adding the
+  existing media item to the album instead
+
+Only media uploaded by rclone on this computer with this set have
+hashes.
+
+The Google Photos API doesn't download the original bytes unless the
+proxy is used, so use --ignore-checksum when downloading with this
+set.`,
+			Advanced: true,
 		}, {
 			Name:     config.ConfigEncoding,
 			Help:     config.ConfigEncodingHelp,
@@ -228,6 +252,7 @@ type Options struct {
 	BatchSize       int                  `config:"batch_size"`
 	BatchTimeout    fs.Duration          `config:"batch_timeout"`
 	Proxy           string               `config:"proxy"`
+	RecordHashes    bool                 `config:"record_hashes"`
 }
 
 // Fs represents a remote storage server
@@ -247,6 +272,7 @@ type Fs struct {
 	uploaded   dirtree.DirTree        // record of uploaded items
 	createMu   sync.Mutex             // held when creating albums to prevent dupes
 	batcher    *batcher.Batcher[uploadedItem, *api.MediaItem]
+	db         *kv.DB // hashes of uploaded media if record_hashes is set
 }
 
 // Object describes a storage object
@@ -260,6 +286,8 @@ type Object struct {
 	bytes    int64     // Bytes in the object
 	modTime  time.Time // Modified time of the object
 	mimeType string
+	md5      string // MD5 recorded when uploaded, if known
+	sha1     string // SHA-1 recorded when uploaded, if known
 }
 
 // ------------------------------------------------------------
@@ -385,6 +413,16 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	}).Fill(ctx, f)
 	f.srv.SetErrorHandler(errorHandler)
 
+	if opt.RecordHashes {
+		if !kv.Supported() {
+			return nil, errors.New("record_hashes is not supported on this OS")
+		}
+		f.db, err = kv.Start(ctx, hashFacility, f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open hash database: %w", err)
+		}
+	}
+
 	_, _, pattern := patterns.match(f.root, "", true)
 	if pattern != nil && pattern.isFile {
 		oldRoot := f.root
@@ -834,7 +872,7 @@ func (f *Fs) Rmdir(ctx context.Context, dir string) (err error) {
 		return fs.ErrorDirNotFound
 	}
 	_ = album
-	return errAlbumDelete
+	return fserrors.NoRetryError(errAlbumDelete)
 }
 
 // Precision returns the precision
@@ -844,6 +882,9 @@ func (f *Fs) Precision() time.Duration {
 
 // Hashes returns the supported hash sets.
 func (f *Fs) Hashes() hash.Set {
+	if f.db != nil {
+		return hash.NewHashSet(hash.MD5, hash.SHA1)
+	}
 	return hash.Set(hash.None)
 }
 
@@ -851,6 +892,9 @@ func (f *Fs) Hashes() hash.Set {
 // cached connections.
 func (f *Fs) Shutdown(ctx context.Context) error {
 	f.batcher.Shutdown()
+	if f.db != nil {
+		return f.db.Stop(false)
+	}
 	return nil
 }
 
@@ -874,8 +918,17 @@ func (o *Object) Remote() string {
 	return o.remote
 }
 
-// Hash returns the Md5sum of an object returning a lowercase hex string
+// Hash returns the hash recorded when the object was uploaded if known
 func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
+	if o.fs.db == nil {
+		return "", hash.ErrUnsupported
+	}
+	switch t {
+	case hash.MD5:
+		return o.md5, nil
+	case hash.SHA1:
+		return o.sha1, nil
+	}
 	return "", hash.ErrUnsupported
 }
 
@@ -921,6 +974,10 @@ func (o *Object) setMetaData(info *api.MediaItem) {
 	o.bytes = -1 // FIXME
 	o.mimeType = info.MimeType
 	o.modTime = info.MediaMetadata.CreationTime
+	o.md5, o.sha1 = "", ""
+	if rec := o.fs.getHashRecord(o.id); rec != nil {
+		o.md5, o.sha1 = rec.MD5, rec.SHA1
+	}
 }
 
 // readMetaData gets the metadata if it hasn't already been fetched
@@ -941,20 +998,11 @@ func (o *Object) readMetaData(ctx context.Context) (err error) {
 	}
 	// If have ID fetch it directly
 	if id := findID(fileName); id != "" {
-		opts := rest.Opts{
-			Method: "GET",
-			Path:   "/mediaItems/" + id,
-		}
-		var item api.MediaItem
-		var resp *http.Response
-		err = o.fs.pacer.Call(func() (bool, error) {
-			resp, err = o.fs.srv.CallJSON(ctx, &opts, nil, &item)
-			return shouldRetry(ctx, resp, err)
-		})
+		item, err := o.fs.getMediaItem(ctx, id)
 		if err != nil {
-			return fmt.Errorf("couldn't get media item: %w", err)
+			return err
 		}
-		o.setMetaData(&item)
+		o.setMetaData(item)
 		return nil
 	}
 	// Otherwise list the directory the file is in
@@ -977,6 +1025,24 @@ func (o *Object) readMetaData(ctx context.Context) (err error) {
 	return fs.ErrorObjectNotFound
 }
 
+// getMediaItem reads the media item with the ID
+func (f *Fs) getMediaItem(ctx context.Context, id string) (item *api.MediaItem, err error) {
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/mediaItems/" + id,
+	}
+	item = new(api.MediaItem)
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, item)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get media item: %w", err)
+	}
+	return item, nil
+}
+
 // ModTime returns the modification time of the object
 //
 // It attempts to read the objects mtime and if that isn't present the
@@ -1140,14 +1206,37 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 
 		if !album.IsWriteable {
 			if o.fs.opt.ReadOnly {
-				return errReadOnly
+				return fserrors.NoRetryError(errReadOnly)
 			}
-			return errOwnAlbums
+			return fserrors.NoRetryError(errOwnAlbums)
 		}
 
 		albumID = album.ID
 	}
 
+	// If the media is already in the library add it to the album
+	// rather than uploading it again
+	if info := o.fs.findUploaded(ctx, src); info != nil {
+		fs.Debugf(o, "Already uploaded as %q so not uploading again", info.ID)
+		if albumID != "" && info.ID != o.id {
+			err = o.fs.addToAlbum(ctx, albumID, info.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return o.finishUpdate(ctx, info, albumID, pattern)
+	}
+
+	// Record the hashes of the media as it is uploaded
+	var hasher *hash.MultiHasher
+	if o.fs.db != nil {
+		hasher, err = hash.NewMultiHasherTypes(hash.NewHashSet(hash.MD5, hash.SHA1))
+		if err != nil {
+			return err
+		}
+		in = io.TeeReader(in, hasher)
+	}
+
 	// Upload the media item in exchange for an UploadToken
 	opts := rest.Opts{
 		Method:  "POST",
@@ -1199,7 +1288,29 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		return fmt.Errorf("failed to commit batch: %w", err)
 	}
 
+	if hasher != nil {
+		o.fs.putHashRecord(info.ID, &hashRecord{
+			MD5:  hasher.Sums()[hash.MD5],
+			SHA1: hasher.Sums()[hash.SHA1],
+			Size: hasher.Size(),
+		})
+	}
+
+	return o.finishUpdate(ctx, info, albumID, pattern)
+}
+
+// finishUpdate sets the object to the uploaded media item, removing
+// the version it replaces from the album so the album only holds the
+// latest version.
+func (o *Object) finishUpdate(ctx context.Context, info *api.MediaItem, albumID string, pattern *dirPattern) error {
+	oldID := o.id
 	o.setMetaData(info)
+	if albumID != "" && oldID != "" && oldID != info.ID {
+		err := o.fs.removeFromAlbum(ctx, albumID, oldID)
+		if err != nil {
+			return fmt.Errorf("uploaded new version but failed to remove the old one from the album: %w", err)
+		}
+	}
 
 	// Add upload to internal storage
 	if pattern.isUpload {
@@ -1210,28 +1321,68 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	return nil
 }
 
-// Remove an object
-func (o *Object) Remove(ctx context.Context) (err error) {
-	match, _, pattern := patterns.match(o.fs.root, o.remote, true)
-	if pattern == nil || !pattern.isFile || !pattern.canUpload || pattern.isUpload {
-		return errRemove
+// findUploaded returns the media item src was uploaded as by a
+// previous upload or nil if it isn't known to be in the library
+func (f *Fs) findUploaded(ctx context.Context, src fs.ObjectInfo) *api.MediaItem {
+	if f.db == nil {
+		return nil
 	}
-	albumTitle, fileName := match[1], match[2]
-	album, ok := o.fs.albums[false].get(albumTitle)
-	if !ok {
-		return fmt.Errorf("couldn't file %q in album %q for delete", fileName, albumTitle)
+	md5, err := src.Hash(ctx, hash.MD5)
+	if err != nil || md5 == "" {
+		return nil
+	}
+	id := f.findMD5(md5)
+	if id == "" {
+		return nil
+	}
+	rec := f.getHashRecord(id)
+	if rec == nil || (src.Size() >= 0 && rec.Size != src.Size()) {
+		return nil
 	}
+	info, err := f.getMediaItem(ctx, id)
+	if err != nil {
+		// The media item may have been deleted in the web interface
+		fs.Debugf(f, "Uploading again as media item %q can't be read: %v", id, err)
+		f.forgetHashRecord(id)
+		return nil
+	}
+	return info
+}
+
+// addToAlbum adds the media item with the ID to the album
+func (f *Fs) addToAlbum(ctx context.Context, albumID, id string) (err error) {
 	opts := rest.Opts{
 		Method:     "POST",
-		Path:       "/albums/" + album.ID + ":batchRemoveMediaItems",
+		Path:       "/albums/" + albumID + ":batchAddMediaItems",
+		NoResponse: true,
+	}
+	request := api.BatchAddItems{
+		MediaItemIDs: []string{id},
+	}
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, &request, nil)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't add item to album: %w", err)
+	}
+	return nil
+}
+
+// removeFromAlbum removes the media item with the ID from the album
+func (f *Fs) removeFromAlbum(ctx context.Context, albumID, id string) (err error) {
+	opts := rest.Opts{
+		Method:     "POST",
+		Path:       "/albums/" + albumID + ":batchRemoveMediaItems",
 		NoResponse: true,
 	}
 	request := api.BatchRemoveItems{
-		MediaItemIDs: []string{o.id},
+		MediaItemIDs: []string{id},
 	}
 	var resp *http.Response
-	err = o.fs.pacer.Call(func() (bool, error) {
-		resp, err = o.fs.srv.CallJSON(ctx, &opts, &request, nil)
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, &request, nil)
 		return shouldRetry(ctx, resp, err)
 	})
 	if err != nil {
@@ -1240,6 +1391,27 @@ func (o *Object) Remove(ctx context.Context) (err error) {
 	return nil
 }
 
+// Remove an object
+func (o *Object) Remove(ctx context.Context) (err error) {
+	match, _, pattern := patterns.match(o.fs.root, o.remote, true)
+	if pattern == nil || !pattern.isFile || !pattern.canUpload || pattern.isUpload {
+		return fserrors.NoRetryError(errRemove)
+	}
+	albumTitle, fileName := match[1], match[2]
+	albums, err := o.fs.listAlbums(ctx, false)
+	if err != nil {
+		return err
+	}
+	album, ok := albums.get(albumTitle)
+	if !ok {
+		return fmt.Errorf("couldn't find %q in album %q for delete", fileName, albumTitle)
+	}
+	if !album.IsWriteable {
+		return fserrors.NoRetryError(errRemoveOwn)
+	}
+	return o.fs.removeFromAlbum(ctx, album.ID, o.id)
+}
+
 // MimeType of an Object if known, "" otherwise
 func (o *Object) MimeType(ctx context.Context) string {
 	return o.mimeType
@@ -0,0 +1,154 @@
+// This file records the hashes of uploaded media items
+
+package googlephotos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/kv"
+)
+
+// hashFacility is the name of the database used by record_hashes
+const hashFacility = "gphotos-hashes"
+
+// Prefixes of the keys in the database
+const (
+	idKeyPrefix  = "id/"  // media item ID to hashRecord
+	md5KeyPrefix = "md5/" // MD5 to media item ID
+)
+
+// hashRecord is what is known about an uploaded media item
+//
+// The Google Photos API doesn't return hashes or sizes so these are
+// recorded as the media is uploaded.
+type hashRecord struct {
+	MD5  string `json:"md5"`
+	SHA1 string `json:"sha1"`
+	Size int64  `json:"size"`
+}
+
+// hashGet reads the record for a media item ID
+type hashGet struct {
+	id  string
+	rec *hashRecord
+}
+
+// Do the get
+func (op *hashGet) Do(ctx context.Context, b kv.Bucket) error {
+	data := b.Get([]byte(idKeyPrefix + op.id))
+	if data == nil {
+		return nil
+	}
+	rec := new(hashRecord)
+	if err := json.Unmarshal(data, rec); err != nil {
+		return err
+	}
+	op.rec = rec
+	return nil
+}
+
+// hashPut writes the record for a media item ID
+type hashPut struct {
+	id  string
+	rec *hashRecord
+}
+
+// Do the put
+func (op *hashPut) Do(ctx context.Context, b kv.Bucket) error {
+	data, err := json.Marshal(op.rec)
+	if err != nil {
+		return err
+	}
+	if err := b.Put([]byte(idKeyPrefix+op.id), data); err != nil {
+		return err
+	}
+	if op.rec.MD5 == "" {
+		return nil
+	}
+	return b.Put([]byte(md5KeyPrefix+op.rec.MD5), []byte(op.id))
+}
+
+// hashFind looks up the media item ID with an MD5
+type hashFind struct {
+	md5 string
+	id  string
+}
+
+// Do the find
+func (op *hashFind) Do(ctx context.Context, b kv.Bucket) error {
+	op.id = string(b.Get([]byte(md5KeyPrefix + op.md5)))
+	return nil
+}
+
+// hashForget removes the record for a media item ID
+type hashForget struct {
+	id string
+}
+
+// Do the delete
+func (op *hashForget) Do(ctx context.Context, b kv.Bucket) error {
+	data := b.Get([]byte(idKeyPrefix + op.id))
+	if data != nil {
+		var rec hashRecord
+		if json.Unmarshal(data, &rec) == nil && rec.MD5 != "" {
+			if string(b.Get([]byte(md5KeyPrefix+rec.MD5))) == op.id {
+				if err := b.Delete([]byte(md5KeyPrefix + rec.MD5)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return b.Delete([]byte(idKeyPrefix + op.id))
+}
+
+// getHashRecord returns the record for the media item ID or nil if
+// there isn't one or hashes aren't being recorded
+func (f *Fs) getHashRecord(id string) *hashRecord {
+	if f.db == nil || id == "" {
+		return nil
+	}
+	op := &hashGet{id: id}
+	err := f.db.Do(false, op)
+	if err != nil && !errors.Is(err, kv.ErrEmpty) {
+		fs.Debugf(f, "Failed to read hashes of %q: %v", id, err)
+	}
+	return op.rec
+}
+
+// putHashRecord records the hashes of the media item ID
+func (f *Fs) putHashRecord(id string, rec *hashRecord) {
+	if f.db == nil || id == "" {
+		return
+	}
+	err := f.db.Do(true, &hashPut{id: id, rec: rec})
+	if err != nil {
+		fs.Errorf(f, "Failed to record hashes of %q: %v", id, err)
+	}
+}
+
+// findMD5 returns the ID of the media item uploaded with the MD5 or ""
+func (f *Fs) findMD5(md5 string) string {
+	if f.db == nil || md5 == "" {
+		return ""
+	}
+	op := &hashFind{md5: md5}
+	err := f.db.Do(false, op)
+	if err != nil && !errors.Is(err, kv.ErrEmpty) {
+		fs.Debugf(f, "Failed to look up MD5 %q: %v", md5, err)
+	}
+	return op.id
+}
+
+// forgetHashRecord removes the record of the media item ID
+func (f *Fs) forgetHashRecord(id string) {
+	if f.db == nil || id == "" {
+		return
+	}
+	err := f.db.Do(true, &hashForget{id: id})
+	if err != nil {
+		fs.Debugf(f, "Failed to remove hashes of %q: %v", id, err)
+	}
+}
@@ -0,0 +1,57 @@
+package googlephotos
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/kv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashRecordsNoDB(t *testing.T) {
+	f := &Fs{name: "TestHashRecordsNoDB"}
+	f.putHashRecord("id1", &hashRecord{MD5: "md5", SHA1: "sha1", Size: 1})
+	assert.Nil(t, f.getHashRecord("id1"))
+	assert.Equal(t, "", f.findMD5("md5"))
+	f.forgetHashRecord("id1")
+	assert.Equal(t, hash.Set(hash.None), f.Hashes())
+}
+
+func TestHashRecords(t *testing.T) {
+	if !kv.Supported() {
+		t.Skip("kv not supported")
+	}
+	ctx := context.Background()
+	f := &Fs{name: "TestHashRecords"}
+	var err error
+	f.db, err = kv.Start(ctx, hashFacility, f)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, f.db.Stop(true))
+	}()
+	assert.Equal(t, hash.NewHashSet(hash.MD5, hash.SHA1), f.Hashes())
+
+	// Nothing recorded yet
+	assert.Nil(t, f.getHashRecord("id1"))
+	assert.Equal(t, "", f.findMD5("md5-1"))
+
+	rec1 := &hashRecord{MD5: "md5-1", SHA1: "sha1-1", Size: 100}
+	f.putHashRecord("id1", rec1)
+	assert.Equal(t, rec1, f.getHashRecord("id1"))
+	assert.Equal(t, "id1", f.findMD5("md5-1"))
+
+	// The same media uploaded again points the MD5 at the new item
+	f.putHashRecord("id2", rec1)
+	assert.Equal(t, "id2", f.findMD5("md5-1"))
+
+	// Forgetting an item the MD5 doesn't point to leaves the MD5
+	f.forgetHashRecord("id1")
+	assert.Nil(t, f.getHashRecord("id1"))
+	assert.Equal(t, "id2", f.findMD5("md5-1"))
+
+	f.forgetHashRecord("id2")
+	assert.Nil(t, f.getHashRecord("id2"))
+	assert.Equal(t, "", f.findMD5("md5-1"))
+}
@@ -24,8 +24,18 @@ func TestIntegration(t *testing.T) {
 		UnimplementableFsMethods: []string{
 			"OpenWriterAt",
 			"OpenChunkWriter",
+			"SetTierBatch",
+			"ChangedPaths",
+			"DeleteObjects",
+			"ListDeleted",
+			"Undelete",
+			"SoftDelete",
+			"PurgeDeleted",
+			"ResumeChunkWriter",
+			"ResumeWriterAt",
 		},
-		UnimplementableObjectMethods: []string{},
+		UnimplementableObjectMethods:    []string{"Tags", "SetTags"},
+		UnimplementableDirectoryMethods: []string{"ChangeToken"},
 	}
 	if *fstest.RemoteName == "" {
 		tempDir := filepath.Join(os.TempDir(), "rclone-hasher-test")
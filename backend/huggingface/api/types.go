@@ -0,0 +1,81 @@
+// Package api provides types used by the Hugging Face Hub API.
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// Error is returned by the Hub API on failure
+type Error struct {
+	Message    string `json:"error"`
+	Status     string `json:"-"`
+	StatusCode int    `json:"-"`
+}
+
+// Error satisfies the error interface
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Status
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, e.Status)
+}
+
+// Entry types returned in TreeEntry.Type
+const (
+	TypeFile      = "file"
+	TypeDirectory = "directory"
+)
+
+// LFS describes a file stored with Git LFS
+type LFS struct {
+	OID         string `json:"oid"` // SHA-256 of the contents
+	Size        int64  `json:"size"`
+	PointerSize int64  `json:"pointerSize"`
+}
+
+// LastCommit is the last commit to touch an entry, only returned
+// when the listing is expanded.
+type LastCommit struct {
+	ID    string    `json:"id"`
+	Title string    `json:"title"`
+	Date  time.Time `json:"date"`
+}
+
+// TreeEntry is an item returned by the tree and paths-info calls
+type TreeEntry struct {
+	Type       string      `json:"type"`
+	OID        string      `json:"oid"` // git blob or tree ID
+	Size       int64       `json:"size"`
+	Path       string      `json:"path"`
+	LFS        *LFS        `json:"lfs,omitempty"`
+	LastCommit *LastCommit `json:"lastCommit,omitempty"`
+}
+
+// Repo is an item returned when listing the repos of an author
+type Repo struct {
+	ID           string    `json:"id"`
+	Private      bool      `json:"private"`
+	LastModified time.Time `json:"lastModified"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Org is an organization the user belongs to
+type Org struct {
+	Name string `json:"name"`
+}
+
+// WhoAmI is the response for /api/whoami-v2
+type WhoAmI struct {
+	Name string `json:"name"`
+	Orgs []Org  `json:"orgs"`
+}
+
+// RepoInfo is the response for /api/{type}s/{repo}/revision/{revision}
+type RepoInfo struct {
+	ID           string    `json:"id"`
+	SHA          string    `json:"sha"`
+	LastModified time.Time `json:"lastModified"`
+	Private      bool      `json:"private"`
+	Gated        any       `json:"gated"`
+}
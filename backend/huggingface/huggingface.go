@@ -0,0 +1,664 @@
+// Package huggingface provides an interface to the Hugging Face Hub.
+//
+// Repos appear as directories under their owner, so the path
+// owner/repo/file refers to file in the repo owner/repo.
+package huggingface
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/backend/huggingface/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+const (
+	minSleep      = 10 * time.Millisecond
+	maxSleep      = 2 * time.Second
+	decayConstant = 2    // bigger for slower decay, exponential
+	listChunk     = 1000 // number of repos to list per page
+)
+
+var (
+	errorReadOnly = errors.New("huggingface remotes are read only")
+	errorNoOwner  = errors.New("can't list the root of the hub without a token - add an owner or repo to the path")
+)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "huggingface",
+		Description: "Hugging Face Hub",
+		NewFs:       NewFs,
+		CommandHelp: commandHelp,
+		Options: []fs.Option{{
+			Name: "token",
+			Help: `User access token.
+
+Needed for private and gated repos. Create one at
+https://huggingface.co/settings/tokens - a read token is enough.
+
+Leave blank to use the HF_TOKEN environment variable if set.`,
+			Sensitive: true,
+		}, {
+			Name:    "repo_type",
+			Help:    `Type of the repos to access.`,
+			Default: "model",
+			Examples: []fs.OptionExample{{
+				Value: "model",
+				Help:  "Models",
+			}, {
+				Value: "dataset",
+				Help:  "Datasets",
+			}, {
+				Value: "space",
+				Help:  "Spaces",
+			}},
+		}, {
+			Name: "revision",
+			Help: `Revision to read.
+
+This can be a branch, a tag or a commit hash. Use a commit hash to
+pin a sync to a fixed version of the repo.`,
+			Default: "main",
+		}, {
+			Name: "commit_times",
+			Help: `Use the date of the last commit to each file as its modification time.
+
+This makes listings slower as the Hub has to look up the commits.
+Without it files have no modification time so syncs compare sizes
+and hashes only.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name:     "endpoint",
+			Help:     `Endpoint of the Hub, for mirrors and private deployments.`,
+			Default:  "https://huggingface.co",
+			Advanced: true,
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Token       string `config:"token"`
+	RepoType    string `config:"repo_type"`
+	Revision    string `config:"revision"`
+	CommitTimes bool   `config:"commit_times"`
+	Endpoint    string `config:"endpoint"`
+}
+
+// Fs represents a remote Hugging Face Hub
+type Fs struct {
+	name     string       // name of this remote
+	root     string       // the path we are working on if any
+	opt      Options      // parsed config options
+	features *fs.Features // optional features
+	srv      *rest.Client // the connection to the Hub
+	pacer    *fs.Pacer    // pacer for API calls
+}
+
+// Object describes a file in a Hugging Face repo
+type Object struct {
+	fs      *Fs       // what this object is part of
+	remote  string    // the remote path
+	size    int64     // size of the object
+	modTime time.Time // date of the last commit if known
+	oid     string    // git blob ID
+	sha256  string    // SHA-256 of the contents for LFS files
+}
+
+// ------------------------------------------------------------
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	return fmt.Sprintf("Hugging Face %ss root '%s'", f.opt.RepoType, f.root)
+}
+
+// Precision of the commit times if enabled
+func (f *Fs) Precision() time.Duration {
+	if f.opt.CommitTimes {
+		return time.Second
+	}
+	return fs.ModTimeNotSupported
+}
+
+// Hashes returns the supported hash sets.
+//
+// Only files stored with LFS have a SHA-256, which is what large
+// model weights and dataset shards are normally stored as.
+func (f *Fs) Hashes() hash.Set {
+	return hash.Set(hash.SHA256)
+}
+
+// Features for this fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// retryErrorCodes is a slice of error codes that we will retry
+var retryErrorCodes = []int{
+	429, // Too Many Requests.
+	500, // Internal Server Error
+	502, // Bad Gateway
+	503, // Service Unavailable
+	504, // Gateway Timeout
+}
+
+// shouldRetry returns a boolean as to whether this resp and err
+// deserve to be retried.  It returns the err as a convenience
+func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if fserrors.ContextError(ctx, &err) {
+		return false, err
+	}
+	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
+}
+
+// errorHandler parses a non 2xx error response into an error
+func errorHandler(resp *http.Response) error {
+	errResponse := new(api.Error)
+	err := rest.DecodeJSON(resp, errResponse)
+	if err != nil {
+		fs.Debugf(nil, "Couldn't decode error response: %v", err)
+	}
+	errResponse.Status = resp.Status
+	errResponse.StatusCode = resp.StatusCode
+	return errResponse
+}
+
+// isNotFound returns true if err is a not found error from the Hub
+func isNotFound(err error) bool {
+	var apiErr *api.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// splitPath splits p into the owner, the repo ID and the path within
+// the repo.
+//
+// repoID is empty if p doesn't reach a repo.
+func splitPath(p string) (owner, repoID, filePath string) {
+	parts := strings.SplitN(p, "/", 3)
+	owner = parts[0]
+	if len(parts) >= 2 {
+		repoID = parts[0] + "/" + parts[1]
+	}
+	if len(parts) == 3 {
+		filePath = parts[2]
+	}
+	return owner, repoID, filePath
+}
+
+// apiURL returns the API URL for the repo with elements appended
+func (f *Fs) apiURL(repoID string, elem ...string) string {
+	u := f.opt.Endpoint + "/api/" + f.opt.RepoType + "s/" + repoID
+	for _, e := range elem {
+		u += "/" + e
+	}
+	return u
+}
+
+// resolveURL returns the download URL for filePath in repoID
+func (f *Fs) resolveURL(repoID, filePath string) string {
+	prefix := ""
+	if f.opt.RepoType != "model" {
+		prefix = "/" + f.opt.RepoType + "s"
+	}
+	return f.opt.Endpoint + prefix + "/" + repoID + "/resolve/" + url.PathEscape(f.opt.Revision) + "/" + rest.URLPathEscape(filePath)
+}
+
+// nextLink returns the URL of the next page from a Link header or ""
+func nextLink(header string) string {
+	for link := range strings.SplitSeq(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "rel" && strings.Trim(value, `"`) == "next" {
+				return target[1 : len(target)-1]
+			}
+		}
+	}
+	return ""
+}
+
+// listPages calls fn for each page of the paged listing at rootURL
+func listPages[T any](ctx context.Context, f *Fs, rootURL string, params url.Values, fn func([]T)) error {
+	opts := rest.Opts{
+		Method:     "GET",
+		RootURL:    rootURL,
+		Parameters: params,
+	}
+	for {
+		var (
+			page []T
+			resp *http.Response
+			err  error
+		)
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err = f.srv.CallJSON(ctx, &opts, nil, &page)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return err
+		}
+		fn(page)
+		next := nextLink(resp.Header.Get("Link"))
+		if next == "" {
+			return nil
+		}
+		// The next link contains all the parameters
+		opts.RootURL = next
+		opts.Parameters = nil
+	}
+}
+
+// newObject makes an Object from a tree entry
+func (f *Fs) newObject(remote string, entry *api.TreeEntry) *Object {
+	o := &Object{
+		fs:     f,
+		remote: remote,
+		size:   entry.Size,
+		oid:    entry.OID,
+	}
+	if entry.LFS != nil {
+		o.size = entry.LFS.Size
+		o.sha256 = entry.LFS.OID
+	}
+	if entry.LastCommit != nil {
+		o.modTime = entry.LastCommit.Date
+	}
+	return o
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	owner, repoID, filePath := splitPath(path.Join(f.root, dir))
+	switch {
+	case owner == "":
+		return f.listOwners(ctx)
+	case repoID == "":
+		return f.listRepos(ctx, dir, owner)
+	}
+	elem := []string{"tree", url.PathEscape(f.opt.Revision)}
+	if filePath != "" {
+		elem = append(elem, rest.URLPathEscape(filePath))
+	}
+	params := url.Values{}
+	if f.opt.CommitTimes {
+		params.Set("expand", "true")
+	}
+	err = listPages(ctx, f, f.apiURL(repoID, elem...), params, func(page []api.TreeEntry) {
+		for i := range page {
+			entry := &page[i]
+			remote := path.Join(dir, path.Base(entry.Path))
+			switch entry.Type {
+			case api.TypeDirectory:
+				var modTime time.Time
+				if entry.LastCommit != nil {
+					modTime = entry.LastCommit.Date
+				}
+				entries = append(entries, fs.NewDir(remote, modTime).SetID(entry.OID))
+			case api.TypeFile:
+				entries = append(entries, f.newObject(remote, entry))
+			default:
+				fs.Debugf(f, "Ignoring %q of unknown type %q", remote, entry.Type)
+			}
+		}
+	})
+	if isNotFound(err) {
+		return nil, fs.ErrorDirNotFound
+	}
+	return entries, err
+}
+
+// listOwners lists the user and their organizations as directories
+func (f *Fs) listOwners(ctx context.Context) (entries fs.DirEntries, err error) {
+	if f.opt.Token == "" {
+		return nil, errorNoOwner
+	}
+	var whoami api.WhoAmI
+	opts := rest.Opts{
+		Method:  "GET",
+		RootURL: f.opt.Endpoint + "/api/whoami-v2",
+	}
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &whoami)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user: %w", err)
+	}
+	entries = append(entries, fs.NewDir(whoami.Name, time.Time{}))
+	for _, org := range whoami.Orgs {
+		entries = append(entries, fs.NewDir(org.Name, time.Time{}))
+	}
+	return entries, nil
+}
+
+// listRepos lists the repos of owner as directories
+func (f *Fs) listRepos(ctx context.Context, dir, owner string) (entries fs.DirEntries, err error) {
+	params := url.Values{
+		"author": {owner},
+		"limit":  {fmt.Sprint(listChunk)},
+		"full":   {"false"},
+	}
+	err = listPages(ctx, f, f.opt.Endpoint+"/api/"+f.opt.RepoType+"s", params, func(page []api.Repo) {
+		for _, repo := range page {
+			_, name, ok := strings.Cut(repo.ID, "/")
+			if !ok {
+				continue
+			}
+			entries = append(entries, fs.NewDir(path.Join(dir, name), repo.LastModified))
+		}
+	})
+	return entries, err
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	_, repoID, filePath := splitPath(path.Join(f.root, remote))
+	if filePath == "" {
+		return nil, fs.ErrorObjectNotFound
+	}
+	form := url.Values{"paths": {filePath}}
+	if f.opt.CommitTimes {
+		form.Set("expand", "true")
+	}
+	opts := rest.Opts{
+		Method:      "POST",
+		RootURL:     f.apiURL(repoID, "paths-info", url.PathEscape(f.opt.Revision)),
+		ContentType: "application/x-www-form-urlencoded",
+	}
+	var (
+		result []api.TreeEntry
+		resp   *http.Response
+		err    error
+	)
+	err = f.pacer.Call(func() (bool, error) {
+		opts.Body = strings.NewReader(form.Encode())
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &result)
+		return shouldRetry(ctx, resp, err)
+	})
+	if isNotFound(err) {
+		return nil, fs.ErrorObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		entry := &result[i]
+		if entry.Path != filePath {
+			continue
+		}
+		if entry.Type == api.TypeDirectory {
+			return nil, fs.ErrorIsDir
+		}
+		return f.newObject(remote, entry), nil
+	}
+	return nil, fs.ErrorObjectNotFound
+}
+
+// Put is not supported
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return nil, errorReadOnly
+}
+
+// Mkdir is not supported
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	return errorReadOnly
+}
+
+// Rmdir is not supported
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	return errorReadOnly
+}
+
+// PublicLink returns the download link for the file at the configured revision
+func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration, unlink bool) (string, error) {
+	if unlink {
+		return "", errorReadOnly
+	}
+	o, err := f.NewObject(ctx, remote)
+	if err != nil {
+		return "", err
+	}
+	_, repoID, filePath := splitPath(path.Join(f.root, o.Remote()))
+	return f.resolveURL(repoID, filePath), nil
+}
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "commit",
+	Short: "Show the commit hash of the configured revision.",
+	Long: `This shows the commit hash the configured revision points to for the
+repo in the remote path. Use it to pin later syncs to the same version.
+
+` + "```console" + `
+rclone backend commit huggingface:openai-community/gpt2
+rclone copy --huggingface-revision <hash> huggingface:openai-community/gpt2 /models/gpt2
+` + "```",
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out any, err error) {
+	switch name {
+	case "commit":
+		_, repoID, _ := splitPath(f.root)
+		if repoID == "" {
+			return nil, errors.New("need a repo in the remote path")
+		}
+		var info api.RepoInfo
+		opts := rest.Opts{
+			Method:  "GET",
+			RootURL: f.apiURL(repoID, "revision", url.PathEscape(f.opt.Revision)),
+		}
+		var resp *http.Response
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err = f.srv.CallJSON(ctx, &opts, nil, &info)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return info.SHA, nil
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// ------------------------------------------------------------
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info {
+	return o.fs
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// Hash returns the SHA-256 of LFS files or "" for other files
+func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
+	if t != hash.SHA256 {
+		return "", hash.ErrUnsupported
+	}
+	return o.sha256, nil
+}
+
+// Size returns the size of an object in bytes
+func (o *Object) Size() int64 {
+	return o.size
+}
+
+// ModTime returns the date of the last commit to the file if known
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+// SetModTime is not supported
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	return errorReadOnly
+}
+
+// Storable returns a boolean showing whether this object storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// ID returns the git blob ID of the Object
+func (o *Object) ID() string {
+	return o.oid
+}
+
+// Open an object for read
+//
+// LFS files are redirected to the CDN which supports ranges, so
+// downloads can be resumed.
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	fs.FixRangeOption(options, o.size)
+	_, repoID, filePath := splitPath(path.Join(o.fs.root, o.remote))
+	opts := rest.Opts{
+		Method:  "GET",
+		RootURL: o.fs.resolveURL(repoID, filePath),
+		Options: options,
+	}
+	var resp *http.Response
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Update is not supported
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	return errorReadOnly
+}
+
+// Remove is not supported
+func (o *Object) Remove(ctx context.Context) error {
+	return errorReadOnly
+}
+
+// ------------------------------------------------------------
+
+// NewFs constructs an Fs from the path
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	switch opt.RepoType {
+	case "model", "dataset", "space":
+	default:
+		return nil, fmt.Errorf("unknown repo_type %q - must be model, dataset or space", opt.RepoType)
+	}
+	if opt.Revision == "" {
+		opt.Revision = "main"
+	}
+	if opt.Token == "" {
+		opt.Token = os.Getenv("HF_TOKEN")
+	}
+	opt.Endpoint = strings.TrimSuffix(opt.Endpoint, "/")
+
+	root = strings.Trim(root, "/")
+	f := &Fs{
+		name:  name,
+		root:  root,
+		opt:   *opt,
+		srv:   rest.NewClient(fshttp.NewClient(ctx)).SetErrorHandler(errorHandler),
+		pacer: fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+	}
+	if opt.Token != "" {
+		// Go drops this header when redirected to the CDN
+		f.srv.SetHeader("Authorization", "Bearer "+opt.Token)
+	}
+	f.features = (&fs.Features{
+		CanHaveEmptyDirectories: true,
+	}).Fill(ctx, f)
+
+	if _, _, filePath := splitPath(root); filePath != "" {
+		// Check to see if the root actually an existing file
+		remote := path.Base(root)
+		f.root = path.Dir(root)
+		_, err := f.NewObject(ctx, remote)
+		if err != nil {
+			if errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorIsDir) {
+				// File doesn't exist so return old f
+				f.root = root
+				return f, nil
+			}
+			return nil, err
+		}
+		// return an error with an fs which points to the parent
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs           = (*Fs)(nil)
+	_ fs.PublicLinker = (*Fs)(nil)
+	_ fs.Commander    = (*Fs)(nil)
+	_ fs.Object       = (*Object)(nil)
+	_ fs.IDer         = (*Object)(nil)
+)
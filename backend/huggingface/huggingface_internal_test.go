@@ -0,0 +1,150 @@
+package huggingface
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/huggingface/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPath(t *testing.T) {
+	for _, test := range []struct {
+		in                      string
+		owner, repoID, filePath string
+	}{
+		{"", "", "", ""},
+		{"owner", "owner", "", ""},
+		{"owner/repo", "owner", "owner/repo", ""},
+		{"owner/repo/dir/file.bin", "owner", "owner/repo", "dir/file.bin"},
+	} {
+		owner, repoID, filePath := splitPath(test.in)
+		assert.Equal(t, test.owner, owner, test.in)
+		assert.Equal(t, test.repoID, repoID, test.in)
+		assert.Equal(t, test.filePath, filePath, test.in)
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	assert.Equal(t, "", nextLink(""))
+	assert.Equal(t, "https://hf.co/api/x?cursor=abc", nextLink(`<https://hf.co/api/x?cursor=abc>; rel="next"`))
+	assert.Equal(t, "https://hf.co/2", nextLink(`<https://hf.co/0>; rel="prev", <https://hf.co/2>; rel="next"`))
+	assert.Equal(t, "", nextLink(`<https://hf.co/0>; rel="prev"`))
+}
+
+// newTestFs makes an Fs talking to a fake Hub
+func newTestFs(t *testing.T, root string) (*Fs, error) {
+	const weights = "0123456789"
+	mux := http.NewServeMux()
+	var serverURL string
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(v))
+	}
+	mux.HandleFunc("GET /api/models/owner/repo/tree/v1.0", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			w.Header().Set("Link", "<"+serverURL+"/api/models/owner/repo/tree/v1.0?cursor=2>; rel=\"next\"")
+			writeJSON(w, []api.TreeEntry{{Type: "directory", OID: "tree1", Path: "dir"}})
+			return
+		}
+		writeJSON(w, []api.TreeEntry{{
+			Type: "file",
+			OID:  "blob1",
+			Size: 135,
+			Path: "model.bin",
+			LFS:  &api.LFS{OID: "8b2a", Size: int64(len(weights))},
+		}})
+	})
+	mux.HandleFunc("POST /api/models/owner/repo/paths-info/v1.0", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.Form.Get("paths") != "model.bin" {
+			writeJSON(w, []api.TreeEntry{})
+			return
+		}
+		writeJSON(w, []api.TreeEntry{{
+			Type: "file",
+			OID:  "blob1",
+			Size: 135,
+			Path: "model.bin",
+			LFS:  &api.LFS{OID: "8b2a", Size: int64(len(weights))},
+		}})
+	})
+	mux.HandleFunc("GET /owner/repo/resolve/v1.0/model.bin", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		http.ServeContent(w, r, "model.bin", time.Time{}, strings.NewReader(weights))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSON(w, api.Error{Message: "Repository not found"})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	serverURL = server.URL
+
+	m := configmap.Simple{
+		"type":      "huggingface",
+		"endpoint":  server.URL,
+		"repo_type": "model",
+		"revision":  "v1.0",
+		"token":     "secret",
+	}
+	f, err := NewFs(context.Background(), "TestHuggingFace", root, m)
+	if f == nil {
+		require.Error(t, err)
+		return nil, err
+	}
+	return f.(*Fs), err
+}
+
+func TestListAndOpen(t *testing.T) {
+	ctx := context.Background()
+	f, err := newTestFs(t, "owner/repo")
+	require.NoError(t, err)
+
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "dir", entries[0].Remote())
+	o, ok := entries[1].(*Object)
+	require.True(t, ok)
+	assert.Equal(t, "model.bin", o.Remote())
+	assert.Equal(t, int64(10), o.Size())
+	sha, err := o.Hash(ctx, hash.SHA256)
+	require.NoError(t, err)
+	assert.Equal(t, "8b2a", sha)
+
+	obj, err := f.NewObject(ctx, "model.bin")
+	require.NoError(t, err)
+	in, err := obj.Open(ctx, &fs.RangeOption{Start: 2, End: 4})
+	require.NoError(t, err)
+	data, err := io.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "234", string(data))
+
+	_, err = f.NewObject(ctx, "missing.bin")
+	assert.ErrorIs(t, err, fs.ErrorObjectNotFound)
+
+	_, err = f.List(ctx, "missing")
+	assert.ErrorIs(t, err, fs.ErrorDirNotFound)
+}
+
+func TestNewFsFile(t *testing.T) {
+	f, err := newTestFs(t, "owner/repo/model.bin")
+	assert.ErrorIs(t, err, fs.ErrorIsFile)
+	assert.Equal(t, "owner/repo", f.Root())
+
+	f, err = newTestFs(t, "owner/repo/dir")
+	require.NoError(t, err)
+	assert.Equal(t, "owner/repo/dir", f.Root())
+}
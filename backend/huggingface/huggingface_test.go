@@ -0,0 +1,17 @@
+// Test Hugging Face filesystem interface
+package huggingface_test
+
+import (
+	"testing"
+
+	"github.com/rclone/rclone/backend/huggingface"
+	"github.com/rclone/rclone/fstest/fstests"
+)
+
+// TestIntegration runs integration tests against the remote
+func TestIntegration(t *testing.T) {
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: "TestHuggingFace:",
+		NilObject:  (*huggingface.Object)(nil),
+	})
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/fshttp"
 	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/lib/bucket"
 	"github.com/rclone/rclone/lib/encoder"
 	"github.com/rclone/rclone/lib/pacer"
@@ -39,6 +40,7 @@ func init() {
 		Name:        "internetarchive",
 		Description: "Internet Archive",
 		NewFs:       NewFs,
+		CommandHelp: commandHelp,
 
 		MetadataInfo: &fs.MetadataInfo{
 			System: map[string]fs.MetadataHelp{
@@ -280,6 +282,21 @@ type ModMetadataResponse struct {
 	Error   string `json:"error"`
 }
 
+// ItemMetadataResponse represents the response for reading the item
+// level metadata from (frontend)/metadata/:item/metadata
+type ItemMetadataResponse struct {
+	Result map[string]any `json:"result"`
+	Error  string         `json:"error"`
+}
+
+// TaskResponse represents the response from the tasks API
+type TaskResponse struct {
+	// https://archive.org/developers/tasks.html
+	Success bool            `json:"success"`
+	Value   json.RawMessage `json:"value"`
+	Error   string          `json:"error"`
+}
+
 // Name of the remote (as passed into NewFs)
 func (f *Fs) Name() string {
 	return f.name
@@ -1334,6 +1351,347 @@ func trimPathPrefix(s, prefix string, enc encoder.MultiEncoder) string {
 	return enc.ToStandardPath(strings.TrimPrefix(s, prefix+"/"))
 }
 
+// items returns the items named in arg or the item in the root if
+// there are none
+func (f *Fs) items(arg []string) ([]string, error) {
+	if len(arg) > 0 {
+		return arg, nil
+	}
+	bucket, _ := f.split("")
+	if bucket == "" {
+		return nil, errors.New("need an item in the remote or as an argument")
+	}
+	return []string{bucket}, nil
+}
+
+// requestItemMetadata reads the item level metadata of item
+func (f *Fs) requestItemMetadata(ctx context.Context, item string) (metadata map[string]any, err error) {
+	var resp *http.Response
+	// make a GET request to (frontend)/metadata/:item/metadata
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   path.Join("/metadata/", item, "metadata"),
+	}
+	var result ItemMetadataResponse
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.front.CallJSON(ctx, &opts, nil, &result)
+		return f.shouldRetry(resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, errors.New(result.Error)
+	}
+	if result.Result == nil {
+		return nil, fs.ErrorDirNotFound
+	}
+	return result.Result, nil
+}
+
+// itemMetadataPatch makes a JSON patch to apply the changes to the
+// current metadata. An empty value in changes removes the key and
+// keys which already have the value aren't changed.
+func itemMetadataPatch(current map[string]any, changes map[string]string) []map[string]any {
+	keys := make([]string, 0, len(changes))
+	for k := range changes {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	patch := []map[string]any{}
+	for _, k := range keys {
+		v := changes[k]
+		old, exists := current[k]
+		op := map[string]any{"path": "/" + k}
+		switch {
+		case v == "" && !exists:
+			continue
+		case old == v:
+			continue
+		case v == "":
+			op["op"] = "remove"
+		case exists:
+			op["op"] = "replace"
+			op["value"] = v
+		default:
+			op["op"] = "add"
+			op["value"] = v
+		}
+		patch = append(patch, op)
+	}
+	return patch
+}
+
+// updateItemMetadata applies changes to the item level metadata of
+// item returning true if anything needed changing
+func (f *Fs) updateItemMetadata(ctx context.Context, item string, changes map[string]string) (changed bool, err error) {
+	current, err := f.requestItemMetadata(ctx, item)
+	if err != nil {
+		return false, err
+	}
+	patch := itemMetadataPatch(current, changes)
+	if len(patch) == 0 {
+		return false, nil
+	}
+	if operations.SkipDestructive(ctx, item, "update item metadata") {
+		return false, nil
+	}
+	res, err := json.Marshal(patch)
+	if err != nil {
+		return false, err
+	}
+	// https://archive.org/services/docs/api/md-write.html
+	params := url.Values{}
+	params.Add("-target", "metadata")
+	params.Add("-patch", string(res))
+	body := []byte(params.Encode())
+	bodyLen := int64(len(body))
+
+	var resp *http.Response
+	var result ModMetadataResponse
+	// make a POST request to (frontend)/metadata/:item/
+	opts := rest.Opts{
+		Method:        "POST",
+		Path:          path.Join("/metadata/", item),
+		Body:          bytes.NewReader(body),
+		ContentLength: &bodyLen,
+		ContentType:   "application/x-www-form-urlencoded",
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.front.CallJSON(ctx, &opts, nil, &result)
+		return f.shouldRetry(resp, err)
+	})
+	if err != nil {
+		return false, err
+	}
+	if !result.Success {
+		return false, errors.New(result.Error)
+	}
+	return true, nil
+}
+
+// callTasks calls the tasks API at (frontend)/services/tasks.php
+// returning the value from the response
+func (f *Fs) callTasks(ctx context.Context, opts *rest.Opts, request any) (value json.RawMessage, err error) {
+	var resp *http.Response
+	var result TaskResponse
+	opts.Path = "/services/tasks.php"
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.front.CallJSON(ctx, opts, request, &result)
+		return f.shouldRetry(resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, errors.New(result.Error)
+	}
+	return result.Value, nil
+}
+
+// derive queues a derive task on item
+func (f *Fs) derive(ctx context.Context, item string, opt map[string]string) (value json.RawMessage, err error) {
+	request := map[string]any{
+		"identifier": item,
+		"cmd":        "derive.php",
+	}
+	args := map[string]string{}
+	for k, v := range opt {
+		if k == "priority" {
+			priority, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("bad priority: %w", err)
+			}
+			request["priority"] = priority
+		} else {
+			args[k] = v
+		}
+	}
+	if len(args) > 0 {
+		request["args"] = args
+	}
+	if operations.SkipDestructive(ctx, item, "queue derive task") {
+		return nil, nil
+	}
+	opts := rest.Opts{
+		Method: "POST",
+	}
+	return f.callTasks(ctx, &opts, request)
+}
+
+// tasks reads the status of the tasks on item
+func (f *Fs) tasks(ctx context.Context, item string, opt map[string]string) (value json.RawMessage, err error) {
+	params := url.Values{}
+	params.Set("identifier", item)
+	params.Set("summary", "1")
+	if _, ok := opt["catalog"]; ok {
+		params.Set("catalog", "1")
+	}
+	if _, ok := opt["history"]; ok {
+		params.Set("history", "1")
+	}
+	opts := rest.Opts{
+		Method:     "GET",
+		Parameters: params,
+	}
+	return f.callTasks(ctx, &opts, nil)
+}
+
+// ItemResult is the result of a backend command on one item
+type ItemResult struct {
+	Item   string          `json:"item"`
+	Status string          `json:"status"`
+	Value  json.RawMessage `json:"value,omitempty"`
+}
+
+// forEachItem runs fn on each item collecting the results
+func (f *Fs) forEachItem(ctx context.Context, arg []string, fn func(item string) (status string, value any, err error)) (out any, err error) {
+	items, err := f.items(arg)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]ItemResult, 0, len(items))
+	for _, item := range items {
+		result := ItemResult{Item: item}
+		status, value, err := fn(item)
+		if err != nil {
+			fs.Errorf(item, "%v", err)
+			result.Status = err.Error()
+		} else {
+			result.Status = status
+			if value != nil {
+				result.Value, err = json.Marshal(value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "metadata",
+	Short: "Show or update the metadata of items.",
+	Long: `This command reads or updates the item level metadata, such as the
+title or collection, of the items given as arguments, or the item in
+the remote if there are none. This is different from the file level
+metadata which can be set with --metadata-set.
+
+With no options it shows the metadata of each item.
+
+` + "```console" + `
+rclone backend metadata internetarchive:item
+rclone backend metadata internetarchive: item1 item2 item3
+` + "```" + `
+
+Each option sets the metadata key to the value given, or removes it
+if the value is empty. Keys which already have the value given aren't
+changed.
+
+` + "```console" + `
+rclone backend metadata internetarchive: item1 item2 -o title="My title" -o subject=
+` + "```" + `
+
+The result is a list with the status of each item. Errors on one item
+are reported in its status and don't stop the others being updated.
+
+Note that you can use --interactive/-i or --dry-run with this command to see what
+it would do.`,
+	Opts: map[string]string{
+		"key": "Set the metadata key to the value, or remove it if the value is empty.",
+	},
+}, {
+	Name:  "derive",
+	Short: "Queue a derive task on items.",
+	Long: `This command queues a derive task on the items given as arguments,
+or the item in the remote if there are none.
+
+The derive task makes the secondary files, such as thumbnails and
+other formats, which Internet Archive shows on the web. It is normally
+queued on every upload. If you are uploading many files to an item it
+is kinder to the Internet Archive's infrastructure to skip this with
+--internetarchive-item-derive=false then queue one derive task at the
+end.
+
+` + "```console" + `
+rclone copy --internetarchive-item-derive=false /path/to/files internetarchive:item
+rclone backend derive internetarchive:item
+` + "```" + `
+
+Options other than priority are passed to the task as arguments.
+
+The result is a list with the status of each item and the task ID and
+log URL of the task queued.
+
+Note that you can use --interactive/-i or --dry-run with this command to see what
+it would do.`,
+	Opts: map[string]string{
+		"priority": "Priority of the task, from -10 to 10.",
+		"comment":  "Comment to record with the task.",
+	},
+}, {
+	Name:  "tasks",
+	Short: "Show the status of the tasks on items.",
+	Long: `This command shows the number of queued, running, errored and paused
+tasks on the items given as arguments, or the item in the remote if
+there are none.
+
+` + "```console" + `
+rclone backend tasks internetarchive:item
+rclone backend tasks internetarchive: item1 item2 -o catalog -o history
+` + "```" + `
+
+This can be used to wait for the derive tasks to finish before
+checking the results of an upload.`,
+	Opts: map[string]string{
+		"catalog": "Show the queued and running tasks.",
+		"history": "Show the finished tasks.",
+	},
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out any, err error) {
+	switch name {
+	case "metadata":
+		return f.forEachItem(ctx, arg, func(item string) (string, any, error) {
+			if len(opt) == 0 {
+				metadata, err := f.requestItemMetadata(ctx, item)
+				return "OK", metadata, err
+			}
+			changed, err := f.updateItemMetadata(ctx, item, opt)
+			if !changed {
+				return "Not changed", nil, err
+			}
+			return "Updated", nil, err
+		})
+	case "derive":
+		return f.forEachItem(ctx, arg, func(item string) (string, any, error) {
+			value, err := f.derive(ctx, item, opt)
+			if value == nil {
+				return "Not queued", nil, err
+			}
+			return "Queued", value, err
+		})
+	case "tasks":
+		return f.forEachItem(ctx, arg, func(item string) (string, any, error) {
+			value, err := f.tasks(ctx, item, opt)
+			return "OK", value, err
+		})
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
 var (
 	_ fs.Fs           = &Fs{}
 	_ fs.Copier       = &Fs{}
@@ -1341,6 +1699,7 @@ var (
 	_ fs.CleanUpper   = &Fs{}
 	_ fs.PublicLinker = &Fs{}
 	_ fs.Abouter      = &Fs{}
+	_ fs.Commander    = &Fs{}
 	_ fs.Object       = &Object{}
 	_ fs.Metadataer   = &Object{}
 )
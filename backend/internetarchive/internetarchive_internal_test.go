@@ -0,0 +1,111 @@
+package internetarchive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemMetadataPatch(t *testing.T) {
+	current := map[string]any{
+		"title":     "Old title",
+		"subject":   "cats",
+		"unchanged": "same",
+	}
+	patch := itemMetadataPatch(current, map[string]string{
+		"title":       "New title",
+		"subject":     "",
+		"description": "About cats",
+		"missing":     "",
+		"unchanged":   "same",
+	})
+	assert.Equal(t, []map[string]any{
+		{"op": "add", "path": "/description", "value": "About cats"},
+		{"op": "remove", "path": "/subject"},
+		{"op": "replace", "path": "/title", "value": "New title"},
+	}, patch)
+
+	assert.Equal(t, []map[string]any{}, itemMetadataPatch(current, nil))
+}
+
+func TestCommand(t *testing.T) {
+	ctx := context.Background()
+	var (
+		gotPatch string
+		gotTask  map[string]any
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metadata/item/metadata", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":{"title":"Old title"}}`))
+	})
+	mux.HandleFunc("GET /metadata/missing/metadata", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("POST /metadata/item", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "metadata", r.Form.Get("-target"))
+		gotPatch = r.Form.Get("-patch")
+		_, _ = w.Write([]byte(`{"success":true}`))
+	})
+	mux.HandleFunc("POST /services/tasks.php", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotTask))
+		_, _ = w.Write([]byte(`{"success":true,"value":{"task_id":42}}`))
+	})
+	mux.HandleFunc("GET /services/tasks.php", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "item", r.URL.Query().Get("identifier"))
+		assert.Equal(t, "1", r.URL.Query().Get("history"))
+		_, _ = w.Write([]byte(`{"success":true,"value":{"summary":{"queued":1}}}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	f, err := NewFs(ctx, "ia", "", configmap.Simple{
+		"endpoint":       ts.URL,
+		"front_endpoint": ts.URL,
+	})
+	require.NoError(t, err)
+	c := f.(*Fs)
+
+	_, err = c.Command(ctx, "metadata", nil, nil)
+	assert.ErrorContains(t, err, "need an item")
+
+	out, err := c.Command(ctx, "metadata", []string{"item", "missing"}, nil)
+	require.NoError(t, err)
+	results := out.([]ItemResult)
+	require.Len(t, results, 2)
+	assert.Equal(t, "OK", results[0].Status)
+	assert.JSONEq(t, `{"title":"Old title"}`, string(results[0].Value))
+	assert.Equal(t, "directory not found", results[1].Status)
+
+	out, err = c.Command(ctx, "metadata", []string{"item"}, map[string]string{"title": "Old title"})
+	require.NoError(t, err)
+	assert.Equal(t, "Not changed", out.([]ItemResult)[0].Status)
+	assert.Equal(t, "", gotPatch)
+
+	out, err = c.Command(ctx, "metadata", []string{"item"}, map[string]string{"title": "New title"})
+	require.NoError(t, err)
+	assert.Equal(t, "Updated", out.([]ItemResult)[0].Status)
+	assert.JSONEq(t, `[{"op":"replace","path":"/title","value":"New title"}]`, gotPatch)
+
+	out, err = c.Command(ctx, "derive", []string{"item"}, map[string]string{"priority": "-5", "comment": "hello"})
+	require.NoError(t, err)
+	results = out.([]ItemResult)
+	assert.Equal(t, "Queued", results[0].Status)
+	assert.JSONEq(t, `{"task_id":42}`, string(results[0].Value))
+	assert.Equal(t, map[string]any{
+		"identifier": "item",
+		"cmd":        "derive.php",
+		"priority":   float64(-5),
+		"args":       map[string]any{"comment": "hello"},
+	}, gotTask)
+
+	out, err = c.Command(ctx, "tasks", []string{"item"}, map[string]string{"history": ""})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"summary":{"queued":1}}`, string(out.([]ItemResult)[0].Value))
+}
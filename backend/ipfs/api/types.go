@@ -0,0 +1,121 @@
+// Package api provides types used by the Kubo RPC API.
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// Error is returned by the Kubo RPC API on failure
+//
+// See https://docs.ipfs.tech/reference/kubo/rpc/#http-status-codes
+type Error struct {
+	Message    string `json:"Message"`
+	Code       int    `json:"Code"`
+	Type       string `json:"Type"`
+	Status     string `json:"-"`
+	StatusCode int    `json:"-"`
+}
+
+// Error satisfies the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%s)", e.Message, e.Status)
+}
+
+// Types returned in FileStat.Type
+const (
+	TypeFile      = "file"
+	TypeDirectory = "directory"
+)
+
+// FileStat is the response for /api/v0/files/stat
+type FileStat struct {
+	Hash           string `json:"Hash"`
+	Size           int64  `json:"Size"`
+	CumulativeSize int64  `json:"CumulativeSize"`
+	Blocks         int    `json:"Blocks"`
+	Type           string `json:"Type"`
+	Mtime          int64  `json:"Mtime,omitempty"`
+	MtimeNsecs     int64  `json:"MtimeNsecs,omitempty"`
+}
+
+// ModTime returns the UnixFS modification time if set or the zero
+// time if not.
+func (s *FileStat) ModTime() time.Time {
+	if s.Mtime == 0 && s.MtimeNsecs == 0 {
+		return time.Time{}
+	}
+	return time.Unix(s.Mtime, s.MtimeNsecs)
+}
+
+// Entry types returned in FilesEntry.Type
+const (
+	EntryTypeFile      = 0
+	EntryTypeDirectory = 1
+)
+
+// FilesEntry is an item in the response for /api/v0/files/ls
+type FilesEntry struct {
+	Name string `json:"Name"`
+	Type int    `json:"Type"`
+	Size int64  `json:"Size"`
+	Hash string `json:"Hash"`
+}
+
+// FilesList is the response for /api/v0/files/ls
+type FilesList struct {
+	Entries []FilesEntry `json:"Entries"`
+}
+
+// UnixFS data types returned in Link.Type
+const (
+	LinkTypeRaw       = 0
+	LinkTypeDirectory = 1
+	LinkTypeFile      = 2
+	LinkTypeMetadata  = 3
+	LinkTypeSymlink   = 4
+	LinkTypeHAMTShard = 5
+)
+
+// Link is a directory entry in the response for /api/v0/ls
+type Link struct {
+	Name   string `json:"Name"`
+	Hash   string `json:"Hash"`
+	Size   int64  `json:"Size"`
+	Type   int    `json:"Type"`
+	Target string `json:"Target"`
+}
+
+// LsObject is a listed object in the response for /api/v0/ls
+type LsObject struct {
+	Hash  string `json:"Hash"`
+	Links []Link `json:"Links"`
+}
+
+// LsOutput is the response for /api/v0/ls
+type LsOutput struct {
+	Objects []LsObject `json:"Objects"`
+}
+
+// PinOutput is the response for /api/v0/pin/add and /api/v0/pin/rm
+type PinOutput struct {
+	Pins []string `json:"Pins"`
+}
+
+// RepoStat is the response for /api/v0/repo/stat
+type RepoStat struct {
+	RepoSize   int64  `json:"RepoSize"`
+	StorageMax int64  `json:"StorageMax"`
+	NumObjects int64  `json:"NumObjects"`
+	RepoPath   string `json:"RepoPath"`
+	Version    string `json:"Version"`
+}
+
+// Version is the response for /api/v0/version
+type Version struct {
+	Version string `json:"Version"`
+	Commit  string `json:"Commit"`
+	Repo    string `json:"Repo"`
+	System  string `json:"System"`
+	Golang  string `json:"Golang"`
+}
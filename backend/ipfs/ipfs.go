@@ -0,0 +1,988 @@
+// Package ipfs provides an interface to IPFS via the Kubo RPC API.
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/backend/ipfs/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+const (
+	minSleep      = 10 * time.Millisecond
+	maxSleep      = 2 * time.Second
+	decayConstant = 2 // bigger for slower decay, exponential
+)
+
+var (
+	errorReadOnly = errors.New("ipfs remotes rooted at /ipfs/ or /ipns/ are read only")
+)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "ipfs",
+		Description: "IPFS via a Kubo node",
+		NewFs:       NewFs,
+		CommandHelp: commandHelp,
+		MetadataInfo: &fs.MetadataInfo{
+			System: map[string]fs.MetadataHelp{
+				"cid": {
+					Help:     "Content identifier of the file",
+					Type:     "string",
+					Example:  "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku",
+					ReadOnly: true,
+				},
+			},
+			Help: `The CID of each file is available as read only metadata.`,
+		},
+		Options: []fs.Option{{
+			Name: "api_url",
+			Help: `Kubo RPC API URL, like http://127.0.0.1:5001.
+
+Keep the default if the Kubo daemon runs on localhost. Note that the
+RPC API gives full control of the node so it should not be exposed
+to untrusted networks.`,
+			Default:   "http://127.0.0.1:5001",
+			Sensitive: true,
+		}, {
+			Name: "api_auth",
+			Help: `Authorization header to send with each RPC request.
+
+Use this if the node has API.Authorizations configured, for example
+"Bearer mysecret" or "Basic dXNlcjpwYXNz".`,
+			Sensitive: true,
+		}, {
+			Name: "pin",
+			Help: `Pin files after they are written.
+
+Files in MFS are kept by the node regardless, but pinning them means
+they stay available even if they are later removed from MFS, and lets
+pinning services which follow the node's pins pick them up.`,
+			Default: true,
+		}, {
+			Name: "unpin",
+			Help: `Unpin the previous CID when a file is overwritten or deleted.
+
+Only use this if the pins on the node are managed solely by this
+remote. Content shared with other files or pinned by other tools
+will be unpinned too.`,
+			Default:  false,
+			Advanced: true,
+		}, {
+			Name:    "gateway_url",
+			Help:    `Gateway used to make public links.`,
+			Default: "https://ipfs.io",
+		}, {
+			Name:     "cid_version",
+			Help:     `CID version to use for newly written files.`,
+			Default:  1,
+			Advanced: true,
+		}, {
+			Name:     "raw_leaves",
+			Help:     `Use raw blocks for the leaf nodes of newly written files.`,
+			Default:  true,
+			Advanced: true,
+		}, {
+			Name:     "hash",
+			Help:     `Multihash function to use for newly written files.`,
+			Default:  "sha2-256",
+			Advanced: true,
+		}, {
+			Name:     config.ConfigEncoding,
+			Help:     config.ConfigEncodingHelp,
+			Advanced: true,
+			Default: encoder.Base |
+				encoder.EncodeInvalidUtf8,
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	APIURL     string               `config:"api_url"`
+	APIAuth    string               `config:"api_auth"`
+	Pin        bool                 `config:"pin"`
+	Unpin      bool                 `config:"unpin"`
+	GatewayURL string               `config:"gateway_url"`
+	CIDVersion int                  `config:"cid_version"`
+	RawLeaves  bool                 `config:"raw_leaves"`
+	Hash       string               `config:"hash"`
+	Enc        encoder.MultiEncoder `config:"encoding"`
+}
+
+// Fs represents a remote Kubo node
+type Fs struct {
+	name      string       // name of this remote
+	root      string       // the path we are working on if any
+	opt       Options      // parsed config options
+	features  *fs.Features // optional features
+	srv       *rest.Client // the connection to the Kubo RPC API
+	pacer     *fs.Pacer    // pacer for API calls
+	immutable bool         // set if root is an /ipfs/ or /ipns/ path
+}
+
+// Object describes an IPFS file
+type Object struct {
+	fs      *Fs
+	remote  string
+	size    int64
+	cid     string
+	modTime time.Time
+}
+
+// isImmutable returns true if root is a path into the immutable
+// /ipfs/ or /ipns/ namespaces rather than into MFS.
+func isImmutable(root string) bool {
+	first, _, _ := strings.Cut(root, "/")
+	return first == "ipfs" || first == "ipns"
+}
+
+// ------------------------------------------------------------
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	return fmt.Sprintf("IPFS /%s", f.root)
+}
+
+// Precision is unsupported because MFS doesn't keep modification times
+func (f *Fs) Precision() time.Duration {
+	return fs.ModTimeNotSupported
+}
+
+// Hashes returns the supported hash sets.
+//
+// CIDs depend on how the file was chunked so they can't be compared
+// with hashes calculated locally.
+func (f *Fs) Hashes() hash.Set {
+	return hash.Set(hash.None)
+}
+
+// Features for this fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// ipfsPath returns the path on the node of remote
+func (f *Fs) ipfsPath(remote string) string {
+	return "/" + f.opt.Enc.FromStandardPath(path.Join(f.root, remote))
+}
+
+// call makes an RPC call to the Kubo node decoding the response
+// into result if it is not nil.
+func (f *Fs) call(ctx context.Context, endpoint string, params url.Values, result any) (err error) {
+	var resp *http.Response
+	opts := rest.Opts{
+		Method:     "POST",
+		Path:       "/api/v0/" + endpoint,
+		Parameters: params,
+	}
+	err = f.pacer.Call(func() (bool, error) {
+		if result != nil {
+			resp, err = f.srv.CallJSON(ctx, &opts, nil, result)
+		} else {
+			opts.NoResponse = true
+			resp, err = f.srv.Call(ctx, &opts)
+		}
+		return f.shouldRetry(resp, err)
+	})
+	return err
+}
+
+// checkWritable returns an error if the Fs can't be written to
+func (f *Fs) checkWritable() error {
+	if f.immutable {
+		return errorReadOnly
+	}
+	return nil
+}
+
+// stat reads the info for the remote path
+func (f *Fs) stat(ctx context.Context, remote string) (info *api.FileStat, err error) {
+	info = new(api.FileStat)
+	err = f.call(ctx, "files/stat", url.Values{"arg": {f.ipfsPath(remote)}}, info)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// newObject makes an Object from the stat info
+func (f *Fs) newObject(remote string, info *api.FileStat) *Object {
+	return &Object{
+		fs:      f,
+		remote:  remote,
+		size:    info.Size,
+		cid:     info.Hash,
+		modTime: info.ModTime(),
+	}
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	if f.immutable {
+		return f.listImmutable(ctx, dir)
+	}
+	var result api.FilesList
+	params := url.Values{
+		"arg":  {f.ipfsPath(dir)},
+		"long": {"true"},
+		"U":    {"true"},
+	}
+	err = f.call(ctx, "files/ls", params, &result)
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		return nil, fs.ErrorDirNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range result.Entries {
+		remote := path.Join(dir, f.opt.Enc.ToStandardName(item.Name))
+		switch item.Type {
+		case api.EntryTypeDirectory:
+			d := fs.NewDir(remote, time.Time{}).SetID(item.Hash)
+			entries = append(entries, d)
+		case api.EntryTypeFile:
+			entries = append(entries, &Object{
+				fs:     f,
+				remote: remote,
+				size:   item.Size,
+				cid:    item.Hash,
+			})
+		default:
+			fs.Debugf(f, "Ignoring %q of unknown type %d", remote, item.Type)
+		}
+	}
+	return entries, nil
+}
+
+// listImmutable lists dir under an /ipfs/ or /ipns/ root
+func (f *Fs) listImmutable(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	var result api.LsOutput
+	params := url.Values{
+		"arg":          {f.ipfsPath(dir)},
+		"resolve-type": {"true"},
+		"size":         {"true"},
+	}
+	err = f.call(ctx, "ls", params, &result)
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		return nil, fs.ErrorDirNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range result.Objects {
+		for _, link := range object.Links {
+			remote := path.Join(dir, f.opt.Enc.ToStandardName(link.Name))
+			switch link.Type {
+			case api.LinkTypeDirectory, api.LinkTypeHAMTShard:
+				d := fs.NewDir(remote, time.Time{}).SetID(link.Hash)
+				entries = append(entries, d)
+			case api.LinkTypeFile, api.LinkTypeRaw:
+				entries = append(entries, &Object{
+					fs:     f,
+					remote: remote,
+					size:   link.Size,
+					cid:    link.Hash,
+				})
+			default:
+				fs.Debugf(f, "Ignoring %q of unsupported type %d", remote, link.Type)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	info, err := f.stat(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	if info.Type == api.TypeDirectory {
+		return nil, fs.ErrorIsDir
+	}
+	return f.newObject(remote, info), nil
+}
+
+// Put the object into the MFS of the node
+//
+// Copy the reader in to the new object which is returned.
+//
+// The new object may have been created if an error is returned
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	o := &Object{
+		fs:     f,
+		remote: src.Remote(),
+	}
+	return o, o.Update(ctx, in, src, options...)
+}
+
+// PutStream uploads to the remote path with the modTime given of indeterminate size
+func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return f.Put(ctx, in, src, options...)
+}
+
+// Mkdir creates the directory if it doesn't exist
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	params := url.Values{
+		"arg":         {f.ipfsPath(dir)},
+		"parents":     {"true"},
+		"cid-version": {strconv.Itoa(f.opt.CIDVersion)},
+	}
+	err := f.call(ctx, "files/mkdir", params, nil)
+	if errors.Is(err, fs.ErrorDirExists) {
+		err = nil
+	}
+	return err
+}
+
+// Rmdir deletes the directory if it is empty
+//
+// Returns an error if it isn't empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	entries, err := f.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 0 {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	if f.root == "" && dir == "" {
+		// The MFS root can't be removed
+		return nil
+	}
+	return f.remove(ctx, dir, true)
+}
+
+// remove deletes remote from MFS
+func (f *Fs) remove(ctx context.Context, remote string, recursive bool) error {
+	params := url.Values{"arg": {f.ipfsPath(remote)}}
+	if recursive {
+		params.Set("recursive", "true")
+	}
+	return f.call(ctx, "files/rm", params, nil)
+}
+
+// Purge deletes all the files in the directory
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	if f.root == "" && dir == "" {
+		// The MFS root can't be removed
+		return fs.ErrorCantPurge
+	}
+	err := f.remove(ctx, dir, true)
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		err = fs.ErrorDirNotFound
+	}
+	return err
+}
+
+// pin pins or unpins cid on the node
+func (f *Fs) pin(ctx context.Context, cid string, add bool) error {
+	endpoint := "pin/rm"
+	if add {
+		endpoint = "pin/add"
+	}
+	var result api.PinOutput
+	err := f.call(ctx, endpoint, url.Values{"arg": {cid}}, &result)
+	if err != nil && !add && errors.Is(err, errNotPinned) {
+		fs.Debugf(f, "%s was not pinned", cid)
+		return nil
+	}
+	return err
+}
+
+// replaceCID pins the new CID and unpins the old one as configured
+func (f *Fs) replaceCID(ctx context.Context, oldCID, newCID string) error {
+	if f.opt.Pin && newCID != "" {
+		if err := f.pin(ctx, newCID, true); err != nil {
+			return fmt.Errorf("failed to pin %s: %w", newCID, err)
+		}
+	}
+	if f.opt.Unpin && oldCID != "" && oldCID != newCID {
+		if err := f.pin(ctx, oldCID, false); err != nil {
+			return fmt.Errorf("failed to unpin %s: %w", oldCID, err)
+		}
+	}
+	return nil
+}
+
+// isSameNode returns true if src is on the same Kubo node as f
+func (f *Fs) isSameNode(src fs.Object) (*Object, bool) {
+	srcObj, ok := src.(*Object)
+	if !ok || srcObj.fs.opt.APIURL != f.opt.APIURL {
+		return nil, false
+	}
+	return srcObj, true
+}
+
+// removeExisting removes remote if it exists so it can be replaced
+func (f *Fs) removeExisting(ctx context.Context, remote string) (oldCID string, err error) {
+	info, err := f.stat(ctx, remote)
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.Type == api.TypeDirectory {
+		return "", fs.ErrorIsDir
+	}
+	return info.Hash, f.remove(ctx, remote, false)
+}
+
+// mkParentDir makes the parent of remote if it doesn't exist
+func (f *Fs) mkParentDir(ctx context.Context, remote string) error {
+	dir := path.Dir(remote)
+	if dir == "." {
+		dir = ""
+	}
+	return f.Mkdir(ctx, dir)
+}
+
+// Copy src to this remote using server-side copy operations.
+//
+// This is stored with the remote path given.
+//
+// It returns the destination Object and a possible error.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantCopy
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := f.isSameNode(src)
+	if !ok || srcObj.cid == "" {
+		fs.Debugf(src, "Can't copy - not same remote type")
+		return nil, fs.ErrorCantCopy
+	}
+	if err := f.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := f.mkParentDir(ctx, remote); err != nil {
+		return nil, err
+	}
+	oldCID, err := f.removeExisting(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{"arg": {"/ipfs/" + srcObj.cid, f.ipfsPath(remote)}}
+	err = f.call(ctx, "files/cp", params, nil)
+	if err != nil {
+		return nil, err
+	}
+	dstObj, err := f.NewObject(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	return dstObj, f.replaceCID(ctx, oldCID, dstObj.(*Object).cid)
+}
+
+// Move src to this remote using server-side move operations.
+//
+// This is stored with the remote path given.
+//
+// It returns the destination Object and a possible error.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantMove
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := f.isSameNode(src)
+	if !ok || srcObj.fs.immutable {
+		fs.Debugf(src, "Can't move - not same remote type")
+		return nil, fs.ErrorCantMove
+	}
+	if err := f.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := f.mkParentDir(ctx, remote); err != nil {
+		return nil, err
+	}
+	oldCID, err := f.removeExisting(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{"arg": {srcObj.fs.ipfsPath(srcObj.remote), f.ipfsPath(remote)}}
+	err = f.call(ctx, "files/mv", params, nil)
+	if err != nil {
+		return nil, err
+	}
+	dstObj, err := f.NewObject(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	if oldCID == dstObj.(*Object).cid {
+		oldCID = ""
+	}
+	if f.opt.Unpin && oldCID != "" {
+		return dstObj, f.pin(ctx, oldCID, false)
+	}
+	return dstObj, nil
+}
+
+// DirMove moves src, srcRemote to this remote at dstRemote
+// using server-side move operations.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantDirMove
+//
+// If destination exists then return fs.ErrorDirExists
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	srcFs, ok := src.(*Fs)
+	if !ok || srcFs.opt.APIURL != f.opt.APIURL || srcFs.immutable {
+		fs.Debugf(srcFs, "Can't move directory - not same remote type")
+		return fs.ErrorCantDirMove
+	}
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	_, err := f.stat(ctx, dstRemote)
+	if err == nil {
+		return fs.ErrorDirExists
+	} else if !errors.Is(err, fs.ErrorObjectNotFound) {
+		return err
+	}
+	if err := f.mkParentDir(ctx, dstRemote); err != nil {
+		return err
+	}
+	params := url.Values{"arg": {srcFs.ipfsPath(srcRemote), f.ipfsPath(dstRemote)}}
+	return f.call(ctx, "files/mv", params, nil)
+}
+
+// About gets quota information from the repository of the node
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	var stat api.RepoStat
+	err := f.call(ctx, "repo/stat", url.Values{"size-only": {"true"}}, &stat)
+	if err != nil {
+		return nil, err
+	}
+	usage := &fs.Usage{
+		Used: fs.NewUsageValue(stat.RepoSize),
+	}
+	if stat.StorageMax > 0 {
+		usage.Total = fs.NewUsageValue(stat.StorageMax)
+		usage.Free = fs.NewUsageValue(max(stat.StorageMax-stat.RepoSize, 0))
+	}
+	return usage, nil
+}
+
+// PublicLink generates a gateway link to the CID of the remote path
+func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration, unlink bool) (string, error) {
+	if unlink {
+		return "", errors.New("can't remove public links from IPFS")
+	}
+	info, err := f.stat(ctx, remote)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(f.opt.GatewayURL, "/") + "/ipfs/" + info.Hash, nil
+}
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "cid",
+	Short: "Show the CIDs of files or directories.",
+	Long: `This shows the CID of each path given, or of the root of the remote
+if there are none.
+
+` + "```console" + `
+rclone backend cid ipfs:dir
+rclone backend cid ipfs:dir file1 subdir
+` + "```" + `
+
+This is useful after a sync to find the CID of the whole tree.`,
+}, {
+	Name:  "pin",
+	Short: "Pin or unpin files or directories.",
+	Long: `This recursively pins the CID of each path given, or of the root of
+the remote if there are none.
+
+` + "```console" + `
+rclone backend pin ipfs:dir
+rclone backend pin ipfs:dir -o unpin
+` + "```",
+	Opts: map[string]string{
+		"unpin": "Unpin instead of pinning",
+	},
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out any, err error) {
+	if len(arg) == 0 {
+		arg = []string{""}
+	}
+	switch name {
+	case "cid":
+		cids := make(map[string]string, len(arg))
+		for _, remote := range arg {
+			info, err := f.stat(ctx, remote)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", remote, err)
+			}
+			cids[remote] = info.Hash
+		}
+		return cids, nil
+	case "pin":
+		_, unpin := opt["unpin"]
+		var cids []string
+		for _, remote := range arg {
+			info, err := f.stat(ctx, remote)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", remote, err)
+			}
+			err = f.pin(ctx, info.Hash, !unpin)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", remote, err)
+			}
+			cids = append(cids, info.Hash)
+		}
+		return cids, nil
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// ------------------------------------------------------------
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info {
+	return o.fs
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// Hash is not supported
+func (o *Object) Hash(ctx context.Context, ty hash.Type) (string, error) {
+	return "", hash.ErrUnsupported
+}
+
+// Size returns the size of an object in bytes
+func (o *Object) Size() int64 {
+	return o.size
+}
+
+// ModTime returns the UnixFS modification time if set
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+// SetModTime is not supported
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	return fs.ErrorCantSetModTime
+}
+
+// Storable returns a boolean showing whether this object storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// ID returns the CID of the Object
+func (o *Object) ID() string {
+	return o.cid
+}
+
+// Metadata returns metadata for an object
+//
+// It should return nil if there is no Metadata
+func (o *Object) Metadata(ctx context.Context) (fs.Metadata, error) {
+	if o.cid == "" {
+		return nil, nil
+	}
+	return fs.Metadata{"cid": o.cid}, nil
+}
+
+// Open an object for read
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	var offset, limit int64 = 0, -1
+	for _, option := range options {
+		switch x := option.(type) {
+		case *fs.SeekOption:
+			offset = x.Offset
+		case *fs.RangeOption:
+			offset, limit = x.Decode(o.size)
+		default:
+			if option.Mandatory() {
+				fs.Logf(o, "Unsupported mandatory option: %v", option)
+			}
+		}
+	}
+	params := url.Values{"arg": {o.fs.ipfsPath(o.remote)}}
+	endpoint, countParam := "files/read", "count"
+	if o.fs.immutable {
+		endpoint, countParam = "cat", "length"
+	}
+	if offset > 0 {
+		params.Set("offset", strconv.FormatInt(offset, 10))
+	}
+	if limit >= 0 {
+		params.Set(countParam, strconv.FormatInt(limit, 10))
+	}
+	var resp *http.Response
+	opts := rest.Opts{
+		Method:     "POST",
+		Path:       "/api/v0/" + endpoint,
+		Parameters: params,
+	}
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return o.fs.shouldRetry(resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Update the object with the contents of the io.Reader
+//
+// The content is written to MFS and then pinned if required.
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
+	if err := o.fs.checkWritable(); err != nil {
+		return err
+	}
+	oldCID := o.cid
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/api/v0/files/write",
+		Body:   in,
+		Parameters: url.Values{
+			"arg":         {o.fs.ipfsPath(o.remote)},
+			"create":      {"true"},
+			"truncate":    {"true"},
+			"parents":     {"true"},
+			"cid-version": {strconv.Itoa(o.fs.opt.CIDVersion)},
+			"raw-leaves":  {strconv.FormatBool(o.fs.opt.RawLeaves)},
+			"hash":        {o.fs.opt.Hash},
+		},
+		MultipartParams:      url.Values{},
+		MultipartContentName: "file",
+		MultipartFileName:    path.Base(o.remote),
+		NoResponse:           true,
+	}
+	var resp *http.Response
+	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return o.fs.shouldRetry(resp, err)
+	})
+	if err != nil {
+		return err
+	}
+	info, err := o.fs.stat(ctx, o.remote)
+	if err != nil {
+		return err
+	}
+	*o = *o.fs.newObject(o.remote, info)
+	return o.fs.replaceCID(ctx, oldCID, o.cid)
+}
+
+// Remove an object
+func (o *Object) Remove(ctx context.Context) error {
+	if err := o.fs.checkWritable(); err != nil {
+		return err
+	}
+	err := o.fs.remove(ctx, o.remote, false)
+	if err != nil {
+		return err
+	}
+	if o.fs.opt.Unpin && o.cid != "" {
+		return o.fs.pin(ctx, o.cid, false)
+	}
+	return nil
+}
+
+// ------------------------------------------------------------
+
+// NewFs constructs an Fs from the path
+//
+// Paths starting with ipfs/ or ipns/ are read from the immutable
+// namespaces, anything else is an MFS path.
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.CIDVersion != 0 && opt.CIDVersion != 1 {
+		return nil, fmt.Errorf("cid_version must be 0 or 1, not %d", opt.CIDVersion)
+	}
+
+	opt.APIURL = strings.TrimSuffix(opt.APIURL, "/")
+	u, err := url.Parse(opt.APIURL)
+	if err != nil {
+		return nil, err
+	}
+
+	rootIsDir := strings.HasSuffix(root, "/")
+	root = strings.Trim(root, "/")
+
+	f := &Fs{
+		name:      name,
+		opt:       *opt,
+		root:      root,
+		immutable: isImmutable(root),
+	}
+	f.pacer = fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant)))
+	f.features = (&fs.Features{
+		CanHaveEmptyDirectories: true,
+		ReadMetadata:            true,
+	}).Fill(ctx, f)
+	if f.immutable {
+		f.features.Purge = nil
+		f.features.Move = nil
+		f.features.DirMove = nil
+		f.features.PutStream = nil
+	}
+
+	f.srv = rest.NewClient(fshttp.NewClient(ctx))
+	f.srv.SetRoot(u.String())
+	f.srv.SetErrorHandler(errorHandler)
+	if opt.APIAuth != "" {
+		f.srv.SetHeader("Authorization", opt.APIAuth)
+	}
+
+	if root != "" && !rootIsDir {
+		// Check to see if the root actually an existing file
+		remote := path.Base(root)
+		f.root = path.Dir(root)
+		if f.root == "." {
+			f.root = ""
+		}
+		_, err := f.NewObject(ctx, remote)
+		if err != nil {
+			if errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorIsDir) {
+				// File doesn't exist so return old f
+				f.root = root
+				return f, nil
+			}
+			return nil, err
+		}
+		// return an error with an fs which points to the parent
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// errNotPinned is returned when unpinning something which isn't pinned
+var errNotPinned = errors.New("not pinned")
+
+// errorHandler translates Kubo errors into native rclone filesystem errors.
+//
+// Kubo returns most errors with status 500 so this has to match on
+// the message.
+func errorHandler(resp *http.Response) error {
+	body, err := rest.ReadBody(resp)
+	if err != nil {
+		return fmt.Errorf("error when trying to read error body: %w", err)
+	}
+	// Decode error response
+	errResponse := new(api.Error)
+	err = json.Unmarshal(body, &errResponse)
+	if err != nil {
+		// Set the Message to be the body if we can't parse the JSON
+		errResponse.Message = strings.TrimSpace(string(body))
+	}
+	errResponse.Status = resp.Status
+	errResponse.StatusCode = resp.StatusCode
+
+	msg := errResponse.Message
+	switch {
+	case strings.Contains(msg, "file does not exist"),
+		strings.HasPrefix(msg, "no link named"),
+		strings.Contains(msg, "no such file or directory"):
+		return fs.ErrorObjectNotFound
+	case strings.Contains(msg, "file already exists"):
+		return fs.ErrorDirExists
+	case strings.Contains(msg, "not pinned"):
+		return fmt.Errorf("%w: %w", errNotPinned, errResponse)
+	}
+	return errResponse
+}
+
+// shouldRetry returns a boolean as to whether this resp and err
+// deserve to be retried.  It returns the err as a convenience
+func (f *Fs) shouldRetry(resp *http.Response, err error) (bool, error) {
+	return fserrors.ShouldRetry(err), err
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs           = (*Fs)(nil)
+	_ fs.PutStreamer  = (*Fs)(nil)
+	_ fs.Copier       = (*Fs)(nil)
+	_ fs.Mover        = (*Fs)(nil)
+	_ fs.DirMover     = (*Fs)(nil)
+	_ fs.Purger       = (*Fs)(nil)
+	_ fs.Abouter      = (*Fs)(nil)
+	_ fs.PublicLinker = (*Fs)(nil)
+	_ fs.Commander    = (*Fs)(nil)
+	_ fs.Object       = (*Object)(nil)
+	_ fs.IDer         = (*Object)(nil)
+	_ fs.Metadataer   = (*Object)(nil)
+)
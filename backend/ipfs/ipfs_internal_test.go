@@ -0,0 +1,57 @@
+package ipfs
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rclone/rclone/backend/ipfs/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsImmutable(t *testing.T) {
+	for _, test := range []struct {
+		root string
+		want bool
+	}{
+		{"", false},
+		{"dir/file", false},
+		{"ipfsdir", false},
+		{"ipfs", true},
+		{"ipfs/bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi", true},
+		{"ipns/example.com/dir", true},
+	} {
+		assert.Equal(t, test.want, isImmutable(test.root), test.root)
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	newResponse := func(body string) *http.Response {
+		return &http.Response{
+			Status:     "500 Internal Server Error",
+			StatusCode: 500,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+	}
+	for _, test := range []struct {
+		body string
+		want error
+	}{
+		{`{"Message":"file does not exist","Code":0,"Type":"error"}`, fs.ErrorObjectNotFound},
+		{`{"Message":"no link named \"x\" under bafy","Code":0,"Type":"error"}`, fs.ErrorObjectNotFound},
+		{`{"Message":"file already exists","Code":0,"Type":"error"}`, fs.ErrorDirExists},
+		{`{"Message":"not pinned or pinned indirectly","Code":0,"Type":"error"}`, errNotPinned},
+	} {
+		err := errorHandler(newResponse(test.body))
+		assert.True(t, errors.Is(err, test.want), "%s: got %v", test.body, err)
+	}
+
+	err := errorHandler(newResponse("something went wrong\n"))
+	var apiErr *api.Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "something went wrong", apiErr.Message)
+	assert.Equal(t, 500, apiErr.StatusCode)
+}
@@ -149,7 +149,7 @@ func init() {
 			Advanced: true,
 		}, {
 			Name:     "upload_resume_limit",
-			Help:     "Files bigger than this can be resumed if the upload fail's.",
+			Help:     "Files bigger than this are uploaded in parts of this size.\n\nIf a part fails to upload, the upload is resumed from where it got\nto rather than starting again. Each transfer buffers a part in memory.",
 			Default:  fs.SizeSuffix(10 * 1024 * 1024),
 			Advanced: true,
 		}, {
@@ -2057,29 +2057,17 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	// the content is already there, possibly it was created with deduplication,
 	// and also any metadata changes are already performed by the allocate request.
 	if response.State != "COMPLETED" {
-		// how much do we still have to upload?
-		remainingBytes := size - response.ResumePos
-		opts = rest.Opts{
-			Method:        "POST",
-			RootURL:       response.UploadURL,
-			ContentLength: &remainingBytes,
-			ContentType:   "application/octet-stream",
-			Body:          in,
-			ExtraHeaders:  make(map[string]string),
-		}
-		if response.ResumePos != 0 {
-			opts.ExtraHeaders["Range"] = "bytes=" + strconv.FormatInt(response.ResumePos, 10) + "-" + strconv.FormatInt(size-1, 10)
-		}
-
 		// copy the already uploaded bytes into the trash :)
-		var result api.UploadResponse
 		_, err = io.CopyN(io.Discard, in, response.ResumePos)
 		if err != nil {
 			return err
 		}
 
-		// send the remaining bytes
-		_, err = o.fs.apiSrv.CallJSON(ctx, &opts, nil, &result)
+		if size > int64(o.fs.opt.UploadThreshold) {
+			err = o.uploadParts(ctx, in, size, &opts, &request, &response)
+		} else {
+			err = o.uploadRemaining(ctx, in, size, &response)
+		}
 		if err != nil {
 			return err
 		}
@@ -2093,6 +2081,93 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	return o.readMetaData(ctx, true)
 }
 
+// uploadRemaining uploads the rest of in, starting at the resume
+// position of the allocated upload, in a single request.
+func (o *Object) uploadRemaining(ctx context.Context, in io.Reader, size int64, response *api.AllocateFileResponse) error {
+	// how much do we still have to upload?
+	remainingBytes := size - response.ResumePos
+	opts := rest.Opts{
+		Method:        "POST",
+		RootURL:       response.UploadURL,
+		ContentLength: &remainingBytes,
+		ContentType:   "application/octet-stream",
+		Body:          in,
+		ExtraHeaders:  make(map[string]string),
+	}
+	if response.ResumePos != 0 {
+		opts.ExtraHeaders["Range"] = "bytes=" + strconv.FormatInt(response.ResumePos, 10) + "-" + strconv.FormatInt(size-1, 10)
+	}
+
+	// send the remaining bytes
+	var result api.UploadResponse
+	_, err := o.fs.apiSrv.CallJSON(ctx, &opts, nil, &result)
+	return err
+}
+
+// uploadParts uploads the rest of in, starting at the resume position
+// of the allocated upload, in parts of upload_resume_limit.
+//
+// If a part fails the upload is allocated again to find out how much
+// of it Jottacloud has, and resumed from there, so only the part is
+// sent again rather than the whole file.
+func (o *Object) uploadParts(ctx context.Context, in io.Reader, size int64, allocateOpts *rest.Opts, request *api.AllocateFileRequest, response *api.AllocateFileResponse) error {
+	buf := make([]byte, min(int64(o.fs.opt.UploadThreshold), size))
+	pos := response.ResumePos // how much of the file Jottacloud has
+	for pos < size {
+		// read the next part
+		partStart := pos
+		n, err := io.ReadFull(in, buf[:min(int64(len(buf)), size-pos)])
+		if err != nil {
+			return fmt.Errorf("failed to read part at %d: %w", partStart, err)
+		}
+		partEnd := partStart + int64(n)
+		retry := false
+		err = o.fs.pacer.Call(func() (bool, error) {
+			if retry {
+				// find out how much of the part was received
+				resp, err := o.fs.apiSrv.CallJSON(ctx, allocateOpts, request, response)
+				if err != nil {
+					return shouldRetry(ctx, resp, err)
+				}
+				if response.State == "COMPLETED" {
+					pos = size
+					return false, nil
+				}
+				if response.ResumePos < partStart || response.ResumePos > partEnd {
+					return false, fmt.Errorf("can't resume upload at %d as the part being uploaded is %d-%d", response.ResumePos, partStart, partEnd)
+				}
+				pos = response.ResumePos
+				if pos == partEnd {
+					return false, nil
+				}
+			}
+			retry = true
+			part := buf[pos-partStart : n]
+			partLength := int64(len(part))
+			opts := rest.Opts{
+				Method:        "POST",
+				RootURL:       response.UploadURL,
+				ContentLength: &partLength,
+				ContentType:   "application/octet-stream",
+				Body:          bytes.NewReader(part),
+				ExtraHeaders: map[string]string{
+					"Range": "bytes=" + strconv.FormatInt(pos, 10) + "-" + strconv.FormatInt(partEnd-1, 10),
+				},
+			}
+			var result api.UploadResponse
+			resp, err := o.fs.apiSrv.CallJSON(ctx, &opts, nil, &result)
+			if err == nil {
+				pos = partEnd
+			}
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload part at %d: %w", partStart, err)
+		}
+	}
+	return nil
+}
+
 func (o *Object) remove(ctx context.Context, hard bool) error {
 	opts := rest.Opts{
 		Method:     "POST",
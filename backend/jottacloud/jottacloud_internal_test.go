@@ -1,18 +1,27 @@
 package jottacloud
 
 import (
+	"bytes"
 	"context"
 	"crypto/md5"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/rclone/rclone/backend/jottacloud/api"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/fstest/fstests"
+	"github.com/rclone/rclone/lib/pacer"
 	"github.com/rclone/rclone/lib/random"
 	"github.com/rclone/rclone/lib/readers"
+	"github.com/rclone/rclone/lib/rest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -91,3 +100,65 @@ func (f *Fs) InternalTest(t *testing.T) {
 }
 
 var _ fstests.InternalTester = (*Fs)(nil)
+
+func TestUploadParts(t *testing.T) {
+	ctx := context.Background()
+	data := []byte("0123456789abcdefghij")
+	var (
+		mu       sync.Mutex
+		received []byte
+		ranges   []string
+		failed   bool
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/files/v1/allocate":
+			_, _ = fmt.Fprintf(w, `{"state":"INCOMPLETE","resume_pos":%d,"upload_url":%q}`, len(received), "http://"+r.Host+"/upload")
+		case "/upload":
+			rng := r.Header.Get("Range")
+			ranges = append(ranges, rng)
+			start, _, _ := strings.Cut(strings.TrimPrefix(rng, "bytes="), "-")
+			pos, err := strconv.Atoi(start)
+			require.NoError(t, err)
+			require.Equal(t, len(received), pos, "upload not resumed from where it got to")
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			if pos == 8 && !failed {
+				// only receive half of the third part
+				failed = true
+				received = append(received, body[:2]...)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			received = append(received, body...)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	f := &Fs{
+		opt:    Options{UploadThreshold: 4},
+		apiSrv: rest.NewClient(http.DefaultClient).SetRoot(ts.URL + "/"),
+		pacer:  fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(time.Millisecond), pacer.MaxSleep(time.Millisecond))),
+	}
+	o := &Object{fs: f, remote: "file.txt"}
+	allocateOpts := rest.Opts{Method: "POST", Path: "files/v1/allocate"}
+	request := api.AllocateFileRequest{Bytes: int64(len(data))}
+	response := api.AllocateFileResponse{UploadURL: ts.URL + "/upload"}
+
+	err := o.uploadParts(ctx, bytes.NewReader(data), int64(len(data)), &allocateOpts, &request, &response)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(received))
+	assert.Equal(t, []string{
+		"bytes=0-3",
+		"bytes=4-7",
+		"bytes=8-11",
+		"bytes=10-11",
+		"bytes=12-15",
+		"bytes=16-19",
+	}, ranges)
+}
@@ -0,0 +1,109 @@
+package local
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// errCloneUnsupported is returned (wrapped) by clone if the files
+// can't be cloned, e.g. because the filesystem doesn't support it.
+var errCloneUnsupported = errors.New("reflink cloning not supported")
+
+// Copy src to this remote using server-side copy operations.
+//
+// This is stored with the remote path given.
+//
+// It returns the destination Object and a possible error.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantCopy
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	if f.opt.Reflink == reflinkNever || f.cloneFailed.Load() {
+		return nil, fs.ErrorCantCopy
+	}
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debugf(src, "Can't clone - not same remote type")
+		return nil, fs.ErrorCantCopy
+	}
+	if f.opt.TranslateSymlinks && srcObj.translatedLink { // in --links mode, use cloning only for regular files
+		return nil, fs.ErrorCantCopy
+	}
+
+	// Fetch metadata if --metadata is in use
+	meta, err := fs.GetMetadataOptions(ctx, f, src, fs.MetadataAsOpenOptions(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("copy: failed to read metadata: %w", err)
+	}
+
+	// Create destination
+	dstObj := f.newObject(remote)
+	err = dstObj.mkdirAll()
+	if err != nil {
+		return nil, err
+	}
+
+	srcPath := srcObj.path
+	if f.opt.FollowSymlinks { // in --copy-links mode, find the real file being pointed to and pass that in instead
+		srcPath, err = filepath.EvalSymlinks(srcPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = clone(srcPath, f.localPath(remote), f.opt.Reflink == reflinkAlways)
+	if errors.Is(err, errCloneUnsupported) && f.opt.Reflink == reflinkAuto {
+		fs.Debugf(src, "Can't clone - copying instead: %v", err)
+		if !errors.Is(err, errCloneCrossDevice) {
+			// Don't try again if the filesystem can't clone
+			f.cloneFailed.Store(true)
+		}
+		return nil, fs.ErrorCantCopy
+	}
+	if err != nil {
+		return nil, fmt.Errorf("copy: failed to clone: %w", err)
+	}
+
+	// Set metadata if --metadata is in use
+	if meta != nil {
+		err = dstObj.writeMetadata(meta)
+		if err != nil {
+			return nil, fmt.Errorf("copy: failed to set metadata: %w", err)
+		}
+	}
+
+	return f.NewObject(ctx, remote)
+}
+
+// errCloneCrossDevice is returned (wrapped) by clone if the files are
+// on different filesystems. It wraps errCloneUnsupported.
+var errCloneCrossDevice = fmt.Errorf("%w across filesystems", errCloneUnsupported)
+
+// cloneViaTemp calls fn to clone to a temporary file next to dst and
+// renames it to dst if successful so an existing dst is replaced.
+func cloneViaTemp(dst string, fn func(tmp string) error) error {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	tmp := dst + ".rclone-reflink-" + hex.EncodeToString(b)
+	err := fn(tmp)
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Copier = &Fs{}
+)
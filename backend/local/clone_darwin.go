@@ -4,72 +4,34 @@
 package local
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"path/filepath"
-	"runtime"
 
 	"github.com/go-darwin/apfs"
 	"github.com/rclone/rclone/fs"
+	"golang.org/x/sys/unix"
 )
 
-// Copy src to this remote using server-side copy operations.
-//
-// # This is stored with the remote path given
-//
-// # It returns the destination Object and a possible error
-//
-// Will only be called if src.Fs().Name() == f.Name()
-//
-// If it isn't possible then return fs.ErrorCantCopy
-func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
-	if runtime.GOOS != "darwin" || f.opt.NoClone {
-		return nil, fs.ErrorCantCopy
-	}
-	srcObj, ok := src.(*Object)
-	if !ok {
-		fs.Debugf(src, "Can't clone - not same remote type")
-		return nil, fs.ErrorCantCopy
-	}
-	if f.opt.TranslateSymlinks && srcObj.translatedLink { // in --links mode, use cloning only for regular files
-		return nil, fs.ErrorCantCopy
-	}
-
-	// Fetch metadata if --metadata is in use
-	meta, err := fs.GetMetadataOptions(ctx, f, src, fs.MetadataAsOpenOptions(ctx))
-	if err != nil {
-		return nil, fmt.Errorf("copy: failed to read metadata: %w", err)
-	}
-
-	// Create destination
-	dstObj := f.newObject(remote)
-	err = dstObj.mkdirAll()
-	if err != nil {
-		return nil, err
-	}
+// cloneSupported is set if clone may work on this platform
+const cloneSupported = true
 
-	srcPath := srcObj.path
-	if f.opt.FollowSymlinks { // in --copy-links mode, find the real file being pointed to and pass that in instead
-		srcPath, err = filepath.EvalSymlinks(srcPath)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	err = Clone(srcPath, f.localPath(remote))
-	if err != nil {
-		return nil, err
-	}
-
-	// Set metadata if --metadata is in use
-	if meta != nil {
-		err = dstObj.writeMetadata(meta)
-		if err != nil {
-			return nil, fmt.Errorf("copy: failed to set metadata: %w", err)
+// clone dst from src.
+//
+// If force is set then it fails rather than falling back to copying.
+func clone(src, dst string, force bool) error {
+	if !force {
+		return Clone(src, dst)
+	}
+	return cloneViaTemp(dst, func(tmp string) error {
+		err := unix.Clonefile(src, tmp, unix.CLONE_NOFOLLOW)
+		switch {
+		case errors.Is(err, unix.EXDEV):
+			return errCloneCrossDevice
+		case errors.Is(err, unix.ENOTSUP):
+			return fmt.Errorf("%w: %v", errCloneUnsupported, err)
 		}
-	}
-
-	return f.NewObject(ctx, remote)
+		return err
+	})
 }
 
 // Clone uses APFS cloning if possible, otherwise falls back to copying (with full metadata preservation)
@@ -86,8 +48,3 @@ func Clone(src, dst string) error {
 	fs.Debugf(dst, "isCloned: %v, error: %v", cloned, err)
 	return err
 }
-
-// Check the interfaces are satisfied
-var (
-	_ fs.Copier = &Fs{}
-)
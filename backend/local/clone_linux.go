@@ -0,0 +1,51 @@
+//go:build linux
+
+package local
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneSupported is set if clone may work on this platform
+const cloneSupported = true
+
+// clone dst from src with the FICLONE ioctl so they share blocks.
+//
+// force is ignored as there is no fallback to a normal copy here.
+func clone(src, dst string, force bool) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	return cloneViaTemp(dst, func(tmp string) error {
+		out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fi.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		err = unix.IoctlFileClone(int(out.Fd()), int(in.Fd()))
+		closeErr := out.Close()
+		switch {
+		case errors.Is(err, unix.EXDEV):
+			return errCloneCrossDevice
+		case errors.Is(err, unix.EOPNOTSUPP), errors.Is(err, unix.ENOTTY), errors.Is(err, unix.EINVAL), errors.Is(err, unix.ENOSYS):
+			return fmt.Errorf("%w: %v", errCloneUnsupported, err)
+		case err != nil:
+			return err
+		case closeErr != nil:
+			return closeErr
+		}
+		// FICLONE doesn't copy the times so set the modification time
+		return os.Chtimes(tmp, fi.ModTime(), fi.ModTime())
+	})
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !(darwin && cgo)
+
+package local
+
+// cloneSupported is set if clone may work on this platform
+const cloneSupported = false
+
+// clone is not supported on this platform
+func clone(src, dst string, force bool) error {
+	return errCloneUnsupported
+}
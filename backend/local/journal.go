@@ -0,0 +1,289 @@
+// Change journal for repeated syncs
+//
+// The watch backend command watches the tree with the platform's file
+// change notifications and records the paths which change in a journal
+// file in the cache directory. When --local-journal is set sync reads
+// the journal and only checks the paths which changed since the last
+// successful sync to the same destination.
+
+package local
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/lib/random"
+)
+
+// how often the watcher writes the journal - journals older than
+// twice this were written by a watcher which has stopped
+var journalFlushInterval = 5 * time.Second
+
+var errWatchUnsupported = errors.New("watching for changes is not supported on this OS")
+
+// journal is the on disk state written by the watcher
+type journal struct {
+	Root       string            `json:"root"`       // the OS path being watched
+	Generation string            `json:"generation"` // changes if the watcher restarts or loses track of changes
+	Seq        uint64            `json:"seq"`        // sequence number of the last change
+	Paths      map[string]uint64 `json:"paths"`      // changed paths relative to Root with the Seq of their last change
+}
+
+// journalCheckpoint records the state of the journal at the start of
+// the last successful sync to a destination
+type journalCheckpoint struct {
+	Generation string `json:"generation"`
+	Seq        uint64 `json:"seq"`
+}
+
+// journalFiles returns the paths of the journal and the checkpoints
+// files for the OS path root
+func journalFiles(root string) (journalPath, checkpointsPath string) {
+	sum := sha1.Sum([]byte(root))
+	base := filepath.Join(config.GetCacheDir(), "local-journal", hex.EncodeToString(sum[:]))
+	return base + ".json", base + "-checkpoints.json"
+}
+
+// readJSON reads the JSON file at path into v
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON atomically replaces the file at path with v as JSON
+func writeJSON(path string, v any) (err error) {
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	out, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(out.Name())
+		}
+	}()
+	err = json.NewEncoder(out).Encode(v)
+	closeErr := out.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return os.Rename(out.Name(), path)
+}
+
+// journalWatcher keeps the journal up to date for the watch command
+type journalWatcher struct {
+	f               *Fs
+	journalPath     string
+	checkpointsPath string
+	mu              sync.Mutex
+	j               journal
+	dirty           bool // set if j needs writing
+}
+
+// newGeneration starts a new generation of the journal forgetting
+// the changed paths. Call with mu held.
+func (w *journalWatcher) newGeneration() {
+	w.j.Generation = random.String(16)
+	w.j.Paths = make(map[string]uint64)
+	w.dirty = true
+}
+
+// changed records that the path rel relative to the root changed
+func (w *journalWatcher) changed(rel string) {
+	if rel == "" {
+		return
+	}
+	w.mu.Lock()
+	w.j.Seq++
+	w.j.Paths[rel] = w.j.Seq
+	w.dirty = true
+	w.mu.Unlock()
+}
+
+// lost is called when the watcher can't tell which paths changed
+func (w *journalWatcher) lost(reason string) {
+	fs.Errorf(w.f, "Lost track of changes: %s: the next sync will check all files", reason)
+	w.mu.Lock()
+	w.newGeneration()
+	w.mu.Unlock()
+}
+
+// prune removes the paths which all the destinations synced in this
+// generation have seen. Call with mu held.
+func (w *journalWatcher) prune() {
+	var checkpoints map[string]journalCheckpoint
+	if err := readJSON(w.checkpointsPath, &checkpoints); err != nil {
+		return
+	}
+	found := false
+	var minSeq uint64
+	for _, cp := range checkpoints {
+		if cp.Generation != w.j.Generation {
+			continue
+		}
+		if !found || cp.Seq < minSeq {
+			minSeq = cp.Seq
+			found = true
+		}
+	}
+	if !found {
+		return
+	}
+	for p, seq := range w.j.Paths {
+		if seq <= minSeq {
+			delete(w.j.Paths, p)
+			w.dirty = true
+		}
+	}
+}
+
+// flush writes the journal if it changed, otherwise it updates its
+// modification time to show the watcher is still running
+func (w *journalWatcher) flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.prune()
+	if !w.dirty {
+		now := time.Now()
+		if err := os.Chtimes(w.journalPath, now, now); err == nil {
+			return nil
+		}
+	}
+	if err := writeJSON(w.journalPath, &w.j); err != nil {
+		return fmt.Errorf("failed to write change journal: %w", err)
+	}
+	w.dirty = false
+	return nil
+}
+
+// watch watches the root for changes writing them to the journal
+// until the context is cancelled.
+func (f *Fs) watch(ctx context.Context) error {
+	if !watchSupported {
+		return errWatchUnsupported
+	}
+	w := &journalWatcher{
+		f: f,
+		j: journal{Root: f.root},
+	}
+	w.journalPath, w.checkpointsPath = journalFiles(f.root)
+	w.newGeneration()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ready := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- watchTree(ctx, f.root, w.changed, w.lost, func() { close(ready) })
+	}()
+	select {
+	case <-ready:
+	case err := <-errs:
+		return err
+	}
+	fs.Logf(f, "Watching for changes and writing them to %q", w.journalPath)
+	ticker := time.NewTicker(journalFlushInterval)
+	defer ticker.Stop()
+	for {
+		if err := w.flush(); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case err := <-errs:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// journalRemote converts a path from the journal into a remote
+func (f *Fs) journalRemote(rel string) (remote string) {
+	for name := range strings.SplitSeq(rel, "/") {
+		remote = f.cleanRemote(remote, name)
+	}
+	return remote
+}
+
+// ChangedPaths returns the paths which changed since the last
+// successful sync from this Fs to the destination identified by key
+// using the journal written by the watch command.
+//
+// If it isn't known which paths changed it returns an error and all
+// the paths must be checked.
+//
+// commit should be called after a successful sync to record the
+// state of the journal for the next sync. It may be nil.
+func (f *Fs) ChangedPaths(ctx context.Context, key string) (paths []string, commit func() error, err error) {
+	journalPath, checkpointsPath := journalFiles(f.root)
+	fi, err := os.Stat(journalPath)
+	if os.IsNotExist(err) {
+		return nil, nil, errors.New("no change journal found: run \"rclone backend watch\" on the source to make one")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read change journal: %w", err)
+	}
+	if age := time.Since(fi.ModTime()); age > 2*journalFlushInterval {
+		return nil, nil, fmt.Errorf("change journal not updated for %v: is \"rclone backend watch\" still running?", age.Truncate(time.Second))
+	}
+	var j journal
+	if err = readJSON(journalPath, &j); err != nil {
+		return nil, nil, fmt.Errorf("failed to read change journal: %w", err)
+	}
+	var checkpoints map[string]journalCheckpoint
+	if err = readJSON(checkpointsPath, &checkpoints); err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to read change journal checkpoints: %w", err)
+	}
+	commit = func() error {
+		// Re-read the checkpoints as other syncs may have updated them
+		checkpoints := map[string]journalCheckpoint{}
+		if err := readJSON(checkpointsPath, &checkpoints); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		checkpoints[key] = journalCheckpoint{Generation: j.Generation, Seq: j.Seq}
+		return writeJSON(checkpointsPath, checkpoints)
+	}
+	cp, found := checkpoints[key]
+	if !found {
+		return nil, commit, errors.New("no previous sync to this destination in the change journal")
+	}
+	if cp.Generation != j.Generation {
+		return nil, commit, errors.New("change journal was restarted since the last sync to this destination")
+	}
+	paths = []string{}
+	for rel, seq := range j.Paths {
+		if seq <= cp.Seq {
+			continue
+		}
+		remote := f.journalRemote(rel)
+		paths = append(paths, remote)
+		if f.opt.TranslateSymlinks {
+			paths = append(paths, remote+fs.LinkSuffix)
+		}
+	}
+	sort.Strings(paths)
+	return paths, commit, nil
+}
@@ -59,6 +59,25 @@ func (timeTypeChoices) Choices() []string {
 	}
 }
 
+// reflinkMode says when to use reflink cloning for server-side copies
+type reflinkMode = fs.Enum[reflinkModeChoices]
+
+const (
+	reflinkAuto reflinkMode = iota
+	reflinkAlways
+	reflinkNever
+)
+
+type reflinkModeChoices struct{}
+
+func (reflinkModeChoices) Choices() []string {
+	return []string{
+		reflinkAuto:   "auto",
+		reflinkAlways: "always",
+		reflinkNever:  "never",
+	}
+}
+
 // Register with Fs
 func init() {
 	fsi := &fs.RegInfo{
@@ -77,6 +96,10 @@ netbsd, macOS and Solaris. It is **not** supported on Windows yet
 User metadata is stored as extended attributes (which may not be
 supported by all file systems) under the "user.*" prefix.
 
+Other extended attributes, ACLs and NTFS alternate data streams can
+be read and written as metadata with --local-extended-metadata. These
+use the "xattr-NAME", "acl-*" and "stream-NAME" keys.
+
 Metadata is supported on files and directories.
 `,
 		},
@@ -91,6 +114,28 @@ Metadata is supported on files and directories.
 					Help:  "Disables long file names.",
 				}},
 			},
+			{
+				Name: "windows_compat",
+				Help: `Map names which Windows can't store so they round trip.
+
+When this is set the names of files and directories are encoded so
+that data created on other systems, for example Linux, can be stored
+on a Windows disk and read back unchanged.
+
+As well as the characters Windows can't use and trailing periods and
+spaces, this encodes the names Windows reserves for devices, such as
+CON, PRN, AUX, NUL, COM1 and LPT1 with or without an extension, by
+replacing their first letter with its FULLWIDTH variant, so "nul.txt"
+is stored as "ｎul.txt".
+
+Long paths are always converted to extended-length paths so this
+can't be used with --local-nounc.
+
+This can be used on any OS, for example to prepare a disk which will
+be read on Windows.`,
+				Default:  false,
+				Advanced: true,
+			},
 			{
 				Name:     "copy_links",
 				Help:     "Follow symlinks and copy the pointed to item.",
@@ -240,8 +285,50 @@ deduplicated by default (i.e. having two identical files does not consume more
 storage than having just one.)  However, for use cases where data redundancy is
 preferable, --local-no-clone can be used to disable cloning and force "deep" copies.
 
-Currently, cloning is only supported when using APFS on macOS (support for other
-platforms may be added in the future.)`,
+This is the same as --local-reflink never.`,
+				Default:  false,
+				Advanced: true,
+			},
+			{
+				Name: "reflink",
+				Help: `When to use reflink cloning for server-side copies.
+
+Cloning is supported when using APFS on macOS and on Linux filesystems
+which support the FICLONE ioctl, such as btrfs, XFS with reflink=1 and
+bcachefs. The source and destination must be on the same filesystem.
+
+In auto mode rclone clones the file if it can and copies it normally
+if not. In always mode a failure to clone is an error, which is useful
+to make sure a copy takes no extra space.
+`,
+				Default:  reflinkAuto,
+				Advanced: true,
+				Examples: []fs.OptionExample{{
+					Value: reflinkAuto.String(),
+					Help:  "Clone if possible, otherwise copy.",
+				}, {
+					Value: reflinkAlways.String(),
+					Help:  "Always clone, failing if not possible.",
+				}, {
+					Value: reflinkNever.String(),
+					Help:  "Never clone, always copy.",
+				}},
+			},
+			{
+				Name: "journal",
+				Help: `Use the change journal to only check changed files when syncing.
+
+When this is set and ` + "`rclone backend watch`" + ` is running on the source
+directory then sync, copy and move only check the paths which have
+changed since the last successful sync to the same destination rather
+than scanning the whole tree.
+
+If the change journal can't be used, for example because it is the
+first sync to that destination or the watcher has been stopped, then
+all the files are checked as normal.
+
+This is only supported on Linux and Windows and can't be used with
+--copy-links.`,
 				Default:  false,
 				Advanced: true,
 			},
@@ -317,6 +404,36 @@ only useful for reading.
 					Help:  "The last status change time.",
 				}},
 			},
+			{
+				Name: "extended_metadata",
+				Help: `Extra metadata to read and write when using --metadata.
+
+This is a comma separated list of:
+
+- xattrs: extended attributes outside the "user.*" namespace, for
+  example the "com.apple.*" attributes on macOS including resource
+  forks, as "xattr-NAME" metadata.
+- acls: POSIX and NFSv4 ACLs on Linux as "acl-access", "acl-default"
+  and "acl-nfs4" metadata and the DACL on Windows as "acl-sddl" metadata.
+- streams: NTFS alternate data streams on Windows as "stream-NAME"
+  metadata.
+
+Binary values are stored base64 encoded after a "base64:" prefix.
+Setting some of these, for example ACLs owned by another user or
+"security.*" attributes, needs extra privileges.`,
+				Default:  extendedOff,
+				Advanced: true,
+				Examples: []fs.OptionExample{{
+					Value: extendedOff.String(),
+					Help:  "Only read and write the standard metadata.",
+				}, {
+					Value: (extendedXattrs | extendedACLs).String(),
+					Help:  "Read and write all the extended attributes and ACLs.",
+				}, {
+					Value: (extendedXattrs | extendedACLs | extendedStreams).String(),
+					Help:  "Read and write everything supported.",
+				}},
+			},
 			{
 				Name:     "hashes",
 				Help:     `Comma separated list of supported checksum types.`,
@@ -343,6 +460,7 @@ type Options struct {
 	UTFNorm           bool                 `config:"unicode_normalization"`
 	NoCheckUpdated    bool                 `config:"no_check_updated"`
 	NoUNC             bool                 `config:"nounc"`
+	WindowsCompat     bool                 `config:"windows_compat"`
 	OneFileSystem     bool                 `config:"one_file_system"`
 	CaseSensitive     bool                 `config:"case_sensitive"`
 	CaseInsensitive   bool                 `config:"case_insensitive"`
@@ -353,6 +471,9 @@ type Options struct {
 	Hashes            fs.CommaSepList      `config:"hashes"`
 	Enc               encoder.MultiEncoder `config:"encoding"`
 	NoClone           bool                 `config:"no_clone"`
+	Reflink           reflinkMode          `config:"reflink"`
+	Journal           bool                 `config:"journal"`
+	ExtendedMetadata  extendedMetadata     `config:"extended_metadata"`
 }
 
 // Fs represents a local filesystem rooted at root
@@ -367,6 +488,7 @@ type Fs struct {
 	warnedMu       sync.Mutex          // used for locking access to 'warned'.
 	warned         map[string]struct{} // whether we have warned about this string
 	xattrSupported atomic.Int32        // whether xattrs are supported
+	cloneFailed    atomic.Bool         // set if the filesystem can't clone files
 
 	// do os.Lstat or os.Stat
 	lstat        func(name string) (os.FileInfo, error)
@@ -397,8 +519,17 @@ type Directory struct {
 var (
 	errLinksAndCopyLinks = errors.New("can't use -l/--links with -L/--copy-links")
 	errLinksNeedsSuffix  = errors.New("need \"" + fs.LinkSuffix + "\" suffix to refer to symlink when using -l/--links")
+	errWindowsCompatUNC  = errors.New("can't use --local-windows-compat with --local-nounc")
 )
 
+// windowsCompatEncoding is the encoding added by --local-windows-compat
+// so names created on any OS can be stored on Windows
+const windowsCompatEncoding = encoder.EncodeWin |
+	encoder.EncodeBackSlash |
+	encoder.EncodeCtl |
+	encoder.EncodeInvalidUtf8 |
+	encoder.EncodeWinReserved
+
 // NewFs constructs an Fs from the path
 func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
 	ci := fs.GetConfig(ctx)
@@ -415,6 +546,12 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if opt.TranslateSymlinks && opt.FollowSymlinks {
 		return nil, errLinksAndCopyLinks
 	}
+	if opt.WindowsCompat {
+		if opt.NoUNC {
+			return nil, errWindowsCompatUNC
+		}
+		opt.Enc |= windowsCompatEncoding
+	}
 
 	f := &Fs{
 		name:   name,
@@ -447,9 +584,16 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		f.lstat = os.Stat
 	}
 	if opt.NoClone {
-		// Disable server-side copy when --local-no-clone is set
+		f.opt.Reflink = reflinkNever
+	}
+	if f.opt.Reflink == reflinkNever || !cloneSupported {
+		// Disable server-side copy when cloning is turned off or not possible
 		f.features.Copy = nil
 	}
+	if !f.opt.Journal || !watchSupported || f.opt.FollowSymlinks {
+		// The watcher doesn't see changes through symlinks
+		f.features.ChangedPaths = nil
+	}
 
 	// Check to see if this points to a file
 	fi, err := f.lstat(f.root)
@@ -1077,6 +1221,33 @@ var commandHelp = []fs.CommandHelp{
 			"error": "Return an error based on option value.",
 		},
 	},
+	{
+		Name:  "watch",
+		Short: "Watch the directory for changes for --local-journal.",
+		Long: `This watches the directory for changes and records the paths which
+changed in a change journal in the cache directory. It runs until it is
+stopped.
+
+` + "```console" + `
+rclone backend watch /big/tree
+` + "```" + `
+
+While it is running, syncs from the same directory with
+` + "`--local-journal`" + ` only check the paths which changed since the last
+sync to the same destination, for example
+
+` + "```console" + `
+rclone sync --local-journal /big/tree remote:backup
+` + "```" + `
+
+The directory must be the same as the source of the sync, not a parent
+or a subdirectory of it.
+
+This uses inotify on Linux and ReadDirectoryChangesW on Windows and is
+not supported on other OSes. On Linux each directory needs a watch so
+you may need to increase the ` + "`fs.inotify.max_user_watches`" + ` sysctl
+for large trees.`,
+	},
 }
 
 // Command the backend to run a named command
@@ -1105,6 +1276,8 @@ func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[str
 			return out, nil
 		}
 		return nil, nil
+	case "watch":
+		return nil, f.watch(ctx)
 	default:
 		return nil, fs.ErrorCommandNotFound
 	}
@@ -1580,6 +1753,27 @@ func (f *Fs) OpenWriterAt(ctx context.Context, remote string, size int64) (fs.Wr
 	return out, nil
 }
 
+// ResumeWriterAt opens with a handle for random access writes
+// without truncating any existing object, creating it if necessary.
+func (f *Fs) ResumeWriterAt(ctx context.Context, remote string) (fs.WriterAtCloser, error) {
+	o := f.newObject(remote)
+
+	err := o.mkdirAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if o.translatedLink {
+		return nil, errors.New("can't open a symlink for random writing")
+	}
+
+	out, err := file.OpenFile(o.path, os.O_WRONLY|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // setMetadata sets the file info from the os.FileInfo passed in
 func (o *Object) setMetadata(info os.FileInfo) {
 	// if not checking updated then don't update the stat
@@ -1637,6 +1831,10 @@ func (o *Object) Metadata(ctx context.Context) (metadata fs.Metadata, err error)
 	if err != nil {
 		return nil, err
 	}
+	err = o.readExtendedMetadata(&metadata)
+	if err != nil {
+		return nil, err
+	}
 	err = o.readMetadataFromFile(&metadata)
 	if err != nil {
 		return nil, err
@@ -1650,6 +1848,11 @@ func (o *Object) writeMetadata(metadata fs.Metadata) (err error) {
 	if err != nil {
 		return err
 	}
+	// Do this before writing the times as it may change them
+	err = o.writeExtendedMetadata(metadata)
+	if err != nil {
+		return err
+	}
 	err = o.writeMetadataToFile(metadata)
 	if err != nil {
 		return err
@@ -1748,18 +1951,20 @@ func (d *Directory) Hash() {
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs              = &Fs{}
-	_ fs.PutStreamer     = &Fs{}
-	_ fs.Mover           = &Fs{}
-	_ fs.DirMover        = &Fs{}
-	_ fs.Commander       = &Fs{}
-	_ fs.OpenWriterAter  = &Fs{}
-	_ fs.DirSetModTimer  = &Fs{}
-	_ fs.MkdirMetadataer = &Fs{}
-	_ fs.Object          = &Object{}
-	_ fs.Metadataer      = &Object{}
-	_ fs.SetMetadataer   = &Object{}
-	_ fs.Directory       = &Directory{}
-	_ fs.SetModTimer     = &Directory{}
-	_ fs.SetMetadataer   = &Directory{}
+	_ fs.Fs               = &Fs{}
+	_ fs.PutStreamer      = &Fs{}
+	_ fs.Mover            = &Fs{}
+	_ fs.DirMover         = &Fs{}
+	_ fs.Commander        = &Fs{}
+	_ fs.OpenWriterAter   = &Fs{}
+	_ fs.ResumeWriterAter = &Fs{}
+	_ fs.DirSetModTimer   = &Fs{}
+	_ fs.MkdirMetadataer  = &Fs{}
+	_ fs.ChangedPathser   = &Fs{}
+	_ fs.Object           = &Object{}
+	_ fs.Metadataer       = &Object{}
+	_ fs.SetMetadataer    = &Object{}
+	_ fs.Directory        = &Directory{}
+	_ fs.SetModTimer      = &Directory{}
+	_ fs.SetMetadataer    = &Directory{}
 )
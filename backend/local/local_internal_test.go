@@ -15,11 +15,13 @@ import (
 
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/config"
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/object"
 	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/sync"
 	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/lib/file"
 	"github.com/rclone/rclone/lib/readers"
@@ -204,6 +206,46 @@ func TestSymlinkError(t *testing.T) {
 	assert.Equal(t, errLinksAndCopyLinks, err)
 }
 
+func TestWindowsCompat(t *testing.T) {
+	ctx := context.Background()
+	_, err := NewFs(ctx, "local", "/", configmap.Simple{
+		"windows_compat": "true",
+		"nounc":          "true",
+	})
+	assert.Equal(t, errWindowsCompatUNC, err)
+
+	dir := t.TempDir()
+	f, err := NewFs(ctx, "local", dir, configmap.Simple{"windows_compat": "true"})
+	require.NoError(t, err)
+	names := []string{"CON", "nul.txt", "com1.tar.gz", "dir./LPT1", "a:b?.txt", "end "}
+	for _, name := range names {
+		_, err := operations.Rcat(ctx, f, name, io.NopCloser(bytes.NewBufferString(name)), time.Now(), nil)
+		require.NoError(t, err)
+	}
+
+	// Check the names on disk are safe for Windows
+	var onDisk []string
+	require.NoError(t, filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, err := filepath.Rel(dir, p)
+			require.NoError(t, err)
+			onDisk = append(onDisk, filepath.ToSlash(rel))
+		}
+		return err
+	}))
+	sort.Strings(onDisk)
+	assert.Equal(t, []string{"a：b？.txt", "dir．/ＬPT1", "end␠", "ＣON", "ｃom1.tar.gz", "ｎul.txt"}, onDisk)
+
+	// Check they read back with their original names
+	var got []string
+	require.NoError(t, operations.ListFn(ctx, f, func(o fs.Object) {
+		got = append(got, o.Remote())
+	}))
+	sort.Strings(names)
+	sort.Strings(got)
+	assert.Equal(t, names, got)
+}
+
 func TestHashWithTypeNone(t *testing.T) {
 	ctx := context.Background()
 	r := fstest.NewRun(t)
@@ -677,3 +719,319 @@ func TestCopySymlink(t *testing.T) {
 	want = fstest.NewItem("dst2/file.txt", "hello world", when)
 	fstest.CompareItems(t, []fs.DirEntry{dst}, []fstest.Item{want}, nil, f.precision, "")
 }
+
+func TestCopyReflink(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	when := time.Now().Add(-time.Hour).Truncate(time.Second)
+	f := r.Flocal.(*Fs)
+	f.cloneFailed.Store(false)
+	defer func() {
+		f.opt.Reflink = reflinkAuto
+		f.cloneFailed.Store(false)
+	}()
+	r.WriteFile("src/file.txt", "hello world", when)
+	src, err := f.NewObject(ctx, "src/file.txt")
+	require.NoError(t, err)
+
+	// checkNoTemp checks no temporary files were left behind
+	checkNoTemp := func() {
+		entries, err := os.ReadDir(filepath.Join(r.LocalName, "dst"))
+		if os.IsNotExist(err) {
+			return
+		}
+		require.NoError(t, err)
+		for _, entry := range entries {
+			assert.NotContains(t, entry.Name(), ".rclone-reflink-")
+		}
+	}
+
+	// In auto mode the file is cloned if possible, otherwise
+	// fs.ErrorCantCopy is returned so a normal copy is done.
+	_, err = f.Copy(ctx, src, "dst/file.txt")
+	if err == fs.ErrorCantCopy {
+		assert.True(t, f.cloneFailed.Load() || !cloneSupported)
+		checkNoTemp()
+
+		// Once cloning has failed it isn't tried again
+		_, err = f.Copy(ctx, src, "dst/file2.txt")
+		assert.Equal(t, fs.ErrorCantCopy, err)
+
+		// In always mode failing to clone is an error
+		f.opt.Reflink = reflinkAlways
+		f.cloneFailed.Store(false)
+		_, err = f.Copy(ctx, src, "dst/file.txt")
+		if cloneSupported {
+			assert.ErrorContains(t, err, "failed to clone")
+		} else {
+			assert.Equal(t, fs.ErrorCantCopy, err)
+		}
+		checkNoTemp()
+	} else {
+		require.NoError(t, err)
+		fstest.CheckItems(t, f, fstest.NewItem("src/file.txt", "hello world", when), fstest.NewItem("dst/file.txt", "hello world", when))
+		checkNoTemp()
+	}
+
+	// In never mode nothing is cloned
+	f.opt.Reflink = reflinkNever
+	_, err = f.Copy(ctx, src, "dst/file.txt")
+	assert.Equal(t, fs.ErrorCantCopy, err)
+}
+
+// setupJournalTest makes a local Fs with --local-journal on a new
+// directory using a temporary cache directory
+func setupJournalTest(t *testing.T) (f *Fs, dir string) {
+	oldCacheDir := config.GetCacheDir()
+	require.NoError(t, config.SetCacheDir(t.TempDir()))
+	t.Cleanup(func() {
+		require.NoError(t, config.SetCacheDir(oldCacheDir))
+	})
+	dir = t.TempDir()
+	fsrc, err := NewFs(context.Background(), "local", dir, configmap.Simple{"journal": "true"})
+	require.NoError(t, err)
+	return fsrc.(*Fs), dir
+}
+
+// newTestJournalWatcher makes a journalWatcher for f without
+// watching anything
+func newTestJournalWatcher(f *Fs) *journalWatcher {
+	w := &journalWatcher{f: f, j: journal{Root: f.root}}
+	w.journalPath, w.checkpointsPath = journalFiles(f.root)
+	w.newGeneration()
+	return w
+}
+
+func TestChangedPaths(t *testing.T) {
+	ctx := context.Background()
+	f, _ := setupJournalTest(t)
+	if !watchSupported {
+		assert.Nil(t, f.Features().ChangedPaths)
+	}
+
+	_, commit, err := f.ChangedPaths(ctx, "dst:")
+	assert.ErrorContains(t, err, "no change journal found")
+	assert.Nil(t, commit)
+
+	w := newTestJournalWatcher(f)
+	w.changed("a.txt")
+	require.NoError(t, w.flush())
+
+	// The first sync must check everything
+	_, commit, err = f.ChangedPaths(ctx, "dst:")
+	assert.ErrorContains(t, err, "no previous sync")
+	require.NotNil(t, commit)
+	require.NoError(t, commit())
+
+	// Nothing has changed since
+	paths, _, err := f.ChangedPaths(ctx, "dst:")
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, paths)
+
+	// Changes since the commit are returned
+	w.changed("dir/b.txt")
+	w.changed("a.txt")
+	require.NoError(t, w.flush())
+	paths, commit, err = f.ChangedPaths(ctx, "dst:")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "dir/b.txt"}, paths)
+
+	// Other destinations are independent
+	_, _, err = f.ChangedPaths(ctx, "other:")
+	assert.ErrorContains(t, err, "no previous sync")
+
+	// Paths seen by all the destinations are pruned
+	require.NoError(t, commit())
+	require.NoError(t, w.flush())
+	assert.Len(t, w.j.Paths, 0)
+
+	// Losing track of the changes starts a new generation
+	w.lost("testing")
+	require.NoError(t, w.flush())
+	_, _, err = f.ChangedPaths(ctx, "dst:")
+	assert.ErrorContains(t, err, "restarted")
+
+	// A journal which isn't being updated is stale
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(w.journalPath, old, old))
+	_, commit, err = f.ChangedPaths(ctx, "dst:")
+	assert.ErrorContains(t, err, "not updated")
+	assert.Nil(t, commit)
+}
+
+func TestSyncChangedPaths(t *testing.T) {
+	ctx := context.Background()
+	f, dir := setupJournalTest(t)
+	if f.Features().ChangedPaths == nil {
+		t.Skip("change journal not supported")
+	}
+	accounting.GlobalStats().ResetCounters()
+	defer accounting.GlobalStats().ResetCounters()
+	fdst, err := NewFs(ctx, "local", t.TempDir(), configmap.Simple{})
+	require.NoError(t, err)
+	t1 := fstest.Time("2001-02-03T04:05:06.499999999Z")
+	writeFile := func(name, content string) fstest.Item {
+		osPath := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(osPath), 0777))
+		require.NoError(t, os.WriteFile(osPath, []byte(content), 0666))
+		require.NoError(t, os.Chtimes(osPath, t1, t1))
+		return fstest.NewItem(name, content, t1)
+	}
+	a := writeFile("a.txt", "a")
+	b := writeFile("dir/b.txt", "b")
+	w := newTestJournalWatcher(f)
+	require.NoError(t, w.flush())
+
+	// First sync checks everything
+	require.NoError(t, sync.Sync(ctx, fdst, f, false))
+	fstest.CheckListingWithPrecision(t, fdst, []fstest.Item{a, b}, nil, fs.GetModifyWindow(ctx, fdst))
+
+	// Only the paths in the journal are checked
+	b = writeFile("dir/b.txt", "b changed")
+	c := writeFile("dir/c.txt", "c")
+	_ = writeFile("unseen.txt", "not in the journal")
+	require.NoError(t, os.Remove(filepath.Join(dir, "a.txt")))
+	w.changed("a.txt")
+	w.changed("dir/b.txt")
+	w.changed("dir/c.txt")
+	require.NoError(t, w.flush())
+	require.NoError(t, sync.Sync(ctx, fdst, f, false))
+	fstest.CheckListingWithPrecision(t, fdst, []fstest.Item{b, c}, []string{"dir"}, fs.GetModifyWindow(ctx, fdst))
+
+	// Nothing changed
+	require.NoError(t, w.flush())
+	require.NoError(t, sync.Sync(ctx, fdst, f, false))
+	fstest.CheckListingWithPrecision(t, fdst, []fstest.Item{b, c}, []string{"dir"}, fs.GetModifyWindow(ctx, fdst))
+}
+
+func TestWatch(t *testing.T) {
+	if !watchSupported {
+		t.Skip("watching not supported")
+	}
+	f, dir := setupJournalTest(t)
+	oldFlushInterval := journalFlushInterval
+	journalFlushInterval = 10 * time.Millisecond
+	defer func() {
+		journalFlushInterval = oldFlushInterval
+	}()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "existing"), 0777))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- f.watch(ctx)
+	}()
+	journalPath, _ := journalFiles(f.root)
+	readJournal := func() (j journal) {
+		_ = readJSON(journalPath, &j)
+		return j
+	}
+	require.Eventually(t, func() bool {
+		return readJournal().Generation != ""
+	}, 10*time.Second, 10*time.Millisecond)
+	generation := readJournal().Generation
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "existing", "a.txt"), []byte("a"), 0666))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "new", "sub"), 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new", "sub", "b.txt"), []byte("b"), 0666))
+	require.Eventually(t, func() bool {
+		j := readJournal()
+		_, foundA := j.Paths["existing/a.txt"]
+		_, foundB := j.Paths["new/sub/b.txt"]
+		return foundA && foundB
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// Moving a directory away loses track of what changed
+	require.NoError(t, os.Rename(filepath.Join(dir, "new"), filepath.Join(t.TempDir(), "moved")))
+	require.Eventually(t, func() bool {
+		return readJournal().Generation != generation
+	}, 10*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-errs)
+}
+
+func TestEncodeMetadataValue(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: ""},
+		{in: "hello", want: "hello"},
+		{in: "hello\x00", want: "base64:aGVsbG8A"},
+		{in: "\xff\xfe", want: "base64://4="},
+		{in: "base64:x", want: "base64:YmFzZTY0Ong="},
+	} {
+		got := encodeMetadataValue([]byte(test.in))
+		assert.Equal(t, test.want, got, test.in)
+		decoded, err := decodeMetadataValue(got)
+		require.NoError(t, err)
+		assert.Equal(t, test.in, string(decoded))
+	}
+	_, err := decodeMetadataValue("base64:!")
+	assert.Error(t, err)
+}
+
+func TestPosixACLText(t *testing.T) {
+	const text = "user::rw-,user:1000:r-x,group::r--,group:100:-w-,mask::rwx,other::---"
+	data, err := posixACLFromText(text)
+	require.NoError(t, err)
+	assert.Equal(t, 4+6*posixACLEntrySize, len(data))
+	got, err := posixACLToText(data)
+	require.NoError(t, err)
+	assert.Equal(t, text, got)
+
+	for _, bad := range []string{"", "user", "user::rw", "potato::rwx", "mask:1:rwx", "user:x:rwx", "user::rwz"} {
+		_, err = posixACLFromText(bad)
+		assert.Error(t, err, bad)
+	}
+	_, err = posixACLToText([]byte{1, 0, 0, 0})
+	assert.ErrorContains(t, err, "version")
+	_, err = posixACLToText(data[:5])
+	assert.ErrorContains(t, err, "length")
+}
+
+func TestExtendedMetadata(t *testing.T) {
+	if runtime.GOOS != "linux" || !xattrSupported {
+		t.Skip("test only runs on Linux")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	f, err := NewFs(ctx, "local", dir, configmap.Simple{"extended_metadata": "xattrs,acls"})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("hello"), 0600))
+	o, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+
+	m, err := o.(*Object).Metadata(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, m, aclAccessKey)
+
+	// Not all filesystems support ACLs or trusted xattrs, or we
+	// may not have the privileges to set them
+	const acl = "user::rw-,user:1234:r--,group::r--,mask::r--,other::---"
+	err = o.(*Object).SetMetadata(ctx, fs.Metadata{aclAccessKey: acl})
+	if err != nil {
+		t.Skipf("can't set ACLs here: %v", err)
+	}
+	m, err = o.(*Object).Metadata(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, acl, m[aclAccessKey])
+
+	err = o.(*Object).SetMetadata(ctx, fs.Metadata{"xattr-trusted.rclone": "base64:AAE=", "potato": "chips"})
+	if err != nil {
+		t.Skipf("can't set trusted xattrs here: %v", err)
+	}
+	m, err = o.(*Object).Metadata(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "base64:AAE=", m["xattr-trusted.rclone"])
+	assert.Equal(t, "chips", m["potato"])
+
+	// Without the option the extended metadata isn't read
+	f.(*Fs).opt.ExtendedMetadata = extendedOff
+	m, err = o.(*Object).Metadata(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, m, aclAccessKey)
+	assert.NotContains(t, m, "xattr-trusted.rclone")
+	assert.Equal(t, "chips", m["potato"])
+}
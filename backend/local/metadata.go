@@ -52,6 +52,26 @@ var systemMetadataInfo = map[string]fs.MetadataHelp{
 		Type:    "RFC 3339",
 		Example: "2006-01-02T15:04:05.999999999Z07:00",
 	},
+	aclAccessKey: {
+		Help:    "POSIX access ACL (Linux, needs --local-extended-metadata acls)",
+		Type:    "POSIX ACL short text form with numeric ids",
+		Example: "user::rw-,user:1000:r--,group::r--,mask::r--,other::---",
+	},
+	aclDefaultKey: {
+		Help:    "POSIX default ACL of a directory (Linux, needs --local-extended-metadata acls)",
+		Type:    "POSIX ACL short text form with numeric ids",
+		Example: "user::rwx,group::r-x,other::r-x",
+	},
+	aclNFS4Key: {
+		Help:    "NFSv4 ACL (Linux, needs --local-extended-metadata acls)",
+		Type:    "base64 encoded XDR",
+		Example: "base64:AAAAAQAAAAA=",
+	},
+	aclSDDLKey: {
+		Help:    "Owner, group and DACL (Windows, needs --local-extended-metadata acls)",
+		Type:    "SDDL string",
+		Example: "O:BAG:SYD:PAI(A;;FA;;;SY)(A;;FA;;;BA)",
+	},
 }
 
 // parse a time string from metadata with key
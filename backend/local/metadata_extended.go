@@ -0,0 +1,188 @@
+package local
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// extendedMetadataChoices type for fs.Bits
+type extendedMetadataChoices struct{}
+
+func (extendedMetadataChoices) Choices() []fs.BitsChoicesInfo {
+	return []fs.BitsChoicesInfo{
+		{Bit: uint64(extendedOff), Name: "off"},
+		{Bit: uint64(extendedXattrs), Name: "xattrs"},
+		{Bit: uint64(extendedACLs), Name: "acls"},
+		{Bit: uint64(extendedStreams), Name: "streams"},
+	}
+}
+
+// extendedMetadata chooses which extra metadata is read and written
+type extendedMetadata = fs.Bits[extendedMetadataChoices]
+
+const (
+	extendedXattrs extendedMetadata = 1 << iota
+	extendedACLs
+	extendedStreams
+	extendedOff extendedMetadata = 0
+)
+
+// Metadata keys for the extended metadata
+const (
+	xattrKeyPrefix  = "xattr-"  // extended attributes outside the user namespace
+	streamKeyPrefix = "stream-" // NTFS alternate data streams
+	aclAccessKey    = "acl-access"
+	aclDefaultKey   = "acl-default"
+	aclNFS4Key      = "acl-nfs4"
+	aclSDDLKey      = "acl-sddl"
+)
+
+// binary metadata values are stored as base64 after this prefix
+const base64Prefix = "base64:"
+
+// encodeMetadataValue turns data into a metadata value, encoding it
+// as base64 if it isn't printable as a string
+func encodeMetadataValue(data []byte) string {
+	if utf8.Valid(data) && bytes.IndexByte(data, 0) < 0 && !bytes.HasPrefix(data, []byte(base64Prefix)) {
+		return string(data)
+	}
+	return base64Prefix + base64.StdEncoding.EncodeToString(data)
+}
+
+// decodeMetadataValue reverses encodeMetadataValue
+func decodeMetadataValue(value string) ([]byte, error) {
+	if encoded, ok := strings.CutPrefix(value, base64Prefix); ok {
+		return base64.StdEncoding.DecodeString(encoded)
+	}
+	return []byte(value), nil
+}
+
+// Linux stores POSIX ACLs in these xattrs in the format below
+const (
+	posixACLAccessXattr  = "system.posix_acl_access"
+	posixACLDefaultXattr = "system.posix_acl_default"
+	nfs4ACLXattr         = "system.nfs4_acl"
+	posixACLVersion      = 2
+	posixACLUndefinedID  = 0xFFFFFFFF
+	posixACLEntrySize    = 8
+)
+
+// POSIX ACL entry tags
+const (
+	posixACLUserObj  = 0x01
+	posixACLUser     = 0x02
+	posixACLGroupObj = 0x04
+	posixACLGroup    = 0x08
+	posixACLMask     = 0x10
+	posixACLOther    = 0x20
+)
+
+var posixACLTags = map[uint16]string{
+	posixACLUserObj:  "user",
+	posixACLUser:     "user",
+	posixACLGroupObj: "group",
+	posixACLGroup:    "group",
+	posixACLMask:     "mask",
+	posixACLOther:    "other",
+}
+
+// posixACLToText converts a Linux POSIX ACL xattr into the short
+// text form with numeric ids, e.g. "user::rw-,user:1000:r--,group::r--,mask::r--,other::---"
+func posixACLToText(data []byte) (string, error) {
+	if len(data) < 4 || (len(data)-4)%posixACLEntrySize != 0 {
+		return "", fmt.Errorf("bad POSIX ACL length %d", len(data))
+	}
+	if version := binary.LittleEndian.Uint32(data); version != posixACLVersion {
+		return "", fmt.Errorf("unknown POSIX ACL version %d", version)
+	}
+	var entries []string
+	for entry := data[4:]; len(entry) > 0; entry = entry[posixACLEntrySize:] {
+		tag := binary.LittleEndian.Uint16(entry[0:])
+		perm := binary.LittleEndian.Uint16(entry[2:])
+		id := binary.LittleEndian.Uint32(entry[4:])
+		name, ok := posixACLTags[tag]
+		if !ok {
+			return "", fmt.Errorf("unknown POSIX ACL tag %#x", tag)
+		}
+		qualifier := ""
+		if tag == posixACLUser || tag == posixACLGroup {
+			qualifier = strconv.FormatUint(uint64(id), 10)
+		}
+		perms := []byte("---")
+		for i, c := range "rwx" {
+			if perm&(4>>i) != 0 {
+				perms[i] = byte(c)
+			}
+		}
+		entries = append(entries, name+":"+qualifier+":"+string(perms))
+	}
+	return strings.Join(entries, ","), nil
+}
+
+// posixACLFromText converts the text form of a POSIX ACL made by
+// posixACLToText into a Linux POSIX ACL xattr
+func posixACLFromText(text string) ([]byte, error) {
+	if text == "" {
+		return nil, errors.New("empty POSIX ACL")
+	}
+	data := binary.LittleEndian.AppendUint32(nil, posixACLVersion)
+	for entry := range strings.SplitSeq(text, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 || len(parts[2]) != 3 {
+			return nil, fmt.Errorf("bad POSIX ACL entry %q", entry)
+		}
+		var tag uint16
+		id := uint64(posixACLUndefinedID)
+		qualified := parts[1] != ""
+		switch parts[0] {
+		case "user":
+			tag = posixACLUserObj
+			if qualified {
+				tag = posixACLUser
+			}
+		case "group":
+			tag = posixACLGroupObj
+			if qualified {
+				tag = posixACLGroup
+			}
+		case "mask":
+			tag = posixACLMask
+		case "other":
+			tag = posixACLOther
+		default:
+			return nil, fmt.Errorf("bad POSIX ACL entry type in %q", entry)
+		}
+		if qualified {
+			if tag != posixACLUser && tag != posixACLGroup {
+				return nil, fmt.Errorf("unexpected id in POSIX ACL entry %q", entry)
+			}
+			var err error
+			id, err = strconv.ParseUint(parts[1], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("bad id in POSIX ACL entry %q: %w", entry, err)
+			}
+		}
+		var perm uint16
+		for i, c := range parts[2] {
+			switch {
+			case c == '-':
+			case c == rune("rwx"[i]):
+				perm |= 4 >> i
+			default:
+				return nil, fmt.Errorf("bad permissions in POSIX ACL entry %q", entry)
+			}
+		}
+		data = binary.LittleEndian.AppendUint16(data, tag)
+		data = binary.LittleEndian.AppendUint16(data, perm)
+		data = binary.LittleEndian.AppendUint32(data, uint32(id))
+	}
+	return data, nil
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package local
+
+import "github.com/rclone/rclone/fs"
+
+// readExtendedMetadata reads the metadata which isn't stored in
+// xattrs - there is none on this OS
+func (o *Object) readExtendedMetadata(m *fs.Metadata) error {
+	return nil
+}
+
+// writeExtendedMetadata writes the metadata which isn't stored in
+// xattrs - there is none on this OS
+func (o *Object) writeExtendedMetadata(m fs.Metadata) error {
+	return nil
+}
@@ -0,0 +1,140 @@
+//go:build windows
+
+package local
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+
+	"github.com/rclone/rclone/fs"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modkernel32          = windows.NewLazySystemDLL("kernel32.dll")
+	procFindFirstStreamW = modkernel32.NewProc("FindFirstStreamW")
+	procFindNextStreamW  = modkernel32.NewProc("FindNextStreamW")
+)
+
+// win32FindStreamData is WIN32_FIND_STREAM_DATA
+type win32FindStreamData struct {
+	StreamSize int64
+	StreamName [windows.MAX_PATH + 36]uint16
+}
+
+// the security information in the acl-sddl metadata
+const sddlSecurityInformation = windows.OWNER_SECURITY_INFORMATION | windows.GROUP_SECURITY_INFORMATION | windows.DACL_SECURITY_INFORMATION
+
+// listStreams returns the names of the alternate data streams of
+// the file at path
+func listStreams(path string) (names []string, err error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	var data win32FindStreamData
+	h, _, err := procFindFirstStreamW.Call(uintptr(unsafe.Pointer(p)), 0, uintptr(unsafe.Pointer(&data)), 0)
+	if windows.Handle(h) == windows.InvalidHandle {
+		if errors.Is(err, windows.ERROR_HANDLE_EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = windows.FindClose(windows.Handle(h))
+	}()
+	for {
+		// Names look like ":NAME:$DATA" with "::$DATA" the main stream
+		name := windows.UTF16ToString(data.StreamName[:])
+		name = strings.TrimSuffix(strings.TrimPrefix(name, ":"), ":$DATA")
+		if name != "" {
+			names = append(names, name)
+		}
+		ok, _, err := procFindNextStreamW.Call(h, uintptr(unsafe.Pointer(&data)))
+		if ok == 0 {
+			if errors.Is(err, windows.ERROR_HANDLE_EOF) {
+				return names, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+// readExtendedMetadata reads the alternate data streams and the
+// security descriptor if required
+func (o *Object) readExtendedMetadata(m *fs.Metadata) error {
+	ext := o.fs.opt.ExtendedMetadata
+	if ext == extendedOff || o.translatedLink {
+		return nil
+	}
+	if ext.IsSet(extendedStreams) {
+		names, err := listStreams(o.path)
+		if err != nil {
+			return fmt.Errorf("failed to list alternate data streams: %w", err)
+		}
+		for _, name := range names {
+			data, err := os.ReadFile(o.path + ":" + name)
+			if err != nil {
+				return fmt.Errorf("failed to read alternate data stream %q: %w", name, err)
+			}
+			m.Set(streamKeyPrefix+name, encodeMetadataValue(data))
+		}
+	}
+	if ext.IsSet(extendedACLs) {
+		sd, err := windows.GetNamedSecurityInfo(o.path, windows.SE_FILE_OBJECT, sddlSecurityInformation)
+		if err != nil {
+			return fmt.Errorf("failed to read security descriptor: %w", err)
+		}
+		m.Set(aclSDDLKey, sd.String())
+	}
+	return nil
+}
+
+// writeExtendedMetadata writes the alternate data streams and the
+// security descriptor if required
+//
+// Only the DACL is set from the security descriptor as setting the
+// owner needs extra privileges.
+func (o *Object) writeExtendedMetadata(m fs.Metadata) error {
+	ext := o.fs.opt.ExtendedMetadata
+	if ext == extendedOff || o.translatedLink {
+		return nil
+	}
+	for k, value := range m {
+		switch {
+		case ext.IsSet(extendedStreams) && strings.HasPrefix(k, streamKeyPrefix):
+			name := k[len(streamKeyPrefix):]
+			data, err := decodeMetadataValue(value)
+			if err != nil {
+				return fmt.Errorf("failed to decode metadata %q: %w", k, err)
+			}
+			err = os.WriteFile(o.path+":"+name, data, 0666)
+			if err != nil {
+				return fmt.Errorf("failed to write alternate data stream %q: %w", name, err)
+			}
+		case ext.IsSet(extendedACLs) && k == aclSDDLKey:
+			sd, err := windows.SecurityDescriptorFromString(value)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", aclSDDLKey, err)
+			}
+			dacl, _, err := sd.DACL()
+			if err != nil {
+				return fmt.Errorf("failed to read DACL from %s: %w", aclSDDLKey, err)
+			}
+			info := windows.SECURITY_INFORMATION(windows.DACL_SECURITY_INFORMATION)
+			if control, _, err := sd.Control(); err == nil && control&windows.SE_DACL_PROTECTED != 0 {
+				info |= windows.PROTECTED_DACL_SECURITY_INFORMATION
+			} else {
+				info |= windows.UNPROTECTED_DACL_SECURITY_INFORMATION
+			}
+			err = windows.SetNamedSecurityInfo(o.path, windows.SE_FILE_OBJECT, info, nil, nil, dacl, nil)
+			if err != nil {
+				return fmt.Errorf("failed to set DACL: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+//go:build linux
+
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const watchSupported = true
+
+// the inotify events which mean something in a directory changed
+const inotifyMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_ATTRIB |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF |
+	unix.IN_DONT_FOLLOW | unix.IN_EXCL_UNLINK | unix.IN_ONLYDIR
+
+// inotifyWatcher watches a directory tree with inotify which needs a
+// watch on every directory
+type inotifyWatcher struct {
+	fd      int
+	root    string
+	dirs    map[int32]string // watch descriptor to directory relative to root
+	changed func(rel string)
+	lost    func(reason string)
+}
+
+// watchTree watches root and everything below it for changes until
+// ctx is cancelled.
+//
+// changed is called with the slash separated path relative to root
+// of each thing which changes. lost is called if it is no longer
+// known what changed. ready is called once the watches are in place.
+func watchTree(ctx context.Context, root string, changed func(rel string), lost func(reason string), ready func()) error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("watch: failed to start inotify: %w", err)
+	}
+	// os.File uses the runtime poller for non blocking fds so Read
+	// can be interrupted by Close
+	file := os.NewFile(uintptr(fd), "inotify")
+	w := &inotifyWatcher{
+		fd:      fd,
+		root:    root,
+		dirs:    make(map[int32]string),
+		changed: changed,
+		lost:    lost,
+	}
+	if err = w.addTree("", false); err != nil {
+		_ = file.Close()
+		return err
+	}
+	ready()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = file.Close()
+	}()
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("watch: failed to read inotify events: %w", err)
+		}
+		for off := 0; off+unix.SizeofInotifyEvent <= n; {
+			ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			start := off + unix.SizeofInotifyEvent
+			off = start + int(ev.Len)
+			name := strings.TrimRight(string(buf[start:min(off, n)]), "\x00")
+			w.event(ev.Wd, ev.Mask, name)
+		}
+	}
+}
+
+// addTree adds watches to the directory rel and all the directories
+// below it, calling changed for everything found if record is set.
+func (w *inotifyWatcher) addTree(rel string, record bool) error {
+	return filepath.WalkDir(filepath.Join(w.root, filepath.FromSlash(rel)), func(osPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Ignore things removed while we are walking
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		entryRel, err := filepath.Rel(w.root, osPath)
+		if err != nil {
+			return err
+		}
+		entryRel = filepath.ToSlash(entryRel)
+		if entryRel == "." {
+			entryRel = ""
+		}
+		if record {
+			w.changed(entryRel)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		wd, err := unix.InotifyAddWatch(w.fd, osPath, inotifyMask)
+		switch {
+		case err == unix.ENOSPC:
+			return errors.New("watch: too many directories to watch: increase the fs.inotify.max_user_watches sysctl")
+		case err == unix.ENOENT || err == unix.ENOTDIR:
+			return nil
+		case err != nil:
+			return fmt.Errorf("watch: failed to watch %q: %w", osPath, err)
+		}
+		w.dirs[int32(wd)] = entryRel
+		return nil
+	})
+}
+
+// event handles a single inotify event
+func (w *inotifyWatcher) event(wd int32, mask uint32, name string) {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		w.lost("too many changes to keep up with")
+		return
+	}
+	dir, found := w.dirs[wd]
+	if !found {
+		return
+	}
+	if mask&unix.IN_IGNORED != 0 {
+		delete(w.dirs, wd)
+		return
+	}
+	if mask&(unix.IN_DELETE_SELF|unix.IN_MOVE_SELF) != 0 {
+		// The parent directory sees the change for everything
+		// except the root
+		if dir == "" {
+			w.lost("the root directory was removed or moved")
+		}
+		return
+	}
+	rel := path.Join(dir, name)
+	if mask&unix.IN_ISDIR != 0 {
+		switch {
+		case mask&unix.IN_MOVED_FROM != 0:
+			// We don't know what was in the directory so can't
+			// say which paths went away
+			w.lost(fmt.Sprintf("directory %q was moved", rel))
+			return
+		case mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+			if err := w.addTree(rel, true); err != nil {
+				w.lost(err.Error())
+			}
+			return
+		}
+	}
+	w.changed(rel)
+}
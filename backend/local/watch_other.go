@@ -0,0 +1,12 @@
+//go:build !linux && !windows
+
+package local
+
+import "context"
+
+const watchSupported = false
+
+// watchTree is not supported on this OS
+func watchTree(ctx context.Context, root string, changed func(rel string), lost func(reason string), ready func()) error {
+	return errWatchUnsupported
+}
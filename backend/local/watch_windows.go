@@ -0,0 +1,114 @@
+//go:build windows
+
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const watchSupported = true
+
+// the changes which ReadDirectoryChangesW reports
+const notifyMask = windows.FILE_NOTIFY_CHANGE_FILE_NAME | windows.FILE_NOTIFY_CHANGE_DIR_NAME |
+	windows.FILE_NOTIFY_CHANGE_ATTRIBUTES | windows.FILE_NOTIFY_CHANGE_SIZE |
+	windows.FILE_NOTIFY_CHANGE_LAST_WRITE | windows.FILE_NOTIFY_CHANGE_CREATION
+
+// watchTree watches root and everything below it for changes until
+// ctx is cancelled.
+//
+// changed is called with the slash separated path relative to root
+// of each thing which changes. lost is called if it is no longer
+// known what changed. ready is called once the watch is in place.
+func watchTree(ctx context.Context, root string, changed func(rel string), lost func(reason string), ready func()) error {
+	p, err := windows.UTF16PtrFromString(root)
+	if err != nil {
+		return err
+	}
+	h, err := windows.CreateFile(p, windows.FILE_LIST_DIRECTORY,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fmt.Errorf("watch: failed to open %q: %w", root, err)
+	}
+	defer func() {
+		_ = windows.CloseHandle(h)
+	}()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = windows.CancelIoEx(h, nil)
+		case <-done:
+		}
+	}()
+	ready()
+	// Network shares can't return more than 64k of changes
+	buf := make([]byte, 64*1024)
+	for {
+		var n uint32
+		err := windows.ReadDirectoryChanges(h, &buf[0], uint32(len(buf)), true, notifyMask, &n, nil, 0)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == windows.ERROR_NOTIFY_ENUM_DIR || (err == nil && n == 0) {
+			lost("too many changes to keep up with")
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("watch: failed to read changes: %w", err)
+		}
+		for off := uint32(0); off < n; {
+			info := (*windows.FileNotifyInformation)(unsafe.Pointer(&buf[off]))
+			name := windows.UTF16ToString(unsafe.Slice(&info.FileName, info.FileNameLength/2))
+			rel := filepath.ToSlash(name)
+			switch info.Action {
+			case windows.FILE_ACTION_ADDED, windows.FILE_ACTION_RENAMED_NEW_NAME:
+				if fi, err := os.Lstat(filepath.Join(root, name)); err == nil && fi.IsDir() {
+					if info.Action == windows.FILE_ACTION_RENAMED_NEW_NAME {
+						// We don't know what the directory was
+						// called before so can't say which
+						// paths went away
+						lost(fmt.Sprintf("directory %q was renamed", rel))
+						break
+					}
+					if err := recordTree(root, name, changed); err != nil {
+						lost(err.Error())
+					}
+				}
+			}
+			changed(rel)
+			if info.NextEntryOffset == 0 {
+				break
+			}
+			off += info.NextEntryOffset
+		}
+	}
+}
+
+// recordTree calls changed for everything in the directory name
+// relative to root
+func recordTree(root, name string, changed func(rel string)) error {
+	return filepath.WalkDir(filepath.Join(root, name), func(osPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(root, osPath)
+		if err != nil {
+			return err
+		}
+		changed(filepath.ToSlash(rel))
+		return nil
+	})
+}
@@ -3,7 +3,9 @@
 package local
 
 import (
+	"errors"
 	"fmt"
+	"runtime"
 	"strings"
 	"syscall"
 
@@ -34,6 +36,43 @@ func (f *Fs) xattrIsNotSupported(err error) bool {
 	return false
 }
 
+// xattrKey returns the metadata key for the xattr name or "" if it
+// shouldn't be read
+func (o *Object) xattrKey(name string) string {
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, xattrPrefix) {
+		k := lower[len(xattrPrefix):]
+		if _, found := systemMetadataInfo[k]; found {
+			return ""
+		}
+		return k
+	}
+	if name == posixACLAccessXattr || name == posixACLDefaultXattr || name == nfs4ACLXattr {
+		// Read by getACLs
+		return ""
+	}
+	if o.fs.opt.ExtendedMetadata.IsSet(extendedXattrs) {
+		return xattrKeyPrefix + name
+	}
+	return ""
+}
+
+// getXattrValue reads the value of the xattr name
+func (o *Object) getXattrValue(name string) ([]byte, error) {
+	if o.fs.opt.FollowSymlinks {
+		return xattr.Get(o.path, name)
+	}
+	return xattr.LGet(o.path, name)
+}
+
+// setXattrValue sets the xattr name to value
+func (o *Object) setXattrValue(name string, value []byte) error {
+	if o.fs.opt.FollowSymlinks {
+		return xattr.Set(o.path, name, value)
+	}
+	return xattr.LSet(o.path, name, value)
+}
+
 // getXattr returns the extended attributes for an object
 //
 // It doesn't return any attributes owned by this backend in
@@ -54,36 +93,104 @@ func (o *Object) getXattr() (metadata fs.Metadata, err error) {
 		}
 		return nil, fmt.Errorf("failed to read xattr: %w", err)
 	}
-	if len(list) == 0 {
-		return nil, nil
-	}
-	metadata = make(fs.Metadata, len(list))
-	for _, k := range list {
-		var v []byte
-		if o.fs.opt.FollowSymlinks {
-			v, err = xattr.Get(o.path, k)
-		} else {
-			v, err = xattr.LGet(o.path, k)
+	for _, name := range list {
+		k := o.xattrKey(name)
+		if k == "" {
+			continue
 		}
+		v, err := o.getXattrValue(name)
 		if err != nil {
 			if o.fs.xattrIsNotSupported(err) {
 				return nil, nil
 			}
-			return nil, fmt.Errorf("failed to read xattr key %q: %w", k, err)
+			return nil, fmt.Errorf("failed to read xattr key %q: %w", name, err)
 		}
-		k = strings.ToLower(k)
-		if !strings.HasPrefix(k, xattrPrefix) {
-			continue
+		if metadata == nil {
+			metadata = make(fs.Metadata, len(list))
 		}
-		k = k[len(xattrPrefix):]
-		if _, found := systemMetadataInfo[k]; found {
-			continue
+		if strings.HasPrefix(k, xattrKeyPrefix) {
+			metadata[k] = encodeMetadataValue(v)
+		} else {
+			metadata[k] = string(v)
+		}
+	}
+	if o.fs.opt.ExtendedMetadata.IsSet(extendedACLs) {
+		err = o.getACLs(&metadata)
+		if err != nil {
+			return nil, err
 		}
-		metadata[k] = string(v)
 	}
 	return metadata, nil
 }
 
+// getACLs reads the Linux POSIX and NFSv4 ACLs into metadata
+//
+// These are read by name as the NFSv4 ACL isn't listed
+func (o *Object) getACLs(metadata *fs.Metadata) error {
+	if runtime.GOOS != "linux" || o.translatedLink {
+		return nil
+	}
+	for _, name := range []string{posixACLAccessXattr, posixACLDefaultXattr, nfs4ACLXattr} {
+		v, err := o.getXattrValue(name)
+		if err != nil {
+			var xattrErr *xattr.Error
+			if errors.As(err, &xattrErr) && (xattrErr.Err == xattr.ENOATTR || xattrErr.Err == syscall.ENOTSUP || xattrErr.Err == syscall.EOPNOTSUPP) {
+				// No ACL or not supported by this filesystem
+				continue
+			}
+			return fmt.Errorf("failed to read ACL %q: %w", name, err)
+		}
+		switch name {
+		case posixACLAccessXattr, posixACLDefaultXattr:
+			text, err := posixACLToText(v)
+			if err != nil {
+				return fmt.Errorf("failed to read ACL %q: %w", name, err)
+			}
+			if name == posixACLAccessXattr {
+				metadata.Set(aclAccessKey, text)
+			} else {
+				metadata.Set(aclDefaultKey, text)
+			}
+		case nfs4ACLXattr:
+			metadata.Set(aclNFS4Key, encodeMetadataValue(v))
+		}
+	}
+	return nil
+}
+
+// xattrName returns the xattr name and value to set for the metadata
+// key and value or "" if it shouldn't be set
+func (o *Object) xattrName(k, value string) (name string, v []byte, err error) {
+	ext := o.fs.opt.ExtendedMetadata
+	switch {
+	case ext.IsSet(extendedXattrs) && strings.HasPrefix(k, xattrKeyPrefix):
+		name = k[len(xattrKeyPrefix):]
+		v, err = decodeMetadataValue(value)
+		return name, v, err
+	case k == aclAccessKey || k == aclDefaultKey:
+		if !ext.IsSet(extendedACLs) || runtime.GOOS != "linux" || o.translatedLink {
+			return "", nil, nil
+		}
+		name = posixACLAccessXattr
+		if k == aclDefaultKey {
+			name = posixACLDefaultXattr
+		}
+		v, err = posixACLFromText(value)
+		return name, v, err
+	case k == aclNFS4Key:
+		if !ext.IsSet(extendedACLs) || runtime.GOOS != "linux" || o.translatedLink {
+			return "", nil, nil
+		}
+		v, err = decodeMetadataValue(value)
+		return nfs4ACLXattr, v, err
+	}
+	k = strings.ToLower(k)
+	if _, found := systemMetadataInfo[k]; found {
+		return "", nil, nil
+	}
+	return xattrPrefix + k, []byte(value), nil
+}
+
 // setXattr sets the metadata on the file Xattrs
 //
 // It doesn't set any attributes owned by this backend in metadataKeys
@@ -92,22 +199,19 @@ func (o *Object) setXattr(metadata fs.Metadata) (err error) {
 		return nil
 	}
 	for k, value := range metadata {
-		k = strings.ToLower(k)
-		if _, found := systemMetadataInfo[k]; found {
-			continue
+		name, v, err := o.xattrName(k, value)
+		if err != nil {
+			return fmt.Errorf("failed to decode metadata %q: %w", k, err)
 		}
-		k = xattrPrefix + k
-		v := []byte(value)
-		if o.fs.opt.FollowSymlinks {
-			err = xattr.Set(o.path, k, v)
-		} else {
-			err = xattr.LSet(o.path, k, v)
+		if name == "" {
+			continue
 		}
+		err = o.setXattrValue(name, v)
 		if err != nil {
-			if o.fs.xattrIsNotSupported(err) {
+			if !strings.HasPrefix(name, "system.") && o.fs.xattrIsNotSupported(err) {
 				return nil
 			}
-			return fmt.Errorf("failed to set xattr key %q: %w", k, err)
+			return fmt.Errorf("failed to set xattr key %q: %w", name, err)
 		}
 	}
 	return nil
@@ -13,6 +13,8 @@ Improvements:
 * Uploads would be more efficient with bigger chunks
 * Looks like mega can support server-side copy, but it isn't implemented in go-mega
 * Upload can set modtime... - set as int64_t - can set ctime and mtime?
+* Uploads can't be resumed as go-mega doesn't expose the upload state
+  (upload URL, file key and chunk MACs) needed to persist and restore it
 */
 
 import (
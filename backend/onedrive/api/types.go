@@ -597,7 +597,8 @@ type DriveResource struct {
 
 // DrivesResponse is returned from /sites/{siteID}/drives",
 type DrivesResponse struct {
-	Drives []DriveResource `json:"value"`
+	Drives   []DriveResource `json:"value"`
+	NextLink string          `json:"@odata.nextLink"`
 }
 
 // SiteResource is part of the response from "/sites/root:"
@@ -609,7 +610,8 @@ type SiteResource struct {
 
 // SiteResponse is returned from "/sites/root:"
 type SiteResponse struct {
-	Sites []SiteResource `json:"value"`
+	Sites    []SiteResource `json:"value"`
+	NextLink string         `json:"@odata.nextLink"`
 }
 
 // GetGrantedTo returns the GrantedTo property.
@@ -633,3 +635,15 @@ func (p *PermissionsType) GetGrantedToIdentities(driveType string) []*IdentitySe
 	}
 	return p.GrantedToIdentitiesV2
 }
+
+// Thumbnail is a thumbnail image of an item
+type Thumbnail struct {
+	Height int    `json:"height"` // The height of the thumbnail, in pixels.
+	Width  int    `json:"width"`  // The width of the thumbnail, in pixels.
+	URL    string `json:"url"`    // The URL used to fetch the thumbnail content.
+}
+
+// PreviewResponse is returned from "/items/{id}/preview"
+type PreviewResponse struct {
+	GetURL string `json:"getUrl"` // URL suitable for embedding using HTTP GET (iframes, etc.)
+}
@@ -1023,6 +1023,12 @@ func (d *Directory) ID() string {
 	return d.id
 }
 
+// ChangeToken returns a string which changes when anything in the
+// directory changes or "" if dir_change_tokens isn't set
+func (d *Directory) ChangeToken() string {
+	return d.token
+}
+
 // MimeType returns the content type of the Object if
 // known, or "" if not
 func (d *Directory) MimeType(ctx context.Context) string {
@@ -27,6 +27,7 @@ import (
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/configstruct"
 	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/fshttp"
 	"github.com/rclone/rclone/fs/hash"
@@ -71,6 +72,7 @@ var (
 	commonPathPrefix = "/common" // prefix for the paths if tenant isn't known
 	authPath         = "/oauth2/v2.0/authorize"
 	tokenPath        = "/oauth2/v2.0/token"
+	devicePath       = "/oauth2/v2.0/devicecode"
 
 	scopeAccess             = fs.SpaceSepList{"Files.Read", "Files.ReadWrite", "Files.Read.All", "Files.ReadWrite.All", "Sites.Read.All", "offline_access"}
 	scopeAccessWithoutSites = fs.SpaceSepList{"Files.Read", "Files.ReadWrite", "Files.Read.All", "Files.ReadWrite.All", "offline_access"}
@@ -120,6 +122,7 @@ func init() {
 			System: systemMetadataInfo,
 			Help:   metadataHelp,
 		},
+		CommandHelp: commandHelp,
 		Options: append(oauthutil.SharedOptions, []fs.Option{{
 			Name:    "region",
 			Help:    "Choose national cloud region for OneDrive.",
@@ -409,6 +412,46 @@ performance loss.
 
 It is recommended if you are mounting your onedrive at the root
 (or near the root when using crypt) and using rclone |rc vfs/refresh|.
+`, "|", "`"),
+			Advanced: true,
+		}, {
+			Name:    "dir_change_tokens",
+			Default: false,
+			Help: strings.ReplaceAll(`Use folder eTags to skip unchanged folders when syncing.
+
+If set then folders are given a change token made from their eTag and
+total size which |--prune-unchanged-dirs| uses to skip source folders
+which haven't changed since the last successful sync.
+
+Only set this if the eTag of a folder changes when anything inside it
+changes, which is the case on OneDrive Personal but may not be for
+every drive type. If in doubt leave it off.
+`, "|", "`"),
+			Advanced: true,
+		}, {
+			Name:    "all_sites",
+			Default: false,
+			Help: strings.ReplaceAll(`Show all SharePoint sites and their drives as directories.
+
+If this flag is set then the remote doesn't use |drive_id| and
+|drive_type|. Instead the top level directories are the SharePoint
+sites the credentials can access and the directories inside those are
+the drives (document libraries) of each site, so
+
+    rclone lsd onedrive:
+    rclone lsd "onedrive:Site Name"
+    rclone sync "onedrive:Site Name/Documents" /backup/site
+
+Sites and drives are discovered when first listed and each drive has
+its own connection and pacer, so a slow or throttled drive doesn't
+slow down the others. This makes it possible to back up a whole tenant
+from one remote.
+
+If two sites or drives have the same name then the last part of the
+site URL or the drive ID is added in brackets to tell them apart.
+
+Files and directories can't be created or removed at the site or
+drive level.
 `, "|", "`"),
 			Advanced: true,
 		}, {
@@ -576,6 +619,7 @@ func makeOauthConfig(ctx context.Context, opt *Options) (*oauthutil.Config, erro
 	}
 	oauthConfig.TokenURL = authEndpoint[opt.Region] + prefix + tokenPath
 	oauthConfig.AuthURL = authEndpoint[opt.Region] + prefix + authPath
+	oauthConfig.DeviceAuthURL = authEndpoint[opt.Region] + prefix + devicePath
 
 	// Check to see if we are using client credentials flow
 	if opt.ClientCredentials {
@@ -782,6 +826,8 @@ type Options struct {
 	HashType                string               `config:"hash_type"`
 	AVOverride              bool                 `config:"av_override"`
 	Delta                   bool                 `config:"delta"`
+	DirChangeTokens         bool                 `config:"dir_change_tokens"`
+	AllSites                bool                 `config:"all_sites"`
 	Enc                     encoder.MultiEncoder `config:"encoding"`
 	MetadataPermissions     rwChoice             `config:"metadata_permissions"`
 }
@@ -826,6 +872,7 @@ type Directory struct {
 	size   int64     // size of directory and contents or -1 if unknown
 	items  int64     // number of objects or -1 for unknown
 	id     string    // dir ID
+	token  string    // change token if dir_change_tokens is set
 	meta   *Metadata // metadata properties
 }
 
@@ -913,7 +960,7 @@ func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, err
 				fs.Errorf(nil, "%v: upload chunks may be taking too long - try reducing --onedrive-chunk-size or decreasing --transfers", err)
 			})
 		case 507: // Insufficient Storage
-			return false, fserrors.FatalError(err)
+			return false, fserrors.FatalError(fserrors.QuotaError(err))
 		}
 	}
 	return retry || fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
@@ -1064,6 +1111,10 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		return nil, fmt.Errorf("onedrive: upload cutoff: %w", err)
 	}
 
+	if opt.AllSites {
+		return newSitesFs(ctx, name, root, m, opt)
+	}
+
 	if opt.DriveID == "" || opt.DriveType == "" {
 		return nil, errors.New("unable to get drive_id and drive_type - if you are upgrading from older versions of rclone, please run `rclone config` and re-configure this backend")
 	}
@@ -1108,7 +1159,10 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		WriteDirMetadata:         true,
 		WriteDirSetModTime:       true,
 		UserDirMetadata:          false,
+		ListQuery:                true,
 		DirModTimeUpdatesOnWrite: false,
+		NativeTrash:              !opt.HardDelete,
+		PublicLinkOptions:        true,
 	}).Fill(ctx, f)
 	f.srv.SetErrorHandler(errorHandler)
 
@@ -1332,9 +1386,19 @@ func (f *Fs) _listAll(ctx context.Context, dirID string, directoriesOnly bool, f
 //
 // If the user fn ever returns true then it early exits with found = true
 func (f *Fs) listAll(ctx context.Context, dirID string, directoriesOnly bool, filesOnly bool, fn listAllFn) (err error) {
+	return f.listAllWithFilter(ctx, dirID, "", directoriesOnly, filesOnly, fn)
+}
+
+// Lists the directory required calling the user function on each
+// item found which matches the OData $filter if set
+func (f *Fs) listAllWithFilter(ctx context.Context, dirID string, odataFilter string, directoriesOnly bool, filesOnly bool, fn listAllFn) (err error) {
 	// Top parameter asks for bigger pages of data
 	// https://dev.onedrive.com/odata/optional-query-parameters.htm
-	opts := f.newOptsCall(dirID, "GET", fmt.Sprintf("/children?$top=%d", f.opt.ListChunk))
+	route := fmt.Sprintf("/children?$top=%d", f.opt.ListChunk)
+	if odataFilter != "" {
+		route += "&$filter=" + strings.ReplaceAll(url.QueryEscape(odataFilter), "+", "%20")
+	}
+	opts := f.newOptsCall(dirID, "GET", route)
 	var result api.ListChildrenResponse
 	return f._listAll(ctx, dirID, directoriesOnly, filesOnly, fn, &opts, &result, &result.Value, &result.NextLink)
 }
@@ -1355,6 +1419,9 @@ func (f *Fs) itemToDirEntry(ctx context.Context, dir string, info *api.Item) (en
 		f.dirCache.Put(remote, id)
 		d := f.newDir(id, remote)
 		d.items = folder.ChildCount
+		if f.opt.DirChangeTokens && info.ETag != "" {
+			d.token = fmt.Sprintf("%s/%d", info.ETag, info.GetSize())
+		}
 		f.setSystemMetadata(info, d.meta, remote, dirMimeType)
 		entry = d
 	} else {
@@ -1399,7 +1466,7 @@ func (f *Fs) ListP(ctx context.Context, dir string, callback fs.ListRCallback) e
 	if err != nil {
 		return err
 	}
-	err = f.listAll(ctx, directoryID, false, false, func(info *api.Item) error {
+	err = f.listAllWithFilter(ctx, directoryID, filter.GetListQuery(ctx), false, false, func(info *api.Item) error {
 		entry, err := f.itemToDirEntry(ctx, dir, info)
 		if err != nil {
 			return err
@@ -1990,6 +2057,26 @@ func (f *Fs) About(ctx context.Context) (usage *fs.Usage, err error) {
 	return usage, nil
 }
 
+// unlinkPublic removes the sharing links made directly on normalizedID
+func (f *Fs) unlinkPublic(ctx context.Context, normalizedID string) error {
+	perms, _, err := f.getPermissions(ctx, normalizedID)
+	if err != nil {
+		return fmt.Errorf("failed to list permissions: %w", err)
+	}
+	m := &Metadata{fs: f, normalizedID: normalizedID}
+	for _, p := range perms {
+		if p.Link == nil || p.InheritedFrom != nil {
+			continue
+		}
+		fs.Debugf(f, "removing sharing link %q", p.Link.WebURL)
+		_, err = m.removePermission(ctx, p)
+		if err != nil {
+			return fmt.Errorf("failed to remove sharing link: %w", err)
+		}
+	}
+	return nil
+}
+
 // Hashes returns the supported hash sets.
 func (f *Fs) Hashes() hash.Set {
 	return hash.Set(f.hashType)
@@ -2001,6 +2088,9 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 	if err != nil {
 		return "", err
 	}
+	if unlink {
+		return "", f.unlinkPublic(ctx, info.GetID())
+	}
 	opts := f.newOptsCall(info.GetID(), "POST", "/createLink")
 
 	share := api.CreateShareLinkRequest{
@@ -2008,6 +2098,13 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 		Scope:    f.opt.LinkScope,
 		Password: f.opt.LinkPassword,
 	}
+	lo := fs.GetLinkOptions(ctx)
+	if lo.Permission != "" {
+		share.Type = lo.Permission
+	}
+	if lo.Password != "" {
+		share.Password = lo.Password
+	}
 
 	if expire < fs.DurationOff {
 		expiry := time.Now().Add(time.Duration(expire))
@@ -2173,6 +2270,113 @@ func (o *Object) deleteVersion(ctx context.Context, ID string) error {
 	})
 }
 
+var commandHelp = []fs.CommandHelp{{
+	Name:  "thumbnail",
+	Short: "Show thumbnail and preview links for files.",
+	Long: `This command shows links to the thumbnails and previews OneDrive makes
+for the files passed in, so applications can show them without
+downloading the files.
+
+Usage example:
+
+` + "```console" + `
+rclone backend thumbnail onedrive: path/to/photo.jpg path/to/video.mp4
+rclone backend thumbnail onedrive: path/to/photo.jpg -o size=512
+` + "```" + `
+
+The thumbnail links are the large thumbnail, or one no bigger than
+size pixels in either dimension if the size option is given. Files
+without a thumbnail or preview have empty links.
+
+The links are only valid for a short time.`,
+	Opts: map[string]string{
+		"size": "Largest dimension of the thumbnail in pixels",
+	},
+}}
+
+// thumbnailInfo is returned by the thumbnail backend command
+type thumbnailInfo struct {
+	Remote        string `json:"remote"`
+	ThumbnailLink string `json:"thumbnailLink"`
+	PreviewLink   string `json:"previewLink"`
+}
+
+// thumbnailInfo reads the thumbnail and preview links of the file at
+// remote
+func (f *Fs) thumbnailInfo(ctx context.Context, remote string, size int) (info thumbnailInfo, err error) {
+	info.Remote = remote
+	o, err := f.NewObject(ctx, remote)
+	if err != nil {
+		return info, err
+	}
+	id := o.(*Object).id
+	thumbnailName := "large"
+	if size > 0 {
+		thumbnailName = fmt.Sprintf("c%dx%d", size, size)
+	}
+	var (
+		resp      *http.Response
+		thumbnail api.Thumbnail
+		preview   api.PreviewResponse
+	)
+	opts := f.newOptsCall(id, "GET", "/thumbnails/0/"+thumbnailName)
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &thumbnail)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return info, fmt.Errorf("failed to read thumbnail: %w", err)
+	}
+	info.ThumbnailLink = thumbnail.URL
+	opts = f.newOptsCall(id, "POST", "/preview")
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &preview)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		// Not all drive types support previews
+		fs.Debugf(o, "Failed to read preview link: %v", err)
+	}
+	info.PreviewLink = preview.GetURL
+	return info, nil
+}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out any, err error) {
+	switch name {
+	case "thumbnail":
+		if len(arg) == 0 {
+			return nil, errors.New("need at least 1 argument")
+		}
+		size := 0
+		if sizeString, ok := opt["size"]; ok {
+			size, err = strconv.Atoi(sizeString)
+			if err != nil || size <= 0 {
+				return nil, fmt.Errorf("bad size %q", sizeString)
+			}
+		}
+		infos := make([]thumbnailInfo, 0, len(arg))
+		for _, remote := range arg {
+			info, err := f.thumbnailInfo(ctx, remote, size)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read thumbnail of %q: %w", remote, err)
+			}
+			infos = append(infos, info)
+		}
+		return infos, nil
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
 // ------------------------------------------------------------
 
 // Fs returns the parent Fs
@@ -2714,6 +2918,27 @@ func (o *Object) ID() string {
 	return o.id
 }
 
+// Thumbnail returns the thumbnail OneDrive made for the object scaled
+// to be no bigger than size pixels.
+func (o *Object) Thumbnail(ctx context.Context, size int) (in io.ReadCloser, mimeType string, err error) {
+	if o.id == "" {
+		return nil, "", errors.New("can't read thumbnail - no id")
+	}
+	var resp *http.Response
+	opts := o.fs.newOptsCall(o.id, "GET", fmt.Sprintf("/thumbnails/0/c%dx%d/content", size, size))
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, "", fs.ErrorNotImplemented
+		}
+		return nil, "", fmt.Errorf("failed to read thumbnail: %w", err)
+	}
+	return resp.Body, resp.Header.Get("Content-Type"), nil
+}
+
 /*
  *       URL Build routine area start
  *       1. In this area, region-related URL rewrites are applied. As the API is blackbox,
@@ -3034,26 +3259,29 @@ func withTrailingColon(remotePath string) string {
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs              = (*Fs)(nil)
-	_ fs.Purger          = (*Fs)(nil)
-	_ fs.Copier          = (*Fs)(nil)
-	_ fs.Mover           = (*Fs)(nil)
-	_ fs.DirMover        = (*Fs)(nil)
-	_ fs.DirCacheFlusher = (*Fs)(nil)
-	_ fs.Abouter         = (*Fs)(nil)
-	_ fs.PublicLinker    = (*Fs)(nil)
-	_ fs.CleanUpper      = (*Fs)(nil)
-	_ fs.ListRer         = (*Fs)(nil)
-	_ fs.ListPer         = (*Fs)(nil)
-	_ fs.Shutdowner      = (*Fs)(nil)
-	_ fs.Object          = (*Object)(nil)
-	_ fs.MimeTyper       = &Object{}
-	_ fs.IDer            = &Object{}
-	_ fs.Metadataer      = (*Object)(nil)
-	_ fs.Metadataer      = (*Directory)(nil)
-	_ fs.SetModTimer     = (*Directory)(nil)
-	_ fs.SetMetadataer   = (*Directory)(nil)
-	_ fs.MimeTyper       = &Directory{}
-	_ fs.DirSetModTimer  = (*Fs)(nil)
-	_ fs.MkdirMetadataer = (*Fs)(nil)
+	_ fs.Fs               = (*Fs)(nil)
+	_ fs.Purger           = (*Fs)(nil)
+	_ fs.Copier           = (*Fs)(nil)
+	_ fs.Mover            = (*Fs)(nil)
+	_ fs.DirMover         = (*Fs)(nil)
+	_ fs.DirCacheFlusher  = (*Fs)(nil)
+	_ fs.Abouter          = (*Fs)(nil)
+	_ fs.PublicLinker     = (*Fs)(nil)
+	_ fs.CleanUpper       = (*Fs)(nil)
+	_ fs.ListRer          = (*Fs)(nil)
+	_ fs.ListPer          = (*Fs)(nil)
+	_ fs.Shutdowner       = (*Fs)(nil)
+	_ fs.Commander        = (*Fs)(nil)
+	_ fs.Object           = (*Object)(nil)
+	_ fs.MimeTyper        = &Object{}
+	_ fs.IDer             = &Object{}
+	_ fs.Thumbnailer      = &Object{}
+	_ fs.Metadataer       = (*Object)(nil)
+	_ fs.Metadataer       = (*Directory)(nil)
+	_ fs.SetModTimer      = (*Directory)(nil)
+	_ fs.SetMetadataer    = (*Directory)(nil)
+	_ fs.MimeTyper        = &Directory{}
+	_ fs.DirChangeTokener = &Directory{}
+	_ fs.DirSetModTimer   = (*Fs)(nil)
+	_ fs.MkdirMetadataer  = (*Fs)(nil)
 )
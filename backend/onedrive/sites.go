@@ -0,0 +1,674 @@
+package onedrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/backend/onedrive/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/oauthutil"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+var (
+	errSiteLevel = errors.New("can't create or remove files or directories outside a drive")
+)
+
+// sitesFs shows all the SharePoint sites the credentials can access
+// as directories with the drives of each site inside them.
+//
+// Everything inside a drive is delegated to an Fs for that drive
+// which is made on first use.
+type sitesFs struct {
+	name     string           // name of this remote
+	root     string           // the path we are working on
+	opt      Options          // parsed options
+	m        configmap.Mapper // config for making the drive Fs
+	features *fs.Features     // optional features
+	srv      *rest.Client     // the connection to the Graph API
+	pacer    *fs.Pacer        // pacer for listing sites and drives
+	hashType hash.Type        // type of the hash used by the drives
+
+	mu    sync.Mutex   // protects the below
+	sites []*siteEntry // sites found - nil until listed
+}
+
+// siteEntry is a SharePoint site
+type siteEntry struct {
+	id     string        // site ID
+	name   string        // directory name of the site
+	drives []*driveEntry // drives in this site - nil until listed
+}
+
+// driveEntry is a drive in a SharePoint site
+type driveEntry struct {
+	id        string // drive ID
+	driveType string // drive type
+	name      string // directory name of the drive
+	f         *Fs    // the Fs for this drive - nil until used
+}
+
+// newSitesFs makes an Fs showing all the sites and drives
+func newSitesFs(ctx context.Context, name, root string, m configmap.Mapper, opt *Options) (fs.Fs, error) {
+	_, graphURL := getRegionURL(m)
+	oauthConfig, err := makeOauthConfig(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	oAuthClient, _, err := oauthutil.NewClientWithBaseClient(ctx, name, m, oauthConfig, fshttp.NewClient(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure OneDrive: %w", err)
+	}
+	f := &sitesFs{
+		name:     name,
+		root:     parsePath(root),
+		opt:      *opt,
+		m:        m,
+		srv:      rest.NewClient(oAuthClient).SetRoot(graphURL),
+		pacer:    fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+		hashType: QuickXorHashType,
+	}
+	f.srv.SetErrorHandler(errorHandler)
+	f.features = (&fs.Features{
+		CaseInsensitive:         true,
+		ReadMimeType:            true,
+		CanHaveEmptyDirectories: true,
+		ReadMetadata:            true,
+		WriteMetadata:           true,
+	}).Fill(ctx, f)
+
+	// Set the user defined hash
+	if opt.HashType != "auto" && opt.HashType != "" {
+		err = f.hashType.Set(opt.HashType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check to see if the root points to a file
+	if site, drive, rest := splitSitePath(f.root); site != "" && drive != "" && rest != "" {
+		_, err := f.NewObject(ctx, "")
+		if err == nil {
+			f.root = path.Dir(f.root)
+			return f, fs.ErrorIsFile
+		}
+	}
+	return f, nil
+}
+
+// splitSitePath splits a path into the site, the drive and the path
+// inside the drive
+func splitSitePath(p string) (site, drive, rest string) {
+	parts := strings.SplitN(p, "/", 3)
+	switch len(parts) {
+	case 3:
+		rest = parts[2]
+		fallthrough
+	case 2:
+		drive = parts[1]
+		fallthrough
+	default:
+		site = parts[0]
+	}
+	return site, drive, rest
+}
+
+// uniqueNames returns names with any duplicates told apart by adding
+// the corresponding extra in brackets
+func uniqueNames(names, extras []string) []string {
+	count := make(map[string]int, len(names))
+	for _, name := range names {
+		count[strings.ToLower(name)]++
+	}
+	out := make([]string, len(names))
+	for i, name := range names {
+		if name == "" {
+			name = extras[i]
+		} else if count[strings.ToLower(name)] > 1 {
+			name = fmt.Sprintf("%s (%s)", name, extras[i])
+		}
+		out[i] = name
+	}
+	return out
+}
+
+// lastURLSegment returns the last part of the path of a URL
+func lastURLSegment(u string) string {
+	return path.Base(strings.TrimRight(u, "/"))
+}
+
+// listSites lists the sites, reading them from the server the first
+// time it is called
+func (f *sitesFs) listSites(ctx context.Context) ([]*siteEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.sites != nil {
+		return f.sites, nil
+	}
+	var sites []api.SiteResource
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/sites?search=*",
+	}
+	for {
+		var result api.SiteResponse
+		err := f.pacer.Call(func() (bool, error) {
+			resp, err := f.srv.CallJSON(ctx, &opts, nil, &result)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sites: %w", err)
+		}
+		sites = append(sites, result.Sites...)
+		if result.NextLink == "" {
+			break
+		}
+		opts.Path = ""
+		opts.RootURL = result.NextLink
+	}
+	names := make([]string, len(sites))
+	extras := make([]string, len(sites))
+	for i, site := range sites {
+		names[i] = f.opt.Enc.ToStandardName(site.SiteName)
+		extras[i] = f.opt.Enc.ToStandardName(lastURLSegment(site.SiteURL))
+	}
+	names = uniqueNames(names, extras)
+	f.sites = make([]*siteEntry, len(sites))
+	for i, site := range sites {
+		f.sites[i] = &siteEntry{
+			id:   site.SiteID,
+			name: names[i],
+		}
+	}
+	fs.Debugf(f, "Found %d sites", len(f.sites))
+	return f.sites, nil
+}
+
+// listDrives lists the drives in site, reading them from the server
+// the first time it is called
+func (f *sitesFs) listDrives(ctx context.Context, site *siteEntry) ([]*driveEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if site.drives != nil {
+		return site.drives, nil
+	}
+	var drives []api.DriveResource
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/sites/" + site.id + "/drives",
+	}
+	for {
+		var result api.DrivesResponse
+		err := f.pacer.Call(func() (bool, error) {
+			resp, err := f.srv.CallJSON(ctx, &opts, nil, &result)
+			return shouldRetry(ctx, resp, err)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list drives of site %q: %w", site.name, err)
+		}
+		drives = append(drives, result.Drives...)
+		if result.NextLink == "" {
+			break
+		}
+		opts.Path = ""
+		opts.RootURL = result.NextLink
+	}
+	names := make([]string, len(drives))
+	extras := make([]string, len(drives))
+	for i, drive := range drives {
+		names[i] = f.opt.Enc.ToStandardName(drive.DriveName)
+		extras[i] = drive.DriveID
+	}
+	names = uniqueNames(names, extras)
+	site.drives = make([]*driveEntry, len(drives))
+	for i, drive := range drives {
+		site.drives[i] = &driveEntry{
+			id:        drive.DriveID,
+			driveType: drive.DriveType,
+			name:      names[i],
+		}
+	}
+	return site.drives, nil
+}
+
+// findName returns the index of the entry called name, preferring an
+// exact match to a case insensitive one, or -1 if not found
+func findName(n int, nameOf func(i int) string, name string) int {
+	found := -1
+	for i := range n {
+		if nameOf(i) == name {
+			return i
+		}
+		if found < 0 && strings.EqualFold(nameOf(i), name) {
+			found = i
+		}
+	}
+	return found
+}
+
+// findSite finds the site called name
+func (f *sitesFs) findSite(ctx context.Context, name string) (*siteEntry, error) {
+	sites, err := f.listSites(ctx)
+	if err != nil {
+		return nil, err
+	}
+	i := findName(len(sites), func(i int) string { return sites[i].name }, name)
+	if i < 0 {
+		return nil, fs.ErrorDirNotFound
+	}
+	return sites[i], nil
+}
+
+// findDrive finds the drive called driveName in the site called siteName
+func (f *sitesFs) findDrive(ctx context.Context, siteName, driveName string) (*driveEntry, error) {
+	site, err := f.findSite(ctx, siteName)
+	if err != nil {
+		return nil, err
+	}
+	drives, err := f.listDrives(ctx, site)
+	if err != nil {
+		return nil, err
+	}
+	i := findName(len(drives), func(i int) string { return drives[i].name }, driveName)
+	if i < 0 {
+		return nil, fs.ErrorDirNotFound
+	}
+	return drives[i], nil
+}
+
+// driveFs returns the Fs for drive, making it if necessary
+func (f *sitesFs) driveFs(ctx context.Context, drive *driveEntry) (*Fs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if drive.f != nil {
+		return drive.f, nil
+	}
+	// Use the config of this remote with the drive overridden so
+	// tokens are saved in the same place
+	m := configmap.New().AddGetter(configmap.Simple{
+		"all_sites":  "false",
+		"drive_id":   drive.id,
+		"drive_type": drive.driveType,
+	}, configmap.PriorityNormal).AddGetter(f.m, configmap.PriorityNormal).AddSetter(f.m)
+	newF, err := NewFs(ctx, f.name, "", m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open drive %q: %w", drive.name, err)
+	}
+	drive.f = newF.(*Fs)
+	return drive.f, nil
+}
+
+// resolve finds the drive Fs and the path within it for remote
+//
+// It returns errSiteLevel if remote isn't inside a drive
+func (f *sitesFs) resolve(ctx context.Context, remote string) (driveF *Fs, rest string, err error) {
+	site, drive, rest := splitSitePath(path.Join(f.root, remote))
+	if site == "" || drive == "" {
+		return nil, "", errSiteLevel
+	}
+	d, err := f.findDrive(ctx, site, drive)
+	if err != nil {
+		return nil, "", err
+	}
+	driveF, err = f.driveFs(ctx, d)
+	if err != nil {
+		return nil, "", err
+	}
+	return driveF, rest, nil
+}
+
+// Name of the remote (as passed into NewFs)
+func (f *sitesFs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *sitesFs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *sitesFs) String() string {
+	return fmt.Sprintf("OneDrive sites root '%s'", f.root)
+}
+
+// Features returns the optional features of this Fs
+func (f *sitesFs) Features() *fs.Features {
+	return f.features
+}
+
+// Precision return the precision of this Fs
+func (f *sitesFs) Precision() time.Duration {
+	return time.Second
+}
+
+// Hashes returns the supported hash sets.
+func (f *sitesFs) Hashes() hash.Set {
+	return hash.Set(f.hashType)
+}
+
+// wrapEntries wraps the entries listed from a drive so they appear in
+// dir of this Fs
+func (f *sitesFs) wrapEntries(dir string, entries fs.DirEntries) fs.DirEntries {
+	for i, entry := range entries {
+		remote := path.Join(dir, path.Base(entry.Remote()))
+		switch x := entry.(type) {
+		case fs.Object:
+			entries[i] = f.wrapObject(x, remote)
+		case fs.Directory:
+			entries[i] = fs.NewDirWrapper(remote, x)
+		}
+	}
+	return entries
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *sitesFs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	site, drive, _ := splitSitePath(path.Join(f.root, dir))
+	switch {
+	case site == "":
+		sites, err := f.listSites(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range sites {
+			entries = append(entries, fs.NewDir(path.Join(dir, s.name), time.Time{}).SetID(s.id))
+		}
+		return entries, nil
+	case drive == "":
+		s, err := f.findSite(ctx, site)
+		if err != nil {
+			return nil, err
+		}
+		drives, err := f.listDrives(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range drives {
+			entries = append(entries, fs.NewDir(path.Join(dir, d.name), time.Time{}).SetID(d.id))
+		}
+		return entries, nil
+	}
+	driveF, rest, err := f.resolve(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err = driveF.List(ctx, rest)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapEntries(dir, entries), nil
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+func (f *sitesFs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	driveF, rest, err := f.resolve(ctx, remote)
+	if err == errSiteLevel || err == fs.ErrorDirNotFound || (err == nil && rest == "") {
+		return nil, fs.ErrorObjectNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	o, err := driveF.NewObject(ctx, rest)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o, remote), nil
+}
+
+// Put the object into the container
+//
+// Copy the reader in to the new object which is returned.
+//
+// The new object may have been created if an error is returned
+func (f *sitesFs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	remote := src.Remote()
+	driveF, rest, err := f.resolve(ctx, remote)
+	if err == nil && rest == "" {
+		err = errSiteLevel
+	}
+	if err != nil {
+		return nil, err
+	}
+	o, err := driveF.Put(ctx, in, fs.NewOverrideRemote(src, rest), options...)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o, remote), nil
+}
+
+// Mkdir creates the directory if it doesn't exist
+//
+// Sites and drives can't be created, but it isn't an error to Mkdir
+// one which exists already.
+func (f *sitesFs) Mkdir(ctx context.Context, dir string) error {
+	driveF, rest, err := f.resolve(ctx, dir)
+	if err == errSiteLevel {
+		site, _, _ := splitSitePath(path.Join(f.root, dir))
+		if site == "" {
+			return nil
+		}
+		_, err = f.findSite(ctx, site)
+	}
+	if err == fs.ErrorDirNotFound {
+		return errSiteLevel
+	} else if err != nil || driveF == nil {
+		return err
+	}
+	return driveF.Mkdir(ctx, rest)
+}
+
+// resolveDir finds the drive Fs and the path within it for dir which
+// must be a directory inside a drive
+func (f *sitesFs) resolveDir(ctx context.Context, dir string) (driveF *Fs, rest string, err error) {
+	driveF, rest, err = f.resolve(ctx, dir)
+	if err == nil && rest == "" {
+		err = errSiteLevel
+	}
+	return driveF, rest, err
+}
+
+// Rmdir deletes the directory which must be inside a drive
+//
+// Returns an error if it isn't empty
+func (f *sitesFs) Rmdir(ctx context.Context, dir string) error {
+	driveF, rest, err := f.resolveDir(ctx, dir)
+	if err != nil {
+		return err
+	}
+	return driveF.Rmdir(ctx, rest)
+}
+
+// Purge deletes all the files in the directory which must be inside
+// a drive
+func (f *sitesFs) Purge(ctx context.Context, dir string) error {
+	driveF, rest, err := f.resolveDir(ctx, dir)
+	if err != nil {
+		return err
+	}
+	return driveF.Purge(ctx, rest)
+}
+
+// Copy src to this remote using server-side copy operations.
+//
+// If it isn't possible then return fs.ErrorCantCopy
+func (f *sitesFs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*siteObject)
+	if !ok {
+		fs.Debugf(src, "Can't copy - not same remote type")
+		return nil, fs.ErrorCantCopy
+	}
+	driveF, rest, err := f.resolve(ctx, remote)
+	if err != nil {
+		fs.Debugf(src, "Can't copy: %v", err)
+		return nil, fs.ErrorCantCopy
+	}
+	o, err := driveF.Copy(ctx, srcObj.Object, rest)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o, remote), nil
+}
+
+// Move src to this remote using server-side move operations.
+//
+// If it isn't possible then return fs.ErrorCantMove
+func (f *sitesFs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*siteObject)
+	if !ok {
+		fs.Debugf(src, "Can't move - not same remote type")
+		return nil, fs.ErrorCantMove
+	}
+	driveF, rest, err := f.resolve(ctx, remote)
+	if err != nil {
+		fs.Debugf(src, "Can't move: %v", err)
+		return nil, fs.ErrorCantMove
+	}
+	o, err := driveF.Move(ctx, srcObj.Object, rest)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o, remote), nil
+}
+
+// DirMove moves src, srcRemote to this remote at dstRemote
+// using server-side move operations.
+//
+// Directories can only be moved within a drive.
+//
+// If it isn't possible then return fs.ErrorCantDirMove
+//
+// If destination exists then return fs.ErrorDirExists
+func (f *sitesFs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	srcFs, ok := src.(*sitesFs)
+	if !ok {
+		fs.Debugf(srcFs, "Can't move directory - not same remote type")
+		return fs.ErrorCantDirMove
+	}
+	srcDriveF, srcRest, err := srcFs.resolveDir(ctx, srcRemote)
+	if err != nil {
+		fs.Debugf(srcFs, "Can't move directory: %v", err)
+		return fs.ErrorCantDirMove
+	}
+	dstDriveF, dstRest, err := f.resolveDir(ctx, dstRemote)
+	if err != nil {
+		fs.Debugf(f, "Can't move directory: %v", err)
+		return fs.ErrorCantDirMove
+	}
+	return dstDriveF.DirMove(ctx, srcDriveF, srcRest, dstRest)
+}
+
+// Shutdown the backend, closing any background tasks and any
+// cached connections.
+func (f *sitesFs) Shutdown(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, site := range f.sites {
+		for _, drive := range site.drives {
+			if drive.f != nil {
+				_ = drive.f.Shutdown(ctx)
+			}
+		}
+	}
+	return nil
+}
+
+// siteObject is an Object from a drive shown in the sitesFs
+type siteObject struct {
+	fs.Object
+	f      *sitesFs
+	remote string
+}
+
+// wrapObject wraps o so it appears at remote in f
+func (f *sitesFs) wrapObject(o fs.Object, remote string) *siteObject {
+	return &siteObject{
+		Object: o,
+		f:      f,
+		remote: remote,
+	}
+}
+
+// Fs returns read only access to the Fs that this object is part of
+func (o *siteObject) Fs() fs.Info {
+	return o.f
+}
+
+// Remote returns the remote path
+func (o *siteObject) Remote() string {
+	return o.remote
+}
+
+// String returns a description of the Object
+func (o *siteObject) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// UnWrap returns the Object that this Object is wrapping or nil if it
+// isn't wrapping anything
+func (o *siteObject) UnWrap() fs.Object {
+	return o.Object
+}
+
+// MimeType returns the content type of the Object if
+// known, or "" if not
+func (o *siteObject) MimeType(ctx context.Context) string {
+	if do, ok := o.Object.(fs.MimeTyper); ok {
+		return do.MimeType(ctx)
+	}
+	return ""
+}
+
+// ID returns the ID of the Object if known, or "" if not
+func (o *siteObject) ID() string {
+	if do, ok := o.Object.(fs.IDer); ok {
+		return do.ID()
+	}
+	return ""
+}
+
+// Metadata returns metadata for an object
+//
+// It should return nil if there is no Metadata
+func (o *siteObject) Metadata(ctx context.Context) (fs.Metadata, error) {
+	if do, ok := o.Object.(fs.Metadataer); ok {
+		return do.Metadata(ctx)
+	}
+	return nil, nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs              = (*sitesFs)(nil)
+	_ fs.Purger          = (*sitesFs)(nil)
+	_ fs.Copier          = (*sitesFs)(nil)
+	_ fs.Mover           = (*sitesFs)(nil)
+	_ fs.DirMover        = (*sitesFs)(nil)
+	_ fs.Shutdowner      = (*sitesFs)(nil)
+	_ fs.Object          = (*siteObject)(nil)
+	_ fs.MimeTyper       = (*siteObject)(nil)
+	_ fs.IDer            = (*siteObject)(nil)
+	_ fs.Metadataer      = (*siteObject)(nil)
+	_ fs.ObjectUnWrapper = (*siteObject)(nil)
+)
@@ -0,0 +1,46 @@
+package onedrive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSitePath(t *testing.T) {
+	for _, test := range []struct {
+		in                  string
+		site, drive, remote string
+	}{
+		{"", "", "", ""},
+		{"Site", "Site", "", ""},
+		{"Site/Documents", "Site", "Documents", ""},
+		{"Site/Documents/dir", "Site", "Documents", "dir"},
+		{"Site/Documents/dir/file.txt", "Site", "Documents", "dir/file.txt"},
+	} {
+		site, drive, remote := splitSitePath(test.in)
+		assert.Equal(t, test.site, site, test.in)
+		assert.Equal(t, test.drive, drive, test.in)
+		assert.Equal(t, test.remote, remote, test.in)
+	}
+}
+
+func TestUniqueNames(t *testing.T) {
+	assert.Equal(t,
+		[]string{"Marketing (marketing)", "Sales", "marketing (marketing2)", "hr"},
+		uniqueNames(
+			[]string{"Marketing", "Sales", "marketing", ""},
+			[]string{"marketing", "sales", "marketing2", "hr"},
+		),
+	)
+	assert.Equal(t, "site", lastURLSegment("https://contoso.sharepoint.com/sites/site/"))
+	assert.Equal(t, "contoso.sharepoint.com", lastURLSegment("https://contoso.sharepoint.com"))
+}
+
+func TestFindName(t *testing.T) {
+	names := []string{"documents", "Documents", "Other"}
+	nameOf := func(i int) string { return names[i] }
+	assert.Equal(t, 1, findName(len(names), nameOf, "Documents"))
+	assert.Equal(t, 0, findName(len(names), nameOf, "DOCUMENTS"))
+	assert.Equal(t, 2, findName(len(names), nameOf, "other"))
+	assert.Equal(t, -1, findName(len(names), nameOf, "missing"))
+}
@@ -0,0 +1,217 @@
+package pack
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+const (
+	// IndexName is the name of the index object in a packed directory
+	IndexName = ".rclone-pack.json"
+	// PackPrefix is the prefix of the names of the pack objects
+	PackPrefix = ".rclone-pack-"
+	// PackSuffix is the suffix of the names of the pack objects
+	PackSuffix = ".tar"
+	// indexVersion is the version of the index format written
+	indexVersion = 1
+)
+
+// IsPackFile returns true if the leaf name is an index or a pack
+// object which should be hidden from listings.
+func IsPackFile(leaf string) bool {
+	return leaf == IndexName || (strings.HasPrefix(leaf, PackPrefix) && strings.HasSuffix(leaf, PackSuffix))
+}
+
+// IndexEntry describes one file stored in a pack
+type IndexEntry struct {
+	Path    string    `json:"path"`          // path relative to the packed directory
+	Pack    string    `json:"pack"`          // leaf name of the pack it is in
+	Offset  int64     `json:"offset"`        // offset of the data in the pack
+	Size    int64     `json:"size"`          // size of the data
+	ModTime time.Time `json:"modtime"`       // modification time of the file
+	MD5     string    `json:"md5,omitempty"` // MD5 of the data
+}
+
+// Index is the index of the files packed into a directory
+type Index struct {
+	Version int           `json:"version"`
+	Files   []*IndexEntry `json:"files"`
+
+	files map[string]*IndexEntry     // files by path
+	dirs  map[string]map[string]bool // directory => leaf => isDir
+}
+
+// parseIndex parses the JSON index and builds the lookup tables
+func parseIndex(data []byte) (*Index, error) {
+	idx := new(Index)
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("pack: failed to parse index: %w", err)
+	}
+	if idx.Version > indexVersion {
+		return nil, fmt.Errorf("pack: unsupported index version %d", idx.Version)
+	}
+	idx.files = make(map[string]*IndexEntry, len(idx.Files))
+	idx.dirs = make(map[string]map[string]bool)
+	add := func(dir, leaf string, isDir bool) bool {
+		children := idx.dirs[dir]
+		if children == nil {
+			children = make(map[string]bool)
+			idx.dirs[dir] = children
+		}
+		_, found := children[leaf]
+		children[leaf] = isDir
+		return found
+	}
+	for _, entry := range idx.Files {
+		idx.files[entry.Path] = entry
+		dir, leaf := splitPath(entry.Path)
+		add(dir, leaf, false)
+		// Add the parent directories until one is already known
+		for dir != "" {
+			parent, leaf := splitPath(dir)
+			if add(parent, leaf, true) {
+				break
+			}
+			dir = parent
+		}
+	}
+	return idx, nil
+}
+
+// splitPath splits remote into its directory, "" for the root, and leaf
+func splitPath(remote string) (dir, leaf string) {
+	dir, leaf = path.Split(remote)
+	return strings.TrimSuffix(dir, "/"), leaf
+}
+
+// packWriter writes a tar file, keeping track of the offset
+type packWriter struct {
+	w      io.Writer
+	offset int64
+}
+
+// Write implements io.Writer
+func (pw *packWriter) Write(p []byte) (n int, err error) {
+	n, err = pw.w.Write(p)
+	pw.offset += int64(n)
+	return n, err
+}
+
+// writePack writes the objects passed in to a single tar file called
+// packRemote on f returning the index entries for them.
+func writePack(ctx context.Context, f fs.Fs, dir, packRemote string, objs []fs.Object) (entries []*IndexEntry, err error) {
+	pr, pipeWriter := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw := &packWriter{w: pipeWriter}
+		tw := tar.NewWriter(pw)
+		var err error
+		for _, o := range objs {
+			var entry *IndexEntry
+			entry, err = writePackEntry(ctx, tw, pw, dir, o)
+			if err != nil {
+				break
+			}
+			entry.Pack = path.Base(packRemote)
+			entries = append(entries, entry)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		_ = pipeWriter.CloseWithError(err)
+	}()
+	_, err = operations.Rcat(ctx, f, packRemote, pr, time.Now(), nil)
+	_ = pr.CloseWithError(err)
+	<-done
+	if err != nil {
+		return nil, fmt.Errorf("pack: failed to write %q: %w", packRemote, err)
+	}
+	return entries, nil
+}
+
+// writePackEntry writes a single object to the tar file
+func writePackEntry(ctx context.Context, tw *tar.Writer, pw *packWriter, dir string, o fs.Object) (entry *IndexEntry, err error) {
+	entry = &IndexEntry{
+		Path:    strings.TrimPrefix(o.Remote(), dir+"/"),
+		Size:    o.Size(),
+		ModTime: o.ModTime(ctx),
+	}
+	if dir == "" {
+		entry.Path = o.Remote()
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     entry.Path,
+		Size:     entry.Size,
+		Mode:     0644,
+		ModTime:  entry.ModTime,
+		Format:   tar.FormatPAX,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The data starts straight after the header
+	entry.Offset = pw.offset
+	in, err := operations.Open(ctx, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %v: %w", o, err)
+	}
+	defer fs.CheckClose(in, &err)
+	md5sum := md5.New()
+	n, err := io.Copy(io.MultiWriter(tw, md5sum), in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v: %w", o, err)
+	}
+	if n != entry.Size {
+		return nil, fmt.Errorf("%v changed size while packing: expecting %d got %d", o, entry.Size, n)
+	}
+	entry.MD5 = hex.EncodeToString(md5sum.Sum(nil))
+	return entry, nil
+}
+
+// WriteDir packs the objects passed in, which must all be in dir or
+// its subdirectories, into pack objects in dir on f and writes the
+// index for them.
+//
+// A new pack is started when a pack reaches maxPackSize bytes. Any
+// existing index in dir is replaced.
+func WriteDir(ctx context.Context, f fs.Fs, dir string, objs []fs.Object, maxPackSize int64) error {
+	idx := Index{Version: indexVersion, Files: []*IndexEntry{}}
+	for n := 0; len(objs) > 0; n++ {
+		var size int64
+		i := 0
+		for ; i < len(objs) && (i == 0 || size+objs[i].Size() <= maxPackSize); i++ {
+			size += objs[i].Size()
+		}
+		packRemote := path.Join(dir, fmt.Sprintf("%s%04d%s", PackPrefix, n, PackSuffix))
+		entries, err := writePack(ctx, f, dir, packRemote, objs[:i])
+		if err != nil {
+			return err
+		}
+		idx.Files = append(idx.Files, entries...)
+		objs = objs[i:]
+	}
+	data, err := json.MarshalIndent(&idx, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = operations.Rcat(ctx, f, path.Join(dir, IndexName), io.NopCloser(bytes.NewReader(data)), time.Now(), nil)
+	if err != nil {
+		return fmt.Errorf("pack: failed to write index: %w", err)
+	}
+	fs.Infof(f, "Packed %d files into %q", len(idx.Files), dir)
+	return nil
+}
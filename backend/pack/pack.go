@@ -0,0 +1,594 @@
+// Package pack implements a backend which reads small files packed
+// into container objects by "rclone archive pack" as if they were
+// ordinary files.
+package pack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// Register with Fs
+func init() {
+	fsi := &fs.RegInfo{
+		Name:        "pack",
+		Description: "Read small files packed into containers",
+		NewFs:       NewFs,
+		MetadataInfo: &fs.MetadataInfo{
+			Help: `Any metadata supported by the underlying remote is read and written for unpacked files.`,
+		},
+		Options: []fs.Option{{
+			Name: "remote",
+			Help: `Remote containing the packed files.
+
+Normally should contain a ':' and a path, e.g. "myremote:path/to/dir",
+"myremote:bucket" or "myremote:".
+
+If this is left empty, then the pack backend will use the root as
+the remote.
+
+This means that you can use :pack:remote:path and it will be
+equivalent to setting remote="remote:path".`,
+		}, {
+			Name:     "index_cache_time",
+			Help:     "How long to cache the pack indexes for.",
+			Default:  fs.Duration(time.Minute),
+			Advanced: true,
+		}},
+	}
+	fs.Register(fsi)
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Remote         string      `config:"remote"`
+	IndexCacheTime fs.Duration `config:"index_cache_time"`
+}
+
+// cachedIndex is an index read from the remote, or nil if there
+// wasn't one
+type cachedIndex struct {
+	idx     *Index
+	expires time.Time
+}
+
+// Fs represents a remote with packed files
+type Fs struct {
+	name     string       // name of this remote
+	root     string       // the path we are working on
+	opt      Options      // options for this Fs
+	features *fs.Features // optional features
+	f        fs.Fs        // remote we are wrapping
+	wrapper  fs.Fs        // fs that wraps us
+
+	mu      sync.Mutex              // protects the below
+	indexes map[string]*cachedIndex // indexes by directory
+}
+
+// NewFs constructs an Fs from the path.
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	remote := opt.Remote
+	if remote == "" {
+		remote = root
+		root = ""
+	}
+	if strings.HasPrefix(remote, name+":") {
+		return nil, errors.New("can't point pack remote at itself - check the value of the remote setting")
+	}
+	wrappedFs, err := cache.Get(ctx, fspath.JoinRootPath(remote, root))
+	if err != fs.ErrorIsFile && err != nil {
+		return nil, fmt.Errorf("failed to make remote %q to wrap: %w", remote, err)
+	}
+	f := &Fs{
+		name:    name,
+		root:    root,
+		opt:     *opt,
+		f:       wrappedFs,
+		indexes: make(map[string]*cachedIndex),
+	}
+	cache.PinUntilFinalized(f.f, f)
+	f.features = (&fs.Features{
+		CaseInsensitive:         true,
+		DuplicateFiles:          false,
+		ReadMimeType:            true,
+		WriteMimeType:           true,
+		CanHaveEmptyDirectories: true,
+		BucketBased:             true,
+		SetTier:                 true,
+		GetTier:                 true,
+		ReadMetadata:            true,
+		WriteMetadata:           true,
+		UserMetadata:            true,
+		PartialUploads:          true,
+	}).Fill(ctx, f).Mask(ctx, wrappedFs).WrapsFs(f, wrappedFs)
+	if err == fs.ErrorIsFile {
+		f.root = path.Dir(f.root)
+		if f.root == "." || f.root == "/" {
+			f.root = ""
+		}
+	}
+	return f, err
+}
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	return fmt.Sprintf("pack root '%s'", f.root)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// Precision of the ModTimes in this Fs
+func (f *Fs) Precision() time.Duration {
+	return f.f.Precision()
+}
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set {
+	return f.f.Hashes()
+}
+
+// getIndex returns the index in dir or nil if there isn't one
+func (f *Fs) getIndex(ctx context.Context, dir string) (*Index, error) {
+	f.mu.Lock()
+	cached := f.indexes[dir]
+	f.mu.Unlock()
+	if cached != nil && time.Now().Before(cached.expires) {
+		return cached.idx, nil
+	}
+	idx, err := f.readIndex(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.indexes[dir] = &cachedIndex{
+		idx:     idx,
+		expires: time.Now().Add(time.Duration(f.opt.IndexCacheTime)),
+	}
+	f.mu.Unlock()
+	return idx, nil
+}
+
+// readIndex reads the index in dir or returns nil if there isn't one
+func (f *Fs) readIndex(ctx context.Context, dir string) (idx *Index, err error) {
+	o, err := f.f.NewObject(ctx, path.Join(dir, IndexName))
+	if errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorDirNotFound) || errors.Is(err, fs.ErrorIsDir) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	in, err := o.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.CheckClose(in, &err)
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(in); err != nil {
+		return nil, err
+	}
+	idx, err = parseIndex(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", o, err)
+	}
+	fs.Debugf(o, "Read index of %d packed files", len(idx.Files))
+	return idx, nil
+}
+
+// packedDir is a directory containing an index
+type packedDir struct {
+	dir string
+	idx *Index
+}
+
+// indexesFor returns the indexes which may contain entries in dir,
+// which are those in dir and its parents.
+func (f *Fs) indexesFor(ctx context.Context, dir string) (packedDirs []packedDir, err error) {
+	parent := ""
+	for {
+		idx, err := f.getIndex(ctx, parent)
+		if err != nil {
+			return nil, err
+		}
+		if idx != nil {
+			packedDirs = append(packedDirs, packedDir{dir: parent, idx: idx})
+		}
+		if parent == dir {
+			break
+		}
+		rest := strings.TrimPrefix(dir, parent)
+		rest = strings.TrimPrefix(rest, "/")
+		next, _, _ := strings.Cut(rest, "/")
+		parent = path.Join(parent, next)
+	}
+	return packedDirs, nil
+}
+
+// relative returns remote relative to dir
+func relative(dir, remote string) string {
+	if dir == "" {
+		return remote
+	}
+	if remote == dir {
+		return ""
+	}
+	return strings.TrimPrefix(remote, dir+"/")
+}
+
+// List the objects and directories in dir into entries. The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	entries, listErr := f.f.List(ctx, dir)
+	if listErr != nil && !errors.Is(listErr, fs.ErrorDirNotFound) {
+		return nil, listErr
+	}
+	packedDirs, err := f.indexesFor(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	if listErr != nil && len(packedDirs) == 0 {
+		return nil, listErr
+	}
+
+	// Hide the pack files and note what is there already
+	existing := make(map[string]struct{}, len(entries))
+	newEntries := entries[:0]
+	for _, entry := range entries {
+		if _, isObject := entry.(fs.Object); isObject && IsPackFile(path.Base(entry.Remote())) {
+			continue
+		}
+		existing[entry.Remote()] = struct{}{}
+		newEntries = append(newEntries, entry)
+	}
+	entries = newEntries
+
+	// Add the packed files and directories
+	found := listErr == nil
+	for _, pd := range packedDirs {
+		children, ok := pd.idx.dirs[relative(pd.dir, dir)]
+		if !ok {
+			continue
+		}
+		found = true
+		for leaf, isDir := range children {
+			remote := path.Join(dir, leaf)
+			if _, ok := existing[remote]; ok {
+				continue
+			}
+			existing[remote] = struct{}{}
+			if isDir {
+				entries = append(entries, fs.NewDir(remote, time.Time{}))
+			} else {
+				entries = append(entries, f.newObject(pd, remote))
+			}
+		}
+	}
+	if !found {
+		return nil, fs.ErrorDirNotFound
+	}
+	return entries, nil
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error fs.ErrorObjectNotFound.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	if IsPackFile(path.Base(remote)) {
+		return nil, fs.ErrorObjectNotFound
+	}
+	o, err := f.f.NewObject(ctx, remote)
+	if err == nil || !(errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorDirNotFound)) {
+		return o, err
+	}
+	dir, _ := splitPath(remote)
+	packedDirs, indexErr := f.indexesFor(ctx, dir)
+	if indexErr != nil {
+		return nil, indexErr
+	}
+	for i := len(packedDirs) - 1; i >= 0; i-- {
+		pd := packedDirs[i]
+		if _, ok := pd.idx.files[relative(pd.dir, remote)]; ok {
+			return f.newObject(pd, remote), nil
+		}
+	}
+	return nil, fs.ErrorObjectNotFound
+}
+
+// Put in to the remote path with the modTime given of the given size
+//
+// Files are always written unpacked and will be used in preference
+// to a packed file with the same name.
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return f.f.Put(ctx, in, src, options...)
+}
+
+// PutStream uploads to the remote path with the modTime given of indeterminate size
+func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	do := f.f.Features().PutStream
+	if do == nil {
+		return nil, errors.New("can't PutStream")
+	}
+	return do(ctx, in, src, options...)
+}
+
+// PutUnchecked in to the remote path with the modTime given of the given size
+//
+// May create the object even if it returns an error - if so
+// will return the object and the error, otherwise will return
+// nil and the error
+//
+// May create duplicates or return errors if src already
+// exists.
+func (f *Fs) PutUnchecked(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	do := f.f.Features().PutUnchecked
+	if do == nil {
+		return nil, errors.New("can't PutUnchecked")
+	}
+	return do(ctx, in, src, options...)
+}
+
+// Mkdir makes the directory
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	return f.f.Mkdir(ctx, dir)
+}
+
+// Rmdir removes the directory
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	return f.f.Rmdir(ctx, dir)
+}
+
+// Purge all files in the directory
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	do := f.f.Features().Purge
+	if do == nil {
+		return fs.ErrorCantPurge
+	}
+	f.DirCacheFlush()
+	return do(ctx, dir)
+}
+
+// Copy src to this remote using server-side copy operations.
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	do := f.f.Features().Copy
+	if do == nil {
+		return nil, fs.ErrorCantCopy
+	}
+	if _, isPacked := src.(*Object); isPacked {
+		return nil, fs.ErrorCantCopy
+	}
+	return do(ctx, src, remote)
+}
+
+// Move src to this remote using server-side move operations.
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	do := f.f.Features().Move
+	if do == nil {
+		return nil, fs.ErrorCantMove
+	}
+	if _, isPacked := src.(*Object); isPacked {
+		return nil, fs.ErrorCantMove
+	}
+	return do(ctx, src, remote)
+}
+
+// DirMove moves src, srcRemote to this remote at dstRemote
+// using server-side move operations.
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	do := f.f.Features().DirMove
+	if do == nil {
+		return fs.ErrorCantDirMove
+	}
+	srcFs, ok := src.(*Fs)
+	if !ok {
+		fs.Debugf(srcFs, "Can't move directory - not same remote type")
+		return fs.ErrorCantDirMove
+	}
+	f.DirCacheFlush()
+	return do(ctx, srcFs.f, srcRemote, dstRemote)
+}
+
+// DirCacheFlush resets the directory cache and the cached indexes
+func (f *Fs) DirCacheFlush() {
+	f.mu.Lock()
+	f.indexes = make(map[string]*cachedIndex)
+	f.mu.Unlock()
+	if do := f.f.Features().DirCacheFlush; do != nil {
+		do()
+	}
+}
+
+// About gets quota information from the Fs
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	do := f.f.Features().About
+	if do == nil {
+		return nil, errors.New("not supported by underlying remote")
+	}
+	return do(ctx)
+}
+
+// Shutdown the backend, closing any background tasks and any
+// cached connections.
+func (f *Fs) Shutdown(ctx context.Context) error {
+	if do := f.f.Features().Shutdown; do != nil {
+		return do(ctx)
+	}
+	return nil
+}
+
+// UnWrap returns the Fs that this Fs is wrapping
+func (f *Fs) UnWrap() fs.Fs {
+	return f.f
+}
+
+// WrapFs returns the Fs that is wrapping this Fs
+func (f *Fs) WrapFs() fs.Fs {
+	return f.wrapper
+}
+
+// SetWrapper sets the Fs that is wrapping this Fs
+func (f *Fs) SetWrapper(wrapper fs.Fs) {
+	f.wrapper = wrapper
+}
+
+// Object is a file stored in a pack
+type Object struct {
+	f      *Fs
+	remote string      // path of the file
+	pack   string      // path of the pack in the wrapped remote
+	entry  *IndexEntry // index entry for the file
+}
+
+// newObject makes an Object for the packed file at remote
+func (f *Fs) newObject(pd packedDir, remote string) *Object {
+	entry := pd.idx.files[relative(pd.dir, remote)]
+	return &Object{
+		f:      f,
+		remote: remote,
+		pack:   path.Join(pd.dir, entry.Pack),
+		entry:  entry,
+	}
+}
+
+// Fs returns read only access to the Fs that this object is part of
+func (o *Object) Fs() fs.Info {
+	return o.f
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// Hash returns the MD5 of the packed file if requested and known
+func (o *Object) Hash(ctx context.Context, ht hash.Type) (string, error) {
+	if ht == hash.MD5 {
+		return o.entry.MD5, nil
+	}
+	if !o.f.Hashes().Contains(ht) {
+		return "", hash.ErrUnsupported
+	}
+	return "", nil
+}
+
+// Size returns the size of the file
+func (o *Object) Size() int64 {
+	return o.entry.Size
+}
+
+// ModTime returns the modification time of the file
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	return o.entry.ModTime
+}
+
+// Storable returns whether this object is storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// errPacked is returned when trying to change a packed file
+var errPacked = errors.New("can't modify a packed file")
+
+// SetModTime sets the modification time of the file
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	return fs.ErrorCantSetModTime
+}
+
+// Update the file - this isn't possible for packed files
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	return errPacked
+}
+
+// Remove the file - this isn't possible for packed files
+func (o *Object) Remove(ctx context.Context) error {
+	return errPacked
+}
+
+// Open the packed file by reading its range of the pack
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	var offset, limit int64 = 0, -1
+	for _, option := range options {
+		switch x := option.(type) {
+		case *fs.SeekOption:
+			offset = x.Offset
+		case *fs.RangeOption:
+			offset, limit = x.Decode(o.entry.Size)
+		default:
+			if option.Mandatory() {
+				fs.Logf(o, "Unsupported mandatory option: %v", option)
+			}
+		}
+	}
+	if limit < 0 || offset+limit > o.entry.Size {
+		limit = o.entry.Size - offset
+	}
+	if limit <= 0 {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	pack, err := o.f.f.NewObject(ctx, o.pack)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pack for %v: %w", o, err)
+	}
+	start := o.entry.Offset + offset
+	return pack.Open(ctx, &fs.RangeOption{Start: start, End: start + limit - 1})
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs              = (*Fs)(nil)
+	_ fs.Purger          = (*Fs)(nil)
+	_ fs.PutStreamer     = (*Fs)(nil)
+	_ fs.PutUncheckeder  = (*Fs)(nil)
+	_ fs.Copier          = (*Fs)(nil)
+	_ fs.Mover           = (*Fs)(nil)
+	_ fs.DirMover        = (*Fs)(nil)
+	_ fs.DirCacheFlusher = (*Fs)(nil)
+	_ fs.Abouter         = (*Fs)(nil)
+	_ fs.Shutdowner      = (*Fs)(nil)
+	_ fs.UnWrapper       = (*Fs)(nil)
+	_ fs.Wrapper         = (*Fs)(nil)
+	_ fs.Object          = (*Object)(nil)
+)
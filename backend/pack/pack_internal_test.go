@@ -0,0 +1,112 @@
+package pack
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPackFile(t *testing.T) {
+	assert.True(t, IsPackFile(IndexName))
+	assert.True(t, IsPackFile(".rclone-pack-0000.tar"))
+	assert.False(t, IsPackFile("file.tar"))
+	assert.False(t, IsPackFile(".rclone-pack-0000.zip"))
+}
+
+func TestWriteDirAndRead(t *testing.T) {
+	ctx := context.Background()
+	fstest.Initialise()
+	modTime := fstest.Time("2001-02-03T04:05:06.499999999Z")
+
+	src, err := fs.NewFs(ctx, ":memory:pack-src")
+	require.NoError(t, err)
+	dst, err := fs.NewFs(ctx, ":memory:pack-dst")
+	require.NoError(t, err)
+
+	contents := map[string]string{
+		"dir/a.txt":     "hello",
+		"dir/b.txt":     "world!",
+		"dir/sub/c.txt": "potato potato potato",
+		"dir/empty.txt": "",
+	}
+	var objs []fs.Object
+	for _, remote := range []string{"dir/a.txt", "dir/b.txt", "dir/sub/c.txt", "dir/empty.txt"} {
+		o, err := operations.Rcat(ctx, src, remote, io.NopCloser(strings.NewReader(contents[remote])), modTime, nil)
+		require.NoError(t, err)
+		objs = append(objs, o)
+	}
+
+	// Small max pack size so we get more than one pack
+	require.NoError(t, WriteDir(ctx, dst, "dir", objs, 8))
+
+	// An unpacked file alongside the packed ones
+	_, err = operations.Rcat(ctx, dst, "dir/d.txt", io.NopCloser(strings.NewReader("unpacked")), modTime, nil)
+	require.NoError(t, err)
+
+	f, err := NewFs(ctx, "TestPack", "", configmap.Simple{"remote": ":memory:pack-dst"})
+	require.NoError(t, err)
+
+	entries, err := f.List(ctx, "dir")
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	assert.ElementsMatch(t, []string{"dir/a.txt", "dir/b.txt", "dir/d.txt", "dir/empty.txt", "dir/sub"}, names)
+
+	entries, err = f.List(ctx, "dir/sub")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "dir/sub/c.txt", entries[0].Remote())
+
+	for remote, want := range contents {
+		o, err := f.NewObject(ctx, remote)
+		require.NoError(t, err, remote)
+		assert.Equal(t, int64(len(want)), o.Size())
+		assert.True(t, o.ModTime(ctx).Equal(modTime))
+		md5sum, err := o.Hash(ctx, hash.MD5)
+		require.NoError(t, err)
+		wantMD5, err := hash.StreamTypes(strings.NewReader(want), hash.NewHashSet(hash.MD5))
+		require.NoError(t, err)
+		assert.Equal(t, wantMD5[hash.MD5], md5sum)
+		assert.Equal(t, want, readObject(ctx, t, o))
+	}
+
+	// Ranges are translated into the pack
+	o, err := f.NewObject(ctx, "dir/sub/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "tato", readObject(ctx, t, o, &fs.RangeOption{Start: 2, End: 5}))
+	assert.Equal(t, "potato", readObject(ctx, t, o, &fs.SeekOption{Offset: 14}))
+	assert.Error(t, o.Remove(ctx))
+
+	// The pack files are hidden
+	_, err = f.NewObject(ctx, "dir/"+IndexName)
+	assert.ErrorIs(t, err, fs.ErrorObjectNotFound)
+	_, err = f.NewObject(ctx, "dir/missing.txt")
+	assert.ErrorIs(t, err, fs.ErrorObjectNotFound)
+
+	// Unpacked files are returned as is
+	o, err = f.NewObject(ctx, "dir/d.txt")
+	require.NoError(t, err)
+	_, isPacked := o.(*Object)
+	assert.False(t, isPacked)
+}
+
+func readObject(ctx context.Context, t *testing.T, o fs.Object, options ...fs.OpenOption) string {
+	in, err := o.Open(ctx, options...)
+	require.NoError(t, err)
+	data, err := io.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	return string(data)
+}
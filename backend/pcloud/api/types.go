@@ -148,6 +148,19 @@ type UploadFileResponse struct {
 	Fileids   []int64  `json:"fileids"`
 }
 
+// UploadCreateResponse is the response from /upload_create
+type UploadCreateResponse struct {
+	Error
+	UploadID int64 `json:"uploadid"`
+}
+
+// UploadInfoResponse is the response from /upload_info
+type UploadInfoResponse struct {
+	Error
+	Hashes
+	Size int64 `json:"size"`
+}
+
 // GetFileLinkResult is returned from /getfilelink
 type GetFileLinkResult struct {
 	Error
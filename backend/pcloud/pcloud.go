@@ -43,6 +43,8 @@ const (
 	maxSleep                    = 2 * time.Second
 	decayConstant               = 2 // bigger for slower decay, exponential
 	defaultHostname             = "api.pcloud.com"
+	defaultUploadCutoff         = 50 * fs.Mebi
+	defaultChunkSize            = 10 * fs.Mebi
 )
 
 // Globals
@@ -145,6 +147,25 @@ we have to rely on user password authentication for it.`,
 			Help:       "Your pcloud password.",
 			IsPassword: true,
 			Advanced:   true,
+		}, {
+			Name: "upload_cutoff",
+			Help: `Cutoff for switching to resumable uploads.
+
+Files bigger than this are uploaded in chunks of chunk_size with an
+upload session. The progress is recorded in the cache directory so if
+the upload fails it carries on from the last chunk written the next
+time the same file is uploaded to the same place, rather than
+starting again from the beginning.`,
+			Default:  defaultUploadCutoff,
+			Advanced: true,
+		}, {
+			Name: "chunk_size",
+			Help: `Chunk size to use for resumable uploads.
+
+This is the amount of data which needs to be uploaded again if a
+resumable upload is interrupted. Each chunk is buffered in memory.`,
+			Default:  defaultChunkSize,
+			Advanced: true,
 		},
 		}...),
 	})
@@ -157,6 +178,8 @@ type Options struct {
 	Hostname     string               `config:"hostname"`
 	Username     string               `config:"username"`
 	Password     string               `config:"password"`
+	UploadCutoff fs.SizeSuffix        `config:"upload_cutoff"`
+	ChunkSize    fs.SizeSuffix        `config:"chunk_size"`
 }
 
 // Fs represents a remote pcloud
@@ -171,6 +194,7 @@ type Fs struct {
 	dirCache     *dircache.DirCache     // Map of directory path to directory id
 	pacer        *fs.Pacer              // pacer for API calls
 	tokenRenewer *oauthutil.Renew       // renew the token on expiry
+	resume       *resumeDB              // progress of resumable uploads
 }
 
 // Object describes a pcloud object
@@ -306,6 +330,9 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, err
 	}
+	if opt.ChunkSize <= 0 {
+		return nil, fmt.Errorf("chunk_size must be greater than 0: %v", opt.ChunkSize)
+	}
 	root = parsePath(root)
 	oAuthClient, ts, err := oauthutil.NewClient(ctx, name, m, oauthConfig)
 	if err != nil {
@@ -315,12 +342,13 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 
 	canCleanup := opt.Username != "" && opt.Password != ""
 	f := &Fs{
-		name:  name,
-		root:  root,
-		opt:   *opt,
-		ts:    ts,
-		srv:   rest.NewClient(oAuthClient).SetRoot("https://" + opt.Hostname),
-		pacer: fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+		name:   name,
+		root:   root,
+		opt:    *opt,
+		ts:     ts,
+		srv:    rest.NewClient(oAuthClient).SetRoot("https://" + opt.Hostname),
+		pacer:  fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))),
+		resume: &resumeDB{},
 	}
 	if canCleanup {
 		f.cleanupSrv = rest.NewClient(fshttp.NewClient(ctx)).SetRoot("https://" + opt.Hostname)
@@ -1030,6 +1058,7 @@ func (f *Fs) About(ctx context.Context) (usage *fs.Usage, err error) {
 // Shutdown shutdown the fs
 func (f *Fs) Shutdown(ctx context.Context) error {
 	f.tokenRenewer.Shutdown()
+	f.stopResume()
 	return nil
 }
 
@@ -1292,6 +1321,10 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		return err
 	}
 
+	if size > int64(o.fs.opt.UploadCutoff) {
+		return o.uploadResumable(ctx, in, src, leaf, directoryID)
+	}
+
 	// Experiments with pcloud indicate that it doesn't like any
 	// form of request which doesn't have a Content-Length.
 	// According to the docs if you close the connection at the
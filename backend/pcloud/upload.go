@@ -0,0 +1,310 @@
+// Resumable uploads using the pcloud upload API
+
+package pcloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/rclone/rclone/backend/pcloud/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/kv"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// resumeFacility is the name of the database recording the progress
+// of resumable uploads
+const resumeFacility = "pcloud-uploads"
+
+// resumeState is the progress of a resumable upload which is stored
+// in the database
+type resumeState struct {
+	UploadID int64 `json:"uploadid"`
+	Offset   int64 `json:"offset"`
+}
+
+// resumeDB is the database of resumable upload progress which is
+// started on first use
+type resumeDB struct {
+	mu sync.Mutex
+	db *kv.DB
+}
+
+// getResumeDB returns the database for resumable uploads starting it
+// if necessary, or nil if it can't be used.
+func (f *Fs) getResumeDB(ctx context.Context) *kv.DB {
+	f.resume.mu.Lock()
+	defer f.resume.mu.Unlock()
+	if f.resume.db != nil {
+		return f.resume.db
+	}
+	if !kv.Supported() {
+		return nil
+	}
+	db, err := kv.Start(ctx, resumeFacility, f)
+	if err != nil {
+		fs.Errorf(f, "Failed to open resumable upload database - uploads won't be resumed: %v", err)
+		return nil
+	}
+	f.resume.db = db
+	return db
+}
+
+// stopResume stops the resumable upload database if it was started
+func (f *Fs) stopResume() {
+	f.resume.mu.Lock()
+	defer f.resume.mu.Unlock()
+	if f.resume.db == nil {
+		return
+	}
+	if err := f.resume.db.Stop(false); err != nil {
+		fs.Errorf(f, "Failed to close resumable upload database: %v", err)
+	}
+	f.resume.db = nil
+}
+
+// resumeKey makes the key the progress of uploading src to remote is
+// stored under.
+//
+// This includes the fingerprint of the source so the upload is only
+// resumed if the source hasn't changed.
+func (f *Fs) resumeKey(ctx context.Context, remote string, src fs.ObjectInfo) string {
+	return fs.ConfigString(f) + "\x00" + remote + "\x00" + fs.Fingerprint(ctx, src, true)
+}
+
+// getResumeState reads the progress for key returning nil if there
+// isn't any
+func (f *Fs) getResumeState(db *kv.DB, key string) *resumeState {
+	if db == nil {
+		return nil
+	}
+	op := &resumeGet{key: key}
+	err := db.Do(false, op)
+	if err != nil {
+		if !errors.Is(err, kv.ErrEmpty) {
+			fs.Errorf(f, "Failed to read resumable upload database: %v", err)
+		}
+		return nil
+	}
+	return op.state
+}
+
+// putResumeState writes the progress for key, removing it if state is nil
+func (f *Fs) putResumeState(db *kv.DB, key string, state *resumeState) {
+	if db == nil {
+		return
+	}
+	err := db.Do(true, &resumePut{key: key, state: state})
+	if err != nil {
+		fs.Errorf(f, "Failed to update resumable upload database: %v", err)
+	}
+}
+
+// uploadCreate starts a new upload session
+//
+// Docs: https://docs.pcloud.com/methods/upload/upload_create.html
+func (f *Fs) uploadCreate(ctx context.Context) (uploadID int64, err error) {
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/upload_create",
+	}
+	var result api.UploadCreateResponse
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, nil, &result)
+		err = result.Error.Update(err)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create upload session: %w", err)
+	}
+	return result.UploadID, nil
+}
+
+// uploadInfo reads the size of the data uploaded so far
+//
+// Docs: https://docs.pcloud.com/methods/upload/upload_info.html
+func (f *Fs) uploadInfo(ctx context.Context, uploadID int64) (info *api.UploadInfoResponse, err error) {
+	opts := rest.Opts{
+		Method:     "POST",
+		Path:       "/upload_info",
+		Parameters: url.Values{},
+	}
+	opts.Parameters.Set("uploadid", strconv.FormatInt(uploadID, 10))
+	var result api.UploadInfoResponse
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, nil, &result)
+		err = result.Error.Update(err)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// uploadWrite writes chunk at offset of the upload session
+//
+// Docs: https://docs.pcloud.com/methods/upload/upload_write.html
+func (f *Fs) uploadWrite(ctx context.Context, uploadID int64, offset int64, chunk []byte) (err error) {
+	size := int64(len(chunk))
+	opts := rest.Opts{
+		Method:           "PUT",
+		Path:             "/upload_write",
+		ContentLength:    &size,
+		Parameters:       url.Values{},
+		TransferEncoding: []string{"identity"}, // pcloud doesn't like chunked encoding
+	}
+	opts.Parameters.Set("uploadid", strconv.FormatInt(uploadID, 10))
+	opts.Parameters.Set("uploadoffset", strconv.FormatInt(offset, 10))
+	var result api.Error
+	err = f.pacer.Call(func() (bool, error) {
+		opts.Body = bytes.NewReader(chunk)
+		resp, err := f.srv.CallJSON(ctx, &opts, nil, &result)
+		err = result.Update(err)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %d bytes at offset %d: %w", size, offset, err)
+	}
+	return nil
+}
+
+// uploadSave saves the upload session as leaf in directoryID
+//
+// Docs: https://docs.pcloud.com/methods/upload/upload_save.html
+func (f *Fs) uploadSave(ctx context.Context, uploadID int64, leaf, directoryID string, mtime int64) (item *api.Item, err error) {
+	opts := rest.Opts{
+		Method:     "POST",
+		Path:       "/upload_save",
+		Parameters: url.Values{},
+	}
+	opts.Parameters.Set("uploadid", strconv.FormatInt(uploadID, 10))
+	opts.Parameters.Set("name", f.opt.Enc.FromStandardName(leaf))
+	opts.Parameters.Set("folderid", dirIDtoNumber(directoryID))
+	opts.Parameters.Set("mtime", strconv.FormatInt(mtime, 10))
+	var result api.ItemResult
+	err = f.pacer.CallNoRetry(func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, nil, &result)
+		err = result.Error.Update(err)
+		return shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save upload: %w", err)
+	}
+	return &result.Metadata, nil
+}
+
+// uploadResumable uploads the object in chunks with an upload
+// session, carrying on from where a previous attempt to upload the
+// same source to the same place got to.
+func (o *Object) uploadResumable(ctx context.Context, in io.Reader, src fs.ObjectInfo, leaf, directoryID string) (err error) {
+	f := o.fs
+	size := src.Size()
+	db := f.getResumeDB(ctx)
+	key := f.resumeKey(ctx, o.remote, src)
+
+	// See if there is an upload to carry on with
+	state := f.getResumeState(db, key)
+	if state != nil {
+		info, err := f.uploadInfo(ctx, state.UploadID)
+		switch {
+		case err != nil:
+			fs.Debugf(o, "Starting upload again as can't resume upload session: %v", err)
+			state = nil
+		case info.Size > size:
+			fs.Debugf(o, "Starting upload again as upload session is bigger than the file")
+			state = nil
+		default:
+			// The server has the definitive size as the
+			// last chunk may have been written without
+			// being recorded.
+			state.Offset = info.Size
+		}
+	}
+	if state == nil {
+		uploadID, err := f.uploadCreate(ctx)
+		if err != nil {
+			return err
+		}
+		state = &resumeState{UploadID: uploadID}
+		f.putResumeState(db, key, state)
+	} else {
+		fs.Infof(o, "Resuming upload at offset %d of %d", state.Offset, size)
+		_, err = io.CopyN(io.Discard, in, state.Offset)
+		if err != nil {
+			return fmt.Errorf("failed to skip already uploaded data: %w", err)
+		}
+	}
+
+	// Upload the remaining chunks
+	chunkSize := int64(f.opt.ChunkSize)
+	buf := make([]byte, min(chunkSize, size))
+	for state.Offset < size {
+		chunk := buf[:min(chunkSize, size-state.Offset)]
+		_, err = io.ReadFull(in, chunk)
+		if err != nil {
+			return fmt.Errorf("failed to read chunk at offset %d: %w", state.Offset, err)
+		}
+		err = f.uploadWrite(ctx, state.UploadID, state.Offset, chunk)
+		if err != nil {
+			return err
+		}
+		state.Offset += int64(len(chunk))
+		f.putResumeState(db, key, state)
+	}
+
+	// Save the upload as a file
+	item, err := f.uploadSave(ctx, state.UploadID, leaf, directoryID, src.ModTime(ctx).Unix())
+	if err != nil {
+		return err
+	}
+	f.putResumeState(db, key, nil)
+	o.setHashes(&api.Hashes{})
+	return o.setMetaData(item)
+}
+
+// resumeGet reads the progress of an upload from the database
+type resumeGet struct {
+	key   string
+	state *resumeState
+}
+
+// Do the get
+func (op *resumeGet) Do(ctx context.Context, b kv.Bucket) error {
+	data := b.Get([]byte(op.key))
+	if data == nil {
+		return nil
+	}
+	state := new(resumeState)
+	if err := json.Unmarshal(data, state); err != nil {
+		fs.Debugf(nil, "Ignoring corrupt resumable upload record: %v", err)
+		return nil
+	}
+	op.state = state
+	return nil
+}
+
+// resumePut writes the progress of an upload to the database
+type resumePut struct {
+	key   string
+	state *resumeState
+}
+
+// Do the put
+func (op *resumePut) Do(ctx context.Context, b kv.Bucket) error {
+	if op.state == nil {
+		return b.Delete([]byte(op.key))
+	}
+	data, err := json.Marshal(op.state)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(op.key), data)
+}
@@ -0,0 +1,699 @@
+// Package readcache implements an overlay backend which keeps the
+// data read from another remote in a persistent chunked cache on
+// local disk.
+package readcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "readcache",
+		Description: "Cache the data read from another remote on local disk",
+		NewFs:       NewFs,
+		CommandHelp: commandHelp,
+		MetadataInfo: &fs.MetadataInfo{
+			Help: `Any metadata supported by the underlying remote is read and written.`,
+		},
+		Options: []fs.Option{{
+			Name: "remote",
+			Help: `Remote to cache.
+
+Normally should contain a ':' and a path, e.g. "myremote:path/to/dir",
+"myremote:bucket" or "myremote:".
+
+If this is left empty, then the readcache backend will use the root as
+the remote.
+
+This means that you can use :readcache:remote:path and it will be
+equivalent to setting remote="remote:path".`,
+		}, {
+			Name:    "max_size",
+			Default: fs.SizeSuffix(10 * fs.Gibi),
+			Help: `Maximum size of the cache.
+
+When the cache is bigger than this the least recently used chunks of
+files which aren't pinned are removed. Set to "off" for no limit.
+
+The limit applies to the whole cache directory so should be the same
+for all the remotes sharing it.`,
+		}, {
+			Name:    "pin",
+			Default: fs.CommaSepList{},
+			Help: `Comma separated list of globs for files never to evict.
+
+These use the same syntax as --include so "*.db" matches files
+ending in .db in any directory and "/models/**" matches everything
+in the models directory at the root of the remote.`,
+		}, {
+			Name:     "chunk_size",
+			Default:  fs.SizeSuffix(8 * fs.Mebi),
+			Help:     "Size of the chunks files are read from the remote and cached in.",
+			Advanced: true,
+		}, {
+			Name: "cache_dir",
+			Help: `Directory to keep the cache in.
+
+Leave empty to use "readcache" in rclone's cache directory
+(see --cache-dir). Remotes using the same directory share the
+cache.`,
+			Advanced: true,
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Remote    string          `config:"remote"`
+	MaxSize   fs.SizeSuffix   `config:"max_size"`
+	Pin       fs.CommaSepList `config:"pin"`
+	ChunkSize fs.SizeSuffix   `config:"chunk_size"`
+	CacheDir  string          `config:"cache_dir"`
+}
+
+// Fs represents a remote with a read cache
+type Fs struct {
+	fs.Fs
+	name     string
+	root     string
+	opt      Options
+	features *fs.Features
+	wrapper  fs.Fs
+	store    *store
+	pins     []*regexp.Regexp
+}
+
+// NewFs constructs an Fs from the path.
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	remote := opt.Remote
+	if remote == "" {
+		remote = root
+		root = ""
+	}
+	if strings.HasPrefix(remote, name+":") {
+		return nil, errors.New("can't point readcache remote at itself - check the value of the remote setting")
+	}
+	var pins []*regexp.Regexp
+	for _, glob := range opt.Pin {
+		re, err := filter.GlobPathToRegexp(glob, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pin %q: %w", glob, err)
+		}
+		pins = append(pins, re)
+	}
+	cacheDir := opt.CacheDir
+	if cacheDir == "" {
+		cacheDir = filepath.Join(config.GetCacheDir(), "readcache")
+	}
+	st, err := newStore(cacheDir, int64(opt.ChunkSize), int64(opt.MaxSize))
+	if err != nil {
+		return nil, err
+	}
+	baseFs, err := cache.Get(ctx, fspath.JoinRootPath(remote, root))
+	if err != fs.ErrorIsFile && err != nil {
+		return nil, fmt.Errorf("failed to make remote %q to wrap: %w", remote, err)
+	}
+	f := &Fs{
+		Fs:    baseFs,
+		name:  name,
+		root:  root,
+		opt:   *opt,
+		store: st,
+		pins:  pins,
+	}
+	cache.PinUntilFinalized(f.Fs, f)
+	f.features = (&fs.Features{
+		CaseInsensitive:         true,
+		DuplicateFiles:          true,
+		ReadMimeType:            true,
+		WriteMimeType:           true,
+		CanHaveEmptyDirectories: true,
+		BucketBased:             true,
+		SetTier:                 true,
+		GetTier:                 true,
+		ReadMetadata:            true,
+		WriteMetadata:           true,
+		UserMetadata:            true,
+		PartialUploads:          true,
+	}).Fill(ctx, f).Mask(ctx, baseFs).WrapsFs(f, baseFs)
+	if err == fs.ErrorIsFile {
+		f.root = path.Dir(f.root)
+		if f.root == "." || f.root == "/" {
+			f.root = ""
+		}
+	}
+	return f, err
+}
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string { return f.name }
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string { return f.root }
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features { return f.features }
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set { return f.Fs.Hashes() }
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	return fmt.Sprintf("readcache root '%s'", f.root)
+}
+
+// UnWrap returns the Fs that this Fs is wrapping
+func (f *Fs) UnWrap() fs.Fs { return f.Fs }
+
+// WrapFs returns the Fs that is wrapping this Fs
+func (f *Fs) WrapFs() fs.Fs { return f.wrapper }
+
+// SetWrapper sets the Fs that is wrapping this Fs
+func (f *Fs) SetWrapper(wrapper fs.Fs) { f.wrapper = wrapper }
+
+// key returns the cache key for remote.
+//
+// This is the full path on the wrapped remote so every Fs and rclone
+// process caching the same remote shares the cached data.
+func (f *Fs) key(remote string) string {
+	return fspath.JoinRootPath(fs.ConfigString(f.Fs), remote)
+}
+
+// keyPrefix returns the start of the cache keys of the objects in
+// this Fs
+func (f *Fs) keyPrefix() string {
+	prefix := f.key("")
+	if !strings.HasSuffix(prefix, ":") && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
+
+// pinned returns true if remote matches one of the pins
+func (f *Fs) pinned(remote string) bool {
+	for _, re := range f.pins {
+		if re.MatchString(remote) {
+			return true
+		}
+	}
+	return false
+}
+
+// forget removes remote from the cache after it has been changed
+func (f *Fs) forget(remote string) {
+	if err := f.store.remove(f.key(remote)); err != nil {
+		fs.Debugf(f, "Failed to remove %q from cache: %v", remote, err)
+	}
+}
+
+// forgetDir removes everything in dir from the cache after it has
+// been changed
+func (f *Fs) forgetDir(dir string) {
+	if err := f.store.removePrefix(f.key(dir)); err != nil {
+		fs.Debugf(f, "Failed to remove %q from cache: %v", dir, err)
+	}
+}
+
+// wrapEntries wraps the objects in entries in place
+func (f *Fs) wrapEntries(entries fs.DirEntries) fs.DirEntries {
+	for i, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			entries[i] = f.wrapObject(o)
+		}
+	}
+	return entries
+}
+
+// List the objects and directories in dir into entries.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	entries, err = f.Fs.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapEntries(entries), nil
+}
+
+// ListR lists the objects and directories recursively into callback.
+func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) error {
+	return f.Fs.Features().ListR(ctx, dir, func(entries fs.DirEntries) error {
+		return callback(f.wrapEntries(entries))
+	})
+}
+
+// NewObject finds the Object at remote.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	o, err := f.Fs.NewObject(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o), nil
+}
+
+// Put in to the remote path with the modTime given of the given size
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	defer f.forget(src.Remote())
+	o, err := f.Fs.Put(ctx, in, src, options...)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o), nil
+}
+
+// PutStream uploads to the remote path with the modTime given of indeterminate size
+func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	do := f.Fs.Features().PutStream
+	if do == nil {
+		return nil, errors.New("PutStream not supported")
+	}
+	defer f.forget(src.Remote())
+	o, err := do(ctx, in, src, options...)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(o), nil
+}
+
+// Purge all files in the directory specified
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	do := f.Fs.Features().Purge
+	if do == nil {
+		return fs.ErrorCantPurge
+	}
+	defer f.forgetDir(dir)
+	return do(ctx, dir)
+}
+
+// Copy src to this remote using server-side copy operations.
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	do := f.Fs.Features().Copy
+	if do == nil {
+		return nil, fs.ErrorCantCopy
+	}
+	o, ok := src.(*Object)
+	if !ok {
+		return nil, fs.ErrorCantCopy
+	}
+	defer f.forget(remote)
+	dst, err := do(ctx, o.Object, remote)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(dst), nil
+}
+
+// Move src to this remote using server-side move operations.
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	do := f.Fs.Features().Move
+	if do == nil {
+		return nil, fs.ErrorCantMove
+	}
+	o, ok := src.(*Object)
+	if !ok {
+		return nil, fs.ErrorCantMove
+	}
+	defer o.f.forget(o.Remote())
+	defer f.forget(remote)
+	dst, err := do(ctx, o.Object, remote)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapObject(dst), nil
+}
+
+// DirMove moves src, srcRemote to this remote at dstRemote using server-side move operations.
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	do := f.Fs.Features().DirMove
+	if do == nil {
+		return fs.ErrorCantDirMove
+	}
+	srcFs, ok := src.(*Fs)
+	if !ok {
+		return fs.ErrorCantDirMove
+	}
+	defer srcFs.forgetDir(srcRemote)
+	defer f.forgetDir(dstRemote)
+	return do(ctx, srcFs.Fs, srcRemote, dstRemote)
+}
+
+// About gets quota information from the Fs
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	do := f.Fs.Features().About
+	if do == nil {
+		return nil, errors.New("not supported by underlying remote")
+	}
+	return do(ctx)
+}
+
+// DirCacheFlush resets the directory cache - used in testing
+// as an optional interface
+func (f *Fs) DirCacheFlush() {
+	if do := f.Fs.Features().DirCacheFlush; do != nil {
+		do()
+	}
+}
+
+// Shutdown the backend, closing any background tasks and any cached connections.
+func (f *Fs) Shutdown(ctx context.Context) error {
+	if do := f.Fs.Features().Shutdown; do != nil {
+		return do(ctx)
+	}
+	return nil
+}
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "stats",
+	Short: "Show what is in the cache.",
+	Long: `Show the number of objects, chunks and bytes cached for this remote,
+how much of that is pinned, and how many chunks this rclone has read
+from the cache (hits) and from the remote (misses).
+
+Usage example:
+
+` + "```console" + `
+rclone rc backend/command command=stats fs=readcache:
+` + "```",
+}, {
+	Name:  "list",
+	Short: "List the cached files.",
+	Long: `List the cached files under the path with the number of bytes of each
+which are cached and whether they are pinned.
+
+Usage example:
+
+` + "```console" + `
+rclone backend list readcache:path/to/dir
+` + "```",
+}, {
+	Name:  "clear",
+	Short: "Remove files from the cache.",
+	Long: `Remove the cached data for everything under the path, or a single file.
+
+Usage example:
+
+` + "```console" + `
+rclone backend clear readcache:path/to/dir
+` + "```",
+}, {
+	Name:  "evict",
+	Short: "Bring the cache within its size limit now.",
+	Long: `Remove the least recently used chunks of files which aren't pinned
+until the cache is within max_size. This normally happens
+automatically as data is added to the cache.`,
+}}
+
+// cachedFile is an item in the output of the list command
+type cachedFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Cached int64  `json:"cached"`
+	Pinned bool   `json:"pinned"`
+}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out any, err error) {
+	switch name {
+	case "stats":
+		return f.store.stats(f.keyPrefix())
+	case "list":
+		prefix := f.keyPrefix()
+		files := []cachedFile{}
+		err := f.store.walk(func(info *entryInfo) error {
+			if strings.HasPrefix(info.meta.Key, prefix) {
+				files = append(files, cachedFile{
+					Path:   strings.TrimPrefix(info.meta.Key, prefix),
+					Size:   info.meta.Size,
+					Cached: info.bytes,
+					Pinned: info.meta.Pinned,
+				})
+			}
+			return nil
+		})
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+		return files, err
+	case "clear":
+		return nil, f.store.removePrefix(f.key(""))
+	case "evict":
+		if err := f.store.evict(); err != nil {
+			return nil, err
+		}
+		return f.store.stats(f.keyPrefix())
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// Object represents an object on the wrapped remote
+type Object struct {
+	fs.Object
+	f *Fs
+}
+
+// wrapObject wraps o as an Object
+func (f *Fs) wrapObject(o fs.Object) *Object {
+	return &Object{Object: o, f: f}
+}
+
+// Fs returns read only access to the Fs that this object is part of
+func (o *Object) Fs() fs.Info { return o.f }
+
+// UnWrap returns the wrapped Object
+func (o *Object) UnWrap() fs.Object { return o.Object }
+
+// String returns a description of the Object
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.Object.String()
+}
+
+// Open an object for read through the cache
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	size := o.Size()
+	if size < 0 {
+		return o.Object.Open(ctx, options...)
+	}
+	var offset, limit int64 = 0, -1
+	for _, option := range options {
+		switch x := option.(type) {
+		case *fs.RangeOption:
+			offset, limit = x.Decode(size)
+		case *fs.SeekOption:
+			offset = x.Offset
+		default:
+			if option.Mandatory() {
+				fs.Logf(o, "Unsupported mandatory option: %v", option)
+			}
+		}
+	}
+	end := size
+	if limit >= 0 && offset+limit < size {
+		end = offset + limit
+	}
+	e, err := o.f.store.openEntry(entryMeta{
+		Key:         o.f.key(o.Remote()),
+		Fingerprint: fs.Fingerprint(ctx, o.Object, true),
+		Size:        size,
+		Pinned:      o.f.pinned(o.Remote()),
+	})
+	if err != nil {
+		fs.Debugf(o, "Reading without cache: %v", err)
+		return o.Object.Open(ctx, options...)
+	}
+	return &chunkReader{ctx: ctx, o: o, e: e, offset: offset, end: end}, nil
+}
+
+// Update in to the object with the modTime given of the given size
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	defer o.f.forget(o.Remote())
+	return o.Object.Update(ctx, in, src, options...)
+}
+
+// Remove an object
+func (o *Object) Remove(ctx context.Context) error {
+	defer o.f.forget(o.Remote())
+	return o.Object.Remove(ctx)
+}
+
+// SetModTime sets the modification time of the object
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	defer o.f.forget(o.Remote())
+	return o.Object.SetModTime(ctx, modTime)
+}
+
+// ID returns the ID of the Object if possible
+func (o *Object) ID() string {
+	if do, ok := o.Object.(fs.IDer); ok {
+		return do.ID()
+	}
+	return ""
+}
+
+// GetTier returns the Tier of the Object if possible
+func (o *Object) GetTier() string {
+	if do, ok := o.Object.(fs.GetTierer); ok {
+		return do.GetTier()
+	}
+	return ""
+}
+
+// SetTier set the Tier of the Object if possible
+func (o *Object) SetTier(tier string) error {
+	if do, ok := o.Object.(fs.SetTierer); ok {
+		return do.SetTier(tier)
+	}
+	return errors.New("SetTier not supported")
+}
+
+// MimeType of an Object if known, "" otherwise
+func (o *Object) MimeType(ctx context.Context) string {
+	if do, ok := o.Object.(fs.MimeTyper); ok {
+		return do.MimeType(ctx)
+	}
+	return ""
+}
+
+// Metadata returns metadata for an object
+//
+// It should return nil if there is no Metadata
+func (o *Object) Metadata(ctx context.Context) (fs.Metadata, error) {
+	do, ok := o.Object.(fs.Metadataer)
+	if !ok {
+		return nil, nil
+	}
+	return do.Metadata(ctx)
+}
+
+// SetMetadata sets metadata for an Object
+//
+// It should return fs.ErrorNotImplemented if it can't set metadata
+func (o *Object) SetMetadata(ctx context.Context, metadata fs.Metadata) error {
+	do, ok := o.Object.(fs.SetMetadataer)
+	if !ok {
+		return fs.ErrorNotImplemented
+	}
+	return do.SetMetadata(ctx, metadata)
+}
+
+// chunkReader reads an object a chunk at a time from the cache,
+// fetching the chunks which aren't cached from the remote
+type chunkReader struct {
+	ctx    context.Context
+	o      *Object
+	e      *entry
+	offset int64  // offset in the object of the next read
+	end    int64  // offset to stop reading at
+	buf    []byte // rest of the current chunk
+	closed bool
+}
+
+// chunk returns chunk i from the cache or the remote
+func (r *chunkReader) chunk(i int64) ([]byte, error) {
+	if data := r.e.readChunk(i); data != nil {
+		return data, nil
+	}
+	r.e.s.misses.Add(1)
+	start, n := i*r.e.meta.ChunkSize, r.e.chunkLen(i)
+	in, err := r.o.Object.Open(r.ctx, &fs.RangeOption{Start: start, End: start + n - 1})
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, n)
+	_, err = io.ReadFull(in, data)
+	fs.CheckClose(in, &err)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.e.writeChunk(i, data); err != nil {
+		fs.Debugf(r.o, "Failed to cache chunk %d: %v", i, err)
+	}
+	return data, nil
+}
+
+// Read bytes from the object
+func (r *chunkReader) Read(p []byte) (n int, err error) {
+	if r.closed {
+		return 0, errors.New("read on closed file")
+	}
+	if r.offset >= r.end {
+		return 0, io.EOF
+	}
+	if len(r.buf) == 0 {
+		i := r.offset / r.e.meta.ChunkSize
+		data, err := r.chunk(i)
+		if err != nil {
+			return 0, err
+		}
+		chunkStart := i * r.e.meta.ChunkSize
+		r.buf = data[r.offset-chunkStart : min(int64(len(data)), r.end-chunkStart)]
+	}
+	n = copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.offset += int64(n)
+	return n, nil
+}
+
+// Close the reader
+func (r *chunkReader) Close() error {
+	r.closed = true
+	r.buf = nil
+	return nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs              = (*Fs)(nil)
+	_ fs.Purger          = (*Fs)(nil)
+	_ fs.Copier          = (*Fs)(nil)
+	_ fs.Mover           = (*Fs)(nil)
+	_ fs.DirMover        = (*Fs)(nil)
+	_ fs.PutStreamer     = (*Fs)(nil)
+	_ fs.ListRer         = (*Fs)(nil)
+	_ fs.Abouter         = (*Fs)(nil)
+	_ fs.UnWrapper       = (*Fs)(nil)
+	_ fs.Wrapper         = (*Fs)(nil)
+	_ fs.Commander       = (*Fs)(nil)
+	_ fs.DirCacheFlusher = (*Fs)(nil)
+	_ fs.Shutdowner      = (*Fs)(nil)
+	_ fs.Object          = (*Object)(nil)
+	_ fs.ObjectUnWrapper = (*Object)(nil)
+	_ fs.IDer            = (*Object)(nil)
+	_ fs.GetTierer       = (*Object)(nil)
+	_ fs.SetTierer       = (*Object)(nil)
+	_ fs.MimeTyper       = (*Object)(nil)
+	_ fs.Metadataer      = (*Object)(nil)
+	_ fs.SetMetadataer   = (*Object)(nil)
+)
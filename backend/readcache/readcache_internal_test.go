@@ -0,0 +1,171 @@
+package readcache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestFs makes a readcache Fs on the memory remote for this test
+// with 4 byte chunks
+func newTestFs(t *testing.T, cacheDir string, m configmap.Simple) *Fs {
+	ctx := context.Background()
+	m["remote"] = ":memory:" + t.Name()
+	m["cache_dir"] = cacheDir
+	if m["chunk_size"] == "" {
+		m["chunk_size"] = "4B"
+	}
+	if m["max_size"] == "" {
+		m["max_size"] = "off"
+	}
+	f, err := NewFs(ctx, "readcache", "", m)
+	require.NoError(t, err)
+	return f.(*Fs)
+}
+
+// put uploads a file through f
+func put(t *testing.T, f *Fs, remote, contents string) fs.Object {
+	ctx := context.Background()
+	src := object.NewStaticObjectInfo(remote, time.Now(), int64(len(contents)), true, nil, nil)
+	o, err := f.Put(ctx, bytes.NewBufferString(contents), src)
+	require.NoError(t, err)
+	return o
+}
+
+// read reads the object with the options given
+func read(t *testing.T, o fs.Object, options ...fs.OpenOption) string {
+	in, err := o.Open(context.Background(), options...)
+	require.NoError(t, err)
+	data, err := io.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	return string(data)
+}
+
+// stats returns the stats for f
+func stats(t *testing.T, f *Fs) storeStats {
+	out, err := f.Command(context.Background(), "stats", nil, nil)
+	require.NoError(t, err)
+	return out.(storeStats)
+}
+
+const contents = "the quick brown fox jumps over the lazy dog"
+
+func TestReadThrough(t *testing.T) {
+	f := newTestFs(t, t.TempDir(), configmap.Simple{})
+	o := put(t, f, "file.txt", contents)
+
+	assert.Equal(t, contents, read(t, o))
+	s := stats(t, f)
+	assert.Equal(t, int64(0), s.Hits)
+	assert.Equal(t, int64(11), s.Misses)
+	assert.Equal(t, 1, s.Objects)
+	assert.Equal(t, 11, s.Chunks)
+	assert.Equal(t, int64(len(contents)), s.Bytes)
+
+	// Second read is served from the cache
+	assert.Equal(t, contents, read(t, o))
+	s = stats(t, f)
+	assert.Equal(t, int64(11), s.Hits)
+	assert.Equal(t, int64(11), s.Misses)
+
+	// Ranges and seeks within and across chunks
+	assert.Equal(t, "quick", read(t, o, &fs.RangeOption{Start: 4, End: 8}))
+	assert.Equal(t, "dog", read(t, o, &fs.RangeOption{Start: -1, End: 3}))
+	assert.Equal(t, "lazy dog", read(t, o, &fs.SeekOption{Offset: 35}))
+	assert.Equal(t, int64(11), stats(t, f).Misses)
+}
+
+func TestPartialRead(t *testing.T) {
+	f := newTestFs(t, t.TempDir(), configmap.Simple{})
+	o := put(t, f, "file.txt", contents)
+
+	// Only the chunks read are cached
+	assert.Equal(t, "brown", read(t, o, &fs.RangeOption{Start: 10, End: 14}))
+	s := stats(t, f)
+	assert.Equal(t, 2, s.Chunks)
+	assert.Equal(t, int64(8), s.Bytes)
+}
+
+func TestPersistent(t *testing.T) {
+	ctx := context.Background()
+	cacheDir := t.TempDir()
+	f := newTestFs(t, cacheDir, configmap.Simple{})
+	o := put(t, f, "file.txt", contents)
+	assert.Equal(t, contents, read(t, o))
+
+	// A new Fs with the same cache directory, as used by another
+	// command, reads from the cache
+	f2 := newTestFs(t, cacheDir, configmap.Simple{})
+	o2, err := f2.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, contents, read(t, o2))
+	s := stats(t, f2)
+	assert.Equal(t, int64(11), s.Hits)
+	assert.Equal(t, int64(0), s.Misses)
+}
+
+func TestInvalidate(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFs(t, t.TempDir(), configmap.Simple{})
+	o := put(t, f, "file.txt", contents)
+	assert.Equal(t, contents, read(t, o))
+
+	// Update throws the cached data away
+	newContents := "jackdaws love my big sphinx of quartz"
+	src := object.NewStaticObjectInfo("file.txt", time.Now(), int64(len(newContents)), true, nil, nil)
+	require.NoError(t, o.Update(ctx, bytes.NewBufferString(newContents), src))
+	assert.Equal(t, 0, stats(t, f).Objects)
+	assert.Equal(t, newContents, read(t, o))
+
+	// Changes made behind the cache's back are noticed
+	under, err := f.Fs.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	src = object.NewStaticObjectInfo("file.txt", time.Now().Add(time.Hour), int64(len(contents)), true, nil, nil)
+	require.NoError(t, under.Update(ctx, bytes.NewBufferString(contents), src))
+	o, err = f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, contents, read(t, o))
+
+	// Remove and clear
+	require.NoError(t, o.Remove(ctx))
+	assert.Equal(t, 0, stats(t, f).Objects)
+	o = put(t, f, "dir/file.txt", contents)
+	assert.Equal(t, contents, read(t, o))
+	assert.Equal(t, 1, stats(t, f).Objects)
+	_, err = f.Command(ctx, "clear", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats(t, f).Objects)
+}
+
+func TestEvict(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFs(t, t.TempDir(), configmap.Simple{"max_size": "20B", "pin": "*.db"})
+	pinned := put(t, f, "file.db", contents)
+	o := put(t, f, "file.txt", contents)
+
+	assert.Equal(t, contents, read(t, pinned))
+	assert.Equal(t, contents, read(t, o))
+
+	// The pinned file is kept and the other file evicted
+	s := stats(t, f)
+	assert.Equal(t, int64(len(contents)), s.PinnedBytes)
+	assert.Equal(t, int64(len(contents)), s.Bytes)
+
+	out, err := f.Command(ctx, "list", nil, nil)
+	require.NoError(t, err)
+	files := out.([]cachedFile)
+	require.Len(t, files, 2)
+	assert.Equal(t, cachedFile{Path: "file.db", Size: int64(len(contents)), Cached: int64(len(contents)), Pinned: true}, files[0])
+	assert.Equal(t, "file.txt", files[1].Path)
+	assert.Equal(t, int64(0), files[1].Cached)
+}
@@ -0,0 +1,352 @@
+package readcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/file"
+)
+
+// metaName is the name of the file describing a cached object in its
+// entry directory
+const metaName = "meta.json"
+
+// tempPrefix starts the names of files being written
+const tempPrefix = ".tmp-"
+
+// entryMeta describes a cached object
+type entryMeta struct {
+	Key         string `json:"key"`         // remote:path of the object
+	Fingerprint string `json:"fingerprint"` // size, modtime and hash when cached
+	Size        int64  `json:"size"`        // size of the object
+	ChunkSize   int64  `json:"chunkSize"`   // size of the chunks
+	Pinned      bool   `json:"pinned"`      // set if never evicted
+}
+
+// store keeps chunks of objects on disk.
+//
+// Each object has a directory named after the hash of its key with
+// its metadata and the chunks which have been read. The store can be
+// shared by any number of Fs and rclone processes as files are only
+// ever replaced by renaming.
+type store struct {
+	dir       string
+	chunkSize int64
+	maxSize   int64        // -1 for no limit
+	used      atomic.Int64 // approximate bytes in the store, -1 if unknown
+	hits      atomic.Int64 // chunks read from the store
+	misses    atomic.Int64 // chunks read from the remote
+	evictMu   sync.Mutex   // held while evicting
+}
+
+// newStore opens the store in dir
+func newStore(dir string, chunkSize, maxSize int64) (*store, error) {
+	if chunkSize <= 0 {
+		return nil, errors.New("chunk_size must be greater than 0")
+	}
+	if err := file.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	s := &store{
+		dir:       dir,
+		chunkSize: chunkSize,
+		maxSize:   maxSize,
+	}
+	s.used.Store(-1)
+	return s, nil
+}
+
+// entryDir returns the directory for key
+func (s *store) entryDir(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:16])
+	return filepath.Join(s.dir, name[:2], name)
+}
+
+// readMeta reads the metadata in the entry directory dir
+func readMeta(dir string) (meta entryMeta, err error) {
+	data, err := os.ReadFile(filepath.Join(dir, metaName))
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(data, &meta)
+	return meta, err
+}
+
+// writeFile writes data to name atomically
+func writeFile(name string, data []byte) error {
+	out, err := os.CreateTemp(filepath.Dir(name), tempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(out.Name(), name)
+	}
+	if err != nil {
+		_ = os.Remove(out.Name())
+	}
+	return err
+}
+
+// entry is a cached object
+type entry struct {
+	s    *store
+	dir  string
+	meta entryMeta
+}
+
+// openEntry returns the entry for the object described by meta.
+//
+// If the object has changed since it was cached the old chunks are
+// thrown away.
+func (s *store) openEntry(meta entryMeta) (*entry, error) {
+	meta.ChunkSize = s.chunkSize
+	e := &entry{s: s, dir: s.entryDir(meta.Key), meta: meta}
+	old, err := readMeta(e.dir)
+	if err == nil && old.Key == meta.Key && old.Fingerprint == meta.Fingerprint && old.Size == meta.Size && old.ChunkSize == meta.ChunkSize {
+		if old.Pinned == meta.Pinned {
+			return e, nil
+		}
+	} else if err == nil || !os.IsNotExist(err) {
+		fs.Debugf(nil, "readcache: discarding stale cache of %q", meta.Key)
+		if err := os.RemoveAll(e.dir); err != nil {
+			return nil, err
+		}
+	}
+	if err := file.MkdirAll(e.dir, 0700); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(e.dir, metaName), data); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// chunkPath returns the file name of chunk i
+func (e *entry) chunkPath(i int64) string {
+	return filepath.Join(e.dir, fmt.Sprintf("%08d", i))
+}
+
+// chunkLen returns the length of chunk i
+func (e *entry) chunkLen(i int64) int64 {
+	return min(e.meta.ChunkSize, e.meta.Size-i*e.meta.ChunkSize)
+}
+
+// readChunk returns chunk i or nil if it isn't in the store
+func (e *entry) readChunk(i int64) []byte {
+	name := e.chunkPath(i)
+	data, err := os.ReadFile(name)
+	if err != nil || int64(len(data)) != e.chunkLen(i) {
+		return nil
+	}
+	// Mark as recently used for eviction
+	now := time.Now()
+	_ = os.Chtimes(name, now, now)
+	e.s.hits.Add(1)
+	return data
+}
+
+// writeChunk puts chunk i in the store
+func (e *entry) writeChunk(i int64, data []byte) error {
+	if err := writeFile(e.chunkPath(i), data); err != nil {
+		return err
+	}
+	return e.s.added(int64(len(data)))
+}
+
+// added accounts for n bytes being added to the store and evicts
+// chunks if it is too big
+func (s *store) added(n int64) error {
+	if s.maxSize < 0 {
+		return nil
+	}
+	used := s.used.Load()
+	if used >= 0 {
+		used = s.used.Add(n)
+	}
+	if used < 0 || used > s.maxSize {
+		return s.evict()
+	}
+	return nil
+}
+
+// entryInfo describes an entry found in the store
+type entryInfo struct {
+	dir    string
+	meta   entryMeta
+	chunks []chunkInfo
+	bytes  int64
+}
+
+// chunkInfo describes a chunk file found in the store
+type chunkInfo struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// walk calls fn for each entry in the store
+func (s *store) walk(fn func(info *entryInfo) error) error {
+	prefixes, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, prefix := range prefixes {
+		if !prefix.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(s.dir, prefix.Name()))
+		if err != nil {
+			return err
+		}
+		for _, de := range entries {
+			if !de.IsDir() {
+				continue
+			}
+			dir := filepath.Join(s.dir, prefix.Name(), de.Name())
+			meta, err := readMeta(dir)
+			if err != nil {
+				// being created or removed
+				continue
+			}
+			info := &entryInfo{dir: dir, meta: meta}
+			files, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, f := range files {
+				if f.Name() == metaName || strings.HasPrefix(f.Name(), tempPrefix) {
+					continue
+				}
+				fi, err := f.Info()
+				if err != nil {
+					continue
+				}
+				info.chunks = append(info.chunks, chunkInfo{
+					path:    filepath.Join(dir, f.Name()),
+					size:    fi.Size(),
+					modTime: fi.ModTime(),
+				})
+				info.bytes += fi.Size()
+			}
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// evict removes the least recently used chunks of unpinned objects
+// until the store is within its size limit
+func (s *store) evict() error {
+	if !s.evictMu.TryLock() {
+		return nil
+	}
+	defer s.evictMu.Unlock()
+
+	var total int64
+	var candidates []chunkInfo
+	err := s.walk(func(info *entryInfo) error {
+		total += info.bytes
+		if !info.meta.Pinned {
+			candidates = append(candidates, info.chunks...)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+	if s.maxSize >= 0 && total > s.maxSize {
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].modTime.Before(candidates[j].modTime)
+		})
+		removed := 0
+		for _, c := range candidates {
+			if total <= s.maxSize {
+				break
+			}
+			if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			total -= c.size
+			removed++
+		}
+		fs.Debugf(nil, "readcache: evicted %d chunks to bring cache down to %v", removed, fs.SizeSuffix(total))
+	}
+	s.used.Store(total)
+	return nil
+}
+
+// remove the entry for key
+func (s *store) remove(key string) error {
+	return os.RemoveAll(s.entryDir(key))
+}
+
+// removePrefix removes the entries for key and all the keys in the
+// directory key
+func (s *store) removePrefix(key string) error {
+	return s.walk(func(info *entryInfo) error {
+		if info.meta.Key == key || strings.HasPrefix(info.meta.Key, strings.TrimSuffix(key, "/")+"/") {
+			return os.RemoveAll(info.dir)
+		}
+		return nil
+	})
+}
+
+// storeStats describes the contents of the store
+type storeStats struct {
+	Dir         string `json:"dir"`
+	ChunkSize   int64  `json:"chunkSize"`
+	MaxSize     int64  `json:"maxSize"`
+	Objects     int    `json:"objects"`
+	Chunks      int    `json:"chunks"`
+	Bytes       int64  `json:"bytes"`
+	PinnedBytes int64  `json:"pinnedBytes"`
+	Hits        int64  `json:"hits"`
+	Misses      int64  `json:"misses"`
+}
+
+// stats returns the stats for the objects in the store whose keys
+// start with prefix
+func (s *store) stats(prefix string) (stats storeStats, err error) {
+	stats = storeStats{
+		Dir:       s.dir,
+		ChunkSize: s.chunkSize,
+		MaxSize:   s.maxSize,
+		Hits:      s.hits.Load(),
+		Misses:    s.misses.Load(),
+	}
+	err = s.walk(func(info *entryInfo) error {
+		if !strings.HasPrefix(info.meta.Key, prefix) {
+			return nil
+		}
+		stats.Objects++
+		stats.Chunks += len(info.chunks)
+		stats.Bytes += info.bytes
+		if info.meta.Pinned {
+			stats.PinnedBytes += info.bytes
+		}
+		return nil
+	})
+	return stats, err
+}
@@ -0,0 +1,545 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	v4signer "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// adminAPI is implemented by the admin APIs of self hosted S3 servers
+type adminAPI interface {
+	// createKey creates an access key called name
+	createKey(ctx context.Context, name string) (*adminKey, error)
+	// createBucket creates the bucket
+	createBucket(ctx context.Context, bucket string) error
+	// allowKey gives the access key the permissions on the bucket
+	allowKey(ctx context.Context, bucket, accessKeyID string, perms adminPermissions) error
+	// setQuota sets the quotas on the bucket
+	setQuota(ctx context.Context, bucket string, quotas adminQuotas) error
+}
+
+// adminKey is an access key returned from admin-create-key
+type adminKey struct {
+	Name            string `json:"name"`
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+}
+
+// adminPermissions are the permissions an access key has on a bucket
+type adminPermissions struct {
+	Read  bool `json:"read"`
+	Write bool `json:"write"`
+	Owner bool `json:"owner"`
+}
+
+// adminQuotas are the quotas on a bucket - nil means no quota
+type adminQuotas struct {
+	MaxSize    *int64 `json:"maxSize"`
+	MaxObjects *int64 `json:"maxObjects"`
+}
+
+// newAdminAPI returns the admin API configured for f
+func (f *Fs) newAdminAPI(ctx context.Context) (adminAPI, error) {
+	adminAPIType := strings.ToLower(f.opt.AdminAPI)
+	if adminAPIType == "" && f.opt.Provider == "SeaweedFS" {
+		adminAPIType = "seaweedfs"
+	}
+	if adminAPIType == "" {
+		return nil, errors.New("need --s3-admin-api to use the admin commands")
+	}
+	if f.opt.AdminEndpoint == "" {
+		return nil, errors.New("need --s3-admin-endpoint to use the admin commands")
+	}
+	endpoint := strings.TrimRight(f.opt.AdminEndpoint, "/")
+	srv := rest.NewClient(fshttp.NewClient(ctx)).SetRoot(endpoint)
+	switch adminAPIType {
+	case "garage":
+		if f.opt.AdminToken == "" {
+			return nil, errors.New("need --s3-admin-token to use the Garage admin API")
+		}
+		srv.SetHeader("Authorization", "Bearer "+f.opt.AdminToken)
+		srv.SetErrorHandler(garageErrorHandler)
+		return &garageAdmin{f: f, srv: srv}, nil
+	case "seaweedfs":
+		a := &seaweedfsAdmin{f: f, srv: srv}
+		srv.SetErrorHandler(iamErrorHandler)
+		srv.SetSigner(a.sign)
+		return a, nil
+	}
+	return nil, fmt.Errorf("unknown admin API %q", f.opt.AdminAPI)
+}
+
+// adminShouldRetry returns a boolean as to whether this resp and err
+// from an admin API deserve to be retried. It returns the err as a
+// convenience
+func adminShouldRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if fserrors.ContextError(ctx, &err) {
+		return false, err
+	}
+	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
+}
+
+// parseAdminPermissions reads the permissions from the command
+// options, defaulting to read and write
+func parseAdminPermissions(opt map[string]string) (perms adminPermissions, err error) {
+	found := false
+	for _, p := range []struct {
+		name string
+		flag *bool
+	}{
+		{"read", &perms.Read},
+		{"write", &perms.Write},
+		{"owner", &perms.Owner},
+	} {
+		value, ok := opt[p.name]
+		if !ok {
+			continue
+		}
+		found = true
+		if value == "" {
+			*p.flag = true
+			continue
+		}
+		*p.flag, err = strconv.ParseBool(value)
+		if err != nil {
+			return perms, fmt.Errorf("bad %s: %w", p.name, err)
+		}
+	}
+	if !found {
+		perms.Read = true
+		perms.Write = true
+	}
+	return perms, nil
+}
+
+// parseAdminQuotas reads the quotas from the command options
+//
+// It returns ok false if no quotas were given
+func parseAdminQuotas(opt map[string]string) (quotas adminQuotas, ok bool, err error) {
+	if value, found := opt["max-size"]; found {
+		ok = true
+		if value != "off" && value != "" {
+			var size fs.SizeSuffix
+			err = size.Set(value)
+			if err != nil {
+				return quotas, ok, fmt.Errorf("bad max-size: %w", err)
+			}
+			maxSize := int64(size)
+			quotas.MaxSize = &maxSize
+		}
+	}
+	if value, found := opt["max-objects"]; found {
+		ok = true
+		if value != "off" && value != "" {
+			maxObjects, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return quotas, ok, fmt.Errorf("bad max-objects: %w", err)
+			}
+			quotas.MaxObjects = &maxObjects
+		}
+	}
+	return quotas, ok, nil
+}
+
+// adminCommand runs the admin-* backend commands
+func (f *Fs) adminCommand(ctx context.Context, name string, arg []string, opt map[string]string) (out any, err error) {
+	a, err := f.newAdminAPI(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if name == "admin-create-key" {
+		if len(arg) != 1 {
+			return nil, errors.New("need exactly one argument - the name of the key")
+		}
+		return a.createKey(ctx, arg[0])
+	}
+	bucket := f.rootBucket
+	if bucket == "" {
+		return nil, errors.New("need a bucket")
+	}
+	switch name {
+	case "admin-create-bucket":
+		perms, err := parseAdminPermissions(opt)
+		if err != nil {
+			return nil, err
+		}
+		quotas, setQuotas, err := parseAdminQuotas(opt)
+		if err != nil {
+			return nil, err
+		}
+		err = a.createBucket(ctx, bucket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create bucket: %w", err)
+		}
+		fs.Infof(f, "Created bucket %q", bucket)
+		if key := opt["key"]; key != "" {
+			err = a.allowKey(ctx, bucket, key, perms)
+			if err != nil {
+				return nil, fmt.Errorf("failed to give key access to bucket: %w", err)
+			}
+		}
+		if setQuotas {
+			err = a.setQuota(ctx, bucket, quotas)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set bucket quotas: %w", err)
+			}
+		}
+		return nil, nil
+	case "admin-allow-key":
+		key := opt["key"]
+		if key == "" {
+			return nil, errors.New("need -o key=ACCESS_KEY_ID")
+		}
+		perms, err := parseAdminPermissions(opt)
+		if err != nil {
+			return nil, err
+		}
+		return nil, a.allowKey(ctx, bucket, key, perms)
+	case "admin-set-quota":
+		quotas, _, err := parseAdminQuotas(opt)
+		if err != nil {
+			return nil, err
+		}
+		return nil, a.setQuota(ctx, bucket, quotas)
+	}
+	return nil, fs.ErrorCommandNotFound
+}
+
+// garageAdmin talks to the Garage admin API
+//
+// See https://garagehq.deuxfleurs.fr/api/garage-admin-v1.html
+type garageAdmin struct {
+	f   *Fs
+	srv *rest.Client
+}
+
+// garageError is returned by the Garage admin API
+type garageError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Status  int    `json:"-"`
+}
+
+// Error satisfies the error interface
+func (e *garageError) Error() string {
+	return fmt.Sprintf("%s: %s (%d)", e.Code, e.Message, e.Status)
+}
+
+// garageErrorHandler parses a non 2xx error response into an error
+func garageErrorHandler(resp *http.Response) error {
+	errResponse := &garageError{}
+	err := rest.DecodeJSON(resp, &errResponse)
+	if err != nil {
+		fs.Debugf(nil, "Couldn't decode error response: %v", err)
+	}
+	errResponse.Status = resp.StatusCode
+	if errResponse.Code == "" {
+		errResponse.Code = resp.Status
+	}
+	return errResponse
+}
+
+// call the Garage admin API with the pacer
+func (a *garageAdmin) call(ctx context.Context, opts *rest.Opts, request, response any) error {
+	return a.f.pacer.Call(func() (bool, error) {
+		resp, err := a.srv.CallJSON(ctx, opts, request, response)
+		return adminShouldRetry(ctx, resp, err)
+	})
+}
+
+// bucketID looks up the ID of the bucket from its alias
+func (a *garageAdmin) bucketID(ctx context.Context, bucket string) (string, error) {
+	opts := rest.Opts{
+		Method:     "GET",
+		Path:       "/v1/bucket",
+		Parameters: url.Values{"globalAlias": {bucket}},
+	}
+	var result struct {
+		ID string `json:"id"`
+	}
+	err := a.call(ctx, &opts, nil, &result)
+	if err != nil {
+		return "", fmt.Errorf("failed to find bucket %q: %w", bucket, err)
+	}
+	return result.ID, nil
+}
+
+// createKey creates an access key called name
+func (a *garageAdmin) createKey(ctx context.Context, name string) (*adminKey, error) {
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/v1/key",
+	}
+	request := struct {
+		Name string `json:"name"`
+	}{Name: name}
+	var key adminKey
+	err := a.call(ctx, &opts, &request, &key)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// createBucket creates the bucket
+func (a *garageAdmin) createBucket(ctx context.Context, bucket string) error {
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/v1/bucket",
+	}
+	request := struct {
+		GlobalAlias string `json:"globalAlias"`
+	}{GlobalAlias: bucket}
+	return a.call(ctx, &opts, &request, nil)
+}
+
+// allowKey gives the access key the permissions on the bucket
+func (a *garageAdmin) allowKey(ctx context.Context, bucket, accessKeyID string, perms adminPermissions) error {
+	id, err := a.bucketID(ctx, bucket)
+	if err != nil {
+		return err
+	}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/v1/bucket/allow",
+	}
+	request := struct {
+		BucketID    string           `json:"bucketId"`
+		AccessKeyID string           `json:"accessKeyId"`
+		Permissions adminPermissions `json:"permissions"`
+	}{
+		BucketID:    id,
+		AccessKeyID: accessKeyID,
+		Permissions: perms,
+	}
+	return a.call(ctx, &opts, &request, nil)
+}
+
+// setQuota sets the quotas on the bucket
+func (a *garageAdmin) setQuota(ctx context.Context, bucket string, quotas adminQuotas) error {
+	id, err := a.bucketID(ctx, bucket)
+	if err != nil {
+		return err
+	}
+	opts := rest.Opts{
+		Method:     "PUT",
+		Path:       "/v1/bucket",
+		Parameters: url.Values{"id": {id}},
+	}
+	request := struct {
+		Quotas adminQuotas `json:"quotas"`
+	}{Quotas: quotas}
+	return a.call(ctx, &opts, &request, nil)
+}
+
+// seaweedfsAdmin talks to the SeaweedFS IAM API
+//
+// This is a subset of the AWS IAM API which SeaweedFS uses to manage
+// its identities.
+type seaweedfsAdmin struct {
+	f   *Fs
+	srv *rest.Client
+}
+
+// iamError is returned by the IAM API
+type iamError struct {
+	XMLName xml.Name `xml:"ErrorResponse"`
+	Code    string   `xml:"Error>Code"`
+	Message string   `xml:"Error>Message"`
+	Status  int      `xml:"-"`
+}
+
+// Error satisfies the error interface
+func (e *iamError) Error() string {
+	return fmt.Sprintf("%s: %s (%d)", e.Code, e.Message, e.Status)
+}
+
+// iamErrorHandler parses a non 2xx error response into an error
+func iamErrorHandler(resp *http.Response) error {
+	errResponse := &iamError{}
+	err := rest.DecodeXML(resp, &errResponse)
+	if err != nil {
+		fs.Debugf(nil, "Couldn't decode error response: %v", err)
+	}
+	errResponse.Status = resp.StatusCode
+	if errResponse.Code == "" {
+		errResponse.Code = resp.Status
+	}
+	return errResponse
+}
+
+// sign signs the request with the credentials of the remote
+//
+// The payload hash is passed in the X-Amz-Content-Sha256 header.
+func (a *seaweedfsAdmin) sign(req *http.Request) error {
+	creds, err := a.f.c.Options().Credentials.Retrieve(req.Context())
+	if err != nil {
+		return fmt.Errorf("failed to read credentials: %w", err)
+	}
+	region := a.f.opt.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return v4signer.NewSigner().SignHTTP(req.Context(), creds, req, req.Header.Get("X-Amz-Content-Sha256"), "iam", region, time.Now())
+}
+
+// call the IAM API action with the parameters and the pacer
+func (a *seaweedfsAdmin) call(ctx context.Context, action string, params url.Values, response any) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("Action", action)
+	params.Set("Version", "2010-05-08")
+	body := params.Encode()
+	sum := sha256.Sum256([]byte(body))
+	return a.f.pacer.Call(func() (bool, error) {
+		opts := rest.Opts{
+			Method:      "POST",
+			Path:        "/",
+			Body:        strings.NewReader(body),
+			ContentType: "application/x-www-form-urlencoded; charset=utf-8",
+			ExtraHeaders: map[string]string{
+				"X-Amz-Content-Sha256": hex.EncodeToString(sum[:]),
+			},
+		}
+		var resp *http.Response
+		var err error
+		if response == nil {
+			opts.NoResponse = true
+			resp, err = a.srv.Call(ctx, &opts)
+		} else {
+			resp, err = a.srv.CallXML(ctx, &opts, nil, response)
+		}
+		return adminShouldRetry(ctx, resp, err)
+	})
+}
+
+// createKey creates a user called name and an access key for it
+func (a *seaweedfsAdmin) createKey(ctx context.Context, name string) (*adminKey, error) {
+	err := a.call(ctx, "CreateUser", url.Values{"UserName": {name}}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	var result struct {
+		AccessKey struct {
+			UserName        string `xml:"UserName"`
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+		} `xml:"CreateAccessKeyResult>AccessKey"`
+	}
+	err = a.call(ctx, "CreateAccessKey", url.Values{"UserName": {name}}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access key: %w", err)
+	}
+	return &adminKey{
+		Name:            result.AccessKey.UserName,
+		AccessKeyID:     result.AccessKey.AccessKeyID,
+		SecretAccessKey: result.AccessKey.SecretAccessKey,
+	}, nil
+}
+
+// createBucket creates the bucket
+//
+// SeaweedFS buckets are created with the S3 API.
+func (a *seaweedfsAdmin) createBucket(ctx context.Context, bucket string) error {
+	return a.f.makeBucket(ctx, bucket)
+}
+
+// userName finds the user which owns the access key
+func (a *seaweedfsAdmin) userName(ctx context.Context, accessKeyID string) (string, error) {
+	var result struct {
+		Keys []struct {
+			UserName    string `xml:"UserName"`
+			AccessKeyID string `xml:"AccessKeyId"`
+		} `xml:"ListAccessKeysResult>AccessKeyMetadata>member"`
+	}
+	err := a.call(ctx, "ListAccessKeys", nil, &result)
+	if err != nil {
+		return "", fmt.Errorf("failed to list access keys: %w", err)
+	}
+	for _, key := range result.Keys {
+		if key.AccessKeyID == accessKeyID {
+			return key.UserName, nil
+		}
+	}
+	return "", fmt.Errorf("access key %q not found", accessKeyID)
+}
+
+// iamPolicy is an IAM policy document
+type iamPolicy struct {
+	Version   string               `json:"Version"`
+	Statement []iamPolicyStatement `json:"Statement"`
+}
+
+// iamPolicyStatement is a statement in an IAM policy document
+type iamPolicyStatement struct {
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource []string `json:"Resource"`
+}
+
+// bucketPolicy makes a policy giving the permissions on the bucket
+func bucketPolicy(bucket string, perms adminPermissions) iamPolicy {
+	var actions []string
+	if perms.Owner {
+		actions = []string{"s3:*"}
+	} else {
+		if perms.Read {
+			actions = append(actions, "s3:Get*", "s3:List*")
+		}
+		if perms.Write {
+			actions = append(actions, "s3:Put*", "s3:DeleteObject")
+		}
+	}
+	return iamPolicy{
+		Version: "2012-10-17",
+		Statement: []iamPolicyStatement{{
+			Effect:   "Allow",
+			Action:   actions,
+			Resource: []string{"arn:aws:s3:::" + bucket, "arn:aws:s3:::" + bucket + "/*"},
+		}},
+	}
+}
+
+// allowKey gives the access key the permissions on the bucket
+func (a *seaweedfsAdmin) allowKey(ctx context.Context, bucket, accessKeyID string, perms adminPermissions) error {
+	userName, err := a.userName(ctx, accessKeyID)
+	if err != nil {
+		return err
+	}
+	policy, err := json.Marshal(bucketPolicy(bucket, perms))
+	if err != nil {
+		return err
+	}
+	return a.call(ctx, "PutUserPolicy", url.Values{
+		"UserName":       {userName},
+		"PolicyName":     {"rclone-" + bucket},
+		"PolicyDocument": {string(policy)},
+	}, nil)
+}
+
+// setQuota sets the quotas on the bucket
+func (a *seaweedfsAdmin) setQuota(ctx context.Context, bucket string, quotas adminQuotas) error {
+	return errors.New("SeaweedFS doesn't support setting quotas with its IAM API - use s3.bucket.quota in weed shell")
+}
+
+// Check the interfaces are satisfied
+var (
+	_ adminAPI = (*garageAdmin)(nil)
+	_ adminAPI = (*seaweedfsAdmin)(nil)
+)
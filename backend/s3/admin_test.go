@@ -0,0 +1,139 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAdminPermissions(t *testing.T) {
+	for _, test := range []struct {
+		opt     map[string]string
+		want    adminPermissions
+		wantErr bool
+	}{
+		{map[string]string{}, adminPermissions{Read: true, Write: true}, false},
+		{map[string]string{"read": ""}, adminPermissions{Read: true}, false},
+		{map[string]string{"owner": "true", "write": "false"}, adminPermissions{Owner: true}, false},
+		{map[string]string{"read": "potato"}, adminPermissions{}, true},
+	} {
+		got, err := parseAdminPermissions(test.opt)
+		if test.wantErr {
+			assert.Error(t, err, test.opt)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.want, got, test.opt)
+	}
+}
+
+func TestParseAdminQuotas(t *testing.T) {
+	quotas, ok, err := parseAdminQuotas(map[string]string{})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, adminQuotas{}, quotas)
+
+	quotas, ok, err = parseAdminQuotas(map[string]string{"max-size": "1G", "max-objects": "100"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NotNil(t, quotas.MaxSize)
+	require.NotNil(t, quotas.MaxObjects)
+	assert.Equal(t, int64(1<<30), *quotas.MaxSize)
+	assert.Equal(t, int64(100), *quotas.MaxObjects)
+
+	quotas, ok, err = parseAdminQuotas(map[string]string{"max-size": "off"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, adminQuotas{}, quotas)
+
+	_, _, err = parseAdminQuotas(map[string]string{"max-objects": "lots"})
+	assert.Error(t, err)
+}
+
+func TestBucketPolicy(t *testing.T) {
+	policy := bucketPolicy("bucket", adminPermissions{Read: true})
+	require.Len(t, policy.Statement, 1)
+	assert.Equal(t, []string{"s3:Get*", "s3:List*"}, policy.Statement[0].Action)
+	assert.Equal(t, []string{"arn:aws:s3:::bucket", "arn:aws:s3:::bucket/*"}, policy.Statement[0].Resource)
+
+	policy = bucketPolicy("bucket", adminPermissions{Read: true, Owner: true})
+	assert.Equal(t, []string{"s3:*"}, policy.Statement[0].Action)
+}
+
+func TestGarageAdmin(t *testing.T) {
+	ctx := context.Background()
+	type call struct {
+		Method string
+		Path   string
+		Body   map[string]any
+	}
+	var calls []call
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		c := call{Method: r.Method, Path: r.URL.RequestURI()}
+		body, _ := io.ReadAll(r.Body)
+		if len(body) > 0 {
+			require.NoError(t, json.Unmarshal(body, &c.Body))
+		}
+		calls = append(calls, c)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1/key":
+			_, _ = w.Write([]byte(`{"name":"ci","accessKeyId":"GK123","secretAccessKey":"secret"}`))
+		case r.URL.Path == "/v1/bucket" && r.Method == "GET":
+			if r.URL.Query().Get("globalAlias") != "bucket" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"code":"NoSuchBucket","message":"Bucket not found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"b1"}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	f := &Fs{
+		opt: Options{
+			AdminAPI:      "garage",
+			AdminEndpoint: server.URL,
+			AdminToken:    "token",
+		},
+		rootBucket: "bucket",
+		pacer:      fs.NewPacer(ctx, pacer.NewS3(pacer.MinSleep(minSleep))),
+	}
+
+	out, err := f.adminCommand(ctx, "admin-create-key", []string{"ci"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &adminKey{Name: "ci", AccessKeyID: "GK123", SecretAccessKey: "secret"}, out)
+
+	calls = nil
+	_, err = f.adminCommand(ctx, "admin-create-bucket", nil, map[string]string{"key": "GK123", "max-objects": "10"})
+	require.NoError(t, err)
+	assert.Equal(t, []call{
+		{"POST", "/v1/bucket", map[string]any{"globalAlias": "bucket"}},
+		{"GET", "/v1/bucket?globalAlias=bucket", nil},
+		{"POST", "/v1/bucket/allow", map[string]any{
+			"bucketId":    "b1",
+			"accessKeyId": "GK123",
+			"permissions": map[string]any{"read": true, "write": true, "owner": false},
+		}},
+		{"GET", "/v1/bucket?globalAlias=bucket", nil},
+		{"PUT", "/v1/bucket?id=b1", map[string]any{
+			"quotas": map[string]any{"maxSize": nil, "maxObjects": float64(10)},
+		}},
+	}, calls)
+
+	f.rootBucket = "missing"
+	_, err = f.adminCommand(ctx, "admin-set-quota", nil, map[string]string{"max-size": "1G"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NoSuchBucket")
+}
@@ -0,0 +1,53 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+)
+
+// listConstraints returns the name prefix and the name to start
+// after to constrain the listing of dir with.
+//
+// These are made from the filters if the listing is filter aware and
+// the --query if set. The --query is of the form
+// "prefix=STRING&start-after=NAME".
+func (f *Fs) listConstraints(ctx context.Context, dir string, recurse bool) (namePrefix, startAfter string, err error) {
+	if fi, use := filter.GetConfig(ctx), filter.GetUseFilter(ctx); fi != nil && use {
+		namePrefix = fi.ListPrefix(dir, recurse)
+		// Only use the prefix if encoding doesn't change it
+		// as encoding a partial name might not give the
+		// start of the encoded name.
+		if f.opt.Enc.FromStandardPath(namePrefix) != namePrefix {
+			namePrefix = ""
+		}
+	}
+	query := filter.GetListQuery(ctx)
+	if query == "" {
+		return namePrefix, "", nil
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse list query: %w", err)
+	}
+	for key := range values {
+		switch key {
+		case "prefix", "start-after":
+		default:
+			return "", "", fmt.Errorf("unknown key %q in list query - use prefix or start-after", key)
+		}
+	}
+	queryPrefix := values.Get("prefix")
+	// Use the longer of the prefixes if one extends the other
+	// otherwise the query prefix as nothing can pass the filters
+	if !strings.HasPrefix(namePrefix, queryPrefix) {
+		namePrefix = queryPrefix
+	}
+	startAfter = values.Get("start-after")
+	fs.Debugf(f, "Listing %q with prefix %q starting after %q", dir, namePrefix, startAfter)
+	return namePrefix, startAfter, nil
+}
@@ -29,6 +29,7 @@ type Quirks struct {
 	UseDataIntegrityProtections *bool  `yaml:"use_data_integrity_protections,omitempty"`
 	MightGzip                   *bool  `yaml:"might_gzip,omitempty"`
 	UseMultipartUploads         *bool  `yaml:"use_multipart_uploads,omitempty"`
+	UseDeleteObjects            *bool  `yaml:"use_delete_objects,omitempty"`
 	UseUnsignedPayload          *bool  `yaml:"use_unsigned_payload,omitempty"`
 	UseXID                      *bool  `yaml:"use_x_id,omitempty"`
 	SignAcceptEncoding          *bool  `yaml:"sign_accept_encoding,omitempty"`
@@ -55,6 +56,7 @@ type Provider struct {
 	// advanced
 	BucketACL             bool `yaml:"bucket_acl,omitempty"`
 	DirectoryBucket       bool `yaml:"directory_bucket,omitempty"`
+	DirectoryBucketAuth   bool `yaml:"directory_bucket_session_auth,omitempty"`
 	LeavePartsOnError     bool `yaml:"leave_parts_on_error,omitempty"`
 	RequesterPays         bool `yaml:"requester_pays,omitempty"`
 	SSECustomerAlgorithm  bool `yaml:"sse_customer_algorithm,omitempty"`
@@ -219,6 +221,8 @@ func constructProviders(options fs.Options, providerMap map[string]*Provider) st
 				addBool(opt, p, p.SSECustomerKeyMd5)
 			case "directory_bucket":
 				addBool(opt, p, p.DirectoryBucket)
+			case "directory_bucket_session_auth":
+				addBool(opt, p, p.DirectoryBucketAuth)
 			case "ibm_api_key":
 				addBool(opt, p, p.IBMApiKey)
 			case "ibm_resource_instance_id":
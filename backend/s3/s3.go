@@ -6,8 +6,10 @@ package s3
 import (
 	"context"
 	"crypto/md5"
+	"crypto/sha256"
 	"crypto/tls"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
@@ -50,6 +52,7 @@ import (
 	"github.com/rclone/rclone/fs/config"
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/credential"
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/fshttp"
 	"github.com/rclone/rclone/fs/hash"
@@ -57,6 +60,7 @@ import (
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/lib/atexit"
 	"github.com/rclone/rclone/lib/bucket"
+	"github.com/rclone/rclone/lib/crc64nvme"
 	"github.com/rclone/rclone/lib/encoder"
 	"github.com/rclone/rclone/lib/multipart"
 	"github.com/rclone/rclone/lib/pacer"
@@ -608,6 +612,35 @@ circumstances or for testing.
 See [AWS Docs on Data Integrity Protections](https://docs.aws.amazon.com/sdkref/latest/guide/feature-dataintegrity.html)`,
 			Default:  fs.Tristate{},
 			Advanced: true,
+		}, {
+			Name: "upload_checksum",
+			Help: strings.ReplaceAll(`Full object checksum to send with uploads.
+
+If set, rclone sends a checksum of this type with every upload and the
+server checks it against the data it received. The checksum is stored
+with the object and can be used by |rclone check| and |rclone hashsum|
+to verify it, even for multipart uploads whose ETag isn't an MD5.
+
+With |crc64nvme| multipart uploads use a full object checksum which
+rclone works out from the checksums of the parts.
+
+With |sha256| multipart uploads can only have their parts checked so
+only single part uploads get a full object checksum.
+
+Single part uploads with |use_presigned_request| don't send checksums.
+`, "|", "`"),
+			Default: "",
+			Examples: []fs.OptionExample{{
+				Value: "",
+				Help:  "Don't send a checksum",
+			}, {
+				Value: "crc64nvme",
+				Help:  "CRC-64/NVME full object checksum",
+			}, {
+				Value: "sha256",
+				Help:  "SHA-256 checksum of single part uploads",
+			}},
+			Advanced: true,
 		}, {
 			Name:     "versions",
 			Help:     "Include old versions in directory listings.",
@@ -745,6 +778,22 @@ knows about - please make a bug report if not.
 `,
 			Default:  fs.Tristate{},
 			Advanced: true,
+		}, {
+			Name: "use_delete_objects",
+			Help: strings.ReplaceAll(`Set if rclone should use the DeleteObjects call to delete files in batches.
+
+If this is set then rclone deletes up to 1000 files with each
+|DeleteObjects| call when deleting many files, for example with
+|rclone delete|, |rclone purge| or when syncing. This is much quicker
+than deleting the files one at a time.
+
+You can change this if your provider doesn't support |DeleteObjects|.
+
+This should be automatically set correctly for all providers rclone
+knows about - please make a bug report if not.
+`, "|", "`"),
+			Default:  fs.Tristate{},
+			Advanced: true,
 		}, {
 			Name: "use_x_id",
 			Help: `Set if rclone should add x-id URL parameters.
@@ -777,30 +826,54 @@ knows about - please make a bug report if not.
 			Name: "directory_bucket",
 			Help: strings.ReplaceAll(`Set to use AWS Directory Buckets
 
-If you are using an AWS Directory Bucket then set this flag.
+If you are using an AWS Directory Bucket then set this flag. This is
+set automatically if the provider is AWS and the bucket in the path
+ends in |--x-s3| as all Directory Bucket names do.
 
 This will ensure no |Content-Md5| headers are sent and ensure |ETag|
 headers are not interpreted as MD5 sums. |X-Amz-Meta-Md5chksum| will
 be set on all objects whether single or multipart uploaded.
 
-This also sets |no_check_bucket = true|.
+This also sets |no_check_bucket = true| and |list_version = 2|, and
+lists Directory Buckets rather than general purpose buckets at the top
+level.
+
+Requests are authorized with sessions made with |CreateSession| unless
+|directory_bucket_session_auth = false|.
 
 Note that Directory Buckets do not support:
 
 - Versioning
 - |Content-Encoding: gzip|
+- v2 signatures
+- listings in lexical order - rclone sorts them where needed
 
 Rclone limitations with Directory Buckets:
 
 - rclone does not support creating Directory Buckets with |rclone mkdir|
 - ... or removing them with |rclone rmdir| yet
-- Directory Buckets do not appear when doing |rclone lsf| at the top level.
+- Incomplete multipart uploads may show up as empty directories.
 - Rclone can't remove auto created directories yet. In theory this should
   work with |directory_markers = true| but it doesn't.
 - Directories don't seem to appear in recursive (ListR) listings.
 `, "|", "`"),
 			Default:  false,
 			Advanced: true,
+		}, {
+			Name: "directory_bucket_session_auth",
+			Help: strings.ReplaceAll(`Use session auth with AWS Directory Buckets
+
+By default requests to Directory Buckets are authorized with a session
+made with the |CreateSession| API call. The session is cached and
+renewed before it expires, which avoids the IAM authorization cost of
+each request.
+
+Set this to false to sign each request with the credentials directly
+instead. This may be useful with proxies or gateways in front of
+Directory Buckets which don't support |CreateSession|.
+`, "|", "`"),
+			Default:  true,
+			Advanced: true,
 		}, {
 			Name: "sdk_log_mode",
 			Help: strings.ReplaceAll(`Set to debug the SDK
@@ -822,6 +895,45 @@ use |-vv| to see the debug level logs.
 `, "|", "`"),
 			Default:  sdkLogMode(0),
 			Advanced: true,
+		}, {
+			Name: "admin_api",
+			Help: strings.ReplaceAll(`Admin API to use for the |admin-*| backend commands.
+
+Self hosted S3 servers have their own APIs for creating buckets, access
+keys and quotas. Set this to the type of server to provision them with
+the |admin-*| backend commands, for example when setting up test or CI
+environments.
+
+If this is left blank it is set to |seaweedfs| if the provider is
+SeaweedFS.
+`, "|", "`"),
+			Examples: []fs.OptionExample{{
+				Value: "garage",
+				Help:  "Garage admin API (usually on port 3903)",
+			}, {
+				Value: "seaweedfs",
+				Help:  "SeaweedFS IAM API (usually on port 8111)",
+			}},
+			Advanced: true,
+		}, {
+			Name: "admin_endpoint",
+			Help: strings.ReplaceAll(`Endpoint for the admin API.
+
+For example |http://localhost:3903| for Garage or
+|http://localhost:8111| for SeaweedFS.
+`, "|", "`"),
+			Advanced: true,
+		}, {
+			Name: "admin_token",
+			Help: strings.ReplaceAll(`Bearer token for the admin API.
+
+This is the |admin_token| from the Garage config file.
+
+It isn't used with SeaweedFS which signs requests to its IAM API with
+the |access_key_id| and |secret_access_key|.
+`, "|", "`"),
+			Advanced:  true,
+			Sensitive: true,
 		}, {
 			Name: "ibm_api_key",
 			Help: "IBM API Key to be used to obtain IAM token",
@@ -976,6 +1088,7 @@ type Options struct {
 	UseMultipartEtag            fs.Tristate          `config:"use_multipart_etag"`
 	UsePresignedRequest         bool                 `config:"use_presigned_request"`
 	UseDataIntegrityProtections fs.Tristate          `config:"use_data_integrity_protections"`
+	UploadChecksum              string               `config:"upload_checksum"`
 	Versions                    bool                 `config:"versions"`
 	VersionAt                   fs.Time              `config:"version_at"`
 	VersionDeleted              bool                 `config:"version_deleted"`
@@ -985,13 +1098,18 @@ type Options struct {
 	NoSystemMetadata            bool                 `config:"no_system_metadata"`
 	UseAlreadyExists            fs.Tristate          `config:"use_already_exists"`
 	UseMultipartUploads         fs.Tristate          `config:"use_multipart_uploads"`
+	UseDeleteObjects            fs.Tristate          `config:"use_delete_objects"`
 	UseUnsignedPayload          fs.Tristate          `config:"use_unsigned_payload"`
 	SDKLogMode                  sdkLogMode           `config:"sdk_log_mode"`
 	DirectoryBucket             bool                 `config:"directory_bucket"`
+	DirectoryBucketSessionAuth  bool                 `config:"directory_bucket_session_auth"`
 	IBMAPIKey                   string               `config:"ibm_api_key"`
 	IBMInstanceID               string               `config:"ibm_resource_instance_id"`
 	UseXID                      fs.Tristate          `config:"use_x_id"`
 	SignAcceptEncoding          fs.Tristate          `config:"sign_accept_encoding"`
+	AdminAPI                    string               `config:"admin_api"`
+	AdminEndpoint               string               `config:"admin_endpoint"`
+	AdminToken                  string               `config:"admin_token"`
 }
 
 // Fs represents a remote s3 server
@@ -1010,6 +1128,7 @@ type Fs struct {
 	srv            *http.Client    // a plain http client
 	srvRest        *rest.Client    // the rest connection to the server
 	etagIsNotMD5   bool            // if set ETags are not MD5s
+	checksums      hash.Set        // full object checksums which can be read from objects
 	versioningMu   sync.Mutex
 	versioning     fs.Tristate // if set bucket is using versions
 	warnCompressed sync.Once   // warn once about compressed files
@@ -1036,6 +1155,8 @@ type Object struct {
 	contentDisposition *string // Content-Disposition: header
 	contentEncoding    *string // Content-Encoding: header
 	contentLanguage    *string // Content-Language: header
+	crc64nvme          *string // CRC-64/NVME full object checksum in hex
+	sha256             *string // SHA-256 full object checksum in hex
 }
 
 // safely dereference the pointer, returning a zero T if nil
@@ -1139,6 +1260,15 @@ func parsePath(path string) (root string) {
 	return
 }
 
+// directoryBucketSuffix ends the names of all AWS Directory Buckets
+const directoryBucketSuffix = "--x-s3"
+
+// isDirectoryBucketName returns true if bucketName is the name of an
+// AWS Directory Bucket
+func isDirectoryBucketName(bucketName string) bool {
+	return strings.HasSuffix(bucketName, directoryBucketSuffix)
+}
+
 // split returns bucket and bucketPath from the rootRelativePath
 // relative to f.root
 func (f *Fs) split(rootRelativePath string) (bucketName, bucketPath string) {
@@ -1344,8 +1474,9 @@ func s3Connection(ctx context.Context, opt *Options, client *http.Client) (s3Cli
 			}
 		}
 
-		// Create AssumeRole credentials provider
-		awsConfig.Credentials = stscreds.NewAssumeRoleProvider(stsClient, opt.RoleARN, assumeRoleOptions)
+		// Create AssumeRole credentials provider, caching the
+		// credentials until they expire
+		awsConfig.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient, opt.RoleARN, assumeRoleOptions))
 	}
 
 	provider = loadProvider(opt.Provider)
@@ -1394,6 +1525,12 @@ func s3Connection(ctx context.Context, opt *Options, client *http.Client) (s3Cli
 		})
 	}
 
+	if opt.DirectoryBucket {
+		options = append(options, func(s3Opt *s3.Options) {
+			s3Opt.DisableS3ExpressSessionAuth = aws.Bool(!opt.DirectoryBucketSessionAuth)
+		})
+	}
+
 	if opt.V2Auth || opt.Region == "other-v2-signature" {
 		fs.Debugf(nil, "Using v2 auth")
 		if opt.Provider == "IBMCOS" && opt.IBMAPIKey != "" && opt.IBMInstanceID != "" {
@@ -1575,6 +1712,7 @@ func setQuirks(opt *Options, provider *Provider) {
 	if !opt.UseMultipartUploads.Value {
 		opt.UploadCutoff = math.MaxInt64
 	}
+	set(&opt.UseDeleteObjects, true, provider.Quirks.UseDeleteObjects)
 	set(&opt.UseUnsignedPayload, true, provider.Quirks.UseUnsignedPayload)
 	set(&opt.UseXID, true, provider.Quirks.UseXID)
 	set(&opt.SignAcceptEncoding, true, provider.Quirks.SignAcceptEncoding)
@@ -1609,9 +1747,30 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if opt.Versions && opt.VersionAt.IsSet() {
 		return nil, errors.New("s3: can't use --s3-versions and --s3-version-at at the same time")
 	}
+	if !opt.DirectoryBucket && opt.Provider == "AWS" {
+		rootBucket, _ := bucket.Split(parsePath(root))
+		if isDirectoryBucketName(rootBucket) {
+			fs.Debugf(nil, "s3: setting directory_bucket = true for bucket %q", rootBucket)
+			opt.DirectoryBucket = true
+		}
+	}
+	if opt.DirectoryBucket {
+		if opt.Versions || opt.VersionAt.IsSet() {
+			return nil, errors.New("s3: directory buckets don't support versions")
+		}
+		if opt.V2Auth {
+			return nil, errors.New("s3: directory buckets don't support v2_auth")
+		}
+	}
 	if opt.BucketACL == "" {
 		opt.BucketACL = opt.ACL
 	}
+	opt.UploadChecksum = strings.ToLower(opt.UploadChecksum)
+	switch opt.UploadChecksum {
+	case "", "crc64nvme", "sha256":
+	default:
+		return nil, fmt.Errorf("s3: unknown upload_checksum %q: must be crc64nvme or sha256", opt.UploadChecksum)
+	}
 	if opt.SSECustomerKeyBase64 != "" && opt.SSECustomerKey != "" {
 		return nil, errors.New("s3: can't use sse_customer_key and sse_customer_key_base64 at the same time")
 	} else if opt.SSECustomerKeyBase64 != "" {
@@ -1654,6 +1813,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		srv:     srv,
 		srvRest: rest.NewClient(fshttp.NewClient(ctx)),
 	}
+	f.registerCredentials(ctx)
 	if opt.ServerSideEncryption == "aws:kms" || opt.SSECustomerAlgorithm != "" {
 		// From: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTCommonResponseHeaders.html
 		//
@@ -1664,6 +1824,14 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		// MD5 digest of their object data.
 		f.etagIsNotMD5 = true
 	}
+	if opt.Provider == "AWS" || opt.UploadChecksum == "crc64nvme" {
+		// AWS stores a CRC-64/NVME full object checksum with new
+		// objects if the uploader didn't send a different one
+		f.checksums.Add(hash.CRC64NVME)
+	}
+	if opt.UploadChecksum == "sha256" {
+		f.checksums.Add(hash.SHA256)
+	}
 	if opt.DirectoryBucket {
 		// Objects uploaded to directory buckets appear to have random ETags
 		//
@@ -1671,6 +1839,11 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		f.etagIsNotMD5 = true
 		// The normal API doesn't work for creating directory buckets, so don't try
 		f.opt.NoCheckBucket = true
+		// Directory buckets only support ListObjectsV2
+		if f.opt.ListVersion != 2 {
+			fs.Debugf(f, "Using list_version = 2 as it is the only version directory buckets support")
+			f.opt.ListVersion = 2
+		}
 	}
 	f.setRoot(root)
 	f.features = (&fs.Features{
@@ -1684,6 +1857,8 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		SetTier:           provider.StorageClass.Len() > 0,
 		GetTier:           provider.StorageClass.Len() > 0,
 		SlowModTime:       true,
+		FilterAware:       true,
+		ListQuery:         true,
 	}).Fill(ctx, f)
 	if opt.Provider == "AWS" {
 		f.features.DoubleSlash = true
@@ -1699,6 +1874,9 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		fs.Debugf(f, "Disabling multipart uploads")
 		f.features.OpenChunkWriter = nil
 	}
+	if !opt.UseDeleteObjects.Value || opt.VersionAt.IsSet() {
+		f.features.DeleteObjects = nil
+	}
 
 	if f.rootBucket != "" && f.rootDirectory != "" && !opt.NoHeadObject && !strings.HasSuffix(root, "/") {
 		// Check to see if the (bucket,directory) is actually an existing file
@@ -1844,6 +2022,45 @@ func (f *Fs) getBucketLocation(ctx context.Context, bucket string) (string, erro
 	return region, nil
 }
 
+// credentialsCache returns the cache of temporary credentials, like
+// those from STS or env_auth, or nil if the credentials are static.
+func (f *Fs) credentialsCache() *aws.CredentialsCache {
+	cache, _ := f.c.Options().Credentials.(*aws.CredentialsCache)
+	return cache
+}
+
+// registerCredentials registers temporary credentials so they are
+// refreshed before they expire.
+func (f *Fs) registerCredentials(ctx context.Context) {
+	if f.credentialsCache() == nil {
+		return
+	}
+	credential.Register(ctx, credential.Source{
+		Remote: f.name,
+		Kind:   credential.KindSTS,
+		Expiry: func(ctx context.Context) (time.Time, error) {
+			cache := f.credentialsCache()
+			if cache == nil {
+				return time.Time{}, nil
+			}
+			creds, err := cache.Retrieve(ctx)
+			if err != nil || !creds.CanExpire {
+				return time.Time{}, err
+			}
+			return creds.Expires, nil
+		},
+		Refresh: func(ctx context.Context) error {
+			cache := f.credentialsCache()
+			if cache == nil {
+				return nil
+			}
+			cache.Invalidate()
+			_, err := cache.Retrieve(ctx)
+			return err
+		},
+	})
+}
+
 // Updates the region for the bucket by reading the region from the
 // bucket then updating the session.
 func (f *Fs) updateRegionForBucket(ctx context.Context, bucket string) error {
@@ -1891,6 +2108,7 @@ func (f *Fs) newV1List(req *s3.ListObjectsV2Input) bucketLister {
 	// Convert v2 req into v1 req
 	//structs.SetFrom(&l.req, req)
 	setFrom_s3ListObjectsInput_s3ListObjectsV2Input(&l.req, req)
+	l.req.Marker = req.StartAfter
 	return l
 }
 
@@ -1994,6 +2212,7 @@ func (f *Fs) newVersionsList(req *s3.ListObjectsV2Input, hidden bool, versionAt
 	// Convert v2 req into withVersions req
 	//structs.SetFrom(&l.req, req)
 	setFrom_s3ListObjectVersionsInput_s3ListObjectsV2Input(&l.req, req)
+	l.req.KeyMarker = req.StartAfter
 	return l
 }
 
@@ -2156,6 +2375,8 @@ type listOpt struct {
 	versionAt     fs.Time // if set only show versions <= this time
 	noSkipMarkers bool    // if set return dir marker objects
 	restoreStatus bool    // if set return restore status in listing too
+	namePrefix    string  // if set only list names in directory starting with this
+	startAfter    string  // if set only list names in directory after this
 }
 
 // list lists the objects into the function supplied with the opt
@@ -2189,12 +2410,17 @@ func (f *Fs) list(ctx context.Context, opt listOpt, fn listFn) error {
 	// So we enable only on providers we know supports it properly, all others can retry when a
 	// XML Syntax error is detected.
 	urlEncodeListings := f.opt.ListURLEncode.Value
+	listPrefix := opt.directory + opt.namePrefix
 	req := s3.ListObjectsV2Input{
 		Bucket:    &opt.bucket,
 		Delimiter: &delimiter,
-		Prefix:    &opt.directory,
+		Prefix:    &listPrefix,
 		MaxKeys:   &f.opt.ListChunk,
 	}
+	if opt.startAfter != "" {
+		startAfter := opt.directory + opt.startAfter
+		req.StartAfter = &startAfter
+	}
 	if opt.restoreStatus {
 		req.OptionalObjectAttributes = []types.OptionalObjectAttributes{types.OptionalObjectAttributesRestoreStatus}
 	}
@@ -2333,7 +2559,7 @@ func (f *Fs) list(ctx context.Context, opt listOpt, fn listFn) error {
 			break
 		}
 	}
-	if f.opt.DirectoryMarkers && foundItems == 0 && opt.directory != "" {
+	if f.opt.DirectoryMarkers && foundItems == 0 && opt.directory != "" && opt.namePrefix == "" && opt.startAfter == "" {
 		// Determine whether the directory exists or not by whether it has a marker
 		req := s3.HeadObjectInput{
 			Bucket: &opt.bucket,
@@ -2368,7 +2594,7 @@ func (f *Fs) itemToDirEntry(ctx context.Context, remote string, object *types.Ob
 }
 
 // listDir lists files and directories to out
-func (f *Fs) listDir(ctx context.Context, bucket, directory, prefix string, addBucket bool, callback func(fs.DirEntry) error) (err error) {
+func (f *Fs) listDir(ctx context.Context, bucket, directory, prefix, namePrefix, startAfter string, addBucket bool, callback func(fs.DirEntry) error) (err error) {
 	// List the objects and directories
 	err = f.list(ctx, listOpt{
 		bucket:       bucket,
@@ -2378,6 +2604,8 @@ func (f *Fs) listDir(ctx context.Context, bucket, directory, prefix string, addB
 		withVersions: f.opt.Versions,
 		versionAt:    f.opt.VersionAt,
 		hidden:       f.opt.VersionDeleted,
+		namePrefix:   namePrefix,
+		startAfter:   startAfter,
 	}, func(remote string, object *types.Object, versionID *string, isDirectory bool) error {
 		entry, err := f.itemToDirEntry(ctx, remote, object, versionID, isDirectory)
 		if err != nil {
@@ -2398,6 +2626,9 @@ func (f *Fs) listDir(ctx context.Context, bucket, directory, prefix string, addB
 
 // listBuckets lists the buckets to out
 func (f *Fs) listBuckets(ctx context.Context) (entries fs.DirEntries, err error) {
+	if f.opt.DirectoryBucket {
+		return f.listDirectoryBuckets(ctx)
+	}
 	req := s3.ListBucketsInput{}
 	var resp *s3.ListBucketsOutput
 	err = f.pacer.Call(func() (bool, error) {
@@ -2416,6 +2647,35 @@ func (f *Fs) listBuckets(ctx context.Context) (entries fs.DirEntries, err error)
 	return entries, nil
 }
 
+// listDirectoryBuckets lists the directory buckets to out
+//
+// Directory buckets aren't returned by ListBuckets so need a
+// different API call.
+func (f *Fs) listDirectoryBuckets(ctx context.Context) (entries fs.DirEntries, err error) {
+	req := s3.ListDirectoryBucketsInput{}
+	for {
+		var resp *s3.ListDirectoryBucketsOutput
+		err = f.pacer.Call(func() (bool, error) {
+			resp, err = f.c.ListDirectoryBuckets(ctx, &req)
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, bucket := range resp.Buckets {
+			bucketName := f.opt.Enc.ToStandardName(deref(bucket.Name))
+			f.cache.MarkOK(bucketName)
+			d := fs.NewDir(bucketName, deref(bucket.CreationDate))
+			entries = append(entries, d)
+		}
+		if deref(resp.ContinuationToken) == "" {
+			break
+		}
+		req.ContinuationToken = resp.ContinuationToken
+	}
+	return entries, nil
+}
+
 // List the objects and directories in dir into entries.  The
 // entries can be returned in any order but should be for a
 // complete directory.
@@ -2460,7 +2720,11 @@ func (f *Fs) ListP(ctx context.Context, dir string, callback fs.ListRCallback) e
 			}
 		}
 	} else {
-		err := f.listDir(ctx, bucket, directory, f.rootDirectory, f.rootBucket == "", list.Add)
+		namePrefix, startAfter, err := f.listConstraints(ctx, dir, false)
+		if err != nil {
+			return err
+		}
+		err = f.listDir(ctx, bucket, directory, f.rootDirectory, namePrefix, startAfter, f.rootBucket == "", list.Add)
 		if err != nil {
 			return err
 		}
@@ -2487,7 +2751,11 @@ func (f *Fs) ListP(ctx context.Context, dir string, callback fs.ListRCallback) e
 func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (err error) {
 	bucket, directory := f.split(dir)
 	list := list.NewHelper(callback)
-	listR := func(bucket, directory, prefix string, addBucket bool) error {
+	listR := func(dir, bucket, directory, prefix string, addBucket bool) error {
+		namePrefix, startAfter, err := f.listConstraints(ctx, dir, true)
+		if err != nil {
+			return err
+		}
 		return f.list(ctx, listOpt{
 			bucket:       bucket,
 			directory:    directory,
@@ -2497,6 +2765,8 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 			withVersions: f.opt.Versions,
 			versionAt:    f.opt.VersionAt,
 			hidden:       f.opt.VersionDeleted,
+			namePrefix:   namePrefix,
+			startAfter:   startAfter,
 		}, func(remote string, object *types.Object, versionID *string, isDirectory bool) error {
 			entry, err := f.itemToDirEntry(ctx, remote, object, versionID, isDirectory)
 			if err != nil {
@@ -2516,7 +2786,7 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 				return err
 			}
 			bucket := entry.Remote()
-			err = listR(bucket, "", f.rootDirectory, true)
+			err = listR(bucket, bucket, "", f.rootDirectory, true)
 			if err != nil {
 				return err
 			}
@@ -2524,7 +2794,7 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 			f.cache.MarkOK(bucket)
 		}
 	} else {
-		err = listR(bucket, directory, f.rootDirectory, f.rootBucket == "")
+		err = listR(dir, bucket, directory, f.rootDirectory, f.rootBucket == "")
 		if err != nil {
 			return err
 		}
@@ -2955,7 +3225,7 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 
 // Hashes returns the supported hash sets.
 func (f *Fs) Hashes() hash.Set {
-	return hash.Set(hash.MD5)
+	return hash.Set(hash.MD5) | f.checksums
 }
 
 // PublicLink generates a public link to the remote path (usually readable by anyone)
@@ -3199,6 +3469,100 @@ the new parameters. Only new parameters need be passed as the values
 will default to those currently in use.
 
 It doesn't return anything.`,
+}, {
+	Name:  "admin-create-key",
+	Short: "Create an access key with the admin API.",
+	Long: `This command creates an access key with the admin API of a self hosted
+S3 server set with --s3-admin-api and returns it.
+
+Usage example:
+
+` + "```console" + `
+rclone backend admin-create-key s3: ci-key
+` + "```" + `
+
+It returns the access key ID and the secret access key, which are only
+shown once, like this:
+
+` + "```json" + `
+{
+    "name": "ci-key",
+    "accessKeyId": "GK31c2f218a2e44f485b94239e",
+    "secretAccessKey": "b892c0665f0ada8a4755dae98baa3b133590e11dae3bcc1f9d769d67f16c3835"
+}
+` + "```" + `
+
+With SeaweedFS the name is used as the user name for the key.`,
+}, {
+	Name:  "admin-create-bucket",
+	Short: "Create a bucket with the admin API.",
+	Long: `This command creates the bucket with the admin API of a self hosted
+S3 server set with --s3-admin-api. It can also give an access key
+access to the bucket and set quotas on it.
+
+Usage examples:
+
+` + "```console" + `
+rclone backend admin-create-bucket s3:bucket
+rclone backend admin-create-bucket s3:bucket -o key=GK31c2f218a2e44f485b94239e
+rclone backend admin-create-bucket s3:bucket -o key=GK31c2f218a2e44f485b94239e -o read -o max-size=10G
+` + "```" + `
+
+If none of the read, write or owner options are given the key gets
+read and write access. See the admin-set-quota command for the quota
+options.`,
+	Opts: map[string]string{
+		"key":         "Access key ID to give access to the bucket.",
+		"read":        "Give the key read access.",
+		"write":       "Give the key write access.",
+		"owner":       "Give the key owner access.",
+		"max-size":    "Maximum size of the bucket.",
+		"max-objects": "Maximum number of objects in the bucket.",
+	},
+}, {
+	Name:  "admin-allow-key",
+	Short: "Give an access key access to a bucket with the admin API.",
+	Long: `This command gives an access key access to the bucket with the admin
+API of a self hosted S3 server set with --s3-admin-api.
+
+Usage examples:
+
+` + "```console" + `
+rclone backend admin-allow-key s3:bucket -o key=GK31c2f218a2e44f485b94239e
+rclone backend admin-allow-key s3:bucket -o key=GK31c2f218a2e44f485b94239e -o read
+` + "```" + `
+
+If none of the read, write or owner options are given the key gets
+read and write access.
+
+Note that with SeaweedFS this replaces the permissions of the user
+which owns the key.`,
+	Opts: map[string]string{
+		"key":   "Access key ID to give access to the bucket.",
+		"read":  "Give the key read access.",
+		"write": "Give the key write access.",
+		"owner": "Give the key owner access.",
+	},
+}, {
+	Name:  "admin-set-quota",
+	Short: "Set the quotas of a bucket with the admin API.",
+	Long: `This command sets the quotas of the bucket with the admin API of a self
+hosted S3 server set with --s3-admin-api.
+
+Usage examples:
+
+` + "```console" + `
+rclone backend admin-set-quota s3:bucket -o max-size=10G -o max-objects=100000
+rclone backend admin-set-quota s3:bucket -o max-size=off -o max-objects=off
+` + "```" + `
+
+Any quota which isn't given, or is set to "off", is removed.
+
+This is only supported by Garage.`,
+	Opts: map[string]string{
+		"max-size":    "Maximum size of the bucket or \"off\".",
+		"max-objects": "Maximum number of objects in the bucket or \"off\".",
+	},
 }}
 
 // Command the backend to run a named command
@@ -3318,6 +3682,8 @@ func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[str
 		}
 		fs.Logf(f, "Updated config values: %s", strings.Join(keys, ", "))
 		return nil, nil
+	case "admin-create-key", "admin-create-bucket", "admin-allow-key", "admin-set-quota":
+		return f.adminCommand(ctx, name, arg, opt)
 	default:
 		return nil, fs.ErrorCommandNotFound
 	}
@@ -3563,6 +3929,9 @@ func (f *Fs) purge(ctx context.Context, dir string, oldOnly bool) error {
 	if bucket == "" {
 		return errors.New("can't purge from root")
 	}
+	// Delete the versions for real whatever --use-trash and
+	// --delete-mode are set to
+	ctx = operations.WithoutTrash(ctx)
 	versioned := f.isVersioned(ctx)
 	if !versioned && oldOnly {
 		fs.Infof(f, "bucket is not versioned so not removing old versions")
@@ -3651,6 +4020,170 @@ func (f *Fs) CleanUpHidden(ctx context.Context) error {
 	return f.purge(ctx, "", true)
 }
 
+// ListDeleted calls fn for each file in dir whose current version is
+// a delete marker and which has a previous version to restore.
+func (f *Fs) ListDeleted(ctx context.Context, dir string, fn func(fs.DeletedObject) error) error {
+	bucket, directory := f.split(dir)
+	if bucket == "" {
+		return fs.ErrorListBucketRequired
+	}
+	var (
+		last    = ""
+		pending *fs.DeletedObject // delete marker waiting for the version it hides
+	)
+	return f.list(ctx, listOpt{
+		bucket:       bucket,
+		directory:    directory,
+		prefix:       f.rootDirectory,
+		addBucket:    f.rootBucket == "",
+		recurse:      true,
+		withVersions: true,
+		hidden:       true,
+	}, func(remote string, object *types.Object, versionID *string, isDirectory bool) error {
+		if isDirectory {
+			return nil
+		}
+		// Versions of each file are listed together, newest
+		// first, and all but the current one have a version
+		// suffix added to their names
+		_, name := version.Remove(remote)
+		if name != last {
+			last = remote
+			pending = nil
+			if object.Size == isDeleteMarker {
+				last = name
+				pending = &fs.DeletedObject{
+					Remote:    name,
+					DeletedAt: deref(object.LastModified),
+					ID:        deref(versionID),
+				}
+			}
+			return nil
+		}
+		if pending == nil || object.Size == isDeleteMarker {
+			return nil
+		}
+		deleted := *pending
+		pending = nil
+		deleted.Size = deref(object.Size)
+		deleted.ModTime = deref(object.LastModified)
+		return fn(deleted)
+	})
+}
+
+// Undelete restores a file by removing the delete marker which hides
+// it.
+func (f *Fs) Undelete(ctx context.Context, deleted fs.DeletedObject) error {
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
+	if _, err := f.NewObject(ctx, deleted.Remote); err == nil {
+		return fs.ErrorCantUndelete
+	}
+	bucket, bucketPath := f.split(deleted.Remote)
+	req := s3.DeleteObjectInput{
+		Bucket:    &bucket,
+		Key:       &bucketPath,
+		VersionId: &deleted.ID,
+	}
+	if f.opt.RequesterPays {
+		req.RequestPayer = types.RequestPayerRequester
+	}
+	return f.pacer.Call(func() (bool, error) {
+		_, err := f.c.DeleteObject(ctx, &req)
+		return f.shouldRetry(ctx, err)
+	})
+}
+
+// SoftDelete adds a delete marker to o so it can be restored with
+// Undelete. The bucket must be versioned.
+func (f *Fs) SoftDelete(ctx context.Context, o fs.Object) error {
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
+	if obj, ok := o.(*Object); ok && obj.versionID != nil {
+		return fmt.Errorf("can't soft delete old version %q", o.Remote())
+	}
+	if !f.isVersioned(ctx) {
+		return fserrors.FatalError(fmt.Errorf("can't add delete markers to %v as versioning isn't enabled on the bucket", f))
+	}
+	bucket, bucketPath := f.split(o.Remote())
+	req := s3.DeleteObjectInput{
+		Bucket: &bucket,
+		Key:    &bucketPath,
+	}
+	if f.opt.RequesterPays {
+		req.RequestPayer = types.RequestPayerRequester
+	}
+	return f.pacer.Call(func() (bool, error) {
+		_, err := f.c.DeleteObject(ctx, &req)
+		return f.shouldRetry(ctx, err)
+	})
+}
+
+// PurgeDeleted deletes the delete marker and all the versions of a
+// deleted file.
+func (f *Fs) PurgeDeleted(ctx context.Context, deleted fs.DeletedObject) error {
+	if f.opt.VersionAt.IsSet() {
+		return errNotWithVersionAt
+	}
+	if _, err := f.NewObject(ctx, deleted.Remote); err == nil {
+		return fmt.Errorf("not purging %q as it has been restored", deleted.Remote)
+	}
+	bucket, bucketPath := f.split(deleted.Remote)
+	var versionIDs []*string
+	req := s3.ListObjectVersionsInput{
+		Bucket: &bucket,
+		Prefix: &bucketPath,
+	}
+	if f.opt.RequesterPays {
+		req.RequestPayer = types.RequestPayerRequester
+	}
+	for {
+		var resp *s3.ListObjectVersionsOutput
+		err := f.pacer.Call(func() (bool, error) {
+			var err error
+			resp, err = f.c.ListObjectVersions(ctx, &req)
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list versions of %q: %w", deleted.Remote, err)
+		}
+		for _, v := range resp.Versions {
+			if deref(v.Key) == bucketPath {
+				versionIDs = append(versionIDs, v.VersionId)
+			}
+		}
+		for _, m := range resp.DeleteMarkers {
+			if deref(m.Key) == bucketPath {
+				versionIDs = append(versionIDs, m.VersionId)
+			}
+		}
+		if !deref(resp.IsTruncated) {
+			break
+		}
+		req.KeyMarker, req.VersionIdMarker = resp.NextKeyMarker, resp.NextVersionIdMarker
+	}
+	for _, versionID := range versionIDs {
+		req := s3.DeleteObjectInput{
+			Bucket:    &bucket,
+			Key:       &bucketPath,
+			VersionId: versionID,
+		}
+		if f.opt.RequesterPays {
+			req.RequestPayer = types.RequestPayerRequester
+		}
+		err := f.pacer.Call(func() (bool, error) {
+			_, err := f.c.DeleteObject(ctx, &req)
+			return f.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete version %q of %q: %w", deref(versionID), deleted.Remote, err)
+		}
+	}
+	return nil
+}
+
 // ------------------------------------------------------------
 
 // Fs returns the parent Fs
@@ -3695,7 +4228,7 @@ func (o *Object) setMD5FromEtag(etag string) {
 // Hash returns the Md5sum of an object returning a lowercase hex string
 func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
 	if t != hash.MD5 {
-		return "", hash.ErrUnsupported
+		return o.checksum(ctx, t)
 	}
 	// If decompressing, erase the hash
 	if o.bytes < 0 {
@@ -3711,6 +4244,45 @@ func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
 	return o.md5, nil
 }
 
+// checksum returns the full object checksum of type t
+//
+// These aren't returned in listings so need a HEAD to read
+func (o *Object) checksum(ctx context.Context, t hash.Type) (string, error) {
+	if !o.fs.checksums.Contains(t) {
+		return "", hash.ErrUnsupported
+	}
+	// If decompressing, erase the hash
+	if o.bytes < 0 {
+		return "", nil
+	}
+	err := o.readMetaData(ctx)
+	if err != nil {
+		return "", err
+	}
+	switch t {
+	case hash.CRC64NVME:
+		return deref(o.crc64nvme), nil
+	case hash.SHA256:
+		return deref(o.sha256), nil
+	}
+	return "", nil
+}
+
+// checksumToHex converts a base64 encoded full object checksum from
+// S3 into hex, returning nil if it isn't valid
+func checksumToHex(checksumBase64 *string, checksumType types.ChecksumType, size int) *string {
+	// Composite checksums are checksums of the part checksums
+	if checksumBase64 == nil || checksumType == types.ChecksumTypeComposite {
+		return nil
+	}
+	checksum, err := base64.StdEncoding.DecodeString(*checksumBase64)
+	if err != nil || len(checksum) != size {
+		return nil
+	}
+	checksumHex := hex.EncodeToString(checksum)
+	return &checksumHex
+}
+
 // Size returns the size of an object in bytes
 func (o *Object) Size() int64 {
 	return o.bytes
@@ -3723,6 +4295,9 @@ func (o *Object) headObject(ctx context.Context) (resp *s3.HeadObjectOutput, err
 		Key:       &bucketPath,
 		VersionId: o.versionID,
 	}
+	if o.fs.checksums.Count() > 0 {
+		req.ChecksumMode = types.ChecksumModeEnabled
+	}
 	return o.fs.headObject(ctx, &req)
 }
 
@@ -3828,6 +4403,8 @@ func (o *Object) setMetaData(resp *s3.HeadObjectOutput) {
 	o.contentDisposition = stringClonePointer(resp.ContentDisposition)
 	o.contentEncoding = stringClonePointer(removeAWSChunked(resp.ContentEncoding))
 	o.contentLanguage = stringClonePointer(resp.ContentLanguage)
+	o.crc64nvme = checksumToHex(resp.ChecksumCRC64NVME, resp.ChecksumType, crc64nvme.Size)
+	o.sha256 = checksumToHex(resp.ChecksumSHA256, resp.ChecksumType, sha256.Size)
 
 	// If decompressing then size and md5sum are unknown
 	if o.fs.opt.Decompress && deref(o.contentEncoding) == "gzip" {
@@ -4125,6 +4702,8 @@ type s3ChunkWriter struct {
 	versionID            string
 	md5sMu               sync.Mutex
 	md5s                 []byte
+	crcs                 []uint64 // CRC-64/NVME of each part if using crc64nvme
+	crcSizes             []int64  // size of each part in crcs
 	ui                   uploadInfo
 	o                    *Object
 }
@@ -4134,6 +4713,35 @@ type s3ChunkWriter struct {
 // Pass in the remote and the src object
 // You can also use options to hint at the desired chunk size
 func (f *Fs) OpenChunkWriter(ctx context.Context, remote string, src fs.ObjectInfo, options ...fs.OpenOption) (info fs.ChunkWriterInfo, writer fs.ChunkWriter, err error) {
+	info, chunkWriter, err := f.newChunkWriter(ctx, remote, src, options...)
+	if err != nil {
+		return info, nil, err
+	}
+
+	var mOut *s3.CreateMultipartUploadOutput
+	err = f.pacer.Call(func() (bool, error) {
+		mOut, err = f.c.CreateMultipartUpload(ctx, chunkWriter.multiPartUploadInput)
+		if err == nil {
+			if mOut == nil {
+				err = fserrors.RetryErrorf("internal error: no info from multipart upload")
+			} else if mOut.UploadId == nil {
+				err = fserrors.RetryErrorf("internal error: no UploadId in multipart upload: %#v", *mOut)
+			}
+		}
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return info, nil, fmt.Errorf("create multipart upload failed: %w", err)
+	}
+	chunkWriter.uploadID = mOut.UploadId
+
+	fs.Debugf(chunkWriter.o, "open chunk writer: started multipart upload: %v", *mOut.UploadId)
+	return info, chunkWriter, err
+}
+
+// newChunkWriter makes a ChunkWriter for remote without starting the
+// multipart upload
+func (f *Fs) newChunkWriter(ctx context.Context, remote string, src fs.ObjectInfo, options ...fs.OpenOption) (info fs.ChunkWriterInfo, chunkWriter *s3ChunkWriter, err error) {
 	// Temporary Object under construction
 	o := &Object{
 		fs:     f,
@@ -4147,6 +4755,10 @@ func (f *Fs) OpenChunkWriter(ctx context.Context, remote string, src fs.ObjectIn
 	//structs.SetFrom(&mReq, req)
 	var mReq s3.CreateMultipartUploadInput
 	setFrom_s3CreateMultipartUploadInput_s3PutObjectInput(&mReq, ui.req)
+	if mReq.ChecksumAlgorithm == types.ChecksumAlgorithmCrc64nvme {
+		// Work out a checksum of the whole object from the parts
+		mReq.ChecksumType = types.ChecksumTypeFullObject
+	}
 
 	uploadParts := f.opt.MaxUploadParts
 	if uploadParts < 1 {
@@ -4171,29 +4783,12 @@ func (f *Fs) OpenChunkWriter(ctx context.Context, remote string, src fs.ObjectIn
 		chunkSize = chunksize.Calculator(src, size, uploadParts, chunkSize)
 	}
 
-	var mOut *s3.CreateMultipartUploadOutput
-	err = f.pacer.Call(func() (bool, error) {
-		mOut, err = f.c.CreateMultipartUpload(ctx, &mReq)
-		if err == nil {
-			if mOut == nil {
-				err = fserrors.RetryErrorf("internal error: no info from multipart upload")
-			} else if mOut.UploadId == nil {
-				err = fserrors.RetryErrorf("internal error: no UploadId in multipart upload: %#v", *mOut)
-			}
-		}
-		return f.shouldRetry(ctx, err)
-	})
-	if err != nil {
-		return info, nil, fmt.Errorf("create multipart upload failed: %w", err)
-	}
-
-	chunkWriter := &s3ChunkWriter{
+	chunkWriter = &s3ChunkWriter{
 		chunkSize:            int64(chunkSize),
 		size:                 size,
 		f:                    f,
 		bucket:               ui.req.Bucket,
 		key:                  ui.req.Key,
-		uploadID:             mOut.UploadId,
 		multiPartUploadInput: &mReq,
 		completedParts:       make([]types.CompletedPart, 0),
 		ui:                   ui,
@@ -4204,20 +4799,147 @@ func (f *Fs) OpenChunkWriter(ctx context.Context, remote string, src fs.ObjectIn
 		Concurrency:       o.fs.opt.UploadConcurrency,
 		LeavePartsOnError: o.fs.opt.LeavePartsOnError,
 	}
-	fs.Debugf(o, "open chunk writer: started multipart upload: %v", *mOut.UploadId)
-	return info, chunkWriter, err
+	return info, chunkWriter, nil
+}
+
+// s3ResumeState is the state of a multipart upload returned by
+// ResumeState
+type s3ResumeState struct {
+	UploadID  string         `json:"uploadId"`
+	ChunkSize int64          `json:"chunkSize"`
+	Parts     []s3ResumePart `json:"parts"`
+}
+
+// s3ResumePart is a part of a multipart upload which has been written
+type s3ResumePart struct {
+	PartNumber        int32   `json:"partNumber"`
+	ETag              *string `json:"etag"`
+	ChecksumCRC64NVME *string `json:"crc64nvme,omitempty"`
+	ChecksumSHA256    *string `json:"sha256,omitempty"`
+	MD5               []byte  `json:"md5"`
+	CRC               uint64  `json:"crc,omitempty"`
+	Size              int64   `json:"size,omitempty"`
+}
+
+// ResumeState returns the state needed to carry on with the upload
+func (w *s3ChunkWriter) ResumeState() ([]byte, error) {
+	w.completedPartsMu.Lock()
+	defer w.completedPartsMu.Unlock()
+	w.md5sMu.Lock()
+	defer w.md5sMu.Unlock()
+	state := s3ResumeState{
+		UploadID:  *w.uploadID,
+		ChunkSize: w.chunkSize,
+		Parts:     make([]s3ResumePart, 0, len(w.completedParts)),
+	}
+	for _, cp := range w.completedParts {
+		chunkNumber := int(*cp.PartNumber) - 1
+		part := s3ResumePart{
+			PartNumber:        *cp.PartNumber,
+			ETag:              cp.ETag,
+			ChecksumCRC64NVME: cp.ChecksumCRC64NVME,
+			ChecksumSHA256:    cp.ChecksumSHA256,
+		}
+		if end := (chunkNumber + 1) * md5.Size; end <= len(w.md5s) {
+			part.MD5 = w.md5s[end-md5.Size : end]
+		}
+		if chunkNumber < len(w.crcs) {
+			part.CRC = w.crcs[chunkNumber]
+			part.Size = w.crcSizes[chunkNumber]
+		}
+		state.Parts = append(state.Parts, part)
+	}
+	return json.Marshal(&state)
+}
+
+// ResumeChunkWriter carries on with a multipart upload from the state
+// returned by ResumeState
+func (f *Fs) ResumeChunkWriter(ctx context.Context, remote string, src fs.ObjectInfo, stateJSON []byte, options ...fs.OpenOption) (info fs.ChunkWriterInfo, writer fs.ChunkWriter, err error) {
+	var state s3ResumeState
+	err = json.Unmarshal(stateJSON, &state)
+	if err != nil {
+		return info, nil, fmt.Errorf("failed to read resume state: %w", err)
+	}
+	info, chunkWriter, err := f.newChunkWriter(ctx, remote, src, options...)
+	if err != nil {
+		return info, nil, err
+	}
+	chunkWriter.uploadID = aws.String(state.UploadID)
+	chunkWriter.chunkSize = state.ChunkSize
+	info.ChunkSize = state.ChunkSize
+
+	// Check the upload still exists
+	err = f.pacer.Call(func() (bool, error) {
+		_, err = f.c.ListParts(ctx, &s3.ListPartsInput{
+			Bucket:               chunkWriter.bucket,
+			Key:                  chunkWriter.key,
+			UploadId:             chunkWriter.uploadID,
+			MaxParts:             aws.Int32(1),
+			RequestPayer:         chunkWriter.multiPartUploadInput.RequestPayer,
+			SSECustomerAlgorithm: chunkWriter.multiPartUploadInput.SSECustomerAlgorithm,
+			SSECustomerKey:       chunkWriter.multiPartUploadInput.SSECustomerKey,
+			SSECustomerKeyMD5:    chunkWriter.multiPartUploadInput.SSECustomerKeyMD5,
+		})
+		return f.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return info, nil, fmt.Errorf("multipart upload %q can't be resumed: %w", state.UploadID, err)
+	}
+
+	for _, part := range state.Parts {
+		chunkNumber := int(part.PartNumber) - 1
+		chunkWriter.completedParts = append(chunkWriter.completedParts, types.CompletedPart{
+			PartNumber:        aws.Int32(part.PartNumber),
+			ETag:              part.ETag,
+			ChecksumCRC64NVME: part.ChecksumCRC64NVME,
+			ChecksumSHA256:    part.ChecksumSHA256,
+		})
+		if len(part.MD5) == md5.Size {
+			chunkWriter.addMd5(&part.MD5, int64(chunkNumber))
+		}
+		if part.Size > 0 {
+			chunkWriter.addCRC(part.CRC, part.Size, chunkNumber)
+		}
+	}
+	fs.Debugf(chunkWriter.o, "resume chunk writer: carrying on with multipart upload %v with %d parts", state.UploadID, len(state.Parts))
+	return info, chunkWriter, nil
 }
 
-// add a part number and etag to the completed parts
-func (w *s3ChunkWriter) addCompletedPart(partNum *int32, eTag *string) {
+// add a part number, etag and checksums to the completed parts
+func (w *s3ChunkWriter) addCompletedPart(req *s3.UploadPartInput, eTag *string) {
 	w.completedPartsMu.Lock()
 	defer w.completedPartsMu.Unlock()
 	w.completedParts = append(w.completedParts, types.CompletedPart{
-		PartNumber: partNum,
-		ETag:       eTag,
+		PartNumber:        req.PartNumber,
+		ETag:              eTag,
+		ChecksumCRC64NVME: req.ChecksumCRC64NVME,
+		ChecksumSHA256:    req.ChecksumSHA256,
 	})
 }
 
+// addCRC adds the CRC-64/NVME of a part so the full object checksum
+// can be worked out when the upload is finished
+func (w *s3ChunkWriter) addCRC(crc uint64, size int64, chunkNumber int) {
+	w.md5sMu.Lock()
+	defer w.md5sMu.Unlock()
+	if extend := chunkNumber + 1 - len(w.crcs); extend > 0 {
+		w.crcs = append(w.crcs, make([]uint64, extend)...)
+		w.crcSizes = append(w.crcSizes, make([]int64, extend)...)
+	}
+	w.crcs[chunkNumber] = crc
+	w.crcSizes[chunkNumber] = size
+}
+
+// fullObjectCRC returns the base64 encoded CRC-64/NVME of the whole
+// object made by combining the CRCs of the parts in order
+func (w *s3ChunkWriter) fullObjectCRC() *string {
+	var crc uint64
+	for i := range w.crcs {
+		crc = crc64nvme.Combine(crc, w.crcs[i], w.crcSizes[i])
+	}
+	return aws.String(base64.StdEncoding.EncodeToString(binary.BigEndian.AppendUint64(nil, crc)))
+}
+
 // addMd5 adds a binary md5 to the md5 calculated so far
 func (w *s3ChunkWriter) addMd5(md5binary *[]byte, chunkNumber int64) {
 	w.md5sMu.Lock()
@@ -4253,7 +4975,17 @@ func (w *s3ChunkWriter) WriteChunk(ctx context.Context, chunkNumber int, reader
 	// currently there is no way to calculate the md5 without reading the chunk a 2nd time (1st read is in uploadMultipart)
 	// possible in AWS SDK v2 with trailers?
 	m := md5.New()
-	currentChunkSize, err := io.Copy(m, reader)
+	// and the checksum of the part if required
+	algorithm := w.multiPartUploadInput.ChecksumAlgorithm
+	crc, sha := crc64nvme.New(), sha256.New()
+	var out io.Writer = m
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc64nvme:
+		out = io.MultiWriter(m, crc)
+	case types.ChecksumAlgorithmSha256:
+		out = io.MultiWriter(m, sha)
+	}
+	currentChunkSize, err := io.Copy(out, reader)
 	if err != nil {
 		return -1, err
 	}
@@ -4284,6 +5016,15 @@ func (w *s3ChunkWriter) WriteChunk(ctx context.Context, chunkNumber int, reader
 		// Directory buckets do not support "Content-Md5" header
 		uploadPartReq.ContentMD5 = nil
 	}
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc64nvme:
+		uploadPartReq.ChecksumAlgorithm = algorithm
+		uploadPartReq.ChecksumCRC64NVME = aws.String(base64.StdEncoding.EncodeToString(crc.Sum(nil)))
+		w.addCRC(crc.Sum64(), currentChunkSize, chunkNumber)
+	case types.ChecksumAlgorithmSha256:
+		uploadPartReq.ChecksumAlgorithm = algorithm
+		uploadPartReq.ChecksumSHA256 = aws.String(base64.StdEncoding.EncodeToString(sha.Sum(nil)))
+	}
 	var uout *s3.UploadPartOutput
 	err = w.f.pacer.Call(func() (bool, error) {
 		// rewind the reader on retry and after reading md5
@@ -4308,7 +5049,7 @@ func (w *s3ChunkWriter) WriteChunk(ctx context.Context, chunkNumber int, reader
 		return -1, fmt.Errorf("failed to upload chunk %d with %v bytes: %w", chunkNumber+1, currentChunkSize, err)
 	}
 
-	w.addCompletedPart(s3PartNumber, uout.ETag)
+	w.addCompletedPart(uploadPartReq, uout.ETag)
 
 	fs.Debugf(w.o, "multipart upload wrote chunk %d with %v bytes and etag %v", chunkNumber+1, currentChunkSize, *uout.ETag)
 	return currentChunkSize, err
@@ -4338,22 +5079,28 @@ func (w *s3ChunkWriter) Close(ctx context.Context) (err error) {
 	sort.Slice(w.completedParts, func(i, j int) bool {
 		return *w.completedParts[i].PartNumber < *w.completedParts[j].PartNumber
 	})
+	req := &s3.CompleteMultipartUploadInput{
+		Bucket: w.bucket,
+		Key:    w.key,
+		MultipartUpload: &types.CompletedMultipartUpload{
+			Parts: w.completedParts,
+		},
+		RequestPayer:         w.multiPartUploadInput.RequestPayer,
+		SSECustomerAlgorithm: w.multiPartUploadInput.SSECustomerAlgorithm,
+		SSECustomerKey:       w.multiPartUploadInput.SSECustomerKey,
+		SSECustomerKeyMD5:    w.multiPartUploadInput.SSECustomerKeyMD5,
+		UploadId:             w.uploadID,
+		IfMatch:              w.ui.req.IfMatch,
+		IfNoneMatch:          w.ui.req.IfNoneMatch,
+	}
+	if w.multiPartUploadInput.ChecksumType == types.ChecksumTypeFullObject {
+		// The server checks this against the checksum of the data it received
+		req.ChecksumCRC64NVME = w.fullObjectCRC()
+		req.ChecksumType = types.ChecksumTypeFullObject
+	}
 	var resp *s3.CompleteMultipartUploadOutput
 	err = w.f.pacer.Call(func() (bool, error) {
-		resp, err = w.f.c.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
-			Bucket: w.bucket,
-			Key:    w.key,
-			MultipartUpload: &types.CompletedMultipartUpload{
-				Parts: w.completedParts,
-			},
-			RequestPayer:         w.multiPartUploadInput.RequestPayer,
-			SSECustomerAlgorithm: w.multiPartUploadInput.SSECustomerAlgorithm,
-			SSECustomerKey:       w.multiPartUploadInput.SSECustomerKey,
-			SSECustomerKeyMD5:    w.multiPartUploadInput.SSECustomerKeyMD5,
-			UploadId:             w.uploadID,
-			IfMatch:              w.ui.req.IfMatch,
-			IfNoneMatch:          w.ui.req.IfNoneMatch,
-		})
+		resp, err = w.f.c.CompleteMultipartUpload(ctx, req)
 		return w.f.shouldRetry(ctx, err)
 	})
 	if err != nil {
@@ -4422,6 +5169,8 @@ func (o *Object) uploadSinglepartPutObject(ctx context.Context, req *s3.PutObjec
 
 // Upload a single part using a presigned request
 func (o *Object) uploadSinglepartPresignedRequest(ctx context.Context, req *s3.PutObjectInput, size int64, in io.Reader) (etag string, lastModified time.Time, versionID *string, err error) {
+	// The SDK can't add the checksum to a presigned request
+	req.ChecksumAlgorithm = ""
 	// Create the presigned request
 	putReq, err := s3.NewPresignClient(o.fs.c).PresignPutObject(ctx, req, s3.WithPresignExpires(15*time.Minute))
 	if err != nil {
@@ -4586,6 +5335,12 @@ func (o *Object) prepareUpload(ctx context.Context, src fs.ObjectInfo, options [
 	if md5sumBase64 != "" && !o.fs.opt.DirectoryBucket {
 		ui.req.ContentMD5 = &md5sumBase64
 	}
+	switch o.fs.opt.UploadChecksum {
+	case "crc64nvme":
+		ui.req.ChecksumAlgorithm = types.ChecksumAlgorithmCrc64nvme
+	case "sha256":
+		ui.req.ChecksumAlgorithm = types.ChecksumAlgorithmSha256
+	}
 	if o.fs.opt.RequesterPays {
 		ui.req.RequestPayer = types.RequestPayerRequester
 	}
@@ -4754,6 +5509,131 @@ func (o *Object) Remove(ctx context.Context) error {
 	return err
 }
 
+// maxDeleteObjects is the maximum number of keys in a DeleteObjects
+// request
+const maxDeleteObjects = 1000
+
+// deleteObjectsRetryCodes are the error codes for keys in a
+// DeleteObjects response which are worth retrying
+var deleteObjectsRetryCodes = []string{
+	"InternalError",
+	"OperationAborted",
+	"RequestTimeout",
+	"ServiceUnavailable",
+	"SlowDown",
+}
+
+// DeleteObjects deletes all the objects passed in using the
+// DeleteObjects call which deletes up to 1000 keys in one request.
+func (f *Fs) DeleteObjects(ctx context.Context, objs []fs.Object) (errs []error, err error) {
+	if f.opt.VersionAt.IsSet() {
+		return nil, errNotWithVersionAt
+	}
+	errs = make([]error, len(objs))
+	// Requests may only contain keys from one bucket
+	var bucketNames []string
+	byBucket := map[string][]*Object{}
+	for i, obj := range objs {
+		o, ok := obj.(*Object)
+		if !ok {
+			errs[i] = errors.New("not an s3 object")
+			continue
+		}
+		bucket, _ := o.split()
+		if _, found := byBucket[bucket]; !found {
+			bucketNames = append(bucketNames, bucket)
+		}
+		byBucket[bucket] = append(byBucket[bucket], o)
+	}
+	results := make(map[*Object]error, len(objs))
+	for _, bucket := range bucketNames {
+		batch := byBucket[bucket]
+		for len(batch) > 0 {
+			n := min(len(batch), maxDeleteObjects)
+			f.deleteObjects(ctx, bucket, batch[:n], results)
+			batch = batch[n:]
+		}
+	}
+	for i, obj := range objs {
+		if o, ok := obj.(*Object); ok {
+			errs[i] = results[o]
+		}
+	}
+	return errs, nil
+}
+
+// deleteObjects deletes objs which must all be in bucket with a
+// DeleteObjects request, storing the result for each object in
+// results.
+//
+// Objects which fail with a retryable error are retried on their own
+// in the next request.
+func (f *Fs) deleteObjects(ctx context.Context, bucket string, objs []*Object, results map[*Object]error) {
+	// deleteKey identifies the object in the response
+	deleteKey := func(key, versionID *string) string {
+		return deref(key) + "\x00" + deref(versionID)
+	}
+	pending := objs
+	err := f.pacer.Call(func() (bool, error) {
+		ids := make([]types.ObjectIdentifier, len(pending))
+		byKey := make(map[string]*Object, len(pending))
+		for i, o := range pending {
+			_, bucketPath := o.split()
+			ids[i] = types.ObjectIdentifier{
+				Key:       &bucketPath,
+				VersionId: o.versionID,
+			}
+			byKey[deleteKey(&bucketPath, o.versionID)] = o
+		}
+		req := s3.DeleteObjectsInput{
+			Bucket: &bucket,
+			Delete: &types.Delete{
+				Objects: ids,
+				Quiet:   aws.Bool(true),
+			},
+		}
+		if f.opt.RequesterPays {
+			req.RequestPayer = types.RequestPayerRequester
+		}
+		resp, err := f.c.DeleteObjects(ctx, &req)
+		if err != nil {
+			return f.shouldRetry(ctx, err)
+		}
+		for _, o := range pending {
+			results[o] = nil
+		}
+		var retry []*Object
+		for _, item := range resp.Errors {
+			o := byKey[deleteKey(item.Key, item.VersionId)]
+			if o == nil {
+				// Some providers leave out the VersionId
+				o = byKey[deleteKey(item.Key, nil)]
+			}
+			if o == nil {
+				fs.Debugf(f, "Ignoring delete error for unknown key %q: %s", deref(item.Key), deref(item.Message))
+				continue
+			}
+			results[o] = fmt.Errorf("%s: %s", deref(item.Code), deref(item.Message))
+			if slices.Contains(deleteObjectsRetryCodes, deref(item.Code)) {
+				retry = append(retry, o)
+			}
+		}
+		pending = retry
+		if len(retry) > 0 {
+			fs.Debugf(f, "Retrying %d objects which failed to delete", len(retry))
+			return true, fmt.Errorf("failed to delete %d objects", len(retry))
+		}
+		return false, nil
+	})
+	if err != nil {
+		for _, o := range pending {
+			if results[o] == nil {
+				results[o] = fmt.Errorf("failed to delete objects: %w", err)
+			}
+		}
+	}
+}
+
 // MimeType of an Object if known, "" otherwise
 func (o *Object) MimeType(ctx context.Context) string {
 	err := o.readMetaData(ctx)
@@ -4837,20 +5717,100 @@ func (o *Object) Metadata(ctx context.Context) (metadata fs.Metadata, err error)
 	return metadata, nil
 }
 
+// Tags returns the object tags
+//
+// It should return nil if there are no tags
+func (o *Object) Tags(ctx context.Context) (tags fs.Tags, err error) {
+	bucket, bucketPath := o.split()
+	req := s3.GetObjectTaggingInput{
+		Bucket:    &bucket,
+		Key:       &bucketPath,
+		VersionId: o.versionID,
+	}
+	if o.fs.opt.RequesterPays {
+		req.RequestPayer = types.RequestPayerRequester
+	}
+	var resp *s3.GetObjectTaggingOutput
+	err = o.fs.pacer.Call(func() (bool, error) {
+		resp, err = o.fs.c.GetObjectTagging(ctx, &req)
+		return o.fs.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+	if len(resp.TagSet) == 0 {
+		return nil, nil
+	}
+	tags = make(fs.Tags, len(resp.TagSet))
+	for _, tag := range resp.TagSet {
+		tags[deref(tag.Key)] = deref(tag.Value)
+	}
+	return tags, nil
+}
+
+// SetTags replaces the object tags
+func (o *Object) SetTags(ctx context.Context, tags fs.Tags) (err error) {
+	bucket, bucketPath := o.split()
+	if len(tags) == 0 {
+		req := s3.DeleteObjectTaggingInput{
+			Bucket:    &bucket,
+			Key:       &bucketPath,
+			VersionId: o.versionID,
+		}
+		err = o.fs.pacer.Call(func() (bool, error) {
+			_, err = o.fs.c.DeleteObjectTagging(ctx, &req)
+			return o.fs.shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove tags: %w", err)
+		}
+		return nil
+	}
+	tagSet := make([]types.Tag, 0, len(tags))
+	for k, v := range tags {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	req := s3.PutObjectTaggingInput{
+		Bucket:    &bucket,
+		Key:       &bucketPath,
+		VersionId: o.versionID,
+		Tagging:   &types.Tagging{TagSet: tagSet},
+	}
+	if o.fs.opt.RequesterPays {
+		req.RequestPayer = types.RequestPayerRequester
+	}
+	err = o.fs.pacer.Call(func() (bool, error) {
+		_, err = o.fs.c.PutObjectTagging(ctx, &req)
+		return o.fs.shouldRetry(ctx, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set tags: %w", err)
+	}
+	return nil
+}
+
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs              = &Fs{}
-	_ fs.Purger          = &Fs{}
-	_ fs.Copier          = &Fs{}
-	_ fs.PutStreamer     = &Fs{}
-	_ fs.ListRer         = &Fs{}
-	_ fs.ListPer         = &Fs{}
-	_ fs.Commander       = &Fs{}
-	_ fs.CleanUpper      = &Fs{}
-	_ fs.OpenChunkWriter = &Fs{}
-	_ fs.Object          = &Object{}
-	_ fs.MimeTyper       = &Object{}
-	_ fs.GetTierer       = &Object{}
-	_ fs.SetTierer       = &Object{}
-	_ fs.Metadataer      = &Object{}
+	_ fs.Fs                 = &Fs{}
+	_ fs.Purger             = &Fs{}
+	_ fs.Copier             = &Fs{}
+	_ fs.PutStreamer        = &Fs{}
+	_ fs.ListRer            = &Fs{}
+	_ fs.ListPer            = &Fs{}
+	_ fs.Commander          = &Fs{}
+	_ fs.CleanUpper         = &Fs{}
+	_ fs.OpenChunkWriter    = &Fs{}
+	_ fs.ResumeChunkWriter  = &Fs{}
+	_ fs.BatchDeleter       = &Fs{}
+	_ fs.Undeleter          = &Fs{}
+	_ fs.SoftDeleter        = &Fs{}
+	_ fs.DeletedPurger      = &Fs{}
+	_ fs.Object             = &Object{}
+	_ fs.MimeTyper          = &Object{}
+	_ fs.GetTierer          = &Object{}
+	_ fs.SetTierer          = &Object{}
+	_ fs.Metadataer         = &Object{}
+	_ fs.Tagger             = &Object{}
+	_ fs.SetTagger          = &Object{}
+	_ fs.ChunkWriterResumer = &s3ChunkWriter{}
 )
@@ -18,10 +18,13 @@ import (
 	"github.com/aws/smithy-go"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/fstest/fstests"
 	"github.com/rclone/rclone/lib/bucket"
+	"github.com/rclone/rclone/lib/crc64nvme"
+	"github.com/rclone/rclone/lib/encoder"
 	"github.com/rclone/rclone/lib/random"
 	"github.com/rclone/rclone/lib/version"
 	"github.com/stretchr/testify/assert"
@@ -289,6 +292,38 @@ func TestRemoveAWSChunked(t *testing.T) {
 	}
 }
 
+func TestChecksumToHex(t *testing.T) {
+	ps := func(s string) *string {
+		return &s
+	}
+	for _, test := range []struct {
+		name         string
+		in           *string
+		checksumType types.ChecksumType
+		want         *string
+	}{
+		{"nil", nil, types.ChecksumTypeFullObject, nil},
+		{"full object", ps("rosUhgp5mIg="), types.ChecksumTypeFullObject, ps("ae8b14860a799888")},
+		{"no type", ps("rosUhgp5mIg="), "", ps("ae8b14860a799888")},
+		{"composite", ps("rosUhgp5mIg=-2"), types.ChecksumTypeComposite, nil},
+		{"bad base64", ps("!!!"), types.ChecksumTypeFullObject, nil},
+		{"wrong length", ps("rosUhg=="), types.ChecksumTypeFullObject, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, checksumToHex(test.in, test.checksumType, 8))
+		})
+	}
+}
+
+func TestFullObjectCRC(t *testing.T) {
+	w := &s3ChunkWriter{}
+	// Add the parts of "123456789" out of order
+	w.addCRC(crc64nvme.Checksum([]byte("789")), 3, 2)
+	w.addCRC(crc64nvme.Checksum([]byte("123")), 3, 0)
+	w.addCRC(crc64nvme.Checksum([]byte("456")), 3, 1)
+	assert.Equal(t, "rosUhgp5mIg=", *w.fullObjectCRC())
+}
+
 func (f *Fs) InternalTestVersions(t *testing.T) {
 	ctx := context.Background()
 
@@ -505,3 +540,45 @@ func (f *Fs) InternalTest(t *testing.T) {
 }
 
 var _ fstests.InternalTester = (*Fs)(nil)
+
+func TestListConstraints(t *testing.T) {
+	ctx := context.Background()
+	f := &Fs{}
+	f.opt.Enc = encoder.EncodeInvalidUtf8 | encoder.EncodeSlash | encoder.EncodeDot
+
+	// No filters or query
+	namePrefix, startAfter, err := f.listConstraints(ctx, "logs", false)
+	require.NoError(t, err)
+	assert.Equal(t, "", namePrefix)
+	assert.Equal(t, "", startAfter)
+
+	// Filters are only used if the listing is filter aware
+	ctx, fi := filter.AddConfig(ctx)
+	require.NoError(t, fi.AddRule("+ /logs/2024-*"))
+	require.NoError(t, fi.AddRule("- **"))
+	namePrefix, _, err = f.listConstraints(ctx, "logs", false)
+	require.NoError(t, err)
+	assert.Equal(t, "", namePrefix)
+	ctx = filter.SetUseFilter(ctx, true)
+	namePrefix, _, err = f.listConstraints(ctx, "logs", false)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-", namePrefix)
+	namePrefix, _, err = f.listConstraints(ctx, "", true)
+	require.NoError(t, err)
+	assert.Equal(t, "logs/2024-", namePrefix)
+
+	// Query prefix is used if it extends the filter prefix
+	namePrefix, startAfter, err = f.listConstraints(filter.SetListQuery(ctx, "prefix=2024-06&start-after=2024-06-15"), "logs", false)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06", namePrefix)
+	assert.Equal(t, "2024-06-15", startAfter)
+	namePrefix, _, err = f.listConstraints(filter.SetListQuery(ctx, "prefix=20"), "logs", false)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-", namePrefix)
+
+	// Bad queries
+	_, _, err = f.listConstraints(filter.SetListQuery(ctx, "potato=1"), "logs", false)
+	assert.ErrorContains(t, err, "unknown key")
+	_, _, err = f.listConstraints(filter.SetListQuery(ctx, "prefix=%zz"), "logs", false)
+	assert.ErrorContains(t, err, "failed to parse")
+}
@@ -75,6 +75,40 @@ func TestAWSDualStackOption(t *testing.T) {
 	}
 }
 
+func TestAWSDirectoryBucketSessionAuthOption(t *testing.T) {
+	{
+		// test default case
+		ctx, opt, client := SetupS3Test(t)
+		s3Conn, _, err := s3Connection(ctx, opt, client)
+		require.NoError(t, err)
+		assert.Nil(t, s3Conn.Options().DisableS3ExpressSessionAuth)
+	}
+	{
+		// test enabled
+		ctx, opt, client := SetupS3Test(t)
+		opt.DirectoryBucket = true
+		opt.DirectoryBucketSessionAuth = true
+		s3Conn, _, err := s3Connection(ctx, opt, client)
+		require.NoError(t, err)
+		assert.Equal(t, aws.Bool(false), s3Conn.Options().DisableS3ExpressSessionAuth)
+	}
+	{
+		// test disabled
+		ctx, opt, client := SetupS3Test(t)
+		opt.DirectoryBucket = true
+		opt.DirectoryBucketSessionAuth = false
+		s3Conn, _, err := s3Connection(ctx, opt, client)
+		require.NoError(t, err)
+		assert.Equal(t, aws.Bool(true), s3Conn.Options().DisableS3ExpressSessionAuth)
+	}
+}
+
+func TestIsDirectoryBucketName(t *testing.T) {
+	assert.True(t, isDirectoryBucketName("bucket--usw2-az1--x-s3"))
+	assert.False(t, isDirectoryBucketName("bucket"))
+	assert.False(t, isDirectoryBucketName("bucket--x-s3-not"))
+}
+
 func (f *Fs) SetUploadChunkSize(cs fs.SizeSuffix) (fs.SizeSuffix, error) {
 	return f.setUploadChunkSize(cs)
 }
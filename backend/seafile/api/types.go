@@ -52,6 +52,18 @@ type Library struct {
 	Modified  int64  `json:"mtime"`
 }
 
+// LibraryDetail contains the properties of a single library, including
+// the keys needed to encrypt blocks for an encrypted library
+type LibraryDetail struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Encrypted  bool   `json:"encrypted"`
+	EncVersion int    `json:"enc_version"`
+	Magic      string `json:"magic"`
+	RandomKey  string `json:"random_key"`
+	Salt       string `json:"salt"`
+}
+
 // CreateLibrary properties. Seafile is not consistent and returns different types for different API calls
 type CreateLibrary struct {
 	ID   string `json:"repo_id"`
@@ -152,3 +164,11 @@ type BatchSourceDestRequest struct {
 	DstLibraryID string   `json:"dst_repo_id"`
 	DstParentDir string   `json:"dst_parent_dir"`
 }
+
+// UploadBlocksLink is returned when asking which blocks of a file the
+// server is missing
+type UploadBlocksLink struct {
+	RawBlocksURL string   `json:"rawblksurl"`
+	CommitURL    string   `json:"commiturl"`
+	Missing      []string `json:"blklist"`
+}
@@ -0,0 +1,143 @@
+package seafile
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+
+	"github.com/rclone/rclone/backend/seafile/api"
+	"github.com/rclone/rclone/fs"
+)
+
+// errNoBlockUpload is returned when a file can't be uploaded in blocks
+// so should be uploaded normally instead
+var errNoBlockUpload = errors.New("block upload not available")
+
+// blockCiphers caches the ciphers for block uploads by library ID
+type blockCiphers struct {
+	mu      sync.Mutex
+	ciphers map[string]*libraryCipher // nil if blocks can't be encrypted
+}
+
+// libraryBlockCipher returns the cipher to encrypt the blocks uploaded
+// to the library with, or nil if it isn't encrypted.
+//
+// It returns errNoBlockUpload if blocks can't be encrypted for the
+// library.
+func (f *Fs) libraryBlockCipher(ctx context.Context, libraryID string) (*libraryCipher, error) {
+	encrypted, err := f.isEncrypted(ctx, libraryID)
+	if err != nil || !encrypted {
+		return nil, err
+	}
+	f.ciphers.mu.Lock()
+	defer f.ciphers.mu.Unlock()
+	if c, found := f.ciphers.ciphers[libraryID]; found {
+		if c == nil {
+			return nil, errNoBlockUpload
+		}
+		return c, nil
+	}
+	if f.opt.LibraryKey == "" {
+		return nil, errNoBlockUpload
+	}
+	library, err := f.getLibraryDetails(ctx, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newLibraryCipher(library, f.opt.LibraryKey)
+	if errors.Is(err, errUnsupportedEncVersion) {
+		fs.Logf(f, "Uploading normally as can't upload blocks: %v", err)
+		err = errNoBlockUpload
+	}
+	if err != nil && !errors.Is(err, errNoBlockUpload) {
+		return nil, err
+	}
+	if f.ciphers.ciphers == nil {
+		f.ciphers.ciphers = make(map[string]*libraryCipher)
+	}
+	f.ciphers.ciphers[libraryID] = c
+	return c, err
+}
+
+// uploadBlocks uploads in to filePath block by block, only sending
+// the blocks the server doesn't already have.
+//
+// If the file can't be uploaded in blocks it returns errNoBlockUpload
+// along with a reader for all of in to upload normally.
+func (f *Fs) uploadBlocks(ctx context.Context, in io.Reader, libraryID, filePath string) (*api.FileDetail, io.Reader, error) {
+	c, err := f.libraryBlockCipher(ctx, libraryID)
+	if err != nil {
+		return nil, in, err
+	}
+	dirPath := path.Dir(path.Join("/", filePath))
+	err = f.mkMultiDir(ctx, libraryID, dirPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		buf       = make([]byte, f.opt.UploadBlockSize)
+		blockIDs  []string
+		size      int64
+		link      *api.UploadBlocksLink
+		sent      int
+		sentBytes int64
+	)
+	for {
+		n, err := io.ReadFull(in, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, nil, err
+		}
+		block := buf[:n]
+		if c != nil {
+			block = c.encrypt(block)
+		}
+		sum := sha1.Sum(block)
+		blockID := hex.EncodeToString(sum[:])
+		link, err = f.checkBlocks(ctx, libraryID, dirPath, []string{blockID})
+		if errors.Is(err, errNoBlockUpload) && len(blockIDs) == 0 {
+			fs.Debugf(f, "Uploading normally as the server doesn't support block uploads")
+			return nil, io.MultiReader(bytes.NewReader(buf[:n]), in), err
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(link.Missing) > 0 {
+			err = f.uploadRawBlocks(ctx, link.RawBlocksURL, []string{blockID}, [][]byte{block})
+			if err != nil {
+				return nil, nil, err
+			}
+			sent++
+			sentBytes += int64(len(block))
+		}
+		blockIDs = append(blockIDs, blockID)
+		size += int64(n)
+		if n < len(buf) {
+			break
+		}
+	}
+	if link == nil {
+		// Empty files have no blocks
+		return nil, bytes.NewReader(nil), errNoBlockUpload
+	}
+	fs.Debugf(f, "%s: uploaded %d of %d blocks (%d bytes)", filePath, sent, len(blockIDs), sentBytes)
+	uploaded, err := f.commitBlocks(ctx, link.CommitURL, filePath, size, blockIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if uploaded == nil {
+		uploaded, err = f.getFileDetails(ctx, libraryID, filePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read file details after uploading blocks: %w", err)
+		}
+	}
+	return uploaded, nil, nil
+}
@@ -0,0 +1,195 @@
+package seafile
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rclone/rclone/backend/seafile/api"
+	"github.com/rclone/rclone/lib/cache"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockServer is a fake Seafile server taking block uploads
+type blockServer struct {
+	t         *testing.T
+	library   *api.LibraryDetail
+	blockAPI  bool // set if the block upload API is available
+	mu        sync.Mutex
+	blocks    map[string][]byte // blocks stored by ID
+	sent      int               // number of blocks uploaded
+	committed []string          // block IDs of the last file committed
+	size      string            // size of the last file committed
+}
+
+func (s *blockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t := s.t
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/api2/repos/":
+		_ = json.NewEncoder(w).Encode([]api.Library{{ID: s.library.ID, Name: "lib", Encrypted: s.library.Encrypted}})
+	case r.URL.Path == "/api2/repos/"+s.library.ID+"/":
+		_ = json.NewEncoder(w).Encode(s.library)
+	case r.URL.Path == "/api/v2.1/repos/"+s.library.ID+"/dir/detail/":
+		_ = json.NewEncoder(w).Encode(api.DirectoryDetail{ID: s.library.ID, Path: r.URL.Query().Get("path")})
+	case r.URL.Path == "/api2/repos/"+s.library.ID+"/upload-blks-link/":
+		if !s.blockAPI {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, "/dir", r.URL.Query().Get("p"))
+		require.NoError(t, r.ParseForm())
+		var missing []string
+		for _, id := range strings.Split(r.PostForm.Get("blklist"), ",") {
+			if _, found := s.blocks[id]; !found {
+				missing = append(missing, id)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(api.UploadBlocksLink{
+			RawBlocksURL: "http://" + r.Host + "/raw",
+			CommitURL:    "http://" + r.Host + "/commit?commitonly=true&ret-json=true",
+			Missing:      missing,
+		})
+	case r.URL.Path == "/raw":
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		for _, file := range r.MultipartForm.File["file"] {
+			in, err := file.Open()
+			require.NoError(t, err)
+			block, err := io.ReadAll(in)
+			require.NoError(t, err)
+			sum := sha1.Sum(block)
+			assert.Equal(t, hex.EncodeToString(sum[:]), file.Filename)
+			s.blocks[file.Filename] = block
+			s.sent++
+		}
+	case r.URL.Path == "/commit":
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "/dir", r.FormValue("parent_dir"))
+		assert.Equal(t, "file.bin", r.FormValue("file_name"))
+		s.committed = nil
+		require.NoError(t, json.Unmarshal([]byte(r.FormValue("blockids")), &s.committed))
+		s.size = r.FormValue("file_size")
+		_ = json.NewEncoder(w).Encode([]api.FileDetail{{ID: "FILEID", Name: "file.bin"}})
+	default:
+		t.Errorf("unexpected %s request to %s", r.Method, r.URL)
+		http.NotFound(w, r)
+	}
+}
+
+// contents returns the contents of the last file committed
+func (s *blockServer) contents(c *libraryCipher) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out bytes.Buffer
+	for _, id := range s.committed {
+		block := s.blocks[id]
+		if c != nil {
+			var err error
+			block, err = c.decrypt(block)
+			require.NoError(s.t, err)
+		}
+		out.Write(block)
+	}
+	return out.String()
+}
+
+// newBlockTestFs makes an Fs uploading blocks to s
+func newBlockTestFs(t *testing.T, s *blockServer) *Fs {
+	ctx := context.Background()
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	f := &Fs{
+		opt: Options{
+			LibraryKey:      "potato",
+			UploadBlocks:    true,
+			UploadBlockSize: 4,
+			Enc:             encoder.EncodeZero,
+		},
+		libraries: cache.New(),
+		srv:       rest.NewClient(ts.Client()).SetRoot(ts.URL + "/"),
+		pacer:     getPacer(ctx, ts.URL),
+	}
+	return f
+}
+
+func TestUploadBlocks(t *testing.T) {
+	ctx := context.Background()
+	for _, encrypted := range []bool{false, true} {
+		name := "plain"
+		if encrypted {
+			name = "encrypted"
+		}
+		t.Run(name, func(t *testing.T) {
+			s := &blockServer{
+				t:        t,
+				library:  &api.LibraryDetail{ID: "LIBRARY"},
+				blockAPI: true,
+				blocks:   map[string][]byte{},
+			}
+			var c *libraryCipher
+			if encrypted {
+				s.library = makeEncryptedLibrary(t, 3, "potato", []byte("0123456789abcdef0123456789abcdef"))
+				var err error
+				c, err = newLibraryCipher(s.library, "potato")
+				require.NoError(t, err)
+			}
+			f := newBlockTestFs(t, s)
+
+			// The repeated block is only sent once
+			uploaded, _, err := f.uploadBlocks(ctx, strings.NewReader("aaaabbbbaaaacc"), s.library.ID, "dir/file.bin")
+			require.NoError(t, err)
+			assert.Equal(t, "FILEID", uploaded.ID)
+			assert.Equal(t, 3, s.sent)
+			assert.Len(t, s.committed, 4)
+			assert.Equal(t, "14", s.size)
+			assert.Equal(t, "aaaabbbbaaaacc", s.contents(c))
+
+			// Only the changed block is sent again
+			_, _, err = f.uploadBlocks(ctx, strings.NewReader("aaaabbbbdddd"), s.library.ID, "dir/file.bin")
+			require.NoError(t, err)
+			assert.Equal(t, 4, s.sent)
+			assert.Equal(t, "aaaabbbbdddd", s.contents(c))
+		})
+	}
+}
+
+func TestUploadBlocksFallback(t *testing.T) {
+	ctx := context.Background()
+	s := &blockServer{
+		t:       t,
+		library: &api.LibraryDetail{ID: "LIBRARY"},
+		blocks:  map[string][]byte{},
+	}
+	f := newBlockTestFs(t, s)
+
+	// The server doesn't have the block API so all the data is returned
+	_, in, err := f.uploadBlocks(ctx, strings.NewReader("aaaabbbbcc"), s.library.ID, "dir/file.bin")
+	assert.ErrorIs(t, err, errNoBlockUpload)
+	data, err := io.ReadAll(in)
+	require.NoError(t, err)
+	assert.Equal(t, "aaaabbbbcc", string(data))
+
+	// Encrypted libraries of unsupported versions can't take blocks
+	s.blockAPI = true
+	s.library = &api.LibraryDetail{ID: "LIBRARY2", Encrypted: true, EncVersion: 1}
+	f = newBlockTestFs(t, s)
+	_, in, err = f.uploadBlocks(ctx, strings.NewReader("aaaabbbbcc"), s.library.ID, "dir/file.bin")
+	assert.ErrorIs(t, err, errNoBlockUpload)
+	data, err = io.ReadAll(in)
+	require.NoError(t, err)
+	assert.Equal(t, "aaaabbbbcc", string(data))
+	assert.Equal(t, 0, s.sent)
+}
@@ -0,0 +1,145 @@
+package seafile
+
+// Encryption of blocks for encrypted libraries
+//
+// From version 2 encrypted libraries have a random file key which is
+// stored on the server encrypted with a key derived from the library
+// password. The blocks of the files are encrypted with AES-256-CBC
+// using the file key. Version 3 is the same as version 2 except each
+// library has its own random salt rather than a fixed one.
+//
+// The server only encrypts data uploaded through the normal upload
+// link, so blocks uploaded with the block upload API must be
+// encrypted here first.
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/rclone/rclone/backend/seafile/api"
+)
+
+// Salt used by version 2 encrypted libraries
+var fixedSalt = []byte{0xda, 0x90, 0x45, 0xc3, 0x06, 0xc7, 0xcc, 0x26}
+
+// Errors from reading the library keys
+var (
+	errUnsupportedEncVersion = errors.New("unsupported encrypted library version")
+	errIncorrectPassword     = errors.New("incorrect library password")
+)
+
+// libraryCipher encrypts blocks for an encrypted library
+type libraryCipher struct {
+	block cipher.Block
+	iv    []byte
+}
+
+// deriveKey derives an AES-256 key and IV from data as Seafile does
+func deriveKey(data, salt []byte) (key, iv []byte, err error) {
+	key, err = pbkdf2.Key(sha256.New, string(data), salt, 1000, 32)
+	if err != nil {
+		return nil, nil, err
+	}
+	iv, err = pbkdf2.Key(sha256.New, string(key), salt, 10, 16)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, iv, nil
+}
+
+// librarySalt returns the salt for the library
+func librarySalt(library *api.LibraryDetail) ([]byte, error) {
+	switch library.EncVersion {
+	case 2:
+		return fixedSalt, nil
+	case 3:
+		salt, err := hex.DecodeString(library.Salt)
+		if err != nil || len(salt) == 0 {
+			return nil, fmt.Errorf("bad salt for encrypted library: %q", library.Salt)
+		}
+		return salt, nil
+	}
+	return nil, fmt.Errorf("%w %d", errUnsupportedEncVersion, library.EncVersion)
+}
+
+// newLibraryCipher checks password is correct for library and reads
+// its file key
+func newLibraryCipher(library *api.LibraryDetail, password string) (*libraryCipher, error) {
+	salt, err := librarySalt(library)
+	if err != nil {
+		return nil, err
+	}
+
+	// The magic is the key derived from the library ID and password
+	magic, _, err := deriveKey([]byte(library.ID+password), salt)
+	if err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(magic) != library.Magic {
+		return nil, errIncorrectPassword
+	}
+
+	// Decrypt the file key with the key derived from the password
+	key, iv, err := deriveKey([]byte(password), salt)
+	if err != nil {
+		return nil, err
+	}
+	encryptedFileKey, err := hex.DecodeString(library.RandomKey)
+	if err != nil {
+		return nil, fmt.Errorf("bad random key for encrypted library: %w", err)
+	}
+	passwordCipher, err := newCipher(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	fileKey, err := passwordCipher.decrypt(encryptedFileKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt random key for encrypted library: %w", err)
+	}
+
+	// The blocks are encrypted with the key derived from the file key
+	key, iv, err = deriveKey(fileKey, salt)
+	if err != nil {
+		return nil, err
+	}
+	return newCipher(key, iv)
+}
+
+// newCipher makes a libraryCipher from key and iv
+func newCipher(key, iv []byte) (*libraryCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &libraryCipher{block: block, iv: iv}, nil
+}
+
+// encrypt returns the encrypted version of in padded with PKCS#7
+func (c *libraryCipher) encrypt(in []byte) []byte {
+	padding := aes.BlockSize - len(in)%aes.BlockSize
+	out := make([]byte, len(in)+padding)
+	copy(out, in)
+	copy(out[len(in):], bytes.Repeat([]byte{byte(padding)}, padding))
+	cipher.NewCBCEncrypter(c.block, c.iv).CryptBlocks(out, out)
+	return out
+}
+
+// decrypt returns the decrypted version of in removing the PKCS#7 padding
+func (c *libraryCipher) decrypt(in []byte) ([]byte, error) {
+	if len(in) == 0 || len(in)%aes.BlockSize != 0 {
+		return nil, errors.New("encrypted data is not a multiple of the block size")
+	}
+	out := make([]byte, len(in))
+	cipher.NewCBCDecrypter(c.block, c.iv).CryptBlocks(out, in)
+	padding := int(out[len(out)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(out[len(out)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("bad padding")
+	}
+	return out[:len(out)-padding], nil
+}
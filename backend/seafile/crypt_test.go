@@ -0,0 +1,73 @@
+package seafile
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/rclone/rclone/backend/seafile/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeEncryptedLibrary makes the details the server would return for
+// an encrypted library with password and fileKey
+func makeEncryptedLibrary(t *testing.T, version int, password string, fileKey []byte) *api.LibraryDetail {
+	library := &api.LibraryDetail{
+		ID:         "0b8fe2a6-f5f5-4e0d-a1f4-6d2c0e0f2a3b",
+		Encrypted:  true,
+		EncVersion: version,
+		Salt:       "8fa1e3b2c4d5e6f708192a3b4c5d6e7f8fa1e3b2c4d5e6f708192a3b4c5d6e7f",
+	}
+	salt, err := librarySalt(library)
+	require.NoError(t, err)
+	magic, _, err := deriveKey([]byte(library.ID+password), salt)
+	require.NoError(t, err)
+	library.Magic = hex.EncodeToString(magic)
+	key, iv, err := deriveKey([]byte(password), salt)
+	require.NoError(t, err)
+	c, err := newCipher(key, iv)
+	require.NoError(t, err)
+	library.RandomKey = hex.EncodeToString(c.encrypt(fileKey))
+	return library
+}
+
+func TestLibraryCipher(t *testing.T) {
+	fileKey := []byte("0123456789abcdef0123456789abcdef")
+	for _, version := range []int{2, 3} {
+		library := makeEncryptedLibrary(t, version, "potato", fileKey)
+		assert.Len(t, library.RandomKey, 96)
+
+		c, err := newLibraryCipher(library, "potato")
+		require.NoError(t, err)
+		for _, in := range []string{"", "hello", "exactly 16 bytes"} {
+			encrypted := c.encrypt([]byte(in))
+			assert.Equal(t, 0, len(encrypted)%16)
+			assert.Greater(t, len(encrypted), len(in))
+			decrypted, err := c.decrypt(encrypted)
+			require.NoError(t, err)
+			assert.Equal(t, in, string(decrypted))
+		}
+
+		// The blocks are encrypted with a key derived from the file key
+		salt, err := librarySalt(library)
+		require.NoError(t, err)
+		key, iv, err := deriveKey(fileKey, salt)
+		require.NoError(t, err)
+		want, err := newCipher(key, iv)
+		require.NoError(t, err)
+		assert.Equal(t, want.encrypt([]byte("hello")), c.encrypt([]byte("hello")))
+
+		_, err = newLibraryCipher(library, "wrong")
+		assert.ErrorIs(t, err, errIncorrectPassword)
+	}
+
+	// Versions 2 and 3 use different salts
+	v2, err := newLibraryCipher(makeEncryptedLibrary(t, 2, "potato", fileKey), "potato")
+	require.NoError(t, err)
+	v3, err := newLibraryCipher(makeEncryptedLibrary(t, 3, "potato", fileKey), "potato")
+	require.NoError(t, err)
+	assert.NotEqual(t, v2.encrypt([]byte("hello")), v3.encrypt([]byte("hello")))
+
+	_, err = newLibraryCipher(&api.LibraryDetail{EncVersion: 1}, "potato")
+	assert.ErrorIs(t, err, errUnsupportedEncVersion)
+}
@@ -2,6 +2,7 @@ package seafile
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
 
@@ -89,6 +90,18 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadClo
 // But for unknown-sized objects (indicated by src.Size() == -1), Upload should either
 // return an error or update the object properly (rather than e.g. calling panic).
 func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	if o.fs.opt.UploadBlocks && (src.Size() < 0 || src.Size() > int64(o.fs.opt.UploadBlockSize)) {
+		uploaded, remaining, err := o.fs.uploadBlocks(ctx, in, o.libraryID, o.pathInLibrary)
+		if err == nil {
+			o.size = uploaded.Size
+			o.id = uploaded.ID
+			return nil
+		}
+		if !errors.Is(err, errNoBlockUpload) {
+			return err
+		}
+		in = remaining
+	}
 	// The upload sometimes return a temporary 500 error
 	// We cannot use the pacer to retry uploading the file as the upload link is single use only
 	for retry := 0; retry <= 3; retry++ {
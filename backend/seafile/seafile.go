@@ -43,6 +43,8 @@ const (
 	configLibraryKey    = "library_key"
 	configCreateLibrary = "create_library"
 	configAuthToken     = "auth_token"
+
+	defaultUploadBlockSize = 8 * fs.Mebi
 )
 
 // This is global to all instances of fs
@@ -96,6 +98,30 @@ func init() {
 			Help:     "Should rclone create a library if it doesn't exist.",
 			Advanced: true,
 			Default:  false,
+		}, {
+			Name: "upload_blocks",
+			Help: `Upload files in blocks, only sending the blocks the server doesn't have.
+
+Files bigger than upload_block_size are split into blocks and the
+server is asked which blocks it already has, from this file or any
+other in the library, so only the new blocks are sent. This saves
+time and bandwidth when large files are uploaded again after small
+changes.
+
+In encrypted libraries the blocks are encrypted by rclone, which
+needs the library password and an encrypted library of version 2 or 3.
+Files are uploaded normally if they can't be uploaded in blocks.`,
+			Advanced: true,
+			Default:  false,
+		}, {
+			Name: "upload_block_size",
+			Help: `Size of the blocks to upload files in with upload_blocks.
+
+The default matches the size of the blocks the server makes from
+files uploaded normally, so their blocks can be reused. Each block
+being uploaded is held in memory.`,
+			Advanced: true,
+			Default:  defaultUploadBlockSize,
 		}, {
 			// Keep the authentication token after entering the 2FA code
 			Name:      configAuthToken,
@@ -119,15 +145,17 @@ func init() {
 
 // Options defines the configuration for this backend
 type Options struct {
-	URL           string               `config:"url"`
-	User          string               `config:"user"`
-	Password      string               `config:"pass"`
-	Is2FA         bool                 `config:"2fa"`
-	AuthToken     string               `config:"auth_token"`
-	LibraryName   string               `config:"library"`
-	LibraryKey    string               `config:"library_key"`
-	CreateLibrary bool                 `config:"create_library"`
-	Enc           encoder.MultiEncoder `config:"encoding"`
+	URL             string               `config:"url"`
+	User            string               `config:"user"`
+	Password        string               `config:"pass"`
+	Is2FA           bool                 `config:"2fa"`
+	AuthToken       string               `config:"auth_token"`
+	LibraryName     string               `config:"library"`
+	LibraryKey      string               `config:"library_key"`
+	CreateLibrary   bool                 `config:"create_library"`
+	UploadBlocks    bool                 `config:"upload_blocks"`
+	UploadBlockSize fs.SizeSuffix        `config:"upload_block_size"`
+	Enc             encoder.MultiEncoder `config:"encoding"`
 }
 
 // Fs represents a remote seafile
@@ -150,6 +178,7 @@ type Fs struct {
 	useOldDirectoryAPI  bool         // Use the old API v2 if seafile < 7
 	moveDirNotAvailable bool         // Version < 7.0 don't have an API to move a directory
 	renew               *Renew       // Renew an encrypted library token
+	ciphers             blockCiphers // Ciphers for block uploads to encrypted libraries
 }
 
 // ------------------------------------------------------------
@@ -172,6 +201,9 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		libraryName, rootDirectory = bucket.Split(root)
 	}
 
+	if opt.UploadBlockSize < 1 {
+		return nil, fmt.Errorf("upload_block_size must be positive, got %v", opt.UploadBlockSize)
+	}
 	if !strings.HasSuffix(opt.URL, "/") {
 		opt.URL += "/"
 	}
@@ -3,12 +3,15 @@ package seafile
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/rclone/rclone/backend/seafile/api"
@@ -735,6 +738,187 @@ func (f *Fs) upload(ctx context.Context, in io.Reader, uploadLink, filePath stri
 	return nil, nil
 }
 
+func (f *Fs) getLibraryDetails(ctx context.Context, libraryID string) (*api.LibraryDetail, error) {
+	// API Documentation
+	// https://download.seafile.com/published/web-api/v2.1/libraries.md#user-content-Get%20Library%20Info
+	if libraryID == "" {
+		return nil, errors.New("cannot get details without a library")
+	}
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   APIv20 + libraryID + "/",
+	}
+	result := &api.LibraryDetail{}
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if resp != nil {
+			if resp.StatusCode == 401 || resp.StatusCode == 403 {
+				return nil, fs.ErrorPermissionDenied
+			}
+		}
+		return nil, fmt.Errorf("failed to get library details: %w", err)
+	}
+	return result, nil
+}
+
+// checkBlocks asks which of blockIDs the server is missing and gets
+// the links to upload them and commit the file into dirPath
+func (f *Fs) checkBlocks(ctx context.Context, libraryID, dirPath string, blockIDs []string) (*api.UploadBlocksLink, error) {
+	// This isn't in the web API documentation - it is what the Seafile
+	// clients use to upload files block by block
+	if libraryID == "" {
+		return nil, errors.New("cannot upload file without a library")
+	}
+	dirPath = path.Join("/", dirPath)
+	opts := rest.Opts{
+		Method:      "POST",
+		Path:        APIv20 + libraryID + "/upload-blks-link/",
+		Parameters:  url.Values{"p": {f.opt.Enc.FromStandardPath(dirPath)}},
+		ContentType: "application/x-www-form-urlencoded",
+	}
+	body := url.Values{"blklist": {strings.Join(blockIDs, ",")}}.Encode()
+	result := &api.UploadBlocksLink{}
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(func() (bool, error) {
+		opts.Body = strings.NewReader(body)
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if resp != nil {
+			if resp.StatusCode == 401 || resp.StatusCode == 403 {
+				return nil, fs.ErrorPermissionDenied
+			}
+			if resp.StatusCode == 404 || resp.StatusCode == 405 {
+				return nil, errNoBlockUpload
+			}
+		}
+		return nil, fmt.Errorf("failed to check blocks: %w", err)
+	}
+	return result, nil
+}
+
+// setUploadURL sets the URL of opts to link which may be relative
+func setUploadURL(opts *rest.Opts, link string) error {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return fmt.Errorf("failed to parse upload url: %w", err)
+	}
+	if parsedURL.IsAbs() {
+		opts.RootURL = link
+	} else {
+		opts.Path = link
+	}
+	return nil
+}
+
+// uploadRawBlocks uploads blocks named by their IDs
+func (f *Fs) uploadRawBlocks(ctx context.Context, rawBlocksLink string, blockIDs []string, blocks [][]byte) error {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for i, block := range blocks {
+		part, err := writer.CreateFormFile("file", blockIDs[i])
+		if err != nil {
+			return err
+		}
+		_, err = part.Write(block)
+		if err != nil {
+			return err
+		}
+	}
+	err := writer.Close()
+	if err != nil {
+		return err
+	}
+	opts := rest.Opts{
+		Method:      "POST",
+		ContentType: writer.FormDataContentType(),
+		NoResponse:  true,
+	}
+	err = setUploadURL(&opts, rawBlocksLink)
+	if err != nil {
+		return err
+	}
+	var resp *http.Response
+	// The blocks are named by their contents so uploading them again is harmless
+	err = f.pacer.Call(func() (bool, error) {
+		opts.Body = bytes.NewReader(buf.Bytes())
+		resp, err = f.srv.Call(ctx, &opts)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if resp != nil {
+			if resp.StatusCode == 401 || resp.StatusCode == 403 {
+				return fs.ErrorPermissionDenied
+			}
+		}
+		return fmt.Errorf("failed to upload blocks: %w", err)
+	}
+	return nil
+}
+
+// commitBlocks makes the file at filePath out of the blocks already uploaded
+func (f *Fs) commitBlocks(ctx context.Context, commitLink, filePath string, size int64, blockIDs []string) (*api.FileDetail, error) {
+	fileDir, filename := path.Split(filePath)
+	blockIDsJSON, err := json.Marshal(blockIDs)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, field := range [][2]string{
+		{"parent_dir", f.opt.Enc.FromStandardPath(path.Join("/", fileDir))},
+		{"file_name", f.opt.Enc.FromStandardName(filename)},
+		{"file_size", strconv.FormatInt(size, 10)},
+		{"replace", "1"},
+		{"blockids", string(blockIDsJSON)},
+	} {
+		err = writer.WriteField(field[0], field[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	opts := rest.Opts{
+		Method:      "POST",
+		ContentType: writer.FormDataContentType(),
+	}
+	err = setUploadURL(&opts, commitLink)
+	if err != nil {
+		return nil, err
+	}
+	var result []api.FileDetail
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		opts.Body = bytes.NewReader(buf.Bytes())
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		if resp != nil {
+			if resp.StatusCode == 401 || resp.StatusCode == 403 {
+				return nil, fs.ErrorPermissionDenied
+			}
+		}
+		return nil, fmt.Errorf("failed to commit uploaded blocks: %w", err)
+	}
+	if len(result) > 0 {
+		result[0].Parent = f.opt.Enc.ToStandardPath(result[0].Parent)
+		result[0].Name = f.opt.Enc.ToStandardName(result[0].Name)
+		return &result[0], nil
+	}
+	return nil, nil
+}
+
 func (f *Fs) listShareLinks(ctx context.Context, libraryID, remote string) ([]api.SharedLink, error) {
 	// API Documentation
 	// https://download.seafile.com/published/web-api/v2.1/share-links.md#user-content-List%20Share%20Link%20of%20a%20Folder%20(File)
@@ -0,0 +1,282 @@
+//go:build !plan9
+
+package sftp
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/gssapi"
+	"github.com/jcmturner/gokrb5/v8/iana/chksumtype"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/iana/nametype"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/types"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/credential"
+	"golang.org/x/crypto/ssh"
+)
+
+// Kerberos authentication with gssapi-with-mic, see RFC 4462
+
+// gssapiClient implements ssh.GSSAPIClient using the Kerberos
+// tickets in a credentials cache.
+//
+// The same gssapiClient is used by all the connections of an Fs so
+// mu is held from the start of InitSecContext until DeleteSecContext
+// to stop connections logging in at the same time overwriting each
+// others security context.
+type gssapiClient struct {
+	spn       string // service principal to log in to or "" for host/<target>
+	getTicket func(spn string) (*credentials.Credentials, messages.Ticket, types.EncryptionKey, error)
+
+	mu     sync.Mutex
+	key    types.EncryptionKey // session key of the security context
+	seqNum uint64              // sequence number of the initiator
+}
+
+// newGSSAPIClient makes a gssapiClient for the remote name with opt
+// and registers the expiry of its tickets.
+func newGSSAPIClient(ctx context.Context, name string, opt *Options) (*gssapiClient, error) {
+	ccachePath, err := kerberosCCachePath(opt.KerberosCCache, runtime.GOOS)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't find Kerberos credentials cache: %w", err)
+	}
+	// Check the credentials are usable now to give a clear error
+	ccache, err := credentials.LoadCCache(ccachePath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read Kerberos credentials cache - run kinit first: %w", err)
+	}
+	if _, ok := kerberosTGT(ccache); !ok {
+		return nil, fmt.Errorf("no Kerberos TGT found in %q - run kinit first", ccachePath)
+	}
+	credential.Register(ctx, credential.Source{
+		Remote: name,
+		Kind:   credential.KindKerberos,
+		Expiry: func(ctx context.Context) (time.Time, error) {
+			ccache, err := credentials.LoadCCache(ccachePath)
+			if err != nil {
+				return time.Time{}, err
+			}
+			tgt, ok := kerberosTGT(ccache)
+			if !ok {
+				return time.Time{}, errors.New("no Kerberos TGT found")
+			}
+			return tgt.EndTime, nil
+		},
+	})
+	return &gssapiClient{
+		spn: opt.KerberosSPN,
+		getTicket: func(spn string) (*credentials.Credentials, messages.Ticket, types.EncryptionKey, error) {
+			// Read the cache each time so tickets renewed by kinit are used
+			cl, err := newKerberosClient(ccachePath)
+			if err != nil {
+				return nil, messages.Ticket{}, types.EncryptionKey{}, err
+			}
+			tkt, key, err := cl.GetServiceTicket(spn)
+			return cl.Credentials, tkt, key, err
+		},
+	}, nil
+}
+
+// InitSecContext makes the Kerberos AP-REQ for target which is in
+// the form host@hostname.
+//
+// Mutual authentication isn't requested so this doesn't need a reply
+// from the server. The server has already been authenticated by its
+// host key.
+func (g *gssapiClient) InitSecContext(target string, token []byte, isGSSDelegCreds bool) (outputToken []byte, needContinue bool, err error) {
+	if token != nil {
+		// Ignore a reply if the server sends one anyway
+		return nil, false, nil
+	}
+	g.mu.Lock()
+	spn := g.spn
+	if spn == "" {
+		spn = strings.Replace(target, "@", "/", 1)
+	}
+	creds, tkt, key, err := g.getTicket(spn)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get Kerberos ticket for %q: %w", spn, err)
+	}
+	auth, err := types.NewAuthenticator(creds.Domain(), creds.CName())
+	if err != nil {
+		return nil, false, err
+	}
+	auth.Cksum = types.Checksum{
+		CksumType: chksumtype.GSSAPI,
+		Checksum:  gssapiChecksum(gssapi.ContextFlagInteg),
+	}
+	apReq, err := messages.NewAPReq(tkt, key, auth)
+	if err != nil {
+		return nil, false, err
+	}
+	apReqBytes, err := apReq.Marshal()
+	if err != nil {
+		return nil, false, err
+	}
+	g.key = key
+	g.seqNum = uint64(auth.SeqNumber)
+	fs.Debugf(nil, "Logging in with Kerberos ticket for %q", spn)
+	outputToken, err = gssapiToken(apReqBytes)
+	return outputToken, false, err
+}
+
+// GetMIC signs micField with the session key
+func (g *gssapiClient) GetMIC(micField []byte) ([]byte, error) {
+	// gokrb5 only makes the RFC 4121 tokens used by the newer encryption types
+	switch g.key.KeyType {
+	case etypeID.DES_CBC_CRC, etypeID.DES_CBC_MD5, etypeID.DES3_CBC_SHA1_KD, etypeID.RC4_HMAC, etypeID.RC4_HMAC_EXP:
+		return nil, fmt.Errorf("unsupported encryption type %d in Kerberos ticket - AES is needed", g.key.KeyType)
+	}
+	token := gssapi.MICToken{
+		SndSeqNum: g.seqNum,
+		Payload:   micField,
+	}
+	err := token.SetChecksum(g.key, keyusage.GSSAPI_INITIATOR_SIGN)
+	if err != nil {
+		return nil, err
+	}
+	return token.Marshal()
+}
+
+// DeleteSecContext forgets the security context
+//
+// It is always called once the login has been tried after
+// InitSecContext.
+func (g *gssapiClient) DeleteSecContext() error {
+	g.key = types.EncryptionKey{}
+	g.seqNum = 0
+	g.mu.Unlock()
+	return nil
+}
+
+// Check interfaces
+var _ ssh.GSSAPIClient = (*gssapiClient)(nil)
+
+// gssapiChecksum makes the authenticator checksum with flags as
+// described in RFC 4121 section 4.1.1. No channel bindings are used.
+func gssapiChecksum(flags ...int) []byte {
+	b := make([]byte, 24)
+	binary.LittleEndian.PutUint32(b[0:4], 16) // length of channel bindings hash
+	var f uint32
+	for _, flag := range flags {
+		f |= uint32(flag)
+	}
+	binary.LittleEndian.PutUint32(b[20:24], f)
+	return b
+}
+
+// gssapiToken wraps the AP-REQ in the GSS-API initial context token
+// as described in RFC 2743 section 3.1 and RFC 4121 section 4.1.
+func gssapiToken(apReq []byte) ([]byte, error) {
+	// Convert as gokrb5 uses a fork of encoding/asn1
+	oid, err := asn1.Marshal(asn1.ObjectIdentifier(gssapi.OIDKRB5.OID()))
+	if err != nil {
+		return nil, err
+	}
+	inner := make([]byte, 0, len(oid)+2+len(apReq))
+	inner = append(inner, oid...)
+	inner = append(inner, 0x01, 0x00) // TOK_ID for KRB_AP_REQ
+	inner = append(inner, apReq...)
+	return asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassApplication,
+		Tag:        0,
+		IsCompound: true,
+		Bytes:      inner,
+	})
+}
+
+// newKerberosClient makes a Kerberos client from the credentials
+// cache at ccachePath and the system Kerberos config.
+func newKerberosClient(ccachePath string) (*client.Client, error) {
+	cfgPath := kerberosConfigPath(runtime.GOOS)
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read Kerberos config %q: %w", cfgPath, err)
+	}
+	ccache, err := credentials.LoadCCache(ccachePath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read Kerberos credentials cache: %w", err)
+	}
+	return client.NewFromCCache(ccache, cfg)
+}
+
+// kerberosTGT finds the ticket granting ticket in ccache
+func kerberosTGT(ccache *credentials.CCache) (*credentials.Credential, bool) {
+	return ccache.GetEntry(types.PrincipalName{
+		NameType:   nametype.KRB_NT_SRV_INST,
+		NameString: []string{"krbtgt", ccache.GetClientRealm()},
+	})
+}
+
+// kerberosCCachePath finds the path of the credentials cache on goos
+// from ccache, KRB5CCNAME or the default location.
+func kerberosCCachePath(ccache, goos string) (string, error) {
+	if ccache == "" {
+		ccache = os.Getenv("KRB5CCNAME")
+	}
+	if ccache == "" {
+		u, err := user.Current()
+		if err != nil {
+			return "", err
+		}
+		if goos == "windows" {
+			// Where kinit from Java and Heimdal put it
+			username := u.Username
+			if i := strings.LastIndex(username, `\`); i >= 0 {
+				username = username[i+1:]
+			}
+			return filepath.Join(u.HomeDir, "krb5cc_"+username), nil
+		}
+		return "/tmp/krb5cc_" + u.Uid, nil
+	}
+	prefix, path, ok := strings.Cut(ccache, ":")
+	if !ok || (goos == "windows" && len(prefix) == 1) {
+		// No type or a Windows drive letter
+		return ccache, nil
+	}
+	switch prefix {
+	case "FILE":
+		return path, nil
+	case "DIR":
+		primary, err := os.ReadFile(filepath.Join(path, "primary"))
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(path, strings.TrimSpace(string(primary))), nil
+	}
+	return "", fmt.Errorf("unsupported credentials cache %q: only FILE: and DIR: caches can be read - set KRB5CCNAME=FILE:/path/to/ccache before running kinit", ccache)
+}
+
+// kerberosConfigPath finds the Kerberos config on goos
+func kerberosConfigPath(goos string) string {
+	for cfgPath := range strings.SplitSeq(os.Getenv("KRB5_CONFIG"), string(os.PathListSeparator)) {
+		if cfgPath != "" {
+			return cfgPath
+		}
+	}
+	if goos == "windows" {
+		// Where MIT Kerberos for Windows puts it, then the older location
+		cfgPath := filepath.Join(os.Getenv("ProgramData"), "MIT", "Kerberos5", "krb5.ini")
+		if _, err := os.Stat(cfgPath); err == nil {
+			return cfgPath
+		}
+		return filepath.Join(os.Getenv("WINDIR"), "krb5.ini")
+	}
+	return "/etc/krb5.conf"
+}
@@ -0,0 +1,260 @@
+//go:build !plan9
+
+package sftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/gssapi"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/iana/nametype"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/service"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/jcmturner/gokrb5/v8/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestKerberosCCachePath(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "primary"), []byte("tkt\n"), 0600))
+	u, err := user.Current()
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		ccache  string
+		env     string
+		goos    string
+		want    string
+		wantErr bool
+	}{
+		{"FILE:/tmp/cc", "", "linux", "/tmp/cc", false},
+		{"", "FILE:/tmp/env", "linux", "/tmp/env", false},
+		{"/tmp/cc", "FILE:/tmp/env", "linux", "/tmp/cc", false},
+		{"DIR:" + dir, "", "linux", filepath.Join(dir, "tkt"), false},
+		{"", "", "linux", "/tmp/krb5cc_" + u.Uid, false},
+		{`C:\Users\me\krb5cc`, "", "windows", `C:\Users\me\krb5cc`, false},
+		{"KEYRING:persistent:1000", "", "linux", "", true},
+		{"", "MSLSA:", "windows", "", true},
+	} {
+		t.Setenv("KRB5CCNAME", test.env)
+		got, err := kerberosCCachePath(test.ccache, test.goos)
+		if test.wantErr {
+			assert.Error(t, err, test.ccache)
+			continue
+		}
+		require.NoError(t, err, test.ccache)
+		assert.Equal(t, test.want, got, test.ccache)
+	}
+
+	t.Setenv("KRB5CCNAME", "")
+	got, err := kerberosCCachePath("", "windows")
+	require.NoError(t, err)
+	assert.Equal(t, u.HomeDir, filepath.Dir(got))
+	assert.Contains(t, filepath.Base(got), "krb5cc_")
+}
+
+func TestKerberosConfigPath(t *testing.T) {
+	t.Setenv("KRB5_CONFIG", "")
+	assert.Equal(t, "/etc/krb5.conf", kerberosConfigPath("linux"))
+	t.Setenv("KRB5_CONFIG", "/path/to/krb5.conf")
+	assert.Equal(t, "/path/to/krb5.conf", kerberosConfigPath("linux"))
+}
+
+const (
+	testRealm   = "EXAMPLE.COM"
+	testService = "host/sftp.example.com"
+)
+
+// newTestGSSAPIClient makes a gssapiClient with a ticket for
+// testService with a key of etype and the keytab to check it.
+func newTestGSSAPIClient(t *testing.T, etype int32) (*gssapiClient, *keytab.Keytab, *[]string) {
+	kt := keytab.New()
+	require.NoError(t, kt.AddEntry(testService, testRealm, "service password", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96))
+	cname := types.NewPrincipalName(nametype.KRB_NT_PRINCIPAL, "user")
+	sname := types.NewPrincipalName(nametype.KRB_NT_SRV_INST, testService)
+	now := time.Now().UTC()
+	tkt, key, err := messages.NewTicket(cname, testRealm, sname, testRealm, types.NewKrbFlags(), kt, etypeID.AES256_CTS_HMAC_SHA1_96, 1, now, now, now.Add(time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	key.KeyType = etype
+	creds := credentials.New("user", testRealm)
+	var spns []string
+	g := &gssapiClient{
+		getTicket: func(spn string) (*credentials.Credentials, messages.Ticket, types.EncryptionKey, error) {
+			spns = append(spns, spn)
+			return creds, tkt, key, nil
+		},
+	}
+	return g, kt, &spns
+}
+
+func TestGSSAPIClient(t *testing.T) {
+	g, kt, spns := newTestGSSAPIClient(t, etypeID.AES256_CTS_HMAC_SHA1_96)
+
+	token, needContinue, err := g.InitSecContext("host@sftp.example.com", nil, false)
+	require.NoError(t, err)
+	assert.False(t, needContinue)
+	assert.Equal(t, []string{testService}, *spns)
+
+	// Check the acceptor can read the token
+	var krb5Token spnego.KRB5Token
+	require.NoError(t, krb5Token.Unmarshal(token))
+	require.True(t, krb5Token.IsAPReq())
+	ok, creds, err := service.VerifyAPREQ(&krb5Token.APReq, service.NewSettings(kt))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "user", creds.UserName())
+
+	// Check the MIC
+	mic, err := g.GetMIC([]byte("mic field"))
+	require.NoError(t, err)
+	var micToken gssapi.MICToken
+	require.NoError(t, micToken.Unmarshal(mic, false))
+	micToken.Payload = []byte("mic field")
+	ok, err = micToken.Verify(krb5Token.APReq.Ticket.DecryptedEncPart.Key, keyusage.GSSAPI_INITIATOR_SIGN)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(krb5Token.APReq.Authenticator.SeqNumber), micToken.SndSeqNum)
+	require.NoError(t, g.DeleteSecContext())
+
+	// Check the configured SPN is used
+	g.spn = "host/other.example.com"
+	_, _, err = g.InitSecContext("host@sftp.example.com", nil, false)
+	require.NoError(t, err)
+	require.NoError(t, g.DeleteSecContext())
+	assert.Equal(t, []string{testService, "host/other.example.com"}, *spns)
+}
+
+func TestGSSAPIClientRC4(t *testing.T) {
+	g, _, _ := newTestGSSAPIClient(t, etypeID.RC4_HMAC)
+	_, _, err := g.InitSecContext("host@sftp.example.com", nil, false)
+	require.NoError(t, err)
+	_, err = g.GetMIC([]byte("mic field"))
+	assert.ErrorContains(t, err, "AES is needed")
+	require.NoError(t, g.DeleteSecContext())
+}
+
+// testGSSAPIServer checks gssapi-with-mic logins with kt
+type testGSSAPIServer struct {
+	kt  *keytab.Keytab
+	key types.EncryptionKey
+}
+
+func (s *testGSSAPIServer) AcceptSecContext(token []byte) (outputToken []byte, srcName string, needContinue bool, err error) {
+	var krb5Token spnego.KRB5Token
+	if err := krb5Token.Unmarshal(token); err != nil {
+		return nil, "", false, err
+	}
+	ok, creds, err := service.VerifyAPREQ(&krb5Token.APReq, service.NewSettings(s.kt))
+	if err != nil {
+		return nil, "", false, err
+	}
+	if !ok {
+		return nil, "", false, errors.New("AP-REQ not valid")
+	}
+	s.key = krb5Token.APReq.Ticket.DecryptedEncPart.Key
+	return nil, creds.UserName() + "@" + creds.Domain(), false, nil
+}
+
+func (s *testGSSAPIServer) VerifyMIC(micField []byte, micToken []byte) error {
+	var token gssapi.MICToken
+	if err := token.Unmarshal(micToken, false); err != nil {
+		return err
+	}
+	token.Payload = micField
+	ok, err := token.Verify(s.key, keyusage.GSSAPI_INITIATOR_SIGN)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("MIC not valid")
+	}
+	return nil
+}
+
+func (s *testGSSAPIServer) DeleteSecContext() error {
+	return nil
+}
+
+func TestGSSAPILogin(t *testing.T) {
+	g, kt, _ := newTestGSSAPIClient(t, etypeID.AES256_CTS_HMAC_SHA1_96)
+
+	// Start an SSH server which only allows gssapi-with-mic logins
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostKey, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				config := &ssh.ServerConfig{
+					GSSAPIWithMICConfig: &ssh.GSSAPIWithMICConfig{
+						AllowLogin: func(c ssh.ConnMetadata, srcName string) (*ssh.Permissions, error) {
+							if c.User() != "user" || srcName != "user@"+testRealm {
+								return nil, errors.New("not allowed")
+							}
+							return nil, nil
+						},
+						Server: &testGSSAPIServer{kt: kt},
+					},
+				}
+				config.AddHostKey(hostKey)
+				sconn, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					_ = conn.Close()
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				go func() {
+					for newChannel := range chans {
+						_ = newChannel.Reject(ssh.Prohibited, "no channels")
+					}
+				}()
+				_ = sconn.Wait()
+			}()
+		}
+	}()
+
+	login := func(user string) error {
+		client, err := ssh.Dial("tcp", l.Addr().String(), &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{ssh.GSSAPIWithMICAuthMethod(g, "sftp.example.com")},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+		if err != nil {
+			return err
+		}
+		return client.Close()
+	}
+
+	// Log in several times at once sharing the client
+	var eg errgroup.Group
+	for range 4 {
+		eg.Go(func() error { return login("user") })
+	}
+	require.NoError(t, eg.Wait())
+
+	// The server refuses other users
+	assert.ErrorContains(t, login("other"), "unable to authenticate")
+}
@@ -0,0 +1,167 @@
+//go:build !plan9
+
+package sftp
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// newSFTPServer starts an SSH server with user "me" and password
+// "pass" which only serves the sftp subsystem. It returns its address.
+func newSFTPServer(t *testing.T) string {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "me" && string(pass) == "pass" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("bad password for %q", c.User())
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	serveChannel := func(newChannel ssh.NewChannel) {
+		channel, reqs, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = channel.Close() }()
+		for req := range reqs {
+			if req.Type != "subsystem" || string(req.Payload[4:]) != "sftp" {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			server, err := sftp.NewServer(channel)
+			if err != nil {
+				return
+			}
+			_ = server.Serve()
+			return
+		}
+	}
+	serve := func(conn net.Conn) {
+		_, chans, reqs, err := ssh.NewServerConn(conn, config)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(reqs)
+		for newChannel := range chans {
+			if newChannel.ChannelType() != "session" {
+				_ = newChannel.Reject(ssh.UnknownChannelType, "only session")
+				continue
+			}
+			go serveChannel(newChannel)
+		}
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestListR(t *testing.T) {
+	ctx := context.Background()
+	addr := newSFTPServer(t)
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+
+	// Make a tree of directories and files
+	dir := t.TempDir()
+	var want []string
+	for i := range 3 {
+		for j := range 3 {
+			subDir := fmt.Sprintf("dir%d/sub%d", i, j)
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.FromSlash(subDir)), 0777))
+			want = append(want, subDir)
+			for k := range 2 {
+				file := fmt.Sprintf("%s/file%d.txt", subDir, k)
+				require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.FromSlash(file)), []byte(file), 0666))
+				want = append(want, file)
+			}
+		}
+		want = append(want, fmt.Sprintf("dir%d", i))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "top.txt"), []byte("top"), 0666))
+	want = append(want, "top.txt")
+	sort.Strings(want)
+
+	remote := fmt.Sprintf(":sftp,host=%s,port=%s,user=me,pass=%s,shell_type=%s:%s", host, port, obscure.MustObscure("pass"), shellTypeNotSupported, filepath.ToSlash(dir))
+	fsys, err := fs.NewFs(ctx, remote)
+	require.NoError(t, err)
+	f := fsys.(*Fs)
+	defer func() { _ = f.Shutdown(ctx) }()
+
+	t.Run("All", func(t *testing.T) {
+		var got []string
+		err := f.ListR(ctx, "", func(entries fs.DirEntries) error {
+			for _, entry := range entries {
+				got = append(got, entry.Remote())
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		sort.Strings(got)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("SubDir", func(t *testing.T) {
+		var got []string
+		err := f.ListR(ctx, "dir1", func(entries fs.DirEntries) error {
+			for _, entry := range entries {
+				got = append(got, entry.Remote())
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Len(t, got, 9)
+		for _, remote := range got {
+			assert.Contains(t, want, remote)
+			assert.Regexp(t, "^dir1/", remote)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		err := f.ListR(ctx, "notfound", func(entries fs.DirEntries) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, fs.ErrorDirNotFound)
+	})
+
+	t.Run("CallbackError", func(t *testing.T) {
+		stop := errors.New("stop")
+		err := f.ListR(ctx, "", func(entries fs.DirEntries) error {
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+	})
+}
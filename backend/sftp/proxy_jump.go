@@ -0,0 +1,164 @@
+//go:build !plan9
+
+package sftp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"golang.org/x/crypto/ssh"
+)
+
+// jumpHost is an SSH server used to reach the next host in the chain
+type jumpHost struct {
+	name   string // name for logs and errors
+	addr   string // host:port to connect to
+	config *ssh.ClientConfig
+}
+
+// parseProxyJump reads the jump hosts from opt.ProxyJump.
+//
+// Hosts given as user@host:port log in with sshConfig and hosts given
+// as remote: log in with the settings of that remote.
+func (f *Fs) parseProxyJump(ctx context.Context, opt *Options, sshConfig *ssh.ClientConfig) (jumpHosts []jumpHost, err error) {
+	for _, item := range opt.ProxyJump {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		var hop jumpHost
+		if strings.HasSuffix(item, ":") {
+			hop, err = f.jumpHostFromRemote(ctx, item)
+		} else {
+			hop, err = parseJumpHost(item, opt.User, sshConfig)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("proxy_jump: %w", err)
+		}
+		jumpHosts = append(jumpHosts, hop)
+	}
+	return jumpHosts, nil
+}
+
+// parseJumpHost parses a jump host in the form user@host:port where
+// user and port are optional.
+func parseJumpHost(item, defaultUser string, sshConfig *ssh.ClientConfig) (hop jumpHost, err error) {
+	user := defaultUser
+	hostPort := item
+	if i := strings.LastIndex(item, "@"); i >= 0 {
+		user, hostPort = item[:i], item[i+1:]
+	}
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host, port = strings.Trim(hostPort, "[]"), "22"
+	}
+	if user == "" || host == "" || port == "" {
+		return hop, fmt.Errorf("can't parse jump host %q: must be user@host:port", item)
+	}
+	config := *sshConfig
+	config.User = user
+	return jumpHost{
+		name:   item,
+		addr:   net.JoinHostPort(host, port),
+		config: &config,
+	}, nil
+}
+
+// jumpHostFromRemote makes a jump host from the sftp remote remoteName
+// which should end in a colon.
+func (f *Fs) jumpHostFromRemote(ctx context.Context, remoteName string) (hop jumpHost, err error) {
+	fsInfo, configName, _, connectionStringConfig, err := fs.ParseRemote(remoteName)
+	if err != nil {
+		return hop, err
+	}
+	// Don't show the parameters of connection strings as they may have passwords
+	name := configName + ":"
+	if len(connectionStringConfig) != 0 {
+		name = configName + ",...:"
+	}
+	if fsInfo.Name != "sftp" {
+		return hop, fmt.Errorf("jump host %q must be an sftp remote not %s", name, fsInfo.Name)
+	}
+	m := fs.ConfigMap(fsInfo.Prefix, fsInfo.Options, configName, connectionStringConfig)
+	opt := new(Options)
+	err = configstruct.Set(m, opt)
+	if err != nil {
+		return hop, fmt.Errorf("jump host %q: %w", name, err)
+	}
+	if len(opt.SSH) != 0 {
+		return hop, fmt.Errorf("jump host %q can't use the ssh option", name)
+	}
+	if opt.Host == "" {
+		return hop, fmt.Errorf("jump host %q has no host set", name)
+	}
+	if opt.User == "" {
+		opt.User = currentUser
+	}
+	if opt.Port == "" {
+		opt.Port = "22"
+	}
+	addr := net.JoinHostPort(opt.Host, opt.Port)
+	if len(connectionStringConfig) != 0 {
+		name = opt.User + "@" + addr
+	}
+	// Use a separate Fs so the jump host asks for its own password
+	hopFs := &Fs{
+		name: configName,
+		ci:   f.ci,
+		opt:  *opt,
+		url:  "sftp://" + opt.User + "@" + addr,
+	}
+	config, err := hopFs.newSSHConfig(ctx, configName, opt)
+	if err != nil {
+		return hop, fmt.Errorf("jump host %q: %w", name, err)
+	}
+	return jumpHost{
+		name:   name,
+		addr:   addr,
+		config: config,
+	}, nil
+}
+
+// dialJumpHosts logs in to each of the jump hosts in turn starting
+// with conn, which must be connected to the first of them, and
+// returns a connection to addr through the last one.
+//
+// It returns the clients for the jump hosts which must be closed once
+// the returned connection is finished with.
+func (f *Fs) dialJumpHosts(ctx context.Context, conn net.Conn, network, addr string) (_ net.Conn, clients []*ssh.Client, err error) {
+	defer func() {
+		if err != nil {
+			closeJumpClients(clients)
+			clients = nil
+		}
+	}()
+	for i, hop := range f.jumpHosts {
+		next := addr
+		if i+1 < len(f.jumpHosts) {
+			next = f.jumpHosts[i+1].addr
+		}
+		c, chans, reqs, err := ssh.NewClientConn(conn, hop.addr, hop.config)
+		if err != nil {
+			return nil, clients, fmt.Errorf("failed to log in to jump host %q: %w", hop.name, err)
+		}
+		fs.Debugf(f, "New connection %s->%s to jump host %q (%q)", c.LocalAddr(), c.RemoteAddr(), hop.name, c.ServerVersion())
+		client := ssh.NewClient(c, chans, reqs)
+		clients = append(clients, client)
+		conn, err = client.DialContext(ctx, network, next)
+		if err != nil {
+			return nil, clients, fmt.Errorf("jump host %q failed to connect to %s: %w", hop.name, next, err)
+		}
+	}
+	return conn, clients, nil
+}
+
+// closeJumpClients closes the jump host clients, last first
+func closeJumpClients(clients []*ssh.Client) {
+	for i := len(clients) - 1; i >= 0; i-- {
+		_ = clients[i].Close()
+	}
+}
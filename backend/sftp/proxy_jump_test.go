@@ -0,0 +1,205 @@
+//go:build !plan9
+
+package sftp
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseJumpHost(t *testing.T) {
+	sshConfig := &ssh.ClientConfig{User: "me"}
+	for _, test := range []struct {
+		in       string
+		wantUser string
+		wantAddr string
+		wantErr  bool
+	}{
+		{"bastion", "me", "bastion:22", false},
+		{"bastion:2222", "me", "bastion:2222", false},
+		{"jump@bastion", "jump", "bastion:22", false},
+		{"jump@example.com@bastion:2222", "jump@example.com", "bastion:2222", false},
+		{"jump@[::1]:2222", "jump", "[::1]:2222", false},
+		{"[::1]", "me", "[::1]:22", false},
+		{"@bastion", "", "", true},
+		{"jump@", "", "", true},
+	} {
+		hop, err := parseJumpHost(test.in, "me", sshConfig)
+		if test.wantErr {
+			assert.Error(t, err, test.in)
+			continue
+		}
+		require.NoError(t, err, test.in)
+		assert.Equal(t, test.wantUser, hop.config.User, test.in)
+		assert.Equal(t, test.wantAddr, hop.addr, test.in)
+	}
+	assert.Equal(t, "me", sshConfig.User, "original unchanged")
+}
+
+// jumpLog records the connections forwarded by the test servers
+type jumpLog struct {
+	mu    sync.Mutex
+	conns []string
+}
+
+func (l *jumpLog) add(format string, a ...any) {
+	l.mu.Lock()
+	l.conns = append(l.conns, fmt.Sprintf(format, a...))
+	l.mu.Unlock()
+}
+
+// newJumpServer starts an SSH server which accepts user and password
+// and forwards direct-tcpip channels. It returns its address.
+func newJumpServer(t *testing.T, name, user, password string, log *jumpLog) string {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == user && string(pass) == password {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("bad password for %q", c.User())
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	forward := func(newChannel ssh.NewChannel) {
+		var dst struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &dst); err != nil {
+			_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			return
+		}
+		addr := net.JoinHostPort(dst.Host, strconv.Itoa(int(dst.Port)))
+		out, err := net.Dial("tcp", addr)
+		if err != nil {
+			_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			return
+		}
+		channel, reqs, err := newChannel.Accept()
+		if err != nil {
+			_ = out.Close()
+			return
+		}
+		log.add("%s->%s", name, addr)
+		go ssh.DiscardRequests(reqs)
+		go func() {
+			_, _ = io.Copy(out, channel)
+			_ = out.Close()
+		}()
+		_, _ = io.Copy(channel, out)
+		_ = channel.Close()
+	}
+	serve := func(conn net.Conn) {
+		_, chans, reqs, err := ssh.NewServerConn(conn, config)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(reqs)
+		for newChannel := range chans {
+			if newChannel.ChannelType() != "direct-tcpip" {
+				_ = newChannel.Reject(ssh.UnknownChannelType, "only direct-tcpip")
+				continue
+			}
+			go forward(newChannel)
+		}
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestProxyJump(t *testing.T) {
+	ctx := context.Background()
+	var log jumpLog
+	jump1 := newJumpServer(t, "jump1", "jump1", "pass", &log)
+	jump2 := newJumpServer(t, "jump2", "jump2", "secret2", &log)
+	target := newJumpServer(t, "target", "me", "pass", &log)
+
+	jump2Host, jump2Port, err := net.SplitHostPort(jump2)
+	require.NoError(t, err)
+	jump2Remote := func(password string) string {
+		return fmt.Sprintf(":sftp,host=%s,port=%s,user=jump2,pass=%s:", jump2Host, jump2Port, obscure.MustObscure(password))
+	}
+
+	newFs := func(proxyJump ...string) (*Fs, *ssh.ClientConfig) {
+		f := &Fs{ci: fs.GetConfig(ctx)}
+		f.opt = Options{
+			User:      "me",
+			Pass:      obscure.MustObscure("pass"),
+			ProxyJump: proxyJump,
+		}
+		sshConfig, err := f.newSSHConfig(ctx, "test", &f.opt)
+		require.NoError(t, err)
+		f.jumpHosts, err = f.parseProxyJump(ctx, &f.opt, sshConfig)
+		require.NoError(t, err)
+		return f, sshConfig
+	}
+
+	t.Run("Chain", func(t *testing.T) {
+		log.conns = nil
+		f, sshConfig := newFs("jump1@"+jump1, jump2Remote("secret2"))
+		require.Len(t, f.jumpHosts, 2)
+		assert.Equal(t, "jump2", f.jumpHosts[1].config.User)
+
+		c, err := f.newSSHClientInternal(ctx, "tcp", target, sshConfig)
+		require.NoError(t, err)
+		client := c.(sshClientInternal)
+		assert.Equal(t, "me", client.srv.User())
+		assert.Equal(t, []string{"jump1->" + jump2, "jump2->" + target}, log.conns)
+
+		// Closing the client closes the jump hosts
+		require.Len(t, client.jumps, 2)
+		require.NoError(t, c.Close())
+		for _, jump := range client.jumps {
+			_ = jump.Wait()
+		}
+	})
+
+	t.Run("BadAuth", func(t *testing.T) {
+		log.conns = nil
+		f, sshConfig := newFs("jump1@"+jump1, jump2Remote("wrong"))
+		_, err := f.newSSHClientInternal(ctx, "tcp", target, sshConfig)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `failed to log in to jump host "jump2@`+jump2+`"`)
+		assert.NotContains(t, err.Error(), "pass=")
+		assert.Equal(t, []string{"jump1->" + jump2}, log.conns)
+	})
+
+	t.Run("BadRemote", func(t *testing.T) {
+		f := &Fs{ci: fs.GetConfig(ctx)}
+		f.opt = Options{User: "me", ProxyJump: fs.CommaSepList{":sftp,user=jump:"}}
+		_, err := f.parseProxyJump(ctx, &f.opt, &ssh.ClientConfig{})
+		assert.ErrorContains(t, err, `jump host ":sftp,...:" has no host set`)
+	})
+}
@@ -0,0 +1,194 @@
+//go:build !plan9
+
+package sftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+)
+
+// Ways of renaming files and directories on the server
+const (
+	renamePosix = "posix-rename" // the posix-rename@openssh.com extension
+	renamePlain = "rename"       // the SFTP rename removing the destination first
+	renameCopy  = "copy"         // give up so rclone copies then deletes
+)
+
+// errRenameUnsupported is returned when the server doesn't support
+// a rename strategy
+var errRenameUnsupported = errors.New("not supported by the server")
+
+// checkRenameStrategy checks the rename_strategy option
+func checkRenameStrategy(strategies fs.CommaSepList) error {
+	if len(strategies) == 0 {
+		return errors.New("rename_strategy must have at least one entry")
+	}
+	for _, strategy := range strategies {
+		switch strategy {
+		case renamePosix, renamePlain, renameCopy:
+		default:
+			return fmt.Errorf("unknown rename_strategy %q - must be %q, %q or %q", strategy, renamePosix, renamePlain, renameCopy)
+		}
+	}
+	return nil
+}
+
+// renameStats counts the renames done by each strategy
+type renameStats struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// add counts a rename done by strategy
+func (s *renameStats) add(strategy string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts == nil {
+		s.counts = make(map[string]int)
+	}
+	s.counts[strategy]++
+}
+
+// String returns the counts for logging
+func (s *renameStats) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []string
+	for strategy, count := range s.counts {
+		out = append(out, fmt.Sprintf("%s: %d", strategy, count))
+	}
+	sort.Strings(out)
+	return strings.Join(out, ", ")
+}
+
+// log logs the number of renames done by each strategy, at info
+// level if any had to fall back from the first strategy
+func (s *renameStats) log(f *Fs) {
+	s.mu.Lock()
+	fellBack := false
+	for strategy := range s.counts {
+		if strategy != f.opt.RenameStrategy[0] {
+			fellBack = true
+		}
+	}
+	s.mu.Unlock()
+	if fellBack {
+		fs.Infof(f, "Renames done by strategy: %v", s)
+	} else if len(s.counts) > 0 {
+		fs.Debugf(f, "Renames done by strategy: %v", s)
+	}
+}
+
+// rename renames srcPath to dstPath with strategy
+func (f *Fs) rename(ctx context.Context, strategy, srcPath, dstPath string, isDir bool) error {
+	c, err := f.getSftpConnection(ctx)
+	if err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	switch strategy {
+	case renamePosix:
+		if _, ok := c.sftpClient.HasExtension("posix-rename@openssh.com"); !ok {
+			f.putSftpConnection(&c, nil)
+			return errRenameUnsupported
+		}
+		err = c.sftpClient.PosixRename(srcPath, dstPath)
+	case renamePlain:
+		// Plain renames fail if the destination exists so remove it first
+		if !isDir {
+			err = c.sftpClient.Remove(dstPath)
+			if err != nil && !errors.Is(err, iofs.ErrNotExist) {
+				fs.Errorf(f, "Move: Failed to remove existing file %q: %v", dstPath, err)
+			}
+		}
+		err = c.sftpClient.Rename(srcPath, dstPath)
+	default:
+		err = fmt.Errorf("can't rename with %q", strategy)
+	}
+	f.putSftpConnection(&c, err)
+	return err
+}
+
+// verifyRename checks the rename of srcPath to dstPath worked as some
+// servers report success for renames across file systems which fail.
+//
+// size is the size the destination should be or -1 for a directory.
+// It returns fatal if the source has gone so no other strategy can be
+// tried.
+func (f *Fs) verifyRename(ctx context.Context, srcPath, dstPath string, size int64) (fatal bool, err error) {
+	c, err := f.getSftpConnection(ctx)
+	if err != nil {
+		return false, fmt.Errorf("verify rename: %w", err)
+	}
+	_, srcErr := c.sftpClient.Stat(srcPath)
+	info, dstErr := c.sftpClient.Stat(dstPath)
+	f.putSftpConnection(&c, nil)
+	if srcErr != nil && !os.IsNotExist(srcErr) {
+		return false, fmt.Errorf("failed to check source after rename: %w", srcErr)
+	}
+	srcGone := srcErr != nil
+	switch {
+	case dstErr != nil && os.IsNotExist(dstErr):
+		err = errors.New("destination missing after rename")
+	case dstErr != nil:
+		return false, fmt.Errorf("failed to check destination after rename: %w", dstErr)
+	case size < 0 && !info.IsDir():
+		err = errors.New("destination isn't a directory after rename")
+	case size >= 0 && (info.IsDir() || info.Size() != size):
+		err = fmt.Errorf("destination is the wrong size after rename: want %d got %d", size, info.Size())
+	case !srcGone:
+		err = errors.New("source still exists after rename")
+	}
+	if err != nil && srcGone {
+		return true, fmt.Errorf("%w and source has gone", err)
+	}
+	return false, err
+}
+
+// renameWithStrategies renames srcPath to dstPath trying each of the
+// rename strategies in turn.
+//
+// It returns errCantRename if the copy strategy is reached or none of
+// the strategies are supported.
+func (f *Fs) renameWithStrategies(ctx context.Context, what any, srcPath, dstPath string, size int64, errCantRename error) error {
+	var lastErr error
+	for _, strategy := range f.opt.RenameStrategy {
+		if strategy == renameCopy {
+			fs.Debugf(what, "Falling back to copy and delete to move to %q", dstPath)
+			f.renames.add(renameCopy)
+			return errCantRename
+		}
+		err := f.rename(ctx, strategy, srcPath, dstPath, size < 0)
+		if errors.Is(err, errRenameUnsupported) {
+			fs.Debugf(what, "Not renaming with %s: %v", strategy, err)
+			continue
+		}
+		if err == nil && f.opt.VerifyRename {
+			var fatal bool
+			fatal, err = f.verifyRename(ctx, srcPath, dstPath, size)
+			if fatal {
+				return fserrors.NoRetryError(fmt.Errorf("rename with %s failed verification: %w", strategy, err))
+			}
+		}
+		if err != nil {
+			fs.Debugf(what, "Rename with %s failed: %v", strategy, err)
+			lastErr = err
+			continue
+		}
+		fs.Debugf(what, "Renamed to %q with %s", dstPath, strategy)
+		f.renames.add(strategy)
+		return nil
+	}
+	if lastErr == nil {
+		return errCantRename
+	}
+	return lastErr
+}
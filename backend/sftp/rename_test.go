@@ -0,0 +1,105 @@
+//go:build !plan9
+
+package sftp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRenameTestFs makes an Fs on a test server using the rename
+// strategy given, returning it and the directory it is serving
+func newRenameTestFs(t *testing.T, strategy string) (*Fs, string) {
+	ctx := context.Background()
+	host, port, err := net.SplitHostPort(newSFTPServer(t))
+	require.NoError(t, err)
+	dir := t.TempDir()
+	remote := fmt.Sprintf(":sftp,host=%s,port=%s,user=me,pass=%s,shell_type=%s,rename_strategy=%q:%s", host, port, obscure.MustObscure("pass"), shellTypeNotSupported, strategy, filepath.ToSlash(dir))
+	fsys, err := fs.NewFs(ctx, remote)
+	require.NoError(t, err)
+	f := fsys.(*Fs)
+	t.Cleanup(func() { _ = f.Shutdown(ctx) })
+	return f, dir
+}
+
+func TestRenameStrategy(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		strategy string
+		wantErr  error
+	}{
+		{strategy: "posix-rename,rename,copy"},
+		{strategy: "rename"},
+		{strategy: "copy,rename", wantErr: fs.ErrorCantMove},
+	} {
+		t.Run(test.strategy, func(t *testing.T) {
+			f, dir := newRenameTestFs(t, test.strategy)
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "src.txt"), []byte("hello"), 0666))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "dst.txt"), []byte("old"), 0666))
+			src, err := f.NewObject(ctx, "src.txt")
+			require.NoError(t, err)
+
+			dst, err := f.Move(ctx, src, "dst.txt")
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				assert.Equal(t, "copy: 1", f.renames.String())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(5), dst.Size())
+			data, err := os.ReadFile(filepath.Join(dir, "dst.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, "hello", string(data))
+			assert.NoFileExists(t, filepath.Join(dir, "src.txt"))
+			first := f.opt.RenameStrategy[0]
+			assert.Equal(t, first+": 1", f.renames.String())
+
+			// Directories are renamed the same way
+			require.NoError(t, os.Mkdir(filepath.Join(dir, "dir"), 0777))
+			require.NoError(t, f.DirMove(ctx, f, "dir", "newdir"))
+			assert.DirExists(t, filepath.Join(dir, "newdir"))
+			assert.Equal(t, first+": 2", f.renames.String())
+		})
+	}
+
+	_, err := fs.NewFs(ctx, ":sftp,host=localhost,rename_strategy=potato:")
+	assert.ErrorContains(t, err, "unknown rename_strategy")
+}
+
+func TestVerifyRename(t *testing.T) {
+	ctx := context.Background()
+	f, dir := newRenameTestFs(t, "rename")
+	abs := func(name string) string { return path.Join(filepath.ToSlash(dir), name) }
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("hello"), 0666))
+
+	// Worked
+	fatal, err := f.verifyRename(ctx, abs("gone.txt"), abs("a.txt"), 5)
+	assert.False(t, fatal)
+	assert.NoError(t, err)
+
+	// Source still there so the next strategy can be tried
+	fatal, err = f.verifyRename(ctx, abs("a.txt"), abs("b.txt"), 5)
+	assert.False(t, fatal)
+	assert.ErrorContains(t, err, "source still exists")
+
+	// Destination missing and source gone
+	fatal, err = f.verifyRename(ctx, abs("gone.txt"), abs("missing.txt"), 5)
+	assert.True(t, fatal)
+	assert.ErrorContains(t, err, "destination missing")
+
+	// Destination wrong size
+	fatal, err = f.verifyRename(ctx, abs("a.txt"), abs("b.txt"), 6)
+	assert.False(t, fatal)
+	assert.ErrorContains(t, err, "wrong size")
+}
@@ -27,10 +27,13 @@ import (
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/configstruct"
 	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/credential"
 	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/list"
 	"github.com/rclone/rclone/lib/encoder"
 	"github.com/rclone/rclone/lib/env"
 	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/ratelimit"
 	"github.com/rclone/rclone/lib/readers"
 	sshagent "github.com/xanzy/ssh-agent"
 	"golang.org/x/crypto/ssh"
@@ -41,6 +44,7 @@ const (
 	defaultShellType        = "unix"
 	shellTypeNotSupported   = "none"
 	hashCommandNotSupported = "none"
+	shellPathPlaceholder    = "{path}" // replaced by the path in shell commands
 	minSleep                = 100 * time.Millisecond
 	maxSleep                = 2 * time.Second
 	decayConstant           = 2           // bigger for slower decay, exponential
@@ -58,7 +62,7 @@ func init() {
 		Name:        "sftp",
 		Description: "SSH/SFTP",
 		NewFs:       NewFs,
-		Options: []fs.Option{{
+		Options: append([]fs.Option{{
 			Name:      "host",
 			Help:      "SSH host to connect to.\n\nE.g. \"example.com\".",
 			Required:  true,
@@ -129,6 +133,48 @@ When key-file is also set, the ".pub" file of the specified key-file is read and
 requested from the ssh-agent. This allows to avoid ` + "`Too many authentication failures for *username*`" + ` errors
 when the ssh-agent contains many keys.`,
 			Default: false,
+		}, {
+			Name: "use_kerberos",
+			Help: `Use Kerberos (GSSAPI) authentication.
+
+If set, rclone will try to log in with the Kerberos tickets of the
+user before the other authentication methods. This is the
+gssapi-with-mic method, called GSSAPIAuthentication in OpenSSH.
+
+This requires a valid Kerberos configuration and credentials cache,
+so run kinit first. These are read from the default locations or as
+specified by the KRB5_CONFIG and KRB5CCNAME environment variables or
+the kerberos_ccache option.
+
+The tickets must use AES encryption. Tickets from an Active Directory
+KDC can be used if they aren't using the older RC4 encryption.`,
+			Default: false,
+		}, {
+			Name: "kerberos_ccache",
+			Help: `Path to the Kerberos credential cache (krb5cc).
+
+Overrides the KRB5CCNAME environment variable and the default
+location, which is /tmp/krb5cc_<uid> on Unix and
+%USERPROFILE%\krb5cc_<user> on Windows.
+
+Supported formats:
+  - FILE:/path/to/ccache   – Use the specified file.
+  - DIR:/path/to/ccachedir – Use the primary file inside the specified directory.
+  - /path/to/ccache        – Interpreted as a file path.
+
+Other credential caches, like KEYRING: or the Windows LSA cache, can't
+be read, so set KRB5CCNAME or this to a file when running kinit.`,
+			Advanced: true,
+		}, {
+			Name: "kerberos_spn",
+			Help: `Kerberos service principal name of the server.
+
+Leave blank to use host/<host>, where host is the host option. Set
+this if you connect to the server with an IP address or a name which
+isn't its canonical name, e.g.
+
+    host/sftp.example.com`,
+			Advanced: true,
 		}, {
 			Name: "use_insecure_cipher",
 			Help: `Enable the use of insecure ciphers and key exchange methods.
@@ -263,6 +309,34 @@ E.g. the second example above should be rewritten as:
 			Default:  "",
 			Help:     "The command used to read XXH128 hashes.\n\nLeave blank for autodetect.",
 			Advanced: true,
+		}, {
+			Name:    "about_command",
+			Default: "",
+			Help: `The command used to read the disk usage.
+
+Leave blank to use the default for the shell type, which is ` + "`df -k`" + ` for
+Unix shells. This is only used if the server doesn't support the SFTP
+statvfs extension.
+
+The output must be in the format of ` + "`df -k`" + `, a header line followed
+by a line with the filesystem name, then the total, used and available
+space in KiB.
+
+The path is quoted for the shell type and added to the end of the
+command, or replaces ` + "`{path}`" + ` if the command contains it.`,
+			Advanced: true,
+		}, {
+			Name:    "mkdir_command",
+			Default: "",
+			Help: `The command used to make a directory if the SFTP server can't.
+
+If set, this command is run when making a directory over SFTP fails.
+It should make any missing parent directories too, for example
+` + "`mkdir -p`" + `.
+
+The path is quoted for the shell type and added to the end of the
+command, or replaces ` + "`{path}`" + ` if the command contains it.`,
+			Advanced: true,
 		}, {
 			Name:     "skip_links",
 			Default:  false,
@@ -520,6 +594,33 @@ Example:
 			Help: `URL for HTTP CONNECT proxy
 
 Set this to a URL for an HTTP proxy which supports the HTTP CONNECT verb.
+`,
+			Advanced: true,
+		}, {
+			Name:    "proxy_jump",
+			Default: fs.CommaSepList{},
+			Help: `Comma separated list of SSH jump hosts to connect through.
+
+This is like the ProxyJump option of OpenSSH. Rclone connects to each
+jump host in turn, using it to connect to the next one, and the last
+one is used to connect to the host. The connection to the first jump
+host uses the socks_proxy or http_proxy if set.
+
+Each jump host can be given as
+
+- user@host:port - the user and port are optional and default to the
+  user of this remote and 22. The authentication settings of this
+  remote are used to log in.
+- remote: - the name of another sftp remote ending in a colon. Its
+  host, user, port, authentication and known_hosts_file settings are
+  used to log in to the jump host, so each hop can have its own
+  credentials.
+
+Example:
+
+    bastion.example.com,jump@10.0.0.1:2222,internal-jump:
+
+This can't be used with the ssh option.
 `,
 			Advanced: true,
 		}, {
@@ -542,12 +643,42 @@ as the source and the destination will be the same file.
 
 This feature may be useful backups made with --copy-dest.`,
 			Advanced: true,
+		}, {
+			Name:    "rename_strategy",
+			Default: fs.CommaSepList{renamePosix, renamePlain, renameCopy},
+			Help: `Comma separated list of ways to try to move files and directories.
+
+Each is tried in turn until one works.
+
+- posix-rename - use the posix-rename@openssh.com extension, which
+  replaces the destination in one step, if the server has it.
+- rename - remove the destination then use the SFTP rename.
+- copy - don't move on the server. Rclone copies the file then
+  deletes the source instead.
+
+Some servers report success for renames which fail, for example
+across file systems, so each rename is checked, see verify_rename.
+
+Remove copy from the list to make moves fail rather than copy when
+the server can't rename.`,
+			Advanced: true,
+		}, {
+			Name:    "verify_rename",
+			Default: true,
+			Help: `Check renames worked before trusting them.
+
+After a rename the destination is checked to exist with the right
+size and the source to have gone. If not the next rename strategy is
+tried.
+
+This costs two stat calls per rename.`,
+			Advanced: true,
 		}, {
 			Name:     config.ConfigEncoding,
 			Help:     config.ConfigEncodingHelp,
 			Advanced: true,
 			Default:  encoder.EncodeZero,
-		}},
+		}}, ratelimit.SharedOptions...),
 	}
 	fs.Register(fsi)
 }
@@ -565,6 +696,9 @@ type Options struct {
 	PubKeyFile              string               `config:"pubkey_file"`
 	KnownHostsFile          string               `config:"known_hosts_file"`
 	KeyUseAgent             bool                 `config:"key_use_agent"`
+	UseKerberos             bool                 `config:"use_kerberos"`
+	KerberosCCache          string               `config:"kerberos_ccache"`
+	KerberosSPN             string               `config:"kerberos_spn"`
 	UseInsecureCipher       bool                 `config:"use_insecure_cipher"`
 	DisableHashCheck        bool                 `config:"disable_hashcheck"`
 	AskPassword             bool                 `config:"ask_password"`
@@ -579,6 +713,8 @@ type Options struct {
 	Blake3sumCommand        string               `config:"blake3sum_command"`
 	Xxh3sumCommand          string               `config:"xxh3sum_command"`
 	Xxh128sumCommand        string               `config:"xxh128sum_command"`
+	AboutCommand            string               `config:"about_command"`
+	MkdirCommand            string               `config:"mkdir_command"`
 	SkipLinks               bool                 `config:"skip_links"`
 	Subsystem               string               `config:"subsystem"`
 	ServerCommand           string               `config:"server_command"`
@@ -597,8 +733,12 @@ type Options struct {
 	SSH                     fs.SpaceSepList      `config:"ssh"`
 	SocksProxy              string               `config:"socks_proxy"`
 	HTTPProxy               string               `config:"http_proxy"`
+	ProxyJump               fs.CommaSepList      `config:"proxy_jump"`
 	CopyIsHardlink          bool                 `config:"copy_is_hardlink"`
+	RenameStrategy          fs.CommaSepList      `config:"rename_strategy"`
+	VerifyRename            bool                 `config:"verify_rename"`
 	Enc                     encoder.MultiEncoder `config:"encoding"`
+	ratelimit.Options
 }
 
 // Fs stores the interface to the remote SFTP files
@@ -618,12 +758,15 @@ type Fs struct {
 	cachedHashes *hash.Set
 	poolMu       sync.Mutex
 	pool         []*conn
-	drain        *time.Timer // used to drain the pool when we stop using the connections
-	pacer        *fs.Pacer   // pacer for operations
+	drain        *time.Timer        // used to drain the pool when we stop using the connections
+	pacer        *fs.Pacer          // pacer for operations
+	limiter      *ratelimit.Limiter // per remote tpslimit and bwlimit
 	savedpswd    string
 	sessions     atomic.Int32 // count in use sessions
 	tokens       *pacer.TokenDispenser
-	proxyURL     *url.URL // address of HTTP proxy read from environment
+	proxyURL     *url.URL    // address of HTTP proxy read from environment
+	jumpHosts    []jumpHost  // jump hosts to connect through
+	renames      renameStats // renames done by each strategy
 }
 
 // Object is a remote SFTP file that has been stat'd (so it exists, but is not necessarily open for reading)
@@ -791,6 +934,7 @@ func (f *Fs) newSftpClient(client sshClient, opts ...sftp.ClientOption) (*sftp.C
 // Get an SFTP connection from the pool, or open a new one
 func (f *Fs) getSftpConnection(ctx context.Context) (c *conn, err error) {
 	accounting.LimitTPS(ctx)
+	f.limiter.Wait(ctx)
 	if f.opt.Connections > 0 {
 		f.tokens.Get()
 	}
@@ -914,6 +1058,10 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, err
 	}
+	err = checkRenameStrategy(opt.RenameStrategy)
+	if err != nil {
+		return nil, err
+	}
 	if len(opt.SSH) != 0 && ((opt.User != currentUser && opt.User != "") || opt.Host != "" || (opt.Port != "22" && opt.Port != "")) {
 		fs.Logf(name, "--sftp-ssh is in use - ignoring user/host/port from config - set in the parameters to --sftp-ssh (remove them from the config to silence this warning)")
 	}
@@ -935,7 +1083,28 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		f.proxyURL = proxyURL
 	}
 
-	sshConfig := &ssh.ClientConfig{
+	sshConfig, err := f.newSSHConfig(ctx, name, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opt.ProxyJump) != 0 {
+		if len(opt.SSH) != 0 {
+			return nil, errors.New("proxy_jump can't be used with ssh - use the -J flag of ssh instead")
+		}
+		f.jumpHosts, err = f.parseProxyJump(ctx, opt, sshConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewFsWithConnection(ctx, f, name, root, m, opt, sshConfig)
+}
+
+// newSSHConfig makes the ssh.ClientConfig to log in with the settings
+// in opt. name is the name of the remote they were read from.
+func (f *Fs) newSSHConfig(ctx context.Context, name string, opt *Options) (sshConfig *ssh.ClientConfig, err error) {
+	sshConfig = &ssh.ClientConfig{
 		User:            opt.User,
 		Auth:            []ssh.AuthMethod{},
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
@@ -979,8 +1148,17 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	keyFile := env.ShellExpand(opt.KeyFile)
 	pubkeyFile := env.ShellExpand(opt.PubKeyFile)
 	//keyPem := env.ShellExpand(opt.KeyPem)
-	// Add ssh agent-auth if no password or file or key PEM specified
-	if (len(opt.SSH) == 0 && opt.Pass == "" && keyFile == "" && !opt.AskPassword && opt.KeyPem == "") || opt.KeyUseAgent {
+	// Add Kerberos auth first if requested as it doesn't need user input
+	if opt.UseKerberos {
+		gssapi, err := newGSSAPIClient(ctx, name, opt)
+		if err != nil {
+			return nil, err
+		}
+		sshConfig.Auth = append(sshConfig.Auth, ssh.GSSAPIWithMICAuthMethod(gssapi, opt.Host))
+	}
+
+	// Add ssh agent-auth if no password or file or key PEM or Kerberos specified
+	if (len(opt.SSH) == 0 && opt.Pass == "" && keyFile == "" && !opt.AskPassword && opt.KeyPem == "" && !opt.UseKerberos) || opt.KeyUseAgent {
 		sshAgentClient, _, err := sshagent.New()
 		if err != nil {
 			return nil, fmt.Errorf("couldn't connect to ssh-agent: %w", err)
@@ -1105,6 +1283,17 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 			if err != nil {
 				return nil, fmt.Errorf("error generating cert signer: %w", err)
 			}
+			// Certificates can't be refreshed so warn before they expire
+			if cert.ValidBefore != ssh.CertTimeInfinity {
+				expiry := time.Unix(int64(cert.ValidBefore), 0)
+				credential.Register(ctx, credential.Source{
+					Remote: name,
+					Kind:   credential.KindSSHCert,
+					Expiry: func(ctx context.Context) (time.Time, error) {
+						return expiry, nil
+					},
+				})
+			}
 			sshConfig.Auth = append(sshConfig.Auth, ssh.PublicKeys(pubsigner))
 		} else {
 			sshConfig.Auth = append(sshConfig.Auth, ssh.PublicKeys(signer))
@@ -1137,7 +1326,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		)
 	}
 
-	return NewFsWithConnection(ctx, f, name, root, m, opt, sshConfig)
+	return sshConfig, nil
 }
 
 // Do the keyboard interactive challenge
@@ -1181,6 +1370,7 @@ func NewFsWithConnection(ctx context.Context, f *Fs, name string, root string, m
 	f.url = "sftp://" + opt.User + "@" + opt.Host + ":" + opt.Port + "/" + root
 	f.mkdirLock = newStringLock()
 	f.pacer = fs.NewPacer(ctx, pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant)))
+	f.limiter = ratelimit.New(name, &opt.Options)
 	f.savedpswd = ""
 	// set the pool drainer timer going
 	if f.opt.IdleTimeout > 0 {
@@ -1419,6 +1609,85 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 	return entries, nil
 }
 
+// ListR lists the objects and directories of the Fs starting
+// from dir recursively into out.
+//
+// dir should be "" to start from the root, and should not
+// have trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+//
+// It should call callback for each tranche of entries read.
+// These need not be returned in any particular order.  If
+// callback returns an error then the listing will stop
+// immediately.
+//
+// SFTP reads a directory in sequence over a single handle, so this
+// reads up to --checkers directories at once, each with its own
+// connection from the pool.
+func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (err error) {
+	var (
+		mu      sync.Mutex // protects the variables below and list
+		cond    = sync.NewCond(&mu)
+		pending = []string{dir} // directories waiting to be listed
+		active  = 0             // directories being listed
+		wg      sync.WaitGroup
+		list    = list.NewHelper(callback)
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for range max(f.ci.Checkers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				for len(pending) == 0 && active > 0 && err == nil {
+					cond.Wait()
+				}
+				if len(pending) == 0 || err != nil {
+					mu.Unlock()
+					return
+				}
+				// Take the newest directory so the tree is walked
+				// depth first which keeps pending short
+				job := pending[len(pending)-1]
+				pending = pending[:len(pending)-1]
+				active++
+				mu.Unlock()
+
+				entries, listErr := f.List(ctx, job)
+
+				mu.Lock()
+				active--
+				if listErr == nil && err == nil {
+					for _, entry := range entries {
+						if d, ok := entry.(fs.Directory); ok {
+							pending = append(pending, d.Remote())
+						}
+						listErr = list.Add(entry)
+						if listErr != nil {
+							break
+						}
+					}
+				}
+				if listErr != nil && err == nil {
+					err = listErr
+					cancel()
+				}
+				cond.Broadcast()
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	return list.Flush()
+}
+
 // Put data from <in> into a new remote sftp file object described by <src.Remote()> and <src.ModTime(ctx)>
 func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
 	err := f.mkParentDir(ctx, src.Remote())
@@ -1479,6 +1748,28 @@ func (f *Fs) mkdir(ctx context.Context, dirPath string) error {
 			fs.Debugf(f, "directory %q exists after Mkdir is attempted", dirPath)
 			return nil
 		}
+		if f.opt.MkdirCommand != "" {
+			return f.mkdirCommand(ctx, dirPath, err)
+		}
+		return fmt.Errorf("mkdir %q failed: %w", dirPath, err)
+	}
+	return nil
+}
+
+// mkdirCommand makes the directory with the mkdir_command after
+// making it over SFTP failed with mkdirErr
+func (f *Fs) mkdirCommand(ctx context.Context, dirPath string, mkdirErr error) error {
+	shellPath, ok := f.nativeShellPath(dirPath)
+	if !ok || f.shellType == shellTypeNotSupported {
+		return fmt.Errorf("mkdir %q failed: %w", dirPath, mkdirErr)
+	}
+	fs.Debugf(f, "Mkdir %q failed, trying mkdir_command instead: %v", dirPath, mkdirErr)
+	shellCmd, err := f.shellCommand(f.opt.MkdirCommand, shellPath)
+	if err != nil {
+		return fmt.Errorf("mkdir %q failed: %w", dirPath, err)
+	}
+	_, err = f.run(ctx, shellCmd)
+	if err != nil {
 		return fmt.Errorf("mkdir %q failed: %w", dirPath, err)
 	}
 	return nil
@@ -1532,22 +1823,11 @@ func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	if err != nil {
 		return nil, fmt.Errorf("Move mkParentDir failed: %w", err)
 	}
-	c, err := f.getSftpConnection(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("Move: %w", err)
-	}
 	srcPath, dstPath := srcObj.path(), path.Join(f.absRoot, remote)
-	if _, ok := c.sftpClient.HasExtension("posix-rename@openssh.com"); ok {
-		err = c.sftpClient.PosixRename(srcPath, dstPath)
-	} else {
-		// If haven't got PosixRename then remove source first before renaming
-		err = c.sftpClient.Remove(dstPath)
-		if err != nil && !errors.Is(err, iofs.ErrNotExist) {
-			fs.Errorf(f, "Move: Failed to remove existing file %q: %v", dstPath, err)
-		}
-		err = c.sftpClient.Rename(srcPath, dstPath)
+	err = f.renameWithStrategies(ctx, src, srcPath, dstPath, srcObj.size, fs.ErrorCantMove)
+	if errors.Is(err, fs.ErrorCantMove) {
+		return nil, err
 	}
-	f.putSftpConnection(&c, err)
 	if err != nil {
 		return nil, fmt.Errorf("Move Rename failed: %w", err)
 	}
@@ -1628,15 +1908,10 @@ func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string
 	}
 
 	// Do the move
-	c, err := f.getSftpConnection(ctx)
-	if err != nil {
-		return fmt.Errorf("DirMove: %w", err)
+	err = f.renameWithStrategies(ctx, srcFs, srcPath, dstPath, -1, fs.ErrorCantDirMove)
+	if errors.Is(err, fs.ErrorCantDirMove) {
+		return err
 	}
-	err = c.sftpClient.Rename(
-		srcPath,
-		dstPath,
-	)
-	f.putSftpConnection(&c, err)
 	if err != nil {
 		return fmt.Errorf("DirMove Rename(%q,%q) failed: %w", srcPath, dstPath, err)
 	}
@@ -1884,7 +2159,7 @@ func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
 	}
 
 	// Fall back to shell command method if possible
-	if f.shellType == shellTypeNotSupported || f.shellType == "cmd" {
+	if f.shellType == shellTypeNotSupported || (f.shellType == "cmd" && f.opt.AboutCommand == "") {
 		fs.Debugf(f, "About shell command is not available for shell type %q (set option shell_type to override)", f.shellType)
 		return nil, fmt.Errorf("not supported with shell type %q", f.shellType)
 	}
@@ -1893,6 +2168,18 @@ func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
 		aboutShellPath = "/"
 	}
 	fs.Debugf(f, "About path %q", aboutShellPath)
+	// Custom command
+	if f.opt.AboutCommand != "" {
+		shellCmd, err := f.shellCommand(f.opt.AboutCommand, aboutShellPath)
+		if err != nil {
+			return nil, err
+		}
+		stdout, err := f.run(ctx, shellCmd)
+		if err != nil {
+			return nil, fmt.Errorf("about_command failed: %w", err)
+		}
+		return parseUsageInfo(stdout), nil
+	}
 	aboutShellPathArg, err := f.quoteOrEscapeShellPath(aboutShellPath)
 	if err != nil {
 		return nil, err
@@ -1931,23 +2218,13 @@ func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
 		fs.Debugf(f, "About shell command for shell type %q failed (set option shell_type to override): %v", f.shellType, err)
 		return nil, fmt.Errorf("your remote may not have the required df utility: %w", err)
 	}
-	usageTotal, usageUsed, usageAvail := parseUsage(stdout)
-	usage := &fs.Usage{}
-	if usageTotal >= 0 {
-		usage.Total = fs.NewUsageValue(usageTotal)
-	}
-	if usageUsed >= 0 {
-		usage.Used = fs.NewUsageValue(usageUsed)
-	}
-	if usageAvail >= 0 {
-		usage.Free = fs.NewUsageValue(usageAvail)
-	}
-	return usage, nil
+	return parseUsageInfo(stdout), nil
 }
 
 // Shutdown the backend, closing any background tasks and any
 // cached connections.
 func (f *Fs) Shutdown(ctx context.Context) error {
+	f.renames.log(f)
 	return f.drainPool(ctx)
 }
 
@@ -2021,11 +2298,11 @@ func (o *Object) Hash(ctx context.Context, r hash.Type) (string, error) {
 		return "", hash.ErrUnsupported
 	}
 
-	shellPathArg, err := o.fs.quoteOrEscapeShellPath(o.shellPath())
+	shellCmd, err := o.fs.shellCommand(hashCmd, o.shellPath())
 	if err != nil {
 		return "", fmt.Errorf("failed to calculate %v hash: %w", r, err)
 	}
-	outBytes, err := o.fs.run(ctx, hashCmd+" "+shellPathArg)
+	outBytes, err := o.fs.run(ctx, shellCmd)
 	if err != nil {
 		return "", fmt.Errorf("failed to calculate %v hash: %w", r, err)
 	}
@@ -2074,6 +2351,27 @@ func (f *Fs) quoteOrEscapeShellPath(shellPath string) (string, error) {
 	return quoteOrEscapeShellPath(f.shellType, shellPath)
 }
 
+// expandShellCommand makes a shell command from command by replacing
+// {path} with shellPathArg, or adding it to the end if there is no {path}.
+//
+// shellPathArg should already be quoted with quoteOrEscapeShellPath.
+func expandShellCommand(command string, shellPathArg string) string {
+	if strings.Contains(command, shellPathPlaceholder) {
+		return strings.ReplaceAll(command, shellPathPlaceholder, shellPathArg)
+	}
+	return command + " " + shellPathArg
+}
+
+// shellCommand makes a shell command from command for shellPath in
+// the configured shell
+func (f *Fs) shellCommand(command string, shellPath string) (string, error) {
+	shellPathArg, err := f.quoteOrEscapeShellPath(shellPath)
+	if err != nil {
+		return "", err
+	}
+	return expandShellCommand(command, shellPathArg), nil
+}
+
 // remotePath returns the native SFTP path of the file or directory at the remote given
 func (f *Fs) remotePath(remote string) string {
 	return path.Join(f.absRoot, f.opt.Enc.FromStandardPath(remote))
@@ -2081,16 +2379,30 @@ func (f *Fs) remotePath(remote string) string {
 
 // remoteShellPath returns the SSH shell path of the file or directory at the remote given
 func (f *Fs) remoteShellPath(remote string) string {
-	encodedRemote := f.opt.Enc.FromStandardPath(remote)
+	shellPath, _ := f.nativeShellPath(f.remotePath(remote))
+	return shellPath
+}
+
+// nativeShellPath returns the SSH shell path of the native SFTP path given
+//
+// It returns false if path_override is set and the path isn't inside
+// the root so can't be redirected.
+func (f *Fs) nativeShellPath(nativePath string) (string, bool) {
 	if f.opt.PathOverride != "" {
-		shellPath := path.Join(f.opt.PathOverride, encodedRemote)
+		var shellPath string
 		if f.opt.PathOverride[0] == '@' {
-			shellPath = path.Join(strings.TrimPrefix(f.opt.PathOverride, "@"), f.absRoot, encodedRemote)
+			shellPath = path.Join(strings.TrimPrefix(f.opt.PathOverride, "@"), nativePath)
+		} else {
+			relPath, ok := relativePath(f.absRoot, nativePath)
+			if !ok {
+				return "", false
+			}
+			shellPath = path.Join(f.opt.PathOverride, relPath)
 		}
 		fs.Debugf(f, "Shell path redirected to %q with option path_override", shellPath)
-		return shellPath
+		return shellPath, true
 	}
-	shellPath := path.Join(f.absRoot, encodedRemote)
+	shellPath := nativePath
 	if f.shellType == "powershell" || f.shellType == "cmd" {
 		// If remote shell is powershell or cmd, then server is probably Windows.
 		// The sftp package converts everything to POSIX paths: Forward slashes, and
@@ -2103,11 +2415,23 @@ func (f *Fs) remoteShellPath(remote string) string {
 		if posixWinAbsPathRegex.MatchString(shellPath) {
 			shellPath = strings.TrimPrefix(shellPath, "/")
 			fs.Debugf(f, "Shell path adjusted to %q (set option path_override to override)", shellPath)
-			return shellPath
+			return shellPath, true
 		}
 	}
 	fs.Debugf(f, "Shell path %q", shellPath)
-	return shellPath
+	return shellPath, true
+}
+
+// relativePath returns nativePath relative to root or false if it
+// isn't inside root
+func relativePath(root, nativePath string) (string, bool) {
+	if nativePath == root {
+		return "", true
+	}
+	if root == "" {
+		return nativePath, true
+	}
+	return strings.CutPrefix(nativePath, strings.TrimSuffix(root, "/")+"/")
 }
 
 // Converts a byte array from the SSH session returned by
@@ -2148,6 +2472,22 @@ func parseUsage(bytes []byte) (spaceTotal int64, spaceUsed int64, spaceAvail int
 	return spaceTotal * 1024, spaceUsed * 1024, spaceAvail * 1024
 }
 
+// parseUsageInfo parses the output of df into an fs.Usage
+func parseUsageInfo(bytes []byte) *fs.Usage {
+	usageTotal, usageUsed, usageAvail := parseUsage(bytes)
+	usage := &fs.Usage{}
+	if usageTotal >= 0 {
+		usage.Total = fs.NewUsageValue(usageTotal)
+	}
+	if usageUsed >= 0 {
+		usage.Used = fs.NewUsageValue(usageUsed)
+	}
+	if usageAvail >= 0 {
+		usage.Free = fs.NewUsageValue(usageAvail)
+	}
+	return usage
+}
+
 // Size returns the size in bytes of the remote sftp file
 func (o *Object) Size() int64 {
 	return o.size
@@ -2317,7 +2657,7 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 		}
 	}
 	in = readers.NewLimitedReadCloser(o.fs.newObjectReader(sftpFile), limit)
-	return in, nil
+	return o.fs.limiter.DownloadReadCloser(ctx, in), nil
 }
 
 type sizeReader struct {
@@ -2370,7 +2710,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 			fs.Debugf(src, "Removed after failed upload: %v", err)
 		}
 	}
-	_, err = file.ReadFrom(&sizeReader{Reader: in, size: src.Size()})
+	_, err = file.ReadFrom(&sizeReader{Reader: o.fs.limiter.UploadReader(ctx, in), size: src.Size()})
 	if err != nil {
 		o.fs.putSftpConnection(&c, err)
 		remove()
@@ -2429,6 +2769,7 @@ var (
 	_ fs.DirMover       = &Fs{}
 	_ fs.DirSetModTimer = &Fs{}
 	_ fs.Abouter        = &Fs{}
+	_ fs.ListRer        = &Fs{}
 	_ fs.Shutdowner     = &Fs{}
 	_ fs.Object         = &Object{}
 )
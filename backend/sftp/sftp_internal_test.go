@@ -86,3 +86,44 @@ func TestParseUsage(t *testing.T) {
 		assert.Equal(t, test.usage, [3]int64{gotSpaceTotal, gotSpaceUsed, gotSpaceAvail}, fmt.Sprintf("Test %d sshOutput = %q", i, test.sshOutput))
 	}
 }
+
+func TestExpandShellCommand(t *testing.T) {
+	for i, test := range []struct {
+		command, shellPathArg, want string
+	}{
+		{"md5sum", `/a\ b`, `md5sum /a\ b`},
+		{"busybox md5sum {path}", `/a\ b`, `busybox md5sum /a\ b`},
+		{"df -k {path} | tail -1 | sed 's/^/x /'", "/", "df -k / | tail -1 | sed 's/^/x /'"},
+		{"mkdir -p {path} && chmod 755 {path}", "/d", "mkdir -p /d && chmod 755 /d"},
+	} {
+		got := expandShellCommand(test.command, test.shellPathArg)
+		assert.Equal(t, test.want, got, fmt.Sprintf("Test %d command = %q", i, test.command))
+	}
+}
+
+func TestNativeShellPath(t *testing.T) {
+	for i, test := range []struct {
+		absRoot, pathOverride, shellType, nativePath string
+		want                                         string
+		wantOK                                       bool
+	}{
+		{"/home/user", "", "unix", "/home/user/dir", "/home/user/dir", true},
+		{"/home/user", "", "unix", "/home", "/home", true},
+		{"/", "", "unix", "/dir", "/dir", true},
+		{"/C:/data", "", "powershell", "/C:/data/dir", "C:/data/dir", true},
+		{"/home/user", "@/volume1", "unix", "/home/user/dir", "/volume1/home/user/dir", true},
+		{"/home/user", "@/volume1", "unix", "/home", "/volume1/home", true},
+		{"/home/user", "/volume1/user", "unix", "/home/user/dir", "/volume1/user/dir", true},
+		{"/home/user", "/volume1/user", "unix", "/home/user", "/volume1/user", true},
+		{"/", "/volume1", "unix", "/dir", "/volume1/dir", true},
+		{"/home/user", "/volume1/user", "unix", "/home", "", false},
+		{"/home/user", "/volume1/user", "unix", "/home/username", "", false},
+	} {
+		f := &Fs{absRoot: test.absRoot, shellType: test.shellType}
+		f.opt.PathOverride = test.pathOverride
+		got, ok := f.nativeShellPath(test.nativePath)
+		what := fmt.Sprintf("Test %d nativePath = %q", i, test.nativePath)
+		assert.Equal(t, test.wantOK, ok, what)
+		assert.Equal(t, test.want, got, what)
+	}
+}
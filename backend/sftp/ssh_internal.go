@@ -16,12 +16,15 @@ import (
 // Internal ssh connections with "golang.org/x/crypto/ssh"
 
 type sshClientInternal struct {
-	srv *ssh.Client
+	srv   *ssh.Client
+	jumps []*ssh.Client // jump hosts srv is connected through
 }
 
 // newSSHClientInternal starts a client connection to the given SSH server. It is a
 // convenience function that connects to the given network address,
 // initiates the SSH handshake, and then sets up a Client.
+//
+// If jump hosts are configured it connects through them.
 func (f *Fs) newSSHClientInternal(ctx context.Context, network, addr string, sshConfig *ssh.ClientConfig) (sshClient, error) {
 
 	baseDialer := fshttp.NewDialer(ctx)
@@ -29,23 +32,32 @@ func (f *Fs) newSSHClientInternal(ctx context.Context, network, addr string, ssh
 		conn net.Conn
 		err  error
 	)
+	dialAddr := addr
+	if len(f.jumpHosts) > 0 {
+		dialAddr = f.jumpHosts[0].addr
+	}
 	if f.opt.SocksProxy != "" {
-		conn, err = proxy.SOCKS5Dial(network, addr, f.opt.SocksProxy, baseDialer)
+		conn, err = proxy.SOCKS5Dial(network, dialAddr, f.opt.SocksProxy, baseDialer)
 	} else if f.proxyURL != nil {
-		conn, err = proxy.HTTPConnectDial(network, addr, f.proxyURL, baseDialer)
+		conn, err = proxy.HTTPConnectDial(network, dialAddr, f.proxyURL, baseDialer)
 	} else {
-		conn, err = baseDialer.Dial(network, addr)
+		conn, err = baseDialer.Dial(network, dialAddr)
+	}
+	if err != nil {
+		return nil, err
 	}
+	conn, jumps, err := f.dialJumpHosts(ctx, conn, network, addr)
 	if err != nil {
 		return nil, err
 	}
 	c, chans, reqs, err := ssh.NewClientConn(conn, addr, sshConfig)
 	if err != nil {
+		closeJumpClients(jumps)
 		return nil, err
 	}
 	fs.Debugf(f, "New connection %s->%s to %q", c.LocalAddr(), c.RemoteAddr(), c.ServerVersion())
 	srv := ssh.NewClient(c, chans, reqs)
-	return sshClientInternal{srv}, nil
+	return sshClientInternal{srv: srv, jumps: jumps}, nil
 }
 
 // Wait for connection to close
@@ -61,9 +73,11 @@ func (s sshClientInternal) SendKeepAlive() {
 	}
 }
 
-// Close the connection
+// Close the connection and any jump hosts it goes through
 func (s sshClientInternal) Close() error {
-	return s.srv.Close()
+	err := s.srv.Close()
+	closeJumpClients(s.jumps)
+	return err
 }
 
 // CanReuse indicates if this client can be reused
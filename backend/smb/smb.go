@@ -579,6 +579,18 @@ func (w *smbWriterAt) Close() error {
 //
 // It truncates any existing object
 func (f *Fs) OpenWriterAt(ctx context.Context, remote string, size int64) (fs.WriterAtCloser, error) {
+	return f.openWriterAt(ctx, remote, true, size)
+}
+
+// ResumeWriterAt opens with a handle for random access writes
+// without truncating any existing object, creating it if necessary.
+func (f *Fs) ResumeWriterAt(ctx context.Context, remote string) (fs.WriterAtCloser, error) {
+	return f.openWriterAt(ctx, remote, false, -1)
+}
+
+// openWriterAt opens remote for random access writes, truncating it
+// to size if truncate is set
+func (f *Fs) openWriterAt(ctx context.Context, remote string, truncate bool, size int64) (fs.WriterAtCloser, error) {
 	o := &Object{
 		fs:     f,
 		remote: remote,
@@ -600,12 +612,16 @@ func (f *Fs) OpenWriterAt(ctx context.Context, remote string, size int64) (fs.Wr
 	if err != nil {
 		return nil, err
 	}
-	file, err := cn.smbShare.OpenFile(smbPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	flags := os.O_CREATE | os.O_WRONLY
+	if truncate {
+		flags |= os.O_TRUNC
+	}
+	file, err := cn.smbShare.OpenFile(smbPath, flags, 0o644)
 	if err != nil {
 		o.fs.putConnection(&cn, err)
 		return nil, err
 	}
-	if size > 0 {
+	if truncate && size > 0 {
 		if truncateErr := file.Truncate(size); truncateErr != nil {
 			_ = file.Close()
 			o.fs.putConnection(&cn, truncateErr)
@@ -956,12 +972,13 @@ func translateError(e error, dir bool) error {
 }
 
 var (
-	_ fs.Fs          = &Fs{}
-	_ fs.PutStreamer = &Fs{}
-	_ fs.Mover       = &Fs{}
-	_ fs.DirMover    = &Fs{}
-	_ fs.Abouter     = &Fs{}
-	_ fs.Shutdowner  = &Fs{}
-	_ fs.Object      = &Object{}
-	_ io.ReadCloser  = &boundReadCloser{}
+	_ fs.Fs               = &Fs{}
+	_ fs.PutStreamer      = &Fs{}
+	_ fs.Mover            = &Fs{}
+	_ fs.DirMover         = &Fs{}
+	_ fs.Abouter          = &Fs{}
+	_ fs.Shutdowner       = &Fs{}
+	_ fs.ResumeWriterAter = &Fs{}
+	_ fs.Object           = &Object{}
+	_ io.ReadCloser       = &boundReadCloser{}
 )
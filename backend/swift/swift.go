@@ -5,10 +5,12 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"maps"
+	"net/url"
 	"path"
 	"regexp"
 	"slices"
@@ -44,6 +46,9 @@ const (
 	segmentsContainerSuffix    = "_segments"
 	segmentsDirectory          = ".file-segments"
 	segmentsDirectorySlash     = segmentsDirectory + "/"
+	largeObjectDLO             = "dlo"
+	largeObjectSLO             = "slo"
+	defaultDefragmentMaxAge    = 24 * time.Hour // default age of orphaned segments to delete
 )
 
 // Auth URLs which imply using fileSegmentsDirectory
@@ -59,7 +64,8 @@ or a |`+segmentsDirectory+`| directory. (See the |use_segments_container| option
 for more info). Default for this is 5 GiB which is its maximum value, which
 means only files above this size will be chunked.
 
-Rclone uploads chunked files as dynamic large objects (DLO).
+Rclone uploads chunked files as dynamic large objects (DLO) unless
+|large_object_type| is set to |slo|.
 `, "|", "`"),
 	Default:  defaultChunkSize,
 	Advanced: true,
@@ -134,6 +140,38 @@ the DEBUG log.
 `, "|", "`"),
 	Default:  fs.Tristate{},
 	Advanced: true,
+}, {
+	Name: "large_object_type",
+	Help: strings.ReplaceAll(`Type of large object to upload chunked files as
+
+Dynamic large objects (DLO) find their segments by listing a prefix
+so the object may be incomplete for a while after upload on eventually
+consistent clusters.
+
+Static large objects (SLO) have a manifest listing each segment with
+its size and MD5 which the server checks when the object is created
+and read. The cluster must have the SLO middleware enabled.
+
+Both types can always be read, copied and deleted whatever this is set
+to.`, "|", "`"),
+	Default: largeObjectDLO,
+	Examples: []fs.OptionExample{{
+		Value: largeObjectDLO,
+		Help:  "Dynamic large objects",
+	}, {
+		Value: largeObjectSLO,
+		Help:  "Static large objects",
+	}},
+	Advanced: true,
+}, {
+	Name: "segments_container_suffix",
+	Help: strings.ReplaceAll(`Suffix for the name of the segments container
+
+When |use_segments_container| is |true| the segments for large
+objects are stored in a container named the same as the destination
+container with this appended.`, "|", "`"),
+	Default:  segmentsContainerSuffix,
+	Advanced: true,
 }, {
 	Name:     config.ConfigEncoding,
 	Help:     config.ConfigEncodingHelp,
@@ -148,6 +186,7 @@ func init() {
 		Name:        "swift",
 		Description: "OpenStack Swift (Rackspace Cloud Files, Blomp Cloud Storage, Memset Memstore, OVH)",
 		NewFs:       NewFs,
+		CommandHelp: commandHelp,
 		Options: append([]fs.Option{{
 			Name:    "env_auth",
 			Help:    "Get swift credentials from environment variables in standard OpenStack form.",
@@ -339,6 +378,8 @@ type Options struct {
 	NoChunk                     bool                 `config:"no_chunk"`
 	NoLargeObjects              bool                 `config:"no_large_objects"`
 	UseSegmentsContainer        fs.Tristate          `config:"use_segments_container"`
+	LargeObjectType             string               `config:"large_object_type"`
+	SegmentsContainerSuffix     string               `config:"segments_container_suffix"`
 	Enc                         encoder.MultiEncoder `config:"encoding"`
 	FetchUntilEmptyPage         bool                 `config:"fetch_until_empty_page"`
 	PartialPageFetchThreshold   int                  `config:"partial_page_fetch_threshold"`
@@ -641,6 +682,16 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, fmt.Errorf("swift: chunk size: %w", err)
 	}
+	switch opt.LargeObjectType = strings.ToLower(opt.LargeObjectType); opt.LargeObjectType {
+	case "":
+		opt.LargeObjectType = largeObjectDLO
+	case largeObjectDLO, largeObjectSLO:
+	default:
+		return nil, fmt.Errorf("swift: large_object_type must be %q or %q, not %q", largeObjectDLO, largeObjectSLO, opt.LargeObjectType)
+	}
+	if opt.SegmentsContainerSuffix == "" {
+		opt.SegmentsContainerSuffix = segmentsContainerSuffix
+	}
 
 	c, err := swiftConnection(ctx, opt, name)
 	if err != nil {
@@ -946,7 +997,7 @@ func (f *Fs) About(ctx context.Context) (usage *fs.Usage, err error) {
 
 		if f.opt.UseSegmentsContainer.Value {
 			err = f.pacer.Call(func() (bool, error) {
-				segmentsContainer := f.rootContainer + segmentsContainerSuffix
+				segmentsContainer := f.rootContainer + f.opt.SegmentsContainerSuffix
 				container, _, err = f.c.Container(ctx, segmentsContainer)
 				return shouldRetry(ctx, err)
 			})
@@ -1138,13 +1189,14 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 
 // Represents a segmented upload or copy
 type segmentedUpload struct {
-	f            *Fs        // parent
-	dstContainer string     // container for the file to live once uploaded
-	container    string     // container for the segments
-	dstPath      string     // path for the object to live once uploaded
-	path         string     // unique path for the segments
-	mu           sync.Mutex // protects the variables below
-	segments     []string   // segments successfully uploaded
+	f            *Fs            // parent
+	dstContainer string         // container for the file to live once uploaded
+	container    string         // container for the segments
+	dstPath      string         // path for the object to live once uploaded
+	path         string         // unique path for the segments
+	mu           sync.Mutex     // protects the variables below
+	segments     []string       // segments successfully uploaded
+	segmentInfos []swift.Object // name, MD5 and size of the segments for SLO manifests
 }
 
 // Create a new segmented upload using the correct container and path
@@ -1169,7 +1221,7 @@ func (f *Fs) newSegmentedUpload(ctx context.Context, dstContainer string, dstPat
 			}
 		}
 
-		su.container += segmentsContainerSuffix
+		su.container += f.opt.SegmentsContainerSuffix
 		err = f.makeContainer(ctx, su.container)
 		if err != nil {
 			return nil, err
@@ -1185,11 +1237,16 @@ func (su *segmentedUpload) segmentPath(i int) string {
 	return fmt.Sprintf("%s/%08d", su.path, i)
 }
 
-// Mark segment as successfully uploaded
-func (su *segmentedUpload) uploaded(segment string) {
+// Mark segment as successfully uploaded with its MD5 and size
+func (su *segmentedUpload) uploaded(segment string, md5 string, size int64) {
 	su.mu.Lock()
 	defer su.mu.Unlock()
 	su.segments = append(su.segments, segment)
+	su.segmentInfos = append(su.segmentInfos, swift.Object{
+		Name:  segment,
+		Hash:  md5,
+		Bytes: size,
+	})
 }
 
 // Return the full path including the container
@@ -1221,17 +1278,74 @@ func (su *segmentedUpload) onFail() {
 
 // upload the manifest when upload is done
 func (su *segmentedUpload) uploadManifest(ctx context.Context, contentType string, headers swift.Headers) (err error) {
+	fs.Debugf(su.f, "uploading manifest %q to %q", su.dstPath, su.dstContainer)
+	return su.f.putManifest(ctx, su.dstContainer, su.dstPath, su.container, su.path, su.segmentInfos, contentType, headers)
+}
+
+// sloSegment is an entry in a static large object manifest
+type sloSegment struct {
+	Path string `json:"path"`
+	Etag string `json:"etag,omitempty"`
+	Size int64  `json:"size_bytes"`
+}
+
+// putManifest uploads a manifest to (container, containerPath) for
+// the segments under prefix in segmentsContainer.
+//
+// The type of manifest is set by the large_object_type option.
+// Static large object manifests need the segments listed in order.
+func (f *Fs) putManifest(ctx context.Context, container, containerPath, segmentsContainer, prefix string, segments []swift.Object, contentType string, headers swift.Headers) (err error) {
+	headers = maps.Clone(headers)
 	delete(headers, "Etag") // remove Etag if present as it is wrong for the manifest
-	headers["X-Object-Manifest"] = urlEncode(su.fullPath())
+	delete(headers, "X-Object-Manifest")
+	delete(headers, "X-Static-Large-Object")
+	if f.opt.LargeObjectType == largeObjectSLO {
+		return f.putSLOManifest(ctx, container, containerPath, segmentsContainer, segments, contentType, headers)
+	}
+	headers["X-Object-Manifest"] = urlEncode(segmentsContainer + "/" + prefix)
 	headers["Content-Length"] = "0" // set Content-Length as we know it
 	emptyReader := bytes.NewReader(nil)
-	fs.Debugf(su.f, "uploading manifest %q to %q", su.dstPath, su.dstContainer)
-	err = su.f.pacer.Call(func() (bool, error) {
+	return f.pacer.Call(func() (bool, error) {
 		var rxHeaders swift.Headers
-		rxHeaders, err = su.f.c.ObjectPut(ctx, su.dstContainer, su.dstPath, emptyReader, true, "", contentType, headers)
+		rxHeaders, err = f.c.ObjectPut(ctx, container, containerPath, emptyReader, true, "", contentType, headers)
+		return shouldRetryHeaders(ctx, rxHeaders, err)
+	})
+}
+
+// putSLOManifest uploads a static large object manifest for segments
+func (f *Fs) putSLOManifest(ctx context.Context, container, containerPath, segmentsContainer string, segments []swift.Object, contentType string, headers swift.Headers) (err error) {
+	manifest := make([]sloSegment, len(segments))
+	for i, segment := range segments {
+		manifest[i] = sloSegment{
+			Path: segmentsContainer + "/" + segment.Name,
+			Etag: segment.Hash,
+			Size: segment.Bytes,
+		}
+	}
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to make SLO manifest: %w", err)
+	}
+	headers["Content-Length"] = strconv.Itoa(len(content))
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+	return f.pacer.Call(func() (bool, error) {
+		var rxHeaders swift.Headers
+		_, rxHeaders, err = f.c.Call(ctx, f.c.StorageUrl, swift.RequestOpts{
+			Container:  container,
+			ObjectName: containerPath,
+			Operation:  "PUT",
+			Parameters: url.Values{"multipart-manifest": {"put"}},
+			Headers:    headers,
+			Body:       bytes.NewReader(content),
+			NoResponse: true,
+			OnReAuth: func() (string, error) {
+				return f.c.StorageUrl, nil
+			},
+		})
 		return shouldRetryHeaders(ctx, rxHeaders, err)
 	})
-	return err
 }
 
 // Copy a large object src into (dstContainer, dstPath)
@@ -1240,7 +1354,7 @@ func (f *Fs) copyLargeObject(ctx context.Context, src *Object, dstContainer stri
 	if err != nil {
 		return err
 	}
-	srcSegmentsContainer, srcSegments, err := src.getSegmentsLargeObject(ctx)
+	srcSegmentsContainer, srcSegments, err := src.getSegmentObjectsLargeObject(ctx)
 	if err != nil {
 		return fmt.Errorf("copy large object: %w", err)
 	}
@@ -1252,13 +1366,13 @@ func (f *Fs) copyLargeObject(ctx context.Context, src *Object, dstContainer stri
 		dstSegment := su.segmentPath(i)
 		err = f.pacer.Call(func() (bool, error) {
 			var rxHeaders swift.Headers
-			rxHeaders, err = f.c.ObjectCopy(ctx, srcSegmentsContainer, srcSegment, su.container, dstSegment, nil)
+			rxHeaders, err = f.c.ObjectCopy(ctx, srcSegmentsContainer, srcSegment.Name, su.container, dstSegment, nil)
 			return shouldRetryHeaders(ctx, rxHeaders, err)
 		})
 		if err != nil {
 			return err
 		}
-		su.uploaded(dstSegment)
+		su.uploaded(dstSegment, srcSegment.Hash, srcSegment.Bytes)
 	}
 	return su.uploadManifest(ctx, src.contentType, src.headers)
 }
@@ -1354,7 +1468,13 @@ func (o *Object) isLargeObject(ctx context.Context) (result bool, err error) {
 }
 
 func (o *Object) isInContainerVersioning(ctx context.Context, container string) (bool, error) {
-	_, headers, err := o.fs.c.Container(ctx, container)
+	return o.fs.isInContainerVersioning(ctx, container)
+}
+
+// isInContainerVersioning returns true if old versions of objects in
+// the container are kept.
+func (f *Fs) isInContainerVersioning(ctx context.Context, container string) (bool, error) {
+	_, headers, err := f.c.Container(ctx, container)
 	if err != nil {
 		return false, err
 	}
@@ -1482,14 +1602,25 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 	return
 }
 
+// Get the segment objects for a large object
+//
+// It returns the segments in order and the container that they live in
+func (o *Object) getSegmentObjectsLargeObject(ctx context.Context) (container string, segments []swift.Object, err error) {
+	container, objectName := o.split()
+	container, segments, err = o.fs.c.LargeObjectGetSegments(ctx, container, objectName)
+	if err != nil {
+		return container, segments, fmt.Errorf("failed to get list segments of object: %w", err)
+	}
+	return container, segments, nil
+}
+
 // Get the segments for a large object
 //
 // It returns the names of the segments and the container that they live in
 func (o *Object) getSegmentsLargeObject(ctx context.Context) (container string, segments []string, err error) {
-	container, objectName := o.split()
-	container, segmentObjects, err := o.fs.c.LargeObjectGetSegments(ctx, container, objectName)
+	container, segmentObjects, err := o.getSegmentObjectsLargeObject(ctx)
 	if err != nil {
-		return container, segments, fmt.Errorf("failed to get list segments of object: %w", err)
+		return container, segments, err
 	}
 	segments = make([]string, len(segmentObjects))
 	for i := range segmentObjects {
@@ -1555,18 +1686,18 @@ func (o *Object) updateChunks(ctx context.Context, in0 io.Reader, headers swift.
 			headers["Content-Length"] = strconv.FormatInt(n, 10) // set Content-Length as we know it
 			left -= n
 		}
-		segmentReader := io.LimitReader(in, n)
+		segmentReader := readers.NewCountingReader(io.LimitReader(in, n))
 		segmentPath := su.segmentPath(i)
 		fs.Debugf(o, "Uploading segment file %q into %q", segmentPath, su.container)
+		var rxHeaders swift.Headers
 		err = o.fs.pacer.CallNoRetry(func() (bool, error) {
-			var rxHeaders swift.Headers
 			rxHeaders, err = o.fs.c.ObjectPut(ctx, su.container, segmentPath, segmentReader, true, "", "", headers)
 			return shouldRetryHeaders(ctx, rxHeaders, err)
 		})
 		if err != nil {
 			return err
 		}
-		su.uploaded(segmentPath)
+		su.uploaded(segmentPath, rxHeaders["Etag"], int64(segmentReader.BytesRead()))
 		i++
 	}
 	return su.uploadManifest(ctx, contentType, headers)
@@ -1711,6 +1842,188 @@ func (o *Object) MimeType(ctx context.Context) string {
 	return o.contentType
 }
 
+var commandHelp = []fs.CommandHelp{{
+	Name:  "defragment",
+	Short: "Remove orphaned large object segments and rebuild manifests.",
+	Long: `This command finds the segments of large objects in the container
+given, and removes any which aren't used by a large object. These are
+usually left behind by interrupted uploads.
+
+Only orphaned segments older than max-age, which defaults to 24 hours,
+are removed so uploads in progress aren't affected.
+
+If the rebuild option is given then the manifest of each large object
+found is rewritten as the type set by the large_object_type option.
+This can be used to convert dynamic large objects into static large
+objects or the other way round.
+
+Note that you can use --interactive/-i or --dry-run with this command
+to see what it would do.
+
+` + "```console" + `
+rclone backend defragment swift:container
+rclone backend defragment -o max-age=7d swift:container/path
+rclone backend defragment -o rebuild --swift-large-object-type slo swift:container
+` + "```" + `
+
+Orphaned segments aren't removed from containers with versioning
+enabled as old versions of objects may still use them.
+
+This returns a summary of what was done.`,
+	Opts: map[string]string{
+		"max-age": "Max age of orphaned segments to remove.",
+		"rebuild": "Rewrite the manifests of large objects as large_object_type.",
+	},
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out any, err error) {
+	switch name {
+	case "defragment":
+		maxAge := defaultDefragmentMaxAge
+		if opt["max-age"] != "" {
+			maxAge, err = fs.ParseDuration(opt["max-age"])
+			if err != nil {
+				return nil, fmt.Errorf("bad max-age: %w", err)
+			}
+		}
+		_, rebuild := opt["rebuild"]
+		return f.defragment(ctx, maxAge, rebuild)
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// defragmentResult is the output of the defragment command
+type defragmentResult struct {
+	LargeObjects     int   `json:"largeObjects"`
+	RebuiltManifests int   `json:"rebuiltManifests"`
+	OrphanedSegments int   `json:"orphanedSegments"`
+	DeletedSegments  int   `json:"deletedSegments"`
+	DeletedBytes     int64 `json:"deletedBytes"`
+}
+
+// defragment finds the segments of the large objects under the root
+// removing any orphaned segments older than maxAge and optionally
+// rebuilding the manifests of the large objects.
+func (f *Fs) defragment(ctx context.Context, maxAge time.Duration, rebuild bool) (result *defragmentResult, err error) {
+	container, directory := f.split("")
+	if container == "" {
+		return nil, errors.New("defragment needs a container")
+	}
+	segmentsContainer, segmentsPrefix := container, segmentsDirectorySlash+directory
+	if f.opt.UseSegmentsContainer.Value {
+		segmentsContainer, segmentsPrefix = container+f.opt.SegmentsContainerSuffix, directory
+	}
+	versioning, err := f.isInContainerVersioning(ctx, container)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read container: %w", err)
+	}
+
+	// Read the segments grouping them by the upload they came from.
+	// Segments are named path/unique-string/00000000
+	uploads := map[string][]swift.Object{}
+	err = f.listContainerRoot(ctx, segmentsContainer, segmentsPrefix, "", false, true, true, func(remote string, object *swift.Object, isDirectory bool) error {
+		if !strings.HasSuffix(object.Name, "/") {
+			upload := path.Dir(object.Name)
+			uploads[upload] = append(uploads[upload], *object)
+		}
+		return nil
+	})
+	result = &defragmentResult{}
+	if err == swift.ContainerNotFound {
+		return result, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list segments: %w", err)
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	for _, upload := range slices.Sorted(maps.Keys(uploads)) {
+		dstPath := path.Dir(strings.TrimPrefix(upload, segmentsDirectorySlash))
+		if f.opt.UseSegmentsContainer.Value {
+			dstPath = path.Dir(upload)
+		}
+		segments := uploads[upload]
+
+		// Find out if the object at dstPath uses these segments
+		var info swift.Object
+		var headers swift.Headers
+		err = f.pacer.Call(func() (bool, error) {
+			info, headers, err = f.c.Object(ctx, container, dstPath)
+			return shouldRetryHeaders(ctx, headers, err)
+		})
+		if err != nil && err != swift.ObjectNotFound {
+			return nil, fmt.Errorf("failed to read %q: %w", dstPath, err)
+		}
+		var manifestContainer string
+		var manifestSegments []swift.Object
+		if err == nil && headers.IsLargeObject() {
+			err = f.pacer.Call(func() (bool, error) {
+				manifestContainer, manifestSegments, err = f.c.LargeObjectGetSegments(ctx, container, dstPath)
+				return shouldRetry(ctx, err)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read segments of %q: %w", dstPath, err)
+			}
+		}
+		if manifestContainer == segmentsContainer && len(manifestSegments) > 0 && strings.HasPrefix(manifestSegments[0].Name, upload+"/") {
+			result.LargeObjects++
+			if rebuild && !operations.SkipDestructive(ctx, dstPath, "rebuild manifest") {
+				fs.Infof(dstPath, "Rebuilding manifest as %s", strings.ToUpper(f.opt.LargeObjectType))
+				err = f.putManifest(ctx, container, dstPath, segmentsContainer, upload, manifestSegments, info.ContentType, headers.ObjectMetadata().ObjectHeaders())
+				if err != nil {
+					return nil, fmt.Errorf("failed to rebuild manifest of %q: %w", dstPath, err)
+				}
+				result.RebuiltManifests++
+			}
+			continue
+		}
+
+		// The segments are orphaned
+		result.OrphanedSegments += len(segments)
+		if versioning {
+			fs.Logf(upload, "Not removing %d orphaned segments as container versioning is enabled", len(segments))
+			continue
+		}
+		var names []string
+		var size int64
+		recent := false
+		for _, segment := range segments {
+			if segment.LastModified.After(cutoff) {
+				recent = true
+			}
+			names = append(names, segment.Name)
+			size += segment.Bytes
+		}
+		if recent {
+			fs.Debugf(upload, "Not removing %d orphaned segments as they are newer than %v", len(segments), fs.Duration(maxAge))
+			continue
+		}
+		if operations.SkipDestructive(ctx, upload, "remove orphaned segments") {
+			continue
+		}
+		fs.Infof(upload, "Removing %d orphaned segments", len(names))
+		err = f.pacer.Call(func() (bool, error) {
+			_, err = f.c.BulkDelete(ctx, segmentsContainer, names)
+			return shouldRetry(ctx, err)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove orphaned segments of %q: %w", upload, err)
+		}
+		result.DeletedSegments += len(names)
+		result.DeletedBytes += size
+	}
+	return result, nil
+}
+
 // Check the interfaces are satisfied
 var (
 	_ fs.Fs          = &Fs{}
@@ -1719,6 +2032,7 @@ var (
 	_ fs.Copier      = &Fs{}
 	_ fs.ListRer     = &Fs{}
 	_ fs.ListPer     = &Fs{}
+	_ fs.Commander   = &Fs{}
 	_ fs.Object      = &Object{}
 	_ fs.MimeTyper   = &Object{}
 )
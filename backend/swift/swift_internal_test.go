@@ -1,13 +1,20 @@
 package swift
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/ncw/swift/v2"
+	"github.com/ncw/swift/v2/swifttest"
+	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/lib/random"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInternalUrlEncode(t *testing.T) {
@@ -66,3 +73,65 @@ func TestInternalShouldRetryHeaders(t *testing.T) {
 	assert.True(t, dt >= time.Hour-time.Second && dt <= time.Hour+time.Second)
 
 }
+
+func TestInternalLargeObjectDefragment(t *testing.T) {
+	ctx := context.Background()
+	srv, err := swifttest.NewSwiftServer("localhost")
+	require.NoError(t, err)
+	defer srv.Close()
+
+	f, err := NewFs(ctx, "TestSwiftSLO", "container", configmap.Simple{
+		"user":                   swifttest.TEST_ACCOUNT,
+		"key":                    swifttest.TEST_ACCOUNT,
+		"auth":                   srv.AuthURL,
+		"chunk_size":             "1k",
+		"large_object_type":      "slo",
+		"use_segments_container": "true",
+	})
+	require.NoError(t, err)
+	sf := f.(*Fs)
+
+	// Upload a static large object
+	contents := random.String(2500)
+	src := object.NewStaticObjectInfo("file.txt", time.Now(), int64(len(contents)), true, nil, nil)
+	o, err := f.Put(ctx, bytes.NewBufferString(contents), src)
+	require.NoError(t, err)
+	isSLO, err := o.(*Object).isStaticLargeObject(ctx)
+	require.NoError(t, err)
+	assert.True(t, isSLO)
+
+	// Upload an orphaned segment
+	_, err = sf.c.ObjectPut(ctx, "container_segments", "orphan/upload/00000000", bytes.NewBufferString("orphan"), true, "", "", nil)
+	require.NoError(t, err)
+
+	out, err := sf.Command(ctx, "defragment", nil, map[string]string{"max-age": "0s"})
+	require.NoError(t, err)
+	assert.Equal(t, &defragmentResult{
+		LargeObjects:     1,
+		OrphanedSegments: 1,
+		DeletedSegments:  1,
+		DeletedBytes:     6,
+	}, out)
+	_, _, err = sf.c.Object(ctx, "container_segments", "orphan/upload/00000000")
+	assert.Equal(t, swift.ObjectNotFound, err)
+
+	// Rebuild the manifest as a dynamic large object
+	sf.opt.LargeObjectType = largeObjectDLO
+	out, err = sf.Command(ctx, "defragment", nil, map[string]string{"rebuild": ""})
+	require.NoError(t, err)
+	assert.Equal(t, &defragmentResult{
+		LargeObjects:     1,
+		RebuiltManifests: 1,
+	}, out)
+	o, err = f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	isDLO, err := o.(*Object).isDynamicLargeObject(ctx)
+	require.NoError(t, err)
+	assert.True(t, isDLO)
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	got, err := io.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, contents, string(got))
+}
@@ -0,0 +1,157 @@
+package union
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/rclone/rclone/backend/union/upstream"
+	"github.com/rclone/rclone/fs"
+)
+
+// Ways of combining the usage of the upstreams
+const (
+	aboutSum = "sum"
+	aboutMin = "min"
+)
+
+// checkAboutPolicy checks the about_policy option is valid
+func checkAboutPolicy(policy string) error {
+	switch policy {
+	case "", aboutSum, aboutMin:
+		return nil
+	}
+	return fmt.Errorf("unknown about_policy %q - must be %q or %q", policy, aboutSum, aboutMin)
+}
+
+// usageField reads a field of a usage
+type usageField func(u *fs.Usage) **int64
+
+// usageFields are all the fields of a usage except Free
+var usageFields = []usageField{
+	func(u *fs.Usage) **int64 { return &u.Total },
+	func(u *fs.Usage) **int64 { return &u.Used },
+	func(u *fs.Usage) **int64 { return &u.Trashed },
+	func(u *fs.Usage) **int64 { return &u.Other },
+	func(u *fs.Usage) **int64 { return &u.Objects },
+}
+
+// freeField reads the Free field of a usage
+func freeField(u *fs.Usage) **int64 { return &u.Free }
+
+// allUsageFields are all the fields of a usage
+var allUsageFields = slices.Concat(usageFields, []usageField{freeField})
+
+// upstreamUsage is the usage of an upstream
+type upstreamUsage struct {
+	u     *upstream.Fs
+	usage *fs.Usage
+}
+
+// combine sets the field of out from the usages with policy, leaving
+// it nil if none of them have it
+func combine(out *fs.Usage, field usageField, policy string, usages []upstreamUsage) {
+	var total *int64
+	for _, uu := range usages {
+		v := *field(uu.usage)
+		if v == nil {
+			continue
+		}
+		switch {
+		case total == nil:
+			total = new(int64)
+			*total = *v
+		case policy == aboutMin:
+			*total = min(*total, *v)
+		default:
+			*total += *v
+		}
+	}
+	*field(out) = total
+}
+
+// sameUsage returns true if a and b report the same usage
+func sameUsage(a, b *fs.Usage) bool {
+	for _, field := range allUsageFields {
+		x, y := *field(a), *field(b)
+		if (x == nil) != (y == nil) || (x != nil && *x != *y) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyUsage returns a copy of the values in u
+func copyUsage(u *fs.Usage) *fs.Usage {
+	out := new(fs.Usage)
+	for _, field := range allUsageFields {
+		if v := *field(u); v != nil {
+			*field(out) = new(int64)
+			**field(out) = *v
+		}
+	}
+	return out
+}
+
+// About gets quota information from the Fs
+//
+// The usage of the upstreams is combined with the about_policy.
+// Upstreams on the same remote reporting the same usage are counted
+// once as they are likely to share a quota. Only upstreams files can
+// be created on count towards the free space. Upstreams which don't
+// support about are left out.
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	var (
+		usages    []upstreamUsage
+		upstreams = map[string]*fs.Usage{}
+		found     = false
+	)
+	for _, u := range f.upstreams {
+		usg, err := u.About(ctx)
+		if errors.Is(err, fs.ErrorDirNotFound) {
+			continue
+		}
+		if errors.Is(err, upstream.ErrUsageFieldNotSupported) {
+			fs.Debugf(u, "Leaving out of about: %v", err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		if usg == nil {
+			continue
+		}
+		usg = copyUsage(usg)
+		upstreams[fs.ConfigString(u.RootFs)] = usg
+		duplicate := false
+		for _, other := range usages {
+			if other.u.RootFs.Name() == u.RootFs.Name() && sameUsage(other.usage, usg) {
+				fs.Debugf(u, "Counting usage once as same as %v", other.u)
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			usages = append(usages, upstreamUsage{u: u, usage: usg})
+		}
+	}
+	if !found {
+		return nil, errors.New("none of the upstreams support about")
+	}
+	usage := &fs.Usage{
+		Upstreams: upstreams,
+	}
+	for _, field := range usageFields {
+		combine(usage, field, f.opt.AboutPolicy, usages)
+	}
+	var creatable []upstreamUsage
+	for _, uu := range usages {
+		if uu.u.IsCreatable() {
+			creatable = append(creatable, uu)
+		}
+	}
+	combine(usage, freeField, f.opt.AboutPolicy, creatable)
+	return usage, nil
+}
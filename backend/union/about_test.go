@@ -0,0 +1,90 @@
+package union
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	_ "github.com/rclone/rclone/backend/local"
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUsage makes a usage with the values given, -1 for nil
+func newUsage(total, used, free int64) *fs.Usage {
+	u := new(fs.Usage)
+	for _, x := range []struct {
+		p **int64
+		v int64
+	}{{&u.Total, total}, {&u.Used, used}, {&u.Free, free}} {
+		if x.v >= 0 {
+			*x.p = new(int64)
+			**x.p = x.v
+		}
+	}
+	return u
+}
+
+func TestAboutCombine(t *testing.T) {
+	usages := []upstreamUsage{
+		{usage: newUsage(100, 10, 90)},
+		{usage: newUsage(50, -1, 40)},
+		{usage: newUsage(-1, -1, -1)},
+	}
+	for _, test := range []struct {
+		policy string
+		want   *fs.Usage
+	}{
+		{aboutSum, newUsage(150, 10, 130)},
+		{aboutMin, newUsage(50, 10, 40)},
+	} {
+		t.Run(test.policy, func(t *testing.T) {
+			got := new(fs.Usage)
+			for _, field := range allUsageFields {
+				combine(got, field, test.policy, usages)
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+	assert.True(t, sameUsage(newUsage(1, 2, 3), copyUsage(newUsage(1, 2, 3))))
+	assert.False(t, sameUsage(newUsage(1, 2, 3), newUsage(1, 2, -1)))
+	assert.False(t, sameUsage(newUsage(1, 2, 3), newUsage(1, 2, 4)))
+}
+
+func TestAbout(t *testing.T) {
+	ctx := context.Background()
+	dirs := MakeTestDirs(t, 2)
+	f, err := NewFs(ctx, "TestUnionAbout", "", configmap.Simple{
+		"upstreams":     strings.Join([]string{dirs[0], dirs[1] + ":ro", ":memory:"}, " "),
+		"action_policy": "epall",
+		"create_policy": "epmfs",
+		"search_policy": "ff",
+		"about_policy":  "min",
+	})
+	require.NoError(t, err)
+
+	usage, err := f.Features().About(ctx)
+	require.NoError(t, err)
+
+	// The memory upstream doesn't support about so is left out
+	require.Len(t, usage.Upstreams, 2)
+	first := usage.Upstreams[dirs[0]]
+	require.NotNil(t, first)
+	require.NotNil(t, usage.Upstreams[dirs[1]])
+	assert.Equal(t, first.Total, usage.Total)
+
+	// Only the writable upstream counts towards the free space
+	assert.Equal(t, first.Free, usage.Free)
+
+	_, err = NewFs(ctx, "TestUnionAbout", "", configmap.Simple{
+		"upstreams":     strings.Join(dirs, " "),
+		"action_policy": "epall",
+		"create_policy": "epmfs",
+		"search_policy": "ff",
+		"about_policy":  "potato",
+	})
+	assert.ErrorContains(t, err, "unknown about_policy")
+}
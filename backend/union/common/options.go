@@ -14,4 +14,7 @@ type Options struct {
 	SearchPolicy string          `config:"search_policy"`
 	CacheTime    int             `config:"cache_time"`
 	MinFreeSpace fs.SizeSuffix   `config:"min_free_space"`
+	AboutPolicy  string          `config:"about_policy"`
+	VerifyReads  bool            `config:"verify_reads"`
+	RepairReads  bool            `config:"repair_reads"`
 }
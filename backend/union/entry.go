@@ -10,6 +10,7 @@ import (
 
 	"github.com/rclone/rclone/backend/union/upstream"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
 )
 
 // Object describes a union Object
@@ -20,6 +21,8 @@ type Object struct {
 	fs          *Fs // what this object is part of
 	co          []upstream.Entry
 	writebackMu sync.Mutex
+	verifyMu    sync.Mutex   // protects verify
+	verify      *verifyState // state of the verified read in progress if any
 }
 
 // Directory describes a union Directory
@@ -83,7 +86,11 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	}
 	if len(entries) == 1 {
 		obj := entries[0].(*upstream.Object)
-		return obj.Update(ctx, in, src, options...)
+		err = obj.Update(ctx, in, src, options...)
+		if err == nil {
+			o.fs.bad.clear(obj.UpstreamFs(), o.Remote())
+		}
+		return err
 	}
 	// Multi-threading
 	readers, errChan := multiReader(len(entries), in)
@@ -103,7 +110,11 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		}
 	})
 	errs[len(entries)] = <-errChan
-	return errs.Err()
+	err = errs.Err()
+	if err == nil {
+		o.fs.bad.clear(nil, o.Remote())
+	}
+	return err
 }
 
 // Remove candidate objects selected by ACTION policy
@@ -123,6 +134,7 @@ func (o *Object) Remove(ctx context.Context) error {
 			errs[i] = fs.ErrorNotAFile
 		}
 	})
+	o.fs.bad.clear(nil, o.Remote())
 	return errs.Err()
 }
 
@@ -200,9 +212,25 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadClo
 		o.Object = newObj
 		o.co = append(o.co, newObj) // FIXME should this append or overwrite or update?
 	}
+	if ht := o.fs.verifyHashType(); ht != hash.None {
+		return o.openVerified(ctx, ht, options...)
+	}
 	return o.Object.Object.Open(ctx, options...)
 }
 
+// Hash returns the selected checksum of the file
+//
+// If reads are being verified this is the hash most of the copies
+// agree on.
+func (o *Object) Hash(ctx context.Context, ht hash.Type) (string, error) {
+	if vt := o.fs.verifyHashType(); vt != hash.None && ht == vt {
+		if sum, _, _ := o.consensus(ctx, ht); sum != "" {
+			return sum, nil
+		}
+	}
+	return o.Object.Hash(ctx, ht)
+}
+
 // ModTime returns the modification date of the directory
 // It returns the latest ModTime of all candidates
 func (d *Directory) ModTime(ctx context.Context) (t time.Time) {
@@ -61,6 +61,48 @@ If a remote has less than this much free space then it won't be
 considered for use in lfs or eplfs policies.`,
 			Advanced: true,
 			Default:  fs.Gibi,
+		}, {
+			Name: "about_policy",
+			Help: `How to combine the quota of the upstreams for about.
+
+Upstreams which don't support about are left out, and upstreams on the
+same remote reporting the same usage are only counted once. Only
+upstreams which files can be created on count towards the free space.
+
+The usage of each upstream is shown too when about is used with --json.`,
+			Advanced: true,
+			Default:  "sum",
+			Examples: []fs.OptionExample{{
+				Value: "sum",
+				Help:  "Add up the usage of the upstreams.\nUse when the upstreams hold different files.",
+			}, {
+				Value: "min",
+				Help:  "Use the smallest value of the upstreams.\nUse when the upstreams mirror each other, as with the epall create policy.",
+			}},
+		}, {
+			Name: "verify_reads",
+			Help: `Verify the data read against the hash the upstreams agree on.
+
+When a file is on more than one upstream, the hash reported for it is
+the one most of the copies have. Data read from the file is hashed
+and checked against it once the end of the file is read, including
+when it is read in chunks one after another as the VFS does.
+
+If the copy read doesn't match, it is marked as bad and the read
+returns a retriable error, so the retry reads another copy.
+
+This needs the upstreams to have a hash in common.`,
+			Advanced: true,
+			Default:  false,
+		}, {
+			Name: "repair_reads",
+			Help: `Repair bad copies found by verify_reads.
+
+When a copy has been read and verified, any copies of the file which
+failed verification or which have a different hash are overwritten
+with it. The read which finds them waits for the repair.`,
+			Advanced: true,
+			Default:  false,
 		}},
 	}
 	fs.Register(fsi)
@@ -77,6 +119,7 @@ type Fs struct {
 	actionPolicy policy.Policy  // policy for ACTION
 	createPolicy policy.Policy  // policy for CREATE
 	searchPolicy policy.Policy  // policy for SEARCH
+	bad          badCopies      // copies which failed verification
 }
 
 // Wrap candidate objects in to a union Object
@@ -620,58 +663,6 @@ func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, opt
 	}
 }
 
-// About gets quota information from the Fs
-func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
-	usage := &fs.Usage{
-		Total:   new(int64),
-		Used:    new(int64),
-		Trashed: new(int64),
-		Other:   new(int64),
-		Free:    new(int64),
-		Objects: new(int64),
-	}
-	for _, u := range f.upstreams {
-		usg, err := u.About(ctx)
-		if errors.Is(err, fs.ErrorDirNotFound) {
-			continue
-		}
-		if err != nil {
-			return nil, err
-		}
-		if usg.Total != nil && usage.Total != nil {
-			*usage.Total += *usg.Total
-		} else {
-			usage.Total = nil
-		}
-		if usg.Used != nil && usage.Used != nil {
-			*usage.Used += *usg.Used
-		} else {
-			usage.Used = nil
-		}
-		if usg.Trashed != nil && usage.Trashed != nil {
-			*usage.Trashed += *usg.Trashed
-		} else {
-			usage.Trashed = nil
-		}
-		if usg.Other != nil && usage.Other != nil {
-			*usage.Other += *usg.Other
-		} else {
-			usage.Other = nil
-		}
-		if usg.Free != nil && usage.Free != nil {
-			*usage.Free += *usg.Free
-		} else {
-			usage.Free = nil
-		}
-		if usg.Objects != nil && usage.Objects != nil {
-			*usage.Objects += *usg.Objects
-		} else {
-			usage.Objects = nil
-		}
-	}
-	return usage, nil
-}
-
 // List the objects and directories in dir into entries.  The
 // entries can be returned in any order but should be for a
 // complete directory.
@@ -959,6 +950,10 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, err
 	}
+	err = checkAboutPolicy(opt.AboutPolicy)
+	if err != nil {
+		return nil, err
+	}
 	f.actionPolicy, err = policy.Get(opt.ActionPolicy)
 	if err != nil {
 		return nil, err
@@ -1020,6 +1015,14 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		}
 	}
 
+	// Enable About if any of the upstreams support it
+	for _, u := range upstreams {
+		if u.Features().About != nil {
+			features.About = f.About
+			break
+		}
+	}
+
 	// Disable ListP always
 	features.ListP = nil
 
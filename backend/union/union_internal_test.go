@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"runtime"
 	"testing"
 	"time"
 
@@ -96,7 +95,8 @@ func TestMoveCopy(t *testing.T) {
 	fLocal := unionFs.upstreams[0].Fs
 	fMemory := unionFs.upstreams[1].Fs
 
-	if runtime.GOOS == "darwin" {
+	if fLocal.Features().Copy != nil {
+		// local can Copy by cloning on macOS and Linux so
 		// need to disable as this test specifically tests a local that can't Copy
 		f.Features().Disable("Copy")
 		fLocal.Features().Disable("Copy")
@@ -0,0 +1,282 @@
+package union
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/rclone/rclone/backend/union/upstream"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// badKey identifies a copy of an object on an upstream
+type badKey struct {
+	u      *upstream.Fs
+	remote string
+}
+
+// badCopies records the copies of objects which failed verification
+type badCopies struct {
+	mu   sync.Mutex
+	keys map[badKey]struct{}
+}
+
+// mark notes the copy of remote on u as bad
+func (b *badCopies) mark(u *upstream.Fs, remote string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.keys == nil {
+		b.keys = make(map[badKey]struct{})
+	}
+	b.keys[badKey{u: u, remote: remote}] = struct{}{}
+}
+
+// isBad returns whether the copy of remote on u is known to be bad
+func (b *badCopies) isBad(u *upstream.Fs, remote string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, found := b.keys[badKey{u: u, remote: remote}]
+	return found
+}
+
+// clear forgets any bad copies of remote, on u only if it is set
+func (b *badCopies) clear(u *upstream.Fs, remote string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key := range b.keys {
+		if key.remote == remote && (u == nil || key.u == u) {
+			delete(b.keys, key)
+		}
+	}
+}
+
+// verifyHashType returns the hash used to verify reads or hash.None
+// if reads aren't being verified
+func (f *Fs) verifyHashType() hash.Type {
+	if !f.opt.VerifyReads {
+		return hash.None
+	}
+	return f.hashSet.GetOne()
+}
+
+// verifyState tracks the verification of the data read from an
+// object, which may be read in several chunks one after another.
+type verifyState struct {
+	obj    *upstream.Object  // the copy being read
+	hasher *hash.MultiHasher // hash of the data read so far
+	offset int64             // offset the next chunk must start at
+}
+
+// consensus works out the hash of the object the copies agree on.
+//
+// This is the hash most of the copies have, with ties going to the
+// copy chosen by the search policy. It returns the copies in the
+// order to read them - the ones with the agreed hash first, then the
+// ones with no hash, then the ones which are known to be bad. The
+// copies which disagree with the consensus are returned in bad.
+func (o *Object) consensus(ctx context.Context, ht hash.Type) (sum string, order, bad []*upstream.Object) {
+	objs := []*upstream.Object{o.Object}
+	for _, e := range o.candidates() {
+		if obj, ok := e.(*upstream.Object); ok && obj != o.Object {
+			objs = append(objs, obj)
+		}
+	}
+	sums := make([]string, len(objs))
+	multithread(len(objs), func(i int) {
+		var err error
+		sums[i], err = objs[i].Hash(ctx, ht)
+		if err != nil {
+			fs.Debugf(o, "%s: failed to read %v hash: %v", objs[i].UpstreamFs().Name(), ht, err)
+			sums[i] = ""
+		}
+	})
+	counts := make(map[string]int, len(sums))
+	for _, s := range sums {
+		if s != "" {
+			counts[s]++
+			if counts[s] > counts[sum] || sum == "" {
+				sum = s
+			}
+		}
+	}
+	var unknown []*upstream.Object
+	for i, obj := range objs {
+		switch {
+		case sum != "" && sums[i] != "" && sums[i] != sum:
+			fs.Errorf(o, "%s: %v hash %q differs from the %q of the other copies", obj.UpstreamFs().Name(), ht, sums[i], sum)
+			o.fs.bad.mark(obj.UpstreamFs(), o.Remote())
+			bad = append(bad, obj)
+		case o.fs.bad.isBad(obj.UpstreamFs(), o.Remote()):
+			bad = append(bad, obj)
+		case sums[i] == "":
+			unknown = append(unknown, obj)
+		default:
+			order = append(order, obj)
+		}
+	}
+	order = append(order, unknown...)
+	order = append(order, bad...)
+	return sum, order, bad
+}
+
+// openVerified opens the object for read checking the data read
+// matches the hash the copies agree on.
+//
+// Reads starting at the beginning of the object, or where the last
+// verified read of the object finished, are verified once the end of
+// the object is read. If the copy being read fails verification it
+// is marked as bad and the read returns a retriable error so it is
+// read again from another copy.
+func (o *Object) openVerified(ctx context.Context, ht hash.Type, options ...fs.OpenOption) (io.ReadCloser, error) {
+	sum, order, bad := o.consensus(ctx, ht)
+	if sum == "" {
+		return o.Object.Object.Open(ctx, options...)
+	}
+	var offset int64
+	for _, option := range options {
+		switch x := option.(type) {
+		case *fs.SeekOption:
+			offset = x.Offset
+		case *fs.RangeOption:
+			offset, _ = x.Decode(o.Size())
+		}
+	}
+
+	o.verifyMu.Lock()
+	state := o.verify
+	if offset == 0 {
+		state = &verifyState{}
+	} else if state == nil || state.offset != offset {
+		state = nil
+	} else {
+		// Carry on reading the same copy if it is still good
+		for i, obj := range order {
+			if obj == state.obj && !o.fs.bad.isBad(obj.UpstreamFs(), o.Remote()) {
+				order[0], order[i] = order[i], order[0]
+				break
+			}
+		}
+	}
+	o.verifyMu.Unlock()
+
+	var (
+		in  io.ReadCloser
+		obj *upstream.Object
+		err error
+	)
+	for _, obj = range order {
+		in, err = obj.Open(ctx, options...)
+		if err == nil {
+			break
+		}
+		fs.Errorf(o, "%s: failed to open for read: %v", obj.UpstreamFs().Name(), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		fs.Debugf(o, "not verifying read starting at %d", offset)
+		return in, nil
+	}
+	if offset == 0 {
+		state.obj = obj
+		state.hasher, err = hash.NewMultiHasherTypes(hash.NewHashSet(ht))
+		if err != nil {
+			_ = in.Close()
+			return nil, err
+		}
+	} else if state.obj != obj {
+		fs.Debugf(o, "not verifying read as it moved to a different copy")
+		state = nil
+	}
+	o.verifyMu.Lock()
+	o.verify = state
+	o.verifyMu.Unlock()
+	if state == nil {
+		return in, nil
+	}
+	return &verifyReader{
+		ReadCloser: in,
+		ctx:        ctx,
+		o:          o,
+		state:      state,
+		ht:         ht,
+		sum:        sum,
+		bad:        bad,
+	}, nil
+}
+
+// verifyReader hashes the data read and checks it when the end of the
+// object is reached
+type verifyReader struct {
+	io.ReadCloser
+	ctx   context.Context
+	o     *Object
+	state *verifyState
+	ht    hash.Type
+	sum   string
+	bad   []*upstream.Object
+}
+
+// Read bytes from the object - see io.Reader
+func (r *verifyReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	o := r.o
+	o.verifyMu.Lock()
+	if o.verify != r.state {
+		// Another read of the object has started
+		o.verifyMu.Unlock()
+		return n, err
+	}
+	_, _ = r.state.hasher.Write(p[:n])
+	r.state.offset += int64(n)
+	if r.state.offset < r.state.obj.Size() {
+		o.verifyMu.Unlock()
+		return n, err
+	}
+	o.verify = nil
+	o.verifyMu.Unlock()
+
+	u := r.state.obj.UpstreamFs()
+	got, _ := r.state.hasher.SumString(r.ht, false)
+	if got != r.sum {
+		o.fs.bad.mark(u, o.Remote())
+		verifyErr := fmt.Errorf("%s: corrupted read: %v hash %q differs from the %q of the other copies", u.Name(), r.ht, got, r.sum)
+		fs.Errorf(o, "%v", verifyErr)
+		return n, fserrors.RetryError(verifyErr)
+	}
+	fs.Debugf(o, "%s: verified %v hash of the data read", u.Name(), r.ht)
+	if o.fs.opt.RepairReads {
+		o.repair(r.ctx, r.state.obj, r.bad)
+	} else {
+		o.fs.bad.clear(u, o.Remote())
+	}
+	return n, err
+}
+
+// repair overwrites the bad copies of the object with good which has
+// just been verified
+func (o *Object) repair(ctx context.Context, good *upstream.Object, bad []*upstream.Object) {
+	o.fs.bad.clear(good.UpstreamFs(), o.Remote())
+	for _, obj := range bad {
+		u := obj.UpstreamFs()
+		if obj == good {
+			continue
+		}
+		if !u.IsWritable() {
+			fs.Logf(o, "%s: can't repair bad copy as upstream is read only", u.Name())
+			continue
+		}
+		_, err := operations.Copy(ctx, u.Fs, obj.UnWrap(), obj.Remote(), good.UnWrap())
+		if err != nil {
+			fs.Errorf(o, "%s: failed to repair bad copy: %v", u.Name(), err)
+			continue
+		}
+		fs.Logf(o, "%s: repaired bad copy from %s", u.Name(), good.UpstreamFs().Name())
+		o.fs.bad.clear(u, o.Remote())
+	}
+}
@@ -0,0 +1,135 @@
+package union
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMirror makes a union which writes to all of its local upstreams
+func newMirror(t *testing.T, repair bool) (*Fs, []string) {
+	dirs := MakeTestDirs(t, 3)
+	m := configmap.Simple{
+		"upstreams":     strings.Join(dirs, " "),
+		"create_policy": "all",
+		"action_policy": "all",
+		"search_policy": "ff",
+		"cache_time":    "120",
+		"verify_reads":  "true",
+	}
+	if repair {
+		m["repair_reads"] = "true"
+	}
+	f, err := NewFs(context.Background(), "TestUnionVerify", "", m)
+	require.NoError(t, err)
+	return f.(*Fs), dirs
+}
+
+// putMirrored puts contents to remote on all the upstreams of f
+func putMirrored(t *testing.T, f *Fs, remote, contents string) {
+	src := object.NewStaticObjectInfo(remote, time.Now(), int64(len(contents)), true, nil, nil)
+	_, err := f.Put(context.Background(), strings.NewReader(contents), src)
+	require.NoError(t, err)
+}
+
+// readAll reads the object in chunks of chunkSize like the VFS does
+func readAll(ctx context.Context, o fs.Object, chunkSize int64) (string, error) {
+	var out strings.Builder
+	for offset := int64(0); offset < o.Size(); offset += chunkSize {
+		in, err := o.Open(ctx, &fs.RangeOption{Start: offset, End: offset + chunkSize - 1})
+		if err != nil {
+			return out.String(), err
+		}
+		_, err = io.Copy(&out, in)
+		_ = in.Close()
+		if err != nil {
+			return out.String(), err
+		}
+	}
+	return out.String(), nil
+}
+
+func TestVerifyReads(t *testing.T) {
+	ctx := context.Background()
+	f, dirs := newMirror(t, false)
+	const contents = "hello, this is the file contents"
+	putMirrored(t, f, "file.txt", contents)
+
+	o, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	good, err := o.Hash(ctx, hash.MD5)
+	require.NoError(t, err)
+
+	// All the copies agree so the reads are fine
+	got, err := readAll(ctx, o, 10)
+	require.NoError(t, err)
+	assert.Equal(t, contents, got)
+
+	// Corrupt the copy which will be read first
+	corrupt := strings.ToUpper(contents)
+	require.NoError(t, os.WriteFile(filepath.Join(dirs[0], "file.txt"), []byte(corrupt), 0666))
+
+	// The consensus is still the good hash
+	sum, err := o.Hash(ctx, hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, good, sum)
+
+	// Reads come from a good copy as the bad one is spotted
+	got, err = readAll(ctx, o, 10)
+	require.NoError(t, err)
+	assert.Equal(t, contents, got)
+	assert.True(t, f.bad.isBad(f.upstreams[0], "file.txt"))
+
+	// Reads which don't start at the beginning aren't verified
+	in, err := o.Open(ctx, &fs.SeekOption{Offset: 5})
+	require.NoError(t, err)
+	_, err = io.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+}
+
+func TestVerifyReadsCorrupted(t *testing.T) {
+	ctx := context.Background()
+	f, dirs := newMirror(t, true)
+	const contents = "hello, this is the file contents"
+	putMirrored(t, f, "file.txt", contents)
+
+	o, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	uo := o.(*Object)
+
+	// Corrupt the first copy after the hashes have been compared
+	// so it is only spotted by reading it
+	in, err := uo.Open(ctx)
+	require.NoError(t, err)
+	corrupt := strings.ToUpper(contents)
+	require.NoError(t, os.WriteFile(filepath.Join(dirs[0], "file.txt"), []byte(corrupt), 0666))
+	data, err := io.ReadAll(in)
+	require.NoError(t, in.Close())
+	require.Equal(t, corrupt, string(data))
+	require.Error(t, err)
+	assert.True(t, fserrors.IsRetryError(err))
+	assert.True(t, f.bad.isBad(f.upstreams[0], "file.txt"))
+
+	// The retry reads a good copy and repairs the bad one
+	got, err := readAll(ctx, o, 10)
+	require.NoError(t, err)
+	assert.Equal(t, contents, got)
+	assert.False(t, f.bad.isBad(f.upstreams[0], "file.txt"))
+	repaired, err := os.ReadFile(filepath.Join(dirs[0], "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(repaired))
+}
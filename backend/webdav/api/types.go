@@ -77,6 +77,7 @@ type Prop struct {
 	Checksums    []string  `xml:"prop>checksums>checksum,omitempty"`
 	Permissions  string    `xml:"prop>permissions,omitempty"`
 	MESha1Hex    *string   `xml:"ME: prop>sha1hex,omitempty"` // Fastmail-specific sha1 checksum
+	FileID       string    `xml:"prop>fileid,omitempty"`      // Nextcloud/ownCloud file ID
 }
 
 // Parse a status of the form "HTTP/1.1 200 OK" or "HTTP/1.1 200"
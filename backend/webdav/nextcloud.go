@@ -0,0 +1,328 @@
+package webdav
+
+/*
+	versions and trash bin for Nextcloud
+	see https://docs.nextcloud.com/server/latest/developer_manual/client_apis/WebDAV/versions.html
+	and https://docs.nextcloud.com/server/latest/developer_manual/client_apis/WebDAV/trashbin.html
+*/
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/backend/webdav/api"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/rest"
+	"golang.org/x/sync/singleflight"
+)
+
+// nextcloudTrashDir is the virtual directory the trash bin is shown as
+const nextcloudTrashDir = ".trash"
+
+// Read the file ID of a file
+var nextcloudFileIDProps = []byte(`<?xml version="1.0"?>
+<d:propfind  xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns">
+ <d:prop>
+  <oc:fileid />
+ </d:prop>
+</d:propfind>
+`)
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "versions",
+	Short: "List the versions of a file on Nextcloud.",
+	Long: `This command lists the old versions of the file passed in which
+Nextcloud has kept. It needs --webdav-nextcloud-versions.
+
+Usage example:
+
+` + "```console" + `
+rclone backend versions nextcloud: path/to/file.txt
+` + "```" + `
+
+It returns a list of versions, newest first, like this:
+
+` + "```json" + `
+[
+    {
+        "version": "1700000000",
+        "modTime": "2023-11-14T22:13:20Z",
+        "size": 1234
+    }
+]
+` + "```" + `
+
+The version can be passed to the restore command.`,
+}, {
+	Name:  "restore",
+	Short: "Restore a version of a file or files from the trash on Nextcloud.",
+	Long: `This command restores an old version of a file, or restores files
+and directories from the trash bin. It needs --webdav-nextcloud-versions.
+
+To restore a version of a file pass the version from the versions
+command:
+
+` + "```console" + `
+rclone backend restore nextcloud: path/to/file.txt -o version=1700000000
+` + "```" + `
+
+To restore files and directories from the trash bin to where they
+were deleted from, pass their names in the ".trash" directory:
+
+` + "```console" + `
+rclone lsf nextcloud:.trash
+rclone backend restore nextcloud:.trash file.txt.d1700000000 dir.d1700000000
+` + "```",
+	Opts: map[string]string{
+		"version": "Version of the file to restore.",
+	},
+}}
+
+// trashPath returns the path inside the trash bin if root is in the
+// trash bin directory
+func trashPath(root string) (trashRoot string, ok bool) {
+	if root == nextcloudTrashDir {
+		return "", true
+	}
+	return strings.CutPrefix(root, nextcloudTrashDir+"/")
+}
+
+// setupNextcloudVersions finds the Nextcloud DAV endpoints for the
+// versions and the trash bin.
+//
+// If root is inside the trash bin directory it returns an Fs for the
+// trash bin and the root inside it.
+func (f *Fs) setupNextcloudVersions(ctx context.Context, client *http.Client, root string) (*Fs, string, error) {
+	if f.opt.Vendor != "nextcloud" {
+		return nil, "", errors.New("nextcloud_versions needs vendor = nextcloud")
+	}
+	submatch := nextCloudURLRegex.FindStringSubmatch(f.endpointURL)
+	if submatch == nil {
+		return nil, "", errors.New("nextcloud_versions needs the url to end in /dav/files/USER")
+	}
+	f.nextcloudURL, f.nextcloudUser = submatch[1]+"/dav", submatch[2]
+	trashRoot, ok := trashPath(root)
+	if !ok {
+		return f, root, nil
+	}
+	trashURL, err := url.Parse(f.nextcloudURL + "/trashbin/" + f.nextcloudUser + "/trash/")
+	if err != nil {
+		return nil, "", err
+	}
+	t := &Fs{
+		name:             f.name,
+		root:             trashRoot,
+		opt:              f.opt,
+		endpoint:         trashURL,
+		endpointURL:      trashURL.String(),
+		srv:              rest.NewClient(client).SetRoot(trashURL.String()),
+		pacer:            f.pacer,
+		precision:        f.precision,
+		hasOCSHA1:        f.hasOCSHA1,
+		nextcloudURL:     f.nextcloudURL,
+		nextcloudUser:    f.nextcloudUser,
+		isTrash:          true,
+		authSingleflight: new(singleflight.Group),
+	}
+	t.features = (&fs.Features{
+		CanHaveEmptyDirectories: true,
+	}).Fill(ctx, t)
+	t.features.PutStream = nil
+	err = t.setAuth()
+	if err != nil {
+		return nil, "", err
+	}
+	if !t.findHeader(t.opt.Headers, "Referer") {
+		t.srv.SetHeader("Referer", f.endpointURL)
+	}
+	return t, trashRoot, nil
+}
+
+// nextcloudVersion describes a version of a file
+type nextcloudVersion struct {
+	Version string    `json:"version"`
+	ModTime time.Time `json:"modTime"`
+	Size    int64     `json:"size"`
+}
+
+// versionsURL returns the URL of the versions of the file with fileID
+func (f *Fs) versionsURL(fileID string) string {
+	return f.nextcloudURL + "/versions/" + f.nextcloudUser + "/versions/" + fileID + "/"
+}
+
+// fileID reads the Nextcloud file ID of the file at remote
+func (f *Fs) fileID(ctx context.Context, remote string) (string, error) {
+	opts := rest.Opts{
+		Method: "PROPFIND",
+		Path:   f.filePath(remote),
+		ExtraHeaders: map[string]string{
+			"Depth": "0",
+		},
+		Body: bytes.NewBuffer(nextcloudFileIDProps),
+	}
+	var result api.Multistatus
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallXML(ctx, &opts, nil, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if apiErr, ok := err.(*api.Error); ok && apiErr.StatusCode == http.StatusNotFound {
+		return "", fs.ErrorObjectNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read file ID: %w", err)
+	}
+	if len(result.Responses) < 1 || result.Responses[0].Props.FileID == "" {
+		return "", fmt.Errorf("no file ID found for %q", remote)
+	}
+	if itemIsDir(&result.Responses[0]) {
+		return "", fs.ErrorIsDir
+	}
+	return result.Responses[0].Props.FileID, nil
+}
+
+// listVersions lists the versions of the file at remote, newest first
+func (f *Fs) listVersions(ctx context.Context, remote string) (versions []nextcloudVersion, err error) {
+	fileID, err := f.fileID(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	opts := rest.Opts{
+		Method:  "PROPFIND",
+		RootURL: f.versionsURL(fileID),
+		ExtraHeaders: map[string]string{
+			"Depth": "1",
+		},
+	}
+	var result api.Multistatus
+	var resp *http.Response
+	err = f.pacer.Call(func() (bool, error) {
+		resp, err = f.srv.CallXML(ctx, &opts, nil, &result)
+		return f.shouldRetry(ctx, resp, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	versions = []nextcloudVersion{}
+	for i := range result.Responses {
+		item := &result.Responses[i]
+		// the listing contains the versions directory itself
+		if strings.HasSuffix(item.Href, "/") || itemIsDir(item) || !item.Props.StatusOK() {
+			continue
+		}
+		versions = append(versions, nextcloudVersion{
+			Version: path.Base(item.Href),
+			ModTime: time.Time(item.Props.Modified),
+			Size:    item.Props.Size,
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ModTime.After(versions[j].ModTime)
+	})
+	return versions, nil
+}
+
+// move the item at srcURL to dstURL with the MOVE method
+func (f *Fs) moveURL(ctx context.Context, srcURL, dstURL string) error {
+	opts := rest.Opts{
+		Method:     "MOVE",
+		RootURL:    srcURL,
+		NoResponse: true,
+		ExtraHeaders: map[string]string{
+			"Destination": dstURL,
+			"Overwrite":   "T",
+		},
+	}
+	return f.pacer.Call(func() (bool, error) {
+		resp, err := f.srv.Call(ctx, &opts)
+		return f.shouldRetry(ctx, resp, err)
+	})
+}
+
+// restoreVersion restores the version of the file at remote
+func (f *Fs) restoreVersion(ctx context.Context, remote, version string) error {
+	fileID, err := f.fileID(ctx, remote)
+	if err != nil {
+		return err
+	}
+	srcURL := f.versionsURL(fileID) + rest.URLPathEscape(version)
+	dstURL := f.nextcloudURL + "/versions/" + f.nextcloudUser + "/restore/target"
+	err = f.moveURL(ctx, srcURL, dstURL)
+	if err != nil {
+		return fmt.Errorf("failed to restore version %q: %w", version, err)
+	}
+	return nil
+}
+
+// restoreTrash restores the item at remote in the trash bin to where
+// it was deleted from
+func (f *Fs) restoreTrash(ctx context.Context, remote string) error {
+	srcURL := f.endpointURL + strings.TrimPrefix(f.filePath(remote), "/")
+	dstURL := f.nextcloudURL + "/trashbin/" + f.nextcloudUser + "/restore/" + rest.URLPathEscape(path.Base(remote))
+	err := f.moveURL(ctx, srcURL, dstURL)
+	if err != nil {
+		return fmt.Errorf("failed to restore %q from trash: %w", remote, err)
+	}
+	return nil
+}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out any, err error) {
+	switch name {
+	case "versions", "restore":
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+	if f.nextcloudURL == "" {
+		return nil, errors.New("need --webdav-nextcloud-versions")
+	}
+	switch name {
+	case "versions":
+		if f.isTrash {
+			return nil, errors.New("can't list the versions of files in the trash")
+		}
+		if len(arg) != 1 {
+			return nil, errors.New("need exactly one argument - the file to list the versions of")
+		}
+		return f.listVersions(ctx, arg[0])
+	case "restore":
+		if len(arg) == 0 {
+			return nil, errors.New("need at least one file to restore")
+		}
+		if f.isTrash {
+			for _, remote := range arg {
+				err = f.restoreTrash(ctx, remote)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		}
+		version := opt["version"]
+		if version == "" {
+			return nil, errors.New("need -o version=VERSION to restore - see the versions command")
+		}
+		if len(arg) != 1 {
+			return nil, errors.New("need exactly one file to restore a version of")
+		}
+		return nil, f.restoreVersion(ctx, arg[0], version)
+	}
+	return nil, fs.ErrorCommandNotFound
+}
@@ -41,6 +41,7 @@ import (
 	"github.com/rclone/rclone/fs/list"
 	"github.com/rclone/rclone/lib/encoder"
 	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/ratelimit"
 	"github.com/rclone/rclone/lib/rest"
 )
 
@@ -71,7 +72,8 @@ func init() {
 		Name:        "webdav",
 		Description: "WebDAV",
 		NewFs:       NewFs,
-		Options: []fs.Option{{
+		CommandHelp: commandHelp,
+		Options: append([]fs.Option{{
 			Name:     "url",
 			Help:     "URL of http host to connect to.\n\nE.g. https://example.com.",
 			Required: true,
@@ -154,6 +156,22 @@ Set to 0 to disable chunked uploading.
 `,
 			Advanced: true,
 			Default:  10 * fs.Mebi, // Default NextCloud `max_chunk_size` is `10 MiB`. See https://github.com/nextcloud/server/blob/0447b53bda9fe95ea0cbed765aa332584605d652/apps/files/lib/App.php#L57
+		}, {
+			Name: "nextcloud_versions",
+			Help: `Enable access to Nextcloud versions and trash bin.
+
+If this is set then the "versions" and "restore" backend commands can
+be used to list and restore old versions of files, and the trash bin
+can be accessed as the ".trash" directory at the top of the remote,
+e.g. "remote:.trash".
+
+The ".trash" directory isn't shown in listings so it won't be copied
+by a sync of the whole remote.
+
+This needs vendor = nextcloud and the url to end in /dav/files/USER.
+`,
+			Advanced: true,
+			Default:  false,
 		}, {
 			Name:     "owncloud_exclude_shares",
 			Help:     "Exclude ownCloud shares",
@@ -183,7 +201,7 @@ files from the webdav server then you can try this option.
 `,
 				Advanced: true,
 				Default:  false,
-			}},
+			}}, ratelimit.SharedOptions...),
 	})
 }
 
@@ -199,10 +217,12 @@ type Options struct {
 	Headers            fs.CommaSepList      `config:"headers"`
 	PacerMinSleep      fs.Duration          `config:"pacer_min_sleep"`
 	ChunkSize          fs.SizeSuffix        `config:"nextcloud_chunk_size"`
+	NextcloudVersions  bool                 `config:"nextcloud_versions"`
 	ExcludeShares      bool                 `config:"owncloud_exclude_shares"`
 	ExcludeMounts      bool                 `config:"owncloud_exclude_mounts"`
 	UnixSocket         string               `config:"unix_socket"`
 	AuthRedirect       bool                 `config:"auth_redirect"`
+	ratelimit.Options
 }
 
 // Fs represents a remote webdav
@@ -228,6 +248,9 @@ type Fs struct {
 	ntlmAuthMu         sync.Mutex    // mutex to serialize NTLM auth roundtrips
 	chunksUploadURL    string        // upload URL for nextcloud chunked
 	canChunk           bool          // set if nextcloud and nextcloud_chunk_size is set
+	nextcloudURL       string        // base URL of the nextcloud DAV endpoints if nextcloud_versions is set
+	nextcloudUser      string        // user name in the nextcloud DAV endpoints
+	isTrash            bool          // set if this is the nextcloud trash bin
 	authSingleflight   *singleflight.Group
 }
 
@@ -258,6 +281,9 @@ func (f *Fs) Root() string {
 
 // String converts this Fs to a string
 func (f *Fs) String() string {
+	if f.isTrash {
+		return fmt.Sprintf("webdav root '%s'", path.Join(nextcloudTrashDir, f.root))
+	}
 	return fmt.Sprintf("webdav root '%s'", f.root)
 }
 
@@ -510,25 +536,17 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 			rt: ntlmssp.Negotiator{RoundTripper: t},
 		}
 	}
+	// Apply the per remote tpslimit and bwlimit if set
+	client.Transport = ratelimit.New(name, &opt.Options).Transport(client.Transport)
 	f.srv = rest.NewClient(client).SetRoot(u.String())
 
 	f.features = (&fs.Features{
 		CanHaveEmptyDirectories: true,
 	}).Fill(ctx, f)
-	if opt.User != "" || opt.Pass != "" {
-		f.srv.SetUserPass(opt.User, opt.Pass)
-	} else if opt.BearerToken != "" {
-		f.setBearerToken(opt.BearerToken)
-	} else if len(f.opt.BearerTokenCommand) != 0 {
-		err = f.fetchAndSetBearerToken()
-		if err != nil {
-			return nil, err
-		}
-	}
-	if opt.Headers != nil {
-		f.addHeaders(opt.Headers)
+	err = f.setAuth()
+	if err != nil {
+		return nil, err
 	}
-	f.srv.SetErrorHandler(errorHandler)
 	err = f.setQuirks(ctx, opt.Vendor)
 	if err != nil {
 		return nil, err
@@ -536,6 +554,12 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if !f.findHeader(opt.Headers, "Referer") {
 		f.srv.SetHeader("Referer", u.String())
 	}
+	if opt.NextcloudVersions {
+		f, root, err = f.setupNextcloudVersions(ctx, client, root)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if root != "" && !rootIsDir {
 		// Check to see if the root actually an existing file
@@ -559,6 +583,26 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	return f, nil
 }
 
+// setAuth sets up the authentication, the extra headers and the
+// error handler for f.srv
+func (f *Fs) setAuth() error {
+	if f.opt.User != "" || f.opt.Pass != "" {
+		f.srv.SetUserPass(f.opt.User, f.opt.Pass)
+	} else if f.opt.BearerToken != "" {
+		f.setBearerToken(f.opt.BearerToken)
+	} else if len(f.opt.BearerTokenCommand) != 0 {
+		err := f.fetchAndSetBearerToken()
+		if err != nil {
+			return err
+		}
+	}
+	if f.opt.Headers != nil {
+		f.addHeaders(f.opt.Headers)
+	}
+	f.srv.SetErrorHandler(errorHandler)
+	return nil
+}
+
 // sets the BearerToken up
 func (f *Fs) setBearerToken(token string) {
 	f.opt.BearerToken = token
@@ -1662,5 +1706,6 @@ var (
 	_ fs.DirMover    = (*Fs)(nil)
 	_ fs.ListPer     = (*Fs)(nil)
 	_ fs.Abouter     = (*Fs)(nil)
+	_ fs.Commander   = (*Fs)(nil)
 	_ fs.Object      = (*Object)(nil)
 )
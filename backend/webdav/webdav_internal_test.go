@@ -2,6 +2,7 @@ package webdav_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -80,3 +81,112 @@ func TestHeaders(t *testing.T) {
 	_, err := f.Features().About(context.Background())
 	require.NoError(t, err)
 }
+
+// prepareNextcloud makes a test server which looks like the Nextcloud
+// versions and trash bin endpoints and records the MOVE requests
+func prepareNextcloud(t *testing.T) (m configmap.Simple, moves map[string]string, tidy func()) {
+	moves = map[string]string{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "MOVE" {
+			moves[r.URL.Path] = r.Header.Get("Destination")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		require.Equal(t, "PROPFIND", r.Method)
+		var responses string
+		switch r.URL.Path {
+		case "/remote.php/dav/files/user/file.txt":
+			responses = `<d:response>
+ <d:href>/remote.php/dav/files/user/file.txt</d:href>
+ <d:propstat><d:prop><oc:fileid>42</oc:fileid></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>
+</d:response>`
+		case "/remote.php/dav/versions/user/versions/42/":
+			responses = `<d:response>
+ <d:href>/remote.php/dav/versions/user/versions/42/</d:href>
+ <d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>
+</d:response>
+<d:response>
+ <d:href>/remote.php/dav/versions/user/versions/42/1600000000</d:href>
+ <d:propstat><d:prop><d:getlastmodified>Sun, 13 Sep 2020 12:26:40 GMT</d:getlastmodified><d:getcontentlength>10</d:getcontentlength><d:resourcetype/></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>
+</d:response>
+<d:response>
+ <d:href>/remote.php/dav/versions/user/versions/42/1700000000</d:href>
+ <d:propstat><d:prop><d:getlastmodified>Tue, 14 Nov 2023 22:13:20 GMT</d:getlastmodified><d:getcontentlength>20</d:getcontentlength><d:resourcetype/></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>
+</d:response>`
+		case "/remote.php/dav/trashbin/user/trash/":
+			responses = `<d:response>
+ <d:href>/remote.php/dav/trashbin/user/trash/</d:href>
+ <d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>
+</d:response>
+<d:response>
+ <d:href>/remote.php/dav/trashbin/user/trash/file.txt.d1700000000</d:href>
+ <d:propstat><d:prop><d:getlastmodified>Tue, 14 Nov 2023 22:13:20 GMT</d:getlastmodified><d:getcontentlength>20</d:getcontentlength><d:resourcetype/></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>
+</d:response>`
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		_, err := fmt.Fprintf(w, `<d:multistatus xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns" xmlns:nc="http://nextcloud.org/ns">%s</d:multistatus>`, responses)
+		require.NoError(t, err)
+	})
+	ts := httptest.NewServer(handler)
+	configfile.Install()
+	m = configmap.Simple{
+		"type":               "webdav",
+		"url":                ts.URL + "/remote.php/dav/files/user/",
+		"vendor":             "nextcloud",
+		"nextcloud_versions": "true",
+	}
+	return m, moves, ts.Close
+}
+
+func TestNextcloudVersions(t *testing.T) {
+	ctx := context.Background()
+	m, moves, tidy := prepareNextcloud(t)
+	defer tidy()
+
+	f, err := webdav.NewFs(ctx, remoteName, "", m)
+	require.NoError(t, err)
+	command := f.Features().Command
+	require.NotNil(t, command)
+
+	out, err := command(ctx, "versions", []string{"file.txt"}, nil)
+	require.NoError(t, err)
+	versions, err := json.Marshal(out)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"version": "1700000000", "modTime": "2023-11-14T22:13:20Z", "size": 20},
+		{"version": "1600000000", "modTime": "2020-09-13T12:26:40Z", "size": 10}
+	]`, string(versions))
+
+	_, err = command(ctx, "restore", []string{"file.txt"}, nil)
+	assert.ErrorContains(t, err, "version")
+
+	_, err = command(ctx, "restore", []string{"file.txt"}, map[string]string{"version": "1600000000"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(moves["/remote.php/dav/versions/user/versions/42/1600000000"], "/remote.php/dav/versions/user/restore/target"), moves)
+}
+
+func TestNextcloudTrash(t *testing.T) {
+	ctx := context.Background()
+	m, moves, tidy := prepareNextcloud(t)
+	defer tidy()
+
+	f, err := webdav.NewFs(ctx, remoteName, ".trash", m)
+	require.NoError(t, err)
+	assert.Equal(t, "webdav root '.trash'", f.String())
+
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "file.txt.d1700000000", entries[0].Remote())
+	assert.Equal(t, int64(20), entries[0].Size())
+
+	_, err = f.Features().Command(ctx, "restore", []string{"file.txt.d1700000000"}, nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(moves["/remote.php/dav/trashbin/user/trash/file.txt.d1700000000"], "/remote.php/dav/trashbin/user/restore/file.txt.d1700000000"), moves)
+
+	_, err = f.Features().Command(ctx, "versions", []string{"file.txt.d1700000000"}, nil)
+	assert.Error(t, err)
+}
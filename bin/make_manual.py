@@ -45,6 +45,7 @@ docs = [
     "doi.md",
     "dropbox.md",
     "filefabric.md",
+    "faulty.md",
     "filelu.md",
     "filescom.md",
     "ftp.md",
@@ -56,9 +57,11 @@ docs = [
     "hdfs.md",
     "hidrive.md",
     "http.md",
+    "huggingface.md",
     "imagekit.md",
     "iclouddrive.md",
     "internetarchive.md",
+    "ipfs.md",
     "jottacloud.md",
     "koofr.md",
     "linkbox.md",
@@ -75,6 +78,7 @@ docs = [
     "quatrix.md",
     "sia.md",
     "swift.md",
+    "pack.md",
     "pcloud.md",
     "pikpak.md",
     "pixeldrain.md",
@@ -82,6 +86,7 @@ docs = [
     "protondrive.md",
     "putio.md",
     "protondrive.md",
+    "readcache.md",
     "seafile.md",
     "sftp.md",
     "smb.md",
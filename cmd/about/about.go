@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 
 	"github.com/rclone/rclone/cmd"
 	"github.com/rclone/rclone/fs"
@@ -43,6 +45,16 @@ func printValue(what string, uv *int64, isSize bool) {
 	fmt.Printf("%-9s%v\n", what, val)
 }
 
+// printUsage prints the values in u
+func printUsage(u *fs.Usage) {
+	printValue("Total", u.Total, true)
+	printValue("Used", u.Used, true)
+	printValue("Free", u.Free, true)
+	printValue("Trashed", u.Trashed, true)
+	printValue("Other", u.Other, true)
+	printValue("Objects", u.Objects, false)
+}
+
 var commandDefinition = &cobra.Command{
 	Use:   "about remote:",
 	Short: `Get quota information from the remote.`,
@@ -95,6 +107,11 @@ A ` + "`--json`" + ` flag generates conveniently machine-readable output, e.g.
 Not all backends print all fields. Information is not included if it is not
 provided by a backend. Where the value is unlimited it is omitted.
 
+Remotes which combine others, such as union, also show the usage of
+each upstream, in the ` + "`upstreams`" + ` object of the JSON output. Remotes
+which wrap another, such as crypt, show the free space as the size of
+file which can be uploaded, allowing for any overhead.
+
 Some backends does not support the ` + "`rclone about`" + ` command at all,
 see complete list in [documentation](https://rclone.org/overview/#optional-features).`,
 	Annotations: map[string]string{
@@ -122,12 +139,12 @@ see complete list in [documentation](https://rclone.org/overview/#optional-featu
 				return out.Encode(u)
 			}
 
-			printValue("Total", u.Total, true)
-			printValue("Used", u.Used, true)
-			printValue("Free", u.Free, true)
-			printValue("Trashed", u.Trashed, true)
-			printValue("Other", u.Other, true)
-			printValue("Objects", u.Objects, false)
+			printUsage(u)
+			names := slices.Sorted(maps.Keys(u.Upstreams))
+			for _, name := range names {
+				fmt.Printf("\nUpstream %s\n", name)
+				printUsage(u.Upstreams[name])
+			}
 			return nil
 		})
 	},
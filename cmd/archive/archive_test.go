@@ -2,6 +2,7 @@ package archive_test
 
 import (
 	"context"
+	"io"
 	"strings"
 	"testing"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/rclone/rclone/cmd/archive/create"
 	"github.com/rclone/rclone/cmd/archive/extract"
 	"github.com/rclone/rclone/cmd/archive/list"
+	"github.com/rclone/rclone/cmd/archive/pack"
 )
 
 var (
@@ -132,6 +134,43 @@ func testArchiveRemote(t *testing.T, fromLocal bool, subDir string, extension st
 	fstest.CheckListingWithPrecision(t, src, items, nil, fs.ModTimeNotSupported)
 }
 
+// test packing to the remote and reading back with the pack backend
+func TestArchivePack(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	f1 := r.WriteFile("file1.txt", "content 1", t1)
+	f2 := r.WriteFile("dir1/sub1.txt", "sub content 1", t1)
+	f3 := r.WriteFile("dir1/deeper/sub2.txt", "sub content 2", t1)
+	f4 := r.WriteFile("big.txt", "this file is too big to be packed", t1)
+
+	opt := pack.DefaultOptions()
+	opt.Threshold = 20
+	opt.Depth = 1
+	require.NoError(t, pack.Pack(ctx, r.Fremote, r.Flocal, opt))
+
+	// Only the big file is stored as is
+	entries, err := r.Fremote.List(ctx, "")
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	assert.ElementsMatch(t, []string{".rclone-pack.json", ".rclone-pack-0000.tar", "big.txt", "dir1"}, names)
+
+	// Read everything back with the pack backend
+	fpack, err := fs.NewFs(ctx, ":pack:"+fs.ConfigString(r.Fremote))
+	require.NoError(t, err)
+	fstest.CheckListingWithPrecision(t, fpack, []fstest.Item{f1, f2, f3, f4}, []string{"dir1", "dir1/deeper"}, fs.GetModifyWindow(ctx, fpack))
+	o, err := fpack.NewObject(ctx, "dir1/deeper/sub2.txt")
+	require.NoError(t, err)
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	data, err := io.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "sub content 2", string(data))
+}
+
 func testArchive(t *testing.T) {
 	var extensions = []string{
 		"zip",
@@ -0,0 +1,184 @@
+//go:build !plan9
+
+// Package pack implements 'rclone archive pack'.
+package pack
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	packbackend "github.com/rclone/rclone/backend/pack"
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/archive"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/walk"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+// Options for Pack
+type Options struct {
+	Threshold   fs.SizeSuffix // files smaller than this are packed
+	Depth       int           // directory depth to pack at or -1 for each directory
+	MaxPackSize fs.SizeSuffix // start a new pack when one gets this big
+}
+
+// DefaultOptions returns the default options for Pack
+func DefaultOptions() Options {
+	return Options{
+		Threshold:   fs.Mebi,
+		Depth:       -1,
+		MaxPackSize: fs.Gibi,
+	}
+}
+
+var opt = DefaultOptions()
+
+func init() {
+	flagSet := Command.Flags()
+	flags.FVarP(flagSet, &opt.Threshold, "threshold", "", "Pack files smaller than this size", "")
+	flags.IntVarP(flagSet, &opt.Depth, "depth", "", opt.Depth, "Make one set of packs per directory at this depth (-1 for every directory)", "")
+	flags.FVarP(flagSet, &opt.MaxPackSize, "max-pack-size", "", "Start a new pack when it reaches this size", "")
+	archive.Command.AddCommand(Command)
+}
+
+// Command - pack
+var Command = &cobra.Command{
+	Use:   "pack [flags] <source> <destination>",
+	Short: `Copy source to destination packing small files into containers.`,
+	// Warning! "!" will be replaced by backticks below
+	Long: strings.ReplaceAll(`
+Copies the files in source:path to dest:path, packing the files
+smaller than !--threshold! into tar containers as it goes.
+
+Uploading lots of small files is slow on most cloud storage systems
+and some charge per object stored. Packing them into larger containers
+avoids this.
+
+Each directory which has packed files gets one or more containers
+called !.rclone-pack-NNNN.tar! and an index called
+!.rclone-pack.json! recording where each file is. Files of
+!--threshold! or larger are copied as normal.
+
+By default each directory is packed separately. Use !--depth! to pack
+whole subtrees together instead, so !--depth 0! packs everything into
+the root of dest:path and !--depth 1! makes one set of packs for each
+top level directory. A new container is started when one reaches
+!--max-pack-size!.
+
+For example:
+
+!!!
+rclone archive pack --threshold 256k /path/to/photos remote:photos
+!!!
+
+The packed files can be read with the [pack backend](/pack/) which
+unpacks them transparently, so to list them, copy them back or mount
+them use:
+
+!!!
+rclone ls :pack:remote:photos
+rclone copy :pack:remote:photos /path/to/restore
+rclone mount :pack:remote:photos /mnt/photos
+!!!
+
+Running !rclone archive pack! again replaces the index in each packed
+directory, so it should be run against the whole of source:path.
+`, "!", "`"),
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.72",
+		"groups":            "Filter,Listing,Copy",
+	},
+	RunE: func(command *cobra.Command, args []string) error {
+		cmd.CheckArgs(2, 2, command, args)
+		fsrc, fdst := cmd.NewFsSrcDst(args)
+		cmd.Run(true, true, command, func() error {
+			return Pack(context.Background(), fdst, fsrc, opt)
+		})
+		return nil
+	},
+}
+
+// packDir returns the directory remote should be packed into
+func packDir(remote string, depth int) string {
+	dir := path.Dir(remote)
+	if dir == "." {
+		return ""
+	}
+	if depth < 0 {
+		return dir
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+// Pack copies the files in fsrc to fdst, packing those smaller than
+// opt.Threshold into containers which can be read with the pack
+// backend.
+func Pack(ctx context.Context, fdst, fsrc fs.Fs, opt Options) error {
+	ci := fs.GetConfig(ctx)
+	var (
+		mu    sync.Mutex
+		large []fs.Object
+		small = map[string][]fs.Object{}
+	)
+	err := walk.ListR(ctx, fsrc, "", false, ci.MaxDepth, walk.ListObjects, func(entries fs.DirEntries) error {
+		mu.Lock()
+		defer mu.Unlock()
+		entries.ForObject(func(o fs.Object) {
+			if packbackend.IsPackFile(path.Base(o.Remote())) {
+				fs.Logf(o, "Not packing as it is part of a pack")
+				return
+			}
+			if o.Size() < 0 || o.Size() >= int64(opt.Threshold) {
+				large = append(large, o)
+				return
+			}
+			dir := packDir(o.Remote(), opt.Depth)
+			small[dir] = append(small[dir], o)
+		})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list source: %w", err)
+	}
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(ci.Transfers)
+	for _, o := range large {
+		g.Go(func() error {
+			_, err := operations.Copy(gCtx, fdst, nil, o.Remote(), o)
+			if err != nil {
+				err = fs.CountError(gCtx, err)
+				fs.Errorf(o, "Failed to copy: %v", err)
+			}
+			return nil
+		})
+	}
+	for dir, objs := range small {
+		sort.Slice(objs, func(i, j int) bool {
+			return objs[i].Remote() < objs[j].Remote()
+		})
+		g.Go(func() error {
+			if ci.DryRun {
+				fs.Logf(fdst, "Not packing %d files into %q as --dry-run is set", len(objs), dir)
+				return nil
+			}
+			err := packbackend.WriteDir(gCtx, fdst, dir, objs, int64(opt.MaxPackSize))
+			if err != nil {
+				return fmt.Errorf("failed to pack %q: %w", dir, err)
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
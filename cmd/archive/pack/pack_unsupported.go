@@ -0,0 +1,7 @@
+// Build for unsupported platforms to stop go complaining
+// about "no buildable Go source files "
+
+//go:build plan9
+
+// Package pack implements 'rclone archive pack'.
+package pack
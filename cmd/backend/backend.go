@@ -32,8 +32,9 @@ var commandDefinition = &cobra.Command{
 	Use:   "backend <command> remote:path [opts] <args>",
 	Short: `Run a backend-specific command.`,
 	Long: `This runs a backend-specific command. The commands themselves (except
-for "help" and "features") are defined by the backends and you should
-see the backend docs for definitions.
+for "help", "features", "list-resumables" and "cleanup-resumables") are
+defined by the backends and you should see the backend docs for
+definitions.
 
 You can discover what commands a backend implements by using
 
@@ -50,6 +51,14 @@ for more info).
 rclone backend features remote:
 ` + "```" + `
 
+You can list the uploads which can be resumed with
+[--resume-uploads](/docs/#resume-uploads) and abort them using
+
+` + "```console" + `
+rclone backend list-resumables remote:path
+rclone backend cleanup-resumables remote:path -o max-age=24h
+` + "```" + `
+
 Pass options to the backend command with -o. This should be key=value or key, e.g.:
 
 ` + "```console" + `
@@ -95,6 +104,8 @@ Note to run these commands on a running backend then see
 				return showHelp(fsInfo)
 			case "features":
 				out = operations.GetFsInfo(f)
+			case "list-resumables", "cleanup-resumables":
+				out, err = operations.ResumablesCommand(context.Background(), f, name, rc.ParseOptions(options))
 			default:
 				doCommand := f.Features().Command
 				if doCommand == nil {
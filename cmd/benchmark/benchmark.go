@@ -0,0 +1,512 @@
+// Package benchmark provides the benchmark command.
+package benchmark
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/lib/atexit"
+	"github.com/rclone/rclone/lib/random"
+	"github.com/rclone/rclone/lib/readers"
+	"github.com/spf13/cobra"
+)
+
+// Workload names
+const (
+	workloadSmall    = "small"
+	workloadBig      = "big"
+	workloadMetadata = "metadata"
+	workloadMixed    = "mixed"
+)
+
+var allWorkloads = []string{workloadSmall, workloadBig, workloadMetadata, workloadMixed}
+
+var (
+	workloads   = []string{workloadSmall, workloadBig, workloadMetadata}
+	duration    = fs.Duration(10 * time.Second)
+	concurrency = 4
+	smallSize   = fs.SizeSuffix(16 * 1024)
+	bigSize     = fs.SizeSuffix(64 * 1024 * 1024)
+	bigFiles    = 2
+	format      = "text"
+	outputFile  = ""
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.StringArrayVarP(cmdFlags, &workloads, "workload", "", workloads, "Workloads to run: "+strings.Join(allWorkloads, ", "), "")
+	flags.FVarP(cmdFlags, &duration, "duration", "", "How long to run each of the small, metadata and mixed workloads", "")
+	flags.IntVarP(cmdFlags, &concurrency, "concurrency", "", concurrency, "Number of operations to run at once", "")
+	flags.FVarP(cmdFlags, &smallSize, "small-size", "", "Size of the files in the small, metadata and mixed workloads", "")
+	flags.FVarP(cmdFlags, &bigSize, "big-size", "", "Size of the files in the big workload", "")
+	flags.IntVarP(cmdFlags, &bigFiles, "big-files", "", bigFiles, "Number of files in the big workload", "")
+	flags.StringVarP(cmdFlags, &format, "format", "", format, "Output format: text, json or csv", "")
+	flags.StringVarP(cmdFlags, &outputFile, "output", "o", outputFile, "Write the results to this file instead of stdout", "")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "benchmark remote:path",
+	Short: `Benchmark the performance of a remote.`,
+	Long: strings.ReplaceAll(`Run workloads against a remote and report the latency and throughput
+of each kind of operation, so providers can be compared and flags tuned
+by measurement rather than guesswork.
+
+The workloads are chosen with |--workload| which may be repeated.
+
+- |small| - upload, download and delete small files (small-file churn)
+- |big| - upload then download |--big-files| files of |--big-size| one at a time
+- |metadata| - stat, list, set modification times and make and remove directories
+- |mixed| - a random mix of the small and metadata operations
+
+The |small|, |metadata| and |mixed| workloads run for |--duration|
+with |--concurrency| operations at once.
+
+For each operation the number run, errors, operations per second,
+throughput and the 50th, 90th, 99th percentile and maximum latency are
+shown. Use |--format json| or |--format csv| for machine readable
+output and |--output| to write it to a file.
+
+    rclone benchmark remote:path --workload small --duration 30s --concurrency 16
+    rclone benchmark remote:path --format csv -o results.csv
+
+The results depend on the global flags, so try varying
+|--transfers|, |--checkers|, chunk sizes and backend flags between runs.
+
+**NB** This command will create and delete files on the remote in a
+randomly named directory under the path given, which will be removed
+on a clean exit.`, "|", "`"),
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.74",
+		"groups":            "Important",
+	},
+	RunE: func(command *cobra.Command, args []string) error {
+		cmd.CheckArgs(1, 1, command, args)
+		for _, w := range workloads {
+			if !slices.Contains(allWorkloads, w) {
+				return fmt.Errorf("unknown workload %q - must be one of %s", w, strings.Join(allWorkloads, ", "))
+			}
+		}
+		switch format {
+		case "text", "json", "csv":
+		default:
+			return fmt.Errorf("unknown --format %q - must be text, json or csv", format)
+		}
+		cmd.Run(false, false, command, func() (err error) {
+			results, err := run(context.Background(), args[0])
+			if err != nil {
+				return err
+			}
+			out := io.Writer(os.Stdout)
+			if outputFile != "" {
+				var fh *os.File
+				fh, err = os.Create(outputFile)
+				if err != nil {
+					return err
+				}
+				defer fs.CheckClose(fh, &err)
+				out = fh
+			}
+			return writeResults(out, format, results)
+		})
+		return nil
+	},
+}
+
+// Result is the summary of one kind of operation in one workload
+type Result struct {
+	Workload  string        `json:"workload"`
+	Op        string        `json:"op"`
+	Count     int           `json:"count"`
+	Errors    int           `json:"errors"`
+	Bytes     int64         `json:"bytes"`
+	Elapsed   time.Duration `json:"elapsed"`
+	OpsPerSec float64       `json:"opsPerSec"`
+	BytesPerS float64       `json:"bytesPerSec"`
+	Min       time.Duration `json:"min"`
+	Mean      time.Duration `json:"mean"`
+	P50       time.Duration `json:"p50"`
+	P90       time.Duration `json:"p90"`
+	P99       time.Duration `json:"p99"`
+	Max       time.Duration `json:"max"`
+}
+
+// recorder collects the latencies of the operations of a workload
+type recorder struct {
+	mu        sync.Mutex
+	workload  string
+	start     time.Time
+	ops       []string // in order first seen
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	bytes     map[string]int64
+}
+
+func newRecorder(workload string) *recorder {
+	return &recorder{
+		workload:  workload,
+		start:     time.Now(),
+		latencies: map[string][]time.Duration{},
+		errors:    map[string]int{},
+		bytes:     map[string]int64{},
+	}
+}
+
+// time runs fn recording how long it took as op
+func (r *recorder) time(op string, size int64, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	dt := time.Since(start)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.latencies[op]; !found {
+		r.ops = append(r.ops, op)
+		r.latencies[op] = nil
+	}
+	if err != nil {
+		r.errors[op]++
+		fs.Debugf(nil, "%s %s failed: %v", r.workload, op, err)
+		return err
+	}
+	r.latencies[op] = append(r.latencies[op], dt)
+	r.bytes[op] += size
+	return nil
+}
+
+// results summarises the recorded operations
+func (r *recorder) results() (results []*Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	elapsed := time.Since(r.start)
+	for _, op := range r.ops {
+		result := summarise(r.latencies[op], elapsed)
+		result.Workload = r.workload
+		result.Op = op
+		result.Errors = r.errors[op]
+		result.Bytes = r.bytes[op]
+		if elapsed > 0 {
+			result.BytesPerS = float64(result.Bytes) / elapsed.Seconds()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// summarise the latencies of operations which took elapsed in total
+func summarise(latencies []time.Duration, elapsed time.Duration) *Result {
+	result := &Result{
+		Count:   len(latencies),
+		Elapsed: elapsed,
+	}
+	if len(latencies) == 0 {
+		return result
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, dt := range sorted {
+		total += dt
+	}
+	result.Min = sorted[0]
+	result.Max = sorted[len(sorted)-1]
+	result.Mean = total / time.Duration(len(sorted))
+	result.P50 = percentile(sorted, 50)
+	result.P90 = percentile(sorted, 90)
+	result.P99 = percentile(sorted, 99)
+	if elapsed > 0 {
+		result.OpsPerSec = float64(len(sorted)) / elapsed.Seconds()
+	}
+	return result
+}
+
+// percentile returns the p-th percentile of sorted using the nearest
+// rank method
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.999999) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank]
+}
+
+// bench holds the state while running the workloads
+type bench struct {
+	f    fs.Fs
+	mu   sync.Mutex
+	n    int      // file name counter
+	live []string // small files which exist
+}
+
+// run the selected workloads on a temporary directory in remote
+func run(ctx context.Context, remote string) (results []*Result, err error) {
+	tempDirPath := path.Join(remote, "rclone-benchmark-"+random.String(8))
+	fbench := cmd.NewFsDir([]string{tempDirPath})
+	aErr := io.EOF
+	defer atexit.OnError(&aErr, func() {
+		err := operations.Purge(ctx, fbench, "")
+		if err != nil {
+			fs.Debugf(fbench, "Failed to remove temp dir %q: %v", tempDirPath, err)
+		}
+	})()
+	if err = fbench.Mkdir(ctx, ""); err != nil {
+		return nil, fmt.Errorf("failed to make benchmark directory: %w", err)
+	}
+	b := &bench{f: fbench}
+	for _, w := range workloads {
+		fs.Infof(fbench, "Running %s workload", w)
+		r := newRecorder(w)
+		switch w {
+		case workloadSmall:
+			b.runFor(ctx, b.smallOp(r))
+		case workloadBig:
+			b.big(ctx, r)
+		case workloadMetadata:
+			err = b.prepareMetadata(ctx)
+			if err != nil {
+				return nil, err
+			}
+			b.runFor(ctx, b.metadataOp(r))
+		case workloadMixed:
+			small, metadata := b.smallOp(r), b.metadataOp(r)
+			b.runFor(ctx, func(ctx context.Context, rnd *rand.Rand) {
+				if rnd.Intn(2) == 0 {
+					small(ctx, rnd)
+				} else {
+					metadata(ctx, rnd)
+				}
+			})
+		}
+		results = append(results, r.results()...)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	err = operations.Purge(ctx, fbench, "")
+	if err != nil {
+		fs.Errorf(fbench, "Failed to remove temp dir %q: %v", tempDirPath, err)
+	}
+	return results, nil
+}
+
+// runFor runs op in --concurrency goroutines for --duration
+func (b *bench) runFor(ctx context.Context, op func(ctx context.Context, rnd *rand.Rand)) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(duration))
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(time.Now().UnixNano() + int64(i)))
+			for ctx.Err() == nil {
+				op(ctx, rnd)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// newName returns a new unique file name
+func (b *bench) newName(prefix string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.n++
+	return fmt.Sprintf("%s-%06d.bin", prefix, b.n)
+}
+
+// put uploads a file of size as remote
+func (b *bench) put(ctx context.Context, remote string, size int64) (fs.Object, error) {
+	src := object.NewStaticObjectInfo(remote, time.Now(), size, true, nil, b.f)
+	return b.f.Put(ctx, readers.NewPatternReader(size), src)
+}
+
+// get downloads o discarding the data
+func get(ctx context.Context, o fs.Object) error {
+	in, err := o.Open(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, in)
+	closeErr := in.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// smallOp returns an op which uploads, downloads and deletes a small file
+func (b *bench) smallOp(r *recorder) func(ctx context.Context, rnd *rand.Rand) {
+	size := int64(smallSize)
+	return func(ctx context.Context, rnd *rand.Rand) {
+		var o fs.Object
+		err := r.time("put", size, func() (err error) {
+			o, err = b.put(ctx, b.newName("small"), size)
+			return err
+		})
+		if err != nil {
+			return
+		}
+		_ = r.time("get", size, func() error {
+			return get(ctx, o)
+		})
+		_ = r.time("delete", 0, func() error {
+			return o.Remove(ctx)
+		})
+	}
+}
+
+// big uploads then downloads --big-files files of --big-size
+func (b *bench) big(ctx context.Context, r *recorder) {
+	size := int64(bigSize)
+	var objs []fs.Object
+	for range bigFiles {
+		var o fs.Object
+		err := r.time("put", size, func() (err error) {
+			o, err = b.put(ctx, b.newName("big"), size)
+			return err
+		})
+		if err == nil {
+			objs = append(objs, o)
+		}
+	}
+	for _, o := range objs {
+		_ = r.time("get", size, func() error {
+			return get(ctx, o)
+		})
+	}
+	for _, o := range objs {
+		if err := o.Remove(ctx); err != nil {
+			fs.Debugf(o, "Failed to remove: %v", err)
+		}
+	}
+}
+
+// prepareMetadata makes the files used by the metadata workload
+func (b *bench) prepareMetadata(ctx context.Context) error {
+	if len(b.live) > 0 {
+		return nil
+	}
+	for range max(concurrency, 1) * 4 {
+		remote := path.Join("metadata", b.newName("meta"))
+		if _, err := b.put(ctx, remote, int64(smallSize)); err != nil {
+			return fmt.Errorf("failed to make files for metadata workload: %w", err)
+		}
+		b.live = append(b.live, remote)
+	}
+	return nil
+}
+
+// metadataOp returns an op which does a random metadata operation
+func (b *bench) metadataOp(r *recorder) func(ctx context.Context, rnd *rand.Rand) {
+	return func(ctx context.Context, rnd *rand.Rand) {
+		if len(b.live) == 0 {
+			if err := b.prepareMetadata(ctx); err != nil {
+				fs.Errorf(b.f, "%v", err)
+				time.Sleep(time.Second)
+				return
+			}
+		}
+		remote := b.live[rnd.Intn(len(b.live))]
+		switch rnd.Intn(4) {
+		case 0:
+			_ = r.time("stat", 0, func() error {
+				_, err := b.f.NewObject(ctx, remote)
+				return err
+			})
+		case 1:
+			_ = r.time("list", 0, func() error {
+				_, err := b.f.List(ctx, "metadata")
+				return err
+			})
+		case 2:
+			o, err := b.f.NewObject(ctx, remote)
+			if err != nil {
+				return
+			}
+			_ = r.time("setmodtime", 0, func() error {
+				err := o.SetModTime(ctx, time.Now())
+				if errors.Is(err, fs.ErrorCantSetModTime) || errors.Is(err, fs.ErrorCantSetModTimeWithoutDelete) {
+					return nil
+				}
+				return err
+			})
+		case 3:
+			dir := "dir-" + strconv.Itoa(rnd.Int())
+			err := r.time("mkdir", 0, func() error {
+				return b.f.Mkdir(ctx, dir)
+			})
+			if err != nil {
+				return
+			}
+			_ = r.time("rmdir", 0, func() error {
+				return b.f.Rmdir(ctx, dir)
+			})
+		}
+	}
+}
+
+// round a duration for display
+func round(d time.Duration) time.Duration {
+	switch {
+	case d > time.Second:
+		return d.Round(time.Millisecond)
+	case d > time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+// writeResults writes the results to out in format
+func writeResults(out io.Writer, format string, results []*Result) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case "csv":
+		w := csv.NewWriter(out)
+		_ = w.Write([]string{"workload", "op", "count", "errors", "bytes", "elapsed_s", "ops_per_s", "bytes_per_s", "min_ms", "mean_ms", "p50_ms", "p90_ms", "p99_ms", "max_ms"})
+		ms := func(d time.Duration) string {
+			return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+		}
+		for _, r := range results {
+			_ = w.Write([]string{
+				r.Workload, r.Op,
+				strconv.Itoa(r.Count), strconv.Itoa(r.Errors), strconv.FormatInt(r.Bytes, 10),
+				strconv.FormatFloat(r.Elapsed.Seconds(), 'f', 3, 64),
+				strconv.FormatFloat(r.OpsPerSec, 'f', 3, 64),
+				strconv.FormatFloat(r.BytesPerS, 'f', 0, 64),
+				ms(r.Min), ms(r.Mean), ms(r.P50), ms(r.P90), ms(r.P99), ms(r.Max),
+			})
+		}
+		w.Flush()
+		return w.Error()
+	}
+	_, _ = fmt.Fprintf(out, "%-9s %-10s %7s %6s %9s %11s %10s %10s %10s %10s\n",
+		"Workload", "Op", "Count", "Errors", "Ops/s", "Speed", "p50", "p90", "p99", "Max")
+	for _, r := range results {
+		_, _ = fmt.Fprintf(out, "%-9s %-10s %7d %6d %9.1f %11s %10v %10v %10v %10v\n",
+			r.Workload, r.Op, r.Count, r.Errors, r.OpsPerSec,
+			fs.SizeSuffix(int64(r.BytesPerS)).ByteUnit()+"/s",
+			round(r.P50), round(r.P90), round(r.P99), round(r.Max))
+	}
+	return nil
+}
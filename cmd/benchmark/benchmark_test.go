@@ -0,0 +1,102 @@
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, time.Duration(0), percentile(nil, 50))
+	assert.Equal(t, 50*time.Millisecond, percentile(sorted, 50))
+	assert.Equal(t, 90*time.Millisecond, percentile(sorted, 90))
+	assert.Equal(t, 99*time.Millisecond, percentile(sorted, 99))
+	assert.Equal(t, 100*time.Millisecond, percentile(sorted, 100))
+	assert.Equal(t, 1*time.Millisecond, percentile(sorted, 0))
+	assert.Equal(t, 3*time.Millisecond, percentile(sorted[:3], 99))
+}
+
+func TestSummarise(t *testing.T) {
+	r := summarise([]time.Duration{3 * time.Second, time.Second, 2 * time.Second}, 2*time.Second)
+	assert.Equal(t, 3, r.Count)
+	assert.Equal(t, time.Second, r.Min)
+	assert.Equal(t, 3*time.Second, r.Max)
+	assert.Equal(t, 2*time.Second, r.Mean)
+	assert.Equal(t, 2*time.Second, r.P50)
+	assert.Equal(t, 1.5, r.OpsPerSec)
+
+	r = summarise(nil, time.Second)
+	assert.Equal(t, 0, r.Count)
+	assert.Equal(t, time.Duration(0), r.Max)
+}
+
+func TestWriteResults(t *testing.T) {
+	results := []*Result{{
+		Workload: "small",
+		Op:       "put",
+		Count:    10,
+		Bytes:    1024,
+		Elapsed:  time.Second,
+		P50:      time.Millisecond,
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeResults(&buf, "csv", results))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "workload,op,count,errors,bytes,"))
+	assert.True(t, strings.HasPrefix(lines[1], "small,put,10,0,1024,1.000,"))
+
+	buf.Reset()
+	require.NoError(t, writeResults(&buf, "json", results))
+	var got []*Result
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, results, got)
+
+	buf.Reset()
+	require.NoError(t, writeResults(&buf, "text", results))
+	assert.Contains(t, buf.String(), "small")
+	assert.Contains(t, buf.String(), "put")
+}
+
+func TestRun(t *testing.T) {
+	oldDuration, oldConcurrency, oldBigSize, oldWorkloads := duration, concurrency, bigSize, workloads
+	defer func() {
+		duration, concurrency, bigSize, workloads = oldDuration, oldConcurrency, oldBigSize, oldWorkloads
+	}()
+	duration = fs.Duration(100 * time.Millisecond)
+	concurrency = 2
+	bigSize = 1024 * 1024
+	workloads = allWorkloads
+
+	dir := t.TempDir()
+	results, err := run(context.Background(), dir)
+	require.NoError(t, err)
+
+	ops := map[string]bool{}
+	for _, r := range results {
+		assert.Equal(t, 0, r.Errors, r.Workload+" "+r.Op)
+		ops[r.Workload+" "+r.Op] = true
+	}
+	for _, want := range []string{"small put", "small get", "small delete", "big put", "big get"} {
+		assert.True(t, ops[want], want)
+	}
+
+	// check the temporary directory was removed
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
@@ -1173,6 +1173,8 @@ func (b *bisyncTest) runBisync(ctx context.Context, args []string) (err error) {
 			opt.BackupDir2 = val
 		case "ignore-listing-checksum":
 			opt.IgnoreListingChecksum = true
+		case "check-integrity":
+			opt.CheckIntegrity = true
 		case "no-norm":
 			ci.NoUnicodeNormalization = true
 			ci.IgnoreCaseSync = false
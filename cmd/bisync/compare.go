@@ -55,6 +55,17 @@ func (b *bisyncRun) setCompareDefaults(ctx context.Context) (err error) {
 		return err
 	}
 
+	if b.opt.CheckIntegrity {
+		if b.opt.IgnoreListingChecksum {
+			return errors.New(Color(terminal.RedFg, "--check-integrity can't be used with --ignore-listing-checksum as it needs the checksums in the listings"))
+		}
+		if b.fs1.Precision() == fs.ModTimeNotSupported || b.fs2.Precision() == fs.ModTimeNotSupported {
+			return errors.New(Color(terminal.RedFg, "--check-integrity can't be used unless both remotes support modtimes"))
+		}
+		// keep checksums in the listings to compare against on the next run
+		b.opt.Compare.Checksum = true
+	}
+
 	if b.fs1.Features().SlowHash || b.fs2.Features().SlowHash {
 		b.opt.Compare.SlowHashDetected = true
 	}
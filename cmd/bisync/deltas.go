@@ -55,6 +55,7 @@ type deltaSet struct {
 	deleted    int    // number of deleted files (for "excess deletes" check)
 	foundSame  bool   // true if found at least one unchanged file
 	checkFiles bilib.Names
+	corrupt    map[string]string // good hashes of files which may be corrupt (--check-integrity)
 }
 
 func (ds *deltaSet) empty() bool {
@@ -176,6 +177,7 @@ func (b *bisyncRun) findDeltas(fctx context.Context, f fs.Fs, oldListing string,
 		oldCount:   len(old.list),
 		opt:        b.opt,
 		checkFiles: bilib.Names{},
+		corrupt:    map[string]string{},
 	}
 
 	for _, file := range old.list {
@@ -188,6 +190,11 @@ func (b *bisyncRun) findDeltas(fctx context.Context, f fs.Fs, oldListing string,
 			b.indent(msg, file, Color(terminal.RedFg, "File was deleted"))
 			ds.deleted++
 			d |= deltaDeleted
+		} else if b.mayBeCorrupt(fctx, f, file, old, now) {
+			b.indent(msg, file, Color(terminal.RedFg, "File content changed without a size or modtime change - possible corruption"))
+			fs.Debugf(file, "(old: %v current: %v)", old.getHash(file), now.getHash(file))
+			ds.corrupt[file] = old.getHash(file)
+			continue
 		} else if !now.isDir(file) {
 			// skip dirs here, as we only care if they are new/deleted, not newer/older
 			whatchanged := []string{}
@@ -0,0 +1,76 @@
+package bisync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/terminal"
+)
+
+// mayBeCorrupt returns true if --check-integrity is set and file's
+// checksum changed between the old and now listings while its size
+// and modtime stayed the same, which is what silent corruption
+// (bitrot) usually looks like.
+func (b *bisyncRun) mayBeCorrupt(fctx context.Context, f fs.Fs, file string, old, now *fileList) bool {
+	if !b.opt.CheckIntegrity || now.isDir(file) {
+		return false
+	}
+	if sizeDiffers(old.getSize(file), now.getSize(file)) || timeDiffers(fctx, old.getTime(file), now.getTime(file), f, f) {
+		return false
+	}
+	return b.hashDiffers(old.getHash(file), now.getHash(file), old.hash, now.hash, old.getSize(file), now.getSize(file))
+}
+
+// keepGoodHashes puts the checksums from the prior run back into the
+// listing for the files in ds.corrupt which weren't replaced or
+// deleted by the sync from the other side.
+//
+// This means they are flagged again on the next run until they are
+// repaired, rather than the bad checksum being taken as correct.
+// It returns the names of the files which are still corrupt.
+func (b *bisyncRun) keepGoodHashes(ds, other *deltaSet, listing string) (corrupt []string, err error) {
+	if len(ds.corrupt) == 0 {
+		return nil, nil
+	}
+	ls, err := b.loadListing(listing)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read listing: %w", err)
+	}
+	for file, goodHash := range ds.corrupt {
+		fi := ls.get(file)
+		if _, synced := other.deltas[file]; synced || fi == nil {
+			continue
+		}
+		fi.hash = goodHash
+		corrupt = append(corrupt, file)
+	}
+	sort.Strings(corrupt)
+	if len(corrupt) == 0 {
+		return nil, nil
+	}
+	return corrupt, ls.save(listing)
+}
+
+// checkIntegrity keeps the good checksums of the files which may be
+// corrupt in the listings and reports them.
+func (b *bisyncRun) checkIntegrity(ds1, ds2 *deltaSet) (nCorrupt int, err error) {
+	for _, x := range []struct {
+		ds, other *deltaSet
+		listing   string
+	}{
+		{ds1, ds2, b.listing1},
+		{ds2, ds1, b.listing2},
+	} {
+		corrupt, err := b.keepGoodHashes(x.ds, x.other, x.listing)
+		if err != nil {
+			return 0, err
+		}
+		for _, file := range corrupt {
+			b.indent("!"+x.ds.msg, file, Color(terminal.RedFg, "File may be corrupt - not synced"))
+		}
+		nCorrupt += len(corrupt)
+	}
+	return nCorrupt, nil
+}
@@ -425,6 +425,17 @@ func (b *bisyncRun) runLocked(octx context.Context) (err error) {
 		return err
 	}
 
+	// Keep the good checksums of any possibly corrupt files
+	nCorrupt := 0
+	if opt.CheckIntegrity {
+		nCorrupt, err = b.checkIntegrity(ds1, ds2)
+		if err != nil {
+			b.critical = true
+			b.retryable = true
+			return err
+		}
+	}
+
 	if !opt.NoCleanup {
 		_ = os.Remove(b.newListing1)
 		_ = os.Remove(b.newListing2)
@@ -456,6 +467,9 @@ func (b *bisyncRun) runLocked(octx context.Context) (err error) {
 		}
 	}
 
+	if nCorrupt > 0 {
+		return fmt.Errorf("%d files may be corrupt - their checksums changed without a size or modtime change", nCorrupt)
+	}
 	return nil
 }
 
@@ -61,6 +61,9 @@ func rcBisync(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	if opt.IgnoreListingChecksum, err = in.GetBool("ignoreListingChecksum"); rc.NotErrParamNotFound(err) {
 		return
 	}
+	if opt.CheckIntegrity, err = in.GetBool("checkIntegrity"); rc.NotErrParamNotFound(err) {
+		return
+	}
 	if opt.Resilient, err = in.GetBool("resilient"); rc.NotErrParamNotFound(err) {
 		return
 	}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/transformlib"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/operations"
@@ -16,12 +17,13 @@ import (
 
 // Globals
 var (
-	head      = int64(0)
-	tail      = int64(0)
-	offset    = int64(0)
-	count     = int64(-1)
-	discard   = false
-	separator = string("")
+	head       = int64(0)
+	tail       = int64(0)
+	offset     = int64(0)
+	count      = int64(-1)
+	discard    = false
+	separator  = string("")
+	transforms []string
 )
 
 func init() {
@@ -33,6 +35,7 @@ func init() {
 	flags.Int64VarP(cmdFlags, &count, "count", "", count, "Only print N characters", "")
 	flags.BoolVarP(cmdFlags, &discard, "discard", "", discard, "Discard the output instead of printing", "")
 	flags.StringVarP(cmdFlags, &separator, "separator", "", separator, "Separator to use between objects when printing multiple files", "")
+	flags.StringArrayVarP(cmdFlags, &transforms, "transform", "", transforms, "Undo transforms applied with rclone rcat --transform, e.g. gzip, zstd, crypt=remote:, hash=md5 (may be repeated)", "")
 }
 
 var commandDefinition = &cobra.Command{
@@ -78,7 +81,25 @@ files, use:
 
   |||powershell
   rclone --include "*.txt" --separator "|n" cat remote:path/to/dir
-  |||`, "|", "`"),
+  |||
+
+Use the |--transform| flag to undo the transforms applied to a file
+uploaded with |rclone rcat --transform|. Give the same |--transform|
+flags in the same order and they will be undone in reverse order, so
+to read back a file uploaded with
+|rclone rcat --transform zstd --transform crypt=secret:| use
+
+|||sh
+rclone cat --transform zstd --transform crypt=secret: remote:backups/db.sql.zst.bin
+|||
+
+Any |hash| steps log the hash of the data at that point which can be
+compared with the checksum file written by |rclone rcat|.
+
+|--transform| can't be used with |--head|, |--tail|, |--offset|,
+|--count| or |--separator| and should be used to output a single file.
+
+`+transformlib.Help, "|", "`"),
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.33",
 		"groups":            "Filter,Listing",
@@ -98,6 +119,13 @@ files, use:
 			offset = -tail
 			count = -1
 		}
+		pipeline, err := transformlib.Parse(transforms)
+		if err != nil {
+			fs.Fatalf(nil, "%v", err)
+		}
+		if !pipeline.IsEmpty() && (usedHead || usedTail || usedOffset || separator != "") {
+			fs.Fatalf(nil, "Can't use --transform with --head, --tail, --offset, --count or --separator")
+		}
 		cmd.CheckArgs(1, 1, command, args)
 		fsrc := cmd.NewFsSrc(args)
 		var w io.Writer = os.Stdout
@@ -105,7 +133,37 @@ files, use:
 			w = io.Discard
 		}
 		cmd.Run(false, false, command, func() error {
+			if !pipeline.IsEmpty() {
+				return catTransform(context.Background(), pipeline, fsrc, w)
+			}
 			return operations.Cat(context.Background(), fsrc, w, offset, count, []byte(separator))
 		})
 	},
 }
+
+// catTransform outputs the files in fsrc to w undoing the transforms
+func catTransform(ctx context.Context, pipeline *transformlib.Pipeline, fsrc fs.Fs, w io.Writer) (err error) {
+	pr, pw := io.Pipe()
+	defer func() {
+		_ = pr.Close()
+	}()
+	go func() {
+		_ = pw.CloseWithError(operations.Cat(ctx, fsrc, pw, 0, -1, nil))
+	}()
+	in, err := pipeline.Decode(pr)
+	if err != nil {
+		return err
+	}
+	defer fs.CheckClose(in, &err)
+	if _, err = io.Copy(w, in); err != nil {
+		return err
+	}
+	sums, err := pipeline.Sums()
+	if err != nil {
+		return err
+	}
+	for _, sum := range sums {
+		fs.Logf(nil, "%v: %s", sum.Type, sum.Value)
+	}
+	return nil
+}
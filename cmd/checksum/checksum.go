@@ -14,12 +14,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var download = false
+var (
+	download = false
+	sidecar  = false
+)
 
 func init() {
 	cmd.Root.AddCommand(commandDefinition)
 	cmdFlags := commandDefinition.Flags()
 	flags.BoolVarP(cmdFlags, &download, "download", "", download, "Check by hashing the contents", "")
+	flags.BoolVarP(cmdFlags, &sidecar, "sidecar", "", sidecar, "Check files against their checksum sidecar files instead of a SUM file", "")
 	check.AddFlags(cmdFlags)
 }
 
@@ -38,18 +42,45 @@ and calculate the content hash on the fly.  This can be useful for remotes
 that don't support hashes or if you really want to check all the data.
 
 Note that hash values in the SUM file are treated as case insensitive.
+
+If you supply the |--sidecar| flag then no SUM file is needed and each
+file is checked against its checksum sidecar, the file with the name of
+the hash added, e.g. |file.txt.sha256|, as written by
+|--checksum-sidecar|. Files without a sidecar are reported as missing
+from the source.
+
+|||console
+rclone checksum --sidecar --download sha256 remote:path
+|||
 `, "|", "`") + check.FlagsHelp,
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.56",
 		"groups":            "Filter,Listing",
 	},
 	RunE: func(command *cobra.Command, args []string) error {
-		cmd.CheckArgs(3, 3, command, args)
+		if sidecar {
+			cmd.CheckArgs(2, 2, command, args)
+		} else {
+			cmd.CheckArgs(3, 3, command, args)
+		}
 		var hashType hash.Type
 		if err := hashType.Set(args[0]); err != nil {
 			fmt.Println(hash.HelpString(0))
 			return err
 		}
+		if sidecar {
+			fdst := cmd.NewFsDir(args[1:])
+			cmd.Run(false, true, command, func() error {
+				opt, close, err := check.GetCheckOpt(nil, fdst)
+				if err != nil {
+					return err
+				}
+				defer close()
+
+				return operations.CheckSidecars(context.Background(), fdst, hashType, opt, download)
+			})
+			return nil
+		}
 		fsum, sumFile, fsrc := cmd.NewFsSrcFileDst(args[1:])
 
 		cmd.Run(false, true, command, func() error {
@@ -17,7 +17,10 @@ var commandDefinition = &cobra.Command{
 	Use:   "cleanup remote:path",
 	Short: `Clean up the remote if possible.`,
 	Long: `Clean up the remote if possible.  Empty the trash or delete old file
-versions. Not supported by all remotes.`,
+versions. Not supported by all remotes.
+
+With ` + "`--use-trash`" + ` this also purges the files in the ` + "`.rclone-trash`" + `
+directory deleted longer ago than ` + "`--trash-retention`" + `.`,
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.31",
 		"groups":            "Important",
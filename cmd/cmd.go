@@ -29,7 +29,9 @@ import (
 	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/fs/history"
 	fslog "github.com/rclone/rclone/fs/log"
+	"github.com/rclone/rclone/fs/notify"
 	"github.com/rclone/rclone/fs/rc"
 	"github.com/rclone/rclone/fs/rc/rcserver"
 	fssync "github.com/rclone/rclone/fs/sync"
@@ -52,6 +54,13 @@ var (
 	errorCommandNotFound    = errors.New("command not found")
 	errorNotEnoughArguments = errors.New("not enough arguments")
 	errorTooManyArguments   = errors.New("too many arguments")
+	// commands which send notifications when they finish
+	notifyCommands = map[string]bool{
+		"sync":   true,
+		"copy":   true,
+		"move":   true,
+		"bisync": true,
+	}
 )
 
 // ShowVersion prints the version to stdout
@@ -240,6 +249,7 @@ func ShowStats() bool {
 func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
 	ctx := context.Background()
 	ci := fs.GetConfig(ctx)
+	start := time.Now()
 	var cmdErr error
 	stopStats := func() {}
 	if !showStats && ShowStats() {
@@ -251,6 +261,7 @@ func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
 		stopStats = StartStats()
 	}
 	SigInfoHandler()
+	history.Start(ctx, cmd.Name(), cmd.Flags().Args())
 	for try := 1; try <= ci.Retries; try++ {
 		cmdErr = f()
 		cmdErr = fs.CountError(ctx, cmdErr)
@@ -295,6 +306,15 @@ func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
 	if showStats && (accounting.GlobalStats().Errored() || *statsInterval > 0) {
 		accounting.GlobalStats().Log()
 	}
+	if args := cmd.Flags().Args(); notifyCommands[cmd.Name()] && len(args) > 0 {
+		notify.JobDone(ctx, notify.Job{
+			Name:  cmd.Name(),
+			Src:   args[0],
+			Dst:   args[len(args)-1],
+			Start: start,
+		}, cmdErr)
+	}
+	history.Finish(ctx, cmdErr)
 	fs.Debugf(nil, "%d go routines active\n", runtime.NumGoroutine())
 
 	if ci.Progress && ci.ProgressTerminalTitle {
@@ -401,6 +421,9 @@ func initConfig() {
 	// Load the config
 	configfile.Install()
 
+	// Activate the --profile if set
+	configflags.SetProfile()
+
 	// Start accounting
 	accounting.Start(ctx)
 
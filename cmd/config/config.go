@@ -14,6 +14,7 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config"
 	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/rc"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -38,6 +39,7 @@ func init() {
 	configCommand.AddCommand(configUserInfoCommand)
 	configCommand.AddCommand(configEncryptionCommand)
 	configCommand.AddCommand(configStringCommand)
+	configCommand.AddCommand(configFeaturesCommand)
 }
 
 var configCommand = &cobra.Command{
@@ -106,19 +108,39 @@ var configPathsCommand = &cobra.Command{
 	},
 }
 
+var showResolved bool
+
+func init() {
+	flags.BoolVarP(configShowCommand.Flags(), &showResolved, "resolved", "", false, "Show the effective config with inherited values merged in", "")
+}
+
 var configShowCommand = &cobra.Command{
 	Use:   "show [<remote>]",
 	Short: `Print (decrypted) config file, or the config for a single remote.`,
+	Long: strings.ReplaceAll(`This prints the config file, either the whole config file or for a
+given remote.
+
+Remotes can inherit values from another section of the config file with
+the |inherit| key. Use |--resolved| to show the effective config of each
+remote with the inherited values merged in.`, "|", "`"),
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.38",
 	},
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(0, 1, command, args)
 		if len(args) == 0 {
-			config.ShowConfig()
+			if showResolved {
+				config.ShowResolvedConfig()
+			} else {
+				config.ShowConfig()
+			}
 		} else {
 			name := strings.TrimRight(args[0], ":")
-			config.ShowRemote(name)
+			if showResolved {
+				config.ShowResolvedRemote(name)
+			} else {
+				config.ShowRemote(name)
+			}
 		}
 	},
 }
@@ -263,6 +285,15 @@ If |--all| is passed then rclone will ask all the config questions,
 not just the post config questions. Any parameters are used as
 defaults for questions as usual.
 
+If |--headless| is passed then OAuth is done without a local web
+browser. Instead rclone asks the |config_redirect_url| question with
+the URL to authorize rclone at in |URL| and a QR code of it, as a PNG
+|data:| URL, in |QRCode| for a web page to show. Continue with an
+empty result to wait for the browser to return to rclone, which needs
+the same rclone process so is best done with the |config/create| and
+|config/update| calls of |rclone rcd|, or with the address the browser
+finished on.
+
 Note that |bin/config.py| in the rclone source implements this protocol
 as a readable demonstration.`, "|", "`")
 var configCreateCommand = &cobra.Command{
@@ -336,6 +367,7 @@ func init() {
 		flags.BoolVarP(cmdFlags, &updateRemoteOpt.All, "all", "", false, "Ask the full set of config questions", "Config")
 		flags.StringVarP(cmdFlags, &updateRemoteOpt.State, "state", "", "", "State - use with --continue", "Config")
 		flags.StringVarP(cmdFlags, &updateRemoteOpt.Result, "result", "", "", "Result - use with --continue", "Config")
+		flags.BoolVarP(cmdFlags, &updateRemoteOpt.Headless, "headless", "", false, "Authorize OAuth remotes with a URL and QR code rather than a local browser", "Config")
 	}
 }
 
@@ -666,3 +698,61 @@ require further quoting which is very shell dependent.
 		return nil
 	},
 }
+
+var configFeaturesCommand = &cobra.Command{
+	Use:   "features [remote:]...",
+	Short: `Print the features of remotes in JSON format.`,
+	Long: strings.ReplaceAll(`Print the features of the configured remotes in JSON format.
+
+This creates each remote named, or all the configured remotes if none
+are named, and prints its features so that programs can decide what
+to do, e.g. whether server-side copy or move, about, change notify,
+metadata or a particular hash type is available.
+
+This is the same as the [operations/features](/rc/#operations-features)
+rc call. The output for each remote is the same as |rclone backend
+features remote:| prints with the remote's name and type added. A
+remote which can't be created has |Error| set instead of |Info|.
+
+Example:
+
+|||sh
+$ rclone config features s3: local:
+[
+	{
+		"Name": "local",
+		"Type": "local",
+		"Info": {
+			"Name": "local",
+			"Root": "/home/user",
+			"Features": {
+				"About": true,
+				"Copy": false,
+				...
+			},
+			"Hashes": [
+				"md5",
+				...
+			],
+			...
+		}
+	},
+	{
+		"Name": "s3",
+		"Type": "s3",
+		"Error": "..."
+	}
+]
+|||
+`, "|", "`"),
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+	},
+	RunE: func(command *cobra.Command, args []string) error {
+		cmd.CheckArgs(0, 1e6, command, args)
+		features := operations.GetRemotesFeatures(context.Background(), args)
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "\t")
+		return out.Encode(features)
+	},
+}
@@ -108,9 +108,20 @@ To minimize risks, users should:
   that it won't show the effect of non-deterministic transformations).
 - Avoid transformations that cause multiple distinct source files to map to the
   same destination name.
+- Use ¡truncate_hash=N¡ rather than ¡truncate=N¡ to shorten long names, as
+  this ends the name with a hash of the original name so names which only
+  differ after N characters don't collide.
 - Consider disabling concurrency with ¡--transfers=1¡ if necessary.
 - Certain transformations (e.g. ¡prefix¡) will have a multiplying effect every
-  time they are used. Avoid these when using ¡bisync¡.`, "¡", "`"),
+  time they are used. Avoid these when using ¡bisync¡.
+
+### Reversing Transformations
+
+If ¡--metadata¡ is in use when ¡sync¡, ¡copy¡ or ¡move¡ rename a file with
+¡--name-transform¡ then its original leaf name is stored in the
+¡original-name¡ metadata key on backends which support arbitrary metadata.
+This can be used to restore the names of files transformed with lossy
+transformations such as ¡truncate_hash¡.`, "¡", "`"),
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.70",
 		"groups":            "Filter,Listing,Important,Copy",
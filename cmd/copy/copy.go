@@ -3,9 +3,11 @@ package copy
 
 import (
 	"context"
+	"errors"
 	"strings"
 
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/operations/operationsflags"
@@ -28,7 +30,7 @@ func init() {
 }
 
 var commandDefinition = &cobra.Command{
-	Use:   "copy source:path dest:path",
+	Use:   "copy source:path dest:path [dest:path...]",
 	Short: `Copy files from source to dest, skipping identical files.`,
 	// Note: "|" will be replaced by backticks below
 	Long: strings.ReplaceAll(`Copy the source to the destination.  Does not transfer files that are
@@ -101,6 +103,26 @@ Note that the modification time and metadata for the root directory
 will **not** be synced. See [issue #7652](https://github.com/rclone/rclone/issues/7652)
 for more info.
 
+### Copying to multiple destinations
+
+More than one destination can be given to copy the source to all of
+them in one pass, for example to replicate data to two clouds:
+
+|||sh
+rclone copy source:path dest1:path dest2:path
+|||
+
+Each file which needs copying to more than one destination is read
+from the source once and the data is sent to all of those destinations
+at the same time, so the transfer goes at the speed of the slowest
+destination. If a copy to one destination fails, the others carry on
+and only the retry reads the file again.
+
+Files which can be copied server-side to a destination are, and
+multi-thread copies aren't used for files sent to more than one
+destination. The logger flags such as |--combined| can't be used with
+multiple destinations.
+
 **Note**: Use the |-P|/|--progress| flag to view real-time transfer statistics.
 
 **Note**: Use the |--dry-run| or the |--interactive|/|-i| flag to test without
@@ -111,7 +133,11 @@ copying anything.
 		"groups": "Copy,Filter,Listing,Important",
 	},
 	Run: func(command *cobra.Command, args []string) {
-		cmd.CheckArgs(2, 2, command, args)
+		cmd.CheckArgs(2, 1e6, command, args)
+		if len(args) > 2 {
+			copyMulti(command, args)
+			return
+		}
 		fsrc, srcFileName, fdst := cmd.NewFsSrcFileDst(args)
 		cmd.Run(true, true, command, func() error {
 			ctx := context.Background()
@@ -132,3 +158,19 @@ copying anything.
 		})
 	},
 }
+
+// copyMulti copies the source in args[0] to all the destinations in
+// args[1:]
+func copyMulti(command *cobra.Command, args []string) {
+	fsrc := cmd.NewFsSrc(args)
+	var fdsts []fs.Fs
+	for _, arg := range args[1:] {
+		fdsts = append(fdsts, cmd.NewFsDir([]string{arg}))
+	}
+	cmd.Run(true, true, command, func() error {
+		if loggerFlagsOpt.AnySet() {
+			return errors.New("can't use the logger flags with multiple destinations")
+		}
+		return sync.CopyDirMulti(context.Background(), fdsts, fsrc, createEmptySrcDirs)
+	})
+}
@@ -8,10 +8,12 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	"github.com/rclone/rclone/cmd"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/lib/errcount"
 	"github.com/spf13/cobra"
@@ -25,6 +27,7 @@ var (
 	stdout         = false
 	noClobber      = false
 	urls           = false
+	failedURLs     = ""
 )
 
 func init() {
@@ -36,6 +39,7 @@ func init() {
 	flags.BoolVarP(cmdFlags, &noClobber, "no-clobber", "", noClobber, "Prevent overwriting file with same name", "")
 	flags.BoolVarP(cmdFlags, &stdout, "stdout", "", stdout, "Write the output to stdout rather than a file", "")
 	flags.BoolVarP(cmdFlags, &urls, "urls", "", stdout, "Use a CSV file of links to process multiple URLs", "")
+	flags.StringVarP(cmdFlags, &failedURLs, "failed-urls", "", failedURLs, "With --urls write the entries which failed to this file", "")
 }
 
 var commandDefinition = &cobra.Command{
@@ -69,6 +73,29 @@ Missing filenames will be autogenerated equivalent to using |--auto-filename|.
 Note that |--stdout| and |--print-filename| are incompatible with |--urls|.
 This will do |--transfers| copies in parallel. Note that if |--auto-filename|
 is desired for all URLs then a file with only URLs and no filename can be used.
+Lines starting with |#| are ignored.
+
+Each line may also have the expected size in bytes and the expected
+hash as TYPE:VALUE, in the format URL,FILENAME,SIZE,HASH, e.g.:
+|||
+https://example.com/v1.0/tool.zip,v1.0/tool.zip,1048576,sha256:9f86d0...
+https://example.com/v1.0/tool.tar.gz,v1.0/tool.tar.gz,,md5:d41d8cd9...
+|||
+The data is checked against these as it is downloaded and if it
+doesn't match the file is removed from the destination and the URL
+counts as failed. If the destination file already exists and matches
+the expected size and hash (where the destination supports that hash)
+then it will be skipped. This means that running the same list again
+only downloads the files which are missing or failed.
+
+The list may instead be JSON, either an array of objects or one object
+per line, with the keys |url|, |path|, |size| and |hash|, e.g.:
+|||json
+[{"url": "https://example.com/a.zip", "path": "a.zip", "size": 1024, "hash": "sha1:..."}]
+|||
+Setting |--failed-urls| writes the entries which failed to the file
+given in CSV format so they can be retried with |--urls| later. A
+summary of the URLs copied, skipped and failed is logged at the end.
 
 ### Troubleshooting
 
@@ -96,9 +123,70 @@ If you can't get |rclone copyurl| to work then here are some things you can try:
 	},
 }
 
-var copyURL = operations.CopyURL // for testing
+var (
+	copyURL       = operations.CopyURL       // for testing
+	copyURLVerify = operations.CopyURLVerify // for testing
+)
+
+// alreadyCopied returns true if the destination of e exists and
+// matches its expected size and hash.
+func alreadyCopied(ctx context.Context, dstFs fs.Fs, e *manifestEntry) bool {
+	if e.Path == "" || !e.verify() {
+		return false
+	}
+	o, err := dstFs.NewObject(ctx, e.Path)
+	if err != nil {
+		return false
+	}
+	if e.Size >= 0 && o.Size() != e.Size {
+		return false
+	}
+	if e.HashType != hash.None {
+		if !dstFs.Hashes().Contains(e.HashType) {
+			// Can't check the hash so only trust the size
+			return e.Size >= 0
+		}
+		sum, err := o.Hash(ctx, e.HashType)
+		if err != nil || sum == "" || !strings.EqualFold(sum, e.Hash) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyEntry copies a single entry from the --urls file
+func copyEntry(ctx context.Context, dstFs fs.Fs, e *manifestEntry) (err error) {
+	if !e.verify() {
+		_, err = copyURL(ctx, dstFs, e.Path, e.URL, e.Path == "", headerFilename, noClobber)
+		return err
+	}
+	expect := operations.URLExpect{
+		Size:     e.Size,
+		HashType: e.HashType,
+		Hash:     e.Hash,
+	}
+	_, err = copyURLVerify(ctx, dstFs, e.Path, e.URL, e.Path == "", headerFilename, noClobber, expect)
+	return err
+}
+
+// writeFailedURLs writes the failed entries to the --failed-urls file
+func writeFailedURLs(failed []manifestEntry) (err error) {
+	f, err := os.Create(failedURLs)
+	if err != nil {
+		return fmt.Errorf("failed to create --failed-urls file: %w", err)
+	}
+	defer fs.CheckClose(f, &err)
+	w := csv.NewWriter(f)
+	for i := range failed {
+		if err = w.Write(failed[i].record()); err != nil {
+			return fmt.Errorf("failed to write --failed-urls file: %w", err)
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
 
-// runURLS processes a .csv file of urls and filenames
+// runURLS processes a .csv or JSON file of urls and filenames
 func runURLS(args []string) (err error) {
 	if stdout {
 		return errors.New("can't use --stdout with --urls")
@@ -113,11 +201,9 @@ func runURLS(args []string) (err error) {
 		return fmt.Errorf("failed to open .csv file: %w", err)
 	}
 	defer fs.CheckClose(f, &err)
-	reader := csv.NewReader(f)
-	reader.FieldsPerRecord = -1
-	urlList, err := reader.ReadAll()
+	entries, err := parseManifest(f)
 	if err != nil {
-		return fmt.Errorf("failed reading .csv file: %w", err)
+		return err
 	}
 
 	ec := errcount.New()
@@ -125,25 +211,41 @@ func runURLS(args []string) (err error) {
 	ci := fs.GetConfig(gCtx)
 	g.SetLimit(ci.Transfers)
 
-	for _, urlEntry := range urlList {
-		if len(urlEntry) == 0 {
-			continue
-		}
+	var (
+		copied   atomic.Int64
+		skipped  atomic.Int64
+		isFailed = make([]bool, len(entries))
+	)
+	for i := range entries {
+		e := &entries[i]
 		g.Go(func() error {
-			url := urlEntry[0]
-			var filename string
-			if len(urlEntry) > 1 {
-				filename = urlEntry[1]
+			if alreadyCopied(gCtx, dstFs, e) {
+				fs.Debugf(e.Path, "Skipping URL %q as destination matches", e.URL)
+				skipped.Add(1)
+				return nil
 			}
-			_, err := copyURL(gCtx, dstFs, filename, url, filename == "", headerFilename, noClobber)
+			err := copyEntry(gCtx, dstFs, e)
 			if err != nil {
-				fs.Errorf(filename, "failed to copy URL %q: %v", url, err)
+				fs.Errorf(e.Path, "failed to copy URL %q: %v", e.URL, err)
 				ec.Add(err)
+				isFailed[i] = true
+				return nil
 			}
+			copied.Add(1)
 			return nil
 		})
 	}
 	ec.Add(g.Wait())
+	var failed []manifestEntry
+	for i := range entries {
+		if isFailed[i] {
+			failed = append(failed, entries[i])
+		}
+	}
+	fs.Logf(dstFs, "copyurl: %d URLs copied, %d skipped, %d failed", copied.Load(), skipped.Load(), len(failed))
+	if failedURLs != "" {
+		ec.Add(writeFailedURLs(failed))
+	}
 	return ec.Err("not all URLs copied successfully")
 }
 
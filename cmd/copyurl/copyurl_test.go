@@ -6,11 +6,13 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"strings"
 	"sync/atomic"
 	"testing"
 
 	_ "github.com/rclone/rclone/backend/local"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,7 +25,9 @@ func resetGlobals() {
 	stdout = false
 	noClobber = false
 	urls = false
+	failedURLs = ""
 	copyURL = operations.CopyURL
+	copyURLVerify = operations.CopyURLVerify
 }
 
 func TestRun_RequiresTwoArgsWhenNotStdout(t *testing.T) {
@@ -155,3 +159,119 @@ func TestRunURLS_ProcessesCSV_ParallelCalls_AndAggregatesError(t *testing.T) {
 		seen,
 	)
 }
+
+func TestParseManifest(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		in   string
+		want []manifestEntry
+		err  string
+	}{{
+		name: "csv",
+		in: "# comment\n" +
+			"https://example.com/a\n" +
+			"https://example.com/b,b.txt\n" +
+			"https://example.com/c,c.txt,3,MD5:900150983cd24fb0d6963f7d28e17f72\n" +
+			"https://example.com/d,,,sha1:a9993e364706816aba3e25717850c26c9cd0d89d\n",
+		want: []manifestEntry{
+			{URL: "https://example.com/a", Size: -1},
+			{URL: "https://example.com/b", Path: "b.txt", Size: -1},
+			{URL: "https://example.com/c", Path: "c.txt", Size: 3, HashType: hash.MD5, Hash: "900150983cd24fb0d6963f7d28e17f72"},
+			{URL: "https://example.com/d", Size: -1, HashType: hash.SHA1, Hash: "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		},
+	}, {
+		name: "json array",
+		in:   ` [{"url": "https://example.com/a", "path": "a.txt", "size": 0, "hash": "md5:d41d8cd98f00b204e9800998ecf8427e"}, {"url": "https://example.com/b"}]`,
+		want: []manifestEntry{
+			{URL: "https://example.com/a", Path: "a.txt", Size: 0, HashType: hash.MD5, Hash: "d41d8cd98f00b204e9800998ecf8427e"},
+			{URL: "https://example.com/b", Size: -1},
+		},
+	}, {
+		name: "json lines",
+		in:   "{\"url\": \"https://example.com/a\", \"size\": 10}\n{\"url\": \"https://example.com/b\", \"path\": \"b\"}\n",
+		want: []manifestEntry{
+			{URL: "https://example.com/a", Size: 10},
+			{URL: "https://example.com/b", Path: "b", Size: -1},
+		},
+	}, {
+		name: "bad size",
+		in:   "https://example.com/a,a,big\n",
+		err:  `line 1: bad size "big"`,
+	}, {
+		name: "bad hash",
+		in:   "https://example.com/a,a,,deadbeef\n",
+		err:  "line 1: hash",
+	}, {
+		name: "unknown hash",
+		in:   "https://example.com/a,a,,potato:deadbeef\n",
+		err:  "line 1:",
+	}, {
+		name: "too many fields",
+		in:   "https://example.com/a,a,1,md5:00,extra\n",
+		err:  "line 1: too many fields",
+	}, {
+		name: "missing url",
+		in:   `[{"path": "a"}]`,
+		err:  "entry 1: missing URL",
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseManifest(strings.NewReader(test.in))
+			if test.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestRunURLS_VerifySkipAndFailedURLs(t *testing.T) {
+	t.Cleanup(resetGlobals)
+	resetGlobals()
+
+	tmp := t.TempDir()
+	dest := t.TempDir()
+	// present.txt already exists with the right contents so is skipped
+	require.NoError(t, os.WriteFile(filepath.Join(dest, "present.txt"), []byte("abc"), 0o600))
+
+	csvPath := filepath.Join(tmp, "urls.csv")
+	csvContent := []byte(
+		"https://example.com/present,present.txt,3,md5:900150983cd24fb0d6963f7d28e17f72\n" + // skipped
+			"https://example.com/wrong,present.txt,4\n" + // size doesn't match so copied
+			"https://example.com/bad,bad.txt,,sha1:a9993e364706816aba3e25717850c26c9cd0d89d\n" + // error
+			"https://example.com/plain,plain.txt\n") // no checks
+	require.NoError(t, os.WriteFile(csvPath, csvContent, 0o600))
+	failedURLs = filepath.Join(tmp, "failed.csv")
+
+	var plainCalls, verifyCalls int32
+	copyURL = func(_ctx context.Context, _dst fs.Fs, dstFileName, url string, auto, header, noclobber bool) (fs.Object, error) {
+		atomic.AddInt32(&plainCalls, 1)
+		assert.Equal(t, "https://example.com/plain", url)
+		return nil, nil
+	}
+	copyURLVerify = func(_ctx context.Context, _dst fs.Fs, dstFileName, url string, auto, header, noclobber bool, expect operations.URLExpect) (fs.Object, error) {
+		atomic.AddInt32(&verifyCalls, 1)
+		switch url {
+		case "https://example.com/wrong":
+			assert.Equal(t, operations.URLExpect{Size: 4}, expect)
+			return nil, nil
+		case "https://example.com/bad":
+			assert.Equal(t, operations.URLExpect{Size: -1, HashType: hash.SHA1, Hash: "a9993e364706816aba3e25717850c26c9cd0d89d"}, expect)
+			return nil, errors.New("hash mismatch")
+		}
+		t.Errorf("unexpected URL %q", url)
+		return nil, nil
+	}
+
+	err := runURLS([]string{csvPath, dest})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not all URLs copied successfully")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&plainCalls))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&verifyCalls))
+
+	failed, err := os.ReadFile(failedURLs)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/bad,bad.txt,,sha1:a9993e364706816aba3e25717850c26c9cd0d89d\n", string(failed))
+}
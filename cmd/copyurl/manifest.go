@@ -0,0 +1,165 @@
+package copyurl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// manifestEntry is one URL to copy read from the --urls file
+type manifestEntry struct {
+	URL      string    // URL to copy
+	Path     string    // destination path, "" to use the name from the URL
+	Size     int64     // expected size or -1 if not known
+	HashType hash.Type // type of Hash or hash.None
+	Hash     string    // expected hash
+}
+
+// verify returns true if the entry has anything to check the download
+// against
+func (e *manifestEntry) verify() bool {
+	return e.Size >= 0 || e.HashType != hash.None
+}
+
+// record returns the entry as a CSV record suitable for reading with
+// --urls again
+func (e *manifestEntry) record() []string {
+	record := []string{e.URL, e.Path, "", ""}
+	if e.Size >= 0 {
+		record[2] = strconv.FormatInt(e.Size, 10)
+	}
+	if e.HashType != hash.None {
+		record[3] = e.HashType.String() + ":" + e.Hash
+	}
+	return record
+}
+
+// parseHash parses a hash in the form TYPE:VALUE, e.g. "sha256:abc..."
+func parseHash(s string) (ht hash.Type, value string, err error) {
+	if s == "" {
+		return hash.None, "", nil
+	}
+	name, value, found := strings.Cut(s, ":")
+	if !found || value == "" {
+		return hash.None, "", fmt.Errorf("hash %q must be in the form TYPE:VALUE, e.g. sha256:0123...", s)
+	}
+	if err = ht.Set(name); err != nil {
+		return hash.None, "", err
+	}
+	if ht == hash.None {
+		return hash.None, "", fmt.Errorf("hash %q has no type", s)
+	}
+	return ht, strings.TrimSpace(value), nil
+}
+
+// newManifestEntry makes an entry from its parts checking them
+func newManifestEntry(url, path, size, hashValue string) (e manifestEntry, err error) {
+	e = manifestEntry{
+		URL:  strings.TrimSpace(url),
+		Path: strings.TrimSpace(path),
+		Size: -1,
+	}
+	if e.URL == "" {
+		return e, errors.New("missing URL")
+	}
+	if size = strings.TrimSpace(size); size != "" {
+		e.Size, err = strconv.ParseInt(size, 10, 64)
+		if err != nil || e.Size < 0 {
+			return e, fmt.Errorf("bad size %q", size)
+		}
+	}
+	e.HashType, e.Hash, err = parseHash(strings.TrimSpace(hashValue))
+	if err != nil {
+		return e, err
+	}
+	return e, nil
+}
+
+// jsonManifestEntry is the JSON form of a manifestEntry
+type jsonManifestEntry struct {
+	URL  string `json:"url"`
+	Path string `json:"path"`
+	Size *int64 `json:"size"`
+	Hash string `json:"hash"`
+}
+
+// parseManifest reads the --urls file.
+//
+// This is either a CSV file with the columns URL, FILENAME, SIZE and
+// HASH where all but the URL are optional, or JSON which is either an
+// array of objects or one object per line, with the keys url, path,
+// size and hash.
+func parseManifest(in io.Reader) (entries []manifestEntry, err error) {
+	br := bufio.NewReader(in)
+	start, _ := br.Peek(512)
+	start = bytes.TrimLeft(start, " \t\r\n")
+	if len(start) > 0 && (start[0] == '[' || start[0] == '{') {
+		return parseJSONManifest(br, start[0] == '[')
+	}
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading .csv file: %w", err)
+	}
+	for i, record := range records {
+		if len(record) == 0 {
+			continue
+		}
+		if len(record) > 4 {
+			return nil, fmt.Errorf("line %d: too many fields, expecting URL,FILENAME,SIZE,HASH", i+1)
+		}
+		record = append(record, "", "", "")
+		e, err := newManifestEntry(record[0], record[1], record[2], record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// parseJSONManifest reads a JSON manifest which is either an array
+// of objects or a stream of objects
+func parseJSONManifest(in io.Reader, isArray bool) (entries []manifestEntry, err error) {
+	var jsonEntries []jsonManifestEntry
+	dec := json.NewDecoder(in)
+	if isArray {
+		err = dec.Decode(&jsonEntries)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading JSON manifest: %w", err)
+		}
+	} else {
+		for {
+			var je jsonManifestEntry
+			err = dec.Decode(&je)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed reading JSON manifest: %w", err)
+			}
+			jsonEntries = append(jsonEntries, je)
+		}
+	}
+	for i, je := range jsonEntries {
+		size := ""
+		if je.Size != nil {
+			size = strconv.FormatInt(*je.Size, 10)
+		}
+		e, err := newManifestEntry(je.URL, je.Path, size, je.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
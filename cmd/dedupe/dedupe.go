@@ -4,6 +4,8 @@ package dedupe
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/rclone/rclone/cmd"
 	"github.com/rclone/rclone/fs"
@@ -13,15 +15,19 @@ import (
 )
 
 var (
-	dedupeMode = operations.DeduplicateInteractive
-	byHash     = false
+	dedupeMode   = operations.DeduplicateInteractive
+	byHash       = false
+	byHashAcross = false
+	dedupeReport = ""
 )
 
 func init() {
 	cmd.Root.AddCommand(commandDefinition)
 	cmdFlag := commandDefinition.Flags()
-	flags.FVarP(cmdFlag, &dedupeMode, "dedupe-mode", "", "Dedupe mode interactive|skip|first|newest|oldest|largest|smallest|rename", "")
+	flags.FVarP(cmdFlag, &dedupeMode, "dedupe-mode", "", "Dedupe mode interactive|skip|first|newest|oldest|largest|smallest|rename|copy", "")
 	flags.BoolVarP(cmdFlag, &byHash, "by-hash", "", false, "Find identical hashes rather than names", "")
+	flags.BoolVarP(cmdFlag, &byHashAcross, "by-hash-across-dirs", "", false, "Find identical hashes anywhere on the remote using an on disk index", "")
+	flags.StringVarP(cmdFlag, &dedupeReport, "dedupe-report", "", "", "Write a CSV report of duplicates to this file (use - for stdout) with --by-hash-across-dirs", "")
 }
 
 var commandDefinition = &cobra.Command{
@@ -41,6 +47,20 @@ duplicate hashes instead which will work on any backend which supports
 at least one hash. This can be used to find files with duplicate
 content. This is known as deduping by hash.
 
+Deduping by hash keeps the whole listing in memory. For large remotes
+use ` + "`--by-hash-across-dirs`" + ` instead which finds files with
+identical hashes anywhere on the remote by sorting the hashes in an
+on disk index, so only one group of duplicates is held in memory at
+a time. With this the ` + "`rename`" + ` mode can't be used, but the
+` + "`copy`" + ` mode can be used to replace the duplicates with
+server-side copies of the first one. Use ` + "`--dedupe-report file.csv`" + `
+to write a CSV report of the duplicates found with the columns
+` + "`hash,size,path,action`" + `, e.g.
+
+` + "```console" + `
+rclone dedupe --by-hash-across-dirs --dedupe-mode list --dedupe-report dupes.csv remote:
+` + "```" + `
+
 If deduping by name, first rclone will merge directories with the same
 name.  It will do this iteratively until all the identically named
 directories have been merged.
@@ -131,6 +151,7 @@ or by using an extra parameter with the same value
 - ` + "`" + `--dedupe-mode smallest` + "`" + ` - removes identical files then keeps the smallest one.
 - ` + "`" + `--dedupe-mode rename` + "`" + ` - removes identical files then renames the rest to be different.
 - ` + "`" + `--dedupe-mode list` + "`" + ` - lists duplicate dirs and files only and changes nothing.
+- ` + "`" + `--dedupe-mode copy` + "`" + ` - keeps the first one and replaces the others with server-side copies of it (only with ` + "`--by-hash-across-dirs`" + `).
 
 For example, to rename all the identically named photos in your Google Photos
 directory, do
@@ -158,6 +179,12 @@ rclone dedupe rename "drive:Google Photos"
 			args = args[1:]
 		}
 		fdst := cmd.NewFsSrc(args)
+		if byHashAcross {
+			cmd.Run(false, false, command, func() error {
+				return dedupeAcrossDirs(context.Background(), fdst)
+			})
+			return
+		}
 		if !byHash && !fdst.Features().DuplicateFiles {
 			fs.Logf(fdst, "Can't have duplicate names here. Perhaps you wanted --by-hash ? Continuing anyway.")
 		}
@@ -166,3 +193,22 @@ rclone dedupe rename "drive:Google Photos"
 		})
 	},
 }
+
+// dedupeAcrossDirs runs the dedupe with --by-hash-across-dirs writing
+// the report if required
+func dedupeAcrossDirs(ctx context.Context, f fs.Fs) (err error) {
+	var report io.Writer
+	switch dedupeReport {
+	case "":
+	case "-":
+		report = os.Stdout
+	default:
+		out, err := os.Create(dedupeReport)
+		if err != nil {
+			return fmt.Errorf("failed to create dedupe report: %w", err)
+		}
+		defer fs.CheckClose(out, &err)
+		report = out
+	}
+	return operations.DeduplicateAcrossDirs(ctx, f, dedupeMode, report)
+}
@@ -0,0 +1,154 @@
+// Package diff provides the diff command.
+package diff
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/spf13/cobra"
+)
+
+// Globals
+var (
+	format    = "json"
+	noRenames = false
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.StringVarP(cmdFlags, &format, "format", "", format, "Output format: json or csv", "")
+	flags.BoolVarP(cmdFlags, &noRenames, "no-renames", "", noRenames, "Don't detect renamed files", "")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "diff source:path dest:path",
+	Short: `Show the differences between two remotes in a machine readable form.`,
+	Long: `Compares the source and the destination and writes a list of the
+differences as JSON or CSV. The differences describe what a sync from
+the source to the destination would do:
+
+- ` + "`added`" + ` - the path is only in the source
+- ` + "`removed`" + ` - the path is only in the destination
+- ` + "`modified`" + ` - the path is in both but differs
+- ` + "`renamed`" + ` - the path is only in the source but has the same size and
+  hash as ` + "`OldPath`" + ` which is only in the destination
+
+Files in both are compared in the same way as sync, so obey
+` + "`--size-only`" + ` and ` + "`--checksum`" + `.
+
+Renames are only detected if the source and destination have a hash
+in common. The files which are only in one of them and which have a
+size matching a file only in the other will be hashed, which may be
+slow on backends which don't store hashes. Use ` + "`--no-renames`" + ` to
+skip this and report these files as added and removed instead. Empty
+files are never detected as renamed.
+
+The default JSON output is a list of objects sorted by path, written
+one per line so it can be processed either as a JSON blob or line by
+line:
+
+` + "```json" + `
+[
+{"Action":"added","Path":"new.txt","Size":6},
+{"Action":"renamed","Path":"dir/b.txt","OldPath":"a.txt","Size":2048,"Hash":"9a0364b9e99bb480dd25e1f0284c8555"},
+{"Action":"removed","Path":"old.txt","Size":3}
+]
+` + "```" + `
+
+With ` + "`--format csv`" + ` the same fields are written as CSV with a
+header line.
+
+The output can be used as input to targeted transfers, for example to
+copy only the added and modified files:
+
+` + "```console" + `
+rclone diff src:path dst:path | jq -r '.[] | select(.Action == "added" or .Action == "modified") | .Path' > changed.txt
+rclone copy src:path dst:path --files-from changed.txt --no-traverse
+` + "```" + `
+
+If you just want to know whether the remotes match use the
+[check](/commands/rclone_check/) command instead.`,
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+		"groups":            "Filter,Listing,Check",
+	},
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(2, 2, command, args)
+		fsrc, fdst := cmd.NewFsSrcDst(args)
+		cmd.Run(false, true, command, func() error {
+			opt := &operations.DiffOpt{
+				Fsrc:          fsrc,
+				Fdst:          fdst,
+				DetectRenames: !noRenames,
+			}
+			return writeDiff(context.Background(), os.Stdout, format, opt)
+		})
+	},
+}
+
+// writeDiff runs the diff writing the output to out in format
+func writeDiff(ctx context.Context, out io.Writer, format string, opt *operations.DiffOpt) error {
+	switch format {
+	case "json":
+		return writeJSON(ctx, out, opt)
+	case "csv":
+		return writeCSV(ctx, out, opt)
+	}
+	return fmt.Errorf("unknown --format %q: must be json or csv", format)
+}
+
+// writeJSON writes the diff as a JSON list with one item per line
+func writeJSON(ctx context.Context, out io.Writer, opt *operations.DiffOpt) error {
+	if _, err := fmt.Fprintln(out, "["); err != nil {
+		return err
+	}
+	first := true
+	err := operations.Diff(ctx, opt, func(item *operations.DiffItem) error {
+		buf, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal diff item: %w", err)
+		}
+		if !first {
+			buf = append([]byte(",\n"), buf...)
+		}
+		first = false
+		_, err = out.Write(buf)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !first {
+		if _, err = fmt.Fprintln(out); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(out, "]")
+	return err
+}
+
+// writeCSV writes the diff as CSV with a header line
+func writeCSV(ctx context.Context, out io.Writer, opt *operations.DiffOpt) error {
+	w := csv.NewWriter(out)
+	err := w.Write([]string{"Action", "Path", "OldPath", "Size", "Hash"})
+	if err != nil {
+		return err
+	}
+	err = operations.Diff(ctx, opt, func(item *operations.DiffItem) error {
+		return w.Write([]string{item.Action, item.Path, item.OldPath, strconv.FormatInt(item.Size, 10), item.Hash})
+	})
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
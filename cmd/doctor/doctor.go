@@ -0,0 +1,565 @@
+// Package doctor provides the doctor command.
+package doctor
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/lib/random"
+	"github.com/spf13/cobra"
+)
+
+var (
+	format     = "text"
+	write      = false
+	bundleFile = ""
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.StringVarP(cmdFlags, &format, "format", "", format, "Output format: text or json", "")
+	flags.BoolVarP(cmdFlags, &write, "write", "", write, "Write, read back and delete a test file on each remote", "")
+	flags.StringVarP(cmdFlags, &bundleFile, "bundle", "", bundleFile, "Write a redacted support bundle to this zip file", "")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "doctor [remote:path]...",
+	Short: `Diagnose problems with the environment and remotes.`,
+	Long: strings.ReplaceAll(`Run diagnostic checks on the rclone environment and on each of the
+remotes given and report the results.
+
+The environment checks look at the config file and for flags which
+conflict with each other or are unsafe.
+
+For each remote it checks
+
+- |connect| - the remote can be created from the config
+- |auth| - whether a failure looks like an authentication problem
+- |list| - the root of the remote can be listed
+- |tls| - the TLS certificate of the endpoint is valid and not about to expire
+- |quota| - the remote isn't nearly full (if it supports |rclone about|)
+
+With |--write| it also checks
+
+- |write| - a test file can be uploaded, read back and deleted
+- |clock| - the clock of the remote agrees with the local clock (only
+  for remotes which set the modification time themselves)
+
+The test file is called |rclone-doctor-XXXXXXXX.txt| and is written to
+the root of the remote given.
+
+Use |--bundle| to write a zip file with the results, the rclone
+version and the config of the remotes with the passwords, tokens and
+other secrets removed, which is suitable for attaching to a support
+request. Check the contents before posting it publicly.
+
+    rclone doctor remote: other:bucket/path --write --bundle doctor.zip
+
+The command exits with an error if any of the checks fail.`, "|", "`"),
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.74",
+	},
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(0, 1000, command, args)
+		cmd.Run(false, false, command, func() error {
+			ctx := context.Background()
+			switch format {
+			case "text", "json":
+			default:
+				return fmt.Errorf("unknown --format %q: must be text or json", format)
+			}
+			report := run(ctx, args)
+			err := output(os.Stdout, format, report)
+			if err != nil {
+				return err
+			}
+			if bundleFile != "" {
+				err = writeBundle(bundleFile, report, args)
+				if err != nil {
+					return fmt.Errorf("failed to write support bundle: %w", err)
+				}
+				fs.Logf(nil, "Written support bundle to %q", bundleFile)
+			}
+			if failed := report.failed(); failed > 0 {
+				return fmt.Errorf("%d checks failed", failed)
+			}
+			return nil
+		})
+	},
+}
+
+// Status of a check
+const (
+	statusOK      = "ok"
+	statusWarning = "warning"
+	statusFailed  = "failed"
+	statusSkipped = "skipped"
+)
+
+// Result is the outcome of one check
+type Result struct {
+	Check   string        `json:"check"`
+	Remote  string        `json:"remote,omitempty"`
+	Status  string        `json:"status"`
+	Message string        `json:"message"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Report is the outcome of all the checks
+type Report struct {
+	Time       time.Time `json:"time"`
+	Version    string    `json:"version"`
+	GoVersion  string    `json:"goVersion"`
+	OS         string    `json:"os"`
+	Arch       string    `json:"arch"`
+	ConfigPath string    `json:"configPath"`
+	Results    []Result  `json:"results"`
+}
+
+// failed returns the number of failed checks
+func (r *Report) failed() (n int) {
+	for _, result := range r.Results {
+		if result.Status == statusFailed {
+			n++
+		}
+	}
+	return n
+}
+
+// checker runs checks recording the results
+type checker struct {
+	report *Report
+	remote string
+}
+
+// check runs fn as the check called name and records the result
+func (c *checker) check(name string, fn func() (status, message string)) (status string) {
+	start := time.Now()
+	status, message := fn()
+	c.report.Results = append(c.report.Results, Result{
+		Check:   name,
+		Remote:  redactRemote(c.remote),
+		Status:  status,
+		Message: message,
+		Elapsed: time.Since(start),
+	})
+	return status
+}
+
+// run the checks on the environment then the remotes
+func run(ctx context.Context, remotes []string) *Report {
+	report := &Report{
+		Time:       time.Now(),
+		Version:    fs.Version,
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		ConfigPath: config.GetConfigPath(),
+	}
+	c := &checker{report: report}
+	c.check("config", checkConfig)
+	c.check("flags", func() (string, string) {
+		problems := flagProblems(fs.GetConfig(ctx))
+		if len(problems) == 0 {
+			return statusOK, "no conflicting flags"
+		}
+		return statusWarning, strings.Join(problems, "; ")
+	})
+	for _, remote := range remotes {
+		c := &checker{report: report, remote: remote}
+		checkRemote(ctx, c, remote)
+	}
+	return report
+}
+
+// checkConfig checks the config file
+func checkConfig() (status, message string) {
+	path := config.GetConfigPath()
+	if path == "" {
+		return statusWarning, "no config file is in use"
+	}
+	fi, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return statusWarning, fmt.Sprintf("config file %q not found - run \"rclone config\" to make one", path)
+	} else if err != nil {
+		return statusFailed, fmt.Sprintf("can't read config file: %v", err)
+	}
+	if fi.Mode().Perm()&0o077 != 0 && runtime.GOOS != "windows" {
+		return statusWarning, fmt.Sprintf("config file %q can be read by other users (mode %v)", path, fi.Mode().Perm())
+	}
+	return statusOK, fmt.Sprintf("using config file %q with %d remotes", path, len(config.FileSections()))
+}
+
+// flagProblems returns descriptions of flags which conflict or are unsafe
+func flagProblems(ci *fs.ConfigInfo) (problems []string) {
+	if ci.CheckSum && ci.SizeOnly {
+		problems = append(problems, "--checksum and --size-only are both set")
+	}
+	if ci.IgnoreTimes && (ci.CheckSum || ci.SizeOnly) {
+		problems = append(problems, "--ignore-times transfers everything so --checksum and --size-only have no effect")
+	}
+	if ci.IgnoreExisting && ci.UpdateOlder {
+		problems = append(problems, "--ignore-existing skips all existing files so --update has no effect")
+	}
+	if ci.DryRun && ci.Interactive {
+		problems = append(problems, "--dry-run and --interactive are both set")
+	}
+	if ci.InsecureSkipVerify {
+		problems = append(problems, "--no-check-certificate disables TLS certificate verification")
+	}
+	if ci.LowLevelRetries <= 1 && ci.Retries <= 1 {
+		problems = append(problems, "--retries and --low-level-retries are both 1 or less so transient errors will fail")
+	}
+	return problems
+}
+
+// authErrorStrings are parts of error messages which indicate an
+// authentication or permission problem
+var authErrorStrings = []string{
+	"401", "403", "unauthorized", "unauthenticated", "forbidden",
+	"access denied", "accessdenied", "permission denied",
+	"invalid_grant", "invalid_client", "token", "credentials", "signature",
+}
+
+// isAuthError returns true if err looks like an authentication or
+// permission problem
+func isAuthError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range authErrorStrings {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// failure returns the status and message for err checking whether it
+// looks like an authentication problem too
+func (c *checker) failure(err error) (status, message string) {
+	if isAuthError(err) {
+		c.check("auth", func() (string, string) {
+			return statusFailed, "this looks like an authentication or permission problem - try \"rclone config reconnect\" or check the credentials"
+		})
+	}
+	return statusFailed, err.Error()
+}
+
+// checkRemote runs the checks on a single remote
+func checkRemote(ctx context.Context, c *checker, remote string) {
+	var f fs.Fs
+	status := c.check("connect", func() (string, string) {
+		var err error
+		f, err = cache.Get(ctx, remote)
+		if errors.Is(err, fs.ErrorIsFile) {
+			return statusOK, fmt.Sprintf("connected to %v which is a file", f)
+		} else if err != nil {
+			return c.failure(err)
+		}
+		return statusOK, fmt.Sprintf("connected to %v", f)
+	})
+	if status == statusFailed {
+		return
+	}
+	c.check("list", func() (string, string) {
+		entries, err := f.List(ctx, "")
+		if errors.Is(err, fs.ErrorDirNotFound) {
+			return statusWarning, "the directory doesn't exist"
+		} else if err != nil {
+			return c.failure(err)
+		}
+		return statusOK, fmt.Sprintf("listed %d entries", len(entries))
+	})
+	c.check("tls", func() (string, string) {
+		return checkTLS(ctx, remote)
+	})
+	c.check("quota", func() (string, string) {
+		return checkQuota(ctx, f)
+	})
+	if !write {
+		return
+	}
+	var o fs.Object
+	var uploadTime time.Time
+	status = c.check("write", func() (string, string) {
+		var err error
+		o, uploadTime, err = writeTestFile(ctx, f)
+		if err != nil {
+			return c.failure(err)
+		}
+		return statusOK, "uploaded, read back and deleted a test file"
+	})
+	c.check("clock", func() (string, string) {
+		if status == statusFailed {
+			return statusSkipped, "the test file couldn't be written"
+		}
+		if f.Precision() != fs.ModTimeNotSupported {
+			return statusSkipped, "the remote stores the modification time it is given"
+		}
+		skew := o.ModTime(ctx).Sub(uploadTime)
+		message := fmt.Sprintf("the remote clock differs from the local clock by %v", skew.Round(time.Second))
+		if skew.Abs() > time.Minute {
+			return statusWarning, message
+		}
+		return statusOK, message
+	})
+}
+
+// writeTestFile uploads a test file, reads it back and deletes it
+// returning the object and the time it was uploaded
+func writeTestFile(ctx context.Context, f fs.Fs) (o fs.Object, uploadTime time.Time, err error) {
+	remote := "rclone-doctor-" + random.String(8) + ".txt"
+	data := []byte("rclone doctor test file - this can be deleted\n")
+	uploadTime = time.Now()
+	src := object.NewStaticObjectInfo(remote, uploadTime, int64(len(data)), true, nil, f)
+	o, err = f.Put(ctx, bytes.NewReader(data), src)
+	if err != nil {
+		return nil, uploadTime, fmt.Errorf("failed to upload test file: %w", err)
+	}
+	defer func() {
+		removeErr := o.Remove(ctx)
+		if removeErr != nil && err == nil {
+			err = fmt.Errorf("failed to delete test file: %w", removeErr)
+		}
+	}()
+	in, err := o.Open(ctx)
+	if err != nil {
+		return o, uploadTime, fmt.Errorf("failed to open test file: %w", err)
+	}
+	got, err := io.ReadAll(in)
+	_ = in.Close()
+	if err != nil {
+		return o, uploadTime, fmt.Errorf("failed to read test file: %w", err)
+	}
+	if !bytes.Equal(got, data) {
+		return o, uploadTime, errors.New("test file read back was different to the one written")
+	}
+	return o, uploadTime, nil
+}
+
+// tlsWarnExpiry is how long before a certificate expires to warn about it
+const tlsWarnExpiry = 14 * 24 * time.Hour
+
+// endpointKeys are the config keys which hold the URL of the endpoint
+var endpointKeys = []string{"url", "endpoint", "server"}
+
+// remoteEndpoint returns the https endpoint of remote or "" if it
+// doesn't have one in its config
+func remoteEndpoint(remote string) string {
+	parsed, err := fspath.Parse(remote)
+	if err != nil || parsed.Name == "" {
+		return ""
+	}
+	for _, key := range endpointKeys {
+		value, ok := parsed.Config[key]
+		if !ok && !strings.HasPrefix(parsed.Name, ":") {
+			value = config.GetValue(parsed.Name, key)
+		}
+		if strings.HasPrefix(value, "https://") {
+			return value
+		}
+	}
+	return ""
+}
+
+// checkTLS checks the TLS certificate of the endpoint of the remote
+func checkTLS(ctx context.Context, remote string) (status, message string) {
+	endpoint := remoteEndpoint(remote)
+	if endpoint == "" {
+		return statusSkipped, "no https endpoint in the config"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return statusFailed, fmt.Sprintf("bad endpoint %q: %v", endpoint, err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	ci := fs.GetConfig(ctx)
+	dialer := &net.Dialer{Timeout: time.Duration(ci.ConnectTimeout)}
+	conn, err := tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	if err != nil {
+		return statusFailed, fmt.Sprintf("TLS connection to %s failed: %v", host, err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return statusFailed, fmt.Sprintf("%s sent no certificates", host)
+	}
+	expires := certs[0].NotAfter
+	message = fmt.Sprintf("certificate for %s is valid until %s", u.Hostname(), expires.Format(time.DateOnly))
+	if time.Until(expires) < tlsWarnExpiry {
+		return statusWarning, message
+	}
+	return statusOK, message
+}
+
+// quotaWarnFraction is the fraction of free space to warn at
+const quotaWarnFraction = 0.05
+
+// checkQuota checks the remote isn't nearly full
+func checkQuota(ctx context.Context, f fs.Fs) (status, message string) {
+	doAbout := f.Features().About
+	if doAbout == nil {
+		return statusSkipped, "the remote doesn't support about"
+	}
+	u, err := doAbout(ctx)
+	if err != nil {
+		return statusWarning, fmt.Sprintf("failed to read quota: %v", err)
+	}
+	if u.Total == nil || u.Free == nil {
+		return statusSkipped, "the remote doesn't report its total and free space"
+	}
+	message = fmt.Sprintf("%v free of %v", fs.SizeSuffix(*u.Free), fs.SizeSuffix(*u.Total))
+	if *u.Total > 0 && float64(*u.Free) < quotaWarnFraction*float64(*u.Total) {
+		return statusWarning, message
+	}
+	return statusOK, message
+}
+
+// redactRemote removes any sensitive values from the connection
+// string parameters of remote
+func redactRemote(remote string) string {
+	parsed, err := fspath.Parse(remote)
+	if err != nil || len(parsed.Config) == 0 {
+		return remote
+	}
+	var fsType string
+	if strings.HasPrefix(parsed.Name, ":") {
+		fsType = parsed.Name[1:]
+	} else {
+		fsType = config.GetValue(parsed.Name, "type")
+	}
+	fsInfo, err := fs.Find(fsType)
+	for key := range parsed.Config {
+		redact := err != nil // redact everything if we don't know the backend
+		if fsInfo != nil {
+			for _, opt := range fsInfo.Options {
+				if opt.Name == key && (opt.IsPassword || opt.Sensitive) {
+					redact = true
+				}
+			}
+		}
+		if redact {
+			parsed.Config[key] = "XXX"
+		} else if key == "remote" {
+			// redact the remotes this one wraps too
+			parsed.Config[key] = redactRemote(parsed.Config[key])
+		}
+	}
+	return parsed.Name + "," + parsed.Config.Human() + ":" + parsed.Path
+}
+
+// statusLabels are the labels for the text output
+var statusLabels = map[string]string{
+	statusOK:      " OK ",
+	statusWarning: "WARN",
+	statusFailed:  "FAIL",
+	statusSkipped: "SKIP",
+}
+
+// output writes the report in format to out
+func output(out io.Writer, format string, report *Report) error {
+	if format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "\t")
+		return enc.Encode(report)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "rclone %s (%s, %s/%s)\n", report.Version, report.GoVersion, report.OS, report.Arch)
+	remote := ""
+	for _, result := range report.Results {
+		if result.Remote != remote {
+			remote = result.Remote
+			fmt.Fprintf(&b, "\n%s\n", remote)
+		}
+		fmt.Fprintf(&b, "[%s] %-8s %s\n", statusLabels[result.Status], result.Check, result.Message)
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// writeBundle writes the support bundle to path
+func writeBundle(path string, report *Report, remotes []string) (err error) {
+	fh, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fs.CheckClose(fh, &err)
+	zw := zip.NewWriter(fh)
+	defer fs.CheckClose(zw, &err)
+
+	add := func(name string, fn func(w io.Writer) error) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		return fn(w)
+	}
+	err = add("report.json", func(w io.Writer) error {
+		return output(w, "json", report)
+	})
+	if err != nil {
+		return err
+	}
+	err = add("report.txt", func(w io.Writer) error {
+		return output(w, "text", report)
+	})
+	if err != nil {
+		return err
+	}
+	return add("config.txt", func(w io.Writer) error {
+		for _, name := range bundleRemotes(remotes) {
+			config.WriteRedactedRemote(w, name)
+			_, _ = fmt.Fprintln(w)
+		}
+		return nil
+	})
+}
+
+// bundleRemotes returns the names of the configured remotes used by
+// remotes, including the remotes they wrap
+func bundleRemotes(remotes []string) (names []string) {
+	seen := map[string]bool{}
+	var addRemote func(remote string)
+	addRemote = func(remote string) {
+		parsed, err := fspath.Parse(remote)
+		if err != nil || parsed.Name == "" {
+			return
+		}
+		if !strings.HasPrefix(parsed.Name, ":") && !seen[parsed.Name] {
+			seen[parsed.Name] = true
+			names = append(names, parsed.Name)
+			addRemote(config.GetValue(parsed.Name, "remote"))
+		}
+		if inner, ok := parsed.Config["remote"]; ok {
+			addRemote(inner)
+		}
+	}
+	for _, remote := range remotes {
+		addRemote(remote)
+	}
+	return names
+}
@@ -0,0 +1,88 @@
+package doctor
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagProblems(t *testing.T) {
+	ci := &fs.ConfigInfo{Retries: 3, LowLevelRetries: 10}
+	assert.Empty(t, flagProblems(ci))
+
+	ci.CheckSum = true
+	ci.SizeOnly = true
+	ci.InsecureSkipVerify = true
+	problems := flagProblems(ci)
+	require.Len(t, problems, 2)
+	assert.Contains(t, problems[0], "--checksum and --size-only")
+	assert.Contains(t, problems[1], "--no-check-certificate")
+}
+
+func TestIsAuthError(t *testing.T) {
+	assert.True(t, isAuthError(errors.New("HTTP error 401 (401 Unauthorized)")))
+	assert.True(t, isAuthError(errors.New("AccessDenied: Access Denied")))
+	assert.False(t, isAuthError(errors.New("directory not found")))
+}
+
+func TestRedactRemote(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"remote:path", "remote:path"},
+		{"/local/path", "/local/path"},
+		{":local,case_insensitive:path", ":local,case_insensitive:path"},
+		{":unknownbackend,secret=xyz:path", ":unknownbackend,secret=XXX:path"},
+	} {
+		assert.Equal(t, test.want, redactRemote(test.in), test.in)
+	}
+}
+
+func TestRun(t *testing.T) {
+	oldWrite := write
+	defer func() { write = oldWrite }()
+	write = true
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	report := run(ctx, []string{dir, filepath.Join(dir, "notfound")})
+
+	checks := map[string]string{}
+	for _, result := range report.Results {
+		checks[result.Remote+" "+result.Check] = result.Status
+	}
+	assert.Equal(t, statusOK, checks[dir+" connect"])
+	assert.Equal(t, statusOK, checks[dir+" list"])
+	assert.Equal(t, statusSkipped, checks[dir+" tls"])
+	assert.Equal(t, statusOK, checks[dir+" write"])
+	assert.Equal(t, statusSkipped, checks[dir+" clock"])
+	assert.Equal(t, statusWarning, checks[filepath.Join(dir, "notfound")+" list"])
+	assert.Equal(t, 0, report.failed())
+
+	var buf bytes.Buffer
+	require.NoError(t, output(&buf, "text", report))
+	assert.Contains(t, buf.String(), "[ OK ] write")
+
+	bundle := filepath.Join(t.TempDir(), "bundle.zip")
+	require.NoError(t, writeBundle(bundle, report, []string{dir}))
+	zr, err := zip.OpenReader(bundle)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, zr.Close())
+	}()
+	var names []string
+	for _, file := range zr.File {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, "report.json report.txt config.txt", strings.Join(names, " "))
+}
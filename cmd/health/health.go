@@ -0,0 +1,346 @@
+// Package health provides the health command.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/cmd"
+	cmdrc "github.com/rclone/rclone/cmd/rc"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/lib/atexit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	format = "text"
+	maxAge = fs.Duration(0)
+)
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	cmdrc.AddConnectionFlags(cmdFlags)
+	flags.StringVarP(cmdFlags, &format, "format", "", format, "Output format: text, json, nagios or prometheus", "")
+	flags.FVarP(cmdFlags, &maxAge, "max-age", "", "A failing backend with no success for this long is critical", "")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "health",
+	Short: `Show the health of the mounts and serves of a running rclone.`,
+	Long: strings.ReplaceAll(`This connects to the remote control of a running rclone, started
+with |--rc| or |rclone rcd|, and shows the health of its mounts,
+serves and VFSes for monitoring agents. It uses the |health/status| rc
+command and connects with the same flags as |rclone rc|.
+
+It shows
+
+- whether each mount passes its health check and how often it was remounted
+- whether each server started with |serve/start| is still running
+- the last successful backend operation and the errors of each VFS
+- the bytes and files in the VFS cache which haven't been uploaded yet
+- when the oauth token of each remote expires
+- the error count of the rclone
+
+A mount which fails its health check, a server which has stopped or a
+VFS cache which is out of space is critical. A VFS whose last backend
+operation failed or which has files which failed to upload is a
+warning. Use |--max-age| to make a VFS critical if its backend has
+been failing without a success for that long.
+
+Use |--format| to choose the output.
+
+- |text| - a summary for people to read (the default)
+- |json| - the output of |health/status|
+- |nagios| - one line for Nagios and compatible systems with an exit
+  code of 0 for OK, 1 for WARNING, 2 for CRITICAL and 3 for UNKNOWN
+- |prometheus| - metrics in the Prometheus text format, for example for
+  the textfile collector of the node exporter
+
+For example to check the rclone mount serving on localhost:5572 from
+Nagios
+
+|||sh
+rclone health --url localhost:5572 --format nagios --max-age 15m
+|||
+
+Or to write metrics for the node exporter textfile collector from cron
+
+|||sh
+rclone health --format prometheus > /var/lib/node_exporter/rclone.prom.$$ &&
+  mv /var/lib/node_exporter/rclone.prom.$$ /var/lib/node_exporter/rclone.prom
+|||
+
+If rclone can't be contacted the Nagios status is CRITICAL and the
+|rclone_up| metric is 0.`, "|", "`"),
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+	},
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(0, 0, command, args)
+		cmd.Run(false, false, command, func() error {
+			ctx := context.Background()
+			status, err := readStatus(ctx)
+			code, err := output(os.Stdout, format, status, err)
+			if err != nil {
+				return err
+			}
+			if code != 0 {
+				atexit.Run()
+				os.Exit(code)
+			}
+			return nil
+		})
+	},
+}
+
+// readStatus reads the health of the rclone using the remote control
+func readStatus(ctx context.Context) (status *Status, err error) {
+	in := rc.Params{}
+	if maxAge > 0 {
+		in["maxAge"] = maxAge.String()
+	}
+	out, err := cmdrc.Call(ctx, "health/status", in)
+	if err != nil {
+		return nil, err
+	}
+	status = new(Status)
+	err = rc.Reshape(status, out)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// output writes the status in format to out returning the exit code
+// to use.
+//
+// readErr is the error reading the status, if any.
+func output(out io.Writer, format string, status *Status, readErr error) (code int, err error) {
+	switch format {
+	case "text":
+		if readErr != nil {
+			return 0, readErr
+		}
+		return 0, outputText(out, status)
+	case "json":
+		if readErr != nil {
+			return 0, readErr
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "\t")
+		return 0, enc.Encode(status)
+	case "nagios":
+		return outputNagios(out, status, readErr)
+	case "prometheus":
+		return 0, outputPrometheus(out, status, readErr)
+	}
+	return 0, fmt.Errorf("unknown --format %q: must be text, json, nagios or prometheus", format)
+}
+
+// Nagios plugin exit codes
+const (
+	nagiosOK       = 0
+	nagiosWarning  = 1
+	nagiosCritical = 2
+	nagiosUnknown  = 3
+)
+
+// outputNagios writes the status as a Nagios plugin line
+func outputNagios(out io.Writer, status *Status, readErr error) (code int, err error) {
+	if readErr != nil {
+		_, err = fmt.Fprintf(out, "RCLONE CRITICAL - can't read health: %v\n", readErr)
+		return nagiosCritical, err
+	}
+	var label string
+	switch status.Status {
+	case statusOK:
+		label, code = "OK", nagiosOK
+	case statusWarning:
+		label, code = "WARNING", nagiosWarning
+	case statusCritical:
+		label, code = "CRITICAL", nagiosCritical
+	default:
+		label, code = "UNKNOWN", nagiosUnknown
+	}
+	summary := fmt.Sprintf("%d mounts, %d serves, %d vfses", len(status.Mounts), len(status.Serves), len(status.VFSes))
+	if len(status.Problems) > 0 {
+		// | separates the performance data so can't be in the summary
+		summary = strings.ReplaceAll(strings.Join(status.Problems, "; "), "|", "/")
+	}
+	var dirtyBytes int64
+	var uploadsQueued, erroredFiles int
+	for _, v := range status.VFSes {
+		dirtyBytes += v.DirtyBytes
+		uploadsQueued += v.UploadsQueued
+		erroredFiles += v.ErroredFiles
+	}
+	_, err = fmt.Fprintf(out, "RCLONE %s - %s | mounts=%d serves=%d vfses=%d errors=%dc dirty_bytes=%dB uploads_queued=%d errored_files=%d\n",
+		label, summary, len(status.Mounts), len(status.Serves), len(status.VFSes), status.Errors, dirtyBytes, uploadsQueued, erroredFiles)
+	return code, err
+}
+
+// outputText writes the status for people to read
+func outputText(out io.Writer, status *Status) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Status: %s\n", status.Status)
+	for _, problem := range status.Problems {
+		fmt.Fprintf(&b, "  - %s\n", problem)
+	}
+	fmt.Fprintf(&b, "Errors: %d\n", status.Errors)
+	if status.LastError != "" {
+		fmt.Fprintf(&b, "Last error: %s\n", status.LastError)
+	}
+	for _, m := range status.Mounts {
+		fmt.Fprintf(&b, "\nMount %s (%s)\n", m.MountPoint, m.Fs)
+		fmt.Fprintf(&b, "  Healthy: %v\n", m.Health.Healthy)
+		fmt.Fprintf(&b, "  Remounts: %d\n", m.Health.Remounts)
+		if m.Health.LastError != "" {
+			fmt.Fprintf(&b, "  Last error: %s\n", m.Health.LastError)
+		}
+	}
+	for _, s := range status.Serves {
+		fmt.Fprintf(&b, "\nServe %s on %s\n", s.ID, s.Addr)
+		fmt.Fprintf(&b, "  Running: %v\n", s.Running)
+		if s.Error != "" {
+			fmt.Fprintf(&b, "  Error: %s\n", s.Error)
+		}
+	}
+	for _, v := range status.VFSes {
+		fmt.Fprintf(&b, "\nVFS %s\n", v.Name)
+		fmt.Fprintf(&b, "  Last success: %s\n", formatTime(v.LastSuccess))
+		fmt.Fprintf(&b, "  Errors: %d\n", v.Errors)
+		if v.LastError != "" {
+			fmt.Fprintf(&b, "  Last error: %s at %s\n", v.LastError, formatTime(v.LastErrorTime))
+		}
+		fmt.Fprintf(&b, "  Dirty: %d files, %v\n", v.DirtyFiles, fs.SizeSuffix(v.DirtyBytes))
+		fmt.Fprintf(&b, "  Uploads: %d in progress, %d queued, %d errored\n", v.UploadsInProgress, v.UploadsQueued, v.ErroredFiles)
+		if v.TokenExpiry != nil {
+			fmt.Fprintf(&b, "  Token expiry: %s\n", formatTime(*v.TokenExpiry))
+		}
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// formatTime formats t for outputText
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+// escape a Prometheus label value
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metrics collects metrics in the Prometheus text format
+//
+// The samples of each metric are kept together as the format needs.
+type metrics struct {
+	names   []string
+	samples map[string]*strings.Builder
+}
+
+// add adds a sample of the metric name with the HELP and TYPE written
+// the first time name is seen. labels are name, value pairs.
+func (m *metrics) add(name, typ, help string, value float64, labels ...string) {
+	if m.samples == nil {
+		m.samples = map[string]*strings.Builder{}
+	}
+	b := m.samples[name]
+	if b == nil {
+		b = new(strings.Builder)
+		m.samples[name] = b
+		m.names = append(m.names, name)
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	b.WriteString(name)
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i == 0 {
+			b.WriteString("{")
+		} else {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(b, `%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1]))
+	}
+	if len(labels) > 1 {
+		b.WriteString("}")
+	}
+	fmt.Fprintf(b, " %v\n", value)
+}
+
+// write the metrics to out
+func (m *metrics) write(out io.Writer) error {
+	for _, name := range m.names {
+		_, err := io.WriteString(out, m.samples[name].String())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timestamp returns t as Unix seconds or 0 if it is zero
+func timestamp(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixMilli()) / 1e3
+}
+
+// boolValue returns 1 for true and 0 for false
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// outputPrometheus writes the status as Prometheus metrics
+func outputPrometheus(out io.Writer, status *Status, readErr error) error {
+	var m metrics
+	if readErr != nil {
+		fs.Errorf(nil, "Can't read health: %v", readErr)
+		m.add("rclone_up", "gauge", "Whether the rclone remote control could be read.", 0)
+		return m.write(out)
+	}
+	m.add("rclone_up", "gauge", "Whether the rclone remote control could be read.", 1)
+	m.add("rclone_status", "gauge", "Health of the rclone: 0 ok, 1 warning, 2 critical.", map[string]float64{
+		statusOK:       0,
+		statusWarning:  1,
+		statusCritical: 2,
+	}[status.Status])
+	m.add("rclone_errors_total", "counter", "Number of errors seen by the rclone.", float64(status.Errors))
+	for _, mnt := range status.Mounts {
+		labels := []string{"mount_point", mnt.MountPoint, "fs", mnt.Fs}
+		m.add("rclone_mount_healthy", "gauge", "Whether the mount passed its last health check.", boolValue(mnt.Health.Healthy), labels...)
+		m.add("rclone_mount_remounts_total", "counter", "Number of times the mount was remounted.", float64(mnt.Health.Remounts), labels...)
+	}
+	for _, s := range status.Serves {
+		m.add("rclone_serve_up", "gauge", "Whether the server is running.", boolValue(s.Running), "id", s.ID, "type", s.Type, "addr", s.Addr)
+	}
+	for _, v := range status.VFSes {
+		labels := []string{"vfs", v.Name, "fs", v.Fs}
+		m.add("rclone_vfs_last_success_timestamp_seconds", "gauge", "When a backend operation of the VFS last succeeded.", timestamp(v.LastSuccess), labels...)
+		m.add("rclone_vfs_last_error_timestamp_seconds", "gauge", "When a backend operation of the VFS last failed.", timestamp(v.LastErrorTime), labels...)
+		m.add("rclone_vfs_backend_errors_total", "counter", "Number of failed backend operations of the VFS.", float64(v.Errors), labels...)
+		m.add("rclone_vfs_dirty_files", "gauge", "Files in the VFS cache not yet uploaded.", float64(v.DirtyFiles), labels...)
+		m.add("rclone_vfs_dirty_bytes", "gauge", "Bytes in the VFS cache not yet uploaded.", float64(v.DirtyBytes), labels...)
+		m.add("rclone_vfs_uploads_in_progress", "gauge", "Uploads from the VFS cache in progress.", float64(v.UploadsInProgress), labels...)
+		m.add("rclone_vfs_uploads_queued", "gauge", "Uploads from the VFS cache waiting to start.", float64(v.UploadsQueued), labels...)
+		m.add("rclone_vfs_errored_files", "gauge", "Files in the VFS cache which failed to upload.", float64(v.ErroredFiles), labels...)
+		m.add("rclone_vfs_out_of_space", "gauge", "Whether the VFS cache disk is full.", boolValue(v.OutOfSpace), labels...)
+		if v.TokenExpiry != nil {
+			m.add("rclone_token_expiry_timestamp_seconds", "gauge", "When the oauth token of the remote expires.", timestamp(*v.TokenExpiry), labels...)
+		}
+	}
+	return m.write(out)
+}
@@ -0,0 +1,128 @@
+package health
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/cmd/mountlib"
+	"github.com/rclone/rclone/cmd/serve"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func testStatus() *Status {
+	return &Status{
+		Errors: 2,
+		Mounts: []mountlib.MountHealthInfo{{
+			MountInfo: mountlib.MountInfo{Fs: "remote:", MountPoint: "/mnt/remote"},
+			Health:    mountlib.MountHealth{Healthy: true, Remounts: 1},
+		}},
+		Serves: []serve.ServerHealth{{ID: "nfs-1", Type: "nfs", Addr: "[::]:2049", Running: true}},
+		VFSes: []VFSHealth{{
+			Name: "remote:",
+			Fs:   "remote:",
+			HealthStats: vfscommon.HealthStats{
+				BackendHealthStats: vfscommon.BackendHealthStats{
+					LastSuccess: t0,
+					Errors:      3,
+				},
+				DirtyFiles:    1,
+				DirtyBytes:    1024,
+				UploadsQueued: 1,
+			},
+		}},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s := testStatus()
+	s.check(0, t0)
+	assert.Equal(t, statusOK, s.Status)
+	assert.Equal(t, []string{}, s.Problems)
+
+	// backend failing is a warning
+	s.VFSes[0].LastError = "potato"
+	s.VFSes[0].LastErrorTime = t0.Add(time.Minute)
+	s.check(time.Hour, t0.Add(time.Minute))
+	assert.Equal(t, statusWarning, s.Status)
+	assert.Equal(t, []string{"vfs remote: backend failing: potato"}, s.Problems)
+
+	// backend failing for longer than maxAge is critical
+	s.check(time.Hour, t0.Add(2*time.Hour))
+	assert.Equal(t, statusCritical, s.Status)
+
+	// critical isn't lowered by a warning
+	s = testStatus()
+	s.Mounts[0].Health.Healthy = false
+	s.Mounts[0].Health.LastError = "not mounted"
+	s.VFSes[0].ErroredFiles = 1
+	s.check(0, t0)
+	assert.Equal(t, statusCritical, s.Status)
+	assert.Equal(t, []string{
+		"mount /mnt/remote is not healthy: not mounted",
+		"vfs remote: has 1 files which failed to upload",
+	}, s.Problems)
+
+	s = testStatus()
+	s.Serves[0].Running = false
+	s.check(0, t0)
+	assert.Equal(t, statusCritical, s.Status)
+}
+
+func TestOutputNagios(t *testing.T) {
+	var buf bytes.Buffer
+	s := testStatus()
+	s.check(0, t0)
+	code, err := output(&buf, "nagios", s, nil)
+	require.NoError(t, err)
+	assert.Equal(t, nagiosOK, code)
+	assert.Equal(t, "RCLONE OK - 1 mounts, 1 serves, 1 vfses | mounts=1 serves=1 vfses=1 errors=2c dirty_bytes=1024B uploads_queued=1 errored_files=0\n", buf.String())
+
+	buf.Reset()
+	s.VFSes[0].ErroredFiles = 2
+	s.check(0, t0)
+	code, err = output(&buf, "nagios", s, nil)
+	require.NoError(t, err)
+	assert.Equal(t, nagiosWarning, code)
+	assert.True(t, strings.HasPrefix(buf.String(), "RCLONE WARNING - vfs remote: has 2 files which failed to upload | "))
+
+	buf.Reset()
+	code, err = output(&buf, "nagios", nil, errors.New("connection refused"))
+	require.NoError(t, err)
+	assert.Equal(t, nagiosCritical, code)
+	assert.Equal(t, "RCLONE CRITICAL - can't read health: connection refused\n", buf.String())
+}
+
+func TestOutputPrometheus(t *testing.T) {
+	var buf bytes.Buffer
+	s := testStatus()
+	s.VFSes = append(s.VFSes, VFSHealth{Name: `other"`, Fs: "other:"})
+	s.check(0, t0)
+	_, err := output(&buf, "prometheus", s, nil)
+	require.NoError(t, err)
+	got := buf.String()
+	assert.Contains(t, got, "# TYPE rclone_up gauge\nrclone_up 1\n")
+	assert.Contains(t, got, "rclone_errors_total 2\n")
+	assert.Contains(t, got, `rclone_mount_healthy{mount_point="/mnt/remote",fs="remote:"} 1`+"\n")
+	assert.Contains(t, got, `rclone_serve_up{id="nfs-1",type="nfs",addr="[::]:2049"} 1`+"\n")
+	assert.Contains(t, got, `rclone_vfs_last_success_timestamp_seconds{vfs="remote:",fs="remote:"} 1.704164645e+09`+"\n")
+	// samples of a metric must be together and labels escaped
+	assert.Contains(t, got, `rclone_vfs_dirty_bytes{vfs="remote:",fs="remote:"} 1024`+"\n"+`rclone_vfs_dirty_bytes{vfs="other\"",fs="other:"} 0`+"\n")
+	assert.Equal(t, 1, strings.Count(got, "# HELP rclone_vfs_dirty_bytes "))
+
+	buf.Reset()
+	_, err = output(&buf, "prometheus", nil, errors.New("connection refused"))
+	require.NoError(t, err)
+	assert.Equal(t, "# HELP rclone_up Whether the rclone remote control could be read.\n# TYPE rclone_up gauge\nrclone_up 0\n", buf.String())
+}
+
+func TestOutputUnknownFormat(t *testing.T) {
+	_, err := output(&bytes.Buffer{}, "potato", testStatus(), nil)
+	assert.ErrorContains(t, err, "unknown --format")
+}
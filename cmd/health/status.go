@@ -0,0 +1,182 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rclone/rclone/cmd/mountlib"
+	"github.com/rclone/rclone/cmd/serve"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"golang.org/x/oauth2"
+)
+
+// Possible values of Status.Status
+const (
+	statusOK       = "ok"
+	statusWarning  = "warning"
+	statusCritical = "critical"
+)
+
+// Status is the health of an rclone instance
+type Status struct {
+	Status    string                     `json:"status"`    // ok, warning or critical
+	Problems  []string                   `json:"problems"`  // what is wrong if not ok
+	Errors    int64                      `json:"errors"`    // number of errors from core/stats
+	LastError string                     `json:"lastError"` // last error from core/stats
+	Mounts    []mountlib.MountHealthInfo `json:"mounts"`    // as returned by mount/health
+	Serves    []serve.ServerHealth       `json:"serves"`    // servers started with serve/start
+	VFSes     []VFSHealth                `json:"vfses"`     // health of each active VFS
+}
+
+// VFSHealth is the health of a VFS
+type VFSHealth struct {
+	Name string `json:"name"` // name as shown by vfs/list
+	Fs   string `json:"fs"`   // the remote the VFS is using
+	vfscommon.HealthStats
+	TokenExpiry *time.Time `json:"tokenExpiry,omitempty"` // expiry of the oauth token if the remote has one
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "health/status",
+		AuthRequired: true,
+		Fn:           rcStatus,
+		Title:        "Show the health of the mounts, serves and VFSes.",
+		Help: `This returns the health of this rclone instance for use by
+monitoring systems. The ` + "`rclone health`" + ` command formats it for
+Nagios and Prometheus.
+
+This takes the following optional parameters:
+
+- maxAge - a failing backend with no success for this long is critical, eg "10m"
+
+It returns
+
+- status - "ok", "warning" or "critical"
+- problems - a list of what is wrong
+- errors - the number of errors, as in core/stats
+- lastError - the last error, as in core/stats
+- mounts - the health of the mounts, as in mount/health
+- serves - the servers started with serve/start
+    - id, type, addr - which server it is
+    - running - false if the server has stopped
+    - error - why the server stopped
+- vfses - the health of each VFS, as in vfs/health
+    - name - the name of the VFS, as in vfs/list
+    - fs - the remote the VFS is using
+    - tokenExpiry - when the oauth token expires, if the remote has one
+
+A mount which fails its health check, a server which has stopped or
+a VFS cache which is out of space is critical.
+
+A VFS whose last backend operation failed or which has files which
+failed to upload is a warning. If maxAge is set and the VFS has not
+had a successful backend operation for maxAge it is critical.
+`,
+	})
+}
+
+func rcStatus(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	maxAge, err := in.GetDuration("maxAge")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	status, err := getStatus()
+	if err != nil {
+		return nil, err
+	}
+	status.check(maxAge, time.Now())
+	err = rc.Reshape(&out, status)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// getStatus reads the health of everything running in this rclone
+func getStatus() (status *Status, err error) {
+	stats := accounting.GlobalStats()
+	status = &Status{
+		Errors: stats.GetErrors(),
+		Serves: serve.ListHealth(),
+		VFSes:  []VFSHealth{},
+	}
+	if lastError := stats.GetLastError(); lastError != nil {
+		status.LastError = lastError.Error()
+	}
+	status.Mounts, err = mountlib.ListHealth("")
+	if err != nil {
+		return nil, err
+	}
+	for name, v := range vfs.Active() {
+		status.VFSes = append(status.VFSes, VFSHealth{
+			Name:        name,
+			Fs:          fs.ConfigString(v.Fs()),
+			HealthStats: v.Health(),
+			TokenExpiry: tokenExpiry(v.Fs()),
+		})
+	}
+	sort.Slice(status.VFSes, func(i, j int) bool {
+		return status.VFSes[i].Name < status.VFSes[j].Name
+	})
+	return status, nil
+}
+
+// tokenExpiry returns when the oauth token of the remote under f
+// expires or nil if it doesn't have one.
+func tokenExpiry(f fs.Fs) *time.Time {
+	tokenString, ok := config.FileGetValue(fs.UnWrapFs(f).Name(), config.ConfigToken)
+	if !ok || tokenString == "" {
+		return nil
+	}
+	var token oauth2.Token
+	if json.Unmarshal([]byte(tokenString), &token) != nil || token.Expiry.IsZero() {
+		return nil
+	}
+	return &token.Expiry
+}
+
+// check sets Status and Problems from the health of the parts
+func (s *Status) check(maxAge time.Duration, now time.Time) {
+	s.Status = statusOK
+	s.Problems = []string{}
+	problem := func(level string, format string, a ...any) {
+		if level == statusCritical || s.Status == statusOK {
+			s.Status = level
+		}
+		s.Problems = append(s.Problems, fmt.Sprintf(format, a...))
+	}
+	for _, m := range s.Mounts {
+		if !m.Health.Healthy {
+			problem(statusCritical, "mount %s is not healthy: %s", m.MountPoint, m.Health.LastError)
+		}
+	}
+	for _, server := range s.Serves {
+		if !server.Running {
+			problem(statusCritical, "serve %s has stopped: %s", server.ID, server.Error)
+		}
+	}
+	for _, v := range s.VFSes {
+		if v.OutOfSpace {
+			problem(statusCritical, "vfs %s cache is out of space", v.Name)
+		}
+		if v.ErroredFiles > 0 {
+			problem(statusWarning, "vfs %s has %d files which failed to upload", v.Name, v.ErroredFiles)
+		}
+		if v.Failing() {
+			if maxAge > 0 && now.Sub(v.LastSuccess) > maxAge {
+				problem(statusCritical, "vfs %s backend failing for more than %v: %s", v.Name, maxAge, v.LastError)
+			} else {
+				problem(statusWarning, "vfs %s backend failing: %s", v.Name, v.LastError)
+			}
+		}
+	}
+}
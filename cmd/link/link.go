@@ -13,8 +13,9 @@ import (
 )
 
 var (
-	expire = fs.DurationOff
-	unlink = false
+	expire      = fs.DurationOff
+	unlink      = false
+	linkOptions = fs.LinkOptions{}
 )
 
 func init() {
@@ -22,6 +23,8 @@ func init() {
 	cmdFlags := commandDefinition.Flags()
 	flags.FVarP(cmdFlags, &expire, "expire", "", "The amount of time that the link will be valid", "")
 	flags.BoolVarP(cmdFlags, &unlink, "unlink", "", unlink, "Remove existing public link to file/folder", "")
+	flags.StringVarP(cmdFlags, &linkOptions.Password, "password", "", "", "Password needed to open the link", "")
+	flags.StringVarP(cmdFlags, &linkOptions.Permission, "permission", "", "", "What the link allows: view or edit", "")
 }
 
 var commandDefinition = &cobra.Command{
@@ -34,6 +37,7 @@ rclone link remote:path/to/file
 rclone link remote:path/to/folder/
 rclone link --unlink remote:path/to/folder/
 rclone link --expire 1d remote:path/to/file
+rclone link --expire 7d --password secret --permission edit remote:path/to/folder/
 ` + "```" + `
 
 If you supply the --expire flag, it will set the expiration time
@@ -41,9 +45,17 @@ otherwise it will use the default (100 years). **Note** not all
 backends support the --expire flag - if the backend doesn't support it
 then the link returned won't expire.
 
+Use the --password flag to make a link which needs a password to open
+and the --permission flag to choose whether the link allows "view"
+(view and download) or "edit" access. The default depends on the
+backend but is usually view. These are supported by Box, Dropbox,
+Google Drive and OneDrive, though not every account type allows every
+combination. Backends which don't support them will return an error
+rather than make a link without the restrictions asked for.
+
 Use the --unlink flag to remove existing public links to the file or
-folder. **Note** not all backends support "--unlink" flag - those that
-don't will just ignore it.
+folder, revoking access through them. **Note** not all backends
+support "--unlink" flag - those that don't will just ignore it.
 
 If successful, the last line of the output will contain the
 link. Exact capabilities depend on the remote, but the link will
@@ -56,7 +68,8 @@ expiry, no password protection, accessible without account.`,
 		cmd.CheckArgs(1, 1, command, args)
 		fsrc, remote := cmd.NewFsFile(args[0])
 		cmd.Run(false, false, command, func() error {
-			link, err := operations.PublicLink(context.Background(), fsrc, remote, expire, unlink)
+			ctx := fs.WithLinkOptions(context.Background(), &linkOptions)
+			link, err := operations.PublicLink(ctx, fsrc, remote, expire, unlink)
 			if err != nil {
 				return err
 			}
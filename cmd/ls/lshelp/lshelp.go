@@ -29,3 +29,27 @@ use |-R| to make them recurse.
 Listing a nonexistent directory will produce an error except for
 remotes which can't have empty directories (e.g. s3, swift, or gcs -
 the bucket-based remotes).`, "|", "`")
+
+// QueryHelp describes the --query flag for the list commands which
+// support it
+// Warning! "|" will be replaced by backticks below
+var QueryHelp = strings.ReplaceAll(`The filtering options are used to reduce the number of items the
+remote has to list where the backend supports it. For example with
+|--include "/logs/2024-*"| s3 only lists objects starting with
+|logs/2024-| and Google Drive only lists items whose names start with
+|logs| at the root and |2024| in the |logs| directory.
+
+The |--query| flag passes a query in the backend's own language
+straight through to its list calls for more complicated filtering.
+Rclone's filters are still applied to the results. It is an error to
+use it with a backend which doesn't support it.
+
+- Google Drive: a [search query](https://developers.google.com/drive/api/guides/ref-search-terms),
+  e.g. |--query "starred = true"|. Add |or mimeType = 'application/vnd.google-apps.folder'|
+  to it to recurse into directories which don't match.
+- OneDrive: an OData |$filter| for the directory listing. Which
+  filters are supported depends on the account type.
+- S3: |prefix=STRING| to only list names starting with |STRING| and
+  |start-after=NAME| to only list names after |NAME| in each directory
+  listed, joined with |&|, e.g. |--query "prefix=2024&start-after=2024-06"|.
+`, "|", "`")
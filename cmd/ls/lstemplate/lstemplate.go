@@ -0,0 +1,327 @@
+// Package lstemplate provides Go template output for the list commands.
+package lstemplate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/lib/transform"
+	"github.com/spf13/pflag"
+)
+
+// Options controls the template output
+type Options struct {
+	Template     string // template text
+	TemplateFile string // file to read the template text from
+}
+
+// AddFlags adds the template flags to the flagSet
+func AddFlags(flagSet *pflag.FlagSet, opt *Options) {
+	flags.StringVarP(flagSet, &opt.Template, "template", "", "", "Format each entry with this Go template - see help for details", "")
+	flags.StringVarP(flagSet, &opt.TemplateFile, "template-file", "", "", "Read the Go template from this file", "")
+}
+
+// IsSet returns true if a template was supplied
+func (opt *Options) IsSet() bool {
+	return opt.Template != "" || opt.TemplateFile != ""
+}
+
+// Help describes the template flags for the list commands
+// Warning! "|" will be replaced by backticks below
+var Help = strings.ReplaceAll(`### Templates
+
+Use |--template| to format each entry with a [Go template](https://pkg.go.dev/text/template)
+instead of the normal output, or |--template-file| to read the
+template from a file. A newline is added after each entry unless the
+output is empty or already ends in one, so |{{if}}| can be used to
+leave entries out.
+
+The template is executed with these fields for each entry:
+
+- |.Path| - path relative to the listed directory
+- |.Name| - leaf name
+- |.Size| - size in bytes (-1 for directories where unknown)
+- |.ModTime| - modification time as a Go |time.Time|
+- |.IsDir| - true for directories
+- |.IsBucket| - true for buckets
+- |.MimeType| - mime type if known
+- |.ID| and |.OrigID| - ID of the entry and of the underlying object
+- |.Tier| - storage tier if known
+- |.Hashes| - map of hash name to hash, e.g. |{{index .Hashes "md5"}}|
+- |.Metadata| - map of [metadata](/docs/#metadata), e.g. |{{index .Metadata "content-type"}}|
+- |.Encrypted| and |.EncryptedPath| - encrypted names on crypt remotes
+- |.Depth| - number of directories above the entry
+- |.Prefix| - the tree drawing before the name (|tree| only)
+
+Hashes, metadata, mime types, modification times, encrypted names and
+original IDs are only read from the remote if the template refers to
+them, as they can take extra transactions.
+
+As well as the standard template functions (including |html| and
+|urlquery| for escaping) these are available:
+
+- |size| - size in human readable form, e.g. |{{size .Size}}|
+- |time| - format a time, e.g. |{{time "RFC3339" .ModTime}}|, taking the same formats as |lsf --time-format|
+- |json| - encode the value as JSON
+- |csv| - join the arguments as a CSV record, e.g. |{{csv .Path .Size}}|
+- |base|, |dir| and |ext| - parts of a path
+
+If the template defines templates called |header| or |footer| these
+are output before and after the entries. These are passed |.Remote|,
+the remote being listed, and in the footer |.Dirs|, |.Files| and
+|.Size|, the totals of the entries output.
+
+For example to make a CSV inventory with MD5 hashes:
+
+|||console
+rclone lsf -R --files-only --template '{{define "header"}}path,size,md5{{end}}{{csv .Path .Size (index .Hashes "md5")}}' remote:path
+|||
+
+Or a simple HTML index:
+
+|||console
+rclone lsf --template '{{define "header"}}<ul>{{end}}<li><a href="{{urlquery .Path}}">{{html .Name}}</a> {{size .Size}}</li>{{define "footer"}}</ul>{{end}}' remote:path
+|||
+`, "|", "`")
+
+// Item is the data passed to the template for each entry
+type Item struct {
+	*operations.ListJSONItem
+	ModTime time.Time // the modification time as a time.Time
+	Depth   int       // number of directories above the entry
+	Prefix  string    // tree drawing before the name, only set by tree
+}
+
+// NewItem makes an Item from a list entry
+func NewItem(item *operations.ListJSONItem) *Item {
+	return &Item{
+		ListJSONItem: item,
+		ModTime:      item.ModTime.When,
+		Depth:        strings.Count(item.Path, "/"),
+	}
+}
+
+// Summary is the data passed to the header and footer templates
+type Summary struct {
+	Remote string // the remote being listed
+	Dirs   int    // number of directories output
+	Files  int    // number of files output
+	Size   int64  // total size of the files output
+}
+
+// Template formats list entries with a Go template
+type Template struct {
+	tmpl    *template.Template
+	fields  map[string]struct{}
+	summary Summary
+}
+
+// funcs are the extra functions available to templates
+var funcs = template.FuncMap{
+	"size": func(size int64) string {
+		if size < 0 {
+			return "-"
+		}
+		return fs.SizeSuffix(size).ByteUnit()
+	},
+	"time": func(format string, t time.Time) string {
+		switch format {
+		case "unix":
+			return fmt.Sprint(t.Unix())
+		case "unixnano":
+			return fmt.Sprint(t.UnixNano())
+		}
+		return t.Local().Format(transform.TimeFormat(format))
+	},
+	"json": func(v any) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+	"csv": func(args ...any) (string, error) {
+		record := make([]string, len(args))
+		for i, arg := range args {
+			record[i] = fmt.Sprint(arg)
+		}
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		_ = w.Write(record)
+		w.Flush()
+		return strings.TrimSuffix(buf.String(), "\n"), w.Error()
+	},
+	"base": path.Base,
+	"dir":  path.Dir,
+	"ext":  path.Ext,
+}
+
+// New parses the template from opt for listing remote
+func New(opt *Options, remote string) (*Template, error) {
+	text := opt.Template
+	if opt.TemplateFile != "" {
+		if text != "" {
+			return nil, errors.New("can't use --template and --template-file together")
+		}
+		data, err := os.ReadFile(opt.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file: %w", err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("entry").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	t := &Template{
+		tmpl:    tmpl,
+		fields:  map[string]struct{}{},
+		summary: Summary{Remote: remote},
+	}
+	for _, tmpl := range tmpl.Templates() {
+		if tmpl.Tree != nil {
+			t.findFields(tmpl.Tree.Root)
+		}
+	}
+	return t, nil
+}
+
+// findFields records the names of the top level fields used by node
+func (t *Template) findFields(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, node := range n.Nodes {
+			t.findFields(node)
+		}
+	case *parse.ActionNode:
+		t.findFields(n.Pipe)
+	case *parse.IfNode:
+		t.findBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		t.findBranch(&n.BranchNode)
+	case *parse.WithNode:
+		t.findBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		t.findFields(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			t.findFields(cmd)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			t.findFields(arg)
+		}
+	case *parse.ChainNode:
+		t.findFields(n.Node)
+	case *parse.FieldNode:
+		t.fields[n.Ident[0]] = struct{}{}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 {
+			t.fields[n.Ident[1]] = struct{}{}
+		}
+	}
+}
+
+// findBranch records the fields used by an if, range or with
+func (t *Template) findBranch(n *parse.BranchNode) {
+	t.findFields(n.Pipe)
+	t.findFields(n.List)
+	t.findFields(n.ElseList)
+}
+
+// Uses returns true if the template refers to the field name
+func (t *Template) Uses(name string) bool {
+	_, found := t.fields[name]
+	return found
+}
+
+// SetListOpt turns on the parts of opt needed to fill in the fields
+// the template uses
+func (t *Template) SetListOpt(opt *operations.ListJSONOpt) {
+	if t.Uses("ModTime") {
+		opt.NoModTime = false
+	}
+	if t.Uses("MimeType") {
+		opt.NoMimeType = false
+	}
+	if t.Uses("Hashes") {
+		opt.ShowHash = true
+	}
+	if t.Uses("Metadata") {
+		opt.Metadata = true
+	}
+	if t.Uses("Encrypted") || t.Uses("EncryptedPath") {
+		opt.ShowEncrypted = true
+	}
+	if t.Uses("OrigID") {
+		opt.ShowOrigIDs = true
+	}
+}
+
+// execute runs the named template with data writing it to out
+// followed by a newline if needed.
+func (t *Template) execute(out io.Writer, name string, data any) error {
+	var buf bytes.Buffer
+	var err error
+	if name == "" {
+		err = t.tmpl.Execute(&buf, data)
+	} else {
+		err = t.tmpl.ExecuteTemplate(&buf, name, data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err = out.Write(buf.Bytes())
+	return err
+}
+
+// Header outputs the header template if defined
+func (t *Template) Header(out io.Writer) error {
+	if t.tmpl.Lookup("header") == nil {
+		return nil
+	}
+	return t.execute(out, "header", t.summary)
+}
+
+// Execute outputs item with the template
+func (t *Template) Execute(out io.Writer, item *Item) error {
+	if item.IsDir {
+		t.summary.Dirs++
+	} else {
+		t.summary.Files++
+		if item.Size > 0 {
+			t.summary.Size += item.Size
+		}
+	}
+	return t.execute(out, "", item)
+}
+
+// Footer outputs the footer template if defined
+func (t *Template) Footer(out io.Writer) error {
+	if t.tmpl.Lookup("footer") == nil {
+		return nil
+	}
+	return t.execute(out, "footer", t.summary)
+}
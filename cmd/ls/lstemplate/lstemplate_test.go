@@ -0,0 +1,61 @@
+package lstemplate
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetListOpt(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		want operations.ListJSONOpt
+	}{
+		{text: `{{.Path}}`, want: operations.ListJSONOpt{NoModTime: true, NoMimeType: true}},
+		{text: `{{.ModTime}}`, want: operations.ListJSONOpt{NoMimeType: true}},
+		{text: `{{if .IsDir}}{{else}}{{index .Hashes "md5"}}{{end}}`, want: operations.ListJSONOpt{NoModTime: true, NoMimeType: true, ShowHash: true}},
+		{text: `{{range $k, $v := $.Metadata}}{{$k}}{{end}}`, want: operations.ListJSONOpt{NoModTime: true, NoMimeType: true, Metadata: true}},
+		{text: `{{define "footer"}}{{.MimeType}}{{end}}{{with .OrigID}}{{.}}{{end}}`, want: operations.ListJSONOpt{NoModTime: true, ShowOrigIDs: true}},
+		{text: `{{html .EncryptedPath}}`, want: operations.ListJSONOpt{NoModTime: true, NoMimeType: true, ShowEncrypted: true}},
+	} {
+		tmpl, err := New(&Options{Template: test.text}, "remote:")
+		require.NoError(t, err, test.text)
+		opt := operations.ListJSONOpt{NoModTime: true, NoMimeType: true}
+		tmpl.SetListOpt(&opt)
+		assert.Equal(t, test.want, opt, test.text)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	tmpl, err := New(&Options{
+		Template: `{{define "header"}}{{.Remote}}{{end}}` +
+			`{{if not .IsDir}}{{csv .Path (size .Size) (time "RFC3339" .ModTime) (ext .Name) .Depth}}{{end}}` +
+			`{{define "footer"}}{{.Dirs}},{{.Files}},{{.Size}}{{end}}`,
+	}, "remote:path")
+	require.NoError(t, err)
+
+	modTime := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Header(&buf))
+	for _, item := range []*operations.ListJSONItem{
+		{Path: "dir", Name: "dir", Size: -1, IsDir: true},
+		{Path: "dir/a, b.txt", Name: "a, b.txt", Size: 2048, ModTime: operations.Timestamp{When: modTime}},
+	} {
+		require.NoError(t, tmpl.Execute(&buf, NewItem(item)))
+	}
+	require.NoError(t, tmpl.Footer(&buf))
+	assert.Equal(t, "remote:path\n\"dir/a, b.txt\",2 KiB,"+modTime.Local().Format(time.RFC3339)+",.txt,1\n1,1,2048\n", buf.String())
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New(&Options{Template: "{{.Path"}, "")
+	assert.ErrorContains(t, err, "failed to parse template")
+	_, err = New(&Options{Template: "{{.Path}}", TemplateFile: "file"}, "")
+	assert.ErrorContains(t, err, "can't use --template and --template-file together")
+	_, err = New(&Options{TemplateFile: "/this/does/not/exist"}, "")
+	assert.ErrorContains(t, err, "failed to read template file")
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/rclone/rclone/cmd"
 	"github.com/rclone/rclone/cmd/ls/lshelp"
+	"github.com/rclone/rclone/cmd/ls/lstemplate"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/hash"
@@ -27,6 +28,8 @@ var (
 	dirsOnly   bool
 	csv        bool
 	absolute   bool
+	query      string
+	tmplOpt    lstemplate.Options
 )
 
 func init() {
@@ -42,6 +45,8 @@ func init() {
 	flags.BoolVarP(cmdFlags, &csv, "csv", "", false, "Output in CSV format", "")
 	flags.BoolVarP(cmdFlags, &absolute, "absolute", "", false, "Put a leading / in front of path names", "")
 	flags.BoolVarP(cmdFlags, &recurse, "recursive", "R", false, "Recurse into the listing", "")
+	flags.StringVarP(cmdFlags, &query, "query", "", "", "Backend specific query to constrain the listing with", "")
+	lstemplate.AddFlags(cmdFlags, &tmplOpt)
 }
 
 var commandDefinition = &cobra.Command{
@@ -176,6 +181,11 @@ rclone lsf remote:path --format pt --time-format unixnano
 ` + "`--time-format max`" + ` will automatically truncate ` + "`2006-01-02 15:04:05.000000000`" + `
 to the maximum precision supported by the remote.
 
+Use ` + "`--template`" + ` instead of ` + "`--format`" + ` for complete control over
+the output, see below.
+
+` + lstemplate.Help + `
+` + lshelp.QueryHelp + `
 ` + lshelp.Help,
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.40",
@@ -211,6 +221,11 @@ func Lsf(ctx context.Context, fsrc fs.Fs, out io.Writer) error {
 		DirsOnly:   dirsOnly,
 		FilesOnly:  filesOnly,
 		Recurse:    recurse,
+		Query:      query,
+	}
+
+	if tmplOpt.IsSet() {
+		return lsfTemplate(ctx, fsrc, out, &opt)
 	}
 
 	for _, char := range format {
@@ -259,3 +274,25 @@ func Lsf(ctx context.Context, fsrc fs.Fs, out io.Writer) error {
 		return nil
 	})
 }
+
+// lsfTemplate lists fsrc to out using the --template
+func lsfTemplate(ctx context.Context, fsrc fs.Fs, out io.Writer, opt *operations.ListJSONOpt) error {
+	tmpl, err := lstemplate.New(&tmplOpt, fs.ConfigString(fsrc))
+	if err != nil {
+		return err
+	}
+	tmpl.SetListOpt(opt)
+	if err := tmpl.Header(out); err != nil {
+		return err
+	}
+	err = operations.ListJSON(ctx, fsrc, "", opt, func(item *operations.ListJSONItem) error {
+		if item.IsDir {
+			item.Size = -1
+		}
+		return tmpl.Execute(out, lstemplate.NewItem(item))
+	})
+	if err != nil {
+		return err
+	}
+	return tmpl.Footer(out)
+}
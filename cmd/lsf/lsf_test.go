@@ -304,3 +304,29 @@ subdir/file3_+_111_+_`+expectedOutput[6]+`
 	recurse = false
 	dirSlash = false
 }
+
+func TestTemplate(t *testing.T) {
+	fstest.Initialise()
+	f, err := fs.NewFs(context.Background(), "testfiles")
+	require.NoError(t, err)
+	recurse = true
+	tmplOpt.Template = `{{define "header"}}path,size,md5{{end}}` +
+		`{{if not .IsDir}}{{csv .Path .Size (index .Hashes "md5")}}{{end}}` +
+		`{{define "footer"}}{{.Files}} files, {{.Size}} bytes{{end}}`
+
+	buf := new(bytes.Buffer)
+	err = Lsf(context.Background(), f, buf)
+	require.NoError(t, err)
+	assert.Equal(t, `path,size,md5
+file1,0,d41d8cd98f00b204e9800998ecf8427e
+file2,321,409d6c19451dd39d4a94e42d2ff2c834
+file3,1234,9b4c8a5e36d3be7e2c4b1d75ded8c8a1
+subdir/file1,0,d41d8cd98f00b204e9800998ecf8427e
+subdir/file2,1,93b885adfe0da089cdf634904fd59f71
+subdir/file3,111,03bc63b77bec853adcb65719a21459ce
+6 files, 1667 bytes
+`, buf.String())
+
+	tmplOpt.Template = ""
+	recurse = false
+}
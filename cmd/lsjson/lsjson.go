@@ -9,6 +9,7 @@ import (
 
 	"github.com/rclone/rclone/cmd"
 	"github.com/rclone/rclone/cmd/ls/lshelp"
+	"github.com/rclone/rclone/cmd/ls/lstemplate"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/operations"
@@ -18,6 +19,7 @@ import (
 var (
 	opt      operations.ListJSONOpt
 	statOnly bool
+	tmplOpt  lstemplate.Options
 )
 
 func init() {
@@ -34,6 +36,8 @@ func init() {
 	flags.BoolVarP(cmdFlags, &opt.Metadata, "metadata", "M", false, "Add metadata to the listing", "")
 	flags.StringArrayVarP(cmdFlags, &opt.HashTypes, "hash-type", "", nil, "Show only this hash type (may be repeated)", "")
 	flags.BoolVarP(cmdFlags, &statOnly, "stat", "", false, "Just return the info for the pointed to file", "")
+	flags.StringVarP(cmdFlags, &opt.Query, "query", "", "", "Backend specific query to constrain the listing with", "")
+	lstemplate.AddFlags(cmdFlags, &tmplOpt)
 }
 
 var commandDefinition = &cobra.Command{
@@ -121,19 +125,35 @@ The whole output can be processed as a JSON blob, or alternatively it
 can be processed line by line as each item is written on individual lines
 (except with ` + "`--stat`" + `).
 
+If ` + "`--template`" + ` is set then each item is output with the template
+instead of as JSON, and the Hashes and Metadata properties are read if
+the template uses them. With ` + "`--stat`" + ` only the pointed to item is output.
+
+` + lstemplate.Help + `
+` + lshelp.QueryHelp + `
 ` + lshelp.Help,
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.37",
 		"groups":            "Filter,Listing",
 	},
 	RunE: func(command *cobra.Command, args []string) error {
+		cmd.CheckArgs(1, 1, command, args)
+		var tmpl *lstemplate.Template
+		if tmplOpt.IsSet() {
+			var err error
+			tmpl, err = lstemplate.New(&tmplOpt, args[0])
+			if err != nil {
+				return err
+			}
+			tmpl.SetListOpt(&opt)
+		}
+
 		// Make sure we set the global Metadata flag too as it
 		// isn't parsed by cobra. We need to do this first
 		// before any backends are created.
 		ci := fs.GetConfig(context.Background())
 		ci.Metadata = opt.Metadata
 
-		cmd.CheckArgs(1, 1, command, args)
 		var fsrc fs.Fs
 		var remote string
 		if statOnly {
@@ -142,6 +162,9 @@ can be processed line by line as each item is written on individual lines
 			fsrc = cmd.NewFsSrc(args)
 		}
 		cmd.Run(false, false, command, func() error {
+			if tmpl != nil {
+				return lsTemplate(context.Background(), tmpl, fsrc, remote)
+			}
 			if statOnly {
 				item, err := operations.StatJSON(context.Background(), fsrc, remote, &opt)
 				if err != nil {
@@ -188,3 +211,29 @@ can be processed line by line as each item is written on individual lines
 		return nil
 	},
 }
+
+// lsTemplate outputs the listing of fsrc with tmpl
+func lsTemplate(ctx context.Context, tmpl *lstemplate.Template, fsrc fs.Fs, remote string) error {
+	if err := tmpl.Header(os.Stdout); err != nil {
+		return err
+	}
+	if statOnly {
+		item, err := operations.StatJSON(ctx, fsrc, remote, &opt)
+		if err != nil {
+			return err
+		}
+		if item != nil {
+			if err := tmpl.Execute(os.Stdout, lstemplate.NewItem(item)); err != nil {
+				return err
+			}
+		}
+	} else {
+		err := operations.ListJSON(ctx, fsrc, remote, &opt, func(item *operations.ListJSONItem) error {
+			return tmpl.Execute(os.Stdout, lstemplate.NewItem(item))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tmpl.Footer(os.Stdout)
+}
@@ -35,6 +35,9 @@ func (d *Dir) Attr(ctx context.Context, a *fuse.Attr) (err error) {
 	a.Gid = d.VFS().Opt.GID
 	a.Uid = d.VFS().Opt.UID
 	a.Mode = d.Mode()
+	if d.VFS().Opt.StableInodes {
+		a.Inode = d.Inode()
+	}
 	modTime := d.ModTime()
 	a.Atime = modTime
 	a.Mtime = modTime
@@ -127,6 +130,9 @@ func (d *Dir) ReadDirAll(ctx context.Context) (dirents []fuse.Dirent, err error)
 			Type: fuse.DT_File,
 			Name: name,
 		}
+		if d.VFS().Opt.StableInodes {
+			dirent.Inode = node.Inode()
+		}
 		if node.IsDir() {
 			dirent.Type = fuse.DT_Dir
 		}
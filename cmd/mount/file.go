@@ -33,6 +33,9 @@ func (f *File) Attr(ctx context.Context, a *fuse.Attr) (err error) {
 	a.Gid = f.VFS().Opt.GID
 	a.Uid = f.VFS().Opt.UID
 	a.Mode = f.File.Mode() &^ os.ModeAppend
+	if f.VFS().Opt.StableInodes {
+		a.Inode = f.File.Inode()
+	}
 	a.Size = Size
 	a.Atime = modTime
 	a.Mtime = modTime
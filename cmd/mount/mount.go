@@ -33,6 +33,13 @@ func mountOptions(VFS *vfs.VFS, device string, opt *mountlib.Options) (options [
 	if opt.AsyncRead {
 		options = append(options, fuse.AsyncRead())
 	}
+	// MaxBackground has been checked to fit in a uint16 by mountlib
+	if opt.MaxBackground > 0 {
+		options = append(options,
+			fuse.MaxBackground(uint16(opt.MaxBackground)),
+			fuse.CongestionThreshold(uint16(opt.MaxBackground*3/4)),
+		)
+	}
 	if opt.AllowOther {
 		options = append(options, fuse.AllowOther())
 	}
@@ -135,6 +135,8 @@ func translateError(err error) syscall.Errno {
 		return syscall.EINVAL
 	case vfs.ELOOP:
 		return syscall.ELOOP
+	case vfs.ENOSPC:
+		return syscall.ENOSPC
 	}
 	fs.Errorf(nil, "IO error: %v", err)
 	return syscall.EIO
@@ -4,11 +4,14 @@ package mountlib
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rclone/rclone/cmd"
@@ -103,6 +106,11 @@ var OptionsInfo = fs.Options{{
 	Default: fs.SizeSuffix(128 * 1024),
 	Help:    "The number of bytes that can be prefetched for sequential reads (not supported on Windows)",
 	Groups:  "Mount",
+}, {
+	Name:    "max_background",
+	Default: 0,
+	Help:    "Max number of FUSE requests the kernel sends in the background, 0 for the default, max 65535 (mount and mount2 on Linux only)",
+	Groups:  "Mount",
 }, {
 	Name:    "write_back_cache",
 	Default: false,
@@ -160,6 +168,31 @@ var OptionsInfo = fs.Options{{
 	}(),
 	Help:   "Time to wait for ready mount from daemon (maximum time on Linux, constant sleep time on OSX/BSD) (not supported on Windows)",
 	Groups: "Mount",
+}, {
+	Name:    "supervise",
+	Default: false,
+	Help:    "Check the health of the mount and remount it if it fails",
+	Groups:  "Mount",
+}, {
+	Name:    "supervise_interval",
+	Default: fs.Duration(30 * time.Second),
+	Help:    "Time between mount health checks when using --supervise",
+	Groups:  "Mount",
+}, {
+	Name:    "supervise_timeout",
+	Default: fs.Duration(10 * time.Second),
+	Help:    "Time to wait for a mount health check before counting it as failed",
+	Groups:  "Mount",
+}, {
+	Name:    "mount_name",
+	Default: "",
+	Help:    "Name of the mount, adds mount/NAME/vfs/* rc calls to control its VFS",
+	Groups:  "Mount",
+}, {
+	Name:    "mount_at",
+	Default: fs.Time{},
+	Help:    "Mount a read-only snapshot of a versioned remote as it was at this time",
+	Groups:  "Mount",
 }}
 
 func init() {
@@ -177,6 +210,7 @@ type Options struct {
 	Daemon             bool          `config:"daemon"`
 	DaemonWait         fs.Duration   `config:"daemon_wait"` // time to wait for ready mount from daemon, maximum on Linux or constant on macOS/BSD
 	MaxReadAhead       fs.SizeSuffix `config:"max_read_ahead"`
+	MaxBackground      int           `config:"max_background"`
 	ExtraOptions       []string      `config:"option"`
 	ExtraFlags         []string      `config:"fuse_flag"`
 	AttrTimeout        fs.Duration   `config:"attr_timeout"` // how long the kernel caches attribute for
@@ -189,6 +223,11 @@ type Options struct {
 	NetworkMode        bool          `config:"network_mode"` // Windows only
 	DirectIO           bool          `config:"direct_io"`    // use Direct IO for file access
 	CaseInsensitive    fs.Tristate   `config:"mount_case_insensitive"`
+	Supervise          bool          `config:"supervise"`          // check the mount health and remount on failure
+	SuperviseInterval  fs.Duration   `config:"supervise_interval"` // time between health checks
+	SuperviseTimeout   fs.Duration   `config:"supervise_timeout"`  // time to wait for a health check
+	MountName          string        `config:"mount_name"`         // name for the mount/NAME/vfs/* rc calls
+	MountAt            fs.Time       `config:"mount_at"`           // show the remote as it was at this time
 }
 
 type (
@@ -209,6 +248,11 @@ type MountPoint struct {
 	MountFn    MountFn
 	UnmountFn  UnmountFn
 	ErrChan    <-chan error
+
+	mu         sync.Mutex  // protects ErrChan, UnmountFn and health while supervised
+	health     MountHealth // health of the mount
+	unmounting atomic.Bool // set when rclone is unmounting the mount
+	removeRc   func()      // removes the mount/NAME/vfs/* rc calls if set
 }
 
 // NewMountPoint makes a new mounting structure
@@ -300,6 +344,15 @@ func NewMountCommand(commandName string, hidden bool, mount MountFn) *cobra.Comm
 				defer cmd.StartStats()()
 			}
 
+			if Opt.MountAt.IsSet() {
+				var err error
+				args[0], err = SnapshotPath(args[0], Opt.MountAt)
+				if err != nil {
+					fs.Fatalf(nil, "Fatal error: %v", err)
+				}
+				vfscommon.Opt.ReadOnly = true
+			}
+
 			mnt := NewMountPoint(mount, args[1], cmd.NewFsDir(args), &Opt, &vfscommon.Opt)
 			mountDaemon, err := mnt.Mount()
 
@@ -307,6 +360,7 @@ func NewMountCommand(commandName string, hidden bool, mount MountFn) *cobra.Comm
 			if mountDaemon == nil {
 				if err == nil {
 					defer systemd.Notify()()
+					addLiveMount(mnt)
 					err = mnt.Wait()
 				}
 				if err != nil {
@@ -356,6 +410,12 @@ func NewMountCommand(commandName string, hidden bool, mount MountFn) *cobra.Comm
 
 // Mount the remote at mountpoint
 func (m *MountPoint) Mount() (mountDaemon *os.Process, err error) {
+	if m.MountOpt.Supervise && (m.MountOpt.SuperviseInterval <= 0 || m.MountOpt.SuperviseTimeout <= 0) {
+		return nil, errors.New("--supervise-interval and --supervise-timeout must be greater than 0")
+	}
+	if m.MountOpt.MaxBackground < 0 || m.MountOpt.MaxBackground > math.MaxUint16 {
+		return nil, fmt.Errorf("--max-background must be between 0 and %d", math.MaxUint16)
+	}
 
 	// Ensure sensible defaults
 	m.SetVolumeName(m.MountOpt.VolumeName)
@@ -403,7 +463,12 @@ func (m *MountPoint) Wait() error {
 	fnHandle := atexit.Register(finalise)
 	defer atexit.Unregister(fnHandle)
 
-	err := <-m.ErrChan
+	var err error
+	if m.MountOpt.Supervise {
+		err = m.supervise()
+	} else {
+		err = <-m.ErrChan
+	}
 
 	finalise()
 
@@ -415,5 +480,9 @@ func (m *MountPoint) Wait() error {
 
 // Unmount the specified mountpoint
 func (m *MountPoint) Unmount() (err error) {
-	return m.UnmountFn()
+	m.unmounting.Store(true)
+	m.mu.Lock()
+	unmountFn := m.UnmountFn
+	m.mu.Unlock()
+	return unmountFn()
 }
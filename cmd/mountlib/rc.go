@@ -3,12 +3,16 @@ package mountlib
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/vfs"
 	"github.com/rclone/rclone/vfs/vfscommon"
 )
 
@@ -36,6 +40,62 @@ func ResolveMountMethod(mountType string) (string, MountFn) {
 	return "", nil
 }
 
+// addLiveMount adds a mount made on the command line to the live
+// mounts so it can be seen and controlled over the rc.
+func addLiveMount(mnt *MountPoint) {
+	mountMu.Lock()
+	defer mountMu.Unlock()
+	if err := checkMountName(mnt.MountOpt.MountName); err != nil {
+		fs.Errorf(mnt.MountPoint, "Not adding rc calls for mount: %v", err)
+		mnt.MountOpt.MountName = ""
+	}
+	addLiveMountLocked(mnt)
+}
+
+// checkMountName returns an error if name can't be used for a new
+// mount. An empty name is always OK.
+//
+// Call with mountMu held.
+func checkMountName(name string) error {
+	if name == "" {
+		return nil
+	}
+	if strings.Contains(name, "/") {
+		return fmt.Errorf("mount name %q can't contain /", name)
+	}
+	for _, m := range liveMounts {
+		if m.MountOpt.MountName == name {
+			return fmt.Errorf("mount name %q is already in use by %q", name, m.MountPoint)
+		}
+	}
+	return nil
+}
+
+// addLiveMountLocked adds mnt to the live mounts and adds the
+// mount/NAME/vfs/* rc calls if it has a name.
+//
+// Call with mountMu held.
+func addLiveMountLocked(mnt *MountPoint) {
+	liveMounts[mnt.MountPoint] = mnt
+	if name := mnt.MountOpt.MountName; name != "" {
+		mnt.removeRc = vfs.AddRcPrefix("mount/"+name, func() *vfs.VFS {
+			return mnt.VFS
+		})
+	}
+}
+
+// removeLiveMountLocked removes the mount at mountPoint from the live
+// mounts along with its rc calls.
+//
+// Call with mountMu held.
+func removeLiveMountLocked(mountPoint string) {
+	if mnt := liveMounts[mountPoint]; mnt != nil && mnt.removeRc != nil {
+		mnt.removeRc()
+		mnt.removeRc = nil
+	}
+	delete(liveMounts, mountPoint)
+}
+
 // AddRc adds mount and unmount functionality to rc
 func AddRc(mountUtilName string, mountFunction MountFn) {
 	mountMu.Lock()
@@ -62,6 +122,16 @@ This takes the following parameters:
 - mountType: one of the values (mount, cmount, mount2) specifies the mount implementation to use
 - mountOpt: a JSON object with Mount options in.
 - vfsOpt: a JSON object with VFS options in.
+- name: a name for the mount (optional)
+
+If a name is given then the vfs/* calls for the mount's VFS are
+available as mount/NAME/vfs/*, for example mount/NAME/vfs/refresh.
+These don't need the "fs" parameter so are useful when there is more
+than one mount. The name can also be set with the --mount-name flag
+or MountName in mountOpt.
+
+If MountAt is set in mountOpt then a read-only snapshot of fs as it
+was at that time is mounted, as with the --mount-at flag.
 
 Example:
 
@@ -104,6 +174,13 @@ func mountRc(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 		return nil, errors.New("daemon option not supported over the API")
 	}
 
+	name, err := in.GetString("name")
+	if err == nil {
+		mountOpt.MountName = name
+	} else if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+
 	mountType, err := in.GetString("mountType")
 
 	mountMu.Lock()
@@ -116,6 +193,23 @@ func mountRc(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	if mountFn == nil {
 		return nil, errors.New("mount option specified is not registered, or is invalid")
 	}
+	if err = checkMountName(mountOpt.MountName); err != nil {
+		return nil, err
+	}
+
+	if mountOpt.MountAt.IsSet() {
+		fsString, err := in.GetString("fs")
+		if err != nil {
+			return nil, err
+		}
+		fsString, err = SnapshotPath(fsString, mountOpt.MountAt)
+		if err != nil {
+			return nil, err
+		}
+		in = maps.Clone(in)
+		in["fs"] = fsString
+		vfsOpt.ReadOnly = true
+	}
 
 	// Get Fs.fs to be mounted from fs parameter in the params
 	fdst, err := rc.GetFs(ctx, in)
@@ -136,10 +230,10 @@ func mountRc(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 		}
 		mountMu.Lock()
 		defer mountMu.Unlock()
-		delete(liveMounts, mountPoint)
+		removeLiveMountLocked(mountPoint)
 	}()
 	// Add mount to list if mount point was successfully created
-	liveMounts[mountPoint] = mnt
+	addLiveMountLocked(mnt)
 
 	fs.Debugf(nil, "Mount for %s created at %s using %s", fdst.String(), mountPoint, mountType)
 	return nil, nil
@@ -182,7 +276,7 @@ func unMountRc(_ context.Context, in rc.Params) (out rc.Params, err error) {
 	if err = mountInfo.Unmount(); err != nil {
 		return nil, err
 	}
-	delete(liveMounts, mountPoint)
+	removeLiveMountLocked(mountPoint)
 	return nil, nil
 }
 
@@ -246,6 +340,7 @@ type MountInfo struct {
 	Fs         string    `json:"Fs"`
 	MountPoint string    `json:"MountPoint"`
 	MountedOn  time.Time `json:"MountedOn"`
+	Name       string    `json:"Name,omitempty"`
 }
 
 // listMountsRc returns a list of current mounts sorted by mount path
@@ -260,11 +355,14 @@ func listMountsRc(_ context.Context, in rc.Params) (out rc.Params, err error) {
 	mountPoints := []MountInfo{}
 	for _, k := range keys {
 		m := liveMounts[k]
+		m.mu.Lock()
 		info := MountInfo{
 			Fs:         fs.ConfigString(m.Fs),
 			MountPoint: m.MountPoint,
 			MountedOn:  m.MountedOn,
+			Name:       m.MountOpt.MountName,
 		}
+		m.mu.Unlock()
 		mountPoints = append(mountPoints, info)
 	}
 	return rc.Params{
@@ -272,6 +370,167 @@ func listMountsRc(_ context.Context, in rc.Params) (out rc.Params, err error) {
 	}, nil
 }
 
+func init() {
+	rc.Add(rc.Call{
+		Path:         "mount/list",
+		AuthRequired: true,
+		Fn:           listRc,
+		Title:        "Show current mount points with their VFS stats",
+		Help: `This shows the current mount points with live stats for each one.
+
+This takes no parameters and returns
+
+- mounts: list of mount points
+
+Each entry has the same keys as mount/listmounts with a "Stats" key
+containing the stats for the mount's VFS as returned by vfs/stats.
+
+Eg
+
+    rclone rc mount/list
+`,
+	})
+}
+
+// MountStatsInfo is a transitional structure for json marshaling
+type MountStatsInfo struct {
+	MountInfo
+	Stats rc.Params `json:"Stats"`
+}
+
+// listRc returns the current mounts with their stats sorted by mount path
+func listRc(_ context.Context, in rc.Params) (out rc.Params, err error) {
+	mountMu.Lock()
+	var mnts []*MountPoint
+	for _, m := range liveMounts {
+		mnts = append(mnts, m)
+	}
+	mountMu.Unlock()
+	sort.Slice(mnts, func(i, j int) bool {
+		return mnts[i].MountPoint < mnts[j].MountPoint
+	})
+	mounts := []MountStatsInfo{}
+	for _, m := range mnts {
+		m.mu.Lock()
+		mountedOn := m.MountedOn
+		m.mu.Unlock()
+		info := MountStatsInfo{
+			MountInfo: MountInfo{
+				Fs:         fs.ConfigString(m.Fs),
+				MountPoint: m.MountPoint,
+				MountedOn:  mountedOn,
+				Name:       m.MountOpt.MountName,
+			},
+		}
+		if m.VFS != nil {
+			info.Stats = m.VFS.Stats()
+		}
+		mounts = append(mounts, info)
+	}
+	return rc.Params{
+		"mounts": mounts,
+	}, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "mount/health",
+		AuthRequired: true,
+		Fn:           mountHealthRc,
+		Title:        "Show the health of the current mount points",
+		Help: `This shows the health of the current mount points.
+
+Mounts started with the --supervise flag (or with "Supervise": true in
+mountOpt) are checked every --supervise-interval and remounted if they
+fail. For these the result of the last check is returned. Other mounts
+are checked when this is called.
+
+This takes the following parameters:
+
+- mountPoint: only show the health of this mount point (optional)
+
+It returns
+
+- mounts: list of mount points with their health
+
+Each entry has the same keys as mount/listmounts with a "Health" key
+containing
+
+- Supervised: true if the mount is supervised
+- Healthy: true if the last health check passed
+- LastCheck: time of the last health check
+- LastError: the last error seen
+- Failures: number of consecutive failed health checks
+- Remounts: number of times the mount has been remounted
+- LastRemount: time the mount was last remounted
+
+Eg
+
+    rclone rc mount/health
+`,
+	})
+}
+
+// MountHealthInfo is a transitional structure for json marshaling
+type MountHealthInfo struct {
+	MountInfo
+	Health MountHealth `json:"Health"`
+}
+
+// mountHealthRc returns the health of the current mounts sorted by mount path
+func mountHealthRc(_ context.Context, in rc.Params) (out rc.Params, err error) {
+	mountPoint, err := in.GetString("mountPoint")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	mounts, err := ListHealth(mountPoint)
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{
+		"mounts": mounts,
+	}, nil
+}
+
+// ListHealth returns the health of the active mounts sorted by mount
+// point, or of the mount at mountPoint if it isn't empty.
+func ListHealth(mountPoint string) ([]MountHealthInfo, error) {
+	mountMu.Lock()
+	var mnts []*MountPoint
+	for key, m := range liveMounts {
+		if mountPoint == "" || key == mountPoint {
+			mnts = append(mnts, m)
+		}
+	}
+	mountMu.Unlock()
+	if mountPoint != "" && len(mnts) == 0 {
+		return nil, errors.New("mount not found")
+	}
+	sort.Slice(mnts, func(i, j int) bool {
+		return mnts[i].MountPoint < mnts[j].MountPoint
+	})
+	mounts := []MountHealthInfo{}
+	for _, m := range mnts {
+		// Check mounts which aren't supervised now
+		if !m.MountOpt.Supervise {
+			_ = m.CheckHealth()
+		}
+		m.mu.Lock()
+		mountedOn := m.MountedOn
+		m.mu.Unlock()
+		mounts = append(mounts, MountHealthInfo{
+			MountInfo: MountInfo{
+				Fs:         fs.ConfigString(m.Fs),
+				MountPoint: m.MountPoint,
+				MountedOn:  mountedOn,
+				Name:       m.MountOpt.MountName,
+			},
+			Health: m.Health(),
+		})
+	}
+	return mounts, nil
+}
+
 func init() {
 	rc.Add(rc.Call{
 		Path:         "mount/unmountall",
@@ -301,7 +560,7 @@ func unmountAll(_ context.Context, in rc.Params) (out rc.Params, err error) {
 			fs.Debugf(nil, "Couldn't unmount : %s", mountPoint)
 			return nil, err
 		}
-		delete(liveMounts, mountPoint)
+		removeLiveMountLocked(mountPoint)
 	}
 	return nil, nil
 }
@@ -70,6 +70,7 @@ func TestRc(t *testing.T) {
 		in := rc.Params{
 			"fs":         localDir,
 			"mountPoint": mountPoint,
+			"name":       "test",
 			"vfsOpt": rc.Params{
 				"FilePerms": 0400,
 			},
@@ -110,6 +111,24 @@ func TestRc(t *testing.T) {
 		mountPoints := checkMountList()
 		require.Equal(t, 1, len(mountPoints))
 		require.Equal(t, mountPoint, mountPoints[0].MountPoint)
+		require.Equal(t, "test", mountPoints[0].Name)
+
+		// check the named rc calls and stats
+		refresh := rc.Calls.Get("mount/test/vfs/refresh")
+		require.NotNil(t, refresh)
+		_, err = refresh.Fn(ctx, rc.Params{})
+		require.NoError(t, err)
+		listReply, err := rc.Calls.Get("mount/list").Fn(ctx, rc.Params{})
+		require.NoError(t, err)
+		mounts, ok := listReply["mounts"].([]mountlib.MountStatsInfo)
+		require.True(t, ok)
+		require.Equal(t, 1, len(mounts))
+		assert.Equal(t, "test", mounts[0].Name)
+		assert.NotNil(t, mounts[0].Stats["metadataCache"])
+
+		// a second mount can't use the same name
+		_, err = mount.Fn(ctx, rc.Params{"fs": localDir, "mountPoint": t.TempDir(), "name": "test"})
+		assert.ErrorContains(t, err, "already in use")
 
 		// FIXME the OS sometimes appears to be using the mount
 		// immediately after it appears so wait a moment
@@ -119,6 +138,7 @@ func TestRc(t *testing.T) {
 			_, err := unmount.Fn(ctx, in)
 			require.NoError(t, err)
 			assert.Equal(t, 0, len(checkMountList()))
+			assert.Nil(t, rc.Calls.Get("mount/test/vfs/refresh"))
 		})
 	})
 }
@@ -0,0 +1,34 @@
+package mountlib
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/fspath"
+)
+
+// SnapshotPath returns fsPath with the backend's version_at option
+// set to at so it shows the remote as it was at that time for
+// --mount-at.
+//
+// It returns an error if the backend doesn't support version_at.
+func SnapshotPath(fsPath string, at fs.Time) (string, error) {
+	fsInfo, _, _, _, err := fs.ParseRemote(fsPath)
+	if err != nil {
+		return "", err
+	}
+	if fsInfo.Options.Get("version_at") == nil {
+		return "", fmt.Errorf("--mount-at isn't supported by the %q backend as it can't show old versions of files", fsInfo.Name)
+	}
+	parsed, err := fspath.Parse(fsPath)
+	if err != nil {
+		return "", err
+	}
+	config := configmap.Simple{}
+	maps.Copy(config, parsed.Config)
+	config.Set("version_at", time.Time(at).UTC().Format(time.RFC3339Nano))
+	return parsed.Name + "," + config.String() + ":" + parsed.Path, nil
+}
@@ -0,0 +1,43 @@
+package mountlib_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/cmd/mountlib"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name: "snapshottest",
+		NewFs: func(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+			return nil, errors.New("not implemented")
+		},
+		Options: fs.Options{{
+			Name:    "version_at",
+			Default: fs.Time{},
+		}},
+	})
+}
+
+func TestSnapshotPath(t *testing.T) {
+	at := fs.Time(time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC))
+
+	got, err := mountlib.SnapshotPath(":snapshottest:bucket/dir", at)
+	require.NoError(t, err)
+	assert.Equal(t, ":snapshottest,version_at='2024-06-01T12:30:00Z':bucket/dir", got)
+
+	got, err = mountlib.SnapshotPath(":snapshottest,version_at=1d,other=x:", at)
+	require.NoError(t, err)
+	assert.Equal(t, ":snapshottest,other='x',version_at='2024-06-01T12:30:00Z':", got)
+
+	_, err = mountlib.SnapshotPath(t.TempDir(), at)
+	assert.ErrorContains(t, err, `"local" backend`)
+}
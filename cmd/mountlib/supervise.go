@@ -0,0 +1,190 @@
+package mountlib
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/diskusage"
+)
+
+// Number of consecutive failed health checks before remounting
+const superviseFailures = 2
+
+// MountHealth describes the health of a mount as seen by the
+// supervisor or the last health check
+type MountHealth struct {
+	Supervised  bool      `json:"Supervised"`  // set if the mount is supervised
+	Healthy     bool      `json:"Healthy"`     // result of the last health check
+	LastCheck   time.Time `json:"LastCheck"`   // when the last health check was done
+	LastError   string    `json:"LastError"`   // the last error seen, if any
+	Failures    int       `json:"Failures"`    // number of consecutive failures
+	Remounts    int       `json:"Remounts"`    // number of times the mount has been remounted
+	LastRemount time.Time `json:"LastRemount"` // when the mount was last remounted
+}
+
+// Health returns a snapshot of the health of the mount
+func (m *MountPoint) Health() MountHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	health := m.health
+	health.Supervised = m.MountOpt.Supervise
+	return health
+}
+
+// recordCheck records the result of a health check returning the
+// number of consecutive failures
+func (m *MountPoint) recordCheck(err error) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health.LastCheck = time.Now()
+	m.health.Healthy = err == nil
+	if err == nil {
+		m.health.Failures = 0
+	} else {
+		m.health.LastError = err.Error()
+		m.health.Failures++
+	}
+	return m.health.Failures
+}
+
+// CheckHealth probes the mount to see if it is working, recording the
+// result in the mount health.
+//
+// It checks the mount is still present then does a statfs on it
+// which goes through the kernel to rclone and the backend. If that
+// doesn't return within --supervise-timeout the mount is assumed to
+// be hung.
+func (m *MountPoint) CheckHealth() error {
+	err := m.probe()
+	m.recordCheck(err)
+	return err
+}
+
+// probe the mount to see if it is working
+func (m *MountPoint) probe() error {
+	if CanCheckMountReady {
+		if err := CheckMountReady(m.MountPoint); err != nil {
+			return err
+		}
+	}
+	timeout := time.Duration(m.MountOpt.SuperviseTimeout)
+	done := make(chan error, 1)
+	go func() {
+		_, err := diskusage.New(m.MountPoint)
+		if errors.Is(err, diskusage.ErrUnsupported) {
+			err = nil
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("statfs failed: %w", err)
+		}
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("statfs timed out after %v", timeout)
+	}
+}
+
+// remount unmounts the mount, if it is still mounted, and mounts it
+// again using the same VFS so any cached data is preserved.
+func (m *MountPoint) remount() error {
+	m.mu.Lock()
+	unmountFn, errChan := m.UnmountFn, m.ErrChan
+	m.ErrChan = nil
+	m.mu.Unlock()
+
+	if unmountFn != nil {
+		if err := unmountFn(); err != nil {
+			fs.Debugf(m.MountPoint, "Unmount before remount failed: %v", err)
+		}
+	}
+	// Wait for the old mount to finish serving
+	if errChan != nil {
+		select {
+		case <-errChan:
+		case <-time.After(time.Duration(m.MountOpt.SuperviseTimeout)):
+			fs.Debugf(m.MountPoint, "Timed out waiting for old mount to finish")
+		}
+	}
+	m.VFS.FlushDirCache()
+
+	errChan, unmountFn, err := m.MountFn(m.VFS, m.MountPoint, &m.MountOpt)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ErrChan, m.UnmountFn = errChan, unmountFn
+	m.MountedOn = time.Now()
+	m.health.Remounts++
+	m.health.LastRemount = m.MountedOn
+	m.health.Failures = 0
+	m.health.Healthy = true
+	return nil
+}
+
+// tryRemount remounts logging the result
+func (m *MountPoint) tryRemount() {
+	fs.Logf(m.MountPoint, "Remounting")
+	if err := m.remount(); err != nil {
+		m.recordCheck(err)
+		fs.Errorf(m.MountPoint, "Remount failed, will retry: %v", err)
+		return
+	}
+	fs.Logf(m.MountPoint, "Remounted")
+}
+
+// supervise waits for the mount to end, checking its health every
+// --supervise-interval and remounting it if it fails.
+//
+// It returns when the mount is unmounted by rclone or externally.
+func (m *MountPoint) supervise() error {
+	ticker := time.NewTicker(time.Duration(m.MountOpt.SuperviseInterval))
+	defer ticker.Stop()
+	m.recordCheck(nil)
+	for {
+		m.mu.Lock()
+		errChan := m.ErrChan
+		m.mu.Unlock()
+		select {
+		case err := <-errChan:
+			// Exit if we unmounted or the mount was unmounted
+			// externally, otherwise the mount failed.
+			if err == nil || m.unmounting.Load() {
+				return err
+			}
+			m.mu.Lock()
+			m.ErrChan = nil
+			m.mu.Unlock()
+			m.recordCheck(err)
+			fs.Errorf(m.MountPoint, "Mount failed: %v", err)
+			m.tryRemount()
+		case <-ticker.C:
+			if m.unmounting.Load() {
+				continue
+			}
+			m.mu.Lock()
+			mounted := m.ErrChan != nil
+			m.mu.Unlock()
+			if !mounted {
+				// A previous remount failed so try again
+				m.tryRemount()
+				continue
+			}
+			err := m.CheckHealth()
+			if err == nil {
+				continue
+			}
+			failures := m.Health().Failures
+			fs.Errorf(m.MountPoint, "Mount health check failed (%d/%d): %v", failures, superviseFailures, err)
+			if failures >= superviseFailures {
+				m.tryRemount()
+			}
+		}
+	}
+}
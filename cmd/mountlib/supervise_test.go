@@ -0,0 +1,115 @@
+package mountlib_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/cmd/mountlib"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMounter counts the mounts made by its MountFn
+type fakeMounter struct {
+	mu       sync.Mutex
+	errChans []chan error
+}
+
+func (fm *fakeMounter) mount(VFS *vfs.VFS, mountpoint string, opt *mountlib.Options) (<-chan error, func() error, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	errChan := make(chan error, 1)
+	fm.errChans = append(fm.errChans, errChan)
+	unmount := func() error {
+		select {
+		case errChan <- nil:
+		default:
+		}
+		return nil
+	}
+	return errChan, unmount, nil
+}
+
+func (fm *fakeMounter) mounts() int {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	return len(fm.errChans)
+}
+
+func (fm *fakeMounter) fail(i int, err error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.errChans[i] <- err
+}
+
+func TestSuperviseRemount(t *testing.T) {
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, t.TempDir())
+	require.NoError(t, err)
+
+	fm := &fakeMounter{}
+	opt := mountlib.Opt
+	opt.Supervise = true
+	opt.SuperviseInterval = fs.Duration(time.Hour)
+	opt.SuperviseTimeout = fs.Duration(time.Second)
+	mnt := mountlib.NewMountPoint(fm.mount, t.TempDir(), f, &opt, &vfscommon.Opt)
+	_, err = mnt.Mount()
+	require.NoError(t, err)
+	defer mnt.VFS.Shutdown()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- mnt.Wait()
+	}()
+
+	// A failed mount should be remounted
+	fm.fail(0, errors.New("transport endpoint is not connected"))
+	require.Eventually(t, func() bool {
+		return mnt.Health().Remounts == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, fm.mounts())
+	health := mnt.Health()
+	assert.True(t, health.Supervised)
+	assert.True(t, health.Healthy)
+	assert.Equal(t, "transport endpoint is not connected", health.LastError)
+
+	// Unmounting should stop the supervisor
+	require.NoError(t, mnt.Unmount())
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for mount to finish")
+	}
+	assert.Equal(t, 2, fm.mounts())
+}
+
+func TestSuperviseBadOptions(t *testing.T) {
+	fm := &fakeMounter{}
+	opt := mountlib.Opt
+	opt.Supervise = true
+	opt.SuperviseInterval = 0
+	mnt := mountlib.NewMountPoint(fm.mount, t.TempDir(), nil, &opt, &vfscommon.Opt)
+	_, err := mnt.Mount()
+	assert.Error(t, err)
+	assert.Equal(t, 0, fm.mounts())
+}
+
+func TestMountBadMaxBackground(t *testing.T) {
+	fm := &fakeMounter{}
+	for _, maxBackground := range []int{-1, 65536} {
+		opt := mountlib.Opt
+		opt.MaxBackground = maxBackground
+		mnt := mountlib.NewMountPoint(fm.mount, t.TempDir(), nil, &opt, &vfscommon.Opt)
+		_, err := mnt.Mount()
+		assert.ErrorContains(t, err, "--max-background", maxBackground)
+	}
+	assert.Equal(t, 0, fm.mounts())
+}
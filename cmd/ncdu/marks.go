@@ -0,0 +1,368 @@
+//go:build !plan9 && !js && !aix
+
+package ncdu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rclone/rclone/cmd/ncdu/scan"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/fs/operations"
+	fssync "github.com/rclone/rclone/fs/sync"
+)
+
+// Options for the marked entries actions
+var (
+	dest       = ""
+	exportFile = ""
+)
+
+// Actions which can be done on the marked entries
+const (
+	actionCancel = iota
+	actionDelete
+	actionMove
+	actionCopy
+	actionExport
+	actionUnmark
+)
+
+var actionNames = []string{"cancel", "delete", "move", "copy", "export", "unmark"}
+
+// markJob is a batched delete, move or copy of the marked entries
+// running in the background
+type markJob struct {
+	action  int
+	cancel  func()
+	mu      sync.Mutex
+	total   int
+	done    int
+	current string   // remote being processed
+	ok      []string // remotes processed successfully
+	errs    int      // number of errors
+	lastErr error    // last error seen
+	updated chan<- struct{}
+}
+
+// verb returns the action as a verb for messages
+func (j *markJob) verb() string {
+	switch j.action {
+	case actionDelete:
+		return "Deleting"
+	case actionMove:
+		return "Moving"
+	default:
+		return "Copying"
+	}
+}
+
+// status returns a one line summary of the job progress
+func (j *markJob) status() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	msg := fmt.Sprintf("%s %d/%d", j.verb(), j.done, j.total)
+	if j.errs > 0 {
+		msg += fmt.Sprintf(" (%d errors)", j.errs)
+	}
+	if j.current != "" {
+		msg += ": " + j.current
+	}
+	return msg
+}
+
+// run the job on entries, writing to fdst if set
+func (j *markJob) run(ctx context.Context, f fs.Fs, fsName string, fdst fs.Fs, entries fs.DirEntries) {
+	for _, entry := range entries {
+		remote := entry.Remote()
+		j.mu.Lock()
+		j.current = remote
+		j.mu.Unlock()
+		j.notify()
+		err := ctx.Err()
+		if err == nil {
+			err = j.do(ctx, f, fsName, fdst, entry)
+		}
+		j.mu.Lock()
+		j.done++
+		if err != nil {
+			fs.Errorf(remote, "ncdu: %s failed: %v", strings.ToLower(j.verb()), err)
+			j.errs++
+			j.lastErr = err
+		} else {
+			j.ok = append(j.ok, remote)
+		}
+		j.mu.Unlock()
+	}
+	j.mu.Lock()
+	j.current = ""
+	j.mu.Unlock()
+}
+
+// notify the UI that the job has changed without blocking
+func (j *markJob) notify() {
+	select {
+	case j.updated <- struct{}{}:
+	default:
+	}
+}
+
+// do the action on a single entry
+//
+// Entries keep their path relative to the root when moved or copied
+func (j *markJob) do(ctx context.Context, f fs.Fs, fsName string, fdst fs.Fs, entry fs.DirEntry) error {
+	remote := entry.Remote()
+	if o, isFile := entry.(fs.Object); isFile {
+		switch j.action {
+		case actionDelete:
+			return operations.DeleteFile(ctx, o)
+		case actionMove:
+			_, err := operations.Move(ctx, fdst, nil, remote, o)
+			return err
+		default:
+			_, err := operations.Copy(ctx, fdst, nil, remote, o)
+			return err
+		}
+	}
+	if j.action == actionDelete {
+		return operations.Purge(ctx, f, remote)
+	}
+	fsrcDir, err := cache.Get(ctx, fspath.JoinRootPath(fsName, remote))
+	if err != nil {
+		return err
+	}
+	fdstDir, err := cache.Get(ctx, fspath.JoinRootPath(fs.ConfigString(fdst), remote))
+	if err != nil {
+		return err
+	}
+	if j.action == actionMove {
+		return fssync.MoveDir(ctx, fdstDir, fsrcDir, true, true)
+	}
+	return fssync.CopyDir(ctx, fdstDir, fsrcDir, true)
+}
+
+// toggleMark marks or unmarks the selected entries, or the entry
+// under the cursor if there are none
+func (u *UI) toggleMark() {
+	if u.d == nil || len(u.entries) == 0 {
+		return
+	}
+	positions := []dirPos{u.dirPosMap[u.path]}
+	if len(u.selectedEntries) > 0 {
+		positions = positions[:0]
+		for _, cursorPos := range u.selectedEntries {
+			positions = append(positions, cursorPos)
+		}
+		u.selectedEntries = make(map[string]dirPos)
+		u.visualSelectMode = false
+	}
+	for _, cursorPos := range positions {
+		entry := u.entries[u.sortPerm[cursorPos.entry]]
+		if _, marked := u.marked[entry.Remote()]; marked {
+			delete(u.marked, entry.Remote())
+		} else {
+			u.marked[entry.Remote()] = entry
+		}
+	}
+}
+
+// markedEntries returns the marked entries sorted by remote
+func (u *UI) markedEntries() fs.DirEntries {
+	entries := make(fs.DirEntries, 0, len(u.marked))
+	for _, entry := range u.marked {
+		entries = append(entries, entry)
+	}
+	sort.Sort(entries)
+	return entries
+}
+
+// markedSize returns the total size of the marked entries
+func (u *UI) markedSize() (size int64) {
+	if u.root == nil {
+		return 0
+	}
+	for remote, entry := range u.marked {
+		if _, isDir := entry.(fs.Directory); isDir {
+			if d := u.root.Find(remote); d != nil {
+				dirSize, _ := d.Attr()
+				size += dirSize
+			}
+		} else if entry.Size() > 0 {
+			size += entry.Size()
+		}
+	}
+	return size
+}
+
+// markedMenu shows the actions which can be done on the marked
+// entries, or allows the running job to be stopped
+func (u *UI) markedMenu() {
+	if u.job != nil {
+		u.boxMenu = []string{"close", "stop"}
+		u.boxMenuHandler = func(f fs.Fs, p string, o int) (string, error) {
+			if o != 1 || u.job == nil {
+				return "Job still running", nil
+			}
+			u.job.cancel()
+			return "Stopping job", nil
+		}
+		u.popupBox([]string{
+			"Background job running",
+			u.job.status(),
+		})
+		return
+	}
+	if len(u.marked) == 0 {
+		u.togglePopupBox([]string{
+			"No marked entries",
+			"Use x to mark files/directories",
+		})
+		return
+	}
+	u.boxMenu = actionNames
+	u.boxMenuHandler = func(f fs.Fs, p string, o int) (string, error) {
+		switch o {
+		case actionCancel:
+			return "Aborted!", nil
+		case actionUnmark:
+			u.marked = make(map[string]fs.DirEntry)
+			return "Unmarked all items", nil
+		case actionExport:
+			return u.exportMarked()
+		}
+		return u.startJob(o)
+	}
+	u.popupBox([]string{
+		"Marked items",
+		fmt.Sprintf("%d items using %s", len(u.marked), operations.SizeString(u.markedSize(), u.humanReadable)),
+	})
+}
+
+// startJob starts the action on the marked entries in the background
+func (u *UI) startJob(action int) (string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var fdst fs.Fs
+	if action != actionDelete {
+		if dest == "" {
+			cancel()
+			return "", errors.New("set the destination with --dest to move or copy")
+		}
+		var err error
+		fdst, err = cache.Get(ctx, dest)
+		if err != nil {
+			cancel()
+			return "", err
+		}
+	}
+	entries := u.markedEntries()
+	job := &markJob{
+		action:  action,
+		cancel:  cancel,
+		total:   len(entries),
+		updated: u.jobUpdated,
+	}
+	u.job = job
+	go func() {
+		defer cancel()
+		job.run(ctx, u.f, u.fsName, fdst, entries)
+		u.jobDone <- job
+	}()
+	return fmt.Sprintf("%s %d items in the background", job.verb(), len(entries)), nil
+}
+
+// finishJob updates the tree with the results of job
+func (u *UI) finishJob(job *markJob) {
+	u.job = nil
+	for _, remote := range job.ok {
+		delete(u.marked, remote)
+		if job.action == actionCopy || u.root == nil {
+			continue
+		}
+		dir := path.Dir(remote)
+		if dir == "." {
+			dir = ""
+		}
+		if d := u.root.Find(dir); d != nil {
+			d.RemoveRemote(remote)
+		}
+	}
+	if u.d != nil {
+		cursorPos := u.dirPosMap[u.path]
+		u.setCurrentDir(u.d)
+		if cursorPos.entry >= len(u.entries) {
+			u.move(-1) // move back onto a valid entry
+		}
+	}
+	msg := fmt.Sprintf("%s finished: %d/%d items done", job.verb(), len(job.ok), job.total)
+	if job.errs > 0 {
+		u.popupBox([]string{msg, fmt.Sprintf("%d errors, last error: %v", job.errs, job.lastErr)})
+		return
+	}
+	u.popupBox([]string{"Finished:", msg})
+}
+
+// exportMarked writes the files in the marked entries to exportFile
+// so it can be used with --files-from
+func (u *UI) exportMarked() (string, error) {
+	if exportFile == "" {
+		return "", errors.New("set the file to export to with --export-file")
+	}
+	if u.listing {
+		return "", errors.New("wait for the listing to finish before exporting")
+	}
+	var files []string
+	for remote, entry := range u.marked {
+		if _, isDir := entry.(fs.Directory); !isDir {
+			files = append(files, remote)
+			continue
+		}
+		d := u.root.Find(remote)
+		if d == nil {
+			return "", fmt.Errorf("directory %q hasn't been read", remote)
+		}
+		files = appendFiles(files, d)
+	}
+	sort.Strings(files)
+	files = uniq(files)
+	var b strings.Builder
+	for _, file := range files {
+		b.WriteString(file)
+		b.WriteByte('\n')
+	}
+	err := os.WriteFile(exportFile, []byte(b.String()), 0666)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Exported %d files to %q", len(files), exportFile), nil
+}
+
+// appendFiles appends all the files in d and its subdirectories
+func appendFiles(files []string, d *scan.Dir) []string {
+	for _, entry := range d.Entries() {
+		if _, isDir := entry.(fs.Directory); !isDir {
+			files = append(files, entry.Remote())
+		} else if subDir := d.Find(entry.Remote()); subDir != nil {
+			files = appendFiles(files, subDir)
+		}
+	}
+	return files
+}
+
+// uniq removes adjacent duplicates from sorted files
+func uniq(files []string) []string {
+	out := files[:0]
+	for i, file := range files {
+		if i == 0 || file != files[i-1] {
+			out = append(out, file)
+		}
+	}
+	return out
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/rclone/rclone/cmd"
 	"github.com/rclone/rclone/cmd/ncdu/scan"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/fspath"
 	"github.com/rclone/rclone/fs/log"
 	"github.com/rclone/rclone/fs/operations"
@@ -28,6 +29,9 @@ import (
 
 func init() {
 	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.StringVarP(cmdFlags, &dest, "dest", "", dest, "Destination remote:path for moving or copying marked files/directories", "")
+	flags.StringVarP(cmdFlags, &exportFile, "export-file", "", exportFile, "Local file to export marked paths to for use with --files-from", "")
 }
 
 var commandDefinition = &cobra.Command{
@@ -75,6 +79,31 @@ but is useful as it stands. Unlike ncdu it does not show excluded files.
 Note that it might take some time to delete big files/directories. The
 UI won't respond in the meantime since the deletion is done synchronously.
 
+Files and directories can also be marked with 'x', in any number of
+directories, and the marked items managed together with 'X'. This
+offers to
+
+- delete the marked items
+- move the marked items to the remote given with ` + "`--dest`" + `
+- copy the marked items to the remote given with ` + "`--dest`" + `
+- export the marked paths to the local file given with ` + "`--export-file`" + `
+- unmark all the items
+
+Deletes, moves and copies run in the background while you carry on
+using the UI, with their progress shown at the bottom of the screen.
+Pressing 'X' while one is running allows it to be stopped. Moved and
+copied items keep their path relative to the root of the remote, so
+` + "`dir/file.txt`" + ` is moved to ` + "`dest:dir/file.txt`" + `.
+
+The export lists all the files in the marked items, one per line,
+relative to the root of the remote. It can be used later with
+` + "`--files-from`" + `, for example
+
+` + "```console" + `
+rclone ncdu remote:path --export-file marked.txt
+rclone sync remote:path remote2:path --files-from marked.txt
+` + "```" + `
+
 For a non-interactive listing of the remote, see the
 [tree](/commands/rclone_tree/) command. To just get the total size of
 the remote you can also use the [size](/commands/rclone_size/) command.`,
@@ -108,6 +137,8 @@ func helpText() (tr []string) {
 		" v select file/directory",
 		" V enter visual select mode",
 		" D delete selected files/directories",
+		" x mark/unmark selected or current file/directory",
+		" X delete/move/copy/export marked files/directories",
 	}
 	if !clipboard.Unsupported {
 		tr = append(tr, " y copy current path to clipboard")
@@ -152,9 +183,13 @@ type UI struct {
 	sortBySize         int8          // +1 for normal (largest first), 0 for off, -1 for reverse (smallest first)
 	sortByCount        int8
 	sortByAverageSize  int8
-	sortByModTime      int8              // +1 for normal (newest first), 0 for off, -1 for reverse (oldest first)
-	dirPosMap          map[string]dirPos // store for directory positions
-	selectedEntries    map[string]dirPos // selected entries of current directory
+	sortByModTime      int8                   // +1 for normal (newest first), 0 for off, -1 for reverse (oldest first)
+	dirPosMap          map[string]dirPos      // store for directory positions
+	selectedEntries    map[string]dirPos      // selected entries of current directory
+	marked             map[string]fs.DirEntry // marked entries in any directory
+	job                *markJob               // background job on marked entries if running
+	jobUpdated         chan struct{}          // signalled when job has progressed
+	jobDone            chan *markJob          // receives job when finished
 }
 
 // Where we have got to in the directory listing
@@ -406,6 +441,7 @@ func (u *UI) Draw() {
 				attrs, err = u.d.AttrI(u.sortPerm[n])
 			}
 			_, isSelected := u.selectedEntries[entry.String()]
+			_, isMarked := u.marked[entry.Remote()]
 			style := tcell.StyleDefault
 			if attrs.EntriesHaveErrors {
 				style = style.Foreground(tcell.ColorYellow)
@@ -413,6 +449,9 @@ func (u *UI) Draw() {
 			if err != nil {
 				style = style.Foreground(tcell.ColorRed)
 			}
+			if isMarked {
+				style = style.Foreground(tcell.ColorLightGreen)
+			}
 			if isSelected {
 				style = style.Foreground(tcell.ColorLightYellow)
 			}
@@ -490,6 +529,12 @@ func (u *UI) Draw() {
 		if u.listing {
 			message = " [listing in progress]"
 		}
+		if len(u.marked) > 0 {
+			message += fmt.Sprintf(" [%d marked]", len(u.marked))
+		}
+		if u.job != nil {
+			message += " [" + u.job.status() + "]"
+		}
 		size, count := u.d.Attr()
 		u.Linef(0, h-1, w, tcell.StyleDefault.Reverse(true), ' ', "Total usage: %s, Objects: %s%s",
 			operations.SizeString(size, u.humanReadable), operations.CountString(count, u.humanReadable), message)
@@ -905,6 +950,9 @@ func NewUI(f fs.Fs) *UI {
 		sortByCount:        0,
 		dirPosMap:          make(map[string]dirPos),
 		selectedEntries:    make(map[string]dirPos),
+		marked:             make(map[string]fs.DirEntry),
+		jobUpdated:         make(chan struct{}, 1),
+		jobDone:            make(chan *markJob, 1),
 	}
 }
 
@@ -971,6 +1019,9 @@ outer:
 		case <-updated:
 			// TODO: might want to limit updates per second
 			u.sortCurrentDir()
+		case <-u.jobUpdated:
+		case job := <-u.jobDone:
+			u.finishJob(job)
 		case ev := <-events:
 			switch ev := ev.(type) {
 			case *tcell.EventResize:
@@ -1049,6 +1100,10 @@ outer:
 					u.humanReadable = !u.humanReadable
 				case 'D':
 					u.deleteSelected()
+				case 'x':
+					u.toggleMark()
+				case 'X':
+					u.markedMenu()
 				case '?':
 					u.togglePopupBox(helpText())
 				case 'r':
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"path"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -162,6 +163,49 @@ func (d *Dir) remove(i int) {
 	}
 }
 
+// RemoveRemote removes the entry called remote from the in-memory
+// representation of the remote directory
+//
+// It returns false if the entry wasn't found
+func (d *Dir) RemoveRemote(remote string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, entry := range d.entries {
+		if entry.Remote() == remote {
+			d.remove(i)
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the Dir for dirPath which should be d or below it
+//
+// It returns nil if dirPath hasn't been scanned (yet)
+func (d *Dir) Find(dirPath string) *Dir {
+	if dirPath == d.path {
+		return d
+	}
+	rel := dirPath
+	if d.path != "" {
+		var ok bool
+		rel, ok = strings.CutPrefix(dirPath, d.path+"/")
+		if !ok {
+			return nil
+		}
+	}
+	for leaf := range strings.SplitSeq(rel, "/") {
+		d.mu.Lock()
+		subDir := d.dirs[leaf]
+		d.mu.Unlock()
+		if subDir == nil {
+			return nil
+		}
+		d = subDir
+	}
+	return d
+}
+
 // gets the directory of the i-th entry
 //
 // returns nil if it is a file
@@ -0,0 +1,64 @@
+// Package purgedeleted provides the purgedeleted command.
+package purgedeleted
+
+import (
+	"context"
+	"time"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/spf13/cobra"
+)
+
+var retention = fs.Duration(30 * 24 * time.Hour)
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.FVarP(cmdFlags, &retention, "retention", "", "Keep files deleted less than this long ago", "")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "purgedeleted remote:path",
+	Short: `Permanently delete files deleted from remote:path a while ago.`,
+	Long: `Permanently deletes the files which were deleted from remote:path
+longer ago than ` + "`--retention`" + ` (default 30 days) along with their
+old versions, so they can't be restored with ` + "`rclone undelete`" + ` any
+more.
+
+This is for cleaning up after ` + "`--delete-mode marker`" + ` which keeps
+the history of the files deleted by a sync rather than deleting them.
+
+- S3 deletes the delete markers and all the versions of files whose
+  current version is a delete marker.
+- B2 deletes the hide markers and all the versions of hidden files.
+- Any remote deletes the files moved into the ` + "`.rclone-trash`" + `
+  directory in remote:path by ` + "`--use-trash`" + `.
+
+Files for which it isn't known when they were deleted are kept. Use
+` + "`rclone cleanup`" + ` to empty the trash of remotes with one.
+
+Use the rclone filters to choose which files are purged and
+` + "`--dry-run`" + ` or ` + "`--interactive`" + `/` + "`-i`" + ` to see what would be
+purged first, for example
+
+` + "```console" + `
+rclone purgedeleted --retention 90d s3:bucket/path --dry-run
+` + "```" + `
+
+The filters apply to the path, size and modification time of the
+files as they were before they were deleted.`,
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+		"groups":            "Filter,Important",
+	},
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		f := cmd.NewFsDir(args)
+		cmd.Run(true, false, command, func() error {
+			return operations.PurgeDeletedAll(context.Background(), f, time.Duration(retention))
+		})
+	},
+}
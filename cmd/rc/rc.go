@@ -38,16 +38,22 @@ func init() {
 	cmd.Root.AddCommand(commandDefinition)
 	cmdFlags := commandDefinition.Flags()
 	flags.BoolVarP(cmdFlags, &noOutput, "no-output", "", noOutput, "If set, don't output the JSON result", "")
-	flags.StringVarP(cmdFlags, &url, "url", "", url, "URL to connect to rclone remote control", "")
-	flags.StringVarP(cmdFlags, &unixSocket, "unix-socket", "", unixSocket, "Path to a unix domain socket to dial to, instead of opening a TCP connection directly", "")
+	AddConnectionFlags(cmdFlags)
 	flags.StringVarP(cmdFlags, &jsonInput, "json", "", jsonInput, "Input JSON - use instead of key=value args", "")
-	flags.StringVarP(cmdFlags, &authUser, "user", "", "", "Username to use to rclone remote control", "")
-	flags.StringVarP(cmdFlags, &authPass, "pass", "", "", "Password to use to connect to rclone remote control", "")
-	flags.BoolVarP(cmdFlags, &loopback, "loopback", "", false, "If set connect to this rclone instance not via HTTP", "")
 	flags.StringArrayVarP(cmdFlags, &options, "opt", "o", options, "Option in the form name=value or name placed in the \"opt\" array", "")
 	flags.StringArrayVarP(cmdFlags, &arguments, "arg", "a", arguments, "Argument placed in the \"arg\" array", "")
 }
 
+// AddConnectionFlags adds the flags which say how to connect to the
+// remote control to flagSet
+func AddConnectionFlags(flagSet *pflag.FlagSet) {
+	flags.StringVarP(flagSet, &url, "url", "", url, "URL to connect to rclone remote control", "")
+	flags.StringVarP(flagSet, &unixSocket, "unix-socket", "", unixSocket, "Path to a unix domain socket to dial to, instead of opening a TCP connection directly", "")
+	flags.StringVarP(flagSet, &authUser, "user", "", "", "Username to use to rclone remote control", "")
+	flags.StringVarP(flagSet, &authPass, "pass", "", "", "Password to use to connect to rclone remote control", "")
+	flags.BoolVarP(flagSet, &loopback, "loopback", "", false, "If set connect to this rclone instance not via HTTP", "")
+}
+
 var commandDefinition = &cobra.Command{
 	Use:   "rc commands parameter",
 	Short: `Run a command against a running rclone.`,
@@ -186,6 +192,13 @@ func setAlternateFlag(flagName string, output *string) {
 	}
 }
 
+// Call the remote control at path with the parameters in using the
+// flags added with AddConnectionFlags
+func Call(ctx context.Context, path string, in rc.Params) (out rc.Params, err error) {
+	parseFlags()
+	return doCall(ctx, path, in)
+}
+
 // Format an error and create a synthetic server return from it
 func errorf(status int, path string, format string, arg ...any) (out rc.Params, err error) {
 	err = fmt.Errorf(format, arg...)
@@ -225,13 +238,13 @@ func doCall(ctx context.Context, path string, in rc.Params) (out rc.Params, err
 	} else {
 		client = fshttp.NewClientWithUnixSocket(ctx, unixSocket)
 	}
-	url += path
+	callURL := url + path
 	data, err := json.Marshal(in)
 	if err != nil {
 		return errorf(http.StatusBadRequest, path, "failed to encode request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", callURL, bytes.NewBuffer(data))
 	if err != nil {
 		return errorf(http.StatusInternalServerError, path, "failed to make request: %w", err)
 	}
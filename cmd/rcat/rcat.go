@@ -3,10 +3,13 @@ package rcat
 
 import (
 	"context"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/transformlib"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/operations"
@@ -14,13 +17,15 @@ import (
 )
 
 var (
-	size = int64(-1)
+	size       = int64(-1)
+	transforms []string
 )
 
 func init() {
 	cmd.Root.AddCommand(commandDefinition)
 	cmdFlags := commandDefinition.Flags()
 	flags.Int64VarP(cmdFlags, &size, "size", "", size, "File size hint to preallocate", "")
+	flags.StringArrayVarP(cmdFlags, &transforms, "transform", "", transforms, "Transform the data before uploading, e.g. gzip, zstd, crypt=remote:, hash=md5 (may be repeated)", "")
 }
 
 var commandDefinition = &cobra.Command{
@@ -56,7 +61,24 @@ Note that the upload cannot be retried because the data is not stored.
 If the backend supports multipart uploading then individual chunks can
 be retried. If you need to transfer a lot of data, you may be better
 off caching it locally and then ` + "`rclone move`" + ` it to the
-destination which can use retries.`,
+destination which can use retries.
+
+Use the ` + "`--transform`" + ` flag to compress, encrypt or checksum the data as
+it is uploaded without needing external tools. For example to upload
+a database dump compressed with zstd, encrypted with the keys of the
+crypt remote ` + "`secret:`" + ` and with an MD5 checksum of the uploaded
+object written to ` + "`remote:backups/db.sql.zst.bin.md5`" + `:
+
+` + "```console" + `
+pg_dump mydb | rclone rcat --transform zstd --transform crypt=secret: --transform hash remote:backups/db.sql.zst.bin
+` + "```" + `
+
+` + strings.ReplaceAll(transformlib.Help, "|", "`") + `
+A ` + "`hash`" + ` step writes a checksum file next to the destination named
+after the hash type, e.g. ` + "`file.md5`" + `, in the format used by ` + "`md5sum`" + `.
+
+To read the data back use ` + "`rclone cat`" + ` with the same ` + "`--transform`" + `
+flags.`,
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.38",
 		"groups":            "Important",
@@ -69,10 +91,37 @@ destination which can use retries.`,
 			fs.Fatalf(nil, "nothing to read from standard input (stdin).")
 		}
 
+		pipeline, err := transformlib.Parse(transforms)
+		if err != nil {
+			fs.Fatalf(nil, "%v", err)
+		}
+
 		fdst, dstFileName := cmd.NewFsDstFile(args)
 		cmd.Run(false, false, command, func() error {
-			_, err := operations.RcatSize(context.Background(), fdst, dstFileName, os.Stdin, size, time.Now(), nil)
-			return err
+			ctx := context.Background()
+			if pipeline.IsEmpty() {
+				_, err := operations.RcatSize(ctx, fdst, dstFileName, os.Stdin, size, time.Now(), nil)
+				return err
+			}
+			return rcatTransform(ctx, pipeline, fdst, dstFileName, os.Stdin)
 		})
 	},
 }
+
+// rcatTransform uploads in to dstFileName on fdst through the transforms
+func rcatTransform(ctx context.Context, pipeline *transformlib.Pipeline, fdst fs.Fs, dstFileName string, in io.Reader) (err error) {
+	out, err := pipeline.Encode(in)
+	if err != nil {
+		return err
+	}
+	defer fs.CheckClose(out, &err)
+	encodedSize := pipeline.EncodedSize(size)
+	if size >= 0 && encodedSize < 0 {
+		fs.Debugf(nil, "Ignoring --size as the size after --transform is unknown")
+	}
+	_, err = operations.RcatSize(ctx, fdst, dstFileName, out, encodedSize, time.Now(), nil)
+	if err != nil {
+		return err
+	}
+	return pipeline.WriteSidecars(ctx, fdst, dstFileName)
+}
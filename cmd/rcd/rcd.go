@@ -6,10 +6,13 @@ import (
 	"strings"
 
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/selfupdate"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/rc"
 	"github.com/rclone/rclone/fs/rc/rcflags"
 	"github.com/rclone/rclone/fs/rc/rcserver"
+	"github.com/rclone/rclone/fs/rc/schedule"
 	libhttp "github.com/rclone/rclone/lib/http"
 	"github.com/rclone/rclone/lib/systemd"
 	"github.com/spf13/cobra"
@@ -17,6 +20,7 @@ import (
 
 func init() {
 	cmd.Root.AddCommand(commandDefinition)
+	flags.AddFlagsFromOptions(commandDefinition.Flags(), "", schedule.OptionsInfo)
 }
 
 var commandDefinition = &cobra.Command{
@@ -57,6 +61,14 @@ See the [rc documentation](/rc/) for more info on the rc flags.
 			fs.Fatal(nil, "rc server not configured")
 		}
 
+		// Restore any saved schedules
+		if err := schedule.Load(); err != nil {
+			fs.Errorf(nil, "Failed to restore schedules: %v", err)
+		}
+
+		// Check for updates if required
+		selfupdate.StartChecks(context.Background())
+
 		// Notify stopping on exit
 		defer systemd.Notify()()
 
@@ -3,9 +3,14 @@
 package selfupdate
 
 import (
+	"context"
+
 	"github.com/rclone/rclone/lib/buildinfo"
 )
 
 func init() {
 	buildinfo.Tags = append(buildinfo.Tags, "noselfupdate")
 }
+
+// StartChecks does nothing as self update is disabled
+func StartChecks(ctx context.Context) {}
@@ -0,0 +1,248 @@
+//go:build !noselfupdate
+
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+)
+
+// CheckOptionsInfo describes the options for checking for updates
+var CheckOptionsInfo = fs.Options{{
+	Name:    "selfupdate_check_interval",
+	Default: fs.Duration(0),
+	Help:    "Check for a new rclone release this often when running rcd (0 to disable)",
+	Groups:  "RC",
+}, {
+	Name:    "selfupdate_channel",
+	Default: "stable",
+	Help:    "Release channel for update checks in rcd and selfupdate/apply: stable|beta|nightly",
+	Groups:  "RC",
+}}
+
+func init() {
+	fs.RegisterGlobalOptions(fs.OptionsInfo{Name: "selfupdate", Opt: &CheckOpt, Options: CheckOptionsInfo})
+}
+
+// CheckOptions contains options for checking for updates
+type CheckOptions struct {
+	Interval fs.Duration `config:"selfupdate_check_interval"` // how often to check, 0 for never
+	Channel  string      `config:"selfupdate_channel"`        // channel to check
+}
+
+// CheckOpt is the options for checking for updates
+var CheckOpt CheckOptions
+
+// checkStatus is the result of an update check
+type checkStatus struct {
+	Channel         string    `json:"channel"`         // channel checked
+	Current         string    `json:"current"`         // version running
+	Latest          string    `json:"latest"`          // latest version in the channel
+	UpdateAvailable bool      `json:"updateAvailable"` // set if latest is newer than current
+	Checked         time.Time `json:"checked"`         // when the check was done
+	Error           string    `json:"error,omitempty"` // error from the check if any
+}
+
+var (
+	statusMu   sync.Mutex   // protects lastStatus
+	lastStatus *checkStatus // result of the last check or nil
+	applyMu    sync.Mutex   // held while an update is being applied
+)
+
+// isNewer returns true if version latest is newer than current
+//
+// Versions which can't be compared, such as -DEV builds, are newer if
+// they are different.
+func isNewer(latest, current string) bool {
+	vLatest, errLatest := semver.NewVersion(stripV(latest))
+	vCurrent, errCurrent := semver.NewVersion(stripV(current))
+	if errLatest != nil || errCurrent != nil {
+		return latest != current
+	}
+	return vCurrent.LessThan(*vLatest)
+}
+
+// stripV removes the leading v from a version
+func stripV(version string) string {
+	if len(version) > 0 && version[0] == 'v' {
+		return version[1:]
+	}
+	return version
+}
+
+// check looks for the latest release in channel and remembers the
+// result for selfupdate/check.
+func check(ctx context.Context, channel string) (status checkStatus, err error) {
+	if channel == "" {
+		channel = CheckOpt.Channel
+	}
+	status = checkStatus{
+		Channel: channel,
+		Current: fs.Version,
+		Checked: time.Now(),
+	}
+	defer func() {
+		if err != nil {
+			status.Error = err.Error()
+		}
+		statusMu.Lock()
+		lastStatus = &status
+		statusMu.Unlock()
+	}()
+	beta, err := parseChannel(channel)
+	if err != nil {
+		return status, err
+	}
+	status.Latest, _, err = GetVersion(ctx, beta, "")
+	if err != nil {
+		return status, err
+	}
+	status.UpdateAvailable = isNewer(status.Latest, status.Current)
+	return status, nil
+}
+
+// StartChecks checks for a new release every
+// --selfupdate-check-interval until ctx is cancelled. It returns
+// immediately and does nothing if the interval isn't set.
+//
+// It is used by rcd.
+func StartChecks(ctx context.Context) {
+	interval := time.Duration(CheckOpt.Interval)
+	if interval <= 0 {
+		return
+	}
+	fs.Debugf(nil, "Checking for rclone updates in the %s channel every %v", CheckOpt.Channel, interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			status, err := check(ctx, "")
+			if err != nil {
+				fs.Errorf(nil, "Failed to check for rclone updates: %v", err)
+			} else if status.UpdateAvailable {
+				fs.Logf(nil, "rclone %s is available in the %s channel - install it with the selfupdate/apply rc command", status.Latest, status.Channel)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "selfupdate/check",
+		Fn:    rcCheck,
+		Title: "Check for a new rclone release",
+		Help: `This checks the release channel for a new version of rclone.
+
+Parameters:
+
+- channel - stable, beta or nightly (optional, default from --selfupdate-channel)
+- cached - if true return the result of the last check instead of checking again (optional)
+
+Returns:
+
+- channel - the channel checked
+- current - the version of rclone running
+- latest - the latest version in the channel
+- updateAvailable - true if latest is newer than current
+- checked - when the check was done
+- error - the error from the check if it failed
+
+If rcd is run with --selfupdate-check-interval then it checks
+periodically in the background and logs when an update is available.
+Use cached=true to read the result of the last check.
+`,
+	})
+}
+
+// Check for a new release
+func rcCheck(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	channel, err := in.GetString("channel")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	cached, err := in.GetBool("cached")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	var status checkStatus
+	statusMu.Lock()
+	if cached && lastStatus != nil && (channel == "" || channel == lastStatus.Channel) {
+		status = *lastStatus
+	}
+	statusMu.Unlock()
+	if status.Checked.IsZero() {
+		status, err = check(ctx, channel)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = rc.Reshape(&out, status)
+	return out, err
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "selfupdate/apply",
+		AuthRequired: true,
+		Fn:           rcApply,
+		Title:        "Update the rclone binary",
+		Help: `This downloads a release of rclone, checks its signed hashsums
+and replaces the rclone binary with it, as rclone selfupdate does.
+
+Parameters:
+
+- channel - stable, beta or nightly (optional, default from --selfupdate-channel)
+- version - the version to install (optional, default the latest in the channel)
+
+Returns:
+
+- previous - the version of rclone running
+- installed - the version installed or "" if rclone was up to date
+
+The running rclone carries on running the previous version until it
+is restarted.
+`,
+	})
+}
+
+// Apply an update
+func rcApply(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	channel, err := in.GetString("channel")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	if channel == "" {
+		channel = CheckOpt.Channel
+	}
+	version, err := in.GetString("version")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	if !applyMu.TryLock() {
+		return nil, errors.New("an update is already being applied")
+	}
+	defer applyMu.Unlock()
+	installed, err := installUpdate(ctx, &Options{
+		Channel: channel,
+		Version: version,
+		Package: "zip",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{
+		"previous":  fs.Version,
+		"installed": installed,
+	}, nil
+}
@@ -0,0 +1,62 @@
+//go:build !noselfupdate
+
+package selfupdate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsNewer(t *testing.T) {
+	for _, test := range []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v1.74.0", "v1.73.1", true},
+		{"v1.73.1", "v1.73.1", false},
+		{"v1.73.0", "v1.74.0-beta.9000.0123456789", false},
+		{"v1.74.0-beta.9001.0123456789", "v1.74.0-beta.9000.0123456789", true},
+		{"v1.74.0", "v1.74.0-beta.9000.0123456789", true},
+		{"v1.73.1", "v1.74.0-DEV", false},
+		{"v1.73.1", "DEV", true},
+	} {
+		assert.Equal(t, test.want, isNewer(test.latest, test.current), "%s vs %s", test.latest, test.current)
+	}
+}
+
+func TestRcCheckCached(t *testing.T) {
+	ctx := context.Background()
+	call := rc.Calls.Get("selfupdate/check")
+	require.NotNil(t, call)
+
+	statusMu.Lock()
+	oldStatus := lastStatus
+	lastStatus = &checkStatus{
+		Channel:         "beta",
+		Current:         fs.Version,
+		Latest:          "v9.9.9",
+		UpdateAvailable: true,
+		Checked:         time.Now(),
+	}
+	statusMu.Unlock()
+	defer func() {
+		statusMu.Lock()
+		lastStatus = oldStatus
+		statusMu.Unlock()
+	}()
+
+	out, err := call.Fn(ctx, rc.Params{"cached": true, "channel": "beta"})
+	require.NoError(t, err)
+	assert.Equal(t, "beta", out["channel"])
+	assert.Equal(t, "v9.9.9", out["latest"])
+	assert.Equal(t, true, out["updateAvailable"])
+
+	_, err = call.Fn(ctx, rc.Params{"channel": "unknown"})
+	assert.ErrorContains(t, err, "unknown release channel")
+}
@@ -43,6 +43,7 @@ type Options struct {
 	Output  string // output path
 	Beta    bool   // mutually exclusive with Stable (false means "stable")
 	Stable  bool   // mutually exclusive with Beta
+	Channel string // release channel: stable, beta or nightly (empty string means "stable")
 	Version string
 	Package string // package format: zip, deb, rpm (empty string means "zip")
 }
@@ -57,6 +58,7 @@ func init() {
 	flags.StringVarP(cmdFlags, &Opt.Output, "output", "", Opt.Output, "Save the downloaded binary at a given path (default: replace running binary)", "")
 	flags.BoolVarP(cmdFlags, &Opt.Stable, "stable", "", Opt.Stable, "Install stable release (this is the default)", "")
 	flags.BoolVarP(cmdFlags, &Opt.Beta, "beta", "", Opt.Beta, "Install beta release", "")
+	flags.StringVarP(cmdFlags, &Opt.Channel, "channel", "", Opt.Channel, "Release channel to install from: stable|beta|nightly (default: stable)", "")
 	flags.StringVarP(cmdFlags, &Opt.Version, "version", "", Opt.Version, "Install the given rclone version (default: latest)", "")
 	flags.StringVarP(cmdFlags, &Opt.Package, "package", "", Opt.Package, "Package format: zip|deb|rpm (default: zip)", "")
 }
@@ -75,7 +77,7 @@ var cmdSelfUpdate = &cobra.Command{
 		if Opt.Package == "" {
 			Opt.Package = "zip"
 		}
-		gotActionFlags := Opt.Stable || Opt.Beta || Opt.Output != "" || Opt.Version != "" || Opt.Package != "zip"
+		gotActionFlags := Opt.Stable || Opt.Beta || Opt.Channel != "" || Opt.Output != "" || Opt.Version != "" || Opt.Package != "zip"
 		if Opt.Check && !gotActionFlags {
 			versionCmd.CheckVersion(ctx)
 			return
@@ -145,28 +147,65 @@ func GetVersion(ctx context.Context, beta bool, version string) (newVersion, sit
 	return
 }
 
+// isBeta works out whether opt asks for a beta release
+func (opt *Options) isBeta() (bool, error) {
+	if opt.Stable && opt.Beta {
+		return false, errors.New("--stable and --beta are mutually exclusive")
+	}
+	if opt.Channel != "" {
+		if opt.Stable || opt.Beta {
+			return false, errors.New("--channel can't be used with --stable or --beta")
+		}
+		return parseChannel(opt.Channel)
+	}
+	return opt.Beta, nil
+}
+
+// parseChannel returns whether the release channel publishes beta
+// releases.
+//
+// Beta releases are built from every commit to master, so the nightly
+// channel is the same as beta.
+func parseChannel(channel string) (beta bool, err error) {
+	switch strings.ToLower(channel) {
+	case "", "stable":
+		return false, nil
+	case "beta", "nightly":
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown release channel %q - must be stable, beta or nightly", channel)
+}
+
 // InstallUpdate performs rclone self-update
 func InstallUpdate(ctx context.Context, opt *Options) error {
+	_, err := installUpdate(ctx, opt)
+	return err
+}
+
+// installUpdate performs rclone self-update returning the version
+// installed or "" if rclone was up to date
+func installUpdate(ctx context.Context, opt *Options) (newVersion string, err error) {
 	// Find the latest release number
-	if opt.Stable && opt.Beta {
-		return errors.New("--stable and --beta are mutually exclusive")
+	beta, err := opt.isBeta()
+	if err != nil {
+		return "", err
 	}
 
 	// The `cmount` tag is added by cmd/cmount/mount.go only if build is static.
 	_, tags := buildinfo.GetLinkingAndTags()
 	if strings.Contains(" "+tags+" ", " cmount ") && !cmount.ProvidedBy(runtime.GOOS) {
-		return errors.New("updating would discard the mount FUSE capability, aborting")
+		return "", errors.New("updating would discard the mount FUSE capability, aborting")
 	}
 
-	newVersion, siteURL, err := GetVersion(ctx, opt.Beta, opt.Version)
+	newVersion, siteURL, err := GetVersion(ctx, beta, opt.Version)
 	if err != nil {
-		return fmt.Errorf("unable to detect new version: %w", err)
+		return "", fmt.Errorf("unable to detect new version: %w", err)
 	}
 
 	oldVersion := fs.Version
 	if newVersion == oldVersion {
 		fs.Logf(nil, "rclone is up to date")
-		return nil
+		return "", nil
 	}
 
 	// Install .deb/.rpm package if requested by user
@@ -174,18 +213,19 @@ func InstallUpdate(ctx context.Context, opt *Options) error {
 		if opt.Check {
 			fmt.Println("Warning: --package flag is ignored in --check mode")
 		} else {
-			err := installPackage(ctx, opt.Beta, newVersion, siteURL, opt.Package)
-			if err == nil {
-				fs.Logf(nil, "Successfully updated rclone package from version %s to version %s", oldVersion, newVersion)
+			err := installPackage(ctx, newVersion, siteURL, opt.Package)
+			if err != nil {
+				return "", err
 			}
-			return err
+			fs.Logf(nil, "Successfully updated rclone package from version %s to version %s", oldVersion, newVersion)
+			return newVersion, nil
 		}
 	}
 
 	// Get the current executable path
 	executable, err := os.Executable()
 	if err != nil {
-		return fmt.Errorf("unable to find executable: %w", err)
+		return "", fmt.Errorf("unable to find executable: %w", err)
 	}
 
 	targetFile := opt.Output
@@ -195,13 +235,13 @@ func InstallUpdate(ctx context.Context, opt *Options) error {
 
 	if opt.Check {
 		fmt.Printf("Without --check this would install rclone version %s at %s\n", newVersion, targetFile)
-		return nil
+		return "", nil
 	}
 
 	// Make temporary file names and check for possible access errors in advance
 	var newFile string
 	if newFile, err = makeRandomExeName(targetFile, "new"); err != nil {
-		return err
+		return "", err
 	}
 	savedFile := ""
 	if runtime.GOOS == "windows" {
@@ -211,27 +251,28 @@ func InstallUpdate(ctx context.Context, opt *Options) error {
 	}
 
 	if savedFile == executable || newFile == executable {
-		return fmt.Errorf("%s: a temporary file would overwrite the executable, specify a different --output path", targetFile)
+		return "", fmt.Errorf("%s: a temporary file would overwrite the executable, specify a different --output path", targetFile)
 	}
 
 	if err := verifyAccess(targetFile); err != nil {
-		return err
+		return "", err
 	}
 
 	// Download the update as a temporary file
-	err = downloadUpdate(ctx, opt.Beta, newVersion, siteURL, newFile, "zip")
+	err = downloadUpdate(ctx, newVersion, siteURL, newFile, "zip")
 	if err != nil {
-		return fmt.Errorf("failed to update rclone: %w", err)
+		return "", fmt.Errorf("failed to update rclone: %w", err)
 	}
 
 	err = replaceExecutable(targetFile, newFile, savedFile)
-	if err == nil {
-		fs.Logf(nil, "Successfully updated rclone from version %s to version %s", oldVersion, newVersion)
+	if err != nil {
+		return "", err
 	}
-	return err
+	fs.Logf(nil, "Successfully updated rclone from version %s to version %s", oldVersion, newVersion)
+	return newVersion, nil
 }
 
-func installPackage(ctx context.Context, beta bool, version, siteURL, packageFormat string) error {
+func installPackage(ctx context.Context, version, siteURL, packageFormat string) error {
 	tempFile, err := os.CreateTemp("", "rclone.*."+packageFormat)
 	if err != nil {
 		return fmt.Errorf("unable to write temporary package: %w", err)
@@ -243,7 +284,7 @@ func installPackage(ctx context.Context, beta bool, version, siteURL, packageFor
 			fs.Errorf(nil, "%s: could not remove temporary package: %v", packageFile, rmErr)
 		}
 	}()
-	if err := downloadUpdate(ctx, beta, version, siteURL, packageFile, packageFormat); err != nil {
+	if err := downloadUpdate(ctx, version, siteURL, packageFile, packageFormat); err != nil {
 		return err
 	}
 
@@ -337,7 +378,7 @@ func makeRandomExeName(baseName, extension string) (string, error) {
 	return "", fmt.Errorf("cannot find a file name like %s.xxxx.%s", baseName, extension)
 }
 
-func downloadUpdate(ctx context.Context, beta bool, version, siteURL, newFile, packageFormat string) error {
+func downloadUpdate(ctx context.Context, version, siteURL, newFile, packageFormat string) error {
 	osName := runtime.GOOS
 	if osName == "darwin" {
 		osName = "osx"
@@ -374,11 +415,9 @@ func downloadUpdate(ctx context.Context, beta bool, version, siteURL, newFile, p
 	strHash := hex.EncodeToString(gotHash[:])
 	fs.Debugf(nil, "downloaded release archive with hashsum %s from %s", strHash, archiveURL)
 
-	// CI/CD does not provide hashsums for beta releases
-	if !beta {
-		if err := verifyHashsum(ctx, siteURL, version, archiveFilename, gotHash[:]); err != nil {
-			return err
-		}
+	// Always check the signed hashsums before the download is used
+	if err := verifyHashsum(ctx, siteURL, version, archiveFilename, gotHash[:]); err != nil {
+		return err
 	}
 
 	if packageFormat == "deb" || packageFormat == "rpm" {
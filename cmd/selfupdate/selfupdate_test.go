@@ -45,6 +45,32 @@ func TestGetVersion(t *testing.T) {
 	assert.Equal(t, "v1.52.3", resultVer)
 }
 
+func TestIsBeta(t *testing.T) {
+	for _, test := range []struct {
+		opt     Options
+		want    bool
+		wantErr string
+	}{
+		{Options{}, false, ""},
+		{Options{Stable: true}, false, ""},
+		{Options{Beta: true}, true, ""},
+		{Options{Channel: "stable"}, false, ""},
+		{Options{Channel: "Beta"}, true, ""},
+		{Options{Channel: "nightly"}, true, ""},
+		{Options{Channel: "edge"}, false, "unknown release channel"},
+		{Options{Stable: true, Beta: true}, false, "mutually exclusive"},
+		{Options{Channel: "beta", Stable: true}, false, "can't be used with"},
+	} {
+		got, err := test.opt.isBeta()
+		if test.wantErr != "" {
+			assert.ErrorContains(t, err, test.wantErr, "%+v", test.opt)
+			continue
+		}
+		require.NoError(t, err, "%+v", test.opt)
+		assert.Equal(t, test.want, got, "%+v", test.opt)
+	}
+}
+
 func TestInstallOnLinux(t *testing.T) {
 	testy.SkipUnreliable(t)
 	if runtime.GOOS != "linux" {
@@ -61,7 +61,7 @@ func verifyHashsum(ctx context.Context, siteURL, version, archive string, hash [
 	sumsURL := fmt.Sprintf("%s/%s/SHA256SUMS", siteURL, version)
 	sumsBuf, err := downloadFile(ctx, sumsURL)
 	if err != nil {
-		return err
+		return fmt.Errorf("can't verify the download without the signed hashsums: %w", err)
 	}
 	fs.Debugf(nil, "downloaded hashsum list: %s", sumsURL)
 	return verifyHashsumDownloaded(ctx, sumsBuf, archive, hash)
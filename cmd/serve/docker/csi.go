@@ -0,0 +1,696 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rclone/rclone/cmd/mountlib"
+	"github.com/rclone/rclone/fs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// CSI plugin defaults
+var (
+	csiPluginName = "csi.rclone.org"
+	csiSocketAddr = "/csi/csi.sock"
+)
+
+// CSI capability types from the CSI spec
+const (
+	csiControllerService  = 1 // PluginCapability.Service.CONTROLLER_SERVICE
+	csiCreateDeleteVolume = 1 // ControllerServiceCapability.RPC.CREATE_DELETE_VOLUME
+)
+
+// CSIServer serves the Container Storage Interface identity,
+// controller and node services over gRPC so that Kubernetes can
+// provision and mount volumes with the driver.
+//
+// Volumes published on a node are mounted straight at the target
+// path kubelet asks for and are kept in the driver state like docker
+// volumes.
+type CSIServer struct {
+	drv    *Driver
+	nodeID string
+	server *grpc.Server
+}
+
+// NewCSIServer creates a new CSI server for drv identifying the node
+// as nodeID
+func NewCSIServer(drv *Driver, nodeID string) (*CSIServer, error) {
+	files, err := csiFiles()
+	if err != nil {
+		return nil, err
+	}
+	s := &CSIServer{
+		drv:    drv,
+		nodeID: nodeID,
+		server: grpc.NewServer(),
+	}
+	for _, sd := range s.serviceDescs(files) {
+		s.server.RegisterService(sd, s)
+	}
+	return s, nil
+}
+
+// Serve accepts CSI requests on listener
+func (s *CSIServer) Serve(listener net.Listener) error {
+	return s.server.Serve(listener)
+}
+
+// ServeUnix makes the server listen for requests on the unix socket
+// at path which is where kubelet and the sidecars expect to find it.
+func (s *CSIServer) ServeUnix(path string, gid int) error {
+	listener, socketPath, err := newUnixListener(path, gid)
+	if err != nil {
+		return err
+	}
+	if socketPath != "" {
+		fs.Infof(nil, "Serving CSI on unix socket: %s", socketPath)
+		defer func() {
+			_ = os.Remove(socketPath)
+		}()
+	} else {
+		fs.Infof(nil, "Serving CSI on systemd socket")
+	}
+	return s.Serve(listener)
+}
+
+// ServeTCP makes the server listen for requests on a TCP address
+func (s *CSIServer) ServeTCP(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	fs.Infof(nil, "Serving CSI on TCP socket: %s", listener.Addr())
+	return s.Serve(listener)
+}
+
+// Stop the server
+func (s *CSIServer) Stop() {
+	s.server.GracefulStop()
+}
+
+// csiMethod describes a method of a CSI service
+type csiMethod struct {
+	service  string // service name without the package
+	name     string // method name
+	request  string // request message name
+	response string // response message name
+	handle   func(s *CSIServer, ctx context.Context, req csiRequest) (map[string]any, error)
+}
+
+// csiMethods are the CSI methods implemented.
+//
+// Calls to methods not listed here are answered with Unimplemented
+// by gRPC which the CSI spec allows for the optional ones.
+var csiMethods = []csiMethod{
+	{"Identity", "GetPluginInfo", "GetPluginInfoRequest", "GetPluginInfoResponse", (*CSIServer).getPluginInfo},
+	{"Identity", "GetPluginCapabilities", "GetPluginCapabilitiesRequest", "GetPluginCapabilitiesResponse", (*CSIServer).getPluginCapabilities},
+	{"Identity", "Probe", "ProbeRequest", "ProbeResponse", (*CSIServer).probe},
+	{"Controller", "CreateVolume", "CreateVolumeRequest", "CreateVolumeResponse", (*CSIServer).createVolume},
+	{"Controller", "DeleteVolume", "DeleteVolumeRequest", "DeleteVolumeResponse", (*CSIServer).deleteVolume},
+	{"Controller", "ValidateVolumeCapabilities", "ValidateVolumeCapabilitiesRequest", "ValidateVolumeCapabilitiesResponse", (*CSIServer).validateVolumeCapabilities},
+	{"Controller", "ControllerGetCapabilities", "ControllerGetCapabilitiesRequest", "ControllerGetCapabilitiesResponse", (*CSIServer).controllerGetCapabilities},
+	{"Node", "NodePublishVolume", "NodePublishVolumeRequest", "NodePublishVolumeResponse", (*CSIServer).nodePublishVolume},
+	{"Node", "NodeUnpublishVolume", "NodeUnpublishVolumeRequest", "NodeUnpublishVolumeResponse", (*CSIServer).nodeUnpublishVolume},
+	{"Node", "NodeGetCapabilities", "NodeGetCapabilitiesRequest", "NodeGetCapabilitiesResponse", (*CSIServer).nodeGetCapabilities},
+	{"Node", "NodeGetInfo", "NodeGetInfoRequest", "NodeGetInfoResponse", (*CSIServer).nodeGetInfo},
+}
+
+// serviceDescs returns the gRPC service descriptions for csiMethods
+func (s *CSIServer) serviceDescs(files *csiDescriptors) []*grpc.ServiceDesc {
+	var descs []*grpc.ServiceDesc
+	byName := map[string]*grpc.ServiceDesc{}
+	for _, method := range csiMethods {
+		sd := byName[method.service]
+		if sd == nil {
+			sd = &grpc.ServiceDesc{
+				ServiceName: csiPackage + "." + method.service,
+				HandlerType: (*any)(nil),
+				Metadata:    "csi.proto",
+			}
+			byName[method.service] = sd
+			descs = append(descs, sd)
+		}
+		sd.Methods = append(sd.Methods, grpc.MethodDesc{
+			MethodName: method.name,
+			Handler:    method.handler(files),
+		})
+	}
+	return descs
+}
+
+// handler returns the gRPC handler for the method
+func (method csiMethod) handler(files *csiDescriptors) grpc.MethodHandler {
+	request := files.message(method.request)
+	response := files.message(method.response)
+	fullMethod := "/" + csiPackage + "." + method.service + "/" + method.name
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := dynamicpb.NewMessage(request)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		handle := func(ctx context.Context, req any) (any, error) {
+			fs.Debugf(nil, "CSI %s", method.name)
+			values, err := method.handle(srv.(*CSIServer), ctx, csiRequest{req.(*dynamicpb.Message)})
+			if err != nil {
+				fs.Debugf(nil, "CSI %s failed: %v", method.name, err)
+				return nil, err
+			}
+			out := dynamicpb.NewMessage(response)
+			csiSet(out, values)
+			return out, nil
+		}
+		if interceptor == nil {
+			return handle(ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}
+		return interceptor(ctx, in, info, handle)
+	}
+}
+
+// getPluginInfo returns the name and version of the plugin
+func (s *CSIServer) getPluginInfo(ctx context.Context, req csiRequest) (map[string]any, error) {
+	return map[string]any{
+		"name":           csiPluginName,
+		"vendor_version": strings.TrimPrefix(fs.Version, "v"),
+	}, nil
+}
+
+// getPluginCapabilities advertises the controller service
+func (s *CSIServer) getPluginCapabilities(ctx context.Context, req csiRequest) (map[string]any, error) {
+	return map[string]any{
+		"capabilities": []map[string]any{
+			{"service": map[string]any{"type": int32(csiControllerService)}},
+		},
+	}, nil
+}
+
+// probe reports the plugin is ready
+func (s *CSIServer) probe(ctx context.Context, req csiRequest) (map[string]any, error) {
+	return map[string]any{}, nil
+}
+
+// createVolume checks the parameters of a new volume.
+//
+// Nothing is created on the remote - the parameters of the storage
+// class become the volume context which is handed to the node when
+// the volume is published.
+func (s *CSIServer) createVolume(ctx context.Context, req csiRequest) (map[string]any, error) {
+	name := req.str("name")
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume name is required")
+	}
+	params := csiVolumeOptions(req.strMap("parameters"), nil, false)
+	if err := s.drv.csiCheck(name, csiVolumeOptions(params, req.strMap("secrets"), false)); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "volume %q: %v", name, err)
+	}
+	return map[string]any{
+		"volume": map[string]any{
+			"volume_id":      name,
+			"volume_context": map[string]string(params),
+		},
+	}, nil
+}
+
+// deleteVolume leaves the data on the remote alone like removing a
+// docker volume does
+func (s *CSIServer) deleteVolume(ctx context.Context, req csiRequest) (map[string]any, error) {
+	if req.str("volume_id") == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume id is required")
+	}
+	return map[string]any{}, nil
+}
+
+// validateVolumeCapabilities confirms all the capabilities asked for
+// as a mount can be shared by any number of pods in any mode
+func (s *CSIServer) validateVolumeCapabilities(ctx context.Context, req csiRequest) (map[string]any, error) {
+	if req.str("volume_id") == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume id is required")
+	}
+	return map[string]any{
+		"confirmed": map[string]any{
+			"volume_context":      req.strMap("volume_context"),
+			"volume_capabilities": req.bytesList("volume_capabilities"),
+			"parameters":          req.strMap("parameters"),
+		},
+	}, nil
+}
+
+// controllerGetCapabilities advertises volume creation and deletion
+func (s *CSIServer) controllerGetCapabilities(ctx context.Context, req csiRequest) (map[string]any, error) {
+	return map[string]any{
+		"capabilities": []map[string]any{
+			{"rpc": map[string]any{"type": int32(csiCreateDeleteVolume)}},
+		},
+	}, nil
+}
+
+// nodePublishVolume mounts the volume at the target path
+func (s *CSIServer) nodePublishVolume(ctx context.Context, req csiRequest) (map[string]any, error) {
+	volumeID, targetPath := req.str("volume_id"), req.str("target_path")
+	if volumeID == "" || targetPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume id and target path are required")
+	}
+	volOpt := csiVolumeOptions(req.strMap("volume_context"), req.strMap("secrets"), req.boolean("readonly"))
+	if err := s.drv.csiPublish(ctx, volumeID, targetPath, volOpt); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to publish volume %q: %v", volumeID, err)
+	}
+	return map[string]any{}, nil
+}
+
+// nodeUnpublishVolume unmounts the volume from the target path
+func (s *CSIServer) nodeUnpublishVolume(ctx context.Context, req csiRequest) (map[string]any, error) {
+	volumeID, targetPath := req.str("volume_id"), req.str("target_path")
+	if volumeID == "" || targetPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume id and target path are required")
+	}
+	if err := s.drv.csiUnpublish(ctx, volumeID, targetPath); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to unpublish volume %q: %v", volumeID, err)
+	}
+	return map[string]any{}, nil
+}
+
+// nodeGetCapabilities advertises no optional node capabilities so
+// volumes are published without staging
+func (s *CSIServer) nodeGetCapabilities(ctx context.Context, req csiRequest) (map[string]any, error) {
+	return map[string]any{}, nil
+}
+
+// nodeGetInfo returns the node ID
+func (s *CSIServer) nodeGetInfo(ctx context.Context, req csiRequest) (map[string]any, error) {
+	return map[string]any{"node_id": s.nodeID}, nil
+}
+
+// csiVolumeOptions returns the volume options made from the volume
+// context and secrets.
+//
+// Keys with a "/" are added by Kubernetes, for example
+// "csi.storage.k8s.io/pod.name", so are left out.
+func csiVolumeOptions(volumeContext, secrets map[string]string, readOnly bool) VolOpts {
+	volOpt := VolOpts{}
+	for _, values := range []map[string]string{volumeContext, secrets} {
+		for key, value := range values {
+			if !strings.Contains(key, "/") {
+				volOpt[key] = value
+			}
+		}
+	}
+	if readOnly {
+		volOpt["read-only"] = "true"
+	}
+	return volOpt
+}
+
+// csiVolumeName returns the name of the driver volume for volumeID
+// published at targetPath.
+//
+// A CSI volume can be published at many target paths on a node, one
+// per pod, and each of these is a separate mount.
+func csiVolumeName(volumeID, targetPath string) string {
+	sum := sha256.Sum256([]byte(targetPath))
+	return volumeID + "-" + hex.EncodeToString(sum[:4])
+}
+
+// csiCheck checks the options of a new CSI volume
+func (drv *Driver) csiCheck(name string, volOpt VolOpts) error {
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+	path := filepath.Join(drv.root, name)
+	vol := &Volume{
+		Name:       name,
+		MountPoint: path,
+		drv:        drv,
+		mnt:        &mountlib.MountPoint{MountPoint: path},
+	}
+	return vol.applyOptions(volOpt)
+}
+
+// csiPublish mounts volumeID at targetPath.
+//
+// Publishing a volume which is already published at targetPath
+// succeeds as CSI calls must be idempotent.
+func (drv *Driver) csiPublish(ctx context.Context, volumeID, targetPath string, volOpt VolOpts) error {
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+
+	name := csiVolumeName(volumeID, targetPath)
+	fs.Debugf(nil, "Publish CSI volume %q as %q at %s", volumeID, name, targetPath)
+	if vol, _ := drv.getVolume(name); vol != nil {
+		if _, found := vol.mountReqs[volumeID]; found {
+			return nil
+		}
+		if err := vol.mount(volumeID); err != nil {
+			return err
+		}
+		return drv.saveState()
+	}
+
+	vol, err := newVolumeAt(ctx, name, targetPath, volOpt, drv)
+	if err != nil {
+		return err
+	}
+	if err = vol.mount(volumeID); err != nil {
+		reportErr(vol.remove(ctx))
+		return err
+	}
+	drv.volumes[name] = vol
+	return drv.saveState()
+}
+
+// csiUnpublish unmounts volumeID from targetPath and removes the
+// target path.
+//
+// Unpublishing a volume which isn't published at targetPath succeeds
+// as CSI calls must be idempotent.
+func (drv *Driver) csiUnpublish(ctx context.Context, volumeID, targetPath string) error {
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+
+	name := csiVolumeName(volumeID, targetPath)
+	fs.Debugf(nil, "Unpublish CSI volume %q as %q from %s", volumeID, name, targetPath)
+	if vol, _ := drv.getVolume(name); vol != nil {
+		if err := vol.unmountAll(); err != nil {
+			return err
+		}
+		if err := vol.remove(ctx); err != nil {
+			return err
+		}
+		delete(drv.volumes, name)
+		if err := drv.saveState(); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(targetPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// csiRequest reads the fields of a CSI request
+type csiRequest struct {
+	msg protoreflect.Message
+}
+
+// get returns the value of the named field
+func (r csiRequest) get(name string) protoreflect.Value {
+	return r.msg.Get(r.msg.Descriptor().Fields().ByName(protoreflect.Name(name)))
+}
+
+// str returns the named string field
+func (r csiRequest) str(name string) string {
+	return r.get(name).String()
+}
+
+// boolean returns the named bool field
+func (r csiRequest) boolean(name string) bool {
+	return r.get(name).Bool()
+}
+
+// strMap returns the named map<string, string> field
+func (r csiRequest) strMap(name string) map[string]string {
+	out := map[string]string{}
+	r.get(name).Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+		out[key.String()] = value.String()
+		return true
+	})
+	return out
+}
+
+// bytesList returns the named repeated bytes field
+func (r csiRequest) bytesList(name string) [][]byte {
+	list := r.get(name).List()
+	out := make([][]byte, list.Len())
+	for i := range out {
+		out[i] = list.Get(i).Bytes()
+	}
+	return out
+}
+
+// csiSet sets the fields of msg from values which may be scalars,
+// map[string]string for maps, [][]byte for repeated bytes,
+// map[string]any for messages and []map[string]any for repeated
+// messages.
+func csiSet(msg protoreflect.Message, values map[string]any) {
+	fields := msg.Descriptor().Fields()
+	for name, value := range values {
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			panic(fmt.Sprintf("unknown field %q in CSI message %s", name, msg.Descriptor().Name()))
+		}
+		switch v := value.(type) {
+		case map[string]string:
+			m := msg.Mutable(fd).Map()
+			for key, x := range v {
+				m.Set(protoreflect.ValueOfString(key).MapKey(), protoreflect.ValueOfString(x))
+			}
+		case [][]byte:
+			list := msg.Mutable(fd).List()
+			for _, x := range v {
+				list.Append(protoreflect.ValueOfBytes(x))
+			}
+		case map[string]any:
+			csiSet(msg.Mutable(fd).Message(), v)
+		case []map[string]any:
+			list := msg.Mutable(fd).List()
+			for _, x := range v {
+				elem := list.NewElement()
+				csiSet(elem.Message(), x)
+				list.Append(elem)
+			}
+		default:
+			msg.Set(fd, protoreflect.ValueOf(v))
+		}
+	}
+}
+
+// csiPackage is the protobuf package of the CSI spec
+const csiPackage = "csi.v1"
+
+// csiDescriptors holds the descriptors of the CSI messages used
+type csiDescriptors struct {
+	file protoreflect.FileDescriptor
+}
+
+// message returns the descriptor of the named message
+func (files *csiDescriptors) message(name string) protoreflect.MessageDescriptor {
+	md := files.file.Messages().ByName(protoreflect.Name(name))
+	if md == nil {
+		panic(fmt.Sprintf("unknown CSI message %q", name))
+	}
+	return md
+}
+
+var (
+	csiFilesOnce sync.Once
+	csiFilesDesc *csiDescriptors
+	csiFilesErr  error
+)
+
+// csiFiles returns the descriptors of the CSI messages
+func csiFiles() (*csiDescriptors, error) {
+	csiFilesOnce.Do(func() {
+		file, err := protodesc.NewFile(csiFileDescriptor(), nil)
+		if err != nil {
+			csiFilesErr = fmt.Errorf("failed to build CSI descriptors: %w", err)
+			return
+		}
+		csiFilesDesc = &csiDescriptors{file: file}
+	})
+	return csiFilesDesc, csiFilesErr
+}
+
+// Field types for csiFileDescriptor
+const (
+	csiString = descriptorpb.FieldDescriptorProto_TYPE_STRING
+	csiBool   = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+	csiInt32  = descriptorpb.FieldDescriptorProto_TYPE_INT32
+	csiInt64  = descriptorpb.FieldDescriptorProto_TYPE_INT64
+	csiBytes  = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+)
+
+// csiField describes a field of a CSI message
+type csiField struct {
+	name     string
+	number   int32
+	kind     descriptorpb.FieldDescriptorProto_Type
+	message  string // message type if set
+	repeated bool
+	isMap    bool // map<string, string>
+}
+
+// csiFileDescriptor returns the part of the CSI spec which is used.
+//
+// Only the field numbers and wire types of the messages have to
+// match the spec, so enums are declared as int32, messages which are
+// only passed back as bytes and nested messages are flattened.
+func csiFileDescriptor() *descriptorpb.FileDescriptorProto {
+	messages := []struct {
+		name   string
+		fields []csiField
+	}{
+		{"GetPluginInfoRequest", nil},
+		{"GetPluginInfoResponse", []csiField{
+			{name: "name", number: 1, kind: csiString},
+			{name: "vendor_version", number: 2, kind: csiString},
+		}},
+		{"GetPluginCapabilitiesRequest", nil},
+		{"GetPluginCapabilitiesResponse", []csiField{
+			{name: "capabilities", number: 1, message: "PluginCapability", repeated: true},
+		}},
+		{"PluginCapability", []csiField{
+			{name: "service", number: 1, message: "PluginCapabilityService"},
+		}},
+		{"PluginCapabilityService", []csiField{
+			{name: "type", number: 1, kind: csiInt32},
+		}},
+		{"ProbeRequest", nil},
+		{"ProbeResponse", nil},
+		{"CreateVolumeRequest", []csiField{
+			{name: "name", number: 1, kind: csiString},
+			{name: "parameters", number: 4, isMap: true},
+			{name: "secrets", number: 5, isMap: true},
+		}},
+		{"CreateVolumeResponse", []csiField{
+			{name: "volume", number: 1, message: "Volume"},
+		}},
+		{"Volume", []csiField{
+			{name: "capacity_bytes", number: 1, kind: csiInt64},
+			{name: "volume_id", number: 2, kind: csiString},
+			{name: "volume_context", number: 3, isMap: true},
+		}},
+		{"DeleteVolumeRequest", []csiField{
+			{name: "volume_id", number: 1, kind: csiString},
+		}},
+		{"DeleteVolumeResponse", nil},
+		{"ValidateVolumeCapabilitiesRequest", []csiField{
+			{name: "volume_id", number: 1, kind: csiString},
+			{name: "volume_context", number: 2, isMap: true},
+			{name: "volume_capabilities", number: 3, kind: csiBytes, repeated: true},
+			{name: "parameters", number: 4, isMap: true},
+			{name: "secrets", number: 5, isMap: true},
+		}},
+		{"ValidateVolumeCapabilitiesResponse", []csiField{
+			{name: "confirmed", number: 1, message: "ValidateVolumeCapabilitiesConfirmed"},
+			{name: "message", number: 2, kind: csiString},
+		}},
+		{"ValidateVolumeCapabilitiesConfirmed", []csiField{
+			{name: "volume_context", number: 1, isMap: true},
+			{name: "volume_capabilities", number: 2, kind: csiBytes, repeated: true},
+			{name: "parameters", number: 3, isMap: true},
+		}},
+		{"ControllerGetCapabilitiesRequest", nil},
+		{"ControllerGetCapabilitiesResponse", []csiField{
+			{name: "capabilities", number: 1, message: "ControllerServiceCapability", repeated: true},
+		}},
+		{"ControllerServiceCapability", []csiField{
+			{name: "rpc", number: 1, message: "ControllerServiceCapabilityRPC"},
+		}},
+		{"ControllerServiceCapabilityRPC", []csiField{
+			{name: "type", number: 1, kind: csiInt32},
+		}},
+		{"NodePublishVolumeRequest", []csiField{
+			{name: "volume_id", number: 1, kind: csiString},
+			{name: "target_path", number: 4, kind: csiString},
+			{name: "readonly", number: 6, kind: csiBool},
+			{name: "secrets", number: 7, isMap: true},
+			{name: "volume_context", number: 8, isMap: true},
+		}},
+		{"NodePublishVolumeResponse", nil},
+		{"NodeUnpublishVolumeRequest", []csiField{
+			{name: "volume_id", number: 1, kind: csiString},
+			{name: "target_path", number: 2, kind: csiString},
+		}},
+		{"NodeUnpublishVolumeResponse", nil},
+		{"NodeGetCapabilitiesRequest", nil},
+		{"NodeGetCapabilitiesResponse", nil},
+		{"NodeGetInfoRequest", nil},
+		{"NodeGetInfoResponse", []csiField{
+			{name: "node_id", number: 1, kind: csiString},
+		}},
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("csi.proto"),
+		Package: proto.String(csiPackage),
+		Syntax:  proto.String("proto3"),
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	for _, message := range messages {
+		md := &descriptorpb.DescriptorProto{Name: proto.String(message.name)}
+		for _, field := range message.fields {
+			fd := &descriptorpb.FieldDescriptorProto{
+				Name:     proto.String(field.name),
+				JsonName: proto.String(field.name),
+				Number:   proto.Int32(field.number),
+				Label:    optional,
+				Type:     field.kind.Enum(),
+			}
+			if field.repeated {
+				fd.Label = repeated
+			}
+			if field.message != "" {
+				fd.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+				fd.TypeName = proto.String("." + csiPackage + "." + field.message)
+			}
+			if field.isMap {
+				entry := csiMapEntry(field.name)
+				md.NestedType = append(md.NestedType, entry)
+				fd.Label = repeated
+				fd.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+				fd.TypeName = proto.String("." + csiPackage + "." + message.name + "." + entry.GetName())
+			}
+			md.Field = append(md.Field, fd)
+		}
+		file.MessageType = append(file.MessageType, md)
+	}
+	return file
+}
+
+// csiMapEntry returns the entry message for a map<string, string>
+// field called name
+func csiMapEntry(name string) *descriptorpb.DescriptorProto {
+	var entryName strings.Builder
+	for part := range strings.SplitSeq(name, "_") {
+		entryName.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	entryName.WriteString("Entry")
+	entryField := func(name string, number int32) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     csiString.Enum(),
+		}
+	}
+	return &descriptorpb.DescriptorProto{
+		Name:    proto.String(entryName.String()),
+		Field:   []*descriptorpb.FieldDescriptorProto{entryField("key", 1), entryField("value", 2)},
+		Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+	}
+}
+
+// csiAddr returns the address to serve CSI on from socketAddr
+func csiAddr(addr string) string {
+	addr = strings.TrimPrefix(addr, "unix://")
+	if addr == "" {
+		addr = csiSocketAddr
+	}
+	return addr
+}
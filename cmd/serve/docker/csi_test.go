@@ -0,0 +1,168 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	_ "github.com/rclone/rclone/backend/memory"
+)
+
+// csiTestClient calls the CSI server like kubelet would
+type csiTestClient struct {
+	t     *testing.T
+	conn  *grpc.ClientConn
+	files *csiDescriptors
+}
+
+// call the CSI method with the request values
+func (c *csiTestClient) call(service, name string, values map[string]any) (csiRequest, error) {
+	request, response := "NodeGetInfoRequest", "NodeGetInfoResponse"
+	for _, method := range csiMethods {
+		if method.service == service && method.name == name {
+			request, response = method.request, method.response
+		}
+	}
+	in := dynamicpb.NewMessage(c.files.message(request))
+	csiSet(in, values)
+	out := dynamicpb.NewMessage(c.files.message(response))
+	err := c.conn.Invoke(context.Background(), "/csi.v1."+service+"/"+name, in, out)
+	return csiRequest{out}, err
+}
+
+func newCSITestClient(t *testing.T, drv *Driver) *csiTestClient {
+	srv, err := NewCSIServer(drv, "node1")
+	require.NoError(t, err)
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///csi",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	files, err := csiFiles()
+	require.NoError(t, err)
+	return &csiTestClient{t: t, conn: conn, files: files}
+}
+
+func TestCSI(t *testing.T) {
+	tempDir := t.TempDir()
+	drv := &Driver{
+		root:      filepath.Join(tempDir, "root"),
+		statePath: filepath.Join(tempDir, stateFile),
+		volumes:   map[string]*Volume{},
+		dummy:     true,
+	}
+	c := newCSITestClient(t, drv)
+
+	// Identity
+	resp, err := c.call("Identity", "GetPluginInfo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "csi.rclone.org", resp.str("name"))
+	_, err = c.call("Identity", "Probe", nil)
+	require.NoError(t, err)
+	resp, err = c.call("Node", "NodeGetInfo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "node1", resp.str("node_id"))
+
+	// Create a volume leaving out the Kubernetes parameters
+	params := map[string]string{
+		"remote":                      ":memory:data",
+		"vfs-cache-mode":              "full",
+		"csi.storage.k8s.io/pvc/name": "claim",
+	}
+	resp, err = c.call("Controller", "CreateVolume", map[string]any{
+		"name":       "pvc-1",
+		"parameters": params,
+	})
+	require.NoError(t, err)
+	volume := csiRequest{resp.get("volume").Message()}
+	assert.Equal(t, "pvc-1", volume.str("volume_id"))
+	assert.Equal(t, map[string]string{
+		"remote":         ":memory:data",
+		"vfs-cache-mode": "full",
+	}, volume.strMap("volume_context"))
+
+	// Bad options are rejected
+	_, err = c.call("Controller", "CreateVolume", map[string]any{
+		"name":       "pvc-2",
+		"parameters": map[string]string{"remote": ":memory:data", "potato": "1"},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.call("Controller", "CreateVolume", nil)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Publish the volume read only at the target path
+	targetPath := filepath.Join(tempDir, "pods", "pod1", "mount")
+	publish := map[string]any{
+		"volume_id":      "pvc-1",
+		"target_path":    targetPath,
+		"readonly":       true,
+		"volume_context": volume.strMap("volume_context"),
+		"secrets":        map[string]string{"memory-description": "secret"},
+	}
+	_, err = c.call("Node", "NodePublishVolume", publish)
+	require.NoError(t, err)
+	name := csiVolumeName("pvc-1", targetPath)
+	vol := drv.volumes[name]
+	require.NotNil(t, vol)
+	assert.Equal(t, targetPath, vol.MountPoint)
+	assert.Contains(t, vol.mountReqs, "pvc-1")
+	assert.True(t, vol.mnt.VFSOpt.ReadOnly)
+	assert.Equal(t, "secret", vol.Options["memory-description"])
+	assert.DirExists(t, targetPath)
+	state, err := os.ReadFile(drv.statePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(state), targetPath)
+
+	// Publishing again is fine
+	_, err = c.call("Node", "NodePublishVolume", publish)
+	require.NoError(t, err)
+	assert.Len(t, drv.volumes, 1)
+
+	// Publishing at another path makes another mount
+	otherPath := filepath.Join(tempDir, "pods", "pod2", "mount")
+	publish["target_path"] = otherPath
+	_, err = c.call("Node", "NodePublishVolume", publish)
+	require.NoError(t, err)
+	assert.Len(t, drv.volumes, 2)
+
+	// Unpublish removes the mount and the target path
+	for range 2 {
+		_, err = c.call("Node", "NodeUnpublishVolume", map[string]any{
+			"volume_id":   "pvc-1",
+			"target_path": targetPath,
+		})
+		require.NoError(t, err)
+		assert.NotContains(t, drv.volumes, name)
+		assert.NoDirExists(t, targetPath)
+	}
+	assert.Len(t, drv.volumes, 1)
+
+	_, err = c.call("Node", "NodePublishVolume", map[string]any{"volume_id": "pvc-1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Optional methods aren't implemented
+	_, err = c.call("Node", "NodeStageVolume", nil)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
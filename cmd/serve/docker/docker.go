@@ -4,6 +4,8 @@ package docker
 import (
 	"context"
 	_ "embed"
+	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"syscall"
@@ -30,6 +32,8 @@ var (
 	canPersist  = false // allows writing to config file
 	forgetState = false
 	noSpec      = false
+	csiMode     = false
+	csiNodeID   = ""
 )
 
 //go:embed docker.md
@@ -48,6 +52,8 @@ func init() {
 	flags.IntVarP(cmdFlags, &socketGid, "socket-gid", "", socketGid, "GID for unix socket (default: current process GID)", "")
 	flags.BoolVarP(cmdFlags, &forgetState, "forget-state", "", forgetState, "Skip restoring previous state", "")
 	flags.BoolVarP(cmdFlags, &noSpec, "no-spec", "", noSpec, "Do not write spec file", "")
+	flags.BoolVarP(cmdFlags, &csiMode, "csi", "", csiMode, "Serve the Kubernetes CSI API instead (default socket: /csi/csi.sock)", "")
+	flags.StringVarP(cmdFlags, &csiNodeID, "csi-node-id", "", csiNodeID, "Node ID reported to Kubernetes in CSI mode (default: hostname)", "")
 	// Add common mount/vfs flags
 	mountlib.AddFlags(cmdFlags)
 	vfsflags.AddFlags(cmdFlags)
@@ -72,6 +78,9 @@ var Command = &cobra.Command{
 			if err != nil {
 				return err
 			}
+			if csiMode {
+				return serveCSI(drv)
+			}
 			srv := NewServer(drv)
 			if socketAddr == "" {
 				// Listen on unix socket at /run/docker/plugins/<pluginName>.sock
@@ -85,3 +94,23 @@ var Command = &cobra.Command{
 		})
 	},
 }
+
+// serveCSI serves the CSI API for drv on --socket-addr
+func serveCSI(drv *Driver) error {
+	nodeID := csiNodeID
+	if nodeID == "" {
+		var err error
+		if nodeID, err = os.Hostname(); err != nil {
+			return fmt.Errorf("failed to read hostname for --csi-node-id: %w", err)
+		}
+	}
+	srv, err := NewCSIServer(drv, nodeID)
+	if err != nil {
+		return err
+	}
+	addr := csiAddr(socketAddr)
+	if filepath.IsAbs(addr) {
+		return srv.ServeUnix(addr, socketGid)
+	}
+	return srv.ServeTCP(addr)
+}
@@ -203,6 +203,104 @@ func TestDockerPluginLogic(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDockerPluginSnapshot(t *testing.T) {
+	ctx := context.Background()
+	oldCacheDir := config.GetCacheDir()
+	testDir, testFs := initialise(ctx, t)
+	err := config.SetCacheDir(testDir)
+	require.NoError(t, err)
+	defer func() {
+		_ = config.SetCacheDir(oldCacheDir)
+		if !t.Failed() {
+			fstest.Purge(testFs)
+			_ = os.RemoveAll(testDir)
+		}
+	}()
+
+	// Make a source volume with some data in
+	dataDir := filepath.Join(testDir, "data")
+	srcDir := filepath.Join(dataDir, "src")
+	require.NoError(t, file.MkdirAll(filepath.Join(srcDir, "dir"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "dir", "file.txt"), []byte("hello"), 0644))
+
+	drv, err := docker.NewDriver(ctx, filepath.Join(testDir, "volumes"), nil, nil, true, true)
+	require.NoError(t, err)
+	require.NoError(t, drv.Create(&docker.CreateRequest{
+		Name:    "src",
+		Options: docker.VolOpts{"remote": srcDir, "vfs-cache-mode": "off"},
+	}))
+
+	// Snapshot next to the source
+	require.NoError(t, drv.Create(&docker.CreateRequest{
+		Name:    "snap",
+		Options: docker.VolOpts{"snapshot-of": "src"},
+	}))
+	data, err := os.ReadFile(filepath.Join(dataDir, "snap", "dir", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	getRes, err := drv.Get(&docker.GetRequest{Name: "snap"})
+	require.NoError(t, err)
+	assert.Equal(t, "src", getRes.Volume.Status["SnapshotOf"])
+
+	// The snapshot doesn't change with the source
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "dir", "file.txt"), []byte("changed"), 0644))
+	data, err = os.ReadFile(filepath.Join(dataDir, "snap", "dir", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	// Clone with an explicit path
+	cloneDir := filepath.Join(testDir, "clones", "clone")
+	require.NoError(t, drv.Create(&docker.CreateRequest{
+		Name:    "clone",
+		Options: docker.VolOpts{"clone_of": "src", "path": cloneDir},
+	}))
+	data, err = os.ReadFile(filepath.Join(cloneDir, "dir", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "changed", string(data))
+	getRes, err = drv.Get(&docker.GetRequest{Name: "clone"})
+	require.NoError(t, err)
+	assert.Equal(t, "src", getRes.Volume.Status["CloneOf"])
+
+	// Errors
+	err = drv.Create(&docker.CreateRequest{
+		Name:    "bad",
+		Options: docker.VolOpts{"snapshot-of": "potato"},
+	})
+	assert.ErrorIs(t, err, docker.ErrVolumeNotFound)
+	err = drv.Create(&docker.CreateRequest{
+		Name:    "bad",
+		Options: docker.VolOpts{"snapshot-of": "src", "clone-of": "src"},
+	})
+	assertErrorContains(t, err, "both a snapshot and a clone")
+	err = drv.Create(&docker.CreateRequest{
+		Name:    "bad",
+		Options: docker.VolOpts{"clone-of": "src", "path": cloneDir},
+	})
+	assertErrorContains(t, err, "is not empty")
+	err = drv.Create(&docker.CreateRequest{
+		Name:    "bad",
+		Options: docker.VolOpts{"clone-of": "src", "path": filepath.Join(srcDir, "dir")},
+	})
+	assertErrorContains(t, err, "overlaps its source volume")
+	_, err = drv.Get(&docker.GetRequest{Name: "bad"})
+	assert.ErrorIs(t, err, docker.ErrVolumeNotFound)
+
+	// Snapshots survive a plugin restart without copying again
+	drv2, err := docker.NewDriver(ctx, filepath.Join(testDir, "volumes"), nil, nil, true, false)
+	require.NoError(t, err)
+	getRes, err = drv2.Get(&docker.GetRequest{Name: "snap"})
+	require.NoError(t, err)
+	assert.Equal(t, "src", getRes.Volume.Status["SnapshotOf"])
+	data, err = os.ReadFile(filepath.Join(dataDir, "snap", "dir", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	// The source can be removed leaving the snapshot
+	require.NoError(t, drv.Remove(&docker.RemoveRequest{Name: "src"}))
+	_, err = drv.Get(&docker.GetRequest{Name: "snap"})
+	assert.NoError(t, err)
+}
+
 const (
 	httpTimeout = 2 * time.Second
 	tempDelay   = 10 * time.Millisecond
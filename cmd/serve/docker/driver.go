@@ -193,6 +193,10 @@ func (drv *Driver) Create(req *CreateRequest) error {
 	if err != nil {
 		return err
 	}
+	if err = vol.copySource(ctx); err != nil {
+		reportErr(vol.remove(ctx))
+		return err
+	}
 	drv.volumes[name] = vol
 	return drv.saveState()
 }
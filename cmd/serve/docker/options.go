@@ -2,6 +2,7 @@ package docker
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/rclone/rclone/cmd/mountlib"
@@ -15,7 +16,7 @@ import (
 
 // applyOptions configures volume from request options.
 //
-// There are 5 special options:
+// There are 7 special options:
 //   - "remote" aka "fs" determines existing remote from config file
 //     with a path or on-the-fly remote using the ":backend:" syntax.
 //     It is usually named "remote" in documentation but can be aliased as
@@ -26,6 +27,14 @@ import (
 //     first found (optional).
 //   - "persist" is reserved for future to create remotes persisted
 //     in rclone.conf similar to rcd (optional).
+//   - "snapshot-of" makes the volume a read only copy of an existing
+//     volume taken when it is created (optional).
+//   - "clone-of" makes the volume a writable copy of an existing
+//     volume (optional).
+//
+// A snapshot or clone given neither "remote" nor "type" inherits the
+// configuration of its source volume and is put in a sibling
+// directory named after the new volume unless "path" is set.
 //
 // Unlike rcd we use the flat naming scheme for mount, vfs and backend
 // options without substructures. Dashes, underscores and mixed case
@@ -43,9 +52,17 @@ func (vol *Volume) applyOptions(volOpt VolOpts) error {
 	*mntOpt = vol.drv.mntOpt
 	*vfsOpt = vol.drv.vfsOpt
 
+	volOpt, err := vol.inheritOptions(volOpt)
+	if err != nil {
+		return err
+	}
+
 	// vol.Options has all options except "remote" and "type"
 	vol.Options = VolOpts{}
 	vol.fsString = ""
+	vol.fsPath = ""
+	vol.snapshotOf = ""
+	vol.cloneOf = ""
 
 	var fsName, fsPath, fsType string
 	var explicitPath string
@@ -125,6 +142,12 @@ func (vol *Volume) applyOptions(volOpt VolOpts) error {
 		case "mount-type":
 			vol.mountType, err = opt.GetString(key)
 			ok = true
+		case "snapshot-of":
+			vol.snapshotOf, err = opt.GetString(key)
+			ok = true
+		case "clone-of":
+			vol.cloneOf, err = opt.GetString(key)
+			ok = true
 		}
 		if err != nil {
 			return fmt.Errorf("cannot parse option %q: %w", key, err)
@@ -165,6 +188,9 @@ func (vol *Volume) applyOptions(volOpt VolOpts) error {
 	if err != nil {
 		return fmt.Errorf("cannot parse vfs options: %w", err)
 	}
+	if vol.snapshotOf != "" {
+		vfsOpt.ReadOnly = true
+	}
 
 	// Parse Mount options
 	err = configstruct.Set(mntMap, mntOpt)
@@ -187,10 +213,69 @@ func (vol *Volume) applyOptions(volOpt VolOpts) error {
 		comma = ""
 	}
 	vol.fsString = fsName + comma + connString + colon + fsPath
+	vol.fsPath = fsPath
 
 	return vol.validate()
 }
 
+// inheritOptions returns the options for a snapshot or clone with
+// neither "remote" nor "type" by merging volOpt with the options of
+// its source volume.
+//
+// Otherwise it returns volOpt unchanged.
+func (vol *Volume) inheritOptions(volOpt VolOpts) (VolOpts, error) {
+	var srcName string
+	for key, str := range volOpt {
+		switch key {
+		case "remote", "fs", "type":
+			if str != "" {
+				return volOpt, nil
+			}
+		}
+		switch normalOptName(key) {
+		case "snapshot-of", "clone-of":
+			if str != "" {
+				srcName = str
+			}
+		}
+	}
+	if srcName == "" {
+		return volOpt, nil
+	}
+	src, err := vol.drv.getVolume(srcName)
+	if err != nil {
+		return nil, fmt.Errorf("source volume %q: %w", srcName, err)
+	}
+
+	merged := VolOpts{}
+	for key, str := range src.Options {
+		switch normalOptName(key) {
+		case "snapshot-of", "clone-of":
+		default:
+			merged[key] = str
+		}
+	}
+	// options for the new volume override those of the source
+	for key, str := range volOpt {
+		for srcKey := range merged {
+			if normalOptName(srcKey) == normalOptName(key) {
+				delete(merged, srcKey)
+			}
+		}
+		merged[key] = str
+	}
+	merged["fs"] = src.Fs
+	merged["type"] = src.Type
+	if merged["path"] == "" {
+		srcPath := strings.TrimRight(src.fsPath, "/")
+		if srcPath == "" {
+			return nil, fmt.Errorf("source volume %q is at the root of its remote so path is required", srcName)
+		}
+		merged["path"] = path.Join(path.Dir(srcPath), vol.Name)
+	}
+	return merged, nil
+}
+
 func normalOptName(key string) string {
 	return strings.ReplaceAll(strings.TrimPrefix(strings.ToLower(key), "--"), "_", "-")
 }
@@ -1,11 +1,14 @@
 package docker
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/rclone/rclone/cmd/mountlib"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs/vfscommon"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -73,3 +76,67 @@ func TestApplyOptions(t *testing.T) {
 	require.ErrorContains(t, err, "unsupported backend option")
 
 }
+
+func TestInheritOptions(t *testing.T) {
+	root := t.TempDir()
+	drv := &Driver{
+		root:    root,
+		volumes: map[string]*Volume{},
+		dummy:   true,
+	}
+	newVol := func(name string) *Volume {
+		return &Volume{
+			Name:       name,
+			MountPoint: filepath.Join(root, name),
+			drv:        drv,
+			mnt: &mountlib.MountPoint{
+				MountPoint: filepath.Join(root, name),
+			},
+			mountReqs: make(map[string]any),
+		}
+	}
+	src := newVol("src")
+	require.NoError(t, src.applyOptions(VolOpts{
+		"remote":                 "/tmp/docker/src",
+		"local_no_check_updated": "1",
+		"vfs-cache-mode":         "full",
+		"no_modtime":             "true",
+	}))
+	drv.volumes["src"] = src
+
+	// A snapshot inherits everything and is read only
+	snap := newVol("snap")
+	require.NoError(t, snap.applyOptions(VolOpts{
+		"snapshot-of": "src",
+		"no-modtime":  "false",
+	}))
+	assert.Equal(t, ":local,no_check_updated='1':/tmp/docker/snap", snap.fsString)
+	assert.Equal(t, "/tmp/docker/src", snap.Fs)
+	assert.Equal(t, "/tmp/docker/snap", snap.Path)
+	assert.Equal(t, "src", snap.snapshotOf)
+	assert.Equal(t, vfscommon.CacheModeFull, snap.mnt.VFSOpt.CacheMode)
+	assert.Equal(t, false, snap.mnt.VFSOpt.NoModTime)
+	assert.Equal(t, true, snap.mnt.VFSOpt.ReadOnly)
+
+	// Restoring the saved state gives the same volume
+	saved := newVol("snap")
+	saved.Fs, saved.Path, saved.Options = snap.Fs, snap.Path, snap.Options
+	require.NoError(t, saved.restoreState(context.Background(), drv))
+	assert.Equal(t, snap.fsString, saved.fsString)
+	assert.Equal(t, true, saved.mnt.VFSOpt.ReadOnly)
+
+	// A clone with its own remote inherits nothing and is writable
+	clone := newVol("clone")
+	require.NoError(t, clone.applyOptions(VolOpts{
+		"clone-of": "src",
+		"remote":   "/tmp/other",
+	}))
+	assert.Equal(t, ":local:/tmp/other", clone.fsString)
+	assert.Equal(t, "src", clone.cloneOf)
+	assert.Equal(t, false, clone.mnt.VFSOpt.ReadOnly)
+
+	// A source at the root needs a path
+	drv.volumes["root"] = &Volume{Name: "root", Fs: ":memory:", drv: drv}
+	err := newVol("bad").applyOptions(VolOpts{"clone-of": "root"})
+	require.ErrorContains(t, err, "path is required")
+}
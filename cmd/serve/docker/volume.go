@@ -13,7 +13,9 @@ import (
 	"github.com/rclone/rclone/cmd/mountlib"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/sync"
 	"github.com/rclone/rclone/lib/file"
 )
 
@@ -37,8 +39,11 @@ type Volume struct {
 	Mounts     []string  `json:"mounts"`         // mountReqs as a string list
 	mountReqs  map[string]any
 	fsString   string // result of merging Fs, Type and Options
+	fsPath     string // path part of fsString
 	persist    bool
 	mountType  string
+	snapshotOf string // name of the source volume for a snapshot
+	cloneOf    string // name of the source volume for a clone
 	drv        *Driver
 	mnt        *mountlib.MountPoint
 }
@@ -55,7 +60,11 @@ type VolInfo struct {
 }
 
 func newVolume(ctx context.Context, name string, volOpt VolOpts, drv *Driver) (*Volume, error) {
-	path := filepath.Join(drv.root, name)
+	return newVolumeAt(ctx, name, filepath.Join(drv.root, name), volOpt, drv)
+}
+
+// newVolumeAt creates a new volume mounted at path
+func newVolumeAt(ctx context.Context, name, path string, volOpt VolOpts, drv *Driver) (*Volume, error) {
 	mnt := &mountlib.MountPoint{
 		MountPoint: path,
 	}
@@ -80,11 +89,18 @@ func newVolume(ctx context.Context, name string, volOpt VolOpts, drv *Driver) (*
 // getInfo returns short digest about volume
 func (vol *Volume) getInfo() *VolInfo {
 	vol.prepareState()
+	status := rc.Params{"Mounts": vol.Mounts}
+	if vol.snapshotOf != "" {
+		status["SnapshotOf"] = vol.snapshotOf
+	}
+	if vol.cloneOf != "" {
+		status["CloneOf"] = vol.cloneOf
+	}
 	return &VolInfo{
 		Name:       vol.Name,
 		CreatedAt:  vol.CreatedAt.Format(time.RFC3339),
 		Mountpoint: vol.MountPoint,
-		Status:     rc.Params{"Mounts": vol.Mounts},
+		Status:     status,
 	}
 }
 
@@ -106,6 +122,9 @@ func (vol *Volume) restoreState(ctx context.Context, drv *Driver) error {
 	volOpt := vol.Options
 	volOpt["fs"] = vol.Fs
 	volOpt["type"] = vol.Type
+	if vol.Path != "" {
+		volOpt["path"] = vol.Path
+	}
 	if err := vol.applyOptions(volOpt); err != nil {
 		return err
 	}
@@ -137,6 +156,12 @@ func (vol *Volume) validate() error {
 	if vol.persist && !canPersist {
 		return errors.New("using backend type to persist remotes is prohibited")
 	}
+	if vol.snapshotOf != "" && vol.cloneOf != "" {
+		return errors.New("volume can't be both a snapshot and a clone")
+	}
+	if vol.snapshotOf == vol.Name || vol.cloneOf == vol.Name {
+		return errors.New("volume can't be a snapshot or clone of itself")
+	}
 	if vol.MountPoint == "" {
 		return errors.New("mount point is required")
 	}
@@ -209,6 +234,55 @@ func (vol *Volume) setup(ctx context.Context) error {
 	return err
 }
 
+// copySource fills a new snapshot or clone with the contents of its
+// source volume.
+//
+// This uses server-side copies where the backend supports them, as
+// --backup-dir does, so a snapshot on the same remote is cheap.
+func (vol *Volume) copySource(ctx context.Context) error {
+	srcName := vol.snapshotOf
+	if srcName == "" {
+		srcName = vol.cloneOf
+	}
+	if srcName == "" {
+		return nil
+	}
+	src, err := vol.drv.getVolume(srcName)
+	if err != nil {
+		return fmt.Errorf("source volume %q: %w", srcName, err)
+	}
+	// Let writes in progress on the source reach the remote
+	if src.mnt.VFS != nil {
+		src.mnt.VFS.WaitForWriters(time.Minute)
+	}
+
+	fsrc, err := fs.NewFs(ctx, src.fsString)
+	if err != nil {
+		return fmt.Errorf("source volume %q: %w", srcName, err)
+	}
+	fdst, err := fs.NewFs(ctx, vol.fsString)
+	if err != nil {
+		return err
+	}
+	if operations.OverlappingFilterCheck(ctx, fdst, fsrc) {
+		return fmt.Errorf("volume %q overlaps its source volume %q", vol.Name, srcName)
+	}
+	// Don't mix the copy with existing data
+	entries, err := fdst.List(ctx, "")
+	if err == nil && len(entries) > 0 {
+		return fmt.Errorf("volume %q is not empty", vol.Name)
+	} else if err != nil && !errors.Is(err, fs.ErrorDirNotFound) {
+		return err
+	}
+
+	fs.Infof(nil, "Copying volume %q to %q", srcName, vol.Name)
+	err = sync.CopyDir(ctx, fdst, fsrc, true)
+	if err != nil {
+		return fmt.Errorf("failed to copy volume %q: %w", srcName, err)
+	}
+	return nil
+}
+
 // remove volume filesystem and mounts
 func (vol *Volume) remove(ctx context.Context) error {
 	count := len(vol.mountReqs)
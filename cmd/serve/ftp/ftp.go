@@ -45,7 +45,7 @@ var OptionsInfo = fs.Options{{
 }, {
 	Name:    "public_ip",
 	Default: "",
-	Help:    "Public IP address to advertise for passive connections",
+	Help:    "Public IPv4 address or host name to advertise for passive connections",
 }, {
 	Name:    "passive_port",
 	Default: "30000-32000",
@@ -66,6 +66,14 @@ var OptionsInfo = fs.Options{{
 	Name:    "key",
 	Default: "",
 	Help:    "TLS PEM Private key",
+}, {
+	Name:    "tls_mode",
+	Default: "implicit",
+	Help:    "FTPS mode if --cert and --key are set: implicit or explicit (AUTH TLS)",
+}, {
+	Name:    "tls_required",
+	Default: false,
+	Help:    "Refuse clients which don't upgrade to TLS in explicit mode",
 }}
 
 // Options contains options for the http Server
@@ -78,6 +86,8 @@ type Options struct {
 	Pass         string `config:"pass"`         // password for User
 	TLSCert      string `config:"cert"`         // TLS PEM key (concatenation of certificate and CA certificate)
 	TLSKey       string `config:"key"`          // TLS PEM Private key
+	TLSMode      string `config:"tls_mode"`     // implicit or explicit FTPS
+	TLSRequired  bool   `config:"tls_required"` // refuse plain text commands in explicit mode
 }
 
 // Opt is options set by command line flags
@@ -141,6 +151,39 @@ By default this will serve files without needing a login.
 
 You can set a single username and password with the --user and --pass flags.
 
+#### TLS (FTPS)
+
+Set --cert and --key to serve FTP over TLS. By default this uses
+implicit FTPS where clients must start TLS as soon as they connect,
+usually on port 990.
+
+Use |--tls-mode explicit| for explicit FTPS (RFC 4217) where clients
+connect in plain text and upgrade with |AUTH TLS|, usually on port 21.
+Add |--tls-required| to refuse any client which doesn't upgrade before
+logging in so passwords are never sent in the clear.
+
+When TLS is in use the passive data connections are always encrypted
+so clients must use |PROT P|.
+
+#### Passive mode and NAT
+
+Passive data connections use a random port from --passive-port
+(default |30000-32000|) which must be forwarded to the server along
+with the control port if it is behind a NAT router or firewall.
+
+The server advertises the IP address the client connected to for
+passive connections. Behind NAT this is a private address so set
+--public-ip to the external address clients should connect to. This
+can be an IPv4 address or a host name (e.g. a dynamic DNS name) which
+is looked up when the server starts.
+
+For example to serve explicit FTPS to the internet from behind NAT
+
+    rclone serve ftp remote:path --addr :21 --cert cert.pem --key key.pem \
+        --tls-mode explicit --tls-required \
+        --public-ip ftp.example.com --passive-port 40000-40100 \
+        --user user --pass secret
+
 ` + strings.TrimSpace(vfs.Help()+proxy.Help),
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.44",
@@ -199,18 +242,35 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options, vfsOpt *vfscommon.Opt
 		ctx: ctx,
 		opt: *opt,
 	}
+	d.useTLS = d.opt.TLSKey != ""
+	if (d.opt.TLSCert == "") != (d.opt.TLSKey == "") {
+		return nil, errors.New("--cert and --key must be set together")
+	}
+	switch d.opt.TLSMode {
+	case "implicit", "explicit":
+	default:
+		return nil, fmt.Errorf("invalid TLS mode %q: must be implicit or explicit", d.opt.TLSMode)
+	}
+	if d.opt.TLSRequired && (!d.useTLS || d.opt.TLSMode != "explicit") {
+		return nil, errors.New("--tls-required needs --cert, --key and --tls-mode explicit")
+	}
+
+	// Check PassivePorts since the server library doesn't!
+	err = checkPassivePorts(opt.PassivePorts)
+	if err != nil {
+		return nil, err
+	}
+	publicIP, err := resolvePublicIP(ctx, opt.PublicIP)
+	if err != nil {
+		return nil, err
+	}
+
 	if proxy.Opt.AuthProxy != "" {
 		d.proxy = proxy.New(ctx, proxyOpt, vfsOpt)
 		d.userPass = make(map[string]string, 16)
 	} else {
 		d.globalVFS = vfs.New(f, vfsOpt)
 	}
-	d.useTLS = d.opt.TLSKey != ""
-
-	// Check PassivePorts format since the server library doesn't!
-	if !passivePortsRe.MatchString(opt.PassivePorts) {
-		return nil, fmt.Errorf("invalid format for passive ports %q", opt.PassivePorts)
-	}
 
 	ftpopt := &ftp.Options{
 		Name:           "Rclone FTP Server",
@@ -218,7 +278,7 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options, vfsOpt *vfscommon.Opt
 		Driver:         d,
 		Hostname:       host,
 		Port:           portNum,
-		PublicIP:       opt.PublicIP,
+		PublicIP:       publicIP,
 		PassivePorts:   opt.PassivePorts,
 		Auth:           d,
 		Perm:           ftp.NewSimplePerm("ftp", "ftp"), // fake user and group
@@ -226,18 +286,114 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options, vfsOpt *vfscommon.Opt
 		TLS:            d.useTLS,
 		CertFile:       d.opt.TLSCert,
 		KeyFile:        d.opt.TLSKey,
+		ExplicitFTPS:   d.opt.TLSMode == "explicit",
 		//TODO implement a maximum of https://godoc.org/goftp.io/server#ServerOpts
 	}
+	if d.useTLS && !ftpopt.ExplicitFTPS {
+		ftpopt.Commands = implicitTLSCommands()
+	}
 	d.srv, err = ftp.NewServer(ftpopt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new FTP server: %w", err)
 	}
+	// NewServer doesn't copy ForceTLS from the options so set it here
+	d.srv.ForceTLS = d.opt.TLSRequired
 	return d, nil
 }
 
+// implicitTLSCommands returns the server commands for implicit FTPS.
+//
+// The server library only accepts PBSZ and PROT after AUTH TLS, so
+// clients which send them on an implicit TLS connection can't log in.
+func implicitTLSCommands() map[string]ftp.Command {
+	commands := make(map[string]ftp.Command, len(ftp.DefaultCommands()))
+	for name, command := range ftp.DefaultCommands() {
+		commands[name] = command
+	}
+	commands["PBSZ"] = commandPbsz{}
+	commands["PROT"] = commandProt{}
+	return commands
+}
+
+// commandPbsz responds to PBSZ on an implicit TLS connection
+type commandPbsz struct{}
+
+func (commandPbsz) IsExtend() bool     { return false }
+func (commandPbsz) RequireParam() bool { return true }
+func (commandPbsz) RequireAuth() bool  { return false }
+
+func (commandPbsz) Execute(sess *ftp.Session, param string) {
+	if param == "0" {
+		sess.WriteMessage(200, "OK")
+	} else {
+		sess.WriteMessage(550, "Action not taken")
+	}
+}
+
+// commandProt responds to PROT on an implicit TLS connection
+type commandProt struct{}
+
+func (commandProt) IsExtend() bool     { return false }
+func (commandProt) RequireParam() bool { return true }
+func (commandProt) RequireAuth() bool  { return false }
+
+func (commandProt) Execute(sess *ftp.Session, param string) {
+	if param == "P" {
+		sess.WriteMessage(200, "OK")
+	} else {
+		sess.WriteMessage(536, "Only P level is supported")
+	}
+}
+
+// checkPassivePorts checks the passive port range is valid. The server
+// library picks a port with rand.Intn(high-low) so panics if they are
+// equal.
+func checkPassivePorts(passivePorts string) error {
+	if !passivePortsRe.MatchString(passivePorts) {
+		return fmt.Errorf("invalid format for passive ports %q", passivePorts)
+	}
+	lowStr, highStr, _ := strings.Cut(passivePorts, "-")
+	low, _ := strconv.Atoi(strings.TrimSpace(lowStr))
+	high, _ := strconv.Atoi(strings.TrimSpace(highStr))
+	if low < 1 || high > 65535 || low >= high {
+		return fmt.Errorf("invalid passive ports %q: need low-high with 1 <= low < high <= 65535", passivePorts)
+	}
+	return nil
+}
+
+// resolvePublicIP returns the address to advertise for passive
+// connections.
+//
+// publicIP may be an IPv4 address or a host name which is looked up
+// once here. IPv6 can't be used as the server only supports PASV.
+func resolvePublicIP(ctx context.Context, publicIP string) (string, error) {
+	if publicIP == "" {
+		return "", nil
+	}
+	if ip := net.ParseIP(publicIP); ip != nil {
+		if ip.To4() == nil {
+			return "", fmt.Errorf("public IP %q must be an IPv4 address", publicIP)
+		}
+		return ip.To4().String(), nil
+	}
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", publicIP)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up public IP: %w", err)
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("no IPv4 address found for public IP %q", publicIP)
+	}
+	fs.Debugf(nil, "Using public IP %v for %q", ips[0], publicIP)
+	return ips[0].String(), nil
+}
+
 // Serve runs the FTP server until it is shutdown
 func (d *driver) Serve() error {
-	fs.Logf(d.f, "Serving FTP on %s", d.srv.Hostname+":"+strconv.Itoa(d.srv.Port))
+	proto := "FTP"
+	if d.useTLS {
+		proto = "FTPS (" + d.opt.TLSMode + ")"
+	}
+	fs.Logf(d.f, "Serving %s on %s", proto, d.srv.Hostname+":"+strconv.Itoa(d.srv.Port))
 	err := d.srv.ListenAndServe()
 	if err == ftp.ErrServerClosed {
 		err = nil
@@ -534,9 +690,33 @@ func (d *driver) GetFile(sctx *ftp.Context, path string, offset int64) (size int
 	tr := accounting.GlobalStats().NewTransferRemoteSize(path, node.Size(), d.f, nil)
 	defer tr.Done(d.ctx, nil)
 
+	if d.useTLS {
+		return node.Size(), handshakeReader{handle}, nil
+	}
 	return node.Size(), handle, nil
 }
 
+// handshakeReader makes sure the TLS handshake is done on the data
+// connection when a download sends no data.
+//
+// The server library only does the handshake when it writes to the
+// data connection, so without this it closes the connection under the
+// client when downloading an empty file.
+type handshakeReader struct {
+	io.ReadCloser
+}
+
+// WriteTo copies the file to w which the server library uses in
+// preference to Read.
+func (r handshakeReader) WriteTo(w io.Writer) (n int64, err error) {
+	n, err = io.Copy(w, r.ReadCloser)
+	if err == nil && n == 0 {
+		// An empty write still does the handshake
+		_, err = w.Write(nil)
+	}
+	return n, err
+}
+
 // PutFile upload a file
 func (d *driver) PutFile(sctx *ftp.Context, path string, data io.Reader, offset int64) (n int64, err error) {
 	defer log.Trace(path, "offset=%d", offset)("err = %v", &err)
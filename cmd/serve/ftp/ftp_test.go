@@ -9,7 +9,17 @@ package ftp
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	_ "github.com/rclone/rclone/backend/local"
 	"github.com/rclone/rclone/cmd/serve/proxy"
@@ -21,6 +31,7 @@ import (
 	"github.com/rclone/rclone/lib/israce"
 	"github.com/rclone/rclone/vfs/vfscommon"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -70,6 +81,132 @@ func TestFTP(t *testing.T) {
 	servetest.Run(t, "ftp", start)
 }
 
+// writeTestCert writes a self signed certificate and key for
+// localhost into dir returning their paths
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+// TestFTPS runs the ftp server with implicit and explicit TLS then
+// runs the unit tests for the ftp remote against it.
+func TestFTPS(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir())
+	for _, mode := range []string{"implicit", "explicit"} {
+		t.Run(mode, func(t *testing.T) {
+			start := func(f fs.Fs) (configmap.Simple, func()) {
+				opt := Opt
+				opt.ListenAddr = testHOST + ":" + testPORT
+				opt.PassivePorts = testPASSIVEPORTRANGE
+				opt.User = testUSER
+				opt.Pass = testPASS
+				opt.TLSCert = certFile
+				opt.TLSKey = keyFile
+				opt.TLSMode = mode
+				opt.TLSRequired = mode == "explicit"
+
+				w, err := newServer(context.Background(), f, &opt, &vfscommon.Opt, &proxy.Opt)
+				assert.NoError(t, err)
+
+				quit := make(chan struct{})
+				go func() {
+					assert.NoError(t, w.Serve())
+					close(quit)
+				}()
+
+				config := configmap.Simple{
+					"type":                 "ftp",
+					"host":                 testHOST,
+					"port":                 testPORT,
+					"user":                 testUSER,
+					"pass":                 obscure.MustObscure(testPASS),
+					"no_check_certificate": "true",
+				}
+				if mode == "explicit" {
+					config["explicit_tls"] = "true"
+				} else {
+					config["tls"] = "true"
+				}
+
+				return config, func() {
+					err := w.Shutdown()
+					assert.NoError(t, err)
+					<-quit
+				}
+			}
+
+			servetest.Run(t, "ftp", start)
+		})
+	}
+}
+
+func TestNewServerErrors(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir())
+	for _, test := range []struct {
+		name    string
+		set     func(opt *Options)
+		wantErr string
+	}{
+		{"CertWithoutKey", func(opt *Options) { opt.TLSCert = certFile }, "--cert and --key must be set together"},
+		{"BadTLSMode", func(opt *Options) { opt.TLSMode = "sometimes" }, "invalid TLS mode"},
+		{"TLSRequiredNoTLS", func(opt *Options) { opt.TLSMode = "explicit"; opt.TLSRequired = true }, "--tls-required needs"},
+		{"TLSRequiredImplicit", func(opt *Options) {
+			opt.TLSCert, opt.TLSKey, opt.TLSRequired = certFile, keyFile, true
+		}, "--tls-required needs"},
+		{"BadPassivePorts", func(opt *Options) { opt.PassivePorts = "30000" }, "invalid format for passive ports"},
+		{"EmptyPassivePorts", func(opt *Options) { opt.PassivePorts = "30000-30000" }, "invalid passive ports"},
+		{"ReversedPassivePorts", func(opt *Options) { opt.PassivePorts = "32000-30000" }, "invalid passive ports"},
+		{"PassivePortTooBig", func(opt *Options) { opt.PassivePorts = "65000-70000" }, "invalid passive ports"},
+		{"PublicIPv6", func(opt *Options) { opt.PublicIP = "2001:db8::1" }, "must be an IPv4 address"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opt := Opt
+			opt.ListenAddr = testHOST + ":" + testPORT
+			test.set(&opt)
+			_, err := newServer(context.Background(), nil, &opt, &vfscommon.Opt, &proxy.Opt)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.wantErr)
+		})
+	}
+}
+
+func TestResolvePublicIP(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"203.0.113.7", "203.0.113.7"},
+		{"::ffff:203.0.113.7", "203.0.113.7"},
+		{"localhost", "127.0.0.1"},
+	} {
+		got, err := resolvePublicIP(ctx, test.in)
+		require.NoError(t, err, test.in)
+		assert.Equal(t, test.want, got, test.in)
+	}
+	_, err := resolvePublicIP(ctx, "2001:db8::1")
+	assert.Error(t, err)
+}
+
 func TestRc(t *testing.T) {
 	if israce.Enabled {
 		t.Skip("Skipping under race detector as underlying library is racy")
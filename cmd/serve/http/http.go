@@ -34,22 +34,30 @@ import (
 )
 
 // OptionsInfo describes the Options in use
-var OptionsInfo = fs.Options{}.
+var OptionsInfo = fs.Options{{
+	Name:    "thumbnails",
+	Default: false,
+	Help:    "Serve thumbnails made by the backend with ?thumbnail=SIZE",
+}}.
 	Add(libhttp.ConfigInfo).
 	Add(libhttp.AuthConfigInfo).
+	Add(libhttp.AuditConfigInfo).
 	Add(libhttp.TemplateConfigInfo)
 
 // Options required for http server
 type Options struct {
 	Auth       libhttp.AuthConfig
+	Audit      libhttp.AuditConfig
 	HTTP       libhttp.Config
 	Template   libhttp.TemplateConfig
 	DisableZip bool
+	Thumbnails bool `config:"thumbnails"`
 }
 
 // DefaultOpt is the default values used for Options
 var DefaultOpt = Options{
 	Auth:     libhttp.DefaultAuthCfg(),
+	Audit:    libhttp.DefaultAuditCfg(),
 	HTTP:     libhttp.DefaultCfg(),
 	Template: libhttp.DefaultTemplateCfg(),
 }
@@ -70,7 +78,7 @@ func init() {
 	flags.AddFlagsFromOptions(flagSet, "", OptionsInfo)
 	vfsflags.AddFlags(flagSet)
 	proxyflags.AddFlags(flagSet)
-	flagSet.BoolVar(&Opt.DisableZip, "disable-zip", false, "Disable zip download of directories")
+	flagSet.BoolVar(&Opt.DisableZip, "disable-zip", false, "Disable zip and tar download of directories")
 	cmdserve.Command.AddCommand(Command)
 	cmdserve.AddRc("http", func(ctx context.Context, f fs.Fs, in rc.Params) (cmdserve.Handle, error) {
 		// Read VFS Opts
@@ -112,7 +120,23 @@ The server will log errors.  Use ` + "`-v`" + ` to see access logs.
 ` + "`--bwlimit`" + ` will be respected for file transfers.  Use ` + "`--stats`" + ` to
 control the stats printing.
 
-` + strings.TrimSpace(libhttp.Help(flagPrefix)+libhttp.TemplateHelp(flagPrefix)+libhttp.AuthHelp(flagPrefix)+vfs.Help()+proxy.Help),
+Directories can be downloaded as an archive made on the fly from the
+remote, without staging it locally, by adding ` + "`?download=zip`" + `,
+` + "`?download=tar` or `?download=tar.gz`" + ` to the URL of the directory.
+Use ` + "`--disable-zip`" + ` to disable this.
+
+Files are served with ` + "`Accept-Ranges`, `ETag` and `Last-Modified`" + `
+headers so video players and transcoders can seek within them and
+resume. The sizes of files on backends such as crypt and chunker are
+the decrypted and joined sizes so ranges are exact.
+
+If ` + "`--thumbnails`" + ` is set then a thumbnail of a file can be fetched
+by adding ` + "`?thumbnail`" + ` or ` + "`?thumbnail=SIZE`" + ` to its URL,
+where SIZE is the largest dimension in pixels (default 256). This only
+works on backends which make thumbnails, such as Google Drive and
+OneDrive, and returns 404 Not Found otherwise.
+
+` + strings.TrimSpace(libhttp.Help(flagPrefix)+libhttp.TemplateHelp(flagPrefix)+libhttp.AuthHelp(flagPrefix)+libhttp.AuditHelp(flagPrefix)+vfs.Help()+proxy.Help),
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.39",
 		"groups":            "Filter",
@@ -187,9 +211,12 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options, vfsOpt *vfscommon.Opt
 		s._vfs = vfs.New(f, vfsOpt)
 	}
 
+	audit := s.opt.Audit
+	audit.Protocol = "http"
 	s.server, err = libhttp.NewServer(ctx,
 		libhttp.WithConfig(s.opt.HTTP),
 		libhttp.WithAuth(s.opt.Auth),
+		libhttp.WithAudit(audit),
 		libhttp.WithTemplate(s.opt.Template),
 	)
 	if err != nil {
@@ -260,20 +287,8 @@ func (s *HTTP) serveDir(w http.ResponseWriter, r *http.Request, dirRemote string
 	}
 	dir := node.(*vfs.Dir)
 
-	if r.URL.Query().Get("download") == "zip" && !s.opt.DisableZip {
-		fs.Infof(dirRemote, "%s: Zipping directory", r.RemoteAddr)
-		zipName := path.Base(dirRemote)
-		if dirRemote == "" {
-			zipName = "root"
-		}
-		w.Header().Set("Content-Disposition", "attachment; filename=\""+zipName+".zip\"")
-		w.Header().Set("Content-Type", "application/zip")
-		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
-		err := vfs.CreateZip(ctx, dir, w)
-		if err != nil {
-			serve.Error(ctx, dirRemote, w, "Failed to create zip", err)
-			return
-		}
+	if !s.opt.DisableZip && serve.IsArchiveRequest(r) {
+		serve.Archive(w, r, dirRemote, dir)
 		return
 	}
 
@@ -336,6 +351,11 @@ func (s *HTTP) serveFile(w http.ResponseWriter, r *http.Request, remote string)
 	obj := entry.(fs.Object)
 	file := node.(*vfs.File)
 
+	if s.opt.Thumbnails && r.URL.Query().Has("thumbnail") {
+		s.serveThumbnail(w, r, remote, obj)
+		return
+	}
+
 	// Set content length if we know how long the object is
 	knownSize := obj.Size() >= 0
 	if knownSize {
@@ -353,6 +373,12 @@ func (s *HTTP) serveFile(w http.ResponseWriter, r *http.Request, remote string)
 	// Set the Last-Modified header to the timestamp
 	w.Header().Set("Last-Modified", file.ModTime().UTC().Format(http.TimeFormat))
 
+	// Set an ETag from the size and timestamp so clients resuming
+	// with If-Range get the range rather than the whole file
+	if knownSize {
+		w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, file.ModTime().UnixNano(), node.Size()))
+	}
+
 	// If HEAD no need to read the object since we have set the headers
 	if r.Method == "HEAD" {
 		return
@@ -393,3 +419,50 @@ func (s *HTTP) serveFile(w http.ResponseWriter, r *http.Request, remote string)
 	}
 
 }
+
+// Thumbnail sizes in pixels
+const (
+	defaultThumbnailSize = 256
+	minThumbnailSize     = 16
+	maxThumbnailSize     = 2048
+)
+
+// serveThumbnail serves the thumbnail the backend made for obj
+func (s *HTTP) serveThumbnail(w http.ResponseWriter, r *http.Request, remote string, obj fs.Object) {
+	ctx := r.Context()
+	size := defaultThumbnailSize
+	if sizeString := r.URL.Query().Get("thumbnail"); sizeString != "" {
+		var err error
+		size, err = strconv.Atoi(sizeString)
+		if err != nil {
+			http.Error(w, "Bad thumbnail size", http.StatusBadRequest)
+			return
+		}
+		size = min(max(size, minThumbnailSize), maxThumbnailSize)
+	}
+	do, ok := obj.(fs.Thumbnailer)
+	if !ok {
+		http.Error(w, "No thumbnail", http.StatusNotFound)
+		return
+	}
+	in, mimeType, err := do.Thumbnail(ctx, size)
+	if errors.Is(err, fs.ErrorNotImplemented) {
+		http.Error(w, "No thumbnail", http.StatusNotFound)
+		return
+	} else if err != nil {
+		serve.Error(ctx, remote, w, "Failed to read thumbnail", err)
+		return
+	}
+	defer fs.CheckClose(in, &err)
+	if mimeType != "" {
+		w.Header().Set("Content-Type", mimeType)
+	}
+	w.Header().Set("Last-Modified", obj.ModTime(ctx).UTC().Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if r.Method == "HEAD" {
+		return
+	}
+	if _, err = io.Copy(w, in); err != nil {
+		fs.Errorf(remote, "Didn't finish writing thumbnail: %v", err)
+	}
+}
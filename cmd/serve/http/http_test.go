@@ -6,6 +6,7 @@ import (
 	"io"
 	stdfs "io/fs"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -18,6 +19,7 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fstest/mockobject"
 	libhttp "github.com/rclone/rclone/lib/http"
 	"github.com/rclone/rclone/vfs/vfscommon"
 	"github.com/stretchr/testify/assert"
@@ -256,6 +258,11 @@ func testGET(t *testing.T, useProxy bool) {
 			Status: http.StatusOK,
 			Golden: "testdata/golden/three.zip",
 		},
+		{
+			URL:    "/three/?download=tar",
+			Status: http.StatusOK,
+			Golden: "testdata/golden/three.tar",
+		},
 	} {
 		method := test.Method
 		if method == "" {
@@ -297,6 +304,55 @@ func TestAuthProxy(t *testing.T) {
 	testGET(t, true)
 }
 
+// thumbnailObject is an fs.Object with a thumbnail
+type thumbnailObject struct {
+	mockobject.Object
+	size int
+}
+
+// Thumbnail returns a fake thumbnail recording the size asked for
+func (o *thumbnailObject) Thumbnail(ctx context.Context, size int) (io.ReadCloser, string, error) {
+	o.size = size
+	if o.Remote() == "none.jpg" {
+		return nil, "", fs.ErrorNotImplemented
+	}
+	return io.NopCloser(strings.NewReader("thumbnail")), "image/png", nil
+}
+
+func TestServeThumbnail(t *testing.T) {
+	s := &HTTP{}
+	for _, test := range []struct {
+		remote   string
+		query    string
+		wantCode int
+		wantSize int
+	}{
+		{remote: "a.jpg", query: "thumbnail", wantCode: http.StatusOK, wantSize: defaultThumbnailSize},
+		{remote: "a.jpg", query: "thumbnail=100", wantCode: http.StatusOK, wantSize: 100},
+		{remote: "a.jpg", query: "thumbnail=1", wantCode: http.StatusOK, wantSize: minThumbnailSize},
+		{remote: "a.jpg", query: "thumbnail=100000", wantCode: http.StatusOK, wantSize: maxThumbnailSize},
+		{remote: "a.jpg", query: "thumbnail=potato", wantCode: http.StatusBadRequest},
+		{remote: "none.jpg", query: "thumbnail", wantCode: http.StatusNotFound, wantSize: defaultThumbnailSize},
+	} {
+		o := &thumbnailObject{Object: mockobject.New(test.remote)}
+		r := httptest.NewRequest("GET", "/"+test.remote+"?"+test.query, nil)
+		w := httptest.NewRecorder()
+		s.serveThumbnail(w, r, test.remote, o)
+		assert.Equal(t, test.wantCode, w.Code, test.query)
+		assert.Equal(t, test.wantSize, o.size, test.query)
+		if test.wantCode == http.StatusOK {
+			assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+			assert.Equal(t, "thumbnail", w.Body.String())
+		}
+	}
+
+	// Objects without thumbnails give 404
+	r := httptest.NewRequest("GET", "/a.txt?thumbnail", nil)
+	w := httptest.NewRecorder()
+	s.serveThumbnail(w, r, "a.txt", mockobject.New("a.txt"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 func TestRc(t *testing.T) {
 	servetest.TestRc(t, rc.Params{
 		"type":           "http",
@@ -30,7 +30,7 @@ import (
 var OptionsInfo = fs.Options{{
 	Name:    "addr",
 	Default: "",
-	Help:    "IPaddress:Port or :Port to bind server to",
+	Help:    "IPaddress:Port, :Port or [unix://]/path/to/socket to bind server to",
 }, {
 	Name:    "nfs_cache_handle_limit",
 	Default: 1000000,
@@ -143,6 +143,12 @@ meaning that it is only available to the local machine. If you want
 other machines to access the NFS mount over local network, you need to
 specify the listening address and port using the |--addr| flag.
 
+|--addr| can also be a unix socket, either |unix:///path/to/socket| or
+just an absolute path, for use with local proxies which forward NFS
+connections. This also supports being run with systemd socket
+activation, in which case it will listen on the first passed FD and
+ignore |--addr|.
+
 Modifying files through the NFS protocol requires VFS caching. Usually
 you will need to specify |--vfs-cache-mode| in order to be able to
 write to the mountpoint (|full| is recommended). If you don't specify
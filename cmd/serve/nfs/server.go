@@ -10,6 +10,7 @@ import (
 	nfs "github.com/willscott/go-nfs"
 
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/listen"
 	"github.com/rclone/rclone/vfs"
 	"github.com/rclone/rclone/vfs/vfscommon"
 )
@@ -41,7 +42,7 @@ func NewServer(ctx context.Context, vfs *vfs.VFS, opt *Options) (s *Server, err
 	if err != nil {
 		return nil, fmt.Errorf("failed to make NFS handler: %w", err)
 	}
-	s.listener, err = net.Listen("tcp", s.opt.ListenAddr)
+	s.listener, err = listen.ActivatedOrListen("tcp", s.opt.ListenAddr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open listening socket: %w", err)
 	}
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/rclone/rclone/fs/cache"
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/fspath"
 	libcache "github.com/rclone/rclone/lib/cache"
 	"github.com/rclone/rclone/vfs"
 	"github.com/rclone/rclone/vfs/vfscommon"
@@ -49,9 +51,25 @@ This config generated must have this extra parameter
 
 - |_root| - root to use for the backend
 
-And it may have this parameter
+And it may have these parameters
 
 - |_obscure| - comma separated strings for parameters to obscure
+- |_read_only| - set to |true| to make the user's files read only
+- |_bwlimit| - limit on the data the user can read and write through
+  the server in bytes/s, e.g. |10M|
+- |_quota| - the most data the user can store, e.g. |5G|
+
+Instead of the |type| and the config for a backend, the output may
+have |_remote| set to the name of a remote in the config file, e.g.
+|"_remote": "s3:bucket"|. The user will then be given |_root| within
+that remote as their root, so many users can share one remote each
+with their own directory.
+
+The quota is checked against the size of the files in the user's root,
+which is measured again every |--dir-cache-time|, plus the data
+written since. Writes which would take the user over the quota fail
+with a "no space left on device" error and the quota is reported as
+the size of the disk to clients which ask for it.
 
 If password authentication was used by the client, input to the proxy
 process (on STDIN) would look similar to this:
@@ -92,6 +110,18 @@ that since |_obscure| is set to |pass|, rclone will obscure the |pass|
 parameter before creating the backend (which is required for sftp
 backends).
 
+To give the user the directory |users/me| on the remote |s3:| instead,
+read only and with a bandwidth limit of 1 MiB/s, return
+
+|||json
+{
+  "_remote": "s3:",
+  "_root": "users/me",
+  "_read_only": "true",
+  "_bwlimit": "1M"
+}
+|||
+
 The program can manipulate the supplied |user| in any way, for example
 to make proxy to many different sftp backends, you could make the
 |user| be |user@example.com| and then set the |host| to |example.com|
@@ -196,6 +226,80 @@ func (p *Proxy) run(in map[string]string) (config configmap.Simple, err error) {
 	return config, nil
 }
 
+// newFsFn returns a function to make the Fs for user described by
+// config.
+//
+// This is either a backend of "type" made from config or, if
+// "_remote" is set instead, root within that remote from the config
+// file.
+func (p *Proxy) newFsFn(user, root string, config configmap.Simple) (func() (fs.Fs, error), error) {
+	if remote, ok := config.Get("_remote"); ok {
+		if _, ok := config.Get("type"); ok {
+			return nil, errors.New("proxy: can't set both type and _remote in result")
+		}
+		fsString := fspath.JoinRootPath(remote, root)
+		return func() (fs.Fs, error) {
+			return cache.Get(p.ctx, fsString)
+		}, nil
+	}
+	fsName, ok := config.Get("type")
+	if !ok {
+		return nil, errors.New("proxy: type not set in result")
+	}
+
+	// Find the backend
+	fsInfo, err := fs.Find(fsName)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: couldn't find backend for %q: %w", fsName, err)
+	}
+
+	// base name of config on user name.  This may appear in logs
+	name := "proxy-" + user
+	fsString := name + ":" + root
+
+	return func() (fs.Fs, error) {
+		// Create the Fs from the cache
+		return cache.GetFn(p.ctx, fsString, func(ctx context.Context, fsString string) (fs.Fs, error) {
+			// Update the config with the default values
+			for i := range fsInfo.Options {
+				o := &fsInfo.Options[i]
+				if _, found := config.Get(o.Name); !found && o.Default != nil && o.String() != "" {
+					config.Set(o.Name, o.String())
+				}
+			}
+			return fsInfo.NewFs(ctx, name, root, config)
+		})
+	}, nil
+}
+
+// userVFSOptions returns the VFS options for a user, applying the
+// _read_only, _bwlimit and _quota limits in config to the defaults.
+func (p *Proxy) userVFSOptions(config configmap.Simple) (*vfscommon.Options, error) {
+	opt := p.vfsOpt
+	if value, ok := config.Get("_read_only"); ok {
+		readOnly, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("proxy: bad _read_only %q: %w", value, err)
+		}
+		// The user can't override a read only server
+		opt.ReadOnly = opt.ReadOnly || readOnly
+	}
+	for _, limit := range []struct {
+		key string
+		opt *fs.SizeSuffix
+	}{
+		{"_bwlimit", &opt.BwLimit},
+		{"_quota", &opt.Quota},
+	} {
+		if value, ok := config.Get(limit.key); ok {
+			if err := limit.opt.Set(value); err != nil {
+				return nil, fmt.Errorf("proxy: bad %s %q: %w", limit.key, value, err)
+			}
+		}
+	}
+	return &opt, nil
+}
+
 // call runs the auth proxy and returns a cacheEntry and an error
 func (p *Proxy) call(user, auth string, isPublicKey bool) (value any, err error) {
 	var config configmap.Simple
@@ -217,38 +321,22 @@ func (p *Proxy) call(user, auth string, isPublicKey bool) (value any, err error)
 	}
 
 	// Look for required fields in the answer
-	fsName, ok := config.Get("type")
-	if !ok {
-		return nil, errors.New("proxy: type not set in result")
-	}
 	root, ok := config.Get("_root")
 	if !ok {
 		return nil, errors.New("proxy: _root not set in result")
 	}
-
-	// Find the backend
-	fsInfo, err := fs.Find(fsName)
+	vfsOpt, err := p.userVFSOptions(config)
 	if err != nil {
-		return nil, fmt.Errorf("proxy: couldn't find backend for %q: %w", fsName, err)
+		return nil, err
+	}
+	newFs, err := p.newFsFn(user, root, config)
+	if err != nil {
+		return nil, err
 	}
-
-	// base name of config on user name.  This may appear in logs
-	name := "proxy-" + user
-	fsString := name + ":" + root
 
 	// Look for fs in the VFS cache
 	value, err = p.vfsCache.Get(user, func(key string) (value any, ok bool, err error) {
-		// Create the Fs from the cache
-		f, err := cache.GetFn(p.ctx, fsString, func(ctx context.Context, fsString string) (fs.Fs, error) {
-			// Update the config with the default values
-			for i := range fsInfo.Options {
-				o := &fsInfo.Options[i]
-				if _, found := config.Get(o.Name); !found && o.Default != nil && o.String() != "" {
-					config.Set(o.Name, o.String())
-				}
-			}
-			return fsInfo.NewFs(ctx, name, root, config)
-		})
+		f, err := newFs()
 		if err != nil {
 			return nil, false, err
 		}
@@ -257,7 +345,7 @@ func (p *Proxy) call(user, auth string, isPublicKey bool) (value any, err error)
 		// need to in memory. An attacker would find it easier to go
 		// after the unencrypted password in memory most likely.
 		entry := cacheEntry{
-			vfs:    vfs.New(f, &p.vfsOpt),
+			vfs:    vfs.New(f, vfsOpt),
 			pwHash: sha256.Sum256([]byte(auth)),
 		}
 		return entry, true, nil
@@ -6,6 +6,7 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"encoding/base64"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -234,3 +235,56 @@ func TestRun(t *testing.T) {
 		assert.Equal(t, 1, p.vfsCache.Entries())
 	})
 }
+
+func TestUserVFSOptions(t *testing.T) {
+	vfsOpt := vfscommon.Opt
+	p := New(context.Background(), &Opt, &vfsOpt)
+
+	opt, err := p.userVFSOptions(configmap.Simple{"_root": ""})
+	require.NoError(t, err)
+	assert.Equal(t, vfsOpt, *opt)
+
+	opt, err = p.userVFSOptions(configmap.Simple{
+		"_read_only": "true",
+		"_bwlimit":   "1M",
+		"_quota":     "5G",
+	})
+	require.NoError(t, err)
+	assert.True(t, opt.ReadOnly)
+	assert.Equal(t, fs.Mebi, opt.BwLimit)
+	assert.Equal(t, 5*fs.Gibi, opt.Quota)
+	assert.False(t, p.vfsOpt.ReadOnly, "defaults must not be modified")
+
+	// A read only server stays read only
+	vfsOpt.ReadOnly = true
+	p = New(context.Background(), &Opt, &vfsOpt)
+	opt, err = p.userVFSOptions(configmap.Simple{"_read_only": "false"})
+	require.NoError(t, err)
+	assert.True(t, opt.ReadOnly)
+
+	for _, config := range []configmap.Simple{
+		{"_read_only": "potato"},
+		{"_bwlimit": "potato"},
+		{"_quota": "potato"},
+	} {
+		_, err = p.userVFSOptions(config)
+		assert.Error(t, err, config)
+	}
+}
+
+func TestNewFsFnRemote(t *testing.T) {
+	p := New(context.Background(), &Opt, &vfscommon.Opt)
+	dir := t.TempDir()
+
+	newFs, err := p.newFsFn("user", "users/me", configmap.Simple{"_remote": dir})
+	require.NoError(t, err)
+	f, err := newFs()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "users", "me"), filepath.FromSlash(f.Root()))
+
+	_, err = p.newFsFn("user", "", configmap.Simple{"_remote": dir, "type": "local"})
+	assert.ErrorContains(t, err, "can't set both")
+
+	_, err = p.newFsFn("user", "", configmap.Simple{})
+	assert.ErrorContains(t, err, "type not set")
+}
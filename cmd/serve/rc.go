@@ -33,11 +33,67 @@ type Handle interface {
 
 // Describes a running server
 type server struct {
-	ID      string     `json:"id"`     // id of the server
-	Addr    string     `json:"addr"`   // address of the server
-	Params  rc.Params  `json:"params"` // Parameters used to start the server
-	h       Handle     `json:"-"`      // control the server
-	errChan chan error `json:"-"`      // receive errors from the server process
+	ID      string          `json:"id"`     // id of the server
+	Addr    string          `json:"addr"`   // address of the server
+	Params  rc.Params       `json:"params"` // Parameters used to start the server
+	h       Handle          `json:"-"`      // control the server - nil if it failed to restart
+	errChan chan error      `json:"-"`      // receive errors from the server process
+	ctx     context.Context // context with the config the server was started with
+	typ     string          // type of the server
+	stopped bool            // set if the server process has returned
+	err     error           // error the server process returned
+}
+
+// check reads the error from the server process if it has stopped
+//
+// call with serveMu held
+func (s *server) check() {
+	if s.stopped {
+		return
+	}
+	select {
+	case err := <-s.errChan:
+		s.stopped = true
+		s.err = err
+		if s.err == nil {
+			s.err = errors.New("server stopped")
+		}
+	default:
+	}
+}
+
+// ServerHealth describes whether a server started with serve/start
+// is still running
+type ServerHealth struct {
+	ID      string `json:"id"`              // id of the server
+	Type    string `json:"type"`            // type of the server, eg "nfs"
+	Addr    string `json:"addr"`            // address of the server
+	Running bool   `json:"running"`         // set if the server is running
+	Error   string `json:"error,omitempty"` // why the server stopped
+}
+
+// ListHealth returns the health of the running servers sorted by ID
+func ListHealth() []ServerHealth {
+	serveMu.Lock()
+	defer serveMu.Unlock()
+	list := []ServerHealth{}
+	for _, s := range servers {
+		s.check()
+		health := ServerHealth{
+			ID:      s.ID,
+			Type:    s.typ,
+			Addr:    s.Addr,
+			Running: !s.stopped,
+		}
+		if s.err != nil {
+			health.Error = s.err.Error()
+		}
+		list = append(list, health)
+	}
+	slices.SortFunc(list, func(a, b ServerHealth) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return list
 }
 
 // Fn starts an rclone serve command
@@ -114,30 +170,62 @@ func startRc(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	serveMu.Lock()
 	defer serveMu.Unlock()
 
+	// Make a background context and copy the config back.
+	newCtx := context.Background()
+	newCtx = fs.CopyConfig(newCtx, ctx)
+	newCtx = filter.CopyConfig(newCtx, ctx)
+
+	h, errChan, err := start(newCtx, serveType, in)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store it for later
+	runningServer := server{
+		ID:      fmt.Sprintf("%s-%08x", serveType, rand.Uint32()),
+		Params:  in,
+		Addr:    h.Addr().String(),
+		h:       h,
+		errChan: errChan,
+		ctx:     newCtx,
+		typ:     serveType,
+	}
+	servers[runningServer.ID] = &runningServer
+
+	out = rc.Params{
+		"id":   runningServer.ID,
+		"addr": runningServer.Addr,
+	}
+	return out, nil
+}
+
+// start runs the server of serveType with the parameters in in the
+// background, returning a channel which receives its error when it
+// stops.
+//
+// ctx should be a background context with the config for the server.
+//
+// call with serveMu held
+func start(ctx context.Context, serveType string, in rc.Params) (h Handle, errChan chan error, err error) {
 	serveFn := serveFns[serveType]
 	if serveFn == nil {
-		return nil, fmt.Errorf("could not find serve type=%q", serveType)
+		return nil, nil, fmt.Errorf("could not find serve type=%q", serveType)
 	}
 
 	// Get Fs.fs to be served from fs parameter in the params
 	f, err := rc.GetFs(ctx, in)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Make a background context and copy the config back.
-	newCtx := context.Background()
-	newCtx = fs.CopyConfig(newCtx, ctx)
-	newCtx = filter.CopyConfig(newCtx, ctx)
-
 	// Start the server
-	h, err := serveFn(newCtx, f, in)
+	h, err = serveFn(ctx, f, in)
 	if err != nil {
-		return nil, fmt.Errorf("could not start serve %q: %w", serveType, err)
+		return nil, nil, fmt.Errorf("could not start serve %q: %w", serveType, err)
 	}
 
 	// Start the server running in the background
-	errChan := make(chan error, 1)
+	errChan = make(chan error, 1)
 	go func() {
 		errChan <- h.Serve()
 		close(errChan)
@@ -153,26 +241,11 @@ func startRc(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 		err = nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error when starting serve %q: %w", serveType, err)
+		return nil, nil, fmt.Errorf("error when starting serve %q: %w", serveType, err)
 	}
 
-	// Store it for later
-	runningServer := server{
-		ID:      fmt.Sprintf("%s-%08x", serveType, rand.Uint32()),
-		Params:  in,
-		Addr:    h.Addr().String(),
-		h:       h,
-		errChan: errChan,
-	}
-	servers[runningServer.ID] = &runningServer
-
-	out = rc.Params{
-		"id":   runningServer.ID,
-		"addr": runningServer.Addr,
-	}
-
-	fs.Debugf(f, "Started serve %s on %s", serveType, runningServer.Addr)
-	return out, nil
+	fs.Debugf(f, "Started serve %s on %s", serveType, h.Addr())
+	return h, errChan, nil
 }
 
 func init() {
@@ -208,12 +281,116 @@ func stopRc(_ context.Context, in rc.Params) (out rc.Params, err error) {
 	if s == nil {
 		return nil, fmt.Errorf("server with id=%q not found", id)
 	}
-	err = s.h.Shutdown()
+	if s.h != nil {
+		err = s.h.Shutdown()
+	}
 	<-s.errChan // ignore server return error - likely is "use of closed network connection"
 	delete(servers, id)
 	return nil, err
 }
 
+func init() {
+	rc.Add(rc.Call{
+		Path:         "serve/restart",
+		AuthRequired: true,
+		Fn:           restartRc,
+		Title:        "Restart active serves with the current config",
+		Help: q(`Drains running |serve| instances and starts them again.
+
+This takes the following parameters:
+
+- id: as returned by serve/start (optional, default all servers)
+
+Each server is shut down gracefully, so it stops accepting new
+connections and waits for requests in progress to finish, then it is
+started again with the same ID, parameters and options as it was
+started with. Its remote is re-created from the current config, so
+use this after |config/reload| to pick up changed credentials without
+restarting rclone.
+
+There will be a short time while each server restarts when
+connections to it are refused. Servers started with a random port,
+eg |addr=:0|, may come back on a different port - see |serve/list|.
+
+This returns
+
+- restarted: list of the IDs of the servers restarted
+
+If a server fails to restart it is left stopped and an error is
+returned. It can be restarted again or removed with |serve/stop|.
+
+Example:
+
+    rclone rc config/reload
+    rclone rc serve/restart
+`),
+	})
+}
+
+// restartRc drains and restarts servers
+func restartRc(_ context.Context, in rc.Params) (out rc.Params, err error) {
+	id, err := in.GetString("id")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	serveMu.Lock()
+	defer serveMu.Unlock()
+	var ids []string
+	if id != "" {
+		if servers[id] == nil {
+			return nil, fmt.Errorf("server with id=%q not found", id)
+		}
+		ids = append(ids, id)
+	} else {
+		for id := range servers {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+	}
+	ec := errcount.New()
+	restarted := []string{}
+	for _, id := range ids {
+		err := servers[id].restart()
+		if err != nil {
+			ec.Add(fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		restarted = append(restarted, id)
+	}
+	out = rc.Params{
+		"restarted": restarted,
+	}
+	return out, ec.Err("error when restarting server")
+}
+
+// restart shuts the server down gracefully and starts it again with
+// the parameters it was started with.
+//
+// call with serveMu held
+func (s *server) restart() error {
+	if s.h != nil {
+		err := s.h.Shutdown()
+		if err != nil {
+			fs.Errorf(nil, "Error when stopping serve %s for restart: %v", s.ID, err)
+		}
+	}
+	<-s.errChan // ignore server return error - likely is "use of closed network connection"
+	h, errChan, err := start(s.ctx, s.typ, s.Params)
+	if err != nil {
+		// Leave the server marked stopped with the error
+		s.h = nil
+		s.stopped = true
+		s.err = err
+		return err
+	}
+	s.h = h
+	s.errChan = errChan
+	s.Addr = h.Addr().String()
+	s.stopped = false
+	s.err = nil
+	return nil
+}
+
 func init() {
 	rc.Add(rc.Call{
 		Path:         "serve/types",
@@ -347,7 +524,9 @@ func stopAll(_ context.Context, in rc.Params) (out rc.Params, err error) {
 	defer serveMu.Unlock()
 	ec := errcount.New()
 	for id, s := range servers {
-		ec.Add(s.h.Shutdown())
+		if s.h != nil {
+			ec.Add(s.h.Shutdown())
+		}
 		<-s.errChan // ignore server return error - likely is "use of closed network connection"
 		delete(servers, id)
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net"
+	"slices"
 	"testing"
 
 	"github.com/rclone/rclone/fs"
@@ -178,3 +179,55 @@ func TestRcStopAll(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 0, len(servers))
 }
+
+func TestRcRestart(t *testing.T) {
+	newTest(t)
+	serveStart := rc.Calls.Get("serve/start")
+	serveRestart := rc.Calls.Get("serve/restart")
+
+	AddRc("dummy", newServer)
+
+	var ids []string
+	for range 2 {
+		out, err := serveStart.Fn(context.Background(), rc.Params{"fs": ":mockfs:", "type": "dummy"})
+		require.NoError(t, err)
+		ids = append(ids, out["id"].(string))
+	}
+	slices.Sort(ids)
+
+	// Restart one server
+	old := servers[ids[0]].h.(*dummyServer)
+	other := servers[ids[1]].h.(*dummyServer)
+	out, err := serveRestart.Fn(context.Background(), rc.Params{"id": ids[0]})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{"restarted": []string{ids[0]}}, out)
+	assert.True(t, old.shutdownCalled)
+	assert.False(t, other.shutdownCalled)
+	assert.NotSame(t, old, servers[ids[0]].h)
+	assert.Equal(t, 2, len(servers))
+
+	// Restart all servers
+	out, err = serveRestart.Fn(context.Background(), rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{"restarted": ids}, out)
+	assert.True(t, other.shutdownCalled)
+
+	// Restart a server which doesn't exist
+	_, err = serveRestart.Fn(context.Background(), rc.Params{"id": "nonexistent"})
+	assert.ErrorContains(t, err, "not found")
+
+	// A server which fails to restart is left stopped
+	AddRc("dummy", newServerError)
+	_, err = serveRestart.Fn(context.Background(), rc.Params{"id": ids[0]})
+	assert.ErrorContains(t, err, "serve error")
+	health := ListHealth()
+	require.Len(t, health, 2)
+	assert.False(t, health[0].Running)
+	assert.Contains(t, health[0].Error, "serve error")
+	assert.True(t, health[1].Running)
+
+	// It can still be stopped
+	_, err = rc.Calls.Get("serve/stop").Fn(context.Background(), rc.Params{"id": ids[0]})
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(servers))
+}
@@ -0,0 +1,146 @@
+package restic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/rc"
+)
+
+// quarantineTimeFormat is the format of the directories in the
+// quarantine which hold each quarantined file
+const quarantineTimeFormat = "20060102T150405.000000000Z"
+
+// inQuarantine returns true if remote is in the quarantine directory
+func (s *server) inQuarantine(remote string) bool {
+	dir := s.opt.QuarantineDir
+	return dir != "" && (remote == dir || strings.HasPrefix(remote, dir+"/"))
+}
+
+// checkQuarantine stops clients accessing the quarantine directory
+func (s *server) checkQuarantine(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote, _ := r.Context().Value(ContextRemoteKey).(string)
+		if s.inQuarantine(remote) {
+			fs.Errorf(remote, "%s request: refusing to access quarantine", r.Method)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// quarantine moves o into the quarantine directory instead of
+// removing or overwriting it
+func (s *server) quarantine(ctx context.Context, o fs.Object) error {
+	remote := o.Remote()
+	dst := path.Join(s.opt.QuarantineDir, time.Now().UTC().Format(quarantineTimeFormat), remote)
+	_, err := operations.Move(ctx, s.f, nil, dst, o)
+	if err != nil {
+		return fmt.Errorf("failed to quarantine: %w", err)
+	}
+	fs.Logf(remote, "Moved to quarantine as %q", dst)
+	s.cache.remove(remote)
+	return nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "restic/purge-quarantine",
+		AuthRequired: true,
+		Fn:           rcPurgeQuarantine,
+		Title:        "Purge the quarantine of a restic server",
+		Help: `This permanently deletes files which rclone serve restic moved
+to its quarantine directory with --quarantine-dir.
+
+This takes the following parameters:
+
+- fs - the remote being served by rclone serve restic (required)
+- quarantineDir - the quarantine directory relative to fs (required)
+- olderThan - only purge files quarantined longer ago than this, e.g. "7d" (optional)
+
+It returns
+
+- purged - list of the quarantine directories purged
+
+Each file is quarantined in a directory named after the time it was
+quarantined so these can be reviewed with, for example, rclone lsl
+before purging them.
+
+Eg
+
+    rclone rc restic/purge-quarantine fs=remote:backup quarantineDir=.quarantine olderThan=7d
+`,
+	})
+}
+
+// rcPurgeQuarantine purges the quarantine directory
+func rcPurgeQuarantine(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	f, err := rc.GetFs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	quarantineDir, err := in.GetString("quarantineDir")
+	if err != nil {
+		return nil, err
+	}
+	quarantineDir = strings.Trim(quarantineDir, "/")
+	if quarantineDir == "" {
+		return nil, errors.New("quarantineDir must not be empty")
+	}
+	var olderThan fs.Duration
+	olderThanString, err := in.GetString("olderThan")
+	if err == nil {
+		err = olderThan.Set(olderThanString)
+	}
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	purged, err := purgeQuarantine(ctx, f, quarantineDir, time.Duration(olderThan))
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{
+		"purged": purged,
+	}, nil
+}
+
+// purgeQuarantine deletes the directories in quarantineDir on f which
+// were quarantined longer than olderThan ago
+func purgeQuarantine(ctx context.Context, f fs.Fs, quarantineDir string, olderThan time.Duration) (purged []string, err error) {
+	entries, err := f.List(ctx, quarantineDir)
+	if errors.Is(err, fs.ErrorDirNotFound) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	purged = []string{}
+	for _, entry := range entries {
+		dir, ok := entry.(fs.Directory)
+		if !ok {
+			continue
+		}
+		when, err := time.Parse(quarantineTimeFormat, path.Base(dir.Remote()))
+		if err != nil {
+			fs.Debugf(dir, "Ignoring unknown directory in quarantine")
+			continue
+		}
+		if when.After(cutoff) {
+			continue
+		}
+		err = operations.Purge(ctx, f, dir.Remote())
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge %q: %w", dir.Remote(), err)
+		}
+		purged = append(purged, dir.Remote())
+	}
+	return purged, nil
+}
@@ -42,6 +42,10 @@ var OptionsInfo = fs.Options{{
 	Name:    "append_only",
 	Default: false,
 	Help:    "Disallow deletion of repository data",
+}, {
+	Name:    "quarantine_dir",
+	Default: "",
+	Help:    "Move deleted and overwritten files to this directory instead of removing them",
 }, {
 	Name:    "private_repos",
 	Default: false,
@@ -52,16 +56,19 @@ var OptionsInfo = fs.Options{{
 	Help:    "Cache listed objects",
 }}.
 	Add(libhttp.ConfigInfo).
-	Add(libhttp.AuthConfigInfo)
+	Add(libhttp.AuthConfigInfo).
+	Add(libhttp.AuditConfigInfo)
 
 // Options required for http server
 type Options struct {
-	Auth         libhttp.AuthConfig
-	HTTP         libhttp.Config
-	Stdio        bool `config:"stdio"`
-	AppendOnly   bool `config:"append_only"`
-	PrivateRepos bool `config:"private_repos"`
-	CacheObjects bool `config:"cache_objects"`
+	Auth          libhttp.AuthConfig
+	Audit         libhttp.AuditConfig
+	HTTP          libhttp.Config
+	Stdio         bool   `config:"stdio"`
+	AppendOnly    bool   `config:"append_only"`
+	QuarantineDir string `config:"quarantine_dir"`
+	PrivateRepos  bool   `config:"private_repos"`
+	CacheObjects  bool   `config:"cache_objects"`
 }
 
 // Opt is options set by command line flags
@@ -180,12 +187,37 @@ $ export RESTIC_REPOSITORY=rest:http://localhost:8080/user2repo/
 # backup user2 stuff
 ` + "```" + `
 
+#### Quarantine
+
+The ` + "`--append-only`" + ` flag stops restic deleting or overwriting
+repository data, but this also stops ` + "`restic forget --prune`" + `
+from working.
+
+Instead, the ` + "`--quarantine-dir`" + ` flag can be used to move any
+files which restic deletes or overwrites into a directory in the remote
+rather than removing them. This protects the backups from an attacker
+who obtains the restic credentials while still allowing pruning. Lock
+files are deleted as normal. Clients can't access the quarantine
+directory.
+
+` + "```console" + `
+rclone serve restic remote:backup --quarantine-dir .quarantine
+` + "```" + `
+
+Each file is moved to a directory named after the time it was
+quarantined, so after reviewing them they can be purged with the
+` + "`restic/purge-quarantine`" + ` remote control call, for example:
+
+` + "```console" + `
+rclone rc restic/purge-quarantine fs=remote:backup quarantineDir=.quarantine olderThan=7d
+` + "```" + `
+
 #### Private repositories
 
 The` + "`--private-repos`" + ` flag can be used to limit users to repositories starting
 with a path of ` + "`/<username>/`" + `.
 
-` + strings.TrimSpace(libhttp.Help(flagPrefix)+libhttp.AuthHelp(flagPrefix)),
+` + strings.TrimSpace(libhttp.Help(flagPrefix)+libhttp.AuthHelp(flagPrefix)+libhttp.AuditHelp(flagPrefix)),
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.40",
 	},
@@ -286,13 +318,17 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options) (s *server, err error
 		cache: newCache(opt.CacheObjects),
 		opt:   *opt,
 	}
+	s.opt.QuarantineDir = strings.Trim(s.opt.QuarantineDir, "/")
 	// Don't bind any HTTP listeners if running with --stdio
 	if opt.Stdio {
 		opt.HTTP.ListenAddr = nil
 	}
+	audit := opt.Audit
+	audit.Protocol = "restic"
 	s.server, err = libhttp.NewServer(ctx,
 		libhttp.WithConfig(opt.HTTP),
 		libhttp.WithAuth(opt.Auth),
+		libhttp.WithAudit(audit),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init server: %w", err)
@@ -352,6 +388,9 @@ func (s *server) Bind(router chi.Router) {
 		middleware.SetHeader("Server", "rclone/"+fs.Version),
 		WithRemote,
 	)
+	if s.opt.QuarantineDir != "" {
+		router.Use(s.checkQuarantine)
+	}
 
 	if s.opt.PrivateRepos {
 		router.Route("/{userID}", func(r chi.Router) {
@@ -410,7 +449,18 @@ func (s *server) postObject(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
-	if s.opt.AppendOnly {
+	if s.opt.QuarantineDir != "" && !isLock(r) {
+		// quarantine the file if it exists already
+		o, err := s.newObject(r.Context(), remote)
+		if err == nil {
+			err = s.quarantine(r.Context(), o)
+			if err != nil {
+				fs.Errorf(remote, "Post request: %v", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+		}
+	} else if s.opt.AppendOnly {
 		// make sure the file does not exist yet
 		_, err := s.newObject(r.Context(), remote)
 		if err == nil {
@@ -441,14 +491,10 @@ func (s *server) deleteObject(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
-	if s.opt.AppendOnly {
-		parts := strings.Split(r.URL.Path, "/")
-
-		// if path doesn't end in "/locks/:name", disallow the operation
-		if len(parts) < 2 || parts[len(parts)-2] != "locks" {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
-			return
-		}
+	// in append-only mode only lock files can be deleted unless quarantining
+	if s.opt.AppendOnly && s.opt.QuarantineDir == "" && !isLock(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
 	}
 
 	o, err := s.newObject(r.Context(), remote)
@@ -458,6 +504,14 @@ func (s *server) deleteObject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.opt.QuarantineDir != "" && !isLock(r) {
+		if err := s.quarantine(r.Context(), o); err != nil {
+			fs.Errorf(remote, "Delete request: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
 	if err := o.Remove(r.Context()); err != nil {
 		fs.Errorf(remote, "Delete request remove error: %v", err)
 		if errors.Is(err, fs.ErrorObjectNotFound) {
@@ -472,6 +526,13 @@ func (s *server) deleteObject(w http.ResponseWriter, r *http.Request) {
 	s.cache.remove(remote)
 }
 
+// isLock returns true if the request is for a lock file, that is the
+// path ends in "/locks/:name"
+func isLock(r *http.Request) bool {
+	parts := strings.Split(r.URL.Path, "/")
+	return len(parts) >= 2 && parts[len(parts)-2] == "locks"
+}
+
 // listItem is an element returned for the restic v2 list response
 type listItem struct {
 	Name string `json:"name"`
@@ -0,0 +1,78 @@
+package restic
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configfile"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listQuarantine returns the files in the quarantine without their time directory
+func listQuarantine(t *testing.T, f fs.Fs) (names []string) {
+	err := operations.ListFn(context.Background(), f, func(o fs.Object) {
+		remote := o.Remote()
+		if strings.HasPrefix(remote, ".quarantine/") {
+			parts := strings.SplitN(remote, "/", 3)
+			names = append(names, parts[2])
+		}
+	})
+	require.NoError(t, err)
+	return names
+}
+
+// TestResticQuarantine checks deleted and overwritten files are quarantined
+func TestResticQuarantine(t *testing.T) {
+	ctx := context.Background()
+	configfile.Install()
+
+	opt := newOpt()
+	opt.AppendOnly = true
+	opt.QuarantineDir = ".quarantine"
+	f := cmd.NewFsSrc([]string{t.TempDir()})
+	s, err := newServer(ctx, f, &opt)
+	require.NoError(t, err)
+	router := s.server.Router()
+
+	for _, seq := range []TestRequest{
+		{newRequest(t, "POST", "/?create=true", nil), []wantFunc{wantCode(http.StatusOK)}},
+		{newRequest(t, "POST", "/config", strings.NewReader("config")), []wantFunc{wantCode(http.StatusOK)}},
+		{newRequest(t, "POST", "/config", strings.NewReader("new config")), []wantFunc{wantCode(http.StatusOK)}},
+		{newRequest(t, "GET", "/config", nil), []wantFunc{wantCode(http.StatusOK), wantBody("new config")}},
+		{newRequest(t, "POST", "/data/0123456789", strings.NewReader("data")), []wantFunc{wantCode(http.StatusOK)}},
+		{newRequest(t, "DELETE", "/data/0123456789", nil), []wantFunc{wantCode(http.StatusOK)}},
+		{newRequest(t, "GET", "/data/0123456789", nil), []wantFunc{wantCode(http.StatusNotFound)}},
+		{newRequest(t, "POST", "/locks/0123456789", strings.NewReader("lock")), []wantFunc{wantCode(http.StatusOK)}},
+		{newRequest(t, "DELETE", "/locks/0123456789", nil), []wantFunc{wantCode(http.StatusOK)}},
+		{newRequest(t, "GET", "/.quarantine/", nil), []wantFunc{wantCode(http.StatusForbidden)}},
+		{newRequest(t, "DELETE", "/.quarantine/x", nil), []wantFunc{wantCode(http.StatusForbidden)}},
+	} {
+		t.Logf("%v %v", seq.req.Method, seq.req.URL.Path)
+		checkRequest(t, router.ServeHTTP, seq.req, seq.want)
+	}
+
+	assert.ElementsMatch(t, []string{"config", "data/01/0123456789"}, listQuarantine(t, f))
+
+	// purge nothing as too new
+	purge := rc.Calls.Get("restic/purge-quarantine")
+	require.NotNil(t, purge)
+	out, err := purge.Fn(ctx, rc.Params{"fs": fs.ConfigString(f), "quarantineDir": ".quarantine", "olderThan": "1h"})
+	require.NoError(t, err)
+	assert.Len(t, out["purged"], 0)
+	assert.Len(t, listQuarantine(t, f), 2)
+
+	// purge everything
+	time.Sleep(10 * time.Millisecond)
+	out, err = purge.Fn(ctx, rc.Params{"fs": fs.ConfigString(f), "quarantineDir": ".quarantine"})
+	require.NoError(t, err)
+	assert.Len(t, out["purged"], 2)
+	assert.Len(t, listQuarantine(t, f), 0)
+}
@@ -0,0 +1,367 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/rclone/gofakes3/signature"
+	"github.com/rclone/rclone/fs"
+)
+
+// Policy effects
+const (
+	effectAllow = "Allow"
+	effectDeny  = "Deny"
+)
+
+// arnPrefix is stripped from resources in policies
+const arnPrefix = "arn:aws:s3:::"
+
+// stringList is a JSON value which may be a string or a list of strings
+type stringList []string
+
+// UnmarshalJSON reads a string or a list of strings
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("expecting a string or a list of strings")
+	}
+	*l = list
+	return nil
+}
+
+// policyStatement is a single rule in a policy
+type policyStatement struct {
+	Sid       string     `json:"Sid"`
+	Effect    string     `json:"Effect"`
+	Principal stringList `json:"Principal"`
+	Action    stringList `json:"Action"`
+	Resource  stringList `json:"Resource"`
+
+	principals []*regexp.Regexp
+	actions    []*regexp.Regexp
+	resources  []*regexp.Regexp
+}
+
+// policy controls which access keys can do what in the server
+type policy struct {
+	Version   string             `json:"Version"`
+	Statement []*policyStatement `json:"Statement"`
+}
+
+// globToRegexp converts a glob with * and ? wildcards matching any
+// characters including / into an anchored regexp
+func globToRegexp(glob string, caseInsensitive bool) (*regexp.Regexp, error) {
+	var re strings.Builder
+	if caseInsensitive {
+		re.WriteString("(?i)")
+	}
+	re.WriteString("^")
+	for _, c := range glob {
+		switch c {
+		case '*':
+			re.WriteString(".*")
+		case '?':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.Compile(re.String())
+}
+
+// compileGlobs compiles the globs in list
+func compileGlobs(list stringList, caseInsensitive bool, transform func(string) string) (res []*regexp.Regexp, err error) {
+	for _, glob := range list {
+		if transform != nil {
+			glob = transform(glob)
+		}
+		re, err := globToRegexp(glob, caseInsensitive)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// matchAny returns true if s matches any of res
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePolicy parses and checks the policy in data
+func parsePolicy(data []byte) (*policy, error) {
+	p := new(policy)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return nil, err
+	}
+	for i, st := range p.Statement {
+		name := st.Sid
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if st.Effect != effectAllow && st.Effect != effectDeny {
+			return nil, fmt.Errorf("statement %s: Effect must be %q or %q not %q", name, effectAllow, effectDeny, st.Effect)
+		}
+		if len(st.Action) == 0 || len(st.Resource) == 0 {
+			return nil, fmt.Errorf("statement %s: Action and Resource must be set", name)
+		}
+		if len(st.Principal) == 0 {
+			st.Principal = stringList{"*"}
+		}
+		var err error
+		if st.principals, err = compileGlobs(st.Principal, false, nil); err != nil {
+			return nil, fmt.Errorf("statement %s: bad Principal: %w", name, err)
+		}
+		if st.actions, err = compileGlobs(st.Action, true, nil); err != nil {
+			return nil, fmt.Errorf("statement %s: bad Action: %w", name, err)
+		}
+		if st.resources, err = compileGlobs(st.Resource, false, func(s string) string {
+			return strings.TrimPrefix(s, arnPrefix)
+		}); err != nil {
+			return nil, fmt.Errorf("statement %s: bad Resource: %w", name, err)
+		}
+	}
+	return p, nil
+}
+
+// loadPolicy reads the policy from the file at path
+func loadPolicy(path string) (*policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	p, err := parsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy %q: %w", path, err)
+	}
+	return p, nil
+}
+
+// allowed returns true if principal may do action on resource.
+//
+// A matching Deny statement always wins, otherwise a matching Allow
+// statement is needed.
+func (p *policy) allowed(principal, action, resource string) bool {
+	allow := false
+	for _, st := range p.Statement {
+		if !matchAny(st.principals, principal) || !matchAny(st.actions, action) || !matchAny(st.resources, resource) {
+			continue
+		}
+		if st.Effect == effectDeny {
+			return false
+		}
+		allow = true
+	}
+	return allow
+}
+
+// policyCheck is an action on a resource needed by a request
+type policyCheck struct {
+	action   string
+	resource string
+}
+
+// join the bucket and object into a resource
+func joinResource(bucket, object string) string {
+	if object == "" {
+		return bucket
+	}
+	return bucket + "/" + object
+}
+
+// policyChecks works out the actions and resources r needs in the same
+// way gofakes3 routes requests. It returns an error if the request
+// can't be checked.
+func policyChecks(r *http.Request, hostBucket bool) (checks []policyCheck, err error) {
+	var (
+		p      = strings.Trim(r.URL.Path, "/")
+		query  = r.URL.Query()
+		bucket string
+		object string
+	)
+	if hostBucket {
+		bucket = strings.SplitN(r.Host, ".", 2)[0]
+		object = p
+	} else {
+		parts := strings.SplitN(p, "/", 2)
+		bucket = parts[0]
+		if len(parts) == 2 {
+			object = parts[1]
+		}
+	}
+	resource := joinResource(bucket, object)
+	check := func(action string) ([]policyCheck, error) {
+		return []policyCheck{{action: action, resource: resource}}, nil
+	}
+	bucketCheck := func(action string) ([]policyCheck, error) {
+		return []policyCheck{{action: action, resource: bucket}}, nil
+	}
+	method := r.Method
+	switch {
+	case query.Get("uploadId") != "":
+		switch method {
+		case "GET":
+			return check("s3:ListMultipartUploadParts")
+		case "PUT", "POST":
+			return check("s3:PutObject")
+		case "DELETE":
+			return check("s3:AbortMultipartUpload")
+		}
+	case query.Has("uploads"):
+		switch method {
+		case "GET":
+			return bucketCheck("s3:ListBucketMultipartUploads")
+		case "POST":
+			return check("s3:PutObject")
+		}
+	case query.Has("versioning"):
+		switch method {
+		case "GET":
+			return bucketCheck("s3:GetBucketVersioning")
+		case "PUT":
+			return bucketCheck("s3:PutBucketVersioning")
+		}
+	case query.Has("versions"):
+		if method == "GET" {
+			return bucketCheck("s3:ListBucketVersions")
+		}
+	case bucket != "" && object != "":
+		switch method {
+		case "GET", "HEAD":
+			return check("s3:GetObject")
+		case "DELETE":
+			return check("s3:DeleteObject")
+		case "PUT":
+			checks = []policyCheck{{action: "s3:PutObject", resource: resource}}
+			if copySource := r.Header.Get("x-amz-copy-source"); copySource != "" {
+				source, err := url.PathUnescape(strings.SplitN(copySource, "?", 2)[0])
+				if err != nil {
+					return nil, fmt.Errorf("bad x-amz-copy-source: %w", err)
+				}
+				checks = append(checks, policyCheck{action: "s3:GetObject", resource: strings.TrimPrefix(source, "/")})
+			}
+			return checks, nil
+		}
+	case bucket != "":
+		switch method {
+		case "GET", "HEAD":
+			if query.Has("location") {
+				return bucketCheck("s3:GetBucketLocation")
+			}
+			// Check listings against the prefix so they can be
+			// restricted to part of the bucket
+			return []policyCheck{{action: "s3:ListBucket", resource: joinResource(bucket, query.Get("prefix"))}}, nil
+		case "PUT":
+			return bucketCheck("s3:CreateBucket")
+		case "DELETE":
+			return bucketCheck("s3:DeleteBucket")
+		case "POST":
+			if query.Has("delete") {
+				return deleteChecks(r, bucket)
+			}
+			// The key of a browser upload is in the form so
+			// needs permission on the whole bucket
+			return []policyCheck{{action: "s3:PutObject", resource: bucket + "/*"}}, nil
+		}
+	case method == "GET":
+		return []policyCheck{{action: "s3:ListAllMyBuckets", resource: "*"}}, nil
+	}
+	return nil, fmt.Errorf("unknown request %s %s", method, r.URL.Path)
+}
+
+// deleteRequest is the body of a DeleteObjects request
+type deleteRequest struct {
+	Objects []struct {
+		Key string `xml:"Key"`
+	} `xml:"Object"`
+}
+
+// deleteChecks reads the keys being deleted by a DeleteObjects
+// request, restoring the body afterwards.
+func deleteChecks(r *http.Request, bucket string) (checks []policyCheck, err error) {
+	body, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	var req deleteRequest
+	if err := xml.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("bad delete request: %w", err)
+	}
+	for _, o := range req.Objects {
+		checks = append(checks, policyCheck{action: "s3:DeleteObject", resource: joinResource(bucket, o.Key)})
+	}
+	return checks, nil
+}
+
+// requestAccessKey returns the access key of the request from the
+// Authorization header or the query of a presigned URL
+func requestAccessKey(r *http.Request) string {
+	if accessKey, errCode := parseAccessKeyID(r); errCode == signature.ErrNone {
+		return accessKey
+	}
+	if credential := r.URL.Query().Get("X-Amz-Credential"); credential != "" {
+		return strings.SplitN(credential, "/", 2)[0]
+	}
+	return ""
+}
+
+// accessDenied writes an S3 access denied error
+func accessDenied(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write(signature.EncodeAPIErrorToResponse(signature.APIError{
+		Code:           "AccessDenied",
+		Description:    "Access Denied",
+		HTTPStatusCode: http.StatusForbidden,
+	}))
+}
+
+// policyMiddleware denies requests which p doesn't allow.
+//
+// The access key is checked against its signature by gofakes3 after
+// this so a forged access key is still rejected.
+func policyMiddleware(next http.Handler, p *policy, hostBucket bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessKey := requestAccessKey(r)
+		checks, err := policyChecks(r, hostBucket)
+		if err != nil {
+			fs.Infof(r.URL.Path, "%s: Access denied to %q: %v", r.RemoteAddr, accessKey, err)
+			accessDenied(w)
+			return
+		}
+		for _, c := range checks {
+			if !p.allowed(accessKey, c.action, c.resource) {
+				fs.Infof(r.URL.Path, "%s: Access denied to %q: %s on %q not allowed by policy", r.RemoteAddr, accessKey, c.action, c.resource)
+				accessDenied(w)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,233 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/rclone/rclone/cmd/serve/proxy"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Sid": "AdminAll",
+      "Effect": "Allow",
+      "Principal": "admin",
+      "Action": "s3:*",
+      "Resource": "*"
+    },
+    {
+      "Sid": "AppReadWrite",
+      "Effect": "Allow",
+      "Principal": ["app", "app2"],
+      "Action": ["s3:GetObject", "s3:PutObject", "s3:ListBucket"],
+      "Resource": ["arn:aws:s3:::data/app/*", "data/shared/*"]
+    },
+    {
+      "Sid": "AppNoSecrets",
+      "Effect": "Deny",
+      "Principal": "app*",
+      "Action": "*",
+      "Resource": "data/app/secret*"
+    }
+  ]
+}`
+
+func TestParsePolicy(t *testing.T) {
+	p, err := parsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	assert.Len(t, p.Statement, 3)
+
+	for _, bad := range []string{
+		`{"Statement": [{"Effect": "Maybe", "Action": "*", "Resource": "*"}]}`,
+		`{"Statement": [{"Effect": "Allow", "Resource": "*"}]}`,
+		`{"Statement": [{"Effect": "Allow", "Action": "*"}]}`,
+		`{"Statement": [{"Effect": "Allow", "Action": 1, "Resource": "*"}]}`,
+		`{"Statements": []}`,
+		`potato`,
+	} {
+		_, err := parsePolicy([]byte(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestPolicyAllowed(t *testing.T) {
+	p, err := parsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	for _, test := range []struct {
+		principal string
+		action    string
+		resource  string
+		want      bool
+	}{
+		{"admin", "s3:DeleteBucket", "data", true},
+		{"admin", "s3:GetObject", "data/app/secret.txt", true},
+		{"app", "s3:GetObject", "data/app/file.txt", true},
+		{"app2", "s3:PutObject", "data/app/dir/file.txt", true},
+		{"app", "s3:getobject", "data/shared/file.txt", true},
+		{"app", "s3:ListBucket", "data/app/", true},
+		{"app", "s3:ListBucket", "data", false},
+		{"app", "s3:DeleteObject", "data/app/file.txt", false},
+		{"app", "s3:GetObject", "data/other/file.txt", false},
+		{"app", "s3:GetObject", "data/app/secret.txt", false},
+		{"app3", "s3:GetObject", "data/app/file.txt", false},
+		{"", "s3:GetObject", "data/app/file.txt", false},
+	} {
+		got := p.allowed(test.principal, test.action, test.resource)
+		assert.Equal(t, test.want, got, fmt.Sprintf("%+v", test))
+	}
+}
+
+func TestPolicyChecks(t *testing.T) {
+	for _, test := range []struct {
+		method     string
+		url        string
+		header     string
+		body       string
+		hostBucket bool
+		want       []policyCheck
+		wantErr    bool
+	}{
+		{method: "GET", url: "/", want: []policyCheck{{"s3:ListAllMyBuckets", "*"}}},
+		{method: "GET", url: "/data?list-type=2&prefix=app/", want: []policyCheck{{"s3:ListBucket", "data/app/"}}},
+		{method: "GET", url: "/data", want: []policyCheck{{"s3:ListBucket", "data"}}},
+		{method: "GET", url: "/data?location", want: []policyCheck{{"s3:GetBucketLocation", "data"}}},
+		{method: "PUT", url: "/data", want: []policyCheck{{"s3:CreateBucket", "data"}}},
+		{method: "DELETE", url: "/data", want: []policyCheck{{"s3:DeleteBucket", "data"}}},
+		{method: "GET", url: "/data/app/file.txt", want: []policyCheck{{"s3:GetObject", "data/app/file.txt"}}},
+		{method: "HEAD", url: "/data/app/file.txt", want: []policyCheck{{"s3:GetObject", "data/app/file.txt"}}},
+		{method: "DELETE", url: "/data/app/file.txt", want: []policyCheck{{"s3:DeleteObject", "data/app/file.txt"}}},
+		{method: "PUT", url: "/data/app/file.txt", want: []policyCheck{{"s3:PutObject", "data/app/file.txt"}}},
+		{method: "PUT", url: "/data/app/copy.txt", header: "/other/file%20one.txt", want: []policyCheck{
+			{"s3:PutObject", "data/app/copy.txt"},
+			{"s3:GetObject", "other/file one.txt"},
+		}},
+		{method: "POST", url: "/data/app/big?uploads", want: []policyCheck{{"s3:PutObject", "data/app/big"}}},
+		{method: "PUT", url: "/data/app/big?uploadId=1&partNumber=1", want: []policyCheck{{"s3:PutObject", "data/app/big"}}},
+		{method: "DELETE", url: "/data/app/big?uploadId=1", want: []policyCheck{{"s3:AbortMultipartUpload", "data/app/big"}}},
+		{method: "GET", url: "/data?uploads", want: []policyCheck{{"s3:ListBucketMultipartUploads", "data"}}},
+		{method: "POST", url: "/data?delete", body: `<Delete><Object><Key>a</Key></Object><Object><Key>b/c</Key></Object></Delete>`, want: []policyCheck{
+			{"s3:DeleteObject", "data/a"},
+			{"s3:DeleteObject", "data/b/c"},
+		}},
+		{method: "GET", url: "/app/file.txt", hostBucket: true, want: []policyCheck{{"s3:GetObject", "data/app/file.txt"}}},
+		{method: "PATCH", url: "/data/file.txt", wantErr: true},
+		{method: "POST", url: "/data?delete", body: `potato`, wantErr: true},
+	} {
+		what := fmt.Sprintf("%s %s", test.method, test.url)
+		r := httptest.NewRequest(test.method, test.url, strings.NewReader(test.body))
+		r.Host = "data.localhost"
+		if test.header != "" {
+			r.Header.Set("x-amz-copy-source", test.header)
+		}
+		got, err := policyChecks(r, test.hostBucket)
+		if test.wantErr {
+			assert.Error(t, err, what)
+			continue
+		}
+		require.NoError(t, err, what)
+		assert.Equal(t, test.want, got, what)
+		if test.body != "" {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, test.body, string(body), "body should be restored")
+		}
+	}
+}
+
+func TestRequestAccessKey(t *testing.T) {
+	r := httptest.NewRequest("GET", "/data/file.txt", nil)
+	assert.Equal(t, "", requestAccessKey(r))
+	r.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=KEY1/20250101/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-date, Signature=abcd")
+	assert.Equal(t, "KEY1", requestAccessKey(r))
+	r = httptest.NewRequest("GET", "/data/file.txt?X-Amz-Credential="+url.QueryEscape("KEY2/20250101/us-east-1/s3/aws4_request"), nil)
+	assert.Equal(t, "KEY2", requestAccessKey(r))
+}
+
+func TestPolicyServer(t *testing.T) {
+	ctx := context.Background()
+	fstest.Initialise()
+	f, _, clean, err := fstest.RandomRemote()
+	require.NoError(t, err)
+	defer clean()
+	require.NoError(t, f.Mkdir(ctx, "data/app"))
+
+	policyFile := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(policyFile, []byte(testPolicy), 0600))
+
+	opt := Opt // copy default options
+	opt.AuthKey = []string{"admin,adminsecret", "app,appsecret"}
+	opt.HTTP.ListenAddr = []string{endpoint}
+	opt.PolicyFile = policyFile
+	w, err := newServer(ctx, f, &opt, &vfscommon.Opt, &proxy.Opt)
+	require.NoError(t, err)
+	go func() {
+		require.NoError(t, w.Serve())
+	}()
+	defer func() {
+		require.NoError(t, w.Shutdown())
+	}()
+	testURL, err := url.Parse(w.server.URLs()[0])
+	require.NoError(t, err)
+
+	client := func(key, secret string) *minio.Client {
+		c, err := minio.New(testURL.Host, &minio.Options{
+			Creds:  credentials.NewStaticV4(key, secret, ""),
+			Secure: false,
+		})
+		require.NoError(t, err)
+		return c
+	}
+	put := func(c *minio.Client, key string) error {
+		content := []byte("hello")
+		_, err := c.PutObject(ctx, "data", key, bytes.NewReader(content), int64(len(content)), minio.PutObjectOptions{})
+		return err
+	}
+	isDenied := func(err error) bool {
+		return minio.ToErrorResponse(err).StatusCode == http.StatusForbidden
+	}
+
+	app := client("app", "appsecret")
+	admin := client("admin", "adminsecret")
+
+	require.NoError(t, put(app, "app/file.txt"))
+	assert.True(t, isDenied(put(app, "app/secret.txt")))
+	assert.True(t, isDenied(put(app, "other.txt")))
+	require.NoError(t, put(admin, "app/secret.txt"))
+
+	_, err = app.ListBuckets(ctx)
+	assert.True(t, isDenied(err))
+	buckets, err := admin.ListBuckets(ctx)
+	require.NoError(t, err)
+	assert.Len(t, buckets, 1)
+
+	var keys []string
+	for o := range app.ListObjects(ctx, "data", minio.ListObjectsOptions{Prefix: "app/", Recursive: true}) {
+		require.NoError(t, o.Err)
+		keys = append(keys, o.Key)
+	}
+	assert.Equal(t, []string{"app/file.txt", "app/secret.txt"}, keys)
+
+	_, err = app.StatObject(ctx, "data", "app/secret.txt", minio.StatObjectOptions{})
+	assert.True(t, isDenied(err))
+	assert.True(t, isDenied(app.RemoveObject(ctx, "data", "app/file.txt", minio.RemoveObjectOptions{})))
+
+	// A forged access key is rejected by the signature check
+	assert.Error(t, put(client("admin", "wrong"), "app/forged.txt"))
+}
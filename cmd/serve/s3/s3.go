@@ -37,9 +37,14 @@ var OptionsInfo = fs.Options{{
 	Name:    "no_cleanup",
 	Default: false,
 	Help:    "Not to cleanup empty folder after object is deleted",
+}, {
+	Name:    "policy_file",
+	Default: "",
+	Help:    "Path to a JSON policy file controlling what each access key can do",
 }}.
 	Add(httplib.ConfigInfo).
-	Add(httplib.AuthConfigInfo)
+	Add(httplib.AuthConfigInfo).
+	Add(httplib.AuditConfigInfo)
 
 // Options contains options for the s3 Server
 type Options struct {
@@ -48,7 +53,9 @@ type Options struct {
 	EtagHash       string   `config:"etag_hash"`
 	AuthKey        []string `config:"auth_key"`
 	NoCleanup      bool     `config:"no_cleanup"`
+	PolicyFile     string   `config:"policy_file"`
 	Auth           httplib.AuthConfig
+	Audit          httplib.AuditConfig
 	HTTP           httplib.Config
 }
 
@@ -105,7 +112,7 @@ var Command = &cobra.Command{
 	},
 	Use:   "s3 remote:path",
 	Short: `Serve remote:path over s3.`,
-	Long:  help() + strings.TrimSpace(httplib.AuthHelp(flagPrefix)+httplib.Help(flagPrefix)+vfs.Help()),
+	Long:  help() + strings.TrimSpace(httplib.AuthHelp(flagPrefix)+httplib.Help(flagPrefix)+httplib.AuditHelp(flagPrefix)+vfs.Help()),
 	RunE: func(command *cobra.Command, args []string) error {
 		var f fs.Fs
 		if proxy.Opt.AuthProxy == "" {
@@ -96,9 +96,21 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options, vfsOpt *vfscommon.Opt
 		}
 	}
 
+	if opt.PolicyFile != "" {
+		p, err := loadPolicy(opt.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		fs.Infof("serve s3", "Using policy from %q with %d statements", opt.PolicyFile, len(p.Statement))
+		w.handler = policyMiddleware(w.handler, p, !opt.ForcePathStyle)
+	}
+
+	audit := opt.Audit
+	audit.Protocol = "s3"
 	w.server, err = httplib.NewServer(ctx,
 		httplib.WithConfig(opt.HTTP),
 		httplib.WithAuth(opt.Auth),
+		httplib.WithAudit(audit),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init server: %w", err)
@@ -335,6 +335,9 @@ func (c *conn) handleChannels(chans <-chan ssh.NewChannel) {
 
 func serveChannel(rwc io.ReadWriteCloser, h sftp.Handlers, what string) error {
 	fs.Debugf(what, "Starting SFTP server")
+	if v, ok := h.FilePut.(vfsHandler); ok {
+		rwc = newExtensionConn(rwc, v, what)
+	}
 	server := sftp.NewRequestServer(rwc, h)
 	defer func() {
 		err := server.Close()
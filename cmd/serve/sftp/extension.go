@@ -0,0 +1,336 @@
+//go:build !plan9
+
+package sftp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"slices"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// SFTP packet types and values used by extensionConn
+const (
+	sshFxpVersion  = 2
+	sshFxpOpen     = 3
+	sshFxpClose    = 4
+	sshFxpStatus   = 101
+	sshFxpHandle   = 102
+	sshFxpExtended = 200
+
+	sshFxfRead   = 0x00000001
+	sshFxfWrite  = 0x00000002
+	sshFxfAppend = 0x00000004
+
+	sshFxOk               = 0
+	sshFxNoSuchFile       = 2
+	sshFxPermissionDenied = 3
+	sshFxFailure          = 4
+	sshFxBadMessage       = 5
+
+	// same limit as the RequestServer
+	maxPacketLength = 256 * 1024
+)
+
+// Extensions served by extensionConn
+const (
+	extensionFsync    = "fsync@openssh.com"
+	extensionCopyData = "copy-data"
+)
+
+// openFile is a file opened by the client
+type openFile struct {
+	path  string
+	flags uint32
+}
+
+// extensionConn sits between the SSH channel and the RequestServer
+// and serves the fsync@openssh.com and copy-data extensions.
+//
+// The RequestServer replies SSH_FX_OP_UNSUPPORTED to any extended
+// request it doesn't know and keeps its handles private, so this
+// reads the packets going each way to track which file each handle
+// refers to, answers the extended requests itself and adds the
+// extensions to the SSH_FXP_VERSION reply.
+type extensionConn struct {
+	io.ReadWriteCloser
+	v    vfsHandler
+	what string
+
+	in  []byte     // rest of the packet being read by the RequestServer
+	out []byte     // incomplete packet written by the RequestServer
+	wmu sync.Mutex // held while writing a packet to the client
+
+	mu      sync.Mutex
+	opens   map[uint32]openFile // open requests awaiting a reply by ID
+	handles map[string]openFile // open files by handle
+}
+
+// newExtensionConn wraps rwc to serve the extensions using v
+func newExtensionConn(rwc io.ReadWriteCloser, v vfsHandler, what string) *extensionConn {
+	return &extensionConn{
+		ReadWriteCloser: rwc,
+		v:               v,
+		what:            what,
+		opens:           map[uint32]openFile{},
+		handles:         map[string]openFile{},
+	}
+}
+
+// Read passes the packets from the client to the RequestServer
+// except for the extended requests which are answered here.
+func (c *extensionConn) Read(p []byte) (n int, err error) {
+	for len(c.in) == 0 {
+		packet, err := c.readPacket()
+		if err != nil {
+			return 0, err
+		}
+		if !c.handleRequest(packet) {
+			c.in = packet
+		}
+	}
+	n = copy(p, c.in)
+	c.in = c.in[n:]
+	return n, nil
+}
+
+// readPacket reads a whole packet including its length
+func (c *extensionConn) readPacket() ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(c.ReadWriteCloser, header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	if length == 0 || length > maxPacketLength {
+		return nil, fmt.Errorf("bad SFTP packet length %d", length)
+	}
+	packet := make([]byte, 4+length)
+	copy(packet, header[:])
+	if _, err := io.ReadFull(c.ReadWriteCloser, packet[4:]); err != nil {
+		return nil, err
+	}
+	return packet, nil
+}
+
+// Write passes the packets from the RequestServer to the client,
+// which may be written in several parts, a whole packet at a time.
+func (c *extensionConn) Write(p []byte) (n int, err error) {
+	c.out = append(c.out, p...)
+	for len(c.out) >= 4 {
+		length := 4 + int(binary.BigEndian.Uint32(c.out))
+		if len(c.out) < length {
+			break
+		}
+		err = c.writePacket(c.handleResponse(c.out[:length]))
+		if err != nil {
+			return 0, err
+		}
+		c.out = c.out[length:]
+	}
+	if len(c.out) == 0 {
+		c.out = nil
+	}
+	return len(p), nil
+}
+
+// writePacket writes a whole packet to the client
+func (c *extensionConn) writePacket(packet []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := c.ReadWriteCloser.Write(packet)
+	return err
+}
+
+// handleRequest looks at a packet from the client, returning true
+// if it was answered here.
+func (c *extensionConn) handleRequest(packet []byte) bool {
+	r := packetReader{b: packet[5:]}
+	switch packet[4] {
+	case sshFxpOpen:
+		id, name, flags := r.uint32(), r.string(), r.uint32()
+		if r.err == nil {
+			c.mu.Lock()
+			c.opens[id] = openFile{path: path.Clean("/" + name), flags: flags}
+			c.mu.Unlock()
+		}
+	case sshFxpClose:
+		_, handle := r.uint32(), r.string()
+		if r.err == nil {
+			c.mu.Lock()
+			delete(c.handles, handle)
+			c.mu.Unlock()
+		}
+	case sshFxpExtended:
+		id, request := r.uint32(), r.string()
+		if r.err != nil {
+			return false
+		}
+		switch request {
+		case extensionFsync:
+			c.reply(id, request, c.fsync(&r))
+			return true
+		case extensionCopyData:
+			c.reply(id, request, c.copyData(&r))
+			return true
+		}
+	}
+	return false
+}
+
+// handleResponse looks at a packet from the RequestServer and
+// returns the packet to send to the client.
+func (c *extensionConn) handleResponse(packet []byte) []byte {
+	r := packetReader{b: packet[5:]}
+	switch packet[4] {
+	case sshFxpVersion:
+		packet = slices.Clone(packet)
+		for _, extension := range []string{extensionFsync, extensionCopyData} {
+			packet = appendString(packet, extension)
+			packet = appendString(packet, "1")
+		}
+		binary.BigEndian.PutUint32(packet, uint32(len(packet)-4))
+	case sshFxpHandle:
+		id, handle := r.uint32(), r.string()
+		c.mu.Lock()
+		if file, found := c.opens[id]; found && r.err == nil {
+			c.handles[handle] = file
+		}
+		delete(c.opens, id)
+		c.mu.Unlock()
+	case sshFxpStatus:
+		id := r.uint32()
+		c.mu.Lock()
+		delete(c.opens, id)
+		c.mu.Unlock()
+	}
+	return packet
+}
+
+// getHandle returns the open file for handle if it was opened with
+// one of flags.
+func (c *extensionConn) getHandle(handle string, flags uint32) (openFile, error) {
+	c.mu.Lock()
+	file, found := c.handles[handle]
+	c.mu.Unlock()
+	if !found || file.flags&flags == 0 {
+		return openFile{}, errBadHandle
+	}
+	return file, nil
+}
+
+// errBadHandle is returned for unknown handles or handles opened
+// without the access the request needs
+var errBadHandle = errors.New("bad handle")
+
+// errBadMessage is returned for requests which can't be decoded
+var errBadMessage = errors.New("bad message")
+
+// fsync decodes and runs an fsync@openssh.com request
+func (c *extensionConn) fsync(r *packetReader) error {
+	handle := r.string()
+	if r.err != nil {
+		return errBadMessage
+	}
+	file, err := c.getHandle(handle, sshFxfRead|sshFxfWrite|sshFxfAppend)
+	if err != nil {
+		return err
+	}
+	return c.v.Fsync(file.path)
+}
+
+// copyData decodes and runs a copy-data request
+func (c *extensionConn) copyData(r *packetReader) error {
+	readHandle, readOffset, length := r.string(), r.uint64(), r.uint64()
+	writeHandle, writeOffset := r.string(), r.uint64()
+	if r.err != nil || readOffset > math.MaxInt64 || length > math.MaxInt64-readOffset || writeOffset > math.MaxInt64 {
+		return errBadMessage
+	}
+	src, err := c.getHandle(readHandle, sshFxfRead)
+	if err != nil {
+		return err
+	}
+	dst, err := c.getHandle(writeHandle, sshFxfWrite|sshFxfAppend)
+	if err != nil {
+		return err
+	}
+	return c.v.CopyData(src.path, int64(readOffset), int64(length), dst.path, int64(writeOffset))
+}
+
+// reply sends the status for request id to the client
+func (c *extensionConn) reply(id uint32, request string, err error) {
+	code, message := uint32(sshFxOk), "OK"
+	switch {
+	case err == nil:
+	case errors.Is(err, errBadMessage):
+		code, message = sshFxBadMessage, err.Error()
+	case os.IsNotExist(err):
+		code, message = sshFxNoSuchFile, err.Error()
+	case os.IsPermission(err):
+		code, message = sshFxPermissionDenied, err.Error()
+	default:
+		code, message = sshFxFailure, err.Error()
+	}
+	if err != nil {
+		fs.Debugf(c.what, "%s failed: %v", request, err)
+	}
+	packet := []byte{0, 0, 0, 0, sshFxpStatus}
+	packet = binary.BigEndian.AppendUint32(packet, id)
+	packet = binary.BigEndian.AppendUint32(packet, code)
+	packet = appendString(packet, message)
+	packet = appendString(packet, "")
+	binary.BigEndian.PutUint32(packet, uint32(len(packet)-4))
+	if err := c.writePacket(packet); err != nil {
+		fs.Debugf(c.what, "Failed to reply to %s: %v", request, err)
+	}
+}
+
+// appendString appends s to b in SFTP string format
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// packetReader decodes the fields of an SFTP packet, recording the
+// first error.
+type packetReader struct {
+	b   []byte
+	err error
+}
+
+func (r *packetReader) uint32() uint32 {
+	if len(r.b) < 4 {
+		r.err = errBadMessage
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.b)
+	r.b = r.b[4:]
+	return v
+}
+
+func (r *packetReader) uint64() uint64 {
+	if len(r.b) < 8 {
+		r.err = errBadMessage
+		return 0
+	}
+	v := binary.BigEndian.Uint64(r.b)
+	r.b = r.b[8:]
+	return v
+}
+
+func (r *packetReader) string() string {
+	n := r.uint32()
+	if r.err != nil || uint32(len(r.b)) < n {
+		r.err = errBadMessage
+		return ""
+	}
+	s := string(r.b[:n])
+	r.b = r.b[n:]
+	return s
+}
@@ -0,0 +1,138 @@
+//go:build !plan9
+
+package sftp
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveTestChannel serves dir over one end of a pipe and returns the
+// other end
+func serveTestChannel(t *testing.T, dir string) net.Conn {
+	f, err := fs.NewFs(context.Background(), dir)
+	require.NoError(t, err)
+	v := vfs.New(f, nil)
+	client, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, serveChannel(server, newVFSHandler(v), "test"))
+	}()
+	t.Cleanup(func() {
+		_ = client.Close()
+		<-done
+		v.Shutdown()
+	})
+	return client
+}
+
+func TestExtensionFsync(t *testing.T) {
+	dir := t.TempDir()
+	conn := serveTestChannel(t, dir)
+	client, err := sftp.NewClientPipe(conn, conn)
+	require.NoError(t, err)
+
+	_, found := client.HasExtension(extensionFsync)
+	assert.True(t, found)
+	_, found = client.HasExtension(extensionCopyData)
+	assert.True(t, found)
+
+	file, err := client.Create("file.txt")
+	require.NoError(t, err)
+	_, err = file.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, file.Sync())
+	require.NoError(t, file.Close())
+
+	data, err := os.ReadFile(filepath.Join(dir, "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+// rawPacket builds an SFTP packet from fields which may be uint32,
+// uint64 or string
+func rawPacket(typ byte, fields ...any) []byte {
+	packet := []byte{0, 0, 0, 0, typ}
+	for _, field := range fields {
+		switch v := field.(type) {
+		case uint32:
+			packet = binary.BigEndian.AppendUint32(packet, v)
+		case uint64:
+			packet = binary.BigEndian.AppendUint64(packet, v)
+		case string:
+			packet = appendString(packet, v)
+		}
+	}
+	binary.BigEndian.PutUint32(packet, uint32(len(packet)-4))
+	return packet
+}
+
+// rawRequest sends the packet and returns the type and body of the reply
+func rawRequest(t *testing.T, conn net.Conn, packet []byte) (byte, *packetReader) {
+	_, err := conn.Write(packet)
+	require.NoError(t, err)
+	var header [4]byte
+	_, err = io.ReadFull(conn, header[:])
+	require.NoError(t, err)
+	reply := make([]byte, binary.BigEndian.Uint32(header[:]))
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	return reply[0], &packetReader{b: reply[1:]}
+}
+
+func TestExtensionCopyData(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src.txt"), []byte("0123456789"), 0o666))
+	conn := serveTestChannel(t, dir)
+
+	typ, _ := rawRequest(t, conn, rawPacket(1, uint32(3)))
+	require.Equal(t, byte(sshFxpVersion), typ)
+
+	open := func(id uint32, name string, flags uint32) string {
+		typ, r := rawRequest(t, conn, rawPacket(sshFxpOpen, id, name, flags, uint32(0)))
+		require.Equal(t, byte(sshFxpHandle), typ)
+		assert.Equal(t, id, r.uint32())
+		return r.string()
+	}
+	status := func(packet []byte) uint32 {
+		typ, r := rawRequest(t, conn, packet)
+		require.Equal(t, byte(sshFxpStatus), typ)
+		r.uint32()
+		return r.uint32()
+	}
+	src := open(1, "src.txt", sshFxfRead)
+	dst := open(2, "dst.txt", sshFxfWrite|0x08|0x10) // CREAT|TRUNC
+
+	// The handles must be opened for the right access
+	assert.Equal(t, uint32(sshFxFailure), status(rawPacket(sshFxpExtended, uint32(3), extensionCopyData, dst, uint64(0), uint64(0), src, uint64(0))))
+	assert.Equal(t, uint32(sshFxFailure), status(rawPacket(sshFxpExtended, uint32(4), extensionCopyData, "unknown", uint64(0), uint64(0), dst, uint64(0))))
+	assert.Equal(t, uint32(sshFxBadMessage), status(rawPacket(sshFxpExtended, uint32(5), extensionCopyData, src)))
+
+	// Copy the middle then the rest of the file
+	assert.Equal(t, uint32(sshFxOk), status(rawPacket(sshFxpExtended, uint32(6), extensionCopyData, src, uint64(2), uint64(3), dst, uint64(0))))
+	assert.Equal(t, uint32(sshFxOk), status(rawPacket(sshFxpExtended, uint32(7), extensionCopyData, src, uint64(5), uint64(0), dst, uint64(3))))
+	assert.Equal(t, uint32(sshFxOk), status(rawPacket(sshFxpExtended, uint32(8), extensionFsync, dst)))
+
+	assert.Equal(t, uint32(sshFxOk), status(rawPacket(sshFxpClose, uint32(9), src)))
+	assert.Equal(t, uint32(sshFxOk), status(rawPacket(sshFxpClose, uint32(10), dst)))
+
+	// Closed handles can't be used
+	assert.Equal(t, uint32(sshFxFailure), status(rawPacket(sshFxpExtended, uint32(11), extensionFsync, dst)))
+
+	data, err := os.ReadFile(filepath.Join(dir, "dst.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "23456789", string(data))
+}
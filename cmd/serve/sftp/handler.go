@@ -3,8 +3,13 @@
 package sftp
 
 import (
+	"errors"
 	"io"
+	"math"
 	"os"
+	"path"
+	"slices"
+	"sync"
 	"syscall"
 	"time"
 
@@ -16,11 +21,26 @@ import (
 // vfsHandler converts the VFS to be served by SFTP
 type vfsHandler struct {
 	*vfs.VFS
+	writers *openWriters
+}
+
+func init() {
+	// Only advertise the extensions the handlers implement - the
+	// default list includes hardlink@openssh.com which the VFS
+	// can't do.
+	//
+	// fsync@openssh.com and copy-data can't be added here as the
+	// RequestServer rejects them, so they are served by
+	// extensionConn instead.
+	err := sftp.SetSFTPExtensions("posix-rename@openssh.com", "statvfs@openssh.com")
+	if err != nil {
+		fs.Errorf(nil, "Failed to set SFTP extensions: %v", err)
+	}
 }
 
 // vfsHandler returns a Handlers object with the test handlers.
 func newVFSHandler(vfs *vfs.VFS) sftp.Handlers {
-	v := vfsHandler{VFS: vfs}
+	v := vfsHandler{VFS: vfs, writers: newOpenWriters()}
 	return sftp.Handlers{
 		FileGet:  v,
 		FilePut:  v,
@@ -42,7 +62,61 @@ func (v vfsHandler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
 	if err != nil {
 		return nil, err
 	}
-	return file, nil
+	return v.writers.add(r.Filepath, file), nil
+}
+
+// openWriters tracks the files open for writing by path so the
+// fsync@openssh.com and copy-data extensions can find them.
+type openWriters struct {
+	mu    sync.Mutex
+	files map[string][]vfs.Handle
+}
+
+func newOpenWriters() *openWriters {
+	return &openWriters{files: map[string][]vfs.Handle{}}
+}
+
+// add tracks file as open for writing at filePath until it is closed
+func (w *openWriters) add(filePath string, file vfs.Handle) vfs.Handle {
+	filePath = path.Clean(filePath)
+	w.mu.Lock()
+	w.files[filePath] = append(w.files[filePath], file)
+	w.mu.Unlock()
+	return &openWriter{Handle: file, writers: w, path: filePath}
+}
+
+// remove stops tracking file
+func (w *openWriters) remove(filePath string, file vfs.Handle) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	files := slices.DeleteFunc(w.files[filePath], func(h vfs.Handle) bool {
+		return h == file
+	})
+	if len(files) == 0 {
+		delete(w.files, filePath)
+	} else {
+		w.files[filePath] = files
+	}
+}
+
+// get returns the files open for writing at filePath
+func (w *openWriters) get(filePath string) []vfs.Handle {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.files[path.Clean(filePath)])
+}
+
+// openWriter is a vfs.Handle which stops being tracked when closed
+type openWriter struct {
+	vfs.Handle
+	writers *openWriters
+	path    string
+}
+
+// Close the file and stop tracking it
+func (o *openWriter) Close() error {
+	o.writers.remove(o.path, o.Handle)
+	return o.Handle.Close()
 }
 
 func (v vfsHandler) Filecmd(r *sftp.Request) error {
@@ -90,6 +164,84 @@ func (v vfsHandler) Filecmd(r *sftp.Request) error {
 	return nil
 }
 
+// PosixRename implements the posix-rename@openssh.com extension
+// which replaces the target if it exists.
+func (v vfsHandler) PosixRename(r *sftp.Request) error {
+	return v.Rename(r.Filepath, r.Target)
+}
+
+// Fsync implements the fsync@openssh.com extension.
+//
+// Like fsync(2) this flushes the file rather than just the handle, so
+// all the handles open for writing at filePath are synced.
+func (v vfsHandler) Fsync(filePath string) error {
+	for _, file := range v.writers.get(filePath) {
+		if err := file.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errCopyDataOverlap is returned by CopyData for overlapping copies
+// within the same file.
+var errCopyDataOverlap = errors.New("copy-data source and destination overlap")
+
+// CopyData implements the copy-data extension which copies length
+// bytes from srcPath at srcOffset to the file open for writing at
+// dstPath at dstOffset. A length of 0 copies up to the end of the
+// source.
+//
+// The data is read through a new handle and written through the
+// client's handle, so it is subject to the same rules as the client's
+// writes, e.g. without --vfs-cache-mode writes they must be
+// sequential.
+func (v vfsHandler) CopyData(srcPath string, srcOffset, length int64, dstPath string, dstOffset int64) (err error) {
+	dsts := v.writers.get(dstPath)
+	if len(dsts) == 0 {
+		return syscall.EBADF
+	}
+	if length == 0 {
+		length = math.MaxInt64 - srcOffset
+	}
+	if path.Clean(srcPath) == path.Clean(dstPath) && srcOffset-dstOffset < length && dstOffset-srcOffset < length {
+		return errCopyDataOverlap
+	}
+	src, err := v.OpenFile(srcPath, os.O_RDONLY, 0777)
+	if err != nil {
+		return err
+	}
+	defer fs.CheckClose(src, &err)
+	_, err = io.Copy(io.NewOffsetWriter(dsts[0], dstOffset), io.NewSectionReader(src, srcOffset, length))
+	return err
+}
+
+// statVFSBlockSize is the block size reported by StatVFS
+const statVFSBlockSize = 4096
+
+// StatVFS implements the statvfs@openssh.com extension which is used
+// by clients such as sshfs to show the size and free space.
+func (v vfsHandler) StatVFS(r *sftp.Request) (*sftp.StatVFS, error) {
+	total, _, free := v.Statfs()
+	blocks := func(n int64) uint64 {
+		if n < 0 {
+			return 0
+		}
+		return uint64(n) / statVFSBlockSize
+	}
+	return &sftp.StatVFS{
+		Bsize:   statVFSBlockSize,
+		Frsize:  statVFSBlockSize,
+		Blocks:  blocks(total),
+		Bfree:   blocks(free),
+		Bavail:  blocks(free),
+		Files:   1e9,
+		Ffree:   1e9,
+		Favail:  1e9,
+		Namemax: 255,
+	}, nil
+}
+
 type listerat []os.FileInfo
 
 // Modeled after strings.Reader's ReadAt() implementation
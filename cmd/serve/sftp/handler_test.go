@@ -0,0 +1,70 @@
+//go:build !plan9
+
+package sftp
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/sftp"
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerExtensions(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("new"), 0o666))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("old"), 0o666))
+	f, err := fs.NewFs(context.Background(), dir)
+	require.NoError(t, err)
+	v := vfsHandler{VFS: vfs.New(f, nil), writers: newOpenWriters()}
+	defer v.Shutdown()
+
+	// posix-rename replaces the target
+	err = v.PosixRename(&sftp.Request{Filepath: "/a.txt", Target: "/b.txt"})
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(dir, "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	_, err = os.Stat(filepath.Join(dir, "a.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	// statvfs reports the size of the disk
+	stat, err := v.StatVFS(&sftp.Request{Filepath: "/"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(statVFSBlockSize), stat.Bsize)
+	assert.NotZero(t, stat.Blocks)
+	assert.LessOrEqual(t, stat.Bfree, stat.Blocks)
+
+	// copy-data needs the destination open for writing
+	err = v.CopyData("/b.txt", 0, 0, "/c.txt", 0)
+	assert.ErrorIs(t, err, syscall.EBADF)
+}
+
+func TestHandlerCopyDataOverlap(t *testing.T) {
+	dir := t.TempDir()
+	f, err := fs.NewFs(context.Background(), dir)
+	require.NoError(t, err)
+	v := vfsHandler{VFS: vfs.New(f, nil), writers: newOpenWriters()}
+	defer v.Shutdown()
+
+	w, err := v.Filewrite(&sftp.Request{Filepath: "/a.txt"})
+	require.NoError(t, err)
+	_, err = w.WriteAt([]byte("0123"), 0)
+	require.NoError(t, err)
+	assert.Len(t, v.writers.get("/a.txt"), 1)
+	require.NoError(t, v.Fsync("/a.txt"))
+
+	assert.ErrorIs(t, v.CopyData("/a.txt", 0, 4, "/a.txt", 2), errCopyDataOverlap)
+	assert.ErrorIs(t, v.CopyData("/a.txt", 2, 0, "/a.txt", 0), errCopyDataOverlap)
+
+	require.NoError(t, w.(io.Closer).Close())
+	assert.Empty(t, v.writers.get("/a.txt"))
+}
@@ -23,11 +23,12 @@ import (
 	"strings"
 
 	"github.com/rclone/rclone/cmd/serve/proxy"
+	"github.com/rclone/rclone/cmd/serve/userdb"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config"
 	"github.com/rclone/rclone/lib/env"
 	"github.com/rclone/rclone/lib/file"
-	sdActivation "github.com/rclone/rclone/lib/sdactivation"
+	"github.com/rclone/rclone/lib/listen"
 	"github.com/rclone/rclone/vfs"
 	"github.com/rclone/rclone/vfs/vfscommon"
 	"golang.org/x/crypto/ssh"
@@ -43,6 +44,7 @@ type server struct {
 	listener net.Listener
 	stopped  chan struct{} // for waiting on the listener to stop
 	proxy    *proxy.Proxy
+	userDB   *userdb.DB
 }
 
 func newServer(ctx context.Context, f fs.Fs, opt *Options, vfsOpt *vfscommon.Options, proxyOpt *proxy.Options) (*server, error) {
@@ -53,7 +55,16 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options, vfsOpt *vfscommon.Opt
 		stopped: make(chan struct{}),
 	}
 	if proxy.Opt.AuthProxy != "" {
+		if opt.UserDB != "" {
+			return nil, errors.New("--auth-proxy and --user-db cannot be used at the same time")
+		}
 		s.proxy = proxy.New(ctx, proxyOpt, vfsOpt)
+	} else if opt.UserDB != "" {
+		var err error
+		s.userDB, err = userdb.Load(ctx, opt.UserDB, f, vfsOpt)
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		s.vfs = vfs.New(f, vfsOpt)
 	}
@@ -64,9 +75,9 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options, vfsOpt *vfscommon.Opt
 	return s, nil
 }
 
-// getVFS gets the vfs from s or the proxy
+// getVFS gets the vfs from s, the proxy or the user database
 func (s *server) getVFS(what string, sshConn *ssh.ServerConn) (VFS *vfs.VFS) {
-	if s.proxy == nil {
+	if s.proxy == nil && s.userDB == nil {
 		return s.vfs
 	}
 	if sshConn.Permissions == nil || sshConn.Permissions.Extensions == nil {
@@ -78,6 +89,14 @@ func (s *server) getVFS(what string, sshConn *ssh.ServerConn) (VFS *vfs.VFS) {
 		fs.Infof(what, "VFS key not found")
 		return nil
 	}
+	if s.userDB != nil {
+		VFS, err := s.userDB.VFS(key)
+		if err != nil {
+			fs.Errorf(what, "Failed to make VFS: %v", err)
+			return nil
+		}
+		return VFS
+	}
 	VFS = s.proxy.Get(key)
 	if VFS == nil {
 		fs.Infof(what, "failed to read VFS from cache")
@@ -143,9 +162,12 @@ func (s *server) configure() (err error) {
 	if proxy.Opt.AuthProxy != "" && s.opt.AuthorizedKeys != "" && s.opt.AuthorizedKeys != Opt.AuthorizedKeys {
 		return errors.New("--auth-proxy and --authorized-keys cannot be used at the same time")
 	}
+	if s.userDB != nil && s.opt.AuthorizedKeys != "" && s.opt.AuthorizedKeys != Opt.AuthorizedKeys {
+		return errors.New("--user-db and --authorized-keys cannot be used at the same time")
+	}
 
 	// Load the authorized keys
-	if s.opt.AuthorizedKeys != "" && proxy.Opt.AuthProxy == "" {
+	if s.opt.AuthorizedKeys != "" && proxy.Opt.AuthProxy == "" && s.userDB == nil {
 		authKeysFile := env.ShellExpand(s.opt.AuthorizedKeys)
 		authorizedKeysMap, err = loadAuthorizedKeys(authKeysFile)
 		// If user set the flag away from the default then report an error
@@ -160,8 +182,8 @@ func (s *server) configure() (err error) {
 		fs.Logf(nil, "Loaded %d authorized keys from %q", len(authorizedKeysMap), authKeysFile)
 	}
 
-	if !s.opt.NoAuth && len(authorizedKeysMap) == 0 && s.opt.User == "" && s.opt.Pass == "" && s.proxy == nil {
-		return errors.New("no authorization found, use --user/--pass or --authorized-keys or --no-auth or --auth-proxy")
+	if !s.opt.NoAuth && len(authorizedKeysMap) == 0 && s.opt.User == "" && s.opt.Pass == "" && s.proxy == nil && s.userDB == nil {
+		return errors.New("no authorization found, use --user/--pass or --authorized-keys or --no-auth or --auth-proxy or --user-db")
 	}
 
 	// An SSH server is represented by a ServerConfig, which holds
@@ -182,6 +204,8 @@ func (s *server) configure() (err error) {
 						"_vfsKey": vfsKey,
 					},
 				}, nil
+			} else if s.userDB != nil {
+				return nil, fmt.Errorf("password login not allowed with --user-db for %q", c.User())
 			} else if s.opt.User != "" && s.opt.Pass != "" {
 				userOK := subtle.ConstantTimeCompare([]byte(c.User()), []byte(s.opt.User))
 				passOK := subtle.ConstantTimeCompare(pass, []byte(s.opt.Pass))
@@ -210,6 +234,19 @@ func (s *server) configure() (err error) {
 					},
 				}, nil
 			}
+			if s.userDB != nil {
+				err := s.userDB.CheckPublicKey(c.User(), pubKey)
+				if err != nil {
+					return nil, err
+				}
+				// return the user so we can find their VFS
+				return &ssh.Permissions{
+					Extensions: map[string]string{
+						"_vfsKey":   c.User(),
+						"pubkey-fp": ssh.FingerprintSHA256(pubKey),
+					},
+				}, nil
+			}
 			if _, ok := authorizedKeysMap[string(pubKey.Marshal())]; ok {
 				return &ssh.Permissions{
 					// Record the public key used for authentication.
@@ -278,25 +315,12 @@ func (s *server) configure() (err error) {
 
 	// Once a ServerConfig has been configured, connections can be
 	// accepted.
-	var listener net.Listener
-
+	//
 	// In case we run in a socket-activated environment, listen on (the first)
 	// passed FD.
-	sdListeners, err := sdActivation.Listeners()
+	listener, err := listen.ActivatedOrListen("tcp", s.opt.ListenAddr)
 	if err != nil {
-		return fmt.Errorf("unable to acquire listeners: %w", err)
-	}
-
-	if len(sdListeners) > 0 {
-		if len(sdListeners) > 1 {
-			fs.LogPrintf(fs.LogLevelWarning, nil, "more than one listener passed, ignoring all but the first.\n")
-		}
-		listener = sdListeners[0]
-	} else {
-		listener, err = net.Listen("tcp", s.opt.ListenAddr)
-		if err != nil {
-			return fmt.Errorf("failed to listen for connection: %w", err)
-		}
+		return fmt.Errorf("failed to listen for connection: %w", err)
 	}
 	s.listener = listener
 	return nil
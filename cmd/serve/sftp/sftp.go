@@ -12,6 +12,7 @@ import (
 	"github.com/rclone/rclone/cmd/serve"
 	"github.com/rclone/rclone/cmd/serve/proxy"
 	"github.com/rclone/rclone/cmd/serve/proxy/proxyflags"
+	"github.com/rclone/rclone/cmd/serve/userdb"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/configstruct"
 	"github.com/rclone/rclone/fs/config/flags"
@@ -28,7 +29,7 @@ import (
 var OptionsInfo = fs.Options{{
 	Name:    "addr",
 	Default: "localhost:2022",
-	Help:    "IPaddress:Port or :Port to bind server to",
+	Help:    "IPaddress:Port, :Port or [unix://]/path/to/socket to bind server to",
 }, {
 	Name:    "key",
 	Default: []string{},
@@ -37,6 +38,10 @@ var OptionsInfo = fs.Options{{
 	Name:    "authorized_keys",
 	Default: "~/.ssh/authorized_keys",
 	Help:    "Authorized keys file",
+}, {
+	Name:    "user_db",
+	Default: "",
+	Help:    "User database file or directory mapping keys to users, roots and permissions",
 }, {
 	Name:    "user",
 	Default: "",
@@ -60,6 +65,7 @@ type Options struct {
 	ListenAddr     string   `config:"addr"`            // Port to listen on
 	HostKeys       []string `config:"key"`             // Paths to private host keys
 	AuthorizedKeys string   `config:"authorized_keys"` // Path to authorized keys file
+	UserDB         string   `config:"user_db"`         // Path to user database
 	User           string   `config:"user"`            // single username
 	Pass           string   `config:"pass"`            // password for user
 	NoAuth         bool     `config:"no_auth"`         // allow no authentication on connections
@@ -121,6 +127,17 @@ The server will respond to a small number of shell commands, mainly
 md5sum, sha1sum and df, which enable it to provide support for checksums
 and the about feature when accessed from an sftp remote.
 
+The server supports the ` + "`posix-rename@openssh.com`" + ` extension, which
+renames a file replacing any existing file, and the
+` + "`statvfs@openssh.com`" + ` extension, which reports the size and free
+space of the remote, for clients such as sshfs and rsync over sshfs.
+It also supports the ` + "`fsync@openssh.com`" + ` extension, which flushes
+the files open for writing to the VFS cache, and the ` + "`copy-data`" + `
+extension, which copies data between two open files on the server
+without sending it to the client. Files are still written to the
+remote when they are closed. The ` + "`hardlink@openssh.com`" + ` extension
+isn't supported as the VFS can't make hard links.
+
 Note that this server uses standard 32 KiB packet payload size, which
 means you must not configure the client to expect anything else, e.g.
 with the [chunk_size](/sftp/#sftp-chunk-size) option on an sftp remote.
@@ -133,8 +150,8 @@ Use ` + "`--stats`" + ` to control the stats printing.
 You must provide some means of authentication, either with
 ` + "`--user`/`--pass`" + `, an authorized keys file (specify location with
 ` + "`--authorized-keys`" + ` - the default is the same as ssh), an
-` + "`--auth-proxy`" + `, or set the ` + "`--no-auth`" + ` flag for no
-authentication when logging in.
+` + "`--auth-proxy`" + `, a ` + "`--user-db`" + ` (see below), or set the
+` + "`--no-auth`" + ` flag for no authentication when logging in.
 
 If you don't supply a host ` + "`--key`" + ` then rclone will generate rsa, ecdsa
 and ed25519 variants, and cache them for later use in rclone's cache
@@ -144,6 +161,11 @@ directory.
 By default the server binds to localhost:2022 - if you want it to be
 reachable externally then supply ` + "`--addr :2022`" + ` for example.
 
+You can use a unix socket by setting the address to
+` + "`unix:///path/to/socket`" + ` or just by using an absolute path name,
+for example to put the server behind a local proxy without opening a
+TCP port.
+
 This also supports being run with socket activation, in which case it will
 listen on the first passed FD.
 It can be configured with .socket and .service unit files as described in
@@ -178,7 +200,7 @@ used. Omitting "restrict" and using  ` + "`--sftp-path-override`" + ` to enable
 checksumming is possible but less secure and you could use the SFTP server
 provided by OpenSSH in this case.
 
-` + strings.TrimSpace(vfs.Help()+proxy.Help),
+` + strings.TrimSpace(vfs.Help()+proxy.Help+userdb.Help),
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.48",
 		"groups":            "Filter",
@@ -9,6 +9,10 @@ package sftp
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -23,6 +27,7 @@ import (
 	"github.com/rclone/rclone/vfs/vfscommon"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 )
 
 const (
@@ -37,6 +42,9 @@ var (
 	_ sftp.FileWriter = vfsHandler{}
 	_ sftp.FileCmder  = vfsHandler{}
 	_ sftp.FileLister = vfsHandler{}
+
+	_ sftp.PosixRenameFileCmder = vfsHandler{}
+	_ sftp.StatVFSFileCmder     = vfsHandler{}
 )
 
 // TestSftp runs the sftp server then runs the unit tests for the
@@ -85,3 +93,64 @@ func TestRc(t *testing.T) {
 		"vfs_cache_mode": "off",
 	})
 }
+
+// TestUserDB checks users from the user database get their own root
+func TestUserDB(t *testing.T) {
+	ctx := context.Background()
+	remoteDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(remoteDir, "users", "alice"), 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "users", "alice", "file.txt"), []byte("hello"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "secret.txt"), []byte("secret"), 0666))
+	f, err := fs.NewFs(ctx, remoteDir)
+	require.NoError(t, err)
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+	dbFile := filepath.Join(t.TempDir(), "users")
+	require.NoError(t, os.WriteFile(dbFile, []byte("alice users/alice ro "+string(ssh.MarshalAuthorizedKey(signer.PublicKey()))), 0666))
+
+	opt := Opt
+	opt.ListenAddr = testBindAddress
+	opt.UserDB = dbFile
+	s, err := newServer(ctx, f, &opt, &vfscommon.Opt, &proxy.Opt)
+	require.NoError(t, err)
+	go func() {
+		require.NoError(t, s.Serve())
+	}()
+	defer func() {
+		assert.NoError(t, s.Shutdown())
+	}()
+
+	dial := func(user string, auth ssh.AuthMethod) (*ssh.Client, error) {
+		return ssh.Dial("tcp", s.Addr().String(), &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{auth},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+	}
+	_, err = dial("bob", ssh.PublicKeys(signer))
+	assert.Error(t, err)
+	_, err = dial("alice", ssh.Password("potato"))
+	assert.Error(t, err)
+
+	sshClient, err := dial("alice", ssh.PublicKeys(signer))
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, sshClient.Close())
+	}()
+	client, err := sftp.NewClient(sshClient)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, client.Close())
+	}()
+	entries, err := client.ReadDir("/")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "file.txt", entries[0].Name())
+	_, err = client.Stat("/../secret.txt")
+	assert.Error(t, err)
+	_, err = client.Create("/new.txt")
+	assert.Error(t, err)
+}
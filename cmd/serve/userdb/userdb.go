@@ -0,0 +1,389 @@
+// Package userdb implements a simple user database for rclone serve
+//
+// It maps users authenticated by SSH public key, SSH certificate or
+// TLS client certificate to a directory of the served remote and a
+// set of permissions.
+package userdb
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/lib/env"
+	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"golang.org/x/crypto/ssh"
+)
+
+// Help contains text describing how to use the user database
+var Help = strings.ReplaceAll(`### User database
+
+If you supply the parameter |--user-db /path/to/users| then rclone
+will authenticate users against a simple user database and give each
+user their own directory of the remote, which they can't see outside
+of, and their own permissions. This is an alternative to
+|--auth-proxy| for small multi-user servers and can't be used with it.
+
+The user database is either a file or a directory, in which case all
+the files in it not starting with |.| and not ending in |.pem|,
+|.crt| or |.cer| are read, so certificates can be kept alongside. Each line in a file
+looks like this
+
+|||
+user root permissions credential
+|||
+
+- |user| - the name the user logs in with
+- |root| - the directory of the remote to give the user, or |/| for all of it
+- |permissions| - |rw| for read and write or |ro| for read only
+- |credential| - what the user may authenticate with, one of
+    - an SSH public key in authorized_keys format, e.g. |ssh-ed25519 AAAAC3Nz...|
+    - |cert-authority| followed by an SSH public key - accept SSH
+      certificates signed by this key with the user as a principal
+    - |x509| followed by the path of a PEM encoded TLS client
+      certificate, relative to the file it is in - accept this exact
+      client certificate
+    - |-| - accept the user however the server authenticated them,
+      e.g. by client certificate common name with |--client-ca|
+
+Blank lines and lines starting with |#| are ignored. A user may have
+several lines, one for each credential, but they must all have the
+same root and permissions.
+
+|||
+# user root         perms credential
+alice  users/alice  rw    ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIG... alice@laptop
+alice  users/alice  rw    x509 alice.pem
+bob    shared       ro    cert-authority ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIH...
+carol  users/carol  rw    -
+|||
+
+SFTP users authenticate with their SSH keys or certificates. WebDAV
+users are authenticated by the server's usual methods, normally a
+TLS client certificate verified by |--client-ca|, and the user name
+found is then looked up in the database.
+
+The database is read when the server starts, so restart the server
+to pick up changes.
+
+`, "|", "`")
+
+// User is an entry in the user database
+type User struct {
+	Name        string // name of the user
+	Root        string // root of the user within the remote, "" for all of it
+	ReadOnly    bool   // set if the user may only read
+	AnyAuth     bool   // accept however the server authenticated the user
+	keys        map[string]struct{}
+	authorities map[string]struct{}
+	certs       map[[sha256.Size]byte]struct{}
+}
+
+// DB is a user database
+type DB struct {
+	ctx    context.Context
+	f      fs.Fs
+	vfsOpt vfscommon.Options
+	users  map[string]*User
+	mu     sync.Mutex
+	vfses  map[string]*vfs.VFS
+}
+
+// Load reads the user database from the file or directory at dbPath.
+//
+// The users are given VFS made from directories of f with vfsOpt.
+func Load(ctx context.Context, dbPath string, f fs.Fs, vfsOpt *vfscommon.Options) (*DB, error) {
+	db := &DB{
+		ctx:    ctx,
+		f:      f,
+		vfsOpt: *vfsOpt,
+		users:  make(map[string]*User),
+		vfses:  make(map[string]*vfs.VFS),
+	}
+	dbPath = env.ShellExpand(dbPath)
+	fi, err := os.Stat(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("user db: %w", err)
+	}
+	files := []string{dbPath}
+	if fi.IsDir() {
+		entries, err := os.ReadDir(dbPath)
+		if err != nil {
+			return nil, fmt.Errorf("user db: %w", err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || isCertFile(entry.Name()) {
+				continue
+			}
+			files = append(files, filepath.Join(dbPath, entry.Name()))
+		}
+	}
+	for _, file := range files {
+		err = db.loadFile(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(db.users) == 0 {
+		return nil, fmt.Errorf("user db: no users found in %q", dbPath)
+	}
+	fs.Logf(nil, "Loaded %d users from %q", len(db.users), dbPath)
+	return db, nil
+}
+
+// isCertFile returns true if name looks like a certificate file
+// rather than part of the user database
+func isCertFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pem", ".crt", ".cer":
+		return true
+	}
+	return false
+}
+
+// loadFile reads the users from a single file
+func (db *DB) loadFile(file string) (err error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("user db: %w", err)
+	}
+	defer fs.CheckClose(in, &err)
+	return db.parse(in, file)
+}
+
+// parse reads users from in, naming errors with file and reading
+// x509 certificates relative to it.
+func (db *DB) parse(in io.Reader, file string) error {
+	scanner := bufio.NewScanner(in)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		err := db.parseLine(line, filepath.Dir(file))
+		if err != nil {
+			return fmt.Errorf("user db: %s:%d: %w", file, lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("user db: %s: %w", file, err)
+	}
+	return nil
+}
+
+// parseLine adds the user described by line to the database
+func (db *DB) parseLine(line, dir string) error {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return errors.New("expecting user, root, permissions and credential")
+	}
+	name, root, perms, credential := fields[0], cleanRoot(fields[1]), fields[2], fields[3:]
+	var readOnly bool
+	switch perms {
+	case "rw":
+	case "ro":
+		readOnly = true
+	default:
+		return fmt.Errorf("unknown permissions %q - expecting rw or ro", perms)
+	}
+	u := db.users[name]
+	if u == nil {
+		u = &User{
+			Name:        name,
+			Root:        root,
+			ReadOnly:    readOnly,
+			keys:        make(map[string]struct{}),
+			authorities: make(map[string]struct{}),
+			certs:       make(map[[sha256.Size]byte]struct{}),
+		}
+		db.users[name] = u
+	} else if u.Root != root || u.ReadOnly != readOnly {
+		return fmt.Errorf("user %q has a different root or permissions to an earlier line", name)
+	}
+	switch credential[0] {
+	case "-":
+		u.AnyAuth = true
+	case "x509":
+		if len(credential) != 2 {
+			return errors.New("expecting path to certificate after x509")
+		}
+		certFile := env.ShellExpand(credential[1])
+		if !filepath.IsAbs(certFile) {
+			certFile = filepath.Join(dir, certFile)
+		}
+		sums, err := loadCertificates(certFile)
+		if err != nil {
+			return err
+		}
+		for _, sum := range sums {
+			u.certs[sum] = struct{}{}
+		}
+	case "cert-authority":
+		pubKey, err := parseKey(strings.Join(credential[1:], " "))
+		if err != nil {
+			return err
+		}
+		u.authorities[string(pubKey.Marshal())] = struct{}{}
+	default:
+		pubKey, err := parseKey(strings.Join(credential, " "))
+		if err != nil {
+			return err
+		}
+		u.keys[string(pubKey.Marshal())] = struct{}{}
+	}
+	return nil
+}
+
+// cleanRoot returns root relative to the root of the remote.
+//
+// Any ".." are resolved as if root was absolute so it can't point
+// outside the remote.
+func cleanRoot(root string) string {
+	return strings.TrimPrefix(path.Clean("/"+root), "/")
+}
+
+// parseKey parses an SSH public key in authorized_keys format
+func parseKey(in string) (ssh.PublicKey, error) {
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(in))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return pubKey, nil
+}
+
+// loadCertificates returns the SHA-256 sums of the PEM encoded
+// certificates in certFile
+func loadCertificates(certFile string) (sums [][sha256.Size]byte, err error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate %q: %w", certFile, err)
+		}
+		sums = append(sums, sha256.Sum256(cert.Raw))
+	}
+	if len(sums) == 0 {
+		return nil, fmt.Errorf("no certificates found in %q", certFile)
+	}
+	return sums, nil
+}
+
+// Users returns the names of the users in the database in order
+func (db *DB) Users() (names []string) {
+	for name := range db.users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the user called name or nil if not found
+func (db *DB) Get(name string) *User {
+	return db.users[name]
+}
+
+// CheckPublicKey checks that the user may log in with pubKey, which
+// may be an SSH certificate.
+func (db *DB) CheckPublicKey(name string, pubKey ssh.PublicKey) error {
+	u := db.users[name]
+	if u == nil {
+		return fmt.Errorf("unknown user %q", name)
+	}
+	if cert, ok := pubKey.(*ssh.Certificate); ok {
+		if cert.CertType != ssh.UserCert {
+			return fmt.Errorf("certificate for %q is not a user certificate", name)
+		}
+		checker := ssh.CertChecker{
+			IsUserAuthority: func(auth ssh.PublicKey) bool {
+				_, found := u.authorities[string(auth.Marshal())]
+				return found
+			},
+		}
+		if !checker.IsUserAuthority(cert.SignatureKey) {
+			return fmt.Errorf("certificate for %q signed by unknown authority", name)
+		}
+		return checker.CheckCert(name, cert)
+	}
+	if _, found := u.keys[string(pubKey.Marshal())]; found {
+		return nil
+	}
+	return fmt.Errorf("unknown public key for %q", name)
+}
+
+// CheckAuthenticated checks that the user, who has already been
+// authenticated by the server presenting the client certificates
+// certs, may log in.
+func (db *DB) CheckAuthenticated(name string, certs []*x509.Certificate) error {
+	u := db.users[name]
+	if u == nil {
+		return fmt.Errorf("unknown user %q", name)
+	}
+	if u.AnyAuth {
+		return nil
+	}
+	for _, cert := range certs {
+		if _, found := u.certs[sha256.Sum256(cert.Raw)]; found {
+			return nil
+		}
+	}
+	return fmt.Errorf("no matching client certificate for %q", name)
+}
+
+// VFS returns the VFS for the user called name, creating it if
+// necessary.
+func (db *DB) VFS(name string) (*vfs.VFS, error) {
+	u := db.users[name]
+	if u == nil {
+		return nil, fmt.Errorf("unknown user %q", name)
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if VFS := db.vfses[name]; VFS != nil {
+		return VFS, nil
+	}
+	f := db.f
+	if u.Root != "" {
+		var err error
+		f, err = cache.Get(db.ctx, fspath.JoinRootPath(fs.ConfigStringFull(db.f), u.Root))
+		if err == fs.ErrorIsFile {
+			return nil, fmt.Errorf("root %q of user %q is a file", u.Root, name)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to make root of user %q: %w", name, err)
+		}
+	}
+	opt := db.vfsOpt
+	// The user can't override a read only server
+	opt.ReadOnly = opt.ReadOnly || u.ReadOnly
+	VFS := vfs.New(f, &opt)
+	db.vfses[name] = VFS
+	return VFS, nil
+}
@@ -0,0 +1,160 @@
+package userdb
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// makeSigner makes a new SSH key
+func makeSigner(t *testing.T) ssh.Signer {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+	return signer
+}
+
+// makeCert makes a self signed TLS certificate for cn
+func makeCert(t *testing.T, cn string) *x509.Certificate {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, public, private)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	remoteDir := t.TempDir()
+	f, err := fs.NewFs(ctx, remoteDir)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(remoteDir, "users", "alice"), 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "users", "alice", "file.txt"), []byte("hello"), 0666))
+
+	aliceKey := makeSigner(t)
+	ca := makeSigner(t)
+	aliceCert := makeCert(t, "alice")
+	dbDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dbDir, "alice.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: aliceCert.Raw}), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dbDir, "users"), []byte(`
+# user root perms credential
+alice users/alice rw `+string(ssh.MarshalAuthorizedKey(aliceKey.PublicKey()))+`
+alice /users/alice/ rw x509 alice.pem
+bob ../../users ro cert-authority `+string(ssh.MarshalAuthorizedKey(ca.PublicKey()))+`
+carol / ro -
+`), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dbDir, ".hidden"), []byte("rubbish"), 0666))
+
+	db, err := Load(ctx, dbDir, f, &vfscommon.Opt)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob", "carol"}, db.Users())
+	assert.Equal(t, "users/alice", db.Get("alice").Root)
+	assert.Equal(t, "users", db.Get("bob").Root)
+	assert.True(t, db.Get("bob").ReadOnly)
+	assert.Equal(t, "", db.Get("carol").Root)
+	assert.Nil(t, db.Get("dave"))
+
+	t.Run("PublicKey", func(t *testing.T) {
+		assert.NoError(t, db.CheckPublicKey("alice", aliceKey.PublicKey()))
+		assert.Error(t, db.CheckPublicKey("bob", aliceKey.PublicKey()))
+		assert.Error(t, db.CheckPublicKey("alice", makeSigner(t).PublicKey()))
+		assert.Error(t, db.CheckPublicKey("dave", aliceKey.PublicKey()))
+	})
+
+	t.Run("Certificate", func(t *testing.T) {
+		makeSSHCert := func(principal string, signer ssh.Signer) *ssh.Certificate {
+			cert := &ssh.Certificate{
+				Key:             makeSigner(t).PublicKey(),
+				CertType:        ssh.UserCert,
+				ValidPrincipals: []string{principal},
+				ValidAfter:      uint64(time.Now().Add(-time.Hour).Unix()),
+				ValidBefore:     uint64(time.Now().Add(time.Hour).Unix()),
+			}
+			require.NoError(t, cert.SignCert(rand.Reader, signer))
+			return cert
+		}
+		assert.NoError(t, db.CheckPublicKey("bob", makeSSHCert("bob", ca)))
+		assert.Error(t, db.CheckPublicKey("bob", makeSSHCert("alice", ca)))
+		assert.Error(t, db.CheckPublicKey("bob", makeSSHCert("bob", makeSigner(t))))
+		assert.Error(t, db.CheckPublicKey("alice", makeSSHCert("alice", ca)))
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		assert.NoError(t, db.CheckAuthenticated("alice", []*x509.Certificate{aliceCert}))
+		assert.Error(t, db.CheckAuthenticated("alice", []*x509.Certificate{makeCert(t, "alice")}))
+		assert.Error(t, db.CheckAuthenticated("alice", nil))
+		assert.Error(t, db.CheckAuthenticated("bob", []*x509.Certificate{aliceCert}))
+		assert.NoError(t, db.CheckAuthenticated("carol", nil))
+	})
+
+	t.Run("VFS", func(t *testing.T) {
+		VFS, err := db.VFS("alice")
+		require.NoError(t, err)
+		_, err = VFS.Stat("file.txt")
+		assert.NoError(t, err)
+		assert.False(t, VFS.Opt.ReadOnly)
+		VFS2, err := db.VFS("alice")
+		require.NoError(t, err)
+		assert.Same(t, VFS, VFS2)
+
+		VFS, err = db.VFS("bob")
+		require.NoError(t, err)
+		_, err = VFS.Stat("alice/file.txt")
+		assert.NoError(t, err)
+		assert.True(t, VFS.Opt.ReadOnly)
+
+		_, err = db.VFS("dave")
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadErrors(t *testing.T) {
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, t.TempDir())
+	require.NoError(t, err)
+	key := string(ssh.MarshalAuthorizedKey(makeSigner(t).PublicKey()))
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"", "no users found"},
+		{"alice users rw", "expecting user, root, permissions and credential"},
+		{"alice users rwx -", "unknown permissions"},
+		{"alice users rw ssh-ed25519 potato", "failed to parse public key"},
+		{"alice users rw x509", "expecting path to certificate"},
+		{"alice users rw x509 notfound.pem", "failed to read certificate"},
+		{"alice users rw -\nalice other rw " + key, ":2: user \"alice\" has a different root"},
+	} {
+		dbFile := filepath.Join(t.TempDir(), "users")
+		require.NoError(t, os.WriteFile(dbFile, []byte(test.in), 0666))
+		_, err := Load(ctx, dbFile, f, &vfscommon.Opt)
+		require.Error(t, err, test.in)
+		assert.Contains(t, err.Error(), test.want, test.in)
+	}
+	_, err = Load(ctx, filepath.Join(t.TempDir(), "notfound"), f, &vfscommon.Opt)
+	assert.Error(t, err)
+}
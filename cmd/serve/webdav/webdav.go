@@ -3,6 +3,7 @@ package webdav
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -21,6 +22,7 @@ import (
 	cmdserve "github.com/rclone/rclone/cmd/serve"
 	"github.com/rclone/rclone/cmd/serve/proxy"
 	"github.com/rclone/rclone/cmd/serve/proxy/proxyflags"
+	"github.com/rclone/rclone/cmd/serve/userdb"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/configstruct"
 	"github.com/rclone/rclone/fs/config/flags"
@@ -45,18 +47,30 @@ var OptionsInfo = fs.Options{{
 	Name:    "disable_dir_list",
 	Default: false,
 	Help:    "Disable HTML directory list on GET request for a directory",
+}, {
+	Name:    "disable_zip",
+	Default: false,
+	Help:    "Disable zip and tar download of directories",
+}, {
+	Name:    "user_db",
+	Default: "",
+	Help:    "User database file or directory mapping users to roots and permissions",
 }}.
 	Add(libhttp.ConfigInfo).
 	Add(libhttp.AuthConfigInfo).
+	Add(libhttp.AuditConfigInfo).
 	Add(libhttp.TemplateConfigInfo)
 
 // Options required for http server
 type Options struct {
 	Auth           libhttp.AuthConfig
+	Audit          libhttp.AuditConfig
 	HTTP           libhttp.Config
 	Template       libhttp.TemplateConfig
 	EtagHash       string `config:"etag_hash"`
 	DisableDirList bool   `config:"disable_dir_list"`
+	DisableZip     bool   `config:"disable_zip"`
+	UserDB         string `config:"user_db"`
 }
 
 // Opt is options set by command line flags
@@ -117,6 +131,13 @@ supported hash on the backend or you can use a named hash such as
 "MD5" or "SHA-1". Use the [hashsum](/commands/rclone_hashsum/) command
 to see the full list.
 
+#### --disable-zip
+
+Directories can be downloaded as an archive made on the fly from the
+remote, without staging it locally, by adding ` + "`?download=zip`" + `,
+` + "`?download=tar` or `?download=tar.gz`" + ` to the URL of the directory.
+This flag disables that.
+
 ### Access WebDAV on Windows
 
 WebDAV shared folder can be mapped as a drive on Windows, however the default
@@ -170,7 +191,7 @@ rclone --webdav-unix-socket /tmp/my.socket --webdav-url http://localhost lsf :we
 Note that there is no authentication on http protocol - this is expected to be
 done by the permissions on the socket.
 
-` + strings.TrimSpace(libhttp.Help(flagPrefix)+libhttp.TemplateHelp(flagPrefix)+libhttp.AuthHelp(flagPrefix)+vfs.Help()+proxy.Help),
+` + strings.TrimSpace(libhttp.Help(flagPrefix)+libhttp.TemplateHelp(flagPrefix)+libhttp.AuthHelp(flagPrefix)+libhttp.AuditHelp(flagPrefix)+vfs.Help()+proxy.Help+userdb.Help),
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.39",
 		"groups":            "Filter",
@@ -214,6 +235,7 @@ type WebDAV struct {
 	_vfs          *vfs.VFS // don't use directly, use getVFS
 	webdavhandler *webdav.Handler
 	proxy         *proxy.Proxy
+	userDB        *userdb.DB
 	ctx           context.Context // for global config
 	etagHashType  hash.Type
 }
@@ -241,16 +263,31 @@ func newWebDAV(ctx context.Context, f fs.Fs, opt *Options, vfsOpt *vfscommon.Opt
 		fs.Debugf(f, "Using hash %v for ETag", w.etagHashType)
 	}
 	if proxyOpt.AuthProxy != "" {
+		if opt.UserDB != "" {
+			return nil, errors.New("--auth-proxy and --user-db cannot be used at the same time")
+		}
 		w.proxy = proxy.New(ctx, proxyOpt, vfsOpt)
 		// override auth
 		w.opt.Auth.CustomAuthFn = w.auth
+	} else if opt.UserDB != "" {
+		auth := opt.Auth
+		if opt.HTTP.ClientCA == "" && auth.HtPasswd == "" && auth.BasicUser == "" && auth.UserFromHeader == "" && auth.OIDCIssuer == "" {
+			return nil, errors.New("--user-db needs users to be authenticated, e.g. with --client-ca")
+		}
+		w.userDB, err = userdb.Load(ctx, opt.UserDB, f, vfsOpt)
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		w._vfs = vfs.New(f, vfsOpt)
 	}
 
+	audit := w.opt.Audit
+	audit.Protocol = "webdav"
 	w.server, err = libhttp.NewServer(ctx,
 		libhttp.WithConfig(w.opt.HTTP),
 		libhttp.WithAuth(w.opt.Auth),
+		libhttp.WithAudit(audit),
 		libhttp.WithTemplate(w.opt.Template),
 	)
 	if err != nil {
@@ -273,6 +310,9 @@ func newWebDAV(ctx context.Context, f fs.Fs, opt *Options, vfsOpt *vfscommon.Opt
 		middleware.SetHeader("Accept-Ranges", "bytes"),
 		middleware.SetHeader("Server", "rclone/"+fs.Version),
 	)
+	if w.userDB != nil {
+		router.Use(w.userDBAuth)
+	}
 
 	router.Handle("/*", w)
 
@@ -294,12 +334,20 @@ func newWebDAV(ctx context.Context, f fs.Fs, opt *Options, vfsOpt *vfscommon.Opt
 	return w, nil
 }
 
+// ctxKeyUserVFS is the context key for the VFS found in the user database
+type ctxKeyUserVFS struct{}
+
 // Gets the VFS in use for this request
 func (w *WebDAV) getVFS(ctx context.Context) (VFS *vfs.VFS, err error) {
 	if w._vfs != nil {
 		return w._vfs, nil
 	}
-	value := libhttp.CtxGetAuth(ctx)
+	var value any
+	if w.userDB != nil {
+		value = ctx.Value(ctxKeyUserVFS{})
+	} else {
+		value = libhttp.CtxGetAuth(ctx)
+	}
 	if value == nil {
 		return nil, errors.New("no VFS found in context")
 	}
@@ -319,6 +367,39 @@ func (w *WebDAV) auth(user, pass string) (value any, err error) {
 	return VFS, err
 }
 
+// userDBAuth is middleware which looks up the user the server
+// authenticated in the user database and adds their VFS to the
+// request context.
+func (w *WebDAV) userDBAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// skip auth for CORS preflight
+		if r.Method == "OPTIONS" {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		user, ok := libhttp.CtxGetUser(r.Context())
+		if !ok {
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		var certs []*x509.Certificate
+		if r.TLS != nil {
+			certs = r.TLS.PeerCertificates
+		}
+		err := w.userDB.CheckAuthenticated(user, certs)
+		var VFS *vfs.VFS
+		if err == nil {
+			VFS, err = w.userDB.VFS(user)
+		}
+		if err != nil {
+			fs.Infof(r.URL.Path, "%s: Auth failed from %s: %v", r.RemoteAddr, user, err)
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), ctxKeyUserVFS{}, VFS)))
+	})
+}
+
 type webdavRW struct {
 	http.ResponseWriter
 	status int
@@ -408,6 +489,12 @@ func (w *WebDAV) serveDir(rw http.ResponseWriter, r *http.Request, dirRemote str
 		return
 	}
 	dir := node.(*vfs.Dir)
+
+	if !w.opt.DisableZip && serve.IsArchiveRequest(r) {
+		serve.Archive(rw, r, dirRemote, dir)
+		return
+	}
+
 	dirEntries, err := dir.ReadDirAll()
 
 	if err != nil {
@@ -429,6 +516,8 @@ func (w *WebDAV) serveDir(rw http.ResponseWriter, r *http.Request, dirRemote str
 	orderParm := r.URL.Query().Get("order")
 	directory.ProcessQueryParams(sortParm, orderParm)
 
+	directory.DisableZip = w.opt.DisableZip
+
 	directory.Serve(rw, r)
 }
 
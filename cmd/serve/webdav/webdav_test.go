@@ -13,6 +13,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -132,6 +133,23 @@ func TestHTTPFunction(t *testing.T) {
 	}
 
 	HelpTestGET(t, testURL)
+
+	// Check directories can be downloaded as archives
+	for format, mimeType := range map[string]string{
+		"zip":    "application/zip",
+		"tar":    "application/x-tar",
+		"tar.gz": "application/gzip",
+	} {
+		resp, err := http.Get(testURL + "three/?download=" + format)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusOK, resp.StatusCode, format)
+		assert.Equal(t, mimeType, resp.Header.Get("Content-Type"), format)
+		assert.Equal(t, `attachment; filename="three.`+format+`"`, resp.Header.Get("Content-Disposition"), format)
+		assert.NotEmpty(t, body, format)
+	}
 }
 
 // check body against the file, or re-write body if -updategolden is
@@ -271,3 +289,58 @@ func TestRc(t *testing.T) {
 		"vfs_cache_mode": "off",
 	})
 }
+
+// TestUserDB checks users from the user database get their own root
+func TestUserDB(t *testing.T) {
+	ctx := context.Background()
+	remoteDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(remoteDir, "users", testUser), 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "users", testUser, "file.txt"), []byte("hello"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "secret.txt"), []byte("secret"), 0666))
+	f, err := fs.NewFs(ctx, remoteDir)
+	require.NoError(t, err)
+
+	get := func(t *testing.T, db string, path string) (int, string) {
+		dbFile := filepath.Join(t.TempDir(), "users")
+		require.NoError(t, os.WriteFile(dbFile, []byte(db), 0666))
+		opt := Opt
+		opt.HTTP.ListenAddr = []string{testBindAddress}
+		opt.Auth.BasicUser = testUser
+		opt.Auth.BasicPass = testPass
+		opt.UserDB = dbFile
+		w, err := newWebDAV(ctx, f, &opt, &vfscommon.Opt, &proxy.Opt)
+		require.NoError(t, err)
+		go func() {
+			require.NoError(t, w.Serve())
+		}()
+		defer func() {
+			assert.NoError(t, w.Shutdown())
+		}()
+		req, err := http.NewRequest("GET", w.server.URLs()[0]+path, nil)
+		require.NoError(t, err)
+		req.SetBasicAuth(testUser, testPass)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, resp.Body.Close())
+		}()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	code, body := get(t, testUser+" users/"+testUser+" rw -", "file.txt")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "hello", body)
+	code, _ = get(t, testUser+" users/"+testUser+" rw -", "../secret.txt")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = get(t, "other / rw -", "file.txt")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// needs authentication
+	opt := Opt
+	opt.HTTP.ListenAddr = []string{testBindAddress}
+	opt.UserDB = filepath.Join(t.TempDir(), "users")
+	_, err = newWebDAV(ctx, f, &opt, &vfscommon.Opt, &proxy.Opt)
+	assert.ErrorContains(t, err, "--user-db needs users to be authenticated")
+}
@@ -3,7 +3,6 @@ package settier
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/rclone/rclone/cmd"
 	"github.com/rclone/rclone/fs/operations"
@@ -43,7 +42,10 @@ Or just provide remote directory and all files in directory will be tiered
 
 ` + "```console" + `
 rclone settier tier remote:path/dir
-` + "```",
+` + "```" + `
+
+See the [tier](/commands/rclone_tier/) command to see the tiers in use
+before changing them.`,
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.44",
 	},
@@ -53,11 +55,6 @@ rclone settier tier remote:path/dir
 		input := args[1:]
 		fsrc := cmd.NewFsSrc(input)
 		cmd.Run(false, false, command, func() error {
-			isSupported := fsrc.Features().SetTier
-			if !isSupported {
-				return fmt.Errorf("remote %s does not support settier", fsrc.Name())
-			}
-
 			return operations.SetTier(context.Background(), fsrc, tier)
 		})
 	},
@@ -0,0 +1,181 @@
+// Package statsdb provides the stats-db command.
+package statsdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/history"
+	"github.com/spf13/cobra"
+)
+
+var (
+	since   fs.Time
+	until   fs.Time
+	filter  history.Filter
+	files   bool
+	jsonOut bool
+	maxAge  = fs.Duration(0)
+)
+
+// timeFormat is the format of the times printed
+const timeFormat = "2006-01-02 15:04:05"
+
+func init() {
+	cmd.Root.AddCommand(Command)
+	Command.AddCommand(queryCommand, pruneCommand)
+	cmdFlags := queryCommand.Flags()
+	flags.FVarP(cmdFlags, &since, "since", "", "Only show records finishing at or after this time or duration ago", "")
+	flags.FVarP(cmdFlags, &until, "until", "", "Only show records finishing before this time or duration ago", "")
+	flags.StringVarP(cmdFlags, &filter.Command, "command", "", "", "Only show runs of this command, e.g. sync", "")
+	flags.StringVarP(cmdFlags, &filter.Run, "run", "", "", "Only show the run with this ID", "")
+	flags.StringVarP(cmdFlags, &filter.Name, "name", "", "", "Only show files whose path contains this", "")
+	flags.BoolVarP(cmdFlags, &filter.Errors, "errors", "", false, "Only show records with errors", "")
+	flags.BoolVarP(cmdFlags, &files, "files", "", false, "Show the files transferred instead of the runs", "")
+	flags.BoolVarP(cmdFlags, &jsonOut, "json", "", false, "Output the records as JSON", "")
+	flags.FVarP(pruneCommand.Flags(), &maxAge, "max-age", "", "Remove runs which finished longer ago than this", "")
+}
+
+// Command definition for cobra
+var Command = &cobra.Command{
+	Use:   "stats-db <subcommand>",
+	Short: `Query the history of transfers recorded with --stats-db.`,
+	Long: `When the ` + "`--stats-db`" + ` flag is used rclone records a summary of
+each run, and a record of each file it transfers, deletes or moves, in
+a database in the cache directory. This builds up a long term history
+of what rclone has done which can be queried without reading the logs.
+
+` + "```console" + `
+rclone sync --stats-db /home/user/docs remote:docs
+` + "```" + `
+
+To record the history of every run put ` + "`RCLONE_STATS_DB=true`" + ` in the
+environment.
+
+These subcommands query and prune the history.`,
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+	},
+}
+
+var queryCommand = &cobra.Command{
+	Use:   "query",
+	Short: `Show the runs or files recorded in the history.`,
+	Long: `This shows the runs recorded by ` + "`--stats-db`" + `, oldest first, with
+how much they transferred, checked and deleted and whether they had
+errors.
+
+Use ` + "`--files`" + ` to show the files transferred, deleted or moved
+instead.
+
+The records can be filtered by when they finished with ` + "`--since`" + `
+and ` + "`--until`" + `, which take a time or a duration ago, by the command,
+run ID, file path and whether they had errors. For example to see
+what changed on the 14th of June 2025:
+
+` + "```console" + `
+rclone stats-db query --files --since 2025-06-14 --until 2025-06-15
+` + "```" + `
+
+Or to see the sync runs which failed in the last week:
+
+` + "```console" + `
+rclone stats-db query --command sync --errors --since 1w
+` + "```" + `
+
+Use ` + "`--json`" + ` to output the records as JSON for further processing.`,
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+	},
+	RunE: func(command *cobra.Command, args []string) error {
+		cmd.CheckArgs(0, 0, command, args)
+		ctx := context.Background()
+		filter.Since = time.Time(since)
+		filter.Until = time.Time(until)
+		var (
+			out any
+			err error
+		)
+		if files {
+			out, err = history.Files(ctx, filter)
+		} else {
+			out, err = history.Runs(ctx, filter)
+		}
+		if err != nil {
+			return err
+		}
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "\t")
+			return enc.Encode(out)
+		}
+		switch records := out.(type) {
+		case []history.File:
+			for _, f := range records {
+				printFile(&f)
+			}
+		case []history.Run:
+			for _, r := range records {
+				printRun(&r)
+			}
+		}
+		return nil
+	},
+}
+
+// printRun prints a one line summary of run
+func printRun(r *history.Run) {
+	result := "OK"
+	if r.Error != "" {
+		result = "FAILED: " + r.Error
+	} else if r.Errors > 0 {
+		result = fmt.Sprintf("%d errors", r.Errors)
+	}
+	fmt.Printf("%s  %s  %-8s  %v in %d files, %d checks, %d deletes, %d renames in %v  %s\n",
+		r.Start.Local().Format(timeFormat), r.ID, r.Command,
+		fs.SizeSuffix(r.Bytes).ByteUnit(), r.Transfers, r.Checks, r.Deletes, r.Renames,
+		r.End.Sub(r.Start).Truncate(time.Second), result)
+}
+
+// printFile prints a one line summary of file
+func printFile(f *history.File) {
+	result := ""
+	if f.Error != "" {
+		result = "  FAILED: " + f.Error
+	}
+	fmt.Printf("%s  %-12s  %9v  %s%s\n",
+		f.End.Local().Format(timeFormat), f.What, fs.SizeSuffix(f.Size).ByteUnit(), f.Name, result)
+}
+
+var pruneCommand = &cobra.Command{
+	Use:   "prune --max-age AGE",
+	Short: `Remove old runs from the history.`,
+	Long: `This removes the runs which finished longer ago than ` + "`--max-age`" + `
+from the history along with the records of their files.
+
+` + "```console" + `
+rclone stats-db prune --max-age 1y
+` + "```",
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+	},
+	RunE: func(command *cobra.Command, args []string) error {
+		cmd.CheckArgs(0, 0, command, args)
+		if maxAge <= 0 {
+			return errors.New("need --max-age")
+		}
+		n, err := history.Prune(context.Background(), time.Now().Add(-time.Duration(maxAge)))
+		if err != nil {
+			return err
+		}
+		fs.Logf(nil, "Removed %d runs from the history", n)
+		return nil
+	},
+}
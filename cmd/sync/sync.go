@@ -3,9 +3,11 @@ package sync
 
 import (
 	"context"
+	"errors"
 	"strings"
 
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/operations/operationsflags"
@@ -15,6 +17,8 @@ import (
 
 var (
 	createEmptySrcDirs = false
+	planFile           = ""
+	applyPlan          = ""
 	loggerOpt          = operations.LoggerOpt{}
 	loggerFlagsOpt     = operationsflags.AddLoggerFlagsOptions{}
 )
@@ -23,6 +27,8 @@ func init() {
 	cmd.Root.AddCommand(commandDefinition)
 	cmdFlags := commandDefinition.Flags()
 	flags.BoolVarP(cmdFlags, &createEmptySrcDirs, "create-empty-src-dirs", "", createEmptySrcDirs, "Create empty source dirs on destination after sync", "")
+	flags.StringVarP(cmdFlags, &planFile, "plan-file", "", planFile, "Write a JSON plan of the operations to this file with --dry-run", "")
+	flags.StringVarP(cmdFlags, &applyPlan, "apply-plan", "", applyPlan, "Do the operations in this plan made by --plan-file", "")
 	operationsflags.AddLoggerFlags(cmdFlags, &loggerOpt, &loggerFlagsOpt)
 	loggerOpt.LoggerFn = operations.NewDefaultLoggerFn(&loggerOpt)
 }
@@ -46,6 +52,23 @@ want to delete files from destination, use the
 rclone sync --interactive SOURCE remote:DESTINATION
 |||
 
+To review a sync before doing it, use |--dry-run| with |--plan-file|
+to write a plan of every operation the sync would do as JSON. The
+plan can then be done exactly as written with |--apply-plan|.
+
+|||sh
+rclone sync --dry-run --plan-file plan.json SOURCE remote:DESTINATION
+rclone sync --apply-plan plan.json SOURCE remote:DESTINATION
+|||
+
+Before doing anything |--apply-plan| checks that every file the plan
+reads, overwrites or deletes still has the size and modification time
+it had when the plan was made, and that no file has appeared where the
+plan will create one. If any have changed then nothing is done. The
+source and destination must be the same as when the plan was made.
+Flags such as |--backup-dir| are used when the plan is applied rather
+than when it is made.
+
 Files in the destination won't be deleted if there were any errors at any
 point. Duplicate objects (files with the same name, on those providers that
 support it) are not yet handled. Files that are excluded won't be deleted
@@ -99,10 +122,32 @@ for more info.
 				ctx = operations.WithSyncLogger(ctx, loggerOpt)
 			}
 
+			if applyPlan != "" {
+				plan, err := operations.LoadPlan(applyPlan)
+				if err != nil {
+					return err
+				}
+				return operations.ApplyPlan(ctx, fdst, fsrc, plan)
+			}
+
+			var plan *operations.Plan
+			if planFile != "" {
+				if !fs.GetConfig(ctx).DryRun {
+					return errors.New("--plan-file can only be used with --dry-run")
+				}
+				plan = operations.NewPlan(fsrc, fdst)
+				ctx = operations.WithPlan(ctx, plan)
+			}
+
 			if srcFileName == "" {
-				return sync.Sync(ctx, fdst, fsrc, createEmptySrcDirs)
+				err = sync.Sync(ctx, fdst, fsrc, createEmptySrcDirs)
+			} else {
+				err = operations.CopyFile(ctx, fdst, fsrc, srcFileName, srcFileName)
+			}
+			if err != nil || plan == nil {
+				return err
 			}
-			return operations.CopyFile(ctx, fdst, fsrc, srcFileName, srcFileName)
+			return plan.Save(planFile)
 		})
 	},
 }
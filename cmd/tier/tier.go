@@ -0,0 +1,94 @@
+// Package tier provides the tier command.
+package tier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/spf13/cobra"
+)
+
+var jsonOutput bool
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.BoolVarP(cmdFlags, &jsonOutput, "json", "", false, "Format the tier summary as JSON", "")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "tier remote:path [tier]",
+	Short: `Shows or changes the storage class/tier of objects in remote:path.`,
+	Long: `With one argument this prints the number and total size of the objects
+in each storage class or tier in remote:path.
+
+With two arguments this changes the storage class or tier of all the
+objects in remote:path to tier. Objects which are already in tier are
+skipped.
+
+This works with backends which support storage classes or tiers, for
+example S3 storage classes like ` + "`STANDARD_IA`" + ` and ` + "`GLACIER`" + `,
+Azure Blob access tiers ` + "`Hot`" + `, ` + "`Cool`" + `, ` + "`Cold`" + ` and
+` + "`Archive`" + ` and Google Cloud Storage classes like ` + "`NEARLINE`" + `
+and ` + "`COLDLINE`" + `.
+
+Use the rclone filters to choose which objects are changed and
+` + "`--dry-run`" + ` or ` + "`--interactive`" + `/` + "`-i`" + ` to see what would be
+changed first, for example to move all the logs older than 90 days to
+the archive tier
+
+` + "```console" + `
+rclone tier --include "*.log" --min-age 90d remote:container/logs Archive --dry-run
+` + "```" + `
+
+The tiers are changed with ` + "`--checkers`" + ` concurrent requests. Where
+the backend has a batch API (Azure Blob) the tiers of many objects are
+changed with each request.
+
+Note that certain tier changes make the objects unavailable to read
+immediately. For example objects in the Azure Blob archive tier or the
+S3 Glacier storage classes must be restored before they can be read.
+
+Use ` + "`--json`" + ` to print the tier summary as JSON, for example
+
+` + "```json" + `
+[{"tier":"Cool","count":1024,"bytes":52428800},{"tier":"Hot","count":3,"bytes":3072}]
+` + "```",
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+		"groups":            "Filter,Listing,Important",
+	},
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 2, command, args)
+		fsrc := cmd.NewFsSrc(args[:1])
+		cmd.Run(false, len(args) == 2, command, func() error {
+			ctx := context.Background()
+			if len(args) == 2 {
+				return operations.SetTier(ctx, fsrc, args[1])
+			}
+			tiers, err := operations.Tiers(ctx, fsrc)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				if tiers == nil {
+					tiers = []operations.TierUsage{}
+				}
+				return json.NewEncoder(os.Stdout).Encode(tiers)
+			}
+			for _, u := range tiers {
+				tier := u.Tier
+				if tier == "" {
+					tier = "unknown"
+				}
+				operations.SyncPrintf("%-16s %9s objects %s (%d Byte)\n", tier, fs.CountSuffix(u.Count), fs.SizeSuffix(u.Bytes).ByteUnit(), u.Bytes)
+			}
+			return nil
+		})
+	},
+}
@@ -0,0 +1,335 @@
+// Package transformlib implements the streaming transforms used by
+// the --transform flag of rclone cat and rclone rcat.
+package transformlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/rclone/rclone/backend/crypt"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// Help describes the transforms for the command help
+const Help = `Each |--transform| flag adds a step to the pipeline and the steps
+are applied in the order given. They are
+
+- |gzip| or |gzip=LEVEL| - compress with gzip, LEVEL is 1-9
+- |zstd| or |zstd=LEVEL| - compress with zstd, LEVEL is 1-22
+- |crypt=REMOTE:| - encrypt using the keys of the crypt remote REMOTE:
+- |hash| or |hash=TYPE| - calculate the hash (default md5) of the data at that point
+
+The data is streamed through the steps so only a small amount of it
+is held in memory at once, and a slow upload or output slows the
+reading of the input down rather than buffering it.
+`
+
+// Sum is the result of a hash step
+type Sum struct {
+	Type  hash.Type
+	Value string
+}
+
+// step is a single transform
+type step struct {
+	spec   string
+	encode func(in io.Reader) (io.Reader, error)
+	decode func(in io.Reader) (io.Reader, error)
+	size   func(size int64) int64 // size of the encoded data or -1 if unknown
+	ht     hash.Type              // set for hash steps
+	hasher *hashReader            // the hasher once running
+}
+
+// Pipeline is a chain of streaming transforms
+//
+// A Pipeline should only be used for one stream.
+type Pipeline struct {
+	steps   []*step
+	closers []io.Closer // closers for the running transforms
+	out     io.Reader   // output of the last transform
+}
+
+// Parse makes a Pipeline from the --transform specifications.
+//
+// Each is NAME or NAME=ARG.
+func Parse(specs []string) (*Pipeline, error) {
+	p := &Pipeline{}
+	for _, spec := range specs {
+		s, err := parseStep(spec)
+		if err != nil {
+			return nil, fmt.Errorf("bad --transform %q: %w", spec, err)
+		}
+		p.steps = append(p.steps, s)
+	}
+	return p, nil
+}
+
+// parseLevel parses the compression level in arg or returns def
+func parseLevel(arg string, def, min, max int) (int, error) {
+	if arg == "" {
+		return def, nil
+	}
+	level, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, fmt.Errorf("bad compression level: %w", err)
+	}
+	if level < min || level > max {
+		return 0, fmt.Errorf("compression level %d out of range %d-%d", level, min, max)
+	}
+	return level, nil
+}
+
+// unknownSize is used as the size function for transforms whose
+// output size can't be known in advance
+func unknownSize(int64) int64 {
+	return -1
+}
+
+// parseStep parses a single transform
+func parseStep(spec string) (*step, error) {
+	name, arg, _ := strings.Cut(spec, "=")
+	s := &step{spec: spec}
+	switch strings.ToLower(name) {
+	case "gzip":
+		level, err := parseLevel(arg, gzip.DefaultCompression, gzip.BestSpeed, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		s.size = unknownSize
+		s.decode = func(in io.Reader) (io.Reader, error) {
+			return gzip.NewReader(in)
+		}
+		s.encode = compressor(func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, level)
+		})
+	case "zstd":
+		level, err := parseLevel(arg, 3, 1, 22)
+		if err != nil {
+			return nil, err
+		}
+		s.size = unknownSize
+		s.decode = func(in io.Reader) (io.Reader, error) {
+			dec, err := zstd.NewReader(in)
+			if err != nil {
+				return nil, err
+			}
+			return dec.IOReadCloser(), nil
+		}
+		s.encode = compressor(func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		})
+	case "crypt":
+		if arg == "" {
+			return nil, errors.New("crypt needs the name of a crypt remote, e.g. crypt=secret:")
+		}
+		fsInfo, _, _, config, err := fs.ConfigFs(arg)
+		if err != nil {
+			return nil, err
+		}
+		if fsInfo.Name != "crypt" {
+			return nil, fmt.Errorf("remote %q needs to be of type \"crypt\"", arg)
+		}
+		cipher, err := crypt.NewCipher(config)
+		if err != nil {
+			return nil, err
+		}
+		s.size = cipher.EncryptedSize
+		s.encode = cipher.EncryptData
+		s.decode = func(in io.Reader) (io.Reader, error) {
+			return cipher.DecryptData(io.NopCloser(in))
+		}
+	case "hash":
+		s.ht = hash.MD5
+		if arg != "" {
+			if err := s.ht.Set(arg); err != nil {
+				return nil, err
+			}
+		}
+		if s.ht == hash.None {
+			return nil, errors.New("hash type can't be none")
+		}
+		s.size = func(size int64) int64 { return size }
+		s.encode = s.tee
+		s.decode = s.tee
+	default:
+		return nil, fmt.Errorf("unknown transform %q", name)
+	}
+	return s, nil
+}
+
+// compressor returns an encode function which runs the data through
+// the writer made by newWriter.
+//
+// The data is copied through an io.Pipe which blocks the writer until
+// the reader is ready for more, so the input is only read as fast as
+// the output is consumed.
+func compressor(newWriter func(w io.Writer) (io.WriteCloser, error)) func(in io.Reader) (io.Reader, error) {
+	return func(in io.Reader) (io.Reader, error) {
+		pr, pw := io.Pipe()
+		w, err := newWriter(pw)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			_, err := io.Copy(w, in)
+			closeErr := w.Close()
+			if err == nil {
+				err = closeErr
+			}
+			_ = pw.CloseWithError(err)
+		}()
+		return pr, nil
+	}
+}
+
+// hashReader calculates the hash of the data read through it
+type hashReader struct {
+	in     io.Reader
+	hasher *hash.MultiHasher
+	eof    bool
+}
+
+// Read implements io.Reader
+func (h *hashReader) Read(p []byte) (n int, err error) {
+	n, err = h.in.Read(p)
+	_, _ = h.hasher.Write(p[:n])
+	if err == io.EOF {
+		h.eof = true
+	}
+	return n, err
+}
+
+// tee calculates the hash of the data passing through the step
+func (s *step) tee(in io.Reader) (io.Reader, error) {
+	hasher, err := hash.NewMultiHasherTypes(hash.NewHashSet(s.ht))
+	if err != nil {
+		return nil, err
+	}
+	s.hasher = &hashReader{in: in, hasher: hasher}
+	return s.hasher, nil
+}
+
+// IsEmpty returns true if there are no transforms in the pipeline
+func (p *Pipeline) IsEmpty() bool {
+	return len(p.steps) == 0
+}
+
+// EncodedSize returns the size of the encoded data for an input of
+// size, or -1 if it isn't known.
+func (p *Pipeline) EncodedSize(size int64) int64 {
+	for _, s := range p.steps {
+		if size < 0 {
+			return -1
+		}
+		size = s.size(size)
+	}
+	return size
+}
+
+// run the steps over in
+func (p *Pipeline) run(in io.Reader, steps []*step, fn func(s *step) func(io.Reader) (io.Reader, error)) (io.ReadCloser, error) {
+	out := in
+	for _, s := range steps {
+		next, err := fn(s)(out)
+		if err != nil {
+			_ = p.Close()
+			return nil, fmt.Errorf("transform %q: %w", s.spec, err)
+		}
+		if closer, ok := next.(io.Closer); ok {
+			p.closers = append(p.closers, closer)
+		}
+		out = next
+	}
+	p.out = out
+	return p, nil
+}
+
+// Encode returns a reader which reads in with the transforms applied
+// in order.
+//
+// Close must be called on the result.
+func (p *Pipeline) Encode(in io.Reader) (io.ReadCloser, error) {
+	return p.run(in, p.steps, func(s *step) func(io.Reader) (io.Reader, error) {
+		return s.encode
+	})
+}
+
+// Decode returns a reader which undoes the transforms, applying the
+// inverse of each in reverse order. This means that data written
+// with Encode can be read with Decode with the same transforms.
+//
+// Close must be called on the result.
+func (p *Pipeline) Decode(in io.Reader) (io.ReadCloser, error) {
+	steps := make([]*step, len(p.steps))
+	for i, s := range p.steps {
+		steps[len(steps)-1-i] = s
+	}
+	return p.run(in, steps, func(s *step) func(io.Reader) (io.Reader, error) {
+		return s.decode
+	})
+}
+
+// Read implements io.Reader reading the output of the pipeline
+func (p *Pipeline) Read(b []byte) (n int, err error) {
+	return p.out.Read(b)
+}
+
+// Close stops all the transforms
+func (p *Pipeline) Close() (err error) {
+	for i := len(p.closers) - 1; i >= 0; i-- {
+		if closeErr := p.closers[i].Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	p.closers = nil
+	return err
+}
+
+// Sums returns the results of the hash steps in order.
+//
+// It returns an error if the stream wasn't read to the end.
+func (p *Pipeline) Sums() (sums []Sum, err error) {
+	for _, s := range p.steps {
+		if s.ht == hash.None {
+			continue
+		}
+		if s.hasher == nil || !s.hasher.eof {
+			return nil, fmt.Errorf("transform %q: stream not read to the end", s.spec)
+		}
+		value, err := s.hasher.hasher.SumString(s.ht, false)
+		if err != nil {
+			return nil, err
+		}
+		sums = append(sums, Sum{Type: s.ht, Value: value})
+	}
+	return sums, nil
+}
+
+// WriteSidecars writes the results of the hash steps next to remote
+// on f in the format used by md5sum and friends, with the hash name
+// as the extension, e.g. "file.gz.md5".
+func (p *Pipeline) WriteSidecars(ctx context.Context, f fs.Fs, remote string) error {
+	sums, err := p.Sums()
+	if err != nil {
+		return err
+	}
+	for _, sum := range sums {
+		sidecar := remote + "." + sum.Type.String()
+		data := fmt.Sprintf("%s  %s\n", sum.Value, path.Base(remote))
+		_, err := operations.Rcat(ctx, f, sidecar, io.NopCloser(strings.NewReader(data)), time.Now(), nil)
+		if err != nil {
+			return fmt.Errorf("failed to write checksum %q: %w", sidecar, err)
+		}
+	}
+	return nil
+}
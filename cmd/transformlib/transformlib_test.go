@@ -0,0 +1,133 @@
+package transformlib
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTrip encodes data with the transforms then decodes it again
+func roundTrip(t *testing.T, specs []string, data []byte) (encoded []byte, encodeSums, decodeSums []Sum) {
+	p, err := Parse(specs)
+	require.NoError(t, err)
+	enc, err := p.Encode(bytes.NewReader(data))
+	require.NoError(t, err)
+	encoded, err = io.ReadAll(enc)
+	require.NoError(t, err)
+	require.NoError(t, enc.Close())
+	encodeSums, err = p.Sums()
+	require.NoError(t, err)
+
+	p, err = Parse(specs)
+	require.NoError(t, err)
+	dec, err := p.Decode(bytes.NewReader(encoded))
+	require.NoError(t, err)
+	decoded, err := io.ReadAll(dec)
+	require.NoError(t, err)
+	require.NoError(t, dec.Close())
+	assert.Equal(t, data, decoded)
+	decodeSums, err = p.Sums()
+	require.NoError(t, err)
+	return encoded, encodeSums, decodeSums
+}
+
+func TestPipelineRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("hello world, this compresses well "), 10000)
+	md5sum, err := hash.StreamTypes(bytes.NewReader(data), hash.NewHashSet(hash.MD5))
+	require.NoError(t, err)
+
+	password := obscure.MustObscure("potato")
+	cryptRemote := ":crypt,remote=':memory:',password='" + password + "':"
+
+	for _, specs := range [][]string{
+		nil,
+		{"gzip"},
+		{"gzip=1"},
+		{"zstd"},
+		{"zstd=19"},
+		{"crypt=" + cryptRemote},
+		{"hash", "zstd", "crypt=" + cryptRemote, "hash=sha1"},
+	} {
+		t.Run(strings.Join(specs, ","), func(t *testing.T) {
+			encoded, encodeSums, decodeSums := roundTrip(t, specs, data)
+			if len(specs) > 0 && specs[0] != "hash" {
+				assert.NotEqual(t, data, encoded)
+			}
+			assert.Equal(t, encodeSums, decodeSums)
+			if len(specs) == 4 {
+				require.Len(t, encodeSums, 2)
+				assert.Equal(t, Sum{Type: hash.MD5, Value: md5sum[hash.MD5]}, encodeSums[0])
+				sha1sum, err := hash.StreamTypes(bytes.NewReader(encoded), hash.NewHashSet(hash.SHA1))
+				require.NoError(t, err)
+				assert.Equal(t, Sum{Type: hash.SHA1, Value: sha1sum[hash.SHA1]}, encodeSums[1])
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"potato",
+		"gzip=10",
+		"zstd=x",
+		"crypt",
+		"crypt=:memory:",
+		"hash=potato",
+		"hash=none",
+	} {
+		_, err := Parse([]string{spec})
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestEncodedSize(t *testing.T) {
+	password := obscure.MustObscure("potato")
+	p, err := Parse([]string{"hash", "crypt=:crypt,remote=':memory:',password='" + password + "':"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(32+16+100), p.EncodedSize(100))
+	assert.Equal(t, int64(-1), p.EncodedSize(-1))
+
+	p, err = Parse([]string{"gzip"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), p.EncodedSize(100))
+}
+
+func TestSumsIncomplete(t *testing.T) {
+	p, err := Parse([]string{"hash"})
+	require.NoError(t, err)
+	_, err = p.Sums()
+	assert.Error(t, err)
+}
+
+func TestWriteSidecars(t *testing.T) {
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, ":memory:sidecars")
+	require.NoError(t, err)
+
+	p, err := Parse([]string{"gzip", "hash=md5"})
+	require.NoError(t, err)
+	enc, err := p.Encode(strings.NewReader("hello"))
+	require.NoError(t, err)
+	encoded, err := io.ReadAll(enc)
+	require.NoError(t, err)
+	require.NoError(t, enc.Close())
+
+	require.NoError(t, p.WriteSidecars(ctx, f, "dir/file.gz"))
+	o, err := f.NewObject(ctx, "dir/file.gz.md5")
+	require.NoError(t, err)
+	got, err := operations.ReadFile(ctx, o)
+	require.NoError(t, err)
+	md5sum, err := hash.StreamTypes(bytes.NewReader(encoded), hash.NewHashSet(hash.MD5))
+	require.NoError(t, err)
+	assert.Equal(t, md5sum[hash.MD5]+"  file.gz\n", string(got))
+}
@@ -8,15 +8,18 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/a8m/tree"
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/ls/lstemplate"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/dirtree"
 	"github.com/rclone/rclone/fs/log"
+	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/walk"
 	"github.com/rclone/rclone/lib/encoder"
 	"github.com/rclone/rclone/lib/terminal"
@@ -29,6 +32,7 @@ var (
 	noReport    bool
 	sort        string
 	enc         = encoder.OS
+	tmplOpt     lstemplate.Options
 )
 
 func init() {
@@ -64,6 +68,8 @@ func init() {
 	flags.StringVarP(cmdFlags, &sort, "sort", "", "", "Select sort: name,version,size,mtime,ctime", "")
 	// Graphics
 	flags.BoolVarP(cmdFlags, &opts.NoIndent, "noindent", "", false, "Don't print indentation lines", "")
+	// Template
+	lstemplate.AddFlags(cmdFlags, &tmplOpt)
 }
 
 var commandDefinition = &cobra.Command{
@@ -95,7 +101,23 @@ sizes with ` + "`--size`" + `.  Note that not all of them have
 short options as they conflict with rclone's short options.
 
 For a more interactive navigation of the remote see the
-[ncdu](/commands/rclone_ncdu/) command.`,
+[ncdu](/commands/rclone_ncdu/) command.
+
+With ` + "`--template`" + ` each entry is output in tree order with the
+template, which is passed the tree drawing in ` + "`.Prefix`" + ` (empty with
+` + "`--noindent`" + `). Only the ` + "`--all`, `--dirs-only`, `--level`, `--dirsfirst`" + `
+and ` + "`--sort-reverse`" + ` options apply and the report at the end is replaced
+by the footer template if there is one. For example
+
+` + "```text" + `
+$ rclone tree --template '{{.Prefix}}{{.Name}} {{size .Size}}' remote:path
+├── file1 0 B
+├── file2 321 B
+└── subdir -
+    └── file4 1 B
+` + "```" + `
+
+` + lstemplate.Help,
 	Annotations: map[string]string{
 		"versionIntroduced": "v1.38",
 		"groups":            "Filter,Listing",
@@ -112,6 +134,8 @@ For a more interactive navigation of the remote see the
 				return fmt.Errorf("failed to create output file: %w", err)
 			}
 			opts.Colorize = false
+		} else if tmplOpt.IsSet() {
+			outFile = os.Stdout
 		} else {
 			terminal.Start()
 			outFile = terminal.Out
@@ -135,6 +159,9 @@ For a more interactive navigation of the remote see the
 
 // Tree lists fsrc to outFile using the Options passed in
 func Tree(fsrc fs.Fs, outFile io.Writer, opts *tree.Options) error {
+	if tmplOpt.IsSet() {
+		return treeTemplate(context.Background(), fsrc, outFile, opts)
+	}
 	dirs, err := walk.NewDirTree(context.Background(), fsrc, "", false, opts.DeepLevel)
 	if err != nil {
 		return err
@@ -158,6 +185,96 @@ func Tree(fsrc fs.Fs, outFile io.Writer, opts *tree.Options) error {
 	return nil
 }
 
+// treeTemplate lists fsrc to outFile in tree order using the --template
+func treeTemplate(ctx context.Context, fsrc fs.Fs, outFile io.Writer, opts *tree.Options) error {
+	tmpl, err := lstemplate.New(&tmplOpt, fs.ConfigString(fsrc))
+	if err != nil {
+		return err
+	}
+	lopt := operations.ListJSONOpt{
+		Recurse:    true,
+		NoModTime:  true,
+		NoMimeType: true,
+		DirsOnly:   opts.DirsOnly,
+	}
+	tmpl.SetListOpt(&lopt)
+	if opts.DeepLevel > 0 {
+		var ci *fs.ConfigInfo
+		ctx, ci = fs.AddConfig(ctx)
+		ci.MaxDepth = opts.DeepLevel
+	}
+
+	// Read the listing into its directories
+	children := map[string][]*operations.ListJSONItem{}
+	err = operations.ListJSON(ctx, fsrc, "", &lopt, func(item *operations.ListJSONItem) error {
+		if !opts.All && strings.HasPrefix(item.Name, ".") {
+			return nil
+		}
+		if item.IsDir {
+			item.Size = -1
+		}
+		dir := path.Dir(item.Path)
+		if dir == "." {
+			dir = ""
+		}
+		children[dir] = append(children[dir], item)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, items := range children {
+		slices.SortFunc(items, func(a, b *operations.ListJSONItem) int {
+			if opts.DirSort && a.IsDir != b.IsDir {
+				if a.IsDir {
+					return -1
+				}
+				return 1
+			}
+			if opts.ReverSort {
+				return strings.Compare(b.Name, a.Name)
+			}
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+
+	if err := tmpl.Header(outFile); err != nil {
+		return err
+	}
+	var visit func(dir, indent string) error
+	visit = func(dir, indent string) error {
+		items := children[dir]
+		for i, item := range items {
+			last := i == len(items)-1
+			tItem := lstemplate.NewItem(item)
+			if !opts.NoIndent {
+				if last {
+					tItem.Prefix = indent + "└── "
+				} else {
+					tItem.Prefix = indent + "├── "
+				}
+			}
+			if err := tmpl.Execute(outFile, tItem); err != nil {
+				return err
+			}
+			if item.IsDir {
+				childIndent := indent + "│   "
+				if last {
+					childIndent = indent + "    "
+				}
+				if err := visit(item.Path, childIndent); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := visit("", ""); err != nil {
+		return err
+	}
+	return tmpl.Footer(outFile)
+}
+
 // FileInfo maps an fs.DirEntry into an os.FileInfo
 type FileInfo struct {
 	entry fs.DirEntry
@@ -33,3 +33,24 @@ func TestTree(t *testing.T) {
 1 directories, 5 files
 `, buf.String())
 }
+
+func TestTreeTemplate(t *testing.T) {
+	fstest.Initialise()
+
+	buf := new(bytes.Buffer)
+
+	f, err := fs.NewFs(context.Background(), "testfiles")
+	require.NoError(t, err)
+	tmplOpt.Template = `{{.Prefix}}{{.Name}} {{.Depth}} {{size .Size}}{{define "footer"}}{{.Dirs}} directories, {{.Files}} files{{end}}`
+	defer func() { tmplOpt.Template = "" }()
+	err = Tree(f, buf, &tree.Options{DirSort: true, ReverSort: true})
+	require.NoError(t, err)
+	assert.Equal(t, `├── subdir 0 -
+│   ├── file5 1 0 B
+│   └── file4 1 0 B
+├── file3 0 0 B
+├── file2 0 0 B
+└── file1 0 0 B
+1 directories, 5 files
+`, buf.String())
+}
@@ -0,0 +1,85 @@
+// Package undelete provides the undelete command.
+package undelete
+
+import (
+	"context"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/spf13/cobra"
+)
+
+var listOnly bool
+
+func init() {
+	cmd.Root.AddCommand(commandDefinition)
+	cmdFlags := commandDefinition.Flags()
+	flags.BoolVarP(cmdFlags, &listOnly, "list", "", false, "List the deleted files instead of restoring them", "")
+}
+
+var commandDefinition = &cobra.Command{
+	Use:   "undelete remote:path",
+	Short: `Restore deleted files in remote:path.`,
+	Long: `Restores the files deleted from remote:path to where they were before
+they were deleted, using whatever the backend keeps of deleted files.
+
+- Google Drive restores files from the trash.
+- S3 removes the delete markers from files in versioned buckets.
+- B2 removes the hide markers from files.
+- Any remote restores the files moved into the ` + "`.rclone-trash`" + `
+  directory in remote:path by ` + "`--use-trash`" + `.
+
+The OneDrive recycle bin isn't supported as Microsoft Graph has no API
+to list it. On OneDrive, and other remotes without a trash or versions
+rclone can read, only files moved into ` + "`.rclone-trash`" + ` can be restored.
+
+Where a file was deleted more than once the most recently deleted one
+is restored. A file isn't restored if there is a file of the same name
+in its place already.
+
+Use ` + "`--list`" + ` to list the deleted files instead of restoring them.
+This shows the size of each, when it was deleted if known and its
+path, for example
+
+` + "```console" + `
+$ rclone undelete --list remote:docs
+     6144 2026-10-17 09:12:40 report.docx
+      512 -                   notes/todo.txt
+` + "```" + `
+
+Use the rclone filters to choose which files are restored and
+` + "`--dry-run`" + ` or ` + "`--interactive`" + `/` + "`-i`" + ` to see what would be
+restored first, for example
+
+` + "```console" + `
+rclone undelete --include "*.jpg" remote:photos --dry-run
+` + "```" + `
+
+The filters apply to the path, size and modification time of the
+files as they were before they were deleted.`,
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+		"groups":            "Filter,Listing,Important",
+	},
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		f := cmd.NewFsDir(args)
+		cmd.Run(!listOnly, false, command, func() error {
+			ctx := context.Background()
+			if !listOnly {
+				return operations.UndeleteAll(ctx, f)
+			}
+			ci := fs.GetConfig(ctx)
+			return operations.ListDeleted(ctx, f, func(deleted fs.DeletedObject) error {
+				deletedAt := "-"
+				if !deleted.DeletedAt.IsZero() {
+					deletedAt = deleted.DeletedAt.Local().Format("2006-01-02 15:04:05")
+				}
+				operations.SyncPrintf("%s %-19s %s\n", operations.SizeStringField(deleted.Size, ci.HumanReadable, 9), deletedAt, deleted.Remote)
+				return nil
+			})
+		})
+	},
+}
@@ -0,0 +1,154 @@
+// Package versions provides the versions command.
+package versions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/sync"
+	"github.com/spf13/cobra"
+)
+
+var (
+	retention operations.VersionRetention
+)
+
+func init() {
+	cmd.Root.AddCommand(Command)
+	Command.AddCommand(listCommand, restoreCommand, pruneCommand)
+	cmdFlags := pruneCommand.Flags()
+	flags.IntVarP(cmdFlags, &retention.Last, "keep-last", "", 0, "Keep this many of the newest versions", "")
+	flags.IntVarP(cmdFlags, &retention.Daily, "keep-daily", "", 0, "Keep the newest version from each of this many days", "")
+	flags.IntVarP(cmdFlags, &retention.Weekly, "keep-weekly", "", 0, "Keep the newest version from each of this many weeks", "")
+}
+
+// Command definition for cobra
+var Command = &cobra.Command{
+	Use:   "versions <subcommand>",
+	Short: `Manage the versions made by --backup-dir-versions.`,
+	Long: `When ` + "`--backup-dir-versions`" + ` is used with ` + "`--backup-dir`" + `,
+each run of rclone moves the files it overwrites or deletes into a new
+directory inside the ` + "`--backup-dir`" + ` named after the time of the run
+in UTC, eg ` + "`2025-06-30-142501`" + `. Each of these directories is a version.
+
+` + "```console" + `
+rclone sync /home/user/docs remote:docs --backup-dir remote:docs-versions --backup-dir-versions
+` + "```" + `
+
+These subcommands list, restore and prune those versions. They take
+the path given to ` + "`--backup-dir`" + ` and ignore any other directories in it.`,
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+	},
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list remote:backup-dir",
+	Short: `List the versions in a --backup-dir, newest first.`,
+	Long: `This lists the versions made by ` + "`--backup-dir-versions`" + ` in the
+backup directory, newest first, along with how long ago they were
+made.`,
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+	},
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		f := cmd.NewFsDir(args)
+		cmd.Run(false, false, command, func() error {
+			versions, err := operations.ListVersions(context.Background(), f)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			for _, v := range versions {
+				fmt.Printf("%s  %v ago\n", v.Name, now.Sub(v.Time).Truncate(time.Second))
+			}
+			return nil
+		})
+	},
+}
+
+var restoreCommand = &cobra.Command{
+	Use:   "restore remote:backup-dir version|latest remote:dest",
+	Short: `Copy the files saved in a version back to the destination.`,
+	Long: `This copies the files saved in the given version back into the
+destination, overwriting the files there. Use ` + "`latest`" + ` for the newest
+version.
+
+A version only holds the files which were overwritten or deleted by
+that run of rclone so this restores just those files to how they were
+before it. Use ` + "`--dry-run`" + ` to see what would be restored first.
+
+` + "```console" + `
+rclone versions restore remote:docs-versions 2025-06-30-142501 remote:docs
+` + "```",
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+	},
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(3, 3, command, args)
+		ctx := context.Background()
+		version := args[1]
+		if version == "latest" {
+			versions, err := operations.ListVersions(ctx, cmd.NewFsDir(args[:1]))
+			if err != nil {
+				fs.Fatalf(nil, "Failed to list versions: %v", err)
+			}
+			if len(versions) == 0 {
+				fs.Fatalf(nil, "No versions found in %q", args[0])
+			}
+			version = versions[0].Name
+		} else if _, err := time.Parse(operations.VersionTimeFormat, version); err != nil {
+			fs.Fatalf(nil, "Bad version %q - should look like %q", version, operations.VersionTimeFormat)
+		}
+		fsrc, fdst := cmd.NewFsSrcDst([]string{fspath.JoinRootPath(args[0], version), args[2]})
+		cmd.Run(true, true, command, func() error {
+			fs.Logf(fsrc, "Restoring version %s", version)
+			return sync.CopyDir(ctx, fdst, fsrc, false)
+		})
+	},
+}
+
+var pruneCommand = &cobra.Command{
+	Use:   "prune remote:backup-dir",
+	Short: `Remove the versions in a --backup-dir not kept by a retention policy.`,
+	Long: `This removes the versions which aren't kept by any of the
+` + "`--keep-*`" + ` flags, of which at least one must be given.
+
+- ` + "`--keep-last N`" + ` keeps the N newest versions.
+- ` + "`--keep-daily N`" + ` keeps the newest version from each of the N most recent days which have a version.
+- ` + "`--keep-weekly N`" + ` keeps the newest version from each of the N most recent weeks which have a version.
+
+Days and weeks are in UTC and weeks start on a Monday. A version kept
+by any flag is kept, so this keeps the last 3 versions, one a day for
+a week and one a week for 2 months:
+
+` + "```console" + `
+rclone versions prune remote:docs-versions --keep-last 3 --keep-daily 7 --keep-weekly 8
+` + "```" + `
+
+Use ` + "`--dry-run`" + ` to see what would be removed first.`,
+	Annotations: map[string]string{
+		"versionIntroduced": "v1.73",
+	},
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(1, 1, command, args)
+		if retention.IsZero() {
+			fs.Fatalf(nil, "Need at least one of --keep-last, --keep-daily or --keep-weekly")
+		}
+		f := cmd.NewFsDir(args)
+		cmd.Run(true, false, command, func() error {
+			removed, err := operations.PruneVersions(context.Background(), f, retention)
+			for _, v := range removed {
+				fs.Infof(f, "Removed version %s", v.Name)
+			}
+			return err
+		})
+	},
+}
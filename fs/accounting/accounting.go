@@ -15,6 +15,7 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/asyncreader"
 	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/hash"
 )
 
 // ErrorMaxTransferLimitReached defines error when transfer limit is reached.
@@ -29,6 +30,9 @@ var ErrorMaxTransferLimitReachedFatal = fserrors.FatalError(ErrorMaxTransferLimi
 // transfer limit is reached and a graceful stop is required.
 var ErrorMaxTransferLimitReachedGraceful = fserrors.NoRetryError(ErrorMaxTransferLimitReached)
 
+// pauseSignalOnce makes sure the pause signal handler is only started once
+var pauseSignalOnce sync.Once
+
 // Start sets up the accounting, in particular the bandwidth limiting
 func Start(ctx context.Context) {
 	// Start the token bucket limiter
@@ -40,6 +44,9 @@ func Start(ctx context.Context) {
 	// Start the transactions per second limiter
 	StartLimitTPS(ctx)
 
+	// Pause the transfers on SIGUSR1
+	pauseSignalOnce.Do(startPauseSignalHandler)
+
 	// Set the error count function pointer up in fs
 	//
 	// We can't do this in an init() method as it uses fs.Config
@@ -65,12 +72,16 @@ type Account struct {
 	close    io.Closer
 	size     int64
 	name     string
+	key      string        // key in stats.inProgress - usually name
 	closed   bool          // set if the file is closed
 	exit     chan struct{} // channel that will be closed when transfer is finished
 	withBuf  bool          // is using a buffered in
 	checking bool          // set if attached transfer is checking
+	hashType hash.Type     // if set the type of hash to calculate while reading
+	hashIn   *hashReader   // reader calculating the hash if hashType is set
 
 	tokenBucket buckets // per file bandwidth limiter (may be nil)
+	tokenGen    uint64  // TokenBucket.fileGeneration() when tokenBucket was made
 
 	values accountValues
 }
@@ -91,6 +102,12 @@ const averagePeriod = 16 // period to do exponentially weighted averages over
 // newAccountSizeName makes an Account reader for an io.ReadCloser of
 // the given size and name
 func newAccountSizeName(ctx context.Context, stats *StatsInfo, in io.ReadCloser, size int64, name string) *Account {
+	return newAccountSizeNameKey(ctx, stats, in, size, name, name)
+}
+
+// newAccountSizeNameKey makes an Account reader for an io.ReadCloser
+// of the given size and name with key for the in progress map
+func newAccountSizeNameKey(ctx context.Context, stats *StatsInfo, in io.ReadCloser, size int64, name, key string) *Account {
 	acc := &Account{
 		stats:  stats,
 		in:     in,
@@ -100,6 +117,7 @@ func newAccountSizeName(ctx context.Context, stats *StatsInfo, in io.ReadCloser,
 		origIn: in,
 		size:   size,
 		name:   name,
+		key:    key,
 		exit:   make(chan struct{}),
 		values: accountValues{
 			avg:    0,
@@ -110,14 +128,14 @@ func newAccountSizeName(ctx context.Context, stats *StatsInfo, in io.ReadCloser,
 	if acc.ci.CutoffMode == fs.CutoffModeHard {
 		acc.values.max = int64((acc.ci.MaxTransfer))
 	}
-	currLimit := acc.ci.BwLimitFile.LimitAt(time.Now())
-	if currLimit.Bandwidth.IsSet() {
-		fs.Debugf(acc.name, "Limiting file transfer to %v", currLimit.Bandwidth)
-		acc.tokenBucket = newTokenBucket(currLimit.Bandwidth)
+	acc.tokenGen = TokenBucket.fileGeneration()
+	if bandwidth := TokenBucket.fileBandwidth(acc.ci); bandwidth.IsSet() {
+		fs.Debugf(acc.name, "Limiting file transfer to %v", bandwidth)
+		acc.tokenBucket = newTokenBucket(bandwidth)
 	}
 
 	go acc.averageLoop()
-	stats.inProgress.set(acc.name, acc)
+	stats.inProgress.set(acc.key, acc)
 	return acc
 }
 
@@ -147,6 +165,137 @@ func (acc *Account) WithBuffer() *Account {
 	return acc
 }
 
+// WithHash makes the Account calculate a hash of type ht of the data
+// as it is read from the source so the transfer can be verified
+// without reading the source again. Use Sum to read the result.
+//
+// This must be called before WithBuffer.
+func (acc *Account) WithHash(ht hash.Type) *Account {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	if ht == hash.None || (ht == acc.hashType && acc.hashIn != nil) {
+		return acc
+	}
+	acc.hashType = ht
+	acc._withHash()
+	return acc
+}
+
+// _withHash puts a new hashReader around the input - call with the
+// lock held
+func (acc *Account) _withHash() {
+	acc.hashIn = nil
+	if acc.origIn == nil {
+		return
+	}
+	hasher, err := hash.NewMultiHasherTypes(hash.NewHashSet(acc.hashType))
+	if err != nil {
+		fs.Errorf(acc.name, "Failed to make hasher: %v", err)
+		return
+	}
+	acc.hashIn = &hashReader{in: acc.origIn, ht: acc.hashType, hasher: hasher}
+	acc.in = acc.hashIn
+	acc.close = acc.hashIn
+	acc.origIn = acc.hashIn
+}
+
+// Sum returns the hash of the data read if WithHash was used.
+//
+// The sum will be blank unless all the data was read in order.
+func (acc *Account) Sum() (ht hash.Type, sum string) {
+	acc.mu.Lock()
+	hashIn := acc.hashIn
+	acc.mu.Unlock()
+	if hashIn == nil {
+		return acc.hashType, ""
+	}
+	return acc.hashType, hashIn.sum(acc.size)
+}
+
+// hashReader calculates the hash of the data read through it
+type hashReader struct {
+	mu      sync.Mutex
+	in      io.ReadCloser
+	ht      hash.Type
+	hasher  *hash.MultiHasher
+	eof     bool // set if we have read to the end
+	invalid bool // set if the data wasn't read in order
+}
+
+// Read bytes hashing them - see io.Reader
+func (h *hashReader) Read(p []byte) (n int, err error) {
+	n, err = h.in.Read(p)
+	h.mu.Lock()
+	_, _ = h.hasher.Write(p[:n])
+	if err == io.EOF {
+		h.eof = true
+	}
+	h.mu.Unlock()
+	return n, err
+}
+
+// Seek to position in the object - see io.Seeker
+//
+// Seeking anywhere but the start makes the hash invalid.
+func (h *hashReader) Seek(offset int64, whence int) (int64, error) {
+	do, ok := h.in.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("internal error: Seek not implemented for %T", h.in)
+	}
+	n, err := do.Seek(offset, whence)
+	h.mu.Lock()
+	if err == nil && n == 0 {
+		h._reset()
+	} else {
+		h.invalid = true
+	}
+	h.mu.Unlock()
+	return n, err
+}
+
+// _reset starts the hash again - call with the lock held
+func (h *hashReader) _reset() {
+	hasher, err := hash.NewMultiHasherTypes(hash.NewHashSet(h.ht))
+	if err != nil {
+		h.invalid = true
+		return
+	}
+	h.hasher = hasher
+	h.eof = false
+	h.invalid = false
+}
+
+// ReadAt from off into p - see io.ReaderAt
+//
+// Data read like this isn't hashed.
+func (h *hashReader) ReadAt(p []byte, off int64) (n int, err error) {
+	do, ok := h.in.(io.ReaderAt)
+	if !ok {
+		return 0, fmt.Errorf("internal error: ReadAt not implemented for %T", h.in)
+	}
+	return do.ReadAt(p, off)
+}
+
+// Close the underlying reader
+func (h *hashReader) Close() error {
+	return h.in.Close()
+}
+
+// sum returns the hash of the data if it was all read in order. size
+// may be -1 if unknown.
+func (h *hashReader) sum(size int64) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.invalid || (size >= 0 && h.hasher.Size() != size) || (size < 0 && !h.eof) {
+		return ""
+	}
+	sum, err := h.hasher.SumString(h.ht, false)
+	if err != nil {
+		return ""
+	}
+	return sum
+}
+
 // HasBuffer - returns true if this Account has an AsyncReader with a buffer
 func (acc *Account) HasBuffer() bool {
 	acc.mu.Lock()
@@ -200,6 +349,9 @@ func (acc *Account) UpdateReader(ctx context.Context, in io.ReadCloser) {
 	acc.close = in
 	acc.origIn = in
 	acc.closed = false
+	if acc.hashType != hash.None {
+		acc._withHash()
+	}
 	if withBuf {
 		acc.WithBuffer()
 	}
@@ -249,6 +401,10 @@ func (acc *Account) checkReadBefore() (bytesUntilLimit int64, err error) {
 	if err = acc.ctx.Err(); err != nil {
 		return 0, err
 	}
+	// Wait here while the transfers are paused
+	if err = acc.stats.waitPaused(acc.ctx); err != nil {
+		return 0, err
+	}
 	acc.values.mu.Lock()
 	if acc.values.max >= 0 {
 		bytesUntilLimit = acc.values.max - acc.stats.GetBytes()
@@ -333,6 +489,15 @@ func (acc *Account) DryRun(n int64) {
 // Account for n bytes from the current file bandwidth limit (if any)
 func (acc *Account) limitPerFileBandwidth(n int) {
 	acc.values.mu.Lock()
+	// Pick up any change in the limit made while the transfer is running
+	if gen := TokenBucket.fileGeneration(); gen != acc.tokenGen {
+		acc.tokenGen = gen
+		acc.tokenBucket = buckets{}
+		if bandwidth := TokenBucket.fileBandwidth(acc.ci); bandwidth.IsSet() {
+			fs.Debugf(acc.name, "Limiting file transfer to %v", bandwidth)
+			acc.tokenBucket = newTokenBucket(bandwidth)
+		}
+	}
 	tokenBucket := acc.tokenBucket[TokenBucketSlotAccounting]
 	acc.values.mu.Unlock()
 
@@ -493,7 +658,7 @@ func (acc *Account) Done() {
 	acc.mu.Lock()
 	defer acc.mu.Unlock()
 	close(acc.exit)
-	acc.stats.inProgress.clear(acc.name)
+	acc.stats.inProgress.clear(acc.key)
 }
 
 // progress returns bytes read as well as the size.
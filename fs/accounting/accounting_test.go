@@ -7,11 +7,13 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 	"unicode/utf8"
 
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/asyncreader"
 	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/lib/readers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -63,6 +65,113 @@ func TestAccountWithBuffer(t *testing.T) {
 	assert.NoError(t, acc.Close())
 }
 
+// readSeekNopCloser adds a no-op Close to an io.ReadSeeker
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error { return nil }
+
+func TestAccountWithHash(t *testing.T) {
+	ctx := context.Background()
+	const md5sum = "5d41402abc4b2a76b9719d911017c592" // of "hello"
+	stats := NewStats(ctx)
+	for _, size := range []int64{5, -1} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			in := io.NopCloser(strings.NewReader("hello"))
+			acc := newAccountSizeName(ctx, stats, in, size, "test").WithHash(hash.MD5).WithBuffer()
+			defer acc.Done()
+
+			// No sum until everything is read - the buffer
+			// used for unknown sizes reads ahead though
+			_, err := acc.Read(make([]byte, 2))
+			require.NoError(t, err)
+			ht, sum := acc.Sum()
+			assert.Equal(t, hash.MD5, ht)
+			if !acc.HasBuffer() {
+				assert.Equal(t, "", sum)
+			}
+
+			_, err = io.Copy(io.Discard, acc)
+			require.NoError(t, err)
+			require.NoError(t, acc.Close())
+			_, sum = acc.Sum()
+			assert.Equal(t, md5sum, sum)
+
+			// Updating the reader starts the hash again
+			acc.UpdateReader(ctx, io.NopCloser(strings.NewReader("world")))
+			_, err = io.Copy(io.Discard, acc)
+			require.NoError(t, err)
+			_, sum = acc.Sum()
+			assert.Equal(t, "7d793037a0760186574b0282f2f435e7", sum)
+
+			acc.UpdateReader(ctx, io.NopCloser(strings.NewReader("hello")))
+			_, err = io.Copy(io.Discard, acc)
+			require.NoError(t, err)
+			_, sum = acc.Sum()
+			assert.Equal(t, md5sum, sum)
+		})
+	}
+
+	t.Run("Seek", func(t *testing.T) {
+		in := readSeekNopCloser{strings.NewReader("hello")}
+		acc := newAccountSizeName(ctx, stats, in, 5, "test").WithHash(hash.MD5)
+		defer acc.Done()
+
+		// Seeking into the middle invalidates the hash
+		_, err := acc.Seek(1, io.SeekStart)
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, acc)
+		require.NoError(t, err)
+		_, sum := acc.Sum()
+		assert.Equal(t, "", sum)
+
+		// Seeking to the start resets it
+		_, err = acc.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, acc)
+		require.NoError(t, err)
+		_, sum = acc.Sum()
+		assert.Equal(t, md5sum, sum)
+	})
+}
+
+func TestAccountPause(t *testing.T) {
+	ctx := context.Background()
+	stats := NewStats(ctx)
+	in := io.NopCloser(strings.NewReader("hello"))
+	acc := newAccountSizeName(ctx, stats, in, 5, "test")
+	defer acc.Done()
+
+	stats.Pause()
+	assert.True(t, stats.Paused())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		data, err := io.ReadAll(acc)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	}()
+	select {
+	case <-done:
+		t.Fatal("read while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	stats.Resume()
+	assert.False(t, stats.Paused())
+	<-done
+
+	// Cancelling the context stops the wait
+	ctx, cancel := context.WithCancel(ctx)
+	acc = newAccountSizeName(ctx, stats, io.NopCloser(strings.NewReader("hello")), 5, "test2")
+	defer acc.Done()
+	stats.Pause()
+	defer stats.Resume()
+	cancel()
+	_, err := acc.Read(make([]byte, 5))
+	assert.Equal(t, context.Canceled, err)
+}
+
 func TestAccountGetUpdateReader(t *testing.T) {
 	ctx := context.Background()
 	test := func(doClose bool) func(t *testing.T) {
@@ -46,3 +46,20 @@ func (tb *tokenBucket) startSignalHandler() {
 		}
 	}()
 }
+
+// startPauseSignalHandler sets a signal handler to catch SIGUSR1 and
+// toggle pausing all the transfers.
+func startPauseSignalHandler() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+
+	go func() {
+		for range signals {
+			if togglePauseAll() {
+				fs.Logf(nil, "Transfers paused by user - send SIGUSR1 again to resume")
+			} else {
+				fs.Logf(nil, "Transfers resumed by user")
+			}
+		}
+	}()
+}
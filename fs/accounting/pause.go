@@ -0,0 +1,87 @@
+package accounting
+
+import (
+	"context"
+	"sync"
+)
+
+// pauser pauses and resumes transfers
+type pauser struct {
+	mu sync.Mutex
+	ch chan struct{} // if set transfers are paused until it is closed
+}
+
+// pauseAll pauses the transfers in all the stats groups
+var pauseAll pauser
+
+// pause the transfers returning false if already paused
+func (p *pauser) pause() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ch != nil {
+		return false
+	}
+	p.ch = make(chan struct{})
+	return true
+}
+
+// resume the transfers returning false if not paused
+func (p *pauser) resume() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ch == nil {
+		return false
+	}
+	close(p.ch)
+	p.ch = nil
+	return true
+}
+
+// paused returns whether the transfers are paused
+func (p *pauser) paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ch != nil
+}
+
+// wait while the transfers are paused returning an error if ctx is
+// cancelled while waiting.
+func (p *pauser) wait(ctx context.Context) error {
+	p.mu.Lock()
+	ch := p.ch
+	p.mu.Unlock()
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PauseAll stops all transfers reading any more data until ResumeAll
+// is called.
+func PauseAll() {
+	pauseAll.pause()
+}
+
+// ResumeAll restarts the transfers stopped by PauseAll
+func ResumeAll() {
+	pauseAll.resume()
+}
+
+// PausedAll returns whether all transfers are paused with PauseAll
+func PausedAll() bool {
+	return pauseAll.paused()
+}
+
+// togglePauseAll pauses all transfers if they are running or resumes
+// them if they are paused.
+func togglePauseAll() (paused bool) {
+	if pauseAll.resume() {
+		return false
+	}
+	return pauseAll.pause()
+}
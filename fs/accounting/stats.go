@@ -67,6 +67,11 @@ type StatsInfo struct {
 	serverSideCopyBytes   int64
 	serverSideMoves       int64
 	serverSideMoveBytes   int64
+	verified              int64  // transfers whose hash matched the destination
+	verifyFailed          int64  // transfers whose hash didn't match the destination
+	unverified            int64  // transfers which couldn't be verified
+	metadataUpdated       int64  // files whose metadata was updated without a transfer
+	pause                 pauser // for pausing the transfers
 	maxCompletedTransfers int
 }
 
@@ -137,6 +142,11 @@ func (s *StatsInfo) RemoteStats(short bool) (out rc.Params, err error) {
 	out["serverSideCopyBytes"] = s.serverSideCopyBytes
 	out["serverSideMoves"] = s.serverSideMoves
 	out["serverSideMoveBytes"] = s.serverSideMoveBytes
+	out["verified"] = s.verified
+	out["verifyFailed"] = s.verifyFailed
+	out["unverified"] = s.unverified
+	out["metadataUpdated"] = s.metadataUpdated
+	out["paused"] = s.pause.paused() || pauseAll.paused()
 	eta, etaOK := eta(s.bytes, ts.totalBytes, ts.speed)
 	if etaOK {
 		out["eta"] = eta.Seconds()
@@ -498,6 +508,9 @@ func (s *StatsInfo) String() string {
 			_, _ = fmt.Fprintf(buf, "Transferred:   %10d / %d, %s\n",
 				s.transfers, ts.totalTransfers, percent(s.transfers, ts.totalTransfers))
 		}
+		if s.metadataUpdated != 0 {
+			_, _ = fmt.Fprintf(buf, "Metadata updated:%8d\n", s.metadataUpdated)
+		}
 		if s.serverSideCopies != 0 || s.serverSideCopyBytes != 0 {
 			_, _ = fmt.Fprintf(buf, "Server Side Copies:%6d @ %s\n",
 				s.serverSideCopies, fs.SizeSuffix(s.serverSideCopyBytes).ByteUnit(),
@@ -508,6 +521,10 @@ func (s *StatsInfo) String() string {
 				s.serverSideMoves, fs.SizeSuffix(s.serverSideMoveBytes).ByteUnit(),
 			)
 		}
+		if s.verified != 0 || s.verifyFailed != 0 || s.unverified != 0 {
+			_, _ = fmt.Fprintf(buf, "Verified:      %10d (ok), %d (failed), %d (unverified)\n",
+				s.verified, s.verifyFailed, s.unverified)
+		}
 		_, _ = fmt.Fprintf(buf, "Elapsed time:  %10ss\n", strings.TrimRight(fs.Duration(elapsedTime.Truncate(time.Minute)).ReadableString(), "0s")+fmt.Sprintf("%.1f", elapsedTimeSecondsOnly.Seconds()))
 	}
 
@@ -727,6 +744,10 @@ func (s *StatsInfo) ResetCounters() {
 	s.deletedDirs = 0
 	s.renames = 0
 	s.listed = 0
+	s.verified = 0
+	s.verifyFailed = 0
+	s.unverified = 0
+	s.metadataUpdated = 0
 	s.startedTransfers = nil
 	s.oldDuration = 0
 
@@ -795,6 +816,8 @@ func (s *StatsInfo) NewCheckingTransfer(obj fs.DirEntry, what string) *Transfer
 }
 
 // DoneChecking removes a check from the stats
+//
+// remote is the key of the check which is usually its remote
 func (s *StatsInfo) DoneChecking(remote string) {
 	s.checking.del(remote)
 	s.mu.Lock()
@@ -835,6 +858,8 @@ func (s *StatsInfo) NewTransferRemoteSize(remote string, size int64, srcFs, dstF
 
 // DoneTransferring removes a transfer from the stats
 //
+// remote is the key of the transfer which is usually its remote.
+//
 // if ok is true and it was in the transfermap (to avoid incrementing in case of nested calls, #6213) then it increments the transfers count
 func (s *StatsInfo) DoneTransferring(remote string, ok bool) {
 	existed := s.transferring.del(remote)
@@ -960,6 +985,59 @@ func (s *StatsInfo) AddServerSideMove(n int64) {
 	s.mu.Unlock()
 }
 
+// Pause stops the transfers using these stats from reading any more
+// data until Resume is called.
+func (s *StatsInfo) Pause() {
+	s.pause.pause()
+}
+
+// Resume restarts the transfers stopped by Pause
+func (s *StatsInfo) Resume() {
+	s.pause.resume()
+}
+
+// Paused returns whether the transfers are paused
+func (s *StatsInfo) Paused() bool {
+	return s.pause.paused() || pauseAll.paused()
+}
+
+// waitPaused waits while the transfers are paused returning an error
+// if ctx is cancelled while waiting.
+func (s *StatsInfo) waitPaused(ctx context.Context) error {
+	if err := pauseAll.wait(ctx); err != nil {
+		return err
+	}
+	return s.pause.wait(ctx)
+}
+
+// AddVerified counts the result of verifying the hash of a transfer
+// against the destination
+func (s *StatsInfo) AddVerified(ok bool) {
+	s.mu.Lock()
+	if ok {
+		s.verified++
+	} else {
+		s.verifyFailed++
+	}
+	s.mu.Unlock()
+}
+
+// AddUnverified counts a transfer which couldn't be verified as there
+// was no hash to check
+func (s *StatsInfo) AddUnverified() {
+	s.mu.Lock()
+	s.unverified++
+	s.mu.Unlock()
+}
+
+// AddMetadataUpdated counts a file whose metadata was updated in
+// place as its content was unchanged
+func (s *StatsInfo) AddMetadataUpdated() {
+	s.mu.Lock()
+	s.metadataUpdated++
+	s.mu.Unlock()
+}
+
 // AddServerSideCopy counts a server side copy
 func (s *StatsInfo) AddServerSideCopy(n int64) {
 	s.mu.Lock()
@@ -97,6 +97,7 @@ Returns the following values:
 	"lastError": last error string,
 	"renames" : number of files renamed,
 	"listed" : number of directory entries listed,
+	"paused": boolean whether the transfers are paused with job/pause,
 	"retryError": boolean showing whether there has been at least one non-NoRetryError,
         "serverSideCopies": number of server side copies done,
         "serverSideCopyBytes": number bytes server side copied,
@@ -108,6 +109,10 @@ Returns the following values:
 	"totalTransfers": total number of transfers in the group,
 	"transferTime" : total time spent on running jobs,
 	"transfers": number of transferred files,
+	"verified": number of transfers verified with --verify-transfers,
+	"verifyFailed": number of transfers which failed verification,
+	"unverified": number of transfers which couldn't be verified,
+	"metadataUpdated": number of files whose metadata was updated without transferring them,
 	"transferring": an array of currently active file transfers:
 		[
 			{
@@ -392,6 +397,10 @@ func (sg *statsGroups) sum(ctx context.Context) *StatsInfo {
 			sum.renameQueueSize += stats.renameQueueSize
 			sum.deletes += stats.deletes
 			sum.deletedDirs += stats.deletedDirs
+			sum.verified += stats.verified
+			sum.verifyFailed += stats.verifyFailed
+			sum.unverified += stats.unverified
+			sum.metadataUpdated += stats.metadataUpdated
 			sum.inProgress.merge(stats.inProgress)
 			sum.startedTransfers = append(sum.startedTransfers, stats.startedTransfers...)
 			sum.oldTimeRanges = append(sum.oldTimeRanges, stats.oldTimeRanges...)
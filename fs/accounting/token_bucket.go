@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rclone/rclone/fs"
@@ -35,6 +36,12 @@ type tokenBucket struct {
 	prev       buckets
 	toggledOff bool
 	currLimit  fs.BwTimeSlot
+
+	// per file bandwidth limit
+	fileLimit    fs.BwPair     // limit set with SetBwLimitFile
+	fileOverride bool          // set if fileLimit overrides --bwlimit-file
+	fileCurr     fs.BwTimeSlot // current --bwlimit-file time slot
+	fileGen      atomic.Uint64 // incremented when the per file limit changes
 }
 
 // Return true if limit is disabled
@@ -117,19 +124,23 @@ func (tb *tokenBucket) StartTokenBucket(ctx context.Context) {
 // StartTokenTicker creates a ticker to update the bandwidth limiter every minute.
 func (tb *tokenBucket) StartTokenTicker(ctx context.Context) {
 	ci := fs.GetConfig(ctx)
-	// If the timetable has a single entry or was not specified, we don't need
+	// If the timetables have a single entry or were not specified, we don't need
 	// a ticker to update the bandwidth.
-	if len(ci.BwLimit) <= 1 {
+	if len(ci.BwLimit) <= 1 && len(ci.BwLimitFile) <= 1 {
 		return
 	}
+	tb.mu.Lock()
+	tb.fileCurr = ci.BwLimitFile.LimitAt(time.Now())
+	tb.mu.Unlock()
 
 	ticker := time.NewTicker(time.Minute)
 	go func() {
 		for range ticker.C {
 			limitNow := ci.BwLimit.LimitAt(time.Now())
+			fileLimitNow := ci.BwLimitFile.LimitAt(time.Now())
 			tb.mu.Lock()
 
-			if tb.currLimit.Bandwidth != limitNow.Bandwidth {
+			if len(ci.BwLimit) > 1 && tb.currLimit.Bandwidth != limitNow.Bandwidth {
 				// If bwlimit is toggled off, the change should only
 				// become active on the next toggle, which causes
 				// an exchange of tb.curr <-> tb.prev
@@ -157,6 +168,15 @@ func (tb *tokenBucket) StartTokenTicker(ctx context.Context) {
 				tb.currLimit = limitNow
 			}
 
+			// A scheduled per file change replaces any limit set
+			// with SetBwLimitFile, the same as for --bwlimit
+			if len(ci.BwLimitFile) > 1 && tb.fileCurr.Bandwidth != fileLimitNow.Bandwidth {
+				tb.fileCurr = fileLimitNow
+				tb.fileOverride = false
+				tb.fileGen.Add(1)
+				fs.Logf(nil, "Scheduled per file bandwidth change. Limit set to %v", &fileLimitNow.Bandwidth)
+			}
+
 			tb.mu.Unlock()
 		}
 	}()
@@ -191,23 +211,64 @@ func (tb *tokenBucket) SetBwLimit(bandwidth fs.BwPair) {
 	}
 }
 
+// SetBwLimitFile sets the per file bandwidth limit, overriding
+// --bwlimit-file. It applies to transfers in progress as well as new
+// ones.
+func (tb *tokenBucket) SetBwLimitFile(bandwidth fs.BwPair) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.fileLimit = bandwidth
+	tb.fileOverride = true
+	tb.fileGen.Add(1)
+	if bandwidth.IsSet() {
+		fs.Logf(nil, "Per file bandwidth limit set to %v", bandwidth)
+	} else {
+		fs.Logf(nil, "Per file bandwidth limit reset to unlimited")
+	}
+}
+
+// fileGeneration returns a number which changes whenever the per
+// file bandwidth limit changes
+func (tb *tokenBucket) fileGeneration() uint64 {
+	return tb.fileGen.Load()
+}
+
+// fileBandwidth returns the per file bandwidth limit for a transfer
+// using ci
+func (tb *tokenBucket) fileBandwidth(ci *fs.ConfigInfo) fs.BwPair {
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+	if tb.fileOverride {
+		return tb.fileLimit
+	}
+	return ci.BwLimitFile.LimitAt(time.Now()).Bandwidth
+}
+
+// parseBwLimitRate parses the "rate" parameter passed to the rc
+func parseBwLimitRate(in rc.Params) (bw fs.BwPair, err error) {
+	bwlimit, err := in.GetString("rate")
+	if err != nil {
+		return bw, err
+	}
+	var bws fs.BwTimetable
+	err = bws.Set(bwlimit)
+	if err != nil {
+		return bw, fmt.Errorf("bad bwlimit: %w", err)
+	}
+	if len(bws) != 1 {
+		return bw, errors.New("need exactly 1 bandwidth setting")
+	}
+	return bws[0].Bandwidth, nil
+}
+
 // read and set the bandwidth limits
 func (tb *tokenBucket) rcBwlimit(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	if in["rate"] != nil {
-		bwlimit, err := in.GetString("rate")
+		bw, err := parseBwLimitRate(in)
 		if err != nil {
 			return out, err
 		}
-		var bws fs.BwTimetable
-		err = bws.Set(bwlimit)
-		if err != nil {
-			return out, fmt.Errorf("bad bwlimit: %w", err)
-		}
-		if len(bws) != 1 {
-			return out, errors.New("need exactly 1 bandwidth setting")
-		}
-		bw := bws[0]
-		tb.SetBwLimit(bw.Bandwidth)
+		tb.SetBwLimit(bw)
 	}
 	tb.mu.RLock()
 	bytesPerSecond := int64(-1)
@@ -231,6 +292,27 @@ func (tb *tokenBucket) rcBwlimit(ctx context.Context, in rc.Params) (out rc.Para
 	return out, nil
 }
 
+// read and set the per file bandwidth limits
+func (tb *tokenBucket) rcBwlimitFile(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	if in["rate"] != nil {
+		bw, err := parseBwLimitRate(in)
+		if err != nil {
+			return out, err
+		}
+		tb.SetBwLimitFile(bw)
+	}
+	bp := tb.fileBandwidth(fs.GetConfig(ctx))
+	if !bp.IsSet() {
+		bp = fs.BwPair{Tx: -1, Rx: -1}
+	}
+	out = rc.Params{
+		"rate":             bp.String(),
+		"bytesPerSecondTx": int64(bp.Tx),
+		"bytesPerSecondRx": int64(bp.Rx),
+	}
+	return out, nil
+}
+
 // Remote control for the token bucket
 func init() {
 	rc.Add(rc.Call{
@@ -281,6 +363,41 @@ except only one bandwidth may be specified.
 
 In either case "rate" is returned as a human-readable string, and
 "bytesPerSecond" is returned as a number.
+`,
+	})
+	rc.Add(rc.Call{
+		Path:  "core/bwlimit-file",
+		Fn:    TokenBucket.rcBwlimitFile,
+		Title: "Set the per file bandwidth limit.",
+		Help: `
+This sets the per file bandwidth limit to the string passed in, the
+same as --bwlimit-file. This should be a single bandwidth limit entry
+or a pair of upload:download bandwidth.
+
+The new limit applies to transfers in progress as well as new ones, so
+it can be used to stop one big file from using all the bandwidth while
+smaller files wait.
+
+Eg
+
+    rclone rc core/bwlimit-file rate=1M
+    {
+        "bytesPerSecondTx": 1048576,
+        "bytesPerSecondRx": 1048576,
+        "rate": "1M"
+    }
+    rclone rc core/bwlimit-file rate=off
+    {
+        "bytesPerSecondTx": -1,
+        "bytesPerSecondRx": -1,
+        "rate": "off"
+    }
+
+If the rate parameter is not supplied then the per file bandwidth
+limit is queried.
+
+A scheduled change in the --bwlimit-file timetable will replace the
+limit set here.
 `,
 	})
 }
@@ -2,6 +2,8 @@ package accounting
 
 import (
 	"context"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/rclone/rclone/fs/rc"
@@ -90,3 +92,61 @@ func TestRcBwLimit(t *testing.T) {
 	}, out)
 
 }
+
+func TestRcBwLimitFile(t *testing.T) {
+	call := rc.Calls.Get("core/bwlimit-file")
+	assert.NotNil(t, call)
+	defer func() {
+		TokenBucket.mu.Lock()
+		TokenBucket.fileOverride = false
+		TokenBucket.mu.Unlock()
+	}()
+
+	ctx := context.Background()
+	in := strings.NewReader("hello")
+	acc := newAccountSizeName(ctx, NewStats(ctx), io.NopCloser(in), -1, "test")
+	defer func() {
+		require.NoError(t, acc.Close())
+	}()
+	assert.Nil(t, acc.tokenBucket[TokenBucketSlotAccounting])
+
+	// Set
+	out, err := call.Fn(ctx, rc.Params{
+		"rate": "1M",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"bytesPerSecondTx": int64(1048576),
+		"bytesPerSecondRx": int64(1048576),
+		"rate":             "1Mi",
+	}, out)
+
+	// Check the transfer in progress picks up the limit
+	acc.limitPerFileBandwidth(1)
+	require.NotNil(t, acc.tokenBucket[TokenBucketSlotAccounting])
+	assert.Equal(t, rate.Limit(1048576), acc.tokenBucket[TokenBucketSlotAccounting].Limit())
+
+	// Query
+	out, err = call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, "1Mi", out["rate"])
+
+	// Reset
+	out, err = call.Fn(ctx, rc.Params{
+		"rate": "off",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"bytesPerSecondTx": int64(-1),
+		"bytesPerSecondRx": int64(-1),
+		"rate":             "off",
+	}, out)
+	acc.limitPerFileBandwidth(1)
+	assert.Nil(t, acc.tokenBucket[TokenBucketSlotAccounting])
+
+	// Bad rate
+	_, err = call.Fn(ctx, rc.Params{
+		"rate": "1M,2M",
+	})
+	require.Error(t, err)
+}
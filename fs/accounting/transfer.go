@@ -43,6 +43,10 @@ func (as TransferSnapshot) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// TransferDone is called, if set, with a snapshot of every transfer
+// and check as it finishes. It is used to record the transfer history.
+var TransferDone func(ctx context.Context, s TransferSnapshot)
+
 // Transfer keeps track of initiated transfers and provides access to
 // accounting functions.
 // Transfer needs to be closed on completion.
@@ -50,6 +54,7 @@ type Transfer struct {
 	// these are initialised at creation and may be accessed without locking
 	stats     *StatsInfo
 	remote    string
+	key       string // key in the transfer maps, set when added - usually remote
 	size      int64
 	startedAt time.Time
 	checking  bool
@@ -125,13 +130,24 @@ func (tr *Transfer) Done(ctx context.Context, err error) {
 	tr.mu.Unlock()
 
 	if tr.checking {
-		tr.stats.DoneChecking(tr.remote)
+		tr.stats.DoneChecking(tr.getKey())
 	} else {
-		tr.stats.DoneTransferring(tr.remote, err == nil)
+		tr.stats.DoneTransferring(tr.getKey(), err == nil)
+	}
+	if TransferDone != nil {
+		TransferDone(ctx, tr.Snapshot())
 	}
 	tr.stats.PruneTransfers()
 }
 
+// getKey returns the key of the transfer in the transfer maps
+func (tr *Transfer) getKey() string {
+	if tr.key == "" {
+		return tr.remote
+	}
+	return tr.key
+}
+
 // Reset allows to switch the Account to another transfer method.
 func (tr *Transfer) Reset(ctx context.Context) {
 	tr.mu.RLock()
@@ -152,7 +168,7 @@ func (tr *Transfer) Reset(ctx context.Context) {
 func (tr *Transfer) Account(ctx context.Context, in io.ReadCloser) *Account {
 	tr.mu.Lock()
 	if tr.acc == nil {
-		tr.acc = newAccountSizeName(ctx, tr.stats, in, tr.size, tr.remote)
+		tr.acc = newAccountSizeNameKey(ctx, tr.stats, in, tr.size, tr.remote, tr.getKey())
 	} else {
 		tr.acc.UpdateReader(ctx, in)
 	}
@@ -1,6 +1,7 @@
 package accounting
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
@@ -83,3 +84,31 @@ func TestTransfer(t *testing.T) {
 		assert.Equal(t, "", snap.DstFs)
 	})
 }
+
+func TestTransferSameRemote(t *testing.T) {
+	ctx := context.Background()
+	s := NewStats(ctx)
+	o := mockobject.Object("obj")
+	dstFs1, err := mockfs.NewFs(ctx, "dstFs1", "dstFs1", nil)
+	require.NoError(t, err)
+	dstFs2, err := mockfs.NewFs(ctx, "dstFs2", "dstFs2", nil)
+	require.NoError(t, err)
+
+	// Transfers of the same remote at the same time are all counted
+	tr1 := s.NewTransfer(o, dstFs1)
+	tr2 := s.NewTransfer(o, dstFs2)
+	assert.Equal(t, "obj", tr1.getKey())
+	assert.NotEqual(t, tr1.getKey(), tr2.getKey())
+	assert.Equal(t, 2, s.transferring.count())
+	assert.Equal(t, []string{"obj", "obj"}, s.transferring.remotes())
+
+	acc1 := tr1.Account(ctx, io.NopCloser(bytes.NewBufferString("hello")))
+	acc2 := tr2.Account(ctx, io.NopCloser(bytes.NewBufferString("hello")))
+	assert.Equal(t, acc1, s.inProgress.get(tr1.getKey()))
+	assert.Equal(t, acc2, s.inProgress.get(tr2.getKey()))
+
+	tr1.Done(ctx, nil)
+	tr2.Done(ctx, nil)
+	assert.Equal(t, int64(2), s.GetTransfers())
+	assert.Equal(t, 0, s.transferring.count())
+}
@@ -28,17 +28,26 @@ func newTransferMap(size int, name string) *transferMap {
 }
 
 // add adds a new transfer to the map
+//
+// If there is a transfer of the same remote in the map already, for
+// example when copying to more than one destination, then this one is
+// given a different key.
 func (tm *transferMap) add(tr *Transfer) {
 	tm.mu.Lock()
-	tm.items[tr.remote] = tr
+	key := tr.remote
+	for i := 2; tm.items[key] != nil; i++ {
+		key = fmt.Sprintf("%s\x00%d", tr.remote, i)
+	}
+	tr.key = key
+	tm.items[key] = tr
 	tm.mu.Unlock()
 }
 
-// del removes a transfer from the map by name
-func (tm *transferMap) del(remote string) bool {
+// del removes a transfer from the map by key
+func (tm *transferMap) del(key string) bool {
 	tm.mu.Lock()
-	_, exists := tm.items[remote]
-	delete(tm.items, remote)
+	_, exists := tm.items[key]
+	delete(tm.items, key)
 	tm.mu.Unlock()
 
 	return exists
@@ -107,7 +116,7 @@ func (tm *transferMap) String(ctx context.Context, progress *inProgress, exclude
 			}
 		}
 		var out string
-		if acc := progress.get(tr.remote); acc != nil {
+		if acc := progress.get(tr.getKey()); acc != nil {
 			out = acc.String()
 			if what != "" {
 				out += ", " + what
@@ -159,7 +168,7 @@ func (tm *transferMap) rcStats(progress *inProgress) (t []rc.Params) {
 	defer tm.mu.RUnlock()
 	for _, tr := range tm._sortedSlice() {
 		out := tr.rcStats() // basic stats
-		if acc := progress.get(tr.remote); acc != nil {
+		if acc := progress.get(tr.getKey()); acc != nil {
 			acc.rcStats(out) // add extended stats if have acc
 		}
 		t = append(t, out)
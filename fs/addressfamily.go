@@ -0,0 +1,25 @@
+package fs
+
+type addressFamilyChoices struct{}
+
+func (addressFamilyChoices) Choices() []string {
+	return []string{
+		AddressFamilyAuto:       "auto",
+		AddressFamilyIPv4:       "ipv4",
+		AddressFamilyIPv6:       "ipv6",
+		AddressFamilyPreferIPv4: "prefer-ipv4",
+		AddressFamilyPreferIPv6: "prefer-ipv6",
+	}
+}
+
+// AddressFamily describes which IP versions outgoing connections use
+type AddressFamily = Enum[addressFamilyChoices]
+
+// AddressFamily constants
+const (
+	AddressFamilyAuto AddressFamily = iota
+	AddressFamilyIPv4
+	AddressFamilyIPv6
+	AddressFamilyPreferIPv4
+	AddressFamilyPreferIPv6
+)
@@ -75,12 +75,15 @@ type ConfigIn struct {
 // OAuth is a special value set by oauthutil.ConfigOAuth
 // Error is displayed to the user before asking a question
 // Result is passed to the next call to Config if Option/OAuth isn't set
+// URL and QRCode are set if the user should visit a URL to answer the question
 type ConfigOut struct {
 	State  string  // State to jump to after this
 	Option *Option // Option to query user about
 	OAuth  any     `json:"-"` // Do OAuth if set
 	Error  string  // error to be displayed to the user
 	Result string  // if Option/OAuth not set then this is passed to the next state
+	URL    string  `json:",omitempty"` // URL for the user to visit if set
+	QRCode string  `json:",omitempty"` // QR code of URL as a PNG data: URL if set
 }
 
 // ConfigInputOptional asks the user for a string which may be empty
@@ -320,6 +323,23 @@ func isConfigOAuthOnly(ctx context.Context) bool {
 	return ctx.Value(configOAuthKey) != nil
 }
 
+type configHeadlessKeyType struct{}
+
+// Headless key for config
+var configHeadlessKey = configHeadlessKeyType{}
+
+// ConfigHeadless marks the ctx so that the OAuth is done without
+// opening a browser or asking any OAuth questions, returning a URL
+// and QR code for the user to authorize with instead
+func ConfigHeadless(ctx context.Context) context.Context {
+	return context.WithValue(ctx, configHeadlessKey, struct{}{})
+}
+
+// IsConfigHeadless returns true if ctx is marked as ConfigHeadless
+func IsConfigHeadless(ctx context.Context) bool {
+	return ctx.Value(configHeadlessKey) != nil
+}
+
 // StatePop pops a state from the front of the config string
 // It returns the new state and the value popped
 func StatePop(state string) (newState string, value string) {
@@ -28,6 +28,7 @@ func createOnFirstUse() {
 		c = cache.New()
 		c.SetExpireDuration(time.Duration(ci.FsCacheExpireDuration))
 		c.SetExpireInterval(time.Duration(ci.FsCacheExpireInterval))
+		c.SetMaxEntries(ci.FsCacheMaxEntries)
 		c.SetFinalizer(func(value any) {
 			if s, ok := value.(fs.Shutdowner); ok {
 				_ = fs.CountError(context.Background(), s.Shutdown(context.Background()))
@@ -163,6 +164,66 @@ func Unpin(f fs.Fs) {
 	c.Unpin(fs.ConfigString(f))
 }
 
+// Evict removes the remote named fsString from the cache, shutting
+// down the backend if it supports it.
+//
+// Pinned remotes are only removed if force is set.
+//
+// Returns whether the remote was found and whether it was removed.
+func Evict(fsString string, force bool) (found, evicted bool) {
+	createOnFirstUse()
+	canonicalName := Canonicalize(fsString)
+	if force {
+		found = c.Delete(canonicalName)
+		evicted = found
+	} else {
+		found, evicted = c.DeleteUnpinned(canonicalName)
+	}
+	if evicted {
+		removeMappings(canonicalName)
+	}
+	return found, evicted
+}
+
+// removeMappings deletes all the mappings to canonicalName
+func removeMappings(canonicalName string) {
+	mu.Lock()
+	for _, mapping := range []map[string]string{remap, childParentMap} {
+		for key, val := range mapping {
+			if val == canonicalName {
+				delete(mapping, key)
+			}
+		}
+	}
+	mu.Unlock()
+}
+
+// EntryInfo describes a remote in the cache
+type EntryInfo struct {
+	Fs       fs.Fs     // the remote - may be nil if Err is set
+	Name     string    // the canonical name of the remote
+	Err      error     // error creating the remote if any
+	LastUsed time.Time // when the remote was last used
+	PinCount int       // number of pins on the remote
+}
+
+// List returns information about the remotes in the cache sorted by
+// name
+func List() (entries []EntryInfo) {
+	createOnFirstUse()
+	for _, entry := range c.List() {
+		f, _ := entry.Value.(fs.Fs)
+		entries = append(entries, EntryInfo{
+			Fs:       f,
+			Name:     entry.Key,
+			Err:      entry.Err,
+			LastUsed: entry.LastUsed,
+			PinCount: entry.PinCount,
+		})
+	}
+	return entries
+}
+
 // To avoid circular dependencies these are filled in by fs/rc/jobs/job.go
 var (
 	// JobGetJobID for internal use only
@@ -301,3 +301,44 @@ func TestEntries(t *testing.T) {
 
 	assert.Equal(t, 1, Entries())
 }
+
+func TestEvict(t *testing.T) {
+	create := mockNewFs(t)
+
+	found, evicted := Evict("mock:/", false)
+	assert.False(t, found)
+	assert.False(t, evicted)
+
+	f, err := GetFn(context.Background(), "mock:/", create)
+	require.NoError(t, err)
+	Pin(f)
+
+	found, evicted = Evict("mock:/", false)
+	assert.True(t, found)
+	assert.False(t, evicted)
+	assert.Equal(t, 1, Entries())
+
+	found, evicted = Evict("mock:/", true)
+	assert.True(t, found)
+	assert.True(t, evicted)
+	assert.Equal(t, 0, Entries())
+}
+
+func TestList(t *testing.T) {
+	create := mockNewFs(t)
+
+	assert.Equal(t, 0, len(List()))
+
+	f, err := GetFn(context.Background(), "mock:/", create)
+	require.NoError(t, err)
+	Pin(f)
+	defer Unpin(f)
+
+	entries := List()
+	require.Len(t, entries, 1)
+	assert.Equal(t, f, entries[0].Fs)
+	assert.Equal(t, fs.ConfigString(f), entries[0].Name)
+	assert.NoError(t, entries[0].Err)
+	assert.Equal(t, 1, entries[0].PinCount)
+	assert.False(t, entries[0].LastUsed.IsZero())
+}
@@ -9,6 +9,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/rclone/rclone/fs/hash"
 )
 
 // Global
@@ -151,6 +153,11 @@ var ConfigOptionsInfo = Options{{
 	Default: SizeSuffix(-1),
 	Help:    "When synchronizing, limit the total size of deletes",
 	Groups:  "Sync",
+}, {
+	Name:    "max_delete_ratio",
+	Default: -1,
+	Help:    "When synchronizing, abort if deleting more than this percentage of the destination files",
+	Groups:  "Sync",
 }, {
 	Name:    "track_renames",
 	Default: false,
@@ -161,6 +168,36 @@ var ConfigOptionsInfo = Options{{
 	Default: "hash",
 	Help:    "Strategies to use when synchronizing using track-renames hash|modtime|leaf",
 	Groups:  "Sync",
+}, {
+	Name:    "track_renames_file",
+	Default: "",
+	Help:    "Listing file to track renames by hash when the source and destination have no common hash",
+	Groups:  "Sync",
+}, {
+	Name:    "dedupe_uploads",
+	Default: false,
+	Help:    "Upload identical source files once and server-side copy the others",
+	Groups:  "Sync",
+}, {
+	Name:    "graceful_stop",
+	Default: false,
+	Help:    "On the first interrupt let transfers in progress finish and start no more",
+	Groups:  "Sync",
+}, {
+	Name:    "graceful_stop_list",
+	Default: "",
+	Help:    "On a graceful stop write the files still to transfer to this file for --files-from",
+	Groups:  "Sync",
+}, {
+	Name:    "quota_fallback",
+	Default: false,
+	Help:    "If the destination runs out of quota stop transferring and just check the remaining files",
+	Groups:  "Sync",
+}, {
+	Name:    "quota_fallback_list",
+	Default: "",
+	Help:    "If the destination runs out of quota write the files still to copy to this file for --files-from",
+	Groups:  "Sync",
 }, {
 	Name:    "retries",
 	Default: 3,
@@ -207,6 +244,11 @@ var ConfigOptionsInfo = Options{{
 	Default: false,
 	Help:    "Skip post copy check of checksums",
 	Groups:  "Copy",
+}, {
+	Name:    "verify_transfers",
+	Default: false,
+	Help:    "Hash files while transferring them and verify the hash against the destination",
+	Groups:  "Copy",
 }, {
 	Name:    "ignore_case_sync",
 	Default: false,
@@ -257,11 +299,21 @@ var ConfigOptionsInfo = Options{{
 	Default: []string{},
 	Help:    "Implies --compare-dest but also copies files from paths into destination",
 	Groups:  "Copy",
+}, {
+	Name:    "compare_expr",
+	Default: "",
+	Help:    "Expression over the src and dst attributes deciding whether to transfer existing files",
+	Groups:  "Copy",
 }, {
 	Name:    "backup_dir",
 	Default: "",
 	Help:    "Make backups into hierarchy based in DIR",
 	Groups:  "Sync",
+}, {
+	Name:    "backup_dir_versions",
+	Default: false,
+	Help:    "Make backups into a new date-stamped directory inside --backup-dir on each run",
+	Groups:  "Sync",
 }, {
 	Name:    "suffix",
 	Default: "",
@@ -272,6 +324,21 @@ var ConfigOptionsInfo = Options{{
 	Default: false,
 	Help:    "Preserve the extension when using --suffix",
 	Groups:  "Sync",
+}, {
+	Name:    "use_trash",
+	Default: false,
+	Help:    "Move deleted files into the backend's trash or a .rclone-trash directory",
+	Groups:  "Sync",
+}, {
+	Name:    "trash_retention",
+	Default: Duration(30 * 24 * time.Hour),
+	Help:    "How long rclone cleanup keeps files in the .rclone-trash directory",
+	Groups:  "Sync",
+}, {
+	Name:    "delete_mode",
+	Default: DeleteActionDelete,
+	Help:    "How to delete files on the destination: delete, or marker to add delete markers keeping their history",
+	Groups:  "Sync",
 }, {
 	Name:    "fast_list",
 	Default: false,
@@ -302,6 +369,16 @@ var ConfigOptionsInfo = Options{{
 	Default: false,
 	Help:    "Do not modify files, fail if existing files have been modified",
 	Groups:  "Copy",
+}, {
+	Name:    "immutable_manifest",
+	Default: "",
+	Help:    "Manifest file of destination hashes to verify --immutable destinations against on each run",
+	Groups:  "Copy",
+}, {
+	Name:    "immutable_report",
+	Default: "",
+	Help:    "File to write a JSON report of --immutable-manifest violations to",
+	Groups:  "Copy",
 }, {
 	Name:    "auto_confirm",
 	Default: false,
@@ -352,6 +429,11 @@ var ConfigOptionsInfo = Options{{
 	Default: DumpFlags(0),
 	Help:    "List of items to dump from: " + DumpFlagsList,
 	Groups:  "Debugging",
+}, {
+	Name:    "capture_http",
+	Default: "",
+	Help:    "Write HTTP transactions with credentials removed to this .tar.gz file",
+	Groups:  "Debugging",
 }, {
 	Name:    "max_transfer",
 	Default: SizeSuffix(-1),
@@ -464,6 +546,26 @@ var ConfigOptionsInfo = Options{{
 	Default: SizeSuffix(64 * 1024 * 1024),
 	Help:    "Chunk size for multi-thread downloads / uploads, if not set by filesystem",
 	Groups:  "Copy",
+}, {
+	Name:    "resume_uploads",
+	Default: false,
+	Help:    "Resume interrupted multi-thread uploads on backends which support it",
+	Groups:  "Copy",
+}, {
+	Name:    "resume_downloads",
+	Default: false,
+	Help:    "Keep the partial files of interrupted downloads and resume them on backends which support it",
+	Groups:  "Copy",
+}, {
+	Name:    "prune_unchanged_dirs",
+	Default: false,
+	Help:    "Skip source directories unchanged since the last successful sync on backends which support it",
+	Groups:  "Sync",
+}, {
+	Name:    "checksum_sidecar",
+	Default: "",
+	Help:    "Write a checksum sidecar file of this hash type (e.g. sha256) next to each uploaded file",
+	Groups:  "Copy",
 }, {
 	Name:    "use_json_log",
 	Default: false,
@@ -472,7 +574,7 @@ var ConfigOptionsInfo = Options{{
 }, {
 	Name:    "order_by",
 	Default: "",
-	Help:    "Instructions on how to order the transfers, e.g. 'size,descending'",
+	Help:    "Instructions on how to order the transfers, e.g. 'size,descending' or 'fairshare'",
 	Groups:  "Copy",
 }, {
 	Name:    "refresh_times",
@@ -494,11 +596,46 @@ var ConfigOptionsInfo = Options{{
 	Default: 60 * time.Second,
 	Help:    "Interval to check for expired remotes",
 	Groups:  "Config",
+}, {
+	Name:    "fs_cache_max_entries",
+	Default: 0,
+	Help:    "Maximum number of remotes to cache (0 for no limit)",
+	Groups:  "Config",
 }, {
 	Name:    "disable_http2",
 	Default: false,
 	Help:    "Disable HTTP/2 in the global transport",
 	Groups:  "Networking",
+}, {
+	Name:    "use_http3",
+	Default: false,
+	Help:    "Use HTTP/3 (QUIC) for servers which advertise it",
+	Groups:  "Networking",
+}, {
+	Name:    "bind",
+	Default: "",
+	Help:    "Local address to bind to for outgoing connections, IPv4, IPv6 or name",
+	Groups:  "Networking",
+}, {
+	Name:    "bind_interface",
+	Default: "",
+	Help:    "Network interface to bind to for outgoing connections, e.g. eth1",
+	Groups:  "Networking",
+}, {
+	Name:    "address_family",
+	Default: AddressFamilyAuto,
+	Help:    "IP versions to use for outgoing connections",
+	Groups:  "Networking",
+}, {
+	Name:    "happy_eyeballs_delay",
+	Default: Duration(0),
+	Help:    "Delay before trying the other IP version when connecting (0 for the default, off to disable)",
+	Groups:  "Networking",
+}, {
+	Name:    "dns_override",
+	Default: []string{},
+	Help:    "Use these addresses for host names instead of DNS, as host=IP",
+	Groups:  "Networking",
 }, {
 	Name:    "human_readable",
 	Default: false,
@@ -520,6 +657,11 @@ var ConfigOptionsInfo = Options{{
 	Default:  false,
 	Help:     "If set, preserve metadata when copying objects",
 	Groups:   "Metadata,Copy",
+}, {
+	Name:    "metadata_update",
+	Default: false,
+	Help:    "With --metadata, update the metadata of unchanged files if it differs",
+	Groups:  "Metadata",
 }, {
 	Name:    "server_side_across_configs",
 	Default: false,
@@ -564,8 +706,26 @@ var ConfigOptionsInfo = Options{{
 }, {
 	Name:    "http_proxy",
 	Default: "",
-	Help:    "HTTP proxy URL.",
+	Help:    "HTTP or SOCKS5 proxy URL, or a comma separated chain of them.",
 	Groups:  "Networking",
+}, {
+	Name:     "pacer_breaker_threshold",
+	Default:  0,
+	Help:     "Consecutive failed API calls to a remote to stop calling it for --pacer-breaker-cooldown, 0 to disable",
+	Advanced: true,
+	Groups:   "Networking",
+}, {
+	Name:     "pacer_breaker_cooldown",
+	Default:  time.Minute,
+	Help:     "How long to fail calls to a remote fast for once --pacer-breaker-threshold is reached",
+	Advanced: true,
+	Groups:   "Networking",
+}, {
+	Name:     "credential_refresh_before",
+	Default:  5 * time.Minute,
+	Help:     "Refresh credentials like OAuth tokens this long before they expire, 0 to disable",
+	Advanced: true,
+	Groups:   "Networking",
 }}
 
 // ConfigInfo is filesystem config options
@@ -588,12 +748,20 @@ type ConfigInfo struct {
 	Timeout                    Duration          `config:"timeout"`    // Data channel timeout
 	ExpectContinueTimeout      Duration          `config:"expect_continue_timeout"`
 	Dump                       DumpFlags         `config:"dump"`
+	CaptureHTTP                string            `config:"capture_http"`
 	InsecureSkipVerify         bool              `config:"no_check_certificate"` // Skip server certificate verification
-	DeleteMode                 DeleteMode        `config:"delete_mode"`
+	DeleteMode                 DeleteMode        `config:"delete_timing"`
 	MaxDelete                  int64             `config:"max_delete"`
 	MaxDeleteSize              SizeSuffix        `config:"max_delete_size"`
+	MaxDeleteRatio             int               `config:"max_delete_ratio"`
 	TrackRenames               bool              `config:"track_renames"`          // Track file renames.
 	TrackRenamesStrategy       string            `config:"track_renames_strategy"` // Comma separated list of strategies used to track renames
+	TrackRenamesFile           string            `config:"track_renames_file"`     // Listing file used to track renames without a common hash
+	DedupeUploads              bool              `config:"dedupe_uploads"`         // Upload identical files once and server-side copy the rest
+	GracefulStop               bool              `config:"graceful_stop"`          // Stop gracefully on the first interrupt
+	GracefulStopList           string            `config:"graceful_stop_list"`     // File to write the remaining files to on a graceful stop
+	QuotaFallback              bool              `config:"quota_fallback"`         // Stop transferring if the destination runs out of quota
+	QuotaFallbackList          string            `config:"quota_fallback_list"`    // File to write the files still to copy to when out of quota
 	Retries                    int               `config:"retries"`                // High-level retries
 	RetriesInterval            Duration          `config:"retries_sleep"`
 	LowLevelRetries            int               `config:"low_level_retries"`
@@ -602,6 +770,7 @@ type ConfigInfo struct {
 	MaxDepth                   int               `config:"max_depth"`
 	IgnoreSize                 bool              `config:"ignore_size"`
 	IgnoreChecksum             bool              `config:"ignore_checksum"`
+	VerifyTransfers            bool              `config:"verify_transfers"`
 	IgnoreCaseSync             bool              `config:"ignore_case_sync"`
 	FixCase                    bool              `config:"fix_case"`
 	NoTraverse                 bool              `config:"no_traverse"`
@@ -613,9 +782,14 @@ type ConfigInfo struct {
 	DataRateUnit               string            `config:"stats_unit"`
 	CompareDest                []string          `config:"compare_dest"`
 	CopyDest                   []string          `config:"copy_dest"`
+	CompareExpr                string            `config:"compare_expr"`
 	BackupDir                  string            `config:"backup_dir"`
+	BackupDirVersions          bool              `config:"backup_dir_versions"`
 	Suffix                     string            `config:"suffix"`
 	SuffixKeepExtension        bool              `config:"suffix_keep_extension"`
+	UseTrash                   bool              `config:"use_trash"`
+	TrashRetention             Duration          `config:"trash_retention"`
+	DeleteAction               DeleteAction      `config:"delete_mode"`
 	UseListR                   bool              `config:"fast_list"`
 	ListCutoff                 int               `config:"list_cutoff"`
 	BufferSize                 SizeSuffix        `config:"buffer_size"`
@@ -623,10 +797,17 @@ type ConfigInfo struct {
 	BwLimitFile                BwTimetable       `config:"bwlimit_file"`
 	TPSLimit                   float64           `config:"tpslimit"`
 	TPSLimitBurst              int               `config:"tpslimit_burst"`
+	Bind                       string            `config:"bind"`
 	BindAddr                   net.IP            `config:"bind_addr"`
+	BindInterface              string            `config:"bind_interface"`
+	AddressFamily              AddressFamily     `config:"address_family"`
+	HappyEyeballsDelay         Duration          `config:"happy_eyeballs_delay"`
+	DNSOverride                []string          `config:"dns_override"`
 	DisableFeatures            []string          `config:"disable"`
 	UserAgent                  string            `config:"user_agent"`
 	Immutable                  bool              `config:"immutable"`
+	ImmutableManifest          string            `config:"immutable_manifest"`
+	ImmutableReport            string            `config:"immutable_report"`
 	AutoConfirm                bool              `config:"auto_confirm"`
 	StreamingUploadCutoff      SizeSuffix        `config:"streaming_upload_cutoff"`
 	StatsFileNameLength        int               `config:"stats_file_name_length"`
@@ -656,6 +837,10 @@ type ConfigInfo struct {
 	MultiThreadSet             bool              `config:"multi_thread_set"`        // whether MultiThreadStreams was set (set in fs/config/configflags)
 	MultiThreadChunkSize       SizeSuffix        `config:"multi_thread_chunk_size"` // Chunk size for multi-thread downloads / uploads, if not set by filesystem
 	MultiThreadWriteBufferSize SizeSuffix        `config:"multi_thread_write_buffer_size"`
+	ResumeUploads              bool              `config:"resume_uploads"`
+	ResumeDownloads            bool              `config:"resume_downloads"`
+	PruneUnchangedDirs         bool              `config:"prune_unchanged_dirs"`
+	ChecksumSidecar            string            `config:"checksum_sidecar"`
 	OrderBy                    string            `config:"order_by"` // instructions on how to order the transfer
 	UploadHeaders              []*HTTPOption     `config:"upload_headers"`
 	DownloadHeaders            []*HTTPOption     `config:"download_headers"`
@@ -666,11 +851,14 @@ type ConfigInfo struct {
 	TrafficClass               uint8             `config:"traffic_class"`
 	FsCacheExpireDuration      Duration          `config:"fs_cache_expire_duration"`
 	FsCacheExpireInterval      Duration          `config:"fs_cache_expire_interval"`
+	FsCacheMaxEntries          int               `config:"fs_cache_max_entries"`
 	DisableHTTP2               bool              `config:"disable_http2"`
+	UseHTTP3                   bool              `config:"use_http3"`
 	HumanReadable              bool              `config:"human_readable"`
 	KvLockTime                 Duration          `config:"kv_lock_time"` // maximum time to keep key-value database locked by process
 	DisableHTTPKeepAlives      bool              `config:"disable_http_keep_alives"`
 	Metadata                   bool              `config:"metadata"`
+	MetadataUpdate             bool              `config:"metadata_update"`
 	ServerSideAcrossConfigs    bool              `config:"server_side_across_configs"`
 	TerminalColorMode          TerminalColorMode `config:"color"`
 	DefaultTime                Time              `config:"default_time"` // time that directories with no time should display
@@ -680,6 +868,9 @@ type ConfigInfo struct {
 	MaxConnections             int               `config:"max_connections"`
 	NameTransform              []string          `config:"name_transform"`
 	HTTPProxy                  string            `config:"http_proxy"`
+	PacerBreakerThreshold      int               `config:"pacer_breaker_threshold"`
+	PacerBreakerCooldown       Duration          `config:"pacer_breaker_cooldown"`
+	CredentialRefreshBefore    Duration          `config:"credential_refresh_before"`
 }
 
 func init() {
@@ -730,6 +921,14 @@ func (ci *ConfigInfo) Reload(ctx context.Context) error {
 		return fmt.Errorf("--partial-suffix: Expecting suffix length not greater than %d but got %d", 16, len(ci.PartialSuffix))
 	}
 
+	// Check --checksum-sidecar
+	if ci.ChecksumSidecar != "" {
+		var ht hash.Type
+		if err := ht.Set(ci.ChecksumSidecar); err != nil || ht == hash.None {
+			return fmt.Errorf("--checksum-sidecar: unknown hash type %q", ci.ChecksumSidecar)
+		}
+	}
+
 	// Make sure some values are > 0
 	nonZero := func(pi *int) {
 		if *pi <= 0 {
@@ -123,8 +123,8 @@ var (
 
 func init() {
 	// Set the function pointers up in fs
-	fs.ConfigFileGet = FileGetValue
-	fs.ConfigFileSet = SetValueAndSave
+	fs.ConfigFileGet = fileGetValueResolved
+	fs.ConfigFileSet = setValueAndSaveUnlessSecret
 	fs.ConfigFileHasSection = func(section string) bool {
 		return LoadedData().HasSection(section)
 	}
@@ -376,6 +376,7 @@ func LoadedData() Storage {
 		} else {
 			fs.Fatalf(nil, "Failed to load config file %q: %v", configPath, err)
 		}
+		rememberLoaded()
 	}
 	return data
 }
@@ -423,6 +424,9 @@ func FileDeleteKey(section, key string) bool {
 // GetValue gets the value for a config key from environment
 // or config file under section returning the default if not set.
 //
+// Values not set in the section are inherited from the section named
+// in its "inherit" key if any.
+//
 // Emulates the preference documented and normally used by rclone via
 // configmap, which means environment variables before config file.
 func GetValue(remote, key string) string {
@@ -431,7 +435,7 @@ func GetValue(remote, key string) string {
 	if found {
 		return value
 	}
-	value, _ = LoadedData().GetValue(remote, key)
+	value, _ = inheritedGetValue(remote, key)
 	return value
 }
 
@@ -456,7 +460,8 @@ type Remote struct {
 
 var remoteEnvRe = regexp.MustCompile(`^RCLONE_CONFIG_(.+?)_TYPE=(.+)$`)
 
-// GetRemotes returns the list of remotes defined in environment and config file.
+// GetRemotes returns the list of remotes defined in environment, the
+// active profile and config file.
 //
 // Emulates the preference documented and normally used by rclone via
 // configmap, which means environment variables before config file.
@@ -480,10 +485,24 @@ func GetRemotes() []Remote {
 		}
 		return false
 	}
+	for _, name := range profileRemotes() {
+		if !remoteExists(name) {
+			typeValue, found := inheritedGetValue(name, "type")
+			if found {
+				description, _ := inheritedGetValue(name, "description")
+				remotes = append(remotes, Remote{
+					Name:        name,
+					Type:        typeValue,
+					Source:      "profile",
+					Description: description,
+				})
+			}
+		}
+	}
 	sections := LoadedData().GetSectionList()
 	for _, section := range sections {
 		if !remoteExists(section) {
-			typeValue, found := LoadedData().GetValue(section, "type")
+			typeValue, found := inheritedGetValue(section, "type")
 			if found {
 				description, _ := LoadedData().GetValue(section, "description")
 				remotes = append(remotes, Remote{
@@ -528,6 +547,8 @@ type UpdateRemoteOpt struct {
 	Result string `json:"result"`
 	// If set then edit existing values
 	Edit bool `json:"edit"`
+	// If set then do OAuth by returning a URL and QR code to visit
+	Headless bool `json:"headless"`
 }
 
 func updateRemote(ctx context.Context, name string, keyValues rc.Params, opt UpdateRemoteOpt) (out *fs.ConfigOut, err error) {
@@ -543,6 +564,9 @@ func updateRemote(ctx context.Context, name string, keyValues rc.Params, opt Upd
 	if interactive && !opt.All {
 		ctx = suppressConfirm(ctx)
 	}
+	if opt.Headless {
+		ctx = fs.ConfigHeadless(ctx)
+	}
 
 	fsType := GetValue(name, "type")
 	if fsType == "" {
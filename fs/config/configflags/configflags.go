@@ -13,6 +13,7 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config"
 	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/hash"
 	"github.com/spf13/pflag"
 )
 
@@ -28,13 +29,14 @@ var (
 	deleteBefore    bool
 	deleteDuring    bool
 	deleteAfter     bool
-	bindAddr        string
 	disableFeatures string
 	dscp            string
 	uploadHeaders   []string
 	downloadHeaders []string
 	headers         []string
 	metadataSet     []string
+	hashCommands    []string
+	profile         string
 )
 
 // AddFlags adds the non filing system specific flags to the command
@@ -52,12 +54,13 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &deleteBefore, "delete-before", "", false, "When synchronizing, delete files on destination before transferring", "Sync")
 	flags.BoolVarP(flagSet, &deleteDuring, "delete-during", "", false, "When synchronizing, delete files during transfer", "Sync")
 	flags.BoolVarP(flagSet, &deleteAfter, "delete-after", "", false, "When synchronizing, delete files on destination after transferring (default)", "Sync")
-	flags.StringVarP(flagSet, &bindAddr, "bind", "", "", "Local address to bind to for outgoing connections, IPv4, IPv6 or name", "Networking")
 	flags.StringVarP(flagSet, &disableFeatures, "disable", "", "", "Disable a comma separated list of features (use --disable help to see a list)", "Config")
 	flags.StringArrayVarP(flagSet, &uploadHeaders, "header-upload", "", nil, "Set HTTP header for upload transactions", "Networking")
 	flags.StringArrayVarP(flagSet, &downloadHeaders, "header-download", "", nil, "Set HTTP header for download transactions", "Networking")
 	flags.StringArrayVarP(flagSet, &headers, "header", "", nil, "Set HTTP header for all transactions", "Networking")
 	flags.StringArrayVarP(flagSet, &metadataSet, "metadata-set", "", nil, "Add metadata key=value when uploading", "Metadata")
+	flags.StringArrayVarP(flagSet, &hashCommands, "hash-command", "", nil, "Add an external hash name=command which prints the checksum of stdin", "Config")
+	flags.StringVarP(flagSet, &profile, "profile", "", "", "Use the named profile from the config file", "Config")
 	flags.StringVarP(flagSet, &dscp, "dscp", "", "", "Set DSCP value to connections, value or name, e.g. CS1, LE, DF, AF21", "Networking")
 }
 
@@ -132,13 +135,13 @@ func SetFlags(ci *fs.ConfigInfo) {
 	}
 
 	// Process --bind into IP address
-	if bindAddr != "" {
-		addrs, err := net.LookupIP(bindAddr)
+	if ci.Bind != "" {
+		addrs, err := net.LookupIP(ci.Bind)
 		if err != nil {
-			fs.Fatalf(nil, "--bind: Failed to parse %q as IP address: %v", bindAddr, err)
+			fs.Fatalf(nil, "--bind: Failed to parse %q as IP address: %v", ci.Bind, err)
 		}
 		if len(addrs) != 1 {
-			fs.Fatalf(nil, "--bind: Expecting 1 IP address for %q but got %d", bindAddr, len(addrs))
+			fs.Fatalf(nil, "--bind: Expecting 1 IP address for %q but got %d", ci.Bind, len(addrs))
 		}
 		ci.BindAddr = addrs[0]
 	}
@@ -175,6 +178,23 @@ func SetFlags(ci *fs.ConfigInfo) {
 		fs.Debugf(nil, "MetadataUpload %v", ci.MetadataSet)
 	}
 
+	// Process --hash-command
+	for _, spec := range hashCommands {
+		equal := strings.IndexRune(spec, '=')
+		if equal < 0 {
+			fs.Fatalf(nil, "Failed to parse '%s' as hash name=command.", spec)
+		}
+		var command fs.SpaceSepList
+		if err := command.Set(spec[equal+1:]); err != nil {
+			fs.Fatalf(nil, "--hash-command: Failed to parse command %q: %v", spec[equal+1:], err)
+		}
+		hashType, err := hash.RegisterCommand(spec[:equal], command)
+		if err != nil {
+			fs.Fatalf(nil, "--hash-command: %v", err)
+		}
+		fs.Debugf(nil, "Registered hash %v using %q", hashType, command)
+	}
+
 	// Process --dscp
 	if len(dscp) != 0 {
 		if value, ok := parseDSCP(dscp); ok {
@@ -266,3 +286,19 @@ func parseDSCP(dscp string) (uint8, bool) {
 		return 0, false
 	}
 }
+
+// SetProfile activates the profile named by the --profile flag if set.
+//
+// This should be called once the config file has been installed.
+func SetProfile() {
+	config.ProfileFlagChanged = func(name string) bool {
+		flag := pflag.Lookup(name)
+		return flag != nil && flag.Changed
+	}
+	if profile == "" {
+		return
+	}
+	if err := config.SetProfile(profile); err != nil {
+		fs.Fatalf(nil, "--profile: %v", err)
+	}
+}
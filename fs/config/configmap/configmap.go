@@ -157,7 +157,9 @@ func (c Simple) string(human bool) string {
 			continue
 		}
 		out.WriteRune('=')
-		if !human || strings.ContainsAny(v, `'":=,`) {
+		// Values starting with { must be quoted so they aren't
+		// read as a nested {value}
+		if !human || strings.ContainsAny(v, `'":=,`) || strings.HasPrefix(v, "{") {
 			out.WriteRune('\'')
 			for _, ch := range v {
 				out.WriteRune(ch)
@@ -98,6 +98,10 @@ func TestSimpleHuman(t *testing.T) {
 			"config1": "o'n'e",
 			"apple":   "",
 		}},
+		{name: "Brace", want: "config1='{one}',config2=t{w}o", in: configmap.Simple{
+			"config1": "{one}",
+			"config2": "t{w}o",
+		}},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			// Check forwards
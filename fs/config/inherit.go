@@ -0,0 +1,89 @@
+// Remote inheritance from template sections
+
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// ConfigInherit is the config key used to name the section a remote
+// inherits its unset values from
+const ConfigInherit = "inherit"
+
+// maxInheritDepth limits the length of inheritance chains
+const maxInheritDepth = 32
+
+// inheritChain returns the sections to look up values for section in,
+// most specific first, starting with section itself.
+func inheritChain(section string) (chain []string, err error) {
+	for len(chain) < maxInheritDepth {
+		if slices.Contains(chain, section) {
+			return chain, fmt.Errorf("%q: inheritance loop via %q", chain[0], section)
+		}
+		chain = append(chain, section)
+		parent, found := LoadedData().GetValue(section, ConfigInherit)
+		if !found || parent == "" {
+			return chain, nil
+		}
+		if !LoadedData().HasSection(parent) {
+			return chain, fmt.Errorf("%q: inherits from %q which doesn't exist", chain[0], parent)
+		}
+		section = parent
+	}
+	return chain, fmt.Errorf("%q: inheritance chain longer than %d", chain[0], maxInheritDepth)
+}
+
+// inheritedGetValue gets the config key under section, falling back
+// to the sections it inherits from if it isn't set there.
+//
+// If section is remapped by the active profile the section it is
+// remapped to is used.
+//
+// Any problems with the inheritance chain are logged and the values
+// found so far used.
+func inheritedGetValue(section, key string) (value string, found bool) {
+	section = profileRemote(section)
+	value, found = LoadedData().GetValue(section, key)
+	if found || key == ConfigInherit {
+		return value, found
+	}
+	chain, err := inheritChain(section)
+	if err != nil {
+		fs.Errorf(nil, "Config file: %v", err)
+	}
+	for _, parent := range chain[1:] {
+		value, found = LoadedData().GetValue(parent, key)
+		if found {
+			return value, found
+		}
+	}
+	return "", false
+}
+
+// ResolvedKeyList returns the keys which are set in section or any
+// section it inherits from, sorted with "type" first.
+func ResolvedKeyList(section string) ([]string, error) {
+	chain, err := inheritChain(profileRemote(section))
+	seen := map[string]struct{}{}
+	var keys []string
+	for _, s := range chain {
+		for _, key := range LoadedData().GetKeyList(s) {
+			if _, ok := seen[key]; ok || key == ConfigInherit {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i] == "type" || keys[j] == "type" {
+			return keys[i] == "type"
+		}
+		return keys[i] < keys[j]
+	})
+	return keys, err
+}
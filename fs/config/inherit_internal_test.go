@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInheritedGetValue(t *testing.T) {
+	oldData := data
+	data = newDefaultStorage()
+	defer func() { data = oldData }()
+
+	FileSetValue("s3-base", "type", "s3")
+	FileSetValue("s3-base", "provider", "AWS")
+	FileSetValue("s3-base", "region", "eu-west-1")
+	FileSetValue("s3-eu", "inherit", "s3-base")
+	FileSetValue("s3-eu", "endpoint", "eu.example.com")
+	FileSetValue("bucket1", "inherit", "s3-eu")
+	FileSetValue("bucket1", "region", "us-east-1")
+	FileSetValue("loop1", "inherit", "loop2")
+	FileSetValue("loop2", "inherit", "loop1")
+	FileSetValue("loop2", "type", "local")
+	FileSetValue("dangling", "inherit", "nothere")
+
+	for _, test := range []struct {
+		section string
+		key     string
+		want    string
+		found   bool
+	}{
+		{"bucket1", "type", "s3", true},
+		{"bucket1", "provider", "AWS", true},
+		{"bucket1", "region", "us-east-1", true},
+		{"bucket1", "endpoint", "eu.example.com", true},
+		{"bucket1", "inherit", "s3-eu", true},
+		{"bucket1", "missing", "", false},
+		{"s3-eu", "region", "eu-west-1", true},
+		{"loop1", "type", "local", true},
+		{"loop1", "missing", "", false},
+		{"dangling", "type", "", false},
+	} {
+		got, found := inheritedGetValue(test.section, test.key)
+		assert.Equal(t, test.want, got, test)
+		assert.Equal(t, test.found, found, test)
+	}
+	assert.Equal(t, "s3", GetValue("bucket1", "type"))
+
+	keys, err := ResolvedKeyList("bucket1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"type", "endpoint", "provider", "region"}, keys)
+
+	_, err = ResolvedKeyList("loop1")
+	assert.ErrorContains(t, err, "loop")
+
+	_, err = ResolvedKeyList("dangling")
+	assert.ErrorContains(t, err, "doesn't exist")
+}
@@ -0,0 +1,176 @@
+// Named profiles selecting remotes and default flags
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/config/configstruct"
+)
+
+// ProfilePrefix is the prefix of config file sections defining profiles
+const ProfilePrefix = "profile."
+
+// ProfileFlagChanged is used by SetProfile to check whether the global
+// flag for an option was set on the command line, in which case the
+// profile won't override it.
+//
+// This is a function pointer to decouple the flags implementation
+// from the config.
+var ProfileFlagChanged = func(name string) bool { return false }
+
+var (
+	profileMu     sync.Mutex
+	activeProfile string
+	profileGlobal map[string]profileValue // global options set by the active profile
+)
+
+// profileValue records a global option set by a profile
+type profileValue struct {
+	old string // value before the profile set it
+	set string // value the profile set
+}
+
+// Profiles returns the sorted names of the profiles defined in the
+// config file.
+func Profiles() []string {
+	var profiles []string
+	for _, section := range LoadedData().GetSectionList() {
+		if name, ok := strings.CutPrefix(section, ProfilePrefix); ok && name != "" {
+			profiles = append(profiles, name)
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// ActiveProfile returns the name of the active profile or "" if none
+func ActiveProfile() string {
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	return activeProfile
+}
+
+// profileRemote returns the section which remote refers to in the
+// active profile, or remote if it isn't remapped.
+func profileRemote(remote string) string {
+	profile := ActiveProfile()
+	if profile == "" || strings.HasPrefix(remote, ProfilePrefix) {
+		return remote
+	}
+	target, found := LoadedData().GetValue(ProfilePrefix+profile, remote)
+	if !found || target == "" {
+		return remote
+	}
+	return strings.TrimSuffix(target, ":")
+}
+
+// profileRemotes returns the remote names remapped by the active
+// profile.
+func profileRemotes() (remotes []string) {
+	profile := ActiveProfile()
+	if profile == "" {
+		return nil
+	}
+	for _, key := range LoadedData().GetKeyList(ProfilePrefix + profile) {
+		if !strings.Contains(key, ".") {
+			remotes = append(remotes, key)
+		}
+	}
+	sort.Strings(remotes)
+	return remotes
+}
+
+// SetProfile makes the named profile active, or deactivates the
+// active profile if name is "".
+//
+// While a profile is active, a remote named as a key in the profile
+// section is looked up in the section given by its value instead,
+// and "global.flag_name" keys set the global flags unless they were
+// set on the command line. The global flags set by any previously
+// active profile are put back first, unless they have been changed
+// since, and cached remotes are discarded. Only the global flags the
+// profiles name are written.
+func SetProfile(name string) error {
+	section := ProfilePrefix + name
+	if name != "" && !LoadedData().HasSection(section) {
+		return fmt.Errorf("%w: profile %q", fs.ErrorNotFoundInConfigFile, name)
+	}
+
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	ci := fs.GetConfig(context.Background())
+	items, err := configstruct.Items(ci)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]configstruct.Item, len(items))
+	for _, item := range items {
+		current[item.Name] = item
+	}
+
+	// Work out the new values before changing anything
+	values := make(map[string]string)
+	for key, v := range profileGlobal {
+		if item, ok := current[key]; ok && valueString(item.Value) == v.set {
+			values[key] = v.old
+		}
+	}
+	newGlobal := make(map[string]profileValue)
+	if name != "" {
+		for i := range fs.ConfigOptionsInfo {
+			opt := &fs.ConfigOptionsInfo[i]
+			value, found := LoadedData().GetValue(section, "global."+opt.Name)
+			item, ok := current[opt.Name]
+			if !found || !ok || ProfileFlagChanged(strings.ReplaceAll(opt.Name, "_", "-")) {
+				continue
+			}
+			old, restoring := values[opt.Name]
+			if !restoring {
+				old = valueString(item.Value)
+			}
+			values[opt.Name] = value
+			newGlobal[opt.Name] = profileValue{old: old}
+		}
+	}
+	newValues := make(map[string]any, len(values))
+	for key, value := range values {
+		newValue, err := configstruct.StringToInterface(current[key].Value, value)
+		if err != nil {
+			return fmt.Errorf("profile %q: failed to set global config %q = %q: %w", name, key, value, err)
+		}
+		newValues[key] = newValue
+		if v, ok := newGlobal[key]; ok {
+			v.set = valueString(newValue)
+			newGlobal[key] = v
+		}
+	}
+
+	for key, newValue := range newValues {
+		current[key].Set(newValue)
+	}
+	profileGlobal = newGlobal
+	activeProfile = name
+	cache.Clear()
+	if name == "" {
+		fs.Debugf(nil, "Deactivated profile")
+	} else {
+		fs.Infof(nil, "Using profile %q", name)
+	}
+	return nil
+}
+
+// valueString returns the config file form of a global option value
+func valueString(value any) string {
+	s, err := configstruct.InterfaceToString(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return s
+}
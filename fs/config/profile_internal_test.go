@@ -0,0 +1,75 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	oldData := data
+	data = newDefaultStorage()
+	ci := fs.GetConfig(context.Background())
+	oldCI := *ci
+	oldFlagChanged := ProfileFlagChanged
+	defer func() {
+		require.NoError(t, SetProfile(""))
+		data = oldData
+		*ci = oldCI
+		profileGlobal = nil
+		ProfileFlagChanged = oldFlagChanged
+	}()
+
+	FileSetValue("s3-dev", "type", "s3")
+	FileSetValue("s3-dev", "bucket", "dev")
+	FileSetValue("s3-prod", "type", "s3")
+	FileSetValue("s3-prod", "bucket", "prod")
+	FileSetValue("profile.dev", "data", "s3-dev")
+	FileSetValue("profile.prod", "data", "s3-prod:")
+	FileSetValue("profile.prod", "global.checkers", "3")
+	FileSetValue("profile.prod", "global.transfers", "2")
+
+	assert.Equal(t, []string{"dev", "prod"}, Profiles())
+	assert.Equal(t, "", ActiveProfile())
+	assert.Equal(t, "", GetValue("data", "type"))
+
+	ci.Checkers = 8
+	ci.Transfers = 4
+	ProfileFlagChanged = func(name string) bool { return name == "transfers" }
+
+	require.NoError(t, SetProfile("prod"))
+	assert.Equal(t, "prod", ActiveProfile())
+	assert.Equal(t, "prod", GetValue("data", "bucket"))
+	assert.Equal(t, "s3", GetValue("data", "type"))
+	assert.Equal(t, 3, ci.Checkers)
+	assert.Equal(t, 4, ci.Transfers, "flags set on the command line should win")
+	found := false
+	for _, remote := range GetRemotes() {
+		if remote.Name == "data" {
+			found = true
+			assert.Equal(t, "profile", remote.Source)
+			assert.Equal(t, "s3", remote.Type)
+		}
+	}
+	assert.True(t, found)
+
+	ci.LowLevelRetries = 7 // changed at runtime, e.g. with options/set
+	require.NoError(t, SetProfile("dev"))
+	assert.Equal(t, "dev", GetValue("data", "bucket"))
+	assert.Equal(t, 8, ci.Checkers, "expecting previous profile flags to be reset")
+	assert.Equal(t, 7, ci.LowLevelRetries, "expecting runtime changes to be kept")
+
+	require.NoError(t, SetProfile("prod"))
+	assert.Equal(t, 3, ci.Checkers)
+	ci.Checkers = 5 // profile flag changed at runtime
+	require.NoError(t, SetProfile("dev"))
+	assert.Equal(t, 5, ci.Checkers, "expecting runtime changes to profile flags to be kept")
+
+	require.NoError(t, SetProfile(""))
+	assert.Equal(t, "", GetValue("data", "bucket"))
+
+	assert.ErrorIs(t, SetProfile("nothere"), fs.ErrorNotFoundInConfigFile)
+}
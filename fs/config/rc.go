@@ -163,6 +163,7 @@ func init() {
     - all - ask all the config questions not just the post config ones
     - state - state to restart with - used with continue
     - result - result to restart with - used with continue
+    - headless - do any OAuth by returning a URL and QR code to visit
 `
 		}
 		rc.Add(rc.Call{
@@ -321,3 +322,96 @@ func rcPaths(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 		"temp":   os.TempDir(),
 	}, nil
 }
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "config/listprofiles",
+		Fn:           rcListProfiles,
+		Title:        "Lists the profiles in the config file.",
+		AuthRequired: true,
+		Help: `
+Returns
+- profiles - array of profile names
+- active - name of the active profile or "" if none
+`,
+	})
+}
+
+// Return the list of profiles and the active profile
+func rcListProfiles(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	profiles := Profiles()
+	if profiles == nil {
+		profiles = []string{}
+	}
+	out = rc.Params{
+		"profiles": profiles,
+		"active":   ActiveProfile(),
+	}
+	return out, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "config/setprofile",
+		Fn:           rcSetProfile,
+		Title:        "Switch the active profile.",
+		AuthRequired: true,
+		Help: `
+Parameters:
+
+- name - name of the profile to activate or "" to deactivate the active profile
+
+This resets any global flags set by the previously active profile,
+applies those of the new profile and discards any cached remotes so
+they are re-created with the new profile's config.
+
+Running jobs continue with the remotes they already have.
+`,
+	})
+}
+
+// Set the active profile
+func rcSetProfile(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	name, err := in.GetString("name")
+	if err != nil {
+		return nil, err
+	}
+	return nil, SetProfile(name)
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "config/reload",
+		Fn:           rcReload,
+		Title:        "Reload the config file.",
+		AuthRequired: true,
+		Help: `
+This reads the config file again so that changes made to it while
+rclone is running, such as rotated credentials, take effect.
+
+Cached remotes made from changed or removed sections, or from sections
+inheriting from them, are discarded so they are re-created with the
+new config when next used. Running jobs and servers continue with the
+remotes they already have - use serve/restart to restart servers with
+the new config.
+
+If the config file can't be read an error is returned and the
+previous config is kept.
+
+Returns
+- added - array of sections new in the config file
+- changed - array of sections whose values changed
+- removed - array of sections no longer in the config file
+`,
+	})
+}
+
+// Reload the config file
+func rcReload(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	result, err := Reload()
+	if err != nil {
+		return nil, err
+	}
+	err = rc.Reshape(&out, result)
+	return out, err
+}
@@ -8,6 +8,7 @@ import (
 
 	_ "github.com/rclone/rclone/backend/local"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
 	"github.com/rclone/rclone/fs/config"
 	"github.com/rclone/rclone/fs/config/configfile"
 	"github.com/rclone/rclone/fs/config/obscure"
@@ -238,3 +239,79 @@ func TestRcConfigUnlock(t *testing.T) {
 	assert.ErrorContains(t, err, `Didn't find key "configPassword" in input`)
 	assert.Nil(t, out)
 }
+
+func TestRcReload(t *testing.T) {
+	ctx := context.Background()
+	oldConfigFile := config.GetConfigPath()
+	defer func() {
+		require.NoError(t, config.SetConfigPath(oldConfigFile))
+	}()
+	configPath := filepath.Join(t.TempDir(), "rclone.conf")
+	writeConfig := func(contents string) {
+		require.NoError(t, os.WriteFile(configPath, []byte(contents), 0600))
+	}
+	writeConfig(`[reloadKeep]
+type = local
+
+[reloadChange]
+type = local
+test_key = potato
+
+[reloadChild]
+inherit = reloadChange
+
+[reloadRemove]
+type = local
+`)
+	require.NoError(t, config.SetConfigPath(configPath))
+	configfile.Install()
+	assert.Equal(t, "potato", config.GetValue("reloadChild", "test_key"))
+
+	// Put remotes in the cache
+	dir := t.TempDir()
+	for _, name := range []string{"reloadKeep", "reloadChange", "reloadChild", "reloadRemove"} {
+		_, err := cache.Get(ctx, name+":"+dir)
+		require.NoError(t, err)
+	}
+	entries := cache.Entries()
+
+	writeConfig(`[reloadKeep]
+type = local
+
+[reloadChange]
+type = local
+test_key = rutabaga
+
+[reloadChild]
+inherit = reloadChange
+
+[reloadAdd]
+type = local
+`)
+	call := rc.Calls.Get("config/reload")
+	assert.NotNil(t, call)
+	out, err := call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"added":   []any{"reloadAdd"},
+		"changed": []any{"reloadChange", "reloadChild"},
+		"removed": []any{"reloadRemove"},
+	}, out)
+	assert.Equal(t, "rutabaga", config.GetValue("reloadChild", "test_key"))
+	assert.Equal(t, entries-3, cache.Entries())
+
+	// Nothing changed since the last reload
+	out, err = call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"added":   []any{},
+		"changed": []any{},
+		"removed": []any{},
+	}, out)
+
+	// Keep the old config if the new one can't be read
+	require.NoError(t, os.Remove(configPath))
+	_, err = call.Fn(ctx, rc.Params{})
+	assert.ErrorIs(t, err, config.ErrorConfigFileNotFound)
+	assert.Equal(t, "rutabaga", config.GetValue("reloadChange", "test_key"))
+}
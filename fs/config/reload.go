@@ -0,0 +1,122 @@
+// Reloading the config file while rclone is running
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+)
+
+var (
+	reloadMu sync.Mutex
+	// config file sections as they were when last loaded or reloaded
+	loadedSections map[string]map[string]string
+)
+
+// ReloadResult describes what changed when the config file was reloaded
+type ReloadResult struct {
+	Added   []string `json:"added"`   // sections new in the config file
+	Changed []string `json:"changed"` // sections whose values changed
+	Removed []string `json:"removed"` // sections no longer in the config file
+}
+
+// readSections reads all the sections of the config file with their
+// values.
+func readSections(data Storage) map[string]map[string]string {
+	sections := map[string]map[string]string{}
+	for _, section := range data.GetSectionList() {
+		values := map[string]string{}
+		for _, key := range data.GetKeyList(section) {
+			values[key], _ = data.GetValue(section, key)
+		}
+		sections[section] = values
+	}
+	return sections
+}
+
+// rememberLoaded records the config file as loaded so Reload can see
+// what changes.
+func rememberLoaded() {
+	reloadMu.Lock()
+	loadedSections = readSections(data)
+	reloadMu.Unlock()
+}
+
+// Reload reads the config file again and returns which sections were
+// added, changed or removed since it was last loaded.
+//
+// Any remotes in the fs cache made from changed or removed sections,
+// or from sections inheriting from them, are discarded so they are
+// re-created with the new config when next used. Remotes already in
+// use carry on with the config they were made with.
+//
+// If the config file can't be read the previous config is kept.
+func Reload() (result ReloadResult, err error) {
+	result = ReloadResult{Added: []string{}, Changed: []string{}, Removed: []string{}}
+	data := LoadedData()
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	oldSections := loadedSections
+	if err := data.Load(); err != nil {
+		return result, fmt.Errorf("failed to reload config file %q: %w", GetConfigPath(), err)
+	}
+	newSections := readSections(data)
+	loadedSections = newSections
+
+	stale := map[string]struct{}{}
+	for section, values := range newSections {
+		oldValues, found := oldSections[section]
+		if !found {
+			result.Added = append(result.Added, section)
+		} else if !maps.Equal(oldValues, values) {
+			result.Changed = append(result.Changed, section)
+			stale[section] = struct{}{}
+		}
+	}
+	for section := range oldSections {
+		if _, found := newSections[section]; !found {
+			result.Removed = append(result.Removed, section)
+			stale[section] = struct{}{}
+		}
+	}
+
+	// Remotes inheriting from a stale section have changed too
+	for section := range newSections {
+		if _, found := stale[section]; found {
+			continue
+		}
+		chain, _ := inheritChain(section)
+		if slices.ContainsFunc(chain, func(parent string) bool {
+			_, found := stale[parent]
+			return found
+		}) {
+			result.Changed = append(result.Changed, section)
+			stale[section] = struct{}{}
+		}
+	}
+
+	// A change to the active profile may remap any remote
+	if profile := ActiveProfile(); profile != "" {
+		if _, found := stale[ProfilePrefix+profile]; found {
+			cache.Clear()
+		}
+	}
+	for section := range stale {
+		if !strings.HasPrefix(section, ProfilePrefix) {
+			cache.ClearConfig(section)
+		}
+	}
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Changed)
+	sort.Strings(result.Removed)
+	fs.Infof(nil, "Reloaded config file %q: %d added, %d changed, %d removed", GetConfigPath(), len(result.Added), len(result.Changed), len(result.Removed))
+	return result, nil
+}
@@ -0,0 +1,346 @@
+// External secret storage for config values
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/fshttp"
+)
+
+// secretTimeout is how long a secret backend may take to return a
+// secret - a var so it can be changed in the tests
+var secretTimeout = time.Minute
+
+// SecretBackend fetches secrets from an external store.
+//
+// A config value of the form "scheme:reference" in a password or
+// sensitive option is passed to the SecretBackend registered under
+// scheme to be resolved when the backend is created.
+type SecretBackend interface {
+	// Get returns the plain text secret for reference
+	Get(ctx context.Context, reference string) (string, error)
+}
+
+// SecretBackendFunc is an adapter to allow the use of ordinary
+// functions as a SecretBackend.
+type SecretBackendFunc func(ctx context.Context, reference string) (string, error)
+
+// Get calls f(ctx, reference)
+func (f SecretBackendFunc) Get(ctx context.Context, reference string) (string, error) {
+	return f(ctx, reference)
+}
+
+var (
+	secretMu       sync.Mutex
+	secretBackends = map[string]SecretBackend{}
+	secretCache    = map[string]string{}
+)
+
+// RegisterSecretBackend registers a SecretBackend under scheme.
+//
+// Registering a scheme twice replaces the previous backend.
+func RegisterSecretBackend(scheme string, backend SecretBackend) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	secretBackends[scheme] = backend
+}
+
+// SecretSchemes returns the sorted names of the registered secret backends
+func SecretSchemes() []string {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	schemes := make([]string, 0, len(secretBackends))
+	for scheme := range secretBackends {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// parseSecretRef splits value into a registered secret backend and
+// its reference, returning a nil backend if value isn't a secret
+// reference.
+func parseSecretRef(value string) (scheme string, backend SecretBackend, reference string) {
+	scheme, reference, found := strings.Cut(value, ":")
+	if !found || reference == "" {
+		return "", nil, ""
+	}
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	backend = secretBackends[scheme]
+	if backend == nil {
+		return "", nil, ""
+	}
+	return scheme, backend, reference
+}
+
+// IsSecretRef returns true if value refers to a registered secret backend
+func IsSecretRef(value string) bool {
+	_, backend, _ := parseSecretRef(value)
+	return backend != nil
+}
+
+// ResolveSecret returns the secret referred to by value.
+//
+// If value isn't a secret reference it is returned unchanged. Secrets
+// are cached for the lifetime of the process. Reading a secret which
+// isn't cached is given secretTimeout to complete.
+func ResolveSecret(ctx context.Context, value string) (string, error) {
+	scheme, backend, reference := parseSecretRef(value)
+	if backend == nil {
+		return value, nil
+	}
+	secretMu.Lock()
+	secret, found := secretCache[value]
+	secretMu.Unlock()
+	if found {
+		return secret, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, secretTimeout)
+	defer cancel()
+	secret, err := backend.Get(ctx, reference)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret from %s: %w", scheme, err)
+	}
+	secretMu.Lock()
+	secretCache[value] = secret
+	secretMu.Unlock()
+	return secret, nil
+}
+
+// secretOption returns the option for key in section if it is one
+// which may hold a secret reference, or nil otherwise.
+func secretOption(section, key string) *fs.Option {
+	typeName, found := inheritedGetValue(section, "type")
+	if !found {
+		return nil
+	}
+	ri, err := fs.Find(typeName)
+	if err != nil {
+		return nil
+	}
+	opt := ri.Options.Get(key)
+	if opt == nil || !(opt.IsPassword || opt.Sensitive) {
+		return nil
+	}
+	return opt
+}
+
+// fileGetValueResolved reads the config key under section like
+// FileGetValue but follows inheritance and resolves any secret
+// references in password and sensitive options.
+//
+// Password options are returned obscured as the backends expect.
+func fileGetValueResolved(section, key string) (string, bool) {
+	value, found := inheritedGetValue(section, key)
+	if !found || !IsSecretRef(value) {
+		return value, found
+	}
+	opt := secretOption(section, key)
+	if opt == nil {
+		return value, found
+	}
+	secret, err := ResolveSecret(context.Background(), value)
+	if err != nil {
+		fs.Errorf(nil, "Failed to resolve %q in section %q of the config file: %v", key, section, err)
+		return "", false
+	}
+	if opt.IsPassword {
+		secret, err = obscure.Obscure(secret)
+		if err != nil {
+			fs.Errorf(nil, "Failed to obscure %q in section %q of the config file: %v", key, section, err)
+			return "", false
+		}
+	}
+	return secret, true
+}
+
+// setValueAndSaveUnlessSecret is SetValueAndSave except that it won't
+// overwrite a secret reference with the value it refers to.
+func setValueAndSaveUnlessSecret(remote, key, value string) error {
+	remote = profileRemote(remote)
+	if oldValue, found := inheritedGetValue(remote, key); found && IsSecretRef(oldValue) {
+		fs.Logf(nil, "Not saving %q in section %q of the config file as it is stored in an external secret backend", key, remote)
+		return nil
+	}
+	return SetValueAndSave(remote, key, value)
+}
+
+// runSecretCommand runs the command and returns its trimmed output
+func runSecretCommand(ctx context.Context, name string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ers := strings.TrimSpace(stderr.String()); ers != "" {
+			return "", fmt.Errorf("%s failed: %w: %s", name, err, ers)
+		}
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+	secret := strings.Trim(stdout.String(), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("%s returned an empty secret", name)
+	}
+	return secret, nil
+}
+
+// jsonField extracts field from a JSON object secret if field is set
+func jsonField(secret, field string) (string, error) {
+	if field == "" {
+		return secret, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object: %w", err)
+	}
+	return stringField(fields, field)
+}
+
+// stringField returns fields[field] as a string
+func stringField(fields map[string]any, field string) (string, error) {
+	value, found := fields[field]
+	if !found {
+		return "", fmt.Errorf("field %q not found in secret", field)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	default:
+		out, err := json.Marshal(v)
+		return string(out), err
+	}
+}
+
+// vaultSecret reads "path#field" from HashiCorp Vault using
+// VAULT_ADDR and VAULT_TOKEN from the environment.
+//
+// Both KV version 1 and version 2 secret engines are supported. For
+// version 2 the path should include the "data/" component.
+//
+// The request is made with the rclone HTTP client so it obeys the
+// TLS, proxy and timeout flags.
+func vaultSecret(ctx context.Context, reference string) (string, error) {
+	path, field, _ := strings.Cut(reference, "#")
+	if field == "" {
+		return "", errors.New("vault reference needs a #field")
+	}
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", errors.New("VAULT_ADDR is not set")
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	resp, err := fshttp.NewClient(ctx).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer fs.CheckClose(resp.Body, &err)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %s for %q", resp.Status, path)
+	}
+	var result struct {
+		Data map[string]any `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+	fields := result.Data
+	// KV version 2 nests the secret inside data.data
+	if inner, ok := fields["data"].(map[string]any); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = inner
+		}
+	}
+	return stringField(fields, field)
+}
+
+// awsSecret reads "secret-id[#field]" from AWS Secrets Manager using the aws CLI
+func awsSecret(ctx context.Context, reference string) (string, error) {
+	id, field, _ := strings.Cut(reference, "#")
+	secret, err := runSecretCommand(ctx, "aws", "secretsmanager", "get-secret-value", "--secret-id", id, "--query", "SecretString", "--output", "text")
+	if err != nil {
+		return "", err
+	}
+	return jsonField(secret, field)
+}
+
+// gcpSecret reads "project/secret[/version][#field]" from Google
+// Cloud Secret Manager using the gcloud CLI
+func gcpSecret(ctx context.Context, reference string) (string, error) {
+	name, field, _ := strings.Cut(reference, "#")
+	parts := strings.Split(name, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", errors.New("gcpsm reference should be project/secret[/version]")
+	}
+	version := "latest"
+	if len(parts) == 3 {
+		version = parts[2]
+	}
+	secret, err := runSecretCommand(ctx, "gcloud", "secrets", "versions", "access", version, "--project", parts[0], "--secret", parts[1])
+	if err != nil {
+		return "", err
+	}
+	return jsonField(secret, field)
+}
+
+// keyringSecret reads "attribute=value,..." from the OS keychain
+// using secret-tool (libsecret) on Linux or security on macOS.
+//
+// On macOS the attributes "service" and "account" are used.
+func keyringSecret(ctx context.Context, reference string) (string, error) {
+	var args []string
+	attrs := map[string]string{}
+	for attr := range strings.SplitSeq(reference, ",") {
+		key, value, found := strings.Cut(attr, "=")
+		if !found {
+			return "", fmt.Errorf("keyring attribute %q should be key=value", attr)
+		}
+		attrs[key] = value
+		args = append(args, key, value)
+	}
+	if _, err := exec.LookPath("secret-tool"); err == nil {
+		return runSecretCommand(ctx, "secret-tool", append([]string{"lookup"}, args...)...)
+	}
+	if _, err := exec.LookPath("security"); err == nil {
+		args = []string{"find-generic-password", "-w"}
+		if service := attrs["service"]; service != "" {
+			args = append(args, "-s", service)
+		}
+		if account := attrs["account"]; account != "" {
+			args = append(args, "-a", account)
+		}
+		return runSecretCommand(ctx, "security", args...)
+	}
+	return "", errors.New("no keyring tool found - install secret-tool (libsecret)")
+}
+
+func init() {
+	RegisterSecretBackend("vault", SecretBackendFunc(vaultSecret))
+	RegisterSecretBackend("awssm", SecretBackendFunc(awsSecret))
+	RegisterSecretBackend("gcpsm", SecretBackendFunc(gcpSecret))
+	RegisterSecretBackend("keyring", SecretBackendFunc(keyringSecret))
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSecret(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	RegisterSecretBackend("test", SecretBackendFunc(func(ctx context.Context, reference string) (string, error) {
+		calls++
+		if reference == "missing" {
+			return "", errors.New("not found")
+		}
+		return "secret-" + reference, nil
+	}))
+	defer func() {
+		secretMu.Lock()
+		delete(secretBackends, "test")
+		secretMu.Unlock()
+	}()
+
+	assert.Contains(t, SecretSchemes(), "test")
+	assert.True(t, IsSecretRef("test:one"))
+	assert.False(t, IsSecretRef("test:"))
+	assert.False(t, IsSecretRef("unknown:one"))
+	assert.False(t, IsSecretRef("plain"))
+
+	got, err := ResolveSecret(ctx, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", got)
+
+	got, err = ResolveSecret(ctx, "test:one")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-one", got)
+	_, err = ResolveSecret(ctx, "test:one")
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls, "expecting secret to be cached")
+
+	_, err = ResolveSecret(ctx, "test:missing")
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestFileGetValueResolved(t *testing.T) {
+	RegisterSecretBackend("test2", SecretBackendFunc(func(ctx context.Context, reference string) (string, error) {
+		return "resolved-" + reference, nil
+	}))
+	defer func() {
+		secretMu.Lock()
+		delete(secretBackends, "test2")
+		secretMu.Unlock()
+	}()
+	backendName := "config_secret_test_remote"
+	if _, err := fs.Find(backendName); err != nil {
+		fs.Register(&fs.RegInfo{
+			Name: backendName,
+			Options: []fs.Option{{
+				Name:       "pass",
+				IsPassword: true,
+			}, {
+				Name:      "token",
+				Sensitive: true,
+			}, {
+				Name: "remote",
+			}},
+		})
+	}
+	oldData := data
+	data = newDefaultStorage()
+	defer func() { data = oldData }()
+	FileSetValue("secret", "type", backendName)
+	FileSetValue("secret", "pass", "test2:pass")
+	FileSetValue("secret", "token", "test2:token")
+	FileSetValue("secret", "remote", "test2:remote")
+
+	got, found := fileGetValueResolved("secret", "pass")
+	assert.True(t, found)
+	assert.Equal(t, "resolved-pass", obscure.MustReveal(got))
+
+	got, found = fileGetValueResolved("secret", "token")
+	assert.True(t, found)
+	assert.Equal(t, "resolved-token", got)
+
+	// Non sensitive options are never resolved
+	got, found = fileGetValueResolved("secret", "remote")
+	assert.True(t, found)
+	assert.Equal(t, "test2:remote", got)
+
+	// Secret references aren't overwritten
+	require.NoError(t, setValueAndSaveUnlessSecret("secret", "token", "new"))
+	got, _ = FileGetValue("secret", "token")
+	assert.Equal(t, "test2:token", got)
+
+	// Nor are they when a backend saves a refreshed token
+	ri, err := fs.Find(backendName)
+	require.NoError(t, err)
+	fs.ConfigMap(ri.Prefix, ri.Options, "secret", nil).Set("token", `{"access_token":"refreshed"}`)
+	got, _ = FileGetValue("secret", "token")
+	assert.Equal(t, "test2:token", got)
+}
+
+func TestVaultSecret(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/rclone/gdrive":
+			_, _ = w.Write([]byte(`{"data":{"data":{"token":"v2"},"metadata":{"version":1}}}`))
+		case "/v1/kv/rclone":
+			_, _ = w.Write([]byte(`{"data":{"pass":"v1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	t.Setenv("VAULT_ADDR", ts.URL)
+	t.Setenv("VAULT_TOKEN", "s.token")
+
+	got, err := vaultSecret(ctx, "secret/data/rclone/gdrive#token")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", got)
+
+	got, err = vaultSecret(ctx, "kv/rclone#pass")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", got)
+
+	_, err = vaultSecret(ctx, "kv/rclone#missing")
+	assert.ErrorContains(t, err, "not found")
+
+	_, err = vaultSecret(ctx, "kv/nothere#pass")
+	assert.ErrorContains(t, err, "404")
+
+	_, err = vaultSecret(ctx, "kv/rclone")
+	assert.ErrorContains(t, err, "#field")
+}
+
+func TestVaultSecretTimeout(t *testing.T) {
+	oldSecretTimeout := secretTimeout
+	secretTimeout = 100 * time.Millisecond
+	defer func() { secretTimeout = oldSecretTimeout }()
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+	t.Setenv("VAULT_ADDR", ts.URL)
+
+	_, err := ResolveSecret(context.Background(), "vault:kv/hang#pass")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
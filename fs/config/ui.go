@@ -334,14 +334,19 @@ func findByName(name string) (*fs.RegInfo, error) {
 	return fs.Find(fsType)
 }
 
-// printRemoteOptions prints the options of the remote
-func printRemoteOptions(name string, prefix string, sep string, redacted bool) {
+// printRemoteOptions prints the options of the remote to out
+func printRemoteOptions(out io.Writer, name string, prefix string, sep string, redacted bool) {
+	printOptions(out, name, LoadedData().GetKeyList(name), prefix, sep, redacted)
+}
+
+// printOptions prints the values of keys for the remote to out
+func printOptions(out io.Writer, name string, keys []string, prefix string, sep string, redacted bool) {
 	fsInfo, err := findByName(name)
 	if err != nil {
-		fmt.Printf("# %v\n", err)
+		_, _ = fmt.Fprintf(out, "# %v\n", err)
 		fsInfo = nil
 	}
-	for _, key := range LoadedData().GetKeyList(name) {
+	for _, key := range keys {
 		isPassword := false
 		isSensitive := false
 		if fsInfo != nil {
@@ -357,30 +362,47 @@ func printRemoteOptions(name string, prefix string, sep string, redacted bool) {
 		}
 		value := GetValue(name, key)
 		if redacted && (isSensitive || isPassword) && value != "" {
-			fmt.Printf("%s%s%sXXX\n", prefix, key, sep)
+			_, _ = fmt.Fprintf(out, "%s%s%sXXX\n", prefix, key, sep)
 		} else if isPassword && value != "" {
-			fmt.Printf("%s%s%s*** ENCRYPTED ***\n", prefix, key, sep)
+			_, _ = fmt.Fprintf(out, "%s%s%s*** ENCRYPTED ***\n", prefix, key, sep)
 		} else {
-			fmt.Printf("%s%s%s%s\n", prefix, key, sep, value)
+			_, _ = fmt.Fprintf(out, "%s%s%s%s\n", prefix, key, sep, value)
 		}
 	}
 }
 
 // listRemoteOptions lists the options of the remote
 func listRemoteOptions(name string) {
-	printRemoteOptions(name, "- ", ": ", false)
+	printRemoteOptions(os.Stdout, name, "- ", ": ", false)
 }
 
 // ShowRemote shows the contents of the remote in config file format
 func ShowRemote(name string) {
 	fmt.Printf("[%s]\n", name)
-	printRemoteOptions(name, "", " = ", false)
+	printRemoteOptions(os.Stdout, name, "", " = ", false)
 }
 
 // ShowRedactedRemote shows the contents of the remote in config file format
 func ShowRedactedRemote(name string) {
+	WriteRedactedRemote(os.Stdout, name)
+}
+
+// WriteRedactedRemote writes the contents of the remote in config
+// file format to out with the sensitive values redacted
+func WriteRedactedRemote(out io.Writer, name string) {
+	_, _ = fmt.Fprintf(out, "[%s]\n", name)
+	printRemoteOptions(out, name, "", " = ", true)
+}
+
+// ShowResolvedRemote shows the effective config of the remote in
+// config file format with any inherited values merged in
+func ShowResolvedRemote(name string) {
 	fmt.Printf("[%s]\n", name)
-	printRemoteOptions(name, "", " = ", true)
+	keys, err := ResolvedKeyList(name)
+	if err != nil {
+		fmt.Printf("# %v\n", err)
+	}
+	printOptions(os.Stdout, name, keys, "", " = ", false)
 }
 
 // OkRemote prints the contents of the remote and ask if it is OK
@@ -699,6 +721,23 @@ func ShowRedactedConfig() {
 	}
 }
 
+// ShowResolvedConfig prints the effective config of every remote
+// with any inherited values merged in
+func ShowResolvedConfig() {
+	remotes := LoadedData().GetSectionList()
+	if len(remotes) == 0 {
+		fmt.Println("; empty config")
+		return
+	}
+	sort.Strings(remotes)
+	for i, remote := range remotes {
+		if i != 0 {
+			fmt.Println()
+		}
+		ShowResolvedRemote(remote)
+	}
+}
+
 // EditConfig edits the config file interactively
 func EditConfig(ctx context.Context) (err error) {
 	for {
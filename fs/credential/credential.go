@@ -0,0 +1,216 @@
+// Package credential keeps track of when the credentials of remotes
+// expire and refreshes them before they do.
+//
+// Backends register the credentials they use, like OAuth tokens or
+// STS credentials. A background process checks them and refreshes
+// them --credential-refresh-before they expire so long running
+// processes like mount and serve don't fail the next operation when
+// the refresh fails. Failures are logged, shown in the
+// credentials/list rc call and sent as a notification.
+package credential
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/notify"
+)
+
+// Kinds of credential
+const (
+	KindOAuth    = "oauth"    // OAuth token
+	KindSTS      = "sts"      // temporary credentials from AWS STS
+	KindSAS      = "sas"      // Azure shared access signature
+	KindSSHCert  = "ssh-cert" // SSH certificate
+	KindKerberos = "kerberos" // Kerberos ticket granting ticket
+)
+
+// Source describes a credential which can expire
+type Source struct {
+	Remote string // name of the remote using the credential
+	Kind   string // kind of credential, eg KindOAuth
+
+	// Expiry reads when the credential expires, or the zero time if
+	// it doesn't.
+	Expiry func(ctx context.Context) (time.Time, error)
+
+	// Refresh gets a new credential. Leave it nil if the credential
+	// can't be refreshed.
+	Refresh func(ctx context.Context) error
+}
+
+// entry is a registered credential
+type entry struct {
+	src         Source
+	expiry      time.Time // when the credential expires
+	lastCheck   time.Time // when the credential was last checked
+	lastRefresh time.Time // when the credential was last refreshed
+	lastError   error     // error from the last check or refresh
+}
+
+type key struct {
+	remote string
+	kind   string
+}
+
+// checkInterval is how often the credentials are checked
+var checkInterval = time.Minute
+
+// Globals
+var (
+	mu      sync.Mutex
+	entries = map[key]*entry{}
+	started bool // set if the background checker is running
+)
+
+// Register src to be checked and refreshed, replacing any credential
+// with the same Remote and Kind.
+//
+// The background checker is started if it isn't running and
+// --credential-refresh-before is set.
+func Register(ctx context.Context, src Source) {
+	mu.Lock()
+	defer mu.Unlock()
+	entries[key{src.Remote, src.Kind}] = &entry{src: src}
+	if !started && fs.GetConfig(ctx).CredentialRefreshBefore > 0 {
+		started = true
+		go checker()
+	}
+}
+
+// Unregister the credential of kind for remote
+func Unregister(remote, kind string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(entries, key{remote, kind})
+}
+
+// checker checks the credentials every checkInterval
+func checker() {
+	ctx := context.Background()
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		checkAll(ctx, time.Now())
+		<-ticker.C
+	}
+}
+
+// list returns the registered credentials sorted by remote and kind
+func list() []*entry {
+	mu.Lock()
+	defer mu.Unlock()
+	out := make([]*entry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].src.Remote != out[j].src.Remote {
+			return out[i].src.Remote < out[j].src.Remote
+		}
+		return out[i].src.Kind < out[j].src.Kind
+	})
+	return out
+}
+
+// checkAll checks all the credentials at time now
+func checkAll(ctx context.Context, now time.Time) {
+	before := time.Duration(fs.GetConfig(ctx).CredentialRefreshBefore)
+	if before <= 0 {
+		return
+	}
+	for _, e := range list() {
+		e.check(ctx, now, before, false)
+	}
+}
+
+// check the credential refreshing it if it expires within before of
+// now, or always if force is set.
+//
+// Errors are logged and notified when they first happen and returned.
+func (e *entry) check(ctx context.Context, now time.Time, before time.Duration, force bool) error {
+	src := e.src
+	expiry, err := src.Expiry(ctx)
+	var refreshed bool
+	if err != nil {
+		err = fmt.Errorf("failed to read expiry: %w", err)
+	} else if force || (!expiry.IsZero() && expiry.Sub(now) < before) {
+		switch {
+		case src.Refresh == nil && !expiry.IsZero() && !expiry.After(now):
+			err = fmt.Errorf("expired at %v and can't be refreshed", expiry.Format(time.RFC3339))
+		case src.Refresh == nil && !expiry.IsZero():
+			err = fmt.Errorf("expires at %v and can't be refreshed", expiry.Format(time.RFC3339))
+		case src.Refresh == nil:
+			err = errors.New("can't be refreshed")
+		default:
+			fs.Debugf(src.Remote, "Refreshing %s credential which expires at %v", src.Kind, expiry.Format(time.RFC3339))
+			err = src.Refresh(ctx)
+			if err != nil {
+				err = fmt.Errorf("failed to refresh: %w", err)
+			} else {
+				refreshed = true
+				expiry, err = src.Expiry(ctx)
+				if err != nil {
+					err = fmt.Errorf("failed to read expiry: %w", err)
+				}
+			}
+		}
+	}
+
+	mu.Lock()
+	prevErr := e.lastError
+	e.lastCheck = now
+	if !expiry.IsZero() || err == nil {
+		e.expiry = expiry
+	}
+	if refreshed {
+		e.lastRefresh = now
+	}
+	e.lastError = err
+	mu.Unlock()
+
+	switch {
+	case err != nil && prevErr == nil:
+		fs.Errorf(src.Remote, "%s credential %v", src.Kind, err)
+		notify.Credential(ctx, src.Remote+":", src.Kind, err)
+	case err == nil && prevErr != nil:
+		fs.Logf(src.Remote, "%s credential is working again", src.Kind)
+	case refreshed:
+		fs.Debugf(src.Remote, "Refreshed %s credential which now expires at %v", src.Kind, expiry.Format(time.RFC3339))
+	}
+	return err
+}
+
+// Info describes a credential for the rc
+type Info struct {
+	Remote      string    `json:"remote"`      // name of the remote
+	Kind        string    `json:"kind"`        // kind of credential, eg "oauth"
+	Refreshable bool      `json:"refreshable"` // set if the credential can be refreshed
+	Expiry      time.Time `json:"expiry"`      // when the credential expires, zero if it doesn't or isn't known yet
+	LastCheck   time.Time `json:"lastCheck"`   // when the credential was last checked
+	LastRefresh time.Time `json:"lastRefresh"` // when the credential was last refreshed
+	LastError   string    `json:"lastError"`   // error from the last check, if any
+}
+
+// info returns the Info for e
+func (e *entry) info() Info {
+	mu.Lock()
+	defer mu.Unlock()
+	info := Info{
+		Remote:      e.src.Remote,
+		Kind:        e.src.Kind,
+		Refreshable: e.src.Refresh != nil,
+		Expiry:      e.expiry,
+		LastCheck:   e.lastCheck,
+		LastRefresh: e.lastRefresh,
+	}
+	if e.lastError != nil {
+		info.LastError = e.lastError.Error()
+	}
+	return info
+}
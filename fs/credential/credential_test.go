@@ -0,0 +1,165 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs/notify"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource is a credential which expires at expiry
+type fakeSource struct {
+	expiry     time.Time
+	refreshErr error
+	refreshes  int
+}
+
+func (s *fakeSource) source(remote string, refreshable bool) Source {
+	src := Source{
+		Remote: remote,
+		Kind:   KindOAuth,
+		Expiry: func(ctx context.Context) (time.Time, error) {
+			return s.expiry, nil
+		},
+	}
+	if refreshable {
+		src.Refresh = func(ctx context.Context) error {
+			s.refreshes++
+			if s.refreshErr != nil {
+				return s.refreshErr
+			}
+			s.expiry = s.expiry.Add(time.Hour)
+			return nil
+		}
+	}
+	return src
+}
+
+// register src for the test
+func register(t *testing.T, src Source) {
+	mu.Lock()
+	entries[key{src.Remote, src.Kind}] = &entry{src: src}
+	mu.Unlock()
+	t.Cleanup(func() {
+		Unregister(src.Remote, src.Kind)
+	})
+}
+
+func TestCheckRefresh(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := &fakeSource{expiry: now.Add(time.Hour)}
+	register(t, s.source("remote", true))
+
+	// Not due for refresh yet
+	checkAll(ctx, now)
+	assert.Equal(t, 0, s.refreshes)
+	infos := list()
+	require.Len(t, infos, 1)
+	info := infos[0].info()
+	assert.Equal(t, now.Add(time.Hour), info.Expiry)
+	assert.Equal(t, now, info.LastCheck)
+	assert.True(t, info.Refreshable)
+
+	// Due for refresh
+	checkAll(ctx, now.Add(56*time.Minute))
+	assert.Equal(t, 1, s.refreshes)
+	info = list()[0].info()
+	assert.Equal(t, now.Add(2*time.Hour), info.Expiry)
+	assert.Equal(t, now.Add(56*time.Minute), info.LastRefresh)
+	assert.Equal(t, "", info.LastError)
+
+	// Refresh fails
+	s.refreshErr = errors.New("potato")
+	checkAll(ctx, now.Add(116*time.Minute))
+	assert.Equal(t, 2, s.refreshes)
+	info = list()[0].info()
+	assert.Equal(t, "failed to refresh: potato", info.LastError)
+	assert.Equal(t, now.Add(2*time.Hour), info.Expiry)
+
+	// And works again
+	s.refreshErr = nil
+	checkAll(ctx, now.Add(117*time.Minute))
+	assert.Equal(t, 3, s.refreshes)
+	assert.Equal(t, "", list()[0].info().LastError)
+}
+
+func TestCheckNotRefreshable(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := &fakeSource{expiry: now.Add(time.Minute)}
+	register(t, s.source("sas", false))
+
+	checkAll(ctx, now)
+	assert.Equal(t, "expires at 2024-01-02T03:05:05Z and can't be refreshed", list()[0].info().LastError)
+	checkAll(ctx, now.Add(time.Hour))
+	assert.Equal(t, "expired at 2024-01-02T03:05:05Z and can't be refreshed", list()[0].info().LastError)
+}
+
+func TestCheckNotify(t *testing.T) {
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies <- body
+	}))
+	defer server.Close()
+	ctx, opt := notify.AddConfig(context.Background())
+	opt.Webhook = server.URL
+	opt.On = notify.EventCredential
+
+	now := time.Now()
+	s := &fakeSource{expiry: now, refreshErr: errors.New("potato")}
+	register(t, s.source("remote", true))
+
+	// Only the first failure is notified
+	checkAll(ctx, now)
+	checkAll(ctx, now)
+	require.Len(t, bodies, 1)
+	var ev notify.Event
+	require.NoError(t, json.Unmarshal(<-bodies, &ev))
+	assert.Equal(t, "credential", ev.Event)
+	assert.Equal(t, "oauth credential", ev.Job)
+	assert.Equal(t, "remote:", ev.Dst)
+	assert.Equal(t, "failed to refresh: potato", ev.Error)
+}
+
+func TestRc(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := &fakeSource{expiry: now.Add(time.Hour)}
+	register(t, s.source("remote", true))
+
+	call := rc.Calls.Get("credentials/list")
+	require.NotNil(t, call)
+	out, err := call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	infos := out["credentials"].([]Info)
+	require.Len(t, infos, 1)
+	assert.Equal(t, "remote", infos[0].Remote)
+	assert.Equal(t, KindOAuth, infos[0].Kind)
+
+	call = rc.Calls.Get("credentials/refresh")
+	require.NotNil(t, call)
+	_, err = call.Fn(ctx, rc.Params{"remote": "notfound"})
+	assert.ErrorContains(t, err, "no credentials found")
+	out, err = call.Fn(ctx, rc.Params{"remote": "remote"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.refreshes)
+	infos = out["credentials"].([]Info)
+	require.Len(t, infos, 1)
+	assert.Equal(t, now.Add(2*time.Hour), infos[0].Expiry)
+
+	s.refreshErr = errors.New("potato")
+	_, err = call.Fn(ctx, rc.Params{"remote": "remote", "kind": KindOAuth})
+	assert.ErrorContains(t, err, "potato")
+}
@@ -0,0 +1,105 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/lib/errcount"
+)
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "credentials/list",
+		AuthRequired: true,
+		Fn:           rcList,
+		Title:        "Show the credentials in use and when they expire.",
+		Help: `
+This shows the credentials of the remotes in use which can expire,
+like OAuth tokens, AWS STS credentials, Azure SAS URLs and SSH
+certificates.
+
+Credentials which can be refreshed are refreshed
+--credential-refresh-before they expire. If that fails or a
+credential which can't be refreshed is about to expire the error is
+logged, shown here and sent as a notification if --notify-on includes
+credential.
+
+This takes no parameters and returns
+
+- credentials - array of objects with
+    - remote - name of the remote, e.g. "drive"
+    - kind - "oauth", "sts", "sas", "ssh-cert" or "kerberos"
+    - refreshable - true if rclone can refresh the credential
+    - expiry - when the credential expires, if known
+    - lastCheck - when the credential was last checked
+    - lastRefresh - when the credential was last refreshed
+    - lastError - the error from the last check or refresh, if any
+`,
+	})
+	rc.Add(rc.Call{
+		Path:         "credentials/refresh",
+		AuthRequired: true,
+		Fn:           rcRefresh,
+		Title:        "Refresh the credentials of a remote now.",
+		Help: `
+This refreshes the credentials of a remote now, even if they aren't
+about to expire, and returns them as in credentials/list.
+
+This takes the following parameters:
+
+- remote - name of the remote, e.g. "drive"
+- kind - optional kind of credential to refresh, e.g. "oauth"
+
+Eg
+
+    rclone rc credentials/refresh remote=drive
+`,
+	})
+}
+
+// rcList returns the registered credentials
+func rcList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	infos := []Info{}
+	for _, e := range list() {
+		infos = append(infos, e.info())
+	}
+	return rc.Params{
+		"credentials": infos,
+	}, nil
+}
+
+// rcRefresh refreshes the credentials of a remote
+func rcRefresh(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	remote, err := in.GetString("remote")
+	if err != nil {
+		return nil, err
+	}
+	kind, err := in.GetString("kind")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	ec := errcount.New()
+	infos := []Info{}
+	before := time.Duration(fs.GetConfig(ctx).CredentialRefreshBefore)
+	for _, e := range list() {
+		if e.src.Remote != remote || (kind != "" && e.src.Kind != kind) {
+			continue
+		}
+		ec.Add(e.check(ctx, time.Now(), before, true))
+		infos = append(infos, e.info())
+	}
+	if len(infos) == 0 {
+		return nil, errors.New("no credentials found")
+	}
+	err = ec.Err(fmt.Sprintf("failed to refresh credentials of %q", remote))
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{
+		"credentials": infos,
+	}, nil
+}
@@ -12,3 +12,22 @@ const (
 	DeleteModeOnly
 	DeleteModeDefault = DeleteModeAfter
 )
+
+type deleteActionChoices struct{}
+
+func (deleteActionChoices) Choices() []string {
+	return []string{
+		DeleteActionDelete: "delete",
+		DeleteActionMarker: "marker",
+	}
+}
+
+// DeleteAction describes what is done to the files deleted from the
+// destination of a sync
+type DeleteAction = Enum[deleteActionChoices]
+
+// DeleteAction constants
+const (
+	DeleteActionDelete DeleteAction = iota // remove the file
+	DeleteActionMarker                     // add a delete marker keeping the history of the file
+)
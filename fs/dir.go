@@ -14,6 +14,7 @@ type Dir struct {
 	items   int64     // number of objects or -1 for unknown
 	id      string    // optional ID
 	parent  string    // optional parent directory ID
+	token   string    // optional change token
 }
 
 // NewDir creates an unspecialized Directory object
@@ -31,7 +32,7 @@ func NewDir(remote string, modTime time.Time) *Dir {
 
 // NewDirCopy creates an unspecialized copy of the Directory object passed in
 func NewDirCopy(ctx context.Context, d Directory) *Dir {
-	return &Dir{
+	newDir := &Dir{
 		f:       d.Fs(),
 		remote:  d.Remote(),
 		modTime: d.ModTime(ctx),
@@ -39,6 +40,10 @@ func NewDirCopy(ctx context.Context, d Directory) *Dir {
 		items:   d.Items(),
 		id:      d.ID(),
 	}
+	if do, ok := d.(DirChangeTokener); ok {
+		newDir.token = do.ChangeToken()
+	}
+	return newDir
 }
 
 // Fs returns the Fs that this directory is part of
@@ -73,6 +78,18 @@ func (d *Dir) SetID(id string) *Dir {
 	return d
 }
 
+// ChangeToken returns the change token of the directory or "" if
+// not known
+func (d *Dir) ChangeToken() string {
+	return d.token
+}
+
+// SetChangeToken sets the change token of the directory
+func (d *Dir) SetChangeToken(token string) *Dir {
+	d.token = token
+	return d
+}
+
 // ParentID returns the IDs of the Dir parent if known
 func (d *Dir) ParentID() string {
 	return d.parent
@@ -120,6 +137,7 @@ func (d *Dir) SetItems(items int64) *Dir {
 
 // Check interfaces
 var (
-	_ DirEntry  = (*Dir)(nil)
-	_ Directory = (*Dir)(nil)
+	_ DirEntry         = (*Dir)(nil)
+	_ Directory        = (*Dir)(nil)
+	_ DirChangeTokener = (*Dir)(nil)
 )
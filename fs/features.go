@@ -35,11 +35,14 @@ type Features struct {
 	UserDirMetadata          bool // can read/write general purpose metadata to/from directories
 	DirModTimeUpdatesOnWrite bool // indicate writing files to a directory updates its modtime
 	FilterAware              bool // can make use of filters if provided for listing
+	ListQuery                bool // List can be constrained with a backend specific query from filter.SetListQuery
 	PartialUploads           bool // uploaded file can appear incomplete on the fs while it's being uploaded
 	NoMultiThreading         bool // set if can't have multiplethreads on one download open
 	Overlay                  bool // this wraps one or more backends to add functionality
 	ChunkWriterDoesntSeek    bool // set if the chunk writer doesn't need to read the data more than once
 	DoubleSlash              bool // set if backend supports double slashes in paths
+	NativeTrash              bool // set if deleted files go to a trash they can be restored from
+	PublicLinkOptions        bool // PublicLink obeys the LinkOptions in the context
 
 	// Purge all files in the directory specified
 	//
@@ -135,6 +138,57 @@ type Features struct {
 	// DirSetModTime sets the metadata on the directory to set the modification date
 	DirSetModTime func(ctx context.Context, dir string, modTime time.Time) error
 
+	// SetTierBatch changes the storage tier or class of all the
+	// objects passed in using as few API calls as possible.
+	//
+	// It returns an error for each object which is nil if the tier
+	// was changed, or an error if the whole batch failed.
+	SetTierBatch func(ctx context.Context, tier string, objs []Object) (errs []error, err error)
+
+	// DeleteObjects deletes all the objects passed in using as few
+	// API calls as possible.
+	//
+	// It returns an error for each object which is nil if the
+	// object was deleted, or an error if the whole batch failed.
+	DeleteObjects func(ctx context.Context, objs []Object) (errs []error, err error)
+
+	// ChangedPaths returns the paths which changed since the last
+	// successful sync from this Fs to the destination identified
+	// by key.
+	//
+	// If it isn't known which paths changed it returns an error
+	// and all the paths must be checked.
+	//
+	// commit should be called after a successful sync to record
+	// the changes as seen. It may be nil.
+	ChangedPaths func(ctx context.Context, key string) (paths []string, commit func() error, err error)
+
+	// ListDeleted calls fn for each deleted file in dir and its
+	// subdirectories which can be restored with Undelete.
+	//
+	// dir should be "" to start from the root, and should not
+	// have trailing slashes.
+	ListDeleted func(ctx context.Context, dir string, fn func(DeletedObject) error) error
+
+	// Undelete restores a deleted file returned by ListDeleted.
+	//
+	// It returns ErrorCantUndelete if there is a file at that
+	// path already.
+	Undelete func(ctx context.Context, deleted DeletedObject) error
+
+	// SoftDelete removes o from the current view of the Fs while
+	// keeping its history, for example by adding a delete marker,
+	// so it can be restored with Undelete.
+	//
+	// It returns an error if the history can't be kept, for
+	// example if versioning isn't enabled.
+	SoftDelete func(ctx context.Context, o Object) error
+
+	// PurgeDeleted permanently deletes a deleted file returned by
+	// ListDeleted along with any delete marker and the old
+	// versions it hides.
+	PurgeDeleted func(ctx context.Context, deleted DeletedObject) error
+
 	// CleanUp the trash in the Fs
 	//
 	// Implement this if you have a way of emptying the trash or
@@ -184,6 +238,11 @@ type Features struct {
 	// It truncates any existing object
 	OpenWriterAt func(ctx context.Context, remote string, size int64) (WriterAtCloser, error)
 
+	// ResumeWriterAt opens with a handle for random access writes
+	// keeping the data already in any existing object so an
+	// interrupted transfer can be carried on with.
+	ResumeWriterAt func(ctx context.Context, remote string) (WriterAtCloser, error)
+
 	// OpenChunkWriter returns the chunk size and a ChunkWriter
 	//
 	// Pass in the remote and the src object
@@ -191,6 +250,11 @@ type Features struct {
 	//
 	OpenChunkWriter func(ctx context.Context, remote string, src ObjectInfo, options ...OpenOption) (info ChunkWriterInfo, writer ChunkWriter, err error)
 
+	// ResumeChunkWriter carries on with an upload started with
+	// OpenChunkWriter from the state returned by the
+	// ChunkWriterResumer.
+	ResumeChunkWriter func(ctx context.Context, remote string, src ObjectInfo, state []byte, options ...OpenOption) (info ChunkWriterInfo, writer ChunkWriter, err error)
+
 	// UserInfo returns info about the connected user
 	UserInfo func(ctx context.Context) (map[string]string, error)
 
@@ -336,6 +400,25 @@ func (ft *Features) Fill(ctx context.Context, f Fs) *Features {
 	if do, ok := f.(DirSetModTimer); ok {
 		ft.DirSetModTime = do.DirSetModTime
 	}
+	if do, ok := f.(SetTierBatcher); ok {
+		ft.SetTierBatch = do.SetTierBatch
+	}
+	if do, ok := f.(BatchDeleter); ok {
+		ft.DeleteObjects = do.DeleteObjects
+	}
+	if do, ok := f.(ChangedPathser); ok {
+		ft.ChangedPaths = do.ChangedPaths
+	}
+	if do, ok := f.(Undeleter); ok {
+		ft.ListDeleted = do.ListDeleted
+		ft.Undelete = do.Undelete
+	}
+	if do, ok := f.(SoftDeleter); ok {
+		ft.SoftDelete = do.SoftDelete
+	}
+	if do, ok := f.(DeletedPurger); ok {
+		ft.PurgeDeleted = do.PurgeDeleted
+	}
 	if do, ok := f.(CleanUpper); ok {
 		ft.CleanUp = do.CleanUp
 	}
@@ -351,9 +434,15 @@ func (ft *Features) Fill(ctx context.Context, f Fs) *Features {
 	if do, ok := f.(OpenWriterAter); ok {
 		ft.OpenWriterAt = do.OpenWriterAt
 	}
+	if do, ok := f.(ResumeWriterAter); ok {
+		ft.ResumeWriterAt = do.ResumeWriterAt
+	}
 	if do, ok := f.(OpenChunkWriter); ok {
 		ft.OpenChunkWriter = do.OpenChunkWriter
 	}
+	if do, ok := f.(ResumeChunkWriter); ok {
+		ft.ResumeChunkWriter = do.ResumeChunkWriter
+	}
 	if do, ok := f.(UserInfoer); ok {
 		ft.UserInfo = do.UserInfo
 	}
@@ -399,11 +488,14 @@ func (ft *Features) Mask(ctx context.Context, f Fs) *Features {
 	ft.SlowModTime = ft.SlowModTime && mask.SlowModTime
 	ft.SlowHash = ft.SlowHash && mask.SlowHash
 	ft.FilterAware = ft.FilterAware && mask.FilterAware
+	ft.ListQuery = ft.ListQuery && mask.ListQuery
 	ft.PartialUploads = ft.PartialUploads && mask.PartialUploads
 	ft.NoMultiThreading = ft.NoMultiThreading && mask.NoMultiThreading
 	// ft.Overlay = ft.Overlay && mask.Overlay don't propagate Overlay
 	ft.ChunkWriterDoesntSeek = ft.ChunkWriterDoesntSeek && mask.ChunkWriterDoesntSeek
 	ft.DoubleSlash = ft.DoubleSlash && mask.DoubleSlash
+	ft.NativeTrash = ft.NativeTrash && mask.NativeTrash
+	ft.PublicLinkOptions = ft.PublicLinkOptions && mask.PublicLinkOptions
 
 	if mask.Purge == nil {
 		ft.Purge = nil
@@ -447,6 +539,27 @@ func (ft *Features) Mask(ctx context.Context, f Fs) *Features {
 	if mask.DirSetModTime == nil {
 		ft.DirSetModTime = nil
 	}
+	if mask.SetTierBatch == nil {
+		ft.SetTierBatch = nil
+	}
+	if mask.DeleteObjects == nil {
+		ft.DeleteObjects = nil
+	}
+	if mask.ChangedPaths == nil {
+		ft.ChangedPaths = nil
+	}
+	if mask.ListDeleted == nil {
+		ft.ListDeleted = nil
+	}
+	if mask.Undelete == nil {
+		ft.Undelete = nil
+	}
+	if mask.SoftDelete == nil {
+		ft.SoftDelete = nil
+	}
+	if mask.PurgeDeleted == nil {
+		ft.PurgeDeleted = nil
+	}
 	if mask.CleanUp == nil {
 		ft.CleanUp = nil
 	}
@@ -462,9 +575,15 @@ func (ft *Features) Mask(ctx context.Context, f Fs) *Features {
 	if mask.OpenWriterAt == nil {
 		ft.OpenWriterAt = nil
 	}
+	if mask.ResumeWriterAt == nil {
+		ft.ResumeWriterAt = nil
+	}
 	if mask.OpenChunkWriter == nil {
 		ft.OpenChunkWriter = nil
 	}
+	if mask.ResumeChunkWriter == nil {
+		ft.ResumeChunkWriter = nil
+	}
 	if mask.UserInfo == nil {
 		ft.UserInfo = nil
 	}
@@ -654,6 +773,75 @@ type DirSetModTimer interface {
 	DirSetModTime(ctx context.Context, dir string, modTime time.Time) error
 }
 
+// SetTierBatcher is an optional interface for Fs
+type SetTierBatcher interface {
+	// SetTierBatch changes the storage tier or class of all the
+	// objects passed in using as few API calls as possible.
+	//
+	// It returns an error for each object which is nil if the tier
+	// was changed, or an error if the whole batch failed.
+	SetTierBatch(ctx context.Context, tier string, objs []Object) (errs []error, err error)
+}
+
+// BatchDeleter is an optional interface for Fs
+type BatchDeleter interface {
+	// DeleteObjects deletes all the objects passed in using as few
+	// API calls as possible.
+	//
+	// It returns an error for each object which is nil if the
+	// object was deleted, or an error if the whole batch failed.
+	DeleteObjects(ctx context.Context, objs []Object) (errs []error, err error)
+}
+
+// ChangedPathser is an optional interface for Fs
+type ChangedPathser interface {
+	// ChangedPaths returns the paths which changed since the last
+	// successful sync from this Fs to the destination identified
+	// by key.
+	//
+	// If it isn't known which paths changed it returns an error
+	// and all the paths must be checked.
+	//
+	// commit should be called after a successful sync to record
+	// the changes as seen. It may be nil.
+	ChangedPaths(ctx context.Context, key string) (paths []string, commit func() error, err error)
+}
+
+// Undeleter is an optional interface for Fs
+type Undeleter interface {
+	// ListDeleted calls fn for each deleted file in dir and its
+	// subdirectories which can be restored with Undelete.
+	//
+	// dir should be "" to start from the root, and should not
+	// have trailing slashes.
+	ListDeleted(ctx context.Context, dir string, fn func(DeletedObject) error) error
+
+	// Undelete restores a deleted file returned by ListDeleted.
+	//
+	// It returns ErrorCantUndelete if there is a file at that
+	// path already.
+	Undelete(ctx context.Context, deleted DeletedObject) error
+}
+
+// SoftDeleter is an optional interface for Fs
+type SoftDeleter interface {
+	// SoftDelete removes o from the current view of the Fs while
+	// keeping its history, for example by adding a delete marker,
+	// so it can be restored with Undelete.
+	//
+	// It returns an error if the history can't be kept, for
+	// example if versioning isn't enabled.
+	SoftDelete(ctx context.Context, o Object) error
+}
+
+// DeletedPurger is an optional interface for Fs
+type DeletedPurger interface {
+	// PurgeDeleted permanently deletes a deleted file returned by
+	// ListDeleted along with any delete marker and the old
+	// versions it hides.
+	PurgeDeleted(ctx context.Context, deleted DeletedObject) error
+}
+
 // CleanUpper is an optional interfaces for Fs
 type CleanUpper interface {
 	// CleanUp the trash in the Fs
@@ -734,6 +922,14 @@ type OpenWriterAter interface {
 // OpenWriterAtFn describes the OpenWriterAt function pointer
 type OpenWriterAtFn func(ctx context.Context, remote string, size int64) (WriterAtCloser, error)
 
+// ResumeWriterAter is an optional interface for Fs
+type ResumeWriterAter interface {
+	// ResumeWriterAt opens with a handle for random access writes
+	// without truncating any existing object, creating it if
+	// necessary.
+	ResumeWriterAt(ctx context.Context, remote string) (WriterAtCloser, error)
+}
+
 // ChunkWriterInfo describes how a backend would like ChunkWriter called
 type ChunkWriterInfo struct {
 	ChunkSize         int64 // preferred chunk size
@@ -767,6 +963,28 @@ type ChunkWriter interface {
 	Abort(ctx context.Context) error
 }
 
+// ChunkWriterResumer is an optional interface for a ChunkWriter
+// whose upload can be carried on with after rclone is restarted
+type ChunkWriterResumer interface {
+	// ResumeState returns the state needed to carry on with the
+	// upload, including the chunks written so far, which is passed
+	// to ResumeChunkWriter.
+	//
+	// It may be called concurrently with WriteChunk.
+	ResumeState() (state []byte, err error)
+}
+
+// ResumeChunkWriter is an optional interface for Fs to carry on with
+// an upload started with OpenChunkWriter
+type ResumeChunkWriter interface {
+	// ResumeChunkWriter returns a ChunkWriter carrying on with the
+	// upload of remote from the state returned by ResumeState.
+	//
+	// It should return an error if the upload can't be resumed,
+	// for example if it has expired on the server.
+	ResumeChunkWriter(ctx context.Context, remote string, src ObjectInfo, state []byte, options ...OpenOption) (info ChunkWriterInfo, writer ChunkWriter, err error)
+}
+
 // UserInfoer is an optional interface for Fs
 type UserInfoer interface {
 	// UserInfo returns info about the connected user
@@ -0,0 +1,218 @@
+// Filters based on the content of objects
+
+package filter
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// hashList holds the hashes read from --include-hashes-from and
+// --exclude-hashes-from
+type hashList struct {
+	ht      hash.Type
+	include map[string]struct{} // nil if not in use
+	exclude map[string]struct{} // nil if not in use
+}
+
+// detectHashType guesses the hash type from the length of a hex hash
+func detectHashType(hexHash string) (hash.Type, error) {
+	switch len(hexHash) {
+	case 32:
+		return hash.MD5, nil
+	case 40:
+		return hash.SHA1, nil
+	case 64:
+		return hash.SHA256, nil
+	}
+	return hash.None, fmt.Errorf("filter: can't detect hash type of %q - use --hash-list-type", hexHash)
+}
+
+// read the hashes from the files into a set
+//
+// Each line should start with the hash, optionally followed by
+// whitespace and a file name as written by md5sum, sha1sum, etc.
+func (hl *hashList) read(files []string) (hashes map[string]struct{}, err error) {
+	hashes = make(map[string]struct{})
+	for _, file := range files {
+		err = forEachLine(file, false, func(line string) error {
+			hexHash := strings.ToLower(strings.Fields(line)[0])
+			if hl.ht == hash.None {
+				hl.ht, err = detectHashType(hexHash)
+				if err != nil {
+					return err
+				}
+			}
+			hashes[hexHash] = struct{}{}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+// parse the hash list options
+func (hl *hashList) parse(hashType string, includeFiles, excludeFiles []string) (err error) {
+	if len(includeFiles) == 0 && len(excludeFiles) == 0 {
+		return nil
+	}
+	if hashType != "" {
+		if err = hl.ht.Set(hashType); err != nil {
+			return fmt.Errorf("filter: --hash-list-type: %w", err)
+		}
+	}
+	if len(includeFiles) > 0 {
+		if hl.include, err = hl.read(includeFiles); err != nil {
+			return err
+		}
+	}
+	if len(excludeFiles) > 0 {
+		if hl.exclude, err = hl.read(excludeFiles); err != nil {
+			return err
+		}
+	}
+	if hl.ht == hash.None {
+		return fmt.Errorf("filter: no hashes found in hash lists - use --hash-list-type to set the type")
+	}
+	return nil
+}
+
+// inUse returns true if any hash lists were supplied
+func (hl *hashList) inUse() bool {
+	return hl.include != nil || hl.exclude != nil
+}
+
+// tagRule is a parsed --filter-tag
+type tagRule struct {
+	key      string
+	value    string
+	hasValue bool // if not set match any value
+}
+
+// parseTagRules parses the --filter-tag options
+func parseTagRules(in []string) (tagRules []tagRule, err error) {
+	for _, s := range in {
+		var r tagRule
+		r.key, r.value, r.hasValue, err = fs.ParseTag(s)
+		if err != nil {
+			return nil, fmt.Errorf("filter: bad --filter-tag: %w", err)
+		}
+		tagRules = append(tagRules, r)
+	}
+	return tagRules, nil
+}
+
+// matchTags returns true if tags satisfy all the rules
+func matchTags(tagRules []tagRule, tags fs.Tags) bool {
+	for _, r := range tagRules {
+		value, found := tags[r.key]
+		if !found || (r.hasValue && value != r.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// usesContentFilters returns true if any filters need to look at the
+// object rather than just its name, size and time
+func (f *Filter) usesContentFilters() bool {
+	return len(f.Opt.IncludeMime) > 0 || len(f.Opt.ExcludeMime) > 0 || f.hashList.inUse() || len(f.tags) > 0
+}
+
+// matchMime returns true if mimeType matches any of the patterns
+func matchMime(patterns []string, mimeType string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), mimeType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// includeContent returns whether the object passes the MIME type, tag
+// and hash list filters, logging the reason if not.
+func (f *Filter) includeContent(ctx context.Context, o fs.Object) bool {
+	// filesFrom takes precedence
+	if f.files != nil || !f.usesContentFilters() {
+		return true
+	}
+	if len(f.Opt.IncludeMime) > 0 || len(f.Opt.ExcludeMime) > 0 {
+		mimeType, _, err := mime.ParseMediaType(fs.MimeType(ctx, o))
+		if err != nil {
+			mimeType = ""
+		}
+		if matchMime(f.Opt.ExcludeMime, mimeType) {
+			fs.Debugf(o, "Excluded (MIME type Filter)")
+			return false
+		}
+		if len(f.Opt.IncludeMime) > 0 && !matchMime(f.Opt.IncludeMime, mimeType) {
+			fs.Debugf(o, "Excluded (MIME type Filter)")
+			return false
+		}
+	}
+	if len(f.tags) > 0 {
+		tags, err := fs.GetTags(ctx, o)
+		if err != nil {
+			fs.Errorf(o, "Failed to read tags: %v", err)
+		}
+		if !matchTags(f.tags, tags) {
+			fs.Debugf(o, "Excluded (Tag Filter)")
+			return false
+		}
+	}
+	if f.hashList.inUse() {
+		sum, err := o.Hash(ctx, f.hashList.ht)
+		if err != nil || sum == "" {
+			// Can't tell so only include if not restricted to a list
+			if f.hashList.include != nil {
+				fs.Debugf(o, "Excluded (Hash list Filter): can't read %v hash: %v", f.hashList.ht, err)
+				return false
+			}
+			return true
+		}
+		sum = strings.ToLower(sum)
+		if _, found := f.hashList.exclude[sum]; found {
+			fs.Debugf(o, "Excluded (Hash list Filter)")
+			return false
+		}
+		if f.hashList.include != nil {
+			if _, found := f.hashList.include[sum]; !found {
+				fs.Debugf(o, "Excluded (Hash list Filter)")
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dumpContentFilters returns the content filters in textual form
+func (f *Filter) dumpContentFilters() (rules []string) {
+	for _, pattern := range f.Opt.IncludeMime {
+		rules = append(rules, fmt.Sprintf("MIME type must match: %s", pattern))
+	}
+	for _, pattern := range f.Opt.ExcludeMime {
+		rules = append(rules, fmt.Sprintf("MIME type must not match: %s", pattern))
+	}
+	for _, r := range f.tags {
+		if r.hasValue {
+			rules = append(rules, fmt.Sprintf("Tag must be set: %s=%s", r.key, r.value))
+		} else {
+			rules = append(rules, fmt.Sprintf("Tag must be present: %s", r.key))
+		}
+	}
+	if f.hashList.include != nil {
+		rules = append(rules, fmt.Sprintf("%v hash must be one of %d hashes", f.hashList.ht, len(f.hashList.include)))
+	}
+	if f.hashList.exclude != nil {
+		rules = append(rules, fmt.Sprintf("%v hash must not be one of %d hashes", f.hashList.ht, len(f.hashList.exclude)))
+	}
+	return rules
+}
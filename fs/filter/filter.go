@@ -35,6 +35,16 @@ var OptionsInfo = fs.Options{{
 	Default: []string{},
 	Help:    "Exclude directories if filename is present",
 	Groups:  "Filter",
+}, {
+	Name:    "use_rcloneignore",
+	Default: false,
+	Help:    "Read gitignore style exclude rules from .rcloneignore files in each source directory",
+	Groups:  "Filter",
+}, {
+	Name:    "marker_file",
+	Default: []string{},
+	Help:    "Marker file rule NAME[,action=exclude|include|filter][,min-depth=N][,max-depth=N]",
+	Groups:  "Filter",
 }, {
 	Name:    "files_from",
 	Default: []string{},
@@ -55,6 +65,26 @@ var OptionsInfo = fs.Options{{
 	Default: fs.DurationOff,
 	Help:    "Only transfer files younger than this in s or suffix ms|s|m|h|d|w|M|y",
 	Groups:  "Filter",
+}, {
+	Name:    "modified",
+	Default: "",
+	Help:    "Only transfer files modified in the time window, e.g. \"between 2024-01-01 and 2024-03-01 in Europe/Oslo\"",
+	Groups:  "Filter",
+}, {
+	Name:    "min_age_dst",
+	Default: fs.DurationOff,
+	Help:    "Only consider destination files older than this in s or suffix ms|s|m|h|d|w|M|y",
+	Groups:  "Filter",
+}, {
+	Name:    "max_age_dst",
+	Default: fs.DurationOff,
+	Help:    "Only consider destination files younger than this in s or suffix ms|s|m|h|d|w|M|y",
+	Groups:  "Filter",
+}, {
+	Name:    "modified_dst",
+	Default: "",
+	Help:    "Only consider destination files modified in the time window, e.g. \"after 30d ago\"",
+	Groups:  "Filter",
 }, {
 	Name:    "min_size",
 	Default: fs.SizeSuffix(-1),
@@ -75,6 +105,36 @@ var OptionsInfo = fs.Options{{
 	Default: "",
 	Help:    "Partition filenames by hash k/n or randomly @/n",
 	Groups:  "Filter",
+}, {
+	Name:    "include_mime_type",
+	Default: []string{},
+	Help:    "Include files with MIME type matching pattern, e.g. image/*",
+	Groups:  "Filter",
+}, {
+	Name:    "exclude_mime_type",
+	Default: []string{},
+	Help:    "Exclude files with MIME type matching pattern, e.g. video/*",
+	Groups:  "Filter",
+}, {
+	Name:    "include_hashes_from",
+	Default: []string{},
+	Help:    "Only include files whose hash is listed in file (use - to read from stdin)",
+	Groups:  "Filter",
+}, {
+	Name:    "exclude_hashes_from",
+	Default: []string{},
+	Help:    "Exclude files whose hash is listed in file (use - to read from stdin)",
+	Groups:  "Filter",
+}, {
+	Name:    "hash_list_type",
+	Default: "",
+	Help:    "Hash type of the --include/exclude-hashes-from lists (default detect from hash length)",
+	Groups:  "Filter",
+}, {
+	Name:    "filter_tag",
+	Default: []string{},
+	Help:    "Only include files with this tag, as key=value or key for any value",
+	Groups:  "Filter",
 }, {
 	Name:     "filter",
 	Default:  []string{},
@@ -143,15 +203,27 @@ type Options struct {
 	DeleteExcluded bool          `config:"delete_excluded"`
 	RulesOpt                     // embedded so we don't change the JSON API
 	ExcludeFile    []string      `config:"exclude_if_present"`
+	UseIgnoreFiles bool          `config:"use_rcloneignore"`
+	MarkerFiles    []string      `config:"marker_file"`
 	FilesFrom      []string      `config:"files_from"`
 	FilesFromRaw   []string      `config:"files_from_raw"`
 	MetaRules      RulesOpt      `config:"metadata"`
 	MinAge         fs.Duration   `config:"min_age"`
 	MaxAge         fs.Duration   `config:"max_age"`
+	Modified       string        `config:"modified"`
+	MinAgeDst      fs.Duration   `config:"min_age_dst"`
+	MaxAgeDst      fs.Duration   `config:"max_age_dst"`
+	ModifiedDst    string        `config:"modified_dst"`
 	MinSize        fs.SizeSuffix `config:"min_size"`
 	MaxSize        fs.SizeSuffix `config:"max_size"`
 	IgnoreCase     bool          `config:"ignore_case"`
 	HashFilter     string        `config:"hash_filter"`
+	IncludeMime    []string      `config:"include_mime_type"`
+	ExcludeMime    []string      `config:"exclude_mime_type"`
+	IncludeHashes  []string      `config:"include_hashes_from"`
+	ExcludeHashes  []string      `config:"exclude_hashes_from"`
+	HashListType   string        `config:"hash_list_type"`
+	FilterTags     []string      `config:"filter_tag"`
 }
 
 func init() {
@@ -160,10 +232,12 @@ func init() {
 
 // Opt is the default config for the filter
 var Opt = Options{
-	MinAge:  fs.DurationOff, // These have to be set here as the options are parsed once before the defaults are set
-	MaxAge:  fs.DurationOff,
-	MinSize: fs.SizeSuffix(-1),
-	MaxSize: fs.SizeSuffix(-1),
+	MinAge:    fs.DurationOff, // These have to be set here as the options are parsed once before the defaults are set
+	MaxAge:    fs.DurationOff,
+	MinAgeDst: fs.DurationOff,
+	MaxAgeDst: fs.DurationOff,
+	MinSize:   fs.SizeSuffix(-1),
+	MaxSize:   fs.SizeSuffix(-1),
 }
 
 // FilesMap describes the map of files to transfer
@@ -177,10 +251,15 @@ type Filter struct {
 	fileRules   rules
 	dirRules    rules
 	metaRules   rules
-	files       FilesMap // files if filesFrom
-	dirs        FilesMap // dirs from filesFrom
-	hashFilterN uint64   // if non 0 do hash filtering
-	hashFilterK uint64   // select partition K/N
+	files       FilesMap     // files if filesFrom
+	dirs        FilesMap     // dirs from filesFrom
+	hashFilterN uint64       // if non 0 do hash filtering
+	hashFilterK uint64       // select partition K/N
+	hashList    hashList     // hashes from --include/exclude-hashes-from
+	ignore      *ignoreFiles // rules from .rcloneignore files if in use
+	markers     *markerFiles // rules from --marker-file if in use
+	dstTime     *timeWindow  // time window for the destination if set
+	tags        []tagRule    // rules from --filter-tag
 }
 
 // NewFilter parses the command line options and creates a Filter
@@ -197,15 +276,14 @@ func NewFilter(opt *Options) (f *Filter, err error) {
 
 	// Filter flags
 	if f.Opt.MinAge.IsSet() {
-		f.ModTimeTo = time.Now().Add(-time.Duration(f.Opt.MinAge))
-		fs.Debugf(nil, "--min-age %v to %v", f.Opt.MinAge, f.ModTimeTo)
+		fs.Debugf(nil, "--min-age %v", f.Opt.MinAge)
 	}
 	if f.Opt.MaxAge.IsSet() {
-		f.ModTimeFrom = time.Now().Add(-time.Duration(f.Opt.MaxAge))
-		if !f.ModTimeTo.IsZero() && f.ModTimeTo.Before(f.ModTimeFrom) {
-			return nil, fmt.Errorf("filter: --min-age %q can't be larger than --max-age %q", opt.MinAge, opt.MaxAge)
-		}
-		fs.Debugf(nil, "--max-age %v to %v", f.Opt.MaxAge, f.ModTimeFrom)
+		fs.Debugf(nil, "--max-age %v", f.Opt.MaxAge)
+	}
+	err = f.parseTimeWindows(time.Now())
+	if err != nil {
+		return nil, err
 	}
 	if f.Opt.HashFilter != "" {
 		f.hashFilterK, f.hashFilterN, err = parseHashFilter(f.Opt.HashFilter)
@@ -220,6 +298,30 @@ func NewFilter(opt *Options) (f *Filter, err error) {
 		return nil, err
 	}
 
+	if f.Opt.UseIgnoreFiles {
+		f.ignore = newIgnoreFiles()
+	}
+	if len(f.Opt.MarkerFiles) > 0 {
+		f.markers, err = newMarkerFiles(f.Opt.MarkerFiles)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = f.hashList.parse(f.Opt.HashListType, f.Opt.IncludeHashes, f.Opt.ExcludeHashes)
+	if err != nil {
+		return nil, err
+	}
+	f.tags, err = parseTagRules(f.Opt.FilterTags)
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range slices.Concat(f.Opt.IncludeMime, f.Opt.ExcludeMime) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("filter: bad MIME type pattern %q: %w", pattern, err)
+		}
+	}
+
 	err = parseRules(&f.Opt.MetaRules, f.metaRules.Add, f.metaRules.clear)
 	if err != nil {
 		return nil, err
@@ -408,13 +510,17 @@ func (f *Filter) InActive() bool {
 	return (f.files == nil &&
 		f.ModTimeFrom.IsZero() &&
 		f.ModTimeTo.IsZero() &&
+		f.dstTime == nil &&
 		f.Opt.MinSize < 0 &&
 		f.Opt.MaxSize < 0 &&
 		f.fileRules.len() == 0 &&
 		f.dirRules.len() == 0 &&
 		f.metaRules.len() == 0 &&
 		len(f.Opt.ExcludeFile) == 0 &&
-		f.hashFilterN == 0)
+		!f.Opt.UseIgnoreFiles &&
+		len(f.Opt.MarkerFiles) == 0 &&
+		f.hashFilterN == 0 &&
+		!f.usesContentFilters())
 }
 
 // IncludeRemote returns whether this remote passes the filter rules.
@@ -479,8 +585,14 @@ func (f *Filter) IncludeDirectory(ctx context.Context, fs fs.Fs) func(string) (b
 			_, include := f.dirs[remote]
 			return include, nil
 		}
-		remote += "/"
-		return f.dirRules.include(remote), nil
+		if !f.dirRules.include(remote + "/") {
+			return false, nil
+		}
+		include, err := f.includeIgnore(ctx, fs, remote, true)
+		if err != nil || !include {
+			return include, err
+		}
+		return f.includeMarker(ctx, fs, remote, true)
 	}
 }
 
@@ -505,6 +617,11 @@ func (f *Filter) DirContainsExcludeFile(ctx context.Context, fremote fs.Fs, remo
 // Include returns whether this object should be included into the
 // sync or not and logs the reason for exclusion if not included
 func (f *Filter) Include(remote string, size int64, modTime time.Time, metadata fs.Metadata) bool {
+	return f.include(remote, size, modTime, metadata, timeWindow{from: f.ModTimeFrom, to: f.ModTimeTo})
+}
+
+// include is Include using the time window passed in
+func (f *Filter) include(remote string, size int64, modTime time.Time, metadata fs.Metadata, window timeWindow) bool {
 	// filesFrom takes precedence
 	if f.files != nil {
 		_, include := f.files[remote]
@@ -513,11 +630,7 @@ func (f *Filter) Include(remote string, size int64, modTime time.Time, metadata
 		}
 		return include
 	}
-	if !f.ModTimeFrom.IsZero() && modTime.Before(f.ModTimeFrom) {
-		fs.Debugf(remote, "Excluded (ModTime Filter)")
-		return false
-	}
-	if !f.ModTimeTo.IsZero() && modTime.After(f.ModTimeTo) {
+	if !window.include(modTime) {
 		fs.Debugf(remote, "Excluded (ModTime Filter)")
 		return false
 	}
@@ -557,7 +670,8 @@ func (f *Filter) Include(remote string, size int64, modTime time.Time, metadata
 func (f *Filter) IncludeObject(ctx context.Context, o fs.Object) bool {
 	var modTime time.Time
 
-	if !f.ModTimeFrom.IsZero() || !f.ModTimeTo.IsZero() {
+	window := f.modTimeWindow(ctx)
+	if window.isSet() {
 		modTime = o.ModTime(ctx)
 	} else {
 		modTime = time.Unix(0, 0)
@@ -572,7 +686,16 @@ func (f *Filter) IncludeObject(ctx context.Context, o fs.Object) bool {
 		}
 
 	}
-	return f.Include(o.Remote(), o.Size(), modTime, metadata)
+	if !f.include(o.Remote(), o.Size(), modTime, metadata, window) {
+		return false
+	}
+	if !f.includeObjectIgnore(ctx, o) {
+		return false
+	}
+	if !f.includeObjectMarker(ctx, o) {
+		return false
+	}
+	return f.includeContent(ctx, o)
 }
 
 // DumpFilters dumps the filters in textual form, 1 per line
@@ -584,6 +707,14 @@ func (f *Filter) DumpFilters() string {
 	if !f.ModTimeTo.IsZero() {
 		rules = append(rules, fmt.Sprintf("Last-modified date must be equal or less than: %s", f.ModTimeTo.String()))
 	}
+	if f.dstTime != nil {
+		if !f.dstTime.from.IsZero() {
+			rules = append(rules, fmt.Sprintf("Destination last-modified date must be equal or greater than: %s", f.dstTime.from.String()))
+		}
+		if !f.dstTime.to.IsZero() {
+			rules = append(rules, fmt.Sprintf("Destination last-modified date must be equal or less than: %s", f.dstTime.to.String()))
+		}
+	}
 	if f.Opt.MinSize >= 0 {
 		rules = append(rules, fmt.Sprintf("Minimum size is: %s", f.Opt.MinSize.ByteUnit()))
 	}
@@ -598,6 +729,16 @@ func (f *Filter) DumpFilters() string {
 	for _, dirRule := range f.dirRules.rules {
 		rules = append(rules, dirRule.String())
 	}
+	if f.Opt.UseIgnoreFiles {
+		rules = append(rules, fmt.Sprintf("Using %s files", IgnoreFileName))
+	}
+	if f.markers != nil {
+		rules = append(rules, "--- Marker file rules ---")
+		for _, rule := range f.markers.rules {
+			rules = append(rules, rule.String())
+		}
+	}
+	rules = append(rules, f.dumpContentFilters()...)
 	if f.metaRules.len() > 0 {
 		rules = append(rules, "--- Metadata filter rules ---")
 		for _, metaRule := range f.metaRules.rules {
@@ -674,6 +815,24 @@ func (f *Filter) UsesDirectoryFilters() bool {
 	return true
 }
 
+// ListPrefix returns a prefix which the names of all the entries in
+// dir which could be included must start with, or "" if there isn't
+// one.
+//
+// If recurse is set then the prefix is for a recursive listing of
+// dir and may contain "/", otherwise it is for the entries directly
+// in dir.
+//
+// Filter aware backends can use this to constrain their listings.
+func (f *Filter) ListPrefix(dir string, recurse bool) string {
+	// The listing must be complete if files in it need to be read
+	// to work out the filtering
+	if f.Opt.IgnoreCase || len(f.Opt.ExcludeFile) > 0 || f.Opt.UseIgnoreFiles || len(f.Opt.MarkerFiles) > 0 {
+		return ""
+	}
+	return f.fileRules.listPrefix(dir, recurse)
+}
+
 // Context key for config
 type configContextKeyType struct{}
 
@@ -748,6 +907,31 @@ func SetUseFilter(ctx context.Context, useFilter bool) context.Context {
 	return context.WithValue(ctx, useFlagContextKey, pVal)
 }
 
+// Context key for the list query
+type listQueryContextKeyType struct{}
+
+var listQueryContextKey = listQueryContextKeyType{}
+
+// GetListQuery obtains the backend specific list query from the context
+//
+// Backends with the ListQuery feature use this to constrain List
+func GetListQuery(ctx context.Context) string {
+	if ctx != nil {
+		if query, ok := ctx.Value(listQueryContextKey).(string); ok {
+			return query
+		}
+	}
+	return ""
+}
+
+// SetListQuery returns a context with the backend specific list query set
+func SetListQuery(ctx context.Context, query string) context.Context {
+	if query == GetListQuery(ctx) {
+		return ctx
+	}
+	return context.WithValue(ctx, listQueryContextKey, query)
+}
+
 // Reload the filters from the flags
 func Reload(ctx context.Context) (err error) {
 	fi := GetConfig(ctx)
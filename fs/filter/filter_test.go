@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fstest/mockfs"
 	"github.com/rclone/rclone/fstest/mockobject"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -924,6 +925,59 @@ func TestNewFilterUsesDirectoryFilters(t *testing.T) {
 	}
 }
 
+func TestNewFilterListPrefix(t *testing.T) {
+	for i, test := range []struct {
+		rules   []string
+		dir     string
+		recurse bool
+		want    string
+	}{
+		{rules: []string{}, want: ""},
+		{rules: []string{"+ /logs/2024-*", "- **"}, want: "logs"},
+		{rules: []string{"+ /logs/2024-*", "- **"}, recurse: true, want: "logs/2024-"},
+		{rules: []string{"+ /logs/2024-*", "- **"}, dir: "logs", want: "2024-"},
+		{rules: []string{"+ /logs/2024-*", "- **"}, dir: "other", want: ""},
+		{rules: []string{"+ /logs/2024-*"}, dir: "logs", want: ""},
+		{rules: []string{"+ /logs/2024-*", "+ /logs/2023-*", "- **"}, dir: "logs", want: "202"},
+		{rules: []string{"+ /logs/2024-*", "+ /logs/misc", "- **"}, dir: "logs", want: ""},
+		{rules: []string{"- /logs/2024-01*", "+ /logs/2024-*", "- **"}, dir: "logs", want: "2024-"},
+		{rules: []string{"+ /logs/2024-*", "+ *.txt", "- **"}, dir: "logs", want: ""},
+		{rules: []string{"+ /logs/**", "- **"}, dir: "logs/2024", want: ""},
+		{rules: []string{"+ /logs/2024-*", "- *.jpg"}, dir: "logs", want: ""},
+		{rules: []string{"+ /ab€c", "+ /ab€d", "- **"}, want: "ab€"},
+		{rules: []string{"+ /ab€", "+ /ab\xe2", "- **"}, want: "ab"},
+	} {
+		what := fmt.Sprintf("#%d", i)
+		f, err := NewFilter(nil)
+		require.NoError(t, err)
+		for _, rule := range test.rules {
+			require.NoError(t, f.AddRule(rule), what)
+		}
+		got := f.ListPrefix(test.dir, test.recurse)
+		assert.Equal(t, test.want, got, fmt.Sprintf("%s: %s", what, f.DumpFilters()))
+	}
+
+	// --include adds an implicit exclude everything rule
+	opt := Opt
+	opt.IncludeRule = []string{"/logs/2024-*"}
+	f, err := NewFilter(&opt)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-", f.ListPrefix("logs", false))
+
+	// Can't use a prefix if the directory has to be read
+	f.Opt.ExcludeFile = []string{".ignore"}
+	assert.Equal(t, "", f.ListPrefix("logs", false))
+}
+
+func TestListQuery(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", GetListQuery(ctx))
+	ctx2 := SetListQuery(ctx, "name contains 'potato'")
+	assert.Equal(t, "name contains 'potato'", GetListQuery(ctx2))
+	assert.Equal(t, ctx2, SetListQuery(ctx2, "name contains 'potato'"))
+	assert.Equal(t, "", GetListQuery(ctx))
+}
+
 func TestGetConfig(t *testing.T) {
 	ctx := context.Background()
 
@@ -952,3 +1006,348 @@ func TestGetConfig(t *testing.T) {
 	ctx3 := ReplaceConfig(ctx, f)
 	assert.Equal(t, globalConfig, GetConfig(ctx3))
 }
+
+func TestNewFilterMimeType(t *testing.T) {
+	ctx := context.Background()
+	opt := Opt
+	opt.IncludeMime = []string{"image/*", "text/plain"}
+	opt.ExcludeMime = []string{"image/gif"}
+	f, err := NewFilter(&opt)
+	require.NoError(t, err)
+	assert.False(t, f.InActive())
+	for _, test := range []struct {
+		remote string
+		want   bool
+	}{
+		{"photo.jpg", true},
+		{"photo.PNG", true},
+		{"anim.gif", false},
+		{"notes.txt", true},
+		{"movie.mp4", false},
+		{"noextension", false},
+	} {
+		o := mockobject.New(test.remote)
+		assert.Equal(t, test.want, f.IncludeObject(ctx, o), test.remote)
+	}
+	assert.Contains(t, f.DumpFilters(), "MIME type must match: image/*")
+
+	opt.IncludeMime = []string{"image/["}
+	_, err = NewFilter(&opt)
+	assert.ErrorContains(t, err, "bad MIME type pattern")
+}
+
+func TestNewFilterHashList(t *testing.T) {
+	ctx := context.Background()
+	// sha1 of "hello" and "world"
+	const helloSHA1 = "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"
+	const worldSHA1 = "7c211433f02071597741e6ff5a8ea34789abbf43"
+	hello := mockobject.New("hello.txt").WithContent([]byte("hello"), mockobject.SeekModeNone)
+	world := mockobject.New("world.txt").WithContent([]byte("world"), mockobject.SeekModeNone)
+	other := mockobject.New("other.txt").WithContent([]byte("other"), mockobject.SeekModeNone)
+
+	opt := Opt
+	opt.ExcludeHashes = []string{testFile(t, "# comment\n"+strings.ToUpper(helloSHA1)+"  hello.txt\n")}
+	f, err := NewFilter(&opt)
+	require.NoError(t, err)
+	assert.False(t, f.InActive())
+	assert.False(t, f.IncludeObject(ctx, hello))
+	assert.True(t, f.IncludeObject(ctx, world))
+	assert.True(t, f.IncludeObject(ctx, other))
+
+	opt = Opt
+	opt.IncludeHashes = []string{testFile(t, helloSHA1+"\n"+worldSHA1+"\n")}
+	opt.ExcludeHashes = []string{testFile(t, worldSHA1+"\n")}
+	f, err = NewFilter(&opt)
+	require.NoError(t, err)
+	assert.True(t, f.IncludeObject(ctx, hello))
+	assert.False(t, f.IncludeObject(ctx, world))
+	assert.False(t, f.IncludeObject(ctx, other))
+	assert.Contains(t, f.DumpFilters(), "sha1 hash must be one of 2 hashes")
+
+	opt = Opt
+	opt.ExcludeHashes = []string{testFile(t, "abcd\n")}
+	_, err = NewFilter(&opt)
+	assert.ErrorContains(t, err, "can't detect hash type")
+
+	opt.HashListType = "crc32"
+	opt.ExcludeHashes = []string{testFile(t, "3610a686\n")}
+	f, err = NewFilter(&opt)
+	require.NoError(t, err)
+	assert.False(t, f.IncludeObject(ctx, hello))
+}
+
+// ignoreTestFs is an Fs returning objects from a map
+type ignoreTestFs struct {
+	fs.Fs
+	objects map[string]fs.Object
+}
+
+func (f *ignoreTestFs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	if o, ok := f.objects[remote]; ok {
+		return o, nil
+	}
+	return nil, fs.ErrorObjectNotFound
+}
+
+func TestNewFilterIgnoreFiles(t *testing.T) {
+	ctx := context.Background()
+	mock, err := mockfs.NewFs(ctx, "mock", "root", nil)
+	require.NoError(t, err)
+	f := &ignoreTestFs{Fs: mock, objects: map[string]fs.Object{}}
+	addIgnore := func(remote, content string) {
+		f.objects[remote] = mockobject.New(remote).WithContent([]byte(content), mockobject.SeekModeNone)
+	}
+	addIgnore(".rcloneignore", "# comment\n*.log\nbuild/\n/top.txt\n")
+	addIgnore("a/.rcloneignore", "!keep.log\ndocs/*.pdf\n")
+	addIgnore("a/b/.rcloneignore", "*.txt\n")
+
+	opt := Opt
+	opt.UseIgnoreFiles = true
+	opt.ExcludeRule = []string{"*.bak"}
+	fi, err := NewFilter(&opt)
+	require.NoError(t, err)
+	assert.False(t, fi.InActive())
+	assert.Contains(t, fi.DumpFilters(), "Using .rcloneignore files")
+
+	ctx = SetIgnoreSource(ctx, f)
+	for _, test := range []struct {
+		remote string
+		want   bool
+	}{
+		{"file.txt", true},
+		{"file.log", false},
+		{"file.bak", false},
+		{"top.txt", false},
+		{"a/top.txt", true},
+		{"a/file.log", false},
+		{"a/keep.log", true},
+		{"a/b/keep.log", true},
+		{"a/docs/x.pdf", false},
+		{"a/docs/sub/x.pdf", true},
+		{"a/b/file.txt", false},
+		{"a/b/c/file.txt", false},
+		{"a/b/file.jpg", true},
+		{"a/build/file.jpg", false},
+		{"build", true}, // build/ only matches directories
+	} {
+		o := mockobject.New(test.remote)
+		assert.Equal(t, test.want, fi.IncludeObject(ctx, o), test.remote)
+	}
+
+	includeDirectory := fi.IncludeDirectory(ctx, nil)
+	for _, test := range []struct {
+		remote string
+		want   bool
+	}{
+		{"a", true},
+		{"build", false},
+		{"a/build", false},
+		{"a/b", true},
+		{"x.log", false},
+	} {
+		got, err := includeDirectory(test.remote)
+		require.NoError(t, err)
+		assert.Equal(t, test.want, got, test.remote)
+	}
+}
+
+func TestNewFilterMarkerFiles(t *testing.T) {
+	ctx := context.Background()
+	mock, err := mockfs.NewFs(ctx, "mock", "root", nil)
+	require.NoError(t, err)
+	f := &ignoreTestFs{Fs: mock, objects: map[string]fs.Object{}}
+	addMarker := func(remote, content string) {
+		f.objects[remote] = mockobject.New(remote).WithContent([]byte(content), mockobject.SeekModeNone)
+	}
+	addMarker("keep", "")
+	addMarker("a/keep", "")
+	addMarker("a/.filter", "# comment\n- *.tmp\n- /only.jpg\n")
+	addMarker("a/skip/nobackup", "")
+	addMarker("b/c/d/keep", "")
+
+	opt := Opt
+	opt.MarkerFiles = []string{"nobackup", "keep,action=include,min-depth=1,max-depth=2", ".filter,action=filter"}
+	fi, err := NewFilter(&opt)
+	require.NoError(t, err)
+	assert.False(t, fi.InActive())
+	assert.Contains(t, fi.DumpFilters(), "keep,action=include,min-depth=1,max-depth=2")
+
+	ctx = SetIgnoreSource(ctx, f)
+	for _, test := range []struct {
+		remote string
+		want   bool
+	}{
+		{"file.txt", false}, // keep at depth 0 is ignored
+		{"a/file.txt", true},
+		{"a/x.tmp", false},
+		{"a/sub/x.tmp", false},
+		{"a/only.jpg", false},
+		{"a/sub/only.jpg", true},
+		{"a/skip/file.txt", false},
+		{"b/file.txt", false},
+		{"b/c/d/file.txt", false}, // keep at depth 3 is ignored
+	} {
+		o := mockobject.New(test.remote)
+		assert.Equal(t, test.want, fi.IncludeObject(ctx, o), test.remote)
+	}
+
+	includeDirectory := fi.IncludeDirectory(ctx, nil)
+	for _, test := range []struct {
+		remote string
+		want   bool
+	}{
+		{"a", true},
+		{"a/skip", false},
+		{"a/skip/sub", false},
+		{"b", true},
+		{"b/c", true},
+	} {
+		got, err := includeDirectory(test.remote)
+		require.NoError(t, err)
+		assert.Equal(t, test.want, got, test.remote)
+	}
+
+	for _, bad := range []string{"", "a/b", "x,action=potato", "x,min-depth=one", "x,colour=red", "x,min-depth"} {
+		opt.MarkerFiles = []string{bad}
+		_, err := NewFilter(&opt)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParseTimeWindow(t *testing.T) {
+	oslo, err := time.LoadLocation("Europe/Oslo")
+	require.NoError(t, err)
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		in      string
+		from    time.Time
+		to      time.Time
+		wantErr string
+	}{
+		{in: "any"},
+		{in: "modified between 2024-01-01 and 2024-03-01 in Europe/Oslo",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, oslo),
+			to:   time.Date(2024, 3, 1, 0, 0, 0, 0, oslo)},
+		{in: "Between 2024-01-01 10:30:00 AND 2024-01-02T00:00:00Z in UTC",
+			from: time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC),
+			to:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{in: "after 30d ago", from: now.Add(-30 * 24 * time.Hour)},
+		{in: "since 2h ago", from: now.Add(-2 * time.Hour)},
+		{in: "before today in UTC", to: time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)},
+		{in: "between yesterday and now in UTC",
+			from: time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC),
+			to:   now},
+		{in: "", wantErr: "empty time window"},
+		{in: "between 2024-03-01 and 2024-01-01", wantErr: "end is before start"},
+		{in: "between 2024-01-01", wantErr: "missing \"and\""},
+		{in: "after potato", wantErr: "can't parse time"},
+		{in: "after 1x ago", wantErr: "bad duration"},
+		{in: "before now in Mars/Olympus", wantErr: "bad time zone"},
+		{in: "around now", wantErr: "must start with"},
+		{in: "any time", wantErr: "unexpected"},
+	} {
+		w, err := parseTimeWindow(test.in, now)
+		if test.wantErr != "" {
+			assert.ErrorContains(t, err, test.wantErr, test.in)
+			continue
+		}
+		require.NoError(t, err, test.in)
+		assert.True(t, test.from.Equal(w.from), "%s: from want %v got %v", test.in, test.from, w.from)
+		assert.True(t, test.to.Equal(w.to), "%s: to want %v got %v", test.in, test.to, w.to)
+	}
+}
+
+func TestNewFilterTimeWindowDst(t *testing.T) {
+	ctx := context.Background()
+	dstCtx := SetDestination(ctx)
+	assert.False(t, IsDestination(ctx))
+	assert.True(t, IsDestination(dstCtx))
+
+	opt := Opt
+	opt.Modified = "after 10d ago"
+	opt.MaxAgeDst = fs.Duration(48 * time.Hour)
+	f, err := NewFilter(&opt)
+	require.NoError(t, err)
+	assert.False(t, f.InActive())
+	assert.Contains(t, f.DumpFilters(), "Destination last-modified date must be equal or greater than")
+
+	now := time.Now()
+	newObj := mockobject.New("new.txt").WithContent(nil, mockobject.SeekModeNone)
+	require.NoError(t, newObj.SetModTime(ctx, now.Add(-time.Hour)))
+	midObj := mockobject.New("mid.txt").WithContent(nil, mockobject.SeekModeNone)
+	require.NoError(t, midObj.SetModTime(ctx, now.Add(-5*24*time.Hour)))
+	oldObj := mockobject.New("old.txt").WithContent(nil, mockobject.SeekModeNone)
+	require.NoError(t, oldObj.SetModTime(ctx, now.Add(-20*24*time.Hour)))
+
+	assert.True(t, f.IncludeObject(ctx, newObj))
+	assert.True(t, f.IncludeObject(ctx, midObj))
+	assert.False(t, f.IncludeObject(ctx, oldObj))
+	assert.True(t, f.IncludeObject(dstCtx, newObj))
+	assert.False(t, f.IncludeObject(dstCtx, midObj))
+	assert.False(t, f.IncludeObject(dstCtx, oldObj))
+
+	from, to := f.ModTimeWindow(dstCtx)
+	assert.WithinDuration(t, now.Add(-48*time.Hour), from, time.Minute)
+	assert.True(t, to.IsZero())
+
+	// Without destination filters the source ones apply to both
+	opt = Opt
+	opt.MinAge = fs.Duration(24 * time.Hour)
+	opt.Modified = "after 10d ago"
+	f, err = NewFilter(&opt)
+	require.NoError(t, err)
+	assert.False(t, f.IncludeObject(dstCtx, newObj))
+	assert.True(t, f.IncludeObject(dstCtx, midObj))
+	assert.False(t, f.IncludeObject(dstCtx, oldObj))
+
+	opt = Opt
+	opt.MaxAge = fs.Duration(24 * time.Hour)
+	opt.Modified = "before 2d ago"
+	_, err = NewFilter(&opt)
+	assert.ErrorContains(t, err, "time window is empty")
+
+	opt = Opt
+	opt.MinAgeDst = fs.Duration(48 * time.Hour)
+	opt.MaxAgeDst = fs.Duration(24 * time.Hour)
+	_, err = NewFilter(&opt)
+	assert.ErrorContains(t, err, "--min-age-dst")
+}
+
+// taggedObject is a mock object with tags
+type taggedObject struct {
+	mockobject.Object
+	tags fs.Tags
+}
+
+// Tags returns the tags of the object
+func (o taggedObject) Tags(ctx context.Context) (fs.Tags, error) {
+	return o.tags, nil
+}
+
+func TestNewFilterTags(t *testing.T) {
+	ctx := context.Background()
+	opt := Opt
+	opt.FilterTags = []string{"project=potato", "keep"}
+	f, err := NewFilter(&opt)
+	require.NoError(t, err)
+	assert.False(t, f.InActive())
+	for _, test := range []struct {
+		tags fs.Tags
+		want bool
+	}{
+		{nil, false},
+		{fs.Tags{"project": "potato"}, false},
+		{fs.Tags{"project": "potato", "keep": ""}, true},
+		{fs.Tags{"project": "potato", "keep": "yes"}, true},
+		{fs.Tags{"project": "carrot", "keep": "yes"}, false},
+	} {
+		o := taggedObject{Object: mockobject.New("file.txt"), tags: test.tags}
+		assert.Equal(t, test.want, f.IncludeObject(ctx, o), test.tags.String())
+	}
+	assert.Contains(t, f.DumpFilters(), "Tag must be set: project=potato")
+	assert.Contains(t, f.DumpFilters(), "Tag must be present: keep")
+
+	opt.FilterTags = []string{"=value"}
+	_, err = NewFilter(&opt)
+	assert.ErrorContains(t, err, "bad --filter-tag")
+}
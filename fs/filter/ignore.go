@@ -0,0 +1,236 @@
+// Per directory gitignore style ignore files
+
+package filter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// IgnoreFileName is the name of the files read with --use-rcloneignore
+const IgnoreFileName = ".rcloneignore"
+
+// ignoreRule is one line of an ignore file
+type ignoreRule struct {
+	negate  bool // line started with ! so re-includes
+	dirOnly bool // line ended with / so only matches directories
+	re      *regexp.Regexp
+}
+
+// parseIgnoreLine parses a gitignore style line into a rule.
+//
+// It returns a nil rule for blank lines and comments.
+func parseIgnoreLine(line string, ignoreCase bool) (*ignoreRule, error) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || line[0] == '#' {
+		return nil, nil
+	}
+	r := &ignoreRule{}
+	if line[0] == '!' {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil, nil
+	}
+	// A pattern with a / in is relative to the directory of the
+	// ignore file, otherwise it matches at any level below it.
+	if strings.Contains(line, "/") && !strings.HasPrefix(line, "/") {
+		line = "/" + line
+	}
+	var err error
+	r.re, err = GlobPathToRegexp(line, ignoreCase)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ignoreFiles caches the rules read from the ignore files
+type ignoreFiles struct {
+	mu    sync.Mutex
+	rules map[string][]*ignoreRule // rules for each Fs and directory
+}
+
+// newIgnoreFiles makes an empty ignore file cache
+func newIgnoreFiles() *ignoreFiles {
+	return &ignoreFiles{
+		rules: make(map[string][]*ignoreRule),
+	}
+}
+
+// read the rules in the ignore file in dir of f, returning nil rules
+// if there isn't one.
+func (ig *ignoreFiles) read(ctx context.Context, f fs.Fs, dir string, ignoreCase bool) (rules []*ignoreRule, err error) {
+	o, err := f.NewObject(ctx, path.Join(dir, IgnoreFileName))
+	if errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorDirNotFound) || errors.Is(err, fs.ErrorIsDir) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	in, err := o.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.CheckClose(in, &err)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		rule, err := parseIgnoreLine(scanner.Text(), ignoreCase)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", o.Remote(), err)
+		}
+		if rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	fs.Debugf(o, "Read %d rules", len(rules))
+	return rules, nil
+}
+
+// get the rules in the ignore file in dir of f using the cache
+func (ig *ignoreFiles) get(ctx context.Context, f fs.Fs, dir string, ignoreCase bool) ([]*ignoreRule, error) {
+	key := fs.ConfigString(f) + "\x00" + dir
+	ig.mu.Lock()
+	rules, found := ig.rules[key]
+	ig.mu.Unlock()
+	if found {
+		return rules, nil
+	}
+	rules, err := ig.read(ctx, f, dir, ignoreCase)
+	if err != nil {
+		return nil, err
+	}
+	ig.mu.Lock()
+	ig.rules[key] = rules
+	ig.mu.Unlock()
+	return rules, nil
+}
+
+// ignoredBy returns whether remote is ignored by the ignore files in
+// the directories above it. Rules in deeper directories and later
+// lines take precedence as with gitignore.
+func (ig *ignoreFiles) ignoredBy(ctx context.Context, f fs.Fs, remote string, isDir bool, ignoreCase bool) (bool, error) {
+	ignored := false
+	dir := ""
+	for {
+		rules, err := ig.get(ctx, f, dir, ignoreCase)
+		if err != nil {
+			return false, err
+		}
+		relative := remote
+		if dir != "" {
+			relative = remote[len(dir)+1:]
+		}
+		for _, rule := range rules {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.re.MatchString(relative) {
+				ignored = !rule.negate
+			}
+		}
+		i := strings.IndexRune(relative, '/')
+		if i < 0 {
+			break
+		}
+		dir = path.Join(dir, relative[:i])
+	}
+	return ignored, nil
+}
+
+// ignored returns whether remote or any of its parent directories are
+// ignored by the ignore files.
+func (ig *ignoreFiles) ignored(ctx context.Context, f fs.Fs, remote string, isDir bool, ignoreCase bool) (bool, error) {
+	// As with gitignore, a file can't be re-included if its
+	// parent directory is excluded.
+	parent := ""
+	for _, part := range strings.Split(path.Dir(remote), "/") {
+		if part == "." || part == "" {
+			break
+		}
+		parent = path.Join(parent, part)
+		ignored, err := ig.ignoredBy(ctx, f, parent, true, ignoreCase)
+		if ignored || err != nil {
+			return ignored, err
+		}
+	}
+	return ig.ignoredBy(ctx, f, remote, isDir, ignoreCase)
+}
+
+// Context key for the source of ignore files
+type ignoreSourceContextKeyType struct{}
+
+var ignoreSourceContextKey = ignoreSourceContextKeyType{}
+
+// SetIgnoreSource returns a context which reads the ignore files from
+// f when using --use-rcloneignore, and the marker files when using
+// --marker-file.
+//
+// This is used when syncing so the ignore and marker files on the
+// source are used to filter the destination too.
+func SetIgnoreSource(ctx context.Context, f fs.Fs) context.Context {
+	return context.WithValue(ctx, ignoreSourceContextKey, f)
+}
+
+// ignoreSource returns the Fs to read the ignore files from, or f if
+// not set.
+func ignoreSource(ctx context.Context, f fs.Fs) fs.Fs {
+	if ctx != nil {
+		if src, ok := ctx.Value(ignoreSourceContextKey).(fs.Fs); ok {
+			return src
+		}
+	}
+	return f
+}
+
+// includeIgnore returns whether remote on f passes the ignore files
+// rules.
+func (f *Filter) includeIgnore(ctx context.Context, fremote fs.Fs, remote string, isDir bool) (bool, error) {
+	if f.ignore == nil {
+		return true, nil
+	}
+	fremote = ignoreSource(ctx, fremote)
+	if fremote == nil {
+		return true, nil
+	}
+	ignored, err := f.ignore.ignored(ctx, fremote, remote, isDir, f.Opt.IgnoreCase)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	}
+	return !ignored, nil
+}
+
+// includeObjectIgnore returns whether the object passes the ignore
+// files rules, logging the reason if not.
+func (f *Filter) includeObjectIgnore(ctx context.Context, o fs.Object) bool {
+	if f.ignore == nil || f.files != nil {
+		return true
+	}
+	fremote, _ := o.Fs().(fs.Fs)
+	include, err := f.includeIgnore(ctx, fremote, o.Remote(), false)
+	if err != nil {
+		fs.Errorf(o, "%v", err)
+		return true
+	}
+	if !include {
+		fs.Debugf(o, "Excluded (%s Filter)", IgnoreFileName)
+	}
+	return include
+}
@@ -0,0 +1,271 @@
+// Marker file rules from --marker-file
+
+package filter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// markerAction is what a marker file does to the directory it is in
+type markerAction int
+
+// Marker file actions
+const (
+	markerExclude markerAction = iota // exclude the directory
+	markerInclude                     // include the directory
+	markerFilter                      // apply the filter rules in the marker file
+)
+
+var markerActions = []string{
+	markerExclude: "exclude",
+	markerInclude: "include",
+	markerFilter:  "filter",
+}
+
+// markerRule is one --marker-file rule
+type markerRule struct {
+	name     string       // leaf name of the marker file
+	action   markerAction // what to do if it is found
+	minDepth int          // minimum depth of the directory it applies in
+	maxDepth int          // maximum depth of the directory it applies in or -1 for no limit
+}
+
+// parseMarkerRule parses a rule in the form
+//
+//	name[,action=exclude|include|filter][,min-depth=N][,max-depth=N]
+func parseMarkerRule(s string) (*markerRule, error) {
+	parts := strings.Split(s, ",")
+	r := &markerRule{
+		name:     strings.TrimSpace(parts[0]),
+		maxDepth: -1,
+	}
+	if r.name == "" || strings.Contains(r.name, "/") {
+		return nil, fmt.Errorf("bad --marker-file %q: need a file name without a /", s)
+	}
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("bad --marker-file %q: parameter %q needs a value", s, part)
+		}
+		var err error
+		switch key {
+		case "action":
+			i := slices.Index(markerActions, value)
+			if i < 0 {
+				return nil, fmt.Errorf("bad --marker-file %q: unknown action %q - need one of %s", s, value, strings.Join(markerActions, ", "))
+			}
+			r.action = markerAction(i)
+		case "min-depth":
+			r.minDepth, err = strconv.Atoi(value)
+		case "max-depth":
+			r.maxDepth, err = strconv.Atoi(value)
+		default:
+			return nil, fmt.Errorf("bad --marker-file %q: unknown parameter %q", s, key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bad --marker-file %q: %s: %w", s, key, err)
+		}
+	}
+	return r, nil
+}
+
+// String returns the rule in the form it was parsed from
+func (r *markerRule) String() string {
+	s := r.name + ",action=" + markerActions[r.action]
+	if r.minDepth > 0 {
+		s += ",min-depth=" + strconv.Itoa(r.minDepth)
+	}
+	if r.maxDepth >= 0 {
+		s += ",max-depth=" + strconv.Itoa(r.maxDepth)
+	}
+	return s
+}
+
+// appliesAt returns whether the rule applies in a directory at depth,
+// where the root is at depth 0.
+func (r *markerRule) appliesAt(depth int) bool {
+	return depth >= r.minDepth && (r.maxDepth < 0 || depth <= r.maxDepth)
+}
+
+// markerFile is a marker file found in a directory
+type markerFile struct {
+	filter *Filter // rules read from it if it is a filter marker
+}
+
+// markerFiles caches the marker files found for the --marker-file rules
+type markerFiles struct {
+	rules      []*markerRule
+	hasInclude bool // set if any rules include directories
+	mu         sync.Mutex
+	found      map[string]*markerFile // marker file or nil for each Fs, directory and name
+}
+
+// newMarkerFiles parses the --marker-file rules
+func newMarkerFiles(ruleStrings []string) (*markerFiles, error) {
+	m := &markerFiles{
+		found: make(map[string]*markerFile),
+	}
+	for _, s := range ruleStrings {
+		r, err := parseMarkerRule(s)
+		if err != nil {
+			return nil, err
+		}
+		if r.action == markerInclude {
+			m.hasInclude = true
+		}
+		m.rules = append(m.rules, r)
+	}
+	return m, nil
+}
+
+// read the marker file for rule in dir of f, returning nil if there
+// isn't one.
+func (m *markerFiles) read(ctx context.Context, f fs.Fs, dir string, rule *markerRule, ignoreCase bool) (mf *markerFile, err error) {
+	o, err := f.NewObject(ctx, path.Join(dir, rule.name))
+	if errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorDirNotFound) || errors.Is(err, fs.ErrorIsDir) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	mf = &markerFile{}
+	if rule.action != markerFilter {
+		return mf, nil
+	}
+	mf.filter = &Filter{}
+	mf.filter.Opt.IgnoreCase = ignoreCase
+	in, err := o.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.CheckClose(in, &err)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if err := mf.filter.AddRule(line); err != nil {
+			return nil, fmt.Errorf("%s: %w", o.Remote(), err)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	fs.Debugf(o, "Read %d rules", mf.filter.fileRules.len())
+	return mf, nil
+}
+
+// get the marker file for rule in dir of f using the cache
+func (m *markerFiles) get(ctx context.Context, f fs.Fs, dir string, rule *markerRule, ignoreCase bool) (*markerFile, error) {
+	key := fs.ConfigString(f) + "\x00" + dir + "\x00" + rule.name
+	m.mu.Lock()
+	mf, found := m.found[key]
+	m.mu.Unlock()
+	if found {
+		return mf, nil
+	}
+	mf, err := m.read(ctx, f, dir, rule, ignoreCase)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.found[key] = mf
+	m.mu.Unlock()
+	return mf, nil
+}
+
+// included returns whether remote passes the rules of the marker
+// files in the directories above it, and in it if it is a directory.
+func (m *markerFiles) included(ctx context.Context, f fs.Fs, remote string, isDir bool, ignoreCase bool) (bool, error) {
+	dirs := []string{""}
+	for i, c := range remote {
+		if c == '/' {
+			dirs = append(dirs, remote[:i])
+		}
+	}
+	if isDir {
+		dirs = append(dirs, remote)
+	}
+	included := false
+	for depth, dir := range dirs {
+		for _, rule := range m.rules {
+			if !rule.appliesAt(depth) {
+				continue
+			}
+			mf, err := m.get(ctx, f, dir, rule, ignoreCase)
+			if err != nil {
+				return false, err
+			}
+			if mf == nil {
+				continue
+			}
+			switch rule.action {
+			case markerExclude:
+				return false, nil
+			case markerInclude:
+				included = true
+			case markerFilter:
+				if dir == remote {
+					continue
+				}
+				relative := remote
+				if dir != "" {
+					relative = remote[len(dir)+1:]
+				}
+				if isDir && !mf.filter.dirRules.include(relative+"/") {
+					return false, nil
+				} else if !isDir && !mf.filter.IncludeRemote(relative) {
+					return false, nil
+				}
+			}
+		}
+	}
+	// Directories without an include marker need to be listed to
+	// find include markers below them.
+	return included || isDir || !m.hasInclude, nil
+}
+
+// includeMarker returns whether remote on f passes the --marker-file
+// rules.
+func (f *Filter) includeMarker(ctx context.Context, fremote fs.Fs, remote string, isDir bool) (bool, error) {
+	if f.markers == nil {
+		return true, nil
+	}
+	fremote = ignoreSource(ctx, fremote)
+	if fremote == nil {
+		return true, nil
+	}
+	include, err := f.markers.included(ctx, fremote, remote, isDir, f.Opt.IgnoreCase)
+	if err != nil {
+		return false, fmt.Errorf("failed to read marker file: %w", err)
+	}
+	return include, nil
+}
+
+// includeObjectMarker returns whether the object passes the
+// --marker-file rules, logging the reason if not.
+func (f *Filter) includeObjectMarker(ctx context.Context, o fs.Object) bool {
+	if f.markers == nil || f.files != nil {
+		return true
+	}
+	fremote, _ := o.Fs().(fs.Fs)
+	include, err := f.includeMarker(ctx, fremote, o.Remote(), false)
+	if err != nil {
+		fs.Errorf(o, "%v", err)
+		return true
+	}
+	if !include {
+		fs.Debugf(o, "Excluded (Marker File Filter)")
+	}
+	return include
+}
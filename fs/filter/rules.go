@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/rclone/rclone/fs"
 )
@@ -114,6 +115,67 @@ func (rs *rules) includeMany(remotes []string) bool {
 	return true
 }
 
+// listPrefix returns the prefix which the names of all entries in dir
+// passing the rules must start with or "" if there isn't one.
+//
+// This is only possible if all the include rules are anchored and
+// are followed by a rule which excludes everything.
+func (rs *rules) listPrefix(dir string, recurse bool) string {
+	if dir != "" {
+		dir += "/"
+	}
+	var prefixes []string
+	for _, rule := range rs.rules {
+		re := rule.Regexp.String()
+		if !rule.Include {
+			if re == "^.*$" || re == "(^|/).*$" {
+				// Everything not matched so far is excluded
+				return commonPrefix(prefixes)
+			}
+			// Other exclude rules can only narrow the listing
+			continue
+		}
+		if !strings.HasPrefix(re, "^") {
+			return ""
+		}
+		prefix, _ := rule.Regexp.LiteralPrefix()
+		switch {
+		case strings.HasPrefix(prefix, dir):
+			prefix = prefix[len(dir):]
+			if !recurse {
+				prefix, _, _ = strings.Cut(prefix, "/")
+			}
+			prefixes = append(prefixes, prefix)
+		case strings.HasPrefix(dir, prefix):
+			// Could match anything in dir
+			return ""
+		}
+		// Otherwise the rule can't match anything in dir
+	}
+	// Anything not matched is included
+	return ""
+}
+
+// commonPrefix returns the longest prefix of all of xs
+func commonPrefix(xs []string) string {
+	if len(xs) == 0 {
+		return ""
+	}
+	prefix := xs[0]
+	for _, x := range xs[1:] {
+		i := 0
+		for i < len(prefix) && i < len(x) && prefix[i] == x[i] {
+			i++
+		}
+		prefix = prefix[:i]
+	}
+	// Don't split a multibyte character
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
+
 // forEachLine calls fn on every line in the file pointed to by path
 //
 // It ignores empty lines and lines starting with '#' or ';' if raw is false
@@ -0,0 +1,258 @@
+// Filters based on a window of modification times
+
+package filter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// timeWindow is the range of modification times to include. A zero
+// from or to means that end of the window is open.
+type timeWindow struct {
+	from time.Time
+	to   time.Time
+}
+
+// include returns true if modTime is within the window
+func (w timeWindow) include(modTime time.Time) bool {
+	if !w.from.IsZero() && modTime.Before(w.from) {
+		return false
+	}
+	if !w.to.IsZero() && modTime.After(w.to) {
+		return false
+	}
+	return true
+}
+
+// isSet returns true if either end of the window is set
+func (w timeWindow) isSet() bool {
+	return !w.from.IsZero() || !w.to.IsZero()
+}
+
+// intersect narrows the window to the part inside other
+func (w *timeWindow) intersect(other timeWindow) error {
+	if !other.from.IsZero() && (w.from.IsZero() || other.from.After(w.from)) {
+		w.from = other.from
+	}
+	if !other.to.IsZero() && (w.to.IsZero() || other.to.Before(w.to)) {
+		w.to = other.to
+	}
+	if !w.from.IsZero() && !w.to.IsZero() && w.to.Before(w.from) {
+		return errors.New("time window is empty")
+	}
+	return nil
+}
+
+// ageWindow makes the time window for --min-age and --max-age style
+// options.
+func ageWindow(minAge, maxAge fs.Duration, now time.Time) (w timeWindow) {
+	if minAge.IsSet() {
+		w.to = now.Add(-time.Duration(minAge))
+	}
+	if maxAge.IsSet() {
+		w.from = now.Add(-time.Duration(maxAge))
+	}
+	return w
+}
+
+// time formats to try parsing absolute times in the location given
+var timeWindowFormats = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseTimePoint parses one end of a time window expression
+//
+// This can be "now", "today", "yesterday", a duration followed by
+// "ago", e.g. "30d ago", or a date and time, e.g. "2024-01-01" which
+// is interpreted in loc unless it has a time zone offset.
+func parseTimePoint(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return time.Time{}, errors.New("missing time")
+	case "now":
+		return now, nil
+	case "today", "yesterday":
+		now = now.In(loc)
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		if strings.EqualFold(s, "yesterday") {
+			midnight = midnight.AddDate(0, 0, -1)
+		}
+		return midnight, nil
+	}
+	if age, found := cutSuffixFold(s, " ago"); found {
+		d, err := fs.ParseDuration(strings.TrimSpace(age))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("bad duration %q: %w", age, err)
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, format := range timeWindowFormats {
+		if t, err := time.ParseInLocation(format, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't parse time %q", s)
+}
+
+// cutSuffixFold is strings.CutSuffix ignoring case
+func cutSuffixFold(s, suffix string) (string, bool) {
+	if len(s) >= len(suffix) && strings.EqualFold(s[len(s)-len(suffix):], suffix) {
+		return s[:len(s)-len(suffix)], true
+	}
+	return s, false
+}
+
+// parseTimeWindow parses a time window expression such as
+//
+//	modified between 2024-01-01 and 2024-03-01 in Europe/Oslo
+//	after 7d ago
+//	before yesterday in UTC
+//	any
+//
+// The leading "modified" is optional. Times are in the local time
+// zone unless "in <zone>" is given.
+func parseTimeWindow(expr string, now time.Time) (w timeWindow, err error) {
+	fields := strings.Fields(expr)
+	if len(fields) > 0 && strings.EqualFold(fields[0], "modified") {
+		fields = fields[1:]
+	}
+	loc := time.Local
+	if n := len(fields); n >= 2 && strings.EqualFold(fields[n-2], "in") {
+		loc, err = time.LoadLocation(fields[n-1])
+		if err != nil {
+			return w, fmt.Errorf("bad time zone in %q: %w", expr, err)
+		}
+		fields = fields[:n-2]
+	}
+	if len(fields) == 0 {
+		return w, fmt.Errorf("empty time window %q", expr)
+	}
+	rest := strings.Join(fields[1:], " ")
+	switch strings.ToLower(fields[0]) {
+	case "any":
+		if rest != "" {
+			return w, fmt.Errorf("unexpected %q after any in %q", rest, expr)
+		}
+	case "after", "since":
+		w.from, err = parseTimePoint(rest, now, loc)
+	case "before":
+		w.to, err = parseTimePoint(rest, now, loc)
+	case "between":
+		i := -1
+		for j, field := range fields {
+			if strings.EqualFold(field, "and") {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return w, fmt.Errorf("missing \"and\" in %q", expr)
+		}
+		w.from, err = parseTimePoint(strings.Join(fields[1:i], " "), now, loc)
+		if err == nil {
+			w.to, err = parseTimePoint(strings.Join(fields[i+1:], " "), now, loc)
+		}
+		if err == nil && w.to.Before(w.from) {
+			err = errors.New("end is before start")
+		}
+	default:
+		return w, fmt.Errorf("time window %q must start with between, after, before or any", expr)
+	}
+	if err != nil {
+		return w, fmt.Errorf("bad time window %q: %w", expr, err)
+	}
+	return w, nil
+}
+
+// parseTimeWindows sets up the time windows for the source and the
+// destination from the options.
+func (f *Filter) parseTimeWindows(now time.Time) error {
+	src := ageWindow(f.Opt.MinAge, f.Opt.MaxAge, now)
+	if !src.from.IsZero() && !src.to.IsZero() && src.to.Before(src.from) {
+		return fmt.Errorf("filter: --min-age %q can't be larger than --max-age %q", f.Opt.MinAge, f.Opt.MaxAge)
+	}
+	if f.Opt.Modified != "" {
+		w, err := parseTimeWindow(f.Opt.Modified, now)
+		if err != nil {
+			return fmt.Errorf("filter: --modified: %w", err)
+		}
+		if err = src.intersect(w); err != nil {
+			return fmt.Errorf("filter: --modified with --min-age/--max-age: %w", err)
+		}
+	}
+	f.ModTimeFrom, f.ModTimeTo = src.from, src.to
+	if src.isSet() {
+		fs.Debugf(nil, "Modification time window from %v to %v", f.ModTimeFrom, f.ModTimeTo)
+	}
+
+	if !f.Opt.MinAgeDst.IsSet() && !f.Opt.MaxAgeDst.IsSet() && f.Opt.ModifiedDst == "" {
+		return nil
+	}
+	dst := ageWindow(f.Opt.MinAgeDst, f.Opt.MaxAgeDst, now)
+	if !dst.from.IsZero() && !dst.to.IsZero() && dst.to.Before(dst.from) {
+		return fmt.Errorf("filter: --min-age-dst %q can't be larger than --max-age-dst %q", f.Opt.MinAgeDst, f.Opt.MaxAgeDst)
+	}
+	if f.Opt.ModifiedDst != "" {
+		w, err := parseTimeWindow(f.Opt.ModifiedDst, now)
+		if err != nil {
+			return fmt.Errorf("filter: --modified-dst: %w", err)
+		}
+		if err = dst.intersect(w); err != nil {
+			return fmt.Errorf("filter: --modified-dst with --min-age-dst/--max-age-dst: %w", err)
+		}
+	}
+	f.dstTime = &dst
+	fs.Debugf(nil, "Destination modification time window from %v to %v", dst.from, dst.to)
+	return nil
+}
+
+// modTimeWindow returns the time window for the side of the sync ctx
+// is for.
+func (f *Filter) modTimeWindow(ctx context.Context) timeWindow {
+	if f.dstTime != nil && IsDestination(ctx) {
+		return *f.dstTime
+	}
+	return timeWindow{from: f.ModTimeFrom, to: f.ModTimeTo}
+}
+
+// ModTimeWindow returns the range of modification times included for
+// the listing in ctx. A zero time means that end is open.
+//
+// This will be the destination time window if the listing is of the
+// destination and one was set.
+func (f *Filter) ModTimeWindow(ctx context.Context) (from, to time.Time) {
+	w := f.modTimeWindow(ctx)
+	return w.from, w.to
+}
+
+// Context key for the destination flag
+type destinationContextKeyType struct{}
+
+var destinationContextKey = destinationContextKeyType{}
+
+// SetDestination returns a context marking that the objects listed
+// with it are on the destination, so the destination time window
+// filters apply to them.
+func SetDestination(ctx context.Context) context.Context {
+	return context.WithValue(ctx, destinationContextKey, true)
+}
+
+// IsDestination returns true if ctx was marked by SetDestination
+func IsDestination(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	isDst, _ := ctx.Value(destinationContextKey).(bool)
+	return isDst
+}
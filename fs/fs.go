@@ -45,11 +45,14 @@ var (
 	ErrorOverlapping                 = errors.New("can't sync or move files on overlapping remotes (try excluding the destination with a filter rule)")
 	ErrorDirectoryNotEmpty           = errors.New("directory not empty")
 	ErrorImmutableModified           = errors.New("immutable file modified")
+	ErrorImmutableMissing            = errors.New("immutable file missing")
+	ErrorImmutableDelete             = errors.New("can't delete immutable file")
 	ErrorPermissionDenied            = errors.New("permission denied")
 	ErrorCantShareDirectories        = errors.New("this backend can't share directories with link")
 	ErrorNotImplemented              = errors.New("optional feature not implemented")
 	ErrorCommandNotFound             = errors.New("command not found")
 	ErrorFileNameTooLong             = errors.New("file name too long")
+	ErrorCantUndelete                = errors.New("can't undelete file - a file with that name exists")
 )
 
 // CheckClose is a utility function used to check the return from
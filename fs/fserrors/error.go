@@ -237,6 +237,55 @@ func IsNoLowLevelRetryError(err error) (isNoLowLevelRetry bool) {
 	return
 }
 
+// Quotaer is an optional interface for error as to whether the
+// operation failed because the destination is out of quota.
+//
+// This should be returned from Update or Put methods as required
+type Quotaer interface {
+	error
+	QuotaExceeded() bool
+}
+
+// wrappedQuotaError is an error wrapped so it will satisfy the
+// Quotaer interface and return true
+type wrappedQuotaError struct {
+	error
+}
+
+// QuotaExceeded interface
+func (err wrappedQuotaError) QuotaExceeded() bool {
+	return true
+}
+
+// Check interfaces
+var _ Quotaer = wrappedQuotaError{error(nil)}
+var _ unwrapper = wrappedQuotaError{}
+
+// QuotaError makes an error which indicates the destination has run
+// out of quota so further uploads will fail too.
+func QuotaError(err error) error {
+	return wrappedQuotaError{err}
+}
+
+// Unwrap returns the underlying error
+func (err wrappedQuotaError) Unwrap() error {
+	return err.error
+}
+
+// IsQuotaError returns true if err conforms to the Quotaer interface
+// and calling the QuotaExceeded method returns true, or if err is
+// caused by a local disk being full.
+func IsQuotaError(err error) (isQuota bool) {
+	liberrors.Walk(err, func(err error) bool {
+		if r, ok := err.(Quotaer); ok {
+			isQuota = r.QuotaExceeded()
+			return true
+		}
+		return false
+	})
+	return isQuota || IsErrNoSpace(err)
+}
+
 // RetryAfter is an optional interface for error as to whether the
 // operation should be retried after a given delay
 //
@@ -159,6 +159,17 @@ func TestRetryAfter(t *testing.T) {
 	assert.Contains(t, e.Error(), "try again after")
 }
 
+func TestQuotaError(t *testing.T) {
+	e := QuotaError(io.EOF)
+	assert.True(t, IsQuotaError(e))
+	assert.Equal(t, io.EOF, errors.Unwrap(e))
+	assert.True(t, IsQuotaError(fmt.Errorf("potato: %w", e)))
+	assert.True(t, IsQuotaError(FatalError(e)))
+	assert.True(t, IsFatalError(FatalError(e)))
+	assert.False(t, IsQuotaError(io.EOF))
+	assert.False(t, IsQuotaError(nil))
+}
+
 func TestContextError(t *testing.T) {
 	var err = io.EOF
 	ctx, cancel := context.WithCancel(context.Background())
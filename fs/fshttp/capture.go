@@ -0,0 +1,501 @@
+package fshttp
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/lib/atexit"
+)
+
+// captureMaxBody is the largest request or response body written to
+// a capture. Bodies which aren't text, like file contents, are never
+// written.
+const captureMaxBody = 64 * 1024
+
+// captureRedacted replaces the sensitive values in a capture
+const captureRedacted = "XXXX"
+
+var (
+	// reCaptureSensitive matches the names of headers, query
+	// parameters and body fields whose values are removed from
+	// captures.
+	reCaptureSensitive = regexp.MustCompile(`(?i)auth|token|secret|passw|credential|signature|cookie|session|api[-_]?key|access[-_]?key|private[-_]?key|customer[-_]?key|^sig$`)
+
+	// reCaptureAllowed matches the names which look sensitive but
+	// whose values are useful and safe to keep, like token_type.
+	reCaptureAllowed = regexp.MustCompile(`(?i)type$|expir|_in$`)
+
+	// reCaptureJSON matches string fields in JSON bodies
+	reCaptureJSON = regexp.MustCompile(`"([^"\\]*)"(\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+	// reCaptureXML matches elements containing only text in XML bodies
+	reCaptureXML = regexp.MustCompile(`<([A-Za-z_][-\w.:]*)>[^<]*</`)
+)
+
+// Globals
+var (
+	captureMu sync.Mutex
+	capturers = map[string]*capturer{} // open captures by file name
+	captureRC string                   // file set by debug/capture-http, "" if none
+)
+
+// capturer writes HTTP transactions to a .tar.gz bundle
+type capturer struct {
+	path   string
+	mu     sync.Mutex
+	f      *os.File
+	gz     *gzip.Writer
+	tw     *tar.Writer
+	n      int   // number of transactions started
+	closed bool  // set when the bundle has been closed
+	err    error // error opening or writing the bundle
+	atexit atexit.FnHandle
+}
+
+// getCapturer returns the capturer writing to path, creating it if
+// necessary. It returns nil if the bundle couldn't be created.
+func getCapturer(path string) *capturer {
+	captureMu.Lock()
+	defer captureMu.Unlock()
+	c := capturers[path]
+	if c == nil {
+		c = newCapturer(path)
+		capturers[path] = c
+	}
+	if c.err != nil {
+		return nil
+	}
+	return c
+}
+
+// newCapturer creates the bundle at path
+func newCapturer(path string) *capturer {
+	c := &capturer{path: path}
+	c.f, c.err = os.Create(path)
+	if c.err != nil {
+		fs.Errorf(nil, "Failed to create HTTP capture: %v", c.err)
+		return c
+	}
+	c.gz = gzip.NewWriter(c.f)
+	c.tw = tar.NewWriter(c.gz)
+	c.atexit = atexit.Register(func() {
+		_ = c.close()
+	})
+	info := fmt.Sprintf("rclone %s\nos/arch: %s/%s\ngo version: %s\nstarted: %s\n",
+		fs.Version, runtime.GOOS, runtime.GOARCH, runtime.Version(), time.Now().Format(time.RFC3339))
+	c.write("info.txt", []byte(info))
+	fs.Logf(nil, "Capturing HTTP transactions to %q", path)
+	return c
+}
+
+// write an entry called name to the bundle
+func (c *capturer) write(name string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.err != nil {
+		return
+	}
+	err := c.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	})
+	if err == nil {
+		_, err = c.tw.Write(data)
+	}
+	if err == nil {
+		// Flush so the bundle is readable if rclone crashes
+		err = c.tw.Flush()
+	}
+	if err == nil {
+		err = c.gz.Flush()
+	}
+	if err != nil {
+		c.err = err
+		fs.Errorf(nil, "Failed to write HTTP capture %q: %v", c.path, err)
+	}
+}
+
+// close the bundle
+func (c *capturer) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.f == nil {
+		return c.err
+	}
+	c.closed = true
+	atexit.Unregister(c.atexit)
+	err := c.tw.Close()
+	if closeErr := c.gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := c.f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		c.err = err
+		return fmt.Errorf("failed to close HTTP capture %q: %w", c.path, err)
+	}
+	fs.Logf(nil, "Wrote %d HTTP transactions to %q", c.n, c.path)
+	return c.err
+}
+
+// captureTransaction is an HTTP transaction being captured
+type captureTransaction struct {
+	c     *capturer
+	name  string
+	start time.Time
+	req   []byte // the sanitised request
+}
+
+// start capturing req
+func (c *capturer) start(req *http.Request) *captureTransaction {
+	c.mu.Lock()
+	c.n++
+	n := c.n
+	c.mu.Unlock()
+	var b bytes.Buffer
+	_, _ = fmt.Fprintf(&b, "%s %s %s\r\nHost: %s\r\n", req.Method, captureURL(req.URL), req.Proto, req.Host)
+	_ = captureHeader(req.Header).Write(&b)
+	b.WriteString("\r\n")
+	writeRequestBody(&b, req)
+	return &captureTransaction{
+		c:     c,
+		name:  fmt.Sprintf("%06d-%s-%s.txt", n, req.Method, strings.ReplaceAll(req.URL.Host, ":", "_")),
+		start: time.Now(),
+		req:   b.Bytes(),
+	}
+}
+
+// finish the transaction with the response or error from the round
+// trip, returning the response to use.
+//
+// If the response body is being captured it is written when the body
+// is closed, otherwise it is written now.
+func (tx *captureTransaction) finish(resp *http.Response, err error) *http.Response {
+	if err != nil {
+		tx.write(nil, nil, err)
+		return resp
+	}
+	var b bytes.Buffer
+	_, _ = fmt.Fprintf(&b, "%s %s\r\n", resp.Proto, resp.Status)
+	_ = captureHeader(resp.Header).Write(&b)
+	b.WriteString("\r\n")
+	header := b.Bytes()
+	contentType := resp.Header.Get("Content-Type")
+	if resp.Body == nil || resp.Body == http.NoBody || resp.ContentLength == 0 {
+		tx.write(header, nil, nil)
+	} else if !isTextContent(contentType) {
+		tx.write(header, fmt.Appendf(nil, "[%s body not captured]\r\n", describeBody(resp.ContentLength, contentType)), nil)
+	} else {
+		resp.Body = &captureBody{
+			ReadCloser:  resp.Body,
+			tx:          tx,
+			header:      header,
+			contentType: contentType,
+		}
+	}
+	return resp
+}
+
+// write the transaction to the bundle
+func (tx *captureTransaction) write(respHeader, respBody []byte, err error) {
+	var b bytes.Buffer
+	_, _ = fmt.Fprintf(&b, "# Time: %s\n# Duration: %v\n", tx.start.Format(time.RFC3339Nano), time.Since(tx.start))
+	if err != nil {
+		_, _ = fmt.Fprintf(&b, "# Error: %v\n", err)
+	}
+	b.WriteString("\n")
+	b.Write(tx.req)
+	if respHeader != nil {
+		b.WriteString("\r\n")
+		b.Write(respHeader)
+		b.Write(respBody)
+	}
+	tx.c.write(tx.name, b.Bytes())
+}
+
+// captureBody keeps the start of a text response body as it is read
+// and writes the transaction when it is closed.
+type captureBody struct {
+	io.ReadCloser
+	tx          *captureTransaction
+	header      []byte
+	contentType string
+	buf         bytes.Buffer
+	n           int64
+	once        sync.Once
+}
+
+// Read the body keeping the first captureMaxBody bytes
+func (cb *captureBody) Read(p []byte) (n int, err error) {
+	n, err = cb.ReadCloser.Read(p)
+	cb.n += int64(n)
+	if room := captureMaxBody - cb.buf.Len(); room > 0 {
+		cb.buf.Write(p[:min(n, room)])
+	}
+	return n, err
+}
+
+// Close the body and write the transaction
+func (cb *captureBody) Close() error {
+	err := cb.ReadCloser.Close()
+	cb.once.Do(func() {
+		body := sanitizeBody(cb.contentType, cb.buf.Bytes())
+		if cb.n > int64(cb.buf.Len()) {
+			body = fmt.Appendf(body, "\r\n[%d more bytes not captured]", cb.n-int64(cb.buf.Len()))
+		}
+		body = append(body, "\r\n"...)
+		cb.tx.write(cb.header, body, nil)
+	})
+	return err
+}
+
+// writeRequestBody writes the request body to b if it is text and
+// can be read without disturbing the request.
+func writeRequestBody(b *bytes.Buffer, req *http.Request) {
+	if req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0 {
+		return
+	}
+	contentType := req.Header.Get("Content-Type")
+	if !isTextContent(contentType) || req.GetBody == nil || req.ContentLength < 0 || req.ContentLength > captureMaxBody {
+		_, _ = fmt.Fprintf(b, "[%s body not captured]\r\n", describeBody(req.ContentLength, contentType))
+		return
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		_, _ = fmt.Fprintf(b, "[failed to read body: %v]\r\n", err)
+		return
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(body, captureMaxBody))
+	if err != nil {
+		_, _ = fmt.Fprintf(b, "[failed to read body: %v]\r\n", err)
+		return
+	}
+	b.Write(sanitizeBody(contentType, data))
+	b.WriteString("\r\n")
+}
+
+// describeBody describes a body which isn't captured
+func describeBody(size int64, contentType string) string {
+	if contentType == "" {
+		contentType = "unknown type"
+	}
+	if size < 0 {
+		return "unknown length " + contentType
+	}
+	return fmt.Sprintf("%d byte %s", size, contentType)
+}
+
+// isTextContent returns true if contentType is text which can be
+// captured.
+func isTextContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+// isSensitive returns true if the value of name should be removed
+func isSensitive(name string) bool {
+	return reCaptureSensitive.MatchString(name) && !reCaptureAllowed.MatchString(name)
+}
+
+// captureHeader returns a copy of header with the sensitive values removed
+func captureHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for name, values := range header {
+		if isSensitive(name) {
+			for i := range values {
+				values[i] = captureRedacted
+			}
+		}
+	}
+	return header
+}
+
+// captureURL returns u as a string with the sensitive values removed
+func captureURL(u *url.URL) string {
+	u2 := *u
+	if u2.User != nil {
+		u2.User = url.User(captureRedacted)
+	}
+	query := u2.Query()
+	changed := false
+	for name, values := range query {
+		if isSensitive(name) || name == "code" {
+			for i := range values {
+				values[i] = captureRedacted
+			}
+			changed = true
+		}
+	}
+	if changed {
+		u2.RawQuery = query.Encode()
+	}
+	return u2.String()
+}
+
+// sanitizeBody removes the sensitive values from the body
+func sanitizeBody(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		values, err := url.ParseQuery(string(body))
+		if err == nil {
+			for name := range values {
+				if isSensitive(name) || name == "code" {
+					values.Set(name, captureRedacted)
+				}
+			}
+			return []byte(values.Encode())
+		}
+	}
+	body = reCaptureJSON.ReplaceAllFunc(body, func(match []byte) []byte {
+		parts := reCaptureJSON.FindSubmatch(match)
+		if !isSensitive(string(parts[1])) {
+			return match
+		}
+		return fmt.Appendf(nil, `"%s"%s"%s"`, parts[1], parts[2], captureRedacted)
+	})
+	body = reCaptureXML.ReplaceAllFunc(body, func(match []byte) []byte {
+		parts := reCaptureXML.FindSubmatch(match)
+		if !isSensitive(string(parts[1])) {
+			return match
+		}
+		return fmt.Appendf(nil, "<%s>%s</", parts[1], captureRedacted)
+	})
+	return body
+}
+
+// capturer returns the capturer for this transport or nil if it
+// isn't capturing.
+func (t *Transport) capturer() *capturer {
+	path := t.ci.CaptureHTTP
+	if path == "" {
+		captureMu.Lock()
+		path = captureRC
+		captureMu.Unlock()
+	}
+	if path == "" {
+		return nil
+	}
+	return getCapturer(path)
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "debug/capture-http",
+		Fn:    rcCaptureHTTP,
+		Title: "Start or stop capturing HTTP transactions for a bug report.",
+		Help: `
+This starts writing the HTTP transactions of all the remotes to a
+.tar.gz bundle in the same way as --capture-http, or stops doing it.
+
+The bundle has a text file for each transaction with the request and
+response headers and text bodies up to 64 KiB. Credentials like
+Authorization headers, signatures in URLs and tokens in bodies are
+replaced with XXXX. File contents aren't written.
+
+Parameters:
+
+- file - start writing a new bundle to this file, e.g. "/tmp/rclone-capture.tar.gz"
+- stop - set to true to stop capturing and close the bundle
+
+With no parameters it shows whether a capture is running.
+
+Results:
+
+- file - the bundle being or last written
+- capturing - true if the capture is running
+- transactions - the number of transactions captured
+
+E.g.
+
+    rclone rc debug/capture-http file=/tmp/rclone-capture.tar.gz
+    rclone rc debug/capture-http stop=true
+`,
+	})
+}
+
+// rcCaptureHTTP starts or stops capturing from the rc
+func rcCaptureHTTP(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	file, err := in.GetString("file")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	stop, err := in.GetBool("stop")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	if file != "" && stop {
+		return nil, errors.New("can't set both file and stop")
+	}
+	captureMu.Lock()
+	old := captureRC
+	c := capturers[old]
+	if file != "" || stop {
+		captureRC = ""
+		delete(capturers, old)
+	}
+	captureMu.Unlock()
+
+	// Close the old capture if stopping or starting a new one
+	if c != nil && (file != "" || stop) {
+		err = c.close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if file != "" {
+		captureMu.Lock()
+		captureRC = file
+		captureMu.Unlock()
+		c = getCapturer(file)
+		if c == nil {
+			captureMu.Lock()
+			captureRC = ""
+			err = capturers[file].err
+			delete(capturers, file)
+			captureMu.Unlock()
+			return nil, fmt.Errorf("failed to start HTTP capture: %w", err)
+		}
+	}
+
+	out = rc.Params{
+		"file":         old,
+		"capturing":    false,
+		"transactions": 0,
+	}
+	if c != nil {
+		c.mu.Lock()
+		out["file"] = c.path
+		out["capturing"] = !c.closed
+		out["transactions"] = c.n
+		c.mu.Unlock()
+	}
+	return out, nil
+}
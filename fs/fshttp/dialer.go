@@ -2,8 +2,11 @@ package fshttp
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -14,11 +17,18 @@ import (
 	"golang.org/x/net/ipv6"
 )
 
+// Default delay before trying the other IP version, as used by net.Dialer
+const defaultHappyEyeballsDelay = 300 * time.Millisecond
+
 // Dialer structure contains default dialer and timeout, tclass support
 type Dialer struct {
 	net.Dialer
-	timeout time.Duration
-	tclass  int
+	timeout   time.Duration
+	tclass    int
+	family    fs.AddressFamily
+	iface     string              // interface to bind to if set
+	overrides map[string][]net.IP // addresses to use instead of DNS for host names
+	err       error               // set if the config couldn't be used
 }
 
 // NewDialer creates a Dialer structure with Timeout, Keepalive,
@@ -32,13 +42,97 @@ func NewDialer(ctx context.Context) *Dialer {
 		},
 		timeout: time.Duration(ci.Timeout),
 		tclass:  int(ci.TrafficClass),
+		family:  ci.AddressFamily,
+		iface:   ci.BindInterface,
+	}
+	if ci.HappyEyeballsDelay == fs.DurationOff {
+		dialer.Dialer.FallbackDelay = -1
+	} else {
+		dialer.Dialer.FallbackDelay = time.Duration(ci.HappyEyeballsDelay)
+	}
+	bind, err := bindIP(ci)
+	if err != nil {
+		dialer.err = err
+	} else if bind != nil {
+		dialer.Dialer.LocalAddr = &net.TCPAddr{IP: bind}
 	}
-	if ci.BindAddr != nil {
-		dialer.Dialer.LocalAddr = &net.TCPAddr{IP: ci.BindAddr}
+	dialer.overrides, err = parseDNSOverrides(ci.DNSOverride)
+	if err != nil {
+		dialer.err = err
 	}
 	return dialer
 }
 
+var bindIPs sync.Map // host name to IP for --bind
+
+// bindIP returns the local IP set with --bind or nil if not set
+func bindIP(ci *fs.ConfigInfo) (net.IP, error) {
+	if ci.Bind == "" {
+		return ci.BindAddr, nil
+	}
+	if ip := net.ParseIP(ci.Bind); ip != nil {
+		return ip, nil
+	}
+	if ip, ok := bindIPs.Load(ci.Bind); ok {
+		return ip.(net.IP), nil
+	}
+	addrs, err := net.LookupIP(ci.Bind)
+	if err != nil {
+		return nil, fmt.Errorf("bind: failed to parse %q as IP address: %w", ci.Bind, err)
+	}
+	if len(addrs) != 1 {
+		return nil, fmt.Errorf("bind: expecting 1 IP address for %q but got %d", ci.Bind, len(addrs))
+	}
+	bindIPs.Store(ci.Bind, addrs[0])
+	return addrs[0], nil
+}
+
+// parseDNSOverrides parses the host=IP entries of --dns-override
+func parseDNSOverrides(entries []string) (map[string][]net.IP, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	overrides := make(map[string][]net.IP, len(entries))
+	for _, entry := range entries {
+		host, ipString, ok := strings.Cut(entry, "=")
+		ip := net.ParseIP(strings.TrimSpace(ipString))
+		host = strings.ToLower(strings.TrimSpace(host))
+		if !ok || ip == nil || host == "" {
+			return nil, fmt.Errorf("dns_override: can't parse %q, expecting host=IP", entry)
+		}
+		overrides[host] = append(overrides[host], ip)
+	}
+	return overrides, nil
+}
+
+// isFamily returns true if ip is of family "4" or "6"
+func isFamily(ip net.IP, family string) bool {
+	return (ip.To4() != nil) == (family == "4")
+}
+
+// interfaceIP returns the first address of family "4" or "6" on the
+// interface, or nil if it doesn't have one.
+func interfaceIP(name, family string) (net.IP, error) {
+	ifi, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("bind_interface: %w", err)
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("bind_interface: failed to read addresses of %q: %w", name, err)
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if isFamily(ipNet.IP, family) {
+			return ipNet.IP, nil
+		}
+	}
+	return nil, nil
+}
+
 // Dial connects to the network address.
 func (d *Dialer) Dial(network, address string) (net.Conn, error) {
 	return d.DialContext(context.Background(), network, address)
@@ -48,18 +142,10 @@ var warnDSCPFail, warnDSCPWindows sync.Once
 
 // DialContext connects to the network address using the provided context.
 func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
-	// If local address is 0.0.0.0 or ::0 force IPv4 or IPv6
-	// This works around https://github.com/golang/go/issues/48723
-	// Which means 0.0.0.0 and ::0 both bind to both IPv4 and IPv6
-	if ip, ok := d.Dialer.LocalAddr.(*net.TCPAddr); ok && ip.IP.IsUnspecified() && (network == "tcp" || network == "udp") {
-		if ip.IP.To4() != nil {
-			network += "4" // IPv4 address
-		} else {
-			network += "6" // IPv6 address
-		}
+	if d.err != nil {
+		return nil, d.err
 	}
-
-	c, err := d.Dialer.DialContext(ctx, network, address)
+	c, err := d.dial(ctx, network, address)
 	if err != nil {
 		return c, err
 	}
@@ -91,6 +177,161 @@ func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.
 	return t, t.nudgeDeadline()
 }
 
+// dialAttempt is one way of connecting to an address
+type dialAttempt struct {
+	network string
+	address string
+	local   net.Addr
+}
+
+// dial connects to the network address, choosing the IP version,
+// local address and remote address as configured.
+func (d *Dialer) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	var ips []net.IP
+	if err == nil {
+		ips = d.overrides[strings.ToLower(host)]
+	}
+	var families []string
+	switch {
+	case err != nil:
+	case network == "tcp4":
+		families = []string{"4"}
+	case network == "tcp6":
+		families = []string{"6"}
+	case network != "tcp":
+	case d.family == fs.AddressFamilyIPv4:
+		families = []string{"4"}
+	case d.family == fs.AddressFamilyIPv6:
+		families = []string{"6"}
+	case d.family == fs.AddressFamilyPreferIPv4:
+		families = []string{"4", "6"}
+	case d.family == fs.AddressFamilyPreferIPv6:
+		families = []string{"6", "4"}
+	case len(ips) > 0 && ips[0].To4() != nil:
+		families = []string{"4", "6"}
+	case len(ips) > 0 || d.iface != "":
+		families = []string{"6", "4"}
+	}
+	if families == nil {
+		return d.dialNetwork(ctx, dialAttempt{network: network, address: address, local: d.Dialer.LocalAddr})
+	}
+
+	// Work out the address to connect to and the local address for
+	// each IP version in the order they should be tried
+	var attempts []dialAttempt
+	for _, family := range families {
+		attempt := dialAttempt{network: "tcp" + family, address: address, local: d.Dialer.LocalAddr}
+		if local, ok := attempt.local.(*net.TCPAddr); ok && !isFamily(local.IP, family) {
+			continue
+		}
+		if d.iface != "" {
+			ip, err := interfaceIP(d.iface, family)
+			if err != nil {
+				return nil, err
+			}
+			if ip == nil {
+				continue
+			}
+			attempt.local = &net.TCPAddr{IP: ip}
+		}
+		if ips != nil {
+			i := slices.IndexFunc(ips, func(ip net.IP) bool { return isFamily(ip, family) })
+			if i < 0 {
+				continue
+			}
+			attempt.address = net.JoinHostPort(ips[i].String(), port)
+		}
+		attempts = append(attempts, attempt)
+	}
+	if len(attempts) == 0 {
+		return nil, fmt.Errorf("no IPv%s address to connect to %q with", strings.Join(families, " or IPv"), address)
+	}
+	return d.dialRace(ctx, attempts)
+}
+
+// dialNetwork makes a single connection attempt
+func (d *Dialer) dialNetwork(ctx context.Context, attempt dialAttempt) (net.Conn, error) {
+	dialer := d.Dialer
+	dialer.LocalAddr = attempt.local
+	network := attempt.network
+	// If local address is 0.0.0.0 or ::0 force IPv4 or IPv6
+	// This works around https://github.com/golang/go/issues/48723
+	// Which means 0.0.0.0 and ::0 both bind to both IPv4 and IPv6
+	if ip, ok := dialer.LocalAddr.(*net.TCPAddr); ok && ip.IP.IsUnspecified() && (network == "tcp" || network == "udp") {
+		if ip.IP.To4() != nil {
+			network += "4" // IPv4 address
+		} else {
+			network += "6" // IPv6 address
+		}
+	}
+	return dialer.DialContext(ctx, network, attempt.address)
+}
+
+// dialRace tries the attempts in order returning the first connection
+// made.
+//
+// Like net.Dialer the next attempt is started if the previous one
+// fails or hasn't connected within the happy eyeballs delay.
+func (d *Dialer) dialRace(ctx context.Context, attempts []dialAttempt) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	delay := d.Dialer.FallbackDelay
+	if delay == 0 {
+		delay = defaultHappyEyeballsDelay
+	}
+	type result struct {
+		c   net.Conn
+		err error
+	}
+	results := make(chan result, len(attempts))
+	started, pending := 0, 0
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	start := func() {
+		attempt := attempts[started]
+		started++
+		pending++
+		go func() {
+			c, err := d.dialNetwork(ctx, attempt)
+			results <- result{c: c, err: err}
+		}()
+		if delay > 0 {
+			timer.Reset(delay)
+		}
+	}
+	start()
+	var errs []error
+	for pending > 0 {
+		var fallback <-chan time.Time
+		if started < len(attempts) && delay > 0 {
+			fallback = timer.C
+		}
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				// Close any connections made by the other attempts
+				go func(pending int) {
+					for range pending {
+						if r := <-results; r.err == nil {
+							_ = r.c.Close()
+						}
+					}
+				}(pending)
+				return r.c, nil
+			}
+			errs = append(errs, r.err)
+			if started < len(attempts) {
+				start()
+			}
+		case <-fallback:
+			start()
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
 // A net.Conn that sets deadline for every Read/Write operation
 type timeoutConn struct {
 	net.Conn
@@ -0,0 +1,134 @@
+package fshttp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listenLocal starts a TCP listener on 127.0.0.1 accepting connections
+// returning its port
+func listenLocal(t *testing.T) string {
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = c.Close()
+		}
+	}()
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	return port
+}
+
+func TestParseDNSOverrides(t *testing.T) {
+	overrides, err := parseDNSOverrides(nil)
+	require.NoError(t, err)
+	assert.Nil(t, overrides)
+
+	overrides, err = parseDNSOverrides([]string{"Example.com=192.0.2.1", "example.com = 2001:db8::1", "other.com=192.0.2.2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]net.IP{
+		"example.com": {net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")},
+		"other.com":   {net.ParseIP("192.0.2.2")},
+	}, overrides)
+
+	for _, bad := range []string{"example.com", "example.com=potato", "=192.0.2.1"} {
+		_, err = parseDNSOverrides([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestDialerDNSOverride(t *testing.T) {
+	port := listenLocal(t)
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.DNSOverride = []string{"rclone.invalid=127.0.0.1"}
+
+	c, err := NewDialer(ctx).DialContext(ctx, "tcp", net.JoinHostPort("rclone.invalid", port))
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:"+port, c.RemoteAddr().String())
+	require.NoError(t, c.Close())
+
+	// Only IPv6 wanted but only an IPv4 address known
+	ci.AddressFamily = fs.AddressFamilyIPv6
+	_, err = NewDialer(ctx).DialContext(ctx, "tcp", net.JoinHostPort("rclone.invalid", port))
+	assert.ErrorContains(t, err, "no IPv6 address")
+}
+
+func TestDialerFallback(t *testing.T) {
+	port := listenLocal(t)
+	ctx, ci := fs.AddConfig(context.Background())
+
+	// IPv6 is preferred but nothing is listening there so it
+	// should fall back to IPv4
+	ci.DNSOverride = []string{"rclone.invalid=::1", "rclone.invalid=127.0.0.1"}
+	ci.AddressFamily = fs.AddressFamilyPreferIPv6
+	for _, delay := range []fs.Duration{0, fs.Duration(time.Millisecond), fs.DurationOff} {
+		ci.HappyEyeballsDelay = delay
+		c, err := NewDialer(ctx).DialContext(ctx, "tcp", net.JoinHostPort("rclone.invalid", port))
+		require.NoError(t, err, delay)
+		assert.Equal(t, "127.0.0.1:"+port, c.RemoteAddr().String())
+		require.NoError(t, c.Close())
+	}
+}
+
+func TestDialerBind(t *testing.T) {
+	port := listenLocal(t)
+	ctx, ci := fs.AddConfig(context.Background())
+
+	ci.Bind = "127.0.0.1"
+	c, err := NewDialer(ctx).DialContext(ctx, "tcp", "127.0.0.1:"+port)
+	require.NoError(t, err)
+	local := c.LocalAddr().(*net.TCPAddr)
+	assert.Equal(t, "127.0.0.1", local.IP.String())
+	require.NoError(t, c.Close())
+
+	ci.Bind = "rclone.invalid"
+	_, err = NewDialer(ctx).DialContext(ctx, "tcp", "127.0.0.1:"+port)
+	assert.ErrorContains(t, err, "bind:")
+}
+
+func TestDialerBindInterface(t *testing.T) {
+	// Find the loopback interface
+	ifaces, err := net.Interfaces()
+	require.NoError(t, err)
+	name := ""
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			continue
+		}
+		if ip, err := interfaceIP(iface.Name, "4"); err == nil && ip != nil {
+			name = iface.Name
+			break
+		}
+	}
+	if name == "" {
+		t.Skip("no loopback interface with an IPv4 address")
+	}
+
+	port := listenLocal(t)
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.BindInterface = name
+	ci.AddressFamily = fs.AddressFamilyIPv4
+	c, err := NewDialer(ctx).DialContext(ctx, "tcp", "127.0.0.1:"+port)
+	require.NoError(t, err)
+	local := c.LocalAddr().(*net.TCPAddr)
+	assert.True(t, local.IP.IsLoopback())
+	require.NoError(t, c.Close())
+
+	ci.BindInterface = "rclone-no-such-interface"
+	_, err = NewDialer(ctx).DialContext(ctx, "tcp", "127.0.0.1:"+port)
+	assert.ErrorContains(t, err, "bind_interface:")
+}
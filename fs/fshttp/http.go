@@ -21,6 +21,7 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/accounting"
 	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/lib/proxy"
 	"github.com/rclone/rclone/lib/structs"
 	"github.com/youmark/pkcs8"
 	"golang.org/x/net/publicsuffix"
@@ -211,14 +212,19 @@ func NewTransportCustom(ctx context.Context, customize func(*http.Transport)) *T
 	// This also means we get new stuff when it gets added to go
 	t := new(http.Transport)
 	structs.SetDefaults(t, http.DefaultTransport.(*http.Transport))
+	var proxyChain []*url.URL // set if dialling through SOCKS5 or several proxies
 	if ci.HTTPProxy != "" {
-		proxyURL, err := url.Parse(ci.HTTPProxy)
-		if err != nil {
+		proxies, err := proxy.ParseURLs(ci.HTTPProxy)
+		switch {
+		case err != nil:
 			t.Proxy = func(*http.Request) (*url.URL, error) {
-				return nil, fmt.Errorf("failed to set --http-proxy from %q: %w", ci.HTTPProxy, err)
+				return nil, fmt.Errorf("failed to set --http-proxy: %w", err)
 			}
-		} else {
-			t.Proxy = http.ProxyURL(proxyURL)
+		case len(proxies) == 1 && (proxies[0].Scheme == "http" || proxies[0].Scheme == "https"):
+			t.Proxy = http.ProxyURL(proxies[0])
+		default:
+			t.Proxy = nil
+			proxyChain = proxies
 		}
 	} else {
 		t.Proxy = http.ProxyFromEnvironment
@@ -266,6 +272,9 @@ func NewTransportCustom(ctx context.Context, customize func(*http.Transport)) *T
 
 	t.DisableCompression = ci.NoGzip
 	t.DialContext = func(reqCtx context.Context, network, addr string) (net.Conn, error) {
+		if proxyChain != nil {
+			return proxy.ChainDialer(proxyChain, NewDialer(ctx)).DialContext(reqCtx, network, addr)
+		}
 		return NewDialer(ctx).DialContext(reqCtx, network, addr)
 	}
 	t.IdleConnTimeout = 60 * time.Second
@@ -340,6 +349,7 @@ type Transport struct {
 	userAgent     string
 	headers       []*fs.HTTPOption
 	metrics       *Metrics
+	http3         *http3Transport // set if --use-http3
 	// Mutex for serializing attempts at reloading the certificates
 	reloadMutex sync.Mutex
 }
@@ -347,7 +357,7 @@ type Transport struct {
 // newTransport wraps the http.Transport passed in and logs all
 // roundtrips including the body if logBody is set.
 func newTransport(ci *fs.ConfigInfo, transport *http.Transport) *Transport {
-	return &Transport{
+	t := &Transport{
 		Transport: transport,
 		ci:        ci,
 		dump:      ci.Dump,
@@ -355,6 +365,10 @@ func newTransport(ci *fs.ConfigInfo, transport *http.Transport) *Transport {
 		headers:   ci.Headers,
 		metrics:   DefaultMetrics,
 	}
+	if ci.UseHTTP3 && ci.HTTPProxy == "" {
+		t.http3 = newHTTP3Transport(ci, transport.TLSClientConfig)
+	}
+	return t
 }
 
 // SetRequestFilter sets a filter to be used on each request
@@ -492,8 +506,21 @@ func (t *Transport) RoundTrip(req *http.Request) (resp *http.Response, err error
 		fs.Debugf(nil, "%s", separatorReq)
 		logMutex.Unlock()
 	}
+	// Capture request if required
+	var tx *captureTransaction
+	if capture := t.capturer(); capture != nil {
+		tx = capture.start(req)
+	}
 	// Do round trip
-	resp, err = t.Transport.RoundTrip(req)
+	if t.http3 != nil {
+		resp, err = t.http3.RoundTrip(req, t.Transport)
+	} else {
+		resp, err = t.Transport.RoundTrip(req)
+	}
+	// Capture response if required
+	if tx != nil {
+		resp = tx.finish(resp, err)
+	}
 	// Logf response
 	if t.dump&(fs.DumpHeaders|fs.DumpBodies|fs.DumpAuth|fs.DumpRequests|fs.DumpResponses) != 0 {
 		logMutex.Lock()
@@ -0,0 +1,498 @@
+package fshttp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+)
+
+// altSvcDefaultMaxAge is how long an Alt-Svc advert lasts if it
+// doesn't have an ma parameter as defined in RFC 7838.
+const altSvcDefaultMaxAge = 24 * time.Hour
+
+// http3RetryAfter is how long to use HTTP/1.1 or HTTP/2 for a host
+// after HTTP/3 to it failed.
+var http3RetryAfter = 5 * time.Minute
+
+// migrateInterval is how often to check whether the local address
+// used by the QUIC connections has changed.
+var migrateInterval = 5 * time.Second
+
+// http3Transport sends requests with HTTP/3 to the hosts which
+// advertise it with an Alt-Svc header and migrates their connections
+// when the local network changes.
+type http3Transport struct {
+	ci        *fs.ConfigInfo
+	rt        *http3.Transport
+	mu        sync.Mutex
+	hosts     map[string]*altSvc       // HTTP/3 adverts keyed by the host:port of the request
+	conns     map[*quic.Conn]*quicConn // open QUIC connections
+	migrating bool                     // set if the migrator is running
+}
+
+// altSvc is an HTTP/3 alternative service advertised by a host
+type altSvc struct {
+	addr    string    // host:port to connect to with HTTP/3
+	expires time.Time // when the advert expires
+	failed  time.Time // when HTTP/3 last failed, zero if it hasn't
+	working bool      // set if a request has succeeded with HTTP/3
+}
+
+// quicConn is a QUIC connection we have dialled
+type quicConn struct {
+	conn    *quic.Conn
+	remote  *net.UDPAddr
+	local   net.IP      // local IP the connection is using
+	bound   bool        // set if local comes from --bind so can't change
+	closers []io.Closer // sockets to close when the connection closes
+}
+
+// newHTTP3Transport makes an HTTP/3 transport using tlsConfig
+func newHTTP3Transport(ci *fs.ConfigInfo, tlsConfig *tls.Config) *http3Transport {
+	h := &http3Transport{
+		ci:    ci,
+		hosts: map[string]*altSvc{},
+		conns: map[*quic.Conn]*quicConn{},
+	}
+	h.rt = &http3.Transport{
+		TLSClientConfig: tlsConfig,
+		QUICConfig: &quic.Config{
+			HandshakeIdleTimeout: time.Duration(ci.ConnectTimeout),
+			MaxIdleTimeout:       time.Duration(ci.Timeout),
+		},
+		Dial:               h.dial,
+		DisableCompression: ci.NoGzip,
+	}
+	return h
+}
+
+// authority returns the host:port of the request URL, as used to key
+// the hosts map and passed to dial by http3.Transport.
+func authority(req *http.Request) string {
+	port := req.URL.Port()
+	if port == "" {
+		port = "443"
+	}
+	return net.JoinHostPort(req.URL.Hostname(), port)
+}
+
+// RoundTrip sends req with HTTP/3 if the host has advertised it and
+// it is working, otherwise with fallback.
+func (h *http3Transport) RoundTrip(req *http.Request, fallback *http.Transport) (*http.Response, error) {
+	key := authority(req)
+	if req.URL.Scheme != "https" || usesProxy(req, fallback) || !h.use(key, req) {
+		resp, err := fallback.RoundTrip(req)
+		if err == nil && req.URL.Scheme == "https" {
+			h.parseAltSvc(key, resp.Header.Values("Alt-Svc"))
+		}
+		return resp, err
+	}
+
+	// Note whether the QUIC connection was made so we know if the
+	// request could have been sent.
+	var gotConn bool
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { gotConn = true },
+	}
+	resp, err := h.rt.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err == nil {
+		h.succeeded(key)
+		h.parseAltSvc(key, resp.Header.Values("Alt-Svc"))
+		return resp, nil
+	}
+	if gotConn || req.Context().Err() != nil {
+		return nil, err
+	}
+
+	// The QUIC connection couldn't be made so the request wasn't
+	// sent. Retry it over TCP if the body can be read again.
+	h.failed(key, err)
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, err
+		}
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+	return fallback.RoundTrip(req)
+}
+
+// usesProxy returns true if t would send req through a proxy which
+// HTTP/3 can't use.
+func usesProxy(req *http.Request, t *http.Transport) bool {
+	if t.Proxy == nil {
+		return false
+	}
+	proxyURL, err := t.Proxy(req)
+	return err != nil || proxyURL != nil
+}
+
+// use returns true if req to key should be sent with HTTP/3
+func (h *http3Transport) use(key string, req *http.Request) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	alt := h.hosts[key]
+	if alt == nil {
+		return false
+	}
+	now := time.Now()
+	if now.After(alt.expires) {
+		delete(h.hosts, key)
+		return false
+	}
+	if !alt.failed.IsZero() && now.Sub(alt.failed) < http3RetryAfter {
+		return false
+	}
+	// A body which can't be read again can't be retried over TCP if
+	// the QUIC connection fails, so only send it once HTTP/3 works.
+	if !alt.working && req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	return true
+}
+
+// succeeded marks HTTP/3 to key as working
+func (h *http3Transport) succeeded(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	alt := h.hosts[key]
+	if alt == nil || alt.working {
+		return
+	}
+	alt.working = true
+	alt.failed = time.Time{}
+	fs.Debugf(nil, "Using HTTP/3 for %s", key)
+}
+
+// failed marks HTTP/3 to key as failing with err
+func (h *http3Transport) failed(key string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	alt := h.hosts[key]
+	if alt == nil {
+		return
+	}
+	alt.working = false
+	alt.failed = time.Now()
+	fs.Debugf(nil, "HTTP/3 to %s failed so not using it for %v: %v", key, http3RetryAfter, err)
+}
+
+// parseAltSvc reads the HTTP/3 advert, if any, out of the Alt-Svc
+// headers of a response from key.
+//
+// The headers look like
+//
+//	Alt-Svc: h3=":443"; ma=2592000, h3-29=":443"; ma=2592000
+//	Alt-Svc: clear
+func (h *http3Transport) parseAltSvc(key string, headers []string) {
+	if len(headers) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, header := range headers {
+		for entry := range strings.SplitSeq(header, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "clear" {
+				delete(h.hosts, key)
+				return
+			}
+			params := strings.Split(entry, ";")
+			protocol, value, ok := strings.Cut(strings.TrimSpace(params[0]), "=")
+			if !ok || protocol != "h3" {
+				continue
+			}
+			addr, err := altSvcAddr(key, value)
+			if err != nil {
+				fs.Debugf(nil, "Ignoring Alt-Svc from %s: %v", key, err)
+				continue
+			}
+			maxAge := altSvcDefaultMaxAge
+			for _, param := range params[1:] {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if name == "ma" {
+					seconds, err := strconv.ParseUint(strings.Trim(value, `"`), 10, 32)
+					if err == nil {
+						maxAge = time.Duration(seconds) * time.Second
+					}
+				}
+			}
+			alt := h.hosts[key]
+			if alt == nil || alt.addr != addr {
+				alt = &altSvc{addr: addr}
+				h.hosts[key] = alt
+			}
+			alt.expires = time.Now().Add(maxAge)
+			return
+		}
+	}
+}
+
+// altSvcAddr returns the host:port to connect to from the quoted
+// alternative authority value advertised by key.
+func altSvcAddr(key string, value string) (string, error) {
+	altAuthority, err := strconv.Unquote(value)
+	if err != nil {
+		return "", fmt.Errorf("bad authority %s: %w", value, err)
+	}
+	host, port, err := net.SplitHostPort(altAuthority)
+	if err != nil {
+		return "", fmt.Errorf("bad authority %q: %w", altAuthority, err)
+	}
+	if host == "" {
+		host, _, err = net.SplitHostPort(key)
+		if err != nil {
+			return "", err
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// dial makes a QUIC connection to the advertised address for addr
+func (h *http3Transport) dial(ctx context.Context, addr string, tlsConf *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+	h.mu.Lock()
+	if alt := h.hosts[addr]; alt != nil {
+		addr = alt.addr
+	}
+	h.mu.Unlock()
+	overrides, err := parseDNSOverrides(h.ci.DNSOverride)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := resolveUDPAddr(ctx, addr, overrides)
+	if err != nil {
+		return nil, err
+	}
+	c := &quicConn{
+		remote: remote,
+	}
+	bind, err := bindIP(h.ci)
+	if err != nil {
+		return nil, err
+	}
+	if bind != nil && !bind.IsUnspecified() {
+		c.local = bind
+		c.bound = true
+	} else {
+		c.local, err = localIP(remote)
+		if err != nil {
+			return nil, err
+		}
+	}
+	tr, closers, err := listenQUIC(c.local)
+	if err != nil {
+		return nil, err
+	}
+	c.closers = closers
+	c.conn, err = tr.DialEarly(ctx, remote, tlsConf, cfg)
+	if err != nil {
+		closeAll(closers)
+		return nil, err
+	}
+	h.track(c)
+	return c.conn, nil
+}
+
+// track c until it closes, starting the migrator if needed
+func (h *http3Transport) track(c *quicConn) {
+	h.mu.Lock()
+	h.conns[c.conn] = c
+	if !c.bound && !h.migrating {
+		h.migrating = true
+		go h.migrator()
+	}
+	h.mu.Unlock()
+	go func() {
+		<-c.conn.Context().Done()
+		h.mu.Lock()
+		delete(h.conns, c.conn)
+		closers := c.closers
+		h.mu.Unlock()
+		closeAll(closers)
+	}()
+}
+
+// migrator checks the QUIC connections every migrateInterval while
+// there are any and moves them to a new local address if it changes,
+// for example when switching from WiFi to a wired network.
+func (h *http3Transport) migrator() {
+	ticker := time.NewTicker(migrateInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.mu.Lock()
+		var conns []*quicConn
+		for _, c := range h.conns {
+			if !c.bound {
+				conns = append(conns, c)
+			}
+		}
+		if len(conns) == 0 {
+			h.migrating = false
+			h.mu.Unlock()
+			return
+		}
+		h.mu.Unlock()
+		for _, c := range conns {
+			ip, err := localIP(c.remote)
+			if err != nil || ip.Equal(c.local) {
+				// If there is no route yet wait for one
+				continue
+			}
+			h.migrate(c, ip)
+		}
+	}
+}
+
+// migrate c to the local address ip, closing it if that fails so the
+// next request makes a new connection.
+func (h *http3Transport) migrate(c *quicConn, ip net.IP) {
+	fs.Debugf(nil, "HTTP/3: local address changed from %v to %v: migrating connection to %v", c.local, ip, c.remote)
+	tr, closers, err := listenQUIC(ip)
+	if err == nil {
+		err = h.switchPath(c, tr)
+		if err != nil {
+			closeAll(closers)
+		}
+	}
+	if err != nil {
+		fs.Debugf(nil, "HTTP/3: failed to migrate connection to %v so closing it: %v", c.remote, err)
+		_ = c.conn.CloseWithError(0, "network changed")
+		return
+	}
+	h.mu.Lock()
+	c.local = ip
+	c.closers = append(c.closers, closers...)
+	h.mu.Unlock()
+}
+
+// switchPath moves c to a new path using tr once it is validated
+func (h *http3Transport) switchPath(c *quicConn, tr *quic.Transport) error {
+	path, err := c.conn.AddPath(tr)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(h.ci.ConnectTimeout))
+	defer cancel()
+	err = path.Probe(ctx)
+	if err == nil {
+		err = path.Switch()
+	}
+	if err != nil {
+		_ = path.Close()
+		return err
+	}
+	return nil
+}
+
+// listenQUIC makes a QUIC transport on a UDP socket bound to ip,
+// returning the things to close when it is finished with.
+func listenQUIC(ip net.IP) (tr *quic.Transport, closers []io.Closer, err error) {
+	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: ip})
+	if err != nil {
+		return nil, nil, err
+	}
+	tr = &quic.Transport{Conn: &limitedUDPConn{UDPConn: udpConn}}
+	// quic.Transport.Close doesn't close a Conn it didn't create
+	return tr, []io.Closer{tr, udpConn}, nil
+}
+
+// closeAll closes closers in order ignoring errors
+func closeAll(closers []io.Closer) {
+	for _, closer := range closers {
+		_ = closer.Close()
+	}
+}
+
+// localIP returns the local IP the OS would use to send to remote.
+//
+// Connecting a UDP socket doesn't send any packets.
+func localIP(remote *net.UDPAddr) (net.IP, error) {
+	conn, err := net.DialUDP("udp", nil, remote)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	local, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return nil, errors.New("local address isn't UDP")
+	}
+	return local.IP, nil
+}
+
+// resolveUDPAddr looks up the host:port in addr, using the overrides
+// from --dns-override in preference to DNS
+func resolveUDPAddr(ctx context.Context, addr string, overrides map[string][]net.IP) (*net.UDPAddr, error) {
+	host, portString, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := net.DefaultResolver.LookupPort(ctx, "udp", portString)
+	if err != nil {
+		return nil, err
+	}
+	if ips := overrides[strings.ToLower(host)]; len(ips) > 0 {
+		return &net.UDPAddr{IP: ips[0], Port: port}, nil
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for %q", host)
+	}
+	return &net.UDPAddr{IP: ips[0].IP, Port: port, Zone: ips[0].Zone}, nil
+}
+
+// limitedUDPConn applies --bwlimit to a UDP socket.
+//
+// It keeps the methods of net.UDPConn that quic-go uses for ECN and
+// GSO so only overrides the ones which read and write packets.
+type limitedUDPConn struct {
+	*net.UDPConn
+}
+
+// ReadFrom reads a packet with rate limiting
+func (c *limitedUDPConn) ReadFrom(b []byte) (n int, addr net.Addr, err error) {
+	n, addr, err = c.UDPConn.ReadFrom(b)
+	accounting.TokenBucket.LimitBandwidth(accounting.TokenBucketSlotTransportRx, n)
+	return n, addr, err
+}
+
+// ReadMsgUDP reads a packet with rate limiting
+func (c *limitedUDPConn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
+	n, oobn, flags, addr, err = c.UDPConn.ReadMsgUDP(b, oob)
+	accounting.TokenBucket.LimitBandwidth(accounting.TokenBucketSlotTransportRx, n)
+	return n, oobn, flags, addr, err
+}
+
+// WriteTo writes a packet with rate limiting
+func (c *limitedUDPConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	accounting.TokenBucket.LimitBandwidth(accounting.TokenBucketSlotTransportTx, len(b))
+	return c.UDPConn.WriteTo(b, addr)
+}
+
+// WriteMsgUDP writes a packet with rate limiting
+func (c *limitedUDPConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+	accounting.TokenBucket.LimitBandwidth(accounting.TokenBucketSlotTransportTx, len(b))
+	return c.UDPConn.WriteMsgUDP(b, oob, addr)
+}
@@ -0,0 +1,220 @@
+package fshttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAltSvc(t *testing.T) {
+	const key = "example.com:443"
+	for _, test := range []struct {
+		in      []string
+		wantOK  bool
+		addr    string
+		wantAge time.Duration
+	}{
+		{nil, false, "", 0},
+		{[]string{`h2=":443"`}, false, "", 0},
+		{[]string{`h3=":443"`}, true, "example.com:443", altSvcDefaultMaxAge},
+		{[]string{`h3=":8443"; ma=60`}, true, "example.com:8443", time.Minute},
+		{[]string{`h3-29=":443"; ma=60, h3="alt.example.com:443"; ma=3600`}, true, "alt.example.com:443", time.Hour},
+		{[]string{`h2=":443"`, `h3=":443"; ma="120"; persist=1`}, true, "example.com:443", 2 * time.Minute},
+		{[]string{`h3=:443`}, false, "", 0},
+		{[]string{`h3="443"`}, false, "", 0},
+	} {
+		what := fmt.Sprintf("%q", test.in)
+		h := newHTTP3Transport(fs.GetConfig(context.Background()), nil)
+		h.parseAltSvc(key, test.in)
+		alt, ok := h.hosts[key]
+		require.Equal(t, test.wantOK, ok, what)
+		if !ok {
+			continue
+		}
+		assert.Equal(t, test.addr, alt.addr, what)
+		assert.WithinDuration(t, time.Now().Add(test.wantAge), alt.expires, time.Second, what)
+	}
+
+	// Check clear removes the advert
+	h := newHTTP3Transport(fs.GetConfig(context.Background()), nil)
+	h.parseAltSvc(key, []string{`h3=":443"`})
+	require.Contains(t, h.hosts, key)
+	h.parseAltSvc(key, []string{`clear`})
+	assert.NotContains(t, h.hosts, key)
+}
+
+func TestHTTP3Use(t *testing.T) {
+	const key = "example.com:443"
+	h := newHTTP3Transport(fs.GetConfig(context.Background()), nil)
+	get, err := http.NewRequest("GET", "https://example.com/", nil)
+	require.NoError(t, err)
+	put, err := http.NewRequest("PUT", "https://example.com/", io.NopCloser(strings.NewReader("body")))
+	require.NoError(t, err)
+
+	assert.False(t, h.use(key, get), "not advertised")
+
+	h.parseAltSvc(key, []string{`h3=":443"`})
+	assert.True(t, h.use(key, get))
+	assert.False(t, h.use(key, put), "body can't be retried")
+
+	h.succeeded(key)
+	assert.True(t, h.use(key, put), "HTTP/3 working")
+
+	h.failed(key, io.EOF)
+	assert.False(t, h.use(key, get), "HTTP/3 failed")
+	h.hosts[key].failed = time.Now().Add(-http3RetryAfter - time.Second)
+	assert.True(t, h.use(key, get), "HTTP/3 failed a while ago")
+
+	h.hosts[key].expires = time.Now().Add(-time.Second)
+	assert.False(t, h.use(key, get), "advert expired")
+	assert.NotContains(t, h.hosts, key)
+}
+
+// newHTTP3TestServer starts a TLS server which advertises HTTP/3 on
+// altPort.
+//
+// If serveHTTP3 is set it serves HTTP/3 on the UDP port with the
+// same number as the TCP port and altPort is ignored.
+func newHTTP3TestServer(t *testing.T, serveHTTP3 bool, altPort int) *httptest.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", fmt.Sprintf(`h3=":%d"; ma=60`, altPort))
+		_, _ = fmt.Fprintf(w, "%s %s", r.Proto, r.Method)
+	})
+	ts := httptest.NewUnstartedServer(handler)
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	if !serveHTTP3 {
+		return ts
+	}
+	port := ts.Listener.Addr().(*net.TCPAddr).Port
+	altPort = port
+	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	require.NoError(t, err)
+	h3 := &http3.Server{
+		Handler:   handler,
+		TLSConfig: http3.ConfigureTLSConfig(ts.TLS.Clone()),
+	}
+	go func() {
+		_ = h3.Serve(udpConn)
+	}()
+	t.Cleanup(func() {
+		_ = h3.Close()
+		_ = udpConn.Close()
+	})
+	return ts
+}
+
+// http3TestClient makes a client with --use-http3 set
+func http3TestClient(t *testing.T) *http.Client {
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.UseHTTP3 = true
+	ci.InsecureSkipVerify = true
+	ci.ConnectTimeout = fs.Duration(time.Second)
+	return NewClient(ctx)
+}
+
+func http3TestGet(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestHTTP3(t *testing.T) {
+	ts := newHTTP3TestServer(t, true, 0)
+	client := http3TestClient(t)
+
+	// The first request finds the advert over TCP then the
+	// following ones use HTTP/3
+	assert.Equal(t, "HTTP/1.1 GET", http3TestGet(t, client, ts.URL))
+	assert.Equal(t, "HTTP/3.0 GET", http3TestGet(t, client, ts.URL))
+	assert.Equal(t, "HTTP/3.0 GET", http3TestGet(t, client, ts.URL))
+
+	h := client.Transport.(*Transport).http3
+	h.mu.Lock()
+	assert.Len(t, h.conns, 1)
+	h.mu.Unlock()
+}
+
+func TestHTTP3Migrate(t *testing.T) {
+	ts := newHTTP3TestServer(t, true, 0)
+	client := http3TestClient(t)
+	assert.Equal(t, "HTTP/1.1 GET", http3TestGet(t, client, ts.URL))
+	assert.Equal(t, "HTTP/3.0 GET", http3TestGet(t, client, ts.URL))
+
+	h := client.Transport.(*Transport).http3
+	h.mu.Lock()
+	var c *quicConn
+	for _, conn := range h.conns {
+		c = conn
+	}
+	h.mu.Unlock()
+	require.NotNil(t, c)
+	assert.True(t, c.local.Equal(net.IPv4(127, 0, 0, 1)))
+
+	// Move the connection to another loopback address
+	newIP := net.IPv4(127, 0, 0, 2)
+	h.migrate(c, newIP)
+	assert.Equal(t, "HTTP/3.0 GET", http3TestGet(t, client, ts.URL))
+	h.mu.Lock()
+	assert.True(t, c.local.Equal(newIP))
+	assert.Len(t, c.closers, 4)
+	assert.Len(t, h.conns, 1)
+	h.mu.Unlock()
+	assert.True(t, c.conn.LocalAddr().(*net.UDPAddr).IP.Equal(newIP))
+}
+
+func TestHTTP3Proxy(t *testing.T) {
+	client := http3TestClient(t)
+	tr := client.Transport.(*Transport)
+	req, err := http.NewRequest("GET", "https://example.com/", nil)
+	require.NoError(t, err)
+	tr.http3.parseAltSvc(authority(req), []string{`h3=":443"`})
+	assert.True(t, tr.http3.use(authority(req), req))
+
+	// HTTP/3 can't go through a proxy
+	tr.Proxy = http.ProxyURL(&url.URL{Scheme: "http", Host: "proxy.example.com:3128"})
+	assert.True(t, usesProxy(req, tr.Transport))
+	tr.Proxy = nil
+	assert.False(t, usesProxy(req, tr.Transport))
+}
+
+func TestHTTP3Fallback(t *testing.T) {
+	// Find a UDP port with nothing listening on it
+	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	altPort := udpConn.LocalAddr().(*net.UDPAddr).Port
+	require.NoError(t, udpConn.Close())
+
+	ts := newHTTP3TestServer(t, false, altPort)
+	client := http3TestClient(t)
+
+	// HTTP/3 fails so the request is retried over TCP
+	assert.Equal(t, "HTTP/1.1 GET", http3TestGet(t, client, ts.URL))
+	assert.Equal(t, "HTTP/1.1 GET", http3TestGet(t, client, ts.URL))
+
+	h := client.Transport.(*Transport).http3
+	key := "127.0.0.1:" + strconv.Itoa(ts.Listener.Addr().(*net.TCPAddr).Port)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	require.Contains(t, h.hosts, key)
+	assert.False(t, h.hosts[key].failed.IsZero())
+	assert.False(t, h.hosts[key].working)
+}
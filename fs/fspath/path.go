@@ -28,6 +28,8 @@ var (
 	errValue             = errors.New("unquoted config value must end with `,` or `:`")
 	errQuotedValue       = errors.New("unterminated quoted config value")
 	errAfterQuote        = errors.New("expecting `:` or `,` or another quote after a quote")
+	errBraceValue        = errors.New("unterminated `{` in config value")
+	errAfterBrace        = errors.New("expecting `:` or `,` after a `}`")
 	errSyntax            = errors.New("syntax error in config string")
 
 	// configNameMatcher is a pattern to match an rclone config name
@@ -123,6 +125,8 @@ func Parse(path string) (parsed Parsed, err error) {
 		stateValue
 		stateQuotedValue
 		stateAfterQuote
+		stateBraceValue
+		stateAfterBrace
 		stateDone
 	)
 	var (
@@ -133,6 +137,8 @@ func Parse(path string) (parsed Parsed, err error) {
 		quote   rune              // kind of quote to end this quoted string
 		param   string            // current parameter value
 		doubled bool              // set if had doubled quotes
+		depth   int               // nesting depth of {} in a braced value
+		last    rune              // previous rune in a braced value
 	)
 loop:
 	for i, c = range path {
@@ -201,6 +207,12 @@ loop:
 					doubled = false
 					break
 				}
+			} else if c == '{' && i == prev {
+				state = stateBraceValue
+				prev = i + 1
+				depth = 1
+				quote = 0
+				last = c
 			} else if c == ':' || c == ',' {
 				value := path[prev:i]
 				prev = i + 1
@@ -239,6 +251,39 @@ loop:
 			} else {
 				return parsed, errAfterQuote
 			}
+		// Parses {value} which may contain nested {} so that
+		// connection strings can be nested without quoting
+		case stateBraceValue:
+			if quote != 0 {
+				// Ignore braces inside quoted values
+				if c == quote {
+					quote = 0
+				}
+			} else if (c == '\'' || c == '"') && (last == '=' || last == c) {
+				// Start of a quoted value or a doubled quote
+				quote = c
+			} else if c == '{' {
+				depth++
+			} else if c == '}' {
+				depth--
+				if depth == 0 {
+					state = stateAfterBrace
+				}
+			}
+			last = c
+		// Parses : or , after {value}
+		case stateAfterBrace:
+			if c == ':' || c == ',' {
+				parsed.Config[param] = path[prev : i-1]
+				prev = i + 1
+				if c == ':' {
+					state = stateDone
+					break loop
+				}
+				state = stateParam
+			} else {
+				return parsed, errAfterBrace
+			}
 		}
 
 	}
@@ -258,6 +303,10 @@ loop:
 		return parsed, errQuotedValue
 	case stateAfterQuote:
 		return parsed, errAfterQuote
+	case stateBraceValue:
+		return parsed, errBraceValue
+	case stateAfterBrace:
+		return parsed, errAfterBrace
 	case stateDone:
 		break
 	}
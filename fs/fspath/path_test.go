@@ -446,6 +446,56 @@ func TestParse(t *testing.T) {
 		}, {
 			in:      `:backend,param=''bad'':`,
 			wantErr: errAfterQuote,
+		}, {
+			in: `:crypt,remote={:chunker,remote={:s3,provider=AWS:bucket}:dir},password=x:path`,
+			wantParsed: Parsed{
+				ConfigString: `:crypt,remote={:chunker,remote={:s3,provider=AWS:bucket}:dir},password=x`,
+				Name:         ":crypt",
+				Path:         "path",
+				Config: configmap.Simple{
+					"remote":   `:chunker,remote={:s3,provider=AWS:bucket}:dir`,
+					"password": "x",
+				},
+			},
+		}, {
+			in: `:alias,remote={:http,url='https://example.com/}{':dir}:`,
+			wantParsed: Parsed{
+				ConfigString: `:alias,remote={:http,url='https://example.com/}{':dir}`,
+				Name:         ":alias",
+				Path:         "",
+				Config: configmap.Simple{
+					"remote": `:http,url='https://example.com/}{':dir`,
+				},
+			},
+		}, {
+			in: `:alias,remote={:http,url='it''s}':dir}:`,
+			wantParsed: Parsed{
+				ConfigString: `:alias,remote={:http,url='it''s}':dir}`,
+				Name:         ":alias",
+				Path:         "",
+				Config: configmap.Simple{
+					"remote": `:http,url='it''s}':dir`,
+				},
+			},
+		}, {
+			in: `:backend,param=x{y}:path`,
+			wantParsed: Parsed{
+				ConfigString: `:backend,param=x{y}`,
+				Name:         ":backend",
+				Path:         "path",
+				Config: configmap.Simple{
+					"param": "x{y}",
+				},
+			},
+		}, {
+			in:      `:backend,param={:s3:bucket:path`,
+			wantErr: errBraceValue,
+		}, {
+			in:      `:backend,param={value}x:path`,
+			wantErr: errAfterBrace,
+		}, {
+			in:      `:backend,param={value}`,
+			wantErr: errAfterBrace,
 		},
 	} {
 		gotParsed, gotErr := Parse(test.in)
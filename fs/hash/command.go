@@ -0,0 +1,141 @@
+package hash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// commandHasher is a hash.Hash which pipes the data through an
+// external command and reads the hex encoded checksum from the first
+// field of its output, as printed by sha256sum for example.
+type commandHasher struct {
+	command []string
+	width   int
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  bytes.Buffer
+	sum     []byte
+	done    bool
+}
+
+// start the command if it isn't running
+func (h *commandHasher) start() (err error) {
+	if h.cmd != nil {
+		return nil
+	}
+	h.cmd = exec.Command(h.command[0], h.command[1:]...)
+	h.cmd.Stdout = &h.stdout
+	h.stdin, err = h.cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	return h.cmd.Start()
+}
+
+// finish the command and parse its output
+func (h *commandHasher) finish() ([]byte, error) {
+	if err := h.start(); err != nil {
+		return nil, err
+	}
+	_ = h.stdin.Close()
+	if err := h.cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("hash command %q failed: %w", h.command[0], err)
+	}
+	fields := strings.Fields(h.stdout.String())
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("hash command %q returned no output", h.command[0])
+	}
+	sum, err := hex.DecodeString(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("hash command %q returned bad hex: %w", h.command[0], err)
+	}
+	if h.width != 0 && len(fields[0]) != h.width {
+		return nil, fmt.Errorf("hash command %q returned %d hex digits not %d", h.command[0], len(fields[0]), h.width)
+	}
+	return sum, nil
+}
+
+// Write data to the command
+func (h *commandHasher) Write(p []byte) (n int, err error) {
+	if h.done {
+		return 0, errors.New("can't write to hash command after Sum")
+	}
+	if err = h.start(); err != nil {
+		return 0, err
+	}
+	return h.stdin.Write(p)
+}
+
+// Sum appends the checksum to b.
+//
+// If the command failed then the checksum will be empty which is
+// treated as an unknown hash.
+func (h *commandHasher) Sum(b []byte) []byte {
+	if !h.done {
+		h.done = true
+		h.sum, _ = h.finish()
+	}
+	return append(b, h.sum...)
+}
+
+// Reset the hasher, stopping any running command
+func (h *commandHasher) Reset() {
+	if h.cmd != nil && !h.done {
+		_ = h.stdin.Close()
+		_ = h.cmd.Process.Kill()
+		_ = h.cmd.Wait()
+	}
+	*h = commandHasher{command: h.command, width: h.width}
+}
+
+// Size returns the number of bytes Sum will return
+func (h *commandHasher) Size() int {
+	return h.width / 2
+}
+
+// BlockSize returns the hash's underlying block size
+func (h *commandHasher) BlockSize() int {
+	return 1
+}
+
+// RegisterCommand registers an external hash called name which is
+// calculated by piping the data into command.
+//
+// The command should print the hex encoded checksum as the first
+// field of its output. The width of the hash is found by running the
+// command on empty input.
+//
+// Hashes which are built into rclone should use RegisterHash from an
+// init function instead.
+func RegisterCommand(name string, command []string) (Type, error) {
+	if name == "" || name != strings.ToLower(name) || strings.ContainsAny(name, " ,=") {
+		return None, fmt.Errorf("invalid hash name %q: must be lower case without spaces, commas or =", name)
+	}
+	if name == "none" || name2hash[name] != nil || alias2hash[name] != nil {
+		return None, fmt.Errorf("hash %q is already registered", name)
+	}
+	if len(type2hash) >= 62 {
+		return None, fmt.Errorf("hash %q: too many hashes registered", name)
+	}
+	if len(command) == 0 {
+		return None, fmt.Errorf("hash %q: command is empty", name)
+	}
+	h := &commandHasher{command: command}
+	sum, err := h.finish()
+	if err != nil {
+		return None, fmt.Errorf("hash %q: %w", name, err)
+	}
+	width := 2 * len(sum)
+	if width == 0 {
+		return None, fmt.Errorf("hash %q: command returned an empty hash", name)
+	}
+	return RegisterHash(name, name, width, func() hash.Hash {
+		return &commandHasher{command: command, width: width}
+	}), nil
+}
@@ -16,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/jzelinskie/whirlpool"
+	"github.com/rclone/rclone/lib/crc64nvme"
 	"github.com/zeebo/blake3"
 	"github.com/zeebo/xxh3"
 )
@@ -102,6 +103,9 @@ var (
 
 	// XXH128 indicates XXH128 support, also known as XXH3-128, a variant of xxHash
 	XXH128 Type
+
+	// CRC64NVME indicates CRC-64/NVME support, as used by S3 full object checksums
+	CRC64NVME Type
 )
 
 type xxh128Hasher struct {
@@ -124,6 +128,7 @@ func init() {
 	BLAKE3 = RegisterHash("blake3", "BLAKE3", 64, func() hash.Hash { return blake3.New() })
 	XXH3 = RegisterHash("xxh3", "XXH3", 16, func() hash.Hash { return xxh3.New() })
 	XXH128 = RegisterHash("xxh128", "XXH128", 32, func() hash.Hash { return &xxh128Hasher{} })
+	CRC64NVME = RegisterHash("crc64nvme", "CRC-64/NVME", 16, func() hash.Hash { return crc64nvme.New() })
 }
 
 // Supported returns a set of all the supported hashes by
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"os/exec"
 	"testing"
 
 	"github.com/rclone/rclone/fs"
@@ -81,6 +82,7 @@ var hashTestSet = []hashTest{
 			hash.BLAKE3:    "0a7276a407a3be1b4d31488318ee05a335aad5a3b82c4420e592a8178c9e86bb",
 			hash.XXH3:      "4b83b0c51c543525",
 			hash.XXH128:    "438de241a57d684214f67657f7aad93b",
+			hash.CRC64NVME: "6870c3fff5245563",
 		},
 	},
 	// Empty data set
@@ -96,6 +98,7 @@ var hashTestSet = []hashTest{
 			hash.BLAKE3:    "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262",
 			hash.XXH3:      "2d06800538d394c2",
 			hash.XXH128:    "99aa06d3014798d86001c324468d497f",
+			hash.CRC64NVME: "0000000000000000",
 		},
 	},
 }
@@ -217,3 +220,37 @@ func TestHashTypeStability(t *testing.T) {
 	assert.True(t, hash.Supported().Contains(hash.SHA1))
 	assert.False(t, hash.Supported().Contains(hash.None))
 }
+
+func TestRegisterCommand(t *testing.T) {
+	sha256sum, err := exec.LookPath("sha256sum")
+	if err != nil {
+		t.Skip("sha256sum not found")
+	}
+	old := hash.SupportOnly(nil)
+	hash.SupportOnly(old)
+	defer hash.SupportOnly(old)
+
+	_, err = hash.RegisterCommand("md5", []string{sha256sum})
+	assert.ErrorContains(t, err, "already registered")
+	_, err = hash.RegisterCommand("Bad Name", []string{sha256sum})
+	assert.ErrorContains(t, err, "invalid hash name")
+	_, err = hash.RegisterCommand("sha256false", []string{"false"})
+	assert.ErrorContains(t, err, "failed")
+
+	ht, err := hash.RegisterCommand("sha256cmd", []string{sha256sum})
+	require.NoError(t, err)
+	assert.Equal(t, "sha256cmd", ht.String())
+	assert.Equal(t, 64, hash.Width(ht, false))
+	assert.True(t, hash.Supported().Contains(ht))
+
+	var parsed hash.Type
+	require.NoError(t, parsed.Set("sha256cmd"))
+	assert.Equal(t, ht, parsed)
+
+	for _, test := range hashTestSet {
+		sums, err := hash.StreamTypes(bytes.NewBuffer(test.input), hash.NewHashSet(ht, hash.SHA256))
+		require.NoError(t, err)
+		assert.Equal(t, test.output[hash.SHA256], sums[ht])
+		assert.Equal(t, sums[hash.SHA256], sums[ht])
+	}
+}
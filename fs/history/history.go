@@ -0,0 +1,433 @@
+// Package history records a summary of each run of rclone and the
+// files it transferred in a database so they can be queried later.
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/lib/kv"
+)
+
+// OptionsInfo describes the Options in use
+var OptionsInfo = fs.Options{{
+	Name:    "stats_db",
+	Default: false,
+	Help:    "Record a summary of each run and the files it transferred in the stats database",
+	Groups:  "Logging",
+}}
+
+func init() {
+	fs.RegisterGlobalOptions(fs.OptionsInfo{Name: "history", Opt: &Opt, Options: OptionsInfo})
+}
+
+// Options contains options for the transfer history
+type Options struct {
+	Enabled bool `config:"stats_db"` // record the history
+}
+
+// Opt is the global history options
+var Opt Options
+
+// facility is the name of the database
+const facility = "stats-db"
+
+// Database keys
+const (
+	runPrefix  = "run/"
+	filePrefix = "file/"
+)
+
+// flushSize is the number of file records to buffer before writing
+// them to the database
+const flushSize = 1000
+
+// Run is the summary of a run of rclone
+type Run struct {
+	ID        string    `json:"id"`             // unique ID of the run which sorts in start order
+	Command   string    `json:"command"`        // name of the command, e.g. "sync"
+	Args      []string  `json:"args,omitempty"` // arguments to the command
+	Start     time.Time `json:"start"`          // when the run started
+	End       time.Time `json:"end"`            // when the run finished
+	Bytes     int64     `json:"bytes"`          // bytes transferred
+	Transfers int64     `json:"transfers"`      // files transferred
+	Checks    int64     `json:"checks"`         // files checked
+	Deletes   int64     `json:"deletes"`        // files deleted
+	Renames   int64     `json:"renames"`        // files renamed
+	Errors    int64     `json:"errors"`         // number of errors
+	Error     string    `json:"error,omitempty"`
+}
+
+// File is the record of a file transferred, deleted or moved in a run
+type File struct {
+	Run   string    `json:"run"`             // ID of the run
+	Name  string    `json:"name"`            // path of the file
+	What  string    `json:"what"`            // transferring, deleting or moving
+	Size  int64     `json:"size"`            // size of the file
+	Bytes int64     `json:"bytes"`           // bytes transferred
+	SrcFs string    `json:"srcFs,omitempty"` // source remote
+	DstFs string    `json:"dstFs,omitempty"` // destination remote
+	Start time.Time `json:"start"`           // when the transfer started
+	End   time.Time `json:"end"`             // when the transfer finished
+	Error string    `json:"error,omitempty"`
+}
+
+// recorder records the current run
+type recorder struct {
+	db    *kv.DB
+	run   Run
+	mu    sync.Mutex
+	seq   int
+	files []File
+}
+
+var (
+	currentMu sync.Mutex
+	current   *recorder
+)
+
+// Start recording the run of command with args if --stats-db is set.
+//
+// Finish must be called when the command has finished.
+func Start(ctx context.Context, command string, args []string) {
+	if !GetConfig(ctx).Enabled {
+		return
+	}
+	if !kv.Supported() {
+		fs.Errorf(nil, "stats-db: not supported on this OS")
+		return
+	}
+	db, err := kv.Start(ctx, facility, nil)
+	if err != nil {
+		fs.Errorf(nil, "stats-db: failed to open database: %v", err)
+		return
+	}
+	now := time.Now()
+	r := &recorder{
+		db: db,
+		run: Run{
+			ID:      fmt.Sprintf("%s-%d", now.UTC().Format("20060102T150405.000000000Z"), os.Getpid()),
+			Command: command,
+			Args:    args,
+			Start:   now,
+		},
+	}
+	currentMu.Lock()
+	current = r
+	currentMu.Unlock()
+	accounting.TransferDone = transferDone
+}
+
+// transferDone records the transfers which changed something
+func transferDone(ctx context.Context, s accounting.TransferSnapshot) {
+	if s.Checked && s.What != "deleting" && s.What != "moving" {
+		return
+	}
+	currentMu.Lock()
+	r := current
+	currentMu.Unlock()
+	if r == nil {
+		return
+	}
+	file := File{
+		Run:   r.run.ID,
+		Name:  s.Name,
+		What:  s.What,
+		Size:  s.Size,
+		Bytes: s.Bytes,
+		SrcFs: s.SrcFs,
+		DstFs: s.DstFs,
+		Start: s.StartedAt,
+		End:   s.CompletedAt,
+	}
+	if s.Error != nil {
+		file.Error = s.Error.Error()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files = append(r.files, file)
+	if len(r.files) >= flushSize {
+		r.flush()
+	}
+}
+
+// flush the buffered files to the database
+//
+// Call with the mutex held
+func (r *recorder) flush() {
+	if len(r.files) == 0 {
+		return
+	}
+	op := &putOp{}
+	for _, file := range r.files {
+		r.seq++
+		op.keys = append(op.keys, fmt.Sprintf("%s%s/%09d", filePrefix, r.run.ID, r.seq))
+		op.values = append(op.values, file)
+	}
+	r.files = r.files[:0]
+	if err := r.db.Do(true, op); err != nil {
+		fs.Errorf(nil, "stats-db: failed to record files: %v", err)
+	}
+}
+
+// Finish recording the run which finished with runErr
+func Finish(ctx context.Context, runErr error) {
+	currentMu.Lock()
+	r := current
+	current = nil
+	currentMu.Unlock()
+	if r == nil {
+		return
+	}
+	accounting.TransferDone = nil
+	stats := accounting.Stats(ctx)
+	r.run.End = time.Now()
+	r.run.Bytes = stats.GetBytes()
+	r.run.Transfers = stats.GetTransfers()
+	r.run.Checks = stats.GetChecks()
+	r.run.Deletes = stats.GetDeletes()
+	r.run.Renames = stats.Renames(0)
+	r.run.Errors = stats.GetErrors()
+	if runErr != nil {
+		r.run.Error = runErr.Error()
+	}
+	r.mu.Lock()
+	r.flush()
+	r.mu.Unlock()
+	err := r.db.Do(true, &putOp{keys: []string{runPrefix + r.run.ID}, values: []any{r.run}})
+	if err != nil {
+		fs.Errorf(nil, "stats-db: failed to record run: %v", err)
+	}
+	if err := r.db.Stop(false); err != nil {
+		fs.Errorf(nil, "stats-db: failed to close database: %v", err)
+	}
+}
+
+// Filter selects the records returned by Runs and Files
+type Filter struct {
+	Since   time.Time // only records finishing at or after this if set
+	Until   time.Time // only records finishing before this if set
+	Command string    // only runs of this command if set
+	Run     string    // only records of the run with this ID if set
+	Name    string    // only files with paths containing this if set
+	Errors  bool      // only records with errors
+}
+
+// matchTime returns true if end is in the time range of the filter
+func (f *Filter) matchTime(end time.Time) bool {
+	return (f.Since.IsZero() || !end.Before(f.Since)) && (f.Until.IsZero() || end.Before(f.Until))
+}
+
+// match returns true if the filter selects run
+func (f *Filter) match(run *Run) bool {
+	return f.matchTime(run.End) &&
+		(f.Command == "" || f.Command == run.Command) &&
+		(f.Run == "" || f.Run == run.ID) &&
+		(!f.Errors || run.Errors > 0 || run.Error != "")
+}
+
+// matchFile returns true if the filter selects file
+func (f *Filter) matchFile(file *File) bool {
+	return f.matchTime(file.End) &&
+		(f.Run == "" || f.Run == file.Run) &&
+		(f.Name == "" || strings.Contains(file.Name, f.Name)) &&
+		(!f.Errors || file.Error != "")
+}
+
+// open the database for querying
+func open(ctx context.Context) (*kv.DB, error) {
+	if !kv.Supported() {
+		return nil, errors.New("stats-db is not supported on this OS")
+	}
+	return kv.Start(ctx, facility, nil)
+}
+
+// Runs returns the runs selected by filter oldest first
+func Runs(ctx context.Context, filter Filter) (runs []Run, err error) {
+	db, err := open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Stop(false)
+	}()
+	runs = []Run{}
+	err = db.Do(false, &scanOp{prefix: runPrefix, fn: func(key string, data []byte) error {
+		var run Run
+		if err := json.Unmarshal(data, &run); err != nil {
+			fs.Debugf(nil, "stats-db: ignoring corrupt record %q: %v", key, err)
+			return nil
+		}
+		if filter.match(&run) {
+			runs = append(runs, run)
+		}
+		return nil
+	}})
+	if errors.Is(err, kv.ErrEmpty) {
+		err = nil
+	}
+	return runs, err
+}
+
+// Files returns the files selected by filter in the order they finished
+// in each run, oldest run first.
+//
+// If filter.Command is set only the files of runs of that command
+// are returned.
+func Files(ctx context.Context, filter Filter) (files []File, err error) {
+	var runIDs map[string]bool
+	if filter.Command != "" {
+		runs, err := Runs(ctx, Filter{Command: filter.Command, Run: filter.Run})
+		if err != nil {
+			return nil, err
+		}
+		runIDs = make(map[string]bool, len(runs))
+		for _, run := range runs {
+			runIDs[run.ID] = true
+		}
+	}
+	db, err := open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Stop(false)
+	}()
+	prefix := filePrefix
+	if filter.Run != "" {
+		prefix += filter.Run + "/"
+	}
+	files = []File{}
+	err = db.Do(false, &scanOp{prefix: prefix, fn: func(key string, data []byte) error {
+		var file File
+		if err := json.Unmarshal(data, &file); err != nil {
+			fs.Debugf(nil, "stats-db: ignoring corrupt record %q: %v", key, err)
+			return nil
+		}
+		if (runIDs == nil || runIDs[file.Run]) && filter.matchFile(&file) {
+			files = append(files, file)
+		}
+		return nil
+	}})
+	if errors.Is(err, kv.ErrEmpty) {
+		err = nil
+	}
+	return files, err
+}
+
+// Prune removes the runs which finished before cutoff along with
+// their files, returning the number of runs removed.
+func Prune(ctx context.Context, cutoff time.Time) (n int, err error) {
+	runs, err := Runs(ctx, Filter{Until: cutoff})
+	if err != nil || len(runs) == 0 {
+		return 0, err
+	}
+	db, err := open(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = db.Stop(false)
+	}()
+	for _, run := range runs {
+		if err := db.Do(true, &deleteOp{prefixes: []string{runPrefix + run.ID, filePrefix + run.ID + "/"}}); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// putOp writes JSON encoded values to the database
+type putOp struct {
+	keys   []string
+	values []any
+}
+
+// Do the put
+func (op *putOp) Do(ctx context.Context, b kv.Bucket) error {
+	for i, key := range op.keys {
+		data, err := json.Marshal(op.values[i])
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte(key), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanOp calls fn for each record with a key starting with prefix
+type scanOp struct {
+	prefix string
+	fn     func(key string, data []byte) error
+}
+
+// Do the scan
+func (op *scanOp) Do(ctx context.Context, b kv.Bucket) error {
+	c := b.Cursor()
+	for k, v := c.Seek([]byte(op.prefix)); k != nil && strings.HasPrefix(string(k), op.prefix); k, v = c.Next() {
+		if err := op.fn(string(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteOp deletes the records with keys starting with any of prefixes
+type deleteOp struct {
+	prefixes []string
+}
+
+// Do the delete
+func (op *deleteOp) Do(ctx context.Context, b kv.Bucket) error {
+	for _, prefix := range op.prefixes {
+		var keys [][]byte
+		c := b.Cursor()
+		for k, _ := c.Seek([]byte(prefix)); k != nil && strings.HasPrefix(string(k), prefix); k, _ = c.Next() {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type configContextKeyType struct{}
+
+// Context key for config
+var configContextKey = configContextKeyType{}
+
+// GetConfig returns the global or context sensitive options
+func GetConfig(ctx context.Context) *Options {
+	if ctx == nil {
+		return &Opt
+	}
+	c := ctx.Value(configContextKey)
+	if c == nil {
+		return &Opt
+	}
+	return c.(*Options)
+}
+
+// AddConfig returns a mutable copy of the options found in ctx and
+// a new context with that added to it.
+func AddConfig(ctx context.Context) (context.Context, *Options) {
+	c := GetConfig(ctx)
+	cCopy := new(Options)
+	*cCopy = *c
+	newCtx := context.WithValue(ctx, configContextKey, cCopy)
+	return newCtx, cCopy
+}
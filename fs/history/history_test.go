@@ -0,0 +1,113 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/rclone/rclone/lib/kv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	if !kv.Supported() {
+		t.Skip("kv not supported")
+	}
+	ctx := context.Background()
+	ctx, opt := AddConfig(ctx)
+
+	// Keep the database open so it isn't removed between uses
+	db, err := kv.Start(ctx, facility, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, db.Stop(true))
+	}()
+
+	// Not recorded unless enabled
+	Start(ctx, "ls", nil)
+	Finish(ctx, nil)
+	runs, err := Runs(ctx, Filter{})
+	require.NoError(t, err)
+	assert.Len(t, runs, 0)
+
+	// Record a run
+	opt.Enabled = true
+	start := time.Now()
+	Start(ctx, "sync", []string{"src:", "dst:"})
+	stats := accounting.Stats(ctx)
+	stats.NewTransferRemoteSize("dir/file1", 5, nil, nil).Done(ctx, nil)
+	stats.NewTransferRemoteSize("dir/file2", 6, nil, nil).Done(ctx, errors.New("boom"))
+	stats.NewCheckingTransfer(mockobject.New("checked"), "checking").Done(ctx, nil)
+	stats.NewCheckingTransfer(mockobject.New("dir/deleted"), "deleting").Done(ctx, nil)
+	Finish(ctx, errors.New("sync failed"))
+
+	// And another
+	stats.ResetCounters()
+	Start(ctx, "copy", nil)
+	stats.NewTransferRemoteSize("file3", 7, nil, nil).Done(ctx, nil)
+	Finish(ctx, nil)
+
+	runs, err = Runs(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "sync", runs[0].Command)
+	assert.Equal(t, []string{"src:", "dst:"}, runs[0].Args)
+	assert.Equal(t, "sync failed", runs[0].Error)
+	assert.False(t, runs[0].Start.Before(start))
+	assert.Equal(t, "copy", runs[1].Command)
+	assert.Less(t, runs[0].ID, runs[1].ID)
+
+	runs, err = Runs(ctx, Filter{Errors: true})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "sync", runs[0].Command)
+	syncID := runs[0].ID
+
+	files, err := Files(ctx, Filter{})
+	require.NoError(t, err)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"dir/file1", "dir/file2", "dir/deleted", "file3"}, names)
+	assert.Equal(t, syncID, files[0].Run)
+	assert.Equal(t, "transferring", files[0].What)
+	assert.Equal(t, int64(5), files[0].Size)
+	assert.Equal(t, "boom", files[1].Error)
+	assert.Equal(t, "deleting", files[2].What)
+
+	for _, test := range []struct {
+		filter Filter
+		want   int
+	}{
+		{Filter{Command: "copy"}, 1},
+		{Filter{Run: syncID}, 3},
+		{Filter{Name: "dir/"}, 3},
+		{Filter{Errors: true}, 1},
+		{Filter{Since: time.Now().Add(time.Hour)}, 0},
+		{Filter{Until: time.Now().Add(time.Hour)}, 4},
+		{Filter{Until: start}, 0},
+	} {
+		files, err := Files(ctx, test.filter)
+		require.NoError(t, err)
+		assert.Len(t, files, test.want, "%+v", test.filter)
+	}
+
+	// Prune
+	n, err := Prune(ctx, start)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	n, err = Prune(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	runs, err = Runs(ctx, Filter{})
+	require.NoError(t, err)
+	assert.Len(t, runs, 0)
+	files, err = Files(ctx, Filter{})
+	require.NoError(t, err)
+	assert.Len(t, files, 0)
+}
@@ -0,0 +1,13 @@
+// Package historyflags implements command line flags to set up the transfer history
+package historyflags
+
+import (
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/history"
+	"github.com/spf13/pflag"
+)
+
+// AddFlags adds the history flags to the flagSet
+func AddFlags(flagSet *pflag.FlagSet) {
+	flags.AddFlagsFromOptions(flagSet, "", history.OptionsInfo)
+}
@@ -0,0 +1,54 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+)
+
+// Link permissions for LinkOptions.Permission
+const (
+	LinkPermissionView = "view" // anyone with the link can view and download
+	LinkPermissionEdit = "edit" // anyone with the link can edit
+)
+
+// LinkOptions are extra settings for links made by PublicLink
+//
+// Backends which support them set Features.PublicLinkOptions and read
+// them with GetLinkOptions.
+type LinkOptions struct {
+	Password   string // password needed to open the link if set
+	Permission string // LinkPermissionView, LinkPermissionEdit or "" for the backend default
+}
+
+// IsSet returns true if any of the options are set
+func (lo *LinkOptions) IsSet() bool {
+	return lo != nil && (lo.Password != "" || lo.Permission != "")
+}
+
+// Check returns an error if the options are invalid
+func (lo *LinkOptions) Check() error {
+	switch lo.Permission {
+	case "", LinkPermissionView, LinkPermissionEdit:
+		return nil
+	}
+	return fmt.Errorf("unknown link permission %q - must be %q or %q", lo.Permission, LinkPermissionView, LinkPermissionEdit)
+}
+
+type linkOptionsContextKeyType struct{}
+
+// Context key for link options
+var linkOptionsContextKey = linkOptionsContextKeyType{}
+
+// WithLinkOptions returns a new context with the link options added
+func WithLinkOptions(ctx context.Context, lo *LinkOptions) context.Context {
+	return context.WithValue(ctx, linkOptionsContextKey, lo)
+}
+
+// GetLinkOptions returns the link options in ctx or empty options if
+// there are none.
+func GetLinkOptions(ctx context.Context) *LinkOptions {
+	if lo, ok := ctx.Value(linkOptionsContextKey).(*LinkOptions); ok && lo != nil {
+		return lo
+	}
+	return &LinkOptions{}
+}
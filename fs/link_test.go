@@ -0,0 +1,34 @@
+package fs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkOptionsCheck(t *testing.T) {
+	for _, test := range []struct {
+		in      LinkOptions
+		wantErr bool
+	}{
+		{LinkOptions{}, false},
+		{LinkOptions{Permission: LinkPermissionView}, false},
+		{LinkOptions{Permission: LinkPermissionEdit, Password: "pass"}, false},
+		{LinkOptions{Permission: "potato"}, true},
+	} {
+		err := test.in.Check()
+		assert.Equal(t, test.wantErr, err != nil, test.in)
+	}
+}
+
+func TestLinkOptionsContext(t *testing.T) {
+	ctx := context.Background()
+	lo := GetLinkOptions(ctx)
+	assert.False(t, lo.IsSet())
+
+	ctx = WithLinkOptions(ctx, &LinkOptions{Password: "pass"})
+	lo = GetLinkOptions(ctx)
+	assert.True(t, lo.IsSet())
+	assert.Equal(t, "pass", lo.Password)
+}
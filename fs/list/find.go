@@ -0,0 +1,86 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/encoder"
+)
+
+// NewObjectNormalized finds the object at remote on f allowing for a
+// different Unicode normalization.
+//
+// If remote isn't found as given then, unless
+// --no-unicode-normalization is set, the other Unicode normalization
+// forms of remote are tried, so a file stored as "é" in NFD is found
+// when looking for it in NFC. This only uses NewObject so is cheap
+// enough to call for every file in a sync.
+//
+// It returns fs.ErrorObjectNotFound if no matching object was found.
+func NewObjectNormalized(ctx context.Context, f fs.Fs, remote string) (fs.Object, error) {
+	o, err := f.NewObject(ctx, remote)
+	if !errors.Is(err, fs.ErrorObjectNotFound) {
+		return o, err
+	}
+	if fs.GetConfig(ctx).NoUnicodeNormalization {
+		return nil, err
+	}
+	for _, alt := range encoder.NormalizationForms(remote)[1:] {
+		o, altErr := f.NewObject(ctx, alt)
+		if altErr == nil {
+			fs.Debugf(o, "Found as %q with different unicode normalization", remote)
+			return o, nil
+		}
+		if !errors.Is(altErr, fs.ErrorObjectNotFound) {
+			return nil, altErr
+		}
+	}
+	return nil, err
+}
+
+// FindObject finds the object at remote on f in the same way the
+// sync matches names.
+//
+// As well as trying the other Unicode normalization forms as
+// NewObjectNormalized does, if --ignore-case-sync is set and f is
+// case sensitive then the directory is listed to find a file whose
+// name differs only in case. As this lists the directory it should
+// only be used for single file lookups such as copyto and moveto.
+//
+// It returns fs.ErrorObjectNotFound if no matching object was found.
+func FindObject(ctx context.Context, f fs.Fs, remote string) (fs.Object, error) {
+	o, err := NewObjectNormalized(ctx, f, remote)
+	if !errors.Is(err, fs.ErrorObjectNotFound) {
+		return o, err
+	}
+	ci := fs.GetConfig(ctx)
+	if !ci.IgnoreCaseSync || f.Features().CaseInsensitive {
+		return nil, err
+	}
+	dir := path.Dir(remote)
+	if dir == "." {
+		dir = ""
+	}
+	var found fs.Object
+	listErr := listP(ctx, f, dir, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			entryObj, ok := entry.(fs.Object)
+			if ok && found == nil && encoder.EqualNormalized(path.Base(entryObj.Remote()), path.Base(remote), true) {
+				found = entryObj
+			}
+		}
+		return nil
+	})
+	if errors.Is(listErr, fs.ErrorDirNotFound) {
+		return nil, err
+	} else if listErr != nil {
+		return nil, listErr
+	}
+	if found == nil {
+		return nil, err
+	}
+	fs.Debugf(found, "Found as %q ignoring case", remote)
+	return found, nil
+}
@@ -0,0 +1,70 @@
+package list
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindObject(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	const (
+		nfc = "caf\u00e9"
+		nfd = "cafe\u0301"
+	)
+	f, err := mockfs.NewFs(ctx, "mock", "", nil)
+	require.NoError(t, err)
+	f.(*mockfs.Fs).AddObject(mockobject.Object(nfd))
+	f.(*mockfs.Fs).AddObject(mockobject.Object("Mixed.txt"))
+
+	check := func(remote, want string) {
+		t.Helper()
+		o, err := FindObject(ctx, f, remote)
+		if want == "" {
+			assert.ErrorIs(t, err, fs.ErrorObjectNotFound)
+			return
+		}
+		require.NoError(t, err)
+		assert.Equal(t, want, o.Remote())
+	}
+
+	check(nfd, nfd)
+	check(nfc, nfd)
+	check("Mixed.txt", "Mixed.txt")
+	check("mixed.txt", "")
+	check("potato", "")
+
+	ci.IgnoreCaseSync = true
+	check("mixed.txt", "Mixed.txt")
+	check("CAF\u00c9", nfd)
+	check("dir/mixed.txt", "")
+
+	ci.IgnoreCaseSync = false
+	ci.NoUnicodeNormalization = true
+	check(nfc, "")
+	check(nfd, nfd)
+}
+
+func TestNewObjectNormalized(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	f, err := mockfs.NewFs(ctx, "mock", "", nil)
+	require.NoError(t, err)
+	f.(*mockfs.Fs).AddObject(mockobject.Object("cafe\u0301"))
+	f.(*mockfs.Fs).AddObject(mockobject.Object("Mixed.txt"))
+
+	o, err := NewObjectNormalized(ctx, f, "caf\u00e9")
+	require.NoError(t, err)
+	assert.Equal(t, "cafe\u0301", o.Remote())
+
+	// Case is never ignored as that needs a listing
+	ci.IgnoreCaseSync = true
+	_, err = NewObjectNormalized(ctx, f, "mixed.txt")
+	assert.ErrorIs(t, err, fs.ErrorObjectNotFound)
+}
@@ -3,6 +3,7 @@ package list
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -22,9 +23,14 @@ import (
 //
 // Files will be returned in sorted order
 func DirSorted(ctx context.Context, f fs.Fs, includeAll bool, dir string) (entries fs.DirEntries, err error) {
-	// Get unfiltered entries from the fs
-	entries, err = f.List(ctx, dir)
-	accounting.Stats(ctx).Listed(int64(len(entries)))
+	// Get unfiltered entries from the fs using paged listing if
+	// the backend supports it
+	stats := accounting.Stats(ctx)
+	err = listP(ctx, f, dir, func(page fs.DirEntries) error {
+		stats.Listed(int64(len(page)))
+		entries = append(entries, page...)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +46,25 @@ func DirSorted(ctx context.Context, f fs.Fs, includeAll bool, dir string) (entri
 }
 
 // listP for every backend
+//
+// If --rc-use-server is set the listing is read from the listing cache
+// in the rc server, falling back to the backend if that fails.
 func listP(ctx context.Context, f fs.Fs, dir string, callback fs.ListRCallback) error {
+	if c := getCacheClient(ctx); c != nil {
+		entries, err := c.list(ctx, f, dir)
+		if err == nil {
+			return callback(entries)
+		}
+		if errors.Is(err, fs.ErrorDirNotFound) {
+			return err
+		}
+		c.disable(err)
+	}
+	return backendListP(ctx, f, dir, callback)
+}
+
+// backendListP lists dir on the backend using ListP if available
+func backendListP(ctx context.Context, f fs.Fs, dir string, callback fs.ListRCallback) error {
 	if doListP := f.Features().ListP; doListP != nil {
 		return doListP(ctx, dir, callback)
 	}
@@ -136,6 +160,9 @@ func filterDir(ctx context.Context, entries fs.DirEntries, includeAll bool, dir
 		switch {
 		case !ok:
 			// ignore
+		case fs.InTrash(ctx, remote):
+			ok = false
+			fs.Debugf(entry, "Excluded as in --use-trash directory")
 		case !strings.HasPrefix(remote, prefix):
 			ok = false
 			fs.Errorf(entry, "Entry doesn't belong in directory %q (too short) - ignoring", dir)
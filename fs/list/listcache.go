@@ -0,0 +1,309 @@
+// Listing cache in the rc server shared with commands run with
+// --rc-use-server
+
+package list
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/rc"
+)
+
+// listCachePollInterval is how often to poll for changes on backends
+// which support ChangeNotify
+const listCachePollInterval = time.Minute
+
+// listCacheItem is a directory entry as sent from the server
+type listCacheItem struct {
+	Path    string    `json:"Path"`
+	Size    int64     `json:"Size"`
+	ModTime time.Time `json:"ModTime"`
+	IsDir   bool      `json:"IsDir,omitempty"`
+}
+
+// listCacheDir is a cached directory listing
+type listCacheDir struct {
+	entries fs.DirEntries
+	expires time.Time
+}
+
+// listCache caches directory listings in the rc server
+type listCache struct {
+	mu     sync.Mutex
+	dirs   map[string]*listCacheDir // listings by Fs and directory
+	notify map[string]struct{}      // Fs which ChangeNotify has been started on
+}
+
+// serverCache is the listing cache used by the rc server
+var serverCache = newListCache()
+
+func newListCache() *listCache {
+	return &listCache{
+		dirs:   make(map[string]*listCacheDir),
+		notify: make(map[string]struct{}),
+	}
+}
+
+// listCacheKey makes the key for dir on the Fs with config string fsString
+func listCacheKey(fsString, dir string) string {
+	return fsString + "\x00" + dir
+}
+
+// parentDir returns the directory remote is in, "" for the root
+func parentDir(remote string) string {
+	dir := path.Dir(remote)
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return dir
+}
+
+// cached returns the unexpired listing of dir on f or nil
+func (c *listCache) cached(f fs.Fs, dir string) fs.DirEntries {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := listCacheKey(fs.ConfigString(f), dir)
+	d := c.dirs[key]
+	if d == nil {
+		return nil
+	}
+	if time.Now().After(d.expires) {
+		delete(c.dirs, key)
+		return nil
+	}
+	return d.entries
+}
+
+// list returns the listing of dir on f, reading it from the backend
+// if it isn't cached.
+func (c *listCache) list(ctx context.Context, f fs.Fs, dir string, ttl time.Duration) (entries fs.DirEntries, err error) {
+	if entries = c.cached(f, dir); entries != nil {
+		return entries, nil
+	}
+	c.startNotify(f)
+	entries = fs.DirEntries{}
+	err = backendListP(ctx, f, dir, func(page fs.DirEntries) error {
+		entries = append(entries, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.dirs[listCacheKey(fs.ConfigString(f), dir)] = &listCacheDir{
+		entries: entries,
+		expires: time.Now().Add(ttl),
+	}
+	c.mu.Unlock()
+	return entries, nil
+}
+
+// invalidate the listing of dir in the Fs with config string
+// fsString, and all the directories below it if recursive is set.
+func (c *listCache) invalidate(fsString, dir string, recursive bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.dirs, listCacheKey(fsString, dir))
+	if !recursive {
+		return
+	}
+	prefix := listCacheKey(fsString, dir)
+	if dir != "" {
+		prefix += "/"
+	}
+	for key := range c.dirs {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.dirs, key)
+		}
+	}
+}
+
+// startNotify starts ChangeNotify on f if it supports it, invalidating
+// the listings which change.
+func (c *listCache) startNotify(f fs.Fs) {
+	changeNotify := f.Features().ChangeNotify
+	if changeNotify == nil {
+		return
+	}
+	fsString := fs.ConfigString(f)
+	c.mu.Lock()
+	_, started := c.notify[fsString]
+	c.notify[fsString] = struct{}{}
+	c.mu.Unlock()
+	if started {
+		return
+	}
+	fs.Debugf(f, "Listing cache: polling for changes every %v", listCachePollInterval)
+	pollInterval := make(chan time.Duration, 1)
+	pollInterval <- listCachePollInterval
+	changeNotify(context.Background(), func(remote string, entryType fs.EntryType) {
+		fs.Debugf(f, "Listing cache: invalidating %q after change", remote)
+		c.invalidate(fsString, parentDir(remote), false)
+		if entryType == fs.EntryDirectory {
+			c.invalidate(fsString, remote, true)
+		}
+	}, pollInterval)
+}
+
+// findObject finds remote on f using the cached listing of its
+// directory if possible.
+func (c *listCache) findObject(ctx context.Context, f fs.Fs, remote string) (fs.Object, error) {
+	for _, entry := range c.cached(f, parentDir(remote)) {
+		if o, ok := entry.(fs.Object); ok && o.Remote() == remote {
+			return o, nil
+		}
+	}
+	return f.NewObject(ctx, remote)
+}
+
+// errListCacheDisabled is returned if the rc server doesn't have the
+// listing cache enabled
+var errListCacheDisabled = errors.New("listing cache not enabled - run the server with --rc-list-cache")
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "listcache/list",
+		AuthRequired: true,
+		Fn:           rcListCacheList,
+		Title:        "List a directory using the listing cache.",
+		Help: `This takes the following parameters:
+
+- fs - a remote name string e.g. "drive:"
+- dir - the directory to list, "" for the root
+
+It returns the unfiltered listing of the directory, reading it from the
+cache if it was listed less than --rc-list-cache-ttl ago.
+
+Returns:
+
+- list - an array of items with Path, Size, ModTime and IsDir
+
+This is used by commands run with --rc-use-server and needs the server
+to be started with --rc-list-cache.
+`,
+	})
+	rc.Add(rc.Call{
+		Path:         "listcache/hash",
+		AuthRequired: true,
+		Fn:           rcListCacheHash,
+		Title:        "Read the hash of an object in the listing cache.",
+		Help: `This takes the following parameters:
+
+- fs - a remote name string e.g. "drive:"
+- remote - the path of the object
+- hashType - the name of the hash e.g. "md5"
+
+Returns:
+
+- hash - the hash of the object or "" if it isn't known
+
+This is used by commands run with --rc-use-server and needs the server
+to be started with --rc-list-cache.
+`,
+	})
+	rc.Add(rc.Call{
+		Path:         "listcache/invalidate",
+		AuthRequired: true,
+		Fn:           rcListCacheInvalidate,
+		Title:        "Remove directories from the listing cache.",
+		Help: `This takes the following parameters:
+
+- fs - a remote name string e.g. "drive:"
+- dir - the directory to remove, "" for the root
+- recursive - set to remove the directories below it too
+
+This is used by commands run with --rc-use-server when they change a
+directory and needs the server to be started with --rc-list-cache.
+`,
+	})
+}
+
+// List a directory using the listing cache
+func rcListCacheList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	if !rc.Opt.ListCache {
+		return nil, errListCacheDisabled
+	}
+	f, err := rc.GetFs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := in.GetString("dir")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	entries, err := serverCache.list(ctx, f, dir, time.Duration(rc.Opt.ListCacheTTL))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]listCacheItem, 0, len(entries))
+	for _, entry := range entries {
+		_, isDir := entry.(fs.Directory)
+		items = append(items, listCacheItem{
+			Path:    entry.Remote(),
+			Size:    entry.Size(),
+			ModTime: entry.ModTime(ctx),
+			IsDir:   isDir,
+		})
+	}
+	return rc.Params{"list": items}, nil
+}
+
+// Read the hash of an object in the listing cache
+func rcListCacheHash(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	if !rc.Opt.ListCache {
+		return nil, errListCacheDisabled
+	}
+	f, err := rc.GetFs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := in.GetString("remote")
+	if err != nil {
+		return nil, err
+	}
+	hashName, err := in.GetString("hashType")
+	if err != nil {
+		return nil, err
+	}
+	var ht hash.Type
+	if err = ht.Set(hashName); err != nil {
+		return nil, err
+	}
+	o, err := serverCache.findObject(ctx, f, remote)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := o.Hash(ctx, ht)
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{"hash": sum}, nil
+}
+
+// Remove directories from the listing cache
+func rcListCacheInvalidate(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	if !rc.Opt.ListCache {
+		return nil, errListCacheDisabled
+	}
+	f, err := rc.GetFs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := in.GetString("dir")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	recursive, err := in.GetBool("recursive")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	serverCache.invalidate(fs.ConfigString(f), dir, recursive)
+	return nil, nil
+}
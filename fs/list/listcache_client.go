@@ -0,0 +1,387 @@
+// Listing cache client for --rc-use-server
+
+package list
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/lib/rest"
+)
+
+// cacheClient reads listings from the rc server given by --rc-use-server
+type cacheClient struct {
+	srv      *rest.Client
+	disabled atomic.Bool // set if the server can't be used
+}
+
+var (
+	cacheClientsMu sync.Mutex
+	cacheClients   = map[string]*cacheClient{} // clients by URL
+)
+
+// getCacheClient returns the client for --rc-use-server or nil if it
+// isn't in use.
+func getCacheClient(ctx context.Context) *cacheClient {
+	rootURL := rc.Opt.UseServer
+	if rootURL == "" {
+		return nil
+	}
+	cacheClientsMu.Lock()
+	c := cacheClients[rootURL]
+	if c == nil {
+		if !strings.HasSuffix(rootURL, "/") {
+			rootURL += "/"
+		}
+		c = &cacheClient{
+			srv: rest.NewClient(fshttp.NewClient(ctx)).SetRoot(rootURL).SetErrorHandler(cacheErrorHandler),
+		}
+		cacheClients[rc.Opt.UseServer] = c
+	}
+	cacheClientsMu.Unlock()
+	if c.disabled.Load() {
+		return nil
+	}
+	return c
+}
+
+// UsingCache returns true if listings are being read from the rc
+// server given by --rc-use-server.
+//
+// Callers should list directory by directory rather than using ListR
+// when this is set so the cache is used.
+func UsingCache(ctx context.Context) bool {
+	return getCacheClient(ctx) != nil
+}
+
+// cacheError is an error returned by the rc server
+type cacheError struct {
+	Status  int    `json:"status"`
+	Message string `json:"error"`
+}
+
+// Error satisfies the error interface
+func (e *cacheError) Error() string {
+	return fmt.Sprintf("rc server error: %s (%d)", e.Message, e.Status)
+}
+
+// cacheErrorHandler parses an error returned by the rc server
+func cacheErrorHandler(resp *http.Response) error {
+	body, err := rest.ReadBody(resp)
+	if err != nil {
+		return fmt.Errorf("error reading error out of body: %w", err)
+	}
+	e := &cacheError{}
+	if err := json.Unmarshal(body, e); err != nil || e.Message == "" {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	e.Status = resp.StatusCode
+	return e
+}
+
+// isNotFound returns true if err is a not found error from the server
+//
+// Servers without the listing cache return not found for the method
+// so these don't count.
+func isNotFound(err error) bool {
+	var e *cacheError
+	return errors.As(err, &e) && e.Status == http.StatusNotFound && !strings.Contains(e.Message, "couldn't find method")
+}
+
+// disable the client after err
+func (c *cacheClient) disable(err error) {
+	if c.disabled.CompareAndSwap(false, true) {
+		fs.Errorf(nil, "Not using listing cache from --rc-use-server: %v", err)
+	}
+}
+
+// call the rc method path on the server
+func (c *cacheClient) call(ctx context.Context, path string, in rc.Params, out any) error {
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   path,
+	}
+	_, err := c.srv.CallJSON(ctx, &opts, in, out)
+	return err
+}
+
+// list dir on f using the server
+func (c *cacheClient) list(ctx context.Context, f fs.Fs, dir string) (entries fs.DirEntries, err error) {
+	var out struct {
+		List []listCacheItem `json:"list"`
+	}
+	err = c.call(ctx, "listcache/list", rc.Params{"fs": fs.ConfigStringFull(f), "dir": dir}, &out)
+	if isNotFound(err) {
+		return nil, fs.ErrorDirNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	entries = make(fs.DirEntries, 0, len(out.List))
+	for _, item := range out.List {
+		if item.IsDir {
+			entries = append(entries, fs.NewDir(item.Path, item.ModTime).SetSize(item.Size))
+			continue
+		}
+		entries = append(entries, &cachedObject{
+			c:       c,
+			f:       f,
+			remote:  item.Path,
+			size:    item.Size,
+			modTime: item.ModTime,
+		})
+	}
+	return entries, nil
+}
+
+// hash reads the hash of remote on f from the server
+func (c *cacheClient) hash(ctx context.Context, f fs.Fs, remote string, ht hash.Type) (string, error) {
+	var out struct {
+		Hash string `json:"hash"`
+	}
+	err := c.call(ctx, "listcache/hash", rc.Params{"fs": fs.ConfigStringFull(f), "remote": remote, "hashType": ht.String()}, &out)
+	if isNotFound(err) {
+		return "", fs.ErrorObjectNotFound
+	}
+	return out.Hash, err
+}
+
+// invalidate dir on f in the server
+func (c *cacheClient) invalidate(ctx context.Context, f fs.Fs, dir string, recursive bool) {
+	err := c.call(ctx, "listcache/invalidate", rc.Params{"fs": fs.ConfigStringFull(f), "dir": dir, "recursive": recursive}, nil)
+	if err != nil {
+		fs.Debugf(f, "Failed to invalidate %q in listing cache: %v", dir, err)
+	}
+}
+
+// CacheInvalidate tells the rc server given by --rc-use-server that
+// remote on f has been changed so its listing is read again. Set isDir
+// if remote is a directory.
+//
+// It does nothing if --rc-use-server isn't in use.
+func CacheInvalidate(ctx context.Context, f fs.Info, remote string, isDir bool) {
+	c := getCacheClient(ctx)
+	if c == nil {
+		return
+	}
+	fsys, ok := f.(fs.Fs)
+	if !ok {
+		return
+	}
+	c.invalidate(ctx, fsys, parentDir(remote), false)
+	if isDir {
+		c.invalidate(ctx, fsys, remote, true)
+	}
+}
+
+// ResolveObject returns the backend object for o if it was read from
+// the listing cache, otherwise it returns o.
+//
+// Use this before passing o to backend methods which need their own
+// object type, such as server-side Copy and Move.
+func ResolveObject(ctx context.Context, o fs.Object) fs.Object {
+	co, ok := o.(*cachedObject)
+	if !ok {
+		return o
+	}
+	resolved, err := co.resolve(ctx)
+	if err != nil {
+		fs.Debugf(o, "Failed to resolve object from listing cache: %v", err)
+		return o
+	}
+	return resolved
+}
+
+// cachedObject is an object from a listing read from the rc server.
+//
+// Its size and modification time come from the listing. It is
+// resolved into the backend object when anything else is needed.
+type cachedObject struct {
+	c       *cacheClient
+	f       fs.Fs
+	remote  string
+	size    int64
+	modTime time.Time
+	mu      sync.Mutex
+	o       fs.Object // backend object once resolved
+}
+
+// resolve the backend object
+func (o *cachedObject) resolve(ctx context.Context) (fs.Object, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.o == nil {
+		obj, err := o.f.NewObject(ctx, o.remote)
+		if err != nil {
+			return nil, err
+		}
+		o.o = obj
+	}
+	return o.o, nil
+}
+
+// resolved returns the backend object if it has been resolved or nil
+func (o *cachedObject) resolved() fs.Object {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.o
+}
+
+// String returns a description of the Object
+func (o *cachedObject) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *cachedObject) Remote() string {
+	return o.remote
+}
+
+// Fs returns the Fs the object is in
+func (o *cachedObject) Fs() fs.Info {
+	return o.f
+}
+
+// ModTime returns the modification time of the object
+func (o *cachedObject) ModTime(ctx context.Context) time.Time {
+	if obj := o.resolved(); obj != nil {
+		return obj.ModTime(ctx)
+	}
+	return o.modTime
+}
+
+// Size returns the size of the object
+func (o *cachedObject) Size() int64 {
+	if obj := o.resolved(); obj != nil {
+		return obj.Size()
+	}
+	return o.size
+}
+
+// Storable says whether this object can be stored
+func (o *cachedObject) Storable() bool {
+	return true
+}
+
+// Hash returns the hash of the object reading it from the server if
+// possible
+func (o *cachedObject) Hash(ctx context.Context, ht hash.Type) (string, error) {
+	if !o.f.Hashes().Contains(ht) {
+		return "", hash.ErrUnsupported
+	}
+	if obj := o.resolved(); obj != nil {
+		return obj.Hash(ctx, ht)
+	}
+	sum, err := o.c.hash(ctx, o.f, o.remote, ht)
+	if err == nil {
+		return sum, nil
+	}
+	fs.Debugf(o, "Failed to read hash from listing cache: %v", err)
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	return obj.Hash(ctx, ht)
+}
+
+// SetModTime sets the modification time of the object
+func (o *cachedObject) SetModTime(ctx context.Context, t time.Time) error {
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	err = obj.SetModTime(ctx, t)
+	CacheInvalidate(ctx, o.f, o.remote, false)
+	return err
+}
+
+// Open opens the object for reading
+func (o *cachedObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return obj.Open(ctx, options...)
+}
+
+// Update the object with the contents of in
+func (o *cachedObject) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	return obj.Update(ctx, in, src, options...)
+}
+
+// Remove the object
+func (o *cachedObject) Remove(ctx context.Context) error {
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	return obj.Remove(ctx)
+}
+
+// MimeType returns the MIME type of the object
+func (o *cachedObject) MimeType(ctx context.Context) string {
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return ""
+	}
+	if do, ok := obj.(fs.MimeTyper); ok {
+		return do.MimeType(ctx)
+	}
+	return ""
+}
+
+// ID returns the ID of the object if known
+func (o *cachedObject) ID() string {
+	obj, err := o.resolve(context.Background())
+	if err != nil {
+		return ""
+	}
+	if do, ok := obj.(fs.IDer); ok {
+		return do.ID()
+	}
+	return ""
+}
+
+// Metadata returns the metadata of the object
+func (o *cachedObject) Metadata(ctx context.Context) (fs.Metadata, error) {
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if do, ok := obj.(fs.Metadataer); ok {
+		return do.Metadata(ctx)
+	}
+	return nil, nil
+}
+
+// UnWrap returns the backend object
+func (o *cachedObject) UnWrap() fs.Object {
+	return ResolveObject(context.Background(), o)
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Object          = (*cachedObject)(nil)
+	_ fs.MimeTyper       = (*cachedObject)(nil)
+	_ fs.IDer            = (*cachedObject)(nil)
+	_ fs.Metadataer      = (*cachedObject)(nil)
+	_ fs.ObjectUnWrapper = (*cachedObject)(nil)
+)
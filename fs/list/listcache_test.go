@@ -0,0 +1,133 @@
+package list
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listCacheServer runs the listcache/* rc calls over http
+func listCacheServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := rc.Calls.Get(strings.TrimPrefix(r.URL.Path, "/"))
+		if call == nil {
+			http.Error(w, "couldn't find method", http.StatusNotFound)
+			return
+		}
+		in := rc.Params{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		out, err := call.Fn(r.Context(), in)
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			var status int
+			out, status = rc.Error(call.Path, in, err, http.StatusInternalServerError)
+			w.WriteHeader(status)
+		}
+		if out == nil {
+			out = rc.Params{}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+}
+
+func TestListCache(t *testing.T) {
+	ctx := context.Background()
+	server := listCacheServer(t)
+	defer server.Close()
+
+	oldOpt := rc.Opt
+	defer func() {
+		rc.Opt = oldOpt
+	}()
+	rc.Opt.ListCache = true
+	rc.Opt.ListCacheTTL = fs.Duration(time.Hour)
+	rc.Opt.UseServer = server.URL
+
+	f, err := mockfs.NewFs(ctx, "listcache-test", "", nil)
+	require.NoError(t, err)
+	mf := f.(*mockfs.Fs)
+	mf.SetHashes(hash.NewHashSet(hash.MD5))
+	cache.Put(fs.ConfigStringFull(f), f)
+	defer cache.Clear()
+
+	t1 := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	a := mockobject.New("a").WithContent([]byte("hello"), mockobject.SeekModeNone)
+	require.NoError(t, a.SetModTime(ctx, t1))
+	mf.AddObject(a)
+
+	entries, err := DirSorted(ctx, f, true, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	o, ok := entries[0].(*cachedObject)
+	require.True(t, ok, "expecting object from the listing cache")
+	assert.Equal(t, "a", o.Remote())
+	assert.Equal(t, int64(5), o.Size())
+	assert.True(t, t1.Equal(o.ModTime(ctx)))
+	assert.Nil(t, o.resolved())
+
+	// Hash is read from the server without resolving the object
+	sum, err := o.Hash(ctx, hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", sum)
+	assert.Nil(t, o.resolved())
+
+	// The object is resolved when needed by the backend
+	assert.Equal(t, fs.Object(a), ResolveObject(ctx, o))
+	assert.NotNil(t, o.resolved())
+
+	// Changes aren't seen until the directory is invalidated
+	mf.AddObject(mockobject.New("b"))
+	entries, err = DirSorted(ctx, f, true, "")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	CacheInvalidate(ctx, f, "b", false)
+	entries, err = DirSorted(ctx, f, true, "")
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// Missing directories are reported
+	_, err = DirSorted(ctx, f, true, "potato")
+	assert.ErrorIs(t, err, fs.ErrorDirNotFound)
+	assert.True(t, UsingCache(ctx))
+}
+
+func TestListCacheDisabled(t *testing.T) {
+	ctx := context.Background()
+	server := listCacheServer(t)
+	defer server.Close()
+
+	oldOpt := rc.Opt
+	defer func() {
+		rc.Opt = oldOpt
+	}()
+	rc.Opt.ListCache = false
+	rc.Opt.UseServer = server.URL
+
+	f, err := mockfs.NewFs(ctx, "listcache-test-disabled", "", nil)
+	require.NoError(t, err)
+	f.(*mockfs.Fs).AddObject(mockobject.New("a"))
+	cache.Put(fs.ConfigStringFull(f), f)
+	defer cache.Clear()
+
+	// Falls back to listing the backend if the server can't be used
+	entries, err := DirSorted(ctx, f, true, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	_, ok := entries[0].(*cachedObject)
+	assert.False(t, ok)
+	assert.False(t, UsingCache(ctx))
+}
@@ -146,6 +146,9 @@ func logSlogWithObject(level LogLevel, o any, text string, attrs []any) {
 			"object", fmt.Sprintf("%+v", o),
 			"objectType", fmt.Sprintf("%T", o),
 		})
+		if traceID := TraceID(o); traceID != "" {
+			attrs = append(attrs, "traceId", traceID)
+		}
 	}
 	logSlog(level, text, attrs)
 }
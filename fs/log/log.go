@@ -46,6 +46,11 @@ var OptionsInfo = fs.Options{{
 	Default: logFormatDate | logFormatTime,
 	Help:    "Comma separated list of log format options",
 	Groups:  "Logging",
+}, {
+	Name:    "log_otel_endpoint",
+	Default: "",
+	Help:    "OpenTelemetry OTLP/HTTP endpoint to export the trace of each file to, e.g. http://localhost:4318",
+	Groups:  "Logging",
 }, {
 	Name:    "syslog",
 	Default: false,
@@ -82,6 +87,7 @@ type Options struct {
 	MaxAge               fs.Duration   `config:"log_file_max_age"`     // Max age of of log file
 	Compress             bool          `config:"log_file_compress"`    // Set to compress log file
 	Format               logFormat     `config:"log_format"`           // Comma separated list of log format options
+	OtelEndpoint         string        `config:"log_otel_endpoint"`    // OTLP/HTTP endpoint to export traces to
 	UseSyslog            bool          `config:"syslog"`               // Use Syslog for logging
 	SyslogFacility       string        `config:"syslog_facility"`      // Facility for syslog, e.g. KERN,USER,...
 	LogSystemdSupport    bool          `config:"log_systemd"`          // set if using systemd logging
@@ -253,6 +259,14 @@ func InitLogging() {
 	// Set the format to the configured format
 	Handler.setFormat(Opt.Format)
 
+	// Trace the life of each file for JSON logs and OpenTelemetry
+	if Opt.Format&logFormatJSON != 0 || Opt.OtelEndpoint != "" {
+		fs.EnableTraces(true)
+	}
+	if Opt.OtelEndpoint != "" {
+		startOtelExport(Opt.OtelEndpoint)
+	}
+
 	// Syslog output
 	if Opt.UseSyslog {
 		if Opt.File != "" {
@@ -0,0 +1,191 @@
+// Export the traces of objects to OpenTelemetry
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/atexit"
+)
+
+const (
+	otelBatchSize     = 512              // export when this many spans are waiting
+	otelFlushInterval = 5 * time.Second  // export at least this often
+	otelTimeout       = 30 * time.Second // timeout for each export
+)
+
+// otelExporter sends the spans of finished objects to an OTLP/HTTP
+// endpoint using the JSON encoding.
+type otelExporter struct {
+	url    string
+	client *http.Client
+	mu     sync.Mutex
+	spans  []*fs.TraceSpan
+}
+
+// newOtelExporter makes an exporter for the OTLP/HTTP endpoint, e.g.
+// http://localhost:4318
+func newOtelExporter(endpoint string) *otelExporter {
+	url := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	return &otelExporter{
+		url:    url,
+		client: &http.Client{Timeout: otelTimeout},
+	}
+}
+
+// startOtelExport exports the traces to endpoint until rclone exits
+func startOtelExport(endpoint string) {
+	e := newOtelExporter(endpoint)
+	fs.TraceExport = e.add
+	go func() {
+		for range time.Tick(otelFlushInterval) {
+			e.flush()
+		}
+	}()
+	atexit.Register(e.flush)
+}
+
+// add a finished span to be exported
+func (e *otelExporter) add(span *fs.TraceSpan) {
+	e.mu.Lock()
+	e.spans = append(e.spans, span)
+	full := len(e.spans) >= otelBatchSize
+	e.mu.Unlock()
+	if full {
+		go e.flush()
+	}
+}
+
+// flush exports the waiting spans logging any errors
+func (e *otelExporter) flush() {
+	e.mu.Lock()
+	spans := e.spans
+	e.spans = nil
+	e.mu.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+	if err := e.export(context.Background(), spans); err != nil {
+		fs.Errorf(nil, "Failed to export %d traces to OpenTelemetry: %v", len(spans), err)
+	}
+}
+
+// OTLP JSON encoding of the spans
+type (
+	otelValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otelAttribute struct {
+		Key   string    `json:"key"`
+		Value otelValue `json:"value"`
+	}
+	otelStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otelSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otelAttribute `json:"attributes"`
+		Status            otelStatus      `json:"status"`
+	}
+	otelScope struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	otelScopeSpans struct {
+		Scope otelScope  `json:"scope"`
+		Spans []otelSpan `json:"spans"`
+	}
+	otelResource struct {
+		Attributes []otelAttribute `json:"attributes"`
+	}
+	otelResourceSpans struct {
+		Resource   otelResource     `json:"resource"`
+		ScopeSpans []otelScopeSpans `json:"scopeSpans"`
+	}
+	otelRequest struct {
+		ResourceSpans []otelResourceSpans `json:"resourceSpans"`
+	}
+)
+
+// OTLP span kinds and status codes
+const (
+	otelKindInternal = 1
+	otelStatusOK     = 1
+	otelStatusError  = 2
+)
+
+// encode the spans as an OTLP export request
+func (e *otelExporter) encode(spans []*fs.TraceSpan) otelRequest {
+	out := make([]otelSpan, 0, len(spans))
+	for _, span := range spans {
+		s := otelSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			Name:              "transfer",
+			Kind:              otelKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes: []otelAttribute{
+				{Key: "rclone.remote", Value: otelValue{StringValue: span.Remote}},
+			},
+			Status: otelStatus{Code: otelStatusOK},
+		}
+		if span.Err != nil {
+			s.Status = otelStatus{Code: otelStatusError, Message: span.Err.Error()}
+		}
+		out = append(out, s)
+	}
+	return otelRequest{
+		ResourceSpans: []otelResourceSpans{{
+			Resource: otelResource{
+				Attributes: []otelAttribute{
+					{Key: "service.name", Value: otelValue{StringValue: "rclone"}},
+				},
+			},
+			ScopeSpans: []otelScopeSpans{{
+				Scope: otelScope{Name: "rclone", Version: fs.Version},
+				Spans: out,
+			}},
+		}},
+	}
+}
+
+// export POSTs the spans to the endpoint
+func (e *otelExporter) export(ctx context.Context, spans []*fs.TraceSpan) error {
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
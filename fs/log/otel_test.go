@@ -0,0 +1,67 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOtelExporter(t *testing.T) {
+	assert.Equal(t, "http://localhost:4318/v1/traces", newOtelExporter("http://localhost:4318").url)
+	assert.Equal(t, "http://localhost:4318/v1/traces", newOtelExporter("http://localhost:4318/").url)
+	assert.Equal(t, "http://localhost:4318/v1/traces", newOtelExporter("http://localhost:4318/v1/traces").url)
+}
+
+func TestOtelExport(t *testing.T) {
+	requests := make(chan otelRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var req otelRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests <- req
+	}))
+	defer server.Close()
+
+	e := newOtelExporter(server.URL)
+	start := time.Unix(1, 0)
+	end := time.Unix(2, 0)
+	e.add(&fs.TraceSpan{TraceID: "0123456789abcdef0123456789abcdef", SpanID: "0123456789abcdef", Remote: "ok", Start: start, End: end})
+	e.add(&fs.TraceSpan{TraceID: "fedcba9876543210fedcba9876543210", SpanID: "fedcba9876543210", Remote: "bad", Start: start, End: end, Err: errors.New("boom")})
+	e.flush()
+	require.Len(t, requests, 1)
+	req := <-requests
+
+	// Nothing to send
+	e.flush()
+	assert.Len(t, requests, 0)
+
+	require.Len(t, req.ResourceSpans, 1)
+	assert.Equal(t, "service.name", req.ResourceSpans[0].Resource.Attributes[0].Key)
+	assert.Equal(t, "rclone", req.ResourceSpans[0].Resource.Attributes[0].Value.StringValue)
+	require.Len(t, req.ResourceSpans[0].ScopeSpans, 1)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+	assert.Equal(t, otelSpan{
+		TraceID:           "0123456789abcdef0123456789abcdef",
+		SpanID:            "0123456789abcdef",
+		Name:              "transfer",
+		Kind:              otelKindInternal,
+		StartTimeUnixNano: "1000000000",
+		EndTimeUnixNano:   "2000000000",
+		Attributes:        []otelAttribute{{Key: "rclone.remote", Value: otelValue{StringValue: "ok"}}},
+		Status:            otelStatus{Code: otelStatusOK},
+	}, spans[0])
+	assert.Equal(t, otelStatus{Code: otelStatusError, Message: "boom"}, spans[1].Status)
+
+	// Errors are returned from export
+	server.Close()
+	assert.Error(t, e.export(t.Context(), []*fs.TraceSpan{{Remote: "x"}}))
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/configstruct"
 	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/lib/encoder"
 )
 
 // Store the hashes of the overridden config
@@ -25,6 +26,12 @@ var (
 	overriddenConfig   = make(map[string]string)
 )
 
+// Store the encoding tables of the remotes by name
+var (
+	encodingTablesMu sync.Mutex
+	encodingTables   = make(map[string]*encoder.Table)
+)
+
 // NewFs makes a new Fs object from the path
 //
 // The path is of the form remote:path
@@ -72,6 +79,11 @@ func NewFs(ctx context.Context, path string) (Fs, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = setEncodingTable(configName, config)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withPacerName(ctx, configName)
 	f, err := fsInfo.NewFs(ctx, configName, fsPath, config)
 	if f != nil && (err == nil || err == ErrorIsFile) {
 		addReverse(f, fsInfo)
@@ -79,6 +91,35 @@ func NewFs(ctx context.Context, path string) (Fs, error) {
 	return f, err
 }
 
+// setEncodingTable parses the encoding_table option of the remote
+// and stores it for EncodingTable.
+func setEncodingTable(configName string, config configmap.Getter) error {
+	var table *encoder.Table
+	if spec, ok := config.Get(optEncodingTable.Name); ok && spec != "" {
+		var err error
+		table, err = encoder.ParseTable(spec)
+		if err != nil {
+			return fmt.Errorf("%s: %w", configName, err)
+		}
+	}
+	encodingTablesMu.Lock()
+	defer encodingTablesMu.Unlock()
+	if table == nil {
+		delete(encodingTables, configName)
+	} else {
+		encodingTables[configName] = table
+	}
+	return nil
+}
+
+// EncodingTable returns the custom character mapping table set with
+// the encoding_table option of the remote f or nil if there isn't one.
+func EncodingTable(f Info) *encoder.Table {
+	encodingTablesMu.Lock()
+	defer encodingTablesMu.Unlock()
+	return encodingTables[f.Name()]
+}
+
 // Add "global" config or "override" to ctx and the global config if required.
 //
 // This looks through keys prefixed with "global." or "override." in
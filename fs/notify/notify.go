@@ -0,0 +1,327 @@
+// Package notify sends notifications by webhook or email when sync,
+// copy, move and bisync jobs finish or credentials can't be refreshed.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/cache"
+)
+
+// Events is the set of events to send notifications for
+type Events = fs.Bits[eventsChoices]
+
+// Events definitions
+const (
+	EventSuccess Events = 1 << iota
+	EventError
+	EventQuota
+	EventCredential
+)
+
+type eventsChoices struct{}
+
+func (eventsChoices) Choices() []fs.BitsChoicesInfo {
+	return []fs.BitsChoicesInfo{
+		{Bit: uint64(EventSuccess), Name: "success"},
+		{Bit: uint64(EventError), Name: "error"},
+		{Bit: uint64(EventQuota), Name: "quota"},
+		{Bit: uint64(EventCredential), Name: "credential"},
+	}
+}
+
+func (eventsChoices) Type() string {
+	return "NotifyEvents"
+}
+
+type templateChoices struct{}
+
+func (templateChoices) Choices() []string {
+	return []string{
+		TemplateJSON:    "json",
+		TemplateSlack:   "slack",
+		TemplateTeams:   "teams",
+		TemplateDiscord: "discord",
+	}
+}
+
+// Template is the format of the body POSTed to the webhook
+type Template = fs.Enum[templateChoices]
+
+// Template definitions
+const (
+	TemplateJSON    Template = iota // the Event as JSON
+	TemplateSlack                   // a Slack incoming webhook message
+	TemplateTeams                   // a Microsoft Teams incoming webhook message
+	TemplateDiscord                 // a Discord webhook message
+)
+
+// Type of the value
+func (templateChoices) Type() string {
+	return "NotifyTemplate"
+}
+
+// OptionsInfo describes the Options in use
+var OptionsInfo = fs.Options{{
+	Name:    "notify_on",
+	Default: EventError | EventCredential,
+	Help:    "Events to send notifications for: " + EventSuccess.Help(),
+	Groups:  "Notify",
+}, {
+	Name:    "notify_webhook",
+	Default: "",
+	Help:    "URL to POST notifications to",
+	Groups:  "Notify",
+}, {
+	Name:    "notify_webhook_template",
+	Default: TemplateJSON,
+	Help:    "Format of the webhook notifications",
+	Groups:  "Notify",
+}, {
+	Name:    "notify_email",
+	Default: "",
+	Help:    "Comma separated email addresses to send notifications to",
+	Groups:  "Notify",
+}, {
+	Name:    "notify_email_from",
+	Default: "rclone@localhost",
+	Help:    "Email address to send notifications from",
+	Groups:  "Notify",
+}, {
+	Name:    "notify_smtp_server",
+	Default: "localhost:25",
+	Help:    "SMTP server host:port to send email notifications with",
+	Groups:  "Notify",
+}, {
+	Name:    "notify_smtp_user",
+	Default: "",
+	Help:    "User name for the SMTP server if it needs authentication",
+	Groups:  "Notify",
+}, {
+	Name:       "notify_smtp_pass",
+	Default:    "",
+	Help:       "Password for the SMTP server",
+	Groups:     "Notify",
+	IsPassword: true,
+}, {
+	Name:    "notify_quota_percent",
+	Default: 90,
+	Help:    "Send a quota notification when the destination is more than this percent full",
+	Groups:  "Notify",
+}}
+
+func init() {
+	fs.RegisterGlobalOptions(fs.OptionsInfo{Name: "notify", Opt: &Opt, Options: OptionsInfo})
+}
+
+// Options contains options for notifications
+type Options struct {
+	On              Events   `config:"notify_on"`               // events to notify about
+	Webhook         string   `config:"notify_webhook"`          // URL to POST to
+	WebhookTemplate Template `config:"notify_webhook_template"` // format of the POST body
+	Email           string   `config:"notify_email"`            // comma separated addresses to email
+	EmailFrom       string   `config:"notify_email_from"`       // address to send email from
+	SMTPServer      string   `config:"notify_smtp_server"`      // host:port of the SMTP server
+	SMTPUser        string   `config:"notify_smtp_user"`        // user for SMTP auth
+	SMTPPass        string   `config:"notify_smtp_pass"`        // password for SMTP auth (obscured)
+	QuotaPercent    int      `config:"notify_quota_percent"`    // percent full to send a quota event at
+}
+
+// Opt is the global notification options
+var Opt Options
+
+// Enabled returns true if there is somewhere to send notifications
+func (opt *Options) Enabled() bool {
+	return opt.On != 0 && (opt.Webhook != "" || opt.Email != "")
+}
+
+type configContextKeyType struct{}
+
+// Context key for config
+var configContextKey = configContextKeyType{}
+
+// GetConfig returns the global or context sensitive options
+func GetConfig(ctx context.Context) *Options {
+	if ctx == nil {
+		return &Opt
+	}
+	c := ctx.Value(configContextKey)
+	if c == nil {
+		return &Opt
+	}
+	return c.(*Options)
+}
+
+// AddConfig returns a mutable copy of the options found in ctx and
+// a new context with that added to it.
+func AddConfig(ctx context.Context) (context.Context, *Options) {
+	c := GetConfig(ctx)
+	cCopy := new(Options)
+	*cCopy = *c
+	newCtx := context.WithValue(ctx, configContextKey, cCopy)
+	return newCtx, cCopy
+}
+
+// Job describes the job which finished
+type Job struct {
+	Name  string    // name of the job, e.g. "sync"
+	Src   string    // source remote if any
+	Dst   string    // destination remote if any
+	Start time.Time // when the job started
+}
+
+// Event is the notification sent
+type Event struct {
+	Event       string    `json:"event"`                 // success, error, quota or credential
+	Job         string    `json:"job"`                   // name of the job
+	Src         string    `json:"src,omitempty"`         // source remote
+	Dst         string    `json:"dst,omitempty"`         // destination remote
+	Error       string    `json:"error,omitempty"`       // error if the job failed
+	Start       time.Time `json:"start"`                 // when the job started
+	End         time.Time `json:"end"`                   // when the job finished
+	Duration    float64   `json:"duration"`              // how long the job took in seconds
+	Bytes       int64     `json:"bytes"`                 // bytes transferred
+	Transfers   int64     `json:"transfers"`             // files transferred
+	Checks      int64     `json:"checks"`                // files checked
+	Deletes     int64     `json:"deletes"`               // files deleted
+	Errors      int64     `json:"errors"`                // number of errors
+	Used        int64     `json:"used,omitempty"`        // bytes used on the destination for quota events
+	Total       int64     `json:"total,omitempty"`       // bytes total on the destination for quota events
+	UsedPercent float64   `json:"usedPercent,omitempty"` // percent of the destination used for quota events
+}
+
+// Summary returns a one line description of the event
+func (e *Event) Summary() string {
+	what := "rclone " + e.Job
+	if e.Src != "" && e.Dst != "" {
+		what += fmt.Sprintf(" %s -> %s", e.Src, e.Dst)
+	} else if e.Dst != "" {
+		what += " " + e.Dst
+	}
+	var result string
+	switch e.Event {
+	case "credential":
+		return fmt.Sprintf("rclone %s for %s %s", e.Job, e.Dst, e.Error)
+	case "error":
+		result = "failed: " + e.Error
+	case "quota":
+		return fmt.Sprintf("%s: destination is %.0f%% full (%v of %v)", what, e.UsedPercent, fs.SizeSuffix(e.Used), fs.SizeSuffix(e.Total))
+	default:
+		result = "succeeded"
+	}
+	return fmt.Sprintf("%s %s - transferred %v in %d files, checked %d, deleted %d, %d errors in %v",
+		what, result, fs.SizeSuffix(e.Bytes).ByteUnit(), e.Transfers, e.Checks, e.Deletes, e.Errors,
+		time.Duration(e.Duration*float64(time.Second)).Truncate(time.Second))
+}
+
+// JobDone sends notifications for a job which has finished with jobErr
+// according to the options in ctx.
+//
+// Failures to send are logged and not returned.
+func JobDone(ctx context.Context, job Job, jobErr error) {
+	opt := GetConfig(ctx)
+	if !opt.Enabled() {
+		return
+	}
+	stats := accounting.Stats(ctx)
+	end := time.Now()
+	ev := Event{
+		Event:     "success",
+		Job:       job.Name,
+		Src:       job.Src,
+		Dst:       job.Dst,
+		Start:     job.Start,
+		End:       end,
+		Duration:  end.Sub(job.Start).Seconds(),
+		Bytes:     stats.GetBytes(),
+		Transfers: stats.GetTransfers(),
+		Checks:    stats.GetChecks(),
+		Deletes:   stats.GetDeletes(),
+		Errors:    stats.GetErrors(),
+	}
+	if jobErr != nil {
+		ev.Event = "error"
+		ev.Error = jobErr.Error()
+	}
+	if (jobErr == nil && opt.On.IsSet(EventSuccess)) || (jobErr != nil && opt.On.IsSet(EventError)) {
+		send(ctx, opt, &ev)
+	}
+	if opt.On.IsSet(EventQuota) && job.Dst != "" {
+		used, total, err := quota(ctx, job.Dst)
+		if err != nil {
+			fs.Debugf(job.Dst, "notify: can't read quota: %v", err)
+		} else if percent := 100 * float64(used) / float64(total); percent >= float64(opt.QuotaPercent) {
+			ev.Event = "quota"
+			ev.Error = ""
+			ev.Used, ev.Total, ev.UsedPercent = used, total, percent
+			send(ctx, opt, &ev)
+		}
+	}
+}
+
+// Credential sends a notification that the credential of kind for
+// remote couldn't be refreshed or is about to expire with err saying
+// why.
+//
+// Failures to send are logged and not returned.
+func Credential(ctx context.Context, remote, kind string, err error) {
+	opt := GetConfig(ctx)
+	if !opt.Enabled() || !opt.On.IsSet(EventCredential) {
+		return
+	}
+	now := time.Now()
+	send(ctx, opt, &Event{
+		Event: "credential",
+		Job:   kind + " credential",
+		Dst:   remote,
+		Error: err.Error(),
+		Start: now,
+		End:   now,
+	})
+}
+
+// quota returns the bytes used and total for remote
+func quota(ctx context.Context, remote string) (used, total int64, err error) {
+	f, err := cache.Get(ctx, remote)
+	if err != nil && !errors.Is(err, fs.ErrorIsFile) {
+		return 0, 0, err
+	}
+	doAbout := f.Features().About
+	if doAbout == nil {
+		return 0, 0, errors.New("about not supported")
+	}
+	usage, err := doAbout(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	if usage.Total == nil || *usage.Total <= 0 {
+		return 0, 0, errors.New("total size not known")
+	}
+	if usage.Used != nil {
+		used = *usage.Used
+	} else if usage.Free != nil {
+		used = *usage.Total - *usage.Free
+	} else {
+		return 0, 0, errors.New("used size not known")
+	}
+	return used, *usage.Total, nil
+}
+
+// send the event to all the configured destinations logging any errors
+func send(ctx context.Context, opt *Options, ev *Event) {
+	if opt.Webhook != "" {
+		if err := sendWebhook(ctx, opt, ev); err != nil {
+			fs.Errorf(nil, "notify: failed to send webhook: %v", err)
+		}
+	}
+	if opt.Email != "" {
+		if err := sendEmail(opt, ev); err != nil {
+			fs.Errorf(nil, "notify: failed to send email: %v", err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookServer returns a server which sends the bodies POSTed to it
+// down the channel
+func webhookServer(t *testing.T) (url string, bodies chan []byte) {
+	bodies = make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var body json.RawMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies <- body
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, bodies
+}
+
+func TestEnabled(t *testing.T) {
+	assert.False(t, (&Options{}).Enabled())
+	assert.False(t, (&Options{On: EventError}).Enabled())
+	assert.False(t, (&Options{Webhook: "http://example.com"}).Enabled())
+	assert.True(t, (&Options{On: EventError, Webhook: "http://example.com"}).Enabled())
+	assert.True(t, (&Options{On: EventSuccess, Email: "a@example.com"}).Enabled())
+}
+
+func TestConfig(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, &Opt, GetConfig(ctx))
+	ctx2, opt := AddConfig(ctx)
+	opt.Webhook = "http://example.com"
+	assert.Equal(t, opt, GetConfig(ctx2))
+	assert.Equal(t, "", GetConfig(ctx).Webhook)
+}
+
+func TestSummary(t *testing.T) {
+	ev := Event{
+		Event:     "success",
+		Job:       "sync",
+		Src:       "/src",
+		Dst:       "remote:dst",
+		Duration:  61.5,
+		Bytes:     2048,
+		Transfers: 2,
+		Checks:    3,
+		Deletes:   1,
+	}
+	assert.Equal(t, "rclone sync /src -> remote:dst succeeded - transferred 2 KiB in 2 files, checked 3, deleted 1, 0 errors in 1m1s", ev.Summary())
+	ev.Event = "error"
+	ev.Error = "boom"
+	ev.Errors = 1
+	assert.Equal(t, "rclone sync /src -> remote:dst failed: boom - transferred 2 KiB in 2 files, checked 3, deleted 1, 1 errors in 1m1s", ev.Summary())
+	ev.Event = "quota"
+	ev.Used, ev.Total, ev.UsedPercent = 95<<20, 100<<20, 95
+	assert.Equal(t, "rclone sync /src -> remote:dst: destination is 95% full (95Mi of 100Mi)", ev.Summary())
+	ev = Event{Event: "credential", Job: "oauth credential", Dst: "drive:", Error: "failed to refresh: boom"}
+	assert.Equal(t, "rclone oauth credential for drive: failed to refresh: boom", ev.Summary())
+}
+
+func TestWebhookBody(t *testing.T) {
+	ev := &Event{Event: "success", Job: "copy"}
+	for _, test := range []struct {
+		template Template
+		key      string
+	}{
+		{TemplateSlack, "text"},
+		{TemplateTeams, "text"},
+		{TemplateDiscord, "content"},
+	} {
+		body, err := webhookBody(test.template, ev)
+		require.NoError(t, err)
+		var got map[string]string
+		require.NoError(t, json.Unmarshal(body, &got))
+		assert.Equal(t, map[string]string{test.key: ev.Summary()}, got, test.template)
+	}
+	body, err := webhookBody(TemplateJSON, ev)
+	require.NoError(t, err)
+	var got Event
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, *ev, got)
+}
+
+func TestJobDone(t *testing.T) {
+	url, bodies := webhookServer(t)
+	ctx, opt := AddConfig(context.Background())
+	opt.Webhook = url
+	opt.On = EventError
+	job := Job{Name: "sync", Src: "/src", Dst: "/dst", Start: time.Now()}
+
+	// Success isn't sent with only error set
+	JobDone(ctx, job, nil)
+	assert.Len(t, bodies, 0)
+
+	JobDone(ctx, job, errors.New("boom"))
+	require.Len(t, bodies, 1)
+	var ev Event
+	require.NoError(t, json.Unmarshal(<-bodies, &ev))
+	assert.Equal(t, "error", ev.Event)
+	assert.Equal(t, "boom", ev.Error)
+	assert.Equal(t, "sync", ev.Job)
+	assert.Equal(t, "/src", ev.Src)
+	assert.Equal(t, "/dst", ev.Dst)
+
+	opt.On = EventSuccess
+	JobDone(ctx, job, nil)
+	require.Len(t, bodies, 1)
+	ev = Event{}
+	require.NoError(t, json.Unmarshal(<-bodies, &ev))
+	assert.Equal(t, "success", ev.Event)
+	assert.Equal(t, "", ev.Error)
+}
+
+func TestJobDoneQuota(t *testing.T) {
+	url, bodies := webhookServer(t)
+	ctx, opt := AddConfig(context.Background())
+	opt.Webhook = url
+	opt.On = EventQuota
+	opt.QuotaPercent = 101
+	job := Job{Name: "copy", Dst: t.TempDir(), Start: time.Now()}
+
+	// Never more than 101% full
+	JobDone(ctx, job, nil)
+	assert.Len(t, bodies, 0)
+
+	// Always at least 0% full
+	opt.QuotaPercent = 0
+	JobDone(ctx, job, nil)
+	require.Len(t, bodies, 1)
+	var ev Event
+	require.NoError(t, json.Unmarshal(<-bodies, &ev))
+	assert.Equal(t, "quota", ev.Event)
+	assert.Greater(t, ev.Total, int64(0))
+	assert.InDelta(t, 100*float64(ev.Used)/float64(ev.Total), ev.UsedPercent, 0.001)
+}
+
+// smtpServer runs a minimal SMTP server which accepts one message
+// and sends the data down the channel
+func smtpServer(t *testing.T) (addr string, messages chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	messages = make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ready")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line + " x")[0]); cmd {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				reply("250 OK")
+			case "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				messages <- data.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 unknown")
+			}
+		}
+	}()
+	return l.Addr().String(), messages
+}
+
+func TestSendEmail(t *testing.T) {
+	addr, messages := smtpServer(t)
+	opt := &Options{
+		Email:      "a@example.com, b@example.com",
+		EmailFrom:  "rclone@example.com",
+		SMTPServer: addr,
+	}
+	ev := &Event{Event: "error", Job: "bisync", Error: "boom", End: time.Now()}
+	require.NoError(t, sendEmail(opt, ev))
+	msg := <-messages
+	assert.Contains(t, msg, "From: rclone@example.com\r\n")
+	assert.Contains(t, msg, "To: a@example.com, b@example.com\r\n")
+	assert.Contains(t, msg, "Subject: rclone bisync: error\r\n")
+	assert.Contains(t, msg, ev.Summary())
+	assert.Contains(t, msg, `"error": "boom"`)
+}
@@ -0,0 +1,13 @@
+// Package notifyflags implements command line flags to set up notifications
+package notifyflags
+
+import (
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/notify"
+	"github.com/spf13/pflag"
+)
+
+// AddFlags adds the notify flags to the flagSet
+func AddFlags(flagSet *pflag.FlagSet) {
+	flags.AddFlagsFromOptions(flagSet, "", notify.OptionsInfo)
+}
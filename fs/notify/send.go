@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/fshttp"
+)
+
+// sendTimeout is how long to wait for a notification to be accepted
+const sendTimeout = 30 * time.Second
+
+// webhookBody makes the body to POST to the webhook for ev
+func webhookBody(template Template, ev *Event) ([]byte, error) {
+	switch template {
+	case TemplateSlack, TemplateTeams:
+		return json.Marshal(map[string]string{"text": ev.Summary()})
+	case TemplateDiscord:
+		return json.Marshal(map[string]string{"content": ev.Summary()})
+	}
+	return json.Marshal(ev)
+}
+
+// sendWebhook POSTs ev to the webhook
+func sendWebhook(ctx context.Context, opt *Options, ev *Event) error {
+	// Send even if the job was cancelled
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+	defer cancel()
+	body, err := webhookBody(opt.WebhookTemplate, ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", opt.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := fshttp.NewClient(ctx).Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+// emailMessage makes the email to send for ev
+func emailMessage(opt *Options, to []string, ev *Event) ([]byte, error) {
+	details, err := json.MarshalIndent(ev, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", opt.EmailFrom)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: rclone %s: %s\r\n", ev.Job, ev.Event)
+	fmt.Fprintf(&buf, "Date: %s\r\n", ev.End.Format(time.RFC1123Z))
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	buf.WriteString(ev.Summary())
+	buf.WriteString("\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(string(details), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes(), nil
+}
+
+// sendEmail emails ev to the addresses in opt
+func sendEmail(opt *Options, ev *Event) error {
+	var to []string
+	for addr := range strings.SplitSeq(opt.Email, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	msg, err := emailMessage(opt, to, ev)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if opt.SMTPUser != "" {
+		host, _, err := net.SplitHostPort(opt.SMTPServer)
+		if err != nil {
+			return fmt.Errorf("bad --notify-smtp-server: %w", err)
+		}
+		pass, err := obscure.Reveal(opt.SMTPPass)
+		if err != nil {
+			return fmt.Errorf("bad --notify-smtp-pass: %w", err)
+		}
+		auth = smtp.PlainAuth("", opt.SMTPUser, pass, host)
+	}
+	return smtp.SendMail(opt.SMTPServer, auth, opt.EmailFrom, to, msg)
+}
@@ -271,11 +271,34 @@ func (c *checkMarch) reportResults(ctx context.Context, err error) error {
 	return nil
 }
 
+// checkHashesAny is like CheckHashes but if the destination is missing
+// the first hash in common it tries the other hashes in common.
+//
+// This means objects without the preferred hash, such as multipart
+// uploads to S3 without an MD5, can still be checked with another.
+func checkHashesAny(ctx context.Context, src fs.ObjectInfo, dst fs.Object) (equal bool, ht hash.Type, err error) {
+	common := src.Fs().Hashes().Overlap(dst.Fs().Hashes())
+	for _, ht := range common.Array() {
+		// Read the destination hash first as it is usually
+		// cheaper than the source hash which may need the file
+		// reading
+		dstHash, err := dst.Hash(ctx, ht)
+		if err == nil && dstHash == "" {
+			continue
+		}
+		equal, ht, _, _, err = checkHashes(ctx, src, dst, ht)
+		if ht != hash.None || err != nil {
+			return equal, ht, err
+		}
+	}
+	return true, hash.None, nil
+}
+
 // Check the files in fsrc and fdst according to Size and hash
 func Check(ctx context.Context, opt *CheckOpt) error {
 	optCopy := *opt
 	optCopy.Check = func(ctx context.Context, dst, src fs.Object) (differ bool, noHash bool, err error) {
-		same, ht, err := CheckHashes(ctx, src, dst)
+		same, ht, err := checkHashesAny(ctx, src, dst)
 		if err != nil {
 			return true, false, err
 		}
@@ -445,8 +468,13 @@ func CheckSum(ctx context.Context, fsrc, fsum fs.Fs, sumFile string, hashType ha
 		c.checkSum(ctx, obj, download, hashes, hashType)
 	})
 	c.wg.Wait() // wait for background go-routines
+	return c.reportUnusedSums(ctx, hashes, lastErr)
+}
 
-	// make census of unhandled sums
+// reportUnusedSums reports the sums in hashes which weren't used by
+// checkSum as missing on the destination then reports the results
+func (c *checkMarch) reportUnusedSums(ctx context.Context, hashes HashSums, lastErr error) error {
+	opt := &c.opt
 	fi := filter.GetConfig(ctx)
 	for filename, hash := range hashes {
 		if hash == "" { // the sum has been successfully consumed
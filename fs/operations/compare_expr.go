@@ -0,0 +1,703 @@
+// Expressions for --compare-expr to decide whether to transfer files
+
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// CompareExpr is a compiled --compare-expr expression
+//
+// The expression language has
+//
+//   - the attributes src.X and dst.X where X is one of size, modtime,
+//     hash, tier, mimetype, path, name or meta.KEY
+//   - equal which is true if rclone's normal comparison finds the files the same
+//   - string "..." or '...', number, true and false literals. Numbers
+//     may have a size suffix, e.g. 10M
+//   - duration("1h") to make a duration for adding to or subtracting
+//     from times
+//   - lower(x) to lower case a string
+//   - the operators ! && || == != < <= > >= =~ !~ + - and brackets
+type CompareExpr struct {
+	expr string
+	root exprNode
+}
+
+// String returns the source of the expression
+func (c *CompareExpr) String() string {
+	return c.expr
+}
+
+var compareExprCache sync.Map // expression string => *CompareExpr
+
+// CompileCompareExpr parses expr returning an error if it is invalid.
+//
+// Compiled expressions are cached.
+func CompileCompareExpr(expr string) (*CompareExpr, error) {
+	if c, ok := compareExprCache.Load(expr); ok {
+		return c.(*CompareExpr), nil
+	}
+	p := &exprParser{}
+	err := p.lex(expr)
+	if err != nil {
+		return nil, fmt.Errorf("--compare-expr: %w", err)
+	}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("--compare-expr: %w at position %d", err, p.peek().pos+1)
+	}
+	c := &CompareExpr{expr: expr, root: root}
+	compareExprCache.Store(expr, c)
+	return c, nil
+}
+
+// Eval evaluates the expression for src and dst which must both
+// exist, returning true if the file should be transferred.
+func (c *CompareExpr) Eval(ctx context.Context, src, dst fs.Object) (bool, error) {
+	env := &exprEnv{
+		ctx:    ctx,
+		src:    src,
+		dst:    dst,
+		window: fs.GetModifyWindow(ctx, dst.Fs(), src.Fs()),
+	}
+	v, err := c.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression returned %s not a boolean", typeName(v))
+	}
+	return b, nil
+}
+
+// evalCompareExpr compiles and evaluates expr for src and dst
+func evalCompareExpr(ctx context.Context, expr string, src, dst fs.Object) (bool, error) {
+	c, err := CompileCompareExpr(expr)
+	if err != nil {
+		return false, err
+	}
+	return c.Eval(ctx, src, dst)
+}
+
+// exprEnv is the environment the expression is evaluated in
+type exprEnv struct {
+	ctx      context.Context
+	src, dst fs.Object
+	window   time.Duration
+	meta     [2]fs.Metadata // metadata for src, dst once read
+	metaRead [2]bool
+}
+
+// object returns the src or dst object and its index
+func (e *exprEnv) object(side string) (fs.Object, int) {
+	if side == "src" {
+		return e.src, 0
+	}
+	return e.dst, 1
+}
+
+// exprNode is a node in the parsed expression
+type exprNode interface {
+	eval(e *exprEnv) (any, error)
+}
+
+// The types of values are bool, int64, string, time.Time and
+// time.Duration
+
+// typeName returns the name of the type of v for error messages
+func typeName(v any) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case int64:
+		return "number"
+	case string:
+		return "string"
+	case time.Time:
+		return "time"
+	case time.Duration:
+		return "duration"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// literal value
+type litNode struct {
+	v any
+}
+
+func (n litNode) eval(e *exprEnv) (any, error) {
+	return n.v, nil
+}
+
+// attribute of src or dst
+type attrNode struct {
+	side string // src or dst
+	attr string // name of the attribute
+	key  string // metadata key for meta
+}
+
+// attributes of src and dst which may be used
+var exprAttrs = map[string]bool{
+	"size":     true,
+	"modtime":  true,
+	"hash":     true,
+	"tier":     true,
+	"mimetype": true,
+	"path":     true,
+	"name":     true,
+	"meta":     true,
+}
+
+func (n attrNode) eval(e *exprEnv) (any, error) {
+	o, i := e.object(n.side)
+	switch n.attr {
+	case "size":
+		return o.Size(), nil
+	case "modtime":
+		return o.ModTime(e.ctx), nil
+	case "hash":
+		ht := e.src.Fs().Hashes().Overlap(e.dst.Fs().Hashes()).GetOne()
+		if ht == hash.None {
+			return "", nil
+		}
+		sum, err := o.Hash(e.ctx, ht)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s.hash: %w", n.side, err)
+		}
+		return sum, nil
+	case "tier":
+		if do, ok := o.(fs.GetTierer); ok {
+			return do.GetTier(), nil
+		}
+		return "", nil
+	case "mimetype":
+		return fs.MimeType(e.ctx, o), nil
+	case "path":
+		return o.Remote(), nil
+	case "name":
+		return path.Base(o.Remote()), nil
+	case "meta":
+		if !e.metaRead[i] {
+			meta, err := fs.GetMetadata(e.ctx, o)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s.meta: %w", n.side, err)
+			}
+			e.meta[i], e.metaRead[i] = meta, true
+		}
+		return e.meta[i][n.key], nil
+	}
+	return nil, fmt.Errorf("unknown attribute %s.%s", n.side, n.attr)
+}
+
+// equal is the result of the normal comparison
+type equalNode struct{}
+
+func (equalNode) eval(e *exprEnv) (any, error) {
+	return Equal(e.ctx, e.src, e.dst), nil
+}
+
+// evalBool evaluates n which must return a bool
+func evalBool(e *exprEnv, n exprNode, op string) (bool, error) {
+	v, err := n.eval(e)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s needs a boolean not a %s", op, typeName(v))
+	}
+	return b, nil
+}
+
+// ! x
+type notNode struct {
+	x exprNode
+}
+
+func (n notNode) eval(e *exprEnv) (any, error) {
+	b, err := evalBool(e, n.x, "!")
+	return !b, err
+}
+
+// x && y or x || y
+type logicNode struct {
+	op   string
+	x, y exprNode
+}
+
+func (n logicNode) eval(e *exprEnv) (any, error) {
+	x, err := evalBool(e, n.x, n.op)
+	if err != nil {
+		return nil, err
+	}
+	// short circuit so attributes which are expensive to read can be avoided
+	if (n.op == "&&" && !x) || (n.op == "||" && x) {
+		return x, nil
+	}
+	return evalBool(e, n.y, n.op)
+}
+
+// x =~ "re" or x !~ "re"
+type matchNode struct {
+	negate bool
+	x      exprNode
+	re     *regexp.Regexp
+}
+
+func (n matchNode) eval(e *exprEnv) (any, error) {
+	v, err := n.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("can only match a string not a %s", typeName(v))
+	}
+	return n.re.MatchString(s) != n.negate, nil
+}
+
+// lower(x)
+type lowerNode struct {
+	x exprNode
+}
+
+func (n lowerNode) eval(e *exprEnv) (any, error) {
+	v, err := n.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("lower needs a string not a %s", typeName(v))
+	}
+	return strings.ToLower(s), nil
+}
+
+// x op y for comparisons and arithmetic
+type binNode struct {
+	op   string
+	x, y exprNode
+}
+
+func (n binNode) eval(e *exprEnv) (any, error) {
+	x, err := n.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	y, err := n.y.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "+" || n.op == "-" {
+		return arith(n.op, x, y)
+	}
+	cmp, err := compareValues(e.window, x, y)
+	if err != nil {
+		return nil, fmt.Errorf("can't compare %s %s %s", typeName(x), n.op, typeName(y))
+	}
+	_, isBool := x.(bool)
+	switch n.op {
+	case "==":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	}
+	if isBool {
+		return nil, fmt.Errorf("can't use %s on booleans", n.op)
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", n.op)
+}
+
+// sign returns -1, 0 or 1 for the sign of a-b
+func sign[T int64 | time.Duration](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+var errCompare = errors.New("can't compare")
+
+// compareValues returns -1, 0, or 1 if x is less than, equal to or
+// greater than y.
+//
+// Times within window of each other are equal.
+func compareValues(window time.Duration, x, y any) (int, error) {
+	switch x := x.(type) {
+	case bool:
+		if y, ok := y.(bool); ok {
+			if x == y {
+				return 0, nil
+			}
+			return 1, nil
+		}
+	case int64:
+		if y, ok := y.(int64); ok {
+			return sign(x, y), nil
+		}
+	case string:
+		if y, ok := y.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	case time.Duration:
+		if y, ok := y.(time.Duration); ok {
+			return sign(x, y), nil
+		}
+	case time.Time:
+		if y, ok := y.(time.Time); ok {
+			dt := x.Sub(y)
+			if dt < window && dt > -window {
+				return 0, nil
+			}
+			return sign(dt, 0), nil
+		}
+	}
+	return 0, errCompare
+}
+
+// arith does x + y or x - y
+func arith(op string, x, y any) (any, error) {
+	neg := func(d time.Duration) time.Duration {
+		if op == "-" {
+			return -d
+		}
+		return d
+	}
+	switch x := x.(type) {
+	case int64:
+		if y, ok := y.(int64); ok {
+			if op == "-" {
+				return x - y, nil
+			}
+			return x + y, nil
+		}
+	case time.Duration:
+		if y, ok := y.(time.Duration); ok {
+			return x + neg(y), nil
+		}
+	case time.Time:
+		switch y := y.(type) {
+		case time.Duration:
+			return x.Add(neg(y)), nil
+		case time.Time:
+			if op == "-" {
+				return x.Sub(y), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("can't do %s %s %s", typeName(x), op, typeName(y))
+}
+
+// Token kinds
+const (
+	tokEOF = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind int
+	text string // for strings this is the unquoted value
+	pos  int    // byte offset in the expression
+}
+
+// exprParser is a recursive descent parser for the expressions
+type exprParser struct {
+	toks []token
+	i    int
+}
+
+// operators longest first
+var exprOps = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "+", "-", "(", ")", "[", "]", ","}
+
+// lex splits expr into tokens
+func (p *exprParser) lex(expr string) error {
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for ; j < len(expr) && expr[j] != c; j++ {
+				if expr[j] == '\\' && j+1 < len(expr) {
+					j++
+				}
+				sb.WriteByte(expr[j])
+			}
+			if j >= len(expr) {
+				return fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			p.toks = append(p.toks, token{kind: tokString, text: sb.String(), pos: i})
+			i = j + 1
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(expr) && (isIdentChar(expr[j]) || expr[j] == '.') {
+				j++
+			}
+			p.toks = append(p.toks, token{kind: tokNumber, text: expr[i:j], pos: i})
+			i = j
+		case isIdentChar(c):
+			j := i
+			for j < len(expr) && (isIdentChar(expr[j]) || expr[j] == '.') {
+				j++
+			}
+			p.toks = append(p.toks, token{kind: tokIdent, text: expr[i:j], pos: i})
+			i = j
+		default:
+			found := false
+			for _, op := range exprOps {
+				if strings.HasPrefix(expr[i:], op) {
+					p.toks = append(p.toks, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("unexpected %q at position %d", c, i+1)
+			}
+		}
+	}
+	p.toks = append(p.toks, token{kind: tokEOF, text: "end of expression", pos: len(expr)})
+	return nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// peek returns the current token
+func (p *exprParser) peek() token {
+	return p.toks[p.i]
+}
+
+// next returns the current token and moves on to the next
+func (p *exprParser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept moves on if the current token is the operator op
+func (p *exprParser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokOp && t.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// expect the operator op
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		return fmt.Errorf("expecting %q but got %q", op, p.peek().text)
+	}
+	return nil
+}
+
+// or := and ("||" and)*
+func (p *exprParser) parseOr() (exprNode, error) {
+	x, err := p.parseAnd()
+	for err == nil && p.accept("||") {
+		var y exprNode
+		y, err = p.parseAnd()
+		x = logicNode{op: "||", x: x, y: y}
+	}
+	return x, err
+}
+
+// and := unary ("&&" unary)*
+func (p *exprParser) parseAnd() (exprNode, error) {
+	x, err := p.parseUnary()
+	for err == nil && p.accept("&&") {
+		var y exprNode
+		y, err = p.parseUnary()
+		x = logicNode{op: "&&", x: x, y: y}
+	}
+	return x, err
+}
+
+// unary := "!" unary | cmp
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.accept("!") {
+		x, err := p.parseUnary()
+		return notNode{x: x}, err
+	}
+	return p.parseCmp()
+}
+
+// cmp := sum [op sum] | sum ("=~" | "!~") string
+func (p *exprParser) parseCmp() (exprNode, error) {
+	x, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokOp {
+		return x, nil
+	}
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		y, err := p.parseSum()
+		return binNode{op: t.text, x: x, y: y}, err
+	case "=~", "!~":
+		p.next()
+		s := p.next()
+		if s.kind != tokString {
+			return nil, fmt.Errorf("%s needs a string regular expression", t.text)
+		}
+		re, err := regexp.Compile(s.text)
+		if err != nil {
+			return nil, fmt.Errorf("bad regular expression: %w", err)
+		}
+		return matchNode{negate: t.text == "!~", x: x, re: re}, nil
+	}
+	return x, nil
+}
+
+// sum := primary (("+" | "-") primary)*
+func (p *exprParser) parseSum() (exprNode, error) {
+	x, err := p.parsePrimary()
+	for err == nil {
+		t := p.peek()
+		if t.kind != tokOp || (t.text != "+" && t.text != "-") {
+			break
+		}
+		p.next()
+		var y exprNode
+		y, err = p.parsePrimary()
+		x = binNode{op: t.text, x: x, y: y}
+	}
+	return x, err
+}
+
+// primary := number | string | ident | func "(" args ")" | "(" or ")"
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.peek()
+	if t.kind == tokEOF || (t.kind == tokOp && t.text != "(") {
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	}
+	p.next()
+	switch t.kind {
+	case tokNumber:
+		return parseNumber(t.text)
+	case tokString:
+		return litNode{v: t.text}, nil
+	case tokIdent:
+		return p.parseIdent(t.text)
+	}
+	x, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	return x, p.expect(")")
+}
+
+// parseNumber parses a number with an optional size suffix
+func parseNumber(s string) (exprNode, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return litNode{v: n}, nil
+	}
+	var size fs.SizeSuffix
+	if err := size.Set(s); err != nil {
+		return nil, fmt.Errorf("bad number %q: %w", s, err)
+	}
+	return litNode{v: int64(size)}, nil
+}
+
+// parseIdent parses an identifier or function call
+func (p *exprParser) parseIdent(name string) (exprNode, error) {
+	switch name {
+	case "true", "false":
+		return litNode{v: name == "true"}, nil
+	case "equal":
+		return equalNode{}, nil
+	case "duration":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		s := p.next()
+		if s.kind != tokString {
+			return nil, errors.New("duration needs a string argument")
+		}
+		d, err := fs.ParseDuration(s.text)
+		if err != nil {
+			return nil, fmt.Errorf("bad duration: %w", err)
+		}
+		return litNode{v: d}, p.expect(")")
+	case "lower":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return lowerNode{x: x}, p.expect(")")
+	}
+	side, attr, ok := strings.Cut(name, ".")
+	if !ok || (side != "src" && side != "dst") {
+		return nil, fmt.Errorf("unknown name %q", name)
+	}
+	attr, key, _ := strings.Cut(attr, ".")
+	if !exprAttrs[attr] {
+		return nil, fmt.Errorf("unknown attribute %q", name)
+	}
+	if attr == "meta" {
+		if key == "" {
+			// src.meta["key"]
+			if err := p.expect("["); err != nil {
+				return nil, err
+			}
+			s := p.next()
+			if s.kind != tokString {
+				return nil, errors.New("metadata key must be a string")
+			}
+			key = s.text
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+		}
+		key = strings.ToLower(key)
+	} else if key != "" {
+		return nil, fmt.Errorf("unknown attribute %q", name)
+	}
+	return attrNode{side: side, attr: attr, key: key}, nil
+}
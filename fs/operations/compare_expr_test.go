@@ -0,0 +1,134 @@
+package operations_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileCompareExpr(t *testing.T) {
+	for _, test := range []struct {
+		expr string
+		err  string
+	}{
+		{expr: `src.size > dst.size`},
+		{expr: `dst.tier == "GLACIER" && src.modtime > dst.modtime`},
+		{expr: `!equal || (src.meta.mtime != dst.meta["mtime"])`},
+		{expr: `src.modtime > dst.modtime + duration("1h") || src.size >= 1.5M`},
+		{expr: `lower(src.name) =~ "\\.jpe?g$" && dst.mimetype !~ 'image/'`},
+		{expr: ``, err: `unexpected "end of expression" at position 1`},
+		{expr: `src.size >`, err: `unexpected "end of expression" at position 11`},
+		{expr: `src.size > 1 1`, err: `unexpected "1" at position 14`},
+		{expr: `src.potato`, err: `unknown attribute "src.potato"`},
+		{expr: `potato.size`, err: `unknown name "potato.size"`},
+		{expr: `src.size.x`, err: `unknown attribute "src.size.x"`},
+		{expr: `src.meta == ""`, err: `expecting "["`},
+		{expr: `(src.size > 1`, err: `expecting ")"`},
+		{expr: `src.name =~ "("`, err: `bad regular expression`},
+		{expr: `src.name =~ 1`, err: `=~ needs a string regular expression`},
+		{expr: `duration("potato")`, err: `bad duration`},
+		{expr: `src.size > 1Q`, err: `bad number "1Q"`},
+		{expr: `src.name == "potato`, err: `unterminated string at position 13`},
+		{expr: `src.size > 1 # comment`, err: `unexpected '#' at position 14`},
+	} {
+		t.Run(test.expr, func(t *testing.T) {
+			c, err := operations.CompileCompareExpr(test.expr)
+			if test.err == "" {
+				require.NoError(t, err)
+				assert.Equal(t, test.expr, c.String())
+			} else {
+				assert.ErrorContains(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestCompareExprEval(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	file1 := r.WriteFile("dir/a.txt", "hello", t1)
+	file2 := r.WriteObject(ctx, "dir/a.txt", "hello world", t2)
+	src, err := r.Flocal.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	dst, err := r.Fremote.NewObject(ctx, file2.Path)
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		expr string
+		want bool
+		err  string
+	}{
+		{expr: `src.size < dst.size`, want: true},
+		{expr: `src.size == 5 && dst.size == 11`, want: true},
+		{expr: `dst.size - src.size == 6`, want: true},
+		{expr: `src.size >= 1K`, want: false},
+		{expr: `src.modtime < dst.modtime`, want: true},
+		{expr: `src.modtime == src.modtime`, want: true},
+		{expr: `src.modtime + duration("100y") > dst.modtime`, want: true},
+		{expr: `dst.modtime - src.modtime > duration("10y")`, want: true},
+		{expr: `src.hash == dst.hash`, want: false},
+		{expr: `src.hash == "5d41402abc4b2a76b9719d911017c592"`, want: true},
+		{expr: `src.path == "dir/a.txt" && src.name == "a.txt"`, want: true},
+		{expr: `src.name =~ "\\.TXT$"`, want: false},
+		{expr: `lower(src.name) =~ "(?i)\\.TXT$"`, want: true},
+		{expr: `src.name !~ "^b"`, want: true},
+		{expr: `src.tier == ""`, want: true},
+		{expr: `src.meta["potato"] == ""`, want: true},
+		{expr: `equal`, want: false},
+		{expr: `!equal`, want: true},
+		{expr: `true || src.size > "x"`, want: true},
+		{expr: `false && src.size > "x"`, want: false},
+		{expr: `src.size`, err: `expression returned number not a boolean`},
+		{expr: `src.size > "x"`, err: `can't compare number > string`},
+		{expr: `true < false`, err: `can't use < on booleans`},
+		{expr: `src.name + 1 == 1`, err: `can't do string + number`},
+		{expr: `src.size || true`, err: `|| needs a boolean not a number`},
+		{expr: `lower(src.size) == ""`, err: `lower needs a string not a number`},
+		{expr: `src.size =~ "1"`, err: `can only match a string not a number`},
+	} {
+		t.Run(test.expr, func(t *testing.T) {
+			c, err := operations.CompileCompareExpr(test.expr)
+			require.NoError(t, err)
+			got, err := c.Eval(ctx, src, dst)
+			if test.err == "" {
+				require.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			} else {
+				assert.ErrorContains(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestNeedTransferCompareExpr(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	file1 := r.WriteFile("a.txt", "hello", t1)
+	file2 := r.WriteObject(ctx, "a.txt", "hello", t1)
+	src, err := r.Flocal.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	dst, err := r.Fremote.NewObject(ctx, file2.Path)
+	require.NoError(t, err)
+
+	// identical files don't normally need transferring
+	assert.False(t, operations.NeedTransfer(ctx, dst, src))
+
+	ci.CompareExpr = `src.size == dst.size && dst.tier == ""`
+	assert.True(t, operations.NeedTransfer(ctx, dst, src))
+
+	ci.CompareExpr = `src.modtime > dst.modtime`
+	assert.False(t, operations.NeedTransfer(ctx, dst, src))
+
+	// a missing destination is always transferred
+	assert.True(t, operations.NeedTransfer(ctx, nil, src))
+
+	// falls back to the normal comparison on errors
+	ci.CompareExpr = `src.size > "potato"`
+	assert.False(t, operations.NeedTransfer(ctx, dst, src))
+}
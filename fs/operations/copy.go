@@ -19,6 +19,7 @@ import (
 	"github.com/rclone/rclone/fs/accounting"
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/list"
 	"github.com/rclone/rclone/lib/atexit"
 	"github.com/rclone/rclone/lib/pacer"
 	"github.com/rclone/rclone/lib/transform"
@@ -30,14 +31,18 @@ type copy struct {
 	dstFeatures   *fs.Features         // Features() for fs.Fs
 	dst           fs.Object            // destination object to update, may be nil
 	remote        string               // destination path, used if dst is nil
+	origRemote    string               // destination path before any --name-transform
 	src           fs.Object            // source object
 	ci            *fs.ConfigInfo       // current config
 	maxTries      int                  // max number of tries to do the copy
 	doUpdate      bool                 // whether we are updating an existing file or not
 	hashType      hash.Type            // common hash to use
 	hashOption    *fs.HashesOption     // open option for the common hash
+	streamHash    hash.Type            // hash to calculate while streaming for --verify-transfers
+	streamSum     string               // hash of the source calculated while streaming, if known
 	tr            *accounting.Transfer // accounting for the transfer
 	inplace       bool                 // set if we are updating inplace and not using a partial name
+	resumePartial bool                 // set if the partial file is kept on failure to be resumed
 	remoteForCopy string               // the name used for the transfer, either remote or remote+".partial"
 }
 
@@ -151,7 +156,7 @@ func (c *copy) serverSideCopy(ctx context.Context) (actionTaken string, newDst f
 	}
 	in := c.tr.Account(ctx, nil) // account the transfer
 	in.ServerSideTransferStart()
-	newDst, err = doCopy(ctx, c.src, c.remoteForCopy)
+	newDst, err = doCopy(ctx, list.ResolveObject(ctx, c.src), c.remoteForCopy)
 	if err == nil {
 		in.ServerSideCopyEnd(newDst.Size()) // account the bytes for the server-side transfer
 	}
@@ -185,6 +190,7 @@ func (c *copy) rcat(ctx context.Context, in io.ReadCloser) (actionTaken string,
 		if err != nil {
 			fs.Errorf(c.src, "Failed to read metadata: %v", err)
 		}
+		meta.Merge(transform.OriginalNameMetadata(ctx, c.origRemote, c.remote))
 	}
 
 	// NB Rcat closes in0
@@ -204,7 +210,7 @@ func (c *copy) rcat(ctx context.Context, in io.ReadCloser) (actionTaken string,
 // Copy the stream from in to (c.f, c.remoteForCopy) and close it
 func (c *copy) updateOrPut(ctx context.Context, in io.ReadCloser, uploadOptions []fs.OpenOption) (actionTaken string, newDst fs.Object, err error) {
 	// account and buffer the transfer
-	inAcc := c.tr.Account(ctx, in).WithBuffer()
+	inAcc := c.tr.Account(ctx, in).WithHash(c.streamHash).WithBuffer()
 	var wrappedSrc fs.ObjectInfo = c.src
 
 	// We try to pass the original object if possible
@@ -224,6 +230,9 @@ func (c *copy) updateOrPut(ctx context.Context, in io.ReadCloser, uploadOptions
 	if err == nil {
 		err = closeErr
 	}
+	if c.streamHash != hash.None {
+		_, c.streamSum = inAcc.Sum()
+	}
 	if c.doUpdate {
 		actionTaken = "Copied (replaced existing)"
 	} else {
@@ -235,7 +244,7 @@ func (c *copy) updateOrPut(ctx context.Context, in io.ReadCloser, uploadOptions
 // Do a manual copy by reading the bytes and writing them
 func (c *copy) manualCopy(ctx context.Context) (actionTaken string, newDst fs.Object, err error) {
 	// Remove partial files on premature exit
-	if !c.inplace {
+	if !c.inplace && !c.resumePartial {
 		defer atexit.Unregister(atexit.Register(func() {
 			ctx := context.Background()
 			c.removeFailedPartialCopy(ctx, c.f, c.remoteForCopy)
@@ -250,6 +259,9 @@ func (c *copy) manualCopy(ctx context.Context) (actionTaken string, newDst fs.Ob
 	if c.ci.MetadataSet != nil {
 		uploadOptions = append(uploadOptions, fs.MetadataOption(c.ci.MetadataSet))
 	}
+	if meta := transform.OriginalNameMetadata(ctx, c.origRemote, c.remote); meta != nil {
+		uploadOptions = append(uploadOptions, fs.MetadataOption(meta))
+	}
 
 	// Options for the download
 	downloadOptions := []fs.OpenOption{c.hashOption}
@@ -257,6 +269,10 @@ func (c *copy) manualCopy(ctx context.Context) (actionTaken string, newDst fs.Ob
 		downloadOptions = append(downloadOptions, option)
 	}
 
+	if c.resumePartial {
+		return c.resumableCopy(ctx, downloadOptions, uploadOptions)
+	}
+
 	if doMultiThreadCopy(ctx, c.f, c.src) {
 		return c.multiThreadCopy(ctx, uploadOptions)
 	}
@@ -278,16 +294,92 @@ func (c *copy) manualCopy(ctx context.Context) (actionTaken string, newDst fs.Ob
 func (c *copy) verify(ctx context.Context, newDst fs.Object) (err error) {
 	// Verify sizes are the same after transfer
 	if sizeDiffers(ctx, c.src, newDst) {
+		c.countVerified(ctx, true, false)
 		return fmt.Errorf("corrupted on transfer: sizes differ src(%s) %d vs dst(%s) %d", c.src.Fs(), c.src.Size(), newDst.Fs(), newDst.Size())
 	}
+	// Verify against the hash calculated while transferring if we have one
+	if c.streamSum != "" {
+		return c.verifyStreamSum(ctx, newDst)
+	}
 	// Verify hashes are the same after transfer - ignoring blank hashes
 	if c.hashType != hash.None {
 		// checkHashes has logs and counts errors
-		equal, _, srcSum, dstSum, _ := checkHashes(ctx, c.src, newDst, c.hashType)
+		equal, ht, srcSum, dstSum, _ := checkHashes(ctx, c.src, newDst, c.hashType)
+		c.countVerified(ctx, ht != hash.None, equal)
 		if !equal {
 			return fmt.Errorf("corrupted on transfer: %v hashes differ src(%s) %q vs dst(%s) %q", c.hashType, c.src.Fs(), srcSum, newDst.Fs(), dstSum)
 		}
+		return nil
+	}
+	c.countVerified(ctx, false, false)
+	return nil
+}
+
+// Verify the copy against the hash of the source calculated while
+// transferring it
+func (c *copy) verifyStreamSum(ctx context.Context, newDst fs.Object) (err error) {
+	dstSum, err := newDst.Hash(ctx, c.streamHash)
+	if err != nil {
+		c.countVerified(ctx, true, false)
+		return fmt.Errorf("failed to read %v hash to verify transfer: %w", c.streamHash, err)
 	}
+	if dstSum == "" {
+		fs.Debugf(newDst, "Dst hash empty - can't verify transfer")
+		c.countVerified(ctx, false, false)
+		return nil
+	}
+	if !hash.Equals(c.streamSum, dstSum) {
+		c.countVerified(ctx, true, false)
+		fs.Debugf(c.src, "%v = %s (transferred)", c.streamHash, c.streamSum)
+		fs.Debugf(newDst, "%v = %s (%v)", c.streamHash, dstSum, newDst.Fs())
+		return fmt.Errorf("corrupted on transfer: %v hashes differ transferred %q vs dst(%s) %q", c.streamHash, c.streamSum, newDst.Fs(), dstSum)
+	}
+	fs.Debugf(newDst, "%v = %s verified", c.streamHash, dstSum)
+	c.countVerified(ctx, true, true)
+	return nil
+}
+
+// countVerified records the result of verifying the copy in the stats
+// if --verify-transfers is set. checked is set if there was a hash to
+// check and ok if the check passed.
+func (c *copy) countVerified(ctx context.Context, checked, ok bool) {
+	if !c.ci.VerifyTransfers {
+		return
+	}
+	if checked {
+		accounting.Stats(ctx).AddVerified(ok)
+	} else {
+		accounting.Stats(ctx).AddUnverified()
+	}
+}
+
+// copyTags copies the tags from the source to newDst if --metadata is
+// set and they are the same type of backend, as tags aren't portable
+// between providers.
+func (c *copy) copyTags(ctx context.Context, newDst fs.Object) (err error) {
+	if !c.ci.Metadata || newDst == nil || !SameRemoteType(c.f, c.src.Fs()) {
+		return nil
+	}
+	do, ok := newDst.(fs.SetTagger)
+	if !ok {
+		return nil
+	}
+	srcTags, err := fs.GetTags(ctx, c.src)
+	if err != nil {
+		return fmt.Errorf("failed to read source tags: %w", err)
+	}
+	dstTags, err := fs.GetTags(ctx, newDst)
+	if err != nil {
+		return fmt.Errorf("failed to read destination tags: %w", err)
+	}
+	if srcTags.Equal(dstTags) {
+		return nil
+	}
+	err = do.SetTags(ctx, srcTags)
+	if err != nil {
+		return fmt.Errorf("failed to copy tags: %w", err)
+	}
+	fs.Debugf(newDst, "Copied tags %v", srcTags)
 	return nil
 }
 
@@ -337,7 +429,9 @@ func (c *copy) copy(ctx context.Context) (newDst fs.Object, err error) {
 	if err != nil {
 		err = fs.CountError(ctx, err)
 		fs.Errorf(c.src, "Failed to copy: %v", err)
-		if !c.inplace {
+		if c.resumePartial {
+			fs.Infof(c.src, "Keeping partial file %q to resume the download with --resume-downloads", c.remoteForCopy)
+		} else if !c.inplace {
 			c.removeFailedPartialCopy(ctx, c.f, c.remoteForCopy)
 		}
 		return newDst, err
@@ -365,6 +459,13 @@ func (c *copy) copy(ctx context.Context) (newDst fs.Object, err error) {
 		newDst = movedNewDst
 	}
 
+	// Preserve the tags if copying between the same type of backend
+	err = c.copyTags(ctx, newDst)
+	if err != nil {
+		fs.Errorf(newDst, "%v", err)
+		return newDst, fs.CountError(ctx, err)
+	}
+
 	// Log what we have done
 	if newDst != nil && c.src.String() != newDst.String() {
 		actionTaken = fmt.Sprintf("%s to: %s", actionTaken, newDst.String())
@@ -380,21 +481,25 @@ func (c *copy) copy(ctx context.Context) (newDst fs.Object, err error) {
 // It returns the destination object if possible.  Note that this may
 // be nil.
 func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Object) (newDst fs.Object, err error) {
+	ctx = transform.ForDestination(ctx, f)
 	ci := fs.GetConfig(ctx)
 	tr := accounting.Stats(ctx).NewTransfer(src, f)
 	defer func() {
 		tr.Done(ctx, err)
 	}()
 	if SkipDestructive(ctx, src, "copy") {
+		planObject(ctx, PlanCopy, f, remote, src, dst)
 		in := tr.Account(ctx, nil)
 		in.DryRun(src.Size())
 		return newDst, nil
 	}
+	defer list.CacheInvalidate(ctx, f, remote, false)
 	c := &copy{
 		f:           f,
 		dstFeatures: f.Features(),
 		dst:         dst,
 		remote:      transform.Path(ctx, remote, false),
+		origRemote:  remote,
 		src:         src,
 		ci:          ci,
 		tr:          tr,
@@ -402,8 +507,16 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 		doUpdate:    dst != nil,
 	}
 	c.hashType, c.hashOption = CommonHash(ctx, f, src.Fs())
+	if ci.VerifyTransfers && !ci.IgnoreChecksum {
+		// Prefer the common hash, otherwise use one the destination supports
+		c.streamHash = c.hashType
+		if c.streamHash == hash.None {
+			c.streamHash = f.Hashes().GetOne()
+		}
+	}
 	if c.dst != nil {
 		c.remote = transform.Path(ctx, c.dst.Remote(), false)
+		c.origRemote = c.dst.Remote()
 	}
 	// Are we using partials?
 	//
@@ -412,8 +525,13 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 	if err != nil {
 		return nil, err
 	}
+	c.resumePartial = c.canResumePartial()
 	// Do the copy now everything is set up
-	return c.copy(ctx)
+	newDst, err = c.copy(ctx)
+	if err == nil && newDst != nil {
+		err = writeSidecar(ctx, f, src, newDst)
+	}
+	return newDst, err
 }
 
 // CopyFile moves a single file possibly to a new name
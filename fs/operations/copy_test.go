@@ -126,6 +126,30 @@ func TestCopyFile(t *testing.T) {
 	r.CheckRemoteItems(t, file2)
 }
 
+func TestCopyVerifyTransfers(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ctx = accounting.WithStatsGroup(ctx, "test-verify-transfers")
+	r := fstest.NewRun(t)
+	ci.VerifyTransfers = true
+
+	file1 := r.WriteFile("file1", "file1 contents", t1)
+	err := operations.CopyFile(ctx, r.Fremote, r.Flocal, file1.Path, file1.Path)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file1)
+
+	out, err := accounting.Stats(ctx).RemoteStats(true)
+	require.NoError(t, err)
+	if r.Fremote.Hashes().Count() > 0 {
+		assert.Equal(t, int64(1), out["verified"])
+		assert.Equal(t, int64(0), out["unverified"])
+	} else {
+		assert.Equal(t, int64(0), out["verified"])
+		assert.Equal(t, int64(1), out["unverified"])
+	}
+	assert.Equal(t, int64(0), out["verifyFailed"])
+}
+
 // Find the longest file name for writing to local
 func maxLengthFileName(t *testing.T, r *fstest.Run) string {
 	require.NoError(t, r.Flocal.Mkdir(context.Background(), "")) // create the root
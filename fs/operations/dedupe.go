@@ -192,6 +192,7 @@ const (
 	DeduplicateLargest                            // choose the largest object
 	DeduplicateSmallest                           // choose the smallest object
 	DeduplicateList                               // list duplicates only
+	DeduplicateCopy                               // replace with server-side copies of the first object
 )
 
 func (x DeduplicateMode) String() string {
@@ -214,6 +215,8 @@ func (x DeduplicateMode) String() string {
 		return "smallest"
 	case DeduplicateList:
 		return "list"
+	case DeduplicateCopy:
+		return "copy"
 	}
 	return "unknown"
 }
@@ -239,6 +242,8 @@ func (x *DeduplicateMode) Set(s string) error {
 		*x = DeduplicateSmallest
 	case "list":
 		*x = DeduplicateList
+	case "copy":
+		*x = DeduplicateCopy
 	default:
 		return fmt.Errorf("unknown mode for dedupe %q", s)
 	}
@@ -401,6 +406,9 @@ func sortSmallestFirst(objs []fs.Object) {
 // Google Drive which can have duplicate file names.
 func Deduplicate(ctx context.Context, f fs.Fs, mode DeduplicateMode, byHash bool) error {
 	ci := fs.GetConfig(ctx)
+	if mode == DeduplicateCopy {
+		return fmt.Errorf("dedupe mode %v is only supported when deduping by hash across directories", mode)
+	}
 	// find a hash to use
 	ht := f.Hashes().GetOne()
 	what := "names"
@@ -0,0 +1,279 @@
+// dedupe by content hash across all the directories of a remote
+
+package operations
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lanrat/extsort"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/walk"
+)
+
+// dedupeGroup is a set of files with the same hash
+type dedupeGroup struct {
+	hash    string
+	size    int64
+	remotes []string
+}
+
+// makeDedupeKey makes the key used to sort the files so that files
+// with the same hash end up next to each other.
+func makeDedupeKey(hashValue string, size int64, remote string) string {
+	return hashValue + "\x00" + strconv.FormatInt(size, 10) + "\x00" + remote
+}
+
+// parseDedupeKey undoes makeDedupeKey
+func parseDedupeKey(key string) (hashValue string, size int64, remote string, err error) {
+	parts := strings.SplitN(key, "\x00", 3)
+	if len(parts) != 3 {
+		return "", 0, "", fmt.Errorf("dedupe: corrupt index key %q", key)
+	}
+	size, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("dedupe: corrupt index key %q: %w", key, err)
+	}
+	return parts[0], size, parts[2], nil
+}
+
+// dedupeUniqueIDs removes all but one of the objects which appear in
+// the listing more than once with the same ID, as deleting one of
+// them would delete them all.
+func dedupeUniqueIDs(objs []fs.Object) []fs.Object {
+	seen := make(map[string]struct{}, len(objs))
+	newObjs := objs[:0]
+	for _, o := range objs {
+		if do, ok := o.(fs.IDer); ok {
+			if ID := do.ID(); ID != "" {
+				if _, found := seen[ID]; found {
+					fs.Logf(o, "Ignoring as it appears more than once in the listing and deleting would lead to data loss")
+					continue
+				}
+				seen[ID] = struct{}{}
+			}
+		}
+		newObjs = append(newObjs, o)
+	}
+	return newObjs
+}
+
+// dedupeAcross holds the state for DeduplicateAcrossDirs
+type dedupeAcross struct {
+	f      fs.Fs
+	ht     hash.Type
+	mode   DeduplicateMode
+	report *csv.Writer
+}
+
+// process deals with one group of files with the same hash
+//
+// It returns false if the user asked to quit.
+func (d *dedupeAcross) process(ctx context.Context, group *dedupeGroup) bool {
+	if len(group.remotes) <= 1 {
+		return true
+	}
+	objs := make([]fs.Object, 0, len(group.remotes))
+	for _, remote := range group.remotes {
+		o, err := d.f.NewObject(ctx, remote)
+		if err != nil {
+			err = fs.CountError(ctx, err)
+			fs.Errorf(remote, "Failed to find duplicate: %v", err)
+			continue
+		}
+		objs = append(objs, o)
+	}
+	objs = dedupeUniqueIDs(objs)
+	if len(objs) <= 1 {
+		return true
+	}
+	what := d.ht.String() + " " + group.hash
+	fs.Logf(what, "Found %d files with duplicate %v hashes", len(objs), d.ht)
+
+	keep := -1
+	switch d.mode {
+	case DeduplicateFirst, DeduplicateCopy:
+		keep = 0
+	case DeduplicateNewest:
+		sortOldestFirst(objs)
+		keep = len(objs) - 1
+	case DeduplicateOldest:
+		sortOldestFirst(objs)
+		keep = 0
+	case DeduplicateLargest:
+		sortSmallestFirst(objs)
+		keep = len(objs) - 1
+	case DeduplicateSmallest:
+		sortSmallestFirst(objs)
+		keep = 0
+	}
+	d.writeReport(group, objs, keep)
+
+	switch d.mode {
+	case DeduplicateInteractive:
+		return dedupeInteractive(ctx, d.f, d.ht, what, objs, true)
+	case DeduplicateSkip:
+		fs.Logf(what, "Skipping %d files with duplicate %v hashes", len(objs), d.ht)
+	case DeduplicateList:
+		dedupeList(ctx, d.f, d.ht, what, objs, true)
+	case DeduplicateCopy:
+		d.replaceWithCopies(ctx, what, keep, objs)
+	default:
+		if keep >= 0 {
+			dedupeDeleteAllButOne(ctx, keep, what, objs)
+		}
+	}
+	return true
+}
+
+// replaceWithCopies replaces all but the one in keep with server-side
+// copies of it
+func (d *dedupeAcross) replaceWithCopies(ctx context.Context, what string, keep int, objs []fs.Object) {
+	count := 0
+	for i, o := range objs {
+		if i == keep {
+			continue
+		}
+		_, err := Copy(ctx, d.f, o, o.Remote(), objs[keep])
+		if err != nil {
+			err = fs.CountError(ctx, err)
+			fs.Errorf(o, "Failed to replace with server-side copy: %v", err)
+			continue
+		}
+		count++
+	}
+	if count > 0 {
+		fs.Logf(what, "Replaced %d duplicates with server-side copies of %q", count, objs[keep].Remote())
+	}
+}
+
+// writeReport writes the group to the report if there is one
+func (d *dedupeAcross) writeReport(group *dedupeGroup, objs []fs.Object, keep int) {
+	if d.report == nil {
+		return
+	}
+	for i, o := range objs {
+		action := "none"
+		switch {
+		case keep < 0:
+		case i == keep:
+			action = "keep"
+		case d.mode == DeduplicateCopy:
+			action = "copy"
+		default:
+			action = "delete"
+		}
+		_ = d.report.Write([]string{group.hash, strconv.FormatInt(group.size, 10), o.Remote(), action})
+	}
+}
+
+// DeduplicateAcrossDirs finds files with identical hashes anywhere
+// on f and deals with them according to mode.
+//
+// Unlike Deduplicate with byHash this doesn't keep the listing in
+// memory. Instead the hashes are sorted on disk so only one group of
+// duplicates needs to be in memory at once.
+//
+// If report is not nil, a CSV report of the duplicates with the
+// columns hash, size, path and action is written to it.
+func DeduplicateAcrossDirs(ctx context.Context, f fs.Fs, mode DeduplicateMode, report io.Writer) (err error) {
+	ci := fs.GetConfig(ctx)
+	ht := f.Hashes().GetOne()
+	if ht == hash.None {
+		return fmt.Errorf("%v has no hashes", f)
+	}
+	switch mode {
+	case DeduplicateRename:
+		return fmt.Errorf("dedupe mode %v can't be used when deduping by hash across directories", mode)
+	case DeduplicateCopy:
+		if f.Features().Copy == nil {
+			return fmt.Errorf("dedupe mode %v needs server-side copy which %v doesn't support", mode, f)
+		}
+	}
+	fs.Infof(f, "Looking for duplicate %v hashes across directories using %v mode.", ht, mode)
+
+	d := &dedupeAcross{
+		f:    f,
+		ht:   ht,
+		mode: mode,
+	}
+	if report != nil {
+		d.report = csv.NewWriter(report)
+		_ = d.report.Write([]string{"hash", "size", "path", "action"})
+		defer func() {
+			d.report.Flush()
+			if reportErr := d.report.Error(); reportErr != nil && err == nil {
+				err = fmt.Errorf("failed to write dedupe report: %w", reportErr)
+			}
+		}()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Sort the keys on disk
+	inputChan := make(chan string, 100)
+	opt := extsort.DefaultConfig()
+	opt.ChunkSize = 32 * 1024 // tuned for 100 char records
+	sorter, outputChan, errChan := extsort.Strings(inputChan, opt)
+	go sorter.Sort(ctx)
+
+	// List the files and hash them
+	listErrChan := make(chan error, 1)
+	go func() {
+		defer close(inputChan)
+		listErrChan <- walk.ListR(ctx, f, "", false, ci.MaxDepth, walk.ListObjects, func(entries fs.DirEntries) (err error) {
+			entries.ForObject(func(o fs.Object) {
+				if err != nil {
+					return
+				}
+				tr := accounting.Stats(ctx).NewCheckingTransfer(o, "hashing")
+				defer tr.Done(ctx, nil)
+				hashValue, hashErr := o.Hash(ctx, ht)
+				if hashErr != nil || hashValue == "" {
+					if hashErr != nil {
+						fs.Errorf(o, "Failed to hash: %v", hashErr)
+					}
+					return
+				}
+				select {
+				case inputChan <- makeDedupeKey(hashValue, o.Size(), o.Remote()):
+				case <-ctx.Done():
+					err = ctx.Err()
+				}
+			})
+			return err
+		})
+	}()
+
+	// Read the sorted keys, processing a group of duplicates at a time
+	var group dedupeGroup
+	for key := range outputChan {
+		hashValue, size, remote, err := parseDedupeKey(key)
+		if err != nil {
+			return err
+		}
+		if hashValue != group.hash {
+			if !d.process(ctx, &group) {
+				return nil
+			}
+			group = dedupeGroup{hash: hashValue, size: size}
+		}
+		group.remotes = append(group.remotes, remote)
+	}
+	if err := <-errChan; err != nil {
+		return fmt.Errorf("dedupe: failed to sort hashes: %w", err)
+	}
+	if err := <-listErrChan; err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	d.process(ctx, &group)
+	return nil
+}
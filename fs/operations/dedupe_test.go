@@ -1,7 +1,9 @@
 package operations_test
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -164,6 +166,58 @@ func TestDeduplicateNewestByHash(t *testing.T) {
 	r.CheckRemoteItems(t, file3, file4)
 }
 
+func TestDeduplicateAcrossDirs(t *testing.T) {
+	r := fstest.NewRun(t)
+	skipIfNoHash(t, r.Fremote)
+	skipIfNoModTime(t, r.Fremote)
+	contents := random.String(100)
+
+	file1 := r.WriteObject(context.Background(), "one", contents, t1)
+	file2 := r.WriteObject(context.Background(), "also/one", contents, t2)
+	file3 := r.WriteObject(context.Background(), "deep/down/another", contents, t3)
+	file4 := r.WriteObject(context.Background(), "not-one", "stuff", t3)
+	file5 := r.WriteObject(context.Background(), "deep/stuff", "stuff", t1)
+	r.CheckRemoteItems(t, file1, file2, file3, file4, file5)
+
+	var report bytes.Buffer
+	err := operations.DeduplicateAcrossDirs(context.Background(), r.Fremote, operations.DeduplicateList, &report)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file1, file2, file3, file4, file5)
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	assert.Equal(t, "hash,size,path,action", lines[0])
+	assert.Len(t, lines, 6)
+	assert.Contains(t, report.String(), ",100,deep/down/another,none\n")
+
+	err = operations.DeduplicateAcrossDirs(context.Background(), r.Fremote, operations.DeduplicateRename, nil)
+	assert.ErrorContains(t, err, "can't be used")
+
+	report.Reset()
+	err = operations.DeduplicateAcrossDirs(context.Background(), r.Fremote, operations.DeduplicateOldest, &report)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file1, file5)
+	assert.Contains(t, report.String(), ",100,one,keep\n")
+	assert.Contains(t, report.String(), ",100,also/one,delete\n")
+}
+
+func TestDeduplicateAcrossDirsCopy(t *testing.T) {
+	r := fstest.NewRun(t)
+	skipIfNoHash(t, r.Fremote)
+	contents := random.String(100)
+
+	file1 := r.WriteObject(context.Background(), "a/one", contents, t1)
+	file2 := r.WriteObject(context.Background(), "b/one", contents, t2)
+	r.CheckRemoteItems(t, file1, file2)
+
+	err := operations.DeduplicateAcrossDirs(context.Background(), r.Fremote, operations.DeduplicateCopy, nil)
+	if r.Fremote.Features().Copy == nil {
+		assert.ErrorContains(t, err, "server-side copy")
+		return
+	}
+	require.NoError(t, err)
+	file2.ModTime = t1
+	r.CheckRemoteItems(t, file1, file2)
+}
+
 func TestDeduplicateOldest(t *testing.T) {
 	r := fstest.NewRun(t)
 	skipIfCantDedupe(t, r.Fremote)
@@ -0,0 +1,196 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/walk"
+	"golang.org/x/sync/errgroup"
+)
+
+// Actions reported by Diff
+const (
+	DiffAdded    = "added"    // only in the source
+	DiffRemoved  = "removed"  // only in the destination
+	DiffModified = "modified" // in both but different
+	DiffRenamed  = "renamed"  // in the destination under OldPath
+)
+
+// DiffItem describes a single difference found by Diff
+type DiffItem struct {
+	Action  string
+	Path    string
+	OldPath string `json:",omitempty"`
+	Size    int64
+	Hash    string `json:",omitempty"`
+}
+
+// DiffOpt contains options for Diff
+type DiffOpt struct {
+	Fsrc          fs.Fs // source to compare
+	Fdst          fs.Fs // destination to compare
+	DetectRenames bool  // match up added and removed files by hash and size
+}
+
+// diffListing lists f into a map of objects by remote
+func diffListing(ctx context.Context, f fs.Fs) (map[string]fs.Object, error) {
+	var mu sync.Mutex
+	objs := make(map[string]fs.Object)
+	err := walk.ListR(ctx, f, "", false, ConfigMaxDepth(ctx, true), walk.ListObjects, func(entries fs.DirEntries) error {
+		mu.Lock()
+		defer mu.Unlock()
+		entries.ForObject(func(o fs.Object) {
+			objs[o.Remote()] = o
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %v: %w", f, err)
+	}
+	return objs, nil
+}
+
+// Diff compares opt.Fsrc with opt.Fdst calling callback for each
+// difference in order of path.
+//
+// The differences describe what a sync from the source to the
+// destination would do. If opt.DetectRenames is set then files only
+// in the destination which have the same size and hash as files only
+// in the source are reported as renamed rather than removed and
+// added.
+//
+// Files which are in both are compared with the same checks as sync
+// so obey --size-only and --checksum.
+func Diff(ctx context.Context, opt *DiffOpt, callback func(*DiffItem) error) error {
+	var srcObjs, dstObjs map[string]fs.Object
+	g, gCtx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		srcObjs, err = diffListing(gCtx, opt.Fsrc)
+		return err
+	})
+	g.Go(func() (err error) {
+		dstObjs, err = diffListing(gCtx, opt.Fdst)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	eqOpt := defaultEqualOpt(ctx)
+	eqOpt.updateModTime = false
+	var items []*DiffItem
+	var added []fs.Object
+	for remote, src := range srcObjs {
+		dst, found := dstObjs[remote]
+		if !found {
+			added = append(added, src)
+			continue
+		}
+		delete(dstObjs, remote)
+		if !equal(ctx, src, dst, eqOpt) {
+			items = append(items, &DiffItem{Action: DiffModified, Path: remote, Size: src.Size()})
+		}
+	}
+
+	if opt.DetectRenames {
+		var renamed []*DiffItem
+		added, renamed = diffRenames(ctx, opt, added, dstObjs)
+		items = append(items, renamed...)
+	}
+	for _, src := range added {
+		items = append(items, &DiffItem{Action: DiffAdded, Path: src.Remote(), Size: src.Size()})
+	}
+	for remote, dst := range dstObjs {
+		items = append(items, &DiffItem{Action: DiffRemoved, Path: remote, Size: dst.Size()})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Path < items[j].Path
+	})
+	for _, item := range items {
+		if err := callback(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffRenames matches up the added objects with the objects left in
+// dstObjs by size and hash.
+//
+// It removes the matched objects from dstObjs and returns the added
+// objects which weren't matched along with the renames.
+func diffRenames(ctx context.Context, opt *DiffOpt, added []fs.Object, dstObjs map[string]fs.Object) (notRenamed []fs.Object, renamed []*DiffItem) {
+	ht, _ := CommonHash(ctx, opt.Fsrc, opt.Fdst)
+	if ht == hash.None {
+		fs.Infof(nil, "Can't detect renames as there is no common hash between %v and %v", opt.Fsrc, opt.Fdst)
+		return added, nil
+	}
+	// Only hash the files which could possibly match by size.
+	// Empty files and files of unknown size can't be matched reliably.
+	sizes := make(map[int64]struct{}, len(added))
+	for _, src := range added {
+		if src.Size() > 0 {
+			sizes[src.Size()] = struct{}{}
+		}
+	}
+	type key struct {
+		size int64
+		hash string
+	}
+	candidates := make(map[key][]fs.Object)
+	candidateSizes := make(map[int64]struct{})
+	// Sort the removed files so the matching is deterministic
+	removed := make([]string, 0, len(dstObjs))
+	for remote := range dstObjs {
+		removed = append(removed, remote)
+	}
+	sort.Strings(removed)
+	for _, remote := range removed {
+		dst := dstObjs[remote]
+		if _, ok := sizes[dst.Size()]; !ok {
+			continue
+		}
+		sum, err := dst.Hash(ctx, ht)
+		if err != nil || sum == "" {
+			fs.Debugf(dst, "Can't detect rename: failed to read %v hash: %v", ht, err)
+			continue
+		}
+		k := key{size: dst.Size(), hash: sum}
+		candidates[k] = append(candidates[k], dst)
+		candidateSizes[k.size] = struct{}{}
+	}
+	if len(candidates) == 0 {
+		return added, nil
+	}
+	sort.Slice(added, func(i, j int) bool {
+		return added[i].Remote() < added[j].Remote()
+	})
+	for _, src := range added {
+		if _, ok := candidateSizes[src.Size()]; !ok {
+			notRenamed = append(notRenamed, src)
+			continue
+		}
+		sum, err := src.Hash(ctx, ht)
+		k := key{size: src.Size(), hash: sum}
+		if err != nil || sum == "" || len(candidates[k]) == 0 {
+			notRenamed = append(notRenamed, src)
+			continue
+		}
+		dst := candidates[k][0]
+		candidates[k] = candidates[k][1:]
+		delete(dstObjs, dst.Remote())
+		renamed = append(renamed, &DiffItem{
+			Action:  DiffRenamed,
+			Path:    src.Remote(),
+			OldPath: dst.Remote(),
+			Size:    src.Size(),
+			Hash:    sum,
+		})
+	}
+	return notRenamed, renamed
+}
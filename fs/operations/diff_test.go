@@ -0,0 +1,59 @@
+package operations_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	r.WriteFile("same.txt", "same", t1)
+	r.WriteObject(ctx, "same.txt", "same", t1)
+	r.WriteFile("modified.txt", "new contents", t2)
+	r.WriteObject(ctx, "modified.txt", "old contents", t1)
+	r.WriteFile("added.txt", "added", t1)
+	r.WriteObject(ctx, "removed.txt", "removed", t1)
+	r.WriteFile("dir/new name.txt", "renamed file", t1)
+	r.WriteObject(ctx, "old name.txt", "renamed file", t1)
+
+	diff := func(detectRenames bool) (got []operations.DiffItem) {
+		opt := operations.DiffOpt{
+			Fsrc:          r.Flocal,
+			Fdst:          r.Fremote,
+			DetectRenames: detectRenames,
+		}
+		err := operations.Diff(ctx, &opt, func(item *operations.DiffItem) error {
+			item.Hash = "" // depends on the hash in common
+			got = append(got, *item)
+			return nil
+		})
+		require.NoError(t, err)
+		return got
+	}
+
+	assert.Equal(t, []operations.DiffItem{
+		{Action: operations.DiffAdded, Path: "added.txt", Size: 5},
+		{Action: operations.DiffAdded, Path: "dir/new name.txt", Size: 12},
+		{Action: operations.DiffModified, Path: "modified.txt", Size: 12},
+		{Action: operations.DiffRemoved, Path: "old name.txt", Size: 12},
+		{Action: operations.DiffRemoved, Path: "removed.txt", Size: 7},
+	}, diff(false))
+
+	got := diff(true)
+	want := []operations.DiffItem{
+		{Action: operations.DiffAdded, Path: "added.txt", Size: 5},
+		{Action: operations.DiffRenamed, Path: "dir/new name.txt", OldPath: "old name.txt", Size: 12},
+		{Action: operations.DiffModified, Path: "modified.txt", Size: 12},
+		{Action: operations.DiffRemoved, Path: "removed.txt", Size: 7},
+	}
+	if r.Fremote.Hashes().Overlap(r.Flocal.Hashes()).Count() == 0 {
+		t.Skip("can't detect renames without a common hash")
+	}
+	assert.Equal(t, want, got)
+}
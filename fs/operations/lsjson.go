@@ -11,6 +11,7 @@ import (
 	"github.com/rclone/rclone/backend/crypt"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/walk"
 )
@@ -84,6 +85,7 @@ type ListJSONOpt struct {
 	FilesOnly     bool     `json:"filesOnly"`
 	Metadata      bool     `json:"metadata"`
 	HashTypes     []string `json:"hashTypes"` // hash types to show if ShowHash is set, e.g. "MD5", "SHA-1"
+	Query         string   `json:"query"`     // backend specific query to constrain the listing with
 }
 
 // state for ListJson
@@ -133,6 +135,9 @@ func newListJSON(ctx context.Context, fsrc fs.Fs, remote string, opt *ListJSONOp
 		}
 	}
 	features := fsrc.Features()
+	if opt.Query != "" && !features.ListQuery {
+		return nil, fmt.Errorf("list queries aren't supported by %v", fsrc)
+	}
 	lj.canGetTier = features.GetTier
 	lj.format = formatForPrecision(fsrc.Precision())
 	lj.isBucket = features.BucketBased && remote == "" && fsrc.Root() == "" // if bucket-based remote listing the root mark directories as buckets
@@ -245,6 +250,9 @@ func ListJSON(ctx context.Context, fsrc fs.Fs, remote string, opt *ListJSONOpt,
 	if err != nil {
 		return err
 	}
+	if opt.Query != "" {
+		ctx = filter.SetListQuery(ctx, opt.Query)
+	}
 	err = walk.ListR(ctx, fsrc, remote, false, ConfigMaxDepth(ctx, lj.opt.Recurse), walk.ListAll, func(entries fs.DirEntries) (err error) {
 		for _, entry := range entries {
 			item, err := lj.entry(ctx, entry)
@@ -254,6 +254,21 @@ func TestListJSON(t *testing.T) {
 	}
 }
 
+func TestListJSONQuery(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	r.WriteObject(ctx, "file1", "file1", t1)
+	opt := operations.ListJSONOpt{Query: "name contains 'file'"}
+	err := operations.ListJSON(ctx, r.Fremote, "", &opt, func(item *operations.ListJSONItem) error {
+		return nil
+	})
+	if r.Fremote.Features().ListQuery {
+		assert.NoError(t, err)
+	} else {
+		assert.ErrorContains(t, err, "list queries aren't supported")
+	}
+}
+
 func TestStatJSON(t *testing.T) {
 	ctx := context.Background()
 	r := fstest.NewRun(t)
@@ -164,17 +164,33 @@ func multiThreadCopy(ctx context.Context, f fs.Fs, remote string, src fs.Object,
 		return nil, fmt.Errorf("multi-thread copy: can't copy zero sized file")
 	}
 
-	info, chunkWriter, err := openChunkWriter(ctx, remote, src, options...)
+	var resume *resumableUpload
+	if !usingOpenWriterAt {
+		resume = newResumableUpload(ctx, f, remote, src)
+	}
+	var (
+		info        fs.ChunkWriterInfo
+		chunkWriter fs.ChunkWriter
+	)
+	if resume != nil {
+		info, chunkWriter, err = resume.open(ctx, openChunkWriter, options...)
+	} else {
+		info, chunkWriter, err = openChunkWriter(ctx, remote, src, options...)
+	}
 	if err != nil {
+		resume.finish(false)
 		return nil, fmt.Errorf("multi-thread copy: failed to open chunk writer: %w", err)
 	}
 
 	uploadCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	uploadedOK := false
+	defer func() {
+		resume.finish(uploadedOK)
+	}()
 	defer atexit.OnError(&err, func() {
 		cancel()
-		if info.LeavePartsOnError || uploadedOK {
+		if info.LeavePartsOnError || uploadedOK || resume.recording() {
 			return
 		}
 		fs.Debugf(src, "multi-thread copy: cancelling transfer on exit")
@@ -226,9 +242,18 @@ func multiThreadCopy(ctx context.Context, f fs.Fs, remote string, src fs.Object,
 		if gCtx.Err() != nil {
 			break
 		}
+		if resume.written(chunk) {
+			start := int64(chunk) * mc.partSize
+			mc.acc.AccountReadN(min(mc.partSize, mc.size-start))
+			continue
+		}
 		chunk := chunk
 		g.Go(func() error {
-			return mc.copyChunk(gCtx, chunk, chunkWriter)
+			err := mc.copyChunk(gCtx, chunk, chunkWriter)
+			if err == nil {
+				resume.chunkWritten(chunk)
+			}
+			return err
 		})
 	}
 
@@ -249,33 +274,9 @@ func multiThreadCopy(ctx context.Context, f fs.Fs, remote string, src fs.Object,
 
 	// OpenWriterAt doesn't set metadata so we need to set it on completion
 	if usingOpenWriterAt {
-		setModTime := true
-		if ci.Metadata {
-			do, ok := obj.(fs.SetMetadataer)
-			if ok {
-				meta, err := fs.GetMetadataOptions(ctx, f, src, options)
-				if err != nil {
-					return nil, fmt.Errorf("multi-thread copy: failed to read metadata from source object: %w", err)
-				}
-				if _, foundMeta := meta["mtime"]; !foundMeta {
-					meta.Set("mtime", src.ModTime(ctx).Format(time.RFC3339Nano))
-				}
-				err = do.SetMetadata(ctx, meta)
-				if err != nil {
-					return nil, fmt.Errorf("multi-thread copy: failed to set metadata: %w", err)
-				}
-				setModTime = false
-			} else {
-				fs.Errorf(obj, "multi-thread copy: can't set metadata as SetMetadata isn't implemented in: %v", f)
-			}
-		}
-		if setModTime {
-			err = obj.SetModTime(ctx, src.ModTime(ctx))
-			switch err {
-			case nil, fs.ErrorCantSetModTime, fs.ErrorCantSetModTimeWithoutDelete:
-			default:
-				return nil, fmt.Errorf("multi-thread copy: failed to set modification time: %w", err)
-			}
+		err = setWriterAtMetadata(ctx, f, obj, src, options)
+		if err != nil {
+			return nil, fmt.Errorf("multi-thread copy: %w", err)
 		}
 	}
 
@@ -283,6 +284,38 @@ func multiThreadCopy(ctx context.Context, f fs.Fs, remote string, src fs.Object,
 	return obj, nil
 }
 
+// setWriterAtMetadata sets the metadata or the modification time of
+// obj from src after it has been written with a WriterAtCloser as
+// that doesn't set them.
+func setWriterAtMetadata(ctx context.Context, f fs.Fs, obj fs.Object, src fs.Object, options []fs.OpenOption) error {
+	ci := fs.GetConfig(ctx)
+	if ci.Metadata {
+		do, ok := obj.(fs.SetMetadataer)
+		if ok {
+			meta, err := fs.GetMetadataOptions(ctx, f, src, options)
+			if err != nil {
+				return fmt.Errorf("failed to read metadata from source object: %w", err)
+			}
+			if _, foundMeta := meta["mtime"]; !foundMeta {
+				meta.Set("mtime", src.ModTime(ctx).Format(time.RFC3339Nano))
+			}
+			err = do.SetMetadata(ctx, meta)
+			if err != nil {
+				return fmt.Errorf("failed to set metadata: %w", err)
+			}
+			return nil
+		}
+		fs.Errorf(obj, "can't set metadata as SetMetadata isn't implemented in: %v", f)
+	}
+	err := obj.SetModTime(ctx, src.ModTime(ctx))
+	switch err {
+	case nil, fs.ErrorCantSetModTime, fs.ErrorCantSetModTimeWithoutDelete:
+	default:
+		return fmt.Errorf("failed to set modification time: %w", err)
+	}
+	return nil
+}
+
 // writerAtChunkWriter converts a WriterAtCloser into a ChunkWriter
 type writerAtChunkWriter struct {
 	remote          string
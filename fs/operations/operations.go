@@ -17,6 +17,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -31,7 +32,9 @@ import (
 	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/fspath"
 	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/list"
 	"github.com/rclone/rclone/fs/object"
 	"github.com/rclone/rclone/fs/walk"
 	"github.com/rclone/rclone/lib/atexit"
@@ -318,7 +321,9 @@ func equal(ctx context.Context, src fs.ObjectInfo, dst fs.Object, opt equalOpt)
 
 	// mod time differs but hash is the same to reset mod time if required
 	if opt.updateModTime {
-		if !SkipDestructive(ctx, src, "update modification time") {
+		if SkipDestructive(ctx, src, "update modification time") {
+			planObject(ctx, PlanSetModTime, dst.Fs(), dst.Remote(), src, dst)
+		} else {
 			// Size and hash the same but mtime different
 			// Error if objects are treated as immutable
 			if ci.Immutable {
@@ -350,6 +355,7 @@ func equal(ctx context.Context, src fs.ObjectInfo, dst fs.Object, opt equalOpt)
 				err = fs.CountError(ctx, err)
 				fs.Errorf(dst, "Failed to set modification time: %v", err)
 			} else {
+				accounting.Stats(ctx).AddMetadataUpdated()
 				fs.Infof(src, "Updated modification time in destination")
 			}
 		}
@@ -358,6 +364,111 @@ func equal(ctx context.Context, src fs.ObjectInfo, dst fs.Object, opt equalOpt)
 	return true
 }
 
+// metadataCompareIgnore are metadata keys which aren't compared when
+// deciding whether to update the metadata of an unchanged file. The
+// modification time is dealt with by equal() and the others change
+// without the file changing or can't be set after upload.
+var metadataCompareIgnore = map[string]struct{}{
+	"mtime": {},
+	"atime": {},
+	"btime": {},
+}
+
+// metadataDiffers returns true if any of the writable metadata in src
+// is missing or different in dst
+func metadataDiffers(srcMeta, dstMeta fs.Metadata, dstInfo *fs.MetadataInfo) bool {
+	for k, v := range srcMeta {
+		if _, ignore := metadataCompareIgnore[k]; ignore {
+			continue
+		}
+		if dstInfo != nil {
+			if help, found := dstInfo.System[k]; found && help.ReadOnly {
+				continue
+			}
+		}
+		if dstV, found := dstMeta[k]; !found || dstV != v {
+			return true
+		}
+	}
+	return false
+}
+
+// updateMetadata updates the metadata and tags of dst to match src if
+// --metadata and --metadata-update are set and they differ. This is
+// called when the content of src and dst is the same so saves
+// re-uploading dst.
+//
+// Reading the metadata can take an extra transaction per object on
+// each side so this is only done if asked for.
+func updateMetadata(ctx context.Context, src fs.ObjectInfo, dst fs.Object) {
+	ci := fs.GetConfig(ctx)
+	if !ci.Metadata || !ci.MetadataUpdate {
+		return
+	}
+	fdst, ok := dst.Fs().(fs.Fs)
+	if !ok {
+		return
+	}
+	updated := false
+	if do, ok := dst.(fs.SetMetadataer); ok && fdst.Features().WriteMetadata {
+		srcMeta, err := fs.GetMetadataOptions(ctx, fdst, src, nil)
+		if err != nil {
+			fs.Errorf(src, "Failed to read metadata: %v", err)
+			return
+		}
+		dstMeta, err := fs.GetMetadata(ctx, dst)
+		if err != nil {
+			fs.Errorf(dst, "Failed to read metadata: %v", err)
+			return
+		}
+		if metadataDiffers(srcMeta, dstMeta, GetFsInfo(fdst).MetadataInfo) {
+			if SkipDestructive(ctx, src, "update metadata") {
+				return
+			}
+			if ci.Immutable {
+				err = fs.CountError(ctx, errors.New("metadata mismatch between immutable objects"))
+				fs.Errorf(dst, "%v", err)
+				return
+			}
+			err = do.SetMetadata(ctx, srcMeta)
+			if err != nil {
+				err = fs.CountError(ctx, err)
+				fs.Errorf(dst, "Failed to update metadata: %v", err)
+				return
+			}
+			updated = true
+		}
+	}
+	if do, ok := dst.(fs.SetTagger); ok && SameRemoteType(fdst, src.Fs()) {
+		srcTags, err := fs.GetTags(ctx, src)
+		if err != nil {
+			fs.Errorf(src, "Failed to read tags: %v", err)
+			return
+		}
+		dstTags, err := fs.GetTags(ctx, dst)
+		if err != nil {
+			fs.Errorf(dst, "Failed to read tags: %v", err)
+			return
+		}
+		if !srcTags.Equal(dstTags) {
+			if SkipDestructive(ctx, src, "update tags") {
+				return
+			}
+			err = do.SetTags(ctx, srcTags)
+			if err != nil {
+				err = fs.CountError(ctx, err)
+				fs.Errorf(dst, "Failed to update tags: %v", err)
+				return
+			}
+			updated = true
+		}
+	}
+	if updated {
+		accounting.Stats(ctx).AddMetadataUpdated()
+		fs.Infof(src, "Updated metadata in destination")
+	}
+}
+
 // CommonHash returns a single hash.Type and a HashOption with that
 // type which is in common between the two fs.Fs.
 func CommonHash(ctx context.Context, fa, fb fs.Info) (hash.Type, *fs.HashesOption) {
@@ -431,6 +542,7 @@ func MoveTransfer(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string,
 
 // move - see Move for help
 func move(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string, src fs.Object, isTransfer bool) (newDst fs.Object, err error) {
+	ctx = transform.ForDestination(ctx, fdst)
 	origRemote := remote // avoid double-transform on fallback to copy
 	remote = transform.Path(ctx, remote, false)
 	ci := fs.GetConfig(ctx)
@@ -455,10 +567,15 @@ func move(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string, src fs.
 		action += " to " + remote
 	}
 	if SkipDestructive(ctx, src, action) {
+		planObject(ctx, PlanMove, fdst, remote, src, dst)
 		in := tr.Account(ctx, nil)
 		in.DryRun(src.Size())
 		return newDst, nil
 	}
+	defer func() {
+		list.CacheInvalidate(ctx, fdst, remote, false)
+		list.CacheInvalidate(ctx, src.Fs(), src.Remote(), false)
+	}()
 	// See if we have Move available
 	if doMove := fdst.Features().Move; doMove != nil && (SameConfig(src.Fs(), fdst) || (SameRemoteType(src.Fs(), fdst) && (fdst.Features().ServerSideAcrossConfigs || ci.ServerSideAcrossConfigs))) {
 		// Delete destination if it exists and is not the same file as src (could be same file while seemingly different if the remote is case insensitive)
@@ -484,7 +601,7 @@ func move(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string, src fs.
 		// Move dst <- src
 		in := tr.Account(ctx, nil) // account the transfer
 		in.ServerSideTransferStart()
-		newDst, err = doMove(ctx, src, remote)
+		newDst, err = doMove(ctx, list.ResolveObject(ctx, src), remote)
 		switch err {
 		case nil:
 			if newDst != nil && src.String() != newDst.String() {
@@ -494,7 +611,7 @@ func move(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string, src fs.
 			}
 			in.ServerSideMoveEnd(newDst.Size()) // account the bytes for the server-side transfer
 			_ = in.Close()
-			return newDst, nil
+			return newDst, moveSidecar(ctx, fdst, src, newDst)
 		case fs.ErrorCantMove:
 			fs.Debugf(src, "Can't move, switching to copy")
 			_ = in.Close()
@@ -515,7 +632,7 @@ func move(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string, src fs.
 		return newDst, err
 	}
 	// Delete src if no error on copy
-	return newDst, DeleteFile(ctx, src)
+	return newDst, DeleteFile(WithoutTrash(ctx), src)
 }
 
 // CanServerSideMove returns true if fdst support server-side moves or
@@ -546,7 +663,9 @@ func SuffixName(ctx context.Context, remote string) string {
 // and accumulating stats and errors.
 //
 // If backupDir is set then it moves the file to there instead of
-// deleting
+// deleting. Otherwise if --use-trash is in effect it moves the file
+// into the trash, or if --delete-mode marker is in effect it soft
+// deletes it.
 func DeleteFileWithBackupDir(ctx context.Context, dst fs.Object, backupDir fs.Fs) (err error) {
 	tr := accounting.Stats(ctx).NewCheckingTransfer(dst, "deleting")
 	defer func() {
@@ -557,14 +676,24 @@ func DeleteFileWithBackupDir(ctx context.Context, dst fs.Object, backupDir fs.Fs
 		return err
 	}
 	action, actioned := "delete", "Deleted"
+	useTrash := backupDir == nil && trashNeeded(ctx, dst.Fs(), dst.Remote())
+	useMarker := backupDir == nil && !useTrash && softDeleteNeeded(ctx)
 	if backupDir != nil {
 		action, actioned = "move into backup dir", "Moved into backup dir"
+	} else if useTrash {
+		action, actioned = "move into trash", "Moved into trash"
+	} else if useMarker {
+		action, actioned = "add delete marker", "Added delete marker"
 	}
 	skip := SkipDestructive(ctx, dst, action)
 	if skip {
-		// do nothing
+		planObject(ctx, PlanDelete, dst.Fs(), dst.Remote(), nil, dst)
 	} else if backupDir != nil {
 		err = MoveBackupDir(ctx, backupDir, dst)
+	} else if useTrash {
+		err = MoveToTrash(ctx, dst)
+	} else if useMarker {
+		err = SoftDelete(ctx, dst)
 	} else {
 		err = dst.Remove(ctx)
 	}
@@ -574,6 +703,12 @@ func DeleteFileWithBackupDir(ctx context.Context, dst fs.Object, backupDir fs.Fs
 	} else if !skip {
 		fs.Infof(dst, "%s", actioned)
 	}
+	if !skip {
+		list.CacheInvalidate(ctx, dst.Fs(), dst.Remote(), false)
+	}
+	if err == nil {
+		err = deleteSidecar(ctx, dst, backupDir)
+	}
 	return err
 }
 
@@ -585,11 +720,87 @@ func DeleteFile(ctx context.Context, dst fs.Object) (err error) {
 	return DeleteFileWithBackupDir(ctx, dst, nil)
 }
 
+// deleteBatchSize is the maximum number of objects passed to each
+// call of the DeleteObjects feature
+const deleteBatchSize = 1000
+
+// deleteBatchWait is how long to wait for more objects before
+// deleting a batch which isn't full
+const deleteBatchWait = 100 * time.Millisecond
+
+// deleteItem is an object waiting to be deleted in a batch
+type deleteItem struct {
+	o  fs.Object
+	tr *accounting.Transfer
+}
+
+// batchDeleter returns the DeleteObjects feature of the Fs dst is in
+// or nil if dst can't be deleted in a batch
+func batchDeleter(ctx context.Context, dst fs.Object, backupDir fs.Fs) func(ctx context.Context, objs []fs.Object) (errs []error, err error) {
+	if backupDir != nil || dst.Fs() == nil || trashNeeded(ctx, dst.Fs(), dst.Remote()) || softDeleteNeeded(ctx) {
+		return nil
+	}
+	return dst.Fs().Features().DeleteObjects
+}
+
+// prepareDelete does the accounting and checks needed before dst is
+// deleted in a batch. It returns nil if dst shouldn't be deleted.
+func prepareDelete(ctx context.Context, dst fs.Object) (*deleteItem, error) {
+	tr := accounting.Stats(ctx).NewCheckingTransfer(dst, "deleting")
+	err := accounting.Stats(ctx).DeleteFile(ctx, dst.Size())
+	if err != nil {
+		tr.Done(ctx, err)
+		return nil, err
+	}
+	if SkipDestructive(ctx, dst, "delete") {
+		planObject(ctx, PlanDelete, dst.Fs(), dst.Remote(), nil, dst)
+		tr.Done(ctx, nil)
+		return nil, nil
+	}
+	return &deleteItem{o: dst, tr: tr}, nil
+}
+
+// deleteBatch deletes all the items with doBatch, logging and
+// accounting the result for each one.
+//
+// It returns an error for each item.
+func deleteBatch(ctx context.Context, doBatch func(ctx context.Context, objs []fs.Object) (errs []error, err error), items []deleteItem) []error {
+	objs := make([]fs.Object, len(items))
+	for i := range items {
+		objs[i] = items[i].o
+	}
+	errs, err := doBatch(ctx, objs)
+	if err == nil && len(errs) != len(objs) {
+		err = fmt.Errorf("delete batch returned %d results for %d objects", len(errs), len(objs))
+	}
+	results := make([]error, len(items))
+	for i, item := range items {
+		deleteErr := err
+		if deleteErr == nil {
+			deleteErr = errs[i]
+		}
+		if deleteErr != nil {
+			fs.Errorf(item.o, "Couldn't delete: %v", deleteErr)
+			deleteErr = fs.CountError(ctx, deleteErr)
+		} else {
+			fs.Infof(item.o, "Deleted")
+			deleteErr = deleteSidecar(ctx, item.o, nil)
+		}
+		item.tr.Done(ctx, deleteErr)
+		results[i] = deleteErr
+	}
+	return results
+}
+
 // DeleteFilesWithBackupDir removes all the files passed in the
 // channel
 //
 // If backupDir is set the files will be placed into that directory
 // instead of being deleted.
+//
+// If the backend supports the DeleteObjects feature then the files
+// are deleted in batches of up to 1000. A batch is deleted as soon as
+// it is full or no more files have arrived for a short while.
 func DeleteFilesWithBackupDir(ctx context.Context, toBeDeleted fs.ObjectsChan, backupDir fs.Fs) error {
 	var wg sync.WaitGroup
 	ci := fs.GetConfig(ctx)
@@ -600,17 +811,84 @@ func DeleteFilesWithBackupDir(ctx context.Context, toBeDeleted fs.ObjectsChan, b
 	for range ci.Checkers {
 		go func() {
 			defer wg.Done()
-			for dst := range toBeDeleted {
-				err := DeleteFileWithBackupDir(ctx, dst, backupDir)
-				if err != nil {
-					errorCount.Add(1)
-					logger, _ := GetLogger(ctx)
-					logger(ctx, TransferError, nil, dst, err)
-					if fserrors.IsFatalError(err) {
-						fs.Errorf(dst, "Got fatal error on delete: %s", err)
-						fatalErrorCount.Add(1)
+			var (
+				batch   []deleteItem
+				batchFs fs.Info
+				doBatch func(ctx context.Context, objs []fs.Object) (errs []error, err error)
+				wait    = time.NewTimer(deleteBatchWait)
+			)
+			defer wait.Stop()
+			// record the error deleting dst returning false if it was fatal
+			check := func(dst fs.Object, err error) bool {
+				if err == nil {
+					return true
+				}
+				errorCount.Add(1)
+				logger, _ := GetLogger(ctx)
+				logger(ctx, TransferError, nil, dst, err)
+				if fserrors.IsFatalError(err) {
+					fs.Errorf(dst, "Got fatal error on delete: %s", err)
+					fatalErrorCount.Add(1)
+					return false
+				}
+				return true
+			}
+			// delete the objects in the batch returning false on a fatal error
+			flush := func() bool {
+				if len(batch) == 0 {
+					return true
+				}
+				items := batch
+				batch = nil
+				ok := true
+				for i, err := range deleteBatch(ctx, doBatch, items) {
+					ok = check(items[i].o, err) && ok
+				}
+				return ok
+			}
+			for {
+				var (
+					dst fs.Object
+					ok  bool
+				)
+				if len(batch) == 0 {
+					dst, ok = <-toBeDeleted
+				} else {
+					wait.Reset(deleteBatchWait)
+					select {
+					case dst, ok = <-toBeDeleted:
+					case <-wait.C:
+						// Nothing has arrived so delete the batch now
+						if !flush() {
+							return
+						}
+						continue
+					}
+				}
+				if !ok {
+					flush()
+					return
+				}
+				if do := batchDeleter(ctx, dst, backupDir); do != nil {
+					// Batches may only contain objects from one Fs
+					if dst.Fs() != batchFs && !flush() {
+						return
+					}
+					item, err := prepareDelete(ctx, dst)
+					if !check(dst, err) {
 						return
 					}
+					if item != nil {
+						batch = append(batch, *item)
+						batchFs, doBatch = dst.Fs(), do
+					}
+					if len(batch) >= deleteBatchSize && !flush() {
+						return
+					}
+					continue
+				}
+				if !check(dst, DeleteFileWithBackupDir(ctx, dst, backupDir)) {
+					return
 				}
 			}
 		}()
@@ -1052,9 +1330,11 @@ func ListDir(ctx context.Context, f fs.Fs, w io.Writer) error {
 // Mkdir makes a destination directory or container
 func Mkdir(ctx context.Context, f fs.Fs, dir string) error {
 	if SkipDestructive(ctx, fs.LogDirName(f, dir), "make directory") {
+		planDir(ctx, PlanMkdir, f, dir, nil)
 		return nil
 	}
 	fs.Infof(fs.LogDirName(f, dir), "Making directory")
+	defer list.CacheInvalidate(ctx, f, dir, true)
 	err := f.Mkdir(ctx, dir)
 	if err != nil {
 		err = fs.CountError(ctx, err)
@@ -1074,9 +1354,11 @@ func MkdirMetadata(ctx context.Context, f fs.Fs, dir string, metadata fs.Metadat
 	}
 	logName := fs.LogDirName(f, dir)
 	if SkipDestructive(ctx, logName, "make directory") {
+		planDir(ctx, PlanMkdir, f, dir, nil)
 		return nil, nil
 	}
 	fs.Debugf(fs.LogDirName(f, dir), "Making directory with metadata")
+	defer list.CacheInvalidate(ctx, f, dir, true)
 	newDst, err = do(ctx, dir, metadata)
 	if err != nil {
 		err = fs.CountError(ctx, err)
@@ -1101,6 +1383,7 @@ func MkdirMetadata(ctx context.Context, f fs.Fs, dir string, metadata fs.Metadat
 func MkdirModTime(ctx context.Context, f fs.Fs, dir string, modTime time.Time) (newDst fs.Directory, err error) {
 	logName := fs.LogDirName(f, dir)
 	if SkipDestructive(ctx, logName, "make directory") {
+		planDir(ctx, PlanMkdir, f, dir, &modTime)
 		return nil, nil
 	}
 	metadata := fs.Metadata{
@@ -1127,9 +1410,11 @@ func MkdirModTime(ctx context.Context, f fs.Fs, dir string, modTime time.Time) (
 func TryRmdir(ctx context.Context, f fs.Fs, dir string) error {
 	accounting.Stats(ctx).DeletedDirs(1)
 	if SkipDestructive(ctx, fs.LogDirName(f, dir), "remove directory") {
+		planDir(ctx, PlanRmdir, f, dir, nil)
 		return nil
 	}
 	fs.Infof(fs.LogDirName(f, dir), "Removing directory")
+	defer list.CacheInvalidate(ctx, f, dir, true)
 	return f.Rmdir(ctx, dir)
 }
 
@@ -1146,13 +1431,16 @@ func Rmdir(ctx context.Context, f fs.Fs, dir string) error {
 // Purge removes a directory and all of its contents
 func Purge(ctx context.Context, f fs.Fs, dir string) (err error) {
 	doFallbackPurge := true
-	if doPurge := f.Features().Purge; doPurge != nil {
+	// Move the files into the trash one by one if required
+	useTrash := trashNeeded(ctx, f, dir)
+	if doPurge := f.Features().Purge; doPurge != nil && !useTrash {
 		doFallbackPurge = false
 		accounting.Stats(ctx).DeletedDirs(1)
 		if SkipDestructive(ctx, fs.LogDirName(f, dir), "purge directory") {
 			return nil
 		}
 		err = doPurge(ctx, dir)
+		list.CacheInvalidate(ctx, f, dir, true)
 		if errors.Is(err, fs.ErrorCantPurge) {
 			doFallbackPurge = true
 		}
@@ -1163,7 +1451,8 @@ func Purge(ctx context.Context, f fs.Fs, dir string) (err error) {
 		if err != nil {
 			return err
 		}
-		err = Rmdirs(ctx, f, dir, false)
+		// The trash is in the root so leave it if purging that
+		err = Rmdirs(ctx, f, dir, useTrash && dir == "")
 	}
 	if err != nil {
 		err = fs.CountError(ctx, err)
@@ -1270,14 +1559,28 @@ func listToChan(ctx context.Context, f fs.Fs, dir string) fs.ObjectsChan {
 }
 
 // CleanUp removes the trash for the Fs
+//
+// If --use-trash is in effect and the backend doesn't have a trash of
+// its own then this purges the files in fs.TrashDir deleted longer
+// ago than --trash-retention first.
 func CleanUp(ctx context.Context, f fs.Fs) error {
 	doCleanUp := f.Features().CleanUp
-	if doCleanUp == nil {
+	useTrash := trashNeeded(ctx, f, "")
+	if doCleanUp == nil && !useTrash {
 		return fmt.Errorf("%v doesn't support cleanup", f)
 	}
 	if SkipDestructive(ctx, f, "clean up old files") {
 		return nil
 	}
+	if useTrash {
+		err := purgeTrash(ctx, f)
+		if err != nil {
+			return err
+		}
+	}
+	if doCleanUp == nil {
+		return nil
+	}
 	return doCleanUp(ctx)
 }
 
@@ -1486,11 +1789,21 @@ func rcatSrc(ctx context.Context, fdst fs.Fs, dstFileName string, in io.ReadClos
 }
 
 // PublicLink adds a "readable by anyone with link" permission on the given file or folder.
+//
+// Any fs.LinkOptions in ctx are checked and passed on to backends which
+// support them.
 func PublicLink(ctx context.Context, f fs.Fs, remote string, expire fs.Duration, unlink bool) (string, error) {
 	doPublicLink := f.Features().PublicLink
 	if doPublicLink == nil {
 		return "", fmt.Errorf("%v doesn't support public links", f)
 	}
+	lo := fs.GetLinkOptions(ctx)
+	if err := lo.Check(); err != nil {
+		return "", err
+	}
+	if lo.IsSet() && !unlink && !f.Features().PublicLinkOptions {
+		return "", fmt.Errorf("%v doesn't support public link passwords or permissions", f)
+	}
 	return doPublicLink(ctx, remote, expire, unlink)
 }
 
@@ -1617,7 +1930,7 @@ func compareDest(ctx context.Context, dst, src fs.Object, CompareDest fs.Fs) (No
 	} else {
 		remote = dst.Remote()
 	}
-	CompareDestFile, err := CompareDest.NewObject(ctx, remote)
+	CompareDestFile, err := list.NewObjectNormalized(ctx, CompareDest, remote)
 	switch err {
 	case fs.ErrorObjectNotFound:
 		return false, nil
@@ -1665,7 +1978,7 @@ func copyDest(ctx context.Context, fdst fs.Fs, dst, src fs.Object, CopyDest, bac
 	} else {
 		remote = dst.Remote()
 	}
-	CopyDestFile, err := CopyDest.NewObject(ctx, remote)
+	CopyDestFile, err := list.NewObjectNormalized(ctx, CopyDest, remote)
 	switch err {
 	case fs.ErrorObjectNotFound:
 		return false, nil
@@ -1744,6 +2057,21 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 		logger(ctx, Match, src, dst, nil)
 		return false
 	}
+	// If there is a --compare-expr then use that to decide
+	if ci.CompareExpr != "" {
+		transfer, err := evalCompareExpr(ctx, ci.CompareExpr, src, dst)
+		if err == nil {
+			if transfer {
+				fs.Debugf(src, "Transferring as --compare-expr is true")
+				logger(ctx, Differ, src, dst, nil)
+			} else {
+				fs.Debugf(src, "Skipping as --compare-expr is false")
+				logger(ctx, Match, src, dst, nil)
+			}
+			return transfer
+		}
+		fs.Errorf(src, "Failed to evaluate --compare-expr - using normal comparison: %v", err)
+	}
 	// If we should upload unconditionally
 	if ci.IgnoreTimes {
 		fs.Debugf(src, "Transferring unconditionally as --ignore-times is in use")
@@ -1773,6 +2101,7 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 			opt := defaultEqualOpt(ctx)
 			opt.forceModTimeMatch = true
 			if equal(ctx, src, dst, opt) {
+				updateMetadata(ctx, src, dst)
 				fs.Debugf(src, "Unchanged skipping")
 				return false
 			}
@@ -1781,6 +2110,7 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 			opt := defaultEqualOpt(ctx)
 			opt.sizeOnly = !ci.CheckSum
 			if equal(ctx, src, dst, opt) {
+				updateMetadata(ctx, src, dst)
 				fs.Debugf(src, "Destination mod time is within %v of source and files identical, skipping", modifyWindow)
 				return false
 			}
@@ -1793,6 +2123,7 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 			return !equalFn(ctx, src, dst)
 		}
 		if Equal(ctx, src, dst) && !SameObject(src, dst) {
+			updateMetadata(ctx, src, dst)
 			fs.Debugf(src, "Unchanged skipping")
 			return false
 		}
@@ -1894,6 +2225,71 @@ func CopyURL(ctx context.Context, fdst fs.Fs, dstFileName string, url string, au
 	return dst, err
 }
 
+// URLExpect describes what the data downloaded by CopyURLVerify
+// should be like
+type URLExpect struct {
+	Size     int64     // expected size or -1 if not known
+	HashType hash.Type // type of Hash or hash.None
+	Hash     string    // expected hash in hex
+}
+
+// CopyURLVerify copies the data from the url to (fdst, dstFileName)
+// like CopyURL checking the size and hash of the data as it is
+// downloaded against expect.
+//
+// If they don't match then the destination is removed and an error
+// returned.
+func CopyURLVerify(ctx context.Context, fdst fs.Fs, dstFileName string, url string, autoFilename, dstFileNameFromHeader bool, noClobber bool, expect URLExpect) (dst fs.Object, err error) {
+	hashes := hash.NewHashSet()
+	if expect.HashType != hash.None {
+		hashes.Add(expect.HashType)
+	}
+	err = copyURLFn(ctx, dstFileName, url, autoFilename, dstFileNameFromHeader, func(ctx context.Context, dstFileName string, in io.ReadCloser, size int64, modTime time.Time) (err error) {
+		if noClobber {
+			_, err = fdst.NewObject(ctx, dstFileName)
+			if err == nil {
+				return errors.New("CopyURL failed: file already exist")
+			}
+		}
+		if expect.Size >= 0 {
+			if size >= 0 && size != expect.Size {
+				return fmt.Errorf("CopyURL failed: size mismatch: server says %d, expecting %d", size, expect.Size)
+			}
+			size = expect.Size
+		}
+		hasher, err := hash.NewMultiHasherTypes(hashes)
+		if err != nil {
+			return err
+		}
+		tee := struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(in, hasher), in}
+		dst, err = RcatSize(ctx, fdst, dstFileName, tee, size, modTime, nil)
+		if err != nil {
+			return err
+		}
+		if expect.Size >= 0 && hasher.Size() != expect.Size {
+			err = fmt.Errorf("CopyURL failed: size mismatch: read %d, expecting %d", hasher.Size(), expect.Size)
+		} else if expect.HashType != hash.None {
+			got, hashErr := hasher.SumString(expect.HashType, false)
+			if hashErr != nil {
+				err = hashErr
+			} else if !strings.EqualFold(got, expect.Hash) {
+				err = fmt.Errorf("CopyURL failed: %v hash mismatch: got %s, expecting %s", expect.HashType, got, expect.Hash)
+			}
+		}
+		if err != nil {
+			if removeErr := dst.Remove(ctx); removeErr != nil {
+				fs.Errorf(dst, "Failed to remove after failed verification: %v", removeErr)
+			}
+			dst = nil
+		}
+		return err
+	})
+	return dst, err
+}
+
 // CopyURLToWriter copies the data from the url to the io.Writer supplied
 func CopyURLToWriter(ctx context.Context, url string, out io.Writer) (err error) {
 	return copyURLFn(ctx, "", url, false, false, func(ctx context.Context, dstFileName string, in io.ReadCloser, size int64, modTime time.Time) (err error) {
@@ -1905,10 +2301,17 @@ func CopyURLToWriter(ctx context.Context, url string, out io.Writer) (err error)
 // BackupDir returns the correctly configured --backup-dir
 func BackupDir(ctx context.Context, fdst fs.Fs, fsrc fs.Fs, srcFileName string) (backupDir fs.Fs, err error) {
 	ci := fs.GetConfig(ctx)
+	if ci.BackupDirVersions && ci.BackupDir == "" {
+		return nil, fserrors.FatalError(errors.New("--backup-dir-versions needs --backup-dir"))
+	}
 	if ci.BackupDir != "" {
-		backupDir, err = cache.Get(ctx, ci.BackupDir)
+		dir := ci.BackupDir
+		if ci.BackupDirVersions {
+			dir = fspath.JoinRootPath(dir, BackupVersion())
+		}
+		backupDir, err = cache.Get(ctx, dir)
 		if err != nil {
-			return nil, fserrors.FatalError(fmt.Errorf("failed to make fs for --backup-dir %q: %w", ci.BackupDir, err))
+			return nil, fserrors.FatalError(fmt.Errorf("failed to make fs for --backup-dir %q: %w", dir, err))
 		}
 		if !SameConfig(fdst, backupDir) {
 			return nil, fserrors.FatalError(errors.New("parameter to --backup-dir has to be on the same remote as destination"))
@@ -1942,6 +2345,8 @@ func BackupDir(ctx context.Context, fdst fs.Fs, fsrc fs.Fs, srcFileName string)
 
 // MoveBackupDir moves a file to the backup dir
 func MoveBackupDir(ctx context.Context, backupDir fs.Fs, dst fs.Object) (err error) {
+	// Backups are made as part of the operation when a plan is applied
+	ctx = WithPlan(ctx, nil)
 	remoteWithSuffix := SuffixName(ctx, dst.Remote())
 	overwritten, _ := backupDir.NewObject(ctx, remoteWithSuffix)
 	_, err = Move(ctx, backupDir, overwritten, remoteWithSuffix, dst)
@@ -2032,13 +2437,18 @@ func moveOrCopyFile(ctx context.Context, fdst fs.Fs, fsrc fs.Fs, dstFileName str
 	// Find dst object if it exists
 	var dstObj fs.Object
 	if !ci.NoCheckDest {
-		dstObj, err = fdst.NewObject(ctx, dstFileName)
+		dstObj, err = list.FindObject(ctx, fdst, dstFileName)
 		if errors.Is(err, fs.ErrorObjectNotFound) {
 			dstObj = nil
 		} else if err != nil {
 			logger(ctx, TransferError, nil, dstObj, err)
 			return err
 		}
+		// If the source was found under a different normalization
+		// or case then this is a rename so don't treat it as the dst
+		if dstObj != nil && dstObj.Remote() != dstFileName && SameObject(srcObj, dstObj) {
+			dstObj = nil
+		}
 	}
 
 	// Special case for changing case of a file on a case insensitive remote
@@ -2056,7 +2466,7 @@ func moveOrCopyFile(ctx context.Context, fdst fs.Fs, fsrc fs.Fs, dstFileName str
 
 	var backupDir fs.Fs
 	var copyDestDir []fs.Fs
-	if ci.BackupDir != "" || ci.Suffix != "" {
+	if ci.BackupDir != "" || ci.Suffix != "" || ci.BackupDirVersions {
 		backupDir, err = BackupDir(ctx, fdst, fsrc, srcFileName)
 		if err != nil {
 			return fmt.Errorf("creating Fs for --backup-dir failed: %w", err)
@@ -2102,7 +2512,7 @@ func moveOrCopyFile(ctx context.Context, fdst fs.Fs, fsrc fs.Fs, dstFileName str
 			fs.Debugf(srcObj, "Not removing source file as destination file exists and --ignore-existing is set")
 			logger(ctx, Match, srcObj, dstObj, nil)
 		} else if !SameObject(srcObj, dstObj) {
-			err = DeleteFile(ctx, srcObj)
+			err = DeleteFile(WithoutTrash(ctx), srcObj)
 			logger(ctx, Differ, srcObj, dstObj, nil)
 		}
 	}
@@ -2123,19 +2533,107 @@ func TransformFile(ctx context.Context, fdst fs.Fs, srcFileName string) (err err
 	return moveOrCopyFile(ctx, fdst, fdst, srcFileName, srcFileName, false, true)
 }
 
-// SetTier changes tier of object in remote
+// setTierBatchSize is the number of objects passed to each call of
+// the SetTierBatch feature
+const setTierBatchSize = 1000
+
+// needsSetTier returns true if the tier of o should be changed to tier
+func needsSetTier(ctx context.Context, o fs.Object, tier string) bool {
+	if do, ok := o.(fs.GetTierer); ok && strings.EqualFold(do.GetTier(), tier) {
+		fs.Debugf(o, "Not setting tier as already %s", do.GetTier())
+		return false
+	}
+	return !SkipDestructive(ctx, o, "set tier")
+}
+
+// SetTier changes the tier of the objects in fsrc which match the
+// filters to tier.
+//
+// Objects already in tier are skipped. If the backend supports the
+// SetTierBatch feature then it is used to change the tiers, otherwise
+// the tiers are changed with --checkers concurrent calls.
 func SetTier(ctx context.Context, fsrc fs.Fs, tier string) error {
-	return ListFn(ctx, fsrc, func(o fs.Object) {
-		objImpl, ok := o.(fs.SetTierer)
-		if !ok {
-			fs.Errorf(fsrc, "Remote object does not implement SetTier")
+	ci := fs.GetConfig(ctx)
+	if !fsrc.Features().SetTier {
+		return fmt.Errorf("remote %s does not support settier", fsrc.Name())
+	}
+	var (
+		doBatch  = fsrc.Features().SetTierBatch
+		mu       sync.Mutex
+		errCount int
+		lastErr  error
+		batch    []fs.Object
+	)
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(ci.Checkers)
+	// record the result of setting the tier on o
+	done := func(o fs.Object, err error) {
+		if err != nil {
+			err = fs.CountError(gCtx, fmt.Errorf("failed to set tier: %w", err))
+			fs.Errorf(o, "%v", err)
+			mu.Lock()
+			errCount++
+			lastErr = err
+			mu.Unlock()
 			return
 		}
-		err := objImpl.SetTier(tier)
-		if err != nil {
-			fs.Errorf(fsrc, "Failed to do SetTier, %v", err)
+		fs.Infof(o, "Set tier to %s", tier)
+	}
+	// set the tier on all the objects in objs in one batch
+	flush := func() {
+		if len(batch) == 0 {
+			return
 		}
+		objs := batch
+		batch = nil
+		g.Go(func() error {
+			errs, err := doBatch(gCtx, tier, objs)
+			if err == nil && len(errs) != len(objs) {
+				err = fmt.Errorf("set tier batch returned %d results for %d objects", len(errs), len(objs))
+			}
+			for i, o := range objs {
+				if err != nil {
+					done(o, err)
+				} else {
+					done(o, errs[i])
+				}
+			}
+			return nil
+		})
+	}
+	err := walk.ListR(ctx, fsrc, "", false, ConfigMaxDepth(ctx, true), walk.ListObjects, func(entries fs.DirEntries) error {
+		entries.ForObject(func(o fs.Object) {
+			if !needsSetTier(ctx, o, tier) {
+				return
+			}
+			if doBatch != nil {
+				batch = append(batch, o)
+				if len(batch) >= setTierBatchSize {
+					flush()
+				}
+				return
+			}
+			g.Go(func() error {
+				do, ok := o.(fs.SetTierer)
+				if !ok {
+					done(o, errors.New("remote object does not implement SetTier"))
+					return nil
+				}
+				done(o, do.SetTier(tier))
+				return nil
+			})
+		})
+		return nil
 	})
+	flush()
+	_ = g.Wait()
+	if err != nil {
+		return err
+	}
+	if errCount > 0 {
+		return fmt.Errorf("failed to set tier on %d objects: last error: %w", errCount, lastErr)
+	}
+	return nil
 }
 
 // SetTierFile changes tier of a single file in remote
@@ -2144,14 +2642,55 @@ func SetTierFile(ctx context.Context, o fs.Object, tier string) error {
 	if !ok {
 		return errors.New("remote object does not implement SetTier")
 	}
+	if !needsSetTier(ctx, o, tier) {
+		return nil
+	}
 	err := do.SetTier(tier)
 	if err != nil {
 		fs.Errorf(o, "Failed to do SetTier, %v", err)
 		return err
 	}
+	fs.Infof(o, "Set tier to %s", tier)
 	return nil
 }
 
+// TierUsage is the number of objects and bytes in a tier
+type TierUsage struct {
+	Tier  string `json:"tier"`
+	Count int64  `json:"count"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Tiers returns the number of objects and bytes in each storage tier
+// or class of the objects in f which match the filters, sorted by
+// tier.
+func Tiers(ctx context.Context, f fs.Fs) (tiers []TierUsage, err error) {
+	if !f.Features().GetTier {
+		return nil, fmt.Errorf("remote %s does not support reading tiers", f.Name())
+	}
+	usage := map[string]*TierUsage{}
+	err = ListFn(ctx, f, func(o fs.Object) {
+		tier := ""
+		if do, ok := o.(fs.GetTierer); ok {
+			tier = do.GetTier()
+		}
+		u := usage[tier]
+		if u == nil {
+			u = &TierUsage{Tier: tier}
+			usage[tier] = u
+		}
+		u.Count++
+		if size := o.Size(); size > 0 {
+			u.Bytes += size
+		}
+	})
+	for _, u := range usage {
+		tiers = append(tiers, *u)
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Tier < tiers[j].Tier })
+	return tiers, err
+}
+
 // TouchDir touches every file in directory with time t
 func TouchDir(ctx context.Context, f fs.Fs, remote string, t time.Time, recursive bool) error {
 	ci := fs.GetConfig(ctx)
@@ -2528,6 +3067,69 @@ func GetFsInfo(f fs.Fs) *FsInfo {
 	return info
 }
 
+// RemoteFeatures describes the features of a configured remote
+type RemoteFeatures struct {
+	// Name of the remote in the config
+	Name string
+
+	// Type of the backend
+	Type string
+
+	// Error if the remote couldn't be created
+	Error string `json:",omitempty"`
+
+	// Info about the remote if it could be created
+	Info *FsInfo `json:",omitempty"`
+}
+
+// GetRemotesFeatures creates each of the named remotes, or all the
+// configured remotes if none are named, and returns their features
+// sorted by name.
+//
+// Up to --checkers remotes are created at once. Remotes which can't
+// be created have Error set rather than failing the whole call.
+func GetRemotesFeatures(ctx context.Context, names []string) []RemoteFeatures {
+	ci := fs.GetConfig(ctx)
+	remotes := config.GetRemotes()
+	if len(names) == 0 {
+		for _, remote := range remotes {
+			names = append(names, remote.Name)
+		}
+	} else {
+		names = slices.Clone(names)
+		for i := range names {
+			names[i] = strings.TrimSuffix(names[i], ":")
+		}
+	}
+	sort.Strings(names)
+	names = slices.Compact(names)
+	out := make([]RemoteFeatures, len(names))
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(max(ci.Checkers, 1))
+	for i, name := range names {
+		out[i].Name = name
+		remoteIndex := slices.IndexFunc(remotes, func(remote config.Remote) bool {
+			return remote.Name == name
+		})
+		if remoteIndex < 0 {
+			out[i].Error = "remote not found in config"
+			continue
+		}
+		out[i].Type = remotes[remoteIndex].Type
+		g.Go(func() error {
+			f, err := cache.Get(gCtx, name+":")
+			if err != nil && err != fs.ErrorIsFile {
+				out[i].Error = err.Error()
+				return nil
+			}
+			out[i].Info = GetFsInfo(f)
+			return nil
+		})
+	}
+	_ = g.Wait() // errors are returned in out
+	return out
+}
+
 var (
 	interactiveMu sync.Mutex // protects the following variables
 	skipped       = map[string]bool{}
@@ -2704,12 +3306,13 @@ func SetDirModTime(ctx context.Context, f fs.Fs, dst fs.Directory, dir string, m
 		fs.Debugf(logName, "Skipping set directory modification time as --no-update-dir-modtime is set")
 		return nil, nil
 	}
-	if SkipDestructive(ctx, logName, "set directory modification time") {
-		return nil, nil
-	}
 	if dst != nil {
 		dir = dst.Remote()
 	}
+	if SkipDestructive(ctx, logName, "set directory modification time") {
+		planDir(ctx, PlanDirModTime, f, dir, &modTime)
+		return nil, nil
+	}
 
 	// Try to set the ModTime with the Directory.SetModTime method first as this is the most efficient
 	if dst != nil {
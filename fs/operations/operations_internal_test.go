@@ -9,8 +9,12 @@ import (
 	"time"
 
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/fstest/mockobject"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSizeDiffers(t *testing.T) {
@@ -41,3 +45,123 @@ func TestSizeDiffers(t *testing.T) {
 		assert.Equal(t, test.want, got, fmt.Sprintf("ignoreSize=%v, srcSize=%v, dstSize=%v", test.ignoreSize, test.srcSize, test.dstSize))
 	}
 }
+
+// noMD5Object is a mock object which doesn't have an MD5
+type noMD5Object struct {
+	*mockobject.ContentMockObject
+}
+
+// Hash returns "" for MD5 or the hash of the content otherwise
+func (o noMD5Object) Hash(ctx context.Context, ht hash.Type) (string, error) {
+	if ht == hash.MD5 {
+		return "", nil
+	}
+	return o.ContentMockObject.Hash(ctx, ht)
+}
+
+func TestCheckHashesAny(t *testing.T) {
+	ctx := context.Background()
+	f, err := mockfs.NewFs(ctx, "mock", "", nil)
+	require.NoError(t, err)
+	f.(*mockfs.Fs).SetHashes(hash.NewHashSet(hash.MD5, hash.SHA1))
+	newObject := func(content string) *mockobject.ContentMockObject {
+		o := mockobject.New("a").WithContent([]byte(content), mockobject.SeekModeNone)
+		o.SetFs(f)
+		return o
+	}
+	src := newObject("potato")
+
+	// Uses the first hash if present
+	equal, ht, err := checkHashesAny(ctx, src, newObject("potato"))
+	require.NoError(t, err)
+	assert.True(t, equal)
+	assert.Equal(t, hash.MD5, ht)
+
+	// Falls back to the next hash if the first is missing
+	equal, ht, err = checkHashesAny(ctx, src, noMD5Object{newObject("potato")})
+	require.NoError(t, err)
+	assert.True(t, equal)
+	assert.Equal(t, hash.SHA1, ht)
+
+	equal, ht, err = checkHashesAny(ctx, src, noMD5Object{newObject("carrot")})
+	require.NoError(t, err)
+	assert.False(t, equal)
+	assert.Equal(t, hash.SHA1, ht)
+
+	// CheckHashes doesn't fall back
+	equal, ht, err = CheckHashes(ctx, src, noMD5Object{newObject("carrot")})
+	require.NoError(t, err)
+	assert.True(t, equal)
+	assert.Equal(t, hash.None, ht)
+}
+
+func TestMetadataDiffers(t *testing.T) {
+	dstInfo := &fs.MetadataInfo{
+		System: map[string]fs.MetadataHelp{
+			"etag": {ReadOnly: true},
+			"tier": {},
+		},
+	}
+	for _, test := range []struct {
+		name    string
+		srcMeta fs.Metadata
+		dstMeta fs.Metadata
+		want    bool
+	}{
+		{"empty", nil, nil, false},
+		{"same", fs.Metadata{"potato": "jersey"}, fs.Metadata{"potato": "jersey", "extra": "1"}, false},
+		{"changed", fs.Metadata{"potato": "jersey"}, fs.Metadata{"potato": "king edward"}, true},
+		{"missing", fs.Metadata{"potato": "jersey"}, nil, true},
+		{"times ignored", fs.Metadata{"mtime": "1", "atime": "2", "btime": "3"}, nil, false},
+		{"read only ignored", fs.Metadata{"etag": "abc"}, fs.Metadata{"etag": "def"}, false},
+		{"writable system", fs.Metadata{"tier": "COLD"}, fs.Metadata{"tier": "HOT"}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, metadataDiffers(test.srcMeta, test.dstMeta, dstInfo))
+		})
+	}
+}
+
+// metadataObject is a mock object with metadata which counts the
+// times its metadata is read
+type metadataObject struct {
+	mockobject.Object
+	f     fs.Fs
+	meta  fs.Metadata
+	reads int
+}
+
+func (o *metadataObject) Fs() fs.Info {
+	return o.f
+}
+
+func (o *metadataObject) Metadata(ctx context.Context) (fs.Metadata, error) {
+	o.reads++
+	return o.meta, nil
+}
+
+func (o *metadataObject) SetMetadata(ctx context.Context, metadata fs.Metadata) error {
+	o.meta = metadata
+	return nil
+}
+
+func TestUpdateMetadata(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.Metadata = true
+	fdst, err := mockfs.NewFs(ctx, "metadata", "", nil)
+	require.NoError(t, err)
+	fdst.Features().WriteMetadata = true
+	src := &metadataObject{Object: "file", f: fdst, meta: fs.Metadata{"potato": "jersey"}}
+	dst := &metadataObject{Object: "file", f: fdst, meta: fs.Metadata{"potato": "king edward"}}
+
+	// Metadata isn't read without --metadata-update
+	updateMetadata(ctx, src, dst)
+	assert.Equal(t, 0, src.reads)
+	assert.Equal(t, 0, dst.reads)
+	assert.Equal(t, "king edward", dst.meta["potato"])
+
+	ci.MetadataUpdate = true
+	updateMetadata(ctx, src, dst)
+	assert.Equal(t, "jersey", dst.meta["potato"])
+}
@@ -30,6 +30,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -42,6 +43,8 @@ import (
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/fstest/fstests"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/fstest/mockobject"
 	"github.com/rclone/rclone/lib/pacer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -894,6 +897,55 @@ func TestCopyURL(t *testing.T) {
 	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2, fstest.NewItem(urlFileName, contents, t1), fstest.NewItem(headerFilename, contents, t1)}, nil, fs.ModTimeNotSupported)
 }
 
+func TestCopyURLVerify(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	r.Mkdir(ctx, r.Fremote)
+
+	contents := "file contents\n"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(contents))
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+	sums, err := hash.StreamTypes(strings.NewReader(contents), hash.NewHashSet(hash.SHA256))
+	require.NoError(t, err)
+	sha256sum := sums[hash.SHA256]
+
+	// Everything matches
+	o, err := operations.CopyURLVerify(ctx, r.Fremote, "file1", ts.URL, false, false, false, operations.URLExpect{
+		Size:     int64(len(contents)),
+		HashType: hash.SHA256,
+		Hash:     strings.ToUpper(sha256sum),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(contents)), o.Size())
+
+	// Nothing to check
+	_, err = operations.CopyURLVerify(ctx, r.Fremote, "file2", ts.URL, false, false, false, operations.URLExpect{Size: -1})
+	require.NoError(t, err)
+
+	// Wrong size
+	_, err = operations.CopyURLVerify(ctx, r.Fremote, "file3", ts.URL, false, false, false, operations.URLExpect{Size: 3})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "size mismatch")
+
+	// Wrong hash removes the file
+	o, err = operations.CopyURLVerify(ctx, r.Fremote, "file4", ts.URL, false, false, false, operations.URLExpect{
+		Size:     -1,
+		HashType: hash.SHA256,
+		Hash:     strings.Repeat("0", 64),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hash mismatch")
+	assert.Nil(t, o)
+
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{
+		fstest.NewItem("file1", contents, t1),
+		fstest.NewItem("file2", contents, t1),
+	}, nil, fs.ModTimeNotSupported)
+}
+
 func TestCopyURLToWriter(t *testing.T) {
 	ctx := context.Background()
 	contents := "file contents\n"
@@ -1965,3 +2017,243 @@ func TestRemoveExisting(t *testing.T) {
 	cleanup(&returnedError)
 	r.CheckRemoteItems(t)
 }
+
+// tierObject is a mock object with a storage tier
+type tierObject struct {
+	mockobject.Object
+	mu   sync.Mutex
+	tier string
+	sets int   // number of calls to SetTier
+	err  error // error to return from SetTier
+}
+
+func (o *tierObject) GetTier() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.tier
+}
+
+func (o *tierObject) SetTier(tier string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sets++
+	if o.err != nil {
+		return o.err
+	}
+	o.tier = tier
+	return nil
+}
+
+// makeTierFs makes a mock Fs with objects in tiers
+func makeTierFs(t *testing.T, ctx context.Context) (fs.Fs, map[string]*tierObject) {
+	f, err := mockfs.NewFs(ctx, "tier", "", nil)
+	require.NoError(t, err)
+	f.Features().SetTier = true
+	f.Features().GetTier = true
+	objs := map[string]*tierObject{}
+	for _, item := range []struct{ name, tier string }{
+		{"a.txt", "STANDARD"},
+		{"b.txt", "GLACIER"},
+		{"c.jpg", "STANDARD"},
+		{"d.txt", "STANDARD"},
+	} {
+		o := &tierObject{Object: mockobject.New(item.name), tier: item.tier}
+		f.(*mockfs.Fs).AddObject(o)
+		objs[item.name] = o
+	}
+	return f, objs
+}
+
+func TestSetTier(t *testing.T) {
+	ctx := context.Background()
+	fi, err := filter.NewFilter(nil)
+	require.NoError(t, err)
+	require.NoError(t, fi.AddRule("- *.jpg"))
+	ctx = filter.ReplaceConfig(ctx, fi)
+
+	t.Run("Unsupported", func(t *testing.T) {
+		f, err := mockfs.NewFs(ctx, "tier", "", nil)
+		require.NoError(t, err)
+		assert.ErrorContains(t, operations.SetTier(ctx, f, "GLACIER"), "does not support settier")
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		ctx, ci := fs.AddConfig(ctx)
+		ci.DryRun = true
+		f, objs := makeTierFs(t, ctx)
+		require.NoError(t, operations.SetTier(ctx, f, "GLACIER"))
+		for _, o := range objs {
+			assert.Equal(t, 0, o.sets, o.Remote())
+		}
+	})
+
+	t.Run("Single", func(t *testing.T) {
+		f, objs := makeTierFs(t, ctx)
+		require.NoError(t, operations.SetTier(ctx, f, "glacier"))
+		assert.Equal(t, "glacier", objs["a.txt"].GetTier())
+		assert.Equal(t, 1, objs["a.txt"].sets)
+		assert.Equal(t, 0, objs["b.txt"].sets, "already in tier")
+		assert.Equal(t, 0, objs["c.jpg"].sets, "excluded")
+		assert.Equal(t, "glacier", objs["d.txt"].GetTier())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		f, objs := makeTierFs(t, ctx)
+		objs["a.txt"].err = errors.New("BOOM")
+		err := operations.SetTier(ctx, f, "GLACIER")
+		assert.ErrorContains(t, err, "failed to set tier on 1 objects")
+		assert.ErrorContains(t, err, "BOOM")
+		assert.Equal(t, "GLACIER", objs["d.txt"].GetTier())
+		accounting.GlobalStats().ResetCounters()
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		f, objs := makeTierFs(t, ctx)
+		var batches [][]string
+		f.Features().SetTierBatch = func(ctx context.Context, tier string, in []fs.Object) (errs []error, err error) {
+			var names []string
+			for _, o := range in {
+				names = append(names, o.Remote())
+				o.(*tierObject).tier = tier
+				errs = append(errs, nil)
+			}
+			batches = append(batches, names)
+			return errs, nil
+		}
+		require.NoError(t, operations.SetTier(ctx, f, "GLACIER"))
+		assert.Equal(t, [][]string{{"a.txt", "d.txt"}}, batches)
+		assert.Equal(t, "GLACIER", objs["a.txt"].GetTier())
+		assert.Equal(t, 0, objs["a.txt"].sets)
+
+		f, _ = makeTierFs(t, ctx)
+		f.Features().SetTierBatch = func(ctx context.Context, tier string, in []fs.Object) (errs []error, err error) {
+			return nil, errors.New("batch failed")
+		}
+		err := operations.SetTier(ctx, f, "GLACIER")
+		assert.ErrorContains(t, err, "failed to set tier on 2 objects")
+		assert.ErrorContains(t, err, "batch failed")
+
+		// A short result fails the whole batch rather than panicking
+		f, _ = makeTierFs(t, ctx)
+		f.Features().SetTierBatch = func(ctx context.Context, tier string, in []fs.Object) (errs []error, err error) {
+			return []error{nil}, nil
+		}
+		err = operations.SetTier(ctx, f, "GLACIER")
+		assert.ErrorContains(t, err, "failed to set tier on 2 objects")
+		assert.ErrorContains(t, err, "set tier batch returned 1 results for 2 objects")
+		accounting.GlobalStats().ResetCounters()
+	})
+}
+
+func TestSetTierFile(t *testing.T) {
+	ctx := context.Background()
+	o := &tierObject{Object: mockobject.New("a.txt"), tier: "STANDARD"}
+	require.NoError(t, operations.SetTierFile(ctx, o, "STANDARD"))
+	assert.Equal(t, 0, o.sets)
+	require.NoError(t, operations.SetTierFile(ctx, o, "GLACIER"))
+	assert.Equal(t, 1, o.sets)
+	assert.Equal(t, "GLACIER", o.GetTier())
+}
+
+func TestTiers(t *testing.T) {
+	ctx := context.Background()
+	f, _ := makeTierFs(t, ctx)
+	tiers, err := operations.Tiers(ctx, f)
+	require.NoError(t, err)
+	assert.Equal(t, []operations.TierUsage{
+		{Tier: "GLACIER", Count: 1},
+		{Tier: "STANDARD", Count: 3},
+	}, tiers)
+}
+
+// batchObject is a mock object in an Fs which deletes in batches
+type batchObject struct {
+	mockobject.Object
+	f       fs.Fs
+	removes int // number of calls to Remove
+}
+
+func (o *batchObject) Fs() fs.Info {
+	return o.f
+}
+
+func (o *batchObject) Remove(ctx context.Context) error {
+	o.removes++
+	return nil
+}
+
+func TestDeleteFilesBatch(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.Checkers = 1
+
+	// makeObjects makes a mock Fs which deletes in batches and
+	// returns a channel with its objects in
+	makeObjects := func(t *testing.T, ctx context.Context) (f fs.Fs, objs []*batchObject, toBeDeleted fs.ObjectsChan, batches *[][]string) {
+		f, err := mockfs.NewFs(ctx, "batch", "", nil)
+		require.NoError(t, err)
+		batches = new([][]string)
+		f.Features().DeleteObjects = func(ctx context.Context, in []fs.Object) (errs []error, err error) {
+			var names []string
+			for _, o := range in {
+				names = append(names, o.Remote())
+				if o.Remote() == "bad.txt" {
+					errs = append(errs, errors.New("BOOM"))
+				} else {
+					errs = append(errs, nil)
+				}
+			}
+			*batches = append(*batches, names)
+			return errs, nil
+		}
+		toBeDeleted = make(fs.ObjectsChan, 10)
+		for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+			o := &batchObject{Object: mockobject.New(name), f: f}
+			objs = append(objs, o)
+			toBeDeleted <- o
+		}
+		close(toBeDeleted)
+		return f, objs, toBeDeleted, batches
+	}
+
+	t.Run("Batch", func(t *testing.T) {
+		_, objs, toBeDeleted, batches := makeObjects(t, ctx)
+		require.NoError(t, operations.DeleteFiles(ctx, toBeDeleted))
+		assert.Equal(t, [][]string{{"a.txt", "b.txt", "c.txt"}}, *batches)
+		for _, o := range objs {
+			assert.Equal(t, 0, o.removes, o.Remote())
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		ctx, ci := fs.AddConfig(ctx)
+		ci.DryRun = true
+		_, _, toBeDeleted, batches := makeObjects(t, ctx)
+		require.NoError(t, operations.DeleteFiles(ctx, toBeDeleted))
+		assert.Empty(t, *batches)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		f, _, _, batches := makeObjects(t, ctx)
+		toBeDeleted := make(fs.ObjectsChan, 10)
+		toBeDeleted <- &batchObject{Object: mockobject.New("ok.txt"), f: f}
+		toBeDeleted <- &batchObject{Object: mockobject.New("bad.txt"), f: f}
+		close(toBeDeleted)
+		err := operations.DeleteFiles(ctx, toBeDeleted)
+		assert.ErrorContains(t, err, "failed to delete 1 files")
+		assert.Equal(t, [][]string{{"ok.txt", "bad.txt"}}, *batches)
+		accounting.GlobalStats().ResetCounters()
+	})
+
+	t.Run("BackupDir", func(t *testing.T) {
+		_, _, toBeDeleted, batches := makeObjects(t, ctx)
+		backupDir, err := mockfs.NewFs(ctx, "backup", "", nil)
+		require.NoError(t, err)
+		// Moving into the backup dir fails as the mock objects
+		// can't be read but the batch mustn't be used
+		err = operations.DeleteFilesWithBackupDir(ctx, toBeDeleted, backupDir)
+		assert.ErrorContains(t, err, "failed to delete 3 files")
+		assert.Empty(t, *batches)
+		accounting.GlobalStats().ResetCounters()
+	})
+}
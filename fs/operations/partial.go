@@ -0,0 +1,136 @@
+// Resuming interrupted downloads from their partial files with --resume-downloads
+
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/readers"
+)
+
+// partialCheckSize is how much of the end of a partial file is
+// compared with the source before the download is carried on with
+const partialCheckSize = 1024 * 1024
+
+// canResumePartial returns true if the copy should be written in
+// order to its partial file and the partial file kept on failure so
+// that the next attempt can carry on from where it got to.
+func (c *copy) canResumePartial() bool {
+	return c.ci.ResumeDownloads && !c.inplace && c.dstFeatures.ResumeWriterAt != nil && c.src.Size() > 0
+}
+
+// rangeSum returns the MD5 of the bytes from start up to end of o
+func rangeSum(ctx context.Context, o fs.Object, start, end int64) (sum string, err error) {
+	in, err := Open(ctx, o, &fs.RangeOption{Start: start, End: end - 1})
+	if err != nil {
+		return "", err
+	}
+	defer fs.CheckClose(in, &err)
+	counter := readers.NewCountingReader(io.LimitReader(in, end-start))
+	sums, err := hash.StreamTypes(counter, hash.NewHashSet(hash.MD5))
+	if err != nil {
+		return "", err
+	}
+	if n := int64(counter.BytesRead()); n != end-start {
+		return "", fmt.Errorf("read %d bytes but expected %d", n, end-start)
+	}
+	return sums[hash.MD5], nil
+}
+
+// partialOffset returns how many bytes of the partial file left by a
+// previous attempt can be kept.
+//
+// The end of the partial file is checked against the same range of
+// the source so a partial file of a different or corrupted source
+// isn't carried on with.
+func (c *copy) partialOffset(ctx context.Context) int64 {
+	partial, err := c.f.NewObject(ctx, c.remoteForCopy)
+	if err != nil {
+		if !errors.Is(err, fs.ErrorObjectNotFound) {
+			fs.Debugf(c.src, "Not resuming download as partial file can't be read: %v", err)
+		}
+		return 0
+	}
+	size := partial.Size()
+	if size <= 0 {
+		return 0
+	}
+	if size >= c.src.Size() {
+		fs.Infof(c.src, "Not resuming download as partial file is %v which isn't smaller than the source", fs.SizeSuffix(size))
+		c.removeFailedCopy(ctx, partial)
+		return 0
+	}
+	start := max(0, size-partialCheckSize)
+	srcSum, err := rangeSum(ctx, c.src, start, size)
+	if err != nil {
+		fs.Infof(c.src, "Not resuming download as source can't be checked: %v", err)
+		return 0
+	}
+	dstSum, err := rangeSum(ctx, partial, start, size)
+	if err != nil {
+		fs.Infof(c.src, "Not resuming download as partial file can't be checked: %v", err)
+		return 0
+	}
+	if srcSum != dstSum {
+		fs.Infof(c.src, "Not resuming download as partial file doesn't match the source")
+		return 0
+	}
+	fs.Infof(c.src, "Resuming download with %v of %v already in partial file", fs.SizeSuffix(size), fs.SizeSuffix(c.src.Size()))
+	return size
+}
+
+// Copy c.src to (c.f, c.remoteForCopy) in order so that if it is
+// interrupted the partial file can be carried on with.
+func (c *copy) resumableCopy(ctx context.Context, downloadOptions, uploadOptions []fs.OpenOption) (actionTaken string, newDst fs.Object, err error) {
+	offset := c.partialOffset(ctx)
+	options := downloadOptions
+	if offset > 0 {
+		options = append(append([]fs.OpenOption{}, downloadOptions...), &fs.RangeOption{Start: offset, End: -1})
+	}
+	in, err := Open(ctx, c.src, options...)
+	if err != nil {
+		return actionTaken, nil, fmt.Errorf("failed to open source object: %w", err)
+	}
+	inAcc := c.tr.Account(ctx, in)
+	inAcc.AccountReadN(offset)
+	out, err := c.dstFeatures.ResumeWriterAt(ctx, c.remoteForCopy)
+	if err != nil {
+		_ = inAcc.Close()
+		return actionTaken, nil, fmt.Errorf("failed to open partial file: %w", err)
+	}
+	n, err := io.Copy(io.NewOffsetWriter(out, offset), inAcc)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := inAcc.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && offset+n != c.src.Size() {
+		err = fmt.Errorf("copied %d bytes but expected %d", offset+n, c.src.Size())
+	}
+	if err != nil {
+		return actionTaken, nil, err
+	}
+	newDst, err = c.f.NewObject(ctx, c.remoteForCopy)
+	if err != nil {
+		return actionTaken, nil, fmt.Errorf("failed to find object after copy: %w", err)
+	}
+	err = setWriterAtMetadata(ctx, c.f, newDst, c.src, uploadOptions)
+	if err != nil {
+		return actionTaken, nil, err
+	}
+	switch {
+	case offset > 0:
+		actionTaken = "Copied (resumed partial)"
+	case c.doUpdate:
+		actionTaken = "Copied (replaced existing)"
+	default:
+		actionTaken = "Copied (new)"
+	}
+	return actionTaken, newDst, nil
+}
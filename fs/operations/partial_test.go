@@ -0,0 +1,111 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/lib/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// partialTestObject records where it is opened from and fails reads
+// after failAfter bytes if set
+type partialTestObject struct {
+	fs.Object
+	starts    []int64
+	failAfter int64
+}
+
+// Open the object recording the start of the range
+func (o *partialTestObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	var start int64
+	for _, option := range options {
+		switch x := option.(type) {
+		case *fs.RangeOption:
+			start = x.Start
+		case *fs.SeekOption:
+			start = x.Offset
+		}
+	}
+	o.starts = append(o.starts, start)
+	in, err := o.Object.Open(ctx, options...)
+	if err != nil || o.failAfter <= 0 {
+		return in, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(io.LimitReader(in, o.failAfter-start), iotest.ErrReader(errors.New("BOOM: simulated read failure"))),
+		Closer: in,
+	}, nil
+}
+
+func TestCopyResumeDownloads(t *testing.T) {
+	r := fstest.NewRun(t)
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.ResumeDownloads = true
+	ci.LowLevelRetries = 1
+	if r.Flocal.Features().ResumeWriterAt == nil {
+		t.Skip("ResumeWriterAt not supported")
+	}
+
+	const size = 3000
+	contents := random.String(size)
+	t1 := fstest.Time("2001-02-03T04:05:06.499999999Z")
+	file1 := r.WriteObject(ctx, "file1", contents, t1)
+	obj, err := r.Fremote.NewObject(ctx, "file1")
+	require.NoError(t, err)
+	require.NoError(t, r.Flocal.Mkdir(ctx, ""))
+
+	// Find the name of the partial file
+	c := &copy{f: r.Flocal, dstFeatures: r.Flocal.Features(), remote: "file1", src: obj, ci: ci}
+	partialName, inplace, err := c.checkPartial(ctx)
+	require.NoError(t, err)
+	require.False(t, inplace)
+	partialPath := filepath.Join(r.LocalName, partialName)
+
+	t.Run("Resume", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(partialPath, []byte(contents[:1000]), 0666))
+		src := &partialTestObject{Object: obj}
+		_, err := Copy(ctx, r.Flocal, nil, "file1", src)
+		require.NoError(t, err)
+		assert.Contains(t, src.starts, int64(1000))
+		r.CheckLocalItems(t, file1)
+		require.NoError(t, os.Remove(filepath.Join(r.LocalName, "file1")))
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(partialPath, []byte(random.String(1000)), 0666))
+		src := &partialTestObject{Object: obj}
+		_, err := Copy(ctx, r.Flocal, nil, "file1", src)
+		require.NoError(t, err)
+		assert.NotContains(t, src.starts, int64(1000))
+		r.CheckLocalItems(t, file1)
+		require.NoError(t, os.Remove(filepath.Join(r.LocalName, "file1")))
+	})
+
+	t.Run("KeepOnFailure", func(t *testing.T) {
+		src := &partialTestObject{Object: obj, failAfter: 2000}
+		_, err := Copy(ctx, r.Flocal, nil, "file1", src)
+		require.Error(t, err)
+		data, err := os.ReadFile(partialPath)
+		require.NoError(t, err)
+		assert.Equal(t, contents[:2000], string(data))
+
+		src = &partialTestObject{Object: obj}
+		_, err = Copy(ctx, r.Flocal, nil, "file1", src)
+		require.NoError(t, err)
+		assert.Contains(t, src.starts, int64(2000))
+		r.CheckLocalItems(t, file1)
+	})
+}
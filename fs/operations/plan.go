@@ -0,0 +1,487 @@
+// Plans of the operations a dry run would have done
+
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+	"golang.org/x/sync/errgroup"
+)
+
+// planVersion is the version of the plan file format
+const planVersion = 1
+
+// Operations which can be in a plan
+const (
+	PlanCopy       = "copy"       // copy Src to Remote
+	PlanMove       = "move"       // move Src to Remote
+	PlanDelete     = "delete"     // delete Remote
+	PlanSetModTime = "settime"    // set the modification time of Remote to that of Src
+	PlanMkdir      = "mkdir"      // make directory Remote
+	PlanRmdir      = "rmdir"      // remove empty directory Remote
+	PlanDirModTime = "setdirtime" // set the modification time of directory Remote
+	PlanDirMove    = "dirmove"    // move all of the source into the destination server-side
+)
+
+// Sides of the sync an operation can be on
+const (
+	PlanSrc = "src"
+	PlanDst = "dst"
+)
+
+// Plan is a list of operations recorded by a dry run which can be
+// applied later with ApplyPlan.
+type Plan struct {
+	Version    int       // version of the plan format
+	Src        string    // source the plan was made for
+	Dst        string    // destination the plan was made for
+	Created    time.Time // when the plan was made
+	Operations []PlanOp  // operations in the order they were done
+
+	mu   sync.Mutex
+	fsrc fs.Fs
+	fdst fs.Fs
+}
+
+// PlanOp is a single operation in a plan
+type PlanOp struct {
+	Op      string     // operation to do, eg "copy"
+	Fs      string     // side Remote is on - "src" or "dst"
+	Remote  string     // file or directory the operation is done to
+	Src     *PlanFile  `json:",omitempty"` // file to copy or move or take the modification time from
+	Dst     *PlanFile  `json:",omitempty"` // file at Remote before the operation - nil if there wasn't one
+	ModTime *time.Time `json:",omitempty"` // modification time for directory operations
+}
+
+// PlanFile is the state of a file when the plan was made. If it has
+// changed when the plan is applied then the plan isn't applied.
+type PlanFile struct {
+	Fs      string `json:",omitempty"` // side the file is on - only set for the source
+	Remote  string `json:",omitempty"` // path of the file - only set for the source
+	Size    int64
+	ModTime time.Time
+}
+
+type planContextKey struct{}
+
+var planKey = planContextKey{}
+
+// NewPlan makes a new empty plan for syncing fsrc to fdst
+func NewPlan(fsrc, fdst fs.Fs) *Plan {
+	return &Plan{
+		Version:    planVersion,
+		Src:        fs.ConfigString(fsrc),
+		Dst:        fs.ConfigString(fdst),
+		Created:    time.Now(),
+		Operations: []PlanOp{},
+		fsrc:       fsrc,
+		fdst:       fdst,
+	}
+}
+
+// WithPlan returns a copy of ctx which records the operations skipped
+// by --dry-run into plan.
+func WithPlan(ctx context.Context, plan *Plan) context.Context {
+	return context.WithValue(ctx, planKey, plan)
+}
+
+// getPlan returns the plan to record into or nil if not recording
+func getPlan(ctx context.Context) *Plan {
+	if !fs.GetConfig(ctx).DryRun {
+		return nil
+	}
+	plan, _ := ctx.Value(planKey).(*Plan)
+	return plan
+}
+
+// side returns which side of the plan f is on or "" if neither
+func (p *Plan) side(f fs.Info) string {
+	switch {
+	case f == nil:
+		return ""
+	case Same(f, p.fdst):
+		return PlanDst
+	case Same(f, p.fsrc):
+		return PlanSrc
+	}
+	return ""
+}
+
+// add adds op to the plan
+func (p *Plan) add(op PlanOp) {
+	p.mu.Lock()
+	p.Operations = append(p.Operations, op)
+	p.mu.Unlock()
+}
+
+// planFile returns the state of o for a plan
+func planFile(ctx context.Context, o fs.ObjectInfo) *PlanFile {
+	return &PlanFile{
+		Size:    o.Size(),
+		ModTime: o.ModTime(ctx),
+	}
+}
+
+// planObject records a file operation to remote in f which will
+// replace or remove dst, taking its data from src if set.
+//
+// If dst is nil it is looked up so the plan can check nothing has
+// appeared there when it is applied.
+//
+// Operations outside the source and destination, like moving files
+// into --backup-dir, aren't recorded as they are done as part of the
+// operation when the plan is applied.
+func planObject(ctx context.Context, op string, f fs.Info, remote string, src fs.ObjectInfo, dst fs.Object) {
+	p := getPlan(ctx)
+	if p == nil {
+		return
+	}
+	side := p.side(f)
+	if side == "" {
+		return
+	}
+	item := PlanOp{
+		Op:     op,
+		Fs:     side,
+		Remote: remote,
+	}
+	if src != nil {
+		item.Src = planFile(ctx, src)
+		item.Src.Fs = p.side(src.Fs())
+		item.Src.Remote = src.Remote()
+		if item.Src.Fs == "" {
+			return
+		}
+	}
+	if fdst, ok := f.(fs.Fs); ok && dst == nil {
+		o, err := fdst.NewObject(ctx, remote)
+		if err == nil {
+			dst = o
+		} else if !errors.Is(err, fs.ErrorObjectNotFound) {
+			fs.Debugf(remote, "Couldn't read destination for plan: %v", err)
+		}
+	}
+	if dst != nil {
+		item.Dst = planFile(ctx, dst)
+	}
+	p.add(item)
+}
+
+// planDir records a directory operation on dir in f
+func planDir(ctx context.Context, op string, f fs.Fs, dir string, modTime *time.Time) {
+	p := getPlan(ctx)
+	if p == nil {
+		return
+	}
+	side := p.side(f)
+	if side == "" {
+		return
+	}
+	p.add(PlanOp{
+		Op:      op,
+		Fs:      side,
+		Remote:  dir,
+		ModTime: modTime,
+	})
+}
+
+// AddPlanDirMove records that the whole of the source would be moved
+// to the destination with a server-side directory move.
+func AddPlanDirMove(ctx context.Context) {
+	p := getPlan(ctx)
+	if p == nil {
+		return
+	}
+	p.add(PlanOp{
+		Op: PlanDirMove,
+		Fs: PlanDst,
+	})
+}
+
+// Save writes the plan as JSON to path
+func (p *Plan) Save(path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, err := json.MarshalIndent(p, "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	err = os.WriteFile(path, append(data, '\n'), 0666)
+	if err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// LoadPlan reads a plan written by Plan.Save from path
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	p := new(Plan)
+	err = json.Unmarshal(data, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode plan %q: %w", path, err)
+	}
+	if p.Version != planVersion {
+		return nil, fmt.Errorf("plan %q has unsupported version %d - need %d", path, p.Version, planVersion)
+	}
+	return p, nil
+}
+
+// planItem is an operation of a plan ready to apply
+type planItem struct {
+	op  *PlanOp
+	f   fs.Fs     // Fs the operation is done on
+	src fs.Object // source if needed
+	dst fs.Object // existing destination if any
+}
+
+// applyPlan applies a plan to fsrc and fdst
+type applyPlan struct {
+	fdst         fs.Fs
+	fsrc         fs.Fs
+	modifyWindow time.Duration
+	backupDir    fs.Fs
+}
+
+// sideFs returns the Fs for side
+func (a *applyPlan) sideFs(side string) (fs.Fs, error) {
+	switch side {
+	case PlanDst:
+		return a.fdst, nil
+	case PlanSrc:
+		return a.fsrc, nil
+	}
+	return nil, fmt.Errorf("unknown side %q", side)
+}
+
+// checkFile reads remote from f checking it is as it was when the
+// plan was made. want is nil if it shouldn't exist.
+func (a *applyPlan) checkFile(ctx context.Context, f fs.Fs, remote string, want *PlanFile) (fs.Object, error) {
+	o, err := f.NewObject(ctx, remote)
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		if want != nil {
+			return nil, fmt.Errorf("%q has been removed since the plan was made", remote)
+		}
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", remote, err)
+	}
+	if want == nil {
+		return nil, fmt.Errorf("%q has been created since the plan was made", remote)
+	}
+	if size := o.Size(); size >= 0 && want.Size >= 0 && size != want.Size {
+		return nil, fmt.Errorf("%q has changed size since the plan was made: was %d now %d", remote, want.Size, size)
+	}
+	if modTime := o.ModTime(ctx); !modTimeEqual(modTime, want.ModTime, a.modifyWindow) {
+		return nil, fmt.Errorf("%q has changed modification time since the plan was made: was %v now %v", remote, want.ModTime, modTime)
+	}
+	return o, nil
+}
+
+// modTimeEqual returns true if a and b are within window of each other
+func modTimeEqual(a, b time.Time, window time.Duration) bool {
+	dt := a.Sub(b)
+	return dt < window && dt > -window || dt == 0
+}
+
+// prepare checks the preconditions of op and reads the objects it needs
+func (a *applyPlan) prepare(ctx context.Context, op *PlanOp) (item planItem, err error) {
+	item.op = op
+	item.f, err = a.sideFs(op.Fs)
+	if err != nil {
+		return item, err
+	}
+	switch op.Op {
+	case PlanCopy, PlanMove, PlanSetModTime:
+		if op.Src == nil {
+			return item, fmt.Errorf("%s of %q has no source", op.Op, op.Remote)
+		}
+		var srcFs fs.Fs
+		srcFs, err = a.sideFs(op.Src.Fs)
+		if err != nil {
+			return item, err
+		}
+		item.src, err = a.checkFile(ctx, srcFs, op.Src.Remote, op.Src)
+		if err != nil {
+			return item, err
+		}
+		if op.Op == PlanSetModTime && op.Dst == nil {
+			return item, fmt.Errorf("%s of %q has no destination", op.Op, op.Remote)
+		}
+		item.dst, err = a.checkFile(ctx, item.f, op.Remote, op.Dst)
+	case PlanDelete:
+		if op.Dst == nil {
+			return item, fmt.Errorf("%s of %q has no file to delete", op.Op, op.Remote)
+		}
+		item.dst, err = a.checkFile(ctx, item.f, op.Remote, op.Dst)
+	case PlanMkdir, PlanRmdir:
+	case PlanDirModTime:
+		if op.ModTime == nil {
+			return item, fmt.Errorf("%s of %q has no modification time", op.Op, op.Remote)
+		}
+	case PlanDirMove:
+		if a.fdst.Features().DirMove == nil || !SameConfig(a.fsrc, a.fdst) {
+			return item, errors.New("can't move the source to the destination server-side")
+		}
+	default:
+		return item, fmt.Errorf("unknown operation %q on %q", op.Op, op.Remote)
+	}
+	return item, err
+}
+
+// apply does the operation in item
+func (a *applyPlan) apply(ctx context.Context, item planItem) (err error) {
+	op := item.op
+	backupDir := a.backupDir
+	if item.op.Fs != PlanDst {
+		backupDir = nil
+	}
+	switch op.Op {
+	case PlanCopy, PlanMove:
+		if item.dst != nil && backupDir != nil {
+			err = MoveBackupDir(ctx, backupDir, item.dst)
+			if err != nil {
+				return err
+			}
+			item.dst = nil
+		}
+		if op.Op == PlanCopy {
+			_, err = Copy(ctx, item.f, item.dst, op.Remote, item.src)
+		} else {
+			_, err = Move(ctx, item.f, item.dst, op.Remote, item.src)
+		}
+	case PlanSetModTime:
+		if SkipDestructive(ctx, item.dst, "update modification time") {
+			return nil
+		}
+		err = item.dst.SetModTime(ctx, item.src.ModTime(ctx))
+		if errors.Is(err, fs.ErrorCantSetModTime) || errors.Is(err, fs.ErrorCantSetModTimeWithoutDelete) {
+			fs.Infof(item.dst, "Can't set modification time so copying instead")
+			_, err = Copy(ctx, item.f, item.dst, op.Remote, item.src)
+		} else if err == nil {
+			fs.Infof(item.dst, "Updated modification time in destination")
+		}
+	case PlanDelete:
+		err = DeleteFileWithBackupDir(ctx, item.dst, backupDir)
+	case PlanMkdir:
+		if op.ModTime != nil {
+			_, err = MkdirModTime(ctx, item.f, op.Remote, *op.ModTime)
+		} else {
+			err = Mkdir(ctx, item.f, op.Remote)
+		}
+	case PlanRmdir:
+		err = Rmdir(ctx, item.f, op.Remote)
+	case PlanDirModTime:
+		_, err = SetDirModTime(ctx, item.f, nil, op.Remote, *op.ModTime)
+	case PlanDirMove:
+		if SkipDestructive(ctx, a.fdst, "server-side directory move") {
+			return nil
+		}
+		err = a.fdst.Features().DirMove(ctx, a.fsrc, "", "")
+		if err == nil {
+			fs.Infof(a.fdst, "Server side directory move succeeded")
+		}
+	}
+	return err
+}
+
+// ApplyPlan does the operations in plan made by a dry run syncing
+// fsrc to fdst.
+//
+// Before doing anything it checks that all the files the plan reads,
+// replaces or deletes are the same size and modification time as
+// when the plan was made and that no files have appeared where the
+// plan will create them. If any aren't then nothing is done.
+//
+// The operations are done in order, with runs of the same operation
+// done in parallel. If any fail then the remaining operations aren't
+// done.
+func ApplyPlan(ctx context.Context, fdst, fsrc fs.Fs, plan *Plan) error {
+	ci := fs.GetConfig(ctx)
+	if src := fs.ConfigString(fsrc); plan.Src != src {
+		return fmt.Errorf("plan was made for source %q not %q", plan.Src, src)
+	}
+	if dst := fs.ConfigString(fdst); plan.Dst != dst {
+		return fmt.Errorf("plan was made for destination %q not %q", plan.Dst, dst)
+	}
+	a := &applyPlan{
+		fdst:         fdst,
+		fsrc:         fsrc,
+		modifyWindow: fs.GetModifyWindow(ctx, fsrc, fdst),
+	}
+	if ci.BackupDir != "" || ci.Suffix != "" || ci.BackupDirVersions {
+		var err error
+		a.backupDir, err = BackupDir(ctx, fdst, fsrc, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check the preconditions of all the operations first
+	items := make([]planItem, len(plan.Operations))
+	var failed int
+	var mu sync.Mutex
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(ci.Checkers)
+	for i := range plan.Operations {
+		g.Go(func() error {
+			item, err := a.prepare(gCtx, &plan.Operations[i])
+			if err != nil {
+				if gCtx.Err() != nil {
+					return gCtx.Err()
+				}
+				fs.Errorf(nil, "Plan operation %d: %s: %v", i+1, plan.Operations[i].Op, err)
+				mu.Lock()
+				failed++
+				mu.Unlock()
+				return nil
+			}
+			items[i] = item
+			return nil
+		})
+	}
+	err := g.Wait()
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fserrors.NoRetryError(fmt.Errorf("%d of %d operations in the plan failed their checks - nothing done", failed, len(items)))
+	}
+	fs.Infof(nil, "Applying plan of %d operations made at %v", len(items), plan.Created)
+
+	// Then do them
+	for start := 0; start < len(items); {
+		end := start + 1
+		for end < len(items) && items[end].op.Op == items[start].op.Op {
+			end++
+		}
+		g, gCtx := errgroup.WithContext(ctx)
+		g.SetLimit(ci.Transfers)
+		for _, item := range items[start:end] {
+			g.Go(func() error {
+				err := a.apply(gCtx, item)
+				if err != nil {
+					fs.Errorf(item.op.Remote, "Failed to %s: %v", item.op.Op, err)
+				}
+				return err
+			})
+		}
+		err = g.Wait()
+		if err != nil {
+			return fmt.Errorf("failed to apply plan: %w", err)
+		}
+		start = end
+	}
+	return nil
+}
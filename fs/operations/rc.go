@@ -40,6 +40,7 @@ func init() {
     - filesOnly - If set only show files
     - metadata - If set return metadata of objects also
     - hashTypes - array of strings of hash types to show if showHash set
+    - query - backend specific query to constrain the listing with
 
 Returns:
 
@@ -322,9 +323,6 @@ func rcSingleCommand(ctx context.Context, in rc.Params, name string, noRemote bo
 	case "cleanup":
 		return nil, CleanUp(ctx, f)
 	case "settier":
-		if !f.Features().SetTier {
-			return nil, fmt.Errorf("remote %s does not support settier", f.Name())
-		}
 		tier, err := in.GetString("tier")
 		if err != nil {
 			return nil, err
@@ -396,6 +394,8 @@ func init() {
 - remote - a path within that remote e.g. "dir"
 - unlink - boolean - if set removes the link rather than adding it (optional)
 - expire - string - the expiry time of the link e.g. "1d" (optional)
+- password - string - password needed to open the link (optional)
+- permission - string - what the link allows, "view" or "edit" (optional)
 
 Returns:
 
@@ -419,7 +419,16 @@ func rcPublicLink(ctx context.Context, in rc.Params) (out rc.Params, err error)
 	} else if err != nil {
 		return nil, err
 	}
-	url, err := PublicLink(ctx, f, remote, fs.Duration(expire), unlink)
+	var lo fs.LinkOptions
+	lo.Password, err = in.GetString("password")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+	lo.Permission, err = in.GetString("permission")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+	url, err := PublicLink(fs.WithLinkOptions(ctx, &lo), f, remote, fs.Duration(expire), unlink)
 	if err != nil {
 		return nil, err
 	}
@@ -563,6 +572,61 @@ func rcFsInfo(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	return out, nil
 }
 
+func init() {
+	rc.Add(rc.Call{
+		Path:         "operations/features",
+		AuthRequired: true,
+		Fn:           rcFeatures,
+		Title:        "Return the features of the configured remotes",
+		Help: `This takes the following parameters:
+
+- remotes - list of remote names, e.g. ["drive:", "s3"] (optional, default all configured remotes)
+
+This creates each remote and returns its features so that programs
+can decide what to do, e.g. whether server-side Copy or Move, About,
+ChangeNotify, metadata or a particular hash is available.
+
+` + "```" + `
+{
+        "remotes": [
+                {
+                        "Name": "drive",  // Name of the remote in the config
+                        "Type": "drive",  // Type of the backend
+                        "Info": {
+                                // as returned by operations/fsinfo
+                        }
+                },
+                {
+                        "Name": "broken",
+                        "Type": "s3",
+                        "Error": "..."    // If the remote couldn't be created
+                }
+        ]
+}
+` + "```" + `
+
+The remotes are sorted by name and are created up to --checkers at
+once. A remote which can't be created has "Error" set instead of
+"Info" and doesn't fail the call.
+
+See the [config features](/commands/rclone_config_features/) command
+for more information on the above.
+`,
+	})
+}
+
+// Return the features of the configured remotes
+func rcFeatures(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	var remotes []string
+	err = in.GetStruct("remotes", &remotes)
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	return rc.Params{
+		"remotes": GetRemotesFeatures(ctx, remotes),
+	}, nil
+}
+
 func init() {
 	rc.Add(rc.Call{
 		Path:         "backend/command",
@@ -620,10 +684,6 @@ func rcBackend(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	if err != nil {
 		return nil, err
 	}
-	doCommand := f.Features().Command
-	if doCommand == nil {
-		return nil, fmt.Errorf("%v: doesn't support backend commands", f)
-	}
 	command, err := in.GetString("command")
 	if err != nil {
 		return nil, err
@@ -638,7 +698,14 @@ func rcBackend(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	if err != nil {
 		return nil, err
 	}
-	result, err := doCommand(ctx, command, arg, opt)
+	result, err := ResumablesCommand(ctx, f, command, opt)
+	if err == fs.ErrorCommandNotFound {
+		doCommand := f.Features().Command
+		if doCommand == nil {
+			return nil, fmt.Errorf("%v: doesn't support backend commands", f)
+		}
+		result, err = doCommand(ctx, command, arg, opt)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("command %q failed: %w", command, err)
 	}
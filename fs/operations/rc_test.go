@@ -95,6 +95,58 @@ func TestRcCopyfile(t *testing.T) {
 	r.CheckRemoteItems(t, file1)
 }
 
+// operations/batch: Run a manifest of copy, move and delete actions
+func TestRcBatch(t *testing.T) {
+	ctx := context.Background()
+	r, call := rcNewRun(t, "operations/batch")
+	file1 := r.WriteFile("file1", "file1 contents", t1)
+	file2 := r.WriteFile("file2", "file2 contents", t2)
+	r.Mkdir(ctx, r.Fremote)
+
+	in := rc.Params{
+		"srcFs":       r.LocalName,
+		"dstFs":       r.FremoteName,
+		"concurrency": 2,
+		"actions": []any{
+			map[string]any{"op": "copyfile", "srcRemote": "file1", "dstRemote": "dir/file1", "id": "copy"},
+			map[string]any{"op": "movefile", "srcRemote": "file2", "dstRemote": "file2"},
+			map[string]any{"op": "deletefile", "fs": r.LocalName, "remote": "file1", "after": []any{"copy"}},
+			map[string]any{"op": "operations/copyfile", "srcRemote": "missing", "dstRemote": "missing", "id": "fail"},
+			map[string]any{"op": "deletefile", "fs": r.FremoteName, "remote": "dir/file1", "after": []any{"fail"}},
+		},
+	}
+	out, err := call.Fn(ctx, in)
+	require.NoError(t, err)
+	assert.Equal(t, 3, out["ok"])
+	assert.Equal(t, 1, out["errors"])
+	assert.Equal(t, 1, out["skipped"])
+	results := out["results"].([]rc.Params)
+	require.Len(t, results, 5)
+	var statuses []string
+	for _, result := range results {
+		statuses = append(statuses, result["status"].(string))
+	}
+	assert.Equal(t, []string{"ok", "ok", "ok", "error", "skipped"}, statuses)
+	assert.Equal(t, "copy", results[0]["id"])
+	assert.Equal(t, "copyfile", results[3]["op"])
+	assert.Contains(t, results[4]["error"], `"fail"`)
+
+	r.CheckLocalItems(t)
+	file1.Path = "dir/file1"
+	r.CheckRemoteItems(t, file1, file2)
+
+	// Invalid manifests are rejected before anything is run
+	for _, actions := range [][]any{
+		{map[string]any{"op": "potato"}},
+		{map[string]any{"op": "mkdir", "after": []any{"nope"}}},
+		{map[string]any{"op": "mkdir", "id": "a"}, map[string]any{"op": "mkdir", "id": "a"}},
+		{map[string]any{"op": "mkdir", "id": "a", "after": []any{"b"}}, map[string]any{"op": "mkdir", "id": "b", "after": []any{"a"}}},
+	} {
+		_, err = call.Fn(ctx, rc.Params{"actions": actions})
+		assert.True(t, rc.IsErrParamInvalid(err), fmt.Sprint(actions))
+	}
+}
+
 // operations/copyurl: Copy the URL to the object
 func TestRcCopyurl(t *testing.T) {
 	r, call := rcNewRun(t, "operations/copyurl")
@@ -543,6 +595,37 @@ func TestRcFsInfo(t *testing.T) {
 
 }
 
+// operations/features: Return the features of the configured remotes
+func TestRcFeatures(t *testing.T) {
+	_, call := rcNewRun(t, "operations/features")
+	t.Setenv("RCLONE_CONFIG_FEATURESLOCAL_TYPE", "local")
+	t.Setenv("RCLONE_CONFIG_FEATURESBAD_TYPE", "nonexistentbackend")
+	in := rc.Params{
+		"remotes": []string{"featuresmissing", "featureslocal:", "featuresbad"},
+	}
+	out, err := call.Fn(context.Background(), in)
+	require.NoError(t, err)
+	got := out["remotes"].([]operations.RemoteFeatures)
+	require.Len(t, got, 3)
+
+	assert.Equal(t, "featuresbad", got[0].Name)
+	assert.Equal(t, "nonexistentbackend", got[0].Type)
+	assert.Contains(t, got[0].Error, "nonexistentbackend")
+	assert.Nil(t, got[0].Info)
+
+	assert.Equal(t, "featureslocal", got[1].Name)
+	assert.Equal(t, "local", got[1].Type)
+	assert.Equal(t, "", got[1].Error)
+	require.NotNil(t, got[1].Info)
+	assert.Equal(t, "featureslocal", got[1].Info.Name)
+	assert.True(t, got[1].Info.Features["About"])
+	assert.Contains(t, got[1].Info.Hashes, "md5")
+
+	assert.Equal(t, "featuresmissing", got[2].Name)
+	assert.Equal(t, "", got[2].Type)
+	assert.Equal(t, "remote not found in config", got[2].Error)
+}
+
 // operations/uploadfile : Tests if upload file succeeds
 func TestUploadFile(t *testing.T) {
 	r, call := rcNewRun(t, "operations/uploadfile")
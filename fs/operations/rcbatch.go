@@ -0,0 +1,336 @@
+// Running a manifest of file operations in one rc call with operations/batch
+
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/rc/jobs"
+	"golang.org/x/sync/errgroup"
+)
+
+// batchOps are the operations which can be used in operations/batch
+var batchOps = map[string]rc.Func{
+	"copyfile": func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return rcMoveOrCopyFile(ctx, in, true)
+	},
+	"movefile": func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return rcMoveOrCopyFile(ctx, in, false)
+	},
+	"deletefile": func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return rcSingleCommand(ctx, in, "deletefile", false)
+	},
+	"mkdir": func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return rcSingleCommand(ctx, in, "mkdir", false)
+	},
+	"rmdir": func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return rcSingleCommand(ctx, in, "rmdir", false)
+	},
+	"rmdirs": func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return rcSingleCommand(ctx, in, "rmdirs", false)
+	},
+	"purge": func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return rcSingleCommand(ctx, in, "purge", false)
+	},
+}
+
+// Parameters of operations/batch which are defaults for the actions
+var batchDefaults = []string{"fs", "srcFs", "dstFs"}
+
+// errBatchStopped is the error of the actions not run because the
+// batch was stopped gracefully
+var errBatchStopped = errors.New("not run as the batch was stopped")
+
+// batchAction is one of the actions of operations/batch
+type batchAction struct {
+	index int            // index in the actions
+	op    string         // name of the operation
+	id    string         // id of the action if set
+	in    rc.Params      // parameters for the operation
+	after []*batchAction // actions which must succeed before this one
+	done  chan struct{}  // closed when the action is finished
+	err   error          // error from the action, valid once done
+	skip  bool           // set if the action wasn't run
+}
+
+// name returns a description of the action for errors and logs
+func (a *batchAction) name() string {
+	if a.id != "" {
+		return fmt.Sprintf("action %q (%s)", a.id, a.op)
+	}
+	return fmt.Sprintf("action %d (%s)", a.index, a.op)
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "operations/batch",
+		AuthRequired: true,
+		Fn:           rcBatch,
+		Title:        "Run a manifest of copy, move and delete actions",
+		Help: strings.ReplaceAll(`This takes the following parameters:
+
+- actions - a list of actions to run, see below
+- concurrency - int - run this many actions at once. Defaults to |--transfers| if not set.
+- stopOnError - boolean - set to not start any more actions once one has failed
+- fs, srcFs, dstFs - defaults for the actions which don't set them
+
+Each action is an object with these keys:
+
+- op - the operation, one of |copyfile|, |movefile|, |deletefile|, |mkdir|, |rmdir|, |rmdirs| or |purge|
+- the parameters of the operation as documented for |operations/<op>|, e.g. |srcFs|, |srcRemote|, |dstFs| and |dstRemote| for |copyfile|
+- id - optional name of the action for use in |after|
+- after - optional list of ids of actions which must succeed before this one is run
+
+Actions are started in order, except that an action isn't started
+until all the actions in its |after| list have finished. If one of
+them fails the action isn't run. The |after| lists can't make a
+loop.
+
+All the actions run in the same job, so when run with |_async=true|
+there is a single job id and the progress of all the transfers can be
+read with |core/stats| for the group of the job. Stopping the job
+gracefully with |job/stop graceful=true| lets the running actions
+finish but doesn't start any more.
+
+Returns:
+
+- results - a list with an entry for each action in the same order
+  with |op|, |id| if set, |status| which is one of |ok|, |error| or
+  |skipped|, and |error| if it didn't succeed
+- ok, errors, skipped - the number of actions with each status
+
+Note that the call itself succeeds even if some of the actions failed,
+so check |errors|.
+
+For example:
+
+|||sh
+rclone rc operations/batch --json '{
+  "srcFs": "drive:",
+  "dstFs": "/backup",
+  "actions": [
+    {"op": "mkdir", "fs": "/backup", "remote": "photos", "id": "dir"},
+    {"op": "copyfile", "srcRemote": "a.jpg", "dstRemote": "photos/a.jpg", "after": ["dir"]},
+    {"op": "movefile", "srcRemote": "b.jpg", "dstRemote": "photos/b.jpg", "after": ["dir"]},
+    {"op": "deletefile", "fs": "drive:", "remote": "old.jpg"}
+  ]
+}'
+|||
+`, "|", "`"),
+	})
+}
+
+// parseBatchActions reads the actions from the parameters of
+// operations/batch linking them to the actions they run after.
+func parseBatchActions(in rc.Params) ([]*batchAction, error) {
+	actionsAny, err := in.Get("actions")
+	if err != nil {
+		return nil, err
+	}
+	inputs, ok := actionsAny.([]any)
+	if !ok {
+		return nil, rc.NewErrParamInvalid(fmt.Errorf("expecting list key %q (was %T)", "actions", actionsAny))
+	}
+	actions := make([]*batchAction, len(inputs))
+	byID := make(map[string]*batchAction)
+	afterIDs := make([][]string, len(inputs))
+	for i, inputAny := range inputs {
+		input, ok := inputAny.(map[string]any)
+		if !ok {
+			return nil, rc.NewErrParamInvalid(fmt.Errorf("action %d: must be an object not %T", i, inputAny))
+		}
+		actionIn := rc.Params(input).Copy()
+		a := &batchAction{
+			index: i,
+			in:    actionIn,
+			done:  make(chan struct{}),
+		}
+		a.op, err = actionIn.GetString("op")
+		if err != nil {
+			return nil, fmt.Errorf("action %d: %w", i, err)
+		}
+		a.op = strings.TrimPrefix(a.op, "operations/")
+		if batchOps[a.op] == nil {
+			return nil, rc.NewErrParamInvalid(fmt.Errorf("action %d: unknown op %q", i, a.op))
+		}
+		a.id, err = actionIn.GetString("id")
+		if rc.NotErrParamNotFound(err) {
+			return nil, fmt.Errorf("action %d: %w", i, err)
+		}
+		if a.id != "" {
+			if byID[a.id] != nil {
+				return nil, rc.NewErrParamInvalid(fmt.Errorf("action %d: duplicate id %q", i, a.id))
+			}
+			byID[a.id] = a
+		}
+		err = actionIn.GetStructMissingOK("after", &afterIDs[i])
+		if err != nil {
+			return nil, fmt.Errorf("action %d: %w", i, err)
+		}
+		for _, key := range []string{"op", "id", "after"} {
+			delete(actionIn, key)
+		}
+		for _, key := range batchDefaults {
+			if _, found := actionIn[key]; !found {
+				if value, found := in[key]; found {
+					actionIn[key] = value
+				}
+			}
+		}
+		actions[i] = a
+	}
+	for i, ids := range afterIDs {
+		for _, id := range ids {
+			after := byID[id]
+			if after == nil {
+				return nil, rc.NewErrParamInvalid(fmt.Errorf("%s: unknown id %q in after", actions[i].name(), id))
+			}
+			actions[i].after = append(actions[i].after, after)
+		}
+	}
+	return actions, nil
+}
+
+// batchOrder returns the actions in the order to start them, which is
+// the order they were given except actions come after the ones they
+// have to wait for.
+//
+// It returns an error if the actions wait for each other in a loop.
+func batchOrder(actions []*batchAction) ([]*batchAction, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(actions))
+	order := make([]*batchAction, 0, len(actions))
+	var visit func(a *batchAction) error
+	visit = func(a *batchAction) error {
+		switch state[a.index] {
+		case visited:
+			return nil
+		case visiting:
+			return rc.NewErrParamInvalid(fmt.Errorf("%s: after makes a loop", a.name()))
+		}
+		state[a.index] = visiting
+		for _, after := range a.after {
+			if err := visit(after); err != nil {
+				return err
+			}
+		}
+		state[a.index] = visited
+		order = append(order, a)
+		return nil
+	}
+	for _, a := range actions {
+		if err := visit(a); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Run a manifest of operations
+func rcBatch(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	actions, err := parseBatchActions(in)
+	if err != nil {
+		return nil, err
+	}
+	order, err := batchOrder(actions)
+	if err != nil {
+		return nil, err
+	}
+	concurrency, err := in.GetInt64("concurrency")
+	if rc.IsErrParamNotFound(err) {
+		concurrency = int64(fs.GetConfig(ctx).Transfers)
+	} else if err != nil {
+		return nil, err
+	}
+	stopOnError, err := in.GetBool("stopOnError")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+
+	var stopped, failed atomic.Bool
+	if job, ok := jobs.GetJob(ctx); ok {
+		defer job.OnStopGraceful(func() {
+			fs.Logf(nil, "operations/batch: stopping gracefully - waiting for running actions to finish")
+			stopped.Store(true)
+		})()
+	}
+
+	run := func(a *batchAction) {
+		defer close(a.done)
+		for _, after := range a.after {
+			<-after.done
+			if after.err != nil {
+				a.err, a.skip = fmt.Errorf("not run as %s didn't succeed", after.name()), true
+				return
+			}
+		}
+		switch {
+		case stopped.Load():
+			a.err, a.skip = errBatchStopped, true
+			return
+		case stopOnError && failed.Load():
+			a.err, a.skip = errors.New("not run as an earlier action failed"), true
+			return
+		}
+		_, a.err = batchOps[a.op](ctx, a.in)
+		if a.err != nil {
+			failed.Store(true)
+			fs.Errorf(nil, "operations/batch: %s failed: %v", a.name(), a.err)
+		}
+	}
+
+	// Actions are started in order so the ones they wait for are
+	// always running or finished, which means waiting for them can't
+	// use up all the slots.
+	var g errgroup.Group
+	g.SetLimit(max(int(concurrency), 1))
+	for _, a := range order {
+		g.Go(func() error {
+			run(a)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	results := make([]rc.Params, len(actions))
+	var nOK, nErrors, nSkipped int
+	for i, a := range actions {
+		result := rc.Params{
+			"op": a.op,
+		}
+		if a.id != "" {
+			result["id"] = a.id
+		}
+		switch {
+		case a.skip:
+			result["status"] = "skipped"
+			nSkipped++
+		case a.err != nil:
+			result["status"] = "error"
+			nErrors++
+		default:
+			result["status"] = "ok"
+			nOK++
+		}
+		if a.err != nil {
+			result["error"] = a.err.Error()
+		}
+		results[i] = result
+	}
+	return rc.Params{
+		"results": results,
+		"ok":      nOK,
+		"errors":  nErrors,
+		"skipped": nSkipped,
+	}, nil
+}
@@ -0,0 +1,377 @@
+// Resumable multi-thread uploads with --resume-uploads
+
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/lib/kv"
+)
+
+// resumeFacility is the name of the database used by --resume-uploads
+const resumeFacility = "resume-uploads"
+
+// resumeRecord is the state of a resumable upload stored in the
+// database
+type resumeRecord struct {
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"modTime"`
+	Fingerprint string    `json:"fingerprint"`
+	ChunkSize   int64     `json:"chunkSize"`
+	Chunks      []int     `json:"chunks"` // chunks written so far
+	State       []byte    `json:"state"`  // state from the ChunkWriterResumer
+	Started     time.Time `json:"started"`
+	Updated     time.Time `json:"updated"`
+}
+
+// resumeKey returns the database key for remote on f
+func resumeKey(f fs.Fs, remote string) string {
+	return f.Name() + ":" + path.Join(f.Root(), remote)
+}
+
+// resumableUpload records the progress of a multi-thread upload so it
+// can be carried on with if it is interrupted.
+type resumableUpload struct {
+	f       fs.Fs
+	remote  string
+	src     fs.Object
+	db      *kv.DB
+	key     string
+	mu      sync.Mutex
+	rec     resumeRecord
+	resumer fs.ChunkWriterResumer // set if the upload is being recorded
+}
+
+// newResumableUpload returns a resumableUpload for uploading src to
+// remote on f or nil if --resume-uploads isn't in use or the
+// destination doesn't support it.
+func newResumableUpload(ctx context.Context, f fs.Fs, remote string, src fs.Object) *resumableUpload {
+	ci := fs.GetConfig(ctx)
+	if !ci.ResumeUploads || f.Features().ResumeChunkWriter == nil || !kv.Supported() {
+		return nil
+	}
+	db, err := kv.Start(ctx, resumeFacility, f)
+	if err != nil {
+		fs.Errorf(f, "Not resuming uploads as the database failed to open: %v", err)
+		return nil
+	}
+	return &resumableUpload{
+		f:      f,
+		remote: remote,
+		src:    src,
+		db:     db,
+		key:    resumeKey(f, remote),
+	}
+}
+
+// open the chunk writer, resuming a previous upload of the same
+// source if there is one.
+func (r *resumableUpload) open(ctx context.Context, openChunkWriter fs.OpenChunkWriterFn, options ...fs.OpenOption) (info fs.ChunkWriterInfo, writer fs.ChunkWriter, err error) {
+	fingerprint := fs.Fingerprint(ctx, r.src, true)
+	rec, err := getResumeRecord(r.db, r.key)
+	if err != nil {
+		fs.Errorf(r.src, "Failed to read resumable upload: %v", err)
+	} else if rec != nil {
+		if rec.Fingerprint == fingerprint {
+			info, writer, err = r.f.Features().ResumeChunkWriter(ctx, r.remote, r.src, rec.State, options...)
+			if err == nil && info.ChunkSize != rec.ChunkSize {
+				_ = writer.Abort(ctx)
+				err = fmt.Errorf("chunk size changed from %v to %v", fs.SizeSuffix(rec.ChunkSize), fs.SizeSuffix(info.ChunkSize))
+			}
+			if err == nil {
+				fs.Infof(r.src, "Resuming upload with %d/%d chunks already written", len(rec.Chunks), calculateNumChunks(rec.Size, rec.ChunkSize))
+				r.rec = *rec
+				r.resumer, _ = writer.(fs.ChunkWriterResumer)
+				return info, writer, nil
+			}
+			fs.Infof(r.src, "Starting upload again as it can't be resumed: %v", err)
+		} else {
+			fs.Infof(r.src, "Starting upload again as the source has changed")
+			abortResumable(ctx, r.f, r.remote, rec)
+		}
+		r.remove()
+	}
+
+	info, writer, err = openChunkWriter(ctx, r.remote, r.src, options...)
+	if err != nil {
+		return info, nil, err
+	}
+	r.resumer, _ = writer.(fs.ChunkWriterResumer)
+	if r.resumer == nil {
+		fs.Debugf(r.src, "Not recording upload as the chunk writer can't be resumed")
+		return info, writer, nil
+	}
+	now := time.Now()
+	r.rec = resumeRecord{
+		Size:        r.src.Size(),
+		ModTime:     r.src.ModTime(ctx),
+		Fingerprint: fingerprint,
+		ChunkSize:   info.ChunkSize,
+		Started:     now,
+	}
+	r.save()
+	return info, writer, nil
+}
+
+// recording returns true if the upload progress is being recorded
+func (r *resumableUpload) recording() bool {
+	return r != nil && r.resumer != nil
+}
+
+// written returns true if chunk was written in a previous attempt
+func (r *resumableUpload) written(chunk int) bool {
+	if !r.recording() {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Contains(r.rec.Chunks, chunk)
+}
+
+// chunkWritten records that chunk has been written
+func (r *resumableUpload) chunkWritten(chunk int) {
+	if !r.recording() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.Chunks = append(r.rec.Chunks, chunk)
+	r.save()
+}
+
+// save the record with the current state of the chunk writer
+//
+// Call with the mutex held if the upload is in progress
+func (r *resumableUpload) save() {
+	state, err := r.resumer.ResumeState()
+	if err != nil {
+		fs.Errorf(r.src, "Failed to read resumable upload state: %v", err)
+		return
+	}
+	r.rec.State = state
+	r.rec.Updated = time.Now()
+	err = r.db.Do(true, &resumePut{key: r.key, rec: &r.rec})
+	if err != nil {
+		fs.Errorf(r.src, "Failed to save resumable upload: %v", err)
+	}
+}
+
+// remove the record
+func (r *resumableUpload) remove() {
+	err := r.db.Do(true, &resumePut{key: r.key})
+	if err != nil {
+		fs.Errorf(r.src, "Failed to remove resumable upload: %v", err)
+	}
+}
+
+// finish the upload, removing the record if it succeeded
+func (r *resumableUpload) finish(ok bool) {
+	if r == nil {
+		return
+	}
+	if ok {
+		r.remove()
+	} else if r.recording() {
+		fs.Infof(r.src, "Upload can be resumed by copying the file again with --resume-uploads")
+	}
+	if err := r.db.Stop(false); err != nil {
+		fs.Errorf(r.src, "Failed to close resumable upload database: %v", err)
+	}
+}
+
+// abortResumable aborts the upload described by rec on the server
+func abortResumable(ctx context.Context, f fs.Fs, remote string, rec *resumeRecord) {
+	src := object.NewStaticObjectInfo(remote, rec.ModTime, rec.Size, true, nil, f)
+	_, writer, err := f.Features().ResumeChunkWriter(ctx, remote, src, rec.State)
+	if err == nil {
+		err = writer.Abort(ctx)
+	}
+	if err != nil {
+		fs.Debugf(remote, "Failed to abort resumable upload: %v", err)
+	}
+}
+
+// getResumeRecord reads the record for key returning nil if there
+// isn't one.
+func getResumeRecord(db *kv.DB, key string) (*resumeRecord, error) {
+	op := &resumeGet{key: key}
+	err := db.Do(false, op)
+	if errors.Is(err, kv.ErrEmpty) {
+		return nil, nil
+	}
+	return op.rec, err
+}
+
+// ResumableUpload describes an upload which can be resumed
+type ResumableUpload struct {
+	Remote  string    `json:"remote"`  // remote path of the object being uploaded
+	Size    int64     `json:"size"`    // size of the object
+	Written int64     `json:"written"` // bytes written so far
+	Chunks  int       `json:"chunks"`  // chunks written so far
+	Total   int       `json:"total"`   // total number of chunks
+	Started time.Time `json:"started"` // when the upload started
+	Updated time.Time `json:"updated"` // when a chunk was last written
+}
+
+// listResumables calls fn for each resumable upload under the root of
+// f in the database.
+func listResumables(ctx context.Context, f fs.Fs, fn func(db *kv.DB, key, remote string, rec *resumeRecord)) error {
+	if !kv.Supported() {
+		return errors.New("resumable uploads are not supported on this OS")
+	}
+	db, err := kv.Start(ctx, resumeFacility, f)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Stop(false)
+	}()
+	op := &resumeList{prefix: f.Name() + ":" + f.Root()}
+	err = db.Do(false, op)
+	if err != nil && !errors.Is(err, kv.ErrEmpty) {
+		return err
+	}
+	for i, key := range op.keys {
+		remote := strings.TrimPrefix(strings.TrimPrefix(key, op.prefix), "/")
+		fn(db, key, remote, op.recs[i])
+	}
+	return nil
+}
+
+// ListResumableUploads returns the uploads to f which can be resumed
+// with --resume-uploads.
+func ListResumableUploads(ctx context.Context, f fs.Fs) (uploads []ResumableUpload, err error) {
+	uploads = []ResumableUpload{}
+	err = listResumables(ctx, f, func(db *kv.DB, key, remote string, rec *resumeRecord) {
+		upload := ResumableUpload{
+			Remote:  remote,
+			Size:    rec.Size,
+			Chunks:  len(rec.Chunks),
+			Total:   calculateNumChunks(rec.Size, rec.ChunkSize),
+			Started: rec.Started,
+			Updated: rec.Updated,
+		}
+		for _, chunk := range rec.Chunks {
+			upload.Written += min(rec.ChunkSize, rec.Size-int64(chunk)*rec.ChunkSize)
+		}
+		uploads = append(uploads, upload)
+	})
+	return uploads, err
+}
+
+// CleanupResumableUploads aborts the uploads to f which can be resumed
+// and which haven't been written to for maxAge.
+func CleanupResumableUploads(ctx context.Context, f fs.Fs, maxAge time.Duration) (err error) {
+	cutoff := time.Now().Add(-maxAge)
+	return listResumables(ctx, f, func(db *kv.DB, key, remote string, rec *resumeRecord) {
+		if rec.Updated.After(cutoff) {
+			fs.Debugf(remote, "Keeping resumable upload as updated %v ago", time.Since(rec.Updated).Truncate(time.Second))
+			return
+		}
+		if SkipDestructive(ctx, remote, "abort resumable upload") {
+			return
+		}
+		if f.Features().ResumeChunkWriter != nil {
+			abortResumable(ctx, f, remote, rec)
+		}
+		if err := db.Do(true, &resumePut{key: key}); err != nil {
+			fs.Errorf(remote, "Failed to remove resumable upload: %v", err)
+			return
+		}
+		fs.Infof(remote, "Aborted resumable upload")
+	})
+}
+
+// resumeGet reads a record from the database
+type resumeGet struct {
+	key string
+	rec *resumeRecord
+}
+
+// Do the get
+func (op *resumeGet) Do(ctx context.Context, b kv.Bucket) error {
+	data := b.Get([]byte(op.key))
+	if data == nil {
+		return nil
+	}
+	rec := new(resumeRecord)
+	if err := json.Unmarshal(data, rec); err != nil {
+		return fmt.Errorf("corrupt record for %q: %w", op.key, err)
+	}
+	op.rec = rec
+	return nil
+}
+
+// resumePut writes a record to the database, removing it if rec is nil
+type resumePut struct {
+	key string
+	rec *resumeRecord
+}
+
+// Do the put
+func (op *resumePut) Do(ctx context.Context, b kv.Bucket) error {
+	if op.rec == nil {
+		return b.Delete([]byte(op.key))
+	}
+	data, err := json.Marshal(op.rec)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(op.key), data)
+}
+
+// resumeList reads the records with keys starting with prefix
+type resumeList struct {
+	prefix string
+	keys   []string
+	recs   []*resumeRecord
+}
+
+// Do the list
+func (op *resumeList) Do(ctx context.Context, b kv.Bucket) error {
+	c := b.Cursor()
+	for k, v := c.Seek([]byte(op.prefix)); k != nil && strings.HasPrefix(string(k), op.prefix); k, v = c.Next() {
+		key := string(k)
+		if rest := key[len(op.prefix):]; op.prefix[len(op.prefix)-1] != ':' && rest != "" && rest[0] != '/' {
+			continue // not in the directory
+		}
+		rec := new(resumeRecord)
+		if err := json.Unmarshal(v, rec); err != nil {
+			fs.Debugf(nil, "Ignoring corrupt resumable upload %q: %v", key, err)
+			continue
+		}
+		op.keys = append(op.keys, key)
+		op.recs = append(op.recs, rec)
+	}
+	return nil
+}
+
+// ResumablesCommand runs the backend commands for resumable uploads
+// which work on any remote, returning fs.ErrorCommandNotFound if name
+// isn't one of them.
+func ResumablesCommand(ctx context.Context, f fs.Fs, name string, opt map[string]string) (out any, err error) {
+	switch name {
+	case "list-resumables":
+		return ListResumableUploads(ctx, f)
+	case "cleanup-resumables":
+		var maxAge time.Duration
+		if opt["max-age"] != "" {
+			maxAge, err = fs.ParseDuration(opt["max-age"])
+			if err != nil {
+				return nil, fmt.Errorf("bad max-age: %w", err)
+			}
+		}
+		return nil, CleanupResumableUploads(ctx, f, maxAge)
+	}
+	return nil, fs.ErrorCommandNotFound
+}
@@ -0,0 +1,205 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/rclone/rclone/lib/kv"
+	"github.com/rclone/rclone/lib/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resumeTestFs is an Fs with a ChunkWriter which can be resumed
+type resumeTestFs struct {
+	*mockfs.Fs
+	features  *fs.Features
+	chunkSize int64
+	mu        sync.Mutex
+	uploads   map[string]map[int][]byte // chunks of each upload by ID
+	written   int                       // number of chunks written
+	failChunk int                       // chunk to fail writing or -1
+}
+
+func newResumeTestFs(ctx context.Context, t *testing.T) *resumeTestFs {
+	mf, err := mockfs.NewFs(ctx, "resume-test", "bucket/dir", nil)
+	require.NoError(t, err)
+	f := &resumeTestFs{
+		Fs:        mf.(*mockfs.Fs),
+		chunkSize: 100,
+		uploads:   map[string]map[int][]byte{},
+		failChunk: -1,
+	}
+	f.features = (&fs.Features{}).Fill(ctx, f)
+	return f
+}
+
+func (f *resumeTestFs) Features() *fs.Features {
+	return f.features
+}
+
+func (f *resumeTestFs) OpenChunkWriter(ctx context.Context, remote string, src fs.ObjectInfo, options ...fs.OpenOption) (info fs.ChunkWriterInfo, writer fs.ChunkWriter, err error) {
+	f.mu.Lock()
+	id := fmt.Sprintf("upload-%d", len(f.uploads))
+	f.uploads[id] = map[int][]byte{}
+	f.mu.Unlock()
+	return f.ResumeChunkWriter(ctx, remote, src, []byte(id), options...)
+}
+
+func (f *resumeTestFs) ResumeChunkWriter(ctx context.Context, remote string, src fs.ObjectInfo, state []byte, options ...fs.OpenOption) (info fs.ChunkWriterInfo, writer fs.ChunkWriter, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := string(state)
+	if _, ok := f.uploads[id]; !ok {
+		return info, nil, fmt.Errorf("upload %q not found", id)
+	}
+	info = fs.ChunkWriterInfo{
+		ChunkSize:   f.chunkSize,
+		Concurrency: 1,
+	}
+	return info, &resumeTestWriter{f: f, id: id, remote: remote, size: src.Size()}, nil
+}
+
+type resumeTestWriter struct {
+	f      *resumeTestFs
+	id     string
+	remote string
+	size   int64
+}
+
+func (w *resumeTestWriter) WriteChunk(ctx context.Context, chunkNumber int, reader io.ReadSeeker) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return -1, err
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return -1, err
+	}
+	w.f.mu.Lock()
+	defer w.f.mu.Unlock()
+	if chunkNumber == w.f.failChunk {
+		return -1, errors.New("BOOM: simulated write failure")
+	}
+	w.f.uploads[w.id][chunkNumber] = data
+	w.f.written++
+	return int64(len(data)), nil
+}
+
+func (w *resumeTestWriter) ResumeState() ([]byte, error) {
+	return []byte(w.id), nil
+}
+
+func (w *resumeTestWriter) Close(ctx context.Context) error {
+	w.f.mu.Lock()
+	defer w.f.mu.Unlock()
+	var data []byte
+	chunks := w.f.uploads[w.id]
+	for i := range len(chunks) {
+		data = append(data, chunks[i]...)
+	}
+	if int64(len(data)) != w.size {
+		return fmt.Errorf("upload %q has %d bytes, want %d", w.id, len(data), w.size)
+	}
+	delete(w.f.uploads, w.id)
+	w.f.AddObject(mockobject.New(w.remote).WithContent(data, mockobject.SeekModeNone))
+	return nil
+}
+
+func (w *resumeTestWriter) Abort(ctx context.Context) error {
+	w.f.mu.Lock()
+	defer w.f.mu.Unlock()
+	delete(w.f.uploads, w.id)
+	return nil
+}
+
+func TestMultithreadCopyResume(t *testing.T) {
+	if !kv.Supported() {
+		t.Skip("kv not supported")
+	}
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.ResumeUploads = true
+	f := newResumeTestFs(ctx, t)
+
+	// Hold the database open between the copies as it is dropped
+	// when it is opened in tests
+	db, err := kv.Start(ctx, resumeFacility, f)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, db.Stop(true))
+	}()
+
+	fsrc, err := mockfs.NewFs(ctx, "resume-src", "", nil)
+	require.NoError(t, err)
+	contents := []byte(random.String(350))
+	src := mockobject.New("file.bin").WithContent(contents, mockobject.SeekModeNone)
+	src.SetFs(fsrc)
+
+	copyFile := func() (fs.Object, error) {
+		tr := accounting.GlobalStats().NewTransfer(src, nil)
+		dst, err := multiThreadCopy(ctx, f, "file.bin", src, 1, tr)
+		tr.Done(ctx, err)
+		return dst, err
+	}
+
+	// First attempt fails on the third chunk
+	f.failChunk = 2
+	_, err = copyFile()
+	require.Error(t, err)
+	assert.Equal(t, 2, f.written)
+	assert.Len(t, f.uploads, 1, "upload should not be aborted")
+
+	uploads, err := ListResumableUploads(ctx, f)
+	require.NoError(t, err)
+	require.Len(t, uploads, 1)
+	assert.Equal(t, "file.bin", uploads[0].Remote)
+	assert.Equal(t, int64(350), uploads[0].Size)
+	assert.Equal(t, int64(200), uploads[0].Written)
+	assert.Equal(t, 2, uploads[0].Chunks)
+	assert.Equal(t, 4, uploads[0].Total)
+
+	// Second attempt only writes the missing chunks
+	f.failChunk = -1
+	f.written = 0
+	dst, err := copyFile()
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.written)
+	assert.Len(t, f.uploads, 0)
+	assert.Equal(t, int64(len(contents)), dst.Size())
+	in, err := dst.Open(ctx)
+	require.NoError(t, err)
+	got, err := io.ReadAll(in)
+	require.NoError(t, err)
+	assert.Equal(t, contents, got)
+
+	uploads, err = ListResumableUploads(ctx, f)
+	require.NoError(t, err)
+	assert.Len(t, uploads, 0)
+
+	// Leave an upload behind then clean it up
+	f.failChunk = 1
+	_, err = copyFile()
+	require.Error(t, err)
+	assert.Len(t, f.uploads, 1)
+	out, err := ResumablesCommand(ctx, f, "cleanup-resumables", map[string]string{"max-age": "1h"})
+	require.NoError(t, err)
+	assert.Nil(t, out)
+	assert.Len(t, f.uploads, 1, "recent upload should be kept")
+	_, err = ResumablesCommand(ctx, f, "cleanup-resumables", nil)
+	require.NoError(t, err)
+	assert.Len(t, f.uploads, 0)
+	uploads, err = ListResumableUploads(ctx, f)
+	require.NoError(t, err)
+	assert.Len(t, uploads, 0)
+
+	_, err = ResumablesCommand(ctx, f, "potato", nil)
+	assert.Equal(t, fs.ErrorCommandNotFound, err)
+}
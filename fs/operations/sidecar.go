@@ -0,0 +1,237 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/object"
+)
+
+// SidecarExtension returns the file extension of checksum sidecars
+// for hashType, e.g. ".sha256"
+func SidecarExtension(hashType hash.Type) string {
+	return "." + hashType.String()
+}
+
+// sidecarHash returns the hash type of the checksum sidecars set
+// with --checksum-sidecar or hash.None if they aren't in use
+func sidecarHash(ctx context.Context) hash.Type {
+	ci := fs.GetConfig(ctx)
+	if ci.ChecksumSidecar == "" {
+		return hash.None
+	}
+	var ht hash.Type
+	if err := ht.Set(ci.ChecksumSidecar); err != nil {
+		return hash.None
+	}
+	return ht
+}
+
+// IsChecksumSidecar returns true if entry is a checksum sidecar file
+// written by --checksum-sidecar
+func IsChecksumSidecar(ctx context.Context, entry fs.DirEntry) bool {
+	ht := sidecarHash(ctx)
+	if ht == hash.None {
+		return false
+	}
+	_, isObject := entry.(fs.ObjectInfo)
+	return isObject && strings.HasSuffix(entry.Remote(), SidecarExtension(ht))
+}
+
+// sidecarLine makes the contents of the checksum sidecar for remote
+// in the same format as sha256sum and friends so it can be checked
+// with them too.
+func sidecarLine(sum, remote string) []byte {
+	return []byte(sum + "  " + path.Base(remote) + "\n")
+}
+
+// sidecarObject returns the checksum sidecar of o or nil if it
+// doesn't have one
+func sidecarObject(ctx context.Context, o fs.Object, ht hash.Type) (fs.Object, error) {
+	f, ok := o.Fs().(fs.Fs)
+	if !ok {
+		return nil, nil
+	}
+	sidecar, err := f.NewObject(ctx, o.Remote()+SidecarExtension(ht))
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		return nil, nil
+	}
+	return sidecar, err
+}
+
+// readSidecar reads the checksum out of the sidecar
+func readSidecar(ctx context.Context, sidecar fs.Object) (string, error) {
+	sums, err := ParseSumFile(ctx, sidecar)
+	if err != nil {
+		return "", err
+	}
+	for _, sum := range sums {
+		return sum, nil
+	}
+	return "", errors.New("no checksum found")
+}
+
+// putSidecar writes sum for the object at remote to its checksum
+// sidecar in f, replacing any sidecar already there
+func putSidecar(ctx context.Context, f fs.Fs, remote string, sum string, ht hash.Type) (err error) {
+	sidecarRemote := remote + SidecarExtension(ht)
+	data := sidecarLine(sum, remote)
+	info := object.NewStaticObjectInfo(sidecarRemote, time.Now(), int64(len(data)), true, nil, f)
+	existing, err := f.NewObject(ctx, sidecarRemote)
+	switch {
+	case err == nil:
+		err = existing.Update(ctx, bytes.NewReader(data), info)
+	case errors.Is(err, fs.ErrorObjectNotFound):
+		_, err = f.Put(ctx, bytes.NewReader(data), info)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write checksum sidecar: %w", err)
+	}
+	fs.Debugf(sidecarRemote, "Wrote checksum sidecar %s = %s", ht, sum)
+	return nil
+}
+
+// writeSidecar writes the checksum sidecar for dst in f which was
+// copied from src if --checksum-sidecar is in use.
+//
+// The checksum is taken from src if it has one, then from dst,
+// otherwise dst is read back to work it out.
+func writeSidecar(ctx context.Context, f fs.Fs, src fs.ObjectInfo, dst fs.Object) error {
+	ht := sidecarHash(ctx)
+	if ht == hash.None || IsChecksumSidecar(ctx, dst) {
+		return nil
+	}
+	sum, _ := src.Hash(ctx, ht)
+	if sum == "" {
+		sum, _ = dst.Hash(ctx, ht)
+	}
+	if sum == "" {
+		in, err := Open(ctx, dst)
+		if err != nil {
+			return fmt.Errorf("failed to read file for checksum sidecar: %w", err)
+		}
+		tr := accounting.Stats(ctx).NewCheckingTransfer(dst, "hashing")
+		sums, err := hash.StreamTypes(in, hash.NewHashSet(ht))
+		fs.CheckClose(in, &err)
+		tr.Done(ctx, err)
+		if err != nil {
+			return fmt.Errorf("failed to hash file for checksum sidecar: %w", err)
+		}
+		sum = sums[ht]
+	}
+	return putSidecar(ctx, f, dst.Remote(), sum, ht)
+}
+
+// moveSidecar moves the checksum sidecar of src, if it has one, to
+// be the sidecar of newDst in f.
+//
+// The sidecar is rewritten rather than moved as it contains the name
+// of the file.
+func moveSidecar(ctx context.Context, f fs.Fs, src fs.Object, newDst fs.Object) error {
+	ht := sidecarHash(ctx)
+	if ht == hash.None || IsChecksumSidecar(ctx, src) {
+		return nil
+	}
+	sidecar, err := sidecarObject(ctx, src, ht)
+	if err != nil || sidecar == nil {
+		return err
+	}
+	sum, err := readSidecar(ctx, sidecar)
+	if err != nil {
+		return fmt.Errorf("failed to read checksum sidecar: %w", err)
+	}
+	err = putSidecar(ctx, f, newDst.Remote(), sum, ht)
+	if err != nil {
+		return err
+	}
+	return DeleteFile(ctx, sidecar)
+}
+
+// deleteSidecar deletes the checksum sidecar of dst, if it has one,
+// in the same way as dst was deleted.
+func deleteSidecar(ctx context.Context, dst fs.Object, backupDir fs.Fs) error {
+	ht := sidecarHash(ctx)
+	if ht == hash.None || IsChecksumSidecar(ctx, dst) {
+		return nil
+	}
+	sidecar, err := sidecarObject(ctx, dst, ht)
+	if err != nil || sidecar == nil {
+		return err
+	}
+	return DeleteFileWithBackupDir(ctx, sidecar, backupDir)
+}
+
+// CheckSidecars checks the files in fdst against their checksum
+// sidecars of hashType.
+//
+// Files without a sidecar are reported as missing on the source and
+// sidecars without a file as missing on the destination.
+func CheckSidecars(ctx context.Context, fdst fs.Fs, hashType hash.Type, opt *CheckOpt, download bool) error {
+	var options CheckOpt
+	if opt != nil {
+		options = *opt
+	} else {
+		options.Combined = os.Stdout
+	}
+	options.Fsrc = nil // the sidecars are the source
+	options.Fdst = fdst
+	opt = &options
+
+	if hashType == hash.None {
+		return errors.New("need a hash type to check sidecars")
+	}
+	if !download && !opt.Fdst.Hashes().Contains(hashType) {
+		return fmt.Errorf("%s: hash type is not supported by file system: %s - use --download", hashType, opt.Fdst)
+	}
+
+	// Sort the files from the sidecars
+	ext := SidecarExtension(hashType)
+	var (
+		mu       sync.Mutex
+		objs     []fs.Object
+		sidecars []fs.Object
+	)
+	lastErr := ListFn(ctx, fdst, func(o fs.Object) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasSuffix(o.Remote(), ext) {
+			sidecars = append(sidecars, o)
+		} else {
+			objs = append(objs, o)
+		}
+	})
+	hashes := HashSums{}
+	for _, sidecar := range sidecars {
+		sum, err := readSidecar(ctx, sidecar)
+		if err != nil {
+			err = fmt.Errorf("failed to read checksum sidecar: %w", err)
+			fs.Errorf(sidecar, "%v", err)
+			_ = fs.CountError(ctx, err)
+			lastErr = err
+			continue
+		}
+		hashes[ApplyTransforms(ctx, strings.TrimSuffix(sidecar.Remote(), ext))] = sum
+	}
+
+	ci := fs.GetConfig(ctx)
+	c := &checkMarch{
+		ctx:    ctx,
+		tokens: make(chan struct{}, ci.Checkers),
+		opt:    *opt,
+	}
+	for _, o := range objs {
+		c.checkSum(ctx, o, download, hashes, hashType)
+	}
+	c.wg.Wait() // wait for background go-routines
+	return c.reportUnusedSums(ctx, hashes, lastErr)
+}
@@ -0,0 +1,91 @@
+package operations_test
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// remoteNames returns the sorted names of all the files in f
+func remoteNames(ctx context.Context, t *testing.T, f fs.Fs) (names []string) {
+	var mu sync.Mutex
+	require.NoError(t, operations.ListFn(ctx, f, func(o fs.Object) {
+		mu.Lock()
+		names = append(names, o.Remote())
+		mu.Unlock()
+	}))
+	sort.Strings(names)
+	return names
+}
+
+// readRemote reads the file at remote in f
+func readRemote(ctx context.Context, t *testing.T, f fs.Fs, remote string) string {
+	o, err := f.NewObject(ctx, remote)
+	require.NoError(t, err)
+	data, err := operations.ReadFile(ctx, o)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestIsChecksumSidecar(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	assert.False(t, operations.IsChecksumSidecar(ctx, mockobject.New("file.sha256")))
+	ci.ChecksumSidecar = "sha256"
+	assert.True(t, operations.IsChecksumSidecar(ctx, mockobject.New("dir/file.sha256")))
+	assert.False(t, operations.IsChecksumSidecar(ctx, mockobject.New("dir/file.md5")))
+	assert.False(t, operations.IsChecksumSidecar(ctx, fs.NewDir("dir.sha256", t1)))
+}
+
+func TestChecksumSidecar(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.ChecksumSidecar = "md5"
+	r := fstest.NewRun(t)
+	const md5sum = "5d41402abc4b2a76b9719d911017c592" // of "hello"
+
+	// Copy writes the sidecar
+	file1 := r.WriteFile("sub/file1", "hello", t1)
+	src, err := r.Flocal.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	dst, err := operations.Copy(ctx, r.Fremote, nil, "sub/file1", src)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sub/file1", "sub/file1.md5"}, remoteNames(ctx, t, r.Fremote))
+	assert.Equal(t, md5sum+"  file1\n", readRemote(ctx, t, r.Fremote, "sub/file1.md5"))
+
+	// Verify against the sidecars
+	check := func() error {
+		opt := operations.CheckOpt{Combined: new(bytes.Buffer)}
+		return operations.CheckSidecars(ctx, r.Fremote, hash.MD5, &opt, true)
+	}
+	require.NoError(t, check())
+
+	// Move rewrites the sidecar for the new name
+	if operations.CanServerSideMove(r.Fremote) {
+		dst, err = operations.Move(ctx, r.Fremote, nil, "sub/file2", dst)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"sub/file2", "sub/file2.md5"}, remoteNames(ctx, t, r.Fremote))
+		assert.Equal(t, md5sum+"  file2\n", readRemote(ctx, t, r.Fremote, "sub/file2.md5"))
+		require.NoError(t, check())
+	}
+
+	// Changing the file without its sidecar is found by the check
+	r.WriteObject(ctx, dst.Remote(), "potato", t2)
+	assert.Error(t, check())
+
+	// Delete removes the sidecar too
+	dst, err = r.Fremote.NewObject(ctx, dst.Remote())
+	require.NoError(t, err)
+	require.NoError(t, operations.DeleteFile(ctx, dst))
+	assert.Equal(t, []string(nil), remoteNames(ctx, t, r.Fremote))
+}
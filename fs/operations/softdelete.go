@@ -0,0 +1,113 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/lib/errcount"
+	"golang.org/x/sync/errgroup"
+)
+
+// softDeleteNeeded returns true if files should be soft deleted with
+// the backend's SoftDelete feature rather than removed.
+//
+// This is the case if --delete-mode marker is set.
+func softDeleteNeeded(ctx context.Context) bool {
+	return fs.GetConfig(ctx).DeleteAction == fs.DeleteActionMarker
+}
+
+// SoftDelete removes dst from the current view of the remote it is on
+// while keeping its history, for example by adding a delete marker on
+// a versioned bucket.
+//
+// It returns a fatal error if the backend can't keep the history of
+// deleted files so a sync stops rather than deleting them for good.
+func SoftDelete(ctx context.Context, dst fs.Object) error {
+	f := dst.Fs()
+	if f == nil {
+		return errors.New("internal error: can't find Fs to soft delete from")
+	}
+	softDelete := f.Features().SoftDelete
+	if softDelete == nil {
+		return fserrors.FatalError(fmt.Errorf("can't use --delete-mode marker on %v as it can't keep the history of deleted files", f))
+	}
+	return softDelete(ctx, dst)
+}
+
+// PurgeDeleted permanently deletes deleted, as returned by
+// ListDeleted, so it can't be restored any more. Any delete marker and
+// old versions of the file are deleted too.
+func PurgeDeleted(ctx context.Context, f fs.Fs, deleted fs.DeletedObject) (err error) {
+	if SkipDestructive(ctx, deleted.Remote, "purge deleted file") {
+		return nil
+	}
+	if strings.HasPrefix(deleted.ID, fs.TrashDir+"/") {
+		err = purgeFromTrash(ctx, f, deleted)
+	} else if doPurge := f.Features().PurgeDeleted; doPurge != nil {
+		err = doPurge(ctx, deleted)
+	} else {
+		err = fmt.Errorf("can't purge deleted files from %v: %w", f, fs.ErrorNotImplemented)
+	}
+	if err != nil {
+		err = fs.CountError(ctx, err)
+		fs.Errorf(deleted.Remote, "Failed to purge deleted file: %v", err)
+		return err
+	}
+	fs.Infof(deleted.Remote, "Purged deleted file")
+	return nil
+}
+
+// purgeFromTrash deletes the copy of deleted in fs.TrashDir
+func purgeFromTrash(ctx context.Context, f fs.Fs, deleted fs.DeletedObject) error {
+	ctx = WithoutTrash(ctx)
+	o, err := f.NewObject(ctx, deleted.ID)
+	if err != nil {
+		return err
+	}
+	return o.Remove(ctx)
+}
+
+// PurgeDeletedAll permanently deletes the files in f found by
+// ListDeleted which were deleted longer ago than retention.
+//
+// Files for which it isn't known when they were deleted are kept.
+func PurgeDeletedAll(ctx context.Context, f fs.Fs, retention time.Duration) error {
+	features := f.Features()
+	if features.ListDeleted != nil && features.PurgeDeleted == nil {
+		return fmt.Errorf("can't purge deleted files from %v - use rclone cleanup to empty its trash: %w", f, fs.ErrorNotImplemented)
+	}
+	cutoff := time.Now().Add(-retention)
+	var expired []fs.DeletedObject
+	err := ListDeleted(ctx, f, func(deleted fs.DeletedObject) error {
+		if deleted.DeletedAt.IsZero() || deleted.DeletedAt.After(cutoff) {
+			fs.Debugf(deleted.Remote, "Keeping deleted file as it was deleted less than %v ago or when isn't known", retention)
+			return nil
+		}
+		expired = append(expired, deleted)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(expired, func(a, b fs.DeletedObject) int {
+		return strings.Compare(a.Remote, b.Remote)
+	})
+	ci := fs.GetConfig(ctx)
+	errCount := errcount.New()
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(ci.Checkers)
+	for _, deleted := range expired {
+		g.Go(func() error {
+			errCount.Add(PurgeDeleted(gCtx, f, deleted))
+			return nil // don't return errors, just count them
+		})
+	}
+	_ = g.Wait()
+	return errCount.Err("failed to purge deleted files")
+}
@@ -0,0 +1,125 @@
+package operations_test
+
+import (
+	"context"
+	"errors"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// removeObject is a mock object which records when it is removed
+type removeObject struct {
+	*mockobject.ContentMockObject
+	removed *[]string
+}
+
+// Remove records the object as removed
+func (o removeObject) Remove(ctx context.Context) error {
+	*o.removed = append(*o.removed, o.Remote())
+	return nil
+}
+
+func TestDeleteFileSoftDelete(t *testing.T) {
+	ctx, ci := fs.AddConfig(context.Background())
+	f, err := mockfs.NewFs(ctx, "softdelete", "", nil)
+	require.NoError(t, err)
+	var removed, softDeleted []string
+	objs := map[string]fs.Object{}
+	for _, remote := range []string{"one", "two"} {
+		o := mockobject.New(remote).WithContent([]byte(remote), mockobject.SeekModeNone)
+		o.SetFs(f)
+		objs[remote] = removeObject{ContentMockObject: o, removed: &removed}
+		f.(*mockfs.Fs).AddObject(objs[remote])
+	}
+	ci.DeleteAction = fs.DeleteActionMarker
+
+	// Files aren't deleted if the backend can't keep them
+	err = operations.DeleteFile(ctx, objs["one"])
+	assert.True(t, fserrors.IsFatalError(err), err)
+	assert.ErrorContains(t, err, "can't use --delete-mode marker")
+	assert.Empty(t, removed)
+
+	f.Features().SoftDelete = func(ctx context.Context, o fs.Object) error {
+		softDeleted = append(softDeleted, o.Remote())
+		return nil
+	}
+
+	// Deletes in a sync and rclone delete are soft deletes
+	require.NoError(t, operations.Delete(ctx, f))
+	assert.ElementsMatch(t, []string{"one", "two"}, softDeleted)
+	assert.Empty(t, removed)
+
+	// WithoutTrash turns off --delete-mode marker as well as
+	// --use-trash so the file is removed
+	require.NoError(t, operations.DeleteFile(operations.WithoutTrash(ctx), objs["one"]))
+	assert.Equal(t, []string{"one"}, removed)
+	assert.ElementsMatch(t, []string{"one", "two"}, softDeleted)
+	assert.Equal(t, fs.DeleteActionMarker, ci.DeleteAction)
+	accounting.GlobalStats().ResetCounters()
+}
+
+func TestPurgeDeletedAll(t *testing.T) {
+	ctx := context.Background()
+	f, err := mockfs.NewFs(ctx, "purgedeleted", "", nil)
+	require.NoError(t, err)
+
+	now := time.Now()
+	var purged []string
+	f.Features().ListDeleted = func(ctx context.Context, dir string, fn func(fs.DeletedObject) error) error {
+		for _, deleted := range []fs.DeletedObject{
+			{Remote: "old.txt", DeletedAt: now.Add(-48 * time.Hour), ID: "old"},
+			{Remote: "new.txt", DeletedAt: now.Add(-time.Hour), ID: "new"},
+			{Remote: "unknown.txt", ID: "unknown"},
+			{Remote: "fail.txt", DeletedAt: now.Add(-72 * time.Hour), ID: "fail"},
+		} {
+			if err := fn(deleted); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Purging needs the PurgeDeleted feature for files the backend deleted
+	err = operations.PurgeDeletedAll(ctx, f, 24*time.Hour)
+	assert.ErrorIs(t, err, fs.ErrorNotImplemented)
+
+	f.Features().PurgeDeleted = func(ctx context.Context, deleted fs.DeletedObject) error {
+		if deleted.ID == "fail" {
+			return errors.New("BOOM")
+		}
+		purged = append(purged, deleted.ID)
+		return nil
+	}
+	err = operations.PurgeDeletedAll(ctx, f, 24*time.Hour)
+	assert.ErrorContains(t, err, "failed to purge deleted files: BOOM")
+	assert.Equal(t, []string{"old"}, purged)
+
+	// Nothing is purged with --dry-run
+	purged = nil
+	ctx, ci := fs.AddConfig(ctx)
+	ci.DryRun = true
+	require.NoError(t, operations.PurgeDeletedAll(ctx, f, 0))
+	assert.Nil(t, purged)
+	accounting.GlobalStats().ResetCounters()
+}
+
+func TestPurgeDeletedFromTrash(t *testing.T) {
+	ctx, _, r := newTrashRun(t)
+	file1 := r.WriteObject(ctx, "one", "one", t1)
+	old := r.WriteObject(ctx, path.Join(fs.TrashDir, "2000-01-01", "old"), "old", t1)
+	require.NoError(t, operations.Delete(ctx, r.Fremote))
+	r.CheckRemoteItems(t, trashed(file1), old)
+
+	require.NoError(t, operations.PurgeDeletedAll(ctx, r.Fremote, 7*24*time.Hour))
+	r.CheckRemoteItems(t, trashed(file1))
+}
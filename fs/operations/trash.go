@@ -0,0 +1,122 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fspath"
+)
+
+// trashDateFormat is the format of the directories in fs.TrashDir
+// which the files deleted on each day are moved into
+const trashDateFormat = "2006-01-02"
+
+// trashNeeded returns true if remote in f should be moved into
+// fs.TrashDir rather than deleted.
+//
+// This is the case if --use-trash is set, the backend doesn't have
+// a trash of its own and remote isn't in the trash already.
+func trashNeeded(ctx context.Context, f fs.Info, remote string) bool {
+	if !fs.GetConfig(ctx).UseTrash || f == nil || f.Features().NativeTrash {
+		return false
+	}
+	if _, ok := f.(fs.Fs); !ok {
+		return false
+	}
+	return !slices.Contains(strings.Split(path.Join(f.Root(), remote), "/"), fs.TrashDir)
+}
+
+// WithoutTrash returns a context in which files are deleted rather
+// than moved into the trash by --use-trash or soft deleted by
+// --delete-mode marker.
+//
+// Use this for deleting the source of a move as the file hasn't been
+// lost.
+func WithoutTrash(ctx context.Context) context.Context {
+	if ci := fs.GetConfig(ctx); !ci.UseTrash && ci.DeleteAction == fs.DeleteActionDelete {
+		return ctx
+	}
+	ctx, ci := fs.AddConfig(ctx)
+	ci.UseTrash = false
+	ci.DeleteAction = fs.DeleteActionDelete
+	return ctx
+}
+
+// trashFor returns the Fs in fs.TrashDir in the root of f that
+// files deleted today are moved into.
+func trashFor(ctx context.Context, f fs.Fs) (trash fs.Fs, err error) {
+	if !CanServerSideMove(f) {
+		return nil, fserrors.FatalError(fmt.Errorf("can't use --use-trash on %v as it doesn't support server-side move or copy", f))
+	}
+	dir := path.Join(fs.TrashDir, time.Now().UTC().Format(trashDateFormat))
+	trash, err = cache.Get(ctx, fspath.JoinRootPath(fs.ConfigString(f), dir))
+	if err != nil && err != fs.ErrorIsFile {
+		return nil, fmt.Errorf("failed to make fs for --use-trash: %w", err)
+	}
+	return trash, nil
+}
+
+// MoveToTrash moves dst into fs.TrashDir in the root of the remote it
+// is on, replacing any file of the same name deleted earlier the same
+// day.
+func MoveToTrash(ctx context.Context, dst fs.Object) (err error) {
+	f, ok := dst.Fs().(fs.Fs)
+	if !ok {
+		return errors.New("internal error: can't find Fs to move into trash")
+	}
+	trash, err := trashFor(ctx, f)
+	if err != nil {
+		return err
+	}
+	overwritten, _ := trash.NewObject(ctx, dst.Remote())
+	_, err = Move(ctx, trash, overwritten, dst.Remote(), dst)
+	return err
+}
+
+// purgeTrash purges the directories in fs.TrashDir in the root of f
+// holding files deleted longer ago than --trash-retention.
+func purgeTrash(ctx context.Context, f fs.Fs) error {
+	ci := fs.GetConfig(ctx)
+	// Delete the trash for real
+	ctx = WithoutTrash(ctx)
+
+	entries, err := f.List(ctx, fs.TrashDir)
+	if errors.Is(err, fs.ErrorDirNotFound) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to list %s: %w", fs.TrashDir, err)
+	}
+	cutoff := time.Now().Add(-time.Duration(ci.TrashRetention))
+	var errs []error
+	for _, entry := range entries {
+		dir, ok := entry.(fs.Directory)
+		if !ok {
+			continue
+		}
+		deleted, err := time.Parse(trashDateFormat, path.Base(dir.Remote()))
+		if err != nil {
+			fs.Debugf(dir, "Ignoring directory in %s which isn't named by date", fs.TrashDir)
+			continue
+		}
+		// Keep the whole day until the last file deleted on it
+		// expires unless keeping nothing
+		if ci.TrashRetention > 0 && deleted.Add(24*time.Hour).After(cutoff) {
+			continue
+		}
+		fs.Infof(dir, "Purging files deleted more than %v ago", ci.TrashRetention)
+		errs = append(errs, Purge(ctx, f, dir.Remote()))
+	}
+	err = errors.Join(errs...)
+	if err == nil {
+		_ = TryRmdir(ctx, f, fs.TrashDir)
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package operations_test
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trashed returns the item as it is in the trash after being deleted today
+func trashed(item fstest.Item) fstest.Item {
+	item.Path = path.Join(fs.TrashDir, time.Now().UTC().Format("2006-01-02"), item.Path)
+	return item
+}
+
+func newTrashRun(t *testing.T) (context.Context, *fs.ConfigInfo, *fstest.Run) {
+	ctx, ci := fs.AddConfig(context.Background())
+	r := fstest.NewRun(t)
+	if !operations.CanServerSideMove(r.Fremote) || r.Fremote.Features().NativeTrash {
+		t.Skip("Skipping test as remote doesn't support server-side move or has its own trash")
+	}
+	ci.UseTrash = true
+	return ctx, ci, r
+}
+
+func TestDeleteFileUseTrash(t *testing.T) {
+	ctx, _, r := newTrashRun(t)
+	file1 := r.WriteObject(ctx, "dir/one", "one", t1)
+	file2 := r.WriteObject(ctx, "two", "two", t2)
+
+	obj, err := r.Fremote.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	require.NoError(t, operations.DeleteFile(ctx, obj))
+	r.CheckRemoteItems(t, trashed(file1), file2)
+
+	// Deleting it again the same day replaces the trashed copy
+	file1b := r.WriteObject(ctx, "dir/one", "one again", t2)
+	obj, err = r.Fremote.NewObject(ctx, file1b.Path)
+	require.NoError(t, err)
+	require.NoError(t, operations.DeleteFile(ctx, obj))
+	r.CheckRemoteItems(t, trashed(file1b), file2)
+
+	// The trash isn't listed or deleted with --use-trash
+	objects, _, _, err := operations.Count(ctx, r.Fremote)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), objects)
+	require.NoError(t, operations.Delete(ctx, r.Fremote))
+	r.CheckRemoteItems(t, trashed(file1b), trashed(file2))
+}
+
+func TestPurgeUseTrash(t *testing.T) {
+	ctx, _, r := newTrashRun(t)
+	file1 := r.WriteObject(ctx, "dir/sub/one", "one", t1)
+	file2 := r.WriteObject(ctx, "dir/two", "two", t2)
+	file3 := r.WriteObject(ctx, "three", "three", t2)
+
+	require.NoError(t, operations.Purge(ctx, r.Fremote, "dir"))
+	r.CheckRemoteItems(t, trashed(file1), trashed(file2), file3)
+
+	// Purging the root leaves the trash
+	require.NoError(t, operations.Purge(ctx, r.Fremote, ""))
+	r.CheckRemoteItems(t, trashed(file1), trashed(file2), trashed(file3))
+}
+
+func TestCleanUpUseTrash(t *testing.T) {
+	ctx, ci, r := newTrashRun(t)
+	file1 := r.WriteObject(ctx, "one", "one", t1)
+	old := r.WriteObject(ctx, path.Join(fs.TrashDir, "2000-01-01", "old"), "old", t1)
+	other := r.WriteObject(ctx, path.Join(fs.TrashDir, "other"), "other", t1)
+
+	obj, err := r.Fremote.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	require.NoError(t, operations.DeleteFile(ctx, obj))
+	r.CheckRemoteItems(t, trashed(file1), old, other)
+
+	// Only files deleted before the retention period are purged
+	ci.TrashRetention = fs.Duration(24 * time.Hour)
+	err = operations.CleanUp(ctx, r.Fremote)
+	if err != nil {
+		// The backend's own cleanup may not be supported
+		assert.ErrorContains(t, err, "not supported")
+	}
+	r.CheckRemoteItems(t, trashed(file1), other)
+
+	// With no retention everything is purged
+	ci.TrashRetention = 0
+	_ = operations.CleanUp(ctx, r.Fremote)
+	r.CheckRemoteItems(t, other)
+}
@@ -0,0 +1,146 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/walk"
+	"github.com/rclone/rclone/lib/errcount"
+	"golang.org/x/sync/errgroup"
+)
+
+// ListDeleted calls fn for each deleted file in f which can be
+// restored with Undelete and which is included by the filters.
+//
+// These are the files in the backend's trash or hidden by newer
+// versions, if it supports the ListDeleted feature, and the files
+// moved into fs.TrashDir by --use-trash.
+func ListDeleted(ctx context.Context, f fs.Fs, fn func(fs.DeletedObject) error) error {
+	fi := filter.GetConfig(ctx)
+	include := func(deleted fs.DeletedObject) error {
+		if !fi.Include(deleted.Remote, deleted.Size, deleted.ModTime, nil) {
+			return nil
+		}
+		return fn(deleted)
+	}
+	listDeleted := f.Features().ListDeleted
+	if listDeleted != nil {
+		err := listDeleted(ctx, "", include)
+		if err != nil {
+			return fmt.Errorf("failed to list deleted files: %w", err)
+		}
+	}
+	foundTrash, err := listTrash(ctx, f, include)
+	if err != nil {
+		return err
+	}
+	if listDeleted == nil && !foundTrash {
+		return fmt.Errorf("can't find deleted files in %v as it has no trash or versions and there is no %s directory: %w", f, fs.TrashDir, fs.ErrorNotImplemented)
+	}
+	return nil
+}
+
+// listTrash calls fn for each file moved into fs.TrashDir in f by
+// --use-trash, returning whether fs.TrashDir was found.
+//
+// The ID of each is its path in f.
+func listTrash(ctx context.Context, f fs.Fs, fn func(fs.DeletedObject) error) (found bool, err error) {
+	ctx = WithoutTrash(ctx)
+	err = walk.ListR(ctx, f, fs.TrashDir, true, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		return entries.ForObjectError(func(o fs.Object) error {
+			date, remote, ok := strings.Cut(strings.TrimPrefix(o.Remote(), fs.TrashDir+"/"), "/")
+			deletedAt, err := time.Parse(trashDateFormat, date)
+			if !ok || err != nil {
+				fs.Debugf(o, "Ignoring file in %s which isn't in a directory named by date", fs.TrashDir)
+				return nil
+			}
+			return fn(fs.DeletedObject{
+				Remote:    remote,
+				Size:      o.Size(),
+				ModTime:   o.ModTime(ctx),
+				DeletedAt: deletedAt,
+				ID:        o.Remote(),
+			})
+		})
+	})
+	if errors.Is(err, fs.ErrorDirNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to list %s: %w", fs.TrashDir, err)
+	}
+	return true, nil
+}
+
+// Undelete restores deleted, as returned by ListDeleted, to where it
+// was deleted from in f.
+//
+// It returns fs.ErrorCantUndelete if there is a file there already.
+func Undelete(ctx context.Context, f fs.Fs, deleted fs.DeletedObject) (err error) {
+	if SkipDestructive(ctx, deleted.Remote, "undelete") {
+		return nil
+	}
+	if strings.HasPrefix(deleted.ID, fs.TrashDir+"/") {
+		err = undeleteFromTrash(ctx, f, deleted)
+	} else if doUndelete := f.Features().Undelete; doUndelete != nil {
+		err = doUndelete(ctx, deleted)
+	} else {
+		err = fmt.Errorf("can't undelete from %v: %w", f, fs.ErrorNotImplemented)
+	}
+	if err != nil {
+		err = fs.CountError(ctx, err)
+		fs.Errorf(deleted.Remote, "Failed to undelete: %v", err)
+		return err
+	}
+	fs.Infof(deleted.Remote, "Undeleted")
+	return nil
+}
+
+// undeleteFromTrash moves deleted back out of fs.TrashDir
+func undeleteFromTrash(ctx context.Context, f fs.Fs, deleted fs.DeletedObject) error {
+	ctx = WithoutTrash(ctx)
+	if _, err := f.NewObject(ctx, deleted.Remote); err == nil {
+		return fs.ErrorCantUndelete
+	}
+	src, err := f.NewObject(ctx, deleted.ID)
+	if err != nil {
+		return err
+	}
+	_, err = Move(ctx, f, nil, deleted.Remote, src)
+	return err
+}
+
+// UndeleteAll restores all the deleted files in f found by
+// ListDeleted. Where a file was deleted more than once the most
+// recently deleted one is restored.
+func UndeleteAll(ctx context.Context, f fs.Fs) error {
+	newest := map[string]fs.DeletedObject{}
+	err := ListDeleted(ctx, f, func(deleted fs.DeletedObject) error {
+		if old, found := newest[deleted.Remote]; !found || deleted.DeletedAt.After(old.DeletedAt) {
+			newest[deleted.Remote] = deleted
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	ci := fs.GetConfig(ctx)
+	errCount := errcount.New()
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(ci.Checkers)
+	for _, remote := range slices.Sorted(maps.Keys(newest)) {
+		deleted := newest[remote]
+		g.Go(func() error {
+			errCount.Add(Undelete(gCtx, f, deleted))
+			return nil // don't return errors, just count them
+		})
+	}
+	_ = g.Wait()
+	return errCount.Err("failed to undelete")
+}
@@ -0,0 +1,109 @@
+package operations_test
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listDeleted(ctx context.Context, f fs.Fs) (remotes []string, err error) {
+	err = operations.ListDeleted(ctx, f, func(deleted fs.DeletedObject) error {
+		remotes = append(remotes, deleted.Remote)
+		return nil
+	})
+	sort.Strings(remotes)
+	return remotes, err
+}
+
+func TestUndeleteFromTrash(t *testing.T) {
+	ctx, _, r := newTrashRun(t)
+	file1 := r.WriteObject(ctx, "dir/one", "one", t1)
+	file2 := r.WriteObject(ctx, "two", "two", t2)
+	old := r.WriteObject(ctx, path.Join(fs.TrashDir, "2000-01-01", "two"), "old two", t1)
+	require.NoError(t, operations.Delete(ctx, r.Fremote))
+	r.CheckRemoteItems(t, trashed(file1), trashed(file2), old)
+
+	remotes, err := listDeleted(ctx, r.Fremote)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dir/one", "two", "two"}, remotes)
+
+	// Filters apply to the deleted files
+	fi, err := filter.NewFilter(nil)
+	require.NoError(t, err)
+	require.NoError(t, fi.AddRule("- dir/**"))
+	remotes, err = listDeleted(filter.ReplaceConfig(ctx, fi), r.Fremote)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"two", "two"}, remotes)
+
+	// The most recently deleted copy is restored
+	require.NoError(t, operations.UndeleteAll(ctx, r.Fremote))
+	r.CheckRemoteItems(t, file1, file2, old)
+
+	// A file in the way isn't overwritten
+	err = operations.Undelete(ctx, r.Fremote, fs.DeletedObject{
+		Remote: "two",
+		ID:     old.Path,
+	})
+	assert.ErrorIs(t, err, fs.ErrorCantUndelete)
+	r.CheckRemoteItems(t, file1, file2, old)
+	accounting.GlobalStats().ResetCounters()
+}
+
+func TestUndeleteFeature(t *testing.T) {
+	ctx := context.Background()
+	f, err := mockfs.NewFs(ctx, "undelete", "", nil)
+	require.NoError(t, err)
+
+	_, err = listDeleted(ctx, f)
+	assert.ErrorIs(t, err, fs.ErrorNotImplemented)
+
+	deletedAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	var restored []string
+	f.Features().ListDeleted = func(ctx context.Context, dir string, fn func(fs.DeletedObject) error) error {
+		for _, deleted := range []fs.DeletedObject{
+			{Remote: "a.txt", Size: 1, DeletedAt: deletedAt, ID: "a1"},
+			{Remote: "a.txt", Size: 2, DeletedAt: deletedAt.Add(time.Hour), ID: "a2"},
+			{Remote: "b.txt", Size: 3, ID: "b"},
+			{Remote: "c.txt", Size: 4, ID: "c"},
+		} {
+			if err := fn(deleted); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	f.Features().Undelete = func(ctx context.Context, deleted fs.DeletedObject) error {
+		if deleted.ID == "c" {
+			return errors.New("BOOM")
+		}
+		restored = append(restored, deleted.ID)
+		return nil
+	}
+	remotes, err := listDeleted(ctx, f)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "a.txt", "b.txt", "c.txt"}, remotes)
+
+	ctx, ci := fs.AddConfig(ctx)
+	ci.Checkers = 1
+	err = operations.UndeleteAll(ctx, f)
+	assert.ErrorContains(t, err, "failed to undelete: BOOM")
+	assert.Equal(t, []string{"a2", "b"}, restored)
+
+	// Nothing is restored with --dry-run
+	restored = nil
+	ci.DryRun = true
+	require.NoError(t, operations.UndeleteAll(ctx, f))
+	assert.Nil(t, restored)
+	accounting.GlobalStats().ResetCounters()
+}
@@ -0,0 +1,144 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/list"
+)
+
+// VersionTimeFormat is the format of the names of the version
+// directories made in --backup-dir by --backup-dir-versions
+const VersionTimeFormat = "2006-01-02-150405"
+
+var (
+	backupVersionOnce sync.Once
+	backupVersion     string
+)
+
+// BackupVersion returns the name of the version directory to use for
+// this run of rclone with --backup-dir-versions.
+//
+// It is the time of the first call so all the files backed up by one
+// run of rclone go into the same version.
+func BackupVersion() string {
+	backupVersionOnce.Do(func() {
+		backupVersion = time.Now().UTC().Format(VersionTimeFormat)
+	})
+	return backupVersion
+}
+
+// Version is a version directory in a --backup-dir made by
+// --backup-dir-versions
+type Version struct {
+	Name string    // name of the directory
+	Time time.Time // when it was made
+}
+
+// ListVersions returns the version directories in f sorted newest
+// first.
+//
+// Directories whose names aren't in VersionTimeFormat are ignored.
+func ListVersions(ctx context.Context, f fs.Fs) (versions []Version, err error) {
+	entries, err := list.DirSorted(ctx, f, true, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		dir, ok := entry.(fs.Directory)
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(VersionTimeFormat, dir.Remote())
+		if err != nil {
+			continue
+		}
+		versions = append(versions, Version{Name: dir.Remote(), Time: t})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Time.After(versions[j].Time)
+	})
+	return versions, nil
+}
+
+// VersionRetention says which versions to keep when pruning
+type VersionRetention struct {
+	Last   int // keep this many of the newest versions
+	Daily  int // keep the newest version from this many days
+	Weekly int // keep the newest version from this many weeks
+}
+
+// IsZero returns true if r doesn't keep anything
+func (r VersionRetention) IsZero() bool {
+	return r.Last <= 0 && r.Daily <= 0 && r.Weekly <= 0
+}
+
+// Keep splits versions, which should be sorted newest first, into the
+// ones to keep and the ones to remove.
+//
+// Days and weeks are in UTC, with weeks as ISO 8601 weeks, and only
+// count if they have a version in them.
+func (r VersionRetention) Keep(versions []Version) (keep, remove []Version) {
+	keepIt := make([]bool, len(versions))
+	for i := 0; i < len(versions) && i < r.Last; i++ {
+		keepIt[i] = true
+	}
+	// keepNewest keeps the newest version in each of the first n
+	// periods
+	keepNewest := func(n int, period func(time.Time) string) {
+		seen := map[string]bool{}
+		for i, v := range versions {
+			if len(seen) >= n {
+				break
+			}
+			p := period(v.Time)
+			if !seen[p] {
+				seen[p] = true
+				keepIt[i] = true
+			}
+		}
+	}
+	keepNewest(r.Daily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	keepNewest(r.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%02d", year, week)
+	})
+	for i, v := range versions {
+		if keepIt[i] {
+			keep = append(keep, v)
+		} else {
+			remove = append(remove, v)
+		}
+	}
+	return keep, remove
+}
+
+// PruneVersions removes the version directories in f which r doesn't
+// keep, returning the ones removed.
+//
+// This respects --dry-run.
+func PruneVersions(ctx context.Context, f fs.Fs, r VersionRetention) (removed []Version, err error) {
+	if r.IsZero() {
+		return nil, errors.New("refusing to prune every version - need a retention policy")
+	}
+	versions, err := ListVersions(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	keep, remove := r.Keep(versions)
+	fs.Debugf(f, "Keeping %d versions and removing %d", len(keep), len(remove))
+	for _, v := range remove {
+		if err = Purge(ctx, f, v.Name); err != nil {
+			return removed, fmt.Errorf("failed to remove version %q: %w", v.Name, err)
+		}
+		removed = append(removed, v)
+	}
+	return removed, nil
+}
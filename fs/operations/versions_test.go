@@ -0,0 +1,113 @@
+package operations_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeVersions(t *testing.T, names ...string) (versions []operations.Version) {
+	for _, name := range names {
+		tm, err := time.Parse(operations.VersionTimeFormat, name)
+		require.NoError(t, err)
+		versions = append(versions, operations.Version{Name: name, Time: tm})
+	}
+	return versions
+}
+
+func versionNames(versions []operations.Version) (names []string) {
+	for _, v := range versions {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+func TestVersionRetentionKeep(t *testing.T) {
+	// Newest first - 2025-06-30 is a Monday
+	versions := makeVersions(t,
+		"2025-07-01-120000",
+		"2025-07-01-080000",
+		"2025-06-30-120000",
+		"2025-06-29-120000",
+		"2025-06-28-120000",
+		"2025-06-20-120000",
+		"2025-06-10-120000",
+	)
+	for _, test := range []struct {
+		r    operations.VersionRetention
+		want []string
+	}{
+		{operations.VersionRetention{}, nil},
+		{operations.VersionRetention{Last: 2}, []string{"2025-07-01-120000", "2025-07-01-080000"}},
+		{operations.VersionRetention{Last: 100}, versionNames(versions)},
+		{operations.VersionRetention{Daily: 2}, []string{"2025-07-01-120000", "2025-06-30-120000"}},
+		{operations.VersionRetention{Weekly: 3}, []string{"2025-07-01-120000", "2025-06-29-120000", "2025-06-20-120000"}},
+		{operations.VersionRetention{Last: 1, Daily: 3, Weekly: 4}, []string{
+			"2025-07-01-120000",
+			"2025-06-30-120000",
+			"2025-06-29-120000",
+			"2025-06-20-120000",
+			"2025-06-10-120000",
+		}},
+	} {
+		keep, remove := test.r.Keep(versions)
+		assert.Equal(t, test.want, versionNames(keep), test.r)
+		assert.Equal(t, len(versions), len(keep)+len(remove), test.r)
+	}
+}
+
+func TestListAndPruneVersions(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+
+	file1 := r.WriteObject(ctx, "2025-06-28-120000/file1", "one", t1)
+	file2 := r.WriteObject(ctx, "2025-06-29-120000/file2", "two", t1)
+	file3 := r.WriteObject(ctx, "2025-06-30-120000/file3", "three", t1)
+	other := r.WriteObject(ctx, "other/file4", "four", t1)
+	r.CheckRemoteItems(t, file1, file2, file3, other)
+
+	versions, err := operations.ListVersions(ctx, r.Fremote)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2025-06-30-120000", "2025-06-29-120000", "2025-06-28-120000"}, versionNames(versions))
+
+	_, err = operations.PruneVersions(ctx, r.Fremote, operations.VersionRetention{})
+	assert.Error(t, err)
+
+	removed, err := operations.PruneVersions(ctx, r.Fremote, operations.VersionRetention{Last: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2025-06-29-120000", "2025-06-28-120000"}, versionNames(removed))
+	r.CheckRemoteListing(t, []fstest.Item{file3, other}, []string{"2025-06-30-120000", "other"})
+}
+
+func TestMoveFileBackupDirVersions(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	if !operations.CanServerSideMove(r.Fremote) {
+		t.Skip("Skipping test as remote does not support server-side move or copy")
+	}
+
+	ci.BackupDirVersions = true
+	file1old := r.WriteObject(ctx, "dst/file1", "file1 contents old", t1)
+	r.CheckRemoteItems(t, file1old)
+
+	// Needs --backup-dir
+	_, err := operations.BackupDir(ctx, r.Fremote, r.Flocal, "")
+	assert.ErrorContains(t, err, "--backup-dir-versions needs --backup-dir")
+
+	ci.BackupDir = r.FremoteName + "/backup"
+	file1 := r.WriteFile("dst/file1", "file1 contents", t1)
+	r.CheckLocalItems(t, file1)
+
+	err = operations.MoveFile(ctx, r.Fremote, r.Flocal, file1.Path, file1.Path)
+	require.NoError(t, err)
+	r.CheckLocalItems(t)
+	file1old.Path = "backup/" + operations.BackupVersion() + "/dst/file1"
+	r.CheckRemoteItems(t, file1old, file1)
+}
@@ -19,18 +19,53 @@ type logCalculator struct {
 	pacer.Calculator
 }
 
+func init() {
+	pacer.BreakerStateChanged = func(name string, state pacer.BreakerState, err error) {
+		if state == pacer.BreakerOpen {
+			Errorf(name, "Circuit breaker open after too many failures - failing calls fast: %v", err)
+		} else {
+			Logf(name, "Circuit breaker closed - calls succeeding again")
+		}
+	}
+}
+
+type pacerNameKeyType struct{}
+
+// Context key for the name of the remote the pacer is for
+var pacerNameKey = pacerNameKeyType{}
+
+// withPacerName returns a context which names the pacers made with it
+// so remotes with the same name share a circuit breaker.
+func withPacerName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, pacerNameKey, name)
+}
+
 // NewPacer creates a Pacer for the given Fs and Calculator.
+//
+// If --pacer-breaker-threshold is set then the Pacer has a circuit
+// breaker shared with the other Pacers for the same remote.
 func NewPacer(ctx context.Context, c pacer.Calculator) *Pacer {
 	ci := GetConfig(ctx)
 	retries := max(ci.LowLevelRetries, 1)
 	maxConnections := max(ci.MaxConnections, 0)
+	options := []pacer.Option{
+		pacer.InvokerOption(pacerInvoker),
+		pacer.MaxConnectionsOption(maxConnections),
+		pacer.RetriesOption(retries),
+		pacer.CalculatorOption(c),
+	}
+	if ci.PacerBreakerThreshold > 0 {
+		cooldown := time.Duration(ci.PacerBreakerCooldown)
+		var breaker *pacer.Breaker
+		if name, ok := ctx.Value(pacerNameKey).(string); ok && name != "" {
+			breaker = pacer.GetBreaker(name, ci.PacerBreakerThreshold, cooldown)
+		} else {
+			breaker = pacer.NewBreaker("unnamed", ci.PacerBreakerThreshold, cooldown)
+		}
+		options = append(options, pacer.BreakerOption(breaker))
+	}
 	p := &Pacer{
-		Pacer: pacer.New(
-			pacer.InvokerOption(pacerInvoker),
-			pacer.MaxConnectionsOption(maxConnections),
-			pacer.RetriesOption(retries),
-			pacer.CalculatorOption(c),
-		),
+		Pacer: pacer.New(options...),
 	}
 	p.SetCalculator(c)
 	return p
@@ -55,3 +55,28 @@ func TestPacerCallNoRetry(t *testing.T) {
 	require.Equal(t, 1, dp.called)
 	require.Implements(t, (*fserrors.Retrier)(nil), err)
 }
+
+func TestNewPacerBreaker(t *testing.T) {
+	ctx := context.Background()
+
+	// No breaker by default
+	p := NewPacer(ctx, nil)
+	require.Nil(t, p.Breaker())
+
+	ctx, ci := AddConfig(ctx)
+	ci.PacerBreakerThreshold = 2
+	ci.PacerBreakerCooldown = Duration(time.Minute)
+
+	// Pacers for the same remote share a breaker
+	named := withPacerName(ctx, "TestNewPacerBreaker")
+	p1 := NewPacer(named, nil)
+	p2 := NewPacer(named, nil)
+	require.NotNil(t, p1.Breaker())
+	require.Equal(t, p1.Breaker(), p2.Breaker())
+	require.Equal(t, p1.Breaker(), pacer.FindBreaker("TestNewPacerBreaker"))
+
+	// Unnamed pacers get their own
+	p3 := NewPacer(ctx, nil)
+	require.NotNil(t, p3.Breaker())
+	require.NotEqual(t, p1.Breaker(), p3.Breaker())
+}
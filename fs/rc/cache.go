@@ -75,12 +75,63 @@ func GetFsNamedFileOK(ctx context.Context, in Params, fsName string) (newCtx con
 //
 // It uses the special parameters _name to name the remote and _root
 // to make the root of the remote.
+//
+// Values may be nested config maps which are converted to config
+// strings so remotes which wrap other remotes can be defined inline.
 func getConfigMap(in Params, fsName string) (fsString string, err error) {
 	var m configmap.Simple
 	err = in.GetStruct(fsName, &m)
 	if err != nil {
-		return fsString, err
+		var nested map[string]any
+		if in.GetStruct(fsName, &nested) != nil || !hasNestedConfigMap(nested) {
+			return fsString, err
+		}
+		var nestedErr error
+		m, nestedErr = flattenConfigMap(nested)
+		if nestedErr != nil {
+			return fsString, ErrParamInvalid{fmt.Errorf("key %q: %w", fsName, nestedErr)}
+		}
+	}
+	return configMapToFsString(m)
+}
+
+// hasNestedConfigMap returns true if any of the values of m are
+// config maps
+func hasNestedConfigMap(m map[string]any) bool {
+	for _, value := range m {
+		if _, ok := value.(map[string]any); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenConfigMap converts any nested config maps in m into config
+// strings
+func flattenConfigMap(nested map[string]any) (m configmap.Simple, err error) {
+	m = make(configmap.Simple, len(nested))
+	for key, value := range nested {
+		switch value := value.(type) {
+		case string:
+			m[key] = value
+		case map[string]any:
+			inner, err := flattenConfigMap(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			m[key], err = configMapToFsString(inner)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		default:
+			return nil, fmt.Errorf("%s: value must be a string or an object not %T", key, value)
+		}
 	}
+	return m, nil
+}
+
+// configMapToFsString converts the config map m into a config string
+func configMapToFsString(m configmap.Simple) (fsString string, err error) {
 	pop := func(key string) string {
 		value := m[key]
 		delete(m, key)
@@ -175,3 +226,154 @@ func rcCacheEntries(ctx context.Context, in Params) (out Params, err error) {
 		"entries": cache.Entries(),
 	}, nil
 }
+
+func init() {
+	Add(Call{
+		Path:         "fscache/list",
+		Fn:           rcCacheList,
+		Title:        "List the remotes in the fs cache.",
+		AuthRequired: true,
+		Help: `
+This lists the remotes in the fs cache along with how many times they
+are pinned. Pinned remotes are in use (for example by a running job or
+by fscache/pin) and won't be expired from the cache.
+
+Returns
+- entries - a list of remotes in the cache sorted by name, each with
+    - fs - the canonical name of the remote
+    - pinCount - number of pins on the remote
+    - lastUsed - time the remote was last used
+    - error - error creating the remote if any
+`,
+	})
+}
+
+// List the remotes in the fs cache
+func rcCacheList(ctx context.Context, in Params) (out Params, err error) {
+	entries := []Params{}
+	for _, entry := range cache.List() {
+		item := Params{
+			"fs":       entry.Name,
+			"pinCount": entry.PinCount,
+			"lastUsed": entry.LastUsed,
+		}
+		if entry.Err != nil && !errors.Is(entry.Err, fs.ErrorIsFile) {
+			item["error"] = entry.Err.Error()
+		}
+		entries = append(entries, item)
+	}
+	return Params{
+		"entries": entries,
+	}, nil
+}
+
+func init() {
+	Add(Call{
+		Path:         "fscache/evict",
+		Fn:           rcCacheEvict,
+		Title:        "Remove a remote from the fs cache.",
+		AuthRequired: true,
+		Help: `
+This removes a single remote from the fs cache, shutting down any
+resources it holds, such as the connection pool of an sftp remote.
+
+Parameters
+- fs - the remote to remove from the cache
+- force - set to true to remove the remote even if it is pinned
+
+Remotes which are pinned are in use, so removing them with force may
+cause errors in running jobs.
+
+Returns
+- evicted - true if the remote was removed
+`,
+	})
+}
+
+// Remove a remote from the fs cache
+func rcCacheEvict(ctx context.Context, in Params) (out Params, err error) {
+	fsString, err := getFsName(in, "fs")
+	if err != nil {
+		return nil, err
+	}
+	force, err := in.GetBool("force")
+	if err != nil && !IsErrParamNotFound(err) {
+		return nil, err
+	}
+	found, evicted := cache.Evict(fsString, force)
+	if !found {
+		return nil, fmt.Errorf("remote %q not found in the fs cache", fsString)
+	}
+	if !evicted {
+		return nil, fmt.Errorf("remote %q is pinned - use force to remove it", fsString)
+	}
+	return Params{
+		"evicted": evicted,
+	}, nil
+}
+
+func init() {
+	Add(Call{
+		Path:         "fscache/pin",
+		Fn:           rcCachePin,
+		Title:        "Pin a remote in the fs cache.",
+		AuthRequired: true,
+		Help: `
+This creates the remote if necessary and pins it in the fs cache so it
+won't be expired. This keeps its connections open to make repeated rc
+calls on it more efficient.
+
+Parameters
+- fs - the remote to pin
+
+Call fscache/unpin to release it. Remotes may be pinned more than once
+and need to be unpinned the same number of times.
+`,
+	})
+}
+
+// Pin a remote in the fs cache
+func rcCachePin(ctx context.Context, in Params) (out Params, err error) {
+	f, err := GetFs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	cache.Pin(f)
+	return nil, nil
+}
+
+func init() {
+	Add(Call{
+		Path:         "fscache/unpin",
+		Fn:           rcCacheUnpin,
+		Title:        "Unpin a remote in the fs cache.",
+		AuthRequired: true,
+		Help: `
+This removes a pin added by fscache/pin so the remote can be expired
+from the fs cache as normal.
+
+Parameters
+- fs - the remote to unpin
+`,
+	})
+}
+
+// Unpin a remote in the fs cache
+func rcCacheUnpin(ctx context.Context, in Params) (out Params, err error) {
+	fsString, err := getFsName(in, "fs")
+	if err != nil {
+		return nil, err
+	}
+	canonicalName := cache.Canonicalize(fsString)
+	for _, entry := range cache.List() {
+		if entry.Name != canonicalName || entry.Fs == nil {
+			continue
+		}
+		if entry.PinCount <= 0 {
+			return nil, fmt.Errorf("remote %q is not pinned", fsString)
+		}
+		cache.Unpin(entry.Fs)
+		return nil, nil
+	}
+	return nil, fmt.Errorf("remote %q not found in the fs cache", fsString)
+}
@@ -160,6 +160,49 @@ func TestGetConfigMap(t *testing.T) {
 			fsName:       "Fs",
 			wantFsString: "potato,parameter='42',parameter2='true':/path/to/somewhere",
 		},
+		{
+			in: Params{
+				"Fs": Params{
+					"type":     "crypt",
+					"password": "secret",
+					"remote": Params{
+						"type": "chunker",
+						"remote": Params{
+							"type":     "s3",
+							"provider": "AWS",
+							"_root":    "bucket",
+						},
+						"_root": "dir",
+					},
+					"_root": "path",
+				},
+			},
+			fsName:       "Fs",
+			wantFsString: `:crypt,password='secret',remote=':chunker,remote='':s3,provider=''''AWS'''':bucket'':dir':path`,
+		},
+		{
+			in: Params{
+				"Fs": Params{
+					"type": "crypt",
+					"remote": Params{
+						"type": "chunker",
+						"bad":  true,
+					},
+				},
+			},
+			fsName:  "Fs",
+			wantErr: `remote: bad: value must be a string or an object not bool`,
+		},
+		{
+			in: Params{
+				"Fs": Params{
+					"type":   "crypt",
+					"remote": Params{},
+				},
+			},
+			fsName:  "Fs",
+			wantErr: `remote: couldn't find "type" or "_name"`,
+		},
 	} {
 		gotFsString, gotErr := getConfigMap(test.in, test.fsName)
 		what := fmt.Sprintf("%+v", test.in)
@@ -250,3 +293,39 @@ func TestGetFsAndRemote(t *testing.T) {
 		})
 	})
 }
+
+func TestCachePinListEvict(t *testing.T) {
+	defer mockNewFs(t)()
+	ctx := context.Background()
+	in := Params{"fs": "mock:/"}
+
+	_, err := rcCachePin(ctx, in)
+	require.NoError(t, err)
+
+	out, err := rcCacheList(ctx, nil)
+	require.NoError(t, err)
+	entries := out["entries"].([]Params)
+	var pinned Params
+	for _, entry := range entries {
+		if entry["fs"] == "mock:/" {
+			pinned = entry
+		}
+	}
+	require.NotNil(t, pinned)
+	assert.Equal(t, 1, pinned["pinCount"])
+
+	_, err = rcCacheEvict(ctx, in)
+	assert.ErrorContains(t, err, "pinned")
+
+	_, err = rcCacheUnpin(ctx, in)
+	require.NoError(t, err)
+	_, err = rcCacheUnpin(ctx, in)
+	assert.ErrorContains(t, err, "not pinned")
+
+	out, err = rcCacheEvict(ctx, in)
+	require.NoError(t, err)
+	assert.Equal(t, Params{"evicted": true}, out)
+
+	_, err = rcCacheEvict(ctx, in)
+	assert.ErrorContains(t, err, "not found")
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/rclone/rclone/lib/atexit"
 	"github.com/rclone/rclone/lib/buildinfo"
 	"github.com/rclone/rclone/lib/debug"
+	"github.com/rclone/rclone/lib/pacer"
 )
 
 func init() {
@@ -199,6 +200,56 @@ func rcGc(ctx context.Context, in Params) (out Params, err error) {
 	return nil, nil
 }
 
+func init() {
+	Add(Call{
+		Path:  "core/breakers",
+		Fn:    rcBreakers,
+		Title: "Shows the state of the circuit breakers for each remote.",
+		Help: `
+When --pacer-breaker-threshold is set, each remote gets a circuit
+breaker which opens after that many consecutive failed API calls. While
+open, calls to the remote fail fast until --pacer-breaker-cooldown has
+passed.
+
+This returns the breakers in use:
+
+- breakers - array of objects with
+    - name - name of the remote, e.g. "s3eu"
+    - state - "closed", "open" or "half-open"
+    - consecutiveFailures - number of failed calls in a row
+    - openUntil - time the breaker stays open until if open
+    - lastError - the last error from a failed call
+    - successes - total number of successful calls
+    - failures - total number of failed calls
+    - trips - number of times the breaker has opened
+    - rejected - number of calls failed fast
+
+(Optional) Pass the name of a remote to close its breaker now:
+
+- reset - string
+`,
+	})
+}
+
+// Return the circuit breakers, resetting one if required
+func rcBreakers(ctx context.Context, in Params) (out Params, err error) {
+	reset, err := in.GetString("reset")
+	if IsErrParamInvalid(err) {
+		return nil, err
+	}
+	if reset != "" {
+		breaker := pacer.FindBreaker(reset)
+		if breaker == nil {
+			return nil, fmt.Errorf("no circuit breaker for %q", reset)
+		}
+		breaker.Reset()
+	}
+	out = Params{
+		"breakers": pacer.Breakers(),
+	}
+	return out, nil
+}
+
 func init() {
 	Add(Call{
 		Path:  "core/version",
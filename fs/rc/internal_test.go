@@ -2,6 +2,7 @@ package rc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -9,12 +10,14 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/lib/pacer"
 )
 
 func TestMain(m *testing.M) {
@@ -119,6 +122,36 @@ func TestCoreVersion(t *testing.T) {
 	assert.True(t, len(v) >= 2)
 }
 
+func TestCoreBreakers(t *testing.T) {
+	call := Calls.Get("core/breakers")
+	assert.NotNil(t, call)
+	breaker := pacer.GetBreaker("TestCoreBreakers", 1, time.Hour)
+	require.NoError(t, breaker.Allow())
+	breaker.Record(true, errors.New("boom"))
+
+	find := func(out Params) (stats pacer.BreakerStats) {
+		for _, stats = range out["breakers"].([]pacer.BreakerStats) {
+			if stats.Name == "TestCoreBreakers" {
+				return stats
+			}
+		}
+		t.Fatal("breaker not found")
+		return stats
+	}
+	out, err := call.Fn(context.Background(), Params{})
+	require.NoError(t, err)
+	stats := find(out)
+	assert.Equal(t, "open", stats.State)
+	assert.Equal(t, "boom", stats.LastError)
+
+	out, err = call.Fn(context.Background(), Params{"reset": "TestCoreBreakers"})
+	require.NoError(t, err)
+	assert.Equal(t, "closed", find(out).State)
+
+	_, err = call.Fn(context.Background(), Params{"reset": "TestCoreBreakersNotFound"})
+	assert.Error(t, err)
+}
+
 func TestCoreObscure(t *testing.T) {
 	call := Calls.Get("core/obscure")
 	assert.NotNil(t, call)
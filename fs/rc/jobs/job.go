@@ -20,6 +20,7 @@ import (
 	"github.com/rclone/rclone/fs/accounting"
 	"github.com/rclone/rclone/fs/cache"
 	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/notify"
 	"github.com/rclone/rclone/fs/rc"
 	"golang.org/x/sync/errgroup"
 )
@@ -45,6 +46,13 @@ type Job struct {
 	Output    rc.Params `json:"output"`
 	Stop      func()    `json:"-"`
 	listeners []*func()
+	notify    bool                          // set to send notifications when the job finishes
+	graceful  []*func()                     // functions to stop the job gracefully
+	excludes  []string                      // exclude rules added with job/exclude
+	exclude   atomic.Pointer[filter.Filter] // filter made from excludes
+	failures  map[string]*Failure           // failed transfers by destination
+	retryCtx  context.Context               // context to retry the failed transfers in
+	retryOpt  retryOptions                  // how to retry the failed transfers
 
 	// realErr is the Error before printing it as a string, it's used to return
 	// the real error to the upper application layers while still printing the
@@ -71,6 +79,7 @@ func (job *Job) finish(out rc.Params, err error) {
 		job.Success = true
 	}
 	job.Finished = true
+	job.scheduleRetries()
 
 	// Notify listeners that the job is finished
 	for i := range job.listeners {
@@ -105,6 +114,53 @@ func (job *Job) OnFinish(fn func()) func() {
 	return func() { job.removeListener(&fn) }
 }
 
+// OnStopGraceful adds fn to be called when the job is asked to stop
+// gracefully with job/stop. It returns a function to remove fn.
+func (job *Job) OnStopGraceful(fn func()) func() {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	job.graceful = append(job.graceful, &fn)
+	return func() {
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		job.graceful = slices.DeleteFunc(job.graceful, func(f *func()) bool { return f == &fn })
+	}
+}
+
+// stopGraceful asks the job to stop gracefully - call with the lock
+// held. It returns false if the job can't be stopped gracefully.
+func (job *Job) stopGraceful() bool {
+	if len(job.graceful) == 0 {
+		return false
+	}
+	for _, fn := range job.graceful {
+		go (*fn)()
+	}
+	return true
+}
+
+// AddExclude adds exclude rules to the job. These stop the files they
+// match being transferred if they haven't started already.
+func (job *Job) AddExclude(rules ...string) error {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	excludes := slices.Concat(job.excludes, rules)
+	// Only IncludeRemote is used so no other options are needed
+	fi, err := filter.NewFilter(&filter.Options{RulesOpt: filter.RulesOpt{ExcludeRule: excludes}})
+	if err != nil {
+		return err
+	}
+	job.excludes = excludes
+	job.exclude.Store(fi)
+	return nil
+}
+
+// Excluded returns whether remote has been excluded by AddExclude
+func (job *Job) Excluded(remote string) bool {
+	fi := job.exclude.Load()
+	return fi != nil && !fi.IncludeRemote(remote)
+}
+
 // run the job until completion writing the return status
 func (job *Job) run(ctx context.Context, fn rc.Func, in rc.Params) {
 	defer func() {
@@ -113,6 +169,24 @@ func (job *Job) run(ctx context.Context, fn rc.Func, in rc.Params) {
 		}
 	}()
 	job.finish(fn(ctx, in))
+	if job.notify {
+		notify.JobDone(ctx, notify.Job{
+			Name:  job.Group,
+			Src:   firstString(in, "srcFs", "path1"),
+			Dst:   firstString(in, "dstFs", "path2"),
+			Start: job.StartTime,
+		}, job.realErr)
+	}
+}
+
+// firstString returns the first of keys in in which is a string or ""
+func firstString(in rc.Params, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := in[key].(string); ok {
+			return s
+		}
+	}
+	return ""
 }
 
 // Jobs describes a collection of running tasks
@@ -167,7 +241,7 @@ func (jobs *Jobs) Expire() {
 	now := time.Now()
 	for ID, job := range jobs.jobs {
 		job.mu.Lock()
-		if job.Finished && now.Sub(job.EndTime) > time.Duration(jobs.opt.JobExpireDuration) {
+		if job.Finished && now.Sub(job.EndTime) > time.Duration(jobs.opt.JobExpireDuration) && !job.retriesPending() {
 			delete(jobs.jobs, ID)
 		}
 		job.mu.Unlock()
@@ -277,6 +351,20 @@ func getFilter(ctx context.Context, in rc.Params) (context.Context, error) {
 	return ctx, nil
 }
 
+// See if _notify is set and if so adjust ctx to include it
+func getNotify(ctx context.Context, in rc.Params) (context.Context, bool, error) {
+	if _, ok := in["_notify"]; !ok {
+		return ctx, false, nil
+	}
+	ctx, opt := notify.AddConfig(ctx)
+	err := in.GetStruct("_notify", opt)
+	if err != nil {
+		return ctx, false, err
+	}
+	delete(in, "_notify") // remove the parameter
+	return ctx, true, nil
+}
+
 type jobKeyType struct{}
 
 // Key for adding jobs to ctx
@@ -302,6 +390,11 @@ func (jobs *Jobs) NewJob(ctx context.Context, fn rc.Func, in rc.Params) (job *Jo
 		return nil, nil, err
 	}
 
+	ctx, sendNotify, err := getNotify(ctx, in)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	ctx, group, err := getGroup(ctx, in, id)
 	if err != nil {
 		return nil, nil, err
@@ -319,6 +412,9 @@ func (jobs *Jobs) NewJob(ctx context.Context, fn rc.Func, in rc.Params) (job *Jo
 		Group:     group,
 		StartTime: time.Now(),
 		Stop:      stop,
+		notify:    sendNotify,
+		retryCtx:  ctx,
+		retryOpt:  newRetryOptions(jobs.opt),
 	}
 
 	jobs.mu.Lock()
@@ -364,6 +460,13 @@ func GetJob(ctx context.Context) (job *Job, ok bool) {
 	return job, ok
 }
 
+// Excluded returns whether remote has been excluded from the job
+// running in ctx, if any, with job/exclude.
+func Excluded(ctx context.Context, remote string) bool {
+	job, ok := GetJob(ctx)
+	return ok && job.Excluded(remote)
+}
+
 // GetJobID gets the Job from the context if possible
 func GetJobID(ctx context.Context) (jobID int64, ok bool) {
 	job, ok := GetJob(ctx)
@@ -455,6 +558,12 @@ func init() {
 		Help: `Parameters:
 
 - jobid - id of the job (integer).
+- graceful - if true stop gracefully (optional, boolean).
+
+If graceful is set then a sync, copy or move job lets the transfers
+in progress finish but doesn't start any more, and writes the files
+still to be transferred to --graceful-stop-list if set. It is an error
+to stop a job which doesn't support this gracefully.
 `,
 	})
 }
@@ -465,6 +574,10 @@ func rcJobStop(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	if err != nil {
 		return nil, err
 	}
+	graceful, err := in.GetBool("graceful")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
 	job := running.Get(jobID)
 	if job == nil {
 		return nil, errors.New("job not found")
@@ -472,10 +585,138 @@ func rcJobStop(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	job.mu.Lock()
 	defer job.mu.Unlock()
 	out = make(rc.Params)
+	if graceful {
+		if !job.stopGraceful() {
+			return nil, errors.New("job can't be stopped gracefully")
+		}
+		return out, nil
+	}
 	job.Stop()
 	return out, nil
 }
 
+func init() {
+	rc.Add(rc.Call{
+		Path:  "job/pause",
+		Fn:    rcJobPause,
+		Title: "Pause the transfers of a running job",
+		Help: `Parameters:
+
+- jobid - id of the job (integer).
+
+This stops the transfers of the job from reading any more data until
+job/resume is called. Transfers in progress are paused where they are
+and no new transfers will start. Checks and listings carry on.
+
+This pauses all the transfers in the stats group of the job, which
+will be other jobs too if they were started with the same _group.
+
+Note that some backends may time out transfers which are paused for a
+long time, in which case they will be retried when resumed.
+
+To change the bandwidth limit of a running job use core/bwlimit.
+`,
+	})
+}
+
+// Pauses the running job.
+func rcJobPause(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	return rcJobPauseResume(ctx, in, true)
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "job/resume",
+		Fn:    rcJobResume,
+		Title: "Resume the transfers of a paused job",
+		Help: `Parameters:
+
+- jobid - id of the job (integer).
+
+This resumes the transfers of a job paused with job/pause.
+`,
+	})
+}
+
+// Resumes the paused job.
+func rcJobResume(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	return rcJobPauseResume(ctx, in, false)
+}
+
+// Pause or resume the job in jobid
+func rcJobPauseResume(ctx context.Context, in rc.Params, pause bool) (out rc.Params, err error) {
+	jobID, err := in.GetInt64("jobid")
+	if err != nil {
+		return nil, err
+	}
+	job := running.Get(jobID)
+	if job == nil {
+		return nil, errors.New("job not found")
+	}
+	stats := accounting.StatsGroup(ctx, job.Group)
+	if pause {
+		fs.Logf(nil, "Pausing transfers of job %d", jobID)
+		stats.Pause()
+	} else {
+		fs.Logf(nil, "Resuming transfers of job %d", jobID)
+		stats.Resume()
+	}
+	return rc.Params{}, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "job/exclude",
+		Fn:    rcJobExclude,
+		Title: "Exclude files from a running job",
+		Help: `Parameters:
+
+- jobid - id of the job (integer).
+- exclude - an exclude rule, or a list of them, as used with --exclude.
+
+This adds exclude rules to a running sync, copy or move job. Files
+which match them and haven't started transferring yet won't be
+transferred. Transfers already in progress carry on.
+
+The rules only stop files being transferred - they don't change which
+files are deleted from the destination.
+
+Returns
+
+- exclude - the list of all the exclude rules added to the job.
+
+Eg
+
+    rclone rc job/exclude jobid=1 exclude='*.iso'
+`,
+	})
+}
+
+// Adds exclude rules to the running job.
+func rcJobExclude(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	jobID, err := in.GetInt64("jobid")
+	if err != nil {
+		return nil, err
+	}
+	var rules []string
+	if rule, err := in.GetString("exclude"); err == nil {
+		rules = []string{rule}
+	} else if err = in.GetStruct("exclude", &rules); err != nil {
+		return nil, err
+	}
+	job := running.Get(jobID)
+	if job == nil {
+		return nil, errors.New("job not found")
+	}
+	err = job.AddExclude(rules...)
+	if err != nil {
+		return nil, err
+	}
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	return rc.Params{"exclude": slices.Clone(job.excludes)}, nil
+}
+
 func init() {
 	rc.Add(rc.Call{
 		Path:  "job/stopgroup",
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"runtime"
 	"testing"
 	"time"
@@ -11,6 +13,7 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/accounting"
 	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/notify"
 	"github.com/rclone/rclone/fs/rc"
 	"github.com/rclone/rclone/fstest/testy"
 	"github.com/stretchr/testify/assert"
@@ -321,6 +324,49 @@ func TestExecuteJobWithFilter(t *testing.T) {
 	assert.Equal(t, true, called)
 }
 
+func TestExecuteJobWithNotify(t *testing.T) {
+	ctx := context.Background()
+	jobID.Store(0)
+	bodies := make(chan notify.Event, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev notify.Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&ev))
+		bodies <- ev
+	}))
+	defer server.Close()
+	jobFn := func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		opt := notify.GetConfig(ctx)
+		assert.Equal(t, server.URL, opt.Webhook)
+		assert.Equal(t, notify.EventError, opt.On)
+		return nil, errors.New("boom")
+	}
+	_, _, err := NewJob(ctx, jobFn, rc.Params{
+		"srcFs":  "/src",
+		"dstFs":  "/dst",
+		"_group": "mygroup",
+		"_notify": rc.Params{
+			"Webhook": server.URL,
+			"On":      "error",
+		},
+	})
+	require.Error(t, err)
+	require.Len(t, bodies, 1)
+	ev := <-bodies
+	assert.Equal(t, "error", ev.Event)
+	assert.Equal(t, "boom", ev.Error)
+	assert.Equal(t, "mygroup", ev.Job)
+	assert.Equal(t, "/src", ev.Src)
+	assert.Equal(t, "/dst", ev.Dst)
+
+	// No notification without _notify
+	jobID.Store(0)
+	_, _, err = NewJob(ctx, func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return nil, errors.New("boom")
+	}, rc.Params{})
+	require.Error(t, err)
+	assert.Len(t, bodies, 0)
+}
+
 func TestExecuteJobWithGroup(t *testing.T) {
 	ctx := context.Background()
 	jobID.Store(0)
@@ -492,6 +538,71 @@ func TestRcSyncJobStop(t *testing.T) {
 	assert.Equal(t, false, out["success"])
 }
 
+func TestRcJobPauseResume(t *testing.T) {
+	ctx := context.Background()
+	jobID.Store(0)
+	job, _, err := NewJob(ctx, ctxFn, rc.Params{"_async": true})
+	require.NoError(t, err)
+	defer job.Stop()
+	stats := accounting.StatsGroup(ctx, job.Group)
+
+	call := rc.Calls.Get("job/pause")
+	require.NotNil(t, call)
+	_, err = call.Fn(ctx, rc.Params{"jobid": 1})
+	require.NoError(t, err)
+	assert.True(t, stats.Paused())
+
+	call = rc.Calls.Get("job/resume")
+	require.NotNil(t, call)
+	_, err = call.Fn(ctx, rc.Params{"jobid": 1})
+	require.NoError(t, err)
+	assert.False(t, stats.Paused())
+
+	_, err = call.Fn(ctx, rc.Params{"jobid": 123123123})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "job not found")
+}
+
+func TestRcJobExclude(t *testing.T) {
+	ctx := context.Background()
+	jobID.Store(0)
+	var jobCtx context.Context
+	started := make(chan struct{})
+	job, _, err := NewJob(ctx, func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		jobCtx = ctx
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, rc.Params{"_async": true})
+	require.NoError(t, err)
+	defer job.Stop()
+	<-started
+
+	assert.False(t, Excluded(jobCtx, "file.iso"))
+	assert.False(t, Excluded(ctx, "file.iso"))
+
+	call := rc.Calls.Get("job/exclude")
+	require.NotNil(t, call)
+	out, err := call.Fn(ctx, rc.Params{"jobid": 1, "exclude": "*.iso"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.iso"}, out["exclude"])
+	out, err = call.Fn(ctx, rc.Params{"jobid": 1, "exclude": []any{"tmp/**", "*.bak"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.iso", "tmp/**", "*.bak"}, out["exclude"])
+
+	assert.True(t, Excluded(jobCtx, "dir/file.iso"))
+	assert.True(t, Excluded(jobCtx, "tmp/file.txt"))
+	assert.True(t, Excluded(jobCtx, "file.bak"))
+	assert.False(t, Excluded(jobCtx, "file.txt"))
+	assert.False(t, Excluded(ctx, "file.iso"))
+
+	_, err = call.Fn(ctx, rc.Params{"jobid": 1})
+	require.Error(t, err)
+	_, err = call.Fn(ctx, rc.Params{"jobid": 123123123, "exclude": "*.iso"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "job not found")
+}
+
 func TestRcJobStopGroup(t *testing.T) {
 	ctx := context.Background()
 	jobID.Store(0)
@@ -0,0 +1,331 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/rc"
+)
+
+// Failure is a transfer which failed in a job. These are kept in a
+// journal in the job, one per destination, and retried after the job
+// has finished.
+type Failure struct {
+	SrcFs     string    `json:"srcFs"`
+	SrcRemote string    `json:"srcRemote"`
+	DstFs     string    `json:"dstFs"`
+	DstRemote string    `json:"dstRemote"`
+	Move      bool      `json:"move"`      // set if the file was being moved rather than copied
+	Error     string    `json:"error"`     // error from the last attempt
+	Attempts  int       `json:"attempts"`  // attempts so far including the one in the job
+	LastTry   time.Time `json:"lastTry"`   // when the last attempt finished
+	NextRetry time.Time `json:"nextRetry"` // when it will be retried - zero if not scheduled
+	Retrying  bool      `json:"retrying"`  // set if a retry is in progress
+
+	call  string      // rc call to retry the transfer with
+	timer *time.Timer // timer for the next retry
+}
+
+// retryOptions are the options for retrying failures read from the
+// rc options when the job is made
+type retryOptions struct {
+	retries    int           // number of retries
+	backoff    time.Duration // delay before the first retry
+	maxBackoff time.Duration // maximum delay between retries
+}
+
+// newRetryOptions reads the retry options from opt
+func newRetryOptions(opt *rc.Options) retryOptions {
+	return retryOptions{
+		retries:    opt.JobRetries,
+		backoff:    time.Duration(opt.JobRetryBackoff),
+		maxBackoff: time.Duration(opt.JobRetryMaxBackoff),
+	}
+}
+
+// key returns the destination the failure is recorded under
+func (f *Failure) key() string {
+	return f.DstFs + "\x00" + f.DstRemote
+}
+
+// backoff returns how long to wait before retrying a failure which
+// has been attempted attempts times. It doubles with each attempt
+// from base up to max.
+func backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
+
+// AddFailure records a failed transfer of srcRemote on fsrc to
+// dstRemote on fdst in the job running in ctx, if any, so it can be
+// retried when the job finishes.
+func AddFailure(ctx context.Context, fsrc fs.Info, srcRemote string, fdst fs.Info, dstRemote string, move bool, err error) {
+	job, ok := GetJob(ctx)
+	if !ok || errors.Is(err, context.Canceled) {
+		return
+	}
+	f := &Failure{
+		SrcFs:     fs.ConfigString(fsrc),
+		SrcRemote: srcRemote,
+		DstFs:     fs.ConfigString(fdst),
+		DstRemote: dstRemote,
+		Move:      move,
+		Error:     err.Error(),
+		Attempts:  1,
+		LastTry:   time.Now(),
+		call:      "operations/copyfile",
+	}
+	if move {
+		f.call = "operations/movefile"
+	}
+	job.addFailure(f)
+}
+
+// addFailure adds f to the journal replacing any failure for the
+// same destination
+func (job *Job) addFailure(f *Failure) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	if job.failures == nil {
+		job.failures = make(map[string]*Failure)
+	}
+	if old := job.failures[f.key()]; old != nil {
+		f.Attempts += old.Attempts
+		if old.timer != nil {
+			old.timer.Stop()
+		}
+	}
+	job.failures[f.key()] = f
+}
+
+// scheduleRetry schedules the retry of f if it hasn't used up its
+// attempts - call with the lock held
+func (job *Job) scheduleRetry(f *Failure) {
+	opt := job.retryOpt
+	if f.Attempts > opt.retries || job.retryCtx == nil || job.retryCtx.Err() != nil {
+		return
+	}
+	delay := backoff(f.Attempts, opt.backoff, opt.maxBackoff)
+	f.NextRetry = time.Now().Add(delay)
+	f.timer = time.AfterFunc(delay, func() { job.retry(f) })
+	fs.Debugf(nil, "Job %d: retrying %q in %v", job.ID, f.DstRemote, delay)
+}
+
+// scheduleRetries schedules the retries of the failures in the job -
+// call with the lock held
+func (job *Job) scheduleRetries() {
+	for _, f := range job.failures {
+		if f.timer == nil && !f.Retrying {
+			job.scheduleRetry(f)
+		}
+	}
+}
+
+// retriesPending returns true if any failures are waiting to be
+// retried or being retried - call with the lock held
+func (job *Job) retriesPending() bool {
+	for _, f := range job.failures {
+		if f.timer != nil || f.Retrying {
+			return true
+		}
+	}
+	return false
+}
+
+// retry runs the transfer in f again, removing it from the journal
+// if it succeeds or scheduling another retry if not.
+func (job *Job) retry(f *Failure) {
+	job.mu.Lock()
+	if job.failures[f.key()] != f || f.Retrying {
+		job.mu.Unlock()
+		return
+	}
+	f.timer = nil
+	f.NextRetry = time.Time{}
+	ctx := job.retryCtx
+	if ctx == nil || ctx.Err() != nil {
+		job.mu.Unlock()
+		return
+	}
+	f.Retrying = true
+	in := rc.Params{
+		"srcFs":     f.SrcFs,
+		"srcRemote": f.SrcRemote,
+		"dstFs":     f.DstFs,
+		"dstRemote": f.DstRemote,
+	}
+	job.mu.Unlock()
+
+	var err error
+	call := rc.Calls.Get(f.call)
+	if call == nil {
+		err = fmt.Errorf("couldn't find rc call %q", f.call)
+	} else {
+		fs.Infof(nil, "Job %d: retrying %q (attempt %d)", job.ID, f.DstRemote, f.Attempts+1)
+		_, err = call.Fn(accounting.WithStatsGroup(ctx, job.Group), in)
+	}
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	f.Retrying = false
+	f.Attempts++
+	f.LastTry = time.Now()
+	if err == nil {
+		fs.Infof(nil, "Job %d: retry of %q succeeded", job.ID, f.DstRemote)
+		delete(job.failures, f.key())
+	} else {
+		fs.Errorf(nil, "Job %d: retry of %q failed: %v", job.ID, f.DstRemote, err)
+		f.Error = err.Error()
+		job.scheduleRetry(f)
+	}
+	if !job.retriesPending() {
+		running.kickExpire()
+	}
+}
+
+// requeue retries the failures now, all of them if dstRemote is
+// empty. It returns the number of failures requeued.
+func (job *Job) requeue(dstRemote string) (n int, err error) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	if !job.Finished {
+		return 0, errors.New("can't requeue failures until the job has finished")
+	}
+	if job.retryCtx.Err() != nil {
+		return 0, errors.New("can't requeue failures as the job was stopped")
+	}
+	for _, f := range job.failures {
+		if f.Retrying || (dstRemote != "" && f.DstRemote != dstRemote) {
+			continue
+		}
+		if f.timer != nil {
+			f.timer.Stop()
+			f.timer = nil
+		}
+		go job.retry(f)
+		n++
+	}
+	if n == 0 && dstRemote != "" {
+		return 0, fmt.Errorf("no failure found for %q", dstRemote)
+	}
+	return n, nil
+}
+
+// Failures returns a copy of the failures journal sorted by
+// destination
+func (job *Job) Failures() []Failure {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	failures := make([]Failure, 0, len(job.failures))
+	for _, f := range job.failures {
+		failures = append(failures, *f)
+		failures[len(failures)-1].timer = nil
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].key() < failures[j].key()
+	})
+	return failures
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "job/failures",
+		Fn:    rcJobFailures,
+		Title: "List the failed transfers of a job",
+		Help: `Parameters:
+
+- jobid - id of the job (integer).
+
+The transfers of sync, copy and move jobs which fail are recorded in a
+journal in the job, one entry per destination file. When the job
+finishes these are retried with exponential backoff if
+--rc-job-retries is set. Retries which succeed are removed from the
+journal.
+
+Jobs with retries pending aren't expired until the retries have
+finished.
+
+Returns
+
+- failures - a list of the failed transfers, each with
+    - srcFs - the source remote
+    - srcRemote - the path of the file in srcFs
+    - dstFs - the destination remote
+    - dstRemote - the path of the file in dstFs
+    - move - true if the file was being moved
+    - error - the error from the last attempt
+    - attempts - the number of attempts so far
+    - lastTry - when the last attempt finished
+    - nextRetry - when the next retry is scheduled, zero if none
+    - retrying - true if a retry is in progress
+`,
+	})
+}
+
+// Lists the failed transfers of the job.
+func rcJobFailures(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	jobID, err := in.GetInt64("jobid")
+	if err != nil {
+		return nil, err
+	}
+	job := running.Get(jobID)
+	if job == nil {
+		return nil, errors.New("job not found")
+	}
+	return rc.Params{"failures": job.Failures()}, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "job/requeue",
+		Fn:    rcJobRequeue,
+		Title: "Retry the failed transfers of a job now",
+		Help: `Parameters:
+
+- jobid - id of the job (integer).
+- dstRemote - path of the failed file to retry (optional, string).
+
+This retries the failed transfers of a finished job listed by
+job/failures now, or just the one for dstRemote if set. This can be
+used whether or not --rc-job-retries is set and retries the transfers
+even if they have used up their attempts.
+
+Returns
+
+- requeued - the number of transfers being retried.
+
+Eg
+
+    rclone rc job/requeue jobid=1
+`,
+	})
+}
+
+// Retries the failed transfers of the job.
+func rcJobRequeue(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	jobID, err := in.GetInt64("jobid")
+	if err != nil {
+		return nil, err
+	}
+	dstRemote, err := in.GetString("dstRemote")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	job := running.Get(jobID)
+	if job == nil {
+		return nil, errors.New("job not found")
+	}
+	n, err := job.requeue(dstRemote)
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{"requeued": n}, nil
+}
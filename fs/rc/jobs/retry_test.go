@@ -0,0 +1,144 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	for _, test := range []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour},
+		{100, time.Hour},
+	} {
+		assert.Equal(t, test.want, backoff(test.attempts, time.Minute, time.Hour), test.attempts)
+	}
+}
+
+// retryCalls counts the calls to test/retry which fails until
+// retrySucceedAfter calls have been made
+var (
+	retryCalls        atomic.Int32
+	retrySucceedAfter atomic.Int32
+)
+
+func init() {
+	rc.Add(rc.Call{
+		Path: "test/retry",
+		Fn: func(ctx context.Context, in rc.Params) (rc.Params, error) {
+			if retryCalls.Add(1) < retrySucceedAfter.Load() {
+				return nil, errors.New("transient failure")
+			}
+			return rc.Params{}, nil
+		},
+	})
+}
+
+// newFailingJob runs a job which records a failure retried with
+// test/retry which succeeds on call succeedAfter
+func newFailingJob(t *testing.T, succeedAfter int32) *Job {
+	retryCalls.Store(0)
+	retrySucceedAfter.Store(succeedAfter)
+	job, _, err := NewJob(context.Background(), func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		job, ok := GetJob(ctx)
+		require.True(t, ok)
+		job.addFailure(&Failure{
+			SrcFs:     "src:",
+			SrcRemote: "file.txt",
+			DstFs:     "dst:",
+			DstRemote: "file.txt",
+			Error:     "failed",
+			Attempts:  1,
+			call:      "test/retry",
+		})
+		return nil, errors.New("failed")
+	}, rc.Params{})
+	require.Error(t, err)
+	return job
+}
+
+func setRetryOpt(t *testing.T, retries int) {
+	oldRetries, oldBackoff, oldMaxBackoff := rc.Opt.JobRetries, rc.Opt.JobRetryBackoff, rc.Opt.JobRetryMaxBackoff
+	t.Cleanup(func() {
+		rc.Opt.JobRetries, rc.Opt.JobRetryBackoff, rc.Opt.JobRetryMaxBackoff = oldRetries, oldBackoff, oldMaxBackoff
+	})
+	rc.Opt.JobRetries = retries
+	rc.Opt.JobRetryBackoff = fs.Duration(time.Millisecond)
+	rc.Opt.JobRetryMaxBackoff = fs.Duration(10 * time.Millisecond)
+}
+
+func TestJobRetry(t *testing.T) {
+	setRetryOpt(t, 3)
+
+	// Succeeds on the second retry
+	job := newFailingJob(t, 2)
+	assert.Eventually(t, func() bool {
+		return len(job.Failures()) == 0
+	}, 5*time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), retryCalls.Load())
+
+	// Never succeeds so gives up after 3 retries
+	job = newFailingJob(t, 100)
+	assert.Eventually(t, func() bool {
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		return !job.retriesPending()
+	}, 5*time.Second, time.Millisecond)
+	failures := job.Failures()
+	require.Len(t, failures, 1)
+	assert.Equal(t, 4, failures[0].Attempts)
+	assert.Equal(t, "transient failure", failures[0].Error)
+	assert.True(t, failures[0].NextRetry.IsZero())
+	assert.Equal(t, int32(3), retryCalls.Load())
+}
+
+func TestRcJobFailuresAndRequeue(t *testing.T) {
+	setRetryOpt(t, 0)
+	ctx := context.Background()
+	job := newFailingJob(t, 1)
+
+	call := rc.Calls.Get("job/failures")
+	require.NotNil(t, call)
+	out, err := call.Fn(ctx, rc.Params{"jobid": job.ID})
+	require.NoError(t, err)
+	failures := out["failures"].([]Failure)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "dst:", failures[0].DstFs)
+	assert.Equal(t, "file.txt", failures[0].DstRemote)
+	assert.True(t, failures[0].NextRetry.IsZero())
+	assert.Equal(t, int32(0), retryCalls.Load())
+
+	call = rc.Calls.Get("job/requeue")
+	require.NotNil(t, call)
+	_, err = call.Fn(ctx, rc.Params{"jobid": job.ID, "dstRemote": "potato"})
+	assert.ErrorContains(t, err, "no failure found")
+	out, err = call.Fn(ctx, rc.Params{"jobid": job.ID})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{"requeued": 1}, out)
+	assert.Eventually(t, func() bool {
+		return len(job.Failures()) == 0
+	}, 5*time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), retryCalls.Load())
+
+	_, err = call.Fn(ctx, rc.Params{"jobid": 123123123})
+	assert.ErrorContains(t, err, "job not found")
+}
+
+func TestAddFailureOutsideJob(t *testing.T) {
+	// Shouldn't panic or record anything
+	AddFailure(context.Background(), nil, "a", nil, "a", false, errors.New("failed"))
+}
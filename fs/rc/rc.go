@@ -83,6 +83,36 @@ var OptionsInfo = fs.Options{{
 	Default: fs.Duration(10 * time.Second),
 	Help:    "Interval to check for expired async jobs",
 	Groups:  "RC",
+}, {
+	Name:    "rc_job_retries",
+	Default: 0,
+	Help:    "Number of times to retry the failed transfers of sync, copy and move jobs after they finish",
+	Groups:  "RC",
+}, {
+	Name:    "rc_job_retry_backoff",
+	Default: fs.Duration(time.Minute),
+	Help:    "Time to wait before retrying a failed transfer of a job, doubling for each retry",
+	Groups:  "RC",
+}, {
+	Name:    "rc_job_retry_max_backoff",
+	Default: fs.Duration(time.Hour),
+	Help:    "Maximum time to wait between retries of a failed transfer of a job",
+	Groups:  "RC",
+}, {
+	Name:    "rc_list_cache",
+	Default: false,
+	Help:    "Cache listings in the server for commands run with --rc-use-server",
+	Groups:  "RC",
+}, {
+	Name:    "rc_list_cache_ttl",
+	Default: fs.Duration(5 * time.Minute),
+	Help:    "How long to keep listings in the --rc-list-cache for",
+	Groups:  "RC",
+}, {
+	Name:    "rc_use_server",
+	Default: "",
+	Help:    "URL of a running rc server with --rc-list-cache to share listings with",
+	Groups:  "RC",
 }, {
 	Name:    "metrics_addr",
 	Default: []string{},
@@ -122,6 +152,12 @@ type Options struct {
 	MetricsTemplate     libhttp.TemplateConfig `config:"metrics"`
 	JobExpireDuration   fs.Duration            `config:"rc_job_expire_duration"`
 	JobExpireInterval   fs.Duration            `config:"rc_job_expire_interval"`
+	JobRetries          int                    `config:"rc_job_retries"`           // times to retry failed transfers of jobs
+	JobRetryBackoff     fs.Duration            `config:"rc_job_retry_backoff"`     // delay before the first retry
+	JobRetryMaxBackoff  fs.Duration            `config:"rc_job_retry_max_backoff"` // maximum delay between retries
+	ListCache           bool                   `config:"rc_list_cache"`            // set to cache listings for --rc-use-server
+	ListCacheTTL        fs.Duration            `config:"rc_list_cache_ttl"`        // how long to cache listings for
+	UseServer           string                 `config:"rc_use_server"`            // URL of rc server to share listings with
 }
 
 // Opt is the default values used for Options
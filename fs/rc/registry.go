@@ -47,6 +47,13 @@ func (r *Registry) Add(call Call) {
 	r.call[call.Path] = &call
 }
 
+// Remove the call at path from the registry
+func (r *Registry) Remove(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.call, strings.Trim(path, "/"))
+}
+
 // Get a Call from a path or nil
 func (r *Registry) Get(path string) *Call {
 	r.mu.RLock()
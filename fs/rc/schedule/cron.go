@@ -0,0 +1,198 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// cronField is a set of allowed values for one field as a bitmap
+type cronField uint64
+
+// has returns true if v is in the field
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// cronSpec is a parsed cron style schedule
+type cronSpec struct {
+	minute  cronField
+	hour    cronField
+	dom     cronField
+	month   cronField
+	dow     cronField
+	domStar bool          // day of month was *
+	dowStar bool          // day of week was *
+	every   time.Duration // if set run at this interval instead
+}
+
+// cronShortcuts are the @ names which can be used instead of the fields
+var cronShortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dowNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// parseCron parses a schedule in the standard 5 field cron format
+//
+//	minute hour day-of-month month day-of-week
+//
+// Each field may be *, a number, a range a-b, a list a,b,c or any of
+// these with a /step. Months and days of the week may be given by
+// their first three letters. The @ shortcuts like @daily are
+// supported as is "@every duration".
+func parseCron(spec string) (c *cronSpec, err error) {
+	spec = strings.TrimSpace(spec)
+	if every, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := fs.ParseDuration(strings.TrimSpace(every))
+		if err != nil {
+			return nil, fmt.Errorf("bad @every duration: %w", err)
+		}
+		if d <= 0 {
+			return nil, errors.New("@every duration must be positive")
+		}
+		return &cronSpec{every: d}, nil
+	}
+	if expanded, ok := cronShortcuts[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("need 5 fields in schedule %q but got %d", spec, len(fields))
+	}
+	c = &cronSpec{
+		domStar: strings.HasPrefix(fields[2], "*"),
+		dowStar: strings.HasPrefix(fields[4], "*"),
+	}
+	for i, p := range []struct {
+		name               string
+		field              *cronField
+		minValue, maxValue int
+		names              []string
+		offset             int
+	}{
+		{"minute", &c.minute, 0, 59, nil, 0},
+		{"hour", &c.hour, 0, 23, nil, 0},
+		{"day of month", &c.dom, 1, 31, nil, 0},
+		{"month", &c.month, 1, 12, monthNames, 1},
+		{"day of week", &c.dow, 0, 7, dowNames, 0},
+	} {
+		value := fields[i]
+		*p.field, err = parseCronField(value, p.minValue, p.maxValue, p.names, p.offset)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s %q: %w", p.name, value, err)
+		}
+	}
+	// Sunday may be 0 or 7
+	if c.dow.has(7) {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+// parseCronValue parses a single number or name
+func parseCronValue(s string, names []string, offset int) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return i + offset, nil
+		}
+	}
+	return strconv.Atoi(s)
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps
+func parseCronField(s string, minValue, maxValue int, names []string, offset int) (field cronField, err error) {
+	for part := range strings.SplitSeq(s, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("bad step %q", stepPart)
+			}
+		}
+		lo, hi := minValue, maxValue
+		if rangePart != "*" {
+			loPart, hiPart, isRange := strings.Cut(rangePart, "-")
+			lo, err = parseCronValue(loPart, names, offset)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", loPart)
+			}
+			switch {
+			case isRange:
+				hi, err = parseCronValue(hiPart, names, offset)
+				if err != nil {
+					return 0, fmt.Errorf("bad value %q", hiPart)
+				}
+			case !hasStep:
+				hi = lo
+			}
+		}
+		if lo < minValue || hi > maxValue || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, minValue, maxValue)
+		}
+		for v := lo; v <= hi; v += step {
+			field |= 1 << uint(v)
+		}
+	}
+	return field, nil
+}
+
+// dayMatches returns true if the day of t is allowed.
+//
+// As in cron if both the day of month and day of week are restricted
+// then either matching is enough.
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	domOK := c.dom.has(t.Day())
+	dowOK := c.dow.has(int(t.Weekday()))
+	if c.domStar || c.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// next returns the first time the schedule runs after t or the zero
+// time if it never does.
+func (c *cronSpec) next(t time.Time) time.Time {
+	if c.every > 0 {
+		return t.Add(c.every)
+	}
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	// Give up if nothing matches in a few years, e.g. 30th February
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		switch {
+		case !c.month.has(int(month)):
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		case !c.hour.has(t.Hour()):
+			next := time.Date(year, month, day, t.Hour()+1, 0, 0, 0, loc)
+			if !next.After(t) {
+				// daylight saving time went backwards
+				next = t.Add(time.Hour)
+			}
+			t = next
+		case !c.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
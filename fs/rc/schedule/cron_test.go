@@ -0,0 +1,72 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"x * * * *",
+		"* * * foo *",
+		"@every",
+		"@every -1h",
+		"@every potato",
+	} {
+		_, err := parseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2025-06-30 is a Monday
+	start := time.Date(2025, 6, 30, 14, 25, 30, 0, time.UTC)
+	for _, test := range []struct {
+		spec string
+		want []string
+	}{
+		{"* * * * *", []string{"2025-06-30 14:26", "2025-06-30 14:27"}},
+		{"*/20 * * * *", []string{"2025-06-30 14:40", "2025-06-30 15:00", "2025-06-30 15:20"}},
+		{"0 2 * * *", []string{"2025-07-01 02:00", "2025-07-02 02:00"}},
+		{"@daily", []string{"2025-07-01 00:00", "2025-07-02 00:00"}},
+		{"@hourly", []string{"2025-06-30 15:00", "2025-06-30 16:00"}},
+		{"@monthly", []string{"2025-07-01 00:00", "2025-08-01 00:00"}},
+		{"@yearly", []string{"2026-01-01 00:00", "2027-01-01 00:00"}},
+		{"30 9 * * mon-fri", []string{"2025-07-01 09:30", "2025-07-02 09:30", "2025-07-03 09:30", "2025-07-04 09:30", "2025-07-07 09:30"}},
+		{"0 0 * * 7", []string{"2025-07-06 00:00", "2025-07-13 00:00"}},
+		{"0 12 1,15 * *", []string{"2025-07-01 12:00", "2025-07-15 12:00", "2025-08-01 12:00"}},
+		// both day fields restricted so either matches
+		{"0 0 13 * fri", []string{"2025-07-04 00:00", "2025-07-11 00:00", "2025-07-13 00:00", "2025-07-18 00:00"}},
+		{"0 0 29 feb *", []string{"2028-02-29 00:00", "2032-02-29 00:00"}},
+		{"@every 90m", []string{"2025-06-30 15:55", "2025-06-30 17:25"}},
+	} {
+		c, err := parseCron(test.spec)
+		require.NoError(t, err, test.spec)
+		var got []string
+		next := start
+		for range test.want {
+			next = c.next(next)
+			got = append(got, next.Format("2006-01-02 15:04"))
+		}
+		assert.Equal(t, test.want, got, test.spec)
+	}
+}
+
+func TestCronNextNever(t *testing.T) {
+	c, err := parseCron("0 0 30 feb *")
+	require.NoError(t, err)
+	assert.True(t, c.next(time.Now()).IsZero())
+}
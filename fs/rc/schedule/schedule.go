@@ -0,0 +1,467 @@
+// Package schedule runs sync, copy, move and bisync jobs on a cron
+// style schedule inside a long running rclone rcd.
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/rc/jobs"
+)
+
+// OptionsInfo describes the Options in use
+var OptionsInfo = fs.Options{{
+	Name:    "schedule_file",
+	Default: "",
+	Help:    "File to save the schedules made with schedule/create in so they are restored when rcd starts",
+	Groups:  "RC",
+}, {
+	Name:    "schedule_notify_url",
+	Default: "",
+	Help:    "URL to POST a JSON notification to when a scheduled job fails",
+	Groups:  "RC",
+}}
+
+func init() {
+	fs.RegisterGlobalOptions(fs.OptionsInfo{Name: "schedule", Opt: &Opt, Options: OptionsInfo})
+}
+
+// Options contains options for the scheduler
+type Options struct {
+	File      string `config:"schedule_file"`       // file to persist the schedules in
+	NotifyURL string `config:"schedule_notify_url"` // URL to POST failures to
+}
+
+// Opt is the options for the scheduler
+var Opt Options
+
+// commands maps the command names which can be scheduled to their rc
+// calls
+var commands = map[string]string{
+	"sync":   "sync/sync",
+	"copy":   "sync/copy",
+	"move":   "sync/move",
+	"bisync": "sync/bisync",
+}
+
+// notifyTimeout is how long to wait for the notify URL to respond
+const notifyTimeout = 30 * time.Second
+
+// schedule is a job run on a schedule
+type schedule struct {
+	Name    string    `json:"name"`     // unique name of the schedule
+	Spec    string    `json:"schedule"` // cron style schedule
+	Command string    `json:"command"`  // command to run - a key of commands
+	Params  rc.Params `json:"params"`   // parameters for the command
+
+	// these are protected by scheduler.mu
+	cron      *cronSpec
+	timer     *time.Timer
+	next      time.Time // when it will next run
+	running   bool      // set if a run is in progress
+	jobID     int64     // ID of the last job started
+	lastStart time.Time // when the last run started
+	lastEnd   time.Time // when the last run finished
+	lastError string    // error from the last run or empty
+	runs      int       // number of runs started
+	failures  int       // number of runs which failed
+	skipped   int       // number of runs skipped as the last was still running
+}
+
+// status returns the schedule and how it is doing - call with
+// scheduler.mu held
+func (s *schedule) status() rc.Params {
+	return rc.Params{
+		"name":      s.Name,
+		"schedule":  s.Spec,
+		"command":   s.Command,
+		"params":    s.Params,
+		"next":      s.next,
+		"running":   s.running,
+		"jobid":     s.jobID,
+		"lastStart": s.lastStart,
+		"lastEnd":   s.lastEnd,
+		"lastError": s.lastError,
+		"runs":      s.runs,
+		"failures":  s.failures,
+		"skipped":   s.skipped,
+	}
+}
+
+// scheduler holds the schedules and runs them
+type scheduler struct {
+	mu        sync.Mutex
+	opt       *Options
+	schedules map[string]*schedule
+}
+
+// newScheduler makes a new scheduler
+func newScheduler(opt *Options) *scheduler {
+	return &scheduler{
+		opt:       opt,
+		schedules: make(map[string]*schedule),
+	}
+}
+
+// global is the scheduler used by the rc calls
+var global = newScheduler(&Opt)
+
+// add a schedule and start its timer
+func (sch *scheduler) add(s *schedule) (err error) {
+	if s.Name == "" {
+		return errors.New("schedule needs a name")
+	}
+	path, ok := commands[s.Command]
+	if !ok {
+		return fmt.Errorf("can't schedule command %q - must be one of sync, copy, move or bisync", s.Command)
+	}
+	if rc.Calls.Get(path) == nil {
+		return fmt.Errorf("command %q isn't available in this rclone", s.Command)
+	}
+	s.cron, err = parseCron(s.Spec)
+	if err != nil {
+		return fmt.Errorf("bad schedule %q: %w", s.Spec, err)
+	}
+	if s.cron.next(time.Now()).IsZero() {
+		return fmt.Errorf("schedule %q never runs", s.Spec)
+	}
+	if s.Params == nil {
+		s.Params = rc.Params{}
+	}
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+	if _, found := sch.schedules[s.Name]; found {
+		return fmt.Errorf("schedule %q already exists", s.Name)
+	}
+	sch.schedules[s.Name] = s
+	sch._arm(s)
+	fs.Infof(nil, "schedule: %q: will %s at %v", s.Name, s.Command, s.next)
+	return nil
+}
+
+// remove the schedule called name
+//
+// Any run in progress isn't stopped.
+func (sch *scheduler) remove(name string) error {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+	s, ok := sch.schedules[name]
+	if !ok {
+		return fmt.Errorf("schedule %q not found", name)
+	}
+	s.timer.Stop()
+	delete(sch.schedules, name)
+	return nil
+}
+
+// list returns the status of the schedules sorted by name
+func (sch *scheduler) list() []rc.Params {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+	names := make([]string, 0, len(sch.schedules))
+	for name := range sch.schedules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]rc.Params, 0, len(names))
+	for _, name := range names {
+		out = append(out, sch.schedules[name].status())
+	}
+	return out
+}
+
+// _arm sets the timer for the next run of s - call with mu held
+func (sch *scheduler) _arm(s *schedule) {
+	s.next = s.cron.next(time.Now())
+	if s.next.IsZero() {
+		fs.Errorf(nil, "schedule: %q: won't run again", s.Name)
+		return
+	}
+	s.timer = time.AfterFunc(time.Until(s.next), func() {
+		sch.fire(s)
+	})
+}
+
+// fire is called when the timer for s goes off
+func (sch *scheduler) fire(s *schedule) {
+	sch.mu.Lock()
+	defer sch.mu.Unlock()
+	if sch.schedules[s.Name] != s {
+		// schedule was removed
+		return
+	}
+	if s.running {
+		s.skipped++
+		fs.Logf(nil, "schedule: %q: skipping run as job %d is still running", s.Name, s.jobID)
+	} else {
+		sch._start(s)
+	}
+	sch._arm(s)
+}
+
+// _start runs the command for s in the background - call with mu held
+func (sch *scheduler) _start(s *schedule) {
+	in := s.Params.Copy()
+	in["_async"] = true
+	if _, ok := in["_group"]; !ok {
+		in["_group"] = "schedule/" + s.Name
+	}
+	s.runs++
+	s.running = true
+	s.lastStart = time.Now()
+	job, _, err := jobs.NewJob(context.Background(), rc.Calls.Get(commands[s.Command]).Fn, in)
+	if err != nil {
+		sch._finished(s, err)
+		return
+	}
+	s.jobID = job.ID
+	fs.Infof(nil, "schedule: %q: started %s as job %d", s.Name, s.Command, job.ID)
+	job.OnFinish(func() {
+		var err error
+		if job.Error != "" {
+			err = errors.New(job.Error)
+		}
+		sch.mu.Lock()
+		defer sch.mu.Unlock()
+		sch._finished(s, err)
+	})
+}
+
+// _finished records the end of a run of s - call with mu held
+func (sch *scheduler) _finished(s *schedule, err error) {
+	s.running = false
+	s.lastEnd = time.Now()
+	if err == nil {
+		s.lastError = ""
+		fs.Infof(nil, "schedule: %q: job %d succeeded", s.Name, s.jobID)
+		return
+	}
+	s.failures++
+	s.lastError = err.Error()
+	fs.Errorf(nil, "schedule: %q: job %d failed: %v", s.Name, s.jobID, err)
+	if sch.opt.NotifyURL != "" {
+		go sch.notify(s.status())
+	}
+}
+
+// notify POSTs the status of a failed run to the notify URL
+func (sch *scheduler) notify(status rc.Params) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	err := func() error {
+		body, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", sch.opt.NotifyURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := fshttp.NewClient(ctx).Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
+		}
+		return nil
+	}()
+	if err != nil {
+		fs.Errorf(nil, "schedule: %q: failed to send notification: %v", status["name"], err)
+	}
+}
+
+// save writes the schedules to the schedule file if set
+func (sch *scheduler) save() error {
+	if sch.opt.File == "" {
+		return nil
+	}
+	sch.mu.Lock()
+	saved := make([]*schedule, 0, len(sch.schedules))
+	for _, s := range sch.schedules {
+		saved = append(saved, s)
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].Name < saved[j].Name })
+	data, err := json.MarshalIndent(saved, "", "\t")
+	sch.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(sch.opt.File, data, 0600); err != nil {
+		return fmt.Errorf("failed to save schedules: %w", err)
+	}
+	return nil
+}
+
+// load reads the schedules from the schedule file if set
+func (sch *scheduler) load() error {
+	if sch.opt.File == "" {
+		return nil
+	}
+	data, err := os.ReadFile(sch.opt.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to load schedules: %w", err)
+	}
+	var loaded []*schedule
+	if err = json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to parse schedules in %q: %w", sch.opt.File, err)
+	}
+	for _, s := range loaded {
+		if err := sch.add(s); err != nil {
+			fs.Errorf(nil, "schedule: failed to restore %q: %v", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Load restores the schedules saved in --schedule-file.
+//
+// It should be called once when the rc server starts.
+func Load() error {
+	return global.load()
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "schedule/create",
+		AuthRequired: true,
+		Fn:           rcCreate,
+		Title:        "Create a schedule to run a job repeatedly",
+		Help: `This runs a sync, copy, move or bisync on a cron style schedule
+for as long as the rc server is running.
+
+Parameters:
+
+- name - unique name for the schedule
+- schedule - when to run, see below
+- command - one of sync, copy, move or bisync
+- any other parameters are passed to the command, e.g. srcFs and dstFs,
+  including _config and _filter
+
+The schedule is in the usual cron format of 5 fields
+"minute hour day-of-month month day-of-week", e.g. "30 2 * * 1-5" for
+02:30 on weekdays, in the local time zone. The shortcuts @hourly,
+@daily, @weekly, @monthly and @yearly may be used, as may
+"@every duration" e.g. "@every 6h".
+
+Each run is an async job in the stats group "schedule/name" unless
+_group is passed, so can be watched with job/status and core/stats.
+If the previous run is still going when a schedule is due then that
+run is skipped.
+
+If a run fails the error is logged and, if --schedule-notify-url is
+set, the status of the schedule as returned by schedule/list is
+POSTed to it as JSON.
+
+If --schedule-file is set the schedules are saved in it and restored
+when rclone rcd starts.
+
+Eg
+
+    rclone rc schedule/create name=nightly schedule="0 2 * * *" command=sync srcFs=/home/user dstFs=remote:backup
+`,
+	})
+}
+
+// Create a schedule
+func rcCreate(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	in = in.Copy()
+	s := &schedule{}
+	for _, p := range []struct {
+		key   string
+		value *string
+	}{
+		{"name", &s.Name},
+		{"schedule", &s.Spec},
+		{"command", &s.Command},
+	} {
+		*p.value, err = in.GetString(p.key)
+		if err != nil {
+			return nil, err
+		}
+		delete(in, p.key)
+	}
+	delete(in, "_async")
+	s.Params = in
+	if err = global.add(s); err != nil {
+		return nil, err
+	}
+	if err = global.save(); err != nil {
+		return nil, err
+	}
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	return s.status(), nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "schedule/list",
+		Fn:    rcList,
+		Title: "List the schedules and the state of their runs",
+		Help: `This takes no parameters and returns
+
+- schedules - a list of the schedules sorted by name, each with
+    - name, schedule, command, params - as passed to schedule/create
+    - next - when it will next run
+    - running - true if a run is in progress
+    - jobid - the job ID of the last run
+    - lastStart - when the last run started
+    - lastEnd - when the last run finished
+    - lastError - the error from the last run or "" if it succeeded
+    - runs - the number of runs started
+    - failures - the number of runs which failed
+    - skipped - the number of runs skipped as the previous was still running
+`,
+	})
+}
+
+// List the schedules
+func rcList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	return rc.Params{
+		"schedules": global.list(),
+	}, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "schedule/delete",
+		AuthRequired: true,
+		Fn:           rcDelete,
+		Title:        "Delete a schedule",
+		Help: `This stops the schedule from running again. Any run in
+progress carries on - use job/stop to stop it.
+
+Parameters:
+
+- name - name of the schedule to delete
+`,
+	})
+}
+
+// Delete a schedule
+func rcDelete(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	name, err := in.GetString("name")
+	if err != nil {
+		return nil, err
+	}
+	if err = global.remove(name); err != nil {
+		return nil, err
+	}
+	return nil, global.save()
+}
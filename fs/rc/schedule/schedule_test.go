@@ -0,0 +1,200 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCalls counts the calls to the schedule/test rc call and
+// blocks until release is closed if it is set
+var testCalls = make(chan rc.Params, 100)
+
+func init() {
+	commands["test"] = "schedule/test"
+	rc.Add(rc.Call{
+		Path: "schedule/test",
+		Fn: func(ctx context.Context, in rc.Params) (rc.Params, error) {
+			testCalls <- in
+			if release, ok := in["release"].(chan struct{}); ok {
+				<-release
+			}
+			if fail, _ := in.GetBool("fail"); fail {
+				return nil, errors.New("test failure")
+			}
+			return nil, nil
+		},
+	})
+}
+
+// setup makes a new global scheduler with opt
+func setup(t *testing.T, opt Options) {
+	oldGlobal := global
+	global = newScheduler(&opt)
+	t.Cleanup(func() {
+		for _, s := range global.list() {
+			_ = global.remove(s["name"].(string))
+		}
+		global = oldGlobal
+	})
+}
+
+// status returns the status of the schedule called name
+func status(t *testing.T, name string) rc.Params {
+	for _, s := range global.list() {
+		if s["name"] == name {
+			return s
+		}
+	}
+	t.Fatalf("schedule %q not found", name)
+	return nil
+}
+
+func TestScheduleCreateErrors(t *testing.T) {
+	setup(t, Options{})
+	call := rc.Calls.Get("schedule/create")
+	for _, in := range []rc.Params{
+		{"schedule": "@daily", "command": "test"},
+		{"name": "a", "command": "test"},
+		{"name": "a", "schedule": "@daily"},
+		{"name": "a", "schedule": "@daily", "command": "potato"},
+		{"name": "a", "schedule": "61 * * * *", "command": "test"},
+		{"name": "a", "schedule": "0 0 31 feb *", "command": "test"},
+	} {
+		_, err := call.Fn(context.Background(), in)
+		assert.Error(t, err, in)
+	}
+	_, err := call.Fn(context.Background(), rc.Params{"name": "a", "schedule": "@daily", "command": "test"})
+	require.NoError(t, err)
+	_, err = call.Fn(context.Background(), rc.Params{"name": "a", "schedule": "@daily", "command": "test"})
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestScheduleRun(t *testing.T) {
+	setup(t, Options{})
+	out, err := rc.Calls.Get("schedule/create").Fn(context.Background(), rc.Params{
+		"name":     "quick",
+		"schedule": "@every 10ms",
+		"command":  "test",
+		"srcFs":    "/src",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "quick", out["name"])
+	assert.Equal(t, rc.Params{"srcFs": "/src"}, out["params"])
+
+	in := <-testCalls
+	assert.Equal(t, "/src", in["srcFs"])
+	<-testCalls
+
+	out, err = rc.Calls.Get("schedule/list").Fn(context.Background(), nil)
+	require.NoError(t, err)
+	schedules := out["schedules"].([]rc.Params)
+	require.Len(t, schedules, 1)
+	assert.GreaterOrEqual(t, schedules[0]["runs"], 2)
+
+	_, err = rc.Calls.Get("schedule/delete").Fn(context.Background(), rc.Params{"name": "quick"})
+	require.NoError(t, err)
+	assert.Len(t, global.list(), 0)
+	_, err = rc.Calls.Get("schedule/delete").Fn(context.Background(), rc.Params{"name": "quick"})
+	assert.Error(t, err)
+
+	// Drain any calls which were already in flight
+	time.Sleep(50 * time.Millisecond)
+	for len(testCalls) > 0 {
+		<-testCalls
+	}
+}
+
+func TestScheduleOverlap(t *testing.T) {
+	setup(t, Options{})
+	release := make(chan struct{})
+	require.NoError(t, global.add(&schedule{
+		Name:    "slow",
+		Spec:    "@every 10ms",
+		Command: "test",
+		Params:  rc.Params{"release": release},
+	}))
+	<-testCalls
+
+	// Wait for some runs to be skipped
+	assert.Eventually(t, func() bool {
+		return status(t, "slow")["skipped"].(int) >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	s := status(t, "slow")
+	assert.Equal(t, 1, s["runs"])
+	assert.Equal(t, true, s["running"])
+
+	require.NoError(t, global.remove("slow"))
+	close(release)
+}
+
+func TestScheduleNotify(t *testing.T) {
+	notified := make(chan rc.Params, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		var in rc.Params
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		notified <- in
+	}))
+	defer server.Close()
+
+	setup(t, Options{NotifyURL: server.URL})
+	require.NoError(t, global.add(&schedule{
+		Name:    "failing",
+		Spec:    "@every 10ms",
+		Command: "test",
+		Params:  rc.Params{"fail": true},
+	}))
+
+	in := <-notified
+	require.NoError(t, global.remove("failing"))
+	assert.Equal(t, "failing", in["name"])
+	assert.Equal(t, "test failure", in["lastError"])
+	assert.GreaterOrEqual(t, in["failures"], float64(1))
+	for len(testCalls) > 0 {
+		<-testCalls
+	}
+}
+
+func TestScheduleSaveLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "schedules.json")
+	setup(t, Options{File: file})
+
+	// Nothing to load yet
+	require.NoError(t, global.load())
+
+	_, err := rc.Calls.Get("schedule/create").Fn(context.Background(), rc.Params{
+		"name":     "nightly",
+		"schedule": "0 2 * * *",
+		"command":  "test",
+		"dstFs":    "remote:backup",
+	})
+	require.NoError(t, err)
+	_, err = rc.Calls.Get("schedule/create").Fn(context.Background(), rc.Params{
+		"name":     "weekly",
+		"schedule": "@weekly",
+		"command":  "test",
+	})
+	require.NoError(t, err)
+	_, err = rc.Calls.Get("schedule/delete").Fn(context.Background(), rc.Params{"name": "weekly"})
+	require.NoError(t, err)
+
+	// Load into a new scheduler
+	global = newScheduler(&Options{File: file})
+	require.NoError(t, global.load())
+	schedules := global.list()
+	require.Len(t, schedules, 1)
+	assert.Equal(t, "nightly", schedules[0]["name"])
+	assert.Equal(t, "0 2 * * *", schedules[0]["schedule"])
+	assert.Equal(t, rc.Params{"dstFs": "remote:backup"}, schedules[0]["params"])
+	assert.True(t, schedules[0]["next"].(time.Time).After(time.Now()))
+}
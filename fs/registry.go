@@ -29,6 +29,26 @@ var optDescription = Option{
 	Advanced: true,
 }
 
+// optEncodingTable is a custom character mapping table applied to
+// names uploaded to the remote
+var optEncodingTable = Option{
+	Name: "encoding_table",
+	Help: `Custom character mapping table for names uploaded to the remote.
+
+This is either a JSON object mapping strings to their replacements,
+for example '{"&": "and", "{emoji}": "_"}', or the path of a file
+containing a JSON object or CSV lines of "from,to".
+
+The special keys {diacritics}, {emoji} and {nonascii} replace those
+classes of characters.
+
+Unlike the encoding option, the mapping isn't reversed when listing so
+the names are changed on the remote. See the [encoding table
+docs](/overview/#encoding-table) for more info.`,
+	Default:  "",
+	Advanced: true,
+}
+
 // RegInfo provides information about a filesystem
 type RegInfo struct {
 	// Name of this fs
@@ -409,7 +429,7 @@ func Register(info *RegInfo) {
 	if info.Prefix == "" {
 		info.Prefix = info.Name
 	}
-	info.Options = append(info.Options, optDescription)
+	info.Options = append(info.Options, optDescription, optEncodingTable)
 	Registry = append(Registry, info)
 	for _, alias := range info.Aliases {
 		// Copy the info block and rename and hide the alias and options
@@ -0,0 +1,203 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/lib/kv"
+)
+
+// dedupeFacility is the name of the database used by --dedupe-uploads
+const dedupeFacility = "dedupe-uploads"
+
+// dedupeUploads implements --dedupe-uploads
+//
+// It keeps an index on disk of the hash and size of each file
+// uploaded to the destination along with where it was uploaded to.
+// When a file with the same hash and size needs transferring it is
+// server-side copied from the existing file on the destination
+// instead of being uploaded again.
+//
+// The index is kept in a key-value database in the cache directory so
+// memory use stays bounded and it is shared by later commands to the
+// same destination.
+type dedupeUploads struct {
+	fdst     fs.Fs
+	ht       hash.Type
+	prefix   string // prefix for keys so different roots don't clash
+	db       *kv.DB
+	mu       sync.Mutex
+	inFlight map[string]chan struct{} // closed when the upload of the key finishes
+}
+
+// newDedupeUploads starts the index for --dedupe-uploads returning nil
+// if it can't be used.
+func newDedupeUploads(ctx context.Context, fdst, fsrc fs.Fs, ht hash.Type) *dedupeUploads {
+	if fdst.Features().Copy == nil {
+		fs.Errorf(fdst, "Ignoring --dedupe-uploads as the destination does not support server-side copy")
+		return nil
+	}
+	if ht == hash.None {
+		ht = fsrc.Hashes().GetOne()
+	}
+	if ht == hash.None {
+		fs.Errorf(fdst, "Ignoring --dedupe-uploads as the source does not support any hashes")
+		return nil
+	}
+	if !kv.Supported() {
+		fs.Errorf(fdst, "Ignoring --dedupe-uploads as it is not supported on this OS")
+		return nil
+	}
+	db, err := kv.Start(ctx, dedupeFacility, fdst)
+	if err != nil {
+		fs.Errorf(fdst, "Ignoring --dedupe-uploads as the index failed to open: %v", err)
+		return nil
+	}
+	fs.Debugf(fdst, "Using --dedupe-uploads with %v hash and index %q", ht, db.Path())
+	return &dedupeUploads{
+		fdst:     fdst,
+		ht:       ht,
+		prefix:   fs.ConfigString(fdst) + "\x00",
+		db:       db,
+		inFlight: make(map[string]chan struct{}),
+	}
+}
+
+// close the index
+func (d *dedupeUploads) close() {
+	if err := d.db.Stop(false); err != nil {
+		fs.Errorf(d.fdst, "Failed to close --dedupe-uploads index: %v", err)
+	}
+}
+
+// lock the key waiting for any other transfer of it to finish
+//
+// Call the returned function to unlock it.
+func (d *dedupeUploads) lock(ctx context.Context, key string) (unlock func(), err error) {
+	for {
+		d.mu.Lock()
+		done, found := d.inFlight[key]
+		if !found {
+			done = make(chan struct{})
+			d.inFlight[key] = done
+			d.mu.Unlock()
+			return func() {
+				d.mu.Lock()
+				delete(d.inFlight, key)
+				d.mu.Unlock()
+				close(done)
+			}, nil
+		}
+		d.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// find an object on the destination matching the key, size and hash
+// or nil if there isn't one.
+func (d *dedupeUploads) find(ctx context.Context, key string, size int64, srcHash string) fs.Object {
+	op := &dedupeGet{key: d.prefix + key}
+	err := d.db.Do(false, op)
+	if err != nil {
+		if !errors.Is(err, kv.ErrEmpty) {
+			fs.Errorf(d.fdst, "Failed to read --dedupe-uploads index: %v", err)
+		}
+		return nil
+	}
+	if op.remote == "" {
+		return nil
+	}
+	o, err := d.fdst.NewObject(ctx, op.remote)
+	if err != nil {
+		fs.Debugf(op.remote, "Not using for --dedupe-uploads: %v", err)
+		return nil
+	}
+	if o.Size() != size {
+		fs.Debugf(o, "Not using for --dedupe-uploads as size changed")
+		return nil
+	}
+	if d.fdst.Hashes().Contains(d.ht) {
+		dstHash, err := o.Hash(ctx, d.ht)
+		if err == nil && dstHash != "" && !hash.Equals(dstHash, srcHash) {
+			fs.Debugf(o, "Not using for --dedupe-uploads as %v hash changed", d.ht)
+			return nil
+		}
+	}
+	return o
+}
+
+// put the remote into the index under key
+func (d *dedupeUploads) put(key string, remote string) {
+	err := d.db.Do(true, &dedupePut{key: d.prefix + key, remote: remote})
+	if err != nil {
+		fs.Errorf(d.fdst, "Failed to update --dedupe-uploads index: %v", err)
+	}
+}
+
+// copy src to dst or remote, server-side copying an identical file
+// already on the destination if there is one, otherwise calling
+// upload and recording the result in the index.
+func (d *dedupeUploads) copy(ctx context.Context, dst fs.Object, src fs.Object, modifyWindow time.Duration, upload func() (fs.Object, error)) (newDst fs.Object, err error) {
+	size := src.Size()
+	srcHash, err := src.Hash(ctx, d.ht)
+	if err != nil || srcHash == "" || size < 0 {
+		return upload()
+	}
+	key := fmt.Sprintf("%v:%s:%d", d.ht, srcHash, size)
+	unlock, err := d.lock(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if existing := d.find(ctx, key, size, srcHash); existing != nil && existing.Remote() != src.Remote() {
+		fs.Debugf(src, "Server-side copying from identical %q for --dedupe-uploads", existing.Remote())
+		newDst, err = operations.Copy(ctx, d.fdst, dst, src.Remote(), existing)
+		if err == nil && newDst != nil && modifyWindow != fs.ModTimeNotSupported {
+			modTime := src.ModTime(ctx)
+			if dt := newDst.ModTime(ctx).Sub(modTime); dt > modifyWindow || dt < -modifyWindow {
+				if err := newDst.SetModTime(ctx, modTime); err != nil {
+					fs.Debugf(newDst, "Failed to set modification time after --dedupe-uploads copy: %v", err)
+				}
+			}
+		}
+		return newDst, err
+	}
+	newDst, err = upload()
+	if err == nil && newDst != nil {
+		d.put(key, newDst.Remote())
+	}
+	return newDst, err
+}
+
+// dedupeGet reads the remote for key from the index
+type dedupeGet struct {
+	key    string
+	remote string
+}
+
+// Do the get
+func (op *dedupeGet) Do(ctx context.Context, b kv.Bucket) error {
+	op.remote = string(b.Get([]byte(op.key)))
+	return nil
+}
+
+// dedupePut writes the remote for key to the index
+type dedupePut struct {
+	key    string
+	remote string
+}
+
+// Do the put
+func (op *dedupePut) Do(ctx context.Context, b kv.Bucket) error {
+	return b.Put([]byte(op.key), []byte(op.remote))
+}
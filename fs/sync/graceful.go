@@ -0,0 +1,129 @@
+package sync
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/rc/jobs"
+	"github.com/rclone/rclone/lib/atexit"
+)
+
+// ErrorGracefulStop is returned when the sync was stopped with
+// --graceful-stop or job/stop graceful=true
+var ErrorGracefulStop = errors.New("sync stopped gracefully before it was finished")
+
+// registerGracefulStop arranges for the sync to be stopped gracefully
+// on the first interrupt if --graceful-stop is set, or by job/stop if
+// running in an rc job.
+//
+// It returns a function to undo the registrations.
+func (s *syncCopyMove) registerGracefulStop() func() {
+	var undo []func()
+	if s.ci.GracefulStop {
+		undo = append(undo, atexit.Graceful(s.stopGracefully))
+	}
+	if job, ok := jobs.GetJob(s.ctx); ok {
+		undo = append(undo, job.OnStopGraceful(s.stopGracefully))
+	}
+	return func() {
+		for _, fn := range undo {
+			fn()
+		}
+	}
+}
+
+// stopGracefully stops the checks and new transfers but lets the
+// transfers in progress finish.
+func (s *syncCopyMove) stopGracefully() {
+	if !s.gracefulStopped.CompareAndSwap(false, true) {
+		return
+	}
+	fs.Logf(s.fdst, "Stopping gracefully - waiting for transfers in progress to finish")
+	// Record a fatal error so the sync isn't retried and nothing is
+	// deleted, but don't cancel the transfers as processError would
+	s.errorMu.Lock()
+	s.fatalErr = fserrors.FatalError(ErrorGracefulStop)
+	s.errorMu.Unlock()
+	s.inCancel()
+}
+
+// addRemaining records a pair which was still to be transferred when
+// the sync was stopped gracefully
+func (s *syncCopyMove) addRemaining(pair fs.ObjectPair) {
+	if !s.gracefulStopped.Load() {
+		return
+	}
+	s.remainingMu.Lock()
+	s.remaining = append(s.remaining, pair)
+	s.remainingMu.Unlock()
+}
+
+// saveRemaining writes the files still to be transferred after a
+// graceful stop to --graceful-stop-list so the sync can be resumed
+// with --files-from.
+//
+// This is only possible if the listing was complete.
+func (s *syncCopyMove) saveRemaining(listingComplete bool) error {
+	if !s.gracefulStopped.Load() {
+		return nil
+	}
+	s.remainingMu.Lock()
+	remaining := s.remaining
+	s.remainingMu.Unlock()
+	for _, p := range []*pipe{s.toBeChecked, s.toBeRenamed, s.toBeUploaded} {
+		remaining = append(remaining, p.drain()...)
+	}
+	if s.ci.GracefulStopList == "" {
+		fs.Logf(s.fdst, "Stopped gracefully with %d files still to be checked or transferred", len(remaining))
+		return nil
+	}
+	if !listingComplete {
+		fs.Logf(s.fdst, "Not writing --graceful-stop-list %q as the listing wasn't complete - run the command again to resume", s.ci.GracefulStopList)
+		return nil
+	}
+	header := fmt.Sprintf("rclone stopped gracefully at %s with these files still to be checked or transferred", time.Now().Format(time.RFC3339))
+	n, err := s.writeFilesFrom(s.ci.GracefulStopList, header, remaining)
+	if err != nil {
+		return fmt.Errorf("failed to write --graceful-stop-list: %w", err)
+	}
+	fs.Logf(s.fdst, "Stopped gracefully - wrote %d files still to be checked or transferred to %q", n, s.ci.GracefulStopList)
+	return nil
+}
+
+// writeFilesFrom writes the sorted unique source remotes of pairs to
+// fileName in --files-from format with header as a comment. It
+// returns the number of remotes written.
+func (s *syncCopyMove) writeFilesFrom(fileName, header string, pairs []fs.ObjectPair) (n int, err error) {
+	remotes := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		remotes = append(remotes, pair.Src.Remote())
+	}
+	slices.Sort(remotes)
+	remotes = slices.Compact(remotes)
+
+	if err := os.MkdirAll(filepath.Dir(fileName), 0777); err != nil {
+		return 0, err
+	}
+	file, err := os.Create(fileName)
+	if err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriter(file)
+	_, _ = fmt.Fprintf(w, "# %s\n", header)
+	_, _ = fmt.Fprintf(w, "# from %s to %s - resume with --files-from\n", fs.ConfigString(s.fsrc), fs.ConfigString(s.fdst))
+	for _, remote := range remotes {
+		_, _ = fmt.Fprintln(w, remote)
+	}
+	err = w.Flush()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return len(remotes), err
+}
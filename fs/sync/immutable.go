@@ -0,0 +1,397 @@
+package sync
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// immutableManifestHeader starts the first line of an --immutable-manifest
+const immutableManifestHeader = "# rclone immutable manifest hash="
+
+// Types of violation in the --immutable-report
+const (
+	violationModified      = "modified"       // file changed since it was recorded
+	violationMissing       = "missing"        // file deleted since it was recorded
+	violationModifyRefused = "modify-refused" // the sync wanted to overwrite the file
+	violationDeleteRefused = "delete-refused" // the sync wanted to delete the file
+)
+
+// immutableEntry is what is recorded about a destination file
+type immutableEntry struct {
+	Size int64  `json:"size"`
+	Hash string `json:"hash,omitempty"`
+}
+
+// immutableViolation is a single entry in the --immutable-report
+type immutableViolation struct {
+	Type     string          `json:"type"`
+	Path     string          `json:"path"`
+	Time     time.Time       `json:"time"`
+	Expected *immutableEntry `json:"expected,omitempty"`
+	Actual   *immutableEntry `json:"actual,omitempty"`
+}
+
+// immutableReport is the document written to --immutable-report
+type immutableReport struct {
+	Source      string               `json:"source"`
+	Destination string               `json:"destination"`
+	Manifest    string               `json:"manifest"`
+	HashType    string               `json:"hashType"`
+	Started     time.Time            `json:"started"`
+	Finished    time.Time            `json:"finished"`
+	Complete    bool                 `json:"complete"`
+	Violations  []immutableViolation `json:"violations"`
+}
+
+// immutableManifest implements --immutable-manifest
+//
+// It records the size and hash of every file seen on the destination
+// in a local file. On later syncs each destination file is checked
+// against what was recorded, and files which have gone missing are
+// reported. Files are only ever added to the manifest so it keeps the
+// state of the destination when each file was first seen.
+type immutableManifest struct {
+	path       string
+	reportPath string
+	ht         hash.Type
+	fsrc       fs.Fs
+	fdst       fs.Fs
+	started    time.Time
+
+	mu         sync.Mutex
+	entries    map[string]immutableEntry // recorded files by destination path
+	seen       map[string]struct{}       // destination paths seen in this sync
+	violations []immutableViolation
+}
+
+// newImmutableManifest reads the manifest at path, returning an empty
+// manifest if it doesn't exist yet.
+func newImmutableManifest(path, reportPath string, fdst, fsrc fs.Fs) (*immutableManifest, error) {
+	m := &immutableManifest{
+		path:       path,
+		reportPath: reportPath,
+		ht:         fdst.Hashes().GetOne(),
+		fsrc:       fsrc,
+		fdst:       fdst,
+		started:    time.Now(),
+		entries:    make(map[string]immutableEntry),
+		seen:       make(map[string]struct{}),
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fs.Infof(nil, "No --immutable-manifest %q yet - recording the destination", path)
+		if m.ht == hash.None {
+			fs.Logf(fdst, "Destination has no hashes so --immutable-manifest can only check sizes")
+		}
+		return m, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	if !scanner.Scan() {
+		return m, scanner.Err()
+	}
+	hashName, ok := strings.CutPrefix(scanner.Text(), immutableManifestHeader)
+	if !ok {
+		return nil, fmt.Errorf("%q is not an --immutable-manifest", path)
+	}
+	m.ht = hash.None
+	if hashName != hash.None.String() {
+		if err := m.ht.Set(hashName); err != nil {
+			return nil, fmt.Errorf("%q: %w", path, err)
+		}
+		if !fdst.Hashes().Contains(m.ht) {
+			return nil, fmt.Errorf("destination doesn't support the %s hash used by --immutable-manifest %q", hashName, path)
+		}
+	}
+	for lineNumber := 2; scanner.Scan(); lineNumber++ {
+		remote, entry, err := parseImmutableManifestLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		m.entries[remote] = entry
+	}
+	return m, scanner.Err()
+}
+
+// parseImmutableManifestLine parses a line of the form
+//
+//	size hash "path"
+//
+// where hash is "-" if there isn't one.
+func parseImmutableManifestLine(line string) (remote string, entry immutableEntry, err error) {
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) != 3 {
+		return "", entry, errors.New("not enough fields")
+	}
+	entry.Size, err = strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return "", entry, fmt.Errorf("bad size: %w", err)
+	}
+	if fields[1] != "-" {
+		entry.Hash = fields[1]
+	}
+	remote, err = strconv.Unquote(fields[2])
+	if err != nil {
+		return "", entry, fmt.Errorf("bad path: %w", err)
+	}
+	return remote, entry, nil
+}
+
+// entryOf reads the size and hash of dst
+func (m *immutableManifest) entryOf(ctx context.Context, dst fs.Object) immutableEntry {
+	entry := immutableEntry{Size: dst.Size()}
+	if m.ht != hash.None {
+		sum, err := dst.Hash(ctx, m.ht)
+		if err != nil {
+			fs.Debugf(dst, "Failed to read %v hash for --immutable-manifest: %v", m.ht, err)
+		}
+		entry.Hash = sum
+	}
+	return entry
+}
+
+// addViolation records a violation for the report
+//
+// Call with the lock held.
+func (m *immutableManifest) addViolation(v immutableViolation) {
+	v.Time = time.Now()
+	m.violations = append(m.violations, v)
+}
+
+// check verifies dst against the manifest, recording it if it isn't
+// in the manifest yet.
+//
+// It returns an error if dst has been modified since it was recorded.
+func (m *immutableManifest) check(ctx context.Context, dst fs.Object) error {
+	actual := m.entryOf(ctx, dst)
+	remote := dst.Remote()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seen[remote] = struct{}{}
+	expected, ok := m.entries[remote]
+	if !ok {
+		m.entries[remote] = actual
+		return nil
+	}
+	if expected.Size == actual.Size && (expected.Hash == "" || actual.Hash == "" || expected.Hash == actual.Hash) {
+		return nil
+	}
+	m.addViolation(immutableViolation{
+		Type:     violationModified,
+		Path:     remote,
+		Expected: &expected,
+		Actual:   &actual,
+	})
+	err := fs.CountError(ctx, fserrors.NoRetryError(fs.ErrorImmutableModified))
+	fs.Errorf(dst, "Modified since it was recorded in --immutable-manifest: %v", err)
+	return err
+}
+
+// record adds a file newly transferred to the destination to the
+// manifest.
+//
+// If the file was in the manifest already then it must have gone
+// missing from the destination for it to be transferred again, so
+// this is reported as a violation and returned as an error.
+func (m *immutableManifest) record(ctx context.Context, dst fs.Object) error {
+	actual := m.entryOf(ctx, dst)
+	remote := dst.Remote()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seen[remote] = struct{}{}
+	expected, ok := m.entries[remote]
+	if !ok {
+		m.entries[remote] = actual
+		return nil
+	}
+	m.addViolation(immutableViolation{
+		Type:     violationMissing,
+		Path:     remote,
+		Expected: &expected,
+		Actual:   &actual,
+	})
+	err := fs.CountError(ctx, fserrors.NoRetryError(fs.ErrorImmutableMissing))
+	fs.Errorf(dst, "Uploaded again as it was missing from destination but recorded in --immutable-manifest: %v", err)
+	return err
+}
+
+// refused records that the sync wanted to overwrite dst with src, or
+// delete dst if src is nil, which --immutable doesn't allow.
+func (m *immutableManifest) refused(ctx context.Context, dst, src fs.Object) {
+	expected := m.entryOf(ctx, dst)
+	v := immutableViolation{
+		Type:     violationDeleteRefused,
+		Path:     dst.Remote(),
+		Expected: &expected,
+	}
+	if src != nil {
+		actual := immutableEntry{Size: src.Size()}
+		if m.ht != hash.None && m.fsrc.Hashes().Contains(m.ht) {
+			actual = m.entryOf(ctx, src)
+		}
+		v.Type = violationModifyRefused
+		v.Actual = &actual
+	}
+	m.mu.Lock()
+	m.addViolation(v)
+	m.mu.Unlock()
+}
+
+// finish reports the files in the manifest which weren't found on the
+// destination, if the listing was complete, and returns an error if
+// there were any.
+//
+// dir is the directory the sync started at and fi the filters in use.
+func (m *immutableManifest) finish(ctx context.Context, dir string, fi *filter.Filter, listingComplete bool) error {
+	if !listingComplete {
+		fs.Logf(m.fdst, "Not checking for missing files in --immutable-manifest as the listing was incomplete")
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var missing []string
+	for remote := range m.entries {
+		if _, ok := m.seen[remote]; ok {
+			continue
+		}
+		if dir != "" && !strings.HasPrefix(remote, dir+"/") {
+			continue
+		}
+		if !fi.IncludeRemote(remote) {
+			continue
+		}
+		missing = append(missing, remote)
+	}
+	sort.Strings(missing)
+	var err error
+	for _, remote := range missing {
+		expected := m.entries[remote]
+		m.addViolation(immutableViolation{
+			Type:     violationMissing,
+			Path:     remote,
+			Expected: &expected,
+		})
+		err = fs.CountError(ctx, fserrors.NoRetryError(fs.ErrorImmutableMissing))
+		fs.Errorf(remote, "Missing from destination but recorded in --immutable-manifest: %v", err)
+	}
+	return err
+}
+
+// save writes the manifest, replacing the old one
+func (m *immutableManifest) save() (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fs.Infof(nil, "Saving --immutable-manifest %q", m.path)
+	remotes := make([]string, 0, len(m.entries))
+	for remote := range m.entries {
+		remotes = append(remotes, remote)
+	}
+	sort.Strings(remotes)
+	// Write to a temporary file and rename it into place so the
+	// old manifest survives a failed write
+	tmp := m.path + ".tmp"
+	if err = os.MkdirAll(filepath.Dir(m.path), 0777); err != nil {
+		return err
+	}
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	_, _ = w.WriteString(immutableManifestHeader + m.ht.String() + "\n")
+	for _, remote := range remotes {
+		entry := m.entries[remote]
+		sum := entry.Hash
+		if sum == "" {
+			sum = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%d %s %s\n", entry.Size, sum, strconv.Quote(remote))
+	}
+	err = w.Flush()
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
+
+// writeReport writes the --immutable-report if required
+func (m *immutableManifest) writeReport(listingComplete bool) error {
+	if m.reportPath == "" {
+		return nil
+	}
+	m.mu.Lock()
+	report := immutableReport{
+		Source:      fs.ConfigString(m.fsrc),
+		Destination: fs.ConfigString(m.fdst),
+		Manifest:    m.path,
+		HashType:    m.ht.String(),
+		Started:     m.started,
+		Finished:    time.Now(),
+		Complete:    listingComplete,
+		Violations:  append([]immutableViolation{}, m.violations...),
+	}
+	m.mu.Unlock()
+	if n := len(report.Violations); n > 0 {
+		fs.Errorf(m.fdst, "Found %d --immutable-manifest violations, writing report to %q", n, m.reportPath)
+	}
+	data, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(m.reportPath), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(m.reportPath, append(data, '\n'), 0666)
+}
+
+// immutableDstOnly handles an entry which is only in the destination
+// when --immutable-manifest is in use.
+//
+// Nothing is deleted from the destination, but every file is checked
+// against the manifest so directories are always recursed into.
+func (s *syncCopyMove) immutableDstOnly(dst fs.DirEntry) (recurse bool) {
+	switch x := dst.(type) {
+	case fs.Object:
+		s.dstObjects.Add(1)
+		s.logger(s.ctx, operations.MissingOnSrc, nil, x, nil)
+		s.processError(s.immutable.check(s.ctx, x))
+		if s.deleteMode != fs.DeleteModeOff {
+			s.immutable.refused(s.ctx, x, nil)
+			err := fs.CountError(s.ctx, fserrors.NoRetryError(fs.ErrorImmutableDelete))
+			fs.Errorf(x, "Not deleting as --immutable-manifest is set: %v", err)
+			s.processError(err)
+		}
+	case fs.Directory:
+		if s.usingLogger {
+			s.logger(s.ctx, operations.MissingOnSrc, nil, dst, fs.ErrorIsDir)
+		}
+		return true
+	default:
+		panic("Bad object in DirEntries")
+	}
+	return false
+}
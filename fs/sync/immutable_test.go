@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImmutableManifestLine(t *testing.T) {
+	remote, entry, err := parseImmutableManifestLine(`6 8ee2027983915ec78acc45027d874316 "dir/file with spaces"`)
+	require.NoError(t, err)
+	assert.Equal(t, "dir/file with spaces", remote)
+	assert.Equal(t, immutableEntry{Size: 6, Hash: "8ee2027983915ec78acc45027d874316"}, entry)
+
+	remote, entry, err = parseImmutableManifestLine(`6 - "file"`)
+	require.NoError(t, err)
+	assert.Equal(t, "file", remote)
+	assert.Equal(t, immutableEntry{Size: 6}, entry)
+
+	for _, in := range []string{"", "6 -", `x - "file"`, "6 - file"} {
+		_, _, err = parseImmutableManifestLine(in)
+		assert.Error(t, err, in)
+	}
+}
+
+// readImmutableReport reads the violation types by path from the report
+func readImmutableReport(t *testing.T, path string) map[string][]string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var report immutableReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.True(t, report.Complete)
+	out := map[string][]string{}
+	for _, v := range report.Violations {
+		out[v.Path] = append(out[v.Path], v.Type)
+	}
+	return out
+}
+
+func TestSyncImmutableManifest(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	dir := t.TempDir()
+	ci.ImmutableManifest = filepath.Join(dir, "manifest")
+	ci.ImmutableReport = filepath.Join(dir, "report.json")
+
+	// Needs --immutable
+	err := Sync(ctx, r.Fremote, r.Flocal, false)
+	assert.ErrorContains(t, err, "needs --immutable")
+	ci.Immutable = true
+
+	file1 := r.WriteFile("one", "potato", t1)
+	file2 := r.WriteFile("dir/two", "carrot", t1)
+	file3 := r.WriteObject(ctx, "three", "beetroot", t1)
+
+	// First copy records the destination
+	accounting.GlobalStats().ResetCounters()
+	require.NoError(t, CopyDir(ctx, r.Fremote, r.Flocal, false))
+	r.CheckRemoteItems(t, file1, file2, file3)
+	assert.Empty(t, readImmutableReport(t, ci.ImmutableReport)["one"])
+	manifest, err := os.ReadFile(ci.ImmutableManifest)
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), immutableManifestHeader)
+	assert.Contains(t, string(manifest), `"dir/two"`)
+	assert.Contains(t, string(manifest), `"three"`)
+
+	// Sync finds nothing modified but refuses to delete three
+	// which isn't on the source
+	accounting.GlobalStats().ResetCounters()
+	err = Sync(ctx, r.Fremote, r.Flocal, false)
+	assert.ErrorIs(t, err, fs.ErrorImmutableDelete)
+	r.CheckRemoteItems(t, file1, file2, file3)
+	assert.Equal(t, map[string][]string{"three": {violationDeleteRefused}}, readImmutableReport(t, ci.ImmutableReport))
+
+	// Copy doesn't try to delete so is clean
+	accounting.GlobalStats().ResetCounters()
+	require.NoError(t, CopyDir(ctx, r.Fremote, r.Flocal, false))
+	assert.Empty(t, readImmutableReport(t, ci.ImmutableReport))
+
+	// Modify and delete files on the destination behind rclone's back
+	r.WriteObject(ctx, "three", "BEETROOT", t2)
+	obj, err := r.Fremote.NewObject(ctx, "one")
+	require.NoError(t, err)
+	require.NoError(t, operations.DeleteFile(ctx, obj))
+	obj, err = r.Fremote.NewObject(ctx, "dir/two")
+	require.NoError(t, err)
+	require.NoError(t, operations.DeleteFile(ctx, obj))
+	require.NoError(t, os.Remove(filepath.Join(r.LocalName, "dir", "two")))
+
+	// one is uploaded again but still reported as missing
+	r.WriteFile("one", "tomato", t2)
+
+	accounting.GlobalStats().ResetCounters()
+	err = CopyDir(ctx, r.Fremote, r.Flocal, false)
+	require.Error(t, err)
+	assert.Equal(t, map[string][]string{
+		"one":     {violationMissing},
+		"dir/two": {violationMissing},
+		"three":   {violationModified},
+	}, readImmutableReport(t, ci.ImmutableReport))
+
+	// Manifest keeps the originally recorded files
+	manifest2, err := os.ReadFile(ci.ImmutableManifest)
+	require.NoError(t, err)
+	assert.Equal(t, string(manifest), string(manifest2))
+
+	// Not compatible with a report and no manifest
+	ci.ImmutableManifest = ""
+	err = CopyDir(ctx, r.Fremote, r.Flocal, false)
+	assert.ErrorContains(t, err, "needs --immutable-manifest")
+}
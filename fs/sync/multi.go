@@ -0,0 +1,481 @@
+// Copy to multiple destinations reading the source once
+
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	mutex "sync" // renamed as "sync" already in use
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/list"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/walk"
+	"golang.org/x/sync/errgroup"
+)
+
+// fanOutBufferSize is the size of the chunks read from the source and
+// written to each destination
+const fanOutBufferSize = 128 * 1024
+
+// fanOut shares a single read of src between the copies of it to
+// several destinations.
+//
+// Each copy opens the source through a fanOutObject. When all the
+// copies have either opened it or finished without opening it, the
+// source is opened once and what is read is written to all of them.
+//
+// The shared read runs under a context belonging to the fanOut rather
+// than to any of the copies, so one copy finishing or being cancelled
+// doesn't stop the read for the others.
+type fanOut struct {
+	src     fs.Object
+	mu      mutex.Mutex
+	pending int                // copies which haven't opened or finished yet
+	writers []*io.PipeWriter   // one for each copy reading the source
+	ctx     context.Context    // context for the shared read
+	cancel  context.CancelFunc // cancel the shared read
+	options []fs.OpenOption    // options from the first open
+	started bool               // set once the source has been opened
+}
+
+// newFanOut makes a fanOut for n copies of src reading it under ctx
+func newFanOut(ctx context.Context, src fs.Object, n int) *fanOut {
+	ctx, cancel := context.WithCancel(ctx)
+	return &fanOut{
+		src:     src,
+		pending: n,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// object returns a wrapped src for one of the copies to use. The
+// copy must call done on it when finished.
+func (f *fanOut) object() *fanOutObject {
+	return &fanOutObject{
+		Object: f.src,
+		fanOut: f,
+	}
+}
+
+// resolve marks o as having opened or finished and starts the read
+// if all the copies are ready - call with mu held.
+func (f *fanOut) resolve(o *fanOutObject) {
+	if o.resolved {
+		return
+	}
+	o.resolved = true
+	f.pending--
+	if f.pending != 0 || f.started {
+		return
+	}
+	if len(f.writers) == 0 {
+		f.cancel()
+		return
+	}
+	f.started = true
+	go f.run(f.options, f.writers)
+}
+
+// open is called when o is opened by its copy
+func (f *fanOut) open(ctx context.Context, o *fanOutObject, options []fs.OpenOption) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// Open the source directly for retries and partial reads
+	if o.resolved || f.started || isPartialRead(options) {
+		f.resolve(o)
+		return f.src.Open(ctx, options...)
+	}
+	pr, pw := io.Pipe()
+	if len(f.writers) == 0 {
+		f.options = options
+	}
+	f.writers = append(f.writers, pw)
+	f.resolve(o)
+	return pr, nil
+}
+
+// done is called when the copy using o has finished
+func (f *fanOut) done(o *fanOutObject) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.resolve(o)
+}
+
+// isPartialRead returns true if options ask for part of the object
+func isPartialRead(options []fs.OpenOption) bool {
+	for _, option := range options {
+		switch option.(type) {
+		case *fs.RangeOption, *fs.SeekOption:
+			return true
+		}
+	}
+	return false
+}
+
+// run reads the source once writing it to all the writers
+//
+// Writers whose readers have been closed are dropped. The read stops
+// if there are none left.
+func (f *fanOut) run(options []fs.OpenOption, writers []*io.PipeWriter) {
+	defer f.cancel()
+	in, err := f.src.Open(f.ctx, options...)
+	if err != nil {
+		for _, w := range writers {
+			_ = w.CloseWithError(err)
+		}
+		return
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	buf := make([]byte, fanOutBufferSize)
+	for {
+		n, readErr := in.Read(buf)
+		if n > 0 {
+			writers = writeAll(writers, buf[:n])
+			if len(writers) == 0 {
+				return
+			}
+		}
+		if readErr == io.EOF {
+			for _, w := range writers {
+				_ = w.Close()
+			}
+			return
+		}
+		if readErr != nil {
+			for _, w := range writers {
+				_ = w.CloseWithError(readErr)
+			}
+			return
+		}
+	}
+}
+
+// writeAll writes p to all the writers at once returning the writers
+// which succeeded
+func writeAll(writers []*io.PipeWriter, p []byte) []*io.PipeWriter {
+	ok := make([]bool, len(writers))
+	var wg mutex.WaitGroup
+	for i, w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := w.Write(p)
+			if err != nil {
+				_ = w.CloseWithError(err)
+				return
+			}
+			ok[i] = true
+		}()
+	}
+	wg.Wait()
+	live := writers[:0]
+	for i, w := range writers {
+		if ok[i] {
+			live = append(live, w)
+		}
+	}
+	return live
+}
+
+// fanOutObject is the source object as seen by one of the copies
+// using a fanOut
+type fanOutObject struct {
+	fs.Object
+	fanOut   *fanOut
+	resolved bool // set when opened or done - protected by fanOut.mu
+}
+
+// Open the object reading from the shared read if possible
+func (o *fanOutObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	return o.fanOut.open(ctx, o, options)
+}
+
+// MimeType returns the mime type of the underlying object or "" if it
+// can't be worked out
+func (o *fanOutObject) MimeType(ctx context.Context) string {
+	if do, ok := o.Object.(fs.MimeTyper); ok {
+		return do.MimeType(ctx)
+	}
+	return ""
+}
+
+// ID returns the ID of the Object if known, or "" if not
+func (o *fanOutObject) ID() string {
+	if do, ok := o.Object.(fs.IDer); ok {
+		return do.ID()
+	}
+	return ""
+}
+
+// UnWrap returns the Object that this Object is wrapping
+func (o *fanOutObject) UnWrap() fs.Object {
+	return o.Object
+}
+
+// GetTier returns storage tier or class of the Object
+func (o *fanOutObject) GetTier() string {
+	if do, ok := o.Object.(fs.GetTierer); ok {
+		return do.GetTier()
+	}
+	return ""
+}
+
+// Metadata returns metadata for an object
+//
+// It should return nil if there is no Metadata
+func (o *fanOutObject) Metadata(ctx context.Context) (fs.Metadata, error) {
+	if do, ok := o.Object.(fs.Metadataer); ok {
+		return do.Metadata(ctx)
+	}
+	return nil, nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Object          = (*fanOutObject)(nil)
+	_ fs.MimeTyper       = (*fanOutObject)(nil)
+	_ fs.IDer            = (*fanOutObject)(nil)
+	_ fs.ObjectUnWrapper = (*fanOutObject)(nil)
+	_ fs.GetTierer       = (*fanOutObject)(nil)
+	_ fs.Metadataer      = (*fanOutObject)(nil)
+)
+
+// multiCopy is the state of a copy to multiple destinations
+type multiCopy struct {
+	ctx    context.Context    // context for the copy
+	teeCtx context.Context    // context for copies reading through a fanOut
+	cancel context.CancelFunc // cancel the copy
+	fdsts  []fs.Fs            // the destinations
+	fsrc   fs.Fs              // the source
+	errMu  mutex.Mutex        // protect err
+	err    error              // last error
+}
+
+// multiItem is a source object with the objects in its directory in
+// each destination
+type multiItem struct {
+	src  fs.Object
+	dsts []map[string]fs.Object // objects in each destination or nil if not listed
+}
+
+// processError records err if it isn't nil cancelling the copy if it
+// is fatal
+func (m *multiCopy) processError(err error) {
+	if err == nil {
+		return
+	}
+	m.errMu.Lock()
+	defer m.errMu.Unlock()
+	m.err = err
+	if fserrors.IsFatalError(err) || errors.Is(err, accounting.ErrorMaxTransferLimitReached) {
+		m.cancel()
+	}
+}
+
+// listDstDir reads the objects in dir of each destination
+//
+// A directory which doesn't exist in a destination has no objects.
+// If --no-traverse is set nothing is listed and the objects are
+// looked up one at a time instead.
+func (m *multiCopy) listDstDir(dir string) (dsts []map[string]fs.Object, err error) {
+	dsts = make([]map[string]fs.Object, len(m.fdsts))
+	if fs.GetConfig(m.ctx).NoTraverse {
+		return dsts, nil
+	}
+	g, gCtx := errgroup.WithContext(m.ctx)
+	for i, fdst := range m.fdsts {
+		g.Go(func() error {
+			objects := make(map[string]fs.Object)
+			entries, err := list.DirSorted(gCtx, fdst, false, dir)
+			if err != nil && !errors.Is(err, fs.ErrorDirNotFound) {
+				return fmt.Errorf("failed to list %q in %v: %w", dir, fdst, err)
+			}
+			for _, entry := range entries {
+				if o, ok := entry.(fs.Object); ok {
+					objects[o.Remote()] = o
+				}
+			}
+			dsts[i] = objects
+			return nil
+		})
+	}
+	return dsts, g.Wait()
+}
+
+// findDst returns the object for item in the i-th destination or nil
+// if it doesn't exist
+func (m *multiCopy) findDst(i int, item multiItem) (fs.Object, error) {
+	if item.dsts[i] != nil {
+		return item.dsts[i][item.src.Remote()], nil
+	}
+	dst, err := m.fdsts[i].NewObject(m.ctx, item.src.Remote())
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		return nil, nil
+	}
+	return dst, err
+}
+
+// canServerSideCopy returns true if src can be copied to fdst
+// server-side, in which case it doesn't need reading
+func canServerSideCopy(ctx context.Context, fdst fs.Fs, src fs.Object) bool {
+	features := fdst.Features()
+	if features.Copy == nil {
+		return false
+	}
+	if operations.SameConfig(src.Fs(), fdst) {
+		return true
+	}
+	return operations.SameRemoteType(src.Fs(), fdst) && (features.ServerSideAcrossConfigs || fs.GetConfig(ctx).ServerSideAcrossConfigs)
+}
+
+// copyObject copies item to all the destinations which need it
+func (m *multiCopy) copyObject(item multiItem) {
+	type target struct {
+		fdst       fs.Fs
+		dst        fs.Object
+		serverSide bool
+	}
+	src := item.src
+	var targets []target
+	shared := 0
+	for i, fdst := range m.fdsts {
+		dst, err := m.findDst(i, item)
+		if err != nil {
+			err = fs.CountError(m.ctx, err)
+			fs.Errorf(src, "Failed to find object on %v: %v", fdst, err)
+			m.processError(err)
+			continue
+		}
+		if dst != nil {
+			tr := accounting.Stats(m.ctx).NewCheckingTransfer(src, "checking")
+			needTransfer := operations.NeedTransfer(m.ctx, dst, src)
+			tr.Done(m.ctx, nil)
+			if !needTransfer {
+				continue
+			}
+		}
+		t := target{fdst: fdst, dst: dst, serverSide: canServerSideCopy(m.ctx, fdst, src)}
+		if !t.serverSide {
+			shared++
+		}
+		targets = append(targets, t)
+	}
+	var fan *fanOut
+	if shared > 1 {
+		fan = newFanOut(m.ctx, src, shared)
+	}
+	var wg mutex.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, obj := m.ctx, src
+			if fan != nil && !t.serverSide {
+				o := fan.object()
+				defer fan.done(o)
+				ctx, obj = m.teeCtx, o
+			}
+			_, err := operations.Copy(ctx, t.fdst, t.dst, src.Remote(), obj)
+			m.processError(err)
+		}()
+	}
+	wg.Wait()
+}
+
+// makeDir makes dir in all the destinations
+func (m *multiCopy) makeDir(dir fs.Directory) {
+	for _, fdst := range m.fdsts {
+		m.processError(operations.Mkdir(m.ctx, fdst, dir.Remote()))
+	}
+}
+
+// CopyDirMulti copies fsrc into each of fdsts reading each source
+// file once.
+//
+// A file which needs copying to more than one destination is read
+// once with the data sent to all of them at the same time. Only
+// retries read it again.
+//
+// This walks the source once, listing each directory of the
+// destinations as the same directory of the source is reached, rather
+// than running a march per destination. Independent marches reach a
+// file at different times, so a shared read waiting for every
+// destination to open it would stall the marches behind it once their
+// transfers were all waiting on each other.
+func CopyDirMulti(ctx context.Context, fdsts []fs.Fs, fsrc fs.Fs, copyEmptySrcDirs bool) error {
+	if len(fdsts) == 1 {
+		return CopyDir(ctx, fdsts[0], fsrc, copyEmptySrcDirs)
+	}
+	ci := fs.GetConfig(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	m := &multiCopy{
+		ctx:    ctx,
+		cancel: cancel,
+		fdsts:  fdsts,
+		fsrc:   fsrc,
+	}
+
+	// Multi-thread copies read the source in chunks so can't share
+	// the read
+	var teeCi *fs.ConfigInfo
+	m.teeCtx, teeCi = fs.AddConfig(ctx)
+	teeCi.MultiThreadStreams = 0
+
+	// Copy the objects as they are listed
+	items := make(chan multiItem, ci.Transfers)
+	var wg mutex.WaitGroup
+	for range ci.Transfers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				if ctx.Err() == nil {
+					m.copyObject(item)
+				}
+			}
+		}()
+	}
+	err := walk.Walk(ctx, fsrc, "", false, -1, func(dir string, entries fs.DirEntries, err error) error {
+		if err != nil {
+			return err
+		}
+		var dsts []map[string]fs.Object
+		for _, entry := range entries {
+			switch x := entry.(type) {
+			case fs.Object:
+				if dsts == nil {
+					dsts, err = m.listDstDir(dir)
+					if err != nil {
+						return err
+					}
+				}
+				select {
+				case items <- multiItem{src: x, dsts: dsts}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			case fs.Directory:
+				if copyEmptySrcDirs {
+					m.makeDir(x)
+				}
+			}
+		}
+		return nil
+	})
+	close(items)
+	wg.Wait()
+	// Don't report the listing being cancelled because of an error
+	if err != nil && (m.err == nil || !errors.Is(err, context.Canceled)) {
+		m.processError(fs.CountError(ctx, fmt.Errorf("failed to list: %w", err)))
+	}
+	return m.err
+}
@@ -0,0 +1,231 @@
+// Test copying to multiple destinations
+
+package sync
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	mutex "sync" // renamed as "sync" already in use
+	"sync/atomic"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/fstest/mockobject"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countOpens is an fs.Object which counts the times it is opened
+type countOpens struct {
+	fs.Object
+	opens atomic.Int32
+}
+
+func (o *countOpens) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	o.opens.Add(1)
+	return o.Object.Open(ctx, options...)
+}
+
+func TestFanOut(t *testing.T) {
+	ctx := context.Background()
+	content := strings.Repeat("0123456789", 3*fanOutBufferSize/10)
+	src := &countOpens{Object: mockobject.New("file").WithContent([]byte(content), mockobject.SeekModeNone)}
+	fan := newFanOut(ctx, src, 3)
+
+	// Two copies read and one finishes without reading
+	var wg mutex.WaitGroup
+	got := make([]string, 2)
+	for i := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o := fan.object()
+			defer fan.done(o)
+			in, err := o.Open(ctx)
+			require.NoError(t, err)
+			data, err := io.ReadAll(in)
+			require.NoError(t, err)
+			require.NoError(t, in.Close())
+			got[i] = string(data)
+		}()
+	}
+	skipped := fan.object()
+	fan.done(skipped)
+	wg.Wait()
+	assert.Equal(t, content, got[0])
+	assert.Equal(t, content, got[1])
+	assert.Equal(t, int32(1), src.opens.Load())
+}
+
+func TestFanOutReaderClosed(t *testing.T) {
+	ctx := context.Background()
+	content := strings.Repeat("0123456789", 3*fanOutBufferSize/10)
+	src := &countOpens{Object: mockobject.New("file").WithContent([]byte(content), mockobject.SeekModeNone)}
+	fan := newFanOut(ctx, src, 2)
+
+	// One copy gives up part way through which mustn't stop the other
+	o1, o2 := fan.object(), fan.object()
+	in1, err := o1.Open(ctx)
+	require.NoError(t, err)
+	in2, err := o2.Open(ctx)
+	require.NoError(t, err)
+	done := make(chan string)
+	go func() {
+		data, err := io.ReadAll(in2)
+		assert.NoError(t, err)
+		done <- string(data)
+	}()
+	buf := make([]byte, 10)
+	_, err = io.ReadFull(in1, buf)
+	require.NoError(t, err)
+	require.NoError(t, in1.Close())
+	assert.Equal(t, content, <-done)
+
+	// A retry opens the source again
+	in1, err = o1.Open(ctx)
+	require.NoError(t, err)
+	data, err := io.ReadAll(in1)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+	assert.Equal(t, int32(2), src.opens.Load())
+}
+
+// ctxReader fails reads once its context is done
+type ctxReader struct {
+	ctx context.Context
+	io.ReadCloser
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.ReadCloser.Read(p)
+}
+
+// ctxObject is an fs.Object whose readers stop when the context
+// passed to Open is done
+type ctxObject struct {
+	fs.Object
+}
+
+func (o *ctxObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	in, err := o.Object.Open(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &ctxReader{ctx: ctx, ReadCloser: in}, nil
+}
+
+func TestFanOutFirstContextCancelled(t *testing.T) {
+	ctx := context.Background()
+	content := strings.Repeat("0123456789", 3*fanOutBufferSize/10)
+	src := &ctxObject{Object: mockobject.New("file").WithContent([]byte(content), mockobject.SeekModeNone)}
+	fan := newFanOut(ctx, src, 2)
+
+	// The first copy to open gives up which mustn't stop the other
+	ctx1, cancel1 := context.WithCancel(ctx)
+	o1, o2 := fan.object(), fan.object()
+	in1, err := o1.Open(ctx1)
+	require.NoError(t, err)
+	in2, err := o2.Open(ctx)
+	require.NoError(t, err)
+	cancel1()
+	require.NoError(t, in1.Close())
+	fan.done(o1)
+	data, err := io.ReadAll(in2)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+	fan.done(o2)
+}
+
+func TestFanOutOpenError(t *testing.T) {
+	ctx := context.Background()
+	fan := newFanOut(ctx, mockobject.New("file"), 2)
+	o1, o2 := fan.object(), fan.object()
+	in1, err := o1.Open(ctx)
+	require.NoError(t, err)
+	in2, err := o2.Open(ctx)
+	require.NoError(t, err)
+	_, err = io.ReadAll(in1)
+	assert.Error(t, err)
+	_, err = io.ReadAll(in2)
+	assert.Error(t, err)
+}
+
+func TestIsPartialRead(t *testing.T) {
+	assert.False(t, isPartialRead(nil))
+	assert.False(t, isPartialRead([]fs.OpenOption{&fs.HashesOption{}}))
+	assert.True(t, isPartialRead([]fs.OpenOption{&fs.RangeOption{Start: 1, End: -1}}))
+	assert.True(t, isPartialRead([]fs.OpenOption{&fs.SeekOption{Offset: 1}}))
+}
+
+func TestCopyDirMulti(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	fdst2, err := fs.NewFs(ctx, t.TempDir())
+	require.NoError(t, err)
+
+	file1 := r.WriteFile("new", "new file", t1)
+	file2 := r.WriteFile("sub dir/same", "same file", t1)
+	file3 := r.WriteFile("changed", "changed file", t2)
+	r.WriteObject(ctx, "sub dir/same", "same file", t1)
+	r.WriteObject(ctx, "changed", "old", t1)
+	r.WriteObjectTo(ctx, fdst2, "changed", "changed file", t2, false)
+	extra := r.WriteObjectTo(ctx, fdst2, "extra", "extra file", t1, false)
+
+	accounting.GlobalStats().ResetCounters()
+	err = CopyDirMulti(ctx, []fs.Fs{r.Fremote, fdst2}, r.Flocal, false)
+	require.NoError(t, err)
+
+	r.CheckLocalItems(t, file1, file2, file3)
+	r.CheckRemoteItems(t, file1, file2, file3)
+	fstest.CheckItems(t, fdst2, file1, file2, file3, extra)
+
+	// new to both, changed to the remote and same to fdst2
+	assert.Equal(t, int64(4), accounting.GlobalStats().GetTransfers())
+}
+
+func TestCopyDirMultiNoTraverse(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.NoTraverse = true
+	r := fstest.NewRun(t)
+	fdst2, err := fs.NewFs(ctx, t.TempDir())
+	require.NoError(t, err)
+
+	file1 := r.WriteFile("new", "new file", t1)
+	file2 := r.WriteFile("same", "same file", t1)
+	r.WriteObject(ctx, "same", "same file", t1)
+
+	accounting.GlobalStats().ResetCounters()
+	err = CopyDirMulti(ctx, []fs.Fs{r.Fremote, fdst2}, r.Flocal, false)
+	require.NoError(t, err)
+
+	r.CheckRemoteItems(t, file1, file2)
+	fstest.CheckItems(t, fdst2, file1, file2)
+	assert.Equal(t, int64(3), accounting.GlobalStats().GetTransfers())
+}
+
+func TestCopyDirMultiError(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	r.WriteFile("file", "file", t1)
+	m := &multiCopy{cancel: func() {}}
+	m.processError(nil)
+	assert.NoError(t, m.err)
+	errBoom := errors.New("boom")
+	m.processError(errBoom)
+	assert.Equal(t, errBoom, m.err)
+
+	// A missing source is an error
+	fsrc, err := fs.NewFs(ctx, r.Flocal.Root()+"/notfound")
+	require.NoError(t, err)
+	err = CopyDirMulti(ctx, []fs.Fs{r.Fremote, r.Fremote}, fsrc, false)
+	assert.Error(t, err)
+	accounting.GlobalStats().ResetCounters()
+}
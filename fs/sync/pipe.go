@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/bits"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,21 +29,23 @@ type pipe struct {
 	stats     func(items int, totalSize int64)
 	less      lessFn
 	fraction  int
+	fair      *fairShare // if set ranks items on Put
 }
 
 func newPipe(orderBy string, stats func(items int, totalSize int64), maxBacklog int) (*pipe, error) {
 	if maxBacklog < 0 {
 		maxBacklog = (1 << (bits.UintSize - 1)) - 1 // largest positive int
 	}
-	less, fraction, err := newLess(orderBy)
+	o, err := parseOrderBy(orderBy)
 	if err != nil {
 		return nil, fserrors.FatalError(err)
 	}
 	p := &pipe{
 		c:        make(chan struct{}, maxBacklog),
 		stats:    stats,
-		less:     less,
-		fraction: fraction,
+		less:     o.less,
+		fraction: o.fraction,
+		fair:     o.fair,
 	}
 	if p.less != nil {
 		deheap.Init(p)
@@ -96,6 +99,9 @@ func (p *pipe) Put(ctx context.Context, pair fs.ObjectPair) (ok bool) {
 		// no order-by
 		p.queue = append(p.queue, pair)
 	} else {
+		if p.fair != nil {
+			p.fair.put(pair)
+		}
 		deheap.Push(p, pair)
 	}
 	size := pair.Src.Size()
@@ -142,6 +148,9 @@ func (p *pipe) GetMax(ctx context.Context, fraction int) (pair fs.ObjectPair, ok
 	} else {
 		pair = deheap.PopMax(p).(fs.ObjectPair)
 	}
+	if p.fair != nil {
+		p.fair.get(pair)
+	}
 	size := pair.Src.Size()
 	if size > 0 && pair.Src != pair.Dst {
 		p.totalSize -= size
@@ -170,6 +179,20 @@ func (p *pipe) Stats() (items int, totalSize int64) {
 	return items, totalSize
 }
 
+// drain removes and returns the pairs still in the pipe
+//
+// This should only be called once nothing is reading from or writing
+// to the pipe.
+func (p *pipe) drain() (pairs []fs.ObjectPair) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pairs = p.queue
+	p.queue = nil
+	p.totalSize = 0
+	p.stats(0, 0)
+	return pairs
+}
+
 // Close the pipe
 //
 // Writes to a closed pipe will panic as will double closing a pipe
@@ -180,14 +203,21 @@ func (p *pipe) Close() {
 	p.mu.Unlock()
 }
 
-// newLess returns a less function for the heap comparison or nil if
-// one is not required
-func newLess(orderBy string) (less lessFn, fraction int, err error) {
-	fraction = -1
+// orderBy is the parsed --order-by flag
+type orderBy struct {
+	less     lessFn     // less function for the heap comparison or nil
+	fraction int        // mixed fraction or -1 if not mixed
+	fair     *fairShare // set if the items need ranking when queued
+}
+
+// parseOrderBy parses the --order-by flag
+func parseOrderBy(orderBy string) (o orderBy, err error) {
+	o.fraction = -1
 	if orderBy == "" {
-		return nil, fraction, nil
+		return o, nil
 	}
 	parts := strings.Split(strings.ToLower(orderBy), ",")
+	var less lessFn
 	switch parts[0] {
 	case "name":
 		less = func(a, b fs.ObjectPair) bool {
@@ -202,8 +232,21 @@ func newLess(orderBy string) (less lessFn, fraction int, err error) {
 			ctx := context.Background()
 			return a.Src.ModTime(ctx).Before(b.Src.ModTime(ctx))
 		}
+	case "dir":
+		less = func(a, b fs.ObjectPair) bool {
+			aRemote, bRemote := a.Src.Remote(), b.Src.Remote()
+			aDir, bDir := path.Dir(aRemote), path.Dir(bRemote)
+			if aDir != bDir {
+				return aDir < bDir
+			}
+			return aRemote < bRemote
+		}
+	case "fairshare":
+		o.fair = newFairShare(topLevelDir)
+	case "sizebucket":
+		o.fair = newFairShare(sizeBucket)
 	default:
-		return nil, fraction, fmt.Errorf("unknown --order-by comparison %q", parts[0])
+		return o, fmt.Errorf("unknown --order-by comparison %q", parts[0])
 	}
 	descending := false
 	if len(parts) > 1 {
@@ -212,20 +255,28 @@ func newLess(orderBy string) (less lessFn, fraction int, err error) {
 		case "descending", "desc":
 			descending = true
 		case "mixed":
-			fraction = 50
+			o.fraction = 50
 			if len(parts) > 2 {
-				fraction, err = strconv.Atoi(parts[2])
+				o.fraction, err = strconv.Atoi(parts[2])
 				if err != nil {
-					return nil, fraction, fmt.Errorf("bad mixed fraction --order-by %q", parts[2])
+					return o, fmt.Errorf("bad mixed fraction --order-by %q", parts[2])
 				}
 			}
 
 		default:
-			return nil, fraction, fmt.Errorf("unknown --order-by sort direction %q", parts[1])
+			return o, fmt.Errorf("unknown --order-by sort direction %q", parts[1])
 		}
 	}
-	if (fraction >= 0 && len(parts) > 3) || (fraction < 0 && len(parts) > 2) {
-		return nil, fraction, fmt.Errorf("bad --order-by string %q", orderBy)
+	if (o.fraction >= 0 && len(parts) > 3) || (o.fraction < 0 && len(parts) > 2) {
+		return o, fmt.Errorf("bad --order-by string %q", orderBy)
+	}
+	if o.fair != nil {
+		// The fair share orders are interleaved so can't be
+		// reversed or mixed
+		if descending || o.fraction >= 0 {
+			return o, fmt.Errorf("--order-by %q can't have a sort direction", parts[0])
+		}
+		less = o.fair.less
 	}
 	if descending {
 		oldLess := less
@@ -233,5 +284,81 @@ func newLess(orderBy string) (less lessFn, fraction int, err error) {
 			return !oldLess(a, b)
 		}
 	}
-	return less, fraction, nil
+	o.less = less
+	return o, nil
+}
+
+// fairShare interleaves the items in the queue so that each group of
+// items, as chosen by the key function, gets an equal share of the
+// transfers.
+//
+// Each item is given a rank when it is queued which is one more than
+// the rank of the last item queued from its group, so the queue takes
+// items from each group in turn. Groups which arrive later start at
+// the rank of the last item taken so they don't jump the queue.
+//
+// The methods must be called with the pipe lock held.
+type fairShare struct {
+	key   func(pair fs.ObjectPair) string
+	next  map[string]int // next rank to give out by group
+	ranks map[string]int // rank of each queued item by remote
+	floor int            // rank of the last item taken
+}
+
+// newFairShare makes a fairShare grouping the items with key
+func newFairShare(key func(pair fs.ObjectPair) string) *fairShare {
+	return &fairShare{
+		key:   key,
+		next:  map[string]int{},
+		ranks: map[string]int{},
+	}
+}
+
+// put ranks a new item
+func (f *fairShare) put(pair fs.ObjectPair) {
+	group := f.key(pair)
+	rank := max(f.next[group], f.floor)
+	f.next[group] = rank + 1
+	f.ranks[pair.Src.Remote()] = rank
+}
+
+// get forgets the rank of an item which has been taken
+func (f *fairShare) get(pair fs.ObjectPair) {
+	remote := pair.Src.Remote()
+	f.floor = f.ranks[remote]
+	delete(f.ranks, remote)
+}
+
+// less orders by rank then group
+func (f *fairShare) less(a, b fs.ObjectPair) bool {
+	aRank, bRank := f.ranks[a.Src.Remote()], f.ranks[b.Src.Remote()]
+	if aRank != bRank {
+		return aRank < bRank
+	}
+	aGroup, bGroup := f.key(a), f.key(b)
+	if aGroup != bGroup {
+		return aGroup < bGroup
+	}
+	return a.Src.Remote() < b.Src.Remote()
+}
+
+// topLevelDir returns the top level directory of the item or "" if it
+// is in the root
+func topLevelDir(pair fs.ObjectPair) string {
+	dir, _, found := strings.Cut(pair.Src.Remote(), "/")
+	if !found {
+		return ""
+	}
+	return dir
+}
+
+// sizeBucket returns the size of the item rounded down to a power of
+// 10 as a string so that items of each order of magnitude of size are
+// grouped together
+func sizeBucket(pair fs.ObjectPair) string {
+	size := pair.Src.Size()
+	if size < 0 {
+		return "unknown"
+	}
+	return strconv.Itoa(len(strconv.FormatInt(size, 10)))
 }
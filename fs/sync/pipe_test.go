@@ -213,43 +213,51 @@ func TestPipeOrderBy(t *testing.T) {
 	}
 }
 
-func TestNewLess(t *testing.T) {
+func TestParseOrderBy(t *testing.T) {
 	t.Run("blankOK", func(t *testing.T) {
-		less, _, err := newLess("")
+		o, err := parseOrderBy("")
 		require.NoError(t, err)
-		assert.Nil(t, less)
+		assert.Nil(t, o.less)
 	})
 
 	t.Run("tooManyParts", func(t *testing.T) {
-		_, _, err := newLess("size,asc,toomanyparts")
+		_, err := parseOrderBy("size,asc,toomanyparts")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "bad --order-by string")
 	})
 
 	t.Run("tooManyParts2", func(t *testing.T) {
-		_, _, err := newLess("size,mixed,50,toomanyparts")
+		_, err := parseOrderBy("size,mixed,50,toomanyparts")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "bad --order-by string")
 	})
 
 	t.Run("badMixed", func(t *testing.T) {
-		_, _, err := newLess("size,mixed,32.7")
+		_, err := parseOrderBy("size,mixed,32.7")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "bad mixed fraction")
 	})
 
 	t.Run("unknownComparison", func(t *testing.T) {
-		_, _, err := newLess("potato")
+		_, err := parseOrderBy("potato")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "unknown --order-by comparison")
 	})
 
 	t.Run("unknownSortDirection", func(t *testing.T) {
-		_, _, err := newLess("name,sideways")
+		_, err := parseOrderBy("name,sideways")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "unknown --order-by sort direction")
 	})
 
+	t.Run("fairShareDirection", func(t *testing.T) {
+		_, err := parseOrderBy("fairshare,desc")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "can't have a sort direction")
+		_, err = parseOrderBy("sizebucket,mixed")
+		require.Error(t, err)
+	})
+
 	var (
 		obj1  = mockobject.New("b").WithContent([]byte("1"), mockobject.SeekModeNone)
 		obj2  = mockobject.New("a").WithContent([]byte("22"), mockobject.SeekModeNone)
@@ -274,12 +282,17 @@ func TestNewLess(t *testing.T) {
 		{"modtime,descending", true, true, -1},
 		{"modtime,mixed", false, false, 50},
 		{"modtime,mixed,30", false, false, 30},
+		{"dir", false, true, -1},
+		{"dir,desc", true, false, -1},
+		{"fairshare", false, true, -1},
+		{"sizebucket,asc", false, true, -1},
 	} {
 		t.Run(test.orderBy, func(t *testing.T) {
-			less, gotFraction, err := newLess(test.orderBy)
-			assert.Equal(t, test.wantFraction, gotFraction)
+			o, err := parseOrderBy(test.orderBy)
+			assert.Equal(t, test.wantFraction, o.fraction)
 			require.NoError(t, err)
-			require.NotNil(t, less)
+			require.NotNil(t, o.less)
+			less := o.less
 			pair1LessPair2 := less(pair1, pair2)
 			assert.Equal(t, test.pair1LessPair2, pair1LessPair2)
 			pair2LessPair1 := less(pair2, pair1)
@@ -288,3 +301,78 @@ func TestNewLess(t *testing.T) {
 	}
 
 }
+
+func TestPipeOrderByGroups(t *testing.T) {
+	var (
+		stats = func(n int, size int64) {}
+		ctx   = context.Background()
+	)
+	newPair := func(remote string, size int) fs.ObjectPair {
+		o := mockobject.New(remote).WithContent(make([]byte, size), mockobject.SeekModeNone)
+		return fs.ObjectPair{Src: o}
+	}
+
+	for _, test := range []struct {
+		orderBy string
+		in      []fs.ObjectPair
+		want    []string
+	}{
+		{
+			orderBy: "dir",
+			in: []fs.ObjectPair{
+				newPair("b/2", 1), newPair("a/1", 1), newPair("b/1", 1), newPair("a/b/1", 1), newPair("a/2", 1),
+			},
+			want: []string{"a/1", "a/2", "a/b/1", "b/1", "b/2"},
+		},
+		{
+			orderBy: "fairshare",
+			in: []fs.ObjectPair{
+				newPair("a/1", 1), newPair("a/2", 1), newPair("a/3", 1), newPair("b/1", 1), newPair("root", 1), newPair("b/2", 1),
+			},
+			want: []string{"root", "a/1", "b/1", "a/2", "b/2", "a/3"},
+		},
+		{
+			orderBy: "sizebucket",
+			in: []fs.ObjectPair{
+				newPair("big1", 100), newPair("big2", 200), newPair("big3", 300), newPair("small1", 1), newPair("small2", 2),
+			},
+			want: []string{"small1", "big1", "small2", "big2", "big3"},
+		},
+	} {
+		t.Run(test.orderBy, func(t *testing.T) {
+			p, err := newPipe(test.orderBy, stats, 10)
+			require.NoError(t, err)
+			for _, pair := range test.in {
+				require.True(t, p.Put(ctx, pair))
+			}
+			var got []string
+			for range test.in {
+				pair, ok := p.Get(ctx)
+				require.True(t, ok)
+				got = append(got, pair.Src.Remote())
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+
+	// Check a group arriving late doesn't jump the queue
+	p, err := newPipe("fairshare", stats, 10)
+	require.NoError(t, err)
+	for _, remote := range []string{"a/1", "a/2", "a/3", "a/4"} {
+		require.True(t, p.Put(ctx, newPair(remote, 1)))
+	}
+	for _, want := range []string{"a/1", "a/2"} {
+		pair, ok := p.Get(ctx)
+		require.True(t, ok)
+		assert.Equal(t, want, pair.Src.Remote())
+	}
+	require.True(t, p.Put(ctx, newPair("b/1", 1)))
+	require.True(t, p.Put(ctx, newPair("b/2", 1)))
+	var got []string
+	for range 4 {
+		pair, ok := p.Get(ctx)
+		require.True(t, ok)
+		got = append(got, pair.Src.Remote())
+	}
+	assert.Equal(t, []string{"b/1", "a/3", "b/2", "a/4"}, got)
+}
@@ -0,0 +1,134 @@
+// Skip unchanged source directories with --prune-unchanged-dirs
+
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/lib/kv"
+)
+
+// pruneFacility is the name of the database used by --prune-unchanged-dirs
+const pruneFacility = "prune-dirs"
+
+// dirPruner remembers the change tokens of the source directories
+// from the last successful sync so unchanged ones can be skipped.
+type dirPruner struct {
+	db     *kv.DB
+	prefix string // key prefix for this source, destination and filter
+	mu     sync.Mutex
+	tokens map[string]string // tokens seen in this sync by directory
+}
+
+// newDirPruner returns a dirPruner for syncing fsrc to fdst or nil if
+// --prune-unchanged-dirs isn't in use.
+func newDirPruner(ctx context.Context, fdst, fsrc fs.Fs, fi *filter.Filter) *dirPruner {
+	ci := fs.GetConfig(ctx)
+	if !ci.PruneUnchangedDirs || !kv.Supported() {
+		return nil
+	}
+	db, err := kv.Start(ctx, pruneFacility, fsrc)
+	if err != nil {
+		fs.Errorf(fsrc, "Not pruning unchanged directories as the database failed to open: %v", err)
+		return nil
+	}
+	// The tokens are only valid for the same source, destination
+	// and filters so make them part of the key.
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s", fs.ConfigString(fsrc), fs.ConfigString(fdst), fi.DumpFilters())
+	return &dirPruner{
+		db:     db,
+		prefix: hex.EncodeToString(h.Sum(nil)[:16]) + ":",
+		tokens: map[string]string{},
+	}
+}
+
+// unchanged returns true if dir has the same change token as it had
+// at the end of the last successful sync so needn't be descended into.
+//
+// It records the token to be saved at the end of this sync.
+func (p *dirPruner) unchanged(dir fs.Directory) bool {
+	if p == nil {
+		return false
+	}
+	do, ok := dir.(fs.DirChangeTokener)
+	if !ok {
+		return false
+	}
+	token := do.ChangeToken()
+	if token == "" {
+		return false
+	}
+	p.mu.Lock()
+	p.tokens[dir.Remote()] = token
+	p.mu.Unlock()
+	op := &pruneGet{key: p.prefix + dir.Remote()}
+	if err := p.db.Do(false, op); err != nil {
+		fs.Debugf(dir, "Failed to read directory change token: %v", err)
+		return false
+	}
+	return op.token == token
+}
+
+// save writes the tokens seen in this sync to the database. It should
+// only be called if the sync was successful.
+func (p *dirPruner) save() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.tokens) == 0 {
+		return nil
+	}
+	err := p.db.Do(true, &prunePut{prefix: p.prefix, tokens: p.tokens})
+	if err != nil {
+		return fmt.Errorf("failed to save directory change tokens: %w", err)
+	}
+	fs.Debugf(nil, "Saved %d directory change tokens", len(p.tokens))
+	return nil
+}
+
+// close the database
+func (p *dirPruner) close() {
+	if p == nil {
+		return
+	}
+	if err := p.db.Stop(false); err != nil {
+		fs.Debugf(nil, "Failed to close directory change token database: %v", err)
+	}
+}
+
+// pruneGet reads a token from the database
+type pruneGet struct {
+	key   string
+	token string
+}
+
+// Do the get
+func (op *pruneGet) Do(ctx context.Context, b kv.Bucket) error {
+	op.token = string(b.Get([]byte(op.key)))
+	return nil
+}
+
+// prunePut writes tokens to the database
+type prunePut struct {
+	prefix string
+	tokens map[string]string
+}
+
+// Do the put
+func (op *prunePut) Do(ctx context.Context, b kv.Bucket) error {
+	for dir, token := range op.tokens {
+		if err := b.Put([]byte(op.prefix+dir), []byte(token)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
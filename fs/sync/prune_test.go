@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/lib/kv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirPruner(t *testing.T) {
+	if !kv.Supported() {
+		t.Skip("kv database not supported")
+	}
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	fsrc, err := mockfs.NewFs(ctx, "prune-test-src", t.Name(), nil)
+	require.NoError(t, err)
+	fdst, err := mockfs.NewFs(ctx, "prune-test-dst", t.Name(), nil)
+	require.NoError(t, err)
+	fi := filter.GetConfig(ctx)
+
+	// Not in use unless the flag is set
+	assert.Nil(t, newDirPruner(ctx, fdst, fsrc, fi))
+	var nilPruner *dirPruner
+	assert.False(t, nilPruner.unchanged(fs.NewDir("dir", time.Time{}).SetChangeToken("a")))
+	ci.PruneUnchangedDirs = true
+
+	dir := fs.NewDir("dir", time.Time{}).SetChangeToken("a")
+	noToken := fs.NewDir("notoken", time.Time{})
+
+	p := newDirPruner(ctx, fdst, fsrc, fi)
+	require.NotNil(t, p)
+	assert.False(t, p.unchanged(dir))
+	assert.False(t, p.unchanged(noToken))
+	require.NoError(t, p.save())
+	defer p.close()
+
+	// The database is shared while open so this sees the saved tokens
+	p = newDirPruner(ctx, fdst, fsrc, fi)
+	require.NotNil(t, p)
+	defer p.close()
+	assert.True(t, p.unchanged(dir))
+	assert.False(t, p.unchanged(noToken))
+	assert.False(t, p.unchanged(fs.NewDir("dir", time.Time{}).SetChangeToken("b")))
+
+	// Different destination doesn't match
+	fother, err := mockfs.NewFs(ctx, "prune-test-other", t.Name(), nil)
+	require.NoError(t, err)
+	other := newDirPruner(ctx, fother, fsrc, fi)
+	require.NotNil(t, other)
+	defer other.close()
+	assert.False(t, other.unchanged(dir))
+}
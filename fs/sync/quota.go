@@ -0,0 +1,67 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+)
+
+// ErrorQuotaFallback is returned when the sync stopped transferring
+// because the destination ran out of quota and --quota-fallback is set
+var ErrorQuotaFallback = errors.New("destination out of quota - files still need copying")
+
+// startQuotaFallback is called with the first quota error when
+// --quota-fallback is set. From then on the sync carries on checking
+// but records the files which need transferring instead of
+// transferring them.
+func (s *syncCopyMove) startQuotaFallback(err error) {
+	if s.quotaExceeded.CompareAndSwap(false, true) {
+		fs.Errorf(s.fdst, "Destination out of quota - checking the remaining files without transferring them: %v", err)
+	}
+	// Don't retry the sync or delete anything, but don't cancel
+	// the sync either as a fatal error would
+	s.errorMu.Lock()
+	s.noRetryErr = fserrors.NoRetryError(ErrorQuotaFallback)
+	s.errorMu.Unlock()
+}
+
+// addQuotaRemaining records a pair which wasn't transferred as the
+// destination is out of quota
+func (s *syncCopyMove) addQuotaRemaining(pair fs.ObjectPair) {
+	fs.Debugf(pair.Src, "Not transferring as destination is out of quota")
+	s.quotaMu.Lock()
+	s.quotaRemaining = append(s.quotaRemaining, pair)
+	s.quotaMu.Unlock()
+}
+
+// saveQuotaRemaining reports the files which still need copying
+// after the destination ran out of quota and writes them to
+// --quota-fallback-list if set.
+func (s *syncCopyMove) saveQuotaRemaining() error {
+	if !s.quotaExceeded.Load() {
+		return nil
+	}
+	s.quotaMu.Lock()
+	remaining := s.quotaRemaining
+	s.quotaMu.Unlock()
+	var size int64
+	for _, pair := range remaining {
+		if pair.Src.Size() > 0 {
+			size += pair.Src.Size()
+		}
+	}
+	fs.Logf(s.fdst, "Destination out of quota - %d files (%v) still need copying", len(remaining), fs.SizeSuffix(size).ByteUnit())
+	if s.ci.QuotaFallbackList == "" {
+		return nil
+	}
+	header := fmt.Sprintf("rclone ran out of quota at %s with these files still to be copied", time.Now().Format(time.RFC3339))
+	n, err := s.writeFilesFrom(s.ci.QuotaFallbackList, header, remaining)
+	if err != nil {
+		return fmt.Errorf("failed to write --quota-fallback-list: %w", err)
+	}
+	fs.Logf(s.fdst, "Wrote %d files still to be copied to %q", n, s.ci.QuotaFallbackList)
+	return nil
+}
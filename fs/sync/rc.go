@@ -3,14 +3,18 @@ package sync
 import (
 	"context"
 
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/rc"
 )
 
 func init() {
 	for _, name := range []string{"sync", "copy", "move"} {
-		moveHelp := ""
+		extraHelp := ""
 		if name == "move" {
-			moveHelp = "- deleteEmptySrcDirs - delete empty src directories if set\n"
+			extraHelp = "- deleteEmptySrcDirs - delete empty src directories if set\n"
+		}
+		if name == "sync" {
+			extraHelp = "- confirmDeletes - skip the --max-delete-ratio check if set\n"
 		}
 		rc.Add(rc.Call{
 			Path:         "sync/" + name,
@@ -24,7 +28,7 @@ func init() {
 - srcFs - a remote name string e.g. "drive:src" for the source
 - dstFs - a remote name string e.g. "drive:dst" for the destination
 - createEmptySrcDirs - create empty src directories on destination if set
-` + moveHelp + `
+` + extraHelp + `
 
 See the [` + name + `](/commands/rclone_` + name + `/) command for more information on the above.`,
 		})
@@ -47,6 +51,15 @@ func rcSyncCopyMove(ctx context.Context, in rc.Params, name string) (out rc.Para
 	}
 	switch name {
 	case "sync":
+		confirmDeletes, err := in.GetBool("confirmDeletes")
+		if rc.NotErrParamNotFound(err) {
+			return nil, err
+		}
+		if confirmDeletes {
+			var ci *fs.ConfigInfo
+			ctx, ci = fs.AddConfig(ctx)
+			ci.MaxDeleteRatio = -1
+		}
 		return nil, Sync(ctx, dstFs, srcFs, createEmptySrcDirs)
 	case "copy":
 		return nil, CopyDir(ctx, dstFs, srcFs, createEmptySrcDirs)
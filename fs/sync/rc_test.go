@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/cache"
 	"github.com/rclone/rclone/fs/rc"
 	"github.com/rclone/rclone/fstest"
@@ -94,3 +95,27 @@ func TestRcSync(t *testing.T) {
 	r.CheckLocalItems(t, file1, file2)
 	r.CheckRemoteItems(t, file1, file2)
 }
+
+// sync/sync: confirmDeletes skips the --max-delete-ratio check
+func TestRcSyncConfirmDeletes(t *testing.T) {
+	r, call := rcNewRun(t, "sync/sync")
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.MaxDeleteRatio = 0
+	r.Mkdir(ctx, r.Flocal)
+
+	file1 := r.WriteObject(ctx, "file1", "file1 contents", t1)
+	r.CheckRemoteItems(t, file1)
+
+	in := rc.Params{
+		"srcFs": r.LocalName,
+		"dstFs": r.FremoteName,
+	}
+	_, err := call.Fn(ctx, in)
+	require.ErrorIs(t, err, ErrorMaxDeleteRatio)
+	r.CheckRemoteItems(t, file1)
+
+	in["confirmDeletes"] = true
+	_, err = call.Fn(ctx, in)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t)
+}
@@ -0,0 +1,203 @@
+package sync
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"golang.org/x/sync/errgroup"
+)
+
+// renamesListingHeader starts the first line of a --track-renames-file
+const renamesListingHeader = "# rclone track-renames listing hash="
+
+// renamesListingEntry is the fingerprint of a destination file and
+// the hash of the source file it was synced from
+type renamesListingEntry struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+// renamesListing is the persisted listing used by --track-renames-file
+// to detect renames when the source and destination have no hash in
+// common.
+//
+// It records, for each destination path, the size and modtime of the
+// file along with the hash of the source it came from. Destination
+// files whose size and modtime still match can then be matched to
+// source files by hash.
+type renamesListing struct {
+	path    string
+	ht      hash.Type
+	entries map[string]renamesListingEntry
+
+	mu      sync.Mutex
+	srcObjs []fs.Object // source objects seen in this sync
+}
+
+// newRenamesListing reads the listing at path returning an empty
+// listing if it doesn't exist.
+//
+// If the listing doesn't exist or uses a hash the source doesn't
+// support then a hash from the source is chosen and the listing will
+// only be useful from the next sync.
+func newRenamesListing(path string, srcHashes hash.Set) (*renamesListing, error) {
+	l := &renamesListing{
+		path:    path,
+		ht:      srcHashes.GetOne(),
+		entries: make(map[string]renamesListingEntry),
+	}
+	if l.ht == hash.None {
+		return nil, errors.New("source has no hashes")
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fs.Infof(nil, "No --track-renames-file %q yet - renames will be detected on the next sync", path)
+		return l, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	if !scanner.Scan() {
+		return l, scanner.Err()
+	}
+	hashName, ok := strings.CutPrefix(scanner.Text(), renamesListingHeader)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a --track-renames-file", path)
+	}
+	var ht hash.Type
+	if err := ht.Set(hashName); err != nil || !srcHashes.Contains(ht) {
+		fs.Logf(nil, "Ignoring --track-renames-file %q as the source doesn't support its %s hash", path, hashName)
+		return l, nil
+	}
+	l.ht = ht
+	for lineNumber := 2; scanner.Scan(); lineNumber++ {
+		remote, entry, err := parseRenamesListingLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		l.entries[remote] = entry
+	}
+	return l, scanner.Err()
+}
+
+// parseRenamesListingLine parses a line of the form
+//
+//	size hash modtime "path"
+func parseRenamesListingLine(line string) (remote string, entry renamesListingEntry, err error) {
+	fields := strings.SplitN(line, " ", 4)
+	if len(fields) != 4 {
+		return "", entry, errors.New("not enough fields")
+	}
+	entry.size, err = strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return "", entry, fmt.Errorf("bad size: %w", err)
+	}
+	entry.hash = fields[1]
+	entry.modTime, err = time.Parse(time.RFC3339Nano, fields[2])
+	if err != nil {
+		return "", entry, fmt.Errorf("bad modtime: %w", err)
+	}
+	remote, err = strconv.Unquote(fields[3])
+	if err != nil {
+		return "", entry, fmt.Errorf("bad path: %w", err)
+	}
+	return remote, entry, nil
+}
+
+// dstHash returns the hash of the source that dst was synced from if
+// dst still matches its fingerprint in the listing or "" if not.
+func (l *renamesListing) dstHash(ctx context.Context, dst fs.Object, modifyWindow time.Duration) string {
+	entry, ok := l.entries[dst.Remote()]
+	if !ok || entry.size != dst.Size() {
+		return ""
+	}
+	if modifyWindow != fs.ModTimeNotSupported {
+		dt := dst.ModTime(ctx).Sub(entry.modTime)
+		if dt >= modifyWindow || dt <= -modifyWindow {
+			return ""
+		}
+	}
+	return entry.hash
+}
+
+// addSrc records a source object seen in this sync
+func (l *renamesListing) addSrc(src fs.Object) {
+	l.mu.Lock()
+	l.srcObjs = append(l.srcObjs, src)
+	l.mu.Unlock()
+}
+
+// save writes the listing for the source objects seen in this sync
+//
+// Hashes are reused from the old listing where the source file's size
+// and modtime haven't changed.
+func (l *renamesListing) save(ctx context.Context, checkers int) (err error) {
+	fs.Infof(nil, "Saving --track-renames-file %q", l.path)
+	lines := make([]string, len(l.srcObjs))
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(checkers)
+	for i, src := range l.srcObjs {
+		g.Go(func() error {
+			remote := src.Remote()
+			modTime := src.ModTime(gCtx)
+			entry, ok := l.entries[remote]
+			sum := entry.hash
+			if !ok || entry.size != src.Size() || !entry.modTime.Equal(modTime) {
+				var err error
+				sum, err = src.Hash(gCtx, l.ht)
+				if err != nil {
+					fs.Debugf(src, "Not adding to --track-renames-file: %v", err)
+					return nil
+				}
+			}
+			if sum != "" {
+				lines[i] = fmt.Sprintf("%d %s %s %s\n", src.Size(), sum, modTime.UTC().Format(time.RFC3339Nano), strconv.Quote(remote))
+			}
+			return gCtx.Err()
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return err
+	}
+	sort.Strings(lines)
+
+	// Write to a temporary file and rename it into place so the
+	// old listing survives a failed write
+	tmp := l.path + ".tmp"
+	if err = os.MkdirAll(filepath.Dir(l.path), 0777); err != nil {
+		return err
+	}
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	_, _ = w.WriteString(renamesListingHeader + l.ht.String() + "\n")
+	for _, line := range lines {
+		_, _ = w.WriteString(line)
+	}
+	err = w.Flush()
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
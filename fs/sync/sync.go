@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rclone/rclone/fs"
@@ -19,6 +20,7 @@ import (
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/march"
 	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/rc/jobs"
 	"github.com/rclone/rclone/lib/errcount"
 	"github.com/rclone/rclone/lib/transform"
 	"golang.org/x/sync/errgroup"
@@ -32,6 +34,10 @@ var ErrorMaxDurationReached = errors.New("max transfer duration reached as set b
 // duration limit is reached.
 var ErrorMaxDurationReachedFatal = fserrors.FatalError(ErrorMaxDurationReached)
 
+// ErrorMaxDeleteRatio is returned when a sync would delete more than
+// --max-delete-ratio percent of the destination files.
+var ErrorMaxDeleteRatio = errors.New("--max-delete-ratio threshold reached")
+
 type syncCopyMove struct {
 	// parameters
 	fdst               fs.Fs
@@ -57,6 +63,7 @@ type syncCopyMove struct {
 	trackRenamesStrategy   trackRenamesStrategy   // strategies used for tracking renames
 	dstFilesMu             sync.Mutex             // protect dstFiles
 	dstFiles               map[string]fs.Object   // dst files, always filled
+	dstObjects             atomic.Int64           // number of objects found in dst
 	srcFiles               map[string]fs.Object   // src files, only used if deleteBefore
 	srcFilesChan           chan fs.Object         // passes src objects
 	srcFilesResult         chan error             // error result of src listing
@@ -83,6 +90,10 @@ type syncCopyMove struct {
 	trackRenamesWg         sync.WaitGroup         // wg for background track renames
 	trackRenamesCh         chan fs.Object         // objects are pumped in here
 	renameCheck            []fs.Object            // accumulate files to check for rename here
+	renamesListing         *renamesListing        // persisted listing for --track-renames-file if in use
+	dedupe                 *dedupeUploads         // index of uploaded files for --dedupe-uploads if in use
+	pruner                 *dirPruner             // change tokens for --prune-unchanged-dirs if in use
+	immutable              *immutableManifest     // manifest of dst files for --immutable-manifest if in use
 	compareCopyDest        []fs.Fs                // place to check for files to server side copy
 	backupDir              fs.Fs                  // place to store overwrites/deletes
 	checkFirst             bool                   // if set run all the checkers before starting transfers
@@ -97,6 +108,12 @@ type syncCopyMove struct {
 	setDirModTimesMaxLevel int                    // max level of the directories to set
 	modifiedDirs           map[string]struct{}    // dirs with changed contents (if s.setDirModTimeAfter)
 	allowOverlap           bool                   // whether we allow src and dst to overlap (i.e. for convmv)
+	gracefulStopped        atomic.Bool            // set if the sync has been stopped gracefully
+	remainingMu            sync.Mutex             // protect remaining
+	remaining              []fs.ObjectPair        // pairs still to transfer after a graceful stop
+	quotaExceeded          atomic.Bool            // set if the destination ran out of quota with --quota-fallback
+	quotaMu                sync.Mutex             // protect quotaRemaining
+	quotaRemaining         []fs.ObjectPair        // pairs not transferred as the destination is out of quota
 }
 
 // For keeping track of delayed modtime sets
@@ -132,6 +149,7 @@ func newSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 	if (deleteMode != fs.DeleteModeOff || DoMove) && operations.OverlappingFilterCheck(ctx, fdst, fsrc) && !allowOverlap {
 		return nil, fserrors.FatalError(fs.ErrorOverlapping)
 	}
+	ctx = filter.SetIgnoreSource(ctx, fsrc)
 	ci := fs.GetConfig(ctx)
 	fi := filter.GetConfig(ctx)
 	s := &syncCopyMove{
@@ -226,6 +244,11 @@ func newSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 	if err != nil {
 		return nil, err
 	}
+	if ci.CompareExpr != "" {
+		if _, err = operations.CompileCompareExpr(ci.CompareExpr); err != nil {
+			return nil, fserrors.FatalError(err)
+		}
+	}
 	if s.noCheckDest {
 		if s.deleteMode != fs.DeleteModeOff {
 			return nil, errors.New("can't use --no-check-dest with sync: use copy instead")
@@ -244,8 +267,16 @@ func newSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 			s.trackRenames = false
 		}
 		if s.trackRenamesStrategy.hash() && s.commonHash == hash.None {
-			fs.Errorf(fdst, "Ignoring --track-renames as the source and destination do not have a common hash")
-			s.trackRenames = false
+			if ci.TrackRenamesFile == "" {
+				fs.Errorf(fdst, "Ignoring --track-renames as the source and destination do not have a common hash")
+				s.trackRenames = false
+			} else if s.renamesListing, err = newRenamesListing(ci.TrackRenamesFile, fsrc.Hashes()); err != nil {
+				fs.Errorf(fdst, "Ignoring --track-renames as --track-renames-file failed: %v", err)
+				s.trackRenames = false
+			} else {
+				fs.Infof(fdst, "Using --track-renames-file to track renames as the source and destination do not have a common hash")
+				s.commonHash = s.renamesListing.ht
+			}
 		}
 
 		if s.trackRenamesStrategy.modTime() && s.modifyWindow == fs.ModTimeNotSupported {
@@ -258,6 +289,26 @@ func newSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 			s.trackRenames = false
 		}
 	}
+	if ci.ImmutableManifest != "" {
+		if !ci.Immutable {
+			return nil, fserrors.FatalError(errors.New("--immutable-manifest needs --immutable"))
+		}
+		s.immutable, err = newImmutableManifest(ci.ImmutableManifest, ci.ImmutableReport, fdst, fsrc)
+		if err != nil {
+			return nil, fserrors.FatalError(fmt.Errorf("failed to read --immutable-manifest: %w", err))
+		}
+		// Every file on the destination must be seen to be checked
+		if s.noTraverse {
+			fs.Errorf(nil, "Ignoring --no-traverse with --immutable-manifest")
+			s.noTraverse = false
+		}
+		if s.trackRenames {
+			fs.Errorf(fdst, "Ignoring --track-renames as it doesn't work with --immutable-manifest")
+			s.trackRenames = false
+		}
+	} else if ci.ImmutableReport != "" {
+		return nil, fserrors.FatalError(errors.New("--immutable-report needs --immutable-manifest"))
+	}
 	if s.trackRenames {
 		// track renames needs delete after
 		if s.deleteMode != fs.DeleteModeOff {
@@ -267,9 +318,25 @@ func newSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 			fs.Errorf(nil, "Ignoring --no-traverse with --track-renames")
 			s.noTraverse = false
 		}
+	} else {
+		s.renamesListing = nil
+	}
+	if ci.DedupeUploads {
+		if DoMove {
+			fs.Errorf(fdst, "Ignoring --dedupe-uploads as it doesn't work with move, only copy or sync")
+		} else {
+			s.dedupe = newDedupeUploads(ctx, fdst, fsrc, s.commonHash)
+		}
+	}
+	if ci.PruneUnchangedDirs {
+		if DoMove || s.trackRenames || s.immutable != nil {
+			fs.Errorf(fdst, "Ignoring --prune-unchanged-dirs as it doesn't work with move, --track-renames or --immutable-manifest")
+		} else {
+			s.pruner = newDirPruner(ctx, fdst, fsrc, s.fi)
+		}
 	}
 	// Make Fs for --backup-dir if required
-	if ci.BackupDir != "" || ci.Suffix != "" {
+	if ci.BackupDir != "" || ci.Suffix != "" || ci.BackupDirVersions {
 		var err error
 		s.backupDir, err = operations.BackupDir(ctx, fdst, fsrc, "")
 		if err != nil {
@@ -320,6 +387,10 @@ func (s *syncCopyMove) processError(err error) {
 	if err == nil {
 		return
 	}
+	if s.ci.QuotaFallback && fserrors.IsQuotaError(err) {
+		s.startQuotaFallback(err)
+		return
+	}
 	if err == context.DeadlineExceeded {
 		err = fserrors.NoRetryError(err)
 	} else if err == accounting.ErrorMaxTransferLimitReachedGraceful {
@@ -377,6 +448,10 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 		}
 		src := pair.Src
 		var err error
+		forwarded := false
+		if s.immutable != nil && pair.Dst != nil {
+			s.processError(s.immutable.check(s.ctx, pair.Dst))
+		}
 		tr := accounting.Stats(s.ctx).NewCheckingTransfer(src, "checking")
 		// Check to see if can store this
 		if src.Storable() {
@@ -402,11 +477,17 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 				}
 			}
 			if needTransfer {
-				// If files are treated as immutable, fail if destination exists and does not match
-				if s.ci.Immutable && pair.Dst != nil {
+				if s.quotaExceeded.Load() {
+					// Destination is out of quota so just record the file
+					s.addQuotaRemaining(pair)
+				} else if s.ci.Immutable && pair.Dst != nil {
+					// If files are treated as immutable, fail if destination exists and does not match
 					err := fs.CountError(s.ctx, fserrors.NoRetryError(fs.ErrorImmutableModified))
 					fs.Errorf(pair.Dst, "Source and destination exist but do not match: %v", err)
 					s.processError(err)
+					if s.immutable != nil {
+						s.immutable.refused(s.ctx, pair.Dst, src)
+					}
 				} else {
 					if pair.Dst != nil {
 						s.markDirModifiedObject(pair.Dst)
@@ -424,14 +505,18 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 							pair.Dst = nil
 							ok = out.Put(s.inCtx, pair)
 							if !ok {
+								s.addRemaining(pair)
 								return
 							}
+							forwarded = true
 						}
 					} else {
 						ok = out.Put(s.inCtx, pair)
 						if !ok {
+							s.addRemaining(pair)
 							return
 						}
+						forwarded = true
 					}
 				}
 			} else {
@@ -450,8 +535,9 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 						if !ok {
 							return
 						}
+						forwarded = true
 					} else {
-						deleteFileErr := operations.DeleteFile(s.ctx, src)
+						deleteFileErr := operations.DeleteFile(operations.WithoutTrash(s.ctx), src)
 						s.processError(deleteFileErr)
 						s.logger(s.ctx, operations.TransferError, pair.Src, pair.Dst, deleteFileErr)
 					}
@@ -459,6 +545,9 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 			}
 		}
 		tr.Done(s.ctx, err)
+		if !forwarded {
+			fs.EndTrace(src.Remote(), err)
+		}
 	}
 }
 
@@ -479,6 +568,8 @@ func (s *syncCopyMove) pairRenamer(in *pipe, out *pipe, fraction int, wg *sync.W
 			if !ok {
 				return
 			}
+		} else {
+			fs.EndTrace(src.Remote(), nil)
 		}
 	}
 }
@@ -494,20 +585,45 @@ func (s *syncCopyMove) pairCopyOrMove(ctx context.Context, in *pipe, fdst fs.Fs,
 		}
 		src := pair.Src
 		dst := pair.Dst
+		if jobs.Excluded(ctx, src.Remote()) {
+			fs.Infof(src, "Not transferring as excluded with job/exclude")
+			fs.EndTrace(src.Remote(), nil)
+			continue
+		}
+		if s.quotaExceeded.Load() && src != dst {
+			s.addQuotaRemaining(pair)
+			fs.EndTrace(src.Remote(), nil)
+			continue
+		}
+		var newDst fs.Object
 		if s.DoMove {
 			if src != dst {
-				_, err = operations.MoveTransfer(ctx, fdst, dst, src.Remote(), src)
+				newDst, err = operations.MoveTransfer(ctx, fdst, dst, src.Remote(), src)
 			} else {
 				// src == dst signals delete the src
-				err = operations.DeleteFile(ctx, src)
+				err = operations.DeleteFile(operations.WithoutTrash(ctx), src)
 			}
+		} else if s.dedupe != nil {
+			newDst, err = s.dedupe.copy(ctx, dst, src, s.modifyWindow, func() (fs.Object, error) {
+				return operations.Copy(ctx, fdst, dst, src.Remote(), src)
+			})
 		} else {
-			_, err = operations.Copy(ctx, fdst, dst, src.Remote(), src)
+			newDst, err = operations.Copy(ctx, fdst, dst, src.Remote(), src)
+		}
+		if err == nil && newDst != nil && s.immutable != nil {
+			s.processError(s.immutable.record(ctx, newDst))
 		}
 		s.processError(err)
 		if err != nil {
+			if s.quotaExceeded.Load() && fserrors.IsQuotaError(err) {
+				s.addQuotaRemaining(pair)
+			}
 			s.logger(ctx, operations.TransferError, src, dst, err)
+			if src != dst {
+				jobs.AddFailure(ctx, s.fsrc, src.Remote(), fdst, src.Remote(), s.DoMove, err)
+			}
 		}
+		fs.EndTrace(src.Remote(), err)
 	}
 }
 
@@ -656,6 +772,24 @@ func (s *syncCopyMove) deleteFiles(checkSrcMap bool) error {
 	return operations.DeleteFilesWithBackupDir(s.ctx, toDelete, s.backupDir)
 }
 
+// checkMaxDeleteRatio returns an error if deleting the files in
+// dstFiles would delete more than --max-delete-ratio percent of the
+// files found in the destination.
+func (s *syncCopyMove) checkMaxDeleteRatio() error {
+	if s.ci.MaxDeleteRatio < 0 {
+		return nil
+	}
+	deletes := int64(len(s.dstFiles))
+	total := s.dstObjects.Load()
+	if deletes == 0 || total == 0 || deletes*100 <= total*int64(s.ci.MaxDeleteRatio) {
+		return nil
+	}
+	for _, o := range s.dstFiles {
+		s.logger(s.ctx, operations.TransferError, nil, o, ErrorMaxDeleteRatio)
+	}
+	return fserrors.FatalError(fmt.Errorf("%w: not deleting %d of the %d files (%d%%) in the destination", ErrorMaxDeleteRatio, deletes, total, deletes*100/total))
+}
+
 // This deletes the empty directories in the slice passed in.  It
 // ignores any errors deleting directories
 func (s *syncCopyMove) deleteEmptyDirectories(ctx context.Context, f fs.Fs, entriesMap map[string]fs.DirEntry) error {
@@ -762,18 +896,26 @@ func parseTrackRenamesStrategy(strategies string) (strategy trackRenamesStrategy
 
 // renameID makes a string with the size and the other identifiers of the requested rename strategies
 //
+// isDst should be set if obj is on the destination.
+//
 // it may return an empty string in which case no hash could be made
-func (s *syncCopyMove) renameID(obj fs.Object, renamesStrategy trackRenamesStrategy, precision time.Duration) string {
+func (s *syncCopyMove) renameID(obj fs.Object, isDst bool, renamesStrategy trackRenamesStrategy, precision time.Duration) string {
 	var builder strings.Builder
 
 	fmt.Fprintf(&builder, "%d", obj.Size())
 
 	if renamesStrategy.hash() {
-		var err error
-		hash, err := obj.Hash(s.ctx, s.commonHash)
-		if err != nil {
-			fs.Debugf(obj, "Hash failed: %v", err)
-			return ""
+		var hash string
+		if isDst && s.renamesListing != nil {
+			// Use the hash of the source this was synced from
+			hash = s.renamesListing.dstHash(s.ctx, obj, s.modifyWindow)
+		} else {
+			var err error
+			hash, err = obj.Hash(s.ctx, s.commonHash)
+			if err != nil {
+				fs.Debugf(obj, "Hash failed: %v", err)
+				return ""
+			}
 		}
 		if hash == "" {
 			return ""
@@ -867,7 +1009,7 @@ func (s *syncCopyMove) makeRenameMap() {
 				// only create hash for dst fs.Object if its size could match
 				if _, found := possibleSizes[obj.Size()]; found {
 					tr := accounting.Stats(s.ctx).NewCheckingTransfer(obj, "renaming")
-					hash := s.renameID(obj, s.trackRenamesStrategy, s.modifyWindow)
+					hash := s.renameID(obj, true, s.trackRenamesStrategy, s.modifyWindow)
 
 					if hash != "" {
 						s.pushRenameMap(hash, obj)
@@ -886,7 +1028,7 @@ func (s *syncCopyMove) makeRenameMap() {
 // possible, it returns true if the object was renamed.
 func (s *syncCopyMove) tryRename(src fs.Object) bool {
 	// Calculate the hash of the src object
-	hash := s.renameID(src, s.trackRenamesStrategy, fs.GetModifyWindow(s.ctx, s.fsrc, s.fdst))
+	hash := s.renameID(src, false, s.trackRenamesStrategy, fs.GetModifyWindow(s.ctx, s.fsrc, s.fdst))
 
 	if hash == "" {
 		return false
@@ -930,6 +1072,9 @@ func (s *syncCopyMove) run() error {
 		return nil
 	}
 
+	// Allow the sync to be stopped gracefully
+	defer s.registerGracefulStop()()
+
 	// Start background checking and transferring pipeline
 	s.startCheckers()
 	s.startRenamers()
@@ -953,7 +1098,9 @@ func (s *syncCopyMove) run() error {
 		NoCheckDest:            s.noCheckDest,
 		NoUnicodeNormalization: s.noUnicodeNormalization,
 	}
-	s.processError(m.Run(s.ctx))
+	err := m.Run(s.ctx)
+	listingComplete := err == nil && s.inCtx.Err() == nil
+	s.processError(err)
 
 	s.stopTrackRenames()
 	if s.trackRenames {
@@ -977,11 +1124,20 @@ func (s *syncCopyMove) run() error {
 	s.stopRenamers()
 	s.stopTransfers()
 	s.stopDeleters()
+	if s.dedupe != nil {
+		s.dedupe.close()
+	}
+	s.processError(s.saveRemaining(listingComplete))
+	s.processError(s.saveQuotaRemaining())
 
 	// Delete files after
 	if s.deleteMode == fs.DeleteModeAfter {
-		if s.currentError() != nil && !s.ci.IgnoreErrors {
+		if s.gracefulStopped.Load() {
+			fs.Logf(s.fdst, "Not deleting files as the sync was stopped gracefully")
+		} else if s.currentError() != nil && !s.ci.IgnoreErrors {
 			fs.Errorf(s.fdst, "%v", fs.ErrorNotDeleting)
+		} else if err := s.checkMaxDeleteRatio(); err != nil {
+			s.processError(err)
 		} else {
 			s.processError(s.deleteFiles(false))
 		}
@@ -994,7 +1150,9 @@ func (s *syncCopyMove) run() error {
 
 	// Prune empty directories
 	if s.deleteMode != fs.DeleteModeOff {
-		if s.currentError() != nil && !s.ci.IgnoreErrors {
+		if s.gracefulStopped.Load() {
+			fs.Logf(s.fdst, "Not deleting directories as the sync was stopped gracefully")
+		} else if s.currentError() != nil && !s.ci.IgnoreErrors {
 			fs.Errorf(s.fdst, "%v", fs.ErrorNotDeletingDirs)
 		} else {
 			s.processError(s.deleteEmptyDirectories(s.ctx, s.fdst, s.dstEmptyDirs))
@@ -1018,6 +1176,34 @@ func (s *syncCopyMove) run() error {
 		s.processError(ErrorMaxDurationReachedFatal)
 	}
 
+	// Save the listing for --track-renames-file for the next sync
+	if s.renamesListing != nil {
+		if s.currentError() != nil {
+			fs.Errorf(s.fdst, "Not updating --track-renames-file as there were errors")
+		} else {
+			s.processError(s.renamesListing.save(s.ctx, s.ci.Checkers))
+		}
+	}
+
+	// Check for missing files and save --immutable-manifest
+	if s.immutable != nil {
+		s.processError(s.immutable.finish(s.ctx, s.dir, s.fi, listingComplete && s.ci.MaxDepth < 0))
+		if !s.ci.DryRun {
+			s.processError(s.immutable.save())
+		}
+		s.processError(s.immutable.writeReport(listingComplete))
+	}
+
+	// Save the directory change tokens for --prune-unchanged-dirs
+	if s.pruner != nil {
+		if s.currentError() != nil {
+			fs.Errorf(s.fdst, "Not saving directory change tokens as there were errors")
+		} else if !s.ci.DryRun {
+			s.processError(s.pruner.save())
+		}
+		s.pruner.close()
+	}
+
 	// Print nothing to transfer message if there were no transfers and no errors
 	if s.deleteMode != fs.DeleteModeOnly && accounting.Stats(s.ctx).GetTransfers() == 0 && s.currentError() == nil {
 		fs.Infof(nil, "There was nothing to transfer")
@@ -1031,6 +1217,13 @@ func (s *syncCopyMove) run() error {
 
 // DstOnly have an object which is in the destination only
 func (s *syncCopyMove) DstOnly(dst fs.DirEntry) (recurse bool) {
+	if operations.IsChecksumSidecar(s.ctx, dst) {
+		// Sidecars are deleted with their files
+		return false
+	}
+	if s.immutable != nil {
+		return s.immutableDstOnly(dst)
+	}
 	if s.deleteMode == fs.DeleteModeOff {
 		if s.usingLogger {
 			switch x := dst.(type) {
@@ -1048,6 +1241,7 @@ func (s *syncCopyMove) DstOnly(dst fs.DirEntry) (recurse bool) {
 	}
 	switch x := dst.(type) {
 	case fs.Object:
+		s.dstObjects.Add(1)
 		s.logger(s.ctx, operations.MissingOnSrc, nil, x, nil)
 		switch s.deleteMode {
 		case fs.DeleteModeAfter:
@@ -1225,13 +1419,21 @@ func (s *syncCopyMove) setDelayedDirModTimes(ctx context.Context) error {
 
 // SrcOnly have an object which is in the source only
 func (s *syncCopyMove) SrcOnly(src fs.DirEntry) (recurse bool) {
+	if operations.IsChecksumSidecar(s.ctx, src) {
+		// Sidecars are written for the destination files
+		return false
+	}
 	if s.deleteMode == fs.DeleteModeOnly {
 		return false
 	}
 	switch x := src.(type) {
 	case fs.Object:
+		fs.StartTrace(x.Remote())
 		s.logger(s.ctx, operations.MissingOnDst, x, nil, nil)
 		s.markParentNotEmpty(src)
+		if s.renamesListing != nil {
+			s.renamesListing.addSrc(x)
+		}
 
 		if s.trackRenames {
 			// Save object to check for a rename later
@@ -1255,6 +1457,8 @@ func (s *syncCopyMove) SrcOnly(src fs.DirEntry) (recurse bool) {
 				if !ok {
 					return
 				}
+			} else {
+				fs.EndTrace(x.Remote(), err)
 			}
 		}
 	case fs.Directory:
@@ -1274,6 +1478,12 @@ func (s *syncCopyMove) SrcOnly(src fs.DirEntry) (recurse bool) {
 
 // Match is called when src and dst are present, so sync src to dst
 func (s *syncCopyMove) Match(ctx context.Context, dst, src fs.DirEntry) (recurse bool) {
+	if operations.IsChecksumSidecar(ctx, src) {
+		return false
+	}
+	if _, ok := dst.(fs.Object); ok {
+		s.dstObjects.Add(1)
+	}
 	switch srcX := src.(type) {
 	case fs.Object:
 		s.markParentNotEmpty(src)
@@ -1281,8 +1491,12 @@ func (s *syncCopyMove) Match(ctx context.Context, dst, src fs.DirEntry) (recurse
 		if s.deleteMode == fs.DeleteModeOnly {
 			return false
 		}
+		if s.renamesListing != nil {
+			s.renamesListing.addSrc(srcX)
+		}
 		dstX, ok := dst.(fs.Object)
 		if ok {
+			fs.StartTrace(srcX.Remote())
 			// No logger here because we'll handle it in equal()
 			ok = s.toBeChecked.Put(s.inCtx, fs.ObjectPair{Src: srcX, Dst: dstX})
 			if !ok {
@@ -1296,6 +1510,7 @@ func (s *syncCopyMove) Match(ctx context.Context, dst, src fs.DirEntry) (recurse
 			s.logger(ctx, operations.TransferError, srcX, dstX, err)
 		}
 	case fs.Directory:
+		unchanged := s.pruner.unchanged(srcX)
 		// Do the same thing to the entire contents of the directory
 		srcX = fs.NewOverrideDirectory(srcX, transform.Path(ctx, src.Remote(), true))
 		src = srcX
@@ -1320,6 +1535,14 @@ func (s *syncCopyMove) Match(ctx context.Context, dst, src fs.DirEntry) (recurse
 				}
 			}
 
+			if unchanged {
+				// Its contents aren't being looked at so don't treat it as empty
+				s.srcEmptyDirsMu.Lock()
+				delete(s.srcEmptyDirs, src.Remote())
+				s.srcEmptyDirsMu.Unlock()
+				fs.Debugf(src, "Skipping directory as unchanged since last sync")
+				return false
+			}
 			return true
 		}
 		// FIXME src is dir, dst is file
@@ -1345,6 +1568,22 @@ func runSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 	if deleteMode != fs.DeleteModeOff && DoMove {
 		return fserrors.FatalError(errors.New("can't delete and move at the same time"))
 	}
+	ctx = transform.ForDestination(ctx, fdst)
+	ctx, noChanges, commit := changedPaths(ctx, fdst, fsrc)
+	if noChanges {
+		commitChangedPaths(fsrc, commit)
+		return nil
+	}
+	// --max-delete-ratio needs to count the dst files before deleting
+	if ci.MaxDeleteRatio >= 0 && (deleteMode == fs.DeleteModeBefore || deleteMode == fs.DeleteModeDuring) {
+		fs.Infof(fdst, "Using --delete-after as --max-delete-ratio is set")
+		deleteMode = fs.DeleteModeAfter
+	}
+	// --immutable-manifest needs to see every dst file in one pass
+	if ci.ImmutableManifest != "" && deleteMode == fs.DeleteModeBefore {
+		fs.Infof(fdst, "Using --delete-after as --immutable-manifest is set")
+		deleteMode = fs.DeleteModeAfter
+	}
 	// Run an extra pass to delete only
 	if deleteMode == fs.DeleteModeBefore {
 		if ci.TrackRenames {
@@ -1366,7 +1605,71 @@ func runSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.Delete
 	if err != nil {
 		return err
 	}
-	return do.run()
+	err = do.run()
+	if err == nil {
+		commitChangedPaths(fsrc, commit)
+	}
+	return err
+}
+
+// changedPaths restricts the sync to the paths which changed since
+// the last sync to fdst if fsrc keeps track of them.
+//
+// It returns the new context, whether nothing changed and a function
+// to call after a successful sync which may be nil.
+func changedPaths(ctx context.Context, fdst, fsrc fs.Fs) (newCtx context.Context, noChanges bool, commit func() error) {
+	do := fsrc.Features().ChangedPaths
+	if do == nil {
+		return ctx, false, nil
+	}
+	ci := fs.GetConfig(ctx)
+	fi := filter.GetConfig(ctx)
+	switch {
+	case !fi.InActive() || fi.Opt.DeleteExcluded:
+		fs.Infof(fsrc, "Not using the change journal as filters are in use")
+		return ctx, false, nil
+	case ci.TrackRenames:
+		fs.Infof(fsrc, "Not using the change journal as --track-renames is in use")
+		return ctx, false, nil
+	case ci.MaxDepth >= 0:
+		fs.Infof(fsrc, "Not using the change journal as --max-depth is in use")
+		return ctx, false, nil
+	case ci.ImmutableManifest != "":
+		fs.Infof(fsrc, "Not using the change journal as --immutable-manifest is in use")
+		return ctx, false, nil
+	}
+	paths, commit, err := do(ctx, fs.ConfigString(fdst))
+	if ci.DryRun {
+		commit = nil
+	}
+	if err != nil {
+		fs.Infof(fsrc, "Checking all files: %v", err)
+		return ctx, false, commit
+	}
+	if len(paths) == 0 {
+		fs.Infof(fsrc, "Nothing has changed since the last sync according to the change journal")
+		return ctx, true, commit
+	}
+	newFi, err := filter.NewFilter(&fi.Opt)
+	if err != nil {
+		fs.Errorf(fsrc, "Checking all files: failed to make filter: %v", err)
+		return ctx, false, nil
+	}
+	for _, remote := range paths {
+		_ = newFi.AddFile(remote)
+	}
+	fs.Infof(fsrc, "Checking %d paths which changed since the last sync according to the change journal", len(paths))
+	return filter.ReplaceConfig(ctx, newFi), false, commit
+}
+
+// commitChangedPaths records a successful sync in the change journal
+func commitChangedPaths(fsrc fs.Fs, commit func() error) {
+	if commit == nil {
+		return
+	}
+	if err := commit(); err != nil {
+		fs.Errorf(fsrc, "Failed to update the change journal: %v", err)
+	}
 }
 
 // Sync fsrc into fdst
@@ -1401,6 +1704,7 @@ func MoveDir(ctx context.Context, fdst, fsrc fs.Fs, deleteEmptySrcDirs bool, cop
 	// First attempt to use DirMover if exists, same Fs and no filters are active
 	if fdstDirMove := fdst.Features().DirMove; fdstDirMove != nil && operations.SameConfig(fsrc, fdst) && fi.InActive() {
 		if operations.SkipDestructive(ctx, fdst, "server-side directory move") {
+			operations.AddPlanDirMove(ctx)
 			return nil
 		}
 		fs.Debugf(fdst, "Using server-side directory move")
@@ -10,6 +10,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
@@ -22,10 +23,13 @@ import (
 	"github.com/rclone/rclone/cmd/bisync/bilib"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/config/obscure"
 	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/rc/jobs"
 	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/lib/transform"
 	"github.com/stretchr/testify/assert"
@@ -86,6 +90,133 @@ func TestCopy(t *testing.T) {
 	r.CheckDirectoryModTimes(t, "sub dir")
 }
 
+// Test excluding files from a running job
+func TestCopyJobExclude(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	file1 := r.WriteFile("sub dir/hello world", "hello world", t1)
+	file2 := r.WriteFile("big.iso", "potato", t1)
+	r.Mkdir(ctx, r.Fremote)
+	fs.EnableTraces(true)
+	defer fs.EnableTraces(false)
+
+	_, _, err := jobs.NewJob(ctx, func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		job, ok := jobs.GetJob(ctx)
+		require.True(t, ok)
+		require.NoError(t, job.AddExclude("*.iso"))
+		return nil, CopyDir(ctx, r.Fremote, r.Flocal, false)
+	}, rc.Params{})
+	require.NoError(t, err)
+
+	r.CheckLocalItems(t, file1, file2)
+	r.CheckRemoteItems(t, file1)
+
+	// The excluded file's trace should have ended
+	assert.Equal(t, "", fs.TraceID(file2.Path))
+	assert.Equal(t, "", fs.TraceID(file1.Path))
+}
+
+// Test stopping a copy gracefully
+func TestCopyGracefulStop(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	ci.Transfers = 1
+	ci.GracefulStopList = filepath.Join(t.TempDir(), "remaining.txt")
+	var files []fstest.Item
+	for i := range 5 {
+		files = append(files, r.WriteFile(fmt.Sprintf("file%d", i), "hello", t1))
+	}
+	r.Mkdir(ctx, r.Fremote)
+
+	started := make(chan *jobs.Job)
+	done := make(chan error)
+	go func() {
+		_, _, err := jobs.NewJob(ctx, func(ctx context.Context, in rc.Params) (rc.Params, error) {
+			job, _ := jobs.GetJob(ctx)
+			accounting.Stats(ctx).Pause()
+			started <- job
+			return nil, CopyDir(ctx, r.Fremote, r.Flocal, false)
+		}, rc.Params{})
+		done <- err
+	}()
+	job := <-started
+	stats := accounting.StatsGroup(ctx, job.Group)
+
+	// Wait for everything to be queued with one transfer paused
+	for {
+		out, err := stats.RemoteStats(false)
+		require.NoError(t, err)
+		if out["totalTransfers"] == int64(len(files)) && out["transferring"] != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	_, err := rc.Calls.Get("job/stop").Fn(ctx, rc.Params{"jobid": job.ID, "graceful": true})
+	require.NoError(t, err)
+	stats.Resume()
+	err = <-done
+	assert.True(t, errors.Is(err, ErrorGracefulStop), err)
+
+	// The transfer in progress finished and the rest are in the list
+	var remote []fstest.Item
+	var remaining []string
+	for _, file := range files {
+		if _, err := r.Fremote.NewObject(ctx, file.Path); err == nil {
+			remote = append(remote, file)
+		} else {
+			remaining = append(remaining, file.Path)
+		}
+	}
+	assert.Equal(t, 1, len(remote))
+	r.CheckRemoteItems(t, remote...)
+	data, err := os.ReadFile(ci.GracefulStopList)
+	require.NoError(t, err)
+	var listed []string
+	for line := range strings.SplitSeq(strings.TrimSpace(string(data)), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			listed = append(listed, line)
+		}
+	}
+	assert.Equal(t, remaining, listed)
+}
+
+// Test a copy carries on checking when the destination runs out of quota
+func TestCopyQuotaFallback(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ctx = accounting.WithStatsGroup(ctx, "test-quota-fallback")
+	r := fstest.NewRun(t)
+	ci.Transfers = 1
+	ci.QuotaFallback = true
+	ci.QuotaFallbackList = filepath.Join(t.TempDir(), "remaining.txt")
+	var want []string
+	for i := range 5 {
+		file := r.WriteFile(fmt.Sprintf("file%d", i), "hello", t1)
+		want = append(want, file.Path)
+	}
+	fdst, err := fs.NewFs(ctx, ":faulty,remote='"+t.TempDir()+"',error_rate=1,error_type=quota,operations=put:")
+	require.NoError(t, err)
+
+	err = CopyDir(ctx, fdst, r.Flocal, false)
+	assert.True(t, errors.Is(err, ErrorQuotaFallback), err)
+	assert.False(t, fserrors.IsRetryError(err))
+
+	// Only the first transfer failed and the rest weren't attempted
+	assert.Equal(t, int64(1), accounting.Stats(ctx).GetErrors())
+	data, err := os.ReadFile(ci.QuotaFallbackList)
+	require.NoError(t, err)
+	var listed []string
+	for line := range strings.SplitSeq(strings.TrimSpace(string(data)), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			listed = append(listed, line)
+		}
+	}
+	assert.Equal(t, want, listed)
+}
+
 func testCopyMetadata(t *testing.T, createEmptySrcDirs bool) {
 	ctx := context.Background()
 	ctx, ci := fs.AddConfig(ctx)
@@ -627,6 +758,30 @@ func TestServerSideCopy(t *testing.T) {
 	fstest.CheckItems(t, FremoteCopy, file1)
 }
 
+// Test copying identical files with --dedupe-uploads
+func TestCopyDedupeUploads(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+
+	ci.DedupeUploads = true
+
+	file1 := r.WriteFile("a/one", "identical contents", t1)
+	file2 := r.WriteFile("b/two", "identical contents", t2)
+	file3 := r.WriteFile("three", "different contents", t3)
+	r.CheckLocalItems(t, file1, file2, file3)
+
+	err := CopyDir(ctx, r.Fremote, r.Flocal, false)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file1, file2, file3)
+
+	// Copy again with another identical file
+	file4 := r.WriteFile("c/four", "identical contents", t3)
+	err = CopyDir(ctx, r.Fremote, r.Flocal, false)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file1, file2, file3, file4)
+}
+
 // Test copying a file over itself
 func TestCopyOverSelf(t *testing.T) {
 	ctx := context.Background()
@@ -769,6 +924,44 @@ func TestCopyRedownload(t *testing.T) {
 // Create a file and sync it. Change the last modified date and resync.
 // If we're only doing sync by size and checksum, we expect nothing to
 // to be transferred on the second sync.
+// Test that each file synced gets a trace which is ended once
+func TestSyncTraces(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	file1 := r.WriteFile("new", "new file", t1)
+	file2 := r.WriteFile("same", "same file", t1)
+	r.WriteObject(ctx, "same", "same file", t1)
+	file3 := r.WriteFile("changed", "changed file", t2)
+	r.WriteObject(ctx, "changed", "old", t1)
+
+	var mu mutex.Mutex
+	ended := map[string][]fs.TraceSpan{}
+	oldExport := fs.TraceExport
+	fs.TraceExport = func(span *fs.TraceSpan) {
+		mu.Lock()
+		ended[span.Remote] = append(ended[span.Remote], *span)
+		mu.Unlock()
+	}
+	fs.EnableTraces(true)
+	defer func() {
+		fs.TraceExport = oldExport
+		fs.EnableTraces(false)
+	}()
+
+	accounting.GlobalStats().ResetCounters()
+	err := Sync(ctx, r.Fremote, r.Flocal, false)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file1, file2, file3)
+
+	for _, remote := range []string{"new", "same", "changed"} {
+		spans := ended[remote]
+		require.Len(t, spans, 1, remote)
+		assert.Len(t, spans[0].TraceID, 32)
+		assert.NoError(t, spans[0].Err)
+		assert.Equal(t, "", fs.TraceID(remote), remote)
+	}
+}
+
 func TestSyncBasedOnCheckSum(t *testing.T) {
 	ctx := context.Background()
 	ctx, ci := fs.AddConfig(ctx)
@@ -1379,6 +1572,36 @@ func TestCopyDeleteBefore(t *testing.T) {
 	r.CheckLocalItems(t, file2)
 }
 
+// Test --max-delete-ratio stops a sync deleting too many files
+func TestSyncMaxDeleteRatio(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	file1 := r.WriteBoth(ctx, "keep", "kept", t1)
+	file2 := r.WriteObject(ctx, "delete1", "deleted", t1)
+	file3 := r.WriteObject(ctx, "dir/delete2", "deleted", t1)
+	file4 := r.WriteObject(ctx, "dir/delete3", "deleted", t1)
+	file5 := r.WriteFile("new", "copied", t2)
+	r.CheckRemoteItems(t, file1, file2, file3, file4)
+
+	// 3 of the 4 files in the destination is 75%
+	ci.MaxDeleteRatio = 50
+	ci.DeleteMode = fs.DeleteModeDuring
+
+	accounting.GlobalStats().ResetCounters()
+	err := Sync(ctx, r.Fremote, r.Flocal, false)
+	require.ErrorIs(t, err, ErrorMaxDeleteRatio)
+	assert.True(t, fserrors.IsFatalError(err))
+	assert.Contains(t, err.Error(), "not deleting 3 of the 4 files (75%)")
+	r.CheckRemoteItems(t, file1, file2, file3, file4, file5)
+
+	ci.MaxDeleteRatio = 75
+	accounting.GlobalStats().ResetCounters()
+	err = Sync(ctx, r.Fremote, r.Flocal, false)
+	require.NoError(t, err)
+	r.CheckRemoteItems(t, file1, file5)
+}
+
 // Test with exclude
 func TestSyncWithExclude(t *testing.T) {
 	ctx := context.Background()
@@ -1595,6 +1818,46 @@ func TestSyncWithTrackRenames(t *testing.T) {
 	}
 }
 
+// Test with TrackRenames and TrackRenamesFile set syncing to a
+// destination with no hashes
+func TestSyncWithTrackRenamesFile(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	r := fstest.NewRun(t)
+	if r.Flocal.Hashes().Count() == 0 {
+		t.Skip("need a source with hashes")
+	}
+	fdst, err := fs.NewFs(ctx, ":crypt,remote='"+t.TempDir()+"',password='"+obscure.MustObscure("potato")+"':")
+	require.NoError(t, err)
+	require.Equal(t, hash.None, fdst.Hashes().Overlap(r.Flocal.Hashes()).GetOne())
+
+	ci.TrackRenames = true
+	ci.TrackRenamesFile = filepath.Join(t.TempDir(), "renames.lst")
+
+	f1 := r.WriteFile("potato", "Potato Content", t1)
+	f2 := r.WriteFile("yam", "Yam Content", t2)
+
+	accounting.GlobalStats().ResetCounters()
+	require.NoError(t, Sync(ctx, fdst, r.Flocal, false))
+	fstest.CheckItems(t, fdst, f1, f2)
+	assert.FileExists(t, ci.TrackRenamesFile)
+
+	// Now rename locally.
+	f2 = r.RenameFile(f2, "yaml")
+
+	accounting.GlobalStats().ResetCounters()
+	require.NoError(t, Sync(ctx, fdst, r.Flocal, false))
+	fstest.CheckItems(t, fdst, f1, f2)
+	assert.Equal(t, int64(1), accounting.GlobalStats().Renames(0))
+	assert.Equal(t, int64(0), accounting.GlobalStats().GetTransfers())
+
+	// Check the listing has been updated with the new name
+	listing, err := newRenamesListing(ci.TrackRenamesFile, r.Flocal.Hashes())
+	require.NoError(t, err)
+	assert.Contains(t, listing.entries, "yaml")
+	assert.NotContains(t, listing.entries, "yam")
+}
+
 func TestParseRenamesStrategyModtime(t *testing.T) {
 	for _, test := range []struct {
 		in      string
@@ -2588,6 +2851,39 @@ func TestSyncUTFNorm(t *testing.T) {
 	r.CheckRemoteItems(t, file1)
 }
 
+// Check we don't duplicate files with differing UTF-8 representations
+// with --no-traverse
+func TestCopyUTFNormNoTraverse(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	if runtime.GOOS == "darwin" {
+		t.Skip("Can't test UTF normalization on OS X")
+	}
+
+	r := fstest.NewRun(t)
+	ci.NoTraverse = true
+
+	// Two strings with different unicode normalization, NFC and NFD
+	Encoding1 := "Test\u00ea\u00e9"
+	Encoding2 := "Teste\u0302e\u0301"
+
+	file1 := r.WriteFile(Encoding1, "This is a test", t1)
+	r.CheckLocalItems(t, file1)
+
+	file2 := r.WriteObject(ctx, Encoding2, "This is a old test", t2)
+	r.CheckRemoteItems(t, file2)
+
+	accounting.GlobalStats().ResetCounters()
+	err := CopyDir(ctx, r.Fremote, r.Flocal, false)
+	require.NoError(t, err)
+
+	// The existing file should have been updated keeping its name
+	assert.Equal(t, toyFileTransfers(r), accounting.GlobalStats().GetTransfers())
+	r.CheckLocalItems(t, file1)
+	file1.Path = file2.Path
+	r.CheckRemoteItems(t, file1)
+}
+
 // Test --immutable
 func TestSyncImmutable(t *testing.T) {
 	ctx := context.Background()
@@ -3104,3 +3400,96 @@ func testLoggerVsLsf(ctx context.Context, fdst, fsrc fs.Fs, logger *bytes.Buffer
 		require.NoError(t, err)
 	}
 }
+
+// Test that checksum sidecars are written and deleted with their files
+func TestSyncChecksumSidecar(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.ChecksumSidecar = "md5"
+	r := fstest.NewRun(t)
+	remoteNames := func() (names []string) {
+		var mu mutex.Mutex
+		require.NoError(t, operations.ListFn(ctx, r.Fremote, func(o fs.Object) {
+			mu.Lock()
+			names = append(names, o.Remote())
+			mu.Unlock()
+		}))
+		sort.Strings(names)
+		return names
+	}
+
+	file1 := r.WriteFile("file1", "hello", t1)
+	r.WriteFile("file2", "potato", t1)
+	// a sidecar in the source isn't copied
+	r.WriteFile("file2.md5", "rubbish", t1)
+	require.NoError(t, Sync(ctx, r.Fremote, r.Flocal, false))
+	assert.Equal(t, []string{"file1", "file1.md5", "file2", "file2.md5"}, remoteNames())
+	o, err := r.Fremote.NewObject(ctx, "file2.md5")
+	require.NoError(t, err)
+	data, err := operations.ReadFile(ctx, o)
+	require.NoError(t, err)
+	assert.Equal(t, "8ee2027983915ec78acc45027d874316  file2\n", string(data))
+
+	// Sync again doesn't delete the sidecars
+	require.NoError(t, Sync(ctx, r.Fremote, r.Flocal, false))
+	assert.Equal(t, []string{"file1", "file1.md5", "file2", "file2.md5"}, remoteNames())
+
+	// Deleting a file deletes its sidecar
+	require.NoError(t, os.Remove(filepath.Join(r.LocalName, file1.Path)))
+	require.NoError(t, Sync(ctx, r.Fremote, r.Flocal, false))
+	assert.Equal(t, []string{"file2", "file2.md5"}, remoteNames())
+}
+
+func TestSyncPlan(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	file1 := r.WriteFile("file1", "new file", t1)
+	file2 := r.WriteFile("sub/file2", "changed file", t2)
+	r.WriteObject(ctx, "sub/file2", "old file", t1)
+	file3 := r.WriteObject(ctx, "file3", "extra file", t1)
+	oldFile2 := fstest.NewItem("sub/file2", "old file", t1)
+	r.CheckRemoteItems(t, oldFile2, file3)
+
+	// Make the plan with a dry run
+	makePlan := func() string {
+		dryCtx, ci := fs.AddConfig(ctx)
+		ci.DryRun = true
+		plan := operations.NewPlan(r.Flocal, r.Fremote)
+		require.NoError(t, Sync(operations.WithPlan(dryCtx, plan), r.Fremote, r.Flocal, false))
+		planFile := filepath.Join(t.TempDir(), "plan.json")
+		require.NoError(t, plan.Save(planFile))
+		return planFile
+	}
+	planFile := makePlan()
+	r.CheckRemoteItems(t, oldFile2, file3)
+	plan, err := operations.LoadPlan(planFile)
+	require.NoError(t, err)
+	ops := map[string]string{}
+	for _, op := range plan.Operations {
+		if op.Op != operations.PlanMkdir && op.Op != operations.PlanDirModTime {
+			ops[op.Remote] = op.Op
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"file1":     operations.PlanCopy,
+		"sub/file2": operations.PlanCopy,
+		"file3":     operations.PlanDelete,
+	}, ops)
+
+	// A plan for somewhere else isn't applied
+	err = operations.ApplyPlan(ctx, r.Flocal, r.Fremote, plan)
+	assert.ErrorContains(t, err, "plan was made for source")
+
+	// Nothing is done if a file has changed since the plan was made
+	r.WriteObject(ctx, "file1", "appeared", t1)
+	err = operations.ApplyPlan(ctx, r.Fremote, r.Flocal, plan)
+	assert.ErrorContains(t, err, "nothing done")
+	r.CheckRemoteItems(t, fstest.NewItem("file1", "appeared", t1), oldFile2, file3)
+	require.NoError(t, operations.DeleteFile(ctx, fstest.NewObject(ctx, t, r.Fremote, "file1")))
+
+	// Applying the plan does the sync
+	plan, err = operations.LoadPlan(makePlan())
+	require.NoError(t, err)
+	require.NoError(t, operations.ApplyPlan(ctx, r.Fremote, r.Flocal, plan))
+	r.CheckRemoteItems(t, file1, file2)
+}
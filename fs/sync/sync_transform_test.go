@@ -13,6 +13,7 @@ import (
 
 	_ "github.com/rclone/rclone/backend/all"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
 	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/walk"
@@ -511,3 +512,58 @@ func TestError(t *testing.T) {
 	r.CheckLocalListing(t, []fstest.Item{file1}, []string{"toe", "toe/toe"})
 	r.CheckRemoteListing(t, []fstest.Item{file1}, []string{"toe", "toe/toe"})
 }
+
+func TestEncodingTable(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	ci.NameTransform = nil
+	accounting.GlobalStats().ResetCounters()
+	r := fstest.NewRun(t)
+	file1 := r.WriteFile("café/😀 party & fun.txt", "hello world", t1)
+
+	table := `{"&": "and", "{emoji}": "_", "{diacritics}": ""}`
+	fdst, err := fs.NewFs(ctx, ":local,encoding_table='"+table+"':"+t.TempDir())
+	require.NoError(t, err)
+	assert.NotNil(t, fs.EncodingTable(fdst))
+	want := fstest.NewItem("cafe/_ party and fun.txt", "hello world", t1)
+
+	err = Sync(ctx, fdst, r.Flocal, true)
+	require.NoError(t, err)
+	r.CheckLocalItems(t, file1)
+	fstest.CheckListingWithPrecision(t, fdst, []fstest.Item{want}, []string{"cafe"}, fs.GetModifyWindow(ctx, fdst))
+
+	// result should not change second time, since src is unchanged
+	err = Sync(ctx, fdst, r.Flocal, true)
+	require.NoError(t, err)
+	fstest.CheckListingWithPrecision(t, fdst, []fstest.Item{want}, []string{"cafe"}, fs.GetModifyWindow(ctx, fdst))
+
+	// a bad table is an error when making the Fs
+	_, err = fs.NewFs(ctx, ":local,encoding_table='{\"a\": \"b/c\"}':"+t.TempDir())
+	assert.ErrorContains(t, err, "can't contain /")
+}
+
+func TestTruncateHashOriginalName(t *testing.T) {
+	ctx := context.Background()
+	ctx, ci := fs.AddConfig(ctx)
+	accounting.GlobalStats().ResetCounters()
+	r := fstest.NewRun(t)
+	if !r.Fremote.Features().UserMetadata {
+		t.Skip("need user metadata support")
+	}
+	ci.Metadata = true
+	err := transform.SetOptions(ctx, "truncate_hash_keep_extension=20")
+	require.NoError(t, err)
+	file1 := r.WriteFile("a very long file name.txt", "hello world", t1)
+
+	err = Sync(ctx, r.Fremote, r.Flocal, true)
+	require.NoError(t, err)
+	r.CheckLocalItems(t, file1)
+	want := fstest.NewItem("a very ~9e1e3e75.txt", "hello world", t1)
+	r.CheckRemoteItems(t, want)
+
+	o, err := r.Fremote.NewObject(ctx, want.Path)
+	require.NoError(t, err)
+	metadata, err := fs.GetMetadata(ctx, o)
+	require.NoError(t, err)
+	assert.Equal(t, "a very long file name.txt", metadata[transform.MetadataOriginalName])
+}
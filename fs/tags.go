@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tags are key=value labels attached to an object which are stored
+// separately from its metadata, for example S3 object tags or Azure
+// blob index tags.
+//
+// Unlike metadata, tags can usually be changed without rewriting the
+// object and are often used by lifecycle rules and access policies.
+type Tags map[string]string
+
+// Tagger is an optional interface for Object
+type Tagger interface {
+	// Tags returns the tags of the Object
+	//
+	// It should return nil if there are no tags
+	Tags(ctx context.Context) (Tags, error)
+}
+
+// SetTagger is an optional interface for Object
+type SetTagger interface {
+	// SetTags replaces all the tags on the Object with tags
+	//
+	// It should return fs.ErrorNotImplemented if it can't set tags
+	SetTags(ctx context.Context, tags Tags) error
+}
+
+// GetTags from an DirEntry
+//
+// If the object has no tags then tags will be nil
+func GetTags(ctx context.Context, o DirEntry) (tags Tags, err error) {
+	do, ok := o.(Tagger)
+	if !ok {
+		return nil, nil
+	}
+	return do.Tags(ctx)
+}
+
+// Equal returns true if tags and other contain the same keys and values
+func (tags Tags) Equal(other Tags) bool {
+	if len(tags) != len(other) {
+		return false
+	}
+	for k, v := range tags {
+		if ov, ok := other[k]; !ok || ov != v {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the tags as a sorted, comma separated list of
+// key=value pairs
+func (tags Tags) String() string {
+	out := make([]string, 0, len(tags))
+	for k, v := range tags {
+		out = append(out, k+"="+v)
+	}
+	sort.Strings(out)
+	return strings.Join(out, ",")
+}
+
+// ParseTag parses a single "key=value" tag.
+//
+// If there is no "=" then the value is returned as "" and hasValue is
+// false.
+func ParseTag(s string) (key, value string, hasValue bool, err error) {
+	key, value, hasValue = strings.Cut(s, "=")
+	if key == "" {
+		return "", "", false, fmt.Errorf("empty key in tag %q", s)
+	}
+	return key, value, hasValue, nil
+}
@@ -0,0 +1,44 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagsEqual(t *testing.T) {
+	a := Tags{"a": "1", "b": "2"}
+	assert.True(t, a.Equal(Tags{"b": "2", "a": "1"}))
+	assert.False(t, a.Equal(Tags{"a": "1"}))
+	assert.False(t, a.Equal(Tags{"a": "1", "b": "3"}))
+	assert.False(t, a.Equal(Tags{"a": "1", "c": "2"}))
+	assert.True(t, Tags(nil).Equal(Tags{}))
+}
+
+func TestTagsString(t *testing.T) {
+	assert.Equal(t, "", Tags(nil).String())
+	assert.Equal(t, "a=1,b=2", Tags{"b": "2", "a": "1"}.String())
+}
+
+func TestParseTag(t *testing.T) {
+	for _, test := range []struct {
+		in       string
+		key      string
+		value    string
+		hasValue bool
+		wantErr  bool
+	}{
+		{"key=value", "key", "value", true, false},
+		{"key=", "key", "", true, false},
+		{"key", "key", "", false, false},
+		{"key=a=b", "key", "a=b", true, false},
+		{"=value", "", "", false, true},
+		{"", "", "", false, true},
+	} {
+		key, value, hasValue, err := ParseTag(test.in)
+		assert.Equal(t, test.wantErr, err != nil, test.in)
+		assert.Equal(t, test.key, key, test.in)
+		assert.Equal(t, test.value, value, test.in)
+		assert.Equal(t, test.hasValue, hasValue, test.in)
+	}
+}
@@ -0,0 +1,121 @@
+// Trace IDs to follow the life of an object through a sync in the logs
+
+package fs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceSpan describes the life of one object through a sync from
+// when it was listed until its transfer finished.
+type TraceSpan struct {
+	TraceID string    // W3C trace ID as 32 hex digits
+	SpanID  string    // W3C span ID as 16 hex digits
+	Remote  string    // path of the object
+	Start   time.Time // when it was listed
+	End     time.Time // when it finished
+	Err     error     // error if it failed
+}
+
+// TraceExport is called with each span as it finishes if set
+var TraceExport func(span *TraceSpan)
+
+var (
+	tracesEnabled atomic.Bool
+	tracesMu      sync.Mutex
+	traces        = map[string]*TraceSpan{}
+)
+
+// EnableTraces turns the recording of trace IDs on or off
+func EnableTraces(enable bool) {
+	tracesEnabled.Store(enable)
+}
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// StartTrace starts a trace for the object at remote if traces are
+// enabled.
+//
+// If there is a trace for remote already, e.g. because the sync is
+// being retried, then it carries on with that one.
+func StartTrace(remote string) {
+	if !tracesEnabled.Load() {
+		return
+	}
+	tracesMu.Lock()
+	defer tracesMu.Unlock()
+	if _, found := traces[remote]; found {
+		return
+	}
+	traces[remote] = &TraceSpan{
+		TraceID: randomHex(16),
+		SpanID:  randomHex(8),
+		Remote:  remote,
+		Start:   time.Now(),
+	}
+}
+
+// EndTrace ends the trace for the object at remote with err.
+//
+// Traces which end in an error are kept so retries carry on with the
+// same trace ID.
+func EndTrace(remote string, err error) {
+	if !tracesEnabled.Load() {
+		return
+	}
+	tracesMu.Lock()
+	span, found := traces[remote]
+	if found && err == nil {
+		delete(traces, remote)
+	}
+	tracesMu.Unlock()
+	if !found {
+		return
+	}
+	if TraceExport != nil {
+		ended := *span
+		ended.End = time.Now()
+		ended.Err = err
+		TraceExport(&ended)
+	}
+	if err != nil {
+		// Start a new span within the same trace for the retry
+		tracesMu.Lock()
+		span.SpanID = randomHex(8)
+		span.Start = time.Now()
+		tracesMu.Unlock()
+	}
+}
+
+// TraceID returns the trace ID for o or "" if it isn't being traced.
+//
+// o may be a DirEntry, an ObjectInfo or the remote as a string.
+func TraceID(o any) string {
+	if !tracesEnabled.Load() {
+		return ""
+	}
+	var remote string
+	switch x := o.(type) {
+	case string:
+		remote = x
+	case interface{ Remote() string }:
+		remote = x.Remote()
+	default:
+		return ""
+	}
+	tracesMu.Lock()
+	defer tracesMu.Unlock()
+	if span, found := traces[remote]; found {
+		return span.TraceID
+	}
+	return ""
+}
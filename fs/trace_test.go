@@ -0,0 +1,67 @@
+package fs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRemote string
+
+func (r testRemote) Remote() string { return string(r) }
+
+func TestTrace(t *testing.T) {
+	var exported []TraceSpan
+	oldExport := TraceExport
+	TraceExport = func(span *TraceSpan) {
+		exported = append(exported, *span)
+	}
+	defer func() {
+		TraceExport = oldExport
+		EnableTraces(false)
+	}()
+
+	// Nothing happens when disabled
+	StartTrace("file")
+	assert.Equal(t, "", TraceID("file"))
+	EnableTraces(true)
+	assert.Equal(t, "", TraceID("file"))
+	EndTrace("file", nil)
+	assert.Len(t, exported, 0)
+
+	StartTrace("file")
+	traceID := TraceID("file")
+	assert.Len(t, traceID, 32)
+	assert.Equal(t, traceID, TraceID(testRemote("file")))
+	assert.Equal(t, "", TraceID("other"))
+	assert.Equal(t, "", TraceID(42))
+
+	// Starting again carries on with the same trace
+	StartTrace("file")
+	assert.Equal(t, traceID, TraceID("file"))
+
+	// An error keeps the trace for the retry with a new span
+	EndTrace("file", errors.New("boom"))
+	require.Len(t, exported, 1)
+	assert.Equal(t, traceID, exported[0].TraceID)
+	assert.Len(t, exported[0].SpanID, 16)
+	assert.Equal(t, "file", exported[0].Remote)
+	assert.EqualError(t, exported[0].Err, "boom")
+	assert.False(t, exported[0].End.Before(exported[0].Start))
+	assert.Equal(t, traceID, TraceID("file"))
+
+	// Success ends the trace
+	EndTrace("file", nil)
+	require.Len(t, exported, 2)
+	assert.Equal(t, traceID, exported[1].TraceID)
+	assert.NotEqual(t, exported[0].SpanID, exported[1].SpanID)
+	assert.NoError(t, exported[1].Err)
+	assert.Equal(t, "", TraceID("file"))
+
+	// A new trace gets a new ID
+	StartTrace("file")
+	assert.NotEqual(t, traceID, TraceID("file"))
+	EndTrace("file", nil)
+}
@@ -0,0 +1,23 @@
+package fs
+
+import (
+	"context"
+	"strings"
+)
+
+// TrashDir is the directory in the root of a remote which --use-trash
+// moves deleted files into when the backend doesn't have a trash of
+// its own.
+const TrashDir = ".rclone-trash"
+
+// InTrash returns true if --use-trash is in effect and remote, which
+// is relative to the root of a remote, is TrashDir or is inside it.
+//
+// These are left out of listings so the trash isn't synced, deleted
+// or moved into itself.
+func InTrash(ctx context.Context, remote string) bool {
+	if !GetConfig(ctx).UseTrash {
+		return false
+	}
+	return remote == TrashDir || strings.HasPrefix(remote, TrashDir+"/")
+}
@@ -182,6 +182,16 @@ type ObjectUnWrapper interface {
 	UnWrap() Object
 }
 
+// Thumbnailer is an optional interface for Object
+type Thumbnailer interface {
+	// Thumbnail returns a preview image of the Object no bigger
+	// than size pixels in either dimension and its MIME type.
+	//
+	// It should return ErrorNotImplemented if the Object has no
+	// thumbnail.
+	Thumbnail(ctx context.Context, size int) (in io.ReadCloser, mimeType string, err error)
+}
+
 // SetTierer is an optional interface for Object
 type SetTierer interface {
 	// SetTier performs changing storage tier of the Object if
@@ -221,6 +231,14 @@ type SetModTimer interface {
 	SetModTime(ctx context.Context, t time.Time) error
 }
 
+// DirChangeTokener is an optional interface for Directory
+type DirChangeTokener interface {
+	// ChangeToken returns an opaque string which changes whenever
+	// anything in the directory or beneath it changes, or "" if
+	// not known.
+	ChangeToken() string
+}
+
 // FullObjectInfo contains all the read-only optional interfaces
 //
 // Use for checking making wrapping ObjectInfos implement everything
@@ -279,6 +297,12 @@ func ObjectOptionalInterfaces(o Object) (supported, unsupported []string) {
 	_, ok = o.(SetMetadataer)
 	store(ok, "SetMetadata")
 
+	_, ok = o.(Tagger)
+	store(ok, "Tags")
+
+	_, ok = o.(SetTagger)
+	store(ok, "SetTags")
+
 	return supported, unsupported
 }
 
@@ -302,9 +326,22 @@ func DirectoryOptionalInterfaces(d Directory) (supported, unsupported []string)
 	_, ok = d.(SetModTimer)
 	store(ok, "SetModTime")
 
+	_, ok = d.(DirChangeTokener)
+	store(ok, "ChangeToken")
+
 	return supported, unsupported
 }
 
+// DeletedObject describes a deleted file which can be restored with
+// the Undelete feature
+type DeletedObject struct {
+	Remote    string    // path of the file relative to the root of the Fs
+	Size      int64     // size of the file or -1 if not known
+	ModTime   time.Time // modification time of the file
+	DeletedAt time.Time // when the file was deleted or zero if not known
+	ID        string    // backend specific identifier of the deleted file
+}
+
 // ListRCallback defines a callback function for ListR to use
 //
 // It is called for each tranche of entries read from the listing and
@@ -358,6 +395,8 @@ type Usage struct {
 	Other   *int64 `json:"other,omitempty"`   // other usage e.g. gmail in drive
 	Free    *int64 `json:"free,omitempty"`    // bytes which can be uploaded before reaching the quota
 	Objects *int64 `json:"objects,omitempty"` // objects in the storage system
+
+	Upstreams map[string]*Usage `json:"upstreams,omitempty"` // usage of each upstream of a remote combining others
 }
 
 // WriterAtCloser wraps io.WriterAt and io.Closer
@@ -63,6 +63,17 @@ type Func func(path string, entries fs.DirEntries, err error) error
 //
 // NB (f, path) to be replaced by fs.Dir at some point
 func Walk(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLevel int, fn Func) error {
+	return walkAll(ctx, f, path, includeAll, maxLevel, fn, true)
+}
+
+// walkAll implements Walk.
+//
+// If ordered is not set then children may be passed to fn before
+// their parents. This means that subdirectories are listed
+// concurrently with fn processing their parent which keeps --checkers
+// listings in flight even if fn is slow. fn should not return
+// ErrorSkipDir in this case.
+func walkAll(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLevel int, fn Func, ordered bool) error {
 	ci := fs.GetConfig(ctx)
 	fi := filter.GetConfig(ctx)
 	ctx = filter.SetUseFilter(ctx, f.Features().FilterAware && !includeAll) // make filter-aware backends constrain List
@@ -70,10 +81,10 @@ func Walk(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLevel i
 		return walkR(ctx, f, path, includeAll, maxLevel, fn, fi.MakeListR(ctx, f.NewObject))
 	}
 	// FIXME should this just be maxLevel < 0 - why the maxLevel > 1
-	if (maxLevel < 0 || maxLevel > 1) && ci.UseListR && f.Features().ListR != nil {
+	if (maxLevel < 0 || maxLevel > 1) && ci.UseListR && f.Features().ListR != nil && !list.UsingCache(ctx) {
 		return walkListR(ctx, f, path, includeAll, maxLevel, fn)
 	}
-	return walkListDirSorted(ctx, f, path, includeAll, maxLevel, fn)
+	return walkParallel(ctx, f, path, includeAll, maxLevel, fn, list.DirSorted, ordered)
 }
 
 // ListType is uses to choose which combination of files or directories is requires
@@ -156,7 +167,10 @@ func ListR(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLevel
 		fi.HaveFilesFrom() || // ...using --files-from
 		maxLevel >= 0 || // ...using bounded recursion
 		len(fi.Opt.ExcludeFile) > 0 || // ...using --exclude-file
-		fi.UsesDirectoryFilters() { // ...using any directory filters
+		fi.Opt.UseIgnoreFiles || // ...using --use-rcloneignore
+		len(fi.Opt.MarkerFiles) > 0 || // ...using --marker-file
+		fi.UsesDirectoryFilters() || // ...using any directory filters
+		list.UsingCache(ctx) { // ...sharing listings with --rc-use-server
 		return listRwalk(ctx, f, path, includeAll, maxLevel, listType, fn)
 	}
 	ctx = filter.SetUseFilter(ctx, f.Features().FilterAware && !includeAll) // make filter-aware backends constrain List
@@ -165,9 +179,12 @@ func ListR(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLevel
 
 // listRwalk walks the file tree for ListR using Walk
 // Note: this will flag filter-aware backends (via Walk)
+//
+// As ListR doesn't guarantee any ordering the directory listings are
+// not held up waiting for fn to process their parents.
 func listRwalk(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLevel int, listType ListType, fn fs.ListRCallback) error {
 	var listErr error
-	walkErr := Walk(ctx, f, path, includeAll, maxLevel, func(path string, entries fs.DirEntries, err error) error {
+	walkErr := walkAll(ctx, f, path, includeAll, maxLevel, func(path string, entries fs.DirEntries, err error) error {
 		// Carry on listing but return the error at the end
 		if err != nil {
 			listErr = err
@@ -177,7 +194,7 @@ func listRwalk(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLe
 		}
 		listType.Filter(&entries)
 		return fn(entries)
-	})
+	}, false)
 	if listErr != nil {
 		return listErr
 	}
@@ -305,20 +322,22 @@ func listR(ctx context.Context, f fs.Fs, path string, includeAll bool, listType
 			}
 		}
 		listType.Filter(&entries)
-		if !includeAll {
+		if !includeAll || fs.GetConfig(ctx).UseTrash {
 			filteredEntries := entries[:0]
 			for _, entry := range entries {
-				var include bool
-				switch x := entry.(type) {
-				case fs.Object:
-					include = fi.IncludeObject(ctx, x)
-				case fs.Directory:
-					include, err = includeDirectory(x.Remote())
-					if err != nil {
-						return err
+				include := !fs.InTrash(ctx, entry.Remote())
+				if include && !includeAll {
+					switch x := entry.(type) {
+					case fs.Object:
+						include = fi.IncludeObject(ctx, x)
+					case fs.Directory:
+						include, err = includeDirectory(x.Remote())
+						if err != nil {
+							return err
+						}
+					default:
+						return fmt.Errorf("unknown object type %T", entry)
 					}
-				default:
-					return fmt.Errorf("unknown object type %T", entry)
 				}
 				if include {
 					filteredEntries = append(filteredEntries, entry)
@@ -342,13 +361,6 @@ func listR(ctx context.Context, f fs.Fs, path string, includeAll bool, listType
 	return nil
 }
 
-// walkListDirSorted lists the directory.
-//
-// It implements Walk using non recursive directory listing.
-func walkListDirSorted(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLevel int, fn Func) error {
-	return walk(ctx, f, path, includeAll, maxLevel, fn, list.DirSorted)
-}
-
 // walkListR lists the directory.
 //
 // It implements Walk using recursive directory listing if
@@ -363,7 +375,20 @@ func walkListR(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLe
 
 type listDirFunc func(ctx context.Context, fs fs.Fs, includeAll bool, dir string) (entries fs.DirEntries, err error)
 
+// walk lists the directories in parallel calling fn for parents
+// before their children
 func walk(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLevel int, fn Func, listDir listDirFunc) error {
+	return walkParallel(ctx, f, path, includeAll, maxLevel, fn, listDir, true)
+}
+
+// walkParallel lists the directories with --checkers listings in
+// flight.
+//
+// If ordered is set then subdirectories aren't listed until fn has
+// been called for their parent, so fn is always called for parents
+// before children and can return ErrorSkipDir. If it isn't set then
+// subdirectories are queued for listing before fn is called.
+func walkParallel(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLevel int, fn Func, listDir listDirFunc, ordered bool) error {
 	var (
 		wg         sync.WaitGroup      // sync closing of go routines
 		traversing sync.WaitGroup      // running directory traversals
@@ -411,6 +436,21 @@ func walk(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLevel i
 							})
 						})
 					}
+					queue := func() {
+						if len(jobs) > 0 {
+							traversing.Add(len(jobs))
+							go func() {
+								// Now we have traversed this directory, send these
+								// jobs off for traversal in the background
+								for _, newJob := range jobs {
+									in <- newJob
+								}
+							}()
+						}
+					}
+					if !ordered {
+						queue()
+					}
 					mu.Lock()
 					err = fn(job.remote, entries, err)
 					mu.Unlock()
@@ -427,15 +467,8 @@ func walk(ctx context.Context, f fs.Fs, path string, includeAll bool, maxLevel i
 						}
 						continue
 					}
-					if err == nil && len(jobs) > 0 {
-						traversing.Add(len(jobs))
-						go func() {
-							// Now we have traversed this directory, send these
-							// jobs off for traversal in the background
-							for _, newJob := range jobs {
-								in <- newJob
-							}
-						}()
+					if ordered && err == nil {
+						queue()
 					}
 					traversing.Done()
 				case <-quit:
@@ -471,6 +504,9 @@ func walkRDirTree(ctx context.Context, f fs.Fs, startPath string, includeAll boo
 		mu.Lock()
 		defer mu.Unlock()
 		for _, entry := range entries {
+			if fs.InTrash(ctx, entry.Remote()) {
+				continue
+			}
 			slashes := strings.Count(entry.Remote(), "/")
 			excluded := true
 			switch x := entry.(type) {
@@ -588,7 +624,7 @@ func NewDirTree(ctx context.Context, f fs.Fs, path string, includeAll bool, maxL
 		return walkRDirTree(ctx, f, path, includeAll, maxLevel, fi.MakeListR(ctx, f.NewObject))
 	}
 	// if have ListR; and recursing; and not using --files-from; then build a DirTree with ListR
-	if ListR := f.Features().ListR; (maxLevel < 0 || maxLevel > 1) && ListR != nil && !fi.HaveFilesFrom() {
+	if ListR := f.Features().ListR; (maxLevel < 0 || maxLevel > 1) && ListR != nil && !fi.HaveFilesFrom() && !list.UsingCache(ctx) {
 		return walkRDirTree(ctx, f, path, includeAll, maxLevel, ListR)
 	}
 	// otherwise just use List
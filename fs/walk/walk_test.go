@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/rclone/rclone/fs"
 	_ "github.com/rclone/rclone/fs/accounting"
@@ -167,6 +168,13 @@ func (ls *listDirs) Walk() {
 	ls.IsFinished()
 }
 
+// WalkUnordered does the unordered walk and tests the expectations
+func (ls *listDirs) WalkUnordered() {
+	err := walkParallel(context.Background(), nil, "", ls.includeAll, ls.maxLevel, ls.WalkFn, ls.ListDir, false)
+	assert.True(ls.t, errors.Is(ls.finalError, err))
+	ls.IsFinished()
+}
+
 // WalkR does the walkR and tests the expectations
 func (ls *listDirs) WalkR() {
 	err := walkR(context.Background(), nil, "", ls.includeAll, ls.maxLevel, ls.WalkFn, ls.ListR)
@@ -428,8 +436,42 @@ func testWalkMultiErrors(t *testing.T) *listDirs {
 		errorBoom,
 	).NoCheckMaps()
 }
-func TestWalkMultiErrors(t *testing.T)  { testWalkMultiErrors(t).Walk() }
-func TestWalkRMultiErrors(t *testing.T) { testWalkMultiErrors(t).Walk() }
+func TestWalkMultiErrors(t *testing.T)     { testWalkMultiErrors(t).Walk() }
+func TestWalkRMultiErrors(t *testing.T)    { testWalkMultiErrors(t).Walk() }
+func TestWalkUnorderedMulti(t *testing.T)  { testWalkMulti(t).WalkUnordered() }
+func TestWalkUnorderedLevels(t *testing.T) { testWalkLevels(t, -1).WalkUnordered() }
+
+// Check that an unordered walk lists subdirectories while fn is
+// still processing their parent
+func TestWalkUnorderedListsWhileProcessing(t *testing.T) {
+	da := mockdir.New("a")
+	listedA := make(chan struct{})
+	listDir := func(ctx context.Context, f fs.Fs, includeAll bool, dir string) (fs.DirEntries, error) {
+		switch dir {
+		case "":
+			return fs.DirEntries{da}, nil
+		case "a":
+			close(listedA)
+			return fs.DirEntries{}, nil
+		}
+		return nil, fmt.Errorf("unexpected list of %q", dir)
+	}
+	var walked []string
+	fn := func(dir string, entries fs.DirEntries, err error) error {
+		if dir == "" {
+			select {
+			case <-listedA:
+			case <-time.After(10 * time.Second):
+				return errors.New("timed out waiting for subdirectory listing")
+			}
+		}
+		walked = append(walked, dir)
+		return err
+	}
+	err := walkParallel(context.Background(), nil, "", true, -1, fn, listDir, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "a"}, walked)
+}
 
 // a very simple listRcallback function
 func makeListRCallback(entries fs.DirEntries, err error) fs.ListRFn {
@@ -15,6 +15,8 @@ import (
 
 var (
 	fns          = make(map[FnHandle]bool)
+	gracefulFns  = make(map[*func()]bool)
+	gracefulRun  bool // set if the gracefulFns have been run
 	fnsMutex     sync.Mutex
 	exitChan     chan os.Signal
 	exitOnce     sync.Once
@@ -36,26 +38,70 @@ func Register(fn func()) FnHandle {
 	fnsMutex.Lock()
 	fns[&fn] = true
 	fnsMutex.Unlock()
+	startSignalHandler()
+	return &fn
+}
 
-	// Run AtExit handlers on exitSignals so everything gets tidied up properly
+// startSignalHandler runs the AtExit handlers on exitSignals so
+// everything gets tidied up properly
+func startSignalHandler() {
 	registerOnce.Do(func() {
 		exitChan = make(chan os.Signal, 1)
 		signal.Notify(exitChan, exitSignals...)
 		go func() {
-			sig := <-exitChan
-			if sig == nil {
-				return
+			for {
+				sig := <-exitChan
+				if sig == nil {
+					return
+				}
+				if runGraceful() {
+					fs.Logf(nil, "Signal received: %s - stopping gracefully, send it again to exit now", sig)
+					continue
+				}
+				signal.Stop(exitChan)
+				signalled.Store(1)
+				fs.Infof(nil, "Signal received: %s", sig)
+				Run()
+				fs.Infof(nil, "Exiting...")
+				os.Exit(exitCode(sig))
 			}
-			signal.Stop(exitChan)
-			signalled.Store(1)
-			fs.Infof(nil, "Signal received: %s", sig)
-			Run()
-			fs.Infof(nil, "Exiting...")
-			os.Exit(exitCode(sig))
 		}()
 	})
+}
 
-	return &fn
+// Graceful registers fn to be called on the first exit signal instead
+// of exiting so the program can stop cleanly. Another signal exits as
+// normal.
+//
+// It returns a function to unregister fn.
+func Graceful(fn func()) func() {
+	if running() {
+		return func() {}
+	}
+	fnsMutex.Lock()
+	gracefulFns[&fn] = true
+	fnsMutex.Unlock()
+	startSignalHandler()
+	return func() {
+		fnsMutex.Lock()
+		delete(gracefulFns, &fn)
+		fnsMutex.Unlock()
+	}
+}
+
+// runGraceful runs the Graceful functions the first time it is called
+// returning true if there were any
+func runGraceful() bool {
+	fnsMutex.Lock()
+	defer fnsMutex.Unlock()
+	if gracefulRun || len(gracefulFns) == 0 {
+		return false
+	}
+	gracefulRun = true
+	for fn := range gracefulFns {
+		go (*fn)()
+	}
+	return true
 }
 
 // Signalled returns true if an exit signal has been received
@@ -39,3 +39,21 @@ func TestExitCode(t *testing.T) {
 	// Never a real signal
 	assert.Equal(t, exitCode(&fakeSignal{}), exitcode.UncategorizedError)
 }
+
+func TestGraceful(t *testing.T) {
+	assert.False(t, runGraceful())
+
+	called := make(chan struct{})
+	unregister := Graceful(func() { close(called) })
+	assert.True(t, runGraceful())
+	<-called
+
+	// Only runs once
+	assert.False(t, runGraceful())
+
+	unregister()
+	fnsMutex.Lock()
+	assert.Equal(t, 0, len(gracefulFns))
+	gracefulRun = false
+	fnsMutex.Unlock()
+}
@@ -3,6 +3,7 @@
 package cache
 
 import (
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +17,7 @@ type Cache struct {
 	expireRunning  bool
 	expireDuration time.Duration // expire the cache entry when it is older than this
 	expireInterval time.Duration // interval to run the cache expire
+	maxEntries     int           // if > 0 the maximum number of entries to keep
 	finalize       func(value any)
 }
 
@@ -54,6 +56,41 @@ func (c *Cache) SetExpireInterval(d time.Duration) *Cache {
 	return c
 }
 
+// SetMaxEntries sets the maximum number of entries in the cache
+//
+// When there are more than this many entries the least recently used
+// unpinned entries are removed. Set to 0 or a -ve number for no limit.
+func (c *Cache) SetMaxEntries(n int) *Cache {
+	c.mu.Lock()
+	c.maxEntries = n
+	c.evictOverflow()
+	c.mu.Unlock()
+	return c
+}
+
+// evictOverflow removes the least recently used unpinned entries
+// until there are no more than maxEntries
+//
+// should be called with the lock held
+func (c *Cache) evictOverflow() {
+	if c.maxEntries <= 0 {
+		return
+	}
+	for len(c.cache) > c.maxEntries {
+		var oldest *cacheEntry
+		for _, entry := range c.cache {
+			if entry.pinCount <= 0 && (oldest == nil || entry.lastUsed.Before(oldest.lastUsed)) {
+				oldest = entry
+			}
+		}
+		if oldest == nil {
+			return // everything is pinned
+		}
+		c.finalize(oldest.value)
+		delete(c.cache, oldest.key)
+	}
+}
+
 // cacheEntry is stored in the cache
 type cacheEntry struct {
 	value    any       // cached item
@@ -101,6 +138,10 @@ func (c *Cache) Get(key string, create CreateFunc) (value any, err error) {
 	}
 	defer c.mu.Unlock()
 	c.used(entry)
+	// Mark the entry as pinned while evicting so it isn't removed
+	entry.pinCount++
+	c.evictOverflow()
+	entry.pinCount--
 	return entry.value, entry.err
 }
 
@@ -138,6 +179,9 @@ func (c *Cache) PutErr(key string, value any, err error) {
 	}
 	c.used(entry)
 	c.cache[key] = entry
+	entry.pinCount++
+	c.evictOverflow()
+	entry.pinCount--
 }
 
 // Put puts a value named key into the cache
@@ -171,6 +215,21 @@ func (c *Cache) Delete(key string) bool {
 	return found
 }
 
+// DeleteUnpinned deletes the entry passed in if it isn't pinned
+//
+// Returns whether the entry was found and whether it was deleted
+func (c *Cache) DeleteUnpinned(key string) (found, deleted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.cache[key]
+	if !found || entry.pinCount > 0 {
+		return found, false
+	}
+	c.finalize(entry.value)
+	delete(c.cache, key)
+	return true, true
+}
+
 // DeletePrefix deletes all entries with the given prefix
 //
 // Returns number of entries deleted
@@ -207,6 +266,7 @@ func (c *Cache) Rename(oldKey, newKey string) (value any, found bool) {
 		c.used(newEntry)
 	} else if oldEntry, oldFound := c.cache[oldKey]; oldFound {
 		// If old entry is found rename it to new and use that
+		oldEntry.key = newKey
 		c.cache[newKey] = oldEntry
 		// No need to shutdown here, as value lives on under newKey
 		delete(c.cache, oldKey)
@@ -276,3 +336,31 @@ func (c *Cache) EntriesWithPinCount() (pinned, unpinned int) {
 	c.mu.Unlock()
 	return pinned, unpinned
 }
+
+// EntryInfo describes an entry in the cache
+type EntryInfo struct {
+	Key      string    // key of the entry
+	Value    any       // cached item
+	Err      error     // creation error
+	LastUsed time.Time // when the entry was last used
+	PinCount int       // number of pins on the entry
+}
+
+// List returns information about the entries in the cache sorted by key
+func (c *Cache) List() (entries []EntryInfo) {
+	c.mu.Lock()
+	for _, entry := range c.cache {
+		entries = append(entries, EntryInfo{
+			Key:      entry.key,
+			Value:    entry.value,
+			Err:      entry.err,
+			LastUsed: entry.lastUsed,
+			PinCount: entry.pinCount,
+		})
+	}
+	c.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
@@ -383,3 +383,91 @@ func TestCacheFinalize(t *testing.T) {
 	c.cacheExpire() // "ok" and "new" fall out of cache
 	assert.Equal(t, 6, numCalled)
 }
+
+func TestCacheMaxEntries(t *testing.T) {
+	c := New()
+	finalized := []string{}
+	c.SetFinalizer(func(value any) {
+		finalized = append(finalized, value.(string))
+	})
+	c.SetMaxEntries(2)
+	create := func(key string) (any, bool, error) {
+		return key, true, nil
+	}
+
+	_, err := c.Get("a", create)
+	require.NoError(t, err)
+	_, err = c.Get("b", create)
+	require.NoError(t, err)
+	c.Pin("a")
+	assert.Equal(t, 2, c.Entries())
+
+	// Adding c should evict b as a is pinned
+	_, err = c.Get("c", create)
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.Entries())
+	assert.Equal(t, []string{"b"}, finalized)
+
+	// Adding d should evict c as a is pinned and d is new
+	c.Put("d", "d")
+	assert.Equal(t, []string{"b", "c"}, finalized)
+
+	// With everything pinned the cache can grow
+	c.Pin("d")
+	_, err = c.Get("e", create)
+	require.NoError(t, err)
+	assert.Equal(t, 3, c.Entries())
+	assert.Equal(t, []string{"b", "c"}, finalized)
+
+	// Unpinning lets the next use shrink it again
+	c.Unpin("d")
+	_, err = c.Get("a", create)
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.Entries())
+	assert.Equal(t, []string{"b", "c", "e"}, finalized)
+}
+
+func TestCacheDeleteUnpinned(t *testing.T) {
+	c, create := setup(t)
+
+	found, deleted := c.DeleteUnpinned("/")
+	assert.False(t, found)
+	assert.False(t, deleted)
+
+	_, err := c.Get("/", create)
+	require.NoError(t, err)
+	c.Pin("/")
+
+	found, deleted = c.DeleteUnpinned("/")
+	assert.True(t, found)
+	assert.False(t, deleted)
+	assert.Equal(t, 1, c.Entries())
+
+	c.Unpin("/")
+	found, deleted = c.DeleteUnpinned("/")
+	assert.True(t, found)
+	assert.True(t, deleted)
+	assert.Equal(t, 0, c.Entries())
+}
+
+func TestCacheList(t *testing.T) {
+	c, create := setup(t)
+
+	assert.Equal(t, []EntryInfo(nil), c.List())
+
+	_, err := c.Get("/", create)
+	require.NoError(t, err)
+	c.Pin("/")
+	c.Put("/other", "/other")
+	_, found := c.Rename("/other", "/renamed")
+	require.True(t, found)
+
+	entries := c.List()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "/", entries[0].Key)
+	assert.Equal(t, "/", entries[0].Value)
+	assert.Equal(t, 1, entries[0].PinCount)
+	assert.False(t, entries[0].LastUsed.IsZero())
+	assert.Equal(t, "/renamed", entries[1].Key)
+	assert.Equal(t, 0, entries[1].PinCount)
+}
@@ -0,0 +1,99 @@
+// Package crc64nvme implements the CRC-64/NVME checksum used by S3
+// for full object checksums.
+package crc64nvme
+
+import (
+	"hash"
+	"hash/crc64"
+)
+
+// Size of a CRC-64/NVME checksum in bytes
+const Size = 8
+
+// poly is the reversed CRC-64/NVME polynomial 0xad93d23594c93659
+const poly = 0x9a6c9329ac4bc9b5
+
+// Table is the crc64 table for CRC-64/NVME
+var Table = crc64.MakeTable(poly)
+
+// New returns a new hash.Hash64 computing the CRC-64/NVME checksum
+func New() hash.Hash64 {
+	return crc64.New(Table)
+}
+
+// Checksum returns the CRC-64/NVME checksum of data
+func Checksum(data []byte) uint64 {
+	return crc64.Checksum(data, Table)
+}
+
+// gf2MatrixTimes multiplies the vector vec by the GF(2) matrix mat
+func gf2MatrixTimes(mat *[64]uint64, vec uint64) (sum uint64) {
+	for i := 0; vec != 0; i++ {
+		if vec&1 != 0 {
+			sum ^= mat[i]
+		}
+		vec >>= 1
+	}
+	return sum
+}
+
+// gf2MatrixSquare sets square to mat * mat
+func gf2MatrixSquare(square, mat *[64]uint64) {
+	for n := range mat {
+		square[n] = gf2MatrixTimes(mat, mat[n])
+	}
+}
+
+// Combine returns the checksum of the concatenation of two blocks of
+// data given the checksum of the first, crc1, the checksum of the
+// second, crc2, and the length of the second, len2.
+//
+// This allows the checksum of a file to be worked out from the
+// checksums of its parts, which may be calculated in any order.
+//
+// This is the algorithm from zlib's crc32_combine extended to 64 bits.
+func Combine(crc1, crc2 uint64, len2 int64) uint64 {
+	if len2 <= 0 {
+		return crc1
+	}
+	var even, odd [64]uint64
+
+	// put operator for one zero bit in odd
+	odd[0] = poly
+	row := uint64(1)
+	for n := 1; n < 64; n++ {
+		odd[n] = row
+		row <<= 1
+	}
+
+	// put operator for two zero bits in even
+	gf2MatrixSquare(&even, &odd)
+
+	// put operator for four zero bits in odd
+	gf2MatrixSquare(&odd, &even)
+
+	// apply len2 zeros to crc1 - the first square puts the operator
+	// for one zero byte, eight zero bits, in even
+	for {
+		// apply zeros operator for this bit of len2
+		gf2MatrixSquare(&even, &odd)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(&even, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+
+		// another iteration of the loop with odd and even swapped
+		gf2MatrixSquare(&odd, &even)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(&odd, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+	}
+	return crc1 ^ crc2
+}
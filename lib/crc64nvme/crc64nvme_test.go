@@ -0,0 +1,40 @@
+package crc64nvme
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecksum(t *testing.T) {
+	// Check value from the CRC catalogue
+	assert.Equal(t, uint64(0xae8b14860a799888), Checksum([]byte("123456789")))
+	assert.Equal(t, uint64(0), Checksum(nil))
+
+	h := New()
+	_, _ = h.Write([]byte("1234"))
+	_, _ = h.Write([]byte("56789"))
+	assert.Equal(t, uint64(0xae8b14860a799888), h.Sum64())
+	assert.Equal(t, Size, h.Size())
+}
+
+func TestCombine(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	want := Checksum(data)
+	for _, split := range []int{0, 1, 7, 8, 9, 500, 999, 1000} {
+		t.Run(fmt.Sprint(split), func(t *testing.T) {
+			a, b := data[:split], data[split:]
+			got := Combine(Checksum(a), Checksum(b), int64(len(b)))
+			assert.Equal(t, want, got)
+		})
+	}
+
+	// Combine three parts
+	a, b, c := data[:100], data[100:600], data[600:]
+	got := Combine(Combine(Checksum(a), Checksum(b), int64(len(b))), Checksum(c), int64(len(c)))
+	assert.Equal(t, want, got)
+}
@@ -65,6 +65,7 @@ const (
 	EncodeSquareBracket              // []
 	EncodeSemicolon                  // ;
 	EncodeExclamation                // !
+	EncodeWinReserved                // CON, PRN, AUX, NUL, COM0-9, LPT0-9 names
 
 	// Synthetic
 	EncodeWin         = EncodeColon | EncodeQuestion | EncodeDoubleQuote | EncodeAsterisk | EncodeLtGt | EncodePipe | EncodeRightSpace | EncodeRightPeriod // :?"*<>| + trailing space/period
@@ -149,6 +150,7 @@ func init() {
 	alias("RightCrLfHtVt", EncodeRightCrLfHtVt)
 	alias("InvalidUtf8", EncodeInvalidUtf8)
 	alias("Dot", EncodeDot)
+	alias("WinReserved", EncodeWinReserved)
 	// Synthetic encodings
 	alias("Win", EncodeWin)
 	alias("HashPercent", EncodeHashPercent)
@@ -265,6 +267,9 @@ func (mask MultiEncoder) Encode(in string) string {
 			prefix, in = string(QuoteRune)+string('~'+fullOffset), in[l:] // FULLWIDTH TILDE
 		}
 	}
+	if mask.Has(EncodeWinReserved) && prefix == "" { // Reserved Windows device names
+		prefix, in = encodeWinReserved(in)
+	}
 	if mask.Has(EncodeLeftCrLfHtVt) && prefix == "" { // Leading CR LF HT VT
 		switch c := in[0]; c {
 		case '\t', '\n', '\v', '\r':
@@ -714,6 +719,8 @@ func (mask MultiEncoder) Decode(in string) string {
 		prefix, in = "~", in[l1:]
 	} else if mask.Has(EncodeLeftCrLfHtVt) && (r == '␀'+'\t' || r == '␀'+'\n' || r == '␀'+'\v' || r == '␀'+'\r') {
 		prefix, in = string(r-'␀'), in[l1:]
+	} else if mask.Has(EncodeWinReserved) && mask.decodeWinReserved(r, in[l1:]) { // FULLWIDTH first letter
+		prefix, in = string(r-fullOffset), in[l1:]
 	} else if r == QuoteRune {
 		if r, l2 := utf8.DecodeRuneInString(in[l1:]); mask.Has(EncodeLeftSpace) && r == '␠' { // SYMBOL FOR SPACE
 			prefix, in = "␠", in[l1+l2:]
@@ -723,6 +730,8 @@ func (mask MultiEncoder) Decode(in string) string {
 			prefix, in = "～", in[l1+l2:]
 		} else if mask.Has(EncodeLeftCrLfHtVt) && (r == '␀'+'\t' || r == '␀'+'\n' || r == '␀'+'\v' || r == '␀'+'\r') {
 			prefix, in = string(r), in[l1+l2:]
+		} else if mask.Has(EncodeWinReserved) && mask.decodeWinReserved(r, in[l1+l2:]) { // FULLWIDTH first letter
+			prefix, in = string(r), in[l1+l2:]
 		}
 	}
 
@@ -27,6 +27,7 @@ func TestEncodeString(t *testing.T) {
 		{EncodeDoubleQuote, "DoubleQuote"},
 		{EncodeDot, "Dot"},
 		{EncodeWin, "Win"},
+		{EncodeColon | EncodeWinReserved, "Colon,WinReserved"},
 		{EncodeHashPercent, "HashPercent"},
 		{EncodeSlash | EncodeDollar | EncodeColon, "Slash,Dollar,Colon"},
 		{EncodeSlash | (1 << 31), "Slash,0x80000000"},
@@ -291,6 +292,120 @@ func TestEncodeWin(t *testing.T) {
 	}
 }
 
+func TestEncodeWinReserved(t *testing.T) {
+	const win = EncodeWin | EncodeWinReserved
+	for i, tc := range []testCase{
+		{
+			mask: EncodeWinReserved,
+			in:   "CON",
+			out:  "ＣON",
+		}, {
+			mask: EncodeWinReserved,
+			in:   "nul.txt",
+			out:  "ｎul.txt",
+		}, {
+			mask: EncodeWinReserved,
+			in:   "Com1.tar.gz",
+			out:  "Ｃom1.tar.gz",
+		}, {
+			mask: EncodeWinReserved,
+			in:   "LPT¹",
+			out:  "ＬPT¹",
+		}, {
+			mask: EncodeWinReserved,
+			in:   "AUX .txt",
+			out:  "ＡUX .txt",
+		}, {
+			mask: EncodeWinReserved,
+			in:   "CONSOLE",
+			out:  "CONSOLE",
+		}, {
+			mask: EncodeWinReserved,
+			in:   "COM10",
+			out:  "COM10",
+		}, {
+			mask: EncodeWinReserved,
+			in:   "my.CON",
+			out:  "my.CON",
+		}, {
+			mask: EncodeWinReserved,
+			in:   "ＣON",
+			out:  "‛ＣON",
+		}, {
+			mask: EncodeWinReserved,
+			in:   "Ｃat",
+			out:  "Ｃat",
+		}, {
+			mask: EncodeZero,
+			in:   "CON",
+			out:  "CON",
+		}, {
+			mask: win,
+			in:   "PRN.",
+			out:  "ＰRN．",
+		}, {
+			mask: win,
+			in:   "ＰRN.",
+			out:  "‛ＰRN．",
+		}, {
+			mask: win,
+			in:   "PRN．",
+			out:  "PRN‛．",
+		}, {
+			mask: win,
+			in:   "ＰRN．",
+			out:  "ＰRN‛．",
+		}, {
+			mask: win,
+			in:   "ＰRN:x",
+			out:  "ＰRN：x",
+		}, {
+			mask: win,
+			in:   "ＰRN.:",
+			out:  "‛ＰRN.：",
+		}, {
+			mask: win,
+			in:   "ＣON‛x",
+			out:  "ＣON‛‛x",
+		}, {
+			mask: win | EncodeLeftSpace,
+			in:   " CON",
+			out:  "␠CON",
+		},
+	} {
+		e := tc.mask
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			got := e.Encode(tc.in)
+			if got != tc.out {
+				t.Errorf("Encode(%q) want %q got %q", tc.in, tc.out, got)
+			}
+			got2 := e.Decode(got)
+			if got2 != tc.in {
+				t.Errorf("Decode(%q) want %q got %q", got, tc.in, got2)
+			}
+		})
+	}
+}
+
+func TestEncodeWinReservedRoundTrip(t *testing.T) {
+	// Check all combinations of these pieces survive a round trip
+	pieces := []string{"C", "Ｃ", "c", "ON", "OM1", "on", "x", ".", "．", " ", "␠", ":", "：", "COM²"}
+	for _, mask := range []MultiEncoder{EncodeWinReserved, Base | EncodeWinReserved, OS | EncodeWin | EncodeWinReserved | EncodeLeftSpace | EncodeLeftPeriod} {
+		for _, a := range pieces {
+			for _, b := range pieces {
+				for _, c := range pieces {
+					in := a + b + c
+					out := mask.Encode(in)
+					assert.Equal(t, in, mask.Decode(out), "mask=%v in=%q out=%q", mask, in, out)
+					if isWinReserved(in) {
+						assert.False(t, isWinReserved(out), "mask=%v in=%q out=%q", mask, in, out)
+					}
+				}
+			}
+		}
+	}
+}
+
 func TestDecodeHalf(t *testing.T) {
 	for i, tc := range []testCase{
 		{
@@ -505,3 +620,21 @@ func restoreReservedChars(in string) string {
 		return c
 	}, in)
 }
+
+func TestNormalizationForms(t *testing.T) {
+	nfc := "Test\u00ea\u00e9"
+	nfd := "Teste\u0302e\u0301"
+	assert.Equal(t, []string{"ascii"}, NormalizationForms("ascii"))
+	assert.Equal(t, []string{nfc, nfd}, NormalizationForms(nfc))
+	assert.Equal(t, []string{nfd, nfc}, NormalizationForms(nfd))
+}
+
+func TestEqualNormalized(t *testing.T) {
+	nfc := "Test\u00ea\u00e9"
+	nfd := "Teste\u0302e\u0301"
+	assert.True(t, EqualNormalized(nfc, nfd, false))
+	assert.True(t, EqualNormalized(nfc, nfc, false))
+	assert.False(t, EqualNormalized(nfc, "Testee", false))
+	assert.False(t, EqualNormalized(strings.ToUpper(nfc), nfd, false))
+	assert.True(t, EqualNormalized(strings.ToUpper(nfc), nfd, true))
+}
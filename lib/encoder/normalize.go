@@ -0,0 +1,36 @@
+package encoder
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizationForms returns s followed by the other Unicode
+// normalization forms (NFC and NFD) of s which differ from it.
+//
+// These are the names a file called s might be stored under, for
+// example macOS stores names decomposed (NFD) whereas most other
+// systems store them as they are given, usually composed (NFC).
+func NormalizationForms(s string) []string {
+	forms := []string{s}
+	for _, form := range []norm.Form{norm.NFC, norm.NFD} {
+		alt := form.String(s)
+		if alt != s && (len(forms) == 1 || alt != forms[1]) {
+			forms = append(forms, alt)
+		}
+	}
+	return forms
+}
+
+// EqualNormalized returns true if a and b are the same name when
+// Unicode normalization is ignored, so "é" in NFC and NFD are equal.
+//
+// If ignoreCase is set then the case of the names is ignored too.
+func EqualNormalized(a, b string, ignoreCase bool) bool {
+	a, b = norm.NFC.String(a), norm.NFC.String(b)
+	if ignoreCase {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
@@ -0,0 +1,65 @@
+package encoder
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// winReservedNames are the device names Windows won't allow as the
+// name of a file, with or without an extension.
+//
+// https://learn.microsoft.com/en-us/windows/win32/fileio/naming-a-file#naming-conventions
+var winReservedNames = func() map[string]struct{} {
+	names := map[string]struct{}{}
+	for _, name := range []string{"CON", "PRN", "AUX", "NUL"} {
+		names[name] = struct{}{}
+	}
+	for _, prefix := range []string{"COM", "LPT"} {
+		for _, digit := range "0123456789¹²³" {
+			names[prefix+string(digit)] = struct{}{}
+		}
+	}
+	return names
+}()
+
+// isWinReserved returns true if in is a name Windows reserves for a
+// device, for example "CON", "nul.txt" or "COM1 .tar.gz".
+func isWinReserved(in string) bool {
+	base, _, _ := strings.Cut(in, ".")
+	base = strings.TrimRight(base, " ")
+	if len(base) < 3 || len(base) > len("COM¹") {
+		return false
+	}
+	_, found := winReservedNames[strings.ToUpper(base)]
+	return found
+}
+
+// isFullwidthLetter returns true if r is the FULLWIDTH variant of an
+// ASCII letter
+func isFullwidthLetter(r rune) bool {
+	return ('A'+fullOffset <= r && r <= 'Z'+fullOffset) || ('a'+fullOffset <= r && r <= 'z'+fullOffset)
+}
+
+// encodeWinReserved returns the prefix to encode a name starting
+// with in with if it is a reserved Windows name, or if it looks like
+// one which has been encoded, along with the rest of in.
+//
+// Reserved names have their first letter replaced with its FULLWIDTH
+// variant, so "CON" becomes "ＣON". Names which look like that are
+// quoted.
+func encodeWinReserved(in string) (prefix, rest string) {
+	if isWinReserved(in) {
+		return string(rune(in[0]) + fullOffset), in[1:]
+	}
+	if r, l := utf8.DecodeRuneInString(in); isFullwidthLetter(r) && isWinReserved(string(r-fullOffset)+in[l:]) {
+		return string(QuoteRune) + string(r), in[l:]
+	}
+	return "", in
+}
+
+// decodeWinReserved returns true if r is the first rune of an encoded
+// reserved Windows name where rest is the remainder of the encoded
+// name.
+func (mask MultiEncoder) decodeWinReserved(r rune, rest string) bool {
+	return isFullwidthLetter(r) && isWinReserved(string(r-fullOffset)+mask.Decode(rest))
+}
@@ -0,0 +1,178 @@
+package encoder
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Character classes which can be used as keys in a Table
+const (
+	TableClassDiacritics = "{diacritics}" // combining marks such as accents
+	TableClassEmoji      = "{emoji}"      // runs of emoji
+	TableClassNonASCII   = "{nonascii}"   // anything which isn't ASCII
+)
+
+// Table is a user defined character mapping table.
+//
+// It maps strings or classes of characters to replacements. Unlike
+// MultiEncoder the mapping needn't be reversible so it is used to
+// enforce naming policies on upload rather than to encode names.
+type Table struct {
+	spec       string
+	replacer   *strings.Replacer
+	diacritics *string
+	emoji      *string
+	nonASCII   *string
+}
+
+// ParseTable parses a custom character mapping table.
+//
+// spec is either a JSON object mapping strings to their replacements,
+// for example
+//
+//	{"&": "and", "{emoji}": "_", "{diacritics}": ""}
+//
+// or the path of a file containing a JSON object or CSV lines of
+// "from,to". Lines in the CSV file starting with # are ignored.
+//
+// The keys {diacritics}, {emoji} and {nonascii} replace those classes
+// of characters after the other mappings have been done.
+func ParseTable(spec string) (*Table, error) {
+	data := strings.TrimSpace(spec)
+	if !strings.HasPrefix(data, "{") {
+		in, err := os.ReadFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read encoding table: %w", err)
+		}
+		data = strings.TrimSpace(string(in))
+	}
+	var mapping map[string]string
+	if strings.HasPrefix(data, "{") {
+		if err := json.Unmarshal([]byte(data), &mapping); err != nil {
+			return nil, fmt.Errorf("failed to parse encoding table JSON: %w", err)
+		}
+	} else {
+		r := csv.NewReader(strings.NewReader(data))
+		r.Comment = '#'
+		r.FieldsPerRecord = 2
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse encoding table CSV: %w", err)
+		}
+		mapping = make(map[string]string, len(records))
+		for _, record := range records {
+			mapping[record[0]] = record[1]
+		}
+	}
+	t := &Table{spec: spec}
+	var pairs [][2]string
+	for from, to := range mapping {
+		if strings.ContainsRune(to, '/') {
+			return nil, fmt.Errorf("encoding table: replacement %q for %q can't contain /", to, from)
+		}
+		switch from {
+		case "":
+			return nil, errors.New("encoding table: can't replace the empty string")
+		case TableClassDiacritics:
+			t.diacritics = &to
+		case TableClassEmoji:
+			t.emoji = &to
+		case TableClassNonASCII:
+			t.nonASCII = &to
+		default:
+			if strings.ContainsRune(from, '/') {
+				return nil, fmt.Errorf("encoding table: can't replace %q as it contains /", from)
+			}
+			pairs = append(pairs, [2]string{from, to})
+		}
+	}
+	if len(pairs) > 0 {
+		// Sort the longest first so they take priority
+		sort.Slice(pairs, func(i, j int) bool {
+			if len(pairs[i][0]) != len(pairs[j][0]) {
+				return len(pairs[i][0]) > len(pairs[j][0])
+			}
+			return pairs[i][0] < pairs[j][0]
+		})
+		oldnew := make([]string, 0, 2*len(pairs))
+		for _, pair := range pairs {
+			oldnew = append(oldnew, pair[0], pair[1])
+		}
+		t.replacer = strings.NewReplacer(oldnew...)
+	}
+	return t, nil
+}
+
+// String returns the spec the table was parsed from
+func (t *Table) String() string {
+	return t.spec
+}
+
+// isEmoji returns true if r is part of an emoji sequence
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, transport, flags, etc
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // misc symbols and dingbats
+		return true
+	case r == 0x200D || r == 0xFE0F || r == 0x20E3: // joiner, emoji presentation, keycap
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // tags for subdivision flags
+		return true
+	}
+	return false
+}
+
+// Apply the table to a single file or directory name
+func (t *Table) Apply(name string) string {
+	if t.replacer != nil {
+		name = t.replacer.Replace(name)
+	}
+	if t.diacritics != nil {
+		var out strings.Builder
+		for _, r := range norm.NFD.String(name) {
+			if unicode.Is(unicode.Mn, r) {
+				out.WriteString(*t.diacritics)
+			} else {
+				out.WriteRune(r)
+			}
+		}
+		name = norm.NFC.String(out.String())
+	}
+	if t.emoji != nil {
+		var out strings.Builder
+		inEmoji := false
+		for _, r := range name {
+			if isEmoji(r) {
+				if !inEmoji {
+					out.WriteString(*t.emoji)
+				}
+				inEmoji = true
+				continue
+			}
+			inEmoji = false
+			out.WriteRune(r)
+		}
+		name = out.String()
+	}
+	if t.nonASCII != nil {
+		var out strings.Builder
+		for _, r := range name {
+			if r > unicode.MaxASCII {
+				out.WriteString(*t.nonASCII)
+			} else {
+				out.WriteRune(r)
+			}
+		}
+		name = out.String()
+	}
+	return name
+}
@@ -0,0 +1,65 @@
+package encoder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableApply(t *testing.T) {
+	for _, test := range []struct {
+		spec string
+		in   string
+		want string
+	}{
+		{`{}`, "hello 😀.txt", "hello 😀.txt"},
+		{`{"&": "and", "a": "A"}`, "a&b", "Aandb"},
+		{`{"ab": "X", "a": "Y"}`, "abac", "XYc"},
+		{`{"{diacritics}": ""}`, "Crème Brûlée.txt", "Creme Brulee.txt"},
+		{`{"{diacritics}": ""}`, "ǅ日本", "ǅ日本"},
+		{`{"{emoji}": "_"}`, "party 🎉🎉 time", "party _ time"},
+		{`{"{emoji}": ""}`, "family 👨‍👩‍👧.jpg", "family .jpg"},
+		{`{"{emoji}": "_"}`, "flag 🇬🇧!", "flag _!"},
+		{`{"{nonascii}": "?"}`, "naïve 日本", "na?ve ??"},
+		{`{"{diacritics}": "", "{nonascii}": "_"}`, "naïve 日本", "naive __"},
+		{`{"é": "e-acute", "{diacritics}": ""}`, "éè", "e-acutee"},
+	} {
+		table, err := ParseTable(test.spec)
+		require.NoError(t, err, test.spec)
+		assert.Equal(t, test.want, table.Apply(test.in), test.spec)
+		assert.Equal(t, test.spec, table.String())
+	}
+}
+
+func TestParseTableFile(t *testing.T) {
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "table.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("# comment\n&,and\n\"a,b\",c\n{emoji},\n"), 0666))
+	table, err := ParseTable(csvPath)
+	require.NoError(t, err)
+	assert.Equal(t, "x and c y", table.Apply("x & a,b y🎉"))
+
+	jsonPath := filepath.Join(dir, "table.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"&": "and"}`), 0666))
+	table, err = ParseTable(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, "x and y", table.Apply("x & y"))
+}
+
+func TestParseTableErrors(t *testing.T) {
+	for _, spec := range []string{
+		`{"a": "b/c"}`,
+		`{"a/b": "c"}`,
+		`{"": "c"}`,
+		`{"a": 1}`,
+		`{`,
+		filepath.Join(t.TempDir(), "notfound.csv"),
+	} {
+		_, err := ParseTable(spec)
+		assert.Error(t, err, spec)
+	}
+}
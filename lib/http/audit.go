@@ -0,0 +1,335 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/spf13/pflag"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// AuditHelp returns text describing the audit log to add to the command help.
+func AuditHelp(prefix string) string {
+	help := `### Audit log
+
+Use ` + "`--{{ .Prefix }}audit-log /path/to/audit.log`" + ` to record every request made to
+the server in a file, one JSON object per line. Set it to ` + "`syslog`" + ` to
+send the records to the system log instead (not available on Windows).
+
+Each record has the time of the request, the protocol, the client
+address, the user (the authenticated user, or the user the client
+tried to log in as), the HTTP method, the path, the status code, the
+bytes received and sent and how long the request took, e.g.
+
+` + "```json" + `
+{"time":"2024-01-02T03:04:05.678Z","protocol":"webdav","remote_addr":"192.0.2.1:51234","user":"alice","method":"PUT","path":"/dir/file.txt","status":201,"bytes_in":1024,"bytes_out":0,"duration_ms":12.5}
+` + "```" + `
+
+The records are written independently of the ` + "`--log-file`" + ` and ` + "`-v`" + ` settings.
+
+Use ` + "`--{{ .Prefix }}audit-log-max-size`" + ` to rotate the audit log when it gets
+bigger than the size given, keeping ` + "`--{{ .Prefix }}audit-log-max-backups`" + ` old
+logs for up to ` + "`--{{ .Prefix }}audit-log-max-age`" + `, compressed if
+` + "`--{{ .Prefix }}audit-log-compress`" + ` is set.
+
+`
+	tmpl, err := template.New("audit help").Parse(help)
+	if err != nil {
+		fs.Fatal(nil, fmt.Sprint("Fatal error parsing template", err))
+	}
+
+	data := struct {
+		Prefix string
+	}{
+		Prefix: prefix,
+	}
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, data)
+	if err != nil {
+		fs.Fatal(nil, fmt.Sprint("Fatal error executing template", err))
+	}
+	return buf.String()
+}
+
+// AuditConfigInfo descripts the Options in use
+var AuditConfigInfo = fs.Options{{
+	Name:    "audit_log",
+	Default: "",
+	Help:    "Record every request in this file as JSON lines, or \"syslog\"",
+}, {
+	Name:    "audit_log_max_size",
+	Default: fs.SizeSuffix(0),
+	Help:    "Maximum size of the audit log before it's rotated (0 for no rotation)",
+}, {
+	Name:    "audit_log_max_backups",
+	Default: 0,
+	Help:    "Maximum number of old audit logs to retain",
+}, {
+	Name:    "audit_log_max_age",
+	Default: fs.Duration(0),
+	Help:    "Maximum duration to retain old audit logs",
+}, {
+	Name:    "audit_log_compress",
+	Default: false,
+	Help:    "If set, compress rotated audit logs using gzip",
+}}
+
+// AuditConfig contains options for the audit log
+type AuditConfig struct {
+	Log        string        `config:"audit_log"`             // file to write the audit log to or "syslog"
+	MaxSize    fs.SizeSuffix `config:"audit_log_max_size"`    // size to rotate the audit log at
+	MaxBackups int           `config:"audit_log_max_backups"` // number of old audit logs to keep
+	MaxAge     fs.Duration   `config:"audit_log_max_age"`     // age to keep old audit logs for
+	Compress   bool          `config:"audit_log_compress"`    // set to compress old audit logs
+	Protocol   string        `json:"-" config:"-"`            // protocol to record (not set by command line flags)
+}
+
+// AddFlagsPrefix adds flags to the flag set for AuditConfig
+func (cfg *AuditConfig) AddFlagsPrefix(flagSet *pflag.FlagSet, prefix string) {
+	flags.StringVarP(flagSet, &cfg.Log, prefix+"audit-log", "", cfg.Log, "Record every request in this file as JSON lines, or \"syslog\"", prefix)
+	flags.FVarP(flagSet, &cfg.MaxSize, prefix+"audit-log-max-size", "", "Maximum size of the audit log before it's rotated (0 for no rotation)", prefix)
+	flags.IntVarP(flagSet, &cfg.MaxBackups, prefix+"audit-log-max-backups", "", cfg.MaxBackups, "Maximum number of old audit logs to retain", prefix)
+	flags.FVarP(flagSet, &cfg.MaxAge, prefix+"audit-log-max-age", "", "Maximum duration to retain old audit logs", prefix)
+	flags.BoolVarP(flagSet, &cfg.Compress, prefix+"audit-log-compress", "", cfg.Compress, "If set, compress rotated audit logs using gzip", prefix)
+}
+
+// DefaultAuditCfg returns a new config which can be customized by command line flags
+//
+// Note that this needs to be kept in sync with AuditConfigInfo above and
+// can be removed when all callers have been converted.
+func DefaultAuditCfg() AuditConfig {
+	return AuditConfig{}
+}
+
+// WithAudit option records every request in the audit log if one is configured
+func WithAudit(cfg AuditConfig) Option {
+	return func(s *Server) {
+		s.auditCfg = cfg
+	}
+}
+
+// AuditRecord is the record of a single request written to the audit log
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	Protocol   string    `json:"protocol,omitempty"`
+	RemoteAddr string    `json:"remote_addr"`
+	User       string    `json:"user"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	BytesIn    int64     `json:"bytes_in"`
+	BytesOut   int64     `json:"bytes_out"`
+	Duration   float64   `json:"duration_ms"`
+}
+
+// auditLog writes AuditRecords as JSON lines
+type auditLog struct {
+	mu       sync.Mutex
+	out      io.WriteCloser
+	protocol string
+	failed   bool // set if a write has failed so it is only reported once
+}
+
+// newAuditLog opens the audit log in cfg, returning nil if there isn't one
+func newAuditLog(cfg AuditConfig) (*auditLog, error) {
+	var out io.WriteCloser
+	switch {
+	case cfg.Log == "":
+		return nil, nil
+	case cfg.Log == "syslog":
+		var err error
+		out, err = openAuditSyslog()
+		if err != nil {
+			return nil, err
+		}
+	case cfg.MaxSize > 0:
+		// Round with a minimum of 1 if set
+		round := func(x float64) int {
+			if x <= 0 {
+				return 0
+			} else if x <= 1 {
+				return 1
+			}
+			return int(x + 0.5)
+		}
+		out = &lumberjack.Logger{
+			Filename:   cfg.Log,
+			MaxSize:    round(float64(cfg.MaxSize) / float64(fs.Mebi)), // MiB
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     round(time.Duration(cfg.MaxAge).Hours() / 24), // Days
+			Compress:   cfg.Compress,
+			LocalTime:  true, // format log file names in localtime
+		}
+	default:
+		f, err := os.OpenFile(cfg.Log, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+		out = f
+	}
+	fs.Infof(nil, "Writing audit log to %q", cfg.Log)
+	return &auditLog{out: out, protocol: cfg.Protocol}, nil
+}
+
+// write the record to the audit log
+func (a *auditLog) write(rec *AuditRecord) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		fs.Errorf(nil, "Failed to encode audit record: %v", err)
+		return
+	}
+	data = append(data, '\n')
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.out.Write(data)
+	if err != nil && !a.failed {
+		fs.Errorf(nil, "Failed to write audit log: %v", err)
+	}
+	a.failed = err != nil
+}
+
+// Close the audit log
+func (a *auditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.out.Close()
+}
+
+// middleware records every request passing through it
+func (a *auditLog) middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &AuditRecord{
+				Time:       start,
+				Protocol:   a.protocol,
+				RemoteAddr: r.RemoteAddr,
+				Method:     r.Method,
+				Path:       r.URL.Path,
+			}
+			aw := &auditResponseWriter{ResponseWriter: w}
+			var body *auditBody
+			if r.Body != nil {
+				body = &auditBody{ReadCloser: r.Body}
+				r.Body = body
+			}
+			r = r.WithContext(context.WithValue(r.Context(), ctxKeyAudit, rec))
+			defer func() {
+				rec.Duration = float64(time.Since(start).Microseconds()) / 1000
+				rec.Status = aw.status
+				if rec.Status == 0 {
+					rec.Status = http.StatusOK
+				}
+				rec.BytesOut = aw.n
+				if body != nil {
+					rec.BytesIn = body.n
+				}
+				if rec.User == "" {
+					rec.User = requestUser(r)
+				}
+				a.write(rec)
+			}()
+			next.ServeHTTP(aw, r)
+		})
+	}
+}
+
+// setAuditUser notes the authenticated user in the audit record of
+// the request if it is being audited
+func setAuditUser(ctx context.Context, user string) {
+	if rec, ok := ctx.Value(ctxKeyAudit).(*AuditRecord); ok {
+		rec.User = user
+	}
+}
+
+// requestUser returns the user an unauthenticated request claimed to
+// be, from its basic auth or the access key ID of its S3 signature.
+func requestUser(r *http.Request) string {
+	if user, _, ok := parseAuthorization(r); ok {
+		return user
+	}
+	return awsAccessKeyID(r)
+}
+
+// awsAccessKeyID returns the access key ID of an S3 signed request or ""
+func awsAccessKeyID(r *http.Request) string {
+	authHeader := r.Header.Get("Authorization")
+	switch {
+	case strings.HasPrefix(authHeader, "AWS4-HMAC-SHA256 "):
+		// AWS4-HMAC-SHA256 Credential=ID/date/region/s3/aws4_request, ...
+		_, credential, ok := strings.Cut(authHeader, "Credential=")
+		if ok {
+			id, _, _ := strings.Cut(credential, "/")
+			return id
+		}
+	case strings.HasPrefix(authHeader, "AWS "):
+		// AWS ID:signature
+		id, _, _ := strings.Cut(strings.TrimPrefix(authHeader, "AWS "), ":")
+		return id
+	}
+	// Presigned URLs
+	if credential := r.URL.Query().Get("X-Amz-Credential"); credential != "" {
+		id, _, _ := strings.Cut(credential, "/")
+		return id
+	}
+	return r.URL.Query().Get("AWSAccessKeyId")
+}
+
+// auditResponseWriter counts the bytes written and notes the status
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+	n      int64
+}
+
+// WriteHeader notes the status code
+func (w *auditResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write counts the bytes written
+func (w *auditResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// Flush passes the flush on if the underlying writer supports it
+func (w *auditResponseWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *auditResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// auditBody counts the bytes read from the request body
+type auditBody struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read counts the bytes read
+func (b *auditBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,15 @@
+// Audit log to syslog for Unix variants only
+
+//go:build !windows && !nacl && !plan9
+
+package http
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// openAuditSyslog opens syslog for writing audit records to
+func openAuditSyslog() (io.WriteCloser, error) {
+	return syslog.New(syslog.LOG_INFO|syslog.LOG_AUTH, "rclone-audit")
+}
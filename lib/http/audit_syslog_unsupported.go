@@ -0,0 +1,16 @@
+// Audit log to syslog for non Unix variants
+
+//go:build windows || nacl || plan9
+
+package http
+
+import (
+	"errors"
+	"io"
+	"runtime"
+)
+
+// openAuditSyslog opens syslog for writing audit records to
+func openAuditSyslog() (io.WriteCloser, error) {
+	return nil, errors.New("audit log to syslog not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAuditLog reads the records from the audit log at path
+func readAuditLog(t *testing.T, path string) (recs []AuditRecord) {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, f.Close())
+	}()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec AuditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		recs = append(recs, rec)
+	}
+	require.NoError(t, scanner.Err())
+	return recs
+}
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+
+	cfg := DefaultCfg()
+	cfg.ListenAddr = []string{"127.0.0.1:0"}
+	auth := AuthConfig{
+		BasicUser: "test",
+		BasicPass: "test",
+	}
+	audit := AuditConfig{
+		Log:      auditPath,
+		Protocol: "test",
+	}
+
+	s, err := NewServer(ctx, WithConfig(cfg), WithAuth(auth), WithAudit(audit))
+	require.NoError(t, err)
+	url := testGetServerURL(t, s)
+	s.Router().Post("/upload", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(strings.Repeat("x", int(n)*2)))
+	})
+	s.Serve()
+
+	do := func(user, pass string) {
+		req, err := http.NewRequest("POST", url+"upload", strings.NewReader("hello"))
+		require.NoError(t, err)
+		req.SetBasicAuth(user, pass)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, resp.Body.Close())
+	}
+	do("test", "test")
+	do("intruder", "wrong")
+	require.NoError(t, s.Shutdown())
+
+	recs := readAuditLog(t, auditPath)
+	require.Len(t, recs, 2)
+
+	rec := recs[0]
+	assert.Equal(t, "test", rec.Protocol)
+	assert.Equal(t, "test", rec.User)
+	assert.Equal(t, "POST", rec.Method)
+	assert.Equal(t, "/upload", rec.Path)
+	assert.Equal(t, http.StatusCreated, rec.Status)
+	assert.Equal(t, int64(5), rec.BytesIn)
+	assert.Equal(t, int64(10), rec.BytesOut)
+	assert.NotEmpty(t, rec.RemoteAddr)
+	assert.False(t, rec.Time.IsZero())
+	assert.GreaterOrEqual(t, rec.Duration, 0.0)
+
+	rec = recs[1]
+	assert.Equal(t, "intruder", rec.User)
+	assert.Equal(t, http.StatusUnauthorized, rec.Status)
+	assert.Equal(t, int64(0), rec.BytesIn)
+}
+
+func TestAuditLogNone(t *testing.T) {
+	a, err := newAuditLog(AuditConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, a)
+}
+
+func TestAWSAccessKeyID(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		header string
+		query  string
+		want   string
+	}{
+		{
+			name:   "V4",
+			header: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240101/us-east-1/s3/aws4_request, SignedHeaders=host, Signature=abc",
+			want:   "AKIDEXAMPLE",
+		}, {
+			name:   "V2",
+			header: "AWS AKIDEXAMPLE:signature",
+			want:   "AKIDEXAMPLE",
+		}, {
+			name:  "PresignedV4",
+			query: "X-Amz-Credential=AKIDEXAMPLE%2F20240101%2Fus-east-1%2Fs3%2Faws4_request",
+			want:  "AKIDEXAMPLE",
+		}, {
+			name:  "PresignedV2",
+			query: "AWSAccessKeyId=AKIDEXAMPLE",
+			want:  "AKIDEXAMPLE",
+		}, {
+			name: "None",
+			want: "",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "http://localhost/bucket/key?"+test.query, nil)
+			require.NoError(t, err)
+			if test.header != "" {
+				req.Header.Set("Authorization", test.header)
+			}
+			assert.Equal(t, test.want, awsAccessKeyID(req))
+		})
+	}
+}
+
+func TestHelpPrefixAudit(t *testing.T) {
+	// This test assumes template variables are placed correctly.
+	const testPrefix = "audit-help-test"
+	helpMessage := AuditHelp(testPrefix)
+	if !strings.Contains(helpMessage, testPrefix) {
+		t.Fatal("flag prefix not found")
+	}
+}
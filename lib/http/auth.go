@@ -26,7 +26,28 @@ may lead to unauthorized access.
 
 If either of the above authentication methods is not configured and client
 certificates are required by the ` + "`--client-ca`" + ` flag passed to the server, the
-client certificate common name will be considered as the username.
+client certificate common name will be considered as the username. Use
+` + "`--{{ .Prefix }}client-cert-user`" + ` to take the username from a different
+part of the certificate instead: ` + "`cn`" + ` for the common name (the default),
+` + "`dn`" + ` for the whole subject, e.g. ` + "`CN=alice,O=Example`" + `, ` + "`email`" + ` for
+the first email address or ` + "`uri`" + ` for the first URI (e.g. a SPIFFE ID) in
+the subject alternative names.
+
+To authenticate with OpenID Connect (OIDC) bearer tokens issued by a single
+sign on provider, set ` + "`--{{ .Prefix }}oidc-issuer`" + ` to the issuer URL of the
+provider and ` + "`--{{ .Prefix }}oidc-audience`" + ` to the audience the tokens
+must be issued for (usually the client ID). Clients then send the token in an
+` + "`Authorization: Bearer TOKEN`" + ` header. The token signature is checked using
+the keys published by the provider, found via its
+` + "`/.well-known/openid-configuration`" + `, along with the issuer, audience and
+expiry. The username is taken from the ` + "`sub`" + ` claim of the token, or the
+claim set with ` + "`--{{ .Prefix }}oidc-user-claim`" + `, e.g. ` + "`email`" + ` or
+` + "`preferred_username`" + `. This can't be used with ` + "`--{{ .Prefix }}htpasswd`" + `
+or ` + "`--{{ .Prefix }}user`" + `.
+
+The usernames from client certificates or OIDC tokens are passed to the
+` + "`--auth-proxy`" + ` if one is in use, with an empty password, so the proxy can
+choose the backend for each user.
 
 Use ` + "`--{{ .Prefix }}htpasswd /path/to/htpasswd`" + ` to provide an htpasswd file.  This is
 in standard apache format and supports MD5, SHA1 and BCrypt for basic
@@ -96,6 +117,22 @@ var AuthConfigInfo = fs.Options{{
 	Name:    "user_from_header",
 	Default: "",
 	Help:    "User name from a defined HTTP header",
+}, {
+	Name:    "client_cert_user",
+	Default: "cn",
+	Help:    "Client certificate field to use as the user name: cn, dn, email or uri",
+}, {
+	Name:    "oidc_issuer",
+	Default: "",
+	Help:    "OpenID Connect issuer URL to validate bearer tokens with",
+}, {
+	Name:    "oidc_audience",
+	Default: "",
+	Help:    "Audience OpenID Connect bearer tokens must be issued for",
+}, {
+	Name:    "oidc_user_claim",
+	Default: "sub",
+	Help:    "OpenID Connect token claim to use as the user name",
 }}
 
 // AuthConfig contains options for the http authentication
@@ -106,6 +143,10 @@ type AuthConfig struct {
 	BasicPass      string       `config:"pass"`             // password for BasicUser
 	Salt           string       `config:"salt"`             // password hashing salt
 	UserFromHeader string       `config:"user_from_header"` // retrieve user name from a defined HTTP header
+	ClientCertUser string       `config:"client_cert_user"` // client certificate field to use as the user name
+	OIDCIssuer     string       `config:"oidc_issuer"`      // OpenID Connect issuer to validate bearer tokens with
+	OIDCAudience   string       `config:"oidc_audience"`    // audience bearer tokens must be issued for
+	OIDCUserClaim  string       `config:"oidc_user_claim"`  // token claim to use as the user name
 	CustomAuthFn   CustomAuthFn `json:"-" config:"-"`       // custom Auth (not set by command line flags)
 }
 
@@ -117,6 +158,10 @@ func (cfg *AuthConfig) AddFlagsPrefix(flagSet *pflag.FlagSet, prefix string) {
 	flags.StringVarP(flagSet, &cfg.BasicPass, prefix+"pass", "", cfg.BasicPass, "Password for authentication", prefix)
 	flags.StringVarP(flagSet, &cfg.Salt, prefix+"salt", "", cfg.Salt, "Password hashing salt", prefix)
 	flags.StringVarP(flagSet, &cfg.UserFromHeader, prefix+"user-from-header", "", cfg.UserFromHeader, "Retrieve the username from a specified HTTP header if no other authentication methods are configured (ideal for proxied setups)", prefix)
+	flags.StringVarP(flagSet, &cfg.ClientCertUser, prefix+"client-cert-user", "", cfg.ClientCertUser, "Client certificate field to use as the user name: cn, dn, email or uri", prefix)
+	flags.StringVarP(flagSet, &cfg.OIDCIssuer, prefix+"oidc-issuer", "", cfg.OIDCIssuer, "OpenID Connect issuer URL to validate bearer tokens with", prefix)
+	flags.StringVarP(flagSet, &cfg.OIDCAudience, prefix+"oidc-audience", "", cfg.OIDCAudience, "Audience OpenID Connect bearer tokens must be issued for", prefix)
+	flags.StringVarP(flagSet, &cfg.OIDCUserClaim, prefix+"oidc-user-claim", "", cfg.OIDCUserClaim, "OpenID Connect token claim to use as the user name", prefix)
 }
 
 // AddAuthFlagsPrefix adds flags to the flag set for AuthConfig
@@ -130,6 +175,8 @@ func AddAuthFlagsPrefix(flagSet *pflag.FlagSet, prefix string, cfg *AuthConfig)
 // can be removed when all callers have been converted.
 func DefaultAuthCfg() AuthConfig {
 	return AuthConfig{
-		Salt: "dlPL2MqE",
+		Salt:           "dlPL2MqE",
+		ClientCertUser: "cn",
+		OIDCUserClaim:  "sub",
 	}
 }
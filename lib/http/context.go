@@ -13,6 +13,7 @@ const (
 	ctxKeyPublicURL
 	ctxKeyUnixSock
 	ctxKeyUser
+	ctxKeyAudit
 )
 
 // NewBaseContext initializes the context for all requests, adding info for use in middleware and handlers
@@ -57,3 +58,10 @@ func CtxGetUser(ctx context.Context) (string, bool) {
 func CtxSetUser(ctx context.Context, value string) context.Context {
 	return context.WithValue(ctx, ctxKeyUser, value)
 }
+
+// withUser returns ctx with the authenticated user set, noting the
+// user in the audit record of the request if it is being audited
+func withUser(ctx context.Context, user string) context.Context {
+	setAuditUser(ctx, user)
+	return context.WithValue(ctx, ctxKeyUser, user)
+}
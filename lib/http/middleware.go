@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/base64"
 	"fmt"
 	"net/http"
@@ -69,19 +70,46 @@ func basicAuth(authenticator *LoggedBasicAuth) func(next http.Handler) http.Hand
 				authenticator.RequireAuth(w, r)
 				return
 			}
-			ctx := context.WithValue(r.Context(), ctxKeyUser, username)
+			ctx := withUser(r.Context(), username)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// certificateUser returns the user name from the field of cert, or
+// "" if it isn't set.
+//
+// field is one of "cn", "dn", "email" or "uri".
+func certificateUser(cert *x509.Certificate, field string) string {
+	switch field {
+	case "", "cn":
+		return cert.Subject.CommonName
+	case "dn":
+		return cert.Subject.String()
+	case "email":
+		if len(cert.EmailAddresses) > 0 {
+			return cert.EmailAddresses[0]
+		}
+	case "uri":
+		if len(cert.URIs) > 0 {
+			return cert.URIs[0].String()
+		}
+	}
+	return ""
+}
+
 // MiddlewareAuthCertificateUser instantiates middleware that extracts the authenticated user via client certificate common name
 func MiddlewareAuthCertificateUser() Middleware {
+	return MiddlewareAuthCertificateField("cn")
+}
+
+// MiddlewareAuthCertificateField instantiates middleware that extracts the authenticated user via the given client certificate field
+func MiddlewareAuthCertificateField(field string) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			for _, cert := range r.TLS.PeerCertificates {
-				if cert.Subject.CommonName != "" {
-					r = r.WithContext(context.WithValue(r.Context(), ctxKeyUser, cert.Subject.CommonName))
+				if user := certificateUser(cert, field); user != "" {
+					r = r.WithContext(withUser(r.Context(), user))
 					next.ServeHTTP(w, r)
 					return
 				}
@@ -145,6 +173,7 @@ func MiddlewareAuthCustom(fn CustomAuthFn, realm string, userFromContext bool) M
 				return
 			}
 
+			setAuditUser(r.Context(), user)
 			if value != nil {
 				r = r.WithContext(context.WithValue(r.Context(), ctxKeyAuth, value))
 			}
@@ -162,7 +191,7 @@ func MiddlewareAuthGetUserFromHeader(header string) Middleware {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			username := strings.TrimSpace(r.Header.Get(header))
 			if username != "" && validUsernameRegexp.MatchString(username) {
-				r = r.WithContext(context.WithValue(r.Context(), ctxKeyUser, username))
+				r = r.WithContext(withUser(r.Context(), username))
 				next.ServeHTTP(w, r)
 				return
 			}
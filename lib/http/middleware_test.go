@@ -3,12 +3,16 @@ package http
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -280,6 +284,38 @@ func TestMiddlewareAuthCertificateUser(t *testing.T) {
 				ClientCA:      "./testdata/client-ca.crt",
 			},
 		},
+		{
+			name:        "Valid/DN",
+			status:      http.StatusOK,
+			result:      "CN=rclone-dev-client,OU=rclone-dev,O=rclone,ST=Washington,C=US",
+			clientCerts: []tls.Certificate{clientCert},
+			http: Config{
+				ListenAddr:    []string{"127.0.0.1:0"},
+				TLSCertBody:   serverCertBytes,
+				TLSKeyBody:    serverKeyBytes,
+				MinTLSVersion: "tls1.0",
+				ClientCA:      "./testdata/client-ca.crt",
+			},
+			auth: AuthConfig{
+				ClientCertUser: "dn",
+			},
+		},
+		{
+			name:        "Valid/NoEmail",
+			status:      http.StatusUnauthorized,
+			result:      fmt.Sprintf("%s\n", http.StatusText(http.StatusUnauthorized)),
+			clientCerts: []tls.Certificate{clientCert},
+			http: Config{
+				ListenAddr:    []string{"127.0.0.1:0"},
+				TLSCertBody:   serverCertBytes,
+				TLSKeyBody:    serverKeyBytes,
+				MinTLSVersion: "tls1.0",
+				ClientCA:      "./testdata/client-ca.crt",
+			},
+			auth: AuthConfig{
+				ClientCertUser: "email",
+			},
+		},
 		{
 			name:        "CustomAuth/Invalid",
 			status:      http.StatusUnauthorized,
@@ -369,6 +405,29 @@ func TestMiddlewareAuthCertificateUser(t *testing.T) {
 
 }
 
+func TestCertificateUser(t *testing.T) {
+	spiffe, err := url.Parse("spiffe://example.org/alice")
+	require.NoError(t, err)
+	cert := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:   "alice",
+			Organization: []string{"Example"},
+		},
+		EmailAddresses: []string{"alice@example.com", "a@example.com"},
+		URIs:           []*url.URL{spiffe},
+	}
+	assert.Equal(t, "alice", certificateUser(cert, ""))
+	assert.Equal(t, "alice", certificateUser(cert, "cn"))
+	assert.Equal(t, "CN=alice,O=Example", certificateUser(cert, "dn"))
+	assert.Equal(t, "alice@example.com", certificateUser(cert, "email"))
+	assert.Equal(t, "spiffe://example.org/alice", certificateUser(cert, "uri"))
+
+	empty := &x509.Certificate{}
+	for _, field := range []string{"cn", "dn", "email", "uri"} {
+		assert.Equal(t, "", certificateUser(empty, field))
+	}
+}
+
 var _testCORSHeaderKeys = []string{
 	"Access-Control-Allow-Origin",
 	"Access-Control-Allow-Headers",
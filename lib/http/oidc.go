@@ -0,0 +1,301 @@
+package http
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/rclone/rclone/fs"
+)
+
+// Don't fetch the signing keys more often than this when a token
+// with an unknown key ID arrives
+const oidcMinKeyRefresh = time.Minute
+
+// Signing methods accepted for bearer tokens - symmetric methods are
+// never accepted as the keys come from the public JWKS
+var oidcValidMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// oidcVerifier validates bearer tokens issued by an OpenID Connect
+// provider
+type oidcVerifier struct {
+	issuer    string
+	audience  string
+	userClaim string
+	client    *http.Client
+
+	mu        sync.Mutex
+	jwksURI   string                      // from the discovery document
+	keys      map[string]crypto.PublicKey // signing keys by key ID
+	refreshed time.Time                   // when keys were last fetched
+}
+
+// newOIDCVerifier makes a verifier for tokens from issuer.
+//
+// The provider isn't contacted until the first token arrives.
+func newOIDCVerifier(issuer, audience, userClaim string) *oidcVerifier {
+	if userClaim == "" {
+		userClaim = "sub"
+	}
+	return &oidcVerifier{
+		issuer:    issuer,
+		audience:  audience,
+		userClaim: userClaim,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// getJSON fetches url decoding the JSON result into out
+func (v *oidcVerifier) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer fs.CheckClose(resp.Body, &err)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching %q: %s", url, resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("decoding %q: %w", url, err)
+	}
+	return nil
+}
+
+// jsonWebKey is a single key from a JWKS
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// decodeBigInt decodes a base64url encoded big endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// publicKey returns the public key described by k
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("bad RSA modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("bad RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("bad EC x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("bad EC y: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("bad Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// refreshKeys fetches the signing keys from the provider, doing the
+// discovery first if necessary.
+//
+// Call with v.mu held.
+func (v *oidcVerifier) refreshKeys(ctx context.Context) error {
+	v.refreshed = time.Now()
+	if v.jwksURI == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		url := strings.TrimSuffix(v.issuer, "/") + "/.well-known/openid-configuration"
+		if err := v.getJSON(ctx, url, &discovery); err != nil {
+			return fmt.Errorf("OIDC discovery failed: %w", err)
+		}
+		if discovery.Issuer != v.issuer {
+			return fmt.Errorf("OIDC discovery failed: issuer %q doesn't match %q", discovery.Issuer, v.issuer)
+		}
+		if discovery.JWKSURI == "" {
+			return errors.New("OIDC discovery failed: no jwks_uri")
+		}
+		v.jwksURI = discovery.JWKSURI
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, v.jwksURI, &jwks); err != nil {
+		return fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			fs.Debugf(nil, "OIDC: ignoring signing key %q: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	v.keys = keys
+	return nil
+}
+
+// key returns the signing key for kid fetching the keys if it isn't
+// known and they haven't been fetched recently.
+//
+// If kid is empty then the provider must only have one key.
+func (v *oidcVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	lookup := func() crypto.PublicKey {
+		if kid == "" && len(v.keys) == 1 {
+			for _, key := range v.keys {
+				return key
+			}
+		}
+		return v.keys[kid]
+	}
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	if time.Since(v.refreshed) >= oidcMinKeyRefresh || v.keys == nil {
+		if err := v.refreshKeys(ctx); err != nil {
+			return nil, err
+		}
+		if key := lookup(); key != nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// verify checks the token returning the user name from it
+func (v *oidcVerifier) verify(ctx context.Context, rawToken string) (user string, err error) {
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.key(ctx, kid)
+	}, jwt.WithValidMethods(oidcValidMethods))
+	if err != nil {
+		return "", err
+	}
+	if _, ok := claims["exp"]; !ok {
+		return "", errors.New("token has no expiry")
+	}
+	if !claims.VerifyIssuer(v.issuer, true) {
+		return "", fmt.Errorf("token issuer %v is not %q", claims["iss"], v.issuer)
+	}
+	if !claims.VerifyAudience(v.audience, true) {
+		return "", fmt.Errorf("token audience %v doesn't include %q", claims["aud"], v.audience)
+	}
+	user, _ = claims[v.userClaim].(string)
+	if user == "" {
+		return "", fmt.Errorf("token has no %q claim", v.userClaim)
+	}
+	if !validUsernameRegexp.MatchString(user) {
+		return "", fmt.Errorf("invalid user name %q in %q claim", user, v.userClaim)
+	}
+	return user, nil
+}
+
+// parseBearer returns the token from a bearer Authorization header
+func parseBearer(r *http.Request) (token string, ok bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// MiddlewareAuthOIDC instantiates middleware that authenticates
+// requests with an OpenID Connect bearer token issued by issuer for
+// audience, taking the user name from userClaim.
+func MiddlewareAuthOIDC(issuer, audience, userClaim, realm string) Middleware {
+	fs.Infof(nil, "Using OIDC bearer tokens from %q for audience %q", issuer, audience)
+	v := newOIDCVerifier(issuer, audience, userClaim)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// skip auth for CORS preflight
+			if r.Method == "OPTIONS" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			challenge := fmt.Sprintf("Bearer realm=%q", realm)
+			token, ok := parseBearer(r)
+			if ok {
+				user, err := v.verify(r.Context(), token)
+				if err == nil {
+					r = r.WithContext(withUser(r.Context(), user))
+					next.ServeHTTP(w, r)
+					return
+				}
+				fs.Infof(r.URL.Path, "%s: Unauthorized bearer token: %v", r.RemoteAddr, err)
+				challenge += `, error="invalid_token"`
+			}
+
+			code := http.StatusUnauthorized
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("WWW-Authenticate", challenge)
+			http.Error(w, http.StatusText(code), code)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testOIDCProvider runs a fake OIDC provider publishing the RSA key
+// "rsa1" and the EC key "ec1"
+type testOIDCProvider struct {
+	server  *httptest.Server
+	rsaKey  *rsa.PrivateKey
+	ecKey   *ecdsa.PrivateKey
+	fetches atomic.Int32
+}
+
+func newTestOIDCProvider(t *testing.T) *testOIDCProvider {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p := &testOIDCProvider{rsaKey: rsaKey, ecKey: ecKey}
+	b64 := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   p.server.URL,
+			"jwks_uri": p.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		p.fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "rsa1",
+				"use": "sig",
+				"n":   b64(rsaKey.N.Bytes()),
+				"e":   b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+			}, {
+				"kty": "EC",
+				"kid": "ec1",
+				"crv": "P-256",
+				"x":   b64(ecKey.X.FillBytes(make([]byte, 32))),
+				"y":   b64(ecKey.Y.FillBytes(make([]byte, 32))),
+			}},
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// token makes a token with claims on top of valid defaults
+func (p *testOIDCProvider) token(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims) string {
+	all := jwt.MapClaims{
+		"iss": p.server.URL,
+		"aud": "rclone",
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		if v == nil {
+			delete(all, k)
+		} else {
+			all[k] = v
+		}
+	}
+	token := jwt.NewWithClaims(method, all)
+	token.Header["kid"] = kid
+	var key any = p.rsaKey
+	if method == jwt.SigningMethodES256 {
+		key = p.ecKey
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestMiddlewareAuthOIDC(t *testing.T) {
+	p := newTestOIDCProvider(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": p.server.URL,
+		"aud": "rclone",
+		"sub": "mallory",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	forged.Header["kid"] = "rsa1"
+	forgedToken, err := forged.SignedString(otherKey)
+	require.NoError(t, err)
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": p.server.URL,
+		"aud": "rclone",
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		name   string
+		auth   AuthConfig
+		token  string
+		status int
+		result string
+	}{{
+		name:   "Missing",
+		status: http.StatusUnauthorized,
+	}, {
+		name:   "RSA",
+		token:  p.token(t, jwt.SigningMethodRS256, "rsa1", nil),
+		status: http.StatusOK,
+		result: "alice",
+	}, {
+		name:   "EC",
+		token:  p.token(t, jwt.SigningMethodES256, "ec1", nil),
+		status: http.StatusOK,
+		result: "alice",
+	}, {
+		name:   "AudienceList",
+		token:  p.token(t, jwt.SigningMethodRS256, "rsa1", jwt.MapClaims{"aud": []string{"other", "rclone"}}),
+		status: http.StatusOK,
+		result: "alice",
+	}, {
+		name:   "UserClaim",
+		auth:   AuthConfig{OIDCUserClaim: "email"},
+		token:  p.token(t, jwt.SigningMethodRS256, "rsa1", jwt.MapClaims{"email": "alice@example.com"}),
+		status: http.StatusOK,
+		result: "alice@example.com",
+	}, {
+		name:   "MissingUserClaim",
+		auth:   AuthConfig{OIDCUserClaim: "email"},
+		token:  p.token(t, jwt.SigningMethodRS256, "rsa1", nil),
+		status: http.StatusUnauthorized,
+	}, {
+		name:   "BadUserName",
+		token:  p.token(t, jwt.SigningMethodRS256, "rsa1", jwt.MapClaims{"sub": "../etc"}),
+		status: http.StatusUnauthorized,
+	}, {
+		name:   "WrongAudience",
+		token:  p.token(t, jwt.SigningMethodRS256, "rsa1", jwt.MapClaims{"aud": "other"}),
+		status: http.StatusUnauthorized,
+	}, {
+		name:   "WrongIssuer",
+		token:  p.token(t, jwt.SigningMethodRS256, "rsa1", jwt.MapClaims{"iss": "https://evil.example.com"}),
+		status: http.StatusUnauthorized,
+	}, {
+		name:   "Expired",
+		token:  p.token(t, jwt.SigningMethodRS256, "rsa1", jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}),
+		status: http.StatusUnauthorized,
+	}, {
+		name:   "NoExpiry",
+		token:  p.token(t, jwt.SigningMethodRS256, "rsa1", jwt.MapClaims{"exp": nil}),
+		status: http.StatusUnauthorized,
+	}, {
+		name:   "UnknownKey",
+		token:  p.token(t, jwt.SigningMethodRS256, "rsa2", nil),
+		status: http.StatusUnauthorized,
+	}, {
+		name:   "Forged",
+		token:  forgedToken,
+		status: http.StatusUnauthorized,
+	}, {
+		name:   "HMAC",
+		token:  hmacToken,
+		status: http.StatusUnauthorized,
+	}, {
+		name: "CustomAuth",
+		auth: AuthConfig{
+			CustomAuthFn: func(user, pass string) (value any, err error) {
+				if user == "alice" && pass == "" {
+					return "alice's VFS", nil
+				}
+				return nil, errors.New("invalid credentials")
+			},
+		},
+		token:  p.token(t, jwt.SigningMethodRS256, "rsa1", nil),
+		status: http.StatusOK,
+		result: "alice",
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			auth := test.auth
+			auth.Realm = "test"
+			auth.OIDCIssuer = p.server.URL
+			auth.OIDCAudience = "rclone"
+			s, err := NewServer(context.Background(), WithConfig(Config{ListenAddr: []string{"127.0.0.1:0"}}), WithAuth(auth))
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, s.Shutdown())
+			}()
+			s.Router().Mount("/", testAuthUserHandler())
+			s.Serve()
+
+			req, err := http.NewRequest("GET", testGetServerURL(t, s), nil)
+			require.NoError(t, err)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			require.Equal(t, test.status, resp.StatusCode)
+			if test.status == http.StatusOK {
+				testExpectRespBody(t, resp, []byte(test.result))
+			} else {
+				assert.Contains(t, resp.Header.Get("WWW-Authenticate"), `Bearer realm="test"`)
+			}
+		})
+	}
+}
+
+func TestOIDCVerifierKeyRefresh(t *testing.T) {
+	p := newTestOIDCProvider(t)
+	v := newOIDCVerifier(p.server.URL, "rclone", "")
+	ctx := context.Background()
+
+	_, err := v.verify(ctx, p.token(t, jwt.SigningMethodRS256, "rsa1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), p.fetches.Load())
+
+	// Known keys don't cause a fetch
+	_, err = v.verify(ctx, p.token(t, jwt.SigningMethodES256, "ec1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), p.fetches.Load())
+
+	// Unknown keys only cause a fetch every oidcMinKeyRefresh
+	for range 3 {
+		_, err = v.verify(ctx, p.token(t, jwt.SigningMethodRS256, "unknown", nil))
+		require.Error(t, err)
+	}
+	assert.Equal(t, int32(1), p.fetches.Load())
+	v.refreshed = time.Now().Add(-oidcMinKeyRefresh)
+	_, err = v.verify(ctx, p.token(t, jwt.SigningMethodRS256, "unknown", nil))
+	require.Error(t, err)
+	assert.Equal(t, int32(2), p.fetches.Load())
+}
+
+func TestNewServerAuthErrors(t *testing.T) {
+	cfg := Config{ListenAddr: []string{"127.0.0.1:0"}}
+	for _, test := range []struct {
+		auth AuthConfig
+		want error
+	}{
+		{AuthConfig{ClientCertUser: "potato"}, ErrInvalidClientCertUser},
+		{AuthConfig{OIDCIssuer: "https://example.com", OIDCAudience: "rclone", BasicUser: "user"}, ErrOIDCWithBasicAuth},
+		{AuthConfig{OIDCIssuer: "https://example.com"}, ErrOIDCAudienceMissing},
+	} {
+		_, err := NewServer(context.Background(), WithConfig(cfg), WithAuth(test.auth))
+		assert.ErrorIs(t, err, test.want)
+	}
+}
@@ -0,0 +1,83 @@
+package serve
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs"
+)
+
+// archiveFormat describes a format directories can be downloaded in
+type archiveFormat struct {
+	ext      string
+	mimeType string
+	create   func(ctx context.Context, dir *vfs.Dir, w io.Writer) error
+}
+
+// archiveFormats are the formats which can be asked for with ?download=
+var archiveFormats = map[string]archiveFormat{
+	"zip": {
+		ext:      ".zip",
+		mimeType: "application/zip",
+		create:   vfs.CreateZip,
+	},
+	"tar": {
+		ext:      ".tar",
+		mimeType: "application/x-tar",
+		create:   vfs.CreateTar,
+	},
+	"tar.gz": {
+		ext:      ".tar.gz",
+		mimeType: "application/gzip",
+		create:   createTarGz,
+	},
+}
+
+// createTarGz creates a gzipped tar file from dir writing it to w
+func createTarGz(ctx context.Context, dir *vfs.Dir, w io.Writer) (err error) {
+	gzipWriter := gzip.NewWriter(w)
+	defer fs.CheckClose(gzipWriter, &err)
+	return vfs.CreateTar(ctx, dir, gzipWriter)
+}
+
+// IsArchiveRequest returns true if r asks for a directory to be
+// downloaded as an archive with ?download=zip, tar or tar.gz
+func IsArchiveRequest(r *http.Request) bool {
+	_, ok := archiveFormats[r.URL.Query().Get("download")]
+	return ok
+}
+
+// Archive streams dir at dirRemote as the archive asked for by
+// ?download=zip, tar or tar.gz in r.
+//
+// The archive is built on the fly from the VFS so nothing is staged
+// locally. Use IsArchiveRequest to see if r asks for an archive first.
+func Archive(w http.ResponseWriter, r *http.Request, dirRemote string, dir *vfs.Dir) {
+	ctx := r.Context()
+	format, ok := archiveFormats[r.URL.Query().Get("download")]
+	if !ok {
+		http.Error(w, "Unknown archive format", http.StatusBadRequest)
+		return
+	}
+	name := path.Base(dirRemote)
+	if dirRemote == "" {
+		name = "root"
+	}
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+format.ext+"\"")
+	w.Header().Set("Content-Type", format.mimeType)
+	w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+	if r.Method == "HEAD" {
+		return
+	}
+	fs.Infof(dirRemote, "%s: Creating %s of directory", r.RemoteAddr, format.ext)
+	err := format.create(ctx, dir, w)
+	if err != nil {
+		Error(ctx, dirRemote, w, "Failed to create "+format.ext, err)
+		return
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"net"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +20,7 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/lib/atexit"
+	"github.com/rclone/rclone/lib/listen"
 	sdActivation "github.com/rclone/rclone/lib/sdactivation"
 	"github.com/spf13/pflag"
 )
@@ -59,7 +59,7 @@ inserts leading and trailing "/" on ` + "`--{{ .Prefix }}baseurl`" + `, so ` + "
 ` + "`--{{ .Prefix }}baseurl \"/rclone\"` and `--{{ .Prefix }}baseurl \"/rclone/\"`" + ` are all treated
 identically.
 
-` + "`--{{ .Prefix }}disable-zip`" + ` may be set to disable the zipping download option.
+` + "`--{{ .Prefix }}disable-zip`" + ` may be set to disable the zip and tar download options.
 
 #### TLS (SSL)
 
@@ -231,6 +231,8 @@ type Server struct {
 	tlsConfig    *tls.Config
 	instances    []instance
 	auth         AuthConfig
+	auditCfg     AuditConfig
+	audit        *auditLog // nil if not auditing requests
 	cfg          Config
 	template     *TemplateConfig
 	htmlTemplate *template.Template
@@ -293,7 +295,7 @@ func newInstance(ctx context.Context, s *Server, listener net.Listener, tlsCfg *
 // This function is provided if the default http server does not meet a services requirements and should not generally be used
 // A http server can listen using multiple listeners. For example, a listener for port 80, and a listener for port 443.
 // tlsListeners are ignored if opt.TLSKey is not provided
-func NewServer(ctx context.Context, options ...Option) (*Server, error) {
+func NewServer(ctx context.Context, options ...Option) (_ *Server, err error) {
 	s := &Server{
 		mux: chi.NewRouter(),
 		cfg: DefaultCfg(),
@@ -308,6 +310,21 @@ func NewServer(ctx context.Context, options ...Option) (*Server, error) {
 		opt(s)
 	}
 
+	// Record every request in the audit log, including the ones
+	// rejected by the middleware below
+	s.audit, err = newAuditLog(s.auditCfg)
+	if err != nil {
+		return nil, err
+	}
+	if s.audit != nil {
+		defer func() {
+			if err != nil {
+				_ = s.audit.Close()
+			}
+		}()
+		s.mux.Use(s.audit.middleware())
+	}
+
 	// Build base router
 	s.mux.MethodNotAllowed(func(w http.ResponseWriter, _ *http.Request) {
 		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
@@ -323,7 +340,7 @@ func NewServer(ctx context.Context, options ...Option) (*Server, error) {
 		s.mux.Use(MiddlewareStripPrefix(s.cfg.BaseURL))
 	}
 
-	err := s.initTemplate()
+	err = s.initTemplate()
 	if err != nil {
 		return nil, err
 	}
@@ -335,7 +352,10 @@ func NewServer(ctx context.Context, options ...Option) (*Server, error) {
 
 	s.mux.Use(MiddlewareCORS(s.cfg.AllowOrigin))
 
-	s.initAuth()
+	err = s.initAuth()
+	if err != nil {
+		return nil, err
+	}
 
 	// (Only) listen on FDs provided by the service manager, if any.
 	sdListeners, err := sdActivation.ListenersWithNames()
@@ -364,8 +384,8 @@ func NewServer(ctx context.Context, options ...Option) (*Server, error) {
 	for _, addr := range s.cfg.ListenAddr {
 		var instance *instance
 
-		if strings.HasPrefix(addr, "unix://") || filepath.IsAbs(addr) {
-			addr = strings.TrimPrefix(addr, "unix://")
+		if path, ok := listen.UnixPath(addr); ok {
+			addr = path
 
 			listener, err := net.Listen("unix", addr)
 			if err != nil {
@@ -396,16 +416,32 @@ func NewServer(ctx context.Context, options ...Option) (*Server, error) {
 	return s, nil
 }
 
-func (s *Server) initAuth() {
+func (s *Server) initAuth() error {
 	s.usingAuth = false
 	altUsernameEnabled := s.auth.HtPasswd == "" && s.auth.BasicUser == ""
 
+	switch s.auth.ClientCertUser {
+	case "", "cn", "dn", "email", "uri":
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidClientCertUser, s.auth.ClientCertUser)
+	}
+	if s.auth.OIDCIssuer != "" {
+		if !altUsernameEnabled {
+			return ErrOIDCWithBasicAuth
+		}
+		if s.auth.OIDCAudience == "" {
+			return ErrOIDCAudienceMissing
+		}
+	}
+
 	if altUsernameEnabled {
 		s.usingAuth = true
-		if s.auth.UserFromHeader != "" {
+		if s.auth.OIDCIssuer != "" {
+			s.mux.Use(MiddlewareAuthOIDC(s.auth.OIDCIssuer, s.auth.OIDCAudience, s.auth.OIDCUserClaim, s.auth.Realm))
+		} else if s.auth.UserFromHeader != "" {
 			s.mux.Use(MiddlewareAuthGetUserFromHeader(s.auth.UserFromHeader))
 		} else if s.tlsConfig != nil && s.tlsConfig.ClientAuth != tls.NoClientCert {
-			s.mux.Use(MiddlewareAuthCertificateUser())
+			s.mux.Use(MiddlewareAuthCertificateField(s.auth.ClientCertUser))
 		} else {
 			s.usingAuth = false
 			altUsernameEnabled = false
@@ -415,20 +451,21 @@ func (s *Server) initAuth() {
 	if s.auth.CustomAuthFn != nil {
 		s.usingAuth = true
 		s.mux.Use(MiddlewareAuthCustom(s.auth.CustomAuthFn, s.auth.Realm, altUsernameEnabled))
-		return
+		return nil
 	}
 
 	if s.auth.HtPasswd != "" {
 		s.usingAuth = true
 		s.mux.Use(MiddlewareAuthHtpasswd(s.auth.HtPasswd, s.auth.Realm))
-		return
+		return nil
 	}
 
 	if s.auth.BasicUser != "" {
 		s.usingAuth = true
 		s.mux.Use(MiddlewareAuthBasic(s.auth.BasicUser, s.auth.BasicPass, s.auth.Realm, s.auth.Salt))
-		return
+		return nil
 	}
+	return nil
 }
 
 func (s *Server) initTemplate() error {
@@ -454,6 +491,12 @@ var (
 	ErrTLSFileMismatch = errors.New("need both --cert and --key to use TLS")
 	// ErrTLSParseCA - hard coded errors, allowing for easier testing
 	ErrTLSParseCA = errors.New("unable to parse client certificate authority")
+	// ErrInvalidClientCertUser - hard coded errors, allowing for easier testing
+	ErrInvalidClientCertUser = errors.New("invalid value for --client-cert-user, need cn, dn, email or uri")
+	// ErrOIDCWithBasicAuth - hard coded errors, allowing for easier testing
+	ErrOIDCWithBasicAuth = errors.New("can't use --oidc-issuer with --htpasswd or --user")
+	// ErrOIDCAudienceMissing - hard coded errors, allowing for easier testing
+	ErrOIDCAudienceMissing = errors.New("need --oidc-audience to use --oidc-issuer")
 )
 
 func (s *Server) initTLS() error {
@@ -564,6 +607,9 @@ func (s *Server) Shutdown() error {
 		cancel()
 	}
 	s.wg.Wait()
+	if s.audit != nil {
+		return s.audit.Close()
+	}
 	return nil
 }
 
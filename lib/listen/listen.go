@@ -0,0 +1,63 @@
+// Package listen makes the network listeners for rclone's servers
+// from addresses which may be Unix domain sockets, or from the
+// sockets passed in by systemd socket activation.
+package listen
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/sdactivation"
+)
+
+// UnixPath returns the path of the socket and true if addr is a Unix
+// domain socket address, either unix:///path/to/socket or just an
+// absolute path.
+func UnixPath(addr string) (string, bool) {
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		return path, true
+	}
+	if filepath.IsAbs(addr) {
+		return addr, true
+	}
+	return "", false
+}
+
+// Listen listens on addr which may be a Unix domain socket as
+// described in UnixPath, otherwise it listens on network.
+func Listen(network, addr string) (net.Listener, error) {
+	if path, ok := UnixPath(addr); ok {
+		return net.Listen("unix", path)
+	}
+	return net.Listen(network, addr)
+}
+
+// Activated returns the first listener passed in by systemd socket
+// activation or nil if there aren't any.
+func Activated() (net.Listener, error) {
+	listeners, err := sdactivation.Listeners()
+	if err != nil {
+		return nil, fmt.Errorf("unable to acquire listeners: %w", err)
+	}
+	if len(listeners) == 0 {
+		return nil, nil
+	}
+	if len(listeners) > 1 {
+		fs.Logf(nil, "More than one listener passed, ignoring all but the first")
+	}
+	return listeners[0], nil
+}
+
+// ActivatedOrListen returns the listener passed in by systemd socket
+// activation if there is one, otherwise it listens on addr as Listen
+// does.
+func ActivatedOrListen(network, addr string) (net.Listener, error) {
+	listener, err := Activated()
+	if err != nil || listener != nil {
+		return listener, err
+	}
+	return Listen(network, addr)
+}
@@ -0,0 +1,72 @@
+package listen
+
+import (
+	"net"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnixPath(t *testing.T) {
+	abs, err := filepath.Abs("socket")
+	require.NoError(t, err)
+	for _, test := range []struct {
+		addr   string
+		want   string
+		wantOK bool
+	}{
+		{"unix:///run/rclone.sock", "/run/rclone.sock", true},
+		{abs, abs, true},
+		{"localhost:8080", "", false},
+		{":8080", "", false},
+		{"[::1]:8080", "", false},
+	} {
+		got, ok := UnixPath(test.addr)
+		assert.Equal(t, test.wantOK, ok, test.addr)
+		assert.Equal(t, test.want, got, test.addr)
+	}
+}
+
+func TestListen(t *testing.T) {
+	t.Run("TCP", func(t *testing.T) {
+		l, err := Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		assert.Equal(t, "tcp", l.Addr().Network())
+		require.NoError(t, l.Close())
+	})
+	t.Run("Unix", func(t *testing.T) {
+		if runtime.GOOS == "plan9" {
+			t.Skip("no unix sockets on plan9")
+		}
+		path := filepath.Join(t.TempDir(), "rclone.sock")
+		l, err := Listen("tcp", "unix://"+path)
+		require.NoError(t, err)
+		defer func() { _ = l.Close() }()
+		assert.Equal(t, "unix", l.Addr().Network())
+		go func() {
+			conn, err := l.Accept()
+			if err == nil {
+				_, _ = conn.Write([]byte("hello"))
+				_ = conn.Close()
+			}
+		}()
+		conn, err := net.Dial("unix", path)
+		require.NoError(t, err)
+		buf := make([]byte, 5)
+		_, err = conn.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(buf))
+		require.NoError(t, conn.Close())
+	})
+	t.Run("NotActivated", func(t *testing.T) {
+		t.Setenv("LISTEN_PID", "")
+		t.Setenv("LISTEN_FDS", "")
+		l, err := ActivatedOrListen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		assert.Equal(t, "tcp", l.Addr().Network())
+		require.NoError(t, l.Close())
+	})
+}
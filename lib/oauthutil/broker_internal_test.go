@@ -0,0 +1,75 @@
+package oauthutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrokerAllowed(t *testing.T) {
+	allow := []string{"alice=gdrive,onedrive", "bob=*"}
+	assert.False(t, brokerAllowed(nil, "anyone", "gdrive"))
+	assert.True(t, brokerAllowed(allow, "alice", "gdrive"))
+	assert.True(t, brokerAllowed(allow, "alice", "onedrive"))
+	assert.False(t, brokerAllowed(allow, "alice", "box"))
+	assert.True(t, brokerAllowed(allow, "bob", "box"))
+	assert.False(t, brokerAllowed(allow, "carol", "gdrive"))
+	assert.False(t, brokerAllowed(allow, "", "gdrive"))
+}
+
+func TestBrokerTokenDisabled(t *testing.T) {
+	oldAllow := BrokerOpt.Allow
+	BrokerOpt.Allow = nil
+	defer func() { BrokerOpt.Allow = oldAllow }()
+	_, err := rcBrokerToken(context.Background(), rc.Params{"fs": "gdrive:"})
+	assert.ErrorContains(t, err, "--oauth-broker-allow isn't set")
+}
+
+func TestRemoteName(t *testing.T) {
+	assert.Equal(t, "gdrive", remoteName("gdrive"))
+	assert.Equal(t, "gdrive", remoteName("gdrive{AbCdE}"))
+}
+
+func TestBrokerTokenSource(t *testing.T) {
+	ctx := context.Background()
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "/oauth/token", r.URL.Path)
+		var in map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		assert.Equal(t, "gdrive", in["fs"])
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"token": map[string]any{
+				"access_token": "ACCESS",
+				"token_type":   "Bearer",
+				"expiry":       expiry,
+			},
+		})
+	}))
+	defer ts.Close()
+
+	bts, err := newBrokerTokenSource(ctx, "gdrive{AbCdE}", "http://user:pass@"+ts.Listener.Addr().String()+"/")
+	require.NoError(t, err)
+	token, err := bts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "ACCESS", token.AccessToken)
+	assert.Equal(t, "", token.RefreshToken)
+	assert.True(t, expiry.Equal(token.Expiry))
+
+	bts, err = newBrokerTokenSource(ctx, "gdrive", ts.URL)
+	require.NoError(t, err)
+	_, err = bts.Token()
+	assert.ErrorContains(t, err, "token broker")
+}
@@ -0,0 +1,257 @@
+// Headless OAuth for configuring remotes over the rc
+
+package oauthutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/lib/random"
+)
+
+// webAuthWaitState is the config state waiting for a headless auth -
+// the ID of the auth is appended to it
+const webAuthWaitState = "*oauth-web-wait-"
+
+var (
+	// webAuthTimeout is how long a headless auth waits to be completed
+	webAuthTimeout = 10 * time.Minute
+	// webAuthPoll is how long a config call waits for the browser to
+	// reach the auth server before asking the question again
+	webAuthPoll = 30 * time.Second
+)
+
+// Headless auths in progress by ID
+var (
+	webAuthsMu sync.Mutex
+	webAuths   = map[string]*webAuth{}
+)
+
+// webAuth is a headless auth in progress.
+//
+// The user visits the URL on any device. If the browser can reach
+// the auth server it collects the code, otherwise the user pastes
+// the address the browser finished on.
+type webAuth struct {
+	id      string
+	state   string      // random state the code must be returned with
+	authURL string      // URL for the user to visit
+	qrPNG   string      // QR code of authURL as a data: URL
+	qrText  string      // QR code of authURL for a terminal
+	server  *authServer // nil if not running
+	timer   *time.Timer // removes the auth when it expires
+	got     chan struct{}
+	mu      sync.Mutex // protects the following
+	result  *AuthResult
+}
+
+// newWebAuth starts a headless auth for the remote name
+func newWebAuth(name string, m configmap.Mapper, oauthConfig *Config, opt *Options) (w *webAuth, err error) {
+	authURL, state, err := getAuthURL(name, m, oauthConfig, opt)
+	if err != nil {
+		return nil, err
+	}
+	w = &webAuth{
+		id:      random.String(16),
+		state:   state,
+		authURL: authURL,
+		got:     make(chan struct{}),
+	}
+	w.qrText, w.qrPNG, err = qrCode(authURL)
+	if err != nil {
+		// The URL can still be used without the QR code
+		fs.Debugf(nil, "Failed to make QR code for auth URL: %v", err)
+	}
+	if !noWebserverNeeded(oauthConfig) {
+		server := newAuthServer(opt, bindAddress, state, authURL)
+		err = server.Init()
+		if err != nil {
+			// The user can still paste the address the browser finishes on
+			fs.Logf(nil, "Not starting auth webserver so the code will need to be pasted: %v", err)
+		} else {
+			w.server = server
+			go server.Serve()
+			go w.collect()
+		}
+	}
+	webAuthsMu.Lock()
+	webAuths[w.id] = w
+	webAuthsMu.Unlock()
+	w.timer = time.AfterFunc(webAuthTimeout, func() {
+		fs.Debugf(nil, "Headless auth for %q timed out", name)
+		w.close()
+	})
+	fs.Debugf(nil, "Started headless auth %s for %q", w.id, name)
+	return w, nil
+}
+
+// findWebAuth returns the headless auth with id or nil if not found
+func findWebAuth(id string) *webAuth {
+	webAuthsMu.Lock()
+	defer webAuthsMu.Unlock()
+	return webAuths[id]
+}
+
+// collect reads the results from the auth server, keeping the first
+// good one, until the server is stopped
+func (w *webAuth) collect() {
+	for res := range w.server.result {
+		w.setResult(res)
+	}
+}
+
+// setResult sets the result of the auth unless it already has a good one
+func (w *webAuth) setResult(res *AuthResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.result != nil && w.result.OK {
+		return
+	}
+	if w.result == nil {
+		close(w.got)
+	}
+	w.result = res
+}
+
+// wait returns the result of the auth.
+//
+// If pasted is set then it is the address the browser finished on or
+// the code it showed. Otherwise this waits a short while for the
+// auth server to collect the code and returns nil if it hasn't.
+//
+// w may be nil if the auth has timed out or was started by another
+// process in which case the state can't be checked.
+func (w *webAuth) wait(ctx context.Context, pasted string) (*AuthResult, error) {
+	if pasted != "" {
+		form := url.Values{}
+		if u, err := url.Parse(pasted); err == nil && u.RawQuery != "" {
+			form = u.Query()
+		} else {
+			form.Set("code", pasted)
+		}
+		wantState := form.Get("state")
+		if w != nil && wantState != "" {
+			wantState = w.state
+		}
+		_, res := authResultFromForm(form, wantState, true)
+		return res, nil
+	}
+	timer := time.NewTimer(webAuthPoll)
+	defer timer.Stop()
+	select {
+	case <-w.got:
+	case <-timer.C:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.result, nil
+}
+
+// question asks the user to visit the auth URL, showing errorText if set
+func (w *webAuth) question(state string, errorText string) (*fs.ConfigOut, error) {
+	var help strings.Builder
+	fmt.Fprintf(&help, "Authorize rclone by going to this URL in a web browser on any device.\n\n%s\n\n", w.authURL)
+	if w.qrText != "" {
+		fmt.Fprintf(&help, "Or scan this QR code.\n\n%s\n", w.qrText)
+	}
+	fmt.Fprintf(&help, `If the browser finishes on a page saying "Success!" then leave this
+blank. Otherwise paste the address of the page the browser finished
+on, even if it failed to load, or the code it showed.
+`)
+	out, err := fs.ConfigInputOptional(state, "config_redirect_url", help.String())
+	if err != nil {
+		return nil, err
+	}
+	out.Error = errorText
+	out.URL = w.authURL
+	out.QRCode = w.qrPNG
+	return out, nil
+}
+
+// close stops the auth server and forgets the auth
+func (w *webAuth) close() {
+	if w == nil {
+		return
+	}
+	webAuthsMu.Lock()
+	_, found := webAuths[w.id]
+	delete(webAuths, w.id)
+	webAuthsMu.Unlock()
+	if !found {
+		return
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	if w.server != nil {
+		w.server.Stop()
+	}
+}
+
+// qrCode encodes s as a QR code, returning it as text to show in a
+// terminal and as a PNG data: URL to show in a web page
+func qrCode(s string) (text, dataURL string, err error) {
+	code, err := qr.Encode(s, qr.M, qr.Auto)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Use half blocks so each line of text is two rows of the code,
+	// inverted so it shows on a dark terminal, with a quiet zone
+	const quiet = 2
+	size := code.Bounds().Dx()
+	dark := func(x, y int) bool {
+		if x < 0 || y < 0 || x >= size || y >= size {
+			return false
+		}
+		r, _, _, _ := code.At(x, y).RGBA()
+		return r < 0x8000
+	}
+	var out strings.Builder
+	for y := -quiet; y < size+quiet; y += 2 {
+		for x := -quiet; x < size+quiet; x++ {
+			switch top, bottom := dark(x, y), dark(x, y+1); {
+			case top && bottom:
+				out.WriteRune(' ')
+			case top:
+				out.WriteRune('▄')
+			case bottom:
+				out.WriteRune('▀')
+			default:
+				out.WriteRune('█')
+			}
+		}
+		out.WriteRune('\n')
+	}
+
+	const scale = 8
+	scaled, err := barcode.Scale(code, size*scale, size*scale)
+	if err != nil {
+		return "", "", err
+	}
+	img := image.NewGray(image.Rect(0, 0, (size+2*quiet)*scale, (size+2*quiet)*scale))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, scaled.Bounds().Add(image.Pt(quiet*scale, quiet*scale)), scaled, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
+	if err != nil {
+		return "", "", err
+	}
+	return out.String(), "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,131 @@
+package oauthutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headlessRegInfo returns a backend which does OAuth against ts
+func headlessRegInfo(ts *httptest.Server) *fs.RegInfo {
+	return &fs.RegInfo{
+		Name: "headless",
+		Config: func(ctx context.Context, name string, m configmap.Mapper, in fs.ConfigIn) (*fs.ConfigOut, error) {
+			return ConfigOut("", &Options{
+				OAuth2Config: &Config{
+					ClientID:    "CLIENT",
+					AuthURL:     ts.URL + "/auth",
+					TokenURL:    ts.URL + "/token",
+					RedirectURL: TitleBarRedirectURL,
+				},
+			})
+		},
+	}
+}
+
+func TestConfigOAuthHeadless(t *testing.T) {
+	ctx := fs.ConfigHeadless(context.Background())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "/token", r.URL.Path)
+		assert.Equal(t, "CODE", r.Form.Get("code"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"ACCESS","refresh_token":"REFRESH","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer ts.Close()
+	ri := headlessRegInfo(ts)
+	m := configmap.Simple{}
+
+	// The first question gives the URL and QR code to visit
+	out, err := fs.BackendConfig(ctx, "test", m, ri, configmap.Simple{}, fs.ConfigIn{})
+	require.NoError(t, err)
+	require.NotNil(t, out.Option)
+	assert.Equal(t, "config_redirect_url", out.Option.Name)
+	assert.True(t, strings.HasPrefix(out.URL, ts.URL+"/auth?"))
+	assert.Contains(t, out.Option.Help, out.URL)
+	assert.True(t, strings.HasPrefix(out.QRCode, "data:image/png;base64,"))
+	u, err := url.Parse(out.URL)
+	require.NoError(t, err)
+	state := u.Query().Get("state")
+
+	// A mismatched state asks again
+	again, err := fs.BackendConfig(ctx, "test", m, ri, configmap.Simple{}, fs.ConfigIn{State: out.State, Result: "http://localhost/?code=CODE&state=wrong"})
+	require.NoError(t, err)
+	assert.Equal(t, out.State, again.State)
+	assert.Contains(t, again.Error, "state doesn't match")
+
+	// Pasting the address the browser finished on gets the token
+	done, err := fs.BackendConfig(ctx, "test", m, ri, configmap.Simple{}, fs.ConfigIn{State: out.State, Result: "http://localhost/?code=CODE&state=" + url.QueryEscape(state)})
+	require.NoError(t, err)
+	assert.Equal(t, "", done.State)
+	token, err := GetToken("test", m)
+	require.NoError(t, err)
+	assert.Equal(t, "ACCESS", token.AccessToken)
+	_, found := m.Get(config.ConfigToken)
+	assert.True(t, found)
+
+	// The auth is finished with
+	id, _ := strings.CutPrefix(strings.SplitN(out.State, ",", 2)[0], webAuthWaitState)
+	assert.Nil(t, findWebAuth(id))
+}
+
+func TestWebAuthWait(t *testing.T) {
+	oldPoll := webAuthPoll
+	webAuthPoll = 10 * time.Millisecond
+	defer func() { webAuthPoll = oldPoll }()
+	ctx := context.Background()
+	w := &webAuth{state: "STATE", got: make(chan struct{})}
+
+	// Nothing received yet
+	auth, err := w.wait(ctx, "")
+	require.NoError(t, err)
+	assert.Nil(t, auth)
+
+	// Received from the auth server - only the first good result is kept
+	w.setResult(&AuthResult{Name: "Auth Error"})
+	w.setResult(&AuthResult{OK: true, Code: "CODE"})
+	w.setResult(&AuthResult{OK: true, Code: "CODE2"})
+	auth, err = w.wait(ctx, "")
+	require.NoError(t, err)
+	assert.True(t, auth.OK)
+	assert.Equal(t, "CODE", auth.Code)
+
+	// Pasted code
+	auth, err = w.wait(ctx, "PASTED")
+	require.NoError(t, err)
+	assert.True(t, auth.OK)
+	assert.Equal(t, "PASTED", auth.Code)
+
+	// Pasted error
+	auth, err = w.wait(ctx, "http://127.0.0.1:53682/?error=access_denied&state=STATE")
+	require.NoError(t, err)
+	assert.False(t, auth.OK)
+	assert.Contains(t, auth.Description, "access_denied")
+
+	// The state can't be checked once the auth has gone
+	w = nil
+	auth, err = w.wait(ctx, "http://127.0.0.1:53682/?code=CODE&state=OTHER")
+	require.NoError(t, err)
+	assert.True(t, auth.OK)
+}
+
+func TestQRCode(t *testing.T) {
+	text, dataURL, err := qrCode("https://example.com/auth?state=potato")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	assert.Greater(t, len(lines), 10)
+	for _, line := range lines {
+		assert.Equal(t, len([]rune(lines[0])), len([]rune(line)))
+	}
+	assert.True(t, strings.HasPrefix(dataURL, "data:image/png;base64,"))
+}
@@ -0,0 +1,123 @@
+package oauthutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestDeviceCodeFlowGetToken(t *testing.T) {
+	ctx := context.Background()
+	polls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/device":
+			assert.Equal(t, "CLIENT", r.Form.Get("client_id"))
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"device_code":      "DEVICE",
+				"user_code":        "ABCD-EFGH",
+				"verification_uri": "https://example.com/device",
+				"expires_in":       60,
+				"interval":         1,
+			})
+		case "/token":
+			assert.Equal(t, "DEVICE", r.Form.Get("device_code"))
+			polls++
+			if polls == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"authorization_pending"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"ACCESS","refresh_token":"REFRESH","token_type":"Bearer","expires_in":3600}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	oauthConfig := &Config{
+		ClientID:      "CLIENT",
+		TokenURL:      ts.URL + "/token",
+		DeviceAuthURL: ts.URL + "/device",
+	}
+	m := configmap.Simple{}
+	err := deviceCodeFlowGetToken(ctx, "test", m, oauthConfig, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, polls)
+	token, err := GetToken("test", m)
+	require.NoError(t, err)
+	assert.Equal(t, "ACCESS", token.AccessToken)
+	assert.Equal(t, "REFRESH", token.RefreshToken)
+	_, found := m.Get(config.ConfigToken)
+	assert.True(t, found)
+}
+
+func TestTokenSourceRefresh(t *testing.T) {
+	ctx := context.Background()
+	refreshes := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "refresh_token", r.Form.Get("grant_type"))
+		assert.Equal(t, "REFRESH", r.Form.Get("refresh_token"))
+		refreshes++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"ACCESS2","refresh_token":"REFRESH","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	m := configmap.Simple{}
+	expiry := time.Now().Add(2 * time.Minute).Round(time.Second)
+	require.NoError(t, PutToken("test", m, &oauth2.Token{
+		AccessToken:  "ACCESS1",
+		RefreshToken: "REFRESH",
+		TokenType:    "Bearer",
+		Expiry:       expiry,
+	}, false))
+	_, tokenSource, err := NewClient(ctx, "test", m, &Config{
+		ClientID: "CLIENT",
+		TokenURL: ts.URL,
+	})
+	require.NoError(t, err)
+	assert.True(t, expiry.Equal(tokenSource.Expiry()))
+
+	// The token is still valid so isn't refreshed
+	token, err := tokenSource.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "ACCESS1", token.AccessToken)
+	assert.Equal(t, 0, refreshes)
+
+	// Refresh gets a new token even though the one in the config is valid
+	require.NoError(t, tokenSource.Refresh())
+	assert.Equal(t, 1, refreshes)
+	assert.True(t, tokenSource.Expiry().After(expiry))
+	token, err = GetToken("test", m)
+	require.NoError(t, err)
+	assert.Equal(t, "ACCESS2", token.AccessToken)
+}
+
+func TestConfigOAuthDeviceCodeDefault(t *testing.T) {
+	ctx := context.Background()
+	ri := &fs.RegInfo{
+		Config: func(ctx context.Context, name string, m configmap.Mapper, in fs.ConfigIn) (*fs.ConfigOut, error) {
+			return &fs.ConfigOut{OAuth: &Options{OAuth2Config: &Config{DeviceAuthURL: "https://example.com/device"}}}, nil
+		},
+	}
+	state := fs.StatePush("", "*oauth-islocal", "", "", "")
+	out, err := ConfigOAuth(ctx, "remote", configmap.Simple{}, ri, fs.ConfigIn{State: state, Result: "false"})
+	require.NoError(t, err)
+	require.NotNil(t, out.Option)
+	assert.Equal(t, "config_device_code", out.Option.Name)
+	assert.Equal(t, false, out.Option.Default, "device code must not be the default so config_token still works")
+}
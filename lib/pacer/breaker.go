@@ -0,0 +1,236 @@
+package pacer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned (wrapped) by Call when the circuit
+// breaker for the endpoint is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+// Circuit breaker states
+const (
+	BreakerClosed   BreakerState = iota // calls are allowed
+	BreakerOpen                         // calls fail fast until the cool-down has passed
+	BreakerHalfOpen                     // one trial call is allowed to see if the endpoint has recovered
+)
+
+// String turns a BreakerState into a string
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("BreakerState(%d)", int(s))
+}
+
+// Breaker is a circuit breaker which tracks the health of an
+// endpoint.
+//
+// After threshold consecutive failed calls it trips open and all
+// calls fail fast with ErrCircuitOpen for the cool-down period. After
+// that a single trial call is let through - if it succeeds the
+// breaker closes, otherwise it opens again for another cool-down.
+type Breaker struct {
+	mu          sync.Mutex
+	name        string        // name of the endpoint
+	threshold   int           // consecutive failures to trip open
+	cooldown    time.Duration // how long to stay open for
+	state       BreakerState
+	consecutive int       // consecutive failures
+	openUntil   time.Time // when the open state ends
+	trial       bool      // set if the half-open trial call is in progress
+	lastError   error     // last failure
+	successes   int64     // total successful calls
+	failures    int64     // total failed calls
+	trips       int64     // number of times the breaker opened
+	rejected    int64     // number of calls failed fast
+}
+
+// BreakerStats is a snapshot of the state of a Breaker
+type BreakerStats struct {
+	Name                string    `json:"name"`
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	OpenUntil           time.Time `json:"openUntil"`
+	LastError           string    `json:"lastError,omitempty"`
+	Successes           int64     `json:"successes"`
+	Failures            int64     `json:"failures"`
+	Trips               int64     `json:"trips"`
+	Rejected            int64     `json:"rejected"`
+}
+
+// NewBreaker makes a new circuit breaker for the endpoint called name
+// which opens for cooldown after threshold consecutive failures.
+func NewBreaker(name string, threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		name:      name,
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+	}
+}
+
+// now is the time source - overridden in the tests
+var now = time.Now
+
+// BreakerStateChanged is called if set when a breaker opens or closes
+// with err the last failure.
+var BreakerStateChanged func(name string, state BreakerState, err error)
+
+// Allow returns nil if a call may be made now or an error wrapping
+// ErrCircuitOpen and the last failure if not.
+//
+// If nil is returned then the result of the call must be passed to
+// Record.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if now().Before(b.openUntil) {
+			break
+		}
+		b.state = BreakerHalfOpen
+		b.trial = false
+		fallthrough
+	case BreakerHalfOpen:
+		if b.trial {
+			break
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+	b.rejected++
+	return fmt.Errorf("%w for %q until %v: %w", ErrCircuitOpen, b.name, b.openUntil.Format(time.TimeOnly), b.lastError)
+}
+
+// Record the result of a call allowed by Allow.
+//
+// failed should be set if the call failed in a way which indicates
+// the endpoint is unhealthy, with err the error returned.
+func (b *Breaker) Record(failed bool, err error) {
+	b.mu.Lock()
+	changed := false
+	trial := b.state == BreakerHalfOpen && b.trial
+	b.trial = false
+	if !failed {
+		b.successes++
+		b.consecutive = 0
+		if trial {
+			b.state = BreakerClosed
+			changed = true
+		}
+	} else {
+		b.failures++
+		b.consecutive++
+		b.lastError = err
+		if trial || (b.state == BreakerClosed && b.consecutive >= b.threshold) {
+			b.state = BreakerOpen
+			b.openUntil = now().Add(b.cooldown)
+			b.trips++
+			changed = true
+		}
+	}
+	state, lastError := b.state, b.lastError
+	b.mu.Unlock()
+	if changed && BreakerStateChanged != nil {
+		BreakerStateChanged(b.name, state, lastError)
+	}
+}
+
+// Reset closes the breaker and clears the consecutive failures
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = BreakerClosed
+	b.consecutive = 0
+	b.trial = false
+	b.openUntil = time.Time{}
+}
+
+// Stats returns a snapshot of the state of the breaker
+func (b *Breaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := BreakerStats{
+		Name:                b.name,
+		State:               b.state.String(),
+		ConsecutiveFailures: b.consecutive,
+		Successes:           b.successes,
+		Failures:            b.failures,
+		Trips:               b.trips,
+		Rejected:            b.rejected,
+	}
+	if b.state == BreakerOpen {
+		stats.OpenUntil = b.openUntil
+	}
+	if b.lastError != nil {
+		stats.LastError = b.lastError.Error()
+	}
+	return stats
+}
+
+// The breakers for each endpoint so the health of an endpoint is
+// shared between all the Pacers using it
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*Breaker{}
+)
+
+// GetBreaker returns the circuit breaker for the endpoint called name
+// making it if necessary.
+//
+// The threshold and cooldown of an existing breaker are updated.
+func GetBreaker(name string, threshold int, cooldown time.Duration) *Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, found := breakers[name]
+	if !found {
+		b = NewBreaker(name, threshold, cooldown)
+		breakers[name] = b
+		return b
+	}
+	b.mu.Lock()
+	b.threshold = max(threshold, 1)
+	b.cooldown = cooldown
+	b.mu.Unlock()
+	return b
+}
+
+// FindBreaker returns the circuit breaker for the endpoint called name
+// or nil if there isn't one
+func FindBreaker(name string) *Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	return breakers[name]
+}
+
+// Breakers returns the stats for all the circuit breakers sorted by
+// name
+func Breakers() []BreakerStats {
+	breakersMu.Lock()
+	all := make([]*Breaker, 0, len(breakers))
+	for _, b := range breakers {
+		all = append(all, b)
+	}
+	breakersMu.Unlock()
+	stats := make([]BreakerStats, 0, len(all))
+	for _, b := range all {
+		stats = append(stats, b.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
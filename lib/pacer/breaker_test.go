@@ -0,0 +1,178 @@
+package pacer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setNow sets the time used by the breakers for the duration of the test
+func setNow(t *testing.T, tm *time.Time) {
+	oldNow := now
+	now = func() time.Time { return *tm }
+	t.Cleanup(func() { now = oldNow })
+}
+
+func TestBreakerStateString(t *testing.T) {
+	assert.Equal(t, "closed", BreakerClosed.String())
+	assert.Equal(t, "open", BreakerOpen.String())
+	assert.Equal(t, "half-open", BreakerHalfOpen.String())
+	assert.Equal(t, "BreakerState(9)", BreakerState(9).String())
+}
+
+func TestBreaker(t *testing.T) {
+	tm := time.Unix(1000, 0)
+	setNow(t, &tm)
+	errBoom := errors.New("boom")
+	b := NewBreaker("test", 3, time.Minute)
+
+	// Failures below the threshold and successes keep it closed
+	for range 2 {
+		require.NoError(t, b.Allow())
+		b.Record(true, errBoom)
+	}
+	require.NoError(t, b.Allow())
+	b.Record(false, nil)
+	assert.Equal(t, 0, b.Stats().ConsecutiveFailures)
+	for range 2 {
+		require.NoError(t, b.Allow())
+		b.Record(true, errBoom)
+	}
+	assert.Equal(t, "closed", b.Stats().State)
+
+	// The threshold trips it open
+	require.NoError(t, b.Allow())
+	b.Record(true, errBoom)
+	stats := b.Stats()
+	assert.Equal(t, "open", stats.State)
+	assert.Equal(t, tm.Add(time.Minute), stats.OpenUntil)
+	assert.Equal(t, int64(1), stats.Trips)
+	assert.Equal(t, "boom", stats.LastError)
+
+	// Calls fail fast while open
+	err := b.Allow()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.True(t, errors.Is(err, errBoom))
+	assert.Contains(t, err.Error(), `"test"`)
+	assert.Equal(t, int64(1), b.Stats().Rejected)
+
+	// After the cool-down one trial call is allowed
+	tm = tm.Add(time.Minute)
+	require.NoError(t, b.Allow())
+	assert.Equal(t, "half-open", b.Stats().State)
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+
+	// A failed trial opens it again
+	b.Record(true, errBoom)
+	stats = b.Stats()
+	assert.Equal(t, "open", stats.State)
+	assert.Equal(t, int64(2), stats.Trips)
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+
+	// A successful trial closes it
+	tm = tm.Add(time.Minute)
+	require.NoError(t, b.Allow())
+	b.Record(false, nil)
+	stats = b.Stats()
+	assert.Equal(t, "closed", stats.State)
+	assert.Equal(t, time.Time{}, stats.OpenUntil)
+	assert.Equal(t, int64(2), stats.Successes)
+	assert.Equal(t, int64(6), stats.Failures)
+	assert.Equal(t, int64(3), stats.Rejected)
+	require.NoError(t, b.Allow())
+}
+
+func TestBreakerReset(t *testing.T) {
+	tm := time.Unix(1000, 0)
+	setNow(t, &tm)
+	b := NewBreaker("test", 1, time.Hour)
+	require.NoError(t, b.Allow())
+	b.Record(true, errors.New("boom"))
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	b.Reset()
+	assert.Equal(t, "closed", b.Stats().State)
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreakerStateChanged(t *testing.T) {
+	tm := time.Unix(1000, 0)
+	setNow(t, &tm)
+	var changes []BreakerState
+	oldChanged := BreakerStateChanged
+	BreakerStateChanged = func(name string, state BreakerState, err error) {
+		assert.Equal(t, "test", name)
+		changes = append(changes, state)
+	}
+	defer func() { BreakerStateChanged = oldChanged }()
+
+	b := NewBreaker("test", 1, time.Minute)
+	require.NoError(t, b.Allow())
+	b.Record(true, errors.New("boom"))
+	tm = tm.Add(time.Minute)
+	require.NoError(t, b.Allow())
+	b.Record(false, nil)
+	assert.Equal(t, []BreakerState{BreakerOpen, BreakerClosed}, changes)
+}
+
+func TestGetBreaker(t *testing.T) {
+	b := GetBreaker("TestGetBreaker", 3, time.Minute)
+	assert.Equal(t, b, FindBreaker("TestGetBreaker"))
+	assert.Nil(t, FindBreaker("TestGetBreakerNotFound"))
+
+	b2 := GetBreaker("TestGetBreaker", 5, time.Hour)
+	assert.Equal(t, b, b2)
+	assert.Equal(t, 5, b.threshold)
+	assert.Equal(t, time.Hour, b.cooldown)
+
+	var found bool
+	for _, stats := range Breakers() {
+		if stats.Name == "TestGetBreaker" {
+			found = true
+			assert.Equal(t, "closed", stats.State)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestCallBreaker(t *testing.T) {
+	tm := time.Unix(1000, 0)
+	setNow(t, &tm)
+	b := NewBreaker("test", 3, time.Minute)
+	p := New(RetriesOption(10), CalculatorOption(NewDefault(MinSleep(1*time.Millisecond), MaxSleep(2*time.Millisecond))), BreakerOption(b))
+	assert.Equal(t, b, p.Breaker())
+
+	// The breaker stops the retries once it trips
+	errBoom := errors.New("boom")
+	calls := 0
+	err := p.Call(func() (bool, error) {
+		calls++
+		return true, errBoom
+	})
+	assert.Equal(t, 3, calls)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.ErrorIs(t, err, errBoom)
+
+	// Calls fail fast while it is open
+	calls = 0
+	err = p.Call(func() (bool, error) {
+		calls++
+		return false, nil
+	})
+	assert.Equal(t, 0, calls)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// Errors which aren't retried don't count as failures
+	tm = tm.Add(time.Minute)
+	errNotFound := errors.New("not found")
+	err = p.Call(func() (bool, error) {
+		calls++
+		return false, errNotFound
+	})
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, errNotFound, err)
+	assert.Equal(t, "closed", b.Stats().State)
+}
@@ -40,6 +40,7 @@ type pacerOptions struct {
 	retries        int         // Max number of retries
 	calculator     Calculator  // switchable pacing algorithm - call with mu held
 	invoker        InvokerFunc // wrapper function used to invoke the target function
+	breaker        *Breaker    // circuit breaker for the endpoint if set
 }
 
 // InvokerFunc is the signature of the wrapper function used to invoke the
@@ -69,6 +70,11 @@ func InvokerOption(invoker InvokerFunc) Option {
 	return func(p *pacerOptions) { p.invoker = invoker }
 }
 
+// BreakerOption sets a circuit Breaker for the new Pacer.
+func BreakerOption(breaker *Breaker) Option {
+	return func(p *pacerOptions) { p.breaker = breaker }
+}
+
 // Paced is a function which is called by the Call and CallNoRetry
 // methods.  It should return a boolean, true if it would like to be
 // retried, and an error.  This error may be returned or returned
@@ -128,6 +134,13 @@ func (p *Pacer) SetRetries(retries int) {
 	p.retries = retries
 }
 
+// Breaker returns the circuit breaker in use or nil if none
+func (p *Pacer) Breaker() *Breaker {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.breaker
+}
+
 // SetCalculator sets the pacing algorithm. Don't modify the Calculator object
 // afterwards, use the ModifyCalculator method when needed.
 //
@@ -205,16 +218,28 @@ func (p *Pacer) endCall(retry bool, err error, limitConnections bool) {
 // This is only needed when p.maxConnections > 0 which isn't a common
 // configuration so adding a bit of extra slowdown here is not a
 // problem.
+//
+// If there is a circuit breaker then each try is recorded with it
+// and the call fails fast if it is open.
 func (p *Pacer) call(fn Paced, retries int) (err error) {
 	var retry bool
 	limitConnections := false
 	if p.maxConnections > 0 && !caller.Present("(*Pacer).call") {
 		limitConnections = true
 	}
+	breaker := p.Breaker()
 	for i := 1; i <= retries; i++ {
+		if breaker != nil {
+			if openErr := breaker.Allow(); openErr != nil {
+				return openErr
+			}
+		}
 		p.beginCall(limitConnections)
 		retry, err = p.invoker(i, retries, fn)
 		p.endCall(retry, err, limitConnections)
+		if breaker != nil {
+			breaker.Record(retry, err)
+		}
 		if !retry {
 			break
 		}
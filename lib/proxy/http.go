@@ -2,7 +2,9 @@ package proxy
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
 	"net"
 	"net/http"
@@ -20,11 +22,20 @@ import (
 // It optionally takes a proxyDialer to dial the HTTP proxy server.
 // If nil is passed, it will use the default net.Dialer.
 func HTTPConnectDial(network, addr string, proxyURL *url.URL, proxyDialer proxy.Dialer) (net.Conn, error) {
+	return HTTPConnectDialContext(context.Background(), network, addr, proxyURL, proxyDialer)
+}
+
+// HTTPConnectDialContext is like HTTPConnectDial but uses ctx to dial
+// the HTTP proxy server if proxyDialer supports it.
+//
+// If proxyURL has a user and password they are sent to the proxy
+// with basic authentication.
+func HTTPConnectDialContext(ctx context.Context, network, addr string, proxyURL *url.URL, proxyDialer proxy.Dialer) (net.Conn, error) {
 	if proxyDialer == nil {
 		proxyDialer = &net.Dialer{}
 	}
 	if proxyURL == nil {
-		return proxyDialer.Dial(network, addr)
+		return dialContext(ctx, proxyDialer, network, addr)
 	}
 
 	// prepare proxy host with default ports
@@ -38,7 +49,7 @@ func HTTPConnectDial(network, addr string, proxyURL *url.URL, proxyDialer proxy.
 	}
 
 	// connect to proxy
-	conn, err := proxyDialer.Dial(network, host)
+	conn, err := dialContext(ctx, proxyDialer, network, host)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP CONNECT proxy failed to Dial: %q", err)
 	}
@@ -47,7 +58,7 @@ func HTTPConnectDial(network, addr string, proxyURL *url.URL, proxyDialer proxy.
 	if strings.EqualFold(proxyURL.Scheme, "https") {
 		tlsConfig := &tls.Config{ServerName: proxyURL.Hostname()}
 		tlsConn := tls.Client(conn, tlsConfig)
-		if err := tlsConn.Handshake(); err != nil {
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
 			_ = conn.Close()
 			return nil, fmt.Errorf("HTTP CONNECT proxy failed to make TLS connection: %q", err)
 		}
@@ -55,7 +66,12 @@ func HTTPConnectDial(network, addr string, proxyURL *url.URL, proxyDialer proxy.
 	}
 
 	// send CONNECT
-	_, err = fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", addr, addr)
+	var auth string
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth = "Proxy-Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username()+":"+password)) + "\r\n"
+	}
+	_, err = fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n%s\r\n", addr, addr, auth)
 	if err != nil {
 		_ = conn.Close()
 		return nil, fmt.Errorf("HTTP CONNECT proxy failed to send CONNECT: %q", err)
@@ -0,0 +1,237 @@
+// Package ratelimit implements transaction and bandwidth limits for a
+// single remote.
+//
+// These apply on top of the global --tpslimit and --bwlimit so that
+// remotes with different capabilities can be driven at different
+// rates in the same rclone process.
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"golang.org/x/time/rate"
+)
+
+// SharedOptions are the options for backends which support per
+// remote limits.
+var SharedOptions = []fs.Option{{
+	Name: "tpslimit",
+	Help: `Limit transactions per second to this remote.
+
+This is like the global --tpslimit but only applies to this remote,
+so a slow server can be limited without slowing down other remotes
+in the same sync or union. 0 means no limit.`,
+	Default:  0.0,
+	Advanced: true,
+}, {
+	Name:     "tpslimit_burst",
+	Help:     "Max burst of transactions for the remote's tpslimit.",
+	Default:  1,
+	Advanced: true,
+}, {
+	Name: "bwlimit",
+	Help: `Bandwidth limit for this remote.
+
+This is like the global --bwlimit but only applies to transfers to and
+from this remote. It takes the same values, a limit in KiB/s, or use
+suffix B|K|M|G|T|P, an upload:download pair or a full timetable. It is
+applied in addition to --bwlimit.`,
+	Default:  fs.BwTimetable{},
+	Advanced: true,
+}}
+
+// Options are the per remote limits from SharedOptions. Embed this
+// in the backend's Options.
+type Options struct {
+	TPSLimit      float64        `config:"tpslimit"`
+	TPSLimitBurst int            `config:"tpslimit_burst"`
+	BwLimit       fs.BwTimetable `config:"bwlimit"`
+}
+
+// timetableCheckInterval is how often the bandwidth timetable is
+// checked for a change of limit
+const timetableCheckInterval = time.Minute
+
+// maxBurst is the largest bandwidth burst allowed
+const maxBurst = 4 * 1024 * 1024
+
+// Limiter applies the limits for a remote
+//
+// A nil *Limiter applies no limits so the methods can be called
+// unconditionally.
+type Limiter struct {
+	tps *rate.Limiter
+
+	mu        sync.Mutex
+	timetable fs.BwTimetable
+	slot      fs.BwTimeSlot
+	nextCheck time.Time
+	tx        *rate.Limiter
+	rx        *rate.Limiter
+}
+
+// New makes a Limiter from opt, returning nil if no limits are set.
+func New(name string, opt *Options) *Limiter {
+	if opt.TPSLimit <= 0 && len(opt.BwLimit) == 0 {
+		return nil
+	}
+	l := &Limiter{
+		timetable: opt.BwLimit,
+	}
+	if opt.TPSLimit > 0 {
+		burst := max(opt.TPSLimitBurst, 1)
+		l.tps = rate.NewLimiter(rate.Limit(opt.TPSLimit), burst)
+		fs.Debugf(name, "Limiting to %g transactions/s with burst %d", opt.TPSLimit, burst)
+	}
+	if len(l.timetable) > 0 {
+		l.mu.Lock()
+		l._update(time.Now())
+		l.mu.Unlock()
+		fs.Debugf(name, "Limiting bandwidth to %v", l.timetable)
+	}
+	return l
+}
+
+// newBandwidthLimiter makes a limiter for bandwidth bytes/s or nil
+// if it is unlimited
+func newBandwidthLimiter(bandwidth fs.SizeSuffix) *rate.Limiter {
+	if bandwidth <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(bandwidth), maxBurst)
+}
+
+// _update sets the bandwidth limiters for the time slot at now
+//
+// Call with mu held
+func (l *Limiter) _update(now time.Time) {
+	l.nextCheck = now.Add(timetableCheckInterval)
+	slot := l.timetable.LimitAt(now)
+	if l.tx != nil || l.rx != nil {
+		if slot == l.slot {
+			return
+		}
+	}
+	l.slot = slot
+	l.tx = newBandwidthLimiter(slot.Bandwidth.Tx)
+	l.rx = newBandwidthLimiter(slot.Bandwidth.Rx)
+}
+
+// bandwidth returns the bandwidth limiter for uploads if tx is set or
+// downloads if not, or nil if there is no limit
+func (l *Limiter) bandwidth(tx bool) *rate.Limiter {
+	if l == nil || len(l.timetable) == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now := time.Now(); len(l.timetable) > 1 && now.After(l.nextCheck) {
+		l._update(now)
+	}
+	if tx {
+		return l.tx
+	}
+	return l.rx
+}
+
+// Wait should be called once before each transaction with the remote
+// to apply the transactions per second limit.
+func (l *Limiter) Wait(ctx context.Context) {
+	if l == nil || l.tps == nil {
+		return
+	}
+	err := l.tps.Wait(ctx)
+	if err != nil && err != context.Canceled {
+		fs.Errorf(nil, "Remote token bucket error: %v", err)
+	}
+}
+
+// reader limits the rate that data is read from in
+type reader struct {
+	ctx context.Context
+	l   *Limiter
+	tx  bool
+	in  io.Reader
+}
+
+// Read data limiting the bandwidth
+func (r *reader) Read(p []byte) (n int, err error) {
+	limiter := r.l.bandwidth(r.tx)
+	if limiter == nil {
+		return r.in.Read(p)
+	}
+	if len(p) > maxBurst {
+		p = p[:maxBurst]
+	}
+	n, err = r.in.Read(p)
+	if n > 0 {
+		if waitErr := limiter.WaitN(r.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return n, err
+}
+
+// readCloser limits the rate that data is read from an io.ReadCloser
+type readCloser struct {
+	reader
+	io.Closer
+}
+
+// UploadReader returns a reader which reads from in at the upload
+// bandwidth limit.
+func (l *Limiter) UploadReader(ctx context.Context, in io.Reader) io.Reader {
+	if l == nil || len(l.timetable) == 0 {
+		return in
+	}
+	return &reader{ctx: ctx, l: l, tx: true, in: in}
+}
+
+// DownloadReadCloser returns a ReadCloser which reads from in at the
+// download bandwidth limit.
+func (l *Limiter) DownloadReadCloser(ctx context.Context, in io.ReadCloser) io.ReadCloser {
+	if l == nil || len(l.timetable) == 0 {
+		return in
+	}
+	return &readCloser{reader: reader{ctx: ctx, l: l, tx: false, in: in}, Closer: in}
+}
+
+// transport applies the limits to HTTP requests
+type transport struct {
+	l       *Limiter
+	wrapped http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	t.l.Wait(ctx)
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(ctx)
+		body := req.Body
+		req.Body = &readCloser{reader: reader{ctx: ctx, l: t.l, tx: true, in: body}, Closer: body}
+	}
+	resp, err := t.wrapped.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	resp.Body = t.l.DownloadReadCloser(ctx, resp.Body)
+	return resp, nil
+}
+
+// Transport returns an http.RoundTripper which applies the limits to
+// requests made with rt.
+func (l *Limiter) Transport(rt http.RoundTripper) http.RoundTripper {
+	if l == nil {
+		return rt
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &transport{l: l, wrapped: rt}
+}
@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptions(t *testing.T) {
+	type backendOptions struct {
+		Host string `config:"host"`
+		Options
+	}
+	opt := new(backendOptions)
+	m := configmap.Simple{
+		"host":           "example.com",
+		"tpslimit":       "2.5",
+		"tpslimit_burst": "3",
+		"bwlimit":        "1M:2M",
+	}
+	require.NoError(t, configstruct.Set(m, opt))
+	assert.Equal(t, 2.5, opt.TPSLimit)
+	assert.Equal(t, 3, opt.TPSLimitBurst)
+	require.Len(t, opt.BwLimit, 1)
+	assert.Equal(t, fs.BwPair{Tx: fs.Mebi, Rx: 2 * fs.Mebi}, opt.BwLimit[0].Bandwidth)
+}
+
+func TestNewNoLimits(t *testing.T) {
+	ctx := context.Background()
+	l := New("test", &Options{TPSLimitBurst: 1})
+	assert.Nil(t, l)
+
+	// A nil limiter passes everything through unchanged
+	l.Wait(ctx)
+	in := strings.NewReader("potato")
+	assert.Equal(t, io.Reader(in), l.UploadReader(ctx, in))
+	rc := io.NopCloser(in)
+	assert.Equal(t, rc, l.DownloadReadCloser(ctx, rc))
+	assert.Equal(t, http.DefaultTransport, l.Transport(http.DefaultTransport))
+}
+
+func TestWait(t *testing.T) {
+	ctx := context.Background()
+	l := New("test", &Options{TPSLimit: 20, TPSLimitBurst: 1})
+	require.NotNil(t, l)
+	start := time.Now()
+	for range 5 {
+		l.Wait(ctx)
+	}
+	// First is free then 4 at 20/s
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+
+	// Cancelled context returns immediately
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	l.Wait(ctx)
+}
+
+func TestBandwidth(t *testing.T) {
+	ctx := context.Background()
+	var bw fs.BwTimetable
+	require.NoError(t, bw.Set("off:64k"))
+	l := New("test", &Options{BwLimit: bw})
+	require.NotNil(t, l)
+
+	// Uploads are unlimited so are passed through
+	assert.Nil(t, l.bandwidth(true))
+	data := bytes.Repeat([]byte{'x'}, 16*1024)
+	out, err := io.ReadAll(l.UploadReader(ctx, bytes.NewReader(data)))
+	require.NoError(t, err)
+	assert.Equal(t, data, out)
+
+	// Downloads are limited - use up the burst so the next read waits
+	rx := l.bandwidth(false)
+	require.NotNil(t, rx)
+	require.NoError(t, rx.WaitN(ctx, maxBurst))
+	start := time.Now()
+	out, err = io.ReadAll(l.DownloadReadCloser(ctx, io.NopCloser(bytes.NewReader(data))))
+	require.NoError(t, err)
+	assert.Equal(t, data, out)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	// Cancelling the context stops the read
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = io.ReadAll(l.DownloadReadCloser(ctx, io.NopCloser(bytes.NewReader(data))))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTransport(t *testing.T) {
+	var got []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte("response"))
+	}))
+	defer ts.Close()
+
+	var bw fs.BwTimetable
+	require.NoError(t, bw.Set("1M"))
+	l := New("test", &Options{TPSLimit: 100, BwLimit: bw})
+	client := &http.Client{Transport: l.Transport(http.DefaultTransport)}
+
+	resp, err := client.Post(ts.URL, "text/plain", strings.NewReader("request"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "request", string(got))
+	assert.Equal(t, "response", string(body))
+}
@@ -40,6 +40,8 @@ var commandList = []commands{
 	{command: "--name-transform truncate_keep_extension=N", description: "Truncates the file name to a maximum of N characters while preserving the original file extension."},
 	{command: "--name-transform truncate_bytes=N", description: "Truncates the file name to a maximum of N bytes (not characters)."},
 	{command: "--name-transform truncate_bytes_keep_extension=N", description: "Truncates the file name to a maximum of N bytes (not characters) while preserving the original file extension."},
+	{command: "--name-transform truncate_hash=N", description: "Truncates the file name to a maximum of N characters ending with a short hash of the original name."},
+	{command: "--name-transform truncate_hash_keep_extension=N", description: "Truncates the file name to a maximum of N characters ending with a short hash of the original name while preserving the original file extension."},
 	{command: "--name-transform base64encode", description: "Encodes the file name in Base64."},
 	{command: "--name-transform base64decode", description: "Decodes a Base64-encoded file name."},
 	{command: "--name-transform encoder=ENCODING", description: "Converts the file name to the specified encoding (e.g., ISO-8859-1, Windows-1252, Macintosh)."},
@@ -70,6 +72,7 @@ var examples = []example{
 	{"stories/The Quick Brown 🦊 Fox Went to the Café!.txt", []string{"all,charmap=ISO-8859-7"}},
 	{"stories/The Quick Brown Fox: A Memoir [draft].txt", []string{"all,encoder=Colon,SquareBracket"}},
 	{"stories/The Quick Brown 🦊 Fox Went to the Café!.txt", []string{"all,truncate=21"}},
+	{"stories/The Quick Brown 🦊 Fox Went to the Café!.txt", []string{"truncate_hash_keep_extension=30"}},
 	{"stories/The Quick Brown Fox!.txt", []string{"all,command=echo"}},
 	{"stories/The Quick Brown Fox!", []string{"date=-{YYYYMMDD}"}},
 	{"stories/The Quick Brown Fox!", []string{"date=-{macfriendlytime}"}},
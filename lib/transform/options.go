@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/encoder"
 )
 
 type transform struct {
@@ -29,7 +30,31 @@ const (
 // Transforming returns true when transforms are in use
 func Transforming(ctx context.Context) bool {
 	ci := fs.GetConfig(ctx)
-	return len(ci.NameTransform) > 0
+	return len(ci.NameTransform) > 0 || getTable(ctx) != nil
+}
+
+// tableKey is the context key for the encoding table
+type tableKey struct{}
+
+// ForDestination returns a context which applies the encoding_table
+// of the destination f after the --name-transform options.
+//
+// If f doesn't have an encoding table ctx is returned unchanged.
+func ForDestination(ctx context.Context, f fs.Info) context.Context {
+	if f == nil {
+		return ctx
+	}
+	table := fs.EncodingTable(f)
+	if table == nil || getTable(ctx) == table {
+		return ctx
+	}
+	return context.WithValue(ctx, tableKey{}, table)
+}
+
+// getTable returns the encoding table set with ForDestination or nil
+func getTable(ctx context.Context) *encoder.Table {
+	table, _ := ctx.Value(tableKey{}).(*encoder.Table)
+	return table
 }
 
 // SetOptions sets the options in ctx from flags passed in.
@@ -165,6 +190,10 @@ func (t *transform) requiresValue() bool {
 		return true
 	case ConvTruncateBytesKeepExtension:
 		return true
+	case ConvTruncateHash:
+		return true
+	case ConvTruncateHashKeepExtension:
+		return true
 	case ConvEncoder:
 		return true
 	case ConvDecoder:
@@ -199,6 +228,8 @@ const (
 	ConvTruncateKeepExtension
 	ConvTruncateBytes
 	ConvTruncateBytesKeepExtension
+	ConvTruncateHash
+	ConvTruncateHashKeepExtension
 	ConvBase64Encode
 	ConvBase64Decode
 	ConvEncoder
@@ -237,6 +268,8 @@ func (transformChoices) Choices() []string {
 		ConvTruncateKeepExtension:      "truncate_keep_extension",
 		ConvTruncateBytes:              "truncate_bytes",
 		ConvTruncateBytesKeepExtension: "truncate_bytes_keep_extension",
+		ConvTruncateHash:               "truncate_hash",
+		ConvTruncateHashKeepExtension:  "truncate_hash_keep_extension",
 		ConvBase64Encode:               "base64encode",
 		ConvBase64Decode:               "base64decode",
 		ConvEncoder:                    "encoder",
@@ -6,8 +6,10 @@ package transform
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	_ "embed"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"mime"
@@ -38,6 +40,7 @@ func Help() string {
 }
 
 // Path transforms a path s according to the --name-transform options in use
+// followed by the encoding table of the destination if set with ForDestination.
 //
 // If no transforms are in use, s is returned unchanged
 func Path(ctx context.Context, s string, isDir bool) string {
@@ -66,6 +69,13 @@ func Path(ctx context.Context, s string, isDir bool) string {
 			fs.Errorf(s, "Failed to transform: %v", err)
 		}
 	}
+	if table := getTable(ctx); table != nil {
+		s, err = applyTable(s, table)
+		if err != nil {
+			err = fs.CountError(ctx, fserrors.NoRetryError(err))
+			fs.Errorf(s, "Failed to apply encoding table: %v", err)
+		}
+	}
 	if old != s {
 		fs.Debugf(old, "transformed to: %v", s)
 	}
@@ -77,6 +87,26 @@ func Path(ctx context.Context, s string, isDir bool) string {
 	return s
 }
 
+// MetadataOriginalName is the metadata key used to record the original
+// name of a file which was renamed by the transforms
+const MetadataOriginalName = "original-name"
+
+// OriginalNameMetadata returns metadata recording the leaf name of
+// remote if transformed has a different leaf name, so that lossy
+// transforms such as truncate_hash can be reversed.
+//
+// It returns nil unless transforms and --metadata are in use.
+func OriginalNameMetadata(ctx context.Context, remote, transformed string) fs.Metadata {
+	if !Transforming(ctx) || !fs.GetConfig(ctx).Metadata {
+		return nil
+	}
+	name := path.Base(remote)
+	if name == path.Base(transformed) {
+		return nil
+	}
+	return fs.Metadata{MetadataOriginalName: name}
+}
+
 // transformPath transforms a path string according to the chosen TransformAlgo.
 // Each path segment is transformed separately, to preserve path separators.
 // If baseOnly is true, only the base will be transformed (useful for renaming while walking a dir tree recursively.)
@@ -110,6 +140,21 @@ func transformPath(s string, t transform, baseOnly bool) (string, error) {
 	return path.Join(transformedSegments...), nil
 }
 
+// applyTable applies the encoding table to each segment of the path s
+func applyTable(s string, table *encoder.Table) (string, error) {
+	segments := strings.Split(s, "/")
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		segments[i] = table.Apply(segment)
+		if err := validateSegment(segments[i]); err != nil {
+			return s, err
+		}
+	}
+	return strings.Join(segments, "/"), nil
+}
+
 // transform all but the last path segment
 func transformDir(s string, t transform) (string, error) {
 	dirPath, err := transformPath(path.Dir(s), t, false)
@@ -184,6 +229,18 @@ func transformPathSegment(s string, t transform) (string, error) {
 			return s, err
 		}
 		return truncateBytes(s, max, true)
+	case ConvTruncateHash:
+		max, err := strconv.Atoi(t.value)
+		if err != nil {
+			return s, err
+		}
+		return truncateHash(s, max, false)
+	case ConvTruncateHashKeepExtension:
+		max, err := strconv.Atoi(t.value)
+		if err != nil {
+			return s, err
+		}
+		return truncateHash(s, max, true)
 	case ConvEncoder:
 		var enc encoder.MultiEncoder
 		err := enc.Set(t.value)
@@ -304,6 +361,30 @@ func truncateBytes(s string, max int, keepExtension bool) (string, error) {
 	return "", errors.New("could not truncate to valid UTF-8")
 }
 
+// truncateHash is like truncateChars but ends the truncated name with
+// "~" and a short hash of the original name so that names which only
+// differ after max characters don't collide
+func truncateHash(s string, max int, keepExtension bool) (string, error) {
+	if max <= 0 {
+		return s, nil
+	}
+	if utf8.RuneCountInString(s) <= max {
+		return s, nil
+	}
+	sum := sha256.Sum256([]byte(s))
+	suffix := "~" + hex.EncodeToString(sum[:4])
+	exts := ""
+	if keepExtension {
+		s, exts = splitExtension(s)
+	}
+	keep := max - utf8.RuneCountInString(suffix+exts)
+	if keep <= 0 {
+		return s + exts, fmt.Errorf("can't truncate to %d characters with a hash suffix", max)
+	}
+	runes := []rune(s)
+	return string(runes[:keep]) + suffix + exts, nil
+}
+
 // forbid transformations that add/remove path separators
 func validateSegment(s string) error {
 	if strings.TrimSpace(s) == "" {
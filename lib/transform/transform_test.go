@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/rclone/rclone/fs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -134,6 +135,9 @@ func TestVarious(t *testing.T) {
 		{"stories/Вот русское предложение, в котором байтов больше, чем символов.txt", "stories/Вот русское предложение, в котором бай", []string{"truncate_bytes=70"}},
 		{"stories/Вот русское предложение, в котором байтов больше, чем символов.txt", "stories/Вот русское предложение, в котором байтов больше, чем си.txt", []string{"truncate_keep_extension=60"}},
 		{"stories/Вот русское предложение, в котором байтов больше, чем символов.txt", "stories/Вот русское предложение, в котором б.txt", []string{"truncate_bytes_keep_extension=70"}},
+		{"stories/The Quick Brown 🦊 Fox Went to the Café!.txt", "stories/The Quick Brown 🦊 Fox Went to the Café!.txt", []string{"truncate_hash=60"}},
+		{"stories/The Quick Brown 🦊 Fox Went to the Café!.txt", "stories/The Quick Brown 🦊 Fox~3e9a142d", []string{"truncate_hash=30"}},
+		{"stories/The Quick Brown 🦊 Fox Went to the Café!.txt", "stories/The Quick Brown 🦊~3e9a142d.txt", []string{"truncate_hash_keep_extension=30"}},
 		{"stories/The Quick Brown Fox!.txt", "stories/The Quick Brown Fox!.txt", []string{"all,command=echo"}},
 		{"stories/The Quick Brown Fox!.txt", "stories/The Quick Brown Fox!.txt-" + time.Now().Local().Format("20060102"), []string{"date=-{YYYYMMDD}"}},
 		{"stories/The Quick Brown Fox!.txt", "stories/The Quick Brown Fox!.txt-" + time.Now().Local().Format("2006-01-02 0304PM"), []string{"date=-{macfriendlytime}"}},
@@ -146,3 +150,27 @@ func TestVarious(t *testing.T) {
 		assert.Equal(t, test.want, got)
 	}
 }
+
+func TestTruncateHashCollisions(t *testing.T) {
+	ctx, err := newOptions("truncate_hash=20")
+	require.NoError(t, err)
+	a := Path(ctx, "dir/a very long file name number 1", false)
+	b := Path(ctx, "dir/a very long file name number 2", false)
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, "dir/a very long~", a[:len("dir/a very long~")])
+	assert.Equal(t, len("dir/")+20, len(a))
+}
+
+func TestOriginalNameMetadata(t *testing.T) {
+	ctx, err := newOptions("truncate_hash=10")
+	require.NoError(t, err)
+	assert.Nil(t, OriginalNameMetadata(ctx, "dir/long file name", "dir/l~12345678"))
+
+	ctx, ci := fs.AddConfig(ctx)
+	ci.Metadata = true
+	assert.Equal(t, fs.Metadata{MetadataOriginalName: "long file name"}, OriginalNameMetadata(ctx, "dir/long file name", "dir/l~12345678"))
+	assert.Nil(t, OriginalNameMetadata(ctx, "dir/short", "dir/short"))
+
+	ci.NameTransform = nil
+	assert.Nil(t, OriginalNameMetadata(ctx, "dir/long file name", "dir/l~12345678"))
+}
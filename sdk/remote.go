@@ -0,0 +1,194 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/walk"
+)
+
+// Remote is a directory on a remote which files can be read from and
+// written to
+type Remote struct {
+	c *Client
+	f fs.Fs
+}
+
+// Entry describes a file or directory on a remote
+type Entry struct {
+	Path     string    // path relative to the Remote
+	Name     string    // leaf name
+	Size     int64     // size in bytes or -1 if not known
+	ModTime  time.Time // modification time
+	IsDir    bool      // set if this is a directory
+	MimeType string    // MIME type of a file if known
+}
+
+// ListOptions control Remote.List
+type ListOptions struct {
+	Recursive bool // list all the directories under dir too
+	FilesOnly bool // only return files
+	DirsOnly  bool // only return directories
+}
+
+// String returns the remote as a string which can be passed to
+// Client.Remote to find it again.
+func (r *Remote) String() string {
+	return fs.ConfigString(r.f)
+}
+
+// isNotFound returns true if err means the file or directory wasn't
+// found
+func isNotFound(err error) bool {
+	return errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorDirNotFound) || errors.Is(err, fs.ErrorNotAFile)
+}
+
+// wrapError makes not found errors match ErrNotFound
+func wrapError(err error) error {
+	if err != nil && isNotFound(err) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
+
+// newEntry makes an Entry from a directory entry
+func newEntry(ctx context.Context, entry fs.DirEntry) Entry {
+	e := Entry{
+		Path:    entry.Remote(),
+		Name:    path.Base(entry.Remote()),
+		Size:    entry.Size(),
+		ModTime: entry.ModTime(ctx),
+	}
+	switch entry.(type) {
+	case fs.Directory:
+		e.IsDir = true
+	case fs.Object:
+		e.MimeType = fs.MimeType(ctx, entry)
+	}
+	return e
+}
+
+// List returns the entries in dir, "" for the root of the Remote.
+func (r *Remote) List(ctx context.Context, dir string, opt ListOptions) (entries []Entry, err error) {
+	ctx, _ = r.c.context(ctx)
+	maxLevel := 1
+	if opt.Recursive {
+		maxLevel = -1
+	}
+	listType := walk.ListAll
+	if opt.FilesOnly {
+		listType = walk.ListObjects
+	} else if opt.DirsOnly {
+		listType = walk.ListDirs
+	}
+	err = walk.ListR(ctx, r.f, dir, true, maxLevel, listType, func(dirEntries fs.DirEntries) error {
+		for _, entry := range dirEntries {
+			entries = append(entries, newEntry(ctx, entry))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return entries, nil
+}
+
+// Stat returns the Entry for the file or directory at remote.
+func (r *Remote) Stat(ctx context.Context, remote string) (Entry, error) {
+	ctx, _ = r.c.context(ctx)
+	entry, err := r.statDirEntry(ctx, remote)
+	if err != nil {
+		return Entry{}, wrapError(err)
+	}
+	return newEntry(ctx, entry), nil
+}
+
+// statDirEntry finds the file or directory at remote
+func (r *Remote) statDirEntry(ctx context.Context, remote string) (fs.DirEntry, error) {
+	remote = strings.Trim(remote, "/")
+	if remote == "" {
+		_, err := r.f.List(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		return fs.NewDir("", time.Time{}), nil
+	}
+	o, err := r.f.NewObject(ctx, remote)
+	if err == nil {
+		return o, nil
+	} else if !errors.Is(err, fs.ErrorObjectNotFound) && !errors.Is(err, fs.ErrorIsDir) && !errors.Is(err, fs.ErrorNotAFile) {
+		return nil, err
+	}
+	// Might be a directory so look for it in its parent
+	parent := path.Dir(remote)
+	if parent == "." {
+		parent = ""
+	}
+	entries, err := r.f.List(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if dir, ok := entry.(fs.Directory); ok && dir.Remote() == remote {
+			return dir, nil
+		}
+	}
+	return nil, fs.ErrorObjectNotFound
+}
+
+// Open opens the file at remote for reading. The caller must close
+// it.
+func (r *Remote) Open(ctx context.Context, remote string) (io.ReadCloser, error) {
+	ctx, _ = r.c.context(ctx)
+	o, err := r.f.NewObject(ctx, remote)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return o.Open(ctx)
+}
+
+// Upload writes the contents of in to the file at remote, replacing
+// it if it exists. Set size to -1 if it isn't known.
+func (r *Remote) Upload(ctx context.Context, remote string, in io.Reader, size int64, modTime time.Time) (Entry, error) {
+	ctx, _ = r.c.context(ctx)
+	o, err := operations.RcatSize(ctx, r.f, remote, io.NopCloser(in), size, modTime, nil)
+	if err != nil {
+		return Entry{}, err
+	}
+	return newEntry(ctx, o), nil
+}
+
+// Delete deletes the file at remote.
+func (r *Remote) Delete(ctx context.Context, remote string) error {
+	ctx, _ = r.c.context(ctx)
+	o, err := r.f.NewObject(ctx, remote)
+	if err != nil {
+		return wrapError(err)
+	}
+	return operations.DeleteFile(ctx, o)
+}
+
+// Mkdir makes the directory dir and any parents needed.
+func (r *Remote) Mkdir(ctx context.Context, dir string) error {
+	ctx, _ = r.c.context(ctx)
+	return operations.Mkdir(ctx, r.f, dir)
+}
+
+// Rmdir removes the empty directory dir.
+func (r *Remote) Rmdir(ctx context.Context, dir string) error {
+	ctx, _ = r.c.context(ctx)
+	return wrapError(operations.Rmdir(ctx, r.f, dir))
+}
+
+// Purge removes the directory dir and everything in it.
+func (r *Remote) Purge(ctx context.Context, dir string) error {
+	ctx, _ = r.c.context(ctx)
+	return wrapError(operations.Purge(ctx, r.f, dir))
+}
@@ -0,0 +1,154 @@
+// Package sdk is a stable Go API for embedding rclone in other Go
+// programs.
+//
+// The rest of rclone's packages are internal in all but name and
+// change from release to release. This package is a small facade
+// over them which follows semantic versioning: within a major
+// version of rclone nothing exported from here will be removed or
+// change meaning. Only types defined in this package and the
+// standard library appear in its API.
+//
+// The backends aren't included, import the ones you need, for
+// example
+//
+//	import _ "github.com/rclone/rclone/backend/all"
+//
+// A minimal program copying a directory looks like this
+//
+//	client, err := sdk.New(sdk.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	stats, err := client.Copy(ctx, "/home/me/photos", "remote:photos", sdk.TransferOptions{
+//		Progress: func(s sdk.Stats) {
+//			fmt.Printf("%d/%d bytes\n", s.Bytes, s.TotalBytes)
+//		},
+//	})
+//
+// All calls take a context and stop as soon as possible when it is
+// cancelled.
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configfile"
+	"github.com/rclone/rclone/fs/log"
+)
+
+// ErrNotFound is returned, possibly wrapped, when a file or
+// directory doesn't exist.
+var ErrNotFound = errors.New("not found")
+
+// Options control a Client
+type Options struct {
+	// ConfigPath is the path of the rclone config file to read
+	// remotes from. If empty the usual rclone config file is used.
+	//
+	// The config file is shared by the whole process so only the
+	// first Client made can set it.
+	ConfigPath string
+
+	// Remotes are extra remotes to define, keyed on the remote
+	// name, with the parameters of each, which must include
+	// "type". These aren't saved to the config file.
+	Remotes map[string]map[string]string
+
+	// Transfers is the number of file transfers to run in
+	// parallel. If 0 the rclone default is used.
+	Transfers int
+
+	// Checkers is the number of checkers to run in parallel. If
+	// 0 the rclone default is used.
+	Checkers int
+}
+
+// Client runs rclone operations
+//
+// A Client is safe for concurrent use.
+type Client struct {
+	opt Options
+}
+
+var (
+	initMu         sync.Mutex
+	initDone       bool
+	initConfigPath string
+)
+
+// initialise sets up the parts of rclone which are global to the
+// process the first time it is called.
+func initialise(configPath string) error {
+	initMu.Lock()
+	defer initMu.Unlock()
+	if initDone {
+		if configPath != "" && configPath != initConfigPath {
+			return fmt.Errorf("config path already set to %q", initConfigPath)
+		}
+		return nil
+	}
+	log.InitLogging()
+	if configPath != "" {
+		if err := config.SetConfigPath(configPath); err != nil {
+			return fmt.Errorf("failed to set config path: %w", err)
+		}
+	}
+	configfile.Install()
+	accounting.Start(context.Background())
+	initDone = true
+	initConfigPath = configPath
+	return nil
+}
+
+// New makes a Client with the options passed in
+func New(opt Options) (*Client, error) {
+	if err := initialise(opt.ConfigPath); err != nil {
+		return nil, err
+	}
+	for name, params := range opt.Remotes {
+		if params["type"] == "" {
+			return nil, fmt.Errorf("remote %q: type not set", name)
+		}
+		for key, value := range params {
+			config.FileSetValue(name, key, value)
+		}
+	}
+	return &Client{opt: opt}, nil
+}
+
+// context returns ctx with the config for the client added
+func (c *Client) context(ctx context.Context) (context.Context, *fs.ConfigInfo) {
+	ctx, ci := fs.AddConfig(ctx)
+	if c.opt.Transfers > 0 {
+		ci.Transfers = c.opt.Transfers
+	}
+	if c.opt.Checkers > 0 {
+		ci.Checkers = c.opt.Checkers
+	}
+	return ctx, ci
+}
+
+// Remotes returns the names of the remotes in the config file
+func (c *Client) Remotes() []string {
+	return config.GetRemoteNames()
+}
+
+// Remote returns the remote at fsPath which can be a configured
+// remote with a path, e.g. "remote:path/to/dir", a connection string,
+// e.g. ":s3,provider=AWS:bucket", or a local path. If fsPath points to
+// a file then the Remote is the directory containing it.
+func (c *Client) Remote(ctx context.Context, fsPath string) (*Remote, error) {
+	ctx, _ = c.context(ctx)
+	f, err := cache.Get(ctx, fsPath)
+	if err != nil && !errors.Is(err, fs.ErrorIsFile) {
+		return nil, err
+	}
+	return &Remote{c: c, f: f}, nil
+}
@@ -0,0 +1,194 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t1 = time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
+func newClient(t *testing.T) *Client {
+	c, err := New(Options{
+		Remotes: map[string]map[string]string{
+			"sdktest": {"type": "local"},
+		},
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func writeFile(t *testing.T, dir, name, contents string) {
+	p := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0777))
+	require.NoError(t, os.WriteFile(p, []byte(contents), 0666))
+}
+
+func TestNew(t *testing.T) {
+	c := newClient(t)
+	assert.Contains(t, c.Remotes(), "sdktest")
+
+	_, err := New(Options{Remotes: map[string]map[string]string{"bad": {}}})
+	assert.ErrorContains(t, err, "type not set")
+}
+
+func TestRemote(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t)
+	dir := t.TempDir()
+	r, err := c.Remote(ctx, "sdktest:"+dir)
+	require.NoError(t, err)
+
+	// Upload and read back
+	entry, err := r.Upload(ctx, "sub/file.txt", bytes.NewBufferString("hello"), 5, t1)
+	require.NoError(t, err)
+	assert.Equal(t, "sub/file.txt", entry.Path)
+	assert.Equal(t, "file.txt", entry.Name)
+	assert.Equal(t, int64(5), entry.Size)
+	assert.True(t, t1.Equal(entry.ModTime))
+	assert.Equal(t, "text/plain; charset=utf-8", entry.MimeType)
+
+	in, err := r.Open(ctx, "sub/file.txt")
+	require.NoError(t, err)
+	data, err := io.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "hello", string(data))
+
+	// Unknown size
+	_, err = r.Upload(ctx, "stream.txt", bytes.NewBufferString("streamed"), -1, t1)
+	require.NoError(t, err)
+
+	// Stat
+	entry, err = r.Stat(ctx, "sub")
+	require.NoError(t, err)
+	assert.True(t, entry.IsDir)
+	entry, err = r.Stat(ctx, "stream.txt")
+	require.NoError(t, err)
+	assert.False(t, entry.IsDir)
+	assert.Equal(t, int64(8), entry.Size)
+	_, err = r.Stat(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// List
+	require.NoError(t, r.Mkdir(ctx, "empty"))
+	entries, err := r.List(ctx, "", ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"empty", "stream.txt", "sub"}, entryPaths(entries))
+	entries, err = r.List(ctx, "", ListOptions{Recursive: true, FilesOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stream.txt", "sub/file.txt"}, entryPaths(entries))
+	entries, err = r.List(ctx, "", ListOptions{Recursive: true, DirsOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"empty", "sub"}, entryPaths(entries))
+	_, err = r.List(ctx, "missing", ListOptions{})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Remove things
+	require.NoError(t, r.Rmdir(ctx, "empty"))
+	require.NoError(t, r.Delete(ctx, "stream.txt"))
+	assert.ErrorIs(t, r.Delete(ctx, "stream.txt"), ErrNotFound)
+	require.NoError(t, r.Purge(ctx, "sub"))
+	entries, err = r.List(ctx, "", ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func entryPaths(entries []Entry) (paths []string) {
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t)
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, src, "a.txt", "aaa")
+	writeFile(t, src, "dir/b.txt", "bbbb")
+	writeFile(t, src, "skip.tmp", "tmp")
+
+	var (
+		mu    sync.Mutex
+		calls []Stats
+	)
+	stats, err := c.Copy(ctx, src, dst, TransferOptions{
+		Filter: []string{"- *.tmp"},
+		Progress: func(s Stats) {
+			mu.Lock()
+			calls = append(calls, s)
+			mu.Unlock()
+		},
+		ProgressInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Transfers)
+	assert.Equal(t, int64(7), stats.Bytes)
+	assert.Equal(t, int64(0), stats.Errors)
+	require.NotEmpty(t, calls)
+	assert.Equal(t, stats, calls[len(calls)-1])
+
+	assert.FileExists(t, filepath.Join(dst, "a.txt"))
+	assert.FileExists(t, filepath.Join(dst, "dir", "b.txt"))
+	assert.NoFileExists(t, filepath.Join(dst, "skip.tmp"))
+
+	// Stats are per call
+	stats, err = c.Copy(ctx, src, dst, TransferOptions{Filter: []string{"- *.tmp"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), stats.Transfers)
+	assert.Equal(t, int64(2), stats.Checks)
+
+	_, err = c.Copy(ctx, src, dst, TransferOptions{Filter: []string{"potato"}})
+	assert.ErrorContains(t, err, "bad filter rule")
+}
+
+func TestSyncMove(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t)
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, src, "a.txt", "aaa")
+	writeFile(t, dst, "extra.txt", "extra")
+
+	// Dry run changes nothing
+	_, err := c.Sync(ctx, src, dst, TransferOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dst, "a.txt"))
+	assert.FileExists(t, filepath.Join(dst, "extra.txt"))
+
+	stats, err := c.Sync(ctx, src, dst, TransferOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Transfers)
+	assert.Equal(t, int64(1), stats.Deletes)
+	assert.FileExists(t, filepath.Join(dst, "a.txt"))
+	assert.NoFileExists(t, filepath.Join(dst, "extra.txt"))
+
+	dst2 := t.TempDir()
+	_, err = c.Move(ctx, src, dst2, TransferOptions{})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dst2, "a.txt"))
+	assert.NoFileExists(t, filepath.Join(src, "a.txt"))
+}
+
+func TestCopyCancel(t *testing.T) {
+	c := newClient(t)
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, src, "a.txt", "aaa")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.Copy(ctx, src, dst, TransferOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoFileExists(t, filepath.Join(dst, "a.txt"))
+}
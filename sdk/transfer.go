@@ -0,0 +1,197 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/rc"
+	fssync "github.com/rclone/rclone/fs/sync"
+	"github.com/rclone/rclone/lib/random"
+)
+
+// Stats are the statistics of a transfer
+type Stats struct {
+	Bytes          int64         // bytes transferred so far
+	TotalBytes     int64         // total bytes to transfer as far as is known
+	Transfers      int64         // files transferred so far
+	TotalTransfers int64         // total files to transfer as far as is known
+	Checks         int64         // files checked so far
+	Deletes        int64         // files deleted so far
+	Errors         int64         // number of errors so far
+	Speed          float64       // average speed in bytes per second
+	Elapsed        time.Duration // time since the transfer started
+}
+
+// TransferOptions control Client.Copy, Client.Sync and Client.Move
+type TransferOptions struct {
+	// DryRun shows what would be done without changing anything.
+	DryRun bool
+
+	// Transfers and Checkers override the values in the Client
+	// Options if set.
+	Transfers int
+	Checkers  int
+
+	// Filter are filter rules in the format used by --filter, for
+	// example "- *.tmp" or "+ /photos/**". They are applied in
+	// order and the first match wins.
+	Filter []string
+
+	// CreateEmptyDirs copies empty directories from the source.
+	CreateEmptyDirs bool
+
+	// Progress if set is called with the current stats every
+	// ProgressInterval while the transfer runs. It is called from
+	// a separate goroutine, one call at a time.
+	Progress func(Stats)
+
+	// ProgressInterval is how often to call Progress. If 0 this is
+	// 1 second.
+	ProgressInterval time.Duration
+}
+
+// Copy copies the files in src to dst, skipping files which are
+// identical already. It doesn't delete files from dst.
+//
+// src and dst are paths as taken by Client.Remote.
+func (c *Client) Copy(ctx context.Context, src, dst string, opt TransferOptions) (Stats, error) {
+	return c.transfer(ctx, src, dst, opt, func(ctx context.Context, fdst, fsrc fs.Fs) error {
+		return fssync.CopyDir(ctx, fdst, fsrc, opt.CreateEmptyDirs)
+	})
+}
+
+// Sync makes dst identical to src, deleting files in dst which
+// aren't in src.
+//
+// src and dst are paths as taken by Client.Remote.
+func (c *Client) Sync(ctx context.Context, src, dst string, opt TransferOptions) (Stats, error) {
+	return c.transfer(ctx, src, dst, opt, func(ctx context.Context, fdst, fsrc fs.Fs) error {
+		return fssync.Sync(ctx, fdst, fsrc, opt.CreateEmptyDirs)
+	})
+}
+
+// Move moves the files in src to dst, deleting them from src once
+// they have been transferred.
+//
+// src and dst are paths as taken by Client.Remote.
+func (c *Client) Move(ctx context.Context, src, dst string, opt TransferOptions) (Stats, error) {
+	return c.transfer(ctx, src, dst, opt, func(ctx context.Context, fdst, fsrc fs.Fs) error {
+		return fssync.MoveDir(ctx, fdst, fsrc, false, opt.CreateEmptyDirs)
+	})
+}
+
+// transfer sets up the context for a transfer from src to dst with
+// its own stats group and runs fn reporting progress as it goes
+func (c *Client) transfer(ctx context.Context, src, dst string, opt TransferOptions, fn func(ctx context.Context, fdst, fsrc fs.Fs) error) (Stats, error) {
+	if err := ctx.Err(); err != nil {
+		return Stats{}, err
+	}
+	ctx, ci := c.context(ctx)
+	if opt.Transfers > 0 {
+		ci.Transfers = opt.Transfers
+	}
+	if opt.Checkers > 0 {
+		ci.Checkers = opt.Checkers
+	}
+	ci.DryRun = opt.DryRun
+	if len(opt.Filter) > 0 {
+		fi, err := filter.NewFilter(nil)
+		if err != nil {
+			return Stats{}, err
+		}
+		for _, rule := range opt.Filter {
+			if err := fi.AddRule(rule); err != nil {
+				return Stats{}, fmt.Errorf("bad filter rule %q: %w", rule, err)
+			}
+		}
+		ctx = filter.ReplaceConfig(ctx, fi)
+	}
+	fsrc, err := c.Remote(ctx, src)
+	if err != nil {
+		return Stats{}, fmt.Errorf("source: %w", err)
+	}
+	fdst, err := c.Remote(ctx, dst)
+	if err != nil {
+		return Stats{}, fmt.Errorf("destination: %w", err)
+	}
+
+	// Give each transfer its own stats which are removed afterwards
+	group := "sdk/" + random.String(16)
+	ctx = accounting.WithStatsGroup(ctx, group)
+	stats := accounting.StatsGroup(ctx, group)
+	defer deleteStatsGroup(group)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	if opt.Progress != nil {
+		interval := opt.ProgressInterval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					opt.Progress(newStats(stats))
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	err = fn(ctx, fdst.f, fsrc.f)
+	if err == nil {
+		// Not all backends notice the context being cancelled
+		err = ctx.Err()
+	}
+	close(done)
+	wg.Wait()
+	final := newStats(stats)
+	if opt.Progress != nil {
+		opt.Progress(final)
+	}
+	return final, wrapError(err)
+}
+
+// newStats reads the Stats from stats
+func newStats(stats *accounting.StatsInfo) Stats {
+	out, _ := stats.RemoteStats(true)
+	get := func(key string) int64 {
+		value, _ := out.GetInt64(key)
+		return value
+	}
+	speed, _ := out.GetFloat64("speed")
+	elapsed, _ := out.GetFloat64("elapsedTime")
+	return Stats{
+		Bytes:          get("bytes"),
+		TotalBytes:     get("totalBytes"),
+		Transfers:      get("transfers"),
+		TotalTransfers: get("totalTransfers"),
+		Checks:         get("checks"),
+		Deletes:        get("deletes"),
+		Errors:         get("errors"),
+		Speed:          speed,
+		Elapsed:        time.Duration(elapsed * float64(time.Second)),
+	}
+}
+
+// deleteStatsGroup removes the stats group so it doesn't accumulate
+func deleteStatsGroup(group string) {
+	call := rc.Calls.Get("core/stats-delete")
+	if call == nil {
+		return
+	}
+	_, err := call.Fn(context.Background(), rc.Params{"group": group})
+	if err != nil {
+		fs.Debugf(nil, "Failed to delete stats group %q: %v", group, err)
+	}
+}
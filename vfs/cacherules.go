@@ -0,0 +1,65 @@
+package vfs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/vfs/vfscommon"
+)
+
+// cacheRule sets the cache mode for files matching a glob for
+// --vfs-cache-rules
+type cacheRule struct {
+	re   *regexp.Regexp
+	mode vfscommon.CacheMode
+}
+
+// parseCacheRules parses a comma separated list of glob=mode rules
+func parseCacheRules(rules string) ([]cacheRule, error) {
+	if rules == "" {
+		return nil, nil
+	}
+	var items fs.CommaSepList
+	if err := items.Set(rules); err != nil {
+		return nil, fmt.Errorf("bad cache rules %q: %w", rules, err)
+	}
+	out := make([]cacheRule, 0, len(items))
+	for _, item := range items {
+		glob, modeString, ok := strings.Cut(item, "=")
+		if !ok || glob == "" {
+			return nil, fmt.Errorf("bad cache rule %q: expecting glob=mode", item)
+		}
+		var mode vfscommon.CacheMode
+		if err := mode.Set(modeString); err != nil {
+			return nil, fmt.Errorf("bad cache rule %q: %w", item, err)
+		}
+		re, err := filter.GlobPathToRegexp(glob, false)
+		if err != nil {
+			return nil, fmt.Errorf("bad cache rule %q: %w", item, err)
+		}
+		out = append(out, cacheRule{re: re, mode: mode})
+	}
+	return out, nil
+}
+
+// cacheMode returns the cache mode to use for the file at path.
+//
+// This is the mode of the first rule in --vfs-cache-rules which
+// matches or --vfs-cache-mode if none do. Rules can't turn the cache
+// on if it isn't running.
+func (vfs *VFS) cacheMode(path string) vfscommon.CacheMode {
+	mode := vfs.Opt.CacheMode
+	for _, rule := range vfs.cacheRules {
+		if rule.re.MatchString(path) {
+			mode = rule.mode
+			break
+		}
+	}
+	if vfs.cache == nil {
+		return vfscommon.CacheModeOff
+	}
+	return mode
+}
@@ -0,0 +1,69 @@
+package vfs
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCacheRules(t *testing.T) {
+	rules, err := parseCacheRules("")
+	require.NoError(t, err)
+	assert.Nil(t, rules)
+
+	rules, err = parseCacheRules("*.iso=off,/db/**=full")
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, vfscommon.CacheModeOff, rules[0].mode)
+	assert.True(t, rules[0].re.MatchString("dir/file.iso"))
+	assert.Equal(t, vfscommon.CacheModeFull, rules[1].mode)
+	assert.True(t, rules[1].re.MatchString("db/file"))
+	assert.False(t, rules[1].re.MatchString("dir/db/file"))
+
+	for _, in := range []string{"*.iso", "=off", "*.iso=potato", "[=off"} {
+		_, err = parseCacheRules(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestCacheRules(t *testing.T) {
+	opt := vfscommon.Opt
+	opt.CacheMode = vfscommon.CacheModeWrites
+	opt.CacheRules = "*.iso=off,*.db=full"
+	r, vfs := newTestVFSOpt(t, &opt)
+
+	assert.Equal(t, vfscommon.CacheModeOff, vfs.cacheMode("dir/file.iso"))
+	assert.Equal(t, vfscommon.CacheModeFull, vfs.cacheMode("file.db"))
+	assert.Equal(t, vfscommon.CacheModeWrites, vfs.cacheMode("file.txt"))
+
+	ctx := context.Background()
+	r.WriteObject(ctx, "file.iso", "iso contents", t1)
+	r.WriteObject(ctx, "file.db", "db contents", t1)
+	r.WriteObject(ctx, "file.txt", "txt contents", t1)
+
+	for _, test := range []struct {
+		name  string
+		flags int
+		want  Handle
+	}{
+		{"file.iso", os.O_RDONLY, (*ReadFileHandle)(nil)},
+		{"file.iso", os.O_WRONLY | os.O_TRUNC, (*WriteFileHandle)(nil)},
+		{"file.db", os.O_RDONLY, (*RWFileHandle)(nil)},
+		{"file.txt", os.O_RDONLY, (*ReadFileHandle)(nil)},
+		{"file.txt", os.O_WRONLY | os.O_TRUNC, (*RWFileHandle)(nil)},
+	} {
+		fd, err := vfs.OpenFile(test.name, test.flags, 0777)
+		require.NoError(t, err, test.name)
+		assert.IsType(t, test.want, fd, test.name)
+		require.NoError(t, fd.Close())
+	}
+
+	// Rules can't turn the cache on
+	opt.CacheMode = vfscommon.CacheModeOff
+	_, vfs = newTestVFSOpt(t, &opt)
+	assert.Equal(t, vfscommon.CacheModeOff, vfs.cacheMode("file.db"))
+}
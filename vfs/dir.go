@@ -65,7 +65,7 @@ func newDir(vfs *VFS, f fs.Fs, parent *Dir, fsDir fs.Directory) *Dir {
 		entry:   fsDir,
 		path:    fsDir.Remote(),
 		modTime: fsDir.ModTime(context.TODO()),
-		inode:   newInode(),
+		inode:   vfs.newInode(fsDir, fsDir.Remote()),
 		items:   make(map[string]Node),
 	}
 	// Set timer up like this to avoid race of d.cacheCleanup being called
@@ -540,6 +540,7 @@ func (d *Dir) _readDir() error {
 		return nil
 	}
 	entries, err := list.DirSorted(context.TODO(), d.f, false, d.path)
+	d.vfs.health.Record(err)
 	if err == fs.ErrorDirNotFound {
 		// We treat directory not found as empty because we
 		// create directories on the fly
@@ -1032,6 +1033,25 @@ func (d *Dir) Open(flags int) (fd Handle, err error) {
 	return newDirHandle(d), nil
 }
 
+// existingName returns the name of the entry in d which matches name
+// case insensitively if --vfs-case-insensitive is set and it isn't
+// self, otherwise it returns name.
+//
+// This makes renaming onto a name which differs only in case from an
+// existing entry replace that entry, as it would on a case
+// insensitive file system, rather than make two entries which differ
+// only in case.
+func (d *Dir) existingName(name string, self Node) string {
+	if !d.vfs.Opt.CaseInsensitive {
+		return name
+	}
+	node, err := d.stat(name)
+	if err != nil || node == self {
+		return name
+	}
+	return node.Name()
+}
+
 // Create makes a new file node
 func (d *Dir) Create(name string, flags int) (*File, error) {
 	// fs.Debugf(path, "Dir.Create")
@@ -1182,13 +1202,15 @@ func (d *Dir) Rename(oldName, newName string, destDir *Dir) error {
 		return EROFS
 	}
 	oldPath := path.Join(d.path, oldName)
-	newPath := path.Join(destDir.path, newName)
 	// fs.Debugf(oldPath, "Dir.Rename to %q", newPath)
 	oldNode, err := d.stat(oldName)
 	if err != nil {
 		fs.Errorf(oldPath, "Dir.Rename error: %v", err)
 		return err
 	}
+	oldName = oldNode.Name()
+	newName = destDir.existingName(newName, oldNode)
+	newPath := path.Join(destDir.path, newName)
 	switch x := oldNode.DirEntry().(type) {
 	case nil:
 		if oldFile, ok := oldNode.(*File); ok {
@@ -0,0 +1,80 @@
+package vfs
+
+import (
+	"sort"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// DirUsage is the space used by a directory and everything in it
+// according to the directory cache
+type DirUsage struct {
+	Path     string `json:"path"`     // path of the directory
+	Size     int64  `json:"size"`     // total size of the files in bytes
+	Files    int64  `json:"files"`    // number of files
+	Dirs     int64  `json:"dirs"`     // number of directories not including this one
+	Complete bool   `json:"complete"` // false if some directories haven't been read into the cache yet
+}
+
+// usage returns the usage of d and everything in it using only the
+// directory cache.
+//
+// Directories down to depth levels below d are appended to dirs. If
+// depth is < 0 then all of them are.
+func (d *Dir) usage(depth int, dirs *[]DirUsage) (du DirUsage) {
+	// Take a snapshot of the directory so the lock isn't held
+	// while descending
+	d.mu.RLock()
+	du.Path = d.path
+	du.Complete = !d.read.IsZero()
+	nodes := make([]Node, 0, len(d.items))
+	for _, node := range d.items {
+		nodes = append(nodes, node)
+	}
+	d.mu.RUnlock()
+
+	for _, node := range nodes {
+		switch x := node.(type) {
+		case *File:
+			du.Files++
+			du.Size += x.Size()
+		case *Dir:
+			subDepth := depth
+			if depth > 0 {
+				subDepth--
+			}
+			sub := x.usage(subDepth, dirs)
+			if depth != 0 {
+				*dirs = append(*dirs, sub)
+			}
+			du.Dirs += 1 + sub.Dirs
+			du.Files += sub.Files
+			du.Size += sub.Size
+			du.Complete = du.Complete && sub.Complete
+		}
+	}
+	return du
+}
+
+// Usage returns the space used by dir and everything in it from the
+// directory cache, without reading anything from the remote which
+// isn't cached already.
+//
+// It also returns the usage of each directory down to depth levels
+// below dir sorted by path, or all of them if depth < 0.
+func (vfs *VFS) Usage(dir string, depth int) (total DirUsage, dirs []DirUsage, err error) {
+	node, err := vfs.Stat(dir)
+	if err != nil {
+		return total, nil, err
+	}
+	d, ok := node.(*Dir)
+	if !ok {
+		return total, nil, fs.ErrorIsFile
+	}
+	dirs = []DirUsage{}
+	total = d.usage(depth, &dirs)
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].Path < dirs[j].Path
+	})
+	return total, dirs, nil
+}
@@ -69,7 +69,7 @@ func newFile(d *Dir, dPath string, o fs.Object, leaf string) *File {
 		dPath: dPath,
 		o:     o,
 		leaf:  leaf,
-		inode: newInode(),
+		inode: d.vfs.newInode(o, path.Join(dPath, leaf)),
 	}
 	if o != nil {
 		f.size.Store(o.Size())
@@ -308,12 +308,12 @@ func (f *File) rename(ctx context.Context, destDir *Dir, newName string) error {
 	writing := f._writingInProgress()
 	f.mu.Unlock()
 
-	// Delay the rename if not using RW caching. For the minimal case we
+	// Delay the rename if not using RW caching. For the minimal case,
+	// or if --vfs-cache-rules turned the cache off for this file, we
 	// need to look in the cache to see if caching is in use.
-	CacheMode := d.vfs.Opt.CacheMode
-	if writing &&
-		(CacheMode < vfscommon.CacheModeMinimal ||
-			(CacheMode == vfscommon.CacheModeMinimal && !destDir.vfs.cache.Exists(oldPath))) {
+	CacheMode := d.vfs.cacheMode(oldPath)
+	if writing && CacheMode < vfscommon.CacheModeWrites &&
+		(destDir.vfs.cache == nil || !destDir.vfs.cache.Exists(oldPath)) {
 		fs.Debugf(oldPath, "File is currently open, delaying rename %p", f)
 		f.mu.Lock()
 		f.pendingRenameFun = renameCall
@@ -328,8 +328,12 @@ func (f *File) rename(ctx context.Context, destDir *Dir, newName string) error {
 func (f *File) addWriter(h Handle) {
 	f.mu.Lock()
 	f.writers = append(f.writers, h)
-	f.nwriters.Add(1)
+	first := f.nwriters.Add(1) == 1
+	locks := f.d.vfs.locks
 	f.mu.Unlock()
+	if first && locks != nil {
+		locks.lock(f)
+	}
 }
 
 // delWriter removes a write handle from the file
@@ -346,7 +350,10 @@ func (f *File) delWriter(h Handle) {
 	}
 	if found >= 0 {
 		f.writers = slices.Delete(f.writers, found, found+1)
-		f.nwriters.Add(-1)
+		if f.nwriters.Add(-1) == 0 && f.d.vfs.locks != nil {
+			// release the lock with f.mu unlocked
+			defer f.d.vfs.locks.unlock(f)
+		}
 	} else {
 		fs.Debugf(f._path(), "File.delWriter couldn't find handle")
 	}
@@ -890,8 +897,8 @@ func (f *File) Open(flags int) (fd Handle, err error) {
 	f.mu.RLock()
 	d := f.d
 	f.mu.RUnlock()
-	CacheMode := d.vfs.Opt.CacheMode
-	if CacheMode >= vfscommon.CacheModeMinimal && (d.vfs.cache.InUse(f.CachePath()) || d.vfs.cache.Exists(f.CachePath())) {
+	CacheMode := d.vfs.cacheMode(f.Path())
+	if d.vfs.cache != nil && (d.vfs.cache.InUse(f.CachePath()) || d.vfs.cache.Exists(f.CachePath())) {
 		fd, err = f.openRW(flags)
 	} else if read && write {
 		if CacheMode >= vfscommon.CacheModeMinimal {
@@ -923,6 +930,9 @@ func (f *File) Open(flags int) (fd Handle, err error) {
 		// called without File.mu held
 		d.addObject(f)
 	}
+	if err == nil && d.vfs.limits != nil {
+		fd = &limitedHandle{Handle: fd, l: d.vfs.limits}
+	}
 	return fd, err
 }
 
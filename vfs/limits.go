@@ -0,0 +1,150 @@
+package vfs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/walk"
+	"golang.org/x/time/rate"
+)
+
+// limiter enforces the --vfs BwLimit and Quota options
+type limiter struct {
+	vfs      *VFS
+	bw       *rate.Limiter // nil if no bandwidth limit
+	mu       sync.Mutex
+	used     int64     // bytes used when last measured
+	usedTime time.Time // when used was measured
+	written  int64     // bytes written since usedTime
+}
+
+// newLimiter returns a limiter for vfs or nil if it doesn't need one
+func newLimiter(vfs *VFS) *limiter {
+	if vfs.Opt.BwLimit <= 0 && vfs.Opt.Quota <= 0 {
+		return nil
+	}
+	l := &limiter{vfs: vfs}
+	if bw := int(vfs.Opt.BwLimit); bw > 0 {
+		l.bw = rate.NewLimiter(rate.Limit(bw), bw)
+	}
+	return l
+}
+
+// usedSize returns the total size of the objects in f using the
+// algorithm from `rclone size`
+func usedSize(ctx context.Context, f fs.Fs) (used int64, err error) {
+	err = walk.ListR(ctx, f, "", true, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		entries.ForObject(func(o fs.Object) {
+			used += o.Size()
+		})
+		return nil
+	})
+	return used, err
+}
+
+// wait for n bytes of bandwidth
+func (l *limiter) wait(n int) {
+	if l.bw == nil {
+		return
+	}
+	burst := l.bw.Burst()
+	for n > 0 {
+		chunk := min(n, burst)
+		_ = l.bw.WaitN(context.Background(), chunk)
+		n -= chunk
+	}
+}
+
+// _used returns the number of bytes counted against the quota,
+// measuring the usage again if it is older than --dir-cache-time.
+//
+// Call with l.mu held.
+func (l *limiter) _used() int64 {
+	if l.usedTime.IsZero() || time.Since(l.usedTime) >= time.Duration(l.vfs.Opt.DirCacheTime) {
+		used, err := usedSize(context.TODO(), l.vfs.f)
+		// Don't measure again until --dir-cache-time has passed
+		// even if this failed
+		l.usedTime = time.Now()
+		if err != nil {
+			fs.Errorf(l.vfs.f, "Failed to measure usage for quota: %v", err)
+		} else {
+			l.used, l.written = used, 0
+		}
+	}
+	return l.used + l.written
+}
+
+// quotaUsed returns the number of bytes counted against the quota
+func (l *limiter) quotaUsed() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l._used()
+}
+
+// reserve n bytes of the quota returning ENOSPC if there isn't enough
+// left.
+//
+// Overwritten data is counted until the usage is next measured so
+// this errs on the side of refusing writes.
+func (l *limiter) reserve(n int) error {
+	quota := int64(l.vfs.Opt.Quota)
+	if quota <= 0 || n == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l._used()+int64(n) > quota {
+		return ENOSPC
+	}
+	l.written += int64(n)
+	return nil
+}
+
+// limitedHandle applies the limiter to the data read and written
+// through a Handle
+type limitedHandle struct {
+	Handle
+	l *limiter
+}
+
+// Read bytes from the handle
+func (h *limitedHandle) Read(b []byte) (n int, err error) {
+	n, err = h.Handle.Read(b)
+	h.l.wait(n)
+	return n, err
+}
+
+// ReadAt bytes from the handle
+func (h *limitedHandle) ReadAt(b []byte, off int64) (n int, err error) {
+	n, err = h.Handle.ReadAt(b, off)
+	h.l.wait(n)
+	return n, err
+}
+
+// Write bytes to the handle
+func (h *limitedHandle) Write(b []byte) (n int, err error) {
+	if err = h.l.reserve(len(b)); err != nil {
+		return 0, err
+	}
+	h.l.wait(len(b))
+	return h.Handle.Write(b)
+}
+
+// WriteAt bytes to the handle
+func (h *limitedHandle) WriteAt(b []byte, off int64) (n int, err error) {
+	if err = h.l.reserve(len(b)); err != nil {
+		return 0, err
+	}
+	h.l.wait(len(b))
+	return h.Handle.WriteAt(b, off)
+}
+
+// WriteString to the handle
+func (h *limitedHandle) WriteString(s string) (n int, err error) {
+	return h.Write([]byte(s))
+}
+
+// Check interfaces
+var _ Handle = (*limitedHandle)(nil)
@@ -0,0 +1,78 @@
+package vfs
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVFSNoLimits(t *testing.T) {
+	_, vfs := newTestVFS(t)
+	assert.Nil(t, vfs.limits)
+	fd, err := vfs.OpenFile("file", os.O_CREATE|os.O_WRONLY, 0777)
+	require.NoError(t, err)
+	_, isLimited := fd.(*limitedHandle)
+	assert.False(t, isLimited)
+	require.NoError(t, fd.Close())
+}
+
+func TestVFSQuota(t *testing.T) {
+	opt := vfscommon.Opt
+	opt.Quota = 100
+	_, vfs := newTestVFSOpt(t, &opt)
+	require.NotNil(t, vfs.limits)
+
+	writeFile := func(name string, size int) error {
+		fd, err := vfs.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0777)
+		require.NoError(t, err)
+		_, err = fd.Write(bytes.Repeat([]byte{'x'}, size))
+		closeErr := fd.Close()
+		if err == nil {
+			err = closeErr
+		}
+		return err
+	}
+
+	require.NoError(t, writeFile("file1", 60))
+	assert.Equal(t, ENOSPC, writeFile("file2", 60))
+	require.NoError(t, writeFile("file3", 40))
+
+	total, used, free := vfs.Statfs()
+	assert.Equal(t, int64(100), total)
+	assert.Equal(t, int64(100), used)
+	assert.Equal(t, int64(0), free)
+
+	// Removing files frees the quota when the usage is next measured
+	require.NoError(t, vfs.Remove("file1"))
+	vfs.limits.usedTime = time.Time{}
+	total, used, free = vfs.Statfs()
+	assert.Equal(t, int64(100), total)
+	assert.Equal(t, int64(40), used)
+	assert.Equal(t, int64(60), free)
+	require.NoError(t, writeFile("file2", 60))
+}
+
+func TestVFSBwLimit(t *testing.T) {
+	opt := vfscommon.Opt
+	opt.BwLimit = 64 * fs.Kibi
+	_, vfs := newTestVFSOpt(t, &opt)
+	require.NotNil(t, vfs.limits)
+
+	// The first 64k goes straight away with the burst and the next
+	// 64k should take a second
+	data := bytes.Repeat([]byte{'x'}, 128*1024)
+	start := time.Now()
+	fd, err := vfs.OpenFile("file", os.O_CREATE|os.O_WRONLY, 0777)
+	require.NoError(t, err)
+	n, err := fd.Write(data)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), n)
+	require.NoError(t, fd.Close())
+	assert.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+}
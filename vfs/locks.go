@@ -0,0 +1,225 @@
+package vfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/lib/random"
+)
+
+// lockSuffix is added to the path of a file to make its lock object
+const lockSuffix = ".lock"
+
+// lockInfo is the contents of a lock object
+type lockInfo struct {
+	Owner    string    `json:"owner"`    // unique ID of the VFS holding the lock
+	Host     string    `json:"host"`     // host name of the machine holding the lock
+	Path     string    `json:"path"`     // path of the file within the VFS
+	Acquired time.Time `json:"acquired"` // when the lock was first taken
+	Expires  time.Time `json:"expires"`  // when the lock may be stolen
+}
+
+// String describes who holds the lock
+func (li *lockInfo) String() string {
+	return fmt.Sprintf("%s (%s) since %s", li.Host, li.Owner, li.Acquired.Format(time.RFC3339))
+}
+
+// remoteLocks takes advisory locks on files open for write by writing
+// lock objects to --vfs-lock-dir so that other rclone mounts of the
+// same remote can warn about conflicting edits.
+//
+// The locks are best-effort - a conflict is only ever logged and the
+// file is opened regardless.
+type remoteLocks struct {
+	f     fs.Fs         // where the lock objects are stored
+	ttl   time.Duration // how long a lock lasts without refreshing
+	owner string        // unique ID for this VFS
+	host  string        // host name for this machine
+	mu    sync.Mutex
+	held  map[*File]*lockInfo // locks held by this VFS
+}
+
+// newRemoteLocks returns the locks for vfs or nil if --vfs-lock-dir
+// isn't set or can't be used
+func newRemoteLocks(ctx context.Context, vfs *VFS) *remoteLocks {
+	if vfs.Opt.LockDir == "" {
+		return nil
+	}
+	f, err := cache.Get(ctx, vfs.Opt.LockDir)
+	if err != nil {
+		fs.Errorf(vfs.f, "vfs locks: disabled as failed to open --vfs-lock-dir: %v", err)
+		return nil
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	l := &remoteLocks{
+		f:     f,
+		ttl:   max(time.Duration(vfs.Opt.LockTTL), time.Second),
+		owner: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), random.String(8)),
+		host:  host,
+		held:  make(map[*File]*lockInfo),
+	}
+	go l.refresher(ctx)
+	return l
+}
+
+// read the lock object for path returning nil if there isn't one
+func (l *remoteLocks) read(ctx context.Context, path string) (li *lockInfo, err error) {
+	o, err := l.f.NewObject(ctx, path+lockSuffix)
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	in, err := o.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.CheckClose(in, &err)
+	li = new(lockInfo)
+	if err = json.NewDecoder(in).Decode(li); err != nil {
+		return nil, fmt.Errorf("bad lock object: %w", err)
+	}
+	return li, nil
+}
+
+// write the lock object for li
+func (l *remoteLocks) write(ctx context.Context, li *lockInfo) error {
+	data, err := json.Marshal(li)
+	if err != nil {
+		return err
+	}
+	_, err = operations.RcatSize(ctx, l.f, li.Path+lockSuffix, io.NopCloser(bytes.NewReader(data)), int64(len(data)), time.Now(), nil)
+	return err
+}
+
+// remove the lock object for path
+func (l *remoteLocks) remove(ctx context.Context, path string) error {
+	o, err := l.f.NewObject(ctx, path+lockSuffix)
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return o.Remove(ctx)
+}
+
+// acquire takes the lock for the file, returning the lock of the
+// other owner if someone else holds it.
+//
+// Expired locks are stolen.
+func (l *remoteLocks) acquire(ctx context.Context, f *File) (conflict *lockInfo, err error) {
+	path := f.Path()
+	li, err := l.read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if li != nil && li.Owner != l.owner {
+		if now.Before(li.Expires) {
+			return li, nil
+		}
+		fs.Infof(path, "vfs locks: stealing expired lock held by %v", li)
+	}
+	li = &lockInfo{
+		Owner:    l.owner,
+		Host:     l.host,
+		Path:     path,
+		Acquired: now,
+		Expires:  now.Add(l.ttl),
+	}
+	if err = l.write(ctx, li); err != nil {
+		return nil, err
+	}
+	// Read it back in case someone else took it at the same time
+	check, err := l.read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if check != nil && check.Owner != l.owner {
+		return check, nil
+	}
+	l.mu.Lock()
+	l.held[f] = li
+	l.mu.Unlock()
+	return nil, nil
+}
+
+// lock takes the lock for the file when it is opened for write,
+// logging a warning if someone else holds it
+func (l *remoteLocks) lock(f *File) {
+	conflict, err := l.acquire(context.TODO(), f)
+	if err != nil {
+		fs.Errorf(f.Path(), "vfs locks: failed to take lock: %v", err)
+	} else if conflict != nil {
+		fs.Logf(f.Path(), "vfs locks: file is being edited by %v - changes may overwrite each other", conflict)
+	}
+}
+
+// unlock releases the lock for the file if held when the last writer
+// closes it
+func (l *remoteLocks) unlock(f *File) {
+	l.mu.Lock()
+	li, ok := l.held[f]
+	delete(l.held, f)
+	l.mu.Unlock()
+	if !ok {
+		return
+	}
+	ctx := context.TODO()
+	current, err := l.read(ctx, li.Path)
+	if err != nil {
+		fs.Errorf(li.Path, "vfs locks: failed to read lock: %v", err)
+		return
+	}
+	if current != nil && current.Owner != l.owner {
+		fs.Logf(li.Path, "vfs locks: lock was taken by %v while the file was open - changes may overwrite each other", current)
+		return
+	}
+	if err = l.remove(ctx, li.Path); err != nil {
+		fs.Errorf(li.Path, "vfs locks: failed to release lock: %v", err)
+	}
+}
+
+// refresh extends the expiry of all the locks held
+func (l *remoteLocks) refresh(ctx context.Context) {
+	l.mu.Lock()
+	held := make([]lockInfo, 0, len(l.held))
+	for _, li := range l.held {
+		li.Expires = time.Now().Add(l.ttl)
+		held = append(held, *li)
+	}
+	l.mu.Unlock()
+	for i := range held {
+		if err := l.write(ctx, &held[i]); err != nil {
+			fs.Errorf(held[i].Path, "vfs locks: failed to refresh lock: %v", err)
+		}
+	}
+}
+
+// refresher refreshes the locks held every half --vfs-lock-ttl until
+// ctx is cancelled
+func (l *remoteLocks) refresher(ctx context.Context) {
+	ticker := time.NewTicker(l.ttl / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.refresh(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
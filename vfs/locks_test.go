@@ -0,0 +1,106 @@
+package vfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVFSNoLocks(t *testing.T) {
+	_, vfs := newTestVFS(t)
+	assert.Nil(t, vfs.locks)
+}
+
+func TestVFSLocks(t *testing.T) {
+	ctx := context.Background()
+	lockDir := t.TempDir()
+	opt := vfscommon.Opt
+	opt.LockDir = lockDir
+	r, vfs1 := newTestVFSOpt(t, &opt)
+	require.NotNil(t, vfs1.locks)
+
+	// A second mount of the same remote - differing options stop
+	// the VFS being shared
+	opt.LockTTL = fs.Duration(time.Hour)
+	vfs2 := New(r.Fremote, &opt)
+	t.Cleanup(func() { cleanupVFS(t, vfs2) })
+	require.NotEqual(t, vfs1, vfs2)
+	require.NotEqual(t, vfs1.locks.owner, vfs2.locks.owner)
+
+	lockPath := filepath.Join(lockDir, "dir", "file.txt"+lockSuffix)
+	r.WriteObject(ctx, "dir/file.txt", "", time.Now())
+	file1, err := vfs1.Stat("dir/file.txt")
+	require.NoError(t, err)
+	file2, err := vfs2.Stat("dir/file.txt")
+	require.NoError(t, err)
+
+	// Opening for write takes the lock
+	fd1, err := vfs1.OpenFile("dir/file.txt", os.O_WRONLY|os.O_TRUNC, 0777)
+	require.NoError(t, err)
+	assert.FileExists(t, lockPath)
+	li, err := vfs1.locks.read(ctx, "dir/file.txt")
+	require.NoError(t, err)
+	require.NotNil(t, li)
+	assert.Equal(t, vfs1.locks.owner, li.Owner)
+	assert.Equal(t, "dir/file.txt", li.Path)
+
+	// The other mount sees the conflict
+	conflict, err := vfs2.locks.acquire(ctx, file2.(*File))
+	require.NoError(t, err)
+	require.NotNil(t, conflict)
+	assert.Equal(t, vfs1.locks.owner, conflict.Owner)
+
+	// Closing releases it
+	_, err = fd1.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, fd1.Close())
+	assert.NoFileExists(t, lockPath)
+
+	// Now the other mount can take it
+	conflict, err = vfs2.locks.acquire(ctx, file2.(*File))
+	require.NoError(t, err)
+	assert.Nil(t, conflict)
+	assert.FileExists(t, lockPath)
+
+	// Expired locks are stolen
+	li, err = vfs2.locks.read(ctx, "dir/file.txt")
+	require.NoError(t, err)
+	li.Expires = time.Now().Add(-time.Second)
+	require.NoError(t, vfs2.locks.write(ctx, li))
+	conflict, err = vfs1.locks.acquire(ctx, file1.(*File))
+	require.NoError(t, err)
+	assert.Nil(t, conflict)
+
+	// and the loser doesn't remove the new owner's lock
+	vfs2.locks.unlock(file2.(*File))
+	li, err = vfs1.locks.read(ctx, "dir/file.txt")
+	require.NoError(t, err)
+	require.NotNil(t, li)
+	assert.Equal(t, vfs1.locks.owner, li.Owner)
+
+	// Refreshing extends the expiry
+	before := li.Expires
+	time.Sleep(10 * time.Millisecond)
+	vfs1.locks.refresh(ctx)
+	li, err = vfs1.locks.read(ctx, "dir/file.txt")
+	require.NoError(t, err)
+	assert.True(t, li.Expires.After(before))
+	vfs1.locks.unlock(file1.(*File))
+	assert.NoFileExists(t, lockPath)
+}
+
+func TestVFSLocksBadDir(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(lockFile, []byte("x"), 0666))
+	opt := vfscommon.Opt
+	opt.LockDir = lockFile
+	_, vfs := newTestVFSOpt(t, &opt)
+	assert.Nil(t, vfs.locks)
+}
@@ -0,0 +1,91 @@
+package vfs
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs/vfscommon"
+)
+
+// prefetcher reads the start of the next file in a directory into the
+// cache for --vfs-prefetch-policy media
+type prefetcher struct {
+	vfs     *VFS
+	mu      sync.Mutex
+	running map[string]struct{} // paths being prefetched
+}
+
+// newPrefetcher returns a prefetcher for vfs or nil if it doesn't need one
+func newPrefetcher(vfs *VFS) *prefetcher {
+	if vfs.Opt.PrefetchPolicy != vfscommon.PrefetchMedia || vfs.Opt.CacheMode < vfscommon.CacheModeFull {
+		return nil
+	}
+	return &prefetcher{
+		vfs:     vfs,
+		running: make(map[string]struct{}),
+	}
+}
+
+// nextFile returns the file after f in its directory or nil if there
+// isn't one
+func nextFile(f *File) *File {
+	name := f.Name()
+	nodes, err := f.Dir().ReadDirAll()
+	if err != nil {
+		fs.Debugf(f.Path(), "vfs prefetch: failed to list directory: %v", err)
+		return nil
+	}
+	found := false
+	for _, node := range nodes {
+		if !found {
+			found = node.Name() == name
+			continue
+		}
+		if next, ok := node.(*File); ok {
+			return next
+		}
+	}
+	return nil
+}
+
+// prefetchNext reads the first --vfs-prefetch-max bytes of the file
+// after f in its directory into the cache.
+//
+// It is run in the background when a reader gets half way through f.
+func (p *prefetcher) prefetchNext(f *File) {
+	next := nextFile(f)
+	if next == nil {
+		return
+	}
+	path := next.Path()
+	p.mu.Lock()
+	if _, ok := p.running[path]; ok {
+		p.mu.Unlock()
+		return
+	}
+	p.running[path] = struct{}{}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.running, path)
+		p.mu.Unlock()
+	}()
+
+	fh, err := next.openRW(os.O_RDONLY)
+	if err != nil {
+		fs.Debugf(path, "vfs prefetch: failed to open: %v", err)
+		return
+	}
+	// Don't let this handle start a chain of prefetches
+	fh.prefetched = true
+	n, err := io.Copy(io.Discard, io.LimitReader(fh, int64(p.vfs.Opt.PrefetchMax)))
+	if err != nil {
+		fs.Debugf(path, "vfs prefetch: failed to read: %v", err)
+	}
+	if closeErr := fh.Close(); closeErr != nil {
+		fs.Debugf(path, "vfs prefetch: failed to close: %v", closeErr)
+	}
+	fs.Debugf(path, "vfs prefetch: read %d bytes into the cache", n)
+}
@@ -0,0 +1,59 @@
+package vfs
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/lib/ranges"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVFSNoPrefetch(t *testing.T) {
+	opt := vfscommon.Opt
+	opt.CacheMode = vfscommon.CacheModeWrites
+	opt.PrefetchPolicy = vfscommon.PrefetchMedia
+	_, vfs := newTestVFSOpt(t, &opt)
+	assert.Nil(t, vfs.prefetch)
+}
+
+func TestVFSPrefetchMedia(t *testing.T) {
+	opt := vfscommon.Opt
+	opt.CacheMode = vfscommon.CacheModeFull
+	opt.PrefetchPolicy = vfscommon.PrefetchMedia
+	opt.PrefetchMax = 4
+	r, vfs := newTestVFSOpt(t, &opt)
+	require.NotNil(t, vfs.prefetch)
+
+	ctx := context.Background()
+	t1 := time.Now()
+	r.WriteObject(ctx, "dir/1.mkv", "0123456789", t1)
+	r.WriteObject(ctx, "dir/2.mkv", "abcdefghij", t1)
+	r.WriteObject(ctx, "dir/3.mkv", "ABCDEFGHIJ", t1)
+
+	fd, err := vfs.OpenFile("dir/1.mkv", os.O_RDONLY, 0)
+	require.NoError(t, err)
+
+	// Reading less than half way doesn't prefetch
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(fd, buf)
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, vfs.cache.Exists("dir/2.mkv"))
+
+	// Reading past half way prefetches the start of the next file
+	_, err = io.ReadFull(fd, buf)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return vfs.cache.Item("dir/2.mkv").HasRange(ranges.Range{Pos: 0, Size: 4})
+	}, 10*time.Second, 10*time.Millisecond)
+	require.NoError(t, fd.Close())
+
+	// The prefetch doesn't chain on to the file after
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, vfs.cache.Exists("dir/3.mkv"))
+}
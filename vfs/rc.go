@@ -25,8 +25,15 @@ must be supplied.`
 // If "fs" is not set and there is one and only one VFS in the active
 // cache then it returns it. This is for backwards compatibility.
 //
+// If the call was made with a prefix added by AddRcPrefix then the
+// VFS for that prefix is returned and "fs" is ignored.
+//
 // This deletes the "fs" parameter from in if it is valid
-func getVFS(in rc.Params) (vfs *VFS, err error) {
+func getVFS(ctx context.Context, in rc.Params) (vfs *VFS, err error) {
+	if vfs, ok := ctx.Value(vfsKey{}).(*VFS); ok {
+		delete(in, "fs")
+		return vfs, nil
+	}
 	fsString, err := in.GetString("fs")
 	if rc.IsErrParamNotFound(err) {
 		var count int
@@ -53,6 +60,38 @@ func getVFS(in rc.Params) (vfs *VFS, err error) {
 	return activeVFS[0], nil
 }
 
+// vfsKey is the context key for the VFS selected by AddRcPrefix
+type vfsKey struct{}
+
+// AddRcPrefix adds a copy of each of the vfs/* rc calls as
+// prefix/vfs/* which always use the VFS returned by get.
+//
+// This is used to give named mounts their own rc calls. It returns a
+// function to remove the calls again.
+func AddRcPrefix(prefix string, get func() *VFS) (remove func()) {
+	prefix = strings.Trim(prefix, "/")
+	var paths []string
+	for _, call := range rc.Calls.List() {
+		if !strings.HasPrefix(call.Path, "vfs/") {
+			continue
+		}
+		fn := call.Fn
+		prefixed := *call
+		prefixed.Path = prefix + "/" + call.Path
+		prefixed.Help = fmt.Sprintf("This is %s for the VFS at %s and ignores the \"fs\" parameter.", call.Path, prefix)
+		prefixed.Fn = func(ctx context.Context, in rc.Params) (rc.Params, error) {
+			return fn(context.WithValue(ctx, vfsKey{}, get()), in)
+		}
+		rc.Add(prefixed)
+		paths = append(paths, prefixed.Path)
+	}
+	return func() {
+		for _, path := range paths {
+			rc.Calls.Remove(path)
+		}
+	}
+}
+
 func init() {
 	rc.Add(rc.Call{
 		Path:  "vfs/refresh",
@@ -78,7 +117,7 @@ will get refreshed. This refresh will use --fast-list if enabled.
 }
 
 func rcRefresh(ctx context.Context, in rc.Params) (out rc.Params, err error) {
-	vfs, err := getVFS(in)
+	vfs, err := getVFS(ctx, in)
 	if err != nil {
 		return nil, err
 	}
@@ -192,7 +231,7 @@ starting with dir will forget that dir, e.g.
 }
 
 func rcForget(ctx context.Context, in rc.Params) (out rc.Params, err error) {
-	vfs, err := getVFS(in)
+	vfs, err := getVFS(ctx, in)
 	if err != nil {
 		return nil, err
 	}
@@ -316,7 +355,7 @@ used remote.
 }
 
 func rcPollInterval(ctx context.Context, in rc.Params) (out rc.Params, err error) {
-	vfs, err := getVFS(in)
+	vfs, err := getVFS(ctx, in)
 	if err != nil {
 		return nil, err
 	}
@@ -374,17 +413,9 @@ parameter.`,
 }
 
 func rcList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
-	activeMu.Lock()
-	defer activeMu.Unlock()
 	var names = []string{}
-	for name, vfses := range active {
-		if len(vfses) == 1 {
-			names = append(names, name)
-		} else {
-			for i := range vfses {
-				names = append(names, fmt.Sprintf("%s[%d]", name, i))
-			}
-		}
+	for name := range Active() {
+		names = append(names, name)
 	}
 	out = rc.Params{}
 	out["vfses"] = names
@@ -402,6 +433,8 @@ This returns stats for the selected VFS.
         // Status of the disk cache - only present if --vfs-cache-mode > off
         "diskCache": {
             "bytesUsed": 0,
+            "dirtyBytes": 0,
+            "dirtyFiles": 0,
             "erroredFiles": 0,
             "files": 0,
             "hashType": 1,
@@ -432,13 +465,54 @@ This returns stats for the selected VFS.
 }
 
 func rcStats(ctx context.Context, in rc.Params) (out rc.Params, err error) {
-	vfs, err := getVFS(in)
+	vfs, err := getVFS(ctx, in)
 	if err != nil {
 		return nil, err
 	}
 	return vfs.Stats(), nil
 }
 
+func init() {
+	rc.Add(rc.Call{
+		Path:  "vfs/health",
+		Title: "Health of a VFS.",
+		Help: strings.ReplaceAll(`
+This returns how well the selected VFS is working with its backend.
+
+It records the last time a listing, read or upload to the backend
+succeeded and the last error. Errors like files not being found don't
+count as failures. The cache fields are 0 if |--vfs-cache-mode| is off.
+
+    {
+        "lastSuccess": "2024-01-02T15:04:05.123Z",  // last successful backend operation
+        "lastError": "",                             // last error from the backend
+        "lastErrorTime": "0001-01-01T00:00:00Z",     // when the last error was
+        "errors": 0,                                 // number of failed operations
+        "dirtyFiles": 0,                             // files not yet uploaded
+        "dirtyBytes": 0,                             // bytes not yet uploaded
+        "uploadsInProgress": 0,
+        "uploadsQueued": 0,
+        "erroredFiles": 0,                           // files which failed to upload
+        "outOfSpace": false                          // set if the cache disk is full
+    }
+
+`, "|", "`") + getVFSHelp,
+		Fn: rcHealth,
+	})
+}
+
+func rcHealth(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	err = rc.Reshape(&out, vfs.Health())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func init() {
 	rc.Add(rc.Call{
 		Path:  "vfs/queue",
@@ -477,7 +551,7 @@ may be files with negative expiry times for which |uploading| is
 }
 
 func rcQueue(ctx context.Context, in rc.Params) (out rc.Params, err error) {
-	vfs, err := getVFS(in)
+	vfs, err := getVFS(ctx, in)
 	if err != nil {
 		return nil, err
 	}
@@ -524,7 +598,7 @@ This returns an empty result on success, or an error.
 }
 
 func rcQueueSetExpiry(ctx context.Context, in rc.Params) (out rc.Params, err error) {
-	vfs, err := getVFS(in)
+	vfs, err := getVFS(ctx, in)
 	if err != nil {
 		return nil, err
 	}
@@ -554,3 +628,167 @@ func rcQueueSetExpiry(ctx context.Context, in rc.Params) (out rc.Params, err err
 	err = vfs.cache.QueueSetExpiry(writeback.Handle(id), refTime, time.Duration(float64(time.Second)*expiry))
 	return nil, err
 }
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "vfs/set",
+		Title: "Get or set the write back options of a VFS.",
+		Help: strings.ReplaceAll(`
+Without any parameters this returns the current write back options
+for the selected VFS. Any of these parameters can be passed to update
+them while the VFS is running:
+
+- |writeBackOrder| - order to upload files which are ready in - |expiry|, |smallest| or |oldest|
+- |writeBackPriority| - comma separated list of globs - files matching earlier globs are uploaded first
+- |writeBackBwLimit| - upload bandwidth limit in bytes/s with optional suffix, eg |10M|, |0| for off
+
+    rclone rc vfs/set writeBackOrder=smallest writeBackBwLimit=10M
+
+These are the same as the |--vfs-write-back-order|,
+|--vfs-write-back-priority| and |--vfs-write-back-bwlimit| flags.
+
+This returns the values in use after any updates, for example
+
+    {
+        "writeBackBwLimit": "10Mi",
+        "writeBackOrder": "smallest",
+        "writeBackPriority": ""
+    }
+
+This will return an error if called with |--vfs-cache-mode| off.
+`, "|", "`") + getVFSHelp,
+		Fn: rcSet,
+	})
+}
+
+func rcSet(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if vfs.cache == nil {
+		return nil, rc.NewErrParamInvalid(errors.New("can't call this unless using the VFS cache"))
+	}
+	wb := vfs.cache.WriteBack()
+	order, priority := wb.Order()
+	bwLimit := wb.BwLimit()
+
+	// Read input values
+	getValue := func(key string, value interface{ Set(string) error }) error {
+		s, err := in.GetString(key)
+		if rc.IsErrParamNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		delete(in, key)
+		if err = value.Set(s); err != nil {
+			return rc.NewErrParamInvalid(fmt.Errorf("bad %s: %w", key, err))
+		}
+		return nil
+	}
+	if err = getValue("writeBackOrder", &order); err != nil {
+		return nil, err
+	}
+	if err = getValue("writeBackBwLimit", &bwLimit); err != nil {
+		return nil, err
+	}
+	if s, err := in.GetString("writeBackPriority"); err == nil {
+		priority = s
+		delete(in, "writeBackPriority")
+	} else if !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+	for k, v := range in {
+		return nil, fmt.Errorf("invalid parameter: %s=%s", k, v)
+	}
+
+	// Apply them
+	if err = wb.SetOrder(order, priority); err != nil {
+		return nil, rc.NewErrParamInvalid(err)
+	}
+	wb.SetBwLimit(bwLimit)
+	vfs.Opt.WriteBackOrder = order
+	vfs.Opt.WriteBackPriority = priority
+	vfs.Opt.WriteBackBwLimit = bwLimit
+
+	return rc.Params{
+		"writeBackOrder":    order.String(),
+		"writeBackPriority": priority,
+		"writeBackBwLimit":  bwLimit.String(),
+	}, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "vfs/du",
+		Title: "Space used by a directory tree from the VFS directory cache.",
+		Help: strings.ReplaceAll(`
+This returns the total size and number of files and directories in a
+directory and everything in it, using only what is in the VFS
+directory cache. Nothing is read from the remote that isn't cached
+already (apart from finding |dir|) so this is much quicker than
+|rclone size| or |operations/size| but only as up to date as the
+cache.
+
+This takes the following parameters
+
+- |fs| - select the VFS in use (optional)
+- |dir| - the directory to report on (optional, default the root)
+- |depth| - also report on each directory this many levels below |dir| (optional, default 1, -1 for all)
+
+For example
+
+    rclone rc vfs/du dir=photos depth=1
+
+Returns
+
+    {
+        "total": {
+            "path": "photos",
+            "size": 3072,     // integer: total size of the files in bytes
+            "files": 3,       // integer: number of files
+            "dirs": 2,        // integer: number of directories inside
+            "complete": true  // boolean: false if some directories haven't been cached yet
+        },
+        "dirs": [
+            {"path": "photos/2024", "size": 1024, "files": 1, "dirs": 0, "complete": true},
+            {"path": "photos/2025", "size": 1024, "files": 1, "dirs": 0, "complete": true}
+        ]
+    }
+
+The |dirs| are sorted by path and each includes everything below it.
+
+If |complete| is |false| then some directories have never been listed
+so their contents aren't counted. Use |vfs/refresh| with
+|recursive=true| first to read the whole tree into the cache.
+`, "|", "`") + getVFSHelp,
+		Fn: rcDu,
+	})
+}
+
+func rcDu(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := in.GetString("dir")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+	depth := int64(1)
+	if _, ok := in["depth"]; ok {
+		depth, err = in.GetInt64("depth")
+		if err != nil {
+			return nil, err
+		}
+	}
+	total, dirs, err := vfs.Usage(dir, int(depth))
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{
+		"total": total,
+		"dirs":  dirs,
+	}, nil
+}
@@ -3,6 +3,7 @@ package vfs
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/rc"
@@ -24,24 +25,24 @@ func rcNewRun(t *testing.T, method string) (r *fstest.Run, vfs *VFS, call *rc.Ca
 
 func TestRcGetVFS(t *testing.T) {
 	in := rc.Params{}
-	vfs, err := getVFS(in)
+	vfs, err := getVFS(context.Background(), in)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "no VFS active")
 	assert.Nil(t, vfs)
 
 	r, vfs2 := newTestVFS(t)
 
-	vfs, err = getVFS(in)
+	vfs, err = getVFS(context.Background(), in)
 	require.NoError(t, err)
 	assert.True(t, vfs == vfs2)
 
 	inPresent := rc.Params{"fs": fs.ConfigString(r.Fremote)}
-	vfs, err = getVFS(inPresent)
+	vfs, err = getVFS(context.Background(), inPresent)
 	require.NoError(t, err)
 	assert.True(t, vfs == vfs2)
 
 	inWrong := rc.Params{"fs": fs.ConfigString(r.Fremote) + "notfound"}
-	vfs, err = getVFS(inWrong)
+	vfs, err = getVFS(context.Background(), inWrong)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "no VFS found with name")
 	assert.Nil(t, vfs)
@@ -51,13 +52,13 @@ func TestRcGetVFS(t *testing.T) {
 	vfs3 := New(r.Fremote, &opt)
 	defer vfs3.Shutdown()
 
-	vfs, err = getVFS(in)
+	vfs, err = getVFS(context.Background(), in)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "more than one VFS active - need")
 	assert.Nil(t, vfs)
 
 	inPresent = rc.Params{"fs": fs.ConfigString(r.Fremote)}
-	vfs, err = getVFS(inPresent)
+	vfs, err = getVFS(context.Background(), inPresent)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "more than one VFS active with name")
 	assert.Nil(t, vfs)
@@ -125,3 +126,143 @@ func TestRcStats(t *testing.T) {
 	assert.Equal(t, 1, out["metadataCache"].(rc.Params)["dirs"])
 	assert.Equal(t, vfs.Opt, out["opt"].(vfscommon.Options))
 }
+
+func TestRcHealth(t *testing.T) {
+	r, vfs, call := rcNewRun(t, "vfs/health")
+	_, err := vfs.ReadDir("")
+	require.NoError(t, err)
+	out, err := call.Fn(context.Background(), rc.Params{"fs": fs.ConfigString(r.Fremote)})
+	require.NoError(t, err)
+	assert.NotEqual(t, time.Time{}.Format(time.RFC3339Nano), out["lastSuccess"])
+	assert.Equal(t, "", out["lastError"])
+	assert.Equal(t, float64(0), out["errors"])
+	assert.Equal(t, float64(0), out["dirtyBytes"])
+	assert.Equal(t, false, out["outOfSpace"])
+}
+
+func TestRcSetNoCache(t *testing.T) {
+	_, _, call := rcNewRun(t, "vfs/set")
+	_, err := call.Fn(context.Background(), rc.Params{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "VFS cache")
+}
+
+func TestRcSet(t *testing.T) {
+	if *fstest.RemoteName != "" {
+		t.Skip("Skipping test on non local remote")
+	}
+	call := rc.Calls.Get("vfs/set")
+	require.NotNil(t, call)
+	ctx := context.Background()
+
+	opt := vfscommon.Opt
+	opt.CacheMode = vfscommon.CacheModeWrites
+	_, vfs := newTestVFSOpt(t, &opt)
+
+	out, err := call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"writeBackOrder":    "expiry",
+		"writeBackPriority": "",
+		"writeBackBwLimit":  "0",
+	}, out)
+
+	out, err = call.Fn(ctx, rc.Params{
+		"writeBackOrder":    "smallest",
+		"writeBackPriority": "important/**,*.doc",
+		"writeBackBwLimit":  "10M",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"writeBackOrder":    "smallest",
+		"writeBackPriority": "important/**,*.doc",
+		"writeBackBwLimit":  "10Mi",
+	}, out)
+	assert.Equal(t, vfscommon.WriteBackOrderSmallest, vfs.Opt.WriteBackOrder)
+	assert.Equal(t, fs.SizeSuffix(10*fs.Mebi), vfs.cache.WriteBack().BwLimit())
+
+	// Unset parameters are left alone
+	out, err = call.Fn(ctx, rc.Params{"writeBackBwLimit": "off"})
+	require.NoError(t, err)
+	assert.Equal(t, "smallest", out["writeBackOrder"])
+	assert.Equal(t, "off", out["writeBackBwLimit"])
+
+	for _, in := range []rc.Params{
+		{"writeBackOrder": "potato"},
+		{"writeBackPriority": "[a"},
+		{"writeBackBwLimit": "fast"},
+		{"potato": "1"},
+	} {
+		_, err = call.Fn(ctx, in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestRcDu(t *testing.T) {
+	r, vfs, call := rcNewRun(t, "vfs/du")
+	ctx := context.Background()
+	t1 := time.Now()
+	r.WriteObject(ctx, "file", "12345", t1)
+	r.WriteObject(ctx, "a/one", "1", t1)
+	r.WriteObject(ctx, "a/b/two", "12", t1)
+	r.WriteObject(ctx, "c/three", "123", t1)
+
+	// Nothing below the root has been read yet
+	_, err := vfs.Stat("file")
+	require.NoError(t, err)
+	out, err := call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, DirUsage{Path: "", Size: 5, Files: 1, Dirs: 2, Complete: false}, out["total"])
+
+	// Read everything into the cache
+	require.NoError(t, vfs.root.readDirTree())
+
+	out, err = call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, DirUsage{Path: "", Size: 11, Files: 4, Dirs: 3, Complete: true}, out["total"])
+	assert.Equal(t, []DirUsage{
+		{Path: "a", Size: 3, Files: 2, Dirs: 1, Complete: true},
+		{Path: "c", Size: 3, Files: 1, Dirs: 0, Complete: true},
+	}, out["dirs"])
+
+	out, err = call.Fn(ctx, rc.Params{"dir": "a", "depth": -1})
+	require.NoError(t, err)
+	assert.Equal(t, DirUsage{Path: "a", Size: 3, Files: 2, Dirs: 1, Complete: true}, out["total"])
+	assert.Equal(t, []DirUsage{
+		{Path: "a/b", Size: 2, Files: 1, Dirs: 0, Complete: true},
+	}, out["dirs"])
+
+	out, err = call.Fn(ctx, rc.Params{"depth": 0})
+	require.NoError(t, err)
+	assert.Equal(t, []DirUsage{}, out["dirs"])
+
+	_, err = call.Fn(ctx, rc.Params{"dir": "file"})
+	assert.ErrorIs(t, err, fs.ErrorIsFile)
+	_, err = call.Fn(ctx, rc.Params{"dir": "potato"})
+	assert.Error(t, err)
+}
+
+func TestRcPrefix(t *testing.T) {
+	r, _ := newTestVFS(t)
+	opt := vfscommon.Opt
+	opt.NoModTime = true
+	vfs2 := New(r.Fremote, &opt)
+	defer vfs2.Shutdown()
+
+	remove := AddRcPrefix("mount/test", func() *VFS { return vfs2 })
+	call := rc.Calls.Get("mount/test/vfs/stats")
+	require.NotNil(t, call)
+	assert.Contains(t, call.Help, "vfs/stats")
+
+	// Without the prefix there are too many VFSes to choose from
+	_, err := rc.Calls.Get("vfs/stats").Fn(context.Background(), rc.Params{"fs": fs.ConfigString(r.Fremote)})
+	require.Error(t, err)
+
+	out, err := call.Fn(context.Background(), rc.Params{"fs": "ignored"})
+	require.NoError(t, err)
+	assert.Equal(t, true, out["opt"].(vfscommon.Options).NoModTime)
+
+	remove()
+	assert.Nil(t, rc.Calls.Get("mount/test/vfs/stats"))
+	assert.NotNil(t, rc.Calls.Get("vfs/stats"))
+}
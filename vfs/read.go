@@ -77,6 +77,7 @@ func (fh *ReadFileHandle) openPending() (err error) {
 	o := fh.file.getObject()
 	opt := &fh.file.VFS().Opt
 	r, err := chunkedreader.New(context.TODO(), o, int64(opt.ChunkSize), int64(opt.ChunkSizeLimit), opt.ChunkStreams).Open()
+	fh.file.VFS().health.Record(err)
 	if err != nil {
 		return err
 	}
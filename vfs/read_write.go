@@ -28,6 +28,7 @@ type RWFileHandle struct {
 	closed      bool  // set if handle has been closed
 	opened      bool
 	writeCalled bool // if any Write() methods have been called
+	prefetched  bool // set if the next file has been prefetched
 }
 
 // Lock performs Unix locking, not supported
@@ -278,9 +279,24 @@ func (fh *RWFileHandle) _readAt(b []byte, off int64, release bool) (n int, err e
 	if release {
 		fh.mu.Lock()
 	}
+	fh._prefetchNext(off + int64(n))
 	return n, err
 }
 
+// _prefetchNext starts prefetching the next file in the directory
+// once the reader is half way through this one if
+// --vfs-prefetch-policy media is set.
+//
+// Call with fh.mu held
+func (fh *RWFileHandle) _prefetchNext(offset int64) {
+	p := fh.d.vfs.prefetch
+	if p == nil || fh.prefetched || !fh.readOnly() || offset < fh._size()/2 {
+		return
+	}
+	fh.prefetched = true
+	go p.prefetchNext(fh.file)
+}
+
 // ReadAt bytes from the file at off
 func (fh *RWFileHandle) ReadAt(b []byte, off int64) (n int, err error) {
 	fh.mu.Lock()
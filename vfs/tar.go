@@ -0,0 +1,86 @@
+package vfs
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// CreateTar creates a tar file from a vfs.Dir writing it to w
+//
+// The tar is streamed so nothing is staged locally. Files whose size
+// isn't known can't be stored in a tar so they are skipped with an
+// error logged.
+func CreateTar(ctx context.Context, dir *Dir, w io.Writer) (err error) {
+	tarWriter := tar.NewWriter(w)
+	defer fs.CheckClose(tarWriter, &err)
+	var walk func(dir *Dir, root string) error
+	walk = func(dir *Dir, root string) error {
+		nodes, err := dir.ReadDirAll()
+		if err != nil {
+			return fmt.Errorf("create tar directory read: %w", err)
+		}
+		for _, node := range nodes {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			switch e := node.(type) {
+			case *File:
+				size := e.Size()
+				if size < 0 {
+					fs.Errorf(e, "Skipping file of unknown size in tar")
+					continue
+				}
+				in, err := e.Open(os.O_RDONLY)
+				if err != nil {
+					return fmt.Errorf("create tar open file: %w", err)
+				}
+				header := &tar.Header{
+					Typeflag: tar.TypeReg,
+					Name:     root + e.Name(),
+					Size:     size,
+					Mode:     0644,
+					ModTime:  e.ModTime(),
+					Format:   tar.FormatPAX,
+				}
+				err = tarWriter.WriteHeader(header)
+				if err != nil {
+					fs.CheckClose(in, &err)
+					return fmt.Errorf("create tar file header: %w", err)
+				}
+				_, err = io.CopyN(tarWriter, in, size)
+				if err != nil {
+					fs.CheckClose(in, &err)
+					return fmt.Errorf("create tar copy: %w", err)
+				}
+				fs.CheckClose(in, &err)
+				if err != nil {
+					return fmt.Errorf("create tar close file: %w", err)
+				}
+			case *Dir:
+				name := root + e.Name() + "/"
+				header := &tar.Header{
+					Typeflag: tar.TypeDir,
+					Name:     name,
+					Mode:     0755,
+					ModTime:  e.ModTime(),
+					Format:   tar.FormatPAX,
+				}
+				err := tarWriter.WriteHeader(header)
+				if err != nil {
+					return fmt.Errorf("create tar directory header: %w", err)
+				}
+				err = walk(e, name)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walk(dir, "")
+}
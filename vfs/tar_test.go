@@ -0,0 +1,50 @@
+package vfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTar(t *testing.T) {
+	r, vfs := newTestVFS(t)
+
+	r.WriteObject(context.Background(), "a/top.txt", "top", t1)
+	r.WriteObject(context.Background(), "a/b/mid.txt", "mid", t1)
+	r.WriteObject(context.Background(), "a/b/c/deep.txt", "deep", t1)
+
+	node, err := vfs.Stat("a")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, CreateTar(context.Background(), node.(*Dir), &buf))
+
+	files := map[string]string{}
+	var dirs []string
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if hdr.Typeflag == tar.TypeDir {
+			dirs = append(dirs, hdr.Name)
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(data)
+	}
+	assert.ElementsMatch(t, []string{"b/", "b/c/"}, dirs)
+	assert.Equal(t, map[string]string{
+		"top.txt":      "top",
+		"b/mid.txt":    "mid",
+		"b/c/deep.txt": "deep",
+	}, files)
+}
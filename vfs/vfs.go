@@ -24,6 +24,7 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"os"
 	"path"
@@ -40,7 +41,6 @@ import (
 	"github.com/rclone/rclone/fs/cache"
 	"github.com/rclone/rclone/fs/log"
 	"github.com/rclone/rclone/fs/rc"
-	"github.com/rclone/rclone/fs/walk"
 	"github.com/rclone/rclone/vfs/vfscache"
 	"github.com/rclone/rclone/vfs/vfscommon"
 )
@@ -185,7 +185,12 @@ type VFS struct {
 	usageTime   time.Time
 	usage       *fs.Usage
 	pollChan    chan time.Duration
-	inUse       atomic.Int32 // count of number of opens
+	inUse       atomic.Int32            // count of number of opens
+	limits      *limiter                // enforces BwLimit and Quota if set
+	prefetch    *prefetcher             // prefetches the next file if --vfs-prefetch-policy media
+	locks       *remoteLocks            // advisory locks if --vfs-lock-dir is set
+	health      vfscommon.BackendHealth // results of backend operations
+	cacheRules  []cacheRule             // cache modes for files set by --vfs-cache-rules
 }
 
 // Keep track of active VFS keyed on fs.ConfigString(f)
@@ -229,8 +234,18 @@ func New(f fs.Fs, opt *vfscommon.Options) *VFS {
 	// Put the VFS into the active cache
 	active[configName] = append(active[configName], vfs)
 
+	// Read the per file cache modes
+	var err error
+	vfs.cacheRules, err = parseCacheRules(vfs.Opt.CacheRules)
+	if err != nil {
+		fs.Errorf(f, "vfs: ignoring --vfs-cache-rules: %v", err)
+	}
+
 	// Create root directory
 	vfs.root = newDir(vfs, f, nil, fsDir)
+	vfs.limits = newLimiter(vfs)
+	vfs.prefetch = newPrefetcher(vfs)
+	vfs.locks = newRemoteLocks(ctx, vfs)
 
 	// Start polling function
 	features := vfs.f.Features()
@@ -327,6 +342,36 @@ func (vfs *VFS) Stats() (out rc.Params) {
 	return out
 }
 
+// Health returns how well the VFS is working with the backend
+//
+// This combines the results of the reads, writes and listings the VFS
+// did with the uploads from the cache, if there is one.
+func (vfs *VFS) Health() (stats vfscommon.HealthStats) {
+	if vfs.cache != nil {
+		stats = vfs.cache.Health()
+	}
+	stats.BackendHealthStats = stats.BackendHealthStats.Merge(vfs.health.Stats())
+	return stats
+}
+
+// Active returns the active VFSes keyed by the names which can be
+// passed to the rc commands in the "fs" parameter.
+func Active() map[string]*VFS {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	out := make(map[string]*VFS, len(active))
+	for name, vfses := range active {
+		if len(vfses) == 1 {
+			out[name] = vfses[0]
+		} else {
+			for i, vfs := range vfses {
+				out[fmt.Sprintf("%s[%d]", name, i)] = vfs
+			}
+		}
+	}
+	return out
+}
+
 // Return the number of active cache entries and a VFS if any are in
 // the cache.
 func activeCacheEntries() (vfs *VFS, count int) {
@@ -464,6 +509,37 @@ func newInode() (inode uint64) {
 	return inodeCount.Add(1)
 }
 
+// newInode returns the inode number for a new node for entry, which
+// may be nil, at remote.
+//
+// With --vfs-stable-inodes this is the same each time the VFS is
+// started, otherwise it is a new unique number.
+func (vfs *VFS) newInode(entry fs.DirEntry, remote string) uint64 {
+	if vfs.Opt.StableInodes {
+		return stableInode(entry, remote)
+	}
+	return newInode()
+}
+
+// stableInode returns an inode number for entry, which may be nil, at
+// remote derived from the backend's ID for it if it has one, so it
+// survives renames, or from remote if not.
+func stableInode(entry fs.DirEntry, remote string) uint64 {
+	key := "path:" + remote
+	if do, ok := entry.(fs.IDer); ok {
+		if id := do.ID(); id != "" {
+			key = "id:" + id
+		}
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	inode := h.Sum64() &^ (1 << 63) // the top bit is used for metadata files
+	if inode == 0 {
+		inode = 1
+	}
+	return inode
+}
+
 // Stat finds the Node by path starting from the root
 //
 // It is the equivalent of os.Stat - Node contains the os.FileInfo
@@ -660,13 +736,7 @@ func (vfs *VFS) Statfs() (total, used, free int64) {
 		}
 		if vfs.Opt.UsedIsSize {
 			var usedBySizeAlgorithm int64
-			// Algorithm from `rclone size`
-			err = walk.ListR(ctx, vfs.f, "", true, -1, walk.ListObjects, func(entries fs.DirEntries) error {
-				entries.ForObject(func(o fs.Object) {
-					usedBySizeAlgorithm += o.Size()
-				})
-				return nil
-			})
+			usedBySizeAlgorithm, err = usedSize(ctx, vfs.f)
 			vfs.usage.Used = &usedBySizeAlgorithm
 			// if we read a Total size then we should calculate Free from it
 			if vfs.usage.Total != nil {
@@ -696,6 +766,13 @@ func (vfs *VFS) Statfs() (total, used, free int64) {
 		total = int64(vfs.Opt.DiskSpaceTotalSize)
 	}
 
+	// Report the quota as the size of the disk
+	if quota := int64(vfs.Opt.Quota); quota > 0 && vfs.limits != nil {
+		total = quota
+		used = vfs.limits.quotaUsed()
+		free = max(total-used, 0)
+	}
+
 	total, used, free = fillInMissingSizes(total, used, free, unknownFreeBytes)
 	return
 }
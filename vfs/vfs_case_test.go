@@ -195,3 +195,41 @@ func TestUnicodeNormalization(t *testing.T) {
 	assertFileDataVFS(t, vfs, norm.NFD.String(both), "data1")
 	assertFileAbsentVFS(t, vfs, nfd)
 }
+
+func TestCaseInsensitiveRename(t *testing.T) {
+	r := fstest.NewRun(t)
+
+	if r.Fremote.Features().CaseInsensitive {
+		t.Skip("Can't test case sensitivity - this remote is officially not case-sensitive")
+	}
+	features := r.Fremote.Features()
+	if features.Move == nil && features.Copy == nil {
+		t.Skip("Can't test rename - this remote can't move or copy files")
+	}
+
+	ctx := context.Background()
+	file1 := r.WriteObject(ctx, "FiLeA", "data1", t1)
+	file2 := r.WriteObject(ctx, "other", "data2", t2)
+	r.CheckRemoteItems(t, file1, file2)
+
+	opt := vfscommon.Opt
+	opt.CaseInsensitive = true
+	vfs := New(r.Fremote, &opt)
+	defer cleanupVFS(t, vfs)
+
+	// Renaming onto a name differing only in case replaces the existing file
+	require.NoError(t, vfs.Rename("other", "FILEA"))
+	file2.Path = "FiLeA"
+	r.CheckRemoteItems(t, file2)
+	assertFileAbsentVFS(t, vfs, "other")
+	assertFileDataVFS(t, vfs, "filea", "data2")
+
+	// Renaming a file to a different case of its own name renames it
+	require.NoError(t, vfs.Rename("filea", "fileA"))
+	file2.Path = "fileA"
+	r.CheckRemoteItems(t, file2)
+	nodes, err := vfs.ReadDir("")
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "fileA", nodes[0].Name())
+}
@@ -311,6 +311,38 @@ func TestVFSRename(t *testing.T) {
 	assert.Equal(t, os.ErrNotExist, err)
 }
 
+func TestVFSStableInodes(t *testing.T) {
+	r := fstest.NewRun(t)
+	r.WriteObject(context.Background(), "dir/file1", "file1 contents", t1)
+	opt := vfscommon.Opt
+	opt.StableInodes = true
+
+	inodes := func(vfs *VFS) (inodes []uint64) {
+		for _, name := range []string{"", "dir", "dir/file1"} {
+			node, err := vfs.Stat(name)
+			require.NoError(t, err)
+			assert.Zero(t, node.Inode()&(1<<63))
+			inodes = append(inodes, node.Inode())
+		}
+		return inodes
+	}
+	vfs := New(r.Fremote, &opt)
+	want := inodes(vfs)
+	cleanupVFS(t, vfs)
+
+	// The inodes are the same when the VFS is started again
+	vfs = New(r.Fremote, &opt)
+	defer cleanupVFS(t, vfs)
+	assert.Equal(t, want, inodes(vfs))
+
+	// The ID is used in preference to the path if there is one
+	dir := fs.NewDir("dir", t1).SetID("id1")
+	renamed := fs.NewDir("renamed", t1).SetID("id1")
+	assert.Equal(t, stableInode(dir, "dir"), stableInode(renamed, "renamed"))
+	assert.NotEqual(t, stableInode(dir, "dir"), stableInode(nil, "dir"))
+	assert.Equal(t, want[1], stableInode(nil, "dir"))
+}
+
 func TestVFSStatfs(t *testing.T) {
 	r, vfs := newTestVFS(t)
 
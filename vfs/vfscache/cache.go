@@ -41,16 +41,17 @@ import (
 // Cache opened files
 type Cache struct {
 	// read only - no locking needed to read these
-	fremote    fs.Fs                // fs for the remote we are caching
-	fcache     fs.Fs                // fs for the cache directory
-	fcacheMeta fs.Fs                // fs for the cache metadata directory
-	opt        *vfscommon.Options   // vfs Options
-	root       string               // root of the cache directory
-	metaRoot   string               // root of the cache metadata directory
-	hashType   hash.Type            // hash to use locally and remotely
-	hashOption *fs.HashesOption     // corresponding OpenOption
-	writeback  *writeback.WriteBack // holds Items for writeback
-	avFn       AddVirtualFn         // if set, can be called to add dir entries
+	fremote    fs.Fs                   // fs for the remote we are caching
+	fcache     fs.Fs                   // fs for the cache directory
+	fcacheMeta fs.Fs                   // fs for the cache metadata directory
+	opt        *vfscommon.Options      // vfs Options
+	root       string                  // root of the cache directory
+	metaRoot   string                  // root of the cache metadata directory
+	hashType   hash.Type               // hash to use locally and remotely
+	hashOption *fs.HashesOption        // corresponding OpenOption
+	writeback  *writeback.WriteBack    // holds Items for writeback
+	avFn       AddVirtualFn            // if set, can be called to add dir entries
+	health     vfscommon.BackendHealth // results of uploads to the remote
 
 	mu            sync.Mutex       // protects the following variables
 	cond          sync.Cond        // cond lock for synchronous cache cleaning
@@ -167,9 +168,42 @@ func (c *Cache) Stats() (out rc.Params) {
 	out["bytesUsed"] = c.used
 	out["outOfSpace"] = c.outOfSpace
 
+	dirtyFiles, dirtyBytes := c._dirty()
+	out["dirtyFiles"] = dirtyFiles
+	out["dirtyBytes"] = dirtyBytes
+
 	return out
 }
 
+// _dirty returns the number of files and bytes in the cache which
+// haven't been uploaded yet
+//
+// call with c.mu held
+func (c *Cache) _dirty() (files int, bytes int64) {
+	for _, item := range c.item {
+		if size, dirty := item.dirtySize(); dirty {
+			files++
+			bytes += size
+		}
+	}
+	return files, bytes
+}
+
+// Health returns the results of the uploads to the remote and the
+// state of the cache
+func (c *Cache) Health() (stats vfscommon.HealthStats) {
+	stats.BackendHealthStats = c.health.Stats()
+	stats.UploadsInProgress, stats.UploadsQueued = c.writeback.Stats()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats.ErroredFiles = len(c.errItems)
+	stats.OutOfSpace = c.outOfSpace
+	stats.DirtyFiles, stats.DirtyBytes = c._dirty()
+	return stats
+}
+
 // Queue returns info about the Cache
 func (c *Cache) Queue() (out rc.Params) {
 	out = make(rc.Params)
@@ -185,6 +219,12 @@ func (c *Cache) QueueSetExpiry(id writeback.Handle, expiry time.Time, relative t
 	return c.writeback.SetExpiry(id, expiry, relative)
 }
 
+// WriteBack returns the writeback queue so its upload order and
+// bandwidth limit can be adjusted
+func (c *Cache) WriteBack() *writeback.WriteBack {
+	return c.writeback
+}
+
 // createDir creates a directory path, along with any necessary parents
 func createDir(dir string) error {
 	return file.MkdirAll(dir, 0700)
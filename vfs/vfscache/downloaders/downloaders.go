@@ -31,6 +31,9 @@ const (
 	// If a downloader is within this range or --buffer-size
 	// whichever is the larger, we will reuse the downloader
 	minWindow = 1024 * 1024
+	// number of sequential reads before --vfs-prefetch-policy
+	// starts growing the read ahead
+	minSequentialReads = 4
 )
 
 // Item is the interface that an item to download must obey
@@ -72,6 +75,8 @@ type Downloaders struct {
 	waiters    []waiter
 	errorCount int   // number of consecutive errors
 	lastErr    error // last error received
+	lastEnd    int64 // end of the last range read
+	seqReads   int   // number of sequential reads in a row
 }
 
 // waiter is a range we are waiting for and a channel to signal when
@@ -259,6 +264,7 @@ func (dls *Downloaders) Download(r ranges.Range) (err error) {
 		errChan: errChan,
 	}
 
+	dls._recordRead(r)
 	err = dls._ensureDownloader(r)
 	if err != nil {
 		dls.mu.Unlock()
@@ -280,6 +286,47 @@ func (dls *Downloaders) _closeWaiters(err error) {
 	dls.waiters = nil
 }
 
+// _recordRead notes the range being read so the read ahead can grow
+// while the reads are sequential.
+//
+// call with lock held
+func (dls *Downloaders) _recordRead(r ranges.Range) {
+	if dls.opt.PrefetchPolicy == vfscommon.PrefetchOff {
+		return
+	}
+	// Allow small gaps and overlaps as readers don't always
+	// read exactly where the last read finished
+	if r.Pos >= dls.lastEnd-minWindow && r.Pos <= dls.lastEnd+minWindow {
+		dls.seqReads++
+	} else {
+		dls.seqReads = 0
+	}
+	dls.lastEnd = r.End()
+}
+
+// _readAhead returns the number of bytes to read ahead of the reader.
+//
+// This is --vfs-read-ahead unless --vfs-prefetch-policy is set and the
+// reads are sequential in which case it doubles with each sequential
+// read up to --vfs-prefetch-max.
+//
+// call with lock held
+func (dls *Downloaders) _readAhead() int64 {
+	readAhead := int64(dls.opt.ReadAhead)
+	if dls.opt.PrefetchPolicy == vfscommon.PrefetchOff || dls.seqReads < minSequentialReads {
+		return readAhead
+	}
+	maxReadAhead := int64(dls.opt.PrefetchMax)
+	if maxReadAhead <= readAhead {
+		return readAhead
+	}
+	readAhead = max(readAhead, minWindow)
+	for i := minSequentialReads; i < dls.seqReads && readAhead < maxReadAhead; i++ {
+		readAhead *= 2
+	}
+	return min(readAhead, maxReadAhead)
+}
+
 // ensure a downloader is running for the range if required.  If one isn't found
 // then it starts it.
 //
@@ -291,8 +338,8 @@ func (dls *Downloaders) _ensureDownloader(r ranges.Range) (err error) {
 	window := int64(fs.GetConfig(context.TODO()).BufferSize)
 
 	// Increase the read range by the read ahead if set
-	if dls.opt.ReadAhead > 0 {
-		r.Size += int64(dls.opt.ReadAhead)
+	if readAhead := dls._readAhead(); readAhead > 0 {
+		r.Size += readAhead
 	}
 
 	// We may be reopening a downloader after a failure here or
@@ -379,6 +426,7 @@ func (dls *Downloaders) _ensureDownloader(r ranges.Range) (err error) {
 func (dls *Downloaders) EnsureDownloader(r ranges.Range) (err error) {
 	dls.mu.Lock()
 	defer dls.mu.Unlock()
+	dls._recordRead(r)
 	return dls._ensureDownloader(r)
 }
 
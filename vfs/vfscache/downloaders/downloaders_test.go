@@ -128,3 +128,38 @@ func TestDownloaders(t *testing.T) {
 		assert.True(t, item.HasRange(r))
 	})
 }
+
+func TestReadAhead(t *testing.T) {
+	const MiB = 1024 * 1024
+	opt := vfscommon.Opt
+	opt.ReadAhead = 0
+	opt.PrefetchMax = 8 * MiB
+	dls := &Downloaders{opt: &opt}
+
+	read := func(pos, size int64) int64 {
+		dls._recordRead(ranges.Range{Pos: pos, Size: size})
+		return dls._readAhead()
+	}
+
+	// No growth with the policy off
+	opt.PrefetchPolicy = vfscommon.PrefetchOff
+	for i := range int64(10) {
+		assert.Equal(t, int64(0), read(i*MiB, MiB))
+	}
+
+	// Grows while sequential up to the max - reading from the
+	// start of the file counts as sequential
+	opt.PrefetchPolicy = vfscommon.PrefetchSequential
+	var got []int64
+	for i := range int64(10) {
+		got = append(got, read(i*MiB, MiB))
+	}
+	assert.Equal(t, []int64{0, 0, 0, 1 * MiB, 2 * MiB, 4 * MiB, 8 * MiB, 8 * MiB, 8 * MiB, 8 * MiB}, got)
+
+	// A seek resets it
+	assert.Equal(t, int64(0), read(40*MiB, MiB))
+
+	// Never less than --vfs-read-ahead
+	opt.ReadAhead = 16 * MiB
+	assert.Equal(t, int64(16*MiB), read(41*MiB, MiB))
+}
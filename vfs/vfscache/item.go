@@ -460,6 +460,13 @@ func (item *Item) IsDirty() bool {
 	return item.info.Dirty
 }
 
+// dirtySize returns the size of the item and whether it is dirty
+func (item *Item) dirtySize() (size int64, dirty bool) {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	return item.info.Size, item.info.Dirty
+}
+
 // Create the cache file and store the metadata on disk
 // Called with item.mu locked
 func (item *Item) _createFile(osPath string) (err error) {
@@ -598,9 +605,11 @@ func (item *Item) _store(ctx context.Context, storeFn StoreFn) (err error) {
 	// Object has disappeared if cacheObj == nil
 	if cacheObj != nil {
 		o, name := item.o, item.name
+		src := item.c.writeback.LimitObject(cacheObj)
 		unlockMutexForCall(&item.mu, func() {
-			o, err = operations.Copy(ctx, item.c.fremote, o, name, cacheObj)
+			o, err = operations.Copy(ctx, item.c.fremote, o, name, src)
 		})
+		item.c.health.Record(err)
 		if err != nil {
 			if errors.Is(err, fs.ErrorCantUploadEmptyFiles) {
 				fs.Errorf(name, "Writeback failed: %v", err)
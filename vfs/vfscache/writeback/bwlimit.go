@@ -0,0 +1,94 @@
+package writeback
+
+import (
+	"context"
+	"io"
+
+	"github.com/rclone/rclone/fs"
+	"golang.org/x/time/rate"
+)
+
+// SetBwLimit sets the bandwidth limit for uploads in bytes/s, 0 for
+// off.
+//
+// This applies to uploads in progress if a limit was set when they
+// started and to all uploads started afterwards.
+func (wb *WriteBack) SetBwLimit(bwLimit fs.SizeSuffix) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	wb.bwLimit = bwLimit
+	if bwLimit <= 0 {
+		wb.bw.SetLimit(rate.Inf)
+		return
+	}
+	wb.bw.SetLimit(rate.Limit(bwLimit))
+	wb.bw.SetBurst(int(bwLimit))
+}
+
+// BwLimit returns the bandwidth limit for uploads in bytes/s, 0 for
+// off.
+func (wb *WriteBack) BwLimit() fs.SizeSuffix {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	return wb.bwLimit
+}
+
+// LimitObject returns o with the data read from it limited to the
+// upload bandwidth, or o itself if there is no limit.
+func (wb *WriteBack) LimitObject(o fs.Object) fs.Object {
+	if wb.BwLimit() <= 0 {
+		return o
+	}
+	return &limitedObject{Object: o, bw: wb.bw}
+}
+
+// limitedObject is an fs.Object whose reads are limited to the
+// upload bandwidth
+type limitedObject struct {
+	fs.Object
+	bw *rate.Limiter
+}
+
+// Open opens the file for read with the reads limited to the upload
+// bandwidth
+func (o *limitedObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	in, err := o.Object.Open(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &limitedReader{ReadCloser: in, ctx: ctx, bw: o.bw}, nil
+}
+
+// UnWrap returns the Object that this Object is wrapping
+func (o *limitedObject) UnWrap() fs.Object {
+	return o.Object
+}
+
+// limitedReader limits reads to the upload bandwidth
+type limitedReader struct {
+	io.ReadCloser
+	ctx context.Context
+	bw  *rate.Limiter
+}
+
+// Read bytes waiting for the bandwidth to read them
+func (r *limitedReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	if r.bw.Limit() == rate.Inf {
+		return n, err
+	}
+	for left := n; left > 0; {
+		chunk := min(left, max(r.bw.Burst(), 1))
+		if waitErr := r.bw.WaitN(r.ctx, chunk); waitErr != nil {
+			return n, waitErr
+		}
+		left -= chunk
+	}
+	return n, err
+}
+
+// Check interfaces
+var (
+	_ fs.Object          = (*limitedObject)(nil)
+	_ fs.ObjectUnWrapper = (*limitedObject)(nil)
+)
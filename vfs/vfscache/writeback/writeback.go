@@ -6,13 +6,18 @@ import (
 	"container/heap"
 	"context"
 	"errors"
+	"fmt"
+	"regexp"
+	"slices"
 	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/vfs/vfscommon"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -38,6 +43,12 @@ type WriteBack struct {
 	timer   *time.Timer               // next scheduled time for the uploader
 	expiry  time.Time                 // time the next item expires or IsZero
 	uploads int                       // number of uploads in progress
+
+	order      vfscommon.WriteBackOrder // order to upload items which are ready in
+	priority   string                   // comma separated globs of items to upload first
+	priorityRe []*regexp.Regexp         // priority compiled
+	bwLimit    fs.SizeSuffix            // upload bandwidth limit or 0 for off
+	bw         *rate.Limiter            // enforces bwLimit
 }
 
 // New make a new WriteBack
@@ -49,11 +60,104 @@ func New(ctx context.Context, opt *vfscommon.Options) *WriteBack {
 		items:  writeBackItems{},
 		lookup: make(map[Handle]*writeBackItem),
 		opt:    opt,
+		bw:     rate.NewLimiter(rate.Inf, 0),
 	}
 	heap.Init(&wb.items)
+	err := wb.SetOrder(opt.WriteBackOrder, opt.WriteBackPriority)
+	if err != nil {
+		fs.Errorf(nil, "vfs cache: ignoring --vfs-write-back-priority: %v", err)
+		_ = wb.SetOrder(opt.WriteBackOrder, "")
+	}
+	wb.SetBwLimit(opt.WriteBackBwLimit)
 	return wb
 }
 
+// SetOrder sets the order to upload items which are ready in.
+//
+// priority is a comma separated list of globs. Items matching earlier
+// globs are uploaded first, then items are uploaded in order.
+func (wb *WriteBack) SetOrder(order vfscommon.WriteBackOrder, priority string) error {
+	var globs fs.CommaSepList
+	if priority != "" {
+		if err := globs.Set(priority); err != nil {
+			return fmt.Errorf("bad write back priority %q: %w", priority, err)
+		}
+	}
+	priorityRe := make([]*regexp.Regexp, 0, len(globs))
+	for _, glob := range globs {
+		re, err := filter.GlobPathToRegexp(glob, false)
+		if err != nil {
+			return fmt.Errorf("bad write back priority %q: %w", glob, err)
+		}
+		priorityRe = append(priorityRe, re)
+	}
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	wb.order = order
+	wb.priority = priority
+	wb.priorityRe = priorityRe
+	return nil
+}
+
+// Order returns the order and priority globs set with SetOrder
+func (wb *WriteBack) Order() (order vfscommon.WriteBackOrder, priority string) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	return wb.order, wb.priority
+}
+
+// _priority returns the index of the first priority glob name
+// matches or the number of globs if it doesn't match any.
+//
+// call with lock held
+func (wb *WriteBack) _priority(name string) int {
+	for i, re := range wb.priorityRe {
+		if re.MatchString(name) {
+			return i
+		}
+	}
+	return len(wb.priorityRe)
+}
+
+// _sortReady sorts the items which are ready for upload into the
+// order they should be uploaded in.
+//
+// They are passed in expiry order.
+//
+// call with lock held
+func (wb *WriteBack) _sortReady(ready []*writeBackItem) {
+	if len(ready) < 2 || (wb.order == vfscommon.WriteBackOrderExpiry && len(wb.priorityRe) == 0) {
+		return
+	}
+	priority := make(map[*writeBackItem]int, len(ready))
+	for _, wbItem := range ready {
+		priority[wbItem] = wb._priority(wbItem.name)
+	}
+	slices.SortStableFunc(ready, func(a, b *writeBackItem) int {
+		if c := priority[a] - priority[b]; c != 0 {
+			return c
+		}
+		switch wb.order {
+		case vfscommon.WriteBackOrderSmallest:
+			if a.size != b.size {
+				if a.size < b.size {
+					return -1
+				}
+				return 1
+			}
+		case vfscommon.WriteBackOrderOldest:
+			// IDs are allocated in the order items are queued
+			if a.id != b.id {
+				if a.id < b.id {
+					return -1
+				}
+				return 1
+			}
+		}
+		return 0
+	})
+}
+
 // writeBackItem stores an Item awaiting writeback
 //
 // These are stored on the items heap when awaiting transfer but
@@ -432,16 +536,27 @@ func (wb *WriteBack) processItems(ctx context.Context) {
 		return
 	}
 
-	resetTimer := true
+	// Pop the items which are ready for upload and put them in
+	// upload order
+	var ready []*writeBackItem
 	for wbItem := wb._peekItem(); wbItem != nil && time.Until(wbItem.expiry) <= 0; wbItem = wb._peekItem() {
+		ready = append(ready, wb._popItem())
+	}
+	wb._sortReady(ready)
+
+	resetTimer := true
+	for i, wbItem := range ready {
 		// If reached transfer limit don't restart the timer
 		if wb.uploads >= fs.GetConfig(context.TODO()).Transfers {
 			fs.Debugf(wbItem.name, "vfs cache: delaying writeback as --transfers exceeded")
 			resetTimer = false
+			// Put the items we aren't uploading back on the heap
+			for _, wbItem := range ready[i:] {
+				wb._pushItem(wbItem)
+			}
 			break
 		}
-		// Pop the item, mark as uploading and start the uploader
-		wbItem = wb._popItem()
+		// Mark the item as uploading and start the uploader
 		//fs.Debugf(wbItem.name, "uploading = true %p item %p", wbItem, wbItem.item)
 		wbItem.uploading = true
 		wb.uploads++
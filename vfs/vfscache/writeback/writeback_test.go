@@ -1,10 +1,12 @@
 package writeback
 
 import (
+	"bytes"
 	"container/heap"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 	"testing"
@@ -13,6 +15,7 @@ import (
 	"slices"
 
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/object"
 	"github.com/rclone/rclone/vfs/vfscommon"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -760,3 +763,91 @@ func TestWriteBackCancelUpload(t *testing.T) {
 	checkInLookup(t, wb, wbItem)
 	assert.True(t, pi.cancelled)
 }
+
+func TestWriteBackOrder(t *testing.T) {
+	wb, cancel := newTestWriteBack(t)
+	defer cancel()
+
+	wb.mu.Lock()
+	var ready []*writeBackItem
+	for _, x := range []struct {
+		name string
+		size int64
+	}{
+		{"dir/big", 300},
+		{"important/one", 200},
+		{"small", 10},
+		{"file.doc", 100},
+		{"important/two", 20},
+	} {
+		// Make the newest items expire first
+		wbItem := wb._newItem(0, x.name, x.size)
+		wb._removeItem(wbItem)
+		ready = append([]*writeBackItem{wbItem}, ready...)
+	}
+	wb.mu.Unlock()
+
+	names := func() string {
+		var out []string
+		for _, wbItem := range ready {
+			out = append(out, wbItem.name)
+		}
+		return strings.Join(out, ",")
+	}
+	for _, test := range []struct {
+		order    vfscommon.WriteBackOrder
+		priority string
+		want     string
+	}{
+		{vfscommon.WriteBackOrderExpiry, "", "important/two,file.doc,small,important/one,dir/big"},
+		{vfscommon.WriteBackOrderSmallest, "", "small,important/two,file.doc,important/one,dir/big"},
+		{vfscommon.WriteBackOrderOldest, "", "dir/big,important/one,small,file.doc,important/two"},
+		{vfscommon.WriteBackOrderSmallest, "important/**", "important/two,important/one,small,file.doc,dir/big"},
+		{vfscommon.WriteBackOrderOldest, "*.doc,important/**", "file.doc,important/one,important/two,dir/big,small"},
+	} {
+		require.NoError(t, wb.SetOrder(test.order, test.priority))
+		order, priority := wb.Order()
+		assert.Equal(t, test.order, order)
+		assert.Equal(t, test.priority, priority)
+		wb.mu.Lock()
+		wb._sortReady(ready)
+		wb.mu.Unlock()
+		assert.Equal(t, test.want, names(), test)
+		// Back to expiry order for the next test
+		slices.SortFunc(ready, func(a, b *writeBackItem) int { return int(b.id) - int(a.id) })
+	}
+
+	assert.Error(t, wb.SetOrder(vfscommon.WriteBackOrderExpiry, "[a"))
+}
+
+func TestWriteBackBwLimit(t *testing.T) {
+	wb, cancel := newTestWriteBack(t)
+	defer cancel()
+
+	ctx := context.Background()
+	content := bytes.Repeat([]byte{'x'}, 1500)
+	o := object.NewMemoryObject("file", time.Now(), content)
+
+	// No limit so object returned as is
+	assert.Equal(t, fs.SizeSuffix(0), wb.BwLimit())
+	assert.Equal(t, fs.Object(o), wb.LimitObject(o))
+
+	// 1000 bytes/s so should take about 0.5s to read
+	wb.SetBwLimit(1000)
+	assert.Equal(t, fs.SizeSuffix(1000), wb.BwLimit())
+	lo := wb.LimitObject(o)
+	require.NotEqual(t, fs.Object(o), lo)
+	assert.Equal(t, fs.Object(o), lo.(fs.ObjectUnWrapper).UnWrap())
+	start := time.Now()
+	in, err := lo.Open(ctx)
+	require.NoError(t, err)
+	got, err := io.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, content, got)
+	assert.Greater(t, time.Since(start), 400*time.Millisecond)
+
+	// Turning it off lifts the limit
+	wb.SetBwLimit(0)
+	assert.Equal(t, fs.Object(o), wb.LimitObject(o))
+}
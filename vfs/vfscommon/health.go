@@ -0,0 +1,95 @@
+package vfscommon
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// BackendHealth records the results of the operations the VFS does
+// on the backend so it can be seen whether the backend is working.
+//
+// The zero value is ready to use.
+type BackendHealth struct {
+	mu            sync.Mutex
+	lastSuccess   time.Time
+	lastError     error
+	lastErrorTime time.Time
+	errors        int64
+}
+
+// BackendHealthStats is a snapshot of a BackendHealth
+type BackendHealthStats struct {
+	LastSuccess   time.Time `json:"lastSuccess"`   // when a backend operation last succeeded
+	LastError     string    `json:"lastError"`     // the last error from the backend, if any
+	LastErrorTime time.Time `json:"lastErrorTime"` // when the last error happened
+	Errors        int64     `json:"errors"`        // number of failed backend operations
+}
+
+// Record the result of a backend operation.
+//
+// Errors which don't show a problem with the backend, like a file
+// not being found or the operation being cancelled, count as
+// successes.
+func (h *BackendHealth) Record(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil || errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorDirNotFound) {
+		h.lastSuccess = time.Now()
+		return
+	}
+	h.lastError = err
+	h.lastErrorTime = time.Now()
+	h.errors++
+}
+
+// Stats returns a snapshot of the health
+func (h *BackendHealth) Stats() (stats BackendHealthStats) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats = BackendHealthStats{
+		LastSuccess:   h.lastSuccess,
+		LastErrorTime: h.lastErrorTime,
+		Errors:        h.errors,
+	}
+	if h.lastError != nil {
+		stats.LastError = h.lastError.Error()
+	}
+	return stats
+}
+
+// Merge combines the stats from two sources returning the most
+// recent success and error and the total number of errors.
+func (stats BackendHealthStats) Merge(other BackendHealthStats) BackendHealthStats {
+	if other.LastSuccess.After(stats.LastSuccess) {
+		stats.LastSuccess = other.LastSuccess
+	}
+	if other.LastErrorTime.After(stats.LastErrorTime) {
+		stats.LastErrorTime = other.LastErrorTime
+		stats.LastError = other.LastError
+	}
+	stats.Errors += other.Errors
+	return stats
+}
+
+// HealthStats describes the health of a VFS
+type HealthStats struct {
+	BackendHealthStats
+	DirtyFiles        int   `json:"dirtyFiles"`        // files in the cache not yet uploaded
+	DirtyBytes        int64 `json:"dirtyBytes"`        // bytes in the cache not yet uploaded
+	UploadsInProgress int   `json:"uploadsInProgress"` // uploads running now
+	UploadsQueued     int   `json:"uploadsQueued"`     // uploads waiting to run
+	ErroredFiles      int   `json:"erroredFiles"`      // files which failed to upload
+	OutOfSpace        bool  `json:"outOfSpace"`        // set if the cache disk is full
+}
+
+// Failing returns true if the last backend operation failed
+func (stats BackendHealthStats) Failing() bool {
+	return stats.LastErrorTime.After(stats.LastSuccess)
+}
@@ -0,0 +1,68 @@
+package vfscommon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendHealthRecord(t *testing.T) {
+	var h BackendHealth
+	stats := h.Stats()
+	assert.True(t, stats.LastSuccess.IsZero())
+	assert.False(t, stats.Failing())
+
+	h.Record(nil)
+	stats = h.Stats()
+	assert.False(t, stats.LastSuccess.IsZero())
+	assert.False(t, stats.Failing())
+
+	// These aren't backend failures
+	h.Record(fs.ErrorObjectNotFound)
+	h.Record(fmt.Errorf("wrapped: %w", fs.ErrorDirNotFound))
+	h.Record(context.Canceled)
+	assert.Equal(t, int64(0), h.Stats().Errors)
+
+	h.Record(errors.New("potato"))
+	stats = h.Stats()
+	assert.Equal(t, int64(1), stats.Errors)
+	assert.Equal(t, "potato", stats.LastError)
+	assert.True(t, stats.Failing())
+
+	h.Record(nil)
+	stats = h.Stats()
+	assert.Equal(t, int64(1), stats.Errors)
+	assert.Equal(t, "potato", stats.LastError)
+	assert.False(t, stats.Failing())
+}
+
+func TestBackendHealthStatsMerge(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := BackendHealthStats{
+		LastSuccess:   t0.Add(2 * time.Minute),
+		LastError:     "a",
+		LastErrorTime: t0,
+		Errors:        1,
+	}
+	b := BackendHealthStats{
+		LastSuccess:   t0,
+		LastError:     "b",
+		LastErrorTime: t0.Add(3 * time.Minute),
+		Errors:        2,
+	}
+	want := BackendHealthStats{
+		LastSuccess:   t0.Add(2 * time.Minute),
+		LastError:     "b",
+		LastErrorTime: t0.Add(3 * time.Minute),
+		Errors:        3,
+	}
+	assert.Equal(t, want, a.Merge(b))
+	assert.Equal(t, want, b.Merge(a))
+	assert.True(t, want.Failing())
+	assert.Equal(t, a, a.Merge(BackendHealthStats{}))
+}
@@ -55,6 +55,11 @@ var OptionsInfo = fs.Options{{
 	Default: CacheModeOff,
 	Help:    "Cache mode off|minimal|writes|full",
 	Groups:  "VFS",
+}, {
+	Name:    "vfs_cache_rules",
+	Default: "",
+	Help:    "Comma separated list of glob=mode - files matching the first glob use that cache mode",
+	Groups:  "VFS",
 }, {
 	Name:    "vfs_cache_poll_interval",
 	Default: fs.Duration(60 * time.Second),
@@ -130,11 +135,46 @@ var OptionsInfo = fs.Options{{
 	Default: fs.Duration(5 * time.Second),
 	Help:    "Time to writeback files after last use when using cache",
 	Groups:  "VFS",
+}, {
+	Name:    "vfs_write_back_order",
+	Default: WriteBackOrderExpiry,
+	Help:    "Order to upload files ready for writeback in expiry|smallest|oldest",
+	Groups:  "VFS",
+}, {
+	Name:    "vfs_write_back_priority",
+	Default: "",
+	Help:    "Comma separated list of globs - files matching earlier globs are written back first",
+	Groups:  "VFS",
+}, {
+	Name:    "vfs_write_back_bwlimit",
+	Default: fs.SizeSuffix(0),
+	Help:    "Bandwidth limit in bytes/s for writing back files, 0 for off",
+	Groups:  "VFS",
+}, {
+	Name:    "vfs_lock_dir",
+	Default: "",
+	Help:    "Remote path for lock objects to warn about files being edited by other rclone mounts",
+	Groups:  "VFS",
+}, {
+	Name:    "vfs_lock_ttl",
+	Default: fs.Duration(5 * time.Minute),
+	Help:    "Time a lock in --vfs-lock-dir lasts unless refreshed",
+	Groups:  "VFS",
 }, {
 	Name:    "vfs_read_ahead",
 	Default: 0 * fs.Mebi,
 	Help:    "Extra read ahead over --buffer-size when using cache-mode full",
 	Groups:  "VFS",
+}, {
+	Name:    "vfs_prefetch_policy",
+	Default: PrefetchOff,
+	Help:    "Read ahead policy off|sequential|media when using cache-mode full",
+	Groups:  "VFS",
+}, {
+	Name:    "vfs_prefetch_max",
+	Default: 128 * fs.Mebi,
+	Help:    "Max read ahead when --vfs-prefetch-policy detects sequential reads",
+	Groups:  "VFS",
 }, {
 	Name:    "vfs_used_is_size",
 	Default: false,
@@ -145,6 +185,11 @@ var OptionsInfo = fs.Options{{
 	Default: false,
 	Help:    "Use fast (less accurate) fingerprints for change detection",
 	Groups:  "VFS",
+}, {
+	Name:    "vfs_stable_inodes",
+	Default: false,
+	Help:    "Derive inode numbers from object IDs or paths so they are the same after a restart",
+	Groups:  "VFS",
 }, {
 	Name:    "vfs_disk_space_total_size",
 	Default: fs.SizeSuffix(-1),
@@ -178,38 +223,49 @@ func init() {
 
 // Options is options for creating the vfs
 type Options struct {
-	NoSeek             bool          `config:"no_seek"`        // don't allow seeking if set
-	NoChecksum         bool          `config:"no_checksum"`    // don't check checksums if set
-	ReadOnly           bool          `config:"read_only"`      // if set VFS is read only
-	Links              bool          `config:"vfs_links"`      // if set interpret link files
-	NoModTime          bool          `config:"no_modtime"`     // don't read mod times for files
-	DirCacheTime       fs.Duration   `config:"dir_cache_time"` // how long to consider directory listing cache valid
-	Refresh            bool          `config:"vfs_refresh"`    // refreshes the directory listing recursively on start
-	PollInterval       fs.Duration   `config:"poll_interval"`
-	Umask              FileMode      `config:"umask"`
-	UID                uint32        `config:"uid"`
-	GID                uint32        `config:"gid"`
-	DirPerms           FileMode      `config:"dir_perms"`
-	FilePerms          FileMode      `config:"file_perms"`
-	LinkPerms          FileMode      `config:"link_perms"`
-	ChunkSize          fs.SizeSuffix `config:"vfs_read_chunk_size"`       // if > 0 read files in chunks
-	ChunkSizeLimit     fs.SizeSuffix `config:"vfs_read_chunk_size_limit"` // if > ChunkSize double the chunk size after each chunk until reached
-	ChunkStreams       int           `config:"vfs_read_chunk_streams"`    // Number of download streams to use
-	CacheMode          CacheMode     `config:"vfs_cache_mode"`
-	CacheMaxAge        fs.Duration   `config:"vfs_cache_max_age"`
-	CacheMaxSize       fs.SizeSuffix `config:"vfs_cache_max_size"`
-	CacheMinFreeSpace  fs.SizeSuffix `config:"vfs_cache_min_free_space"`
-	CachePollInterval  fs.Duration   `config:"vfs_cache_poll_interval"`
-	CaseInsensitive    bool          `config:"vfs_case_insensitive"`
-	BlockNormDupes     bool          `config:"vfs_block_norm_dupes"`
-	WriteWait          fs.Duration   `config:"vfs_write_wait"`       // time to wait for in-sequence write
-	ReadWait           fs.Duration   `config:"vfs_read_wait"`        // time to wait for in-sequence read
-	WriteBack          fs.Duration   `config:"vfs_write_back"`       // time to wait before writing back dirty files
-	ReadAhead          fs.SizeSuffix `config:"vfs_read_ahead"`       // bytes to read ahead in cache mode "full"
-	UsedIsSize         bool          `config:"vfs_used_is_size"`     // if true, use the `rclone size` algorithm for Used size
-	FastFingerprint    bool          `config:"vfs_fast_fingerprint"` // if set use fast fingerprints
-	DiskSpaceTotalSize fs.SizeSuffix `config:"vfs_disk_space_total_size"`
-	MetadataExtension  string        `config:"vfs_metadata_extension"` // if set respond to files with this extension with metadata
+	NoSeek             bool           `config:"no_seek"`        // don't allow seeking if set
+	NoChecksum         bool           `config:"no_checksum"`    // don't check checksums if set
+	ReadOnly           bool           `config:"read_only"`      // if set VFS is read only
+	Links              bool           `config:"vfs_links"`      // if set interpret link files
+	NoModTime          bool           `config:"no_modtime"`     // don't read mod times for files
+	DirCacheTime       fs.Duration    `config:"dir_cache_time"` // how long to consider directory listing cache valid
+	Refresh            bool           `config:"vfs_refresh"`    // refreshes the directory listing recursively on start
+	PollInterval       fs.Duration    `config:"poll_interval"`
+	Umask              FileMode       `config:"umask"`
+	UID                uint32         `config:"uid"`
+	GID                uint32         `config:"gid"`
+	DirPerms           FileMode       `config:"dir_perms"`
+	FilePerms          FileMode       `config:"file_perms"`
+	LinkPerms          FileMode       `config:"link_perms"`
+	ChunkSize          fs.SizeSuffix  `config:"vfs_read_chunk_size"`       // if > 0 read files in chunks
+	ChunkSizeLimit     fs.SizeSuffix  `config:"vfs_read_chunk_size_limit"` // if > ChunkSize double the chunk size after each chunk until reached
+	ChunkStreams       int            `config:"vfs_read_chunk_streams"`    // Number of download streams to use
+	CacheMode          CacheMode      `config:"vfs_cache_mode"`
+	CacheRules         string         `config:"vfs_cache_rules"` // globs of files to use a different cache mode for
+	CacheMaxAge        fs.Duration    `config:"vfs_cache_max_age"`
+	CacheMaxSize       fs.SizeSuffix  `config:"vfs_cache_max_size"`
+	CacheMinFreeSpace  fs.SizeSuffix  `config:"vfs_cache_min_free_space"`
+	CachePollInterval  fs.Duration    `config:"vfs_cache_poll_interval"`
+	CaseInsensitive    bool           `config:"vfs_case_insensitive"`
+	BlockNormDupes     bool           `config:"vfs_block_norm_dupes"`
+	WriteWait          fs.Duration    `config:"vfs_write_wait"`          // time to wait for in-sequence write
+	ReadWait           fs.Duration    `config:"vfs_read_wait"`           // time to wait for in-sequence read
+	WriteBack          fs.Duration    `config:"vfs_write_back"`          // time to wait before writing back dirty files
+	WriteBackOrder     WriteBackOrder `config:"vfs_write_back_order"`    // order to write back files which are ready
+	WriteBackPriority  string         `config:"vfs_write_back_priority"` // globs of files to write back first
+	WriteBackBwLimit   fs.SizeSuffix  `config:"vfs_write_back_bwlimit"`  // if > 0 limit write back to this many bytes/s
+	LockDir            string         `config:"vfs_lock_dir"`            // if set take advisory locks on files open for write here
+	LockTTL            fs.Duration    `config:"vfs_lock_ttl"`            // how long the advisory locks last without refreshing
+	ReadAhead          fs.SizeSuffix  `config:"vfs_read_ahead"`          // bytes to read ahead in cache mode "full"
+	PrefetchPolicy     PrefetchPolicy `config:"vfs_prefetch_policy"`     // how to grow the read ahead in cache mode "full"
+	PrefetchMax        fs.SizeSuffix  `config:"vfs_prefetch_max"`        // max read ahead when reads are sequential
+	UsedIsSize         bool           `config:"vfs_used_is_size"`        // if true, use the `rclone size` algorithm for Used size
+	FastFingerprint    bool           `config:"vfs_fast_fingerprint"`    // if set use fast fingerprints
+	StableInodes       bool           `config:"vfs_stable_inodes"`       // if set derive inode numbers from IDs or paths
+	DiskSpaceTotalSize fs.SizeSuffix  `config:"vfs_disk_space_total_size"`
+	MetadataExtension  string         `config:"vfs_metadata_extension"` // if set respond to files with this extension with metadata
+	BwLimit            fs.SizeSuffix  `config:"-"`                      // if > 0 limit data read and written through file handles to this many bytes/s
+	Quota              fs.SizeSuffix  `config:"-"`                      // if > 0 refuse writes which would make the VFS use more than this
 }
 
 // Opt is the default options modified by the environment variables and command line flags
@@ -0,0 +1,30 @@
+package vfscommon
+
+import (
+	"github.com/rclone/rclone/fs"
+)
+
+type prefetchPolicyChoices struct{}
+
+func (prefetchPolicyChoices) Choices() []string {
+	return []string{
+		PrefetchOff:        "off",
+		PrefetchSequential: "sequential",
+		PrefetchMedia:      "media",
+	}
+}
+
+// PrefetchPolicy controls how the cache reads ahead of the reader
+type PrefetchPolicy = fs.Enum[prefetchPolicyChoices]
+
+// PrefetchPolicy options
+const (
+	PrefetchOff        PrefetchPolicy = iota // only read ahead by --vfs-read-ahead
+	PrefetchSequential                       // grow the read ahead while reads are sequential
+	PrefetchMedia                            // as sequential and also prefetch the next file in the directory
+)
+
+// Type of the value
+func (prefetchPolicyChoices) Type() string {
+	return "PrefetchPolicy"
+}
@@ -0,0 +1,25 @@
+package vfscommon
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+// Check PrefetchPolicy it satisfies the pflag interface
+var _ pflag.Value = (*PrefetchPolicy)(nil)
+
+func TestPrefetchPolicySet(t *testing.T) {
+	var p PrefetchPolicy
+
+	err := p.Set("media")
+	assert.NoError(t, err)
+	assert.Equal(t, PrefetchMedia, p)
+	assert.Equal(t, "media", p.String())
+
+	err = p.Set("potato")
+	assert.Error(t, err)
+
+	assert.Equal(t, "PrefetchPolicy", p.Type())
+}
@@ -0,0 +1,31 @@
+package vfscommon
+
+import (
+	"github.com/rclone/rclone/fs"
+)
+
+type writeBackOrderChoices struct{}
+
+func (writeBackOrderChoices) Choices() []string {
+	return []string{
+		WriteBackOrderExpiry:   "expiry",
+		WriteBackOrderSmallest: "smallest",
+		WriteBackOrderOldest:   "oldest",
+	}
+}
+
+// WriteBackOrder controls the order files are uploaded in when more
+// are ready than can be uploaded at once
+type WriteBackOrder = fs.Enum[writeBackOrderChoices]
+
+// WriteBackOrder options
+const (
+	WriteBackOrderExpiry   WriteBackOrder = iota // least recently modified first
+	WriteBackOrderSmallest                       // smallest file first
+	WriteBackOrderOldest                         // first queued first
+)
+
+// Type of the value
+func (writeBackOrderChoices) Type() string {
+	return "WriteBackOrder"
+}
@@ -72,6 +72,7 @@ func (fh *WriteFileHandle) openPending() (err error) {
 	go func() {
 		// NB Rcat deals with Stats.Transferring, etc.
 		o, err := operations.Rcat(context.TODO(), fh.file.Fs(), fh.remote, pipeReader, time.Now(), nil)
+		fh.file.VFS().health.Record(err)
 		if err != nil {
 			fs.Errorf(fh.remote, "WriteFileHandle.New Rcat failed: %v", err)
 		}
@@ -44,10 +44,7 @@ func CreateZip(ctx context.Context, dir *Dir, w io.Writer) (err error) {
 				}
 				fs.CheckClose(in, &err)
 			case *Dir:
-				name := root + e.Path()
-				if name != "" && name[len(name)-1] != '/' {
-					name += "/"
-				}
+				name := root + e.Name() + "/"
 				header := &zip.FileHeader{
 					Name:     name,
 					Method:   zip.Store,
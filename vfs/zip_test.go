@@ -158,3 +158,20 @@ func TestZipDirsInRoot(t *testing.T) {
 	gz, _ := zipReadFile(t, zr, func(n string) bool { return strings.HasSuffix(n, "/c.txt") })
 	require.Equal(t, "z", string(gz))
 }
+
+func TestZipNames(t *testing.T) {
+	r, vfs := newTestVFS(t)
+
+	r.WriteObject(context.Background(), "a/top.txt", "top", t1)
+	r.WriteObject(context.Background(), "a/b/c/deep.txt", "deep", t1)
+
+	node, err := vfs.Stat("a")
+	require.NoError(t, err)
+
+	zr := readZip(t, mustCreateZip(t, node.(*Dir)))
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	require.ElementsMatch(t, []string{"top.txt", "b/", "b/c/", "b/c/deep.txt"}, names)
+}